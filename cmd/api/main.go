@@ -10,8 +10,10 @@ import (
 	"time"
 
 	"github.com/afterdarksys/adsops-utils/internal/api"
+	"github.com/afterdarksys/adsops-utils/internal/cache"
 	"github.com/afterdarksys/adsops-utils/internal/config"
 	"github.com/afterdarksys/adsops-utils/internal/pkg/logger"
+	"github.com/afterdarksys/adsops-utils/internal/store"
 	"go.uber.org/zap"
 )
 
@@ -29,8 +31,33 @@ func main() {
 	}
 	defer zapLogger.Sync()
 
+	// Connect to database
+	db, err := store.New(&cfg.Database)
+	if err != nil {
+		zapLogger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	defer db.Close()
+	db.Instrument(zapLogger, time.Duration(cfg.Database.SlowQueryMS)*time.Millisecond)
+
+	if cfg.Replica.Host != "" {
+		if err := db.OpenReplica(&cfg.Replica); err != nil {
+			zapLogger.Warn("Failed to connect to read replica; reading from the primary", zap.Error(err))
+		}
+	}
+
+	if cfg.Inventory.Host != "" {
+		if err := db.OpenInventory(&cfg.Inventory); err != nil {
+			zapLogger.Fatal("Failed to connect to inventory database", zap.Error(err))
+		}
+	}
+
+	if cfg.Cache.Enabled {
+		db.CacheTickets(cache.New(&cfg.Redis),
+			time.Duration(cfg.Cache.TicketTTL)*time.Second, time.Duration(cfg.Cache.QueueTTL)*time.Second)
+	}
+
 	// Create router
-	router := api.NewRouter(cfg, zapLogger)
+	router := api.NewRouter(cfg, zapLogger, db)
 
 	// Create server
 	srv := &http.Server{
@@ -4,10 +4,13 @@ import (
 	"os"
 
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
 )
 
 func main() {
+	// Run functions exit with their own status; an error here is cobra
+	// rejecting the command line
 	if err := commands.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 }
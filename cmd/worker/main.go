@@ -1,19 +1,46 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/audit"
+	"github.com/afterdarksys/adsops-utils/internal/blackout"
+	"github.com/afterdarksys/adsops-utils/internal/cache"
 	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/jira"
+	"github.com/afterdarksys/adsops-utils/internal/jobs"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/notifications"
+	"github.com/afterdarksys/adsops-utils/internal/objectstore"
 	"github.com/afterdarksys/adsops-utils/internal/pkg/logger"
+	"github.com/afterdarksys/adsops-utils/internal/queuebot"
+	"github.com/afterdarksys/adsops-utils/internal/reconcile"
+	"github.com/afterdarksys/adsops-utils/internal/reports"
+	"github.com/afterdarksys/adsops-utils/internal/reposync"
+	"github.com/afterdarksys/adsops-utils/internal/ses"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+	"github.com/afterdarksys/adsops-utils/internal/webhooks"
 	"go.uber.org/zap"
 )
 
 func main() {
+	backfillFrom := flag.String("audit-backfill-from", "", "Archive the audit log from this day (YYYY-MM-DD) and exit")
+	backfillTo := flag.String("audit-backfill-to", "", "Last day to archive with -audit-backfill-from (default yesterday)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -31,40 +58,296 @@ func main() {
 		zap.String("environment", cfg.Environment),
 	)
 
+	// Connect to database
+	db, err := store.New(&cfg.Database)
+	if err != nil {
+		zapLogger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	defer db.Close()
+	db.Instrument(zapLogger, time.Duration(cfg.Database.SlowQueryMS)*time.Millisecond)
+
+	if cfg.Replica.Host != "" {
+		if err := db.OpenReplica(&cfg.Replica); err != nil {
+			zapLogger.Warn("Failed to connect to read replica; reading from the primary", zap.Error(err))
+		}
+	}
+
+	if cfg.Inventory.Host != "" {
+		if err := db.OpenInventory(&cfg.Inventory); err != nil {
+			zapLogger.Fatal("Failed to connect to inventory database", zap.Error(err))
+		}
+	}
+
+	// Expiring approvals, escalations and queue assignment change tickets
+	// the API may have cached
+	if cfg.Cache.Enabled {
+		db.CacheTickets(cache.New(&cfg.Redis),
+			time.Duration(cfg.Cache.TicketTTL)*time.Second, time.Duration(cfg.Cache.QueueTTL)*time.Second)
+	}
+
+	var archiver *audit.Archiver
+	if cfg.AuditArchive.Bucket != "" {
+		uploader, err := objectstore.NewClient(&cfg.AuditArchive.ObjectStoreConfig, &cfg.AWS)
+		if err != nil {
+			zapLogger.Fatal("Failed to configure audit log archive", zap.Error(err))
+		}
+		archiver = audit.NewArchiver(db, uploader, &cfg.AuditArchive)
+	}
+
+	if *backfillFrom != "" {
+		if archiver == nil {
+			zapLogger.Fatal("Audit log backfill needs audit_archive.bucket")
+		}
+		backfillAuditLog(archiver, *backfillFrom, *backfillTo, zapLogger)
+		return
+	}
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// TODO: Initialize worker services
-	// - Email notification processor
 	// - Approval reminder scheduler
 	// - Audit log exporter
-	// - Cleanup jobs
-
-	// Start workers
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(10 * time.Second):
-				// TODO: Process notification queue
-				zapLogger.Debug("Checking notification queue...")
-			}
+
+	// A job returns the error that stopped its run, which the scheduler logs
+	// and counts. Failures of single items are logged by the job and don't
+	// fail the run.
+	scheduler := jobs.New(zapLogger)
+	emails := &emailCounts{}
+
+	if mailer, err := ses.NewClient(&cfg.AWS); err != nil {
+		zapLogger.Warn("Notification delivery disabled", zap.Error(err))
+	} else {
+		processor := notifications.NewProcessor(db, mailer, cfg.Email)
+		scheduler.MustRegister(jobs.Job{
+			Name:     "process-notifications",
+			Schedule: "@every 10s",
+			Timeout:  2 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return processNotifications(ctx, processor, emails, zapLogger)
+			},
+		})
+	}
+
+	digester := notifications.NewDigester(db, cfg.Email.BaseURL)
+	scheduler.MustRegister(jobs.Job{
+		Name:     "send-digests",
+		Schedule: "*/5 * * * *",
+		Timeout:  4 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return sendDigests(ctx, digester, zapLogger)
+		},
+	})
+
+	dispatcher := webhooks.NewDispatcher(db)
+	scheduler.MustRegister(jobs.Job{
+		Name:     "deliver-webhooks",
+		Schedule: "@every 10s",
+		Timeout:  4 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return deliverWebhooks(ctx, dispatcher, zapLogger)
+		},
+	})
+
+	if cfg.AWS.SESFeedbackQueueURL != "" {
+		queue, err := ses.NewQueueClient(&cfg.AWS, cfg.AWS.SESFeedbackQueueURL)
+		if err != nil {
+			zapLogger.Warn("SES bounce and complaint handling disabled", zap.Error(err))
+		} else {
+			consumer := notifications.NewFeedbackConsumer(db, queue)
+			scheduler.MustRegister(jobs.Job{
+				Name:       "consume-ses-feedback",
+				Schedule:   "@every 1m",
+				Timeout:    3 * time.Minute,
+				RunOnStart: true,
+				Run: func(ctx context.Context) error {
+					return consumeSESFeedback(ctx, consumer, zapLogger)
+				},
+			})
 		}
-	}()
+	}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(1 * time.Hour):
-				// TODO: Send approval reminders
-				zapLogger.Debug("Checking for pending approval reminders...")
-			}
+	scheduler.MustRegister(jobs.Job{
+		Name:       "maintain-audit-partitions",
+		Schedule:   "5 0 * * *",
+		Timeout:    30 * time.Minute,
+		RunOnStart: true,
+		Run: func(ctx context.Context) error {
+			return maintainAuditPartitions(ctx, db, archiver, zapLogger)
+		},
+	})
+
+	if archiver != nil {
+		scheduler.MustRegister(jobs.Job{
+			Name:     "archive-audit-log",
+			Schedule: "15 1 * * *",
+			Timeout:  2 * time.Hour,
+			Jitter:   10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return archiveAuditLog(ctx, archiver, zapLogger)
+			},
+		})
+	}
+
+	if cfg.Reports.Bucket != "" {
+		storage, err := objectstore.NewClient(&cfg.Reports.ObjectStoreConfig, &cfg.AWS)
+		if err != nil {
+			zapLogger.Fatal("Failed to configure report storage", zap.Error(err))
 		}
-	}()
+		generator := reports.NewGenerator(db, storage, &cfg.Reports)
+		scheduler.MustRegister(jobs.Job{
+			Name:     "generate-reports",
+			Schedule: "@every 15s",
+			Timeout:  models.ReportJobLease,
+			Run: func(ctx context.Context) error {
+				return generateReports(ctx, generator, zapLogger)
+			},
+		})
+		scheduler.MustRegister(jobs.Job{
+			Name:     "expire-reports",
+			Schedule: "45 2 * * *",
+			Timeout:  30 * time.Minute,
+			Jitter:   10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return expireReports(ctx, generator, zapLogger)
+			},
+		})
+	}
+
+	cleaner := blackout.NewCleaner(db, cfg.Blackout)
+	scheduler.MustRegister(jobs.Job{
+		Name:       "expire-blackouts",
+		Schedule:   "@every 1m",
+		Timeout:    50 * time.Second,
+		RunOnStart: true,
+		Run: func(ctx context.Context) error {
+			return expireBlackouts(ctx, cleaner, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "escalate-emergencies",
+		Schedule: "@every 1m",
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return escalateEmergencies(ctx, db, cfg, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "expire-approvals",
+		Schedule: "@every 5m",
+		Timeout:  4 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return expireApprovals(ctx, db, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "apply-retention",
+		Schedule: "0 3 * * *",
+		Timeout:  2 * time.Hour,
+		Jitter:   10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return enforceRetention(ctx, db, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "retry-anonymizations",
+		Schedule: "*/15 * * * *",
+		Timeout:  10 * time.Minute,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			return retryAnonymizations(ctx, db, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "purge-expired-keys",
+		Schedule: "@hourly",
+		Timeout:  10 * time.Minute,
+		Jitter:   5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return errors.Join(
+				purgeIdempotencyKeys(ctx, db, zapLogger),
+				purgeAuthChallenges(ctx, db, zapLogger),
+			)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "purge-webhook-deliveries",
+		Schedule: "30 3 * * *",
+		Timeout:  30 * time.Minute,
+		Jitter:   10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return errors.Join(
+				purgeWebhookDeliveries(ctx, db, zapLogger),
+				purgeWebhookOutbox(ctx, db, zapLogger),
+			)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "sync-jira",
+		Schedule: "@every 5m",
+		Timeout:  4 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return syncJira(ctx, db, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "assign-queues",
+		Schedule: "@every 5m",
+		Timeout:  4 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return assignQueues(ctx, db, zapLogger)
+		},
+	})
+	scheduler.MustRegister(jobs.Job{
+		Name:     "sync-repositories",
+		Schedule: "0 */6 * * *",
+		Timeout:  time.Hour,
+		Jitter:   15 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return syncRepositories(ctx, db, zapLogger)
+		},
+	})
+
+	if providers := cfg.Reconcile.GetProviders(); len(providers) > 0 {
+		reconciler := reconcile.NewReconciler(db, reconcile.NewCloudtop(cfg.Reconcile.CloudtopPath, cfg.Reconcile.CloudtopConfig))
+		scheduler.MustRegister(jobs.Job{
+			Name:     "reconcile-inventory",
+			Schedule: "20 * * * *",
+			Timeout:  15 * time.Minute,
+			Jitter:   5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return reconcileInventory(ctx, reconciler, providers, zapLogger)
+			},
+		})
+	}
+
+	// Elect one replica to run the jobs. The election outlives ctx so the
+	// lock is only released once this replica's runs have finished.
+	var elector *jobs.Elector
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	defer stopElection()
+	if cfg.Worker.LeaderElection {
+		elector = jobs.NewElector(db.DB(), "adsops-worker", zapLogger)
+		elector.Start(electionCtx)
+		scheduler.SetLeadership(elector)
+	}
+
+	var health *http.Server
+	if cfg.Worker.HealthAddr != "" {
+		health = &http.Server{
+			Addr:              cfg.Worker.HealthAddr,
+			Handler:           statusHandler(db, scheduler, elector, emails, zapLogger),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			if err := health.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				zapLogger.Fatal("Failed to start health server", zap.Error(err))
+			}
+		}()
+	}
+
+	scheduler.Start(ctx)
 
 	// Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
@@ -74,7 +357,735 @@ func main() {
 	zapLogger.Info("Shutting down worker...")
 	cancel()
 
-	// Give workers time to finish
-	time.Sleep(5 * time.Second)
+	// Give running jobs time to finish
+	if !scheduler.Wait(30 * time.Second) {
+		zapLogger.Warn("Jobs still running at shutdown")
+	}
+	if elector != nil {
+		stopElection()
+		elector.Wait()
+	}
+	if health != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		health.Shutdown(shutdownCtx)
+		shutdownCancel()
+	}
 	zapLogger.Info("Worker stopped")
 }
+
+// statusHandler serves the worker's probes and metrics:
+//   - /livez answers while the process is up.
+//   - /readyz and /healthz check the database. /healthz also reports
+//     whether this replica is the leader. A standby is healthy and ready.
+//   - /metrics serves job, queue and email counters for Prometheus.
+func statusHandler(db *store.Store, scheduler *jobs.Scheduler, elector *jobs.Elector, emails *emailCounts, zapLogger *zap.Logger) http.Handler {
+	instance, _ := os.Hostname()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "alive"})
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := pingDatabase(r.Context(), db); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status": "not ready",
+				"error":  "database unreachable",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ready"})
+	})
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{
+			"status":          "healthy",
+			"instance":        instance,
+			"leader_election": elector != nil,
+		}
+		status := http.StatusOK
+		if err := pingDatabase(r.Context(), db); err != nil {
+			status = http.StatusServiceUnavailable
+			body["status"] = "unhealthy"
+			body["error"] = "database unreachable"
+		}
+		if elector != nil {
+			leader := elector.Status()
+			body["leader"] = leader.Leader
+			body["leader_since"] = leader.Since
+		} else {
+			// Without an election every replica runs the jobs
+			body["leader"] = true
+		}
+		writeJSON(w, status, body)
+	})
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		var b bytes.Buffer
+		leader := 1
+		if elector != nil && !elector.Status().Leader {
+			leader = 0
+		}
+		fmt.Fprintln(&b, "# HELP adsops_worker_leader Whether this replica runs the scheduled jobs.")
+		fmt.Fprintln(&b, "# TYPE adsops_worker_leader gauge")
+		fmt.Fprintf(&b, "adsops_worker_leader %d\n", leader)
+
+		scheduler.WriteMetrics(&b)
+		emails.writeMetrics(&b)
+
+		queues := map[string]func(context.Context) (*store.QueueStats, error){
+			"notifications": db.Notifications.QueueStats,
+			"webhooks":      db.Webhooks.QueueStats,
+			"reports":       db.ReportJobs.QueueStats,
+		}
+		stats := make(map[string]*store.QueueStats)
+		for name, get := range queues {
+			st, err := get(ctx)
+			if err != nil {
+				zapLogger.Warn("Failed to read queue stats for metrics", zap.String("queue", name), zap.Error(err))
+				continue
+			}
+			stats[name] = st
+		}
+		writeQueueMetrics(&b, stats, time.Now())
+		db.WriteMetrics(&b)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(b.Bytes())
+	})
+
+	return mux
+}
+
+func pingDatabase(ctx context.Context, db *store.Store) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return db.DB().PingContext(ctx)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeQueueMetrics writes the depth and age of the notification and
+// webhook queues. They are read from the database, so every replica
+// reports the same values.
+func writeQueueMetrics(b *bytes.Buffer, stats map[string]*store.QueueStats, now time.Time) {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(b, "# HELP adsops_worker_queue_pending Items waiting to be sent, including retries scheduled later.")
+	fmt.Fprintln(b, "# TYPE adsops_worker_queue_pending gauge")
+	for _, name := range names {
+		fmt.Fprintf(b, "adsops_worker_queue_pending{queue=%q} %d\n", name, stats[name].Pending)
+	}
+	fmt.Fprintln(b, "# HELP adsops_worker_queue_due Items due to be sent now.")
+	fmt.Fprintln(b, "# TYPE adsops_worker_queue_due gauge")
+	for _, name := range names {
+		fmt.Fprintf(b, "adsops_worker_queue_due{queue=%q} %d\n", name, stats[name].Due)
+	}
+	fmt.Fprintln(b, "# HELP adsops_worker_queue_oldest_due_seconds How long the longest-waiting due item has been due.")
+	fmt.Fprintln(b, "# TYPE adsops_worker_queue_oldest_due_seconds gauge")
+	for _, name := range names {
+		age := 0.0
+		if oldest := stats[name].OldestDue; oldest != nil && oldest.Before(now) {
+			age = now.Sub(*oldest).Seconds()
+		}
+		fmt.Fprintf(b, "adsops_worker_queue_oldest_due_seconds{queue=%q} %g\n", name, age)
+	}
+}
+
+// emailCounts counts the notification processor's outcomes for /metrics
+type emailCounts struct {
+	sent       atomic.Int64
+	retried    atomic.Int64
+	failed     atomic.Int64
+	bounced    atomic.Int64
+	suppressed atomic.Int64
+	batched    atomic.Int64
+}
+
+func (e *emailCounts) add(result *notifications.ProcessResult) {
+	e.sent.Add(int64(result.Sent))
+	e.retried.Add(int64(result.Retried))
+	e.failed.Add(int64(result.Failed))
+	e.bounced.Add(int64(result.Bounced))
+	e.suppressed.Add(int64(result.Suppressed))
+	e.batched.Add(int64(result.Batched))
+}
+
+func (e *emailCounts) writeMetrics(b *bytes.Buffer) {
+	fmt.Fprintln(b, "# HELP adsops_worker_emails_total Notification emails processed by this replica, by outcome.")
+	fmt.Fprintln(b, "# TYPE adsops_worker_emails_total counter")
+	fmt.Fprintf(b, "adsops_worker_emails_total{status=\"sent\"} %d\n", e.sent.Load())
+	fmt.Fprintf(b, "adsops_worker_emails_total{status=\"retried\"} %d\n", e.retried.Load())
+	fmt.Fprintf(b, "adsops_worker_emails_total{status=\"failed\"} %d\n", e.failed.Load())
+	fmt.Fprintf(b, "adsops_worker_emails_total{status=\"bounced\"} %d\n", e.bounced.Load())
+	fmt.Fprintf(b, "adsops_worker_emails_total{status=\"suppressed\"} %d\n", e.suppressed.Load())
+	fmt.Fprintln(b, "# HELP adsops_worker_emails_batched_total Notifications sent together with others in one email.")
+	fmt.Fprintln(b, "# TYPE adsops_worker_emails_batched_total counter")
+	fmt.Fprintf(b, "adsops_worker_emails_batched_total %d\n", e.batched.Load())
+}
+
+// escalateEmergencies re-notifies on-call approvers for emergency tickets that
+// have passed their approval deadline. From the second escalation onward org
+// admins are paged as well.
+func escalateEmergencies(ctx context.Context, db *store.Store, cfg *config.Config, zapLogger *zap.Logger) error {
+	tickets, err := db.Emergency.ListOverdue(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list overdue emergency tickets: %w", err)
+	}
+
+	for i := range tickets {
+		ticket := &tickets[i]
+
+		level, err := db.Emergency.Escalate(ctx, ticket.ID, ticket.EscalationLevel)
+		if err != nil {
+			zapLogger.Debug("Skipping emergency escalation", zap.String("ticket", ticket.TicketNumber), zap.Error(err))
+			continue
+		}
+		ticket.EscalationLevel = level
+
+		msg := notifications.EmergencyEscalation(ticket, level, cfg.Email.BaseURL)
+		notified, err := db.Emergency.NotifyOnCallApprovers(ctx, ticket, msg, level >= 2)
+		if err != nil {
+			zapLogger.Error("Failed to queue escalation notifications", zap.String("ticket", ticket.TicketNumber), zap.Error(err))
+		}
+
+		db.Audit.LogSystemEvent(ctx, ticket.ID, models.AuditActionEmergencyEscalation, map[string]interface{}{
+			"escalation_level": level,
+			"notified":         notified,
+		})
+
+		zapLogger.Info("Escalated emergency ticket",
+			zap.String("ticket", ticket.TicketNumber),
+			zap.Int("level", level),
+			zap.Int("notified", notified),
+		)
+	}
+	return nil
+}
+
+// expireApprovals sends tickets whose approval deadline has passed back to
+// their creators, expiring the approvals still pending on them
+func expireApprovals(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	tickets, err := db.Approvals.ExpireOverdue(ctx, time.Now())
+	for i := range tickets {
+		ticket := &tickets[i]
+		db.Audit.LogSystemEvent(ctx, ticket.ID, models.AuditActionApprovalsExpired, map[string]interface{}{
+			"approval_deadline": ticket.ApprovalDeadline,
+			"status":            ticket.Status,
+		})
+		zapLogger.Info("Expired approvals on overdue ticket", zap.String("ticket", ticket.TicketNumber))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to expire overdue approvals: %w", err)
+	}
+	return nil
+}
+
+// enforceRetention scrubs personal data and purges trashed tickets past
+// each organization's retention policy, deletes expired API keys and
+// sessions, and records a summary of the run. The summary is recorded even
+// when a step fails or the run times out.
+func enforceRetention(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	run := &models.PurgeRun{StartedAt: time.Now()}
+	err := errors.Join(
+		applyRetention(ctx, db, run, zapLogger),
+		purgeTrash(ctx, db, run, zapLogger),
+		purgeExpiredCredentials(ctx, db, run, zapLogger),
+	)
+	run.FinishedAt = time.Now()
+
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if recordErr := db.Retention.RecordPurgeRun(recordCtx, run); recordErr != nil {
+		return errors.Join(err, recordErr)
+	}
+	zapLogger.Info("Recorded purge run",
+		zap.String("run", run.ID.String()),
+		zap.Int("organizations", run.Organizations),
+		zap.Int64("tickets_purged", run.TicketsPurged),
+		zap.Int64("api_keys_deleted", run.APIKeysDeleted),
+		zap.Int64("sessions_deleted", run.SessionsDeleted),
+		zap.Int("errors", len(run.Errors)),
+	)
+	return err
+}
+
+// applyRetention scrubs personal data past each organization's retention period
+func applyRetention(ctx context.Context, db *store.Store, run *models.PurgeRun, zapLogger *zap.Logger) error {
+	orgIDs, err := db.Retention.ListOrganizationIDs(ctx)
+	if err != nil {
+		run.Errors = append(run.Errors, err.Error())
+		return fmt.Errorf("failed to list organizations for retention: %w", err)
+	}
+
+	now := time.Now()
+	for _, orgID := range orgIDs {
+		policy, err := db.Retention.GetPolicy(ctx, orgID)
+		if err != nil {
+			zapLogger.Error("Failed to get retention policy", zap.String("org", orgID.String()), zap.Error(err))
+			run.Errors = append(run.Errors, fmt.Sprintf("organization %s: %v", orgID, err))
+			continue
+		}
+
+		result, err := db.Retention.ApplyRetention(ctx, policy, now)
+		if err != nil {
+			zapLogger.Error("Failed to apply retention policy", zap.String("org", orgID.String()), zap.Error(err))
+			run.Errors = append(run.Errors, fmt.Sprintf("organization %s: %v", orgID, err))
+			continue
+		}
+		run.Organizations++
+		run.AddRetention(result)
+
+		zapLogger.Info("Applied retention policy",
+			zap.String("org", orgID.String()),
+			zap.Int64("audit_logs", result.AuditLogsScrubbed),
+			zap.Int64("ticket_audits", result.TicketAuditsScrubbed),
+			zap.Int64("comments", result.CommentsScrubbed),
+		)
+	}
+	return nil
+}
+
+// purgeTrash purges tickets that have been in the trash longer than each
+// organization's trash retention period
+func purgeTrash(ctx context.Context, db *store.Store, run *models.PurgeRun, zapLogger *zap.Logger) error {
+	orgIDs, err := db.Retention.ListOrganizationIDs(ctx)
+	if err != nil {
+		run.Errors = append(run.Errors, err.Error())
+		return fmt.Errorf("failed to list organizations for trash purge: %w", err)
+	}
+
+	now := time.Now()
+	for _, orgID := range orgIDs {
+		policy, err := db.Retention.GetPolicy(ctx, orgID)
+		if err != nil {
+			zapLogger.Error("Failed to get retention policy", zap.String("org", orgID.String()), zap.Error(err))
+			run.Errors = append(run.Errors, fmt.Sprintf("organization %s: %v", orgID, err))
+			continue
+		}
+
+		purged, err := db.Tickets.PurgeTrash(ctx, orgID, policy.TrashCutoff(now))
+		if err != nil {
+			zapLogger.Error("Failed to purge trash", zap.String("org", orgID.String()), zap.Error(err))
+			run.Errors = append(run.Errors, fmt.Sprintf("organization %s: %v", orgID, err))
+			continue
+		}
+		for _, ticketID := range purged {
+			db.Audit.LogSystemEvent(ctx, ticketID, models.AuditActionPurge, map[string]interface{}{
+				"trash_retention_days": policy.TrashRetentionDays,
+			})
+		}
+		run.TicketsPurged += int64(len(purged))
+
+		if len(purged) > 0 {
+			zapLogger.Info("Purged trashed tickets",
+				zap.String("org", orgID.String()),
+				zap.Int("count", len(purged)),
+			)
+		}
+	}
+	return nil
+}
+
+// purgeExpiredCredentials deletes API keys and sessions that expired or
+// were revoked longer ago than they are kept for investigation
+func purgeExpiredCredentials(ctx context.Context, db *store.Store, run *models.PurgeRun, zapLogger *zap.Logger) error {
+	now := time.Now()
+	keys, keysErr := db.Retention.PurgeAPIKeys(ctx, now.Add(-models.ExpiredAPIKeyRetention))
+	if keysErr != nil {
+		run.Errors = append(run.Errors, keysErr.Error())
+	}
+	sessions, sessionsErr := db.Auth.PurgeSessions(ctx, now.Add(-models.ExpiredSessionRetention))
+	if sessionsErr != nil {
+		run.Errors = append(run.Errors, sessionsErr.Error())
+	}
+	run.APIKeysDeleted = keys
+	run.SessionsDeleted = sessions
+
+	if keys+sessions > 0 {
+		zapLogger.Info("Purged expired credentials",
+			zap.Int64("api_keys", keys),
+			zap.Int64("sessions", sessions),
+		)
+	}
+	return errors.Join(keysErr, sessionsErr)
+}
+
+// retryAnonymizations processes anonymization requests that failed when first submitted
+func retryAnonymizations(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	requests, err := db.Retention.ListPendingAnonymizationRequests(ctx, 50)
+	if err != nil {
+		return fmt.Errorf("failed to list pending anonymization requests: %w", err)
+	}
+
+	for _, req := range requests {
+		if _, err := db.Retention.ProcessAnonymizationRequest(ctx, req.ID); err != nil {
+			zapLogger.Warn("Anonymization request failed",
+				zap.String("request", req.ID.String()),
+				zap.Int("attempts", req.Attempts+1),
+				zap.Error(err),
+			)
+			continue
+		}
+		zapLogger.Info("Processed anonymization request", zap.String("request", req.ID.String()))
+	}
+	return nil
+}
+
+// purgeIdempotencyKeys removes stored responses for expired idempotency keys
+func purgeIdempotencyKeys(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	purged, err := db.Idempotency.PurgeExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	if purged > 0 {
+		zapLogger.Info("Purged expired idempotency keys", zap.Int64("count", purged))
+	}
+	return nil
+}
+
+// purgeAuthChallenges removes passkey and MFA challenges that were never answered
+func purgeAuthChallenges(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	purged, err := db.Auth.PurgeChallenges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge auth challenges: %w", err)
+	}
+	if purged > 0 {
+		zapLogger.Info("Purged expired auth challenges", zap.Int64("count", purged))
+	}
+	return nil
+}
+
+func purgeWebhookDeliveries(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	purged, err := db.GitHub.PurgeDeliveries(ctx, time.Now().Add(-models.GitHubDeliveryRetention))
+	if err != nil {
+		return fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	if purged > 0 {
+		zapLogger.Info("Purged old webhook deliveries", zap.Int64("count", purged))
+	}
+	return nil
+}
+
+func purgeWebhookOutbox(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	purged, err := db.Webhooks.PurgeDeliveries(ctx, time.Now().Add(-models.WebhookDeliveryRetention))
+	if err != nil {
+		return fmt.Errorf("failed to purge outgoing webhook deliveries: %w", err)
+	}
+	if purged > 0 {
+		zapLogger.Info("Purged old outgoing webhook deliveries", zap.Int64("count", purged))
+	}
+	return nil
+}
+
+func syncJira(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	integrations, err := db.Jira.ListActiveIntegrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list Jira integrations: %w", err)
+	}
+
+	for i := range integrations {
+		integration := &integrations[i]
+		result, err := jira.NewSyncer(db, integration).Run(ctx, time.Now())
+		if err != nil {
+			zapLogger.Error("Jira sync failed",
+				zap.String("org", integration.OrganizationID.String()),
+				zap.Error(err),
+			)
+			continue
+		}
+		if result.IssuesCreated+result.IssuesUpdated+result.StatusesPulled+result.CommentsPulled+result.Errors > 0 {
+			zapLogger.Info("Synced Jira",
+				zap.String("org", integration.OrganizationID.String()),
+				zap.Int("issues_created", result.IssuesCreated),
+				zap.Int("issues_updated", result.IssuesUpdated),
+				zap.Int("transitions", result.Transitions),
+				zap.Int("statuses_pulled", result.StatusesPulled),
+				zap.Int("comments_pulled", result.CommentsPulled),
+				zap.Int("errors", result.Errors),
+			)
+		}
+	}
+	return nil
+}
+
+// assignQueues runs the queue bot for every organization with active
+// assignment rules
+func assignQueues(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	orgIDs, err := db.AssignmentRules.ListOrganizations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations with assignment rules: %w", err)
+	}
+
+	bot := queuebot.New(db)
+	for _, orgID := range orgIDs {
+		results, err := bot.Run(ctx, orgID, models.AssignmentTriggerSchedule)
+		if err != nil {
+			zapLogger.Error("Queue assignment failed",
+				zap.String("org", orgID.String()),
+				zap.Error(err),
+			)
+			continue
+		}
+		assigned := 0
+		for _, r := range results {
+			if r.AssignedTo != nil {
+				assigned++
+			}
+		}
+		if assigned > 0 {
+			zapLogger.Info("Assigned queued tickets",
+				zap.String("org", orgID.String()),
+				zap.Int("assigned", assigned),
+				zap.Int("unassigned", len(results)-assigned),
+			)
+		}
+	}
+	return nil
+}
+
+// syncRepositories refreshes repository details from GitHub and GitLab for
+// every organization with a sync token
+func syncRepositories(ctx context.Context, db *store.Store, zapLogger *zap.Logger) error {
+	orgIDs, err := db.Repositories.ListSyncOrganizations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list repository sync organizations: %w", err)
+	}
+
+	syncer := reposync.NewSyncer(db)
+	for _, orgID := range orgIDs {
+		run, err := syncer.SyncOrganization(ctx, orgID)
+		if err != nil {
+			zapLogger.Error("Repository sync failed",
+				zap.String("org", orgID.String()),
+				zap.Error(err),
+			)
+			continue
+		}
+		if run.Changed+run.Failed > 0 {
+			zapLogger.Info("Synced repositories",
+				zap.String("org", orgID.String()),
+				zap.Int("synced", run.Synced),
+				zap.Int("changed", run.Changed),
+				zap.Int("failed", run.Failed),
+			)
+		}
+	}
+	return nil
+}
+
+// reconcileInventory compares each configured provider's inventory hosts
+// with its cloud instances and records ghosts and strays
+func reconcileInventory(ctx context.Context, reconciler *reconcile.Reconciler, providers []string, zapLogger *zap.Logger) error {
+	for _, provider := range providers {
+		result, err := reconciler.ReconcileProvider(ctx, provider)
+		if err != nil {
+			zapLogger.Error("Inventory reconciliation failed",
+				zap.String("provider", provider),
+				zap.Error(err),
+			)
+			continue
+		}
+		zapLogger.Info("Reconciled inventory",
+			zap.String("provider", provider),
+			zap.Int("hosts", result.Hosts),
+			zap.Int("instances", result.Instances),
+			zap.Int("ghosts", result.Ghosts),
+			zap.Int("strays", result.Strays),
+			zap.Int64("opened", result.Opened),
+			zap.Int64("resolved", result.Resolved),
+		)
+	}
+	return nil
+}
+
+// processNotifications sends due queued notifications through SES
+func processNotifications(ctx context.Context, processor *notifications.Processor, emails *emailCounts, zapLogger *zap.Logger) error {
+	result, err := processor.Process(ctx, time.Now())
+	emails.add(result)
+	if result.Sent+result.Retried+result.Failed+result.Bounced+result.Suppressed > 0 {
+		zapLogger.Info("Processed notifications",
+			zap.Int("sent", result.Sent),
+			zap.Int("retried", result.Retried),
+			zap.Int("failed", result.Failed),
+			zap.Int("bounced", result.Bounced),
+			zap.Int("suppressed", result.Suppressed),
+			zap.Int("batched", result.Batched),
+		)
+	}
+	return err
+}
+
+// sendDigests queues the daily and weekly digests due at users' local
+// digest times
+func sendDigests(ctx context.Context, digester *notifications.Digester, zapLogger *zap.Logger) error {
+	result, err := digester.Run(ctx, time.Now())
+	if result.Queued+result.Skipped+result.Failed > 0 {
+		zapLogger.Info("Queued digests",
+			zap.Int("queued", result.Queued),
+			zap.Int("empty", result.Skipped),
+			zap.Int("failed", result.Failed),
+		)
+	}
+	return err
+}
+
+// deliverWebhooks sends due deliveries from the webhook outbox
+func deliverWebhooks(ctx context.Context, dispatcher *webhooks.Dispatcher, zapLogger *zap.Logger) error {
+	result, err := dispatcher.Deliver(ctx, time.Now())
+	if result.Delivered+result.Retried+result.Dead > 0 {
+		zapLogger.Info("Delivered webhooks",
+			zap.Int("delivered", result.Delivered),
+			zap.Int("retried", result.Retried),
+			zap.Int("dead", result.Dead),
+		)
+	}
+	return err
+}
+
+// consumeSESFeedback applies the bounce and complaint notifications SES has
+// queued since the last run
+func consumeSESFeedback(ctx context.Context, consumer *notifications.FeedbackConsumer, zapLogger *zap.Logger) error {
+	result, err := consumer.Consume(ctx)
+	if result.Received > 0 {
+		zapLogger.Info("Processed SES feedback",
+			zap.Int("received", result.Received),
+			zap.Int64("bounced", result.Bounced),
+			zap.Int("suppressed", result.Suppressed),
+			zap.Int64("flagged_users", result.Flagged),
+			zap.Int("invalid", result.Invalid),
+		)
+	}
+	return err
+}
+
+// archiveAuditLog exports each finished day of the ticket audit log not yet
+// archived to object storage
+func archiveAuditLog(ctx context.Context, archiver *audit.Archiver, zapLogger *zap.Logger) error {
+	archives, err := archiver.ArchivePending(ctx, time.Now())
+	for _, a := range archives {
+		zapLogger.Info("Archived audit log",
+			zap.String("date", a.Date.Format("2006-01-02")),
+			zap.Int64("records", a.RecordCount),
+			zap.String("object", a.ObjectKey),
+		)
+	}
+	return err
+}
+
+// auditPartitionsAhead is how many months of audit log partitions are kept
+// ready beyond the current one
+const auditPartitionsAhead = 3
+
+// maintainAuditPartitions creates the coming months' audit log partitions
+// and, when the audit log is archived, drops archived months past retention
+func maintainAuditPartitions(ctx context.Context, db *store.Store, archiver *audit.Archiver, zapLogger *zap.Logger) error {
+	now := time.Now()
+	created, err := db.Audit.EnsurePartitions(ctx, now.AddDate(0, auditPartitionsAhead, 0))
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		zapLogger.Info("Created audit log partitions", zap.Int("count", created))
+	}
+
+	if archiver == nil {
+		return nil
+	}
+	dropped, err := archiver.DropArchivedPartitions(ctx, now)
+	for _, p := range dropped {
+		zapLogger.Info("Dropped archived audit log partition",
+			zap.String("partition", p.Name),
+			zap.Int64("estimated_rows", p.EstimatedRows),
+		)
+	}
+	if err != nil {
+		// Months not fully archived yet are kept for a later run
+		zapLogger.Warn("Kept audit log partitions", zap.Error(err))
+	}
+	return nil
+}
+
+// generateReports renders queued report jobs and emails their requesters
+func generateReports(ctx context.Context, generator *reports.Generator, zapLogger *zap.Logger) error {
+	result, err := generator.Run(ctx, time.Now())
+	if result.Completed+result.Retried+result.Failed > 0 {
+		zapLogger.Info("Generated reports",
+			zap.Int("completed", result.Completed),
+			zap.Int("retried", result.Retried),
+			zap.Int("failed", result.Failed),
+		)
+	}
+	return err
+}
+
+// expireReports deletes generated reports past retention
+func expireReports(ctx context.Context, generator *reports.Generator, zapLogger *zap.Logger) error {
+	expired, err := generator.Expire(ctx, time.Now())
+	if expired > 0 {
+		zapLogger.Info("Expired generated reports", zap.Int("count", expired))
+	}
+	return err
+}
+
+// backfillAuditLog archives a historical range of the audit log, skipping
+// days already archived
+func backfillAuditLog(archiver *audit.Archiver, fromDate, toDate string, zapLogger *zap.Logger) {
+	now := time.Now()
+	from, err := time.Parse("2006-01-02", fromDate)
+	if err != nil {
+		zapLogger.Fatal("Invalid -audit-backfill-from date", zap.Error(err))
+	}
+	to := now.UTC().AddDate(0, 0, -1)
+	if toDate != "" {
+		if to, err = time.Parse("2006-01-02", toDate); err != nil {
+			zapLogger.Fatal("Invalid -audit-backfill-to date", zap.Error(err))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	archives, err := archiver.ArchiveRange(ctx, from, to, now)
+	var records int64
+	for _, a := range archives {
+		records += a.RecordCount
+		zapLogger.Info("Archived audit log",
+			zap.String("date", a.Date.Format("2006-01-02")),
+			zap.Int64("records", a.RecordCount),
+			zap.String("object", a.ObjectKey),
+		)
+	}
+	if err != nil {
+		zapLogger.Fatal("Audit log backfill failed", zap.Int("days_archived", len(archives)), zap.Error(err))
+	}
+	zapLogger.Info("Audit log backfill finished",
+		zap.Int("days_archived", len(archives)),
+		zap.Int64("records", records),
+	)
+}
+
+// expireBlackouts ends blackouts past their end time, returns their hosts to
+// active and rewrites the monitoring export
+func expireBlackouts(ctx context.Context, cleaner *blackout.Cleaner, zapLogger *zap.Logger) error {
+	result, err := cleaner.Run(ctx, time.Now())
+	if result.Expired+result.Restored > 0 {
+		zapLogger.Info("Expired blackouts",
+			zap.Int64("expired", result.Expired),
+			zap.Int64("restored", result.Restored),
+			zap.Int("active", result.Active),
+		)
+	}
+	return err
+}
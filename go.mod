@@ -17,6 +17,12 @@ require (
 
 	// Logging
 	go.uber.org/zap v1.26.0
+
+	// Character set conversion for PDF output
+	golang.org/x/text v0.15.0
+
+	// Ticket documents for 'changes ticket edit'
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -31,7 +37,7 @@ require (
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 
 	// Validation
-	github.com/go-playground/validator/v10 v10.20.0 // indirect
+	github.com/go-playground/validator/v10 v10.20.0
 	github.com/goccy/go-json v0.10.2 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -57,15 +63,23 @@ require (
 	golang.org/x/arch v0.8.0 // indirect
 
 	// Cryptography
-	golang.org/x/crypto v0.23.0 // indirect
+	golang.org/x/crypto v0.23.0
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
 	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
-	golang.org/x/text v0.15.0 // indirect
 	google.golang.org/protobuf v1.34.1 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
-require github.com/lib/pq v1.10.9 // indirect
+require (
+	github.com/jackc/pgx/v5 v5.5.5
+	github.com/lib/pq v1.10.9
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
+	golang.org/x/sync v0.5.0 // indirect
+)
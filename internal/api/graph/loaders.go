@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// viewer is the caller of a GraphQL request. It caches the users and
+// projects loaded while the request runs, so an object referenced from
+// several places in the response is fetched once.
+type viewer struct {
+	store    *store.Store
+	orgID    uuid.UUID
+	userID   uuid.UUID
+	users    map[uuid.UUID]*models.UserSummary
+	projects map[uuid.UUID]*models.Project
+}
+
+type viewerKey struct{}
+
+// WithViewer attaches the caller to the context. Every request executed
+// against the schema needs one.
+func WithViewer(ctx context.Context, s *store.Store, orgID, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, viewerKey{}, &viewer{
+		store:    s,
+		orgID:    orgID,
+		userID:   userID,
+		users:    make(map[uuid.UUID]*models.UserSummary),
+		projects: make(map[uuid.UUID]*models.Project),
+	})
+}
+
+func viewerFrom(ctx context.Context) *viewer {
+	v, _ := ctx.Value(viewerKey{}).(*viewer)
+	return v
+}
+
+// loadUsers fetches the users not yet cached in one query. Users that
+// don't exist are cached as nil so they aren't looked up again.
+func (v *viewer) loadUsers(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.UserSummary, error) {
+	missing := uncached(v.users, ids)
+	if len(missing) > 0 {
+		users, err := v.store.Users.GetSummaries(ctx, v.orgID, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			v.users[id] = nil
+		}
+		for i := range users {
+			v.users[users[i].ID] = &users[i]
+		}
+	}
+	return v.users, nil
+}
+
+// loadProjects fetches the projects not yet cached in one query
+func (v *viewer) loadProjects(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Project, error) {
+	missing := uncached(v.projects, ids)
+	if len(missing) > 0 {
+		projects, err := v.store.Projects.GetByIDs(ctx, v.orgID, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			v.projects[id] = nil
+		}
+		for i := range projects {
+			v.projects[projects[i].ID] = &projects[i]
+		}
+	}
+	return v.projects, nil
+}
+
+// uncached returns the distinct IDs that have no cache entry
+func uncached[T any](cache map[uuid.UUID]*T, ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	var missing []uuid.UUID
+	for _, id := range ids {
+		if _, ok := cache[id]; ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		missing = append(missing, id)
+	}
+	return missing
+}
+
+// loadTickets fetches tickets by ID, keeping the order of ids and leaving
+// out tickets the caller may not see
+func (v *viewer) loadTickets(ctx context.Context, ids []uuid.UUID) ([]*models.Ticket, error) {
+	if len(ids) == 0 {
+		return []*models.Ticket{}, nil
+	}
+	tickets, err := v.store.Tickets.GetByIDs(ctx, v.orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Ticket, len(tickets))
+	for i := range tickets {
+		byID[tickets[i].ID] = &tickets[i]
+	}
+	ordered := make([]*models.Ticket, 0, len(ids))
+	for _, id := range ids {
+		if t, ok := byID[id]; ok {
+			ordered = append(ordered, t)
+		}
+	}
+	return ordered, nil
+}
+
+// ticketIDs returns the IDs of ticket sources
+func ticketIDs(sources []interface{}) []uuid.UUID {
+	ids := make([]uuid.UUID, len(sources))
+	for i, source := range sources {
+		ids[i] = source.(*models.Ticket).ID
+	}
+	return ids
+}
@@ -0,0 +1,430 @@
+// Package graph defines the read-only GraphQL schema served at /v1/graphql.
+//
+// Every field resolves for all objects at the same path in one call, so a
+// ticket list with its approvals, comments and linked repositories costs
+// one query per field rather than one per ticket.
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/graphql"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Schema is the GraphQL schema. Requests must carry a viewer, see WithViewer.
+var Schema = newSchema()
+
+// ticketConnection is a page of tickets
+type ticketConnection struct {
+	nodes      []*models.Ticket
+	totalCount int
+	nextCursor *string
+}
+
+func newSchema() *graphql.Schema {
+	user := &graphql.Object{Name: "User", Fields: map[string]*graphql.FieldDef{
+		"id":       scalar(func(u *models.UserSummary) interface{} { return u.ID }),
+		"email":    scalar(func(u *models.UserSummary) interface{} { return u.Email }),
+		"fullName": scalar(func(u *models.UserSummary) interface{} { return u.FullName }),
+	}}
+
+	project := &graphql.Object{Name: "Project", Fields: map[string]*graphql.FieldDef{
+		"id":              scalar(func(p *models.Project) interface{} { return p.ID }),
+		"key":             scalar(func(p *models.Project) interface{} { return p.ProjectKey }),
+		"name":            scalar(func(p *models.Project) interface{} { return p.Name }),
+		"description":     scalar(func(p *models.Project) interface{} { return p.Description }),
+		"isActive":        scalar(func(p *models.Project) interface{} { return p.IsActive }),
+		"iconUrl":         scalar(func(p *models.Project) interface{} { return p.IconURL }),
+		"createdAt":       scalar(func(p *models.Project) interface{} { return p.CreatedAt }),
+		"updatedAt":       scalar(func(p *models.Project) interface{} { return p.UpdatedAt }),
+		"lead":            userRef(user, func(p *models.Project) *uuid.UUID { return p.LeadUserID }),
+		"defaultAssignee": userRef(user, func(p *models.Project) *uuid.UUID { return p.DefaultAssigneeID }),
+	}}
+
+	repository := &graphql.Object{Name: "Repository", Fields: map[string]*graphql.FieldDef{
+		"id":       scalar(func(r *models.RepositorySummary) interface{} { return r.ID }),
+		"name":     scalar(func(r *models.RepositorySummary) interface{} { return r.Name }),
+		"url":      scalar(func(r *models.RepositorySummary) interface{} { return r.URL }),
+		"provider": scalar(func(r *models.RepositorySummary) interface{} { return r.Provider }),
+		"isActive": scalar(func(r *models.RepositorySummary) interface{} { return r.IsActive }),
+	}}
+
+	linkedRepository := &graphql.Object{Name: "LinkedRepository", Fields: map[string]*graphql.FieldDef{
+		"id":         scalar(func(tr *models.TicketRepository) interface{} { return tr.ID }),
+		"linkType":   scalar(func(tr *models.TicketRepository) interface{} { return tr.LinkType }),
+		"branchName": scalar(func(tr *models.TicketRepository) interface{} { return tr.BranchName }),
+		"commitSha":  scalar(func(tr *models.TicketRepository) interface{} { return tr.CommitSHA }),
+		"prNumber":   scalar(func(tr *models.TicketRepository) interface{} { return tr.PRNumber }),
+		"notes":      scalar(func(tr *models.TicketRepository) interface{} { return tr.Notes }),
+		"createdAt":  scalar(func(tr *models.TicketRepository) interface{} { return tr.CreatedAt }),
+		"repository": {Type: repository, Resolve: graphql.Each(func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			return source.(*models.TicketRepository).Repository, nil
+		})},
+		"linkedBy": userRef(user, func(tr *models.TicketRepository) *uuid.UUID { return &tr.LinkedBy }),
+	}}
+
+	approval := &graphql.Object{Name: "Approval", Fields: map[string]*graphql.FieldDef{
+		"id":              scalar(func(a *models.Approval) interface{} { return a.ID }),
+		"type":            scalar(func(a *models.Approval) interface{} { return a.ApprovalType }),
+		"sequenceOrder":   scalar(func(a *models.Approval) interface{} { return a.SequenceOrder }),
+		"status":          scalar(func(a *models.Approval) interface{} { return a.Status }),
+		"approvedAt":      scalar(func(a *models.Approval) interface{} { return a.ApprovedAt }),
+		"deniedAt":        scalar(func(a *models.Approval) interface{} { return a.DeniedAt }),
+		"decisionComment": scalar(func(a *models.Approval) interface{} { return a.DecisionComment }),
+		"conditions":      scalar(func(a *models.Approval) interface{} { return a.Conditions }),
+		"createdAt":       scalar(func(a *models.Approval) interface{} { return a.CreatedAt }),
+		"updatedAt":       scalar(func(a *models.Approval) interface{} { return a.UpdatedAt }),
+		"approver":        userRef(user, func(a *models.Approval) *uuid.UUID { return &a.ApproverID }),
+		"delegatedFrom":   userRef(user, func(a *models.Approval) *uuid.UUID { return a.DelegatedFrom }),
+	}}
+
+	comment := &graphql.Object{Name: "Comment", Fields: map[string]*graphql.FieldDef{
+		"id":         scalar(func(c *models.Comment) interface{} { return c.ID }),
+		"body":       scalar(func(c *models.Comment) interface{} { return c.Comment }),
+		"isInternal": scalar(func(c *models.Comment) interface{} { return c.IsInternal }),
+		"edited":     scalar(func(c *models.Comment) interface{} { return c.Edited }),
+		"createdAt":  scalar(func(c *models.Comment) interface{} { return c.CreatedAt }),
+		"updatedAt":  scalar(func(c *models.Comment) interface{} { return c.UpdatedAt }),
+		// Anonymized comments no longer name their author
+		"author": userRef(user, func(c *models.Comment) *uuid.UUID {
+			if c.Anonymized {
+				return nil
+			}
+			return &c.AuthorID
+		}),
+	}}
+
+	ticket := &graphql.Object{Name: "Ticket", Fields: map[string]*graphql.FieldDef{
+		"id":                   scalar(func(t *models.Ticket) interface{} { return t.ID }),
+		"number":               scalar(func(t *models.Ticket) interface{} { return t.TicketNumber }),
+		"title":                scalar(func(t *models.Ticket) interface{} { return t.Title }),
+		"description":          scalar(func(t *models.Ticket) interface{} { return t.Description }),
+		"status":               scalar(func(t *models.Ticket) interface{} { return t.Status }),
+		"priority":             scalar(func(t *models.Ticket) interface{} { return t.Priority }),
+		"riskLevel":            scalar(func(t *models.Ticket) interface{} { return t.RiskLevel }),
+		"industry":             scalar(func(t *models.Ticket) interface{} { return t.Industry }),
+		"changeType":           scalar(func(t *models.Ticket) interface{} { return t.ChangeType }),
+		"complianceFrameworks": scalar(func(t *models.Ticket) interface{} { return t.ComplianceFrameworks }),
+		"affectedSystems":      scalar(func(t *models.Ticket) interface{} { return t.AffectedSystems }),
+		"labels":               scalar(func(t *models.Ticket) interface{} { return t.Labels }),
+		"externalReference":    scalar(func(t *models.Ticket) interface{} { return t.ExternalReference }),
+		"isConfidential":       scalar(func(t *models.Ticket) interface{} { return t.IsConfidential }),
+		"isEmergency":          scalar(func(t *models.Ticket) interface{} { return t.IsEmergency }),
+		"scheduledStart":       scalar(func(t *models.Ticket) interface{} { return t.ScheduledStart }),
+		"scheduledEnd":         scalar(func(t *models.Ticket) interface{} { return t.ScheduledEnd }),
+		"submittedAt":          scalar(func(t *models.Ticket) interface{} { return t.SubmittedAt }),
+		"closedAt":             scalar(func(t *models.Ticket) interface{} { return t.ClosedAt }),
+		"createdAt":            scalar(func(t *models.Ticket) interface{} { return t.CreatedAt }),
+		"updatedAt":            scalar(func(t *models.Ticket) interface{} { return t.UpdatedAt }),
+		"creator":              userRef(user, func(t *models.Ticket) *uuid.UUID { return &t.CreatedBy }),
+		"assignee":             userRef(user, func(t *models.Ticket) *uuid.UUID { return t.AssignedTo }),
+		"project":              {Type: project, Resolve: resolveTicketProjects},
+		"approvals": ticketChildren(approval,
+			func(ctx context.Context, v *viewer, ids []uuid.UUID) ([]models.Approval, error) {
+				return v.store.Approvals.ListByTickets(ctx, v.orgID, ids)
+			},
+			func(a *models.Approval) uuid.UUID { return a.TicketID }),
+		"comments": ticketChildren(comment,
+			func(ctx context.Context, v *viewer, ids []uuid.UUID) ([]models.Comment, error) {
+				return v.store.Comments.ListByTickets(ctx, v.orgID, ids)
+			},
+			func(c *models.Comment) uuid.UUID { return c.TicketID }),
+		"repositories": ticketChildren(linkedRepository,
+			func(ctx context.Context, v *viewer, ids []uuid.UUID) ([]models.TicketRepository, error) {
+				return v.store.Repositories.ListTicketRepositories(ctx, ids)
+			},
+			func(tr *models.TicketRepository) uuid.UUID { return tr.TicketID }),
+	}}
+
+	connection := &graphql.Object{Name: "TicketConnection", Fields: map[string]*graphql.FieldDef{
+		"nodes": {Type: ticket, Resolve: graphql.Each(func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			return source.(*ticketConnection).nodes, nil
+		})},
+		"totalCount": scalar(func(c *ticketConnection) interface{} { return c.totalCount }),
+		"nextCursor": scalar(func(c *ticketConnection) interface{} { return c.nextCursor }),
+	}}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.FieldDef{
+		"me": {Type: user, Resolve: graphql.Each(resolveMe)},
+		"user": {Type: user, Args: map[string]interface{}{"id": nil},
+			Resolve: graphql.Each(resolveUser)},
+		"ticket": {Type: ticket, Args: map[string]interface{}{"id": nil, "number": nil},
+			Resolve: graphql.Each(resolveTicket)},
+		"tickets": {Type: connection, Args: map[string]interface{}{
+			"first": 25, "after": nil, "status": nil, "priority": nil,
+			"projectId": nil, "search": nil, "sortBy": nil, "sortOrder": nil,
+		}, Resolve: graphql.Each(resolveTickets)},
+		"project": {Type: project, Args: map[string]interface{}{"id": nil, "key": nil},
+			Resolve: graphql.Each(resolveProject)},
+		"projects": {Type: project, Args: map[string]interface{}{"activeOnly": true},
+			Resolve: graphql.Each(resolveProjects)},
+	}}
+
+	return &graphql.Schema{Query: query}
+}
+
+// scalar defines a field read straight off its source
+func scalar[T any](get func(T) interface{}) *graphql.FieldDef {
+	return &graphql.FieldDef{Resolve: graphql.Each(func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+		return get(source.(T)), nil
+	})}
+}
+
+// userRef defines a field holding a user ID, loading the users of all
+// sources at once
+func userRef[T any](user *graphql.Object, id func(T) *uuid.UUID) *graphql.FieldDef {
+	return &graphql.FieldDef{Type: user, Resolve: func(ctx context.Context, sources []interface{}, _ graphql.Args) ([]interface{}, error) {
+		ids := make([]uuid.UUID, 0, len(sources))
+		for _, source := range sources {
+			if uid := id(source.(T)); uid != nil {
+				ids = append(ids, *uid)
+			}
+		}
+		users, err := viewerFrom(ctx).loadUsers(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]interface{}, len(sources))
+		for i, source := range sources {
+			if uid := id(source.(T)); uid != nil {
+				values[i] = users[*uid]
+			}
+		}
+		return values, nil
+	}}
+}
+
+// ticketChildren defines a list field of a ticket, loading the items of
+// all tickets at once and grouping them by ticket
+func ticketChildren[T any](typ *graphql.Object, load func(context.Context, *viewer, []uuid.UUID) ([]T, error), ticketID func(*T) uuid.UUID) *graphql.FieldDef {
+	return &graphql.FieldDef{Type: typ, Resolve: func(ctx context.Context, sources []interface{}, _ graphql.Args) ([]interface{}, error) {
+		items, err := load(ctx, viewerFrom(ctx), ticketIDs(sources))
+		if err != nil {
+			return nil, err
+		}
+
+		byTicket := make(map[uuid.UUID][]*T)
+		for i := range items {
+			id := ticketID(&items[i])
+			byTicket[id] = append(byTicket[id], &items[i])
+		}
+
+		values := make([]interface{}, len(sources))
+		for i, source := range sources {
+			values[i] = byTicket[source.(*models.Ticket).ID]
+		}
+		return values, nil
+	}}
+}
+
+func resolveTicketProjects(ctx context.Context, sources []interface{}, _ graphql.Args) ([]interface{}, error) {
+	var ids []uuid.UUID
+	for _, source := range sources {
+		if id := source.(*models.Ticket).ProjectID; id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	projects, err := viewerFrom(ctx).loadProjects(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(sources))
+	for i, source := range sources {
+		if id := source.(*models.Ticket).ProjectID; id != nil {
+			values[i] = projects[*id]
+		}
+	}
+	return values, nil
+}
+
+func resolveMe(ctx context.Context, _ interface{}, _ graphql.Args) (interface{}, error) {
+	v := viewerFrom(ctx)
+	users, err := v.loadUsers(ctx, []uuid.UUID{v.userID})
+	if err != nil {
+		return nil, err
+	}
+	return users[v.userID], nil
+}
+
+func resolveUser(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+	id, err := uuidArg(args, "id")
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return nil, fmt.Errorf("id is required")
+	}
+	users, err := viewerFrom(ctx).loadUsers(ctx, []uuid.UUID{*id})
+	if err != nil {
+		return nil, err
+	}
+	return users[*id], nil
+}
+
+// resolveTicket looks a ticket up by ID or number. Tickets that don't
+// exist or that the caller may not see resolve to null.
+func resolveTicket(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+	v := viewerFrom(ctx)
+	id, err := uuidArg(args, "id")
+	if err != nil {
+		return nil, err
+	}
+	number, err := args.String("number")
+	if err != nil {
+		return nil, err
+	}
+	if (id == nil) == (number == "") {
+		return nil, fmt.Errorf("exactly one of id and number is required")
+	}
+
+	if id == nil {
+		t, err := v.store.Tickets.GetByNumber(ctx, v.orgID, number)
+		if err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		id = &t.ID
+	}
+
+	tickets, err := v.loadTickets(ctx, []uuid.UUID{*id})
+	if err != nil || len(tickets) == 0 {
+		return nil, err
+	}
+	return tickets[0], nil
+}
+
+// resolveTickets lists tickets with the same filters and cursor paging as
+// GET /v1/tickets
+func resolveTickets(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+	v := viewerFrom(ctx)
+	first, err := args.Int("first")
+	if err != nil {
+		return nil, err
+	}
+	if first < 1 || first > 100 {
+		return nil, fmt.Errorf("first must be between 1 and 100")
+	}
+
+	filter := &models.TicketListFilter{PerPage: first}
+	if filter.Cursor, err = args.String("after"); err != nil {
+		return nil, err
+	}
+	if filter.Search, err = args.String("search"); err != nil {
+		return nil, err
+	}
+	if filter.SortBy, err = args.String("sortBy"); err != nil {
+		return nil, err
+	}
+	if filter.SortOrder, err = args.String("sortOrder"); err != nil {
+		return nil, err
+	}
+	status, err := args.String("status")
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		filter.Status = []models.TicketStatus{models.TicketStatus(status)}
+	}
+	priority, err := args.String("priority")
+	if err != nil {
+		return nil, err
+	}
+	if priority != "" {
+		filter.Priority = []models.TicketPriority{models.TicketPriority(priority)}
+	}
+	if filter.ProjectID, err = uuidArg(args, "projectId"); err != nil {
+		return nil, err
+	}
+
+	// The list holds summary rows, so the page is reloaded in full
+	page, total, err := v.store.Tickets.List(ctx, v.orgID, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(page))
+	for i := range page {
+		ids[i] = page[i].ID
+	}
+	nodes, err := v.loadTickets(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &ticketConnection{nodes: nodes, totalCount: total}
+	if filter.NextCursor != "" {
+		conn.nextCursor = &filter.NextCursor
+	}
+	return conn, nil
+}
+
+func resolveProject(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+	v := viewerFrom(ctx)
+	id, err := uuidArg(args, "id")
+	if err != nil {
+		return nil, err
+	}
+	key, err := args.String("key")
+	if err != nil {
+		return nil, err
+	}
+	if (id == nil) == (key == "") {
+		return nil, fmt.Errorf("exactly one of id and key is required")
+	}
+
+	if id == nil {
+		p, err := v.store.Projects.GetByKey(ctx, v.orgID, key)
+		if err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		v.projects[p.ID] = p
+		return p, nil
+	}
+
+	projects, err := v.loadProjects(ctx, []uuid.UUID{*id})
+	if err != nil {
+		return nil, err
+	}
+	return projects[*id], nil
+}
+
+func resolveProjects(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+	v := viewerFrom(ctx)
+	activeOnly, err := args.Bool("activeOnly")
+	if err != nil {
+		return nil, err
+	}
+	projects, err := v.store.Projects.List(ctx, v.orgID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*models.Project, len(projects))
+	for i := range projects {
+		values[i] = &projects[i]
+	}
+	return values, nil
+}
+
+// uuidArg returns a UUID argument, or nil when it is null
+func uuidArg(args graphql.Args, name string) (*uuid.UUID, error) {
+	s, err := args.String(name)
+	if err != nil || s == "" {
+		return nil, err
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("argument %q must be a UUID", name)
+	}
+	return &id, nil
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
+	"github.com/afterdarksys/adsops-utils/internal/store"
 )
 
 // APIKeyHandler handles API key-related HTTP requests
@@ -105,12 +107,12 @@ func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
 		c.Request.Context(),
 		query,
 		userID, orgID, input.Name, keyHash, keyPrefix,
-		input.Scopes, expiresAt, c.ClientIP(),
+		pq.Array(input.Scopes), expiresAt, c.ClientIP(),
 	).Scan(&keyID, &createdAt)
 
 	if err != nil {
 		// Check if it's the 5-key limit error
-		if err.Error() == "pq: Maximum of 5 active API keys per user. Please revoke an existing key first." {
+		if store.ConstraintName(err) == "api_key_limit" {
 			c.JSON(http.StatusForbidden, gin.H{
 				"error": gin.H{
 					"code":    "KEY_LIMIT_EXCEEDED",
@@ -178,7 +180,7 @@ func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
 		var id string
 
 		err := rows.Scan(
-			&id, &key.Name, &key.KeyPrefix, (*StringArray)(&scopes),
+			&id, &key.Name, &key.KeyPrefix, pq.Array(&scopes),
 			&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt,
 			&key.UsageCount, &key.IsActive,
 		)
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// ApprovalHandler handles approval HTTP requests, both from logged-in
+// approvers and from the links in approval request emails
+type ApprovalHandler struct {
+	store *store.Store
+}
+
+// NewApprovalHandler creates a new approval handler
+func NewApprovalHandler(s *store.Store) *ApprovalHandler {
+	return &ApprovalHandler{store: s}
+}
+
+// ListApprovals handles GET /api/v1/approvals, the caller's approvals.
+// status and approval_type may be repeated or comma-separated.
+func (h *ApprovalHandler) ListApprovals(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var filter models.ApprovalListFilter
+	for _, value := range c.QueryArray("status") {
+		for _, v := range splitQueryList(value) {
+			st := models.ApprovalStatus(v)
+			if !st.Valid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status: " + v})
+				return
+			}
+			filter.Status = append(filter.Status, st)
+		}
+	}
+	for _, value := range c.QueryArray("approval_type") {
+		for _, v := range splitQueryList(value) {
+			at := models.ApprovalType(v)
+			if !at.Valid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval type: " + v})
+				return
+			}
+			filter.ApprovalType = append(filter.ApprovalType, at)
+		}
+	}
+	if value := c.Query("ticket_id"); value != "" {
+		ticketID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket_id"})
+			return
+		}
+		filter.TicketID = &ticketID
+	}
+	var err error
+	if filter.Page, err = parseIntQuery(c, "page", 1); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.PerPage, err = parseIntQuery(c, "per_page", 50); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	approvals, total, err := h.store.Approvals.ListForApprover(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if approvals == nil {
+		approvals = []models.ApprovalSummary{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": approvals,
+		"total":     total,
+		"page":      filter.Page,
+		"per_page":  filter.PerPage,
+	})
+}
+
+// GetApproval handles GET /api/v1/approvals/:id. Approvals on tickets the
+// caller may not see are reported as missing, unless assigned to them.
+func (h *ApprovalHandler) GetApproval(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	approvalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval ID"})
+		return
+	}
+
+	approval, err := h.store.Approvals.Get(ctx, orgID.(uuid.UUID), approvalID)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if approval.ApproverID != userID.(uuid.UUID) {
+		if _, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), approval.TicketID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "approval not found"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"approval": approval})
+}
+
+// Approve handles POST /api/v1/approvals/:id/approve. Approving a
+// critical-risk change needs a recent MFA verification.
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	var input models.ApproveInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	h.decide(c, input.Decision())
+}
+
+// Deny handles POST /api/v1/approvals/:id/deny
+func (h *ApprovalHandler) Deny(c *gin.Context) {
+	var input models.DenyInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	h.decide(c, input.Decision())
+}
+
+// RequestUpdate handles POST /api/v1/approvals/:id/request-update, sending
+// the ticket back to its creator for changes
+func (h *ApprovalHandler) RequestUpdate(c *gin.Context) {
+	var input models.RequestUpdateInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	h.decide(c, input.Decision())
+}
+
+// decide records the caller's decision on the approval in the path
+func (h *ApprovalHandler) decide(c *gin.Context, decision models.ApprovalDecision) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	approvalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval ID"})
+		return
+	}
+
+	if decision.Status == models.ApprovalStatusApproved {
+		approval, err := h.store.Approvals.Get(ctx, orgID.(uuid.UUID), approvalID)
+		if err != nil {
+			c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		ticket, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), approval.TicketID)
+		if err == nil && ticket.ApprovalRequiresStepUp() && !requireStepUp(c) {
+			return
+		}
+	}
+
+	ip, userAgent := c.ClientIP(), c.Request.UserAgent()
+	decision.IP, decision.UserAgent = &ip, &userAgent
+	approval, transition, err := h.store.Approvals.Decide(ctx, orgID.(uuid.UUID), approvalID, userID.(uuid.UUID), &decision)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondDecided(c, approval, transition)
+}
+
+// GetApprovalByToken handles GET /api/v1/approvals/token/:token, showing
+// the approval an email link decides and its ticket
+func (h *ApprovalHandler) GetApprovalByToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	approval, err := h.store.Approvals.GetByToken(ctx, c.Param("token"))
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := h.store.Tickets.GetByID(ctx, approval.OrganizationID, approval.TicketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"approval": approval,
+		"ticket":   ticket.ToSummary(),
+	})
+}
+
+// ApproveByToken handles POST /api/v1/approvals/token/:token/approve. The
+// body is optional. Critical-risk changes can't be approved from a link,
+// since that skips the MFA verification approving them needs.
+func (h *ApprovalHandler) ApproveByToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var input models.ApproveInput
+	if c.Request.ContentLength != 0 && !bindJSON(c, &input) {
+		return
+	}
+
+	approval, err := h.store.Approvals.GetByToken(ctx, c.Param("token"))
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ticket, err := h.store.Tickets.GetByID(ctx, approval.OrganizationID, approval.TicketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if ticket.ApprovalRequiresStepUp() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "critical-risk changes must be approved after logging in with MFA",
+			"code":  "STEP_UP_REQUIRED",
+		})
+		return
+	}
+
+	decision := input.Decision()
+	ip, userAgent := c.ClientIP(), c.Request.UserAgent()
+	decision.IP, decision.UserAgent = &ip, &userAgent
+	approval, transition, err := h.store.Approvals.Decide(ctx, approval.OrganizationID, approval.ID, approval.ApproverID, &decision)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondDecided(c, approval, transition)
+}
+
+// DenyByToken handles POST /api/v1/approvals/token/:token/deny
+func (h *ApprovalHandler) DenyByToken(c *gin.Context) {
+	var input models.DenyInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	decision := input.Decision()
+	ip, userAgent := c.ClientIP(), c.Request.UserAgent()
+	decision.IP, decision.UserAgent = &ip, &userAgent
+	approval, transition, err := h.store.Approvals.DecideByToken(c.Request.Context(), c.Param("token"), &decision)
+	if err != nil {
+		c.JSON(approvalErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondDecided(c, approval, transition)
+}
+
+// respondDecided records a decision in the audit log, along with the
+// ticket status change it caused, and returns the decided approval
+func (h *ApprovalHandler) respondDecided(c *gin.Context, approval *models.Approval, transition *models.TicketTransition) {
+	ip, userAgent := c.ClientIP(), c.Request.UserAgent()
+	h.store.Audit.LogTicketAccess(c.Request.Context(), approval.TicketID, approval.ApproverID, approvalAuditAction(approval.Status), &ip, &userAgent, map[string]interface{}{
+		"approval_id":   approval.ID,
+		"approval_type": approval.ApprovalType,
+		"comment":       approval.DecisionComment,
+		"conditions":    approval.Conditions,
+	})
+
+	response := gin.H{"approval": approval}
+	if transition != nil {
+		logTransition(c, h.store, approval.OrganizationID, approval.TicketID, approval.ApproverID, transition)
+		response["ticket_status"] = transition.To
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func approvalAuditAction(status models.ApprovalStatus) string {
+	switch status {
+	case models.ApprovalStatusDenied:
+		return models.AuditActionDeny
+	case models.ApprovalStatusUpdateRequested:
+		return models.AuditActionRequestUpdate
+	}
+	return models.AuditActionApprove
+}
+
+// approvalErrorStatus maps approval store errors to HTTP status codes
+func approvalErrorStatus(err error) int {
+	if errors.Is(err, models.ErrApprovalLinkExpired) {
+		return http.StatusGone
+	}
+	return storeErrorStatus(err, http.StatusInternalServerError)
+}
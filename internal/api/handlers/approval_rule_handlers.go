@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// ApprovalRuleHandler handles approval rule HTTP requests
+type ApprovalRuleHandler struct {
+	store *store.Store
+}
+
+// NewApprovalRuleHandler creates a new approval rule handler
+func NewApprovalRuleHandler(s *store.Store) *ApprovalRuleHandler {
+	return &ApprovalRuleHandler{store: s}
+}
+
+// ListRules handles GET /api/v1/approval-rules
+func (h *ApprovalRuleHandler) ListRules(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	rules, err := h.store.ApprovalRules.List(c.Request.Context(), orgID.(uuid.UUID), c.Query("active") == "true")
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// CreateRule handles POST /api/v1/approval-rules
+func (h *ApprovalRuleHandler) CreateRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateApprovalRuleInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.store.ApprovalRules.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rule": rule,
+	})
+}
+
+// GetRule handles GET /api/v1/approval-rules/:id
+func (h *ApprovalRuleHandler) GetRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	rule, err := h.store.ApprovalRules.GetByID(c.Request.Context(), orgID.(uuid.UUID), ruleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "approval rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule": rule,
+	})
+}
+
+// UpdateRule handles PATCH /api/v1/approval-rules/:id
+func (h *ApprovalRuleHandler) UpdateRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	var input models.UpdateApprovalRuleInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	// Validate the merged result so a partial update cannot leave the rule inconsistent
+	current, err := h.store.ApprovalRules.GetByID(c.Request.Context(), orgID.(uuid.UUID), ruleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "approval rule not found"})
+		return
+	}
+	merged := models.CreateApprovalRuleInput{
+		Name:         current.Name,
+		Requirements: current.Requirements,
+		AutoApprove:  current.AutoApprove,
+	}
+	if input.Requirements != nil {
+		merged.Requirements = input.Requirements
+	}
+	if input.AutoApprove != nil {
+		merged.AutoApprove = *input.AutoApprove
+	}
+	if err := merged.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.store.ApprovalRules.Update(c.Request.Context(), orgID.(uuid.UUID), ruleID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule": rule,
+	})
+}
+
+// DeleteRule handles DELETE /api/v1/approval-rules/:id
+func (h *ApprovalRuleHandler) DeleteRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	if err := h.store.ApprovalRules.Delete(c.Request.Context(), orgID.(uuid.UUID), ruleID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Approval rule deleted",
+	})
+}
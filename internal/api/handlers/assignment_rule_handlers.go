@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/queuebot"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// AssignmentRuleHandler handles assignment rule HTTP requests
+type AssignmentRuleHandler struct {
+	store *store.Store
+}
+
+// NewAssignmentRuleHandler creates a new assignment rule handler
+func NewAssignmentRuleHandler(s *store.Store) *AssignmentRuleHandler {
+	return &AssignmentRuleHandler{store: s}
+}
+
+// ListRules handles GET /api/v1/assignment-rules
+func (h *AssignmentRuleHandler) ListRules(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	rules, err := h.store.AssignmentRules.List(c.Request.Context(), orgID.(uuid.UUID), c.Query("active") == "true")
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// CreateRule handles POST /api/v1/assignment-rules
+func (h *AssignmentRuleHandler) CreateRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateAssignmentRuleInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.store.AssignmentRules.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rule": rule,
+	})
+}
+
+// GetRule handles GET /api/v1/assignment-rules/:id
+func (h *AssignmentRuleHandler) GetRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	rule, err := h.store.AssignmentRules.GetByID(c.Request.Context(), orgID.(uuid.UUID), ruleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "assignment rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule": rule,
+	})
+}
+
+// UpdateRule handles PATCH /api/v1/assignment-rules/:id
+func (h *AssignmentRuleHandler) UpdateRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	var input models.UpdateAssignmentRuleInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.store.AssignmentRules.Update(c.Request.Context(), orgID.(uuid.UUID), ruleID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule": rule,
+	})
+}
+
+// DeleteRule handles DELETE /api/v1/assignment-rules/:id
+func (h *AssignmentRuleHandler) DeleteRule(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule ID"})
+		return
+	}
+
+	if err := h.store.AssignmentRules.Delete(c.Request.Context(), orgID.(uuid.UUID), ruleID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Assignment rule deleted",
+	})
+}
+
+// RunQueue handles POST /api/v1/assignment-rules/run. The queue bot applies
+// the active rules to the current queue straight away instead of waiting
+// for the worker's next pass.
+func (h *AssignmentRuleHandler) RunQueue(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	results, err := queuebot.New(h.store).Run(c.Request.Context(), orgID.(uuid.UUID), models.AssignmentTriggerManual)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	assigned := 0
+	for _, r := range results {
+		if r.AssignedTo != nil {
+			assigned++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":    results,
+		"assigned":   assigned,
+		"unassigned": len(results) - assigned,
+	})
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/objectstore"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// AttachmentStorage keeps attached files and signs links to download them
+type AttachmentStorage interface {
+	PutObject(ctx context.Context, obj *objectstore.Object) error
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// multipartOverhead is room for the multipart boundaries and headers around
+// an uploaded file
+const multipartOverhead = 1 << 20
+
+// AttachmentHandler handles ticket attachment HTTP requests
+type AttachmentHandler struct {
+	store    *store.Store
+	files    AttachmentStorage
+	prefix   string
+	maxBytes int64
+	linkTTL  time.Duration
+}
+
+// NewAttachmentHandler creates a new attachment handler. Without files,
+// attachment storage is not configured and nothing can be uploaded.
+func NewAttachmentHandler(s *store.Store, files AttachmentStorage, cfg *config.Config) *AttachmentHandler {
+	maxBytes := int64(cfg.Attachments.MaxSizeMB) << 20
+	if maxBytes <= 0 {
+		maxBytes = models.DefaultMaxAttachmentBytes
+	}
+	linkTTL := time.Duration(cfg.Attachments.LinkTTLMinutes) * time.Minute
+	if linkTTL <= 0 || linkTTL > models.MaxAttachmentLinkTTL {
+		linkTTL = time.Hour
+	}
+	return &AttachmentHandler{
+		store:    s,
+		files:    files,
+		prefix:   strings.Trim(cfg.Attachments.Prefix, "/"),
+		maxBytes: maxBytes,
+		linkTTL:  linkTTL,
+	}
+}
+
+// ListAttachments handles GET /api/v1/tickets/:id/attachments. Each
+// attachment comes with a freshly signed download link.
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	if _, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID); err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	attachments, err := h.store.Attachments.ListByTicket(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	for i := range attachments {
+		if err := h.sign(&attachments[i]); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments":    attachments,
+		"count":          len(attachments),
+		"max_size_bytes": h.maxBytes,
+	})
+}
+
+// UploadAttachment handles POST /api/v1/tickets/:id/attachments with the
+// file in the multipart "file" field
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	if h.files == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "attachment storage is not configured"})
+		return
+	}
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Refuse what is plainly too large before reading any of it
+	if c.Request.ContentLength > h.maxBytes+multipartOverhead {
+		h.respondTooLarge(c)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.respondTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected the file in a multipart \"file\" field"})
+		return
+	}
+	defer file.Close()
+	if c.Request.MultipartForm != nil {
+		defer c.Request.MultipartForm.RemoveAll()
+	}
+	if header.Size > h.maxBytes {
+		h.respondTooLarge(c)
+		return
+	}
+
+	filename, err := models.CleanAttachmentName(header.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(path.Ext(filename)); byExt != "" {
+			contentType = byExt
+		} else {
+			contentType = "application/octet-stream"
+		}
+	}
+
+	// The object store signs the body's SHA-256, so hash the file first
+	sha := sha256.New()
+	sum := md5.New()
+	size, err := io.Copy(io.MultiWriter(sha, sum), file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file: " + err.Error()})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rewind uploaded file: " + err.Error()})
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	attachment := &models.Attachment{
+		ID:             uuid.New(),
+		OrganizationID: orgID.(uuid.UUID),
+		TicketID:       ticketID,
+		Filename:       filename,
+		ContentType:    contentType,
+		SizeBytes:      size,
+		SHA256:         hex.EncodeToString(sha.Sum(nil)),
+		UploadedBy:     &uid,
+	}
+	attachment.ObjectKey = fmt.Sprintf("%s/%s/%s/%s", h.prefix, attachment.OrganizationID, ticketID, attachment.ID)
+	attachment.ObjectKey = strings.TrimPrefix(attachment.ObjectKey, "/")
+
+	err = h.files.PutObject(c.Request.Context(), &objectstore.Object{
+		Key:         attachment.ObjectKey,
+		Body:        file,
+		Size:        size,
+		SHA256:      attachment.SHA256,
+		ContentMD5:  base64.StdEncoding.EncodeToString(sum.Sum(nil)),
+		ContentType: contentType,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to store attachment: " + err.Error()})
+		return
+	}
+
+	created, err := h.store.Attachments.Create(c.Request.Context(), attachment)
+	if err != nil {
+		// Don't leave an object nothing refers to
+		h.files.DeleteObject(context.Background(), attachment.ObjectKey)
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.sign(created); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceTicket,
+		ResourceID:   &ticketID,
+		Description:  fmt.Sprintf("Attached %s (%d bytes) to %s", created.Filename, created.SizeBytes, ticket.TicketNumber),
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"attachment": created,
+	})
+}
+
+// sign fills in an attachment's download link. Without storage there is
+// nothing to link to.
+func (h *AttachmentHandler) sign(a *models.Attachment) error {
+	if h.files == nil {
+		return nil
+	}
+	link, err := h.files.PresignGet(a.ObjectKey, a.Filename, h.linkTTL)
+	if err != nil {
+		return fmt.Errorf("failed to sign download link: %w", err)
+	}
+	expires := time.Now().Add(h.linkTTL).UTC()
+	a.DownloadURL = link
+	a.DownloadExpiresAt = &expires
+	return nil
+}
+
+func (h *AttachmentHandler) respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":          fmt.Sprintf("file too large: attachments can be at most %d MB", h.maxBytes>>20),
+		"max_size_bytes": h.maxBytes,
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/audit"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// auditExportFlushRows is how many rows are written between flushes to the client
+const auditExportFlushRows = 500
+
+// AuditHandler handles audit log HTTP requests
+type AuditHandler struct {
+	store *store.Store
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(s *store.Store) *AuditHandler {
+	return &AuditHandler{store: s}
+}
+
+// ExportAuditLog handles GET /api/v1/audit/export (and /api/v1/reports/audit)
+// Streams ticket audit log entries as CSV, NDJSON or CEF.
+//
+// Query parameters: format, from, to, framework (comma-separated), category,
+// compliance_only, emergency_only, max_rows. Requests matching more rows than
+// max_rows are rejected unless truncate=true.
+func (h *AuditHandler) ExportAuditLog(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	format := audit.Format(strings.ToLower(c.DefaultQuery("format", string(audit.FormatCSV))))
+	if !format.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of csv, ndjson, cef"})
+		return
+	}
+
+	filter := &models.AuditExportFilter{}
+	var err error
+	if filter.FromDate, err = parseTimeQuery(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.ToDate, err = parseTimeQuery(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if frameworks := c.Query("framework"); frameworks != "" {
+		for _, f := range strings.Split(frameworks, ",") {
+			framework := models.ComplianceFramework(strings.TrimSpace(f))
+			if !framework.Valid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid compliance framework: " + string(framework)})
+				return
+			}
+			filter.Frameworks = append(filter.Frameworks, framework)
+		}
+	}
+	if category := c.Query("category"); category != "" {
+		filter.ActionCategory = &category
+	}
+	filter.ComplianceOnly = c.Query("compliance_only") == "true"
+	filter.EmergencyOnly = c.Query("emergency_only") == "true"
+	if filter.MaxRows, err = parseIntQuery(c, "max_rows", models.MaxAuditExportRows); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.SetDefaults()
+
+	total, err := h.store.Audit.CountForExport(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if total > filter.MaxRows && c.Query("truncate") != "true" {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":    fmt.Sprintf("export matches %d rows, more than the maximum of %d; narrow the date range or pass truncate=true", total, filter.MaxRows),
+			"total":    total,
+			"max_rows": filter.MaxRows,
+		})
+		return
+	}
+
+	// Record the export itself before any data leaves the system
+	uid := userID.(uuid.UUID)
+	metadata, _ := json.Marshal(gin.H{"format": format, "filter": filter, "rows": total})
+	ip := net.ParseIP(c.ClientIP())
+	userAgent := c.Request.UserAgent()
+	h.store.Audit.Log(c.Request.Context(), orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:               &uid,
+		Action:               models.AuditActionExport,
+		ResourceType:         models.AuditResourceReport,
+		Description:          "Exported ticket audit log",
+		Metadata:             metadata,
+		IPAddress:            &ip,
+		UserAgent:            &userAgent,
+		ComplianceRelevant:   true,
+		ComplianceFrameworks: filter.Frameworks,
+	})
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102-150405"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.Status(http.StatusOK)
+
+	enc, err := audit.NewEncoder(format, c.Writer)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	written := 0
+	// The export streams for as long as the client reads, so it runs
+	// with the long-running statement timeout
+	err = h.store.Audit.StreamForExport(h.store.LongRunning(c.Request.Context()), orgID.(uuid.UUID), filter, func(entry *models.TicketAuditLog) error {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+		written++
+		if written%auditExportFlushRows == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	enc.Flush()
+	c.Writer.Flush()
+
+	// Headers are already sent, so a mid-stream failure can only be recorded
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// recordAudit writes an organization-level audit entry, filling in the
+// caller's IP address and user agent from the request
+func recordAudit(c *gin.Context, s *store.Store, orgID uuid.UUID, input *models.CreateAuditLogInput) {
+	ip := net.ParseIP(c.ClientIP())
+	userAgent := c.Request.UserAgent()
+	input.IPAddress = &ip
+	input.UserAgent = &userAgent
+	s.Audit.Log(c.Request.Context(), orgID, input)
+}
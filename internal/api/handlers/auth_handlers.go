@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/auth"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+	"github.com/afterdarksys/adsops-utils/internal/webauthn"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthHandler handles login, MFA and passkey HTTP requests
+type AuthHandler struct {
+	store *store.Store
+	cfg   *config.Config
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(s *store.Store, cfg *config.Config) *AuthHandler {
+	return &AuthHandler{store: s, cfg: cfg}
+}
+
+// relyingParty returns the site passkeys are bound to, falling back to the
+// host of the configured base URL
+func (h *AuthHandler) relyingParty() (*webauthn.RelyingParty, error) {
+	rpID := h.cfg.WebAuthn.RPID
+	origins := h.cfg.WebAuthn.GetOrigins()
+	if base, err := url.Parse(h.cfg.Email.BaseURL); err == nil && base.Host != "" {
+		if rpID == "" {
+			rpID = base.Hostname()
+		}
+		if len(origins) == 0 {
+			origins = []string{base.Scheme + "://" + base.Host}
+		}
+	}
+	if rpID == "" || len(origins) == 0 {
+		return nil, errors.New("passkeys are not configured")
+	}
+	return &webauthn.RelyingParty{ID: rpID, Name: h.cfg.WebAuthn.RPName, Origins: origins}, nil
+}
+
+// Login handles POST /api/v1/auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var input models.LoginInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.store.Auth.FindLoginUsers(c.Request.Context(), input.Email, input.Organization)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	// Check the password against every account with this email so the
+	// organization is only needed when it is actually ambiguous
+	var matched []*models.LoginUser
+	for i := range users {
+		u := &users[i]
+		if u.PasswordHash != nil && bcrypt.CompareHashAndPassword([]byte(*u.PasswordHash), []byte(input.Password)) == nil {
+			matched = append(matched, u)
+		}
+	}
+
+	switch {
+	case len(matched) == 0:
+		if len(users) == 1 {
+			h.recordLoginFailure(c, &users[0], models.AuthMethodPassword, "invalid password")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	case len(matched) > 1:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email belongs to several organizations; specify organization"})
+		return
+	}
+
+	h.completeLogin(c, matched[0], models.AuthMethodPassword)
+}
+
+// LoginPasskeyBegin handles POST /api/v1/auth/login/passkey/begin
+func (h *AuthHandler) LoginPasskeyBegin(c *gin.Context) {
+	var input models.PasskeyLoginBeginInput
+	if c.Request.ContentLength != 0 {
+		if !bindJSON(c, &input) {
+			return
+		}
+	}
+
+	rp, err := h.relyingParty()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	// With an email, limit the ceremony to that account's passkeys, or point
+	// the client at password login if it has none
+	var userID *uuid.UUID
+	var allowed []webauthn.CredentialDescriptor
+	if input.Email != "" {
+		users, err := h.store.Auth.FindLoginUsers(c.Request.Context(), input.Email, input.Organization)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		for i := range users {
+			for _, cred := range users[i].Credentials {
+				allowed = append(allowed, webauthn.CredentialDescriptor{Type: "public-key", ID: cred.ID, Transports: cred.Transports})
+			}
+		}
+		if len(allowed) == 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"passkey_available": false,
+				"fallback":          "password",
+			})
+			return
+		}
+		if len(users) == 1 {
+			userID = &users[0].ID
+		}
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	challengeID, err := h.store.Auth.CreateChallenge(c.Request.Context(), models.AuthChallengePasskeyLogin, userID, challenge, nil, models.PasskeyChallengeTTL)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"passkey_available": true,
+		"challenge_id":      challengeID,
+		"options":           rp.RequestOptions(challenge, allowed),
+	})
+}
+
+// LoginPasskeyFinish handles POST /api/v1/auth/login/passkey/finish
+func (h *AuthHandler) LoginPasskeyFinish(c *gin.Context) {
+	var input struct {
+		ChallengeID uuid.UUID                  `json:"challenge_id" binding:"required"`
+		Credential  webauthn.AssertionResponse `json:"credential" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	rp, err := h.relyingParty()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.store.Auth.ConsumeChallenge(c.Request.Context(), input.ChallengeID, models.AuthChallengePasskeyLogin)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Auth.FindPasskeyUser(c.Request.Context(), input.Credential.ID)
+	if err != nil || (challenge.UserID != nil && *challenge.UserID != user.ID) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "passkey not recognized"})
+		return
+	}
+	cred := user.Credential(input.Credential.ID)
+
+	signCount, err := rp.VerifyAssertion(challenge.Challenge, &input.Credential, cred.PublicKey, cred.SignCount)
+	if err != nil {
+		h.recordLoginFailure(c, user, models.AuthMethodPasskey, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "passkey verification failed"})
+		return
+	}
+
+	if err := h.store.Auth.RecordPasskeyUse(c.Request.Context(), user.ID, cred.ID, signCount); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.issueTokens(c, user, models.AuthMethodPasskey)
+}
+
+// completeLogin finishes a sign-in after its first factor. Users with MFA
+// get a pending login to complete with a code; everyone else gets tokens.
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.LoginUser, firstFactor string) {
+	if !user.MFAEnabled {
+		h.issueTokens(c, user, firstFactor)
+		return
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	data, _ := json.Marshal(gin.H{"method": firstFactor})
+	mfaToken, err := h.store.Auth.CreateChallenge(c.Request.Context(), models.AuthChallengeMFA, &user.ID, challenge, data, models.MFAChallengeTTL)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mfa_required": true,
+		"mfa_token":    mfaToken,
+		"expires_in":   int(models.MFAChallengeTTL.Seconds()),
+	})
+}
+
+// issueTokens opens a session for an authenticated user and responds with
+// its access token
+func (h *AuthHandler) issueTokens(c *gin.Context, user *models.LoginUser, method string) {
+	tokens, err := h.openSession(c, user, method)
+	if errors.Is(err, auth.ErrNotConfigured) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// openSession records a new session and signs its access token. Passkeys
+// and MFA count as a fresh MFA verification; any other single factor gets
+// an enrollment-only token when the user is required to use MFA. Customer
+// portal users get portal-only tokens.
+func (h *AuthHandler) openSession(c *gin.Context, user *models.LoginUser, method string) (*models.AuthTokens, error) {
+	now := time.Now()
+	sessionID := uuid.New()
+	claims := &auth.Claims{
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		Roles:          user.Roles,
+		SessionID:      sessionID,
+		AuthMethod:     method,
+	}
+	if models.StrongAuthMethod(method) {
+		claims.MFAAt = now.Unix()
+	} else if user.MFARequired() {
+		claims.Scope = auth.ScopeMFAEnrollment
+	}
+	if user.CustomerID != nil {
+		claims.CustomerID = user.CustomerID
+		if claims.Scope == "" {
+			claims.Scope = auth.ScopeCustomerPortal
+		}
+	}
+
+	token, err := auth.Sign(&h.cfg.JWT, claims, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.store.Auth.CreateSession(c.Request.Context(), sessionID, user, token,
+		net.ParseIP(c.ClientIP()), c.Request.UserAgent(), claims.Expiry())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, _ := json.Marshal(gin.H{"method": method, "scope": claims.Scope})
+	recordAudit(c, h.store, user.OrganizationID, &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Username:     &user.Email,
+		Action:       models.AuditActionLogin,
+		ResourceType: models.AuditResourceSession,
+		ResourceID:   &sessionID,
+		Description:  "Signed in with " + method,
+		Metadata:     metadata,
+	})
+
+	return &models.AuthTokens{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(claims.Expiry().Sub(now).Seconds()),
+		ExpiresAt:   claims.Expiry(),
+		AuthMethod:  method,
+		Scope:       claims.Scope,
+		User: models.UserSummary{
+			ID:       user.ID,
+			Email:    user.Email,
+			FullName: user.FullName,
+		},
+	}, nil
+}
+
+func (h *AuthHandler) recordLoginFailure(c *gin.Context, user *models.LoginUser, method, reason string) {
+	metadata, _ := json.Marshal(gin.H{"method": method, "reason": reason})
+	recordAudit(c, h.store, user.OrganizationID, &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Username:     &user.Email,
+		Action:       models.AuditActionLoginFailed,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Description:  "Failed sign-in with " + method,
+		Metadata:     metadata,
+	})
+}
+
+// BeginPasskeyRegistration handles POST /api/v1/auth/passkeys/register/begin
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	rp, err := h.relyingParty()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Auth.GetLoginUser(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if len(user.Credentials) >= models.MaxPasskeysPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "passkey limit reached"})
+		return
+	}
+
+	existing := make([]webauthn.CredentialDescriptor, 0, len(user.Credentials))
+	for _, cred := range user.Credentials {
+		existing = append(existing, webauthn.CredentialDescriptor{Type: "public-key", ID: cred.ID, Transports: cred.Transports})
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	challengeID, err := h.store.Auth.CreateChallenge(c.Request.Context(), models.AuthChallengePasskeyRegistration, &user.ID, challenge, nil, models.PasskeyChallengeTTL)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge_id": challengeID,
+		"options": rp.CreationOptions(challenge, webauthn.User{
+			ID:          user.ID[:],
+			Name:        user.Email,
+			DisplayName: user.FullName,
+		}, existing),
+	})
+}
+
+// FinishPasskeyRegistration handles POST /api/v1/auth/passkeys/register/finish
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input struct {
+		models.PasskeyRegistrationInput
+		Credential webauthn.AttestationResponse `json:"credential" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rp, err := h.relyingParty()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.store.Auth.ConsumeChallenge(c.Request.Context(), input.ChallengeID, models.AuthChallengePasskeyRegistration)
+	if err != nil || challenge.UserID == nil || *challenge.UserID != userID.(uuid.UUID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "challenge not found or expired"})
+		return
+	}
+
+	verified, err := rp.VerifyRegistration(challenge.Challenge, &input.Credential)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cred := &models.WebAuthnCredential{
+		ID:             verified.ID,
+		Name:           input.Name,
+		PublicKey:      verified.PublicKey,
+		Algorithm:      verified.Algorithm,
+		SignCount:      verified.SignCount,
+		AAGUID:         verified.AAGUID,
+		Transports:     verified.Transports,
+		BackupEligible: verified.BackupEligible,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := h.store.Auth.AddPasskey(c.Request.Context(), userID.(uuid.UUID), cred); err != nil {
+		if errors.Is(err, models.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	metadata, _ := json.Marshal(gin.H{"passkey_id": cred.ID, "name": cred.Name})
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionPasskeyRegister,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &uid,
+		Description:  "Registered passkey " + cred.Name,
+		Metadata:     metadata,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"passkey": cred.ToPasskey(),
+	})
+}
+
+// ListPasskeys handles GET /api/v1/auth/passkeys
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	h.listPasskeys(c, orgID.(uuid.UUID), userID.(uuid.UUID))
+}
+
+// DeletePasskey handles DELETE /api/v1/auth/passkeys/:credential_id
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	h.removePasskey(c, orgID.(uuid.UUID), userID.(uuid.UUID), userID.(uuid.UUID))
+}
+
+// ListUserPasskeys handles GET /api/v1/users/:id/passkeys
+func (h *AuthHandler) ListUserPasskeys(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	h.listPasskeys(c, orgID.(uuid.UUID), targetID)
+}
+
+// RevokeUserPasskey handles DELETE /api/v1/users/:id/passkeys/:credential_id
+func (h *AuthHandler) RevokeUserPasskey(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	h.removePasskey(c, orgID.(uuid.UUID), userID.(uuid.UUID), targetID)
+}
+
+func (h *AuthHandler) listPasskeys(c *gin.Context, orgID, userID uuid.UUID) {
+	creds, err := h.store.Auth.ListPasskeys(c.Request.Context(), orgID, userID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	passkeys := make([]models.Passkey, 0, len(creds))
+	for i := range creds {
+		passkeys = append(passkeys, creds[i].ToPasskey())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"passkeys": passkeys,
+		"count":    len(passkeys),
+	})
+}
+
+func (h *AuthHandler) removePasskey(c *gin.Context, orgID, actorID, targetID uuid.UUID) {
+	credentialID := c.Param("credential_id")
+	if err := h.store.Auth.RemovePasskey(c.Request.Context(), orgID, targetID, credentialID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	metadata, _ := json.Marshal(gin.H{"passkey_id": credentialID})
+	recordAudit(c, h.store, orgID, &models.CreateAuditLogInput{
+		UserID:       &actorID,
+		Action:       models.AuditActionPasskeyRevoke,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  "Revoked passkey",
+		Metadata:     metadata,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Passkey revoked",
+	})
+}
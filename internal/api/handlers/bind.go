@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// InvalidParam is one field error in a problem response
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+// payloadValidator returns the validator for the validate tags on request
+// inputs. Gin's own validator, which checks binding tags, is set to report
+// JSON field names as well.
+func payloadValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.SetTagName("validate")
+		validate.RegisterTagNameFunc(jsonFieldName)
+		if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			engine.RegisterTagNameFunc(jsonFieldName)
+		}
+	})
+	return validate
+}
+
+// bindJSON decodes the request body into obj and enforces its validate
+// tags. On failure it writes an RFC 7807 problem response listing the
+// offending fields and returns false.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	v := payloadValidator()
+
+	if err := c.ShouldBindJSON(obj); err != nil {
+		respondInvalidPayload(c, err)
+		return false
+	}
+	if err := v.Struct(obj); err != nil {
+		respondInvalidPayload(c, err)
+		return false
+	}
+	return true
+}
+
+func respondInvalidPayload(c *gin.Context, err error) {
+	detail := "request body is invalid"
+	var params []InvalidParam
+
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			params = append(params, InvalidParam{Name: fieldPath(fe), Reason: fieldReason(fe)})
+		}
+		detail = "request body failed validation"
+	case errors.Is(err, io.EOF):
+		detail = "request body is required"
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		detail = "request body is not valid JSON"
+	case errors.As(err, &typeErr):
+		params = append(params, InvalidParam{Name: typeErr.Field, Reason: "must be of type " + jsonTypeName(typeErr.Type)})
+		detail = "request body has a field of the wrong type"
+	default:
+		// Custom decoders such as enum and timestamp fields
+		detail = err.Error()
+	}
+
+	// error mirrors detail so clients reading the usual error field keep working
+	c.Header("Content-Type", "application/problem+json")
+	c.JSON(http.StatusBadRequest, gin.H{
+		"type":           "about:blank",
+		"title":          "Invalid request payload",
+		"status":         http.StatusBadRequest,
+		"detail":         detail,
+		"invalid_params": params,
+		"error":          detail,
+	})
+}
+
+// fieldPath returns the JSON path of a failed field, without the struct name
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return fe.Field()
+}
+
+func fieldReason(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "uppercase":
+		return "must be uppercase"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(param, " ", ", ")
+	case "min", "max", "gt", "gte", "lt", "lte":
+		return boundReason(fe.Tag(), param, fe.Kind())
+	}
+	return "failed the " + fe.Tag() + " check"
+}
+
+func boundReason(tag, param string, kind reflect.Kind) string {
+	var op string
+	switch tag {
+	case "min", "gte":
+		op = "at least"
+	case "max", "lte":
+		op = "at most"
+	case "gt":
+		op = "greater than"
+	case "lt":
+		op = "less than"
+	}
+
+	switch kind {
+	case reflect.String:
+		return "must be " + op + " " + param + " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "must have " + op + " " + param + " items"
+	}
+	return "must be " + op + " " + param
+}
+
+func jsonFieldName(f reflect.StructField) string {
+	name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
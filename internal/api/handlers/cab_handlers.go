@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/notifications"
+	"github.com/afterdarksys/adsops-utils/internal/pdf"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// CABHandler handles change management board agenda and meeting HTTP requests
+type CABHandler struct {
+	store *store.Store
+	cfg   *config.Config
+}
+
+// NewCABHandler creates a new CAB handler
+func NewCABHandler(s *store.Store, cfg *config.Config) *CABHandler {
+	return &CABHandler{store: s, cfg: cfg}
+}
+
+// GetAgenda handles GET /api/v1/cab/agenda?date=. The agenda lists tickets
+// submitted by the end of the meeting date that still need the board's
+// approval, grouped by risk and by affected system.
+func (h *CABHandler) GetAgenda(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	ctx := c.Request.Context()
+
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := c.Query("date"); value != "" {
+		d, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date: expected YYYY-MM-DD"})
+			return
+		}
+		date = d
+	}
+
+	tickets, err := h.store.CAB.ListAwaiting(ctx, orgID.(uuid.UUID), date.Add(24*time.Hour))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	ids := make([]uuid.UUID, len(tickets))
+	for i := range tickets {
+		ids[i] = tickets[i].ID
+	}
+	var approvals []models.Approval
+	if len(ids) > 0 {
+		approvals, err = h.store.Approvals.ListByTickets(ctx, orgID.(uuid.UUID), ids)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"agenda": models.BuildCABAgenda(date, tickets, approvals)})
+}
+
+// RecordMeeting handles POST /api/v1/cab/meetings. Each decision is recorded
+// as the caller's change management board approval and the ticket moves to
+// the status its approvals imply. Decisions are applied independently: one
+// that fails is reported in its result and doesn't stop the rest. The
+// creators of the tickets decided are emailed the board's decision
+// together once the meeting is recorded.
+func (h *CABHandler) RecordMeeting(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	var input models.RecordCABMeetingInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Approving a critical-risk change needs a recent MFA verification
+	for _, decision := range input.Decisions {
+		if decision.Decision != models.CABDecisionApprove {
+			continue
+		}
+		ticket, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), decision.TicketID)
+		if err == nil && ticket.ApprovalRequiresStepUp() {
+			if !requireStepUp(c) {
+				return
+			}
+			break
+		}
+	}
+
+	meeting := &models.CABMeeting{
+		OrganizationID: orgID.(uuid.UUID),
+		MeetingDate:    input.Date,
+		RecordedBy:     userID.(uuid.UUID),
+		Attendees:      input.Attendees,
+		Notes:          input.Notes,
+	}
+	failed := 0
+	var decided []cabNotice
+	for i := range input.Decisions {
+		decision := &input.Decisions[i]
+		result := models.CABDecisionResult{CABTicketDecision: *decision}
+
+		// Confidential tickets the caller can't see are reported as missing
+		ticket, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), decision.TicketID)
+		if err == nil {
+			ticket, err = h.store.CAB.RecordDecision(ctx, orgID.(uuid.UUID), userID.(uuid.UUID), decision)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		} else {
+			result.TicketNumber = ticket.TicketNumber
+			result.Title = ticket.Title
+			result.TicketStatus = ticket.Status
+			h.store.Audit.LogTicketAccess(ctx, ticket.ID, userID.(uuid.UUID), cabAuditAction(decision.Decision), nil, nil, map[string]interface{}{
+				"source":       "cab_meeting",
+				"meeting_date": input.Date.Format("2006-01-02"),
+				"comment":      decision.Comment,
+				"conditions":   decision.Conditions,
+				"status":       ticket.Status,
+			})
+			decided = append(decided, cabNotice{ticket: ticket, decision: decision})
+		}
+		meeting.Decisions = append(meeting.Decisions, result)
+	}
+
+	if err := h.store.CAB.CreateMeeting(ctx, meeting); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"meeting":  meeting,
+		"recorded": len(meeting.Decisions) - failed,
+		"failed":   failed,
+	}
+	// The decisions stand either way, so a failure to email them is
+	// reported alongside rather than failing the request
+	if err := h.notifyDecided(ctx, orgID.(uuid.UUID), decided); err != nil {
+		response["warnings"] = []string{fmt.Sprintf("failed to email the decisions to the tickets' creators: %v", err)}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// cabNotice is a ticket the board decided, for its creator to be told
+type cabNotice struct {
+	ticket   *models.Ticket
+	decision *models.CABTicketDecision
+}
+
+// notifyDecided emails the creators of the tickets decided at a meeting
+// the board's decision on each
+func (h *CABHandler) notifyDecided(ctx context.Context, orgID uuid.UUID, decided []cabNotice) error {
+	if len(decided) == 0 {
+		return nil
+	}
+
+	creatorIDs := make([]uuid.UUID, len(decided))
+	for i, d := range decided {
+		creatorIDs[i] = d.ticket.CreatedBy
+	}
+	creators, err := h.store.Users.GetSummaries(ctx, orgID, creatorIDs)
+	if err != nil {
+		return err
+	}
+	emails := make(map[uuid.UUID]string, len(creators))
+	for _, u := range creators {
+		emails[u.ID] = u.Email
+	}
+
+	var queued []models.QueuedNotification
+	for _, d := range decided {
+		email, ok := emails[d.ticket.CreatedBy]
+		if !ok {
+			continue
+		}
+		creatorID, ticketID := d.ticket.CreatedBy, d.ticket.ID
+		queued = append(queued, models.QueuedNotification{
+			Recipient: models.NotificationRecipient{
+				OrganizationID: orgID,
+				UserID:         &creatorID,
+				Email:          email,
+				TicketID:       &ticketID,
+			},
+			Message: notifications.CABDecision(d.ticket, d.decision, h.cfg.Email.BaseURL),
+		})
+	}
+	_, err = h.store.Notifications.EnqueueMany(ctx, queued)
+	return err
+}
+
+// ListMeetings handles GET /api/v1/cab/meetings
+func (h *CABHandler) ListMeetings(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	limit, err := parseIntQuery(c, "limit", 50)
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	meetings, err := h.store.CAB.ListMeetings(c.Request.Context(), orgID.(uuid.UUID), limit)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"meetings": meetings})
+}
+
+// GetMeeting handles GET /api/v1/cab/meetings/:id
+func (h *CABHandler) GetMeeting(c *gin.Context) {
+	meeting, ok := h.loadMeeting(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"meeting": meeting})
+}
+
+// GetMinutes handles GET /api/v1/cab/meetings/:id/minutes.pdf
+func (h *CABHandler) GetMinutes(c *gin.Context) {
+	meeting, ok := h.loadMeeting(c)
+	if !ok {
+		return
+	}
+
+	recorder := meeting.RecordedBy.String()
+	users, err := h.store.Users.GetSummaries(c.Request.Context(), meeting.OrganizationID, []uuid.UUID{meeting.RecordedBy})
+	if err == nil && len(users) == 1 {
+		recorder = users[0].FullName
+	}
+
+	doc := renderMinutes(meeting, recorder)
+	filename := fmt.Sprintf("cab-minutes-%s.pdf", meeting.MeetingDate.Format("2006-01-02"))
+	c.Header("Content-Type", pdf.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	if _, err := doc.WriteTo(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+func (h *CABHandler) loadMeeting(c *gin.Context) (*models.CABMeeting, bool) {
+	orgID, _ := c.Get("org_id")
+
+	meetingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid meeting ID"})
+		return nil, false
+	}
+
+	meeting, err := h.store.CAB.GetMeeting(c.Request.Context(), orgID.(uuid.UUID), meetingID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		respondStoreError(c, err)
+		return nil, false
+	}
+	return meeting, true
+}
+
+// renderMinutes lays out a meeting's minutes: attendees, notes and the
+// decision on each ticket discussed
+func renderMinutes(m *models.CABMeeting, recorder string) *pdf.Document {
+	date := m.MeetingDate.Format("January 2, 2006")
+	doc := pdf.New("Change Advisory Board minutes - " + date)
+	doc.Title("Change Advisory Board minutes")
+	doc.Field("Meeting date", date)
+	doc.Field("Recorded by", recorder)
+	doc.Field("Recorded at", m.CreatedAt.UTC().Format("2006-01-02 15:04 MST"))
+
+	doc.Heading("Attendees")
+	if len(m.Attendees) == 0 {
+		doc.Text("Not recorded")
+	}
+	for _, a := range m.Attendees {
+		doc.Bullet(a)
+	}
+
+	if m.Notes != nil && strings.TrimSpace(*m.Notes) != "" {
+		doc.Heading("Notes")
+		doc.Text(*m.Notes)
+	}
+
+	doc.Heading(fmt.Sprintf("Decisions (%d)", len(m.Decisions)))
+	for _, d := range m.Decisions {
+		doc.Space()
+		if d.Error != "" {
+			doc.Text(fmt.Sprintf("%s - %s: not recorded (%s)", d.TicketID, d.Decision, d.Error))
+			continue
+		}
+		doc.Text(fmt.Sprintf("%s: %s", d.TicketNumber, d.Title))
+		doc.Bullet(fmt.Sprintf("Decision: %s; ticket is now %s", cabDecisionLabel(d.Decision), d.TicketStatus))
+		if d.Comment != "" {
+			doc.Bullet("Comment: " + d.Comment)
+		}
+		if d.Conditions != "" {
+			doc.Bullet("Conditions: " + d.Conditions)
+		}
+	}
+
+	return doc
+}
+
+func cabDecisionLabel(d models.CABDecision) string {
+	switch d {
+	case models.CABDecisionApprove:
+		return "approved"
+	case models.CABDecisionDeny:
+		return "denied"
+	default:
+		return "update requested"
+	}
+}
+
+func cabAuditAction(d models.CABDecision) string {
+	switch d {
+	case models.CABDecisionApprove:
+		return models.AuditActionApprove
+	case models.CABDecisionDeny:
+		return models.AuditActionDeny
+	default:
+		return models.AuditActionRequestUpdate
+	}
+}
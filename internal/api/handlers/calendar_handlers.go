@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/calendar"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// CalendarHandler handles iCalendar feed HTTP requests
+type CalendarHandler struct {
+	store *store.Store
+	cfg   *config.Config
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(s *store.Store, cfg *config.Config) *CalendarHandler {
+	return &CalendarHandler{store: s, cfg: cfg}
+}
+
+// ListFeeds handles GET /api/v1/calendar/feeds
+func (h *CalendarHandler) ListFeeds(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	feeds, err := h.store.Calendar.List(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feeds": feeds})
+}
+
+// CreateFeed handles POST /api/v1/calendar/feeds. The feed URL is only
+// returned here; it can't be recovered later.
+func (h *CalendarHandler) CreateFeed(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateCalendarFeedInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Scope == models.CalendarFeedScopeOrganization && !h.isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can create organization calendar feeds"})
+		return
+	}
+
+	feed, token, err := h.store.Calendar.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceCalendarFeed,
+		ResourceID:   &feed.ID,
+		Description:  fmt.Sprintf("Created %s calendar feed %q", feed.Scope(), feed.Name),
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"feed":  feed,
+		"scope": feed.Scope(),
+		"url":   feedURL(h.cfg.Email.BaseURL, token),
+	})
+}
+
+// RevokeFeed handles DELETE /api/v1/calendar/feeds/:id. Admins may revoke
+// any feed in the organization.
+func (h *CalendarHandler) RevokeFeed(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid feed ID"})
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	createdBy := &uid
+	if h.isAdmin(c) {
+		createdBy = nil
+	}
+	if err := h.store.Calendar.Revoke(c.Request.Context(), orgID.(uuid.UUID), feedID, createdBy); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceCalendarFeed,
+		ResourceID:   &feedID,
+		Description:  "Revoked calendar feed",
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "calendar feed revoked"})
+}
+
+// Feed handles GET /api/v1/calendar.ics?token=. Calendar clients can't send
+// credentials, so the token in the URL authenticates the request. User feeds
+// list what the feed's owner could see; organization feeds leave out
+// confidential tickets, as anyone holding the URL can read them.
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token is required"})
+		return
+	}
+
+	feed, err := h.store.Calendar.Redeem(c.Request.Context(), token)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	accessor := &models.TicketAccessor{}
+	if feed.UserID != nil {
+		accessor.UserID = *feed.UserID
+		accessor.Roles = feed.OwnerRoles
+	}
+	ctx := store.WithAccessor(c.Request.Context(), accessor)
+
+	now := time.Now()
+	tickets, err := h.store.Tickets.ListScheduled(ctx, feed.OrganizationID, feed.UserID,
+		now.Add(-models.CalendarFeedLookback), now.Add(models.CalendarFeedHorizon), models.MaxCalendarFeedItems)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", calendar.ContentType)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	if err := calendar.Write(c.Writer, feed.Name, tickets, h.cfg.Email.BaseURL); err != nil {
+		c.Error(err)
+	}
+}
+
+func (h *CalendarHandler) isAdmin(c *gin.Context) bool {
+	a := store.AccessorFrom(c.Request.Context())
+	return a != nil && a.CanWriteAll()
+}
+
+// feedURL builds the subscription URL for a feed token from the configured
+// base URL. The request's Host header is the client's to choose, so it
+// mustn't decide where a secret link points.
+func feedURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/v1/calendar.ics?token=%s", strings.TrimRight(baseURL, "/"), url.QueryEscape(token))
+}
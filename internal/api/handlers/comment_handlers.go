@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// CommentHandler handles ticket comment HTTP requests
+type CommentHandler struct {
+	store *store.Store
+}
+
+// NewCommentHandler creates a new comment handler
+func NewCommentHandler(s *store.Store) *CommentHandler {
+	return &CommentHandler{store: s}
+}
+
+// ListComments handles GET /api/v1/tickets/:id/comments, oldest first.
+// ?threaded=true nests replies under the comments they answer.
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	ctx := c.Request.Context()
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+	if _, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), ticketID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	comments, err := h.store.Comments.ListByTicket(ctx, orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	count := len(comments)
+	if c.Query("threaded") == "true" {
+		comments = models.ThreadComments(comments)
+	}
+	if comments == nil {
+		comments = []models.Comment{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"count":    count,
+	})
+}
+
+// CreateComment handles POST /api/v1/tickets/:id/comments. Set
+// parent_comment_id to reply to another comment on the ticket.
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	var input models.CreateCommentInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if ticket.IsConfidential {
+		access, err := h.store.ACLs.EffectiveAccess(ctx, ticket)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		if !access.CanComment() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient ticket permissions"})
+			return
+		}
+	}
+
+	comment, err := h.store.Comments.Create(ctx, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+// UpdateComment handles PATCH /api/v1/comments/:id. Authors can edit their
+// comments for 15 minutes; the text replaced is kept in the edit history.
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	comment, ok := h.loadComment(c)
+	if !ok {
+		return
+	}
+	if !comment.CanEdit(userID.(uuid.UUID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit a comment, within 15 minutes of posting it"})
+		return
+	}
+
+	var input models.UpdateCommentInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	updated, err := h.store.Comments.Update(c.Request.Context(), orgID.(uuid.UUID), comment.ID, input.Comment)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment": updated})
+}
+
+// DeleteComment handles DELETE /api/v1/comments/:id, by the author or an
+// admin. Replies to the comment stay in its thread.
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	comment, ok := h.loadComment(c)
+	if !ok {
+		return
+	}
+	a := store.AccessorFrom(c.Request.Context())
+	if !comment.CanDelete(userID.(uuid.UUID), a != nil && a.CanWriteAll()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author or an admin can delete a comment"})
+		return
+	}
+
+	if err := h.store.Comments.Delete(c.Request.Context(), orgID.(uuid.UUID), comment.ID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
+
+// loadComment loads the comment in the path, reporting comments on tickets
+// the caller may not see as missing
+func (h *CommentHandler) loadComment(c *gin.Context) (*models.Comment, bool) {
+	orgID, _ := c.Get("org_id")
+	ctx := c.Request.Context()
+
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment ID"})
+		return nil, false
+	}
+
+	comment, err := h.store.Comments.Get(ctx, orgID.(uuid.UUID), commentID)
+	if err == nil {
+		_, err = h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), comment.TicketID)
+		if errors.Is(err, models.ErrNotFound) {
+			err = errCommentNotFound
+		}
+	}
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, false
+	}
+	return comment, true
+}
+
+var errCommentNotFound = models.NotFound("comment not found")
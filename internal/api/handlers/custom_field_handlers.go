@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// CustomFieldHandler handles custom field schema HTTP requests
+type CustomFieldHandler struct {
+	store *store.Store
+}
+
+// NewCustomFieldHandler creates a new custom field handler
+func NewCustomFieldHandler(s *store.Store) *CustomFieldHandler {
+	return &CustomFieldHandler{store: s}
+}
+
+// ListFields handles GET /api/v1/custom-fields?change_type=. With a change
+// type, only the fields that apply to it are returned.
+func (h *CustomFieldHandler) ListFields(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	fields, err := h.store.CustomFields.List(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if changeType, ok := c.GetQuery("change_type"); ok {
+		applicable := make([]models.CustomField, 0, len(fields))
+		for i := range fields {
+			if fields[i].AppliesTo(&changeType) {
+				applicable = append(applicable, fields[i])
+			}
+		}
+		fields = applicable
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"fields": fields,
+		"count":  len(fields),
+	})
+}
+
+// CreateField handles POST /api/v1/custom-fields
+func (h *CustomFieldHandler) CreateField(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateCustomFieldInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field, err := h.store.CustomFields.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"field": field,
+	})
+}
+
+// GetField handles GET /api/v1/custom-fields/:id
+func (h *CustomFieldHandler) GetField(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	fieldID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field ID"})
+		return
+	}
+
+	field, err := h.store.CustomFields.GetByID(c.Request.Context(), orgID.(uuid.UUID), fieldID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"field": field,
+	})
+}
+
+// UpdateField handles PATCH /api/v1/custom-fields/:id
+func (h *CustomFieldHandler) UpdateField(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	fieldID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field ID"})
+		return
+	}
+
+	var input models.UpdateCustomFieldInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	current, err := h.store.CustomFields.GetByID(c.Request.Context(), orgID.(uuid.UUID), fieldID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := input.Validate(current.FieldType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field, err := h.store.CustomFields.Update(c.Request.Context(), orgID.(uuid.UUID), fieldID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"field": field,
+	})
+}
+
+// DeleteField handles DELETE /api/v1/custom-fields/:id
+func (h *CustomFieldHandler) DeleteField(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	fieldID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field ID"})
+		return
+	}
+
+	if err := h.store.CustomFields.Delete(c.Request.Context(), orgID.(uuid.UUID), fieldID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Custom field deleted",
+	})
+}
+
+// checkCustomFields validates a ticket's custom fields against the
+// organization's schema, responding with 400 and every field error if they
+// don't conform
+func checkCustomFields(c *gin.Context, s *store.Store, orgID uuid.UUID, changeType *string, values, current json.RawMessage) bool {
+	defs, err := s.CustomFields.List(c.Request.Context(), orgID)
+	if err != nil {
+		respondStoreError(c, err)
+		return false
+	}
+	if errs := models.ValidateCustomFields(defs, changeType, values, current); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":        "invalid custom fields",
+			"field_errors": errs,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/auth"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// StartDeviceLogin handles POST /api/v1/auth/device/code. It starts an OAuth
+// device authorization (RFC 8628) for a client that can't show a sign-in
+// page, such as the CLI. An optional ?provider= is passed on to the web
+// app, which then signs the user in with that SSO provider.
+func (h *AuthHandler) StartDeviceLogin(c *gin.Context) {
+	provider := models.SSOProvider(c.Query("provider"))
+	if provider != "" && !provider.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown provider %q", provider)})
+		return
+	}
+
+	userCode, err := auth.GenerateUserCode()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	deviceCode, err := h.store.Auth.CreateDeviceLogin(c.Request.Context(), userCode, models.DeviceCodeTTL)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	verificationURI := strings.TrimRight(h.cfg.Email.BaseURL, "/") + "/device"
+	q := url.Values{"user_code": {userCode}}
+	if provider != "" {
+		q.Set("provider", string(provider))
+	}
+
+	c.JSON(http.StatusOK, models.DeviceAuthorization{
+		DeviceCode:              deviceCode.String(),
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?" + q.Encode(),
+		ExpiresIn:               int(models.DeviceCodeTTL.Seconds()),
+		Interval:                int(models.DevicePollInterval.Seconds()),
+	})
+}
+
+// PollDeviceLogin handles POST /api/v1/auth/device/token. Until the user
+// approves the code it answers 400 with authorization_pending; once they
+// have, it opens a session for them, a single time.
+func (h *AuthHandler) PollDeviceLogin(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var input models.DeviceTokenInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceCode, err := uuid.Parse(input.DeviceCode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.DeviceErrorExpired})
+		return
+	}
+	challenge, err := h.store.Auth.GetChallenge(ctx, deviceCode, models.AuthChallengeDeviceLogin)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.DeviceErrorExpired})
+		return
+	}
+	if challenge.UserID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.DeviceErrorPending})
+		return
+	}
+
+	// Only one poll gets the session
+	challenge, err = h.store.Auth.ConsumeChallenge(ctx, deviceCode, models.AuthChallengeDeviceLogin)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.DeviceErrorExpired})
+		return
+	}
+	user, err := h.store.Auth.GetLoginUser(ctx, *challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": models.DeviceErrorExpired})
+		return
+	}
+
+	var approved struct {
+		Method string `json:"method"`
+	}
+	if len(challenge.Data) > 0 {
+		json.Unmarshal(challenge.Data, &approved)
+	}
+	method := models.AuthMethodDevice
+	if approved.Method != "" {
+		method = approved.Method
+	}
+
+	h.issueTokens(c, user, method)
+}
+
+// ApproveDeviceLogin handles POST /api/v1/auth/device/approve. The web app
+// calls it when the signed-in user confirms the code a device shows. Users
+// with MFA must have verified it recently, and the device's session then
+// counts as verified too.
+func (h *AuthHandler) ApproveDeviceLogin(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	mfaAt, _ := c.Get("mfa_at")
+	ctx := c.Request.Context()
+
+	var input models.DeviceApprovalInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Auth.GetLoginUser(ctx, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	method := models.AuthMethodDevice
+	if at, ok := mfaAt.(time.Time); ok && !at.IsZero() && time.Since(at) <= models.StepUpMaxAge {
+		method = models.WithMFA(models.AuthMethodDevice)
+	} else if user.MFAEnabled && !requireStepUp(c) {
+		return
+	}
+
+	data, _ := json.Marshal(gin.H{"method": method})
+	if err := h.store.Auth.ApproveDeviceLogin(ctx, auth.NormalizeUserCode(input.UserCode), user.ID, data); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device signed in",
+	})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// EpicHandler handles epic HTTP requests
+type EpicHandler struct {
+	store *store.Store
+}
+
+// NewEpicHandler creates a new epic handler
+func NewEpicHandler(s *store.Store) *EpicHandler {
+	return &EpicHandler{store: s}
+}
+
+// ListEpics handles GET /api/v1/epics
+func (h *EpicHandler) ListEpics(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	filter := &models.EpicListFilter{}
+	if projectID := c.Query("project_id"); projectID != "" {
+		uid, err := uuid.Parse(projectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		filter.ProjectID = &uid
+	}
+	if status := c.Query("status"); status != "" {
+		s := models.EpicStatus(status)
+		if !s.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid epic status: " + status})
+			return
+		}
+		filter.Status = &s
+	}
+
+	epics, err := h.store.Epics.List(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"epics": epics,
+		"count": len(epics),
+	})
+}
+
+// CreateEpic handles POST /api/v1/epics
+func (h *EpicHandler) CreateEpic(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateEpicInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkProject(c, h.store, orgID.(uuid.UUID), input.ProjectID) {
+		return
+	}
+
+	epic, err := h.store.Epics.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"epic": epic,
+	})
+}
+
+// GetEpic handles GET /api/v1/epics/:id
+func (h *EpicHandler) GetEpic(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	epicID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid epic ID"})
+		return
+	}
+
+	epic, err := h.store.Epics.GetByID(c.Request.Context(), orgID.(uuid.UUID), epicID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	rollup, err := h.store.Epics.Rollup(c.Request.Context(), orgID.(uuid.UUID), epicID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"epic":   epic,
+		"rollup": rollup,
+	})
+}
+
+// GetEpicRollup handles GET /api/v1/epics/:id/rollup
+func (h *EpicHandler) GetEpicRollup(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	epicID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid epic ID"})
+		return
+	}
+
+	if _, err := h.store.Epics.GetByID(c.Request.Context(), orgID.(uuid.UUID), epicID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	rollup, err := h.store.Epics.Rollup(c.Request.Context(), orgID.(uuid.UUID), epicID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"epic_id": epicID,
+		"rollup":  rollup,
+	})
+}
+
+// UpdateEpic handles PATCH /api/v1/epics/:id
+func (h *EpicHandler) UpdateEpic(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	epicID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid epic ID"})
+		return
+	}
+
+	var input models.UpdateEpicInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	epic, err := h.store.Epics.Update(c.Request.Context(), orgID.(uuid.UUID), epicID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"epic": epic,
+	})
+}
+
+// DeleteEpic handles DELETE /api/v1/epics/:id
+func (h *EpicHandler) DeleteEpic(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	epicID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid epic ID"})
+		return
+	}
+
+	if err := h.store.Epics.Delete(c.Request.Context(), orgID.(uuid.UUID), epicID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Epic deleted",
+	})
+}
+
+// checkProject verifies a project belongs to the organization, responding
+// with 400 if it doesn't
+func checkProject(c *gin.Context, s *store.Store, orgID uuid.UUID, projectID *uuid.UUID) bool {
+	if projectID == nil {
+		return true
+	}
+	if _, err := s.Projects.GetByID(c.Request.Context(), orgID, *projectID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			respondStoreError(c, err)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// storeErrorStatus maps a store error to an HTTP status code by its kind:
+// 400 for invalid input, 404 for missing records, 403 for callers not
+// allowed to act, and 409 for conflicts, stale versions and actions the
+// record's state doesn't allow. Other errors get the fallback.
+func storeErrorStatus(err error, fallback int) int {
+	var invalid *models.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrConflict), errors.Is(err, models.ErrInvalidTransition):
+		return http.StatusConflict
+	}
+	return fallback
+}
+
+// respondStoreError writes a store error with the status for its kind, or
+// 500
+func respondStoreError(c *gin.Context, err error) {
+	c.JSON(storeErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store/memstore"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestRespondStoreError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{models.NotFound("ticket not found"), http.StatusNotFound},
+		{fmt.Errorf("wrapped: %w", models.Conflict("already decided")), http.StatusConflict},
+		{models.Forbidden("not yours"), http.StatusForbidden},
+		{&models.ValidationError{Field: "name", Message: "is required"}, http.StatusBadRequest},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		respondStoreError(c, tt.err)
+		if w.Code != tt.want {
+			t.Errorf("respondStoreError(%v) status = %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
+
+func TestGetTicketNotFound(t *testing.T) {
+	h := NewTicketHandler(memstore.New(), &config.Config{})
+	router := gin.New()
+	router.GET("/tickets/:id", func(c *gin.Context) {
+		c.Set("org_id", uuid.New())
+		c.Set("user_id", uuid.New())
+		h.GetTicket(c)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickets/"+uuid.NewString(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body)
+	}
+}
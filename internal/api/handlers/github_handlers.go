@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// GitHubHandler handles the GitHub integration and its webhook receiver
+type GitHubHandler struct {
+	store *store.Store
+}
+
+// NewGitHubHandler creates a new GitHub handler
+func NewGitHubHandler(s *store.Store) *GitHubHandler {
+	return &GitHubHandler{store: s}
+}
+
+// GetIntegration handles GET /api/v1/organization/integrations/github
+func (h *GitHubHandler) GetIntegration(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	integration, err := h.store.GitHub.GetIntegration(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"github_integration": integration})
+}
+
+// UpdateIntegration handles PUT /api/v1/organization/integrations/github
+func (h *GitHubHandler) UpdateIntegration(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateGitHubIntegrationInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	integration, err := h.store.GitHub.UpdateIntegration(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	oid := orgID.(uuid.UUID)
+	changes, _ := json.Marshal(gin.H{
+		"actor_user_id":  integration.ActorUserID,
+		"is_active":      integration.IsActive,
+		"secret_rotated": input.WebhookSecret != nil,
+	})
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Updated GitHub integration",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"github_integration": integration})
+}
+
+// Webhook handles POST /api/v1/integrations/github/webhook. Deliveries are
+// matched to organizations by repository URL and must be signed with that
+// organization's webhook secret. Pull requests whose title or branch names
+// a ticket number are linked to the ticket, and status changes are posted
+// to it as comments.
+func (h *GitHubHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, models.MaxGitHubWebhookPayloadBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	if len(body) > models.MaxGitHubWebhookPayloadBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "payload too large"})
+		return
+	}
+
+	signature := c.GetHeader("X-Hub-Signature-256")
+	if signature == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing signature"})
+		return
+	}
+
+	event := c.GetHeader("X-GitHub-Event")
+	deliveryID := c.GetHeader("X-GitHub-Delivery")
+
+	var payload models.GitHubPullRequestEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if payload.Repository == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature or unknown repository"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	matches, err := h.store.GitHub.MatchRepository(ctx, payload.Repository.URLs())
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	var verified []models.GitHubRepositoryMatch
+	for _, m := range matches {
+		if validGitHubSignature(m.Integration.WebhookSecret, body, signature) {
+			verified = append(verified, m)
+		}
+	}
+	if len(verified) == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature or unknown repository"})
+		return
+	}
+
+	switch event {
+	case "ping":
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+		return
+	case "pull_request":
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"message": "event ignored"})
+		return
+	}
+	if !payload.Linkable() {
+		c.JSON(http.StatusAccepted, gin.H{"message": "action ignored"})
+		return
+	}
+
+	linked := []string{}
+	for _, m := range verified {
+		if deliveryID != "" {
+			isNew, err := h.store.GitHub.RecordDelivery(ctx, m.Integration.OrganizationID, deliveryID, event)
+			if err != nil {
+				respondStoreError(c, err)
+				return
+			}
+			if !isNew {
+				continue
+			}
+		}
+
+		numbers, err := h.linkPullRequest(ctx, &m, &payload)
+		if err != nil {
+			if deliveryID != "" {
+				h.store.GitHub.ReleaseDelivery(context.WithoutCancel(ctx), m.Integration.OrganizationID, deliveryID)
+			}
+			respondStoreError(c, err)
+			return
+		}
+		linked = append(linked, numbers...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"linked_tickets": linked})
+}
+
+// linkPullRequest links the pull request to each ticket it references in
+// the matched organization and comments on status changes. Unknown ticket
+// numbers are skipped.
+func (h *GitHubHandler) linkPullRequest(ctx context.Context, m *models.GitHubRepositoryMatch, payload *models.GitHubPullRequestEvent) ([]string, error) {
+	orgID := m.Integration.OrganizationID
+	actorID := m.Integration.ActorUserID
+	pr := payload.PullRequest
+	comment := payload.StatusComment()
+
+	var linked []string
+	for _, number := range payload.TicketNumbers(m.TicketNumberPrefix) {
+		ticket, err := h.store.Tickets.GetByNumber(ctx, orgID, number)
+		if err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		input := &models.LinkRepositoryInput{
+			RepositoryID: m.RepositoryID,
+			LinkType:     "implements",
+			BranchName:   &pr.Head.Ref,
+			CommitSHA:    &pr.Head.SHA,
+			PRNumber:     &pr.Number,
+			Notes:        &pr.HTMLURL,
+		}
+		if err := h.store.Tickets.LinkRepository(ctx, ticket.ID, m.RepositoryID, actorID, input); err != nil {
+			return nil, err
+		}
+
+		if comment != "" {
+			if _, err := h.store.Comments.Create(ctx, orgID, ticket.ID, actorID, &models.CreateCommentInput{Comment: comment}); err != nil {
+				return nil, err
+			}
+		}
+
+		if payload.Action != "synchronize" {
+			h.store.Audit.LogTicketAccess(ctx, ticket.ID, actorID, models.AuditActionRepositoryLink, nil, nil, map[string]interface{}{
+				"repository_id": m.RepositoryID,
+				"repository":    payload.Repository.FullName,
+				"pr_number":     pr.Number,
+				"pr_action":     payload.Action,
+				"branch_name":   pr.Head.Ref,
+				"commit_sha":    pr.Head.SHA,
+				"source":        "github_webhook",
+			})
+		}
+
+		linked = append(linked, number)
+	}
+
+	return linked, nil
+}
+
+// validGitHubSignature checks an X-Hub-Signature-256 header against the
+// HMAC-SHA256 of the body under secret
+func validGitHubSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/api/graph"
+	"github.com/afterdarksys/adsops-utils/internal/graphql"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// GraphQLHandler handles read-only GraphQL HTTP requests
+type GraphQLHandler struct {
+	store *store.Store
+}
+
+// NewGraphQLHandler creates a new GraphQL handler
+func NewGraphQLHandler(s *store.Store) *GraphQLHandler {
+	return &GraphQLHandler{store: s}
+}
+
+// Query handles POST /api/v1/graphql. Field errors are returned alongside
+// the data with 200; requests that can't run at all get 400.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var req graphql.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := graph.WithViewer(c.Request.Context(), h.store, orgID.(uuid.UUID), userID.(uuid.UUID))
+	resp := graph.Schema.Execute(ctx, &req)
+	if resp.Data == nil {
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
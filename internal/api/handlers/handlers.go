@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"bytes"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/afterdarksys/adsops-utils/internal/store"
 )
 
 // APIDocumentation returns an HTML page documenting the API
@@ -289,10 +291,22 @@ func Ready(c *gin.Context) {
 	})
 }
 
-// Metrics returns Prometheus metrics
-func Metrics(c *gin.Context) {
-	// TODO: Implement Prometheus metrics
-	c.String(http.StatusOK, "# HELP api_requests_total Total API requests\n# TYPE api_requests_total counter\n")
+// MetricsHandler serves the API's Prometheus metrics
+type MetricsHandler struct {
+	store *store.Store
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(s *store.Store) *MetricsHandler {
+	return &MetricsHandler{store: s}
+}
+
+// Metrics returns Prometheus metrics: database statement durations and
+// errors per store method
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	var b bytes.Buffer
+	h.store.WriteMetrics(&b)
+	c.Data(http.StatusOK, "text/plain; version=0.0.4", b.Bytes())
 }
 
 // NotImplemented returns a 501 Not Implemented response
@@ -307,12 +321,6 @@ func notImplemented(c *gin.Context) {
 }
 
 // Auth handlers
-func Login(c *gin.Context)              { notImplemented(c) }
-func LoginMFA(c *gin.Context)           { notImplemented(c) }
-func LoginOAuth2Google(c *gin.Context)  { notImplemented(c) }
-func LoginOAuth2AfterDark(c *gin.Context) { notImplemented(c) }
-func LoginPasskeyBegin(c *gin.Context)  { notImplemented(c) }
-func LoginPasskeyFinish(c *gin.Context) { notImplemented(c) }
 func RefreshToken(c *gin.Context)       { notImplemented(c) }
 func GetCurrentUser(c *gin.Context)     { notImplemented(c) }
 func Logout(c *gin.Context)             { notImplemented(c) }
@@ -339,8 +347,7 @@ func UnlinkRepository(c *gin.Context)   { notImplemented(c) }
 func AddWatcher(c *gin.Context)         { notImplemented(c) }
 func RemoveWatcher(c *gin.Context)      { notImplemented(c) }
 
-// Repository handlers
-func ListRepositories(c *gin.Context)   { notImplemented(c) }
+// Repository handlers - ListRepositories is implemented in repository_handlers.go
 func CreateRepository(c *gin.Context)   { notImplemented(c) }
 func GetRepository(c *gin.Context)      { notImplemented(c) }
 func UpdateRepository(c *gin.Context)   { notImplemented(c) }
@@ -367,48 +374,10 @@ func SearchEmployees(c *gin.Context)    { notImplemented(c) }
 func GetEmployee(c *gin.Context)        { notImplemented(c) }
 func UpdateEmployee(c *gin.Context)     { notImplemented(c) }
 
-// Ticket ACL handlers
-func GetTicketACLs(c *gin.Context)      { notImplemented(c) }
-func GrantTicketACL(c *gin.Context)     { notImplemented(c) }
-func RevokeTicketACL(c *gin.Context)    { notImplemented(c) }
-
-// Failed signup handlers
-func CollectFailedSignupContact(c *gin.Context) { notImplemented(c) }
-func ListFailedSignups(c *gin.Context)          { notImplemented(c) }
-func ResolveFailedSignup(c *gin.Context)        { notImplemented(c) }
-
-// Approval handlers
-func ListApprovals(c *gin.Context)      { notImplemented(c) }
-func GetApproval(c *gin.Context)        { notImplemented(c) }
-func Approve(c *gin.Context)            { notImplemented(c) }
-func Deny(c *gin.Context)               { notImplemented(c) }
-func RequestUpdate(c *gin.Context)      { notImplemented(c) }
-func ApproveByToken(c *gin.Context)     { notImplemented(c) }
-func DenyByToken(c *gin.Context)        { notImplemented(c) }
-func GetApprovalByToken(c *gin.Context) { notImplemented(c) }
-
-// Comment handlers
-func CreateComment(c *gin.Context)      { notImplemented(c) }
-func ListComments(c *gin.Context)       { notImplemented(c) }
-func UpdateComment(c *gin.Context)      { notImplemented(c) }
-func DeleteComment(c *gin.Context)      { notImplemented(c) }
-
-// User handlers
-func ListUsers(c *gin.Context)          { notImplemented(c) }
-func CreateUser(c *gin.Context)         { notImplemented(c) }
-func GetUser(c *gin.Context)            { notImplemented(c) }
-func UpdateUser(c *gin.Context)         { notImplemented(c) }
-func DeleteUser(c *gin.Context)         { notImplemented(c) }
-func ResetUserPassword(c *gin.Context)  { notImplemented(c) }
-func EnableUserMFA(c *gin.Context)      { notImplemented(c) }
-func DisableUserMFA(c *gin.Context)     { notImplemented(c) }
-
 // Compliance handlers
 func ListComplianceFrameworks(c *gin.Context) { notImplemented(c) }
 func ListComplianceTemplates(c *gin.Context)  { notImplemented(c) }
 func CreateComplianceTemplate(c *gin.Context) { notImplemented(c) }
 
 // Report handlers
-func AuditReport(c *gin.Context)        { notImplemented(c) }
-func ComplianceReport(c *gin.Context)   { notImplemented(c) }
-func UserActivityReport(c *gin.Context) { notImplemented(c) }
+func ComplianceReport(c *gin.Context) { notImplemented(c) }
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/servicenow"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// ImportHandler handles ticket imports from other change systems
+type ImportHandler struct {
+	store *store.Store
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(s *store.Store) *ImportHandler {
+	return &ImportHandler{store: s}
+}
+
+// ImportServiceNow handles POST /api/v1/import/servicenow
+// Accepts a change_request export as a JSON or CSV body, or as a multipart
+// "file" upload. With ?dry_run=true every record is validated and reported
+// but nothing is created. Records already imported are skipped. The import
+// fails as a whole if the imported tickets' audit events can't be recorded.
+func (h *ImportHandler) ImportServiceNow(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	opts, err := h.serviceNowOptions(c, orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, models.MaxServiceNowImportBytes)
+	body, format, err := importExport(c)
+	if err != nil {
+		respondImportReadError(c, err)
+		return
+	}
+	defer body.Close()
+	records, err := servicenow.Parse(body, format)
+	if err != nil {
+		respondImportReadError(c, err)
+		return
+	}
+
+	report := &models.ServiceNowImportReport{DryRun: opts.DryRun, Results: []models.ServiceNowImportResult{}}
+	seen := make(map[string]int)
+
+	// The tickets and their audit events are written together or not at
+	// all. Each ticket is inserted under its own savepoint, so a row that
+	// fails is reported without undoing the others.
+	err = h.store.WithTx(ctx, func(tx *store.Store) error {
+		var events []models.TicketAuditEvent
+		for i, rec := range records {
+			result := models.ServiceNowImportResult{Row: i + 1, Number: rec.Get("number")}
+
+			input, err := servicenow.Ticket(rec, opts)
+			if err != nil {
+				result.Result = models.ImportResultFailed
+				result.Error = err.Error()
+				report.Add(result)
+				continue
+			}
+			result.Status = input.Status
+
+			if row, ok := seen[result.Number]; ok {
+				result.Result = models.ImportResultSkipped
+				result.Error = fmt.Sprintf("duplicate of row %d", row)
+				report.Add(result)
+				continue
+			}
+			seen[result.Number] = result.Row
+
+			existing, err := tx.Tickets.GetNumberByExternalReference(ctx, orgID.(uuid.UUID), result.Number)
+			if err != nil {
+				return err
+			}
+			if existing != "" {
+				result.Result = models.ImportResultSkipped
+				result.TicketNumber = existing
+				result.Error = "already imported as " + existing
+				report.Add(result)
+				continue
+			}
+
+			if opts.DryRun {
+				result.Result = models.ImportResultValid
+				report.Add(result)
+				continue
+			}
+
+			ticket, err := tx.Tickets.Import(ctx, orgID.(uuid.UUID), userID.(uuid.UUID), input)
+			if err != nil {
+				result.Result = models.ImportResultFailed
+				if errors.Is(err, models.ErrConflict) {
+					result.Result = models.ImportResultSkipped
+				}
+				result.Error = err.Error()
+				report.Add(result)
+				continue
+			}
+			result.Result = models.ImportResultImported
+			result.TicketNumber = ticket.TicketNumber
+			result.TicketID = &ticket.ID
+			report.Add(result)
+
+			uid := userID.(uuid.UUID)
+			events = append(events, models.TicketAuditEvent{
+				TicketID: ticket.ID,
+				UserID:   &uid,
+				Action:   models.AuditActionImport,
+				Changes: map[string]interface{}{
+					"source":             "servicenow",
+					"external_reference": result.Number,
+					"status":             ticket.Status,
+				},
+			})
+		}
+		if err := tx.Audit.LogTicketEvents(ctx, events); err != nil {
+			return fmt.Errorf("failed to record the audit events of the imported tickets: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if report.Imported > 0 {
+		uid := userID.(uuid.UUID)
+		oid := orgID.(uuid.UUID)
+		changes, _ := json.Marshal(gin.H{
+			"source":   "servicenow",
+			"total":    report.Total,
+			"imported": report.Imported,
+			"skipped":  report.Skipped,
+			"failed":   report.Failed,
+		})
+		recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+			UserID:       &uid,
+			Action:       models.AuditActionImport,
+			ResourceType: models.AuditResourceOrganization,
+			ResourceID:   &oid,
+			Description:  fmt.Sprintf("Imported %d tickets from ServiceNow", report.Imported),
+			Changes:      changes,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"import_report": report})
+}
+
+// serviceNowOptions reads the import options from the query string, filling
+// industry and frameworks from the organization's ticket defaults
+func (h *ImportHandler) serviceNowOptions(c *gin.Context, orgID uuid.UUID) (*models.ServiceNowImportOptions, error) {
+	opts := &models.ServiceNowImportOptions{Industry: models.IndustryType(c.Query("industry"))}
+
+	if value := c.Query("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, &models.ValidationError{Field: "dry_run", Message: "expected true or false"}
+		}
+		opts.DryRun = dryRun
+	}
+	if frameworks := c.Query("compliance_frameworks"); frameworks != "" {
+		for _, f := range strings.Split(frameworks, ",") {
+			opts.ComplianceFrameworks = append(opts.ComplianceFrameworks, models.ComplianceFramework(strings.TrimSpace(f)))
+		}
+	}
+	if types := c.Query("approval_types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			opts.RequiresApprovalTypes = append(opts.RequiresApprovalTypes, models.ApprovalType(strings.TrimSpace(t)))
+		}
+	}
+	if tz := c.Query("timezone"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, &models.ValidationError{Field: "timezone", Message: "unknown time zone: " + tz}
+		}
+		opts.Location = loc
+	}
+
+	if opts.Industry == "" || len(opts.ComplianceFrameworks) == 0 {
+		industry, frameworks, err := h.store.Organizations.GetTicketDefaults(c.Request.Context(), orgID)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Industry == "" {
+			opts.Industry = industry
+		}
+		if len(opts.ComplianceFrameworks) == 0 {
+			opts.ComplianceFrameworks = frameworks
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// importExport returns the uploaded export and its format: a multipart
+// "file" field is read by extension, a text/csv body as CSV and anything
+// else as JSON
+func importExport(c *gin.Context) (io.ReadCloser, string, error) {
+	switch c.ContentType() {
+	case "multipart/form-data":
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+		format := servicenow.FormatJSON
+		if strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+			format = servicenow.FormatCSV
+		}
+		return file, format, nil
+	case "text/csv", "application/csv":
+		return c.Request.Body, servicenow.FormatCSV, nil
+	default:
+		return c.Request.Body, servicenow.FormatJSON, nil
+	}
+}
+
+// respondImportReadError reports an export that could not be read or parsed
+func respondImportReadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "export too large"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// InventoryHandler handles host inventory and blackout HTTP requests
+type InventoryHandler struct {
+	store *store.Store
+}
+
+// NewInventoryHandler creates a new inventory handler
+func NewInventoryHandler(s *store.Store) *InventoryHandler {
+	return &InventoryHandler{store: s}
+}
+
+// ListHosts handles GET /api/v1/hosts
+func (h *InventoryHandler) ListHosts(c *gin.Context) {
+	filter := &models.HostListFilter{
+		Environment: c.Query("environment"),
+		Type:        c.Query("type"),
+		Provider:    c.Query("provider"),
+		Search:      c.Query("search"),
+	}
+	if status := c.Query("status"); status != "" {
+		s := models.HostStatus(status)
+		if !s.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			return
+		}
+		filter.Status = &s
+	}
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	hosts, total, err := h.store.Inventory.ListHosts(c.Request.Context(), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hosts":    hosts,
+		"total":    total,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+	})
+}
+
+// ListDiscrepancies handles GET /api/v1/hosts/discrepancies
+func (h *InventoryHandler) ListDiscrepancies(c *gin.Context) {
+	filter := &models.DiscrepancyFilter{
+		Kind:     models.DiscrepancyKind(c.Query("kind")),
+		Provider: c.Query("provider"),
+		Resolved: c.Query("resolved") == "true",
+	}
+	if filter.Kind != "" && !filter.Kind.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be ghost or stray"})
+		return
+	}
+	filter.Limit, _ = parseIntQuery(c, "limit", 500)
+
+	discrepancies, err := h.store.Inventory.ListDiscrepancies(c.Request.Context(), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"discrepancies": discrepancies})
+}
+
+// GetHost handles GET /api/v1/hosts/:hostname
+func (h *InventoryHandler) GetHost(c *gin.Context) {
+	host, err := h.store.Inventory.GetHost(c.Request.Context(), c.Param("hostname"))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"host": host})
+}
+
+// CreateHost handles POST /api/v1/hosts
+func (h *InventoryHandler) CreateHost(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateHostInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	host, err := h.store.Inventory.CreateHost(c.Request.Context(), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.recordHostAudit(c, orgID.(uuid.UUID), userID.(uuid.UUID), models.AuditActionCreate, host.Hostname, input)
+
+	c.JSON(http.StatusCreated, gin.H{"host": host})
+}
+
+// UpdateHost handles PATCH /api/v1/hosts/:hostname
+func (h *InventoryHandler) UpdateHost(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateHostInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	host, err := h.store.Inventory.UpdateHost(c.Request.Context(), c.Param("hostname"), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.recordHostAudit(c, orgID.(uuid.UUID), userID.(uuid.UUID), models.AuditActionUpdate, host.Hostname, input)
+
+	c.JSON(http.StatusOK, gin.H{"host": host})
+}
+
+// DeleteHost handles DELETE /api/v1/hosts/:hostname
+func (h *InventoryHandler) DeleteHost(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	hostname := c.Param("hostname")
+
+	if err := h.store.Inventory.DeleteHost(c.Request.Context(), hostname); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.recordHostAudit(c, orgID.(uuid.UUID), userID.(uuid.UUID), models.AuditActionDelete, hostname, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "host deleted"})
+}
+
+// ListBlackouts handles GET /api/v1/hosts/:hostname/blackouts
+// With ?active=true only the blackout in effect is returned.
+func (h *InventoryHandler) ListBlackouts(c *gin.Context) {
+	limit, err := parseIntQuery(c, "limit", 50)
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	blackouts, err := h.store.Inventory.ListBlackouts(c.Request.Context(), c.Param("hostname"), c.Query("active") == "true", limit)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blackouts": blackouts})
+}
+
+// ListTicketBlackouts handles GET /api/v1/tickets/:id/blackouts
+func (h *InventoryHandler) ListTicketBlackouts(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+	limit, err := parseIntQuery(c, "limit", 50)
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	blackouts, err := h.store.Inventory.ListBlackoutsByTicket(c.Request.Context(), ticket.TicketNumber, c.Query("active") == "true", limit)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blackouts": blackouts})
+}
+
+// StartBlackout handles POST /api/v1/hosts/:hostname/blackouts
+// The ticket must be approved or implementing and list the host among its
+// affected systems. Monitoring alerts for the host are suppressed until the
+// blackout ends.
+func (h *InventoryHandler) StartBlackout(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+	hostname := c.Param("hostname")
+
+	var input models.StartBlackoutInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByNumber(ctx, orgID.(uuid.UUID), input.TicketNumber)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if !ticket.CanStartBlackout() {
+		c.JSON(http.StatusConflict, gin.H{"error": "blackouts can only be started from approved or implementing tickets"})
+		return
+	}
+	ticket.AffectedResources, _ = h.store.Tickets.ListAffectedResources(ctx, ticket.ID)
+	if !ticket.Affects(hostname) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ticket %s does not list %s as an affected system", ticket.TicketNumber, hostname)})
+		return
+	}
+
+	var endTime time.Time
+	switch {
+	case input.DurationMinutes > 0:
+		endTime = time.Now().Add(time.Duration(input.DurationMinutes) * time.Minute)
+	case ticket.ScheduledEnd != nil && ticket.ScheduledEnd.After(time.Now()):
+		endTime = *ticket.ScheduledEnd
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration_minutes is required when the ticket has no upcoming scheduled end"})
+		return
+	}
+	if time.Until(endTime) > models.MaxBlackoutMinutes*time.Minute {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "blackouts may last at most 7 days"})
+		return
+	}
+	reason := input.Reason
+	if reason == "" {
+		reason = ticket.Title
+	}
+
+	blackout, err := h.store.Inventory.StartBlackout(ctx, hostname, ticket.TicketNumber, endTime, reason, h.actorName(c, orgID.(uuid.UUID), userID.(uuid.UUID)))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.store.Audit.LogTicketAccess(ctx, ticket.ID, userID.(uuid.UUID), models.AuditActionBlackoutStart, nil, nil, map[string]interface{}{
+		"hostname":    hostname,
+		"blackout_id": blackout.ID,
+		"end_time":    blackout.EndTime,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"blackout": blackout})
+}
+
+// EndBlackout handles POST /api/v1/hosts/:hostname/blackouts/end
+func (h *InventoryHandler) EndBlackout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	active, ticket, ok := h.activeBlackout(c)
+	if !ok {
+		return
+	}
+
+	blackout, err := h.store.Inventory.EndBlackout(ctx, active.ID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.store.Audit.LogTicketAccess(ctx, ticket.ID, userID.(uuid.UUID), models.AuditActionBlackoutEnd, nil, nil, map[string]interface{}{
+		"hostname":    blackout.Hostname,
+		"blackout_id": blackout.ID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"blackout": blackout})
+}
+
+// ExtendBlackout handles POST /api/v1/hosts/:hostname/blackouts/extend
+func (h *InventoryHandler) ExtendBlackout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	var input models.ExtendBlackoutInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	active, ticket, ok := h.activeBlackout(c)
+	if !ok {
+		return
+	}
+
+	blackout, err := h.store.Inventory.ExtendBlackout(ctx, active.ID, time.Duration(input.DurationMinutes)*time.Minute)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	h.store.Audit.LogTicketAccess(ctx, ticket.ID, userID.(uuid.UUID), models.AuditActionBlackoutExtend, nil, nil, map[string]interface{}{
+		"hostname":     blackout.Hostname,
+		"blackout_id":  blackout.ID,
+		"old_end_time": active.EndTime,
+		"end_time":     blackout.EndTime,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"blackout": blackout})
+}
+
+// activeBlackout loads the host's blackout in effect and the ticket it was
+// started for. Blackouts for tickets the caller can't see, including other
+// organizations' tickets, are reported as missing.
+func (h *InventoryHandler) activeBlackout(c *gin.Context) (*models.Blackout, *models.Ticket, bool) {
+	orgID, _ := c.Get("org_id")
+	ctx := c.Request.Context()
+	hostname := c.Param("hostname")
+
+	blackouts, err := h.store.Inventory.ActiveBlackouts(ctx, []string{hostname})
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, nil, false
+	}
+	active := blackouts[hostname]
+	if active == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no active blackout"})
+		return nil, nil, false
+	}
+
+	ticket, err := h.store.Tickets.GetByNumber(ctx, orgID.(uuid.UUID), active.TicketNumber)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no active blackout"})
+		return nil, nil, false
+	}
+
+	return active, ticket, true
+}
+
+// actorName is recorded as a blackout's creator: the caller's email, as the
+// blackout tool records a Unix username
+func (h *InventoryHandler) actorName(c *gin.Context, orgID, userID uuid.UUID) string {
+	users, err := h.store.Users.GetSummaries(c.Request.Context(), orgID, []uuid.UUID{userID})
+	if err == nil && len(users) == 1 {
+		return users[0].Email
+	}
+	return userID.String()
+}
+
+// recordHostAudit logs an inventory change to the caller's organization
+func (h *InventoryHandler) recordHostAudit(c *gin.Context, orgID, userID uuid.UUID, action, hostname string, input interface{}) {
+	var changes json.RawMessage
+	if input != nil {
+		changes, _ = json.Marshal(input)
+	}
+	metadata, _ := json.Marshal(map[string]string{"hostname": hostname})
+	description := map[string]string{
+		models.AuditActionCreate: "Added host ",
+		models.AuditActionUpdate: "Updated host ",
+		models.AuditActionDelete: "Removed host ",
+	}[action] + hostname
+	recordAudit(c, h.store, orgID, &models.CreateAuditLogInput{
+		UserID:       &userID,
+		Action:       action,
+		ResourceType: models.AuditResourceHost,
+		Description:  description,
+		Changes:      changes,
+		Metadata:     metadata,
+	})
+}
+
+// resolveAffectedSystems matches a ticket's affected systems against
+// inventory and records the hosts they resolve to, rescoring the ticket's
+// risk. It returns a warning for each system matching no host; unknown
+// systems don't block the ticket. The lookup runs under a savepoint when s
+// is in a transaction, so a failure is reported as a warning without
+// aborting the caller's transaction.
+func resolveAffectedSystems(ctx context.Context, s *store.Store, orgID uuid.UUID, ticket *models.Ticket) []string {
+	var resources []models.AffectedResource
+	var unknown []string
+	err := s.WithTx(ctx, func(tx *store.Store) error {
+		var err error
+		resources, unknown, err = tx.Inventory.ResolveSystems(ctx, ticket.AffectedSystems)
+		if err != nil {
+			return fmt.Errorf("affected systems could not be checked against inventory: %w", err)
+		}
+		if err := tx.Tickets.SetAffectedResources(ctx, orgID, ticket.ID, resources); err != nil {
+			return fmt.Errorf("affected systems could not be recorded: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return []string{err.Error()}
+	}
+	ticket.AffectedResources = resources
+	ticket.ProductionSystems = models.CountProductionSystems(resources)
+	ticket.RiskScore = ticket.ScoreRisk()
+
+	var warnings []string
+	for _, system := range unknown {
+		warnings = append(warnings, fmt.Sprintf("affected system %q does not match any inventory host", system))
+	}
+	return warnings
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/jira"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// JiraHandler handles Jira sync configuration HTTP requests
+type JiraHandler struct {
+	store *store.Store
+}
+
+// NewJiraHandler creates a new Jira handler
+func NewJiraHandler(s *store.Store) *JiraHandler {
+	return &JiraHandler{store: s}
+}
+
+// GetIntegration handles GET /api/v1/organization/integrations/jira
+func (h *JiraHandler) GetIntegration(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	integration, err := h.store.Jira.GetIntegration(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jira_integration": integration})
+}
+
+// UpdateIntegration handles PUT /api/v1/organization/integrations/jira
+func (h *JiraHandler) UpdateIntegration(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateJiraIntegrationInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	integration, err := h.store.Jira.UpdateIntegration(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	oid := orgID.(uuid.UUID)
+	changes, _ := json.Marshal(gin.H{
+		"base_url":       integration.BaseURL,
+		"project_key":    integration.ProjectKey,
+		"issue_type":     integration.IssueType,
+		"field_mapping":  integration.FieldMapping,
+		"status_mapping": integration.StatusMapping,
+		"actor_user_id":  integration.ActorUserID,
+		"is_active":      integration.IsActive,
+		"token_rotated":  input.APIToken != nil,
+	})
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Updated Jira integration",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"jira_integration": integration})
+}
+
+// SyncNow handles POST /api/v1/organization/integrations/jira/sync, running
+// a sync immediately instead of waiting for the worker
+func (h *JiraHandler) SyncNow(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	integration, err := h.store.Jira.GetIntegration(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if !integration.IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "jira integration is not active"})
+		return
+	}
+
+	result, err := jira.NewSyncer(h.store, integration).Run(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "sync_result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sync_result": result})
+}
+
+// GetTicketSyncState handles GET /api/v1/tickets/:id/jira
+func (h *JiraHandler) GetTicketSyncState(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	// Resolve the ticket first so confidential tickets stay hidden
+	if _, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := h.store.Jira.GetSyncState(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jira_sync": state})
+}
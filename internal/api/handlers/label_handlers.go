@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// LabelHandler handles label HTTP requests
+type LabelHandler struct {
+	store *store.Store
+}
+
+// NewLabelHandler creates a new label handler
+func NewLabelHandler(s *store.Store) *LabelHandler {
+	return &LabelHandler{store: s}
+}
+
+// ListLabels handles GET /api/v1/labels
+func (h *LabelHandler) ListLabels(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	labels, err := h.store.Labels.List(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"labels": labels,
+		"count":  len(labels),
+	})
+}
+
+// GetLabelUsage handles GET /api/v1/labels/usage
+func (h *LabelHandler) GetLabelUsage(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	usage, err := h.store.Labels.Usage(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"usage": usage,
+	})
+}
+
+// CreateLabel handles POST /api/v1/labels
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateLabelInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := h.store.Labels.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"label": label,
+	})
+}
+
+// GetLabel handles GET /api/v1/labels/:id
+func (h *LabelHandler) GetLabel(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	labelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label ID"})
+		return
+	}
+
+	label, err := h.store.Labels.GetByID(c.Request.Context(), orgID.(uuid.UUID), labelID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"label": label,
+	})
+}
+
+// UpdateLabel handles PATCH /api/v1/labels/:id
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	labelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label ID"})
+		return
+	}
+
+	var input models.UpdateLabelInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := h.store.Labels.Update(c.Request.Context(), orgID.(uuid.UUID), labelID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"label": label,
+	})
+}
+
+// DeleteLabel handles DELETE /api/v1/labels/:id
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	labelID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label ID"})
+		return
+	}
+
+	if err := h.store.Labels.Delete(c.Request.Context(), orgID.(uuid.UUID), labelID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Label deleted",
+	})
+}
+
+// checkLabels verifies a ticket's labels are defined by the organization,
+// responding with 400 and the unknown labels if they aren't. Labels the
+// ticket already carries are accepted.
+func checkLabels(c *gin.Context, s *store.Store, orgID uuid.UUID, labels, current []string) bool {
+	if len(labels) == 0 {
+		return true
+	}
+	defined, err := s.Labels.List(c.Request.Context(), orgID)
+	if err != nil {
+		respondStoreError(c, err)
+		return false
+	}
+	if unknown := models.UnknownLabels(defined, labels, current); len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "unknown labels",
+			"unknown_labels": unknown,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,506 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/auth"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// How an MFA code was verified
+const (
+	mfaCodeTOTP   = "totp"
+	mfaCodeBackup = "backup_code"
+)
+
+// LoginMFA handles POST /api/v1/auth/login/mfa
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var input models.LoginMFAInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challengeID, err := uuid.Parse(input.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "challenge not found or expired"})
+		return
+	}
+	challenge, err := h.store.Auth.GetChallenge(ctx, challengeID, models.AuthChallengeMFA)
+	if err != nil || challenge.UserID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "challenge not found or expired"})
+		return
+	}
+	user, err := h.store.Auth.GetLoginUser(ctx, *challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "challenge not found or expired"})
+		return
+	}
+
+	// The first factor was a password unless the pending login says otherwise
+	var pending struct {
+		Method string `json:"method"`
+	}
+	if len(challenge.Data) > 0 {
+		json.Unmarshal(challenge.Data, &pending)
+	}
+	authMethod := models.AuthMethodMFA
+	if pending.Method != "" {
+		authMethod = models.WithMFA(pending.Method)
+	}
+
+	method, err := h.verifyMFACode(ctx, user, input.Code, true)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if method == "" {
+		if err := h.store.Auth.FailChallenge(ctx, challenge.ID, models.MaxMFAAttempts); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		h.recordLoginFailure(c, user, authMethod, "invalid code")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+
+	// The pending login can only be completed once
+	if _, err := h.store.Auth.ConsumeChallenge(ctx, challenge.ID, models.AuthChallengeMFA); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if method == mfaCodeBackup {
+		h.recordBackupCodeUse(c, user)
+	}
+
+	h.issueTokens(c, user, authMethod)
+}
+
+// GetMFAStatus handles GET /api/v1/auth/mfa
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	user, err := h.store.Auth.GetLoginUser(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mfa": user.MFAStatus(),
+	})
+}
+
+// EnrollMFA handles POST /api/v1/auth/mfa/enroll
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	user, err := h.store.Auth.GetLoginUser(ctx, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "mfa is already enabled"})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.store.Auth.StartMFAEnrollment(ctx, user.ID, secret); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": auth.TOTPProvisioningURI(h.cfg.WebAuthn.RPName, user.Email, secret),
+	})
+}
+
+// ActivateMFA handles POST /api/v1/auth/mfa/activate
+func (h *AuthHandler) ActivateMFA(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	var input models.MFACodeInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Auth.GetLoginUser(ctx, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "mfa is already enabled"})
+		return
+	}
+	if user.MFASecret == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa enrollment not started"})
+		return
+	}
+
+	method, err := h.verifyMFACode(ctx, user, input.Code, false)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if method == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+		return
+	}
+
+	codes, err := auth.GenerateBackupCodes(models.BackupCodeCount)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.store.Auth.EnableMFA(ctx, user.ID, codes); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Action:       models.AuditActionMFAEnable,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Description:  "Enabled TOTP multi-factor authentication",
+	})
+
+	// Replace an enrollment-only token with one that has full access
+	user.MFAEnabled = true
+	tokens, err := h.openSession(c, user, models.AuthMethodMFA)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backup_codes": codes,
+		"tokens":       tokens,
+	})
+}
+
+// RegenerateBackupCodes handles POST /api/v1/auth/mfa/backup-codes
+func (h *AuthHandler) RegenerateBackupCodes(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	user, ok := h.bindMFAUser(c, false)
+	if !ok {
+		return
+	}
+
+	codes, err := auth.GenerateBackupCodes(models.BackupCodeCount)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.store.Auth.ReplaceBackupCodes(ctx, user.ID, codes); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionMFABackupCodes,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &uid,
+		Description:  "Generated new MFA backup codes",
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"backup_codes": codes,
+	})
+}
+
+// StepUp handles POST /api/v1/auth/mfa/step-up
+func (h *AuthHandler) StepUp(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	sessionID, _ := c.Get("session_id")
+	authMethod, _ := c.Get("auth_method")
+	ctx := c.Request.Context()
+
+	user, ok := h.bindMFAUser(c, true)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	claims := &auth.Claims{
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		Roles:          user.Roles,
+		SessionID:      sessionID.(uuid.UUID),
+		AuthMethod:     authMethod.(string),
+		MFAAt:          now.Unix(),
+	}
+	token, err := auth.Sign(&h.cfg.JWT, claims, now)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.store.Auth.RotateSessionToken(ctx, claims.SessionID, token, claims.Expiry()); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Action:       models.AuditActionMFAStepUp,
+		ResourceType: models.AuditResourceSession,
+		ResourceID:   &claims.SessionID,
+		Description:  "Verified MFA for a sensitive action",
+	})
+
+	c.JSON(http.StatusOK, models.AuthTokens{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(claims.Expiry().Sub(now).Seconds()),
+		ExpiresAt:   claims.Expiry(),
+		AuthMethod:  claims.AuthMethod,
+		User: models.UserSummary{
+			ID:       user.ID,
+			Email:    user.Email,
+			FullName: user.FullName,
+		},
+	})
+}
+
+// DisableMFA handles DELETE /api/v1/auth/mfa
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	user, ok := h.bindMFAUser(c, true)
+	if !ok {
+		return
+	}
+	if user.MFARequired() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "mfa is required for your account"})
+		return
+	}
+
+	if err := h.store.Auth.ResetMFA(c.Request.Context(), orgID.(uuid.UUID), user.ID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Action:       models.AuditActionMFADisable,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Description:  "Disabled multi-factor authentication",
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "MFA disabled",
+	})
+}
+
+// EnableUserMFA handles POST /api/v1/users/:id/enable-mfa. The user must
+// set up MFA at their next sign-in, whatever their roles.
+func (h *AuthHandler) EnableUserMFA(c *gin.Context) {
+	h.setUserMFARequired(c, true)
+}
+
+// DisableUserMFA handles POST /api/v1/users/:id/disable-mfa. It resets the
+// user's MFA, e.g. after a lost device, and drops any requirement set by
+// EnableUserMFA. The org policy still applies.
+func (h *AuthHandler) DisableUserMFA(c *gin.Context) {
+	h.setUserMFARequired(c, false)
+}
+
+func (h *AuthHandler) setUserMFARequired(c *gin.Context, required bool) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	if err := h.store.Auth.SetMFARequired(ctx, orgID.(uuid.UUID), targetID, required); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	action, description := models.AuditActionMFARequire, "Required multi-factor authentication"
+	if !required {
+		if err := h.store.Auth.ResetMFA(ctx, orgID.(uuid.UUID), targetID); err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		action, description = models.AuditActionMFADisable, "Reset multi-factor authentication"
+	}
+
+	actorID := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &actorID,
+		Action:       action,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  description,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": description,
+	})
+}
+
+// bindMFAUser reads a code from the request and checks it against the
+// current user's MFA. It writes the error response and returns false if
+// the code isn't accepted.
+func (h *AuthHandler) bindMFAUser(c *gin.Context, allowBackup bool) (*models.LoginUser, bool) {
+	userID, _ := c.Get("user_id")
+	ctx := c.Request.Context()
+
+	var input models.MFACodeInput
+	if !bindJSON(c, &input) {
+		return nil, false
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	user, err := h.store.Auth.GetLoginUser(ctx, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa is not enabled"})
+		return nil, false
+	}
+	if user.MFALockedUntil != nil {
+		respondMFALocked(c, *user.MFALockedUntil)
+		return nil, false
+	}
+
+	method, err := h.verifyMFACode(ctx, user, input.Code, allowBackup)
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, false
+	}
+	if method == "" {
+		locked, err := h.store.Auth.FailMFACode(ctx, user.ID, models.MaxMFAAttempts, models.MFALockout)
+		if err != nil {
+			respondStoreError(c, err)
+			return nil, false
+		}
+		if locked {
+			recordAudit(c, h.store, user.OrganizationID, &models.CreateAuditLogInput{
+				UserID:       &user.ID,
+				Action:       models.AuditActionMFALockout,
+				ResourceType: models.AuditResourceUser,
+				ResourceID:   &user.ID,
+				Description:  "Locked MFA after too many invalid codes",
+			})
+			respondMFALocked(c, time.Now().Add(models.MFALockout))
+			return nil, false
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return nil, false
+	}
+	if err := h.store.Auth.ClearMFAFailures(ctx, user.ID); err != nil {
+		respondStoreError(c, err)
+		return nil, false
+	}
+	if method == mfaCodeBackup {
+		h.recordBackupCodeUse(c, user)
+	}
+
+	return user, true
+}
+
+// respondMFALocked refuses an MFA code while the user is locked out
+func respondMFALocked(c *gin.Context, until time.Time) {
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":        "too many invalid codes, try again later",
+		"locked_until": until,
+	})
+}
+
+// verifyMFACode checks a TOTP code, or a backup code if allowed, and
+// returns how it was verified. It returns an empty string for a wrong or
+// already used code.
+func (h *AuthHandler) verifyMFACode(ctx context.Context, user *models.LoginUser, code string, allowBackup bool) (string, error) {
+	if user.MFASecret == nil {
+		return "", nil
+	}
+
+	if step, ok := auth.MatchTOTP(*user.MFASecret, code, time.Now()); ok {
+		fresh, err := h.store.Auth.UseTOTPStep(ctx, user.ID, step)
+		if err != nil || !fresh {
+			return "", err
+		}
+		return mfaCodeTOTP, nil
+	}
+
+	if !allowBackup || !user.MFAEnabled {
+		return "", nil
+	}
+	used, err := h.store.Auth.UseBackupCode(ctx, user.ID, auth.NormalizeBackupCode(code))
+	if err != nil || !used {
+		return "", err
+	}
+	return mfaCodeBackup, nil
+}
+
+func (h *AuthHandler) recordBackupCodeUse(c *gin.Context, user *models.LoginUser) {
+	metadata, _ := json.Marshal(gin.H{"backup_codes_remaining": user.BackupCodes - 1})
+	recordAudit(c, h.store, user.OrganizationID, &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Username:     &user.Email,
+		Action:       models.AuditActionMFABackupCodes,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Description:  "Used an MFA backup code",
+		Metadata:     metadata,
+	})
+}
+
+// requireStepUp checks the caller completed MFA or passkey verification
+// within models.StepUpMaxAge. It writes the error response and returns
+// false if they need to step up first.
+func requireStepUp(c *gin.Context) bool {
+	mfaAt, _ := c.Get("mfa_at")
+	if at, ok := mfaAt.(time.Time); ok && !at.IsZero() && time.Since(at) <= models.StepUpMaxAge {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":            "this action requires a recent MFA verification",
+		"code":             "STEP_UP_REQUIRED",
+		"step_up_endpoint": "/v1/auth/mfa/step-up",
+	})
+	return false
+}
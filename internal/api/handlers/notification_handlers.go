@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// NotificationHandler handles the current user's notification preferences
+type NotificationHandler struct {
+	store *store.Store
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(s *store.Store) *NotificationHandler {
+	return &NotificationHandler{store: s}
+}
+
+// GetPreferences handles GET /api/v1/auth/notifications
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	prefs, err := h.store.Notifications.GetPreferences(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences":    prefs,
+		"next_digest_at": nextDigestAt(prefs),
+	})
+}
+
+// UpdatePreferences handles PATCH /api/v1/auth/notifications
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateNotificationPreferencesInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	prefs, err := h.store.Notifications.GetPreferences(ctx, orgID.(uuid.UUID), userID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	input.Apply(prefs)
+	if err := h.store.Notifications.SavePreferences(ctx, prefs); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences":    prefs,
+		"next_digest_at": nextDigestAt(prefs),
+	})
+}
+
+// nextDigestAt returns when the user's next digest is scheduled, or nil if
+// digests are off
+func nextDigestAt(prefs *models.NotificationPreferences) *time.Time {
+	last, ok := prefs.ScheduledDigestAt(time.Now())
+	if !ok {
+		return nil
+	}
+	next := last.AddDate(0, 0, int(prefs.Period()/(24*time.Hour)))
+	return &next
+}
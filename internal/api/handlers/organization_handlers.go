@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// OrganizationHandler handles organization HTTP requests
+type OrganizationHandler struct {
+	store *store.Store
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(s *store.Store) *OrganizationHandler {
+	return &OrganizationHandler{store: s}
+}
+
+// CreateOrganization handles POST /api/v1/organizations (platform admin)
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateOrganizationInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	org, err := h.store.Organizations.Create(c.Request.Context(), &uid, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &org.ID,
+		Description:  "Created organization " + org.Slug,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"organization": org})
+}
+
+// ListOrganizations handles GET /api/v1/organizations (platform admin)
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	filter := &models.OrganizationListFilter{}
+	if search := c.Query("search"); search != "" {
+		filter.Search = &search
+	}
+	filter.IncludeDeleted = c.Query("include_deleted") == "true"
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	orgs, total, err := h.store.Organizations.List(c.Request.Context(), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organizations": orgs,
+		"total":         total,
+		"page":          filter.Page,
+		"per_page":      filter.PerPage,
+	})
+}
+
+// GetOrganization handles GET /api/v1/organizations/:id (platform admin)
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+	h.getOrganization(c, id)
+}
+
+// UpdateOrganization handles PATCH /api/v1/organizations/:id (platform admin)
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+	h.updateOrganization(c, id)
+}
+
+// DeleteOrganization handles DELETE /api/v1/organizations/:id (platform admin)
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID"})
+		return
+	}
+	if id == orgID.(uuid.UUID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot delete your own organization"})
+		return
+	}
+
+	if err := h.store.Organizations.Delete(c.Request.Context(), id, userID.(uuid.UUID)); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:             &uid,
+		Action:             models.AuditActionDelete,
+		ResourceType:       models.AuditResourceOrganization,
+		ResourceID:         &id,
+		Description:        "Deleted organization",
+		ComplianceRelevant: true,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "organization deleted"})
+}
+
+// GetCurrentOrganization handles GET /api/v1/organization
+func (h *OrganizationHandler) GetCurrentOrganization(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	h.getOrganization(c, orgID.(uuid.UUID))
+}
+
+// UpdateCurrentOrganization handles PATCH /api/v1/organization (admin)
+func (h *OrganizationHandler) UpdateCurrentOrganization(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	h.updateOrganization(c, orgID.(uuid.UUID))
+}
+
+// GetSettings handles GET /api/v1/organization/settings
+func (h *OrganizationHandler) GetSettings(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	settings, err := h.store.Organizations.GetSettings(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// UpdateSettings handles PUT /api/v1/organization/settings (admin)
+func (h *OrganizationHandler) UpdateSettings(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateOrganizationSettingsInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.store.Organizations.UpdateSettings(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	oid := orgID.(uuid.UUID)
+	changes, _ := json.Marshal(input)
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Updated organization settings",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"settings": org.Settings()})
+}
+
+// ListEmailIssues handles GET /api/v1/organization/email-issues, listing
+// users whose address hard-bounced or complained
+func (h *OrganizationHandler) ListEmailIssues(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	users, err := h.store.Users.ListEmailFlagged(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"count": len(users),
+	})
+}
+
+// ClearEmailIssue handles DELETE /api/v1/organization/email-issues/:user_id.
+// It clears the user's flag and lifts the suppression on their current
+// address so notifications are sent to it again.
+func (h *OrganizationHandler) ClearEmailIssue(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	targetID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	email, err := h.store.Users.ClearEmailFlag(ctx, orgID.(uuid.UUID), targetID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.store.Notifications.Unsuppress(ctx, email); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  "Cleared email delivery flag and resumed notifications to " + email,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email flag cleared",
+	})
+}
+
+// RequeueNotifications handles POST /api/v1/organization/notifications/requeue,
+// sending failed notifications again, e.g. after an SES outage. ids or email
+// also pick bounced ones whose address is no longer suppressed.
+func (h *OrganizationHandler) RequeueNotifications(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	oid := orgID.(uuid.UUID)
+
+	var input models.RequeueNotificationsInput
+	if c.Request.ContentLength != 0 && !bindJSON(c, &input) {
+		return
+	}
+
+	count, err := h.store.Notifications.Requeue(c.Request.Context(), oid, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	metadata, _ := json.Marshal(gin.H{"requeued": count, "ids": input.IDs, "email": input.Email, "since": input.Since})
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Requeued failed notifications",
+		Metadata:     metadata,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"requeued": count})
+}
+
+func (h *OrganizationHandler) getOrganization(c *gin.Context, id uuid.UUID) {
+	org, err := h.store.Organizations.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organization": org})
+}
+
+func (h *OrganizationHandler) updateOrganization(c *gin.Context, id uuid.UUID) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateOrganizationInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.store.Organizations.Update(c.Request.Context(), id, userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	changes, _ := json.Marshal(input)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &id,
+		Description:  "Updated organization",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"organization": org})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// parseTimeQuery parses an optional RFC 3339 or YYYY-MM-DD query parameter
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("invalid %s: expected RFC 3339 timestamp or YYYY-MM-DD", name)
+}
+
+// parseIntQuery parses an optional integer query parameter, returning def when absent
+func parseIntQuery(c *gin.Context, name string, def int) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: expected integer", name)
+	}
+	return n, nil
+}
+
+// splitQueryList splits a comma-separated query value, dropping blanks
+func splitQueryList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseUserQuery parses a user ID query value, where "me" is the caller
+func parseUserQuery(value string, me uuid.UUID) (uuid.UUID, bool) {
+	if value == "me" {
+		return me, true
+	}
+	uid, err := uuid.Parse(value)
+	return uid, err == nil
+}
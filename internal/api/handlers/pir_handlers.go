@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// PIRHandler handles post-implementation review HTTP requests
+type PIRHandler struct {
+	store *store.Store
+}
+
+// NewPIRHandler creates a new PIR handler
+func NewPIRHandler(s *store.Store) *PIRHandler {
+	return &PIRHandler{store: s}
+}
+
+// GetPIR handles GET /api/v1/tickets/:id/pir
+func (h *PIRHandler) GetPIR(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	pir, err := h.store.PIRs.GetByTicket(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"post_implementation_review": pir,
+		"overdue":                    pir.IsOverdue(),
+	})
+}
+
+// SubmitPIR handles PUT /api/v1/tickets/:id/pir
+func (h *PIRHandler) SubmitPIR(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	var input models.SubmitPIRInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if ticket.Status != models.TicketStatusCompleted && ticket.Status != models.TicketStatusClosed {
+		c.JSON(http.StatusConflict, gin.H{"error": "post-implementation reviews can only be recorded for completed or closed tickets"})
+		return
+	}
+
+	if existing, err := h.store.PIRs.GetByTicket(c.Request.Context(), orgID.(uuid.UUID), ticketID); err == nil && !existing.CanEdit() {
+		c.JSON(http.StatusConflict, gin.H{"error": "post-implementation review has been signed off and cannot be changed"})
+		return
+	}
+
+	pir, err := h.store.PIRs.Submit(c.Request.Context(), orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	changes := map[string]interface{}{"outcome": input.Outcome}
+	h.store.Audit.LogTicketAccess(c.Request.Context(), ticketID, userID.(uuid.UUID), "pir_submit", nil, nil, changes)
+
+	c.JSON(http.StatusOK, gin.H{
+		"post_implementation_review": pir,
+	})
+}
+
+// SignOffPIR handles POST /api/v1/tickets/:id/pir/sign-off
+func (h *PIRHandler) SignOffPIR(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	var input models.SignOffPIRInput
+	c.ShouldBindJSON(&input)
+
+	pir, err := h.store.PIRs.SignOff(c.Request.Context(), orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.store.Audit.LogTicketAccess(c.Request.Context(), ticketID, userID.(uuid.UUID), "pir_sign_off", nil, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"post_implementation_review": pir,
+	})
+}
+
+// ListPIRs handles GET /api/v1/pirs
+func (h *PIRHandler) ListPIRs(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	filter := &models.PIRListFilter{}
+	if status := c.Query("status"); status != "" {
+		filter.Status = []models.PIRStatus{models.PIRStatus(status)}
+	}
+	if c.Query("overdue") == "true" {
+		filter.OverdueOnly = true
+	}
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	reviews, total, err := h.store.PIRs.List(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"post_implementation_reviews": reviews,
+		"total":                       total,
+		"page":                        filter.Page,
+		"per_page":                    filter.PerPage,
+	})
+}
+
+// MissingPIRReport handles GET /api/v1/reports/missing-pirs
+// Lists closed changes without a completed review as SOX evidence.
+func (h *PIRHandler) MissingPIRReport(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.store.PIRs.ListClosedWithoutPIR(c.Request.Context(), orgID.(uuid.UUID), from, to)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	emergencies := 0
+	for _, e := range entries {
+		if e.IsEmergency {
+			emergencies++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes":         entries,
+		"count":           len(entries),
+		"emergency_count": emergencies,
+		"from":            from,
+		"to":              to,
+	})
+}
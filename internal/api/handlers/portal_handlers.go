@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// PortalHandler handles customer portal HTTP requests and the admin
+// endpoints that manage portal accounts
+type PortalHandler struct {
+	store *store.Store
+}
+
+// NewPortalHandler creates a new portal handler
+func NewPortalHandler(s *store.Store) *PortalHandler {
+	return &PortalHandler{store: s}
+}
+
+// GetProfile handles GET /api/v1/portal/me
+func (h *PortalHandler) GetProfile(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	customerID, _ := c.Get("customer_id")
+
+	customer, err := h.store.Portal.GetCustomer(c.Request.Context(), orgID.(uuid.UUID), customerID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":  userID,
+		"customer": customer,
+	})
+}
+
+// ListTickets handles GET /api/v1/portal/tickets
+func (h *PortalHandler) ListTickets(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	customerID, _ := c.Get("customer_id")
+
+	filter := &models.PortalTicketFilter{Search: c.Query("search")}
+	if status := c.Query("status"); status != "" {
+		filter.Status = []models.TicketStatus{models.TicketStatus(status)}
+	}
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	tickets, total, err := h.store.Portal.ListTickets(c.Request.Context(), orgID.(uuid.UUID), customerID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tickets":  tickets,
+		"total":    total,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+	})
+}
+
+// GetTicket handles GET /api/v1/portal/tickets/:id
+func (h *PortalHandler) GetTicket(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	customerID, _ := c.Get("customer_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	ticket, err := h.store.Portal.GetTicket(c.Request.Context(), orgID.(uuid.UUID), customerID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	comments, err := h.store.Portal.ListComments(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":   ticket,
+		"comments": comments,
+	})
+}
+
+// ListComments handles GET /api/v1/portal/tickets/:id/comments
+func (h *PortalHandler) ListComments(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	customerID, _ := c.Get("customer_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	if _, err := h.store.Portal.GetTicket(c.Request.Context(), orgID.(uuid.UUID), customerID.(uuid.UUID), ticketID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	comments, err := h.store.Portal.ListComments(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"count":    len(comments),
+	})
+}
+
+// CreateComment handles POST /api/v1/portal/tickets/:id/comments. Portal
+// comments are always public.
+func (h *PortalHandler) CreateComment(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+	customerID, _ := c.Get("customer_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	var input models.CreatePortalCommentInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	if _, err := h.store.Portal.GetTicket(c.Request.Context(), orgID.(uuid.UUID), customerID.(uuid.UUID), ticketID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	comment, err := h.store.Comments.Create(c.Request.Context(), orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), &models.CreateCommentInput{
+		Comment: input.Comment,
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"comment": models.PortalComment{
+			ID:           comment.ID,
+			FromCustomer: true,
+			Comment:      comment.Comment,
+			CreatedAt:    comment.CreatedAt,
+		},
+	})
+}
+
+// ListPortalUsers handles GET /api/v1/organization/portal-users
+func (h *PortalHandler) ListPortalUsers(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	var customerID *uuid.UUID
+	if value := c.Query("customer_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer ID"})
+			return
+		}
+		customerID = &id
+	}
+
+	users, err := h.store.Portal.ListUsers(c.Request.Context(), orgID.(uuid.UUID), customerID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"portal_users": users,
+		"count":        len(users),
+	})
+}
+
+// CreatePortalUser handles POST /api/v1/organization/portal-users
+func (h *PortalHandler) CreatePortalUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreatePortalUserInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Portal.CreateUser(c.Request.Context(), orgID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	metadata, _ := json.Marshal(gin.H{"customer_id": user.CustomerID, "email": user.Email})
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Description:  "Created customer portal account",
+		Metadata:     metadata,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"portal_user": user,
+	})
+}
+
+// DeactivatePortalUser handles DELETE /api/v1/organization/portal-users/:id
+func (h *PortalHandler) DeactivatePortalUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	if err := h.store.Portal.DeactivateUser(c.Request.Context(), orgID.(uuid.UUID), targetID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  "Deactivated customer portal account",
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Portal user deactivated",
+	})
+}
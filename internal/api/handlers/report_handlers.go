@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/cache"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// reportCacheTTL is how long computed change metrics are served from the
+// cache before they are recomputed
+const reportCacheTTL = 5 * time.Minute
+
+// ReportHandler handles report HTTP requests
+type ReportHandler struct {
+	store *store.Store
+	cache cache.Cache
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(s *store.Store, c cache.Cache) *ReportHandler {
+	return &ReportHandler{store: s, cache: c}
+}
+
+// ChangeMetrics handles GET /api/v1/reports/change-metrics. ?team= (a
+// group's name or ID) limits the metrics to the tickets the group owns.
+func (h *ReportHandler) ChangeMetrics(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	period, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	var team *models.GroupSummary
+	if ref := c.Query("team"); ref != "" {
+		g, err := h.store.Groups.Find(c.Request.Context(), orgID.(uuid.UUID), ref)
+		if err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "team not found"})
+				return
+			}
+			respondStoreError(c, err)
+			return
+		}
+		team = g
+	}
+
+	var metrics *models.ChangeMetrics
+	key := reportCacheKey("change-metrics", orgID.(uuid.UUID), period)
+	scope := models.ChangeMetricsScope{}
+	if team != nil {
+		key += ":team:" + team.ID.String()
+		scope.GroupID = &team.ID
+	}
+	err := h.cached(c, key, &metrics, func(ctx context.Context) (interface{}, error) {
+		m, err := h.store.Reports.ChangeMetrics(ctx, orgID.(uuid.UUID), period, scope)
+		if m != nil {
+			m.Team = team
+		}
+		metrics = m
+		return m, err
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// MyChangeMetrics handles GET /api/v1/auth/me/change-metrics: the change
+// KPIs of the tickets the current user created or is assigned. Unlike the
+// other reports it is open to every user.
+func (h *ReportHandler) MyChangeMetrics(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	period, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	metrics, err := h.store.Reports.ChangeMetrics(c.Request.Context(), orgID.(uuid.UUID), period, models.ChangeMetricsScope{UserID: &uid})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if users, err := h.store.Users.GetSummaries(c.Request.Context(), orgID.(uuid.UUID), []uuid.UUID{uid}); err == nil && len(users) == 1 {
+		metrics.User = &users[0]
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// SystemChanges handles GET /api/v1/reports/change-metrics/systems
+func (h *ReportHandler) SystemChanges(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	period, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	var report *models.SystemChangeReport
+	key := reportCacheKey("system-changes", orgID.(uuid.UUID), period)
+	err := h.cached(c, key, &report, func(ctx context.Context) (interface{}, error) {
+		r, err := h.store.Reports.SystemChanges(ctx, orgID.(uuid.UUID), period)
+		report = r
+		return r, err
+	})
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// UserActivityReport handles GET /api/v1/reports/user-activity/:user_id
+func (h *ReportHandler) UserActivityReport(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	period, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.store.Reports.UserActivity(c.Request.Context(), orgID.(uuid.UUID), userID, period)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// cached serves dest from the cache, or computes it and caches the result.
+// ?refresh=true skips the cache lookup. The cache is best effort: when it
+// can't be reached the report is computed every time.
+func (h *ReportHandler) cached(c *gin.Context, key string, dest interface{}, compute func(context.Context) (interface{}, error)) error {
+	ctx := c.Request.Context()
+	if c.Query("refresh") != "true" {
+		if hit, _ := cache.GetJSON(ctx, h.cache, key, dest); hit {
+			c.Header("X-Cache", "HIT")
+			return nil
+		}
+	}
+
+	value, err := compute(ctx)
+	if err != nil {
+		return err
+	}
+	cache.SetJSON(ctx, h.cache, key, value, reportCacheTTL)
+	c.Header("X-Cache", "MISS")
+	return nil
+}
+
+func reportCacheKey(report string, orgID uuid.UUID, period models.ReportPeriod) string {
+	return fmt.Sprintf("reports:%s:%s:%d:%d", report, orgID, period.From.Unix(), period.To.Unix())
+}
+
+// reportPeriod reads ?from= and ?to=, responding with 400 if they are invalid
+func reportPeriod(c *gin.Context) (models.ReportPeriod, bool) {
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.ReportPeriod{}, false
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.ReportPeriod{}, false
+	}
+
+	period, err := models.NewReportPeriod(from, to, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.ReportPeriod{}, false
+	}
+	return period, true
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/reports"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// ReportLinker signs download links to generated reports
+type ReportLinker interface {
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+}
+
+// ReportJobHandler handles reports generated in the background by the
+// worker
+type ReportJobHandler struct {
+	store   *store.Store
+	links   ReportLinker
+	linkTTL time.Duration
+}
+
+// NewReportJobHandler creates a new report job handler. Without links,
+// report storage is not configured and reports can't be requested.
+func NewReportJobHandler(s *store.Store, links ReportLinker, cfg *config.Config) *ReportJobHandler {
+	return &ReportJobHandler{store: s, links: links, linkTTL: reports.LinkTTL(&cfg.Reports)}
+}
+
+// CreateReportJob handles POST /api/v1/reports/jobs. The report is queued
+// and the requester is emailed a download link when it is ready.
+func (h *ReportJobHandler) CreateReportJob(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	if h.links == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "report storage is not configured"})
+		return
+	}
+
+	var input models.CreateReportJobInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	params, err := input.Params(time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	job, err := h.store.ReportJobs.Create(c.Request.Context(), orgID.(uuid.UUID), uid, &input, params)
+	if err != nil {
+		if errors.Is(err, models.ErrConflict) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		respondStoreError(c, err)
+		return
+	}
+
+	metadata, _ := json.Marshal(gin.H{"report_type": job.ReportType, "format": job.Format, "parameters": job.Parameters})
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:               &uid,
+		Action:               models.AuditActionExport,
+		ResourceType:         models.AuditResourceReport,
+		ResourceID:           &job.ID,
+		Description:          "Requested " + job.Title() + " report",
+		Metadata:             metadata,
+		ComplianceRelevant:   true,
+		ComplianceFrameworks: params.Frameworks,
+	})
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListReportJobs handles GET /api/v1/reports/jobs
+func (h *ReportJobHandler) ListReportJobs(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	filter := &models.ReportJobFilter{Status: c.Query("status")}
+	if requestedBy := c.Query("requested_by"); requestedBy != "" {
+		id, err := uuid.Parse(requestedBy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid requested_by"})
+			return
+		}
+		filter.RequestedBy = &id
+	}
+	var err error
+	if filter.Limit, err = parseIntQuery(c, "limit", 50); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobs, err := h.store.ReportJobs.List(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetReportJob handles GET /api/v1/reports/jobs/:id
+func (h *ReportJobHandler) GetReportJob(c *gin.Context) {
+	job, ok := h.getJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadReport handles GET /api/v1/reports/jobs/:id/download. It
+// redirects to a freshly signed link, so a report can be downloaded
+// after the emailed link has expired.
+func (h *ReportJobHandler) DownloadReport(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	job, ok := h.getJob(c)
+	if !ok {
+		return
+	}
+	switch job.Status {
+	case models.ReportJobCompleted:
+	case models.ReportJobExpired:
+		c.JSON(http.StatusGone, gin.H{"error": "report has expired"})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "report is " + job.Status})
+		return
+	}
+	if h.links == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "report storage is not configured"})
+		return
+	}
+
+	ttl := h.linkTTL
+	if job.ExpiresAt != nil {
+		if remaining := time.Until(*job.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl <= 0 || job.ObjectKey == nil {
+		c.JSON(http.StatusGone, gin.H{"error": "report has expired"})
+		return
+	}
+	link, err := h.links.PresignGet(*job.ObjectKey, *job.Filename, ttl)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:             &uid,
+		Action:             models.AuditActionDownload,
+		ResourceType:       models.AuditResourceReport,
+		ResourceID:         &job.ID,
+		Description:        "Downloaded " + job.Title() + " report",
+		ComplianceRelevant: true,
+	})
+
+	c.Redirect(http.StatusFound, link)
+}
+
+func (h *ReportJobHandler) getJob(c *gin.Context) (*models.ReportJob, bool) {
+	orgID, _ := c.Get("org_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report job ID"})
+		return nil, false
+	}
+
+	job, err := h.store.ReportJobs.Get(c.Request.Context(), orgID.(uuid.UUID), id)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		respondStoreError(c, err)
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/reposync"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// RepositoryHandler handles repository HTTP requests
+type RepositoryHandler struct {
+	store *store.Store
+}
+
+// NewRepositoryHandler creates a new repository handler
+func NewRepositoryHandler(s *store.Store) *RepositoryHandler {
+	return &RepositoryHandler{store: s}
+}
+
+// ListRepositories handles GET /api/v1/repositories
+func (h *RepositoryHandler) ListRepositories(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	filter := &models.RepositoryListFilter{
+		Search:    c.Query("search"),
+		SortBy:    c.Query("sort_by"),
+		SortOrder: c.Query("sort_order"),
+		Cursor:    c.Query("cursor"),
+	}
+	if provider := c.Query("provider"); provider != "" {
+		p := models.RepositoryProvider(provider)
+		if !p.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider"})
+			return
+		}
+		filter.Provider = &p
+	}
+	if ownerUserID := c.Query("owner_user_id"); ownerUserID != "" {
+		if uid, err := uuid.Parse(ownerUserID); err == nil {
+			filter.OwnerUserID = &uid
+		}
+	}
+	if ownerGroupID := c.Query("owner_group_id"); ownerGroupID != "" {
+		if gid, err := uuid.Parse(ownerGroupID); err == nil {
+			filter.OwnerGroupID = &gid
+		}
+	}
+	switch c.Query("active") {
+	case "true":
+		active := true
+		filter.IsActive = &active
+	case "false":
+		active := false
+		filter.IsActive = &active
+	}
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	repos, total, err := h.store.Repositories.List(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"repositories": repos,
+		"total":        total,
+		"per_page":     filter.PerPage,
+	}
+	if filter.Cursor == "" {
+		response["page"] = filter.Page
+	}
+	if filter.NextCursor != "" {
+		response["next_cursor"] = filter.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SyncRepository handles POST /api/v1/repositories/:id/sync, refreshing a
+// repository's details from its provider
+func (h *RepositoryHandler) SyncRepository(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	repoID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repository ID"})
+		return
+	}
+
+	oid := orgID.(uuid.UUID)
+	repo, err := h.store.Repositories.GetByID(c.Request.Context(), oid, repoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !repo.Provider.Syncable() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "repository sync is not supported for provider " + string(repo.Provider)})
+		return
+	}
+
+	result, updated, err := reposync.NewSyncer(h.store).SyncRepository(c.Request.Context(), repo)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSyncTokenNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no " + string(repo.Provider) + " sync token is configured"})
+		case result.Error != "":
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "sync_result": result})
+		default:
+			respondStoreError(c, err)
+		}
+		return
+	}
+
+	if len(result.Changed) > 0 {
+		uid := userID.(uuid.UUID)
+		changes, _ := json.Marshal(gin.H{"changed": result.Changed})
+		recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+			UserID:       &uid,
+			Action:       models.AuditActionUpdate,
+			ResourceType: models.AuditResourceRepository,
+			ResourceID:   &repoID,
+			Description:  "Synced repository " + updated.Name + " from " + string(updated.Provider),
+			Changes:      changes,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"repository": updated, "sync_result": result})
+}
+
+// ListSyncTokens handles GET /api/v1/organization/integrations/repository-sync
+func (h *RepositoryHandler) ListSyncTokens(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	tokens, err := h.store.Repositories.ListSyncTokens(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sync_tokens": tokens})
+}
+
+// UpdateSyncToken handles PUT /api/v1/organization/integrations/repository-sync/:provider
+func (h *RepositoryHandler) UpdateSyncToken(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	provider, ok := syncProviderParam(c)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateRepositorySyncTokenInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	oid := orgID.(uuid.UUID)
+	token, err := h.store.Repositories.SetSyncToken(c.Request.Context(), oid, uid, provider, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	changes, _ := json.Marshal(gin.H{
+		"provider":      token.Provider,
+		"api_base_url":  token.APIBaseURL,
+		"token_rotated": true,
+	})
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Updated " + string(provider) + " repository sync token",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"sync_token": token})
+}
+
+// DeleteSyncToken handles DELETE /api/v1/organization/integrations/repository-sync/:provider
+func (h *RepositoryHandler) DeleteSyncToken(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	provider, ok := syncProviderParam(c)
+	if !ok {
+		return
+	}
+
+	oid := orgID.(uuid.UUID)
+	if err := h.store.Repositories.DeleteSyncToken(c.Request.Context(), oid, provider); err != nil {
+		if errors.Is(err, models.ErrSyncTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Removed " + string(provider) + " repository sync token",
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "sync token removed"})
+}
+
+// SyncAll handles POST /api/v1/organization/integrations/repository-sync/run,
+// syncing the organization's repositories immediately instead of waiting
+// for the worker
+func (h *RepositoryHandler) SyncAll(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	run, err := reposync.NewSyncer(h.store).SyncOrganization(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "sync_run": run})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sync_run": run})
+}
+
+func syncProviderParam(c *gin.Context) (models.RepositoryProvider, bool) {
+	provider := models.RepositoryProvider(c.Param("provider"))
+	if !provider.Syncable() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider must be github or gitlab"})
+		return "", false
+	}
+	return provider, true
+}
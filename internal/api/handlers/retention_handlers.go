@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// RetentionHandler handles data retention and anonymization HTTP requests
+type RetentionHandler struct {
+	store *store.Store
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(s *store.Store) *RetentionHandler {
+	return &RetentionHandler{store: s}
+}
+
+// GetPolicy handles GET /api/v1/organization/retention-policy
+func (h *RetentionHandler) GetPolicy(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	policy, err := h.store.Retention.GetPolicy(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"retention_policy": policy})
+}
+
+// UpdatePolicy handles PUT /api/v1/organization/retention-policy
+func (h *RetentionHandler) UpdatePolicy(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateRetentionPolicyInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.store.Retention.UpdatePolicy(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	oid := orgID.(uuid.UUID)
+	changes, _ := json.Marshal(input)
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Updated data retention policy",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"retention_policy": policy})
+}
+
+// AnonymizeUser handles POST /api/v1/users/:id/anonymize. The request is
+// recorded first and processed immediately; failures are left pending for
+// the worker to retry.
+func (h *RetentionHandler) AnonymizeUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	subjectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if subjectID == userID.(uuid.UUID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot anonymize your own account"})
+		return
+	}
+
+	var input models.CreateAnonymizationRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req, err := h.store.Retention.CreateAnonymizationRequest(c.Request.Context(), orgID.(uuid.UUID), subjectID, userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	metadata, _ := json.Marshal(gin.H{"request_id": req.ID, "reason": input.Reason})
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:             &uid,
+		Action:             models.AuditActionAnonymize,
+		ResourceType:       models.AuditResourceUser,
+		ResourceID:         &subjectID,
+		Description:        "Requested anonymization of user data",
+		Metadata:           metadata,
+		ComplianceRelevant: true,
+	})
+
+	processed, err := h.store.Retention.ProcessAnonymizationRequest(c.Request.Context(), req.ID)
+	if err != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"anonymization_request": req,
+			"message":               "anonymization failed and will be retried",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"anonymization_request": processed})
+}
+
+// ListAnonymizationRequests handles GET /api/v1/anonymization-requests
+func (h *RetentionHandler) ListAnonymizationRequests(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	requests, err := h.store.Retention.ListAnonymizationRequests(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"anonymization_requests": requests})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// SavedFilterHandler handles saved ticket filter (personal view) HTTP requests
+type SavedFilterHandler struct {
+	store *store.Store
+}
+
+// NewSavedFilterHandler creates a new saved filter handler
+func NewSavedFilterHandler(s *store.Store) *SavedFilterHandler {
+	return &SavedFilterHandler{store: s}
+}
+
+// ListSavedFilters handles GET /api/v1/saved-filters
+func (h *SavedFilterHandler) ListSavedFilters(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	filters, err := h.store.SavedFilters.List(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"saved_filters": filters})
+}
+
+// CreateSavedFilter handles POST /api/v1/saved-filters
+func (h *SavedFilterHandler) CreateSavedFilter(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateSavedFilterInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := h.store.SavedFilters.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"saved_filter": filter})
+}
+
+// GetSavedFilter handles GET /api/v1/saved-filters/:id
+func (h *SavedFilterHandler) GetSavedFilter(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid saved filter ID"})
+		return
+	}
+
+	filter, err := h.store.SavedFilters.GetByID(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), filterID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"saved_filter": filter})
+}
+
+// UpdateSavedFilter handles PATCH /api/v1/saved-filters/:id
+func (h *SavedFilterHandler) UpdateSavedFilter(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid saved filter ID"})
+		return
+	}
+
+	var input models.UpdateSavedFilterInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := h.store.SavedFilters.Update(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), filterID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"saved_filter": filter})
+}
+
+// SetDefaultSavedFilter handles POST /api/v1/saved-filters/:id/default
+func (h *SavedFilterHandler) SetDefaultSavedFilter(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid saved filter ID"})
+		return
+	}
+
+	filter, err := h.store.SavedFilters.SetDefault(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), filterID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"saved_filter": filter})
+}
+
+// DeleteSavedFilter handles DELETE /api/v1/saved-filters/:id
+func (h *SavedFilterHandler) DeleteSavedFilter(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	filterID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid saved filter ID"})
+		return
+	}
+
+	if err := h.store.SavedFilters.Delete(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), filterID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Saved filter deleted",
+	})
+}
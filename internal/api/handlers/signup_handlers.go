@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/notifications"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// SignupHandler handles self-service signup and failed signup follow-up
+type SignupHandler struct {
+	store *store.Store
+	cfg   *config.Config
+}
+
+// NewSignupHandler creates a new signup handler
+func NewSignupHandler(s *store.Store, cfg *config.Config) *SignupHandler {
+	return &SignupHandler{store: s, cfg: cfg}
+}
+
+// Signup handles POST /api/v1/signup
+// Creates an organization and its first admin, then emails a verification link.
+func (h *SignupHandler) Signup(c *gin.Context) {
+	var input models.SignupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.rejectSignup(c, input.Email, http.StatusBadRequest, err)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		h.rejectSignup(c, input.Email, http.StatusBadRequest, err)
+		return
+	}
+
+	exists, err := h.store.Organizations.SlugExists(c.Request.Context(), input.Slug)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if exists {
+		h.rejectSignup(c, input.Email, http.StatusConflict, errors.New("organization slug already exists"))
+		return
+	}
+
+	org, user, token, err := h.store.Organizations.Signup(c.Request.Context(), &input)
+	if err != nil {
+		if errors.Is(err, models.ErrConflict) {
+			h.rejectSignup(c, input.Email, http.StatusConflict, err)
+			return
+		}
+		respondStoreError(c, err)
+		return
+	}
+
+	msg := notifications.EmailVerification(user, org, token, h.cfg.Email.BaseURL)
+	if err := h.store.Organizations.QueueVerificationEmail(c.Request.Context(), user, msg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "organization created but failed to send verification email: " + err.Error()})
+		return
+	}
+
+	recordAudit(c, h.store, org.ID, &models.CreateAuditLogInput{
+		UserID:       &user.ID,
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &org.ID,
+		Description:  "Organization created via self-service signup",
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"organization": org,
+		"user":         user.ToSummary(),
+		"message":      "check your email to verify your account",
+	})
+}
+
+// rejectSignup records a failed attempt and tells the client whether to
+// offer the contact form
+func (h *SignupHandler) rejectSignup(c *gin.Context, email string, status int, err error) {
+	resp := gin.H{"error": err.Error()}
+	if email != "" {
+		count, trackErr := h.store.Signups.TrackFailure(c.Request.Context(), email, c.ClientIP())
+		if trackErr == nil && count >= models.FailedSignupContactThreshold {
+			resp["collect_contact"] = true
+		}
+	}
+	c.JSON(status, resp)
+}
+
+// VerifyEmail handles POST /api/v1/signup/verify-email
+func (h *SignupHandler) VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	user, err := h.store.Organizations.VerifyEmail(c.Request.Context(), input.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":           user.ToSummary(),
+		"email_verified": user.EmailVerified,
+	})
+}
+
+// CollectFailedSignupContact handles POST /api/v1/signup/contact
+// Called by the signup form after repeated failures so we can follow up.
+func (h *SignupHandler) CollectFailedSignupContact(c *gin.Context) {
+	var input models.CollectContactInfoInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if input.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+	if input.PreferredContact != nil && !input.PreferredContact.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid preferred_contact"})
+		return
+	}
+
+	if _, err := h.store.Signups.CollectContact(c.Request.Context(), c.ClientIP(), &input); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "thanks, we'll be in touch"})
+}
+
+// ListFailedSignups handles GET /api/v1/failed-signups (platform admin)
+func (h *SignupHandler) ListFailedSignups(c *gin.Context) {
+	filter := &models.FailedSignupFilter{}
+	switch c.Query("contact_collected") {
+	case "true":
+		collected := true
+		filter.ContactCollected = &collected
+	case "false":
+		collected := false
+		filter.ContactCollected = &collected
+	}
+	filter.Unresolved = c.Query("unresolved") == "true"
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	attempts, total, err := h.store.Signups.List(c.Request.Context(), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"failed_signups": attempts,
+		"total":          total,
+		"page":           filter.Page,
+		"per_page":       filter.PerPage,
+	})
+}
+
+// ResolveFailedSignup handles POST /api/v1/failed-signups/:id/resolve (platform admin)
+func (h *SignupHandler) ResolveFailedSignup(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid failed signup ID"})
+		return
+	}
+
+	var input models.ResolveFailedSignupInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Resolution == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resolution is required"})
+		return
+	}
+
+	attempt, err := h.store.Signups.Resolve(c.Request.Context(), id, userID.(uuid.UUID), &input)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"failed_signup": attempt})
+}
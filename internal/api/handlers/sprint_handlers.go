@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// SprintHandler handles sprint HTTP requests
+type SprintHandler struct {
+	store *store.Store
+}
+
+// NewSprintHandler creates a new sprint handler
+func NewSprintHandler(s *store.Store) *SprintHandler {
+	return &SprintHandler{store: s}
+}
+
+// ListSprints handles GET /api/v1/sprints
+func (h *SprintHandler) ListSprints(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	filter := &models.SprintListFilter{}
+	if projectID := c.Query("project_id"); projectID != "" {
+		uid, err := uuid.Parse(projectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+			return
+		}
+		filter.ProjectID = &uid
+	}
+	if status := c.Query("status"); status != "" {
+		s := models.SprintStatus(status)
+		if !s.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint status: " + status})
+			return
+		}
+		filter.Status = &s
+	}
+
+	sprints, err := h.store.Sprints.List(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sprints": sprints,
+		"count":   len(sprints),
+	})
+}
+
+// CreateSprint handles POST /api/v1/sprints
+func (h *SprintHandler) CreateSprint(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateSprintInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkProject(c, h.store, orgID.(uuid.UUID), input.ProjectID) {
+		return
+	}
+
+	sprint, err := h.store.Sprints.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"sprint": sprint,
+	})
+}
+
+// GetSprint handles GET /api/v1/sprints/:id
+func (h *SprintHandler) GetSprint(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	sprintID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint ID"})
+		return
+	}
+
+	sprint, err := h.store.Sprints.GetByID(c.Request.Context(), orgID.(uuid.UUID), sprintID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	rollup, err := h.store.Sprints.Rollup(c.Request.Context(), orgID.(uuid.UUID), sprintID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sprint": sprint,
+		"rollup": rollup,
+	})
+}
+
+// GetSprintRollup handles GET /api/v1/sprints/:id/rollup
+func (h *SprintHandler) GetSprintRollup(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	sprintID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint ID"})
+		return
+	}
+
+	if _, err := h.store.Sprints.GetByID(c.Request.Context(), orgID.(uuid.UUID), sprintID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	rollup, err := h.store.Sprints.Rollup(c.Request.Context(), orgID.(uuid.UUID), sprintID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sprint_id": sprintID,
+		"rollup":    rollup,
+	})
+}
+
+// GetSprintBurndown handles GET /api/v1/sprints/:id/burndown
+func (h *SprintHandler) GetSprintBurndown(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	sprintID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint ID"})
+		return
+	}
+
+	sprint, err := h.store.Sprints.GetByID(c.Request.Context(), orgID.(uuid.UUID), sprintID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	burndown, err := h.store.Sprints.Burndown(c.Request.Context(), sprint)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"burndown": burndown,
+	})
+}
+
+// UpdateSprint handles PATCH /api/v1/sprints/:id
+func (h *SprintHandler) UpdateSprint(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	sprintID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint ID"})
+		return
+	}
+
+	var input models.UpdateSprintInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	current, err := h.store.Sprints.GetByID(c.Request.Context(), orgID.(uuid.UUID), sprintID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := input.Validate(current); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sprint, err := h.store.Sprints.Update(c.Request.Context(), orgID.(uuid.UUID), sprintID, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sprint": sprint,
+	})
+}
+
+// DeleteSprint handles DELETE /api/v1/sprints/:id
+func (h *SprintHandler) DeleteSprint(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	sprintID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sprint ID"})
+		return
+	}
+
+	if err := h.store.Sprints.Delete(c.Request.Context(), orgID.(uuid.UUID), sprintID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sprint deleted",
+	})
+}
+
+// checkPlanning verifies the epic and sprint a ticket is linked to belong to
+// the organization, responding with 400 if they don't. uuid.Nil unlinks and
+// is always accepted.
+func checkPlanning(c *gin.Context, s *store.Store, orgID uuid.UUID, epicID, sprintID *uuid.UUID) bool {
+	ctx := c.Request.Context()
+	var err error
+	if epicID != nil && *epicID != uuid.Nil {
+		_, err = s.Epics.GetByID(ctx, orgID, *epicID)
+	}
+	if err == nil && sprintID != nil && *sprintID != uuid.Nil {
+		_, err = s.Sprints.GetByID(ctx, orgID, *sprintID)
+	}
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, models.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	} else {
+		respondStoreError(c, err)
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// TemplateHandler handles ticket template HTTP requests
+type TemplateHandler struct {
+	store *store.Store
+}
+
+// NewTemplateHandler creates a new ticket template handler
+func NewTemplateHandler(s *store.Store) *TemplateHandler {
+	return &TemplateHandler{store: s}
+}
+
+// canManageOrgTemplates reports whether the caller may change the
+// organization's templates, which is for admins
+func canManageOrgTemplates(c *gin.Context) bool {
+	a := store.AccessorFrom(c.Request.Context())
+	return a != nil && a.CanWriteAll()
+}
+
+// templateScope reads the scope query param of template changes, "user"
+// unless given. It writes the error response and returns false if the
+// scope is unknown or the caller can't change it.
+func templateScope(c *gin.Context) (string, bool) {
+	scope := c.DefaultQuery("scope", models.TemplateScopeUser)
+	switch scope {
+	case models.TemplateScopeUser:
+		return scope, true
+	case models.TemplateScopeOrg:
+		if !canManageOrgTemplates(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change organization templates"})
+			return "", false
+		}
+		return scope, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be user or org"})
+	return "", false
+}
+
+// ListTemplates handles GET /api/v1/templates
+func (h *TemplateHandler) ListTemplates(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	templates, err := h.store.Templates.List(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// GetTemplate handles GET /api/v1/templates/:name
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	template, err := h.store.Templates.Get(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), c.Param("name"))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"template": template})
+}
+
+// CreateTemplate handles POST /api/v1/templates
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateTicketTemplateInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Scope == models.TemplateScopeOrg && !canManageOrgTemplates(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can change organization templates"})
+		return
+	}
+
+	template, err := h.store.Templates.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"template": template})
+}
+
+// UpdateTemplate handles PATCH /api/v1/templates/:name?scope=
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	scope, ok := templateScope(c)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateTicketTemplateInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.store.Templates.Update(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), scope, c.Param("name"), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"template": template})
+}
+
+// DeleteTemplate handles DELETE /api/v1/templates/:name?scope=
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	scope, ok := templateScope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Templates.Delete(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), scope, c.Param("name")); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Template deleted",
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// TicketACLHandler handles ticket access control HTTP requests
+type TicketACLHandler struct {
+	store *store.Store
+}
+
+// NewTicketACLHandler creates a new ticket ACL handler
+func NewTicketACLHandler(s *store.Store) *TicketACLHandler {
+	return &TicketACLHandler{store: s}
+}
+
+// ListACLs handles GET /api/v1/tickets/:id/acls
+func (h *TicketACLHandler) ListACLs(c *gin.Context) {
+	ticket, access, ok := h.loadTicket(c)
+	if !ok {
+		return
+	}
+
+	acls, err := h.store.ACLs.List(c.Request.Context(), ticket.ID, c.Query("include_revoked") == "true")
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"acls":            acls,
+		"acl_inheritance": ticket.ACLInheritance,
+		"is_confidential": ticket.IsConfidential,
+		"access":          access,
+	})
+}
+
+// GrantACL handles POST /api/v1/tickets/:id/acls
+func (h *TicketACLHandler) GrantACL(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticket, access, ok := h.loadTicket(c)
+	if !ok {
+		return
+	}
+	if !access.CanManageACLs() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient ticket permissions"})
+		return
+	}
+
+	var input models.GrantTicketACLInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	input.TicketID = ticket.ID
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acl, err := h.store.ACLs.Grant(c.Request.Context(), orgID.(uuid.UUID), ticket.ID, userID.(uuid.UUID), &input)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondStoreError(c, err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"acl_id":         acl.ID,
+		"principal_type": acl.PrincipalType,
+		"principal_id":   acl.PrincipalID,
+		"role_name":      acl.RoleName,
+		"acl_role":       acl.ACLRole,
+		"expires_at":     acl.ExpiresAt,
+		"reason":         acl.Reason,
+	}
+	h.store.Audit.LogTicketAccess(c.Request.Context(), ticket.ID, userID.(uuid.UUID), models.AuditActionACLGrant, nil, nil, changes)
+
+	c.JSON(http.StatusCreated, gin.H{"acl": acl})
+}
+
+// RevokeACL handles DELETE /api/v1/tickets/:id/acls/:acl_id
+func (h *TicketACLHandler) RevokeACL(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	aclID, err := uuid.Parse(c.Param("acl_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ACL ID"})
+		return
+	}
+
+	ticket, access, ok := h.loadTicket(c)
+	if !ok {
+		return
+	}
+	if !access.CanManageACLs() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient ticket permissions"})
+		return
+	}
+
+	var input struct {
+		Reason *string `json:"reason"`
+	}
+	c.ShouldBindJSON(&input)
+
+	acl, err := h.store.ACLs.Revoke(c.Request.Context(), ticket.ID, aclID, userID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	changes := map[string]interface{}{
+		"acl_id":         acl.ID,
+		"principal_type": acl.PrincipalType,
+		"principal_id":   acl.PrincipalID,
+		"role_name":      acl.RoleName,
+		"acl_role":       acl.ACLRole,
+		"reason":         input.Reason,
+	}
+	h.store.Audit.LogTicketAccess(c.Request.Context(), ticket.ID, userID.(uuid.UUID), models.AuditActionACLRevoke, nil, nil, changes)
+
+	c.JSON(http.StatusOK, gin.H{"acl": acl})
+}
+
+// loadTicket fetches the ticket named in the path along with the caller's
+// effective access, writing the error response on failure
+func (h *TicketACLHandler) loadTicket(c *gin.Context) (*models.Ticket, *models.TicketAccess, bool) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return nil, nil, false
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, nil, false
+	}
+
+	access, err := h.store.ACLs.EffectiveAccess(c.Request.Context(), ticket)
+	if err != nil {
+		respondStoreError(c, err)
+		return nil, nil, false
+	}
+
+	return ticket, access, true
+}
@@ -1,71 +1,217 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
 	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/notifications"
+	"github.com/afterdarksys/adsops-utils/internal/queuebot"
 	"github.com/afterdarksys/adsops-utils/internal/store"
 )
 
 // TicketHandler handles ticket-related HTTP requests
 type TicketHandler struct {
 	store *store.Store
+	cfg   *config.Config
 }
 
 // NewTicketHandler creates a new ticket handler
-func NewTicketHandler(s *store.Store) *TicketHandler {
-	return &TicketHandler{store: s}
+func NewTicketHandler(s *store.Store, cfg *config.Config) *TicketHandler {
+	return &TicketHandler{store: s, cfg: cfg}
 }
 
-// CreateTicket handles POST /api/v1/tickets
+// CreateTicket handles POST /api/v1/tickets. With ?template=<name> the
+// template fills in the fields the body leaves out.
 func (h *TicketHandler) CreateTicket(c *gin.Context) {
+	// Get user and org from context (set by auth middleware)
+	userID, _ := c.Get("user_id")
+	orgID, _ := c.Get("org_id")
+
 	var input models.CreateTicketInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if name := c.Query("template"); name != "" {
+		if !h.bindTemplated(c, orgID.(uuid.UUID), userID.(uuid.UUID), name, &input) {
+			return
+		}
+	} else if !bindJSON(c, &input) {
 		return
 	}
 
-	// Get user and org from context (set by auth middleware)
-	userID, _ := c.Get("user_id")
-	orgID, _ := c.Get("org_id")
+	// Fill in organization defaults for anything the ticket doesn't specify
+	if input.Industry == "" || len(input.ComplianceFrameworks) == 0 {
+		industry, frameworks, err := h.store.Organizations.GetTicketDefaults(c.Request.Context(), orgID.(uuid.UUID))
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		if input.Industry == "" {
+			input.Industry = industry
+		}
+		if len(input.ComplianceFrameworks) == 0 {
+			input.ComplianceFrameworks = frameworks
+		}
+	}
 
-	ticket, err := h.store.Tickets.Create(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !checkCustomFields(c, h.store, orgID.(uuid.UUID), input.ChangeType, input.CustomFields, nil) {
+		return
+	}
+	if !checkLabels(c, h.store, orgID.(uuid.UUID), input.Labels, nil) {
+		return
+	}
+	if !checkPlanning(c, h.store, orgID.(uuid.UUID), input.EpicID, input.SprintID) {
 		return
 	}
 
-	// Log audit
-	h.store.Audit.LogTicketAccess(c.Request.Context(), ticket.ID, userID.(uuid.UUID), "create", nil, nil, nil)
+	// The ticket, its audit trail, its webhook events and, when it is
+	// submitted straight away, the submit, its approvals and its
+	// notifications are saved together or not at all
+	ctx := c.Request.Context()
+	var ticket *models.Ticket
+	var complianceErr *models.ComplianceError
+	var warnings []string
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		var err error
+		ticket, err = tx.Tickets.Create(ctx, orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+		if err != nil {
+			return err
+		}
+		// The production hosts among the affected systems count towards the
+		// risk score approval rules may match on
+		if len(ticket.AffectedSystems) > 0 {
+			warnings = resolveAffectedSystems(ctx, tx, orgID.(uuid.UUID), ticket)
+		}
+		if err := tx.Audit.LogTicketAccess(ctx, ticket.ID, userID.(uuid.UUID), "create", nil, nil, nil); err != nil {
+			return fmt.Errorf("failed to record audit log: %w", err)
+		}
+		if err := enqueueWebhook(c, tx, orgID.(uuid.UUID), models.WebhookEventTicketCreated, ticket); err != nil {
+			return err
+		}
+		if !input.Submit {
+			return nil
+		}
 
-	// Submit if requested
-	if input.Submit {
-		if err := h.store.Tickets.Submit(c.Request.Context(), orgID.(uuid.UUID), ticket.ID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "ticket created but failed to submit: " + err.Error()})
-			return
+		plan, err := tx.ApprovalRules.Evaluate(ctx, ticket)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate approval rules: %w", err)
+		}
+		if err := models.ValidateCompliance(ticket, plan); err != nil {
+			errors.As(err, &complianceErr)
+			return err
+		}
+		transition, err := tx.Tickets.Submit(ctx, orgID.(uuid.UUID), ticket.ID, userID.(uuid.UUID), plan, 0)
+		if err != nil {
+			return fmt.Errorf("failed to submit: %w", err)
+		}
+		if err := logTransition(c, tx, orgID.(uuid.UUID), ticket.ID, userID.(uuid.UUID), transition); err != nil {
+			return err
+		}
+		approvals, err := tx.Approvals.Create(ctx, ticket, plan)
+		if err != nil {
+			return err
+		}
+		if err := h.requestApprovals(ctx, tx, ticket, approvals); err != nil {
+			return err
+		}
+		ticket.Status = transition.To
+		if !plan.AutoApprove && ticket.IsEmergencyChange() {
+			if _, err := h.startEmergencyWorkflow(ctx, tx, orgID.(uuid.UUID), ticket.ID, userID.(uuid.UUID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if complianceErr != nil {
+		respondComplianceError(c, complianceErr)
+		return
+	}
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	// Drafts may be incomplete, but say what submitting will need
+	var draftGaps *models.ComplianceError
+	if err := models.ValidateCompliance(ticket, nil); !input.Submit && errors.As(err, &draftGaps) {
+		for _, issue := range draftGaps.Issues {
+			warnings = append(warnings, issue.String())
 		}
-		ticket.Status = models.TicketStatusSubmitted
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"ticket": ticket,
-	})
+	}
+	if input.Submit {
+		if assignment := h.autoAssign(c.Request.Context(), orgID.(uuid.UUID), ticket.ID); assignment != nil {
+			ticket.AssignedTo = assignment.AssignedTo
+			response["assignment"] = assignment
+		}
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // ListTickets handles GET /api/v1/tickets
 func (h *TicketHandler) ListTickets(c *gin.Context) {
 	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
 
-	// Parse filter from query params
+	// Start from a saved view when one is named; query params refine it
 	filter := &models.TicketListFilter{}
+	if view := c.Query("view"); view != "" {
+		saved, err := h.loadSavedView(c, orgID.(uuid.UUID), userID.(uuid.UUID), view)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		filter = &saved.Filter
+	}
+
+	// Parse filter from query params
 
 	if status := c.Query("status"); status != "" {
-		filter.Status = []models.TicketStatus{models.TicketStatus(status)}
+		filter.Status = nil
+		for _, v := range splitQueryList(status) {
+			filter.Status = append(filter.Status, models.TicketStatus(v))
+		}
 	}
 	if priority := c.Query("priority"); priority != "" {
-		filter.Priority = []models.TicketPriority{models.TicketPriority(priority)}
+		filter.Priority = nil
+		for _, v := range splitQueryList(priority) {
+			filter.Priority = append(filter.Priority, models.TicketPriority(v))
+		}
+	}
+	if risk := c.Query("risk_level"); risk != "" {
+		filter.RiskLevel = nil
+		for _, v := range splitQueryList(risk) {
+			filter.RiskLevel = append(filter.RiskLevel, models.RiskLevel(v))
+		}
+	}
+	if assignee := c.Query("assigned_to"); assignee != "" {
+		uid, ok := parseUserQuery(assignee, userID.(uuid.UUID))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid assigned_to"})
+			return
+		}
+		filter.AssignedTo = &uid
+	}
+	if creator := c.Query("created_by"); creator != "" {
+		uid, ok := parseUserQuery(creator, userID.(uuid.UUID))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid created_by"})
+			return
+		}
+		filter.CreatedBy = &uid
 	}
 	if search := c.Query("search"); search != "" {
 		filter.Search = search
@@ -75,27 +221,213 @@ func (h *TicketHandler) ListTickets(c *gin.Context) {
 			filter.ProjectID = &uid
 		}
 	}
+	if epicID := c.Query("epic_id"); epicID != "" {
+		if uid, err := uuid.Parse(epicID); err == nil {
+			filter.EpicID = &uid
+		}
+	}
+	if sprintID := c.Query("sprint_id"); sprintID != "" {
+		if uid, err := uuid.Parse(sprintID); err == nil {
+			filter.SprintID = &uid
+		}
+	}
+	if host := c.Query("host"); host != "" {
+		filter.Host = host
+	}
+	if labels := c.Query("labels"); labels != "" {
+		filter.Labels = splitQueryList(labels)
+	}
 	if c.Query("needs_assignment") == "true" {
 		filter.NeedsAssignment = true
 	}
+	switch c.Query("confidential") {
+	case "true":
+		confidential := true
+		filter.IsConfidential = &confidential
+	case "false":
+		confidential := false
+		filter.IsConfidential = &confidential
+	}
 
-	filter.Page = 1
-	filter.PerPage = 50
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", filter.PerPage)
+	if sortBy := c.Query("sort_by"); sortBy != "" {
+		filter.SortBy = sortBy
+	}
+	if sortOrder := c.Query("sort_order"); sortOrder != "" {
+		filter.SortOrder = sortOrder
+	}
+	filter.Cursor = c.Query("cursor")
+	// List views can show each ticket's latest comments
+	latestComments, err := parseIntQuery(c, "comments", 0)
+	if err != nil || latestComments < 0 || latestComments > models.MaxListComments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("comments must be between 0 and %d", models.MaxListComments)})
+		return
+	}
 
 	tickets, total, err := h.store.Tickets.List(c.Request.Context(), orgID.(uuid.UUID), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.loadTicketUsers(c.Request.Context(), orgID.(uuid.UUID), tickets); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if err := h.setTicketSLAs(c.Request.Context(), orgID.(uuid.UUID), tickets); err != nil {
+		respondStoreError(c, err)
 		return
 	}
+	if latestComments > 0 && len(tickets) > 0 {
+		ids := make([]uuid.UUID, len(tickets))
+		byID := make(map[uuid.UUID]*models.Ticket, len(tickets))
+		for i := range tickets {
+			ids[i] = tickets[i].ID
+			byID[tickets[i].ID] = &tickets[i]
+		}
+		comments, err := h.store.Comments.LatestByTickets(c.Request.Context(), orgID.(uuid.UUID), ids, latestComments)
+		if err != nil {
+			respondStoreError(c, err)
+			return
+		}
+		for _, comment := range comments {
+			t := byID[comment.TicketID]
+			t.Comments = append(t.Comments, comment)
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"tickets": tickets,
 		"total":   total,
-		"page":    filter.Page,
 		"per_page": filter.PerPage,
+	}
+	if filter.Cursor == "" {
+		response["page"] = filter.Page
+	}
+	if filter.NextCursor != "" {
+		response["next_cursor"] = filter.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// setTicketSLAs sets each ticket's approval SLA under the organization's
+// windows
+func (h *TicketHandler) setTicketSLAs(ctx context.Context, orgID uuid.UUID, tickets []models.Ticket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+	settings, err := h.store.Organizations.GetSettings(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range tickets {
+		tickets[i].SLA = settings.TicketSLA(&tickets[i], now)
+	}
+	return nil
+}
+
+// loadTicketUsers fills in the creator and assignee of each ticket, looking
+// all of them up in one query rather than one per ticket
+func (h *TicketHandler) loadTicketUsers(ctx context.Context, orgID uuid.UUID, tickets []models.Ticket) error {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for i := range tickets {
+		for _, id := range []*uuid.UUID{&tickets[i].CreatedBy, tickets[i].AssignedTo} {
+			if id != nil && !seen[*id] {
+				seen[*id] = true
+				ids = append(ids, *id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	users, err := h.store.Users.GetSummaries(ctx, orgID, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]*models.UserSummary, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range tickets {
+		t := &tickets[i]
+		t.Creator = byID[t.CreatedBy]
+		if t.AssignedTo != nil {
+			t.Assignee = byID[*t.AssignedTo]
+		}
+	}
+	return nil
+}
+
+// SearchTickets handles GET /api/v1/tickets/search?q=, returning the tickets
+// best matching q with an excerpt of each one's description
+func (h *TicketHandler) SearchTickets(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	limit, err := parseIntQuery(c, "limit", 20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if limit < 1 || limit > models.MaxTicketSearchResults {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", models.MaxTicketSearchResults)})
+		return
+	}
+
+	results, err := h.store.Tickets.Search(c.Request.Context(), orgID.(uuid.UUID), query, limit)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"count":   len(results),
 	})
 }
 
+// bindTemplated binds a ticket body and fills in what it leaves out from
+// the named template before validating it, so required fields may come
+// from either
+func (h *TicketHandler) bindTemplated(c *gin.Context, orgID, userID uuid.UUID, name string, input *models.CreateTicketInput) bool {
+	if err := c.ShouldBindJSON(input); err != nil {
+		respondInvalidPayload(c, err)
+		return false
+	}
+	template, err := h.store.Templates.Get(c.Request.Context(), orgID, userID, name)
+	if err != nil {
+		respondStoreError(c, err)
+		return false
+	}
+	template.Ticket.Apply(input)
+	if err := payloadValidator().Struct(input); err != nil {
+		respondInvalidPayload(c, err)
+		return false
+	}
+	return true
+}
+
+// loadSavedView resolves the view query param: a saved filter ID, or
+// "default" for the caller's default view
+func (h *TicketHandler) loadSavedView(c *gin.Context, orgID, userID uuid.UUID, view string) (*models.SavedFilter, error) {
+	if view == "default" {
+		return h.store.SavedFilters.GetDefault(c.Request.Context(), orgID, userID)
+	}
+	filterID, err := uuid.Parse(view)
+	if err != nil {
+		return nil, models.NotFound("saved filter not found")
+	}
+	return h.store.SavedFilters.GetByID(c.Request.Context(), orgID, userID, filterID)
+}
+
 // GetTicket handles GET /api/v1/tickets/:id
 func (h *TicketHandler) GetTicket(c *gin.Context) {
 	orgID, _ := c.Get("org_id")
@@ -109,7 +441,7 @@ func (h *TicketHandler) GetTicket(c *gin.Context) {
 
 	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		respondStoreError(c, err)
 		return
 	}
 
@@ -120,6 +452,18 @@ func (h *TicketHandler) GetTicket(c *gin.Context) {
 	repos, _ := h.store.Repositories.GetTicketRepositories(c.Request.Context(), ticketID)
 	ticket.Repositories = repos
 
+	// Get the inventory hosts the affected systems resolved to
+	ticket.AffectedResources, _ = h.store.Tickets.ListAffectedResources(c.Request.Context(), ticketID)
+
+	tickets := []models.Ticket{*ticket}
+	if err := h.loadTicketUsers(c.Request.Context(), orgID.(uuid.UUID), tickets); err == nil {
+		ticket.Creator, ticket.Assignee = tickets[0].Creator, tickets[0].Assignee
+	}
+	if err := h.setTicketSLAs(c.Request.Context(), orgID.(uuid.UUID), tickets); err == nil {
+		ticket.SLA = tickets[0].SLA
+	}
+
+	setTicketETag(c, ticket.Version)
 	c.JSON(http.StatusOK, gin.H{
 		"ticket": ticket,
 	})
@@ -137,23 +481,68 @@ func (h *TicketHandler) UpdateTicket(c *gin.Context) {
 	}
 
 	var input models.UpdateTicketInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	version, ok := requireVersion(c, input.Version)
+	if !ok {
+		return
+	}
+	input.Version = &version
+
+	if !checkPlanning(c, h.store, orgID.(uuid.UUID), input.EpicID, input.SprintID) {
 		return
 	}
 
-	ticket, err := h.store.Tickets.Update(c.Request.Context(), orgID.(uuid.UUID), ticketID, &input)
+	// Custom fields are merged into the ticket's current ones and the result
+	// is checked against the organization's schema. Labels must be defined
+	// unless the ticket already has them.
+	if input.CustomFields != nil || input.Labels != nil {
+		current, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+		if err != nil {
+			respondTicketError(c, err, http.StatusInternalServerError)
+			return
+		}
+		if input.CustomFields != nil {
+			merged, err := models.MergeCustomFields(current.CustomFields, input.CustomFields)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if !checkCustomFields(c, h.store, orgID.(uuid.UUID), current.ChangeType, merged, current.CustomFields) {
+				return
+			}
+			input.CustomFields = merged
+		}
+		if input.Labels != nil && !checkLabels(c, h.store, orgID.(uuid.UUID), input.Labels, current.Labels) {
+			return
+		}
+	}
+
+	ticket, err := h.store.Tickets.Update(c.Request.Context(), orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), &input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondTicketError(c, err, http.StatusInternalServerError)
 		return
 	}
+	setTicketETag(c, ticket.Version)
 
 	// Log audit
 	h.store.Audit.LogTicketEdit(c.Request.Context(), ticketID, userID.(uuid.UUID), nil, nil, nil)
+	enqueueWebhook(c, h.store, orgID.(uuid.UUID), models.WebhookEventTicketUpdated, ticket)
 
-	c.JSON(http.StatusOK, gin.H{
+	var warnings []string
+	if input.AffectedSystems != nil {
+		warnings = resolveAffectedSystems(c.Request.Context(), h.store, orgID.(uuid.UUID), ticket)
+	}
+
+	response := gin.H{
 		"ticket": ticket,
-	})
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // SubmitTicket handles POST /api/v1/tickets/:id/submit
@@ -167,16 +556,230 @@ func (h *TicketHandler) SubmitTicket(c *gin.Context) {
 		return
 	}
 
-	if err := h.store.Tickets.Submit(c.Request.Context(), orgID.(uuid.UUID), ticketID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var input struct {
+		Version *int `json:"version"`
+	}
+	c.ShouldBindJSON(&input)
+
+	version, ok := requireVersion(c, input.Version)
+	if !ok {
 		return
 	}
 
-	// Log status change
-	h.store.Audit.LogTicketStatusChange(c.Request.Context(), ticketID, userID.(uuid.UUID), "draft", "submitted", nil, nil)
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	plan, err := h.store.ApprovalRules.Evaluate(c.Request.Context(), ticket)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if err := models.ValidateCompliance(ticket, plan); err != nil {
+		var gaps *models.ComplianceError
+		if errors.As(err, &gaps) {
+			respondComplianceError(c, gaps)
+		} else {
+			respondStoreError(c, err)
+		}
+		return
+	}
+
+	// The submit, its audit trail and webhook events, the approvals it fans
+	// out to and their requests, and an emergency change's on-call
+	// notifications are saved together or not at all
+	ctx := c.Request.Context()
+	notified := 0
+	var submitErr error
+	err = h.store.WithTx(ctx, func(tx *store.Store) error {
+		transition, err := tx.Tickets.Submit(ctx, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), plan, version)
+		if err != nil {
+			submitErr = err
+			return err
+		}
+		if err := logTransition(c, tx, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), transition); err != nil {
+			return err
+		}
+		approvals, err := tx.Approvals.Create(ctx, ticket, plan)
+		if err != nil {
+			return err
+		}
+		if err := h.requestApprovals(ctx, tx, ticket, approvals); err != nil {
+			return err
+		}
+		if !plan.AutoApprove && ticket.IsEmergencyChange() {
+			notified, err = h.startEmergencyWorkflow(ctx, tx, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID))
+			return err
+		}
+		return nil
+	})
+	if submitErr != nil {
+		respondTicketError(c, submitErr, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if plan.AutoApprove {
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "Ticket auto-approved by approval rules",
+			"approval_plan": plan,
+		})
+		return
+	}
+
+	response := gin.H{
+		"message":       "Ticket submitted for approval",
+		"approval_plan": plan,
+	}
+	if ticket.IsEmergencyChange() {
+		response["message"] = "Emergency ticket submitted; on-call approvers notified"
+		response["on_call_notified"] = notified
+	}
+	if assignment := h.autoAssign(c.Request.Context(), orgID.(uuid.UUID), ticketID); assignment != nil {
+		response["assignment"] = assignment
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// logTransition records a status change made through the ticket's workflow
+// and tells webhook subscribers about it
+func logTransition(c *gin.Context, s *store.Store, orgID, ticketID, userID uuid.UUID, transition *models.TicketTransition) error {
+	if err := s.Audit.LogTicketStatusChange(c.Request.Context(), ticketID, userID, string(transition.From), string(transition.To), nil, nil); err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+
+	return enqueueWebhook(c, s, orgID, models.WebhookEventTicketStatusChanged, gin.H{
+		"ticket_id":  ticketID,
+		"from":       transition.From,
+		"to":         transition.To,
+		"changed_by": userID,
+	})
+}
+
+// autoAssign runs the queue bot on a freshly submitted ticket. Assignment
+// is best-effort: the submit has already succeeded, so failures only leave
+// the ticket in the queue for the worker's next pass. Returns nil unless
+// the ticket was assigned.
+func (h *TicketHandler) autoAssign(ctx context.Context, orgID, ticketID uuid.UUID) *models.AssignmentResult {
+	ticket, err := h.store.Tickets.GetByID(ctx, orgID, ticketID)
+	if err != nil {
+		return nil
+	}
+	result, err := queuebot.New(h.store).AssignTicket(ctx, ticket, models.AssignmentTriggerSubmit)
+	if err != nil || result.AssignedTo == nil {
+		return nil
+	}
+	return result
+}
+
+// requestApprovals emails each approver their approval request, with the
+// link that decides it, through s so that it can join the submit's
+// transaction
+func (h *TicketHandler) requestApprovals(ctx context.Context, s *store.Store, ticket *models.Ticket, approvals []models.Approval) error {
+	if len(approvals) == 0 {
+		return nil
+	}
+
+	approverIDs := make([]uuid.UUID, len(approvals))
+	for i := range approvals {
+		approverIDs[i] = approvals[i].ApproverID
+	}
+	approvers, err := s.Users.GetSummaries(ctx, ticket.OrganizationID, approverIDs)
+	if err != nil {
+		return err
+	}
+	emails := make(map[uuid.UUID]string, len(approvers))
+	for _, u := range approvers {
+		emails[u.ID] = u.Email
+	}
+
+	queued := make([]models.QueuedNotification, 0, len(approvals))
+	for i := range approvals {
+		a := &approvals[i]
+		queued = append(queued, models.QueuedNotification{
+			Recipient: models.NotificationRecipient{
+				OrganizationID: ticket.OrganizationID,
+				UserID:         &a.ApproverID,
+				Email:          emails[a.ApproverID],
+				TicketID:       &a.TicketID,
+				ApprovalID:     &a.ID,
+			},
+			Message: notifications.ApprovalRequest(ticket, a, h.cfg.Email.BaseURL),
+		})
+	}
+	_, err = s.Notifications.EnqueueMany(ctx, queued)
+	return err
+}
+
+// startEmergencyWorkflow pages on-call approvers for a freshly submitted
+// emergency ticket and records the expedited path in the audit log, through
+// s so that it can join the submit's transaction. Returns the number of
+// approvers notified.
+func (h *TicketHandler) startEmergencyWorkflow(ctx context.Context, s *store.Store, orgID, ticketID, userID uuid.UUID) (int, error) {
+	// Reload to pick up the shortened approval deadline set on submit
+	ticket, err := s.Tickets.GetByID(ctx, orgID, ticketID)
+	if err != nil {
+		return 0, err
+	}
+
+	msg := notifications.EmergencyApproval(ticket, h.cfg.Email.BaseURL)
+	notified, err := s.Emergency.NotifyOnCallApprovers(ctx, ticket, msg, false)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := map[string]interface{}{
+		"approval_deadline": ticket.ApprovalDeadline,
+		"on_call_notified": notified,
+	}
+	if err := s.Audit.LogTicketAccess(ctx, ticketID, userID, models.AuditActionEmergencySubmit, nil, nil, changes); err != nil {
+		return 0, fmt.Errorf("failed to record audit log: %w", err)
+	}
+
+	return notified, nil
+}
+
+// GetApprovalPlan handles GET /api/v1/tickets/:id/approval-plan
+// Returns the plan frozen at submit, or a preview for unsubmitted tickets.
+func (h *TicketHandler) GetApprovalPlan(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	if len(ticket.ApprovalPlan) > 0 && !ticket.CanSubmit() {
+		c.JSON(http.StatusOK, gin.H{
+			"approval_plan": ticket.ApprovalPlan,
+			"preview":       false,
+		})
+		return
+	}
+
+	plan, err := h.store.ApprovalRules.Evaluate(c.Request.Context(), ticket)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Ticket submitted for approval",
+		"approval_plan": plan,
+		"preview":       true,
 	})
 }
 
@@ -192,17 +795,24 @@ func (h *TicketHandler) CancelTicket(c *gin.Context) {
 	}
 
 	var input struct {
-		Reason string `json:"reason"`
+		Reason  string `json:"reason"`
+		Version *int   `json:"version"`
 	}
 	c.ShouldBindJSON(&input)
 
-	if err := h.store.Tickets.Cancel(c.Request.Context(), orgID.(uuid.UUID), ticketID, input.Reason); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	version, ok := requireVersion(c, input.Version)
+	if !ok {
+		return
+	}
+
+	transition, err := h.store.Tickets.Cancel(c.Request.Context(), orgID.(uuid.UUID), ticketID, input.Reason, version)
+	if err != nil {
+		respondTicketError(c, err, http.StatusBadRequest)
 		return
 	}
 
 	// Log status change
-	h.store.Audit.LogTicketStatusChange(c.Request.Context(), ticketID, userID.(uuid.UUID), "", "cancelled", nil, nil)
+	logTransition(c, h.store, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), transition)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ticket cancelled",
@@ -220,13 +830,45 @@ func (h *TicketHandler) CloseTicket(c *gin.Context) {
 		return
 	}
 
-	if err := h.store.Tickets.Close(c.Request.Context(), orgID.(uuid.UUID), ticketID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var input struct {
+		Version *int `json:"version"`
+	}
+	c.ShouldBindJSON(&input)
+
+	version, ok := requireVersion(c, input.Version)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	transition, err := h.store.Tickets.Close(c.Request.Context(), orgID.(uuid.UUID), ticketID, version)
+	if err != nil {
+		respondTicketError(c, err, http.StatusBadRequest)
 		return
 	}
 
 	// Log status change
-	h.store.Audit.LogTicketStatusChange(c.Request.Context(), ticketID, userID.(uuid.UUID), "completed", "closed", nil, nil)
+	logTransition(c, h.store, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), transition)
+
+	// Emergency changes require a post-implementation review
+	if ticket.IsEmergencyChange() {
+		pir, err := h.store.PIRs.CreateRequired(c.Request.Context(), orgID.(uuid.UUID), ticketID,
+			models.PIRReasonEmergencyChange, time.Now().Add(models.EmergencyPIRWindow))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ticket closed but failed to create post-implementation review: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":                    "Ticket closed; post-implementation review required",
+			"post_implementation_review": pir,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ticket closed",
@@ -244,22 +886,166 @@ func (h *TicketHandler) ReopenTicket(c *gin.Context) {
 		return
 	}
 
-	if err := h.store.Tickets.UpdateStatus(c.Request.Context(), orgID.(uuid.UUID), ticketID, models.TicketStatusUpdateRequested); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var input struct {
+		Version *int `json:"version"`
+	}
+	c.ShouldBindJSON(&input)
+
+	version, ok := requireVersion(c, input.Version)
+	if !ok {
+		return
+	}
+
+	transition, err := h.store.Tickets.Reopen(c.Request.Context(), orgID.(uuid.UUID), ticketID, version)
+	if err != nil {
+		respondTicketError(c, err, http.StatusBadRequest)
 		return
 	}
 
 	// Log status change
-	h.store.Audit.LogTicketStatusChange(c.Request.Context(), ticketID, userID.(uuid.UUID), "closed", "update_requested", nil, nil)
+	logTransition(c, h.store, orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), transition)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ticket reopened",
 	})
 }
 
+// DeleteTicket handles DELETE /api/v1/tickets/:id. The ticket goes to the
+// trash, where it can be restored until the worker purges it.
+func (h *TicketHandler) DeleteTicket(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	var input models.DeleteTicketInput
+	c.ShouldBindJSON(&input)
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, ok := requireVersion(c, input.Version)
+	if !ok {
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	if err := h.store.Tickets.Delete(c.Request.Context(), orgID.(uuid.UUID), ticketID, uid, input.Reason, version); err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	description := "Moved ticket to trash"
+	if input.Reason != nil && *input.Reason != "" {
+		description += ": " + *input.Reason
+	}
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceTicket,
+		ResourceID:   &ticketID,
+		Description:  description,
+	})
+	enqueueWebhook(c, h.store, orgID.(uuid.UUID), models.WebhookEventTicketDeleted, gin.H{
+		"ticket_id":  ticketID,
+		"reason":     input.Reason,
+		"deleted_by": uid,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ticket moved to trash",
+	})
+}
+
+// ListTrash handles GET /api/v1/tickets/trash
+func (h *TicketHandler) ListTrash(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	page, err := parseIntQuery(c, "page", 1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	perPage, err := parseIntQuery(c, "per_page", 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+
+	policy, err := h.store.Retention.GetPolicy(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	tickets, total, err := h.store.Tickets.ListTrash(c.Request.Context(), orgID.(uuid.UUID), page, perPage)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	for i := range tickets {
+		tickets[i].PurgeAfter = tickets[i].DeletedAt.AddDate(0, 0, policy.TrashRetentionDays)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tickets":              tickets,
+		"total":                total,
+		"page":                 page,
+		"per_page":             perPage,
+		"trash_retention_days": policy.TrashRetentionDays,
+	})
+}
+
+// RestoreTicket handles POST /api/v1/tickets/:id/restore
+func (h *TicketHandler) RestoreTicket(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	ticket, err := h.store.Tickets.Restore(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		if errors.Is(err, models.ErrTicketPurged) {
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+			return
+		}
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionRestore,
+		ResourceType: models.AuditResourceTicket,
+		ResourceID:   &ticketID,
+		Description:  "Restored ticket " + ticket.TicketNumber + " from trash",
+	})
+	enqueueWebhook(c, h.store, orgID.(uuid.UUID), models.WebhookEventTicketRestored, ticket)
+
+	setTicketETag(c, ticket.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"ticket": ticket,
+	})
+}
+
 // GetTicketRevisions handles GET /api/v1/tickets/:id/revisions
 func (h *TicketHandler) GetTicketRevisions(c *gin.Context) {
-	// Return audit log for this ticket
 	orgID, _ := c.Get("org_id")
 
 	ticketID, err := uuid.Parse(c.Param("id"))
@@ -271,27 +1057,74 @@ func (h *TicketHandler) GetTicketRevisions(c *gin.Context) {
 	// Verify ticket exists
 	_, err = h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "ticket not found"})
+		respondStoreError(c, err)
 		return
 	}
 
-	filter := &models.AuditLogFilter{}
-	logs, total, err := h.store.Audit.GetTicketAuditLog(c.Request.Context(), ticketID, filter)
+	filter := &models.TicketRevisionFilter{}
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+
+	revisions, total, err := h.store.Revisions.ListByTicket(c.Request.Context(), orgID.(uuid.UUID), ticketID, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"revisions": logs,
+		"revisions": revisions,
 		"total":     total,
+		"page":      filter.Page,
+		"per_page":  filter.PerPage,
 	})
 }
 
 // GetTicketAudit handles GET /api/v1/tickets/:id/audit
 func (h *TicketHandler) GetTicketAudit(c *gin.Context) {
-	// Alias for GetTicketRevisions
-	h.GetTicketRevisions(c)
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	// Verify ticket exists
+	_, err = h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	filter := &models.AuditLogFilter{Cursor: c.Query("cursor")}
+	filter.Page, _ = parseIntQuery(c, "page", 1)
+	filter.PerPage, _ = parseIntQuery(c, "per_page", 50)
+	if filter.FromDate, err = parseTimeQuery(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.ToDate, err = parseTimeQuery(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logs, total, err := h.store.Audit.GetTicketAuditLog(c.Request.Context(), ticketID, filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"audit_log": logs,
+		"total":     total,
+		"per_page":  filter.PerPage,
+	}
+	if filter.Cursor == "" {
+		response["page"] = filter.Page
+	}
+	if filter.NextCursor != "" {
+		response["next_cursor"] = filter.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // AssignTicket handles POST /api/v1/tickets/:id/assign
@@ -308,13 +1141,12 @@ func (h *TicketHandler) AssignTicket(c *gin.Context) {
 	var input struct {
 		AssigneeID uuid.UUID `json:"assignee_id" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
 	if err := h.store.Tickets.Assign(c.Request.Context(), orgID.(uuid.UUID), ticketID, input.AssigneeID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err)
 		return
 	}
 
@@ -333,7 +1165,7 @@ func (h *TicketHandler) GetTicketQueue(c *gin.Context) {
 
 	tickets, err := h.store.Tickets.GetQueue(c.Request.Context(), orgID.(uuid.UUID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err)
 		return
 	}
 
@@ -343,7 +1175,10 @@ func (h *TicketHandler) GetTicketQueue(c *gin.Context) {
 	})
 }
 
-// LinkRepository handles POST /api/v1/tickets/:id/repositories
+// LinkRepository handles POST /api/v1/tickets/:id/repositories, linking a
+// repository by ID or URL. A URL the organization doesn't have yet is added as
+// a repository first. Linking a repository again updates the link's branch,
+// commit and pull request.
 func (h *TicketHandler) LinkRepository(c *gin.Context) {
 	orgID, _ := c.Get("org_id")
 	userID, _ := c.Get("user_id")
@@ -360,57 +1195,107 @@ func (h *TicketHandler) LinkRepository(c *gin.Context) {
 		URL          string     `json:"url"`
 		LinkType     string     `json:"link_type"`
 		BranchName   *string    `json:"branch_name"`
+		CommitSHA    *string    `json:"commit_sha"`
+		PRNumber     *int       `json:"pr_number"`
 		Notes        *string    `json:"notes"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
+		return
+	}
+	switch input.LinkType {
+	case "", "related", "implements", "fixes", "affects":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "link_type must be related, implements, fixes or affects"})
+		return
+	}
+	if input.PRNumber != nil && *input.PRNumber <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pr_number must be positive"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	oid := orgID.(uuid.UUID)
+	if _, err := h.store.Tickets.GetByID(ctx, oid, ticketID); err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
 		return
 	}
 
-	var repoID uuid.UUID
+	var repo *models.Repository
 	if input.RepositoryID != nil {
-		repoID = *input.RepositoryID
+		repo, err = h.store.Repositories.GetByID(ctx, oid, *input.RepositoryID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
+			return
+		}
 	} else if input.URL != "" {
+		repoURL, name, ok := normalizeRepositoryURL(input.URL)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an http(s) repository URL"})
+			return
+		}
 		// Find or create repository by URL
-		repo, err := h.store.Repositories.GetByURL(c.Request.Context(), orgID.(uuid.UUID), input.URL)
+		repo, err = h.store.Repositories.GetByURL(ctx, oid, repoURL)
+		if err != nil && err.Error() != "repository not found" {
+			respondStoreError(c, err)
+			return
+		}
 		if err != nil {
-			// Create new repository
 			createInput := &models.CreateRepositoryInput{
-				Name:     input.URL, // Will be updated later
-				URL:      input.URL,
-				Provider: guessProvider(input.URL),
+				Name:     name,
+				URL:      repoURL,
+				Provider: guessProvider(repoURL),
 			}
-			repo, err = h.store.Repositories.Create(c.Request.Context(), orgID.(uuid.UUID), createInput)
+			repo, err = h.store.Repositories.Create(ctx, oid, createInput)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				respondStoreError(c, err)
 				return
 			}
 		}
-		repoID = repo.ID
 	} else {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "repository_id or url is required"})
 		return
 	}
 
 	linkInput := &models.LinkRepositoryInput{
-		RepositoryID: repoID,
+		RepositoryID: repo.ID,
 		LinkType:     input.LinkType,
 		BranchName:   input.BranchName,
+		CommitSHA:    input.CommitSHA,
+		PRNumber:     input.PRNumber,
 		Notes:        input.Notes,
 	}
 
-	if err := h.store.Tickets.LinkRepository(c.Request.Context(), ticketID, repoID, userID.(uuid.UUID), linkInput); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	uid := userID.(uuid.UUID)
+	if err := h.store.Tickets.LinkRepository(ctx, ticketID, repo.ID, uid, linkInput); err != nil {
+		respondStoreError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Repository linked",
+	h.store.Audit.LogTicketAccess(ctx, ticketID, uid, models.AuditActionRepositoryLink, nil, nil, map[string]interface{}{
+		"repository_id": repo.ID,
+		"repository":    repo.Name,
+		"link_type":     input.LinkType,
+		"branch_name":   input.BranchName,
+		"commit_sha":    input.CommitSHA,
+		"pr_number":     input.PRNumber,
 	})
+
+	response := gin.H{"message": "Repository linked"}
+	if links, err := h.store.Repositories.GetTicketRepositories(ctx, ticketID); err == nil {
+		for _, link := range links {
+			if link.RepositoryID == repo.ID {
+				response["repository_link"] = link
+			}
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // UnlinkRepository handles DELETE /api/v1/tickets/:id/repositories/:repo_id
 func (h *TicketHandler) UnlinkRepository(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
 	ticketID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
@@ -423,16 +1308,41 @@ func (h *TicketHandler) UnlinkRepository(c *gin.Context) {
 		return
 	}
 
-	if err := h.store.Tickets.UnlinkRepository(c.Request.Context(), ticketID, repoID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	ctx := c.Request.Context()
+	if _, err := h.store.Tickets.GetByID(ctx, orgID.(uuid.UUID), ticketID); err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.Tickets.UnlinkRepository(ctx, ticketID, repoID); err != nil {
+		respondStoreError(c, err)
 		return
 	}
 
+	h.store.Audit.LogTicketAccess(ctx, ticketID, userID.(uuid.UUID), models.AuditActionRepositoryUnlink, nil, nil, map[string]interface{}{
+		"repository_id": repoID,
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Repository unlinked",
 	})
 }
 
+// normalizeRepositoryURL reduces a repository URL to the form repositories
+// are stored under, https://host/owner/name without credentials, ".git" or
+// a trailing slash, and returns it with the repository's owner/name
+func normalizeRepositoryURL(raw string) (string, string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", false
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return "", "", false
+	}
+	return u.Scheme + "://" + strings.ToLower(u.Host) + "/" + path, path, true
+}
+
 // AddWatcher handles POST /api/v1/tickets/:id/watchers
 func (h *TicketHandler) AddWatcher(c *gin.Context) {
 	orgID, _ := c.Get("org_id")
@@ -446,13 +1356,12 @@ func (h *TicketHandler) AddWatcher(c *gin.Context) {
 	var input struct {
 		UserID uuid.UUID `json:"user_id" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
 	if err := h.store.Tickets.AddWatcher(c.Request.Context(), orgID.(uuid.UUID), ticketID, input.UserID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err)
 		return
 	}
 
@@ -478,7 +1387,7 @@ func (h *TicketHandler) RemoveWatcher(c *gin.Context) {
 	}
 
 	if err := h.store.Tickets.RemoveWatcher(c.Request.Context(), orgID.(uuid.UUID), ticketID, watcherID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondStoreError(c, err)
 		return
 	}
 
@@ -515,3 +1424,62 @@ func containsCheck(s, substr string) bool {
 	}
 	return false
 }
+
+// setTicketETag exposes the ticket version so clients can make conditional writes
+func setTicketETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// requireVersion returns the ticket version a write is based on, taken from
+// the If-Match header or the request body. Writes without one are refused so
+// concurrent edits can't silently overwrite each other.
+func requireVersion(c *gin.Context, bodyVersion *int) (int, bool) {
+	if header := c.GetHeader("If-Match"); header != "" {
+		version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid If-Match header"})
+			return 0, false
+		}
+		if bodyVersion != nil && *bodyVersion != version {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match header and version do not match"})
+			return 0, false
+		}
+		return version, true
+	}
+
+	if bodyVersion == nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header or version is required"})
+		return 0, false
+	}
+	if *bodyVersion < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return 0, false
+	}
+	return *bodyVersion, true
+}
+
+// respondComplianceError writes a 400 listing the compliance requirements a
+// ticket being submitted doesn't meet
+func respondComplianceError(c *gin.Context, err *models.ComplianceError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":             err.Error(),
+		"compliance_issues": err.Issues,
+	})
+}
+
+// respondTicketError writes a ticket store error, reporting stale writes as
+// 409 with the current version and conflicting fields
+func respondTicketError(c *gin.Context, err error, fallback int) {
+	var conflict *models.VersionConflictError
+	if errors.As(err, &conflict) {
+		setTicketETag(c, conflict.CurrentVersion)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            conflict.Error(),
+			"expected_version": conflict.ExpectedVersion,
+			"current_version":  conflict.CurrentVersion,
+			"conflicts":        conflict.Conflicts,
+		})
+		return
+	}
+	c.JSON(storeErrorStatus(err, fallback), gin.H{"error": err.Error()})
+}
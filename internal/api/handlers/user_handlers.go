@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// UserHandler handles the organization's user administration
+type UserHandler struct {
+	store *store.Store
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(s *store.Store) *UserHandler {
+	return &UserHandler{store: s}
+}
+
+// ListUsers handles GET /api/v1/users
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	var filter models.UserListFilter
+	if value := c.Query("role"); value != "" {
+		role := models.UserRole(value)
+		if !role.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role: " + value})
+			return
+		}
+		filter.Role = &role
+	}
+	if value := c.Query("approval_type"); value != "" {
+		at := models.ApprovalType(value)
+		if !at.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid approval type: " + value})
+			return
+		}
+		filter.ApprovalType = &at
+	}
+	if value := c.Query("is_active"); value != "" {
+		active := value == "true"
+		if !active && value != "false" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "is_active must be true or false"})
+			return
+		}
+		filter.IsActive = &active
+	}
+	filter.Search = strings.TrimSpace(c.Query("search"))
+	var err error
+	if filter.Page, err = parseIntQuery(c, "page", 1); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.PerPage, err = parseIntQuery(c, "per_page", 50); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, total, err := h.store.Users.List(c.Request.Context(), orgID.(uuid.UUID), &filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":    users,
+		"total":    total,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+	})
+}
+
+// CreateUser handles POST /api/v1/users
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateUserInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Users.Create(c.Request.Context(), orgID.(uuid.UUID), &input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID := userID.(uuid.UUID)
+	metadata, _ := json.Marshal(gin.H{"email": user.Email, "roles": user.Roles})
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &actorID,
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &user.ID,
+		Description:  "Created user",
+		Metadata:     metadata,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"user": user})
+}
+
+// GetUser handles GET /api/v1/users/:id
+func (h *UserHandler) GetUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	user, err := h.store.Users.Get(c.Request.Context(), orgID.(uuid.UUID), targetID)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// UpdateUser handles PATCH /api/v1/users/:id
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var input models.UpdateUserInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.store.Users.Update(c.Request.Context(), orgID.(uuid.UUID), targetID, &input)
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID := userID.(uuid.UUID)
+	changes, _ := json.Marshal(input)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &actorID,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  "Updated user",
+		Metadata:     changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// DeleteUser handles DELETE /api/v1/users/:id. Admins can't delete
+// themselves.
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	actorID := userID.(uuid.UUID)
+	if targetID == actorID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot delete your own account"})
+		return
+	}
+
+	if err := h.store.Users.Delete(c.Request.Context(), orgID.(uuid.UUID), targetID); err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &actorID,
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  "Deleted user",
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// ResetUserPassword handles POST /api/v1/users/:id/reset-password. Without
+// a password in the body a temporary one is generated and returned once.
+// Either way the user must change it at their next login, and their
+// sessions are revoked.
+func (h *UserHandler) ResetUserPassword(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var input models.SetPasswordInput
+	if c.Request.ContentLength != 0 && !bindJSON(c, &input) {
+		return
+	}
+	generated := input.Password == ""
+	if generated {
+		if input.Password, err = generateTemporaryPassword(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate password"})
+			return
+		}
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.store.Users.SetPassword(c.Request.Context(), orgID.(uuid.UUID), targetID, input.Password, true); err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	actorID := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &actorID,
+		Action:       models.AuditActionPasswordChange,
+		ResourceType: models.AuditResourceUser,
+		ResourceID:   &targetID,
+		Description:  "Reset user password",
+	})
+
+	response := gin.H{"message": "Password reset; the user must change it at their next login"}
+	if generated {
+		response["temporary_password"] = input.Password
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+const temporaryPasswordChars = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789!@#$%*-_+="
+
+// generateTemporaryPassword returns a random 20-character password that
+// meets the default password policy
+func generateTemporaryPassword() (string, error) {
+	for {
+		b := make([]byte, 20)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(temporaryPasswordChars))))
+			if err != nil {
+				return "", err
+			}
+			b[i] = temporaryPasswordChars[n.Int64()]
+		}
+		if (&models.SetPasswordInput{Password: string(b)}).Validate() == nil {
+			return string(b), nil
+		}
+	}
+}
+
+// userErrorStatus maps user store errors to HTTP status codes
+func userErrorStatus(err error) int {
+	return storeErrorStatus(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// WebhookHandler handles the organization's webhook subscriptions
+type WebhookHandler struct {
+	store *store.Store
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(s *store.Store) *WebhookHandler {
+	return &WebhookHandler{store: s}
+}
+
+// ListWebhooks handles GET /api/v1/organization/webhooks
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	subs, err := h.store.Webhooks.ListSubscriptions(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":    subs,
+		"count":       len(subs),
+		"event_types": models.WebhookEventTypes,
+	})
+}
+
+// CreateWebhook handles POST /api/v1/organization/webhooks. The signing
+// secret is only ever returned here and when it is rotated.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.CreateWebhookSubscriptionInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	sub, err := h.store.Webhooks.CreateSubscription(c.Request.Context(), orgID.(uuid.UUID), uid, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionCreate,
+		ResourceType: models.AuditResourceWebhook,
+		ResourceID:   &sub.ID,
+		Description:  "Subscribed webhook " + sub.URL,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": sub,
+		"secret":  sub.Secret,
+	})
+}
+
+// GetWebhook handles GET /api/v1/organization/webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return
+	}
+
+	sub, err := h.store.Webhooks.GetSubscription(c.Request.Context(), orgID.(uuid.UUID), id)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": sub,
+	})
+}
+
+// UpdateWebhook handles PATCH /api/v1/organization/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return
+	}
+
+	var input models.UpdateWebhookSubscriptionInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.store.Webhooks.UpdateSubscription(c.Request.Context(), orgID.(uuid.UUID), id, &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	description := "Updated webhook " + sub.URL
+	if input.RotateSecret {
+		description += " and rotated its secret"
+	}
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceWebhook,
+		ResourceID:   &id,
+		Description:  description,
+	})
+
+	response := gin.H{
+		"webhook": sub,
+	}
+	if input.RotateSecret {
+		response["secret"] = sub.Secret
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook handles DELETE /api/v1/organization/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return
+	}
+
+	if err := h.store.Webhooks.DeleteSubscription(c.Request.Context(), orgID.(uuid.UUID), id); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	recordAudit(c, h.store, orgID.(uuid.UUID), &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionDelete,
+		ResourceType: models.AuditResourceWebhook,
+		ResourceID:   &id,
+		Description:  "Deleted webhook subscription",
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted",
+	})
+}
+
+// ListDeliveries handles GET /api/v1/organization/webhooks/deliveries.
+// Filters by ?webhook_id= and ?status=.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	var filter models.WebhookDeliveryFilter
+	if raw := c.Query("webhook_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook_id"})
+			return
+		}
+		filter.SubscriptionID = &id
+	}
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+			filter.Status = &status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or dead"})
+			return
+		}
+	}
+	var err error
+	if filter.Page, err = parseIntQuery(c, "page", 1); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.PerPage, err = parseIntQuery(c, "per_page", 50); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, total, err := h.store.Webhooks.ListDeliveries(c.Request.Context(), orgID.(uuid.UUID), &filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       filter.Page,
+		"per_page":   filter.PerPage,
+	})
+}
+
+// RetryDelivery handles POST /api/v1/organization/webhooks/deliveries/:id/retry
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return
+	}
+
+	if err := h.store.Webhooks.RetryDelivery(c.Request.Context(), orgID.(uuid.UUID), id); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Delivery queued for retry",
+	})
+}
+
+// enqueueWebhook queues an event for the organization's webhook
+// subscribers. Most callers report a change that has already been made and,
+// as with the audit log, ignore the error; inside a transaction it is
+// returned so the event commits with the change or not at all.
+func enqueueWebhook(c *gin.Context, s *store.Store, orgID uuid.UUID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	_, err = s.Webhooks.Enqueue(c.Request.Context(), &models.WebhookEvent{
+		ID:             uuid.New(),
+		Type:           eventType,
+		OrganizationID: orgID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           payload,
+	})
+	return err
+}
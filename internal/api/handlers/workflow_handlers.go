@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// WorkflowHandler handles ticket workflow HTTP requests
+type WorkflowHandler struct {
+	store *store.Store
+}
+
+// NewWorkflowHandler creates a new workflow handler
+func NewWorkflowHandler(s *store.Store) *WorkflowHandler {
+	return &WorkflowHandler{store: s}
+}
+
+// GetWorkflow handles GET /api/v1/organization/workflow
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	workflow, err := h.store.Workflows.Get(c.Request.Context(), orgID.(uuid.UUID))
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow":            workflow,
+		"default_transitions": models.DefaultTicketTransitions,
+	})
+}
+
+// UpdateWorkflow handles PUT /api/v1/organization/workflow. The transitions
+// given replace the organization's current extra transitions.
+func (h *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	var input models.UpdateTicketWorkflowInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workflow, err := h.store.Workflows.Update(c.Request.Context(), orgID.(uuid.UUID), userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	oid := orgID.(uuid.UUID)
+	changes, _ := json.Marshal(input)
+	recordAudit(c, h.store, oid, &models.CreateAuditLogInput{
+		UserID:       &uid,
+		Action:       models.AuditActionUpdate,
+		ResourceType: models.AuditResourceOrganization,
+		ResourceID:   &oid,
+		Description:  "Updated ticket workflow",
+		Changes:      changes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow":            workflow,
+		"default_transitions": models.DefaultTicketTransitions,
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// WorklogHandler handles ticket worklog HTTP requests
+type WorklogHandler struct {
+	store *store.Store
+}
+
+// NewWorklogHandler creates a new worklog handler
+func NewWorklogHandler(s *store.Store) *WorklogHandler {
+	return &WorklogHandler{store: s}
+}
+
+// CreateWorklog handles POST /api/v1/tickets/:id/worklogs
+func (h *WorklogHandler) CreateWorklog(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	var input models.CreateWorklogInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if err := input.Validate(time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID); err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	worklog, err := h.store.Worklogs.Create(c.Request.Context(), orgID.(uuid.UUID), ticketID, userID.(uuid.UUID), &input)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"worklog":       worklog,
+		"time_tracking": timeTracking(ticket),
+	})
+}
+
+// ListWorklogs handles GET /api/v1/tickets/:id/worklogs
+func (h *WorklogHandler) ListWorklogs(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+
+	ticket, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	worklogs, err := h.store.Worklogs.ListByTicket(c.Request.Context(), orgID.(uuid.UUID), ticketID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"worklogs":      worklogs,
+		"count":         len(worklogs),
+		"time_tracking": timeTracking(ticket),
+	})
+}
+
+// DeleteWorklog handles DELETE /api/v1/tickets/:id/worklogs/:worklog_id
+func (h *WorklogHandler) DeleteWorklog(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+	userID, _ := c.Get("user_id")
+
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ticket ID"})
+		return
+	}
+	worklogID, err := uuid.Parse(c.Param("worklog_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid worklog ID"})
+		return
+	}
+
+	if _, err := h.store.Tickets.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID); err != nil {
+		respondTicketError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	worklog, err := h.store.Worklogs.GetByID(c.Request.Context(), orgID.(uuid.UUID), ticketID, worklogID)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+	a := store.AccessorFrom(c.Request.Context())
+	if !worklog.CanDelete(userID.(uuid.UUID), a != nil && a.CanWriteAll()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the author or an admin can delete a worklog"})
+		return
+	}
+
+	if err := h.store.Worklogs.Delete(c.Request.Context(), orgID.(uuid.UUID), ticketID, worklogID); err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Worklog deleted",
+	})
+}
+
+// WorklogReport handles GET /api/v1/reports/worklogs
+// Totals the time each user logged, for billing change work to customers.
+func (h *WorklogHandler) WorklogReport(c *gin.Context) {
+	orgID, _ := c.Get("org_id")
+
+	filter := &models.WorklogReportFilter{}
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for name, dest := range map[string]**uuid.UUID{
+		"user_id":     &filter.UserID,
+		"customer_id": &filter.CustomerID,
+		"project_id":  &filter.ProjectID,
+	} {
+		if value := c.Query(name); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*dest = &id
+		}
+	}
+
+	totals, err := h.store.Worklogs.Report(c.Request.Context(), orgID.(uuid.UUID), filter)
+	if err != nil {
+		respondStoreError(c, err)
+		return
+	}
+
+	var hours, billable float64
+	for _, t := range totals {
+		hours += t.Hours
+		billable += t.BillableHours
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":          totals,
+		"total_hours":    hours,
+		"billable_hours": billable,
+		"from":           filter.From,
+		"to":             filter.To,
+	})
+}
+
+// timeTracking summarizes a ticket's time spent against its estimate
+func timeTracking(t *models.Ticket) gin.H {
+	return gin.H{
+		"time_estimate_hours":  t.TimeEstimateHours,
+		"time_spent_hours":     t.TimeSpentHours,
+		"time_remaining_hours": t.TimeRemainingHours,
+		"over_estimate":        t.OverEstimate,
+	}
+}
@@ -1,11 +1,21 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/auth"
 	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -47,8 +57,10 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("user_agent", c.Request.UserAgent()),
 		}
 
-		if userID, exists := c.Get("user_id"); exists {
-			fields = append(fields, zap.String("user_id", userID.(string)))
+		if userID, ok := c.Get("user_id"); ok {
+			if id, ok := userID.(uuid.UUID); ok {
+				fields = append(fields, zap.String("user_id", id.String()))
+			}
 		}
 
 		if status >= 500 {
@@ -108,7 +120,8 @@ func CORS(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Accept, Origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Accept, Origin, Cache-Control, X-Requested-With, X-Request-ID, If-Match, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "ETag, X-Request-ID, Idempotent-Replayed")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Max-Age", "86400")
 
@@ -170,16 +183,236 @@ func Auth(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		token := parts[1]
+		claims, err := auth.Parse(&cfg.JWT, parts[1], time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": gin.H{
+					"code":      "INVALID_TOKEN",
+					"message":   err.Error(),
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+
+		// Users who must set up MFA can only reach the auth endpoints until they do
+		if claims.Scope == auth.ScopeMFAEnrollment && !strings.HasPrefix(c.FullPath(), "/v1/auth/") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":      "MFA_ENROLLMENT_REQUIRED",
+					"message":   "Set up multi-factor authentication to continue",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+
+		// Customer portal users can only reach the portal and their own auth endpoints
+		if claims.CustomerID != nil && !strings.HasPrefix(c.FullPath(), "/v1/portal/") && !strings.HasPrefix(c.FullPath(), "/v1/auth/") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":      "PORTAL_ONLY",
+					"message":   "Customer portal accounts can only use the portal endpoints",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("org_id", claims.OrganizationID)
+		c.Set("roles", claims.Roles)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_method", claims.AuthMethod)
+		c.Set("mfa_at", claims.MFAVerifiedAt())
+		c.Set("token_scope", claims.Scope)
+		if claims.CustomerID != nil {
+			c.Set("customer_id", *claims.CustomerID)
+		}
+		// Keep the request's database work inside the caller's organization,
+		// reading its settings at most once
+		ctx := store.WithOrg(c.Request.Context(), claims.OrganizationID)
+		c.Request = c.Request.WithContext(store.WithSettingsCache(ctx))
+
+		c.Next()
+	}
+}
+
+// RequireCustomer restricts a route to customer portal users. Must run
+// after Auth.
+func RequireCustomer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("customer_id"); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":      "FORBIDDEN",
+					"message":   "The customer portal is only available to customer accounts",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TicketAccess attaches the authenticated user to the request context so the
+// store can enforce ticket ACLs. Must run after Auth.
+func TicketAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessor := &models.TicketAccessor{}
+		if userID, ok := c.Get("user_id"); ok {
+			accessor.UserID, _ = userID.(uuid.UUID)
+		}
+		if roles, ok := c.Get("roles"); ok {
+			accessor.Roles, _ = roles.([]string)
+		}
+
+		c.Request = c.Request.WithContext(store.WithAccessor(c.Request.Context(), accessor))
+		c.Next()
+	}
+}
+
+// idempotencyWriter captures the response body so it can be stored for replay
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header, so retried creates don't produce duplicates.
+// Keys are scoped to the user and kept for 24 hours. Must run after Auth.
+func Idempotency(s *store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > models.MaxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":      "INVALID_IDEMPOTENCY_KEY",
+					"message":   "Idempotency-Key must be at most 255 characters",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+
+		orgValue, _ := c.Get("org_id")
+		userValue, _ := c.Get("user_id")
+		orgID, orgOK := orgValue.(uuid.UUID)
+		userID, userOK := userValue.(uuid.UUID)
+		if !orgOK || !userOK {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, models.MaxIdempotentBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": gin.H{
+					"code":      "REQUEST_TOO_LARGE",
+					"message":   fmt.Sprintf("Requests with an Idempotency-Key must be at most %d bytes", tooLarge.Limit),
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":      "INVALID_REQUEST",
+					"message":   "Failed to read request body",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
 
-		// TODO: Validate JWT token and extract claims
-		// For now, we'll just set placeholder values
-		_ = token
-		c.Set("user_id", "placeholder")
-		c.Set("organization_id", "placeholder")
-		c.Set("roles", []string{"user"})
+		record, created, err := s.Idempotency.Begin(c.Request.Context(), orgID, userID, key, c.Request.Method, c.Request.URL.Path, requestHash)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":      "INTERNAL_ERROR",
+					"message":   "Failed to process Idempotency-Key",
+					"timestamp": time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+			return
+		}
+
+		if !created {
+			switch {
+			case record.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": gin.H{
+						"code":      "IDEMPOTENCY_KEY_REUSED",
+						"message":   "Idempotency-Key was already used for a different request",
+						"timestamp": time.Now().UTC().Format(time.RFC3339),
+					},
+				})
+			case !record.IsComplete():
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": gin.H{
+						"code":      "IDEMPOTENCY_KEY_IN_PROGRESS",
+						"message":   "A request with this Idempotency-Key is still being processed",
+						"timestamp": time.Now().UTC().Format(time.RFC3339),
+					},
+				})
+			default:
+				contentType := "application/json; charset=utf-8"
+				if record.ContentType != nil && *record.ContentType != "" {
+					contentType = *record.ContentType
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(*record.StatusCode, contentType, record.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// Store the outcome even if the client has gone away. Server errors
+		// are not stored so the request can be retried with the same key.
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// A panicking handler would leave the key in progress until it
+		// expires; free it and let Recovery answer the request
+		defer func() {
+			if r := recover(); r != nil {
+				s.Idempotency.Release(ctx, record.ID)
+				panic(r)
+			}
+		}()
 
 		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			s.Idempotency.Release(ctx, record.ID)
+			return
+		}
+		s.Idempotency.Complete(ctx, record.ID, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
 	}
 }
 
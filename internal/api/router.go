@@ -6,13 +6,16 @@ import (
 
 	"github.com/afterdarksys/adsops-utils/internal/api/handlers"
 	"github.com/afterdarksys/adsops-utils/internal/api/middleware"
+	"github.com/afterdarksys/adsops-utils/internal/cache"
 	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/objectstore"
+	"github.com/afterdarksys/adsops-utils/internal/store"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // NewRouter creates and configures the Gin router
-func NewRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
+func NewRouter(cfg *config.Config, logger *zap.Logger, s *store.Store) *gin.Engine {
 	// Set Gin mode based on environment
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -20,6 +23,67 @@ func NewRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
 
 	router := gin.New()
 
+	ticketHandler := handlers.NewTicketHandler(s, cfg)
+	approvalHandler := handlers.NewApprovalHandler(s)
+	approvalRuleHandler := handlers.NewApprovalRuleHandler(s)
+	commentHandler := handlers.NewCommentHandler(s)
+	userHandler := handlers.NewUserHandler(s)
+	customFieldHandler := handlers.NewCustomFieldHandler(s)
+	labelHandler := handlers.NewLabelHandler(s)
+	epicHandler := handlers.NewEpicHandler(s)
+	sprintHandler := handlers.NewSprintHandler(s)
+	worklogHandler := handlers.NewWorklogHandler(s)
+	portalHandler := handlers.NewPortalHandler(s)
+	reportHandler := handlers.NewReportHandler(s, cache.New(&cfg.Redis))
+	assignmentRuleHandler := handlers.NewAssignmentRuleHandler(s)
+	pirHandler := handlers.NewPIRHandler(s)
+	auditHandler := handlers.NewAuditHandler(s)
+	retentionHandler := handlers.NewRetentionHandler(s)
+	workflowHandler := handlers.NewWorkflowHandler(s)
+	organizationHandler := handlers.NewOrganizationHandler(s)
+	signupHandler := handlers.NewSignupHandler(s, cfg)
+	ticketACLHandler := handlers.NewTicketACLHandler(s)
+	repositoryHandler := handlers.NewRepositoryHandler(s)
+	savedFilterHandler := handlers.NewSavedFilterHandler(s)
+	templateHandler := handlers.NewTemplateHandler(s)
+	githubHandler := handlers.NewGitHubHandler(s)
+	jiraHandler := handlers.NewJiraHandler(s)
+	importHandler := handlers.NewImportHandler(s)
+	graphqlHandler := handlers.NewGraphQLHandler(s)
+	inventoryHandler := handlers.NewInventoryHandler(s)
+	calendarHandler := handlers.NewCalendarHandler(s, cfg)
+	cabHandler := handlers.NewCABHandler(s, cfg)
+	authHandler := handlers.NewAuthHandler(s, cfg)
+	webhookHandler := handlers.NewWebhookHandler(s)
+	notificationHandler := handlers.NewNotificationHandler(s)
+	apiKeyHandler := handlers.NewAPIKeyHandler(s.DB())
+	metricsHandler := handlers.NewMetricsHandler(s)
+
+	// Reports are only generated in the background when they have somewhere
+	// to go
+	var reportLinks handlers.ReportLinker
+	if cfg.Reports.Bucket != "" {
+		client, err := objectstore.NewClient(&cfg.Reports.ObjectStoreConfig, &cfg.AWS)
+		if err != nil {
+			logger.Error("Report storage is misconfigured; background reports are disabled", zap.Error(err))
+		} else {
+			reportLinks = client
+		}
+	}
+	reportJobHandler := handlers.NewReportJobHandler(s, reportLinks, cfg)
+
+	// Files can only be attached to tickets when they have somewhere to go
+	var attachmentFiles handlers.AttachmentStorage
+	if cfg.Attachments.Bucket != "" {
+		client, err := objectstore.NewClient(&cfg.Attachments.ObjectStoreConfig, &cfg.AWS)
+		if err != nil {
+			logger.Error("Attachment storage is misconfigured; uploads are disabled", zap.Error(err))
+		} else {
+			attachmentFiles = client
+		}
+	}
+	attachmentHandler := handlers.NewAttachmentHandler(s, attachmentFiles, cfg)
+
 	// Global middleware
 	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
@@ -41,76 +105,390 @@ func NewRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
 		// Authentication routes (public)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/login", handlers.Login)
-			auth.POST("/login/mfa", handlers.LoginMFA)
-			auth.POST("/login/oauth2/google", handlers.LoginOAuth2Google)
-			auth.POST("/login/oauth2/afterdark", handlers.LoginOAuth2AfterDark)
-			auth.POST("/login/passkey/begin", handlers.LoginPasskeyBegin)
-			auth.POST("/login/passkey/finish", handlers.LoginPasskeyFinish)
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/mfa", authHandler.LoginMFA)
+			auth.POST("/login/oauth2/google/begin", authHandler.BeginOAuth2Google)
+			auth.POST("/login/oauth2/google", authHandler.LoginOAuth2Google)
+			auth.POST("/login/oauth2/afterdark/begin", authHandler.BeginOAuth2AfterDark)
+			auth.POST("/login/oauth2/afterdark", authHandler.LoginOAuth2AfterDark)
+			auth.POST("/login/oauth2/link", authHandler.LinkOAuth2Account)
+			auth.POST("/login/passkey/begin", authHandler.LoginPasskeyBegin)
+			auth.POST("/login/passkey/finish", authHandler.LoginPasskeyFinish)
+			auth.POST("/device/code", authHandler.StartDeviceLogin)
+			auth.POST("/device/token", authHandler.PollDeviceLogin)
 			auth.POST("/refresh", handlers.RefreshToken)
 		}
 
+		// Self-service signup (public)
+		signup := v1.Group("/signup")
+		{
+			signup.POST("", signupHandler.Signup)
+			signup.POST("/verify-email", signupHandler.VerifyEmail)
+			signup.POST("/contact", signupHandler.CollectFailedSignupContact)
+		}
+
 		// Token-based approval routes (public with token validation)
-		v1.POST("/approvals/token/:token/approve", handlers.ApproveByToken)
-		v1.POST("/approvals/token/:token/deny", handlers.DenyByToken)
-		v1.GET("/approvals/token/:token", handlers.GetApprovalByToken)
+		v1.POST("/approvals/token/:token/approve", approvalHandler.ApproveByToken)
+		v1.POST("/approvals/token/:token/deny", approvalHandler.DenyByToken)
+		v1.GET("/approvals/token/:token", approvalHandler.GetApprovalByToken)
+
+		// Integration webhooks (public, verified by per-org signing secret)
+		v1.POST("/integrations/github/webhook", githubHandler.Webhook)
+
+		// Calendar feed of scheduled changes (public, authenticated by feed token)
+		v1.GET("/calendar.ics", calendarHandler.Feed)
+
+		// Customer portal (customer accounts only; their tokens can't reach the routes below)
+		portal := v1.Group("/portal")
+		portal.Use(middleware.Auth(cfg))
+		portal.Use(middleware.RequireCustomer())
+		{
+			portal.GET("/me", portalHandler.GetProfile)
+			portal.GET("/tickets", portalHandler.ListTickets)
+			portal.GET("/tickets/:id", portalHandler.GetTicket)
+			portal.GET("/tickets/:id/comments", portalHandler.ListComments)
+			portal.POST("/tickets/:id/comments", middleware.Idempotency(s), portalHandler.CreateComment)
+		}
 
 		// Protected routes (require authentication)
 		protected := v1.Group("")
 		protected.Use(middleware.Auth(cfg))
+		protected.Use(middleware.TicketAccess())
+		idempotent := middleware.Idempotency(s)
 		{
 			// Current user
 			protected.GET("/auth/me", handlers.GetCurrentUser)
 			protected.POST("/auth/logout", handlers.Logout)
+			protected.GET("/auth/notifications", notificationHandler.GetPreferences)
+			protected.PATCH("/auth/notifications", notificationHandler.UpdatePreferences)
+			protected.GET("/auth/me/change-metrics", reportHandler.MyChangeMetrics)
+			protected.POST("/auth/device/approve", authHandler.ApproveDeviceLogin)
+
+			// MFA of the current user
+			mfa := protected.Group("/auth/mfa")
+			{
+				mfa.GET("", authHandler.GetMFAStatus)
+				mfa.DELETE("", authHandler.DisableMFA)
+				mfa.POST("/enroll", authHandler.EnrollMFA)
+				mfa.POST("/activate", authHandler.ActivateMFA)
+				mfa.POST("/backup-codes", authHandler.RegenerateBackupCodes)
+				mfa.POST("/step-up", authHandler.StepUp)
+			}
+
+			// Passkeys of the current user
+			passkeys := protected.Group("/auth/passkeys")
+			{
+				passkeys.GET("", authHandler.ListPasskeys)
+				passkeys.POST("/register/begin", authHandler.BeginPasskeyRegistration)
+				passkeys.POST("/register/finish", authHandler.FinishPasskeyRegistration)
+				passkeys.DELETE("/:credential_id", authHandler.DeletePasskey)
+			}
+
+			// API keys of the current user, for CI and other automation
+			apiKeys := protected.Group("/api-keys")
+			{
+				apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+				apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+				apiKeys.DELETE("/:id", apiKeyHandler.DeleteAPIKey)
+			}
 
 			// Tickets
 			tickets := protected.Group("/tickets")
 			{
-				tickets.POST("", handlers.CreateTicket)
-				tickets.GET("", handlers.ListTickets)
-				tickets.GET("/:id", handlers.GetTicket)
-				tickets.PATCH("/:id", handlers.UpdateTicket)
-				tickets.POST("/:id/submit", handlers.SubmitTicket)
-				tickets.POST("/:id/cancel", handlers.CancelTicket)
-				tickets.POST("/:id/close", handlers.CloseTicket)
-				tickets.POST("/:id/reopen", handlers.ReopenTicket)
-				tickets.GET("/:id/revisions", handlers.GetTicketRevisions)
-				tickets.GET("/:id/audit", handlers.GetTicketAudit)
+				tickets.POST("", idempotent, ticketHandler.CreateTicket)
+				tickets.GET("", ticketHandler.ListTickets)
+				tickets.GET("/search", ticketHandler.SearchTickets)
+				tickets.GET("/queue", ticketHandler.GetTicketQueue)
+				tickets.GET("/trash", middleware.RequireRole("admin"), ticketHandler.ListTrash)
+				tickets.GET("/:id", ticketHandler.GetTicket)
+				tickets.PATCH("/:id", ticketHandler.UpdateTicket)
+				tickets.POST("/:id/submit", ticketHandler.SubmitTicket)
+				tickets.POST("/:id/cancel", ticketHandler.CancelTicket)
+				tickets.POST("/:id/close", ticketHandler.CloseTicket)
+				tickets.POST("/:id/reopen", ticketHandler.ReopenTicket)
+				tickets.DELETE("/:id", middleware.RequireRole("admin"), ticketHandler.DeleteTicket)
+				tickets.POST("/:id/restore", middleware.RequireRole("admin"), ticketHandler.RestoreTicket)
+				tickets.GET("/:id/revisions", ticketHandler.GetTicketRevisions)
+				tickets.GET("/:id/audit", ticketHandler.GetTicketAudit)
+				tickets.GET("/:id/approval-plan", ticketHandler.GetApprovalPlan)
+				tickets.GET("/:id/jira", jiraHandler.GetTicketSyncState)
+
+				// Linked repositories
+				tickets.POST("/:id/repositories", ticketHandler.LinkRepository)
+				tickets.DELETE("/:id/repositories/:repo_id", ticketHandler.UnlinkRepository)
+
+				// Access control
+				tickets.GET("/:id/acls", ticketACLHandler.ListACLs)
+				tickets.POST("/:id/acls", ticketACLHandler.GrantACL)
+				tickets.DELETE("/:id/acls/:acl_id", ticketACLHandler.RevokeACL)
+
+				// Post-implementation review
+				tickets.GET("/:id/pir", pirHandler.GetPIR)
+				tickets.PUT("/:id/pir", pirHandler.SubmitPIR)
+				tickets.POST("/:id/pir/sign-off", pirHandler.SignOffPIR)
 
 				// Comments
-				tickets.POST("/:id/comments", handlers.CreateComment)
-				tickets.GET("/:id/comments", handlers.ListComments)
+				tickets.POST("/:id/comments", idempotent, commentHandler.CreateComment)
+				tickets.GET("/:id/comments", commentHandler.ListComments)
+
+				// Worklogs
+				tickets.POST("/:id/worklogs", idempotent, worklogHandler.CreateWorklog)
+				tickets.GET("/:id/worklogs", worklogHandler.ListWorklogs)
+				tickets.DELETE("/:id/worklogs/:worklog_id", worklogHandler.DeleteWorklog)
+
+				// Attachments
+				tickets.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+				tickets.GET("/:id/attachments", attachmentHandler.ListAttachments)
+
+				// Blackouts started for the ticket's hosts
+				tickets.GET("/:id/blackouts", inventoryHandler.ListTicketBlackouts)
+			}
+
+			// Saved ticket filters (personal and shared views)
+			savedFilters := protected.Group("/saved-filters")
+			{
+				savedFilters.GET("", savedFilterHandler.ListSavedFilters)
+				savedFilters.POST("", savedFilterHandler.CreateSavedFilter)
+				savedFilters.GET("/:id", savedFilterHandler.GetSavedFilter)
+				savedFilters.PATCH("/:id", savedFilterHandler.UpdateSavedFilter)
+				savedFilters.DELETE("/:id", savedFilterHandler.DeleteSavedFilter)
+				savedFilters.POST("/:id/default", savedFilterHandler.SetDefaultSavedFilter)
+			}
+
+			// Ticket templates, the organization's and the caller's own
+			templates := protected.Group("/templates")
+			{
+				templates.GET("", templateHandler.ListTemplates)
+				templates.POST("", templateHandler.CreateTemplate)
+				templates.GET("/:name", templateHandler.GetTemplate)
+				templates.PATCH("/:name", templateHandler.UpdateTemplate)
+				templates.DELETE("/:name", templateHandler.DeleteTemplate)
 			}
 
 			// Comments (for editing/deleting by ID)
 			comments := protected.Group("/comments")
 			{
-				comments.PATCH("/:id", handlers.UpdateComment)
-				comments.DELETE("/:id", handlers.DeleteComment)
+				comments.PATCH("/:id", commentHandler.UpdateComment)
+				comments.DELETE("/:id", commentHandler.DeleteComment)
 			}
 
 			// Approvals
 			approvals := protected.Group("/approvals")
 			{
-				approvals.GET("", handlers.ListApprovals)
-				approvals.GET("/:id", handlers.GetApproval)
-				approvals.POST("/:id/approve", handlers.Approve)
-				approvals.POST("/:id/deny", handlers.Deny)
-				approvals.POST("/:id/request-update", handlers.RequestUpdate)
+				approvals.GET("", approvalHandler.ListApprovals)
+				approvals.GET("/:id", approvalHandler.GetApproval)
+				approvals.POST("/:id/approve", idempotent, approvalHandler.Approve)
+				approvals.POST("/:id/deny", idempotent, approvalHandler.Deny)
+				approvals.POST("/:id/request-update", idempotent, approvalHandler.RequestUpdate)
+			}
+
+			// Change advisory board meetings
+			cab := protected.Group("/cab")
+			{
+				cab.GET("/agenda", cabHandler.GetAgenda)
+				cab.GET("/meetings", cabHandler.ListMeetings)
+				cab.POST("/meetings", middleware.RequireRole("admin", "approver"), idempotent, cabHandler.RecordMeeting)
+				cab.GET("/meetings/:id", cabHandler.GetMeeting)
+				cab.GET("/meetings/:id/minutes.pdf", cabHandler.GetMinutes)
+			}
+
+			// Post-implementation reviews
+			protected.GET("/pirs", pirHandler.ListPIRs)
+
+			// Repositories
+			protected.GET("/repositories", repositoryHandler.ListRepositories)
+			protected.POST("/repositories/:id/sync", middleware.RequireRole("admin"), repositoryHandler.SyncRepository)
+
+			// Custom field schema (readable by everyone, managed by admins)
+			customFields := protected.Group("/custom-fields")
+			{
+				customFields.GET("", customFieldHandler.ListFields)
+				customFields.GET("/:id", customFieldHandler.GetField)
+				customFields.POST("", middleware.RequireRole("admin"), customFieldHandler.CreateField)
+				customFields.PATCH("/:id", middleware.RequireRole("admin"), customFieldHandler.UpdateField)
+				customFields.DELETE("/:id", middleware.RequireRole("admin"), customFieldHandler.DeleteField)
+			}
+
+			// Labels (readable by everyone, managed by admins)
+			labels := protected.Group("/labels")
+			{
+				labels.GET("", labelHandler.ListLabels)
+				labels.GET("/usage", labelHandler.GetLabelUsage)
+				labels.GET("/:id", labelHandler.GetLabel)
+				labels.POST("", middleware.RequireRole("admin"), labelHandler.CreateLabel)
+				labels.PATCH("/:id", middleware.RequireRole("admin"), labelHandler.UpdateLabel)
+				labels.DELETE("/:id", middleware.RequireRole("admin"), labelHandler.DeleteLabel)
+			}
+
+			// Epics and sprints (planned by everyone, deleted by admins)
+			epics := protected.Group("/epics")
+			{
+				epics.GET("", epicHandler.ListEpics)
+				epics.POST("", epicHandler.CreateEpic)
+				epics.GET("/:id", epicHandler.GetEpic)
+				epics.GET("/:id/rollup", epicHandler.GetEpicRollup)
+				epics.PATCH("/:id", epicHandler.UpdateEpic)
+				epics.DELETE("/:id", middleware.RequireRole("admin"), epicHandler.DeleteEpic)
+			}
+
+			sprints := protected.Group("/sprints")
+			{
+				sprints.GET("", sprintHandler.ListSprints)
+				sprints.POST("", sprintHandler.CreateSprint)
+				sprints.GET("/:id", sprintHandler.GetSprint)
+				sprints.GET("/:id/rollup", sprintHandler.GetSprintRollup)
+				sprints.GET("/:id/burndown", sprintHandler.GetSprintBurndown)
+				sprints.PATCH("/:id", sprintHandler.UpdateSprint)
+				sprints.DELETE("/:id", middleware.RequireRole("admin"), sprintHandler.DeleteSprint)
+			}
+
+			// Approval rules (admin only)
+			approvalRules := protected.Group("/approval-rules")
+			approvalRules.Use(middleware.RequireRole("admin"))
+			{
+				approvalRules.GET("", approvalRuleHandler.ListRules)
+				approvalRules.POST("", approvalRuleHandler.CreateRule)
+				approvalRules.GET("/:id", approvalRuleHandler.GetRule)
+				approvalRules.PATCH("/:id", approvalRuleHandler.UpdateRule)
+				approvalRules.DELETE("/:id", approvalRuleHandler.DeleteRule)
+			}
+
+			// Assignment rules for the queue bot (admin only)
+			assignmentRules := protected.Group("/assignment-rules")
+			assignmentRules.Use(middleware.RequireRole("admin"))
+			{
+				assignmentRules.GET("", assignmentRuleHandler.ListRules)
+				assignmentRules.POST("", assignmentRuleHandler.CreateRule)
+				assignmentRules.POST("/run", assignmentRuleHandler.RunQueue)
+				assignmentRules.GET("/:id", assignmentRuleHandler.GetRule)
+				assignmentRules.PATCH("/:id", assignmentRuleHandler.UpdateRule)
+				assignmentRules.DELETE("/:id", assignmentRuleHandler.DeleteRule)
 			}
 
 			// Users (admin only)
 			users := protected.Group("/users")
 			users.Use(middleware.RequireRole("admin"))
 			{
-				users.GET("", handlers.ListUsers)
-				users.POST("", handlers.CreateUser)
-				users.GET("/:id", handlers.GetUser)
-				users.PATCH("/:id", handlers.UpdateUser)
-				users.DELETE("/:id", handlers.DeleteUser)
-				users.POST("/:id/reset-password", handlers.ResetUserPassword)
-				users.POST("/:id/enable-mfa", handlers.EnableUserMFA)
-				users.POST("/:id/disable-mfa", handlers.DisableUserMFA)
+				users.GET("", userHandler.ListUsers)
+				users.POST("", userHandler.CreateUser)
+				users.GET("/:id", userHandler.GetUser)
+				users.PATCH("/:id", userHandler.UpdateUser)
+				users.DELETE("/:id", userHandler.DeleteUser)
+				users.POST("/:id/reset-password", userHandler.ResetUserPassword)
+				users.POST("/:id/enable-mfa", authHandler.EnableUserMFA)
+				users.POST("/:id/disable-mfa", authHandler.DisableUserMFA)
+				users.GET("/:id/passkeys", authHandler.ListUserPasskeys)
+				users.DELETE("/:id/passkeys/:credential_id", authHandler.RevokeUserPasskey)
+				users.POST("/:id/anonymize", retentionHandler.AnonymizeUser)
+			}
+
+			// Current organization
+			organization := protected.Group("/organization")
+			{
+				organization.GET("", organizationHandler.GetCurrentOrganization)
+				organization.GET("/settings", organizationHandler.GetSettings)
+
+				orgAdmin := organization.Group("")
+				orgAdmin.Use(middleware.RequireRole("admin"))
+				{
+					orgAdmin.PATCH("", organizationHandler.UpdateCurrentOrganization)
+					orgAdmin.PUT("/settings", organizationHandler.UpdateSettings)
+
+					// Data retention & GDPR
+					orgAdmin.GET("/retention-policy", retentionHandler.GetPolicy)
+					orgAdmin.PUT("/retention-policy", retentionHandler.UpdatePolicy)
+
+					// Ticket workflow
+					orgAdmin.GET("/workflow", workflowHandler.GetWorkflow)
+					orgAdmin.PUT("/workflow", workflowHandler.UpdateWorkflow)
+
+					// Integrations
+					orgAdmin.GET("/integrations/github", githubHandler.GetIntegration)
+					orgAdmin.PUT("/integrations/github", githubHandler.UpdateIntegration)
+					orgAdmin.GET("/integrations/jira", jiraHandler.GetIntegration)
+					orgAdmin.PUT("/integrations/jira", jiraHandler.UpdateIntegration)
+					orgAdmin.POST("/integrations/jira/sync", jiraHandler.SyncNow)
+					orgAdmin.GET("/integrations/repository-sync", repositoryHandler.ListSyncTokens)
+					orgAdmin.POST("/integrations/repository-sync/run", repositoryHandler.SyncAll)
+					orgAdmin.PUT("/integrations/repository-sync/:provider", repositoryHandler.UpdateSyncToken)
+					orgAdmin.DELETE("/integrations/repository-sync/:provider", repositoryHandler.DeleteSyncToken)
+
+					// Users whose address bounced or complained
+					orgAdmin.GET("/email-issues", organizationHandler.ListEmailIssues)
+					orgAdmin.DELETE("/email-issues/:user_id", organizationHandler.ClearEmailIssue)
+					orgAdmin.POST("/notifications/requeue", organizationHandler.RequeueNotifications)
+
+					// Webhook subscriptions and their deliveries
+					orgAdmin.GET("/webhooks", webhookHandler.ListWebhooks)
+					orgAdmin.POST("/webhooks", webhookHandler.CreateWebhook)
+					orgAdmin.GET("/webhooks/deliveries", webhookHandler.ListDeliveries)
+					orgAdmin.POST("/webhooks/deliveries/:id/retry", webhookHandler.RetryDelivery)
+					orgAdmin.GET("/webhooks/:id", webhookHandler.GetWebhook)
+					orgAdmin.PATCH("/webhooks/:id", webhookHandler.UpdateWebhook)
+					orgAdmin.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+
+					// Customer portal accounts
+					orgAdmin.GET("/portal-users", portalHandler.ListPortalUsers)
+					orgAdmin.POST("/portal-users", portalHandler.CreatePortalUser)
+					orgAdmin.DELETE("/portal-users/:id", portalHandler.DeactivatePortalUser)
+
+					// Single sign-on
+					orgAdmin.GET("/sso", authHandler.ListSSOConnections)
+					orgAdmin.PUT("/sso/:provider", authHandler.UpdateSSOConnection)
+					orgAdmin.DELETE("/sso/:provider", authHandler.DeleteSSOConnection)
+				}
+			}
+			protected.GET("/anonymization-requests", middleware.RequireRole("admin"), retentionHandler.ListAnonymizationRequests)
+
+			// Imports from other change systems (admin only)
+			protected.POST("/import/servicenow", middleware.RequireRole("admin"), importHandler.ImportServiceNow)
+
+			// Read-only GraphQL over tickets, approvals, comments, users and projects
+			protected.POST("/graphql", graphqlHandler.Query)
+
+			// Host inventory and blackouts (shared with hostctl and the blackout tool)
+			hosts := protected.Group("/hosts")
+			{
+				hosts.GET("", inventoryHandler.ListHosts)
+				hosts.GET("/discrepancies", inventoryHandler.ListDiscrepancies)
+				hosts.POST("", middleware.RequireRole("admin"), inventoryHandler.CreateHost)
+				hosts.GET("/:hostname", inventoryHandler.GetHost)
+				hosts.PATCH("/:hostname", middleware.RequireRole("admin"), inventoryHandler.UpdateHost)
+				hosts.DELETE("/:hostname", middleware.RequireRole("admin"), inventoryHandler.DeleteHost)
+				hosts.GET("/:hostname/blackouts", inventoryHandler.ListBlackouts)
+				hosts.POST("/:hostname/blackouts", inventoryHandler.StartBlackout)
+				hosts.POST("/:hostname/blackouts/end", inventoryHandler.EndBlackout)
+				hosts.POST("/:hostname/blackouts/extend", inventoryHandler.ExtendBlackout)
+			}
+
+			// Calendar feed subscriptions
+			calendarFeeds := protected.Group("/calendar/feeds")
+			{
+				calendarFeeds.GET("", calendarHandler.ListFeeds)
+				calendarFeeds.POST("", calendarHandler.CreateFeed)
+				calendarFeeds.DELETE("/:id", calendarHandler.RevokeFeed)
+			}
+
+			// Organizations (platform admin only)
+			organizations := protected.Group("/organizations")
+			organizations.Use(middleware.RequireRole("platform_admin"))
+			{
+				organizations.GET("", organizationHandler.ListOrganizations)
+				organizations.POST("", organizationHandler.CreateOrganization)
+				organizations.GET("/:id", organizationHandler.GetOrganization)
+				organizations.PATCH("/:id", organizationHandler.UpdateOrganization)
+				organizations.DELETE("/:id", organizationHandler.DeleteOrganization)
+				organizations.GET("/:id/sso-domains", authHandler.ListOrganizationSSODomains)
+				organizations.POST("/:id/sso-domains", authHandler.AddOrganizationSSODomain)
+				organizations.DELETE("/:id/sso-domains/:domain", authHandler.RemoveOrganizationSSODomain)
+			}
+
+			// Failed signup follow-up (platform admin only)
+			failedSignups := protected.Group("/failed-signups")
+			failedSignups.Use(middleware.RequireRole("platform_admin"))
+			{
+				failedSignups.GET("", signupHandler.ListFailedSignups)
+				failedSignups.POST("/:id/resolve", signupHandler.ResolveFailedSignup)
 			}
 
 			// Compliance & Reporting
@@ -121,18 +499,33 @@ func NewRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
 				compliance.POST("/templates", handlers.CreateComplianceTemplate)
 			}
 
+			// Audit export for SIEM ingestion
+			auditLogs := protected.Group("/audit")
+			auditLogs.Use(middleware.RequireRole("admin", "auditor"))
+			{
+				auditLogs.GET("/export", auditHandler.ExportAuditLog)
+			}
+
 			reports := protected.Group("/reports")
 			reports.Use(middleware.RequireRole("admin", "auditor"))
 			{
-				reports.GET("/audit", handlers.AuditReport)
+				reports.GET("/audit", auditHandler.ExportAuditLog)
 				reports.GET("/compliance/:framework", handlers.ComplianceReport)
-				reports.GET("/user-activity/:user_id", handlers.UserActivityReport)
+				reports.GET("/user-activity/:user_id", reportHandler.UserActivityReport)
+				reports.GET("/change-metrics", reportHandler.ChangeMetrics)
+				reports.GET("/change-metrics/systems", reportHandler.SystemChanges)
+				reports.GET("/missing-pirs", pirHandler.MissingPIRReport)
+				reports.GET("/worklogs", worklogHandler.WorklogReport)
+				reports.POST("/jobs", reportJobHandler.CreateReportJob)
+				reports.GET("/jobs", reportJobHandler.ListReportJobs)
+				reports.GET("/jobs/:id", reportJobHandler.GetReportJob)
+				reports.GET("/jobs/:id/download", reportJobHandler.DownloadReport)
 			}
 		}
 	}
 
 	// Metrics endpoint (internal only)
-	router.GET("/metrics", middleware.InternalOnly(), handlers.Metrics)
+	router.GET("/metrics", middleware.InternalOnly(), metricsHandler.Metrics)
 
 	// 404 handler
 	router.NoRoute(func(c *gin.Context) {
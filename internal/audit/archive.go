@@ -0,0 +1,327 @@
+package audit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/objectstore"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+const (
+	// ManifestSchema identifies the archive layout for readers of the
+	// manifests
+	ManifestSchema = "adsops.ticket_audit_log.v1"
+
+	// archiveSettle is how long after midnight UTC a day is left before
+	// archiving, so transactions still in flight at midnight have committed
+	archiveSettle = 10 * time.Minute
+	// maxCatchUpDays bounds the days the nightly run archives, oldest first
+	maxCatchUpDays = 31
+)
+
+// Uploader stores archive objects
+type Uploader interface {
+	PutObject(ctx context.Context, obj *objectstore.Object) error
+}
+
+// Archiver exports days of the ticket audit log to object storage as
+// gzipped NDJSON, each with a manifest of its checksums and record counts
+type Archiver struct {
+	store           *store.Store
+	uploader        Uploader
+	prefix          string
+	lockDays        int
+	retentionMonths int
+}
+
+// NewArchiver creates an archiver writing under the configured prefix
+func NewArchiver(s *store.Store, uploader Uploader, cfg *config.AuditArchiveConfig) *Archiver {
+	return &Archiver{
+		store:           s,
+		uploader:        uploader,
+		prefix:          strings.Trim(cfg.Prefix, "/"),
+		lockDays:        cfg.ObjectLockDays,
+		retentionMonths: cfg.PartitionRetentionMonths,
+	}
+}
+
+// Manifest describes one archived day. It is written next to the data
+// object so the archive can be verified without the database.
+type Manifest struct {
+	Schema             string           `json:"schema"`
+	Date               string           `json:"date"` // YYYY-MM-DD, UTC
+	From               time.Time        `json:"from"`
+	To                 time.Time        `json:"to"` // Exclusive
+	Object             string           `json:"object"`
+	ContentEncoding    string           `json:"content_encoding"`
+	RecordCount        int64            `json:"record_count"`
+	FirstRecordID      string           `json:"first_record_id,omitempty"`
+	LastRecordID       string           `json:"last_record_id,omitempty"`
+	Organizations      map[string]int64 `json:"organizations"` // Records per organization ID
+	CompressedBytes    int64            `json:"compressed_bytes"`
+	SHA256             string           `json:"sha256"`
+	UncompressedSHA256 string           `json:"uncompressed_sha256"`
+	ExportedAt         time.Time        `json:"exported_at"`
+}
+
+// ArchivePending archives every finished day since the last archived one,
+// at most maxCatchUpDays per run. With nothing archived yet it starts from
+// yesterday; older days are archived with ArchiveRange.
+func (a *Archiver) ArchivePending(ctx context.Context, now time.Time) ([]models.AuditArchive, error) {
+	last := lastFinishedDay(now)
+
+	from := last
+	lastArchived, err := a.store.Audit.LastArchivedDay(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if lastArchived != nil {
+		from = lastArchived.UTC().AddDate(0, 0, 1)
+	}
+	if from.After(last) {
+		return nil, nil
+	}
+	to := from.AddDate(0, 0, maxCatchUpDays-1)
+	if to.After(last) {
+		to = last
+	}
+	return a.ArchiveRange(ctx, from, to, now)
+}
+
+// ArchiveRange archives each day from from to to inclusive that has not
+// been archived yet. Days not yet over are refused.
+func (a *Archiver) ArchiveRange(ctx context.Context, from, to, now time.Time) ([]models.AuditArchive, error) {
+	from = truncateDay(from)
+	to = truncateDay(to)
+	if to.After(lastFinishedDay(now)) {
+		return nil, fmt.Errorf("%s is not over yet", to.Format("2006-01-02"))
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("range start %s is after its end %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	archived, err := a.store.Audit.ArchivedDays(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []models.AuditArchive
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if archived[day.Format("2006-01-02")] {
+			continue
+		}
+		archive, err := a.ArchiveDay(ctx, day, now)
+		if err != nil {
+			return created, fmt.Errorf("failed to archive %s: %w", day.Format("2006-01-02"), err)
+		}
+		created = append(created, *archive)
+	}
+	return created, nil
+}
+
+// ArchiveDay exports the entries created on a UTC day, uploads them and
+// their manifest, and records the archive
+func (a *Archiver) ArchiveDay(ctx context.Context, day, now time.Time) (*models.AuditArchive, error) {
+	from := truncateDay(day)
+	to := from.AddDate(0, 0, 1)
+	date := from.Format("2006-01-02")
+	dir := a.prefix + "/" + from.Format("2006/01/02")
+	if a.prefix == "" {
+		dir = from.Format("2006/01/02")
+	}
+
+	tmp, err := os.CreateTemp("", "audit-archive-*.ndjson.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	manifest := &Manifest{
+		Schema:          ManifestSchema,
+		Date:            date,
+		From:            from,
+		To:              to,
+		Object:          dir + "/ticket_audit_log-" + date + ".ndjson.gz",
+		ContentEncoding: "gzip",
+		Organizations:   make(map[string]int64),
+	}
+
+	compressedSHA, compressedMD5, rawSHA := sha256.New(), md5.New(), sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, compressedSHA, compressedMD5)}
+	gz := gzip.NewWriter(counter)
+	enc, err := NewEncoder(FormatNDJSON, io.MultiWriter(gz, rawSHA))
+	if err != nil {
+		return nil, err
+	}
+
+	// A day of audit log can take longer to stream than the API's
+	// statement timeout allows
+	err = a.store.Audit.StreamRange(a.store.LongRunning(ctx), from, to, func(entry *models.TicketAuditLog) error {
+		if manifest.FirstRecordID == "" {
+			manifest.FirstRecordID = entry.ID.String()
+		}
+		manifest.LastRecordID = entry.ID.String()
+		manifest.RecordCount++
+		manifest.Organizations[entry.OrganizationID.String()]++
+		return enc.Encode(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress audit archive: %w", err)
+	}
+
+	manifest.CompressedBytes = counter.n
+	manifest.SHA256 = hex.EncodeToString(compressedSHA.Sum(nil))
+	manifest.UncompressedSHA256 = hex.EncodeToString(rawSHA.Sum(nil))
+	manifest.ExportedAt = now.UTC()
+
+	var retainUntil time.Time
+	if a.lockDays > 0 {
+		retainUntil = now.AddDate(0, 0, a.lockDays)
+	}
+	metadata := map[string]string{
+		"Record-Count": strconv.FormatInt(manifest.RecordCount, 10),
+		"Sha256":       manifest.SHA256,
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind audit archive: %w", err)
+	}
+	err = a.uploader.PutObject(ctx, &objectstore.Object{
+		Key:         manifest.Object,
+		Body:        tmp,
+		Size:        manifest.CompressedBytes,
+		SHA256:      manifest.SHA256,
+		ContentMD5:  base64Sum(compressedMD5),
+		ContentType: "application/x-ndjson",
+		Metadata:    metadata,
+		RetainUntil: retainUntil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit manifest: %w", err)
+	}
+	manifestSHA := sha256.Sum256(manifestJSON)
+	manifestMD5 := md5.Sum(manifestJSON)
+	manifestKey := dir + "/manifest.json"
+	err = a.uploader.PutObject(ctx, &objectstore.Object{
+		Key:         manifestKey,
+		Body:        bytes.NewReader(manifestJSON),
+		Size:        int64(len(manifestJSON)),
+		SHA256:      hex.EncodeToString(manifestSHA[:]),
+		ContentMD5:  base64.StdEncoding.EncodeToString(manifestMD5[:]),
+		ContentType: "application/json",
+		RetainUntil: retainUntil,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &models.AuditArchive{
+		Date:               from,
+		ObjectKey:          manifest.Object,
+		ManifestKey:        manifestKey,
+		RecordCount:        manifest.RecordCount,
+		CompressedBytes:    manifest.CompressedBytes,
+		SHA256:             manifest.SHA256,
+		UncompressedSHA256: manifest.UncompressedSHA256,
+		ExportedAt:         manifest.ExportedAt,
+	}
+	if err := a.store.Audit.RecordArchive(ctx, archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// lastFinishedDay returns the latest UTC day that ended at least
+// archiveSettle before now
+// DropArchivedPartitions drops the monthly audit log partitions that ended
+// more than the retention period before the current UTC month and whose
+// days have all been archived. Months with days missing from the archive
+// are kept and reported in the error. With no retention period configured
+// nothing is dropped.
+func (a *Archiver) DropArchivedPartitions(ctx context.Context, now time.Time) ([]models.AuditPartition, error) {
+	if a.retentionMonths <= 0 {
+		return nil, nil
+	}
+	today := truncateDay(now)
+	cutoff := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -a.retentionMonths, 0)
+
+	partitions, err := a.store.Audit.ListPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []models.AuditPartition
+	var errs []error
+	for _, p := range partitions {
+		if p.To.After(cutoff) {
+			continue
+		}
+		archived, err := a.store.Audit.ArchivedDays(ctx, p.From, p.To.AddDate(0, 0, -1))
+		if err != nil {
+			return dropped, err
+		}
+		if days := int(p.To.Sub(p.From).Hours() / 24); len(archived) < days {
+			errs = append(errs, fmt.Errorf("%s: %d of %d days archived", p.Name, len(archived), days))
+			continue
+		}
+		if err := a.store.Audit.DropPartition(ctx, p.From); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+		dropped = append(dropped, p)
+	}
+	return dropped, errors.Join(errs...)
+}
+
+func lastFinishedDay(now time.Time) time.Time {
+	return truncateDay(now.Add(-archiveSettle)).AddDate(0, 0, -1)
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func base64Sum(h hash.Hash) string {
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,253 @@
+// Package audit provides encoders for exporting ticket audit logs to
+// spreadsheets and SIEM systems, and archives them to object storage.
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Format is an audit export format
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatCEF    Format = "cef"
+)
+
+// Valid returns true if the format is supported
+func (f Format) Valid() bool {
+	switch f {
+	case FormatCSV, FormatNDJSON, FormatCEF:
+		return true
+	}
+	return false
+}
+
+// ContentType returns the MIME type for the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension for the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatCEF:
+		return "cef"
+	case FormatNDJSON:
+		return "ndjson"
+	default:
+		return "csv"
+	}
+}
+
+// CEF header values identifying this system to the SIEM
+const (
+	cefVendor  = "AfterDarkSystems"
+	cefProduct = "ChangeManagement"
+	cefVersion = "1.0"
+)
+
+// Encoder writes audit log entries in a specific format
+type Encoder interface {
+	// Encode writes a single entry
+	Encode(entry *models.TicketAuditLog) error
+	// Flush writes any buffered data
+	Flush() error
+}
+
+// NewEncoder returns an encoder for the given format. CSV encoders write
+// their header row immediately.
+func NewEncoder(format Format, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		enc := &csvEncoder{w: csv.NewWriter(w)}
+		if err := enc.w.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return enc, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case FormatCEF:
+		return &cefEncoder{w: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+var csvHeader = []string{
+	"id", "created_at", "organization_id", "ticket_id", "user_id", "action",
+	"action_category", "field_name", "old_value", "new_value", "changes",
+	"ip_address", "user_agent", "request_id", "is_compliance_relevant",
+	"compliance_frameworks", "is_emergency", "requires_review", "reviewed_by", "reviewed_at",
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(entry *models.TicketAuditLog) error {
+	changes := ""
+	if entry.Changes != nil {
+		b, _ := json.Marshal(entry.Changes)
+		changes = string(b)
+	}
+	reviewedAt := ""
+	if entry.ReviewedAt != nil {
+		reviewedAt = entry.ReviewedAt.UTC().Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{
+		entry.ID.String(),
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		entry.OrganizationID.String(),
+		entry.TicketID.String(),
+		uuidString(entry.UserID),
+		entry.Action,
+		entry.ActionCategory,
+		stringValue(entry.FieldName),
+		stringValue(entry.OldValue),
+		stringValue(entry.NewValue),
+		changes,
+		stringValue(entry.IPAddress),
+		stringValue(entry.UserAgent),
+		stringValue(entry.RequestID),
+		strconv.FormatBool(entry.IsComplianceRelevant),
+		joinFrameworks(entry.ComplianceFrameworks),
+		strconv.FormatBool(entry.IsEmergency),
+		strconv.FormatBool(entry.RequiresReview),
+		uuidString(entry.ReviewedBy),
+		reviewedAt,
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(entry *models.TicketAuditLog) error {
+	return e.enc.Encode(entry)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+// cefEncoder writes ArcSight Common Event Format lines:
+// CEF:Version|Vendor|Product|Version|SignatureID|Name|Severity|Extension
+type cefEncoder struct {
+	w io.Writer
+}
+
+func (e *cefEncoder) Encode(entry *models.TicketAuditLog) error {
+	ext := []string{
+		"rt=" + strconv.FormatInt(entry.CreatedAt.UnixMilli(), 10),
+		"externalId=" + cefExtension(entry.ID.String()),
+		"cat=" + cefExtension(entry.ActionCategory),
+		"act=" + cefExtension(entry.Action),
+		"cs1Label=ticketId cs1=" + cefExtension(entry.TicketID.String()),
+		"cs2Label=organizationId cs2=" + cefExtension(entry.OrganizationID.String()),
+	}
+	if entry.UserID != nil {
+		ext = append(ext, "suid="+cefExtension(entry.UserID.String()))
+	}
+	if entry.IPAddress != nil {
+		ext = append(ext, "src="+cefExtension(*entry.IPAddress))
+	}
+	if entry.UserAgent != nil {
+		ext = append(ext, "requestClientApplication="+cefExtension(*entry.UserAgent))
+	}
+	if len(entry.ComplianceFrameworks) > 0 {
+		ext = append(ext, "cs3Label=complianceFrameworks cs3="+cefExtension(joinFrameworks(entry.ComplianceFrameworks)))
+	}
+	if entry.Changes != nil {
+		b, _ := json.Marshal(entry.Changes)
+		ext = append(ext, "cs4Label=changes cs4="+cefExtension(string(b)))
+	}
+	if entry.IsEmergency {
+		ext = append(ext, "cs5Label=emergency cs5=true")
+	}
+
+	line := fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s\n",
+		cefHeader(cefVendor), cefHeader(cefProduct), cefHeader(cefVersion),
+		cefHeader(entry.Action), cefHeader(entry.ActionCategory+" "+entry.Action),
+		cefSeverity(entry), strings.Join(ext, " "),
+	)
+	_, err := io.WriteString(e.w, line)
+	return err
+}
+
+func (e *cefEncoder) Flush() error {
+	return nil
+}
+
+// cefSeverity maps an entry to the 0-10 CEF severity scale
+func cefSeverity(entry *models.TicketAuditLog) int {
+	switch {
+	case entry.IsEmergency:
+		return 8
+	case entry.RequiresReview:
+		return 6
+	case entry.IsComplianceRelevant:
+		return 5
+	case entry.ActionCategory == "access":
+		return 1
+	default:
+		return 3
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+func cefHeader(s string) string {
+	return cefHeaderEscaper.Replace(s)
+}
+
+func cefExtension(s string) string {
+	return cefExtensionEscaper.Replace(s)
+}
+
+func joinFrameworks(frameworks []models.ComplianceFramework) string {
+	parts := make([]string, len(frameworks))
+	for i, f := range frameworks {
+		parts[i] = string(f)
+	}
+	return strings.Join(parts, ",")
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// userCodeAlphabet leaves out vowels, so codes can't spell words, and
+// characters that are easy to misread (RFC 8628 section 6.1)
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters in a user code, not counting
+// the dash in the middle
+const userCodeLength = 8
+
+// GenerateUserCode returns a random code, e.g. WDJB-MJHT, for the user to
+// enter when approving a device login
+func GenerateUserCode() (string, error) {
+	buf := make([]byte, userCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate user code: %w", err)
+	}
+	var b strings.Builder
+	for i, v := range buf {
+		if i == userCodeLength/2 {
+			b.WriteByte('-')
+		}
+		// Same slight bias as backup codes; it doesn't matter for a
+		// short-lived code
+		b.WriteByte(userCodeAlphabet[int(v)%len(userCodeAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// NormalizeUserCode puts a user code as typed into the form it was issued in
+func NormalizeUserCode(code string) string {
+	code = strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+)
+
+// ErrOAuth2Provider is returned when an identity provider rejects a request
+// or answers with something unusable
+var ErrOAuth2Provider = errors.New("identity provider request failed")
+
+var oauth2Client = &http.Client{Timeout: 10 * time.Second}
+
+// OAuth2UserInfo is the signed-in user as reported by a provider's userinfo
+// endpoint
+type OAuth2UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+	HostedDomain  string // Google Workspace domain, empty for consumer accounts
+	Groups        []string
+}
+
+// OAuth2Configured returns an error unless the provider has everything the
+// authorization-code flow needs
+func OAuth2Configured(name string, p *config.OAuth2Provider) error {
+	if p.ClientID == "" || p.RedirectURL == "" || p.AuthURL == "" || p.TokenURL == "" || p.UserInfoURL == "" {
+		return fmt.Errorf("%s sign-in is not configured", name)
+	}
+	return nil
+}
+
+// NewPKCEVerifier returns a random PKCE code verifier
+func NewPKCEVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// OAuth2AuthorizationURL returns the provider URL that starts a sign-in.
+// The state comes back on the callback; the verifier's S256 challenge binds
+// the code to this request.
+func OAuth2AuthorizationURL(p *config.OAuth2Provider, state, verifier string, extra url.Values) string {
+	sum := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.GetScopes(), " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+	for k, v := range extra {
+		q[k] = v
+	}
+
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + q.Encode()
+}
+
+// ExchangeOAuth2Code trades an authorization code for an access token
+func ExchangeOAuth2Code(ctx context.Context, p *config.OAuth2Provider, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		Error       string `json:"error"`
+	}
+	if err := doOAuth2(req, &body); err != nil {
+		return "", err
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("%w: %s", ErrOAuth2Provider, body.Error)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("%w: no access token returned", ErrOAuth2Provider)
+	}
+	return body.AccessToken, nil
+}
+
+// FetchOAuth2UserInfo returns the user an access token belongs to
+func FetchOAuth2UserInfo(ctx context.Context, p *config.OAuth2Provider, accessToken string) (*OAuth2UserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims map[string]interface{}
+	if err := doOAuth2(req, &claims); err != nil {
+		return nil, err
+	}
+
+	info := &OAuth2UserInfo{
+		Subject:       stringClaim(claims["sub"]),
+		Email:         stringClaim(claims["email"]),
+		EmailVerified: boolClaim(claims["email_verified"]),
+		Name:          stringClaim(claims["name"]),
+		Picture:       stringClaim(claims["picture"]),
+		HostedDomain:  strings.ToLower(stringClaim(claims["hd"])),
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("%w: userinfo has no subject", ErrOAuth2Provider)
+	}
+
+	// Groups may be a list or a single comma-separated string
+	groupsClaim := p.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	switch v := claims[groupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if s := strings.TrimSpace(stringClaim(g)); s != "" {
+				info.Groups = append(info.Groups, s)
+			}
+		}
+	case string:
+		for _, g := range strings.Split(v, ",") {
+			if s := strings.TrimSpace(g); s != "" {
+				info.Groups = append(info.Groups, s)
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func doOAuth2(req *http.Request, out interface{}) error {
+	resp, err := oauth2Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuth2Provider, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuth2Provider, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrOAuth2Provider, req.URL.Host, resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%w: invalid response: %v", ErrOAuth2Provider, err)
+	}
+	return nil
+}
+
+func stringClaim(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// boolClaim accepts booleans and the "true" strings some providers send
+func boolClaim(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+// Package auth issues and validates the signed access tokens the API
+// accepts as bearer tokens. Tokens are HS256 JWTs signed with the
+// configured secret and carry the user, organization, roles and session,
+// plus the customer for customer portal users.
+// The package also generates and checks the TOTP codes used for MFA and
+// runs the OAuth2 authorization-code flow used for single sign-on.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+)
+
+// ErrNotConfigured is returned when no signing secret is configured
+var ErrNotConfigured = errors.New("authentication is not configured")
+
+// ErrInvalidToken is returned for tokens that are malformed, tampered with,
+// expired or issued by someone else
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Claims are the contents of an access token
+type Claims struct {
+	UserID         uuid.UUID  `json:"sub"`
+	OrganizationID uuid.UUID  `json:"org"`
+	Roles          []string   `json:"roles"`
+	SessionID      uuid.UUID  `json:"sid"`
+	AuthMethod     string     `json:"amr"`              // password, passkey, ...
+	MFAAt          int64      `json:"mfa_at,omitempty"` // Last MFA or passkey verification
+	Scope          string     `json:"scope,omitempty"`  // Empty for full access
+	CustomerID     *uuid.UUID `json:"cust,omitempty"`   // Set for customer portal users
+	Issuer         string     `json:"iss"`
+	IssuedAt       int64      `json:"iat"`
+	ExpiresAt      int64      `json:"exp"`
+}
+
+// ScopeMFAEnrollment limits a token to the auth endpoints, for users who
+// must set up MFA before doing anything else
+const ScopeMFAEnrollment = "mfa_enrollment"
+
+// ScopeCustomerPortal limits a token to the customer portal endpoints, for
+// users who belong to one of the organization's customers
+const ScopeCustomerPortal = "customer_portal"
+
+// Expiry returns when the token stops being accepted
+func (c *Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// MFAVerifiedAt returns when the user last completed MFA, or the zero time
+func (c *Claims) MFAVerifiedAt() time.Time {
+	if c.MFAAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.MFAAt, 0)
+}
+
+// AccessTokenTTL returns the configured access token lifetime
+func AccessTokenTTL(cfg *config.JWTConfig) time.Duration {
+	if cfg.AccessTokenDuration <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(cfg.AccessTokenDuration) * time.Minute
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Sign fills in the issuer and lifetime and returns the signed token
+func Sign(cfg *config.JWTConfig, claims *Claims, now time.Time) (string, error) {
+	if cfg.SecretKey == "" {
+		return "", ErrNotConfigured
+	}
+	claims.Issuer = cfg.Issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(AccessTokenTTL(cfg)).Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signature(cfg.SecretKey, unsigned), nil
+}
+
+// Parse validates a token's signature, issuer and expiry and returns its claims
+func Parse(cfg *config.JWTConfig, token string, now time.Time) (*Claims, error) {
+	if cfg.SecretKey == "" {
+		return nil, ErrNotConfigured
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, ErrInvalidToken
+	}
+	expected := signature(cfg.SecretKey, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != cfg.Issuer || !now.Before(claims.Expiry()) || claims.UserID == uuid.Nil {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func signature(secret, unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,113 @@
+package blackout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// Result summarizes one cleanup run
+type Result struct {
+	Expired  int64 // Blackouts past their end time marked expired
+	Restored int64 // Hosts returned to active
+	Active   int   // Blackouts still in effect and exported
+}
+
+// Cleaner expires finished blackouts and rewrites the monitoring export
+type Cleaner struct {
+	store       *store.Store
+	exportPath  string
+	metricsPath string
+
+	mu          sync.Mutex
+	expired     int64
+	restored    int64
+	failures    int64
+	active      int
+	lastSuccess time.Time
+}
+
+// NewCleaner creates a cleaner writing the export and, if configured, a
+// Prometheus textfile of its counters
+func NewCleaner(s *store.Store, cfg config.BlackoutConfig) *Cleaner {
+	return &Cleaner{store: s, exportPath: cfg.ExportPath, metricsPath: cfg.MetricsPath}
+}
+
+// Run expires blackouts past their end time, restores their hosts and
+// writes the export of the blackouts still in effect
+func (c *Cleaner) Run(ctx context.Context, now time.Time) (*Result, error) {
+	result, err := c.run(ctx, now)
+
+	c.mu.Lock()
+	if err != nil {
+		c.failures++
+	} else {
+		c.expired += result.Expired
+		c.restored += result.Restored
+		c.active = result.Active
+		c.lastSuccess = now
+	}
+	c.mu.Unlock()
+
+	if c.metricsPath != "" {
+		if mErr := c.writeMetrics(); mErr != nil && err == nil {
+			err = mErr
+		}
+	}
+	return result, err
+}
+
+func (c *Cleaner) run(ctx context.Context, now time.Time) (*Result, error) {
+	result := &Result{}
+
+	expired, restored, err := c.store.Inventory.ExpireBlackouts(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Expired = expired
+	result.Restored = restored
+
+	blackouts, err := c.store.Inventory.ListBlackoutsInEffect(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Active = len(blackouts)
+
+	if err := WriteExport(c.exportPath, blackouts, now); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// writeMetrics writes the cleaner's counters in the Prometheus text format
+// for node_exporter's textfile collector
+func (c *Cleaner) writeMetrics() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# HELP adsops_blackouts_expired_total Blackouts expired by the worker.")
+	fmt.Fprintln(&b, "# TYPE adsops_blackouts_expired_total counter")
+	fmt.Fprintf(&b, "adsops_blackouts_expired_total %d\n", c.expired)
+	fmt.Fprintln(&b, "# HELP adsops_blackout_hosts_restored_total Hosts returned to active after their blackout ended.")
+	fmt.Fprintln(&b, "# TYPE adsops_blackout_hosts_restored_total counter")
+	fmt.Fprintf(&b, "adsops_blackout_hosts_restored_total %d\n", c.restored)
+	fmt.Fprintln(&b, "# HELP adsops_blackout_cleanup_failures_total Blackout cleanup runs that failed.")
+	fmt.Fprintln(&b, "# TYPE adsops_blackout_cleanup_failures_total counter")
+	fmt.Fprintf(&b, "adsops_blackout_cleanup_failures_total %d\n", c.failures)
+	fmt.Fprintln(&b, "# HELP adsops_blackouts_active Blackouts in effect at the last successful cleanup.")
+	fmt.Fprintln(&b, "# TYPE adsops_blackouts_active gauge")
+	fmt.Fprintf(&b, "adsops_blackouts_active %d\n", c.active)
+	if !c.lastSuccess.IsZero() {
+		fmt.Fprintln(&b, "# HELP adsops_blackout_cleanup_last_success_timestamp_seconds Time of the last successful cleanup.")
+		fmt.Fprintln(&b, "# TYPE adsops_blackout_cleanup_last_success_timestamp_seconds gauge")
+		fmt.Fprintf(&b, "adsops_blackout_cleanup_last_success_timestamp_seconds %d\n", c.lastSuccess.Unix())
+	}
+
+	return writeFileAtomic(c.metricsPath, b.Bytes())
+}
@@ -0,0 +1,87 @@
+// Package blackout expires finished host blackouts and keeps the monitoring
+// export of active blackouts current, so alert suppression ends on time
+// without anyone running the blackout tool's cleanup.
+package blackout
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ActiveExport is one entry in the monitoring export. The format matches
+// the blackout tool's.
+type ActiveExport struct {
+	Hostname      string `json:"hostname"`
+	Ticket        string `json:"ticket"`
+	EndTime       string `json:"end_time"` // RFC 3339
+	Reason        string `json:"reason"`
+	RemainingTime string `json:"remaining_time,omitempty"`
+}
+
+// WriteExport writes the blackouts in effect to path. The file is replaced
+// atomically so monitoring never reads a partial export.
+func WriteExport(path string, blackouts []models.Blackout, now time.Time) error {
+	exports := make([]ActiveExport, 0, len(blackouts))
+	for _, b := range blackouts {
+		exports = append(exports, ActiveExport{
+			Hostname:      b.Hostname,
+			Ticket:        b.TicketNumber,
+			EndTime:       b.EndTime.UTC().Format(time.RFC3339),
+			Reason:        b.Reason,
+			RemainingTime: formatRemaining(b.EndTime.Sub(now)),
+		})
+	}
+
+	data, err := json.MarshalIndent(exports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode blackout export: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// formatRemaining formats a duration as the blackout tool does, e.g. 2h 5m
+func formatRemaining(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatRemaining(-d)
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
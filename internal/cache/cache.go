@@ -0,0 +1,54 @@
+// Package cache keeps short-lived results, such as report aggregates and
+// the tickets the web UI fetches over and over, in Redis so repeated reads
+// don't rerun the queries.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Cache stores values under keys for a limited time
+type Cache interface {
+	// Get returns the value stored under the key, and false if there is none
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value under the key until the TTL passes
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys, ignoring those that aren't set
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Nop is a cache that stores nothing
+type Nop struct{}
+
+// Get always misses
+func (Nop) Get(context.Context, string) ([]byte, bool, error) { return nil, false, nil }
+
+// Set discards the value
+func (Nop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+
+// Delete does nothing
+func (Nop) Delete(context.Context, ...string) error { return nil }
+
+// GetJSON decodes the value stored under the key into dest. A value that
+// fails to decode counts as a miss.
+func GetJSON(ctx context.Context, c Cache, key string, dest interface{}) (bool, error) {
+	data, ok, err := c.Get(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// SetJSON stores the value under the key as JSON
+func SetJSON(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, data, ttl)
+}
@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+)
+
+// dialTimeout bounds connecting to Redis when the context has no deadline
+const dialTimeout = 2 * time.Second
+
+// commandTimeout bounds a command when the context has no deadline
+const commandTimeout = 2 * time.Second
+
+// Redis is a cache backed by a Redis server. It speaks just enough of the
+// RESP protocol for GET, SET and DEL over one connection, reconnecting after
+// any error.
+type Redis struct {
+	addr     string
+	password string
+	db       int
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedis creates a Redis cache. No connection is made until first use.
+func NewRedis(cfg *config.RedisConfig) *Redis {
+	return &Redis{addr: cfg.Addr(), password: cfg.Password, db: cfg.DB}
+}
+
+// New returns a Redis cache for the configuration, or a Nop cache when no
+// Redis host is configured
+func New(cfg *config.RedisConfig) Cache {
+	if cfg.Host == "" {
+		return Nop{}
+	}
+	return NewRedis(cfg)
+}
+
+// Get returns the value stored under the key
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return data, true, nil
+}
+
+// Set stores the value under the key for the TTL, rounded up to a whole
+// millisecond
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := (ttl + time.Millisecond - 1) / time.Millisecond
+	if ms < 1 {
+		ms = 1
+	}
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(int64(ms), 10))
+	return err
+}
+
+// Delete removes the keys
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Close closes the connection, if one is open
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reset()
+}
+
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := r.command(ctx, args...)
+	if err != nil {
+		var redisErr redisError
+		if !errors.As(err, &redisErr) {
+			// The connection may be mid-reply; start over next time
+			r.reset()
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (r *Redis) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return fmt.Errorf("redis: failed to connect: %w", err)
+	}
+	r.conn = conn
+	r.rd = bufio.NewReader(conn)
+
+	if r.password != "" {
+		if _, err := r.command(ctx, "AUTH", r.password); err != nil {
+			r.reset()
+			return fmt.Errorf("redis: failed to authenticate: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := r.command(ctx, "SELECT", strconv.Itoa(r.db)); err != nil {
+			r.reset()
+			return fmt.Errorf("redis: failed to select database: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Redis) reset() error {
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn, r.rd = nil, nil
+	return err
+}
+
+// command sends one command as an array of bulk strings and reads its reply
+func (r *Redis) command(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(commandTimeout)
+	}
+	if err := r.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := r.conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("redis: failed to send command: %w", err)
+	}
+
+	return readReply(r.rd)
+}
+
+// redisError is an error reply from the server. The connection stays
+// usable after one.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// readReply reads one RESP reply. Simple strings decode as string, bulk
+// strings as []byte, integers as int64 and arrays as []interface{}; nil
+// bulk strings and arrays decode as nil.
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := readLine(rd)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	payload := string(line[1:])
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid integer reply: %q", payload)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: invalid bulk length: %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, fmt.Errorf("redis: failed to read reply: %w", err)
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: invalid array length: %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
+
+func readLine(rd *bufio.Reader) ([]byte, error) {
+	line, err := rd.ReadSlice('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to read reply: %w", err)
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply line")
+	}
+	return line[:len(line)-2], nil
+}
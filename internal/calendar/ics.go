@@ -0,0 +1,125 @@
+// Package calendar renders scheduled change windows as iCalendar (RFC 5545)
+// feeds for calendar clients to subscribe to.
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/notifications"
+)
+
+// ContentType is the MIME type of an iCalendar feed
+const ContentType = "text/calendar; charset=utf-8"
+
+// DefaultWindow is the length assumed for a change with no scheduled end
+const DefaultWindow = time.Hour
+
+const (
+	prodID        = "-//AfterDarkSystems//ChangeManagement//EN"
+	refreshPeriod = "PT1H"
+	timeLayout    = "20060102T150405Z"
+	maxLineOctets = 75
+)
+
+// Write renders tickets as a calendar named name, one event per scheduled
+// window. Tickets without a scheduled start are skipped. baseURL is used to
+// link each event to its ticket.
+func Write(w io.Writer, name string, tickets []models.Ticket, baseURL string) error {
+	bw := bufio.NewWriter(w)
+	line := func(property, value string) {
+		writeFolded(bw, property+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeText(name))
+	line("X-PUBLISHED-TTL", refreshPeriod)
+	line("REFRESH-INTERVAL;VALUE=DURATION", refreshPeriod)
+
+	now := time.Now()
+	for i := range tickets {
+		t := &tickets[i]
+		if t.ScheduledStart == nil {
+			continue
+		}
+		end := t.ScheduledStart.Add(DefaultWindow)
+		if t.ScheduledEnd != nil && t.ScheduledEnd.After(*t.ScheduledStart) {
+			end = *t.ScheduledEnd
+		}
+		link := notifications.TicketURL(baseURL, t)
+
+		line("BEGIN", "VEVENT")
+		line("UID", t.ID.String()+"@adsops-utils")
+		line("DTSTAMP", formatTime(now))
+		line("LAST-MODIFIED", formatTime(t.UpdatedAt))
+		line("SEQUENCE", fmt.Sprint(t.Version))
+		line("DTSTART", formatTime(*t.ScheduledStart))
+		line("DTEND", formatTime(end))
+		line("SUMMARY", escapeText(t.TicketNumber+": "+t.Title))
+		line("DESCRIPTION", escapeText(describe(t, link)))
+		line("URL", link)
+		line("STATUS", "CONFIRMED")
+		if t.ChangeType != nil && *t.ChangeType != "" {
+			line("CATEGORIES", escapeText(*t.ChangeType))
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// describe summarizes a change for the event description
+func describe(t *models.Ticket, link string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Status: %s\nPriority: %s\nRisk: %s\n", t.Status, t.Priority, t.RiskLevel)
+	if t.IsEmergencyChange() {
+		b.WriteString("Emergency change\n")
+	}
+	if len(t.AffectedSystems) > 0 {
+		fmt.Fprintf(&b, "Affected systems: %s\n", strings.Join(t.AffectedSystems, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s", link)
+	return b.String()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11)
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes a content line, folding it so no line is longer than
+// 75 octets without splitting a UTF-8 sequence (RFC 5545 section 3.1)
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // continuation lines start with a space
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,67 @@
+package apiclient
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// APIKey is an API key as listed by GET /v1/api-keys. The key itself is
+// never returned after creation; KeyPrefix identifies it.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	UsageCount int64      `json:"usage_count"`
+	IsActive   bool       `json:"is_active"`
+}
+
+// CreatedAPIKey is the response from POST /v1/api-keys, the only time the
+// full key is sent
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"api_key"`
+}
+
+// APIKeyList is the response from GET /v1/api-keys. Limit is the most
+// active keys a user may hold.
+type APIKeyList struct {
+	Keys  []APIKey `json:"keys"`
+	Total int      `json:"total"`
+	Limit int      `json:"limit"`
+}
+
+// CreateAPIKeyInput is the request to create an API key. ExpiresIn is in
+// days; nil means the key never expires.
+type CreateAPIKeyInput struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes,omitempty"`
+	ExpiresIn *int     `json:"expires_in,omitempty"`
+}
+
+// ListAPIKeys returns the caller's active API keys, newest first
+func (c *Client) ListAPIKeys() (*APIKeyList, error) {
+	var list APIKeyList
+	if err := c.Get("/v1/api-keys", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// CreateAPIKey creates an API key for the caller
+func (c *Client) CreateAPIKey(input CreateAPIKeyInput) (*CreatedAPIKey, error) {
+	var key CreatedAPIKey
+	if err := c.Post("/v1/api-keys", "", input, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey revokes one of the caller's API keys
+func (c *Client) RevokeAPIKey(id string) error {
+	return c.Do(http.MethodDelete, "/v1/api-keys/"+url.PathEscape(id), nil, nil, nil)
+}
@@ -0,0 +1,54 @@
+package apiclient
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// ApprovalList is the response from GET /v1/approvals
+type ApprovalList struct {
+	Approvals []models.ApprovalSummary `json:"approvals"`
+	Total     int                      `json:"total"`
+}
+
+// ListApprovals returns the caller's approvals matching the filter
+func (c *Client) ListApprovals(filter models.ApprovalListFilter) (*ApprovalList, error) {
+	filter.SetDefaults()
+
+	query := url.Values{}
+	for _, s := range filter.Status {
+		query.Add("status", string(s))
+	}
+	for _, t := range filter.ApprovalType {
+		query.Add("approval_type", string(t))
+	}
+	if filter.TicketID != nil {
+		query.Set("ticket_id", filter.TicketID.String())
+	}
+	query.Set("page", strconv.Itoa(filter.Page))
+	query.Set("per_page", strconv.Itoa(filter.PerPage))
+
+	var list ApprovalList
+	if err := c.Get("/v1/approvals", query, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Approve records an approval decision
+func (c *Client) Approve(id uuid.UUID, input models.ApproveInput) error {
+	return c.Post("/v1/approvals/"+id.String()+"/approve", "cli-approve-"+id.String(), input, nil)
+}
+
+// Deny records a denial
+func (c *Client) Deny(id uuid.UUID, input models.DenyInput) error {
+	return c.Post("/v1/approvals/"+id.String()+"/deny", "cli-deny-"+id.String(), input, nil)
+}
+
+// RequestUpdate sends the ticket back to its creator for changes
+func (c *Client) RequestUpdate(id uuid.UUID, input models.RequestUpdateInput) error {
+	return c.Post("/v1/approvals/"+id.String()+"/request-update", "", input, nil)
+}
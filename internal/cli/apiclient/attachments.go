@@ -0,0 +1,97 @@
+package apiclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// AttachmentList is the response from GET /v1/tickets/:id/attachments
+type AttachmentList struct {
+	Attachments  []models.Attachment `json:"attachments"`
+	Count        int                 `json:"count"`
+	MaxSizeBytes int64               `json:"max_size_bytes"`
+}
+
+// ListAttachments returns a ticket's attachments with download links, and
+// the largest file the API accepts
+func (c *Client) ListAttachments(ticketID uuid.UUID) (*AttachmentList, error) {
+	var list AttachmentList
+	if err := c.Get("/v1/tickets/"+ticketID.String()+"/attachments", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// UploadAttachment streams size bytes of body to a ticket as the file name.
+// progress, when set, is called with the number of bytes of the file sent so
+// far. The upload has no overall timeout, since large files on slow links
+// take as long as they take.
+func (c *Client) UploadAttachment(ticketID uuid.UUID, name string, body io.Reader, size int64, progress func(sent int64)) (*models.Attachment, error) {
+	// Frame the file in a multipart body of known length, so the API can
+	// refuse an oversized upload from its Content-Length alone
+	var frame bytes.Buffer
+	form := multipart.NewWriter(&frame)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": name}))
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	if _, err := form.CreatePart(header); err != nil {
+		return nil, fmt.Errorf("failed to encode upload: %w", err)
+	}
+	head := append([]byte(nil), frame.Bytes()...)
+	frame.Reset()
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode upload: %w", err)
+	}
+	tail := frame.Bytes()
+
+	if progress != nil {
+		body = &progressReader{r: body, report: progress}
+	}
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL+"/v1/tickets/"+ticketID.String()+"/attachments",
+		io.MultiReader(bytes.NewReader(head), body, bytes.NewReader(tail)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = int64(len(head)) + size + int64(len(tail))
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	var resp struct {
+		Attachment models.Attachment `json:"attachment"`
+	}
+	if _, err := exchange(&http.Client{Transport: c.HTTPClient.Transport}, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Attachment, nil
+}
+
+// progressReader reports how much has been read through it
+type progressReader struct {
+	r      io.Reader
+	read   int64
+	report func(int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	p.report(p.read)
+	return n, err
+}
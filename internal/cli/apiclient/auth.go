@@ -0,0 +1,131 @@
+package apiclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// LoginResponse is the response from the login endpoints: either tokens, or
+// an MFA challenge to answer with LoginMFA
+type LoginResponse struct {
+	models.AuthTokens
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// Login signs in with an email and password
+func (c *Client) Login(input models.LoginInput) (*LoginResponse, error) {
+	var resp LoginResponse
+	if err := c.Post("/v1/auth/login", "", input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// LoginMFA answers the MFA challenge from a first-factor login
+func (c *Client) LoginMFA(mfaToken, code string) (*LoginResponse, error) {
+	var resp LoginResponse
+	body := models.LoginMFAInput{MFAToken: mfaToken, Code: code}
+	if err := c.Post("/v1/auth/login/mfa", "", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// StartDeviceLogin starts a device login. provider, if set (google or
+// afterdark), is the SSO provider the web app signs the user in with.
+func (c *Client) StartDeviceLogin(provider string) (*models.DeviceAuthorization, error) {
+	path := "/v1/auth/device/code"
+	if provider != "" {
+		path += "?" + url.Values{"provider": []string{provider}}.Encode()
+	}
+	var resp models.DeviceAuthorization
+	if err := c.Post(path, "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PollDeviceLogin asks whether the user has approved a device login yet.
+// Until they have it returns an error that IsDeviceLoginPending reports.
+func (c *Client) PollDeviceLogin(deviceCode string) (*LoginResponse, error) {
+	var resp LoginResponse
+	body := models.DeviceTokenInput{DeviceCode: deviceCode}
+	if err := c.Post("/v1/auth/device/token", "", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// IsDeviceLoginPending reports whether err means a device login is still
+// waiting for the user to approve it
+func IsDeviceLoginPending(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.Message == models.DeviceErrorPending
+}
+
+// CurrentUser returns the signed-in user
+func (c *Client) CurrentUser() (*models.User, error) {
+	var resp struct {
+		User models.User `json:"user"`
+	}
+	if err := c.Get("/v1/auth/me", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.User, nil
+}
+
+// Logout revokes the current session
+func (c *Client) Logout() error {
+	return c.Post("/v1/auth/logout", "", nil, nil)
+}
+
+// refresh exchanges the current session token for a new one and stores it
+func (c *Client) refresh(creds *Credentials) error {
+	var tokens models.AuthTokens
+	if err := c.Post("/v1/auth/refresh", "", nil, &tokens); err != nil {
+		return err
+	}
+	if tokens.AccessToken == "" {
+		return &APIError{StatusCode: http.StatusBadGateway, Message: "refresh returned no token"}
+	}
+
+	c.Token = tokens.AccessToken
+	creds.Token = tokens.AccessToken
+	creds.ExpiresAt = expiryOf(&tokens)
+	creds.Scope = tokens.Scope
+	_, err := SaveCredentials(creds)
+	return err
+}
+
+// SessionCredentials builds the credentials to store for a completed login
+func SessionCredentials(apiURL string, tokens *models.AuthTokens) *Credentials {
+	return &Credentials{
+		APIURL:     apiURL,
+		Kind:       CredentialSession,
+		Token:      tokens.AccessToken,
+		ExpiresAt:  expiryOf(tokens),
+		AuthMethod: tokens.AuthMethod,
+		Scope:      tokens.Scope,
+		UserID:     tokens.User.ID.String(),
+		Email:      tokens.User.Email,
+		FullName:   tokens.User.FullName,
+	}
+}
+
+// expiryOf prefers the absolute expiry and falls back to expires_in
+func expiryOf(tokens *models.AuthTokens) *time.Time {
+	if !tokens.ExpiresAt.IsZero() {
+		t := tokens.ExpiresAt
+		return &t
+	}
+	if tokens.ExpiresIn > 0 {
+		t := time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+		return &t
+	}
+	return nil
+}
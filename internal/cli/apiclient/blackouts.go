@@ -0,0 +1,57 @@
+package apiclient
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// StartBlackout starts a blackout for a host from an approved or
+// implementing ticket that lists the host as an affected system
+func (c *Client) StartBlackout(hostname string, input models.StartBlackoutInput) (*models.Blackout, error) {
+	var resp struct {
+		Blackout models.Blackout `json:"blackout"`
+	}
+	if err := c.Post("/v1/hosts/"+url.PathEscape(hostname)+"/blackouts", "", input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Blackout, nil
+}
+
+// EndBlackout ends a host's active blackout early
+func (c *Client) EndBlackout(hostname string) (*models.Blackout, error) {
+	var resp struct {
+		Blackout models.Blackout `json:"blackout"`
+	}
+	if err := c.Do(http.MethodPost, "/v1/hosts/"+url.PathEscape(hostname)+"/blackouts/end", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Blackout, nil
+}
+
+// ListHostBlackouts returns a host's blackouts, newest first
+func (c *Client) ListHostBlackouts(hostname string, activeOnly bool) ([]models.Blackout, error) {
+	return c.listBlackouts("/v1/hosts/"+url.PathEscape(hostname)+"/blackouts", activeOnly)
+}
+
+// ListTicketBlackouts returns the blackouts started for a ticket, newest
+// first
+func (c *Client) ListTicketBlackouts(ticketID uuid.UUID, activeOnly bool) ([]models.Blackout, error) {
+	return c.listBlackouts("/v1/tickets/"+ticketID.String()+"/blackouts", activeOnly)
+}
+
+func (c *Client) listBlackouts(path string, activeOnly bool) ([]models.Blackout, error) {
+	query := url.Values{}
+	if activeOnly {
+		query.Set("active", "true")
+	}
+	var resp struct {
+		Blackouts []models.Blackout `json:"blackouts"`
+	}
+	if err := c.Get(path, query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Blackouts, nil
+}
@@ -0,0 +1,251 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultAPIURL is used when neither --api-url nor the config file set one
+const DefaultAPIURL = "https://api.changes.afterdarksys.com"
+
+// ErrNotAuthenticated is returned when no API token is configured
+var ErrNotAuthenticated = errors.New("not authenticated. Run 'changes login' first")
+
+// ErrSessionExpired is returned when the stored session has expired and
+// could not be refreshed
+var ErrSessionExpired = errors.New("session expired. Run 'changes login' to sign in again")
+
+// refreshWindow is how close to expiry a stored session is refreshed
+const refreshWindow = 5 * time.Minute
+
+// Client talks to the Change Management API on behalf of the CLI user
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// APIError is a non-2xx response from the API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsConflict reports whether err is a 409 from the API
+func IsConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// IsUnauthorized reports whether err is a 401 from the API
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// IsUnreachable reports whether err means the API could not be reached at
+// all: the connection failed or timed out, or a gateway in front of the API
+// answered for it
+func IsUnreachable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotImplemented reports whether err is a 501 from an endpoint the server
+// does not support yet
+func IsNotImplemented(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotImplemented
+}
+
+// New builds a client from the CLI configuration. The API URL comes from
+// --api-url (viper api_url). An explicit token in auth_token (config file or
+// ADSOPS_AUTH_TOKEN) or CHANGES_API_TOKEN wins, then the token token_ref
+// points at; otherwise the credentials stored by 'changes login' are used,
+// refreshing a session close to expiry.
+func New() (*Client, error) {
+	client := NewUnauthenticated()
+
+	token := viper.GetString("auth_token")
+	if token == "" {
+		token = os.Getenv("CHANGES_API_TOKEN")
+	}
+	if token == "" {
+		if ref := viper.GetString("token_ref"); ref != "" {
+			var err error
+			if token, err = ResolveTokenRef(ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if token != "" {
+		client.Token = token
+		return client, nil
+	}
+
+	creds, err := LoadCredentials(client.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		return nil, ErrNotAuthenticated
+	}
+	client.Token = creds.Token
+
+	if creds.Kind == CredentialSession && creds.ExpiresAt != nil && time.Until(*creds.ExpiresAt) < refreshWindow {
+		if err := client.refresh(creds); err != nil && creds.Expired(time.Now()) {
+			return nil, ErrSessionExpired
+		}
+	}
+	return client, nil
+}
+
+// NewUnauthenticated builds a client without a token, for the login endpoints
+func NewUnauthenticated() *Client {
+	return &Client{
+		BaseURL:    APIURL(),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Get fetches path with the given query and decodes the response into out
+func (c *Client) Get(path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.Do(http.MethodGet, path, nil, nil, out)
+}
+
+// Post sends body as JSON to path and decodes the response into out. A
+// non-empty idempotencyKey is sent as Idempotency-Key so a retried request
+// is not applied twice.
+func (c *Client) Post(path, idempotencyKey string, body, out interface{}) error {
+	var headers http.Header
+	if idempotencyKey != "" {
+		headers = http.Header{"Idempotency-Key": []string{idempotencyKey}}
+	}
+	return c.Do(http.MethodPost, path, headers, body, out)
+}
+
+// Do performs an authenticated request. out may be nil when the response
+// body is not needed.
+func (c *Client) Do(method, path string, headers http.Header, body, out interface{}) error {
+	_, err := c.send(method, path, headers, body, out)
+	return err
+}
+
+// send performs a request like Do and also returns the response headers
+func (c *Client) send(method, path string, headers http.Header, body, out interface{}) (http.Header, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return exchange(c.HTTPClient, req, out)
+}
+
+// exchange sends a prepared request and decodes a successful response into
+// out, returning the response headers
+func exchange(client *http.Client, req *http.Request, out interface{}) (http.Header, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.Header, &APIError{StatusCode: resp.StatusCode, Message: errorMessage(respBody, resp.Status)}
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return resp.Header, nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return resp.Header, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// errorMessage pulls the message out of an error body, which is either
+// {"error": "..."}, {"error": {"code": ..., "message": "..."}} or an
+// RFC 7807 problem with a detail field
+func errorMessage(body []byte, status string) string {
+	var problem struct {
+		Error  json.RawMessage `json:"error"`
+		Detail string          `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &problem); err == nil {
+		if problem.Detail != "" {
+			return problem.Detail
+		}
+		var msg string
+		if json.Unmarshal(problem.Error, &msg) == nil && msg != "" {
+			return msg
+		}
+		var coded struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(problem.Error, &coded) == nil && coded.Message != "" {
+			return coded.Message
+		}
+	}
+	if msg := strings.TrimSpace(string(body)); msg != "" && len(msg) < 200 {
+		return msg
+	}
+	return status
+}
@@ -0,0 +1,285 @@
+package apiclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// keyringService names the CLI's entries in the OS keyring
+const keyringService = "adsops-utils"
+
+// Credential kinds
+const (
+	CredentialSession = "session"
+	CredentialAPIKey  = "api_key"
+)
+
+// Credentials are what 'changes login' stores for one API server
+type Credentials struct {
+	APIURL     string     `json:"api_url"`
+	Kind       string     `json:"kind"`
+	Token      string     `json:"token"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	AuthMethod string     `json:"auth_method,omitempty"`
+	Scope      string     `json:"scope,omitempty"`
+	UserID     string     `json:"user_id,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	FullName   string     `json:"full_name,omitempty"`
+	StoredAt   time.Time  `json:"stored_at"`
+}
+
+// Expired reports whether a session token has passed its expiry. API keys
+// are checked by the server.
+func (c *Credentials) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+// APIURL returns the API server the CLI is pointed at
+func APIURL() string {
+	baseURL := viper.GetString("api_url")
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return strings.TrimSuffix(baseURL, "/")
+}
+
+// LoadCredentials returns the stored credentials for apiURL, or nil when
+// the user has not logged in to it
+func LoadCredentials(apiURL string) (*Credentials, error) {
+	var data []byte
+	if kr := keyring(); kr != nil {
+		secret, err := kr.get(apiURL)
+		if err != nil {
+			return nil, err
+		}
+		data = secret
+	}
+	if data == nil {
+		all, err := readCredentialsFile()
+		if err != nil {
+			return nil, err
+		}
+		creds, ok := all[apiURL]
+		if !ok {
+			return nil, nil
+		}
+		return creds, nil
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("stored credentials are corrupt, run 'changes login' again: %w", err)
+	}
+	return &creds, nil
+}
+
+// SaveCredentials stores creds in the OS keyring when one is available, or
+// in ~/.adsops-utils/credentials.json otherwise, and returns where they went
+func SaveCredentials(creds *Credentials) (string, error) {
+	creds.StoredAt = time.Now().UTC()
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return "", err
+	}
+
+	if kr := keyring(); kr != nil {
+		if err := kr.set(creds.APIURL, data); err == nil {
+			// Drop any copy a previous login left on disk
+			removeFromCredentialsFile(creds.APIURL)
+			return kr.name, nil
+		} else if viper.GetString("credential_store") == "keyring" {
+			return "", err
+		}
+	}
+
+	all, err := readCredentialsFile()
+	if err != nil {
+		return "", err
+	}
+	all[creds.APIURL] = creds
+	path, err := writeCredentialsFile(all)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// DeleteCredentials removes the stored credentials for apiURL from both the
+// keyring and the credentials file
+func DeleteCredentials(apiURL string) error {
+	if kr := keyring(); kr != nil {
+		if err := kr.remove(apiURL); err != nil {
+			return err
+		}
+	}
+	return removeFromCredentialsFile(apiURL)
+}
+
+// credentialsPath is the fallback store used when there is no keyring
+func credentialsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".adsops-utils", "credentials.json"), nil
+}
+
+func readCredentialsFile() (map[string]*Credentials, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	all := map[string]*Credentials{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return all, nil
+}
+
+func writeCredentialsFile(all map[string]*Credentials) (string, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return "", err
+	}
+	if len(all) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func removeFromCredentialsFile(apiURL string) error {
+	all, err := readCredentialsFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[apiURL]; !ok {
+		return nil
+	}
+	delete(all, apiURL)
+	_, err = writeCredentialsFile(all)
+	return err
+}
+
+// keyringStore reaches the OS keyring through the platform's own tool, so
+// the CLI needs no cgo or D-Bus bindings: security(1) on macOS and
+// secret-tool from libsecret on Linux
+type keyringStore struct {
+	name   string
+	get    func(account string) ([]byte, error)
+	set    func(account string, secret []byte) error
+	remove func(account string) error
+}
+
+// keyring returns the OS keyring, or nil when credential_store is "file" or
+// the platform tool is not installed
+func keyring() *keyringStore {
+	if viper.GetString("credential_store") == "file" {
+		return nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return nil
+		}
+		return &keyringStore{
+			name: "macOS keychain",
+			get: func(account string) ([]byte, error) {
+				out, err := exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w").Output()
+				if err != nil {
+					// Exit status 44 means no such item
+					var exitErr *exec.ExitError
+					if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+						return nil, nil
+					}
+					return nil, fmt.Errorf("failed to read keychain: %w", err)
+				}
+				return bytes.TrimSpace(out), nil
+			},
+			set: func(account string, secret []byte) error {
+				// The secret goes in on stdin, hex encoded, so it never shows
+				// up in the process list
+				cmd := exec.Command("security", "-i")
+				cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+					securityQuote(keyringService), securityQuote(account), hex.EncodeToString(secret)))
+				if out, err := cmd.CombinedOutput(); err != nil {
+					return fmt.Errorf("failed to write keychain: %s", strings.TrimSpace(string(out)))
+				}
+				return nil
+			},
+			remove: func(account string) error {
+				exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", account).Run()
+				return nil
+			},
+		}
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil
+		}
+		return &keyringStore{
+			name: "system keyring",
+			get: func(account string) ([]byte, error) {
+				out, err := exec.Command("secret-tool", "lookup", "service", keyringService, "account", account).Output()
+				if err != nil {
+					// secret-tool exits 1 both for a missing item and when no
+					// keyring daemon is running; either way there is nothing to use
+					return nil, nil
+				}
+				out = bytes.TrimSpace(out)
+				if len(out) == 0 {
+					return nil, nil
+				}
+				return out, nil
+			},
+			set: func(account string, secret []byte) error {
+				cmd := exec.Command("secret-tool", "store", "--label", "changes CLI ("+account+")", "service", keyringService, "account", account)
+				cmd.Stdin = bytes.NewReader(secret)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					return fmt.Errorf("failed to write keyring: %s", strings.TrimSpace(string(out)))
+				}
+				return nil
+			},
+			remove: func(account string) error {
+				exec.Command("secret-tool", "clear", "service", keyringService, "account", account).Run()
+				return nil
+			},
+		}
+	}
+	return nil
+}
+
+// securityQuote quotes an argument for a security(1) interactive command line
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,118 @@
+package apiclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// ListSprints returns the sprints matching the query parameters (status,
+// project_id), latest start first
+func (c *Client) ListSprints(query url.Values) ([]models.Sprint, error) {
+	var resp struct {
+		Sprints []models.Sprint `json:"sprints"`
+	}
+	if err := c.Get("/v1/sprints", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Sprints, nil
+}
+
+// GetSprint fetches a sprint with its story point rollup
+func (c *Client) GetSprint(id uuid.UUID) (*models.Sprint, *models.TicketRollup, error) {
+	var resp struct {
+		Sprint models.Sprint       `json:"sprint"`
+		Rollup models.TicketRollup `json:"rollup"`
+	}
+	if err := c.Get("/v1/sprints/"+id.String(), nil, &resp); err != nil {
+		return nil, nil, err
+	}
+	return &resp.Sprint, &resp.Rollup, nil
+}
+
+// SprintRollup returns the story point rollup of a sprint's tickets
+func (c *Client) SprintRollup(id uuid.UUID) (*models.TicketRollup, error) {
+	var resp struct {
+		Rollup models.TicketRollup `json:"rollup"`
+	}
+	if err := c.Get("/v1/sprints/"+id.String()+"/rollup", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Rollup, nil
+}
+
+// FindSprint resolves a sprint by ID or by name, ignoring case
+func (c *Client) FindSprint(ref string) (*models.Sprint, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		sprint, _, err := c.GetSprint(id)
+		return sprint, err
+	}
+
+	sprints, err := c.ListSprints(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sprints {
+		if strings.EqualFold(sprints[i].Name, ref) {
+			return &sprints[i], nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "sprint " + ref + " not found"}
+}
+
+// ListEpics returns the epics matching the query parameters (status,
+// project_id)
+func (c *Client) ListEpics(query url.Values) ([]models.Epic, error) {
+	var resp struct {
+		Epics []models.Epic `json:"epics"`
+	}
+	if err := c.Get("/v1/epics", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Epics, nil
+}
+
+// GetEpic fetches an epic with its story point rollup
+func (c *Client) GetEpic(id uuid.UUID) (*models.Epic, *models.TicketRollup, error) {
+	var resp struct {
+		Epic   models.Epic         `json:"epic"`
+		Rollup models.TicketRollup `json:"rollup"`
+	}
+	if err := c.Get("/v1/epics/"+id.String(), nil, &resp); err != nil {
+		return nil, nil, err
+	}
+	return &resp.Epic, &resp.Rollup, nil
+}
+
+// EpicRollup returns the story point rollup of an epic's tickets
+func (c *Client) EpicRollup(id uuid.UUID) (*models.TicketRollup, error) {
+	var resp struct {
+		Rollup models.TicketRollup `json:"rollup"`
+	}
+	if err := c.Get("/v1/epics/"+id.String()+"/rollup", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Rollup, nil
+}
+
+// FindEpic resolves an epic by ID or by name, ignoring case
+func (c *Client) FindEpic(ref string) (*models.Epic, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		epic, _, err := c.GetEpic(id)
+		return epic, err
+	}
+
+	epics, err := c.ListEpics(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range epics {
+		if strings.EqualFold(epics[i].Name, ref) {
+			return &epics[i], nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "epic " + ref + " not found"}
+}
@@ -0,0 +1,28 @@
+package apiclient
+
+import (
+	"net/url"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ChangeMetrics returns the organization's change KPIs, or one team's with
+// the team query parameter, over the from/to period (default: 90 days).
+// Only admins and auditors may read them.
+func (c *Client) ChangeMetrics(query url.Values) (*models.ChangeMetrics, error) {
+	var m models.ChangeMetrics
+	if err := c.Get("/v1/reports/change-metrics", query, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MyChangeMetrics returns the change KPIs of the tickets the current user
+// created or is assigned, over the from/to period
+func (c *Client) MyChangeMetrics(query url.Values) (*models.ChangeMetrics, error) {
+	var m models.ChangeMetrics
+	if err := c.Get("/v1/auth/me/change-metrics", query, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,75 @@
+package apiclient
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// TicketTemplate is a named set of ticket defaults, such as the approvals,
+// compliance frameworks and plans every database migration needs. Scope is
+// "org" or "user" for templates served by the API.
+type TicketTemplate struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Scope       string         `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Ticket      TemplateFields `json:"ticket" yaml:"ticket"`
+}
+
+// TemplateFields are the ticket fields a template fills in. Empty fields
+// leave the usual defaults alone.
+type TemplateFields struct {
+	Description          string   `json:"description,omitempty" yaml:"description,omitempty"`
+	ChangeType           string   `json:"change_type,omitempty" yaml:"change_type,omitempty"`
+	Priority             string   `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Risk                 string   `json:"risk,omitempty" yaml:"risk,omitempty"`
+	Industry             string   `json:"industry,omitempty" yaml:"industry,omitempty"`
+	ComplianceFrameworks []string `json:"compliance_frameworks,omitempty" yaml:"compliance_frameworks,omitempty"`
+	ApprovalTypes        []string `json:"approval_types,omitempty" yaml:"approval_types,omitempty"`
+	AffectedSystems      []string `json:"affected_systems,omitempty" yaml:"affected_systems,omitempty"`
+	TestingPlan          string   `json:"testing_plan,omitempty" yaml:"testing_plan,omitempty"`
+	RollbackPlan         string   `json:"rollback_plan,omitempty" yaml:"rollback_plan,omitempty"`
+}
+
+// ListTemplates returns the ticket templates available to the caller: their
+// organization's and their own
+func (c *Client) ListTemplates() ([]TicketTemplate, error) {
+	var resp struct {
+		Templates []TicketTemplate `json:"templates"`
+	}
+	if err := c.Get("/v1/templates", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Templates, nil
+}
+
+// GetTemplate fetches a ticket template by name
+func (c *Client) GetTemplate(name string) (*TicketTemplate, error) {
+	var resp struct {
+		Template TicketTemplate `json:"template"`
+	}
+	if err := c.Get("/v1/templates/"+url.PathEscape(name), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Template, nil
+}
+
+// CreateTemplate publishes a ticket template to the API, for the caller
+// alone (scope "user") or the whole organization ("org", admins only)
+func (c *Client) CreateTemplate(t *TicketTemplate, scope string) (*TicketTemplate, error) {
+	input := *t
+	input.Scope = scope
+	var resp struct {
+		Template TicketTemplate `json:"template"`
+	}
+	if err := c.Post("/v1/templates", "", &input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Template, nil
+}
+
+// DeleteTemplate removes a template the caller published, or the
+// organization's when scope is "org"
+func (c *Client) DeleteTemplate(name, scope string) error {
+	path := "/v1/templates/" + url.PathEscape(name) + "?scope=" + url.QueryEscape(scope)
+	return c.Do(http.MethodDelete, path, nil, nil, nil)
+}
@@ -0,0 +1,179 @@
+package apiclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// TicketList is the response from GET /v1/tickets
+type TicketList struct {
+	Tickets []models.Ticket `json:"tickets"`
+	Total   int             `json:"total"`
+	Page    int             `json:"page"`
+	PerPage int             `json:"per_page"`
+}
+
+// ApprovalPlanResponse is the response from GET /v1/tickets/:id/approval-plan.
+// Preview is true when the plan was evaluated now rather than frozen at submit.
+type ApprovalPlanResponse struct {
+	Plan    models.ApprovalPlan `json:"approval_plan"`
+	Preview bool                `json:"preview"`
+}
+
+// ListTickets returns one page of tickets matching the query parameters
+func (c *Client) ListTickets(query url.Values) (*TicketList, error) {
+	var list TicketList
+	if err := c.Get("/v1/tickets", query, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetTicket fetches a ticket with its linked repositories
+func (c *Client) GetTicket(id uuid.UUID) (*models.Ticket, error) {
+	var resp struct {
+		Ticket models.Ticket `json:"ticket"`
+	}
+	if err := c.Get("/v1/tickets/"+id.String(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Ticket, nil
+}
+
+// FindTicket fetches a ticket by its number (CHG-2025-00001) or ID. The API
+// addresses tickets by ID, so a number is resolved through search first.
+func (c *Client) FindTicket(ref string) (*models.Ticket, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return c.GetTicket(id)
+	}
+
+	list, err := c.ListTickets(url.Values{"search": []string{ref}, "per_page": []string{"20"}})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range list.Tickets {
+		if strings.EqualFold(t.TicketNumber, ref) {
+			return c.GetTicket(t.ID)
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "ticket " + ref + " not found"}
+}
+
+// GetApprovalPlan returns the approvals a ticket needs
+func (c *Client) GetApprovalPlan(id uuid.UUID) (*ApprovalPlanResponse, error) {
+	var resp ApprovalPlanResponse
+	if err := c.Get("/v1/tickets/"+id.String()+"/approval-plan", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListComments returns a ticket's comments, oldest first
+func (c *Client) ListComments(id uuid.UUID) ([]models.Comment, error) {
+	var resp struct {
+		Comments []models.Comment `json:"comments"`
+	}
+	if err := c.Get("/v1/tickets/"+id.String()+"/comments", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Comments, nil
+}
+
+// CreateTicket files a new ticket. idempotencyKey makes retries safe.
+func (c *Client) CreateTicket(input models.CreateTicketInput, idempotencyKey string) (*models.Ticket, error) {
+	var resp struct {
+		Ticket models.Ticket `json:"ticket"`
+	}
+	if err := c.Post("/v1/tickets", idempotencyKey, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Ticket, nil
+}
+
+// UpdateTicket patches a ticket. input.Version must be the version the
+// change is based on; the API answers 409 if the ticket has moved on.
+func (c *Client) UpdateTicket(id uuid.UUID, input models.UpdateTicketInput) (*models.Ticket, error) {
+	var resp struct {
+		Ticket models.Ticket `json:"ticket"`
+	}
+	if err := c.Do(http.MethodPatch, "/v1/tickets/"+id.String(), nil, input, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Ticket, nil
+}
+
+// TicketETag returns a ticket's current ETag, which an update sends back as
+// If-Match
+func (c *Client) TicketETag(id string) (string, error) {
+	headers, err := c.send(http.MethodGet, "/v1/tickets/"+id, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return headers.Get("ETag"), nil
+}
+
+// TransitionResult is the response from the ticket workflow endpoints
+// (submit, cancel, close, reopen). The optional parts only come back from
+// the transitions that produce them.
+type TransitionResult struct {
+	Message                  string                           `json:"message"`
+	ApprovalPlan             *models.ApprovalPlan             `json:"approval_plan,omitempty"`
+	OnCallNotified           int                              `json:"on_call_notified,omitempty"`
+	Assignment               *models.AssignmentResult         `json:"assignment,omitempty"`
+	PostImplementationReview *models.PostImplementationReview `json:"post_implementation_review,omitempty"`
+}
+
+// TransitionTicket moves a ticket through its workflow. action is submit,
+// cancel, close or reopen; version is the version the caller last read, and
+// reason is only used by cancel.
+func (c *Client) TransitionTicket(id uuid.UUID, action string, version int, reason string) (*TransitionResult, error) {
+	body := struct {
+		Version int    `json:"version"`
+		Reason  string `json:"reason,omitempty"`
+	}{version, reason}
+
+	var result TransitionResult
+	if err := c.Post("/v1/tickets/"+id.String()+"/"+action, "", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AddComment posts a comment on a ticket. A non-empty idempotencyKey makes
+// a retry of the same comment safe.
+func (c *Client) AddComment(id uuid.UUID, input models.CreateCommentInput, idempotencyKey string) error {
+	return c.Post("/v1/tickets/"+id.String()+"/comments", idempotencyKey, input, nil)
+}
+
+// TicketAudit returns the most recent entries of a ticket's audit trail,
+// newest first
+func (c *Client) TicketAudit(id uuid.UUID) ([]models.TicketAuditLog, error) {
+	var resp struct {
+		AuditLog []models.TicketAuditLog `json:"audit_log"`
+	}
+	if err := c.Get("/v1/tickets/"+id.String()+"/audit", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.AuditLog, nil
+}
+
+// LinkRepository links a repository to a ticket by URL, or updates the
+// branch, commit and pull request of an existing link
+func (c *Client) LinkRepository(id uuid.UUID, input models.LinkRepositoryByURLInput) (*models.TicketRepository, error) {
+	var resp struct {
+		Link *models.TicketRepository `json:"repository_link"`
+	}
+	if err := c.Post("/v1/tickets/"+id.String()+"/repositories", "", input, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Link, nil
+}
+
+// UnlinkRepository removes a repository link from a ticket
+func (c *Client) UnlinkRepository(id, repoID uuid.UUID) error {
+	return c.Do(http.MethodDelete, "/v1/tickets/"+id.String()+"/repositories/"+repoID.String(), nil, nil, nil)
+}
@@ -0,0 +1,80 @@
+package apiclient
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ParseTokenRef splits a token_ref setting into its kind and target. A
+// token reference says where the API token is kept without putting the token
+// in the config file:
+//
+//	env:NAME      the environment variable NAME
+//	file:PATH     the contents of PATH (~ is the home directory)
+//	cmd:COMMAND   the output of COMMAND, run by the shell, e.g. a password
+//	              manager's "pass show changes/api-token"
+func ParseTokenRef(ref string) (kind, target string, err error) {
+	kind, target, ok := strings.Cut(ref, ":")
+	target = strings.TrimSpace(target)
+	if !ok || target == "" {
+		return "", "", fmt.Errorf("token_ref %q must be env:NAME, file:PATH or cmd:COMMAND", ref)
+	}
+	switch kind {
+	case "env", "file", "cmd":
+		return kind, target, nil
+	}
+	return "", "", fmt.Errorf("token_ref %q must be env:NAME, file:PATH or cmd:COMMAND", ref)
+}
+
+// ResolveTokenRef reads the token a token_ref setting points at
+func ResolveTokenRef(ref string) (string, error) {
+	kind, target, err := ParseTokenRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	var token string
+	switch kind {
+	case "env":
+		token = os.Getenv(target)
+		if token == "" {
+			return "", fmt.Errorf("token_ref: environment variable %s is not set", target)
+		}
+	case "file":
+		if rest, ok := strings.CutPrefix(target, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			target = filepath.Join(home, rest)
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return "", fmt.Errorf("token_ref: %w", err)
+		}
+		token = string(data)
+	case "cmd":
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", target)
+		} else {
+			cmd = exec.Command("sh", "-c", target)
+		}
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("token_ref: %s failed: %w", target, err)
+		}
+		token = string(out)
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("token_ref: %s gave an empty token", ref)
+	}
+	return token, nil
+}
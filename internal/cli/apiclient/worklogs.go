@@ -0,0 +1,51 @@
+package apiclient
+
+import (
+	"net/http"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// TimeTracking is a ticket's time spent against its estimate, as returned
+// with worklogs
+type TimeTracking struct {
+	TimeEstimateHours  *float64 `json:"time_estimate_hours"`
+	TimeSpentHours     *float64 `json:"time_spent_hours"`
+	TimeRemainingHours *float64 `json:"time_remaining_hours"`
+	OverEstimate       bool     `json:"over_estimate"`
+}
+
+// WorklogList is the response from GET /v1/tickets/:id/worklogs
+type WorklogList struct {
+	Worklogs     []models.Worklog `json:"worklogs"`
+	Count        int              `json:"count"`
+	TimeTracking TimeTracking     `json:"time_tracking"`
+}
+
+// CreateWorklog logs time on a ticket and returns the worklog with the
+// ticket's updated time tracking
+func (c *Client) CreateWorklog(ticketID uuid.UUID, input models.CreateWorklogInput) (*models.Worklog, *TimeTracking, error) {
+	var resp struct {
+		Worklog      models.Worklog `json:"worklog"`
+		TimeTracking TimeTracking   `json:"time_tracking"`
+	}
+	if err := c.Post("/v1/tickets/"+ticketID.String()+"/worklogs", "cli-worklog-"+uuid.NewString(), input, &resp); err != nil {
+		return nil, nil, err
+	}
+	return &resp.Worklog, &resp.TimeTracking, nil
+}
+
+// ListWorklogs returns a ticket's worklogs and time tracking
+func (c *Client) ListWorklogs(ticketID uuid.UUID) (*WorklogList, error) {
+	var list WorklogList
+	if err := c.Get("/v1/tickets/"+ticketID.String()+"/worklogs", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// DeleteWorklog deletes one of a ticket's worklogs
+func (c *Client) DeleteWorklog(ticketID, worklogID uuid.UUID) error {
+	return c.Do(http.MethodDelete, "/v1/tickets/"+ticketID.String()+"/worklogs/"+worklogID.String(), nil, nil, nil)
+}
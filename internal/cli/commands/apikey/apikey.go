@@ -0,0 +1,328 @@
+package apikey
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/spf13/cobra"
+)
+
+// defaultKeyLimit is the server's limit on active keys per user, used when
+// the list response doesn't say
+const defaultKeyLimit = 5
+
+// APIKeyCmd represents the apikey command group
+var APIKeyCmd = &cobra.Command{
+	Use:     "apikey",
+	Aliases: []string{"apikeys", "api-key"},
+	Short:   "Manage API keys",
+	Long: `Manage API keys for CI systems and other automation that file change
+tickets without a person logging in.
+
+Each user may hold up to 5 active keys. The full key is printed once, when
+it is created; afterwards only its prefix is shown.
+
+Examples:
+  # Create a key for a pipeline
+  changes apikey create "Production CI/CD" --expires-in 90
+
+  # List your keys
+  changes apikey list
+
+  # Revoke a key
+  changes apikey revoke chg_Ab12Cd34Ef56`,
+}
+
+func init() {
+	APIKeyCmd.AddCommand(createCmd)
+	APIKeyCmd.AddCommand(listCmd)
+	APIKeyCmd.AddCommand(revokeCmd)
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create an API key",
+	Long: `Create an API key and print it once.
+
+Store the key in your CI system's secret store straight away; it cannot be
+shown again. Use it with 'changes login --with-token' or CHANGES_API_TOKEN.
+
+Examples:
+  # Key with the default scopes (tickets:read, tickets:write)
+  changes apikey create "Production CI/CD"
+
+  # Read-only key that expires in 30 days
+  changes apikey create "Dashboards" --scope tickets:read --expires-in 30
+
+  # Print only the key, e.g. to pipe into a secret store
+  changes apikey create "Deploy bot" --quiet | gh secret set CHANGES_API_TOKEN`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCreate,
+}
+
+func init() {
+	createCmd.Flags().StringSlice("scope", nil, "Scopes to grant (repeatable; default tickets:read,tickets:write)")
+	createCmd.Flags().Int("expires-in", 0, "Days until the key expires (default: never)")
+	createCmd.Flags().BoolP("quiet", "q", false, "Print only the key")
+}
+
+func runCreate(cmd *cobra.Command, args []string) {
+	scopes, _ := cmd.Flags().GetStringSlice("scope")
+	expiresIn, _ := cmd.Flags().GetInt("expires-in")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+
+	name := strings.TrimSpace(args[0])
+	if name == "" {
+		fmt.Fprintln(os.Stderr, "Error: name is required")
+		os.Exit(exitcode.Validation)
+	}
+	if expiresIn < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --expires-in must be a positive number of days")
+		os.Exit(exitcode.Validation)
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	// Check the limit first so the error can name the keys to revoke
+	list, err := client.ListAPIKeys()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list API keys: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	limit := keyLimit(list)
+	if len(list.Keys) >= limit {
+		fmt.Fprintf(os.Stderr, "Error: you already have %d of %d active API keys. Revoke one first:\n\n", len(list.Keys), limit)
+		printKeys(os.Stderr, list.Keys)
+		os.Exit(exitcode.Conflict)
+	}
+
+	input := apiclient.CreateAPIKeyInput{Name: name, Scopes: scopes}
+	if expiresIn > 0 {
+		input.ExpiresIn = &expiresIn
+	}
+	key, err := client.CreateAPIKey(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create API key: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if quiet {
+		fmt.Println(key.Key)
+		return
+	}
+	if output.Structured() {
+		output.Print(key)
+		return
+	}
+
+	fmt.Printf("Created API key %q (%s)\n", key.Name, key.ID)
+	fmt.Println()
+	fmt.Printf("  %s\n", key.Key)
+	fmt.Println()
+	fmt.Println("Copy this key now. It will not be shown again.")
+	fmt.Println()
+	fmt.Printf("Scopes:   %s\n", strings.Join(key.Scopes, ", "))
+	if key.ExpiresAt != nil {
+		fmt.Printf("Expires:  %s\n", key.ExpiresAt.Format("2006-01-02"))
+	} else {
+		fmt.Println("Expires:  never")
+	}
+
+	used := len(list.Keys) + 1
+	switch {
+	case used >= limit:
+		fmt.Printf("\nWarning: this is your last key (%d of %d). Revoke unused keys before you need another.\n", used, limit)
+	case used == limit-1:
+		fmt.Printf("\nNote: %d of %d API keys in use.\n", used, limit)
+	}
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List your API keys",
+	Long: `List your active API keys with their prefix, scopes and last use.
+
+Examples:
+  changes apikey list
+  changes apikey list --output json`,
+	Args: cobra.NoArgs,
+	Run:  runList,
+}
+
+func runList(cmd *cobra.Command, args []string) {
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	list, err := client.ListAPIKeys()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list API keys: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(list)
+		return
+	}
+
+	if len(list.Keys) == 0 {
+		fmt.Println("No API keys. Create one with 'changes apikey create <name>'.")
+		return
+	}
+	printKeys(os.Stdout, list.Keys)
+	fmt.Printf("\n%d of %d API keys in use\n", len(list.Keys), keyLimit(list))
+}
+
+var revokeCmd = &cobra.Command{
+	Use:     "revoke [id|prefix|name]",
+	Aliases: []string{"delete", "rm"},
+	Short:   "Revoke an API key",
+	Long: `Revoke an API key. Anything still using it stops working immediately.
+
+The key can be given by ID, by the prefix shown in 'changes apikey list',
+or by name when the name is unique.
+
+Examples:
+  changes apikey revoke chg_Ab12Cd34Ef56
+  changes apikey revoke "Production CI/CD" --force`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRevoke,
+}
+
+func init() {
+	revokeCmd.Flags().BoolP("force", "f", false, "Skip confirmation")
+}
+
+func runRevoke(cmd *cobra.Command, args []string) {
+	force, _ := cmd.Flags().GetBool("force")
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	list, err := client.ListAPIKeys()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to list API keys: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	key, err := findKey(list.Keys, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	// Warn when revoking the key this CLI is logged in with
+	inUse := strings.HasPrefix(client.Token, key.KeyPrefix)
+	if !force {
+		prompt := fmt.Sprintf("Revoke API key %q (%s)?", key.Name, key.KeyPrefix)
+		if inUse {
+			prompt = fmt.Sprintf("Revoke API key %q (%s)? This CLI is logged in with it.", key.Name, key.KeyPrefix)
+		}
+		if !ui.ConfirmStdin(prompt) {
+			fmt.Println("Aborted")
+			return
+		}
+	}
+
+	if err := client.RevokeAPIKey(key.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to revoke API key: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if output.Structured() {
+		output.Print(map[string]interface{}{"revoked": key, "was_in_use": inUse})
+		return
+	}
+	fmt.Printf("Revoked API key %q (%s)\n", key.Name, key.KeyPrefix)
+	if inUse {
+		fmt.Println("This CLI was using that key; run 'changes login' to sign in again.")
+	}
+}
+
+// findKey matches ref against key IDs, then prefixes, then names
+func findKey(keys []apiclient.APIKey, ref string) (*apiclient.APIKey, error) {
+	for i := range keys {
+		if keys[i].ID == ref {
+			return &keys[i], nil
+		}
+	}
+
+	var matches []*apiclient.APIKey
+	for i := range keys {
+		// A full key starts with its prefix; a partial prefix needs enough
+		// characters past "chg_" to mean something
+		prefix := keys[i].KeyPrefix
+		if strings.HasPrefix(ref, prefix) || (len(ref) >= 8 && strings.HasPrefix(prefix, ref)) {
+			matches = append(matches, &keys[i])
+		}
+	}
+	if len(matches) == 0 {
+		for i := range keys {
+			if strings.EqualFold(keys[i].Name, ref) {
+				matches = append(matches, &keys[i])
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no active API key matches %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%d API keys match %q; use the ID from 'changes apikey list --output json'", len(matches), ref)
+}
+
+func keyLimit(list *apiclient.APIKeyList) int {
+	if list.Limit > 0 {
+		return list.Limit
+	}
+	return defaultKeyLimit
+}
+
+func printKeys(out io.Writer, keys []apiclient.APIKey) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PREFIX\tNAME\tSCOPES\tCREATED\tLAST USED\tEXPIRES")
+	fmt.Fprintln(w, "------\t----\t------\t-------\t---------\t-------")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.KeyPrefix,
+			truncate(k.Name, 30),
+			strings.Join(k.Scopes, ","),
+			k.CreatedAt.Format("2006-01-02"),
+			formatDate(k.LastUsedAt, "never"),
+			formatDate(k.ExpiresAt, "never"),
+		)
+	}
+	w.Flush()
+}
+
+func formatDate(t *time.Time, none string) string {
+	if t == nil {
+		return none
+	}
+	return t.Format("2006-01-02")
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
@@ -3,8 +3,14 @@ package approval
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/afterdarksys/adsops-utils/internal/models"
 	"github.com/spf13/cobra"
 )
 
@@ -61,15 +67,128 @@ func init() {
 }
 
 func runList(cmd *cobra.Command, args []string) {
-	// TODO: Implement API call to list approvals
+	all, _ := cmd.Flags().GetBool("all")
+	approvalType, _ := cmd.Flags().GetString("type")
+	status, _ := cmd.Flags().GetString("status")
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	filter := models.ApprovalListFilter{PerPage: 100}
+	switch {
+	case status != "":
+		filter.Status = []models.ApprovalStatus{models.ApprovalStatus(status)}
+	case !all:
+		filter.Status = []models.ApprovalStatus{models.ApprovalStatusPending}
+	}
+	if approvalType != "" {
+		if !models.ApprovalType(approvalType).Valid() {
+			fmt.Fprintf(os.Stderr, "Error: invalid approval type %q\n", approvalType)
+			os.Exit(exitcode.Validation)
+		}
+		filter.ApprovalType = []models.ApprovalType{models.ApprovalType(approvalType)}
+	}
+
+	list, err := client.ListApprovals(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing approvals: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(list.Approvals)
+		return
+	}
+
+	if len(list.Approvals) == 0 {
+		fmt.Println("No approvals found")
+		return
+	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "TICKET\tTYPE\tSTATUS\tTITLE\tEXPIRES")
 	fmt.Fprintln(w, "------\t----\t------\t-----\t-------")
-	fmt.Fprintln(w, "CHG-2025-00001\toperations\tpending\tDatabase schema update\t2025-12-27")
-	fmt.Fprintln(w, "CHG-2025-00002\tsecurity\tpending\tNetwork configuration\t2025-12-28")
-	fmt.Fprintln(w, "CHG-2025-00003\tit\tapproved\tSecurity patch\t-")
+	for _, a := range list.Approvals {
+		expires := "-"
+		if a.TokenExpiresAt != nil {
+			expires = a.TokenExpiresAt.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.TicketNumber, a.ApprovalType, a.Status, truncate(a.TicketTitle, 40), expires)
+	}
 	w.Flush()
+
+	if list.Total > len(list.Approvals) {
+		fmt.Printf("\nShowing %d of %d approvals\n", len(list.Approvals), list.Total)
+	}
+}
+
+// findPendingApproval resolves a ticket number to the caller's pending
+// approval on it. When the caller holds more than one approval type on the
+// ticket, approvalType must pick between them.
+func findPendingApproval(client *apiclient.Client, ticketNumber, approvalType string) (*models.ApprovalSummary, error) {
+	filter := models.ApprovalListFilter{
+		Status:  []models.ApprovalStatus{models.ApprovalStatusPending},
+		PerPage: 100,
+	}
+	if approvalType != "" {
+		if !models.ApprovalType(approvalType).Valid() {
+			return nil, exitcode.Wrap(exitcode.Validation, fmt.Errorf("invalid approval type %q", approvalType))
+		}
+		filter.ApprovalType = []models.ApprovalType{models.ApprovalType(approvalType)}
+	}
+
+	var matches []models.ApprovalSummary
+	for filter.Page = 1; ; filter.Page++ {
+		list, err := client.ListApprovals(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list approvals: %w", err)
+		}
+		for _, a := range list.Approvals {
+			if strings.EqualFold(a.TicketNumber, ticketNumber) {
+				matches = append(matches, a)
+			}
+		}
+		if len(list.Approvals) < filter.PerPage || filter.Page*filter.PerPage >= list.Total {
+			break
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if approvalType != "" {
+			return nil, exitcode.Wrap(exitcode.NotFound, fmt.Errorf("no pending %s approval for you on %s", approvalType, ticketNumber))
+		}
+		return nil, exitcode.Wrap(exitcode.NotFound, fmt.Errorf("no pending approval for you on %s", ticketNumber))
+	case 1:
+		return &matches[0], nil
+	}
+
+	types := make([]string, len(matches))
+	for i, a := range matches {
+		types[i] = string(a.ApprovalType)
+	}
+	return nil, exitcode.Wrap(exitcode.Validation, fmt.Errorf("you have %d pending approvals on %s (%s); choose one with --type", len(matches), ticketNumber, strings.Join(types, ", ")))
+}
+
+// decision is what approve, deny and request-update print with --output
+// json or yaml
+type decision struct {
+	TicketNumber string              `json:"ticket_number"`
+	ApprovalType models.ApprovalType `json:"approval_type"`
+	Decision     string              `json:"decision"`
+	Comment      string              `json:"comment,omitempty"`
+	Conditions   string              `json:"conditions,omitempty"`
+	Reason       string              `json:"reason,omitempty"`
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
 }
 
 var approveCmd = &cobra.Command{
@@ -77,6 +196,9 @@ var approveCmd = &cobra.Command{
 	Short: "Approve a change ticket",
 	Long: `Approve a change ticket for your approval type.
 
+The ticket number is resolved to your pending approval on it. If you
+hold more than one approval type on the ticket, pick one with --type.
+
 Examples:
   # Approve a ticket
   changes approval approve CHG-2025-00001
@@ -85,12 +207,16 @@ Examples:
   changes approval approve CHG-2025-00001 --comment "Looks good"
 
   # Approve with conditions
-  changes approval approve CHG-2025-00001 --conditions "Deploy during maintenance window only"`,
+  changes approval approve CHG-2025-00001 --conditions "Deploy during maintenance window only"
+
+  # Approve one of several approvals you hold on the ticket
+  changes approval approve CHG-2025-00001 --type security --comment "Threat model reviewed"`,
 	Args: cobra.ExactArgs(1),
 	Run:  runApprove,
 }
 
 func init() {
+	approveCmd.Flags().String("type", "", "Approval type to act on when you hold more than one on the ticket")
 	approveCmd.Flags().String("comment", "", "Comment for the approval")
 	approveCmd.Flags().String("conditions", "", "Conditions for the approval")
 	approveCmd.Flags().Bool("force", false, "Skip confirmation")
@@ -98,31 +224,49 @@ func init() {
 
 func runApprove(cmd *cobra.Command, args []string) {
 	ticketNumber := args[0]
+	approvalType, _ := cmd.Flags().GetString("type")
 	force, _ := cmd.Flags().GetBool("force")
 	comment, _ := cmd.Flags().GetString("comment")
 	conditions, _ := cmd.Flags().GetString("conditions")
 
-	if !force {
-		fmt.Printf("Approve ticket %s? [y/N] ", ticketNumber)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Cancelled")
-			return
-		}
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
 	}
 
-	// TODO: Implement API call to approve
+	approval, err := findPendingApproval(client, ticketNumber, approvalType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
 
-	fmt.Printf("Approving ticket %s...\n", ticketNumber)
+	if !force && !ui.ConfirmStdin(fmt.Sprintf("Approve %s (%s) for %s?", approval.TicketNumber, approval.TicketTitle, approval.ApprovalType.DisplayName())) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	input := models.ApproveInput{}
 	if comment != "" {
-		fmt.Printf("Comment: %s\n", comment)
+		input.Comment = &comment
 	}
+	if conditions != "" {
+		input.Conditions = &conditions
+	}
+
+	if err := client.Approve(approval.ID, input); err != nil {
+		fmt.Fprintf(os.Stderr, "Error approving ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(decision{approval.TicketNumber, approval.ApprovalType, "approved", comment, conditions, ""})
+		return
+	}
+	fmt.Printf("Approved %s (%s)\n", approval.TicketNumber, approval.ApprovalType)
 	if conditions != "" {
 		fmt.Printf("Conditions: %s\n", conditions)
 	}
-	fmt.Println()
-	fmt.Println("Ticket approved successfully!")
 }
 
 var denyCmd = &cobra.Command{
@@ -134,31 +278,61 @@ A reason is required when denying a ticket.
 
 Examples:
   # Deny a ticket
-  changes approval deny CHG-2025-00001 --reason "Missing security review"`,
+  changes approval deny CHG-2025-00001 --reason "Missing security review"
+
+  # Deny one of several approvals you hold on the ticket
+  changes approval deny CHG-2025-00001 --type security --reason "No threat model attached"`,
 	Args: cobra.ExactArgs(1),
 	Run:  runDeny,
 }
 
 func init() {
+	denyCmd.Flags().String("type", "", "Approval type to act on when you hold more than one on the ticket")
 	denyCmd.Flags().String("reason", "", "Reason for denial (required)")
-	denyCmd.Flags().String("comment", "", "Additional comment")
+	denyCmd.Flags().String("comment", "", "Additional comment (defaults to the reason)")
+	denyCmd.Flags().Bool("force", false, "Skip confirmation")
 	denyCmd.MarkFlagRequired("reason")
 }
 
 func runDeny(cmd *cobra.Command, args []string) {
 	ticketNumber := args[0]
+	approvalType, _ := cmd.Flags().GetString("type")
 	reason, _ := cmd.Flags().GetString("reason")
 	comment, _ := cmd.Flags().GetString("comment")
+	force, _ := cmd.Flags().GetBool("force")
 
-	// TODO: Implement API call to deny
+	if comment == "" {
+		comment = reason
+	}
 
-	fmt.Printf("Denying ticket %s...\n", ticketNumber)
-	fmt.Printf("Reason: %s\n", reason)
-	if comment != "" {
-		fmt.Printf("Comment: %s\n", comment)
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	approval, err := findPendingApproval(client, ticketNumber, approvalType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if !force && !ui.ConfirmStdin(fmt.Sprintf("Deny %s (%s) for %s?", approval.TicketNumber, approval.TicketTitle, approval.ApprovalType.DisplayName())) {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	if err := client.Deny(approval.ID, models.DenyInput{Comment: comment, Reason: reason}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error denying ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(decision{approval.TicketNumber, approval.ApprovalType, "denied", comment, "", reason})
+		return
 	}
-	fmt.Println()
-	fmt.Println("Ticket denied.")
+	fmt.Printf("Denied %s (%s)\n", approval.TicketNumber, approval.ApprovalType)
+	fmt.Printf("Reason: %s\n", reason)
 }
 
 var requestUpdateCmd = &cobra.Command{
@@ -178,23 +352,42 @@ Examples:
 }
 
 func init() {
+	requestUpdateCmd.Flags().String("type", "", "Approval type to act on when you hold more than one on the ticket")
 	requestUpdateCmd.Flags().String("comment", "", "Comment explaining requested changes (required)")
-	requestUpdateCmd.Flags().String("required-changes", "", "Specific changes required")
+	requestUpdateCmd.Flags().String("required-changes", "", "Specific changes required (defaults to the comment)")
 	requestUpdateCmd.MarkFlagRequired("comment")
 }
 
 func runRequestUpdate(cmd *cobra.Command, args []string) {
 	ticketNumber := args[0]
+	approvalType, _ := cmd.Flags().GetString("type")
 	comment, _ := cmd.Flags().GetString("comment")
 	changes, _ := cmd.Flags().GetString("required-changes")
 
-	// TODO: Implement API call to request update
+	if changes == "" {
+		changes = comment
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	approval, err := findPendingApproval(client, ticketNumber, approvalType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if err := client.RequestUpdate(approval.ID, models.RequestUpdateInput{Comment: comment, RequiredChanges: changes}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error requesting update: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
 
-	fmt.Printf("Requesting update for ticket %s...\n", ticketNumber)
-	fmt.Printf("Comment: %s\n", comment)
-	if changes != "" {
-		fmt.Printf("Required changes: %s\n", changes)
+	if output.Structured() {
+		output.Print(decision{approval.TicketNumber, approval.ApprovalType, "update_requested", comment, "", ""})
+		return
 	}
-	fmt.Println()
-	fmt.Println("Update requested. The ticket creator has been notified.")
+	fmt.Printf("Update requested on %s (%s). The ticket creator has been notified.\n", approval.TicketNumber, approval.ApprovalType)
 }
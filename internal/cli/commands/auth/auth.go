@@ -1,9 +1,23 @@
 package auth
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/afterdarksys/adsops-utils/internal/models"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // AuthCmd represents the auth command group
@@ -12,6 +26,8 @@ var AuthCmd = &cobra.Command{
 	Short: "Authentication commands",
 	Long: `Manage authentication with the Change Management API.
 
+login, logout and whoami are also available at the top level.
+
 Examples:
   # Login interactively
   changes auth login
@@ -26,104 +42,450 @@ Examples:
   changes auth logout`,
 }
 
+// LoginCmd, LogoutCmd and WhoamiCmd are registered on the root command as
+// 'changes login', 'changes logout' and 'changes whoami'
+var (
+	LoginCmd  = newLoginCmd()
+	LogoutCmd = newLogoutCmd()
+	WhoamiCmd = newWhoamiCmd()
+)
+
 func init() {
-	AuthCmd.AddCommand(loginCmd)
-	AuthCmd.AddCommand(logoutCmd)
+	statusCmd := newWhoamiCmd()
+	statusCmd.Use = "status"
+	statusCmd.Aliases = []string{"whoami"}
+
+	AuthCmd.AddCommand(newLoginCmd())
+	AuthCmd.AddCommand(newLogoutCmd())
 	AuthCmd.AddCommand(statusCmd)
 }
 
-var loginCmd = &cobra.Command{
-	Use:   "login",
-	Short: "Login to the Change Management API",
-	Long: `Login to the Change Management API.
+func newLoginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Login to the Change Management API",
+		Long: `Login to the Change Management API.
 
 Authentication methods:
-  - Email/password (default)
-  - Google OAuth
-  - After Dark Central Auth
-  - Passkey/WebAuthn
+  - Email/password (default), with an MFA code when the account requires one
+  - Device login (--device): approve a short code in the web app, where you
+    can sign in any way it offers, while the CLI waits
+  - Google OAuth or After Dark Central Auth (--provider): a device login
+    that signs you in to the web app with that provider
+  - API key (--api-key, or --with-token to read it from stdin)
+
+Credentials are kept in the OS keyring (macOS keychain or libsecret's
+secret-tool) and fall back to ~/.adsops-utils/credentials.json, readable only
+by you. Set credential_store to "file" or "keyring" in the config to force
+one. Sessions are refreshed automatically shortly before they expire.
 
 Examples:
   # Login interactively
-  changes auth login
+  changes login
+
+  # Login from the web app, e.g. on a machine with no browser
+  changes login --device
 
   # Login with Google
-  changes auth login --provider google
+  changes login --provider google
 
   # Login with After Dark Central Auth
-  changes auth login --provider afterdark`,
-	Run: runLogin,
-}
+  changes login --provider afterdark
 
-func init() {
-	loginCmd.Flags().String("provider", "", "Auth provider (google, afterdark)")
-	loginCmd.Flags().String("email", "", "Email address")
-	loginCmd.Flags().Bool("passkey", false, "Use passkey/WebAuthn")
+  # Use an API key in CI
+  echo "$CHANGES_API_KEY" | changes login --with-token`,
+		Args: cobra.NoArgs,
+		Run:  runLogin,
+	}
+	cmd.Flags().Bool("device", false, "Login by approving a code in the web app")
+	cmd.Flags().String("provider", "", "Auth provider (google, afterdark)")
+	cmd.Flags().String("email", "", "Email address")
+	cmd.Flags().String("org", "", "Organization slug, when your email belongs to several")
+	cmd.Flags().Bool("api-key", false, "Login with an API key instead of a session")
+	cmd.Flags().Bool("with-token", false, "Read an API key from stdin")
+	return cmd
 }
 
 func runLogin(cmd *cobra.Command, args []string) {
+	device, _ := cmd.Flags().GetBool("device")
 	provider, _ := cmd.Flags().GetString("provider")
-	passkey, _ := cmd.Flags().GetBool("passkey")
+	email, _ := cmd.Flags().GetString("email")
+	org, _ := cmd.Flags().GetString("org")
+	apiKey, _ := cmd.Flags().GetBool("api-key")
+	withToken, _ := cmd.Flags().GetBool("with-token")
+
+	client := apiclient.NewUnauthenticated()
+	in := bufio.NewReader(os.Stdin)
 
-	if passkey {
-		fmt.Println("Starting passkey authentication...")
-		fmt.Println("(WebAuthn implementation pending)")
+	if apiKey || withToken {
+		loginWithAPIKey(client, in, withToken)
 		return
 	}
 
+	var resp *apiclient.LoginResponse
+	var err error
 	switch provider {
-	case "google":
-		fmt.Println("Opening browser for Google authentication...")
-		fmt.Println("(OAuth2 implementation pending)")
-	case "afterdark":
-		fmt.Println("Opening browser for After Dark Central Auth...")
-		fmt.Println("(OAuth2 implementation pending)")
+	case "":
+		if device {
+			resp, err = loginWithDevice(client, "")
+		} else {
+			resp, err = loginWithPassword(client, in, email, org)
+		}
+	case "google", "afterdark":
+		resp, err = loginWithDevice(client, provider)
 	default:
-		fmt.Println("Login to After Dark Systems Change Management")
-		fmt.Println("=============================================")
-		fmt.Println()
-		fmt.Print("Email: ")
-		// TODO: Implement password-based login
-		fmt.Println("(Authentication implementation pending)")
+		fmt.Fprintf(os.Stderr, "Error: unknown provider %q (use google or afterdark)\n", provider)
+		os.Exit(exitcode.Validation)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: login failed: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if resp.MFARequired {
+		code, err := prompt(in, "MFA code (or backup code): ", true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		resp, err = client.LoginMFA(resp.MFAToken, code)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: MFA verification failed: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+
+	creds := apiclient.SessionCredentials(client.BaseURL, &resp.AuthTokens)
+	where, err := apiclient.SaveCredentials(creds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to store credentials: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	fmt.Println()
+	fmt.Printf("Logged in as %s\n", userLabel(creds))
+	if creds.Scope == "mfa_enrollment" {
+		fmt.Println("Your organization requires MFA. Set it up in the web app before using other commands.")
+	}
+	if creds.ExpiresAt != nil {
+		fmt.Printf("Session valid until %s\n", creds.ExpiresAt.Local().Format("2006-01-02 15:04 MST"))
+	}
+	fmt.Printf("Credentials stored in %s\n", where)
+}
+
+func loginWithPassword(client *apiclient.Client, in *bufio.Reader, email, org string) (*apiclient.LoginResponse, error) {
+	fmt.Println("Login to After Dark Systems Change Management")
+	fmt.Println("=============================================")
+	fmt.Println()
+
+	var err error
+	if email == "" {
+		if email, err = prompt(in, "Email: ", false); err != nil {
+			return nil, err
+		}
+	}
+	password, err := prompt(in, "Password: ", true)
+	if err != nil {
+		return nil, err
+	}
+	return client.Login(models.LoginInput{Email: email, Password: password, Organization: org})
+}
+
+// loginWithDevice runs the OAuth device flow: the user approves the code
+// shown here in the web app, signing in there if they need to, while the
+// CLI polls for the session
+func loginWithDevice(client *apiclient.Client, provider string) (*apiclient.LoginResponse, error) {
+	start, err := client.StartDeviceLogin(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("To sign in, open this URL and enter the code below:")
+	fmt.Println()
+	fmt.Println("  " + start.VerificationURI)
+	fmt.Println()
+	fmt.Println("  Code: " + start.UserCode)
+	fmt.Println()
+	if err := ui.OpenBrowser(start.VerificationURIComplete); err == nil {
+		fmt.Println("(opened in your browser)")
+	}
+	fmt.Println("Waiting for the sign-in to be approved...")
+
+	interval := time.Duration(start.Interval) * time.Second
+	if interval <= 0 {
+		interval = models.DevicePollInterval
+	}
+	deadline := time.Now().Add(time.Duration(start.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		resp, err := client.PollDeviceLogin(start.DeviceCode)
+		if !apiclient.IsDeviceLoginPending(err) {
+			return resp, err
+		}
+	}
+	return nil, errors.New("the code expired before the sign-in was approved; run login again")
+}
+
+func loginWithAPIKey(client *apiclient.Client, in *bufio.Reader, fromStdin bool) {
+	var key string
+	var err error
+	if fromStdin {
+		var data []byte
+		data, err = io.ReadAll(in)
+		key = strings.TrimSpace(string(data))
+	} else {
+		key, err = prompt(in, "API key: ", true)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if key == "" {
+		fmt.Fprintln(os.Stderr, "Error: no API key given")
+		os.Exit(exitcode.Validation)
+	}
+
+	creds := &apiclient.Credentials{APIURL: client.BaseURL, Kind: apiclient.CredentialAPIKey, Token: key, AuthMethod: "api_key"}
+
+	// Check the key before keeping it; servers without /auth/me can't
+	// tell us who it belongs to
+	client.Token = key
+	user, err := client.CurrentUser()
+	switch {
+	case err == nil:
+		creds.UserID = user.ID.String()
+		creds.Email = user.Email
+		creds.FullName = user.FullName
+	case apiclient.IsUnauthorized(err):
+		fmt.Fprintln(os.Stderr, "Error: the API rejected this key")
+		os.Exit(exitcode.Auth)
+	case !apiclient.IsNotImplemented(err):
+		fmt.Fprintf(os.Stderr, "Error: failed to verify API key: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	where, err := apiclient.SaveCredentials(creds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to store credentials: %v\n", err)
+		os.Exit(exitcode.For(err))
 	}
+	fmt.Printf("Logged in with an API key as %s\n", userLabel(creds))
+	fmt.Printf("Credentials stored in %s\n", where)
 }
 
-var logoutCmd = &cobra.Command{
-	Use:   "logout",
-	Short: "Logout from the Change Management API",
-	Long: `Logout and clear stored credentials.
+func newLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Logout from the Change Management API",
+		Long: `Logout and clear stored credentials.
 
-This will revoke your current session and remove stored tokens.`,
-	Run: runLogout,
+This will revoke your current session and remove stored tokens. API keys
+are only forgotten locally; revoke them with 'changes apikey revoke'.`,
+		Args: cobra.NoArgs,
+		Run:  runLogout,
+	}
 }
 
 func runLogout(cmd *cobra.Command, args []string) {
-	// TODO: Implement logout
+	apiURL := apiclient.APIURL()
+	creds, err := apiclient.LoadCredentials(apiURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if creds == nil {
+		fmt.Printf("Not logged in to %s\n", apiURL)
+		return
+	}
+
+	// Revoking is best effort: the local credentials go either way
+	if creds.Kind == apiclient.CredentialSession && !creds.Expired(time.Now()) {
+		client := apiclient.NewUnauthenticated()
+		client.Token = creds.Token
+		if err := client.Logout(); err != nil && !apiclient.IsNotImplemented(err) && !apiclient.IsUnauthorized(err) {
+			fmt.Fprintf(os.Stderr, "Warning: could not revoke session: %v\n", err)
+		}
+	}
 
-	fmt.Println("Logging out...")
-	fmt.Println("Session revoked. Goodbye!")
+	if err := apiclient.DeleteCredentials(apiURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to remove stored credentials: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	fmt.Printf("Logged out of %s\n", apiURL)
 }
 
-var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Check authentication status",
-	Long: `Check your current authentication status.
+func newWhoamiCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "whoami",
+		Short: "Show who you are logged in as",
+		Long: `Show the user and session behind the stored credentials and check
+them against the API.
 
-Shows information about the currently logged in user
-and session details.`,
-	Run: runStatus,
+Exits non-zero when not logged in or the session is no longer valid.`,
+		Args: cobra.NoArgs,
+		Run:  runWhoami,
+	}
 }
 
-func runStatus(cmd *cobra.Command, args []string) {
-	// TODO: Implement status check
+// whoami is the JSON form of the status report
+type whoami struct {
+	APIURL     string     `json:"api_url"`
+	Source     string     `json:"source"`
+	Kind       string     `json:"kind,omitempty"`
+	UserID     string     `json:"user_id,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	FullName   string     `json:"full_name,omitempty"`
+	Roles      []string   `json:"roles,omitempty"`
+	AuthMethod string     `json:"auth_method,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Valid      bool       `json:"valid"`
+	Verified   bool       `json:"verified"`
+}
 
+func runWhoami(cmd *cobra.Command, args []string) {
+	apiURL := apiclient.APIURL()
+	status := whoami{APIURL: apiURL, Source: "login"}
+
+	// Building the client first refreshes a stored session that is close to
+	// expiry, so the details below are the current ones
+	client, err := apiclient.New()
+
+	switch {
+	case viper.GetString("auth_token") != "":
+		status.Source = "auth_token"
+	case os.Getenv("CHANGES_API_TOKEN") != "":
+		status.Source = "CHANGES_API_TOKEN"
+	case viper.GetString("token_ref") != "":
+		status.Source = "token_ref " + viper.GetString("token_ref")
+	default:
+		creds, loadErr := apiclient.LoadCredentials(apiURL)
+		if loadErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", loadErr)
+			os.Exit(exitcode.For(loadErr))
+		}
+		if creds == nil {
+			fmt.Fprintf(os.Stderr, "Not logged in to %s. Run 'changes login'.\n", apiURL)
+			os.Exit(exitcode.Auth)
+		}
+		status.Kind = creds.Kind
+		status.UserID = creds.UserID
+		status.Email = creds.Email
+		status.FullName = creds.FullName
+		status.AuthMethod = creds.AuthMethod
+		status.ExpiresAt = creds.ExpiresAt
+	}
+
+	if err == nil {
+		var user *models.User
+		user, err = client.CurrentUser()
+		switch {
+		case err == nil:
+			status.Verified = true
+			status.UserID = user.ID.String()
+			status.Email = user.Email
+			status.FullName = user.FullName
+			for _, r := range user.Roles {
+				status.Roles = append(status.Roles, string(r))
+			}
+		case apiclient.IsNotImplemented(err):
+			// Nothing to check against; trust the stored session
+			err = nil
+		}
+	}
+	if err == nil && status.ExpiresAt != nil && !time.Now().Before(*status.ExpiresAt) {
+		err = apiclient.ErrSessionExpired
+	}
+	status.Valid = err == nil
+
+	if output.Structured() {
+		output.Print(status)
+	} else {
+		printWhoami(&status, err)
+	}
+	if !status.Valid {
+		os.Exit(exitcode.Auth)
+	}
+}
+
+func printWhoami(s *whoami, err error) {
 	fmt.Println("Authentication Status")
 	fmt.Println("====================")
 	fmt.Println()
-	fmt.Println("Status:        Authenticated")
-	fmt.Println("User:          John Doe")
-	fmt.Println("Email:         john@example.com")
-	fmt.Println("Organization:  Acme Corp")
-	fmt.Println("Roles:         user, approver")
-	fmt.Println("Session:       Valid until 2025-12-23 12:00:00 UTC")
+	if err != nil {
+		fmt.Printf("Status:        Not authenticated (%v)\n", err)
+	} else if s.Verified {
+		fmt.Println("Status:        Authenticated")
+	} else {
+		fmt.Println("Status:        Authenticated (not verified with the server)")
+	}
+	fmt.Printf("Server:        %s\n", s.APIURL)
+	if s.Email != "" {
+		fmt.Printf("User:          %s\n", orDash(s.FullName))
+		fmt.Printf("Email:         %s\n", s.Email)
+	}
+	if len(s.Roles) > 0 {
+		fmt.Printf("Roles:         %s\n", strings.Join(s.Roles, ", "))
+	}
+	switch {
+	case s.Source != "login":
+		fmt.Printf("Credentials:   token from %s\n", s.Source)
+	case s.Kind == apiclient.CredentialAPIKey:
+		fmt.Println("Credentials:   API key")
+	default:
+		fmt.Printf("Credentials:   session (%s)\n", orDash(s.AuthMethod))
+	}
+	if s.ExpiresAt != nil {
+		fmt.Printf("Session:       Valid until %s\n", s.ExpiresAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+	}
+}
+
+// prompt reads one line from in. secret turns off terminal echo while the
+// user types.
+func prompt(in *bufio.Reader, label string, secret bool) (string, error) {
+	fmt.Print(label)
+	if secret && setEcho(false) {
+		defer func() {
+			setEcho(true)
+			fmt.Println()
+		}()
+	}
+	line, err := in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && (err != io.EOF || line == "") {
+		return "", errors.New("input closed")
+	}
+	return line, nil
+}
+
+// setEcho toggles terminal echo through stty and reports whether it worked,
+// which it won't when stdin is not a terminal
+func setEcho(on bool) bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run() == nil
+}
+
+func userLabel(c *apiclient.Credentials) string {
+	switch {
+	case c.FullName != "" && c.Email != "":
+		return fmt.Sprintf("%s <%s>", c.FullName, c.Email)
+	case c.Email != "":
+		return c.Email
+	default:
+		return "(unknown user)"
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
@@ -0,0 +1,287 @@
+package blackout
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/githooks"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// BlackoutCmd represents the blackout command group
+var BlackoutCmd = &cobra.Command{
+	Use:   "blackout",
+	Short: "Start and end maintenance blackouts for a change",
+	Long: `Put hosts into blackout while a change is implemented, suppressing their
+monitoring alerts, and take them out again when it is done. Blackouts are
+recorded against a change ticket that is approved or implementing and lists
+the host among its affected systems.
+
+The ticket is the one given with --ticket, or $CHANGES_TICKET, or the one the
+current git branch is named after (chg-2025-00001-resize-disks).
+
+Examples:
+  # Black out two hosts until the ticket's scheduled end
+  changes blackout start db-01 db-02 --ticket CHG-2025-00001
+
+  # Black out a host for 90 minutes, taking the ticket from the branch
+  changes blackout start db-01 --duration 1:30
+
+  # End all of the ticket's blackouts
+  changes blackout end
+
+  # Show a host's blackout history
+  changes blackout list db-01`,
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start <hostname...>",
+	Short: "Start a blackout for hosts affected by the ticket",
+	Long: `Start a blackout for each host. Without --duration it lasts until the
+ticket's scheduled end. The duration is H:MM, whole hours, or a duration like
+90m or 1h30m, at most 7 days.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runStart,
+}
+
+var endCmd = &cobra.Command{
+	Use:   "end [hostname...]",
+	Short: "End blackouts early",
+	Long: `End the active blackout of each host given, or without hosts, every
+blackout in effect for the ticket.`,
+	Run: runEnd,
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list [hostname]",
+	Aliases: []string{"ls"},
+	Short:   "List a host's or the ticket's blackouts",
+	Args:    cobra.MaximumNArgs(1),
+	Run:     runList,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{startCmd, endCmd, listCmd} {
+		cmd.Flags().StringP("ticket", "t", "", "Change ticket (default: $CHANGES_TICKET or the git branch's ticket)")
+	}
+	startCmd.Flags().StringP("duration", "d", "", "How long the blackout lasts (default: until the ticket's scheduled end)")
+	startCmd.Flags().String("reason", "", "Reason shown to monitoring (default: the ticket title)")
+	listCmd.Flags().Bool("active", false, "Only blackouts in effect")
+
+	BlackoutCmd.AddCommand(startCmd)
+	BlackoutCmd.AddCommand(endCmd)
+	BlackoutCmd.AddCommand(listCmd)
+}
+
+func runStart(cmd *cobra.Command, args []string) {
+	durationFlag, _ := cmd.Flags().GetString("duration")
+	reason, _ := cmd.Flags().GetString("reason")
+
+	input := models.StartBlackoutInput{Reason: reason}
+	if durationFlag != "" {
+		d, err := parseDuration(durationFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.Validation)
+		}
+		input.DurationMinutes = int(d / time.Minute)
+	}
+
+	client := newClient()
+	ticket := requireTicket(cmd, client)
+	input.TicketNumber = ticket.TicketNumber
+	if err := input.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.Validation)
+	}
+
+	var started []*models.Blackout
+	status := exitcode.OK
+	for _, host := range args {
+		b, err := client.StartBlackout(host, input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting blackout for %s: %v\n", host, err)
+			if status == exitcode.OK {
+				status = exitcode.For(err)
+			}
+			continue
+		}
+		started = append(started, b)
+		if !output.Structured() {
+			fmt.Printf("Blackout started for %s until %s (%s)\n", b.Hostname, b.EndTime.Local().Format("2006-01-02 15:04 MST"), b.TicketNumber)
+		}
+	}
+	if output.Structured() {
+		output.Print(started)
+	}
+	os.Exit(status)
+}
+
+func runEnd(cmd *cobra.Command, args []string) {
+	client := newClient()
+
+	hosts := args
+	if len(hosts) == 0 {
+		ticket := requireTicket(cmd, client)
+		active, err := client.ListTicketBlackouts(ticket.ID, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing blackouts: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		if len(active) == 0 {
+			fmt.Fprintf(os.Stderr, "%s has no blackouts in effect\n", ticket.TicketNumber)
+			os.Exit(exitcode.NotFound)
+		}
+		for _, b := range active {
+			hosts = append(hosts, b.Hostname)
+		}
+	}
+
+	var ended []*models.Blackout
+	status := exitcode.OK
+	for _, host := range hosts {
+		b, err := client.EndBlackout(host)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error ending blackout for %s: %v\n", host, err)
+			if status == exitcode.OK {
+				status = exitcode.For(err)
+			}
+			continue
+		}
+		ended = append(ended, b)
+		if !output.Structured() {
+			fmt.Printf("Blackout ended for %s (%s)\n", b.Hostname, b.TicketNumber)
+		}
+	}
+	if output.Structured() {
+		output.Print(ended)
+	}
+	os.Exit(status)
+}
+
+func runList(cmd *cobra.Command, args []string) {
+	activeOnly, _ := cmd.Flags().GetBool("active")
+	client := newClient()
+
+	var blackouts []models.Blackout
+	var err error
+	if len(args) == 1 {
+		blackouts, err = client.ListHostBlackouts(args[0], activeOnly)
+	} else {
+		ticket := requireTicket(cmd, client)
+		blackouts, err = client.ListTicketBlackouts(ticket.ID, activeOnly)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing blackouts: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(blackouts)
+		return
+	}
+	if len(blackouts) == 0 {
+		fmt.Println("No blackouts")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tTICKET\tSTATUS\tSTART\tEND\tBY\tREASON")
+	fmt.Fprintln(w, "----\t------\t------\t-----\t---\t--\t------")
+	for _, b := range blackouts {
+		end := b.EndTime
+		if b.ActualEndTime != nil {
+			end = *b.ActualEndTime
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", b.Hostname, b.TicketNumber, blackoutState(b),
+			b.StartTime.Local().Format("2006-01-02 15:04"), end.Local().Format("2006-01-02 15:04"), b.CreatedBy, truncate(b.Reason, 40))
+	}
+	w.Flush()
+}
+
+func newClient() *apiclient.Client {
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	return client
+}
+
+// requireTicket looks up the ticket blackouts are recorded against: --ticket,
+// then $CHANGES_TICKET, then the ticket the git branch is named after
+func requireTicket(cmd *cobra.Command, client *apiclient.Client) *models.Ticket {
+	number, _ := cmd.Flags().GetString("ticket")
+	source := ""
+	if number == "" {
+		if number = os.Getenv("CHANGES_TICKET"); number != "" {
+			source = "$CHANGES_TICKET"
+		} else if number = githooks.BranchTicket(); number != "" {
+			source = "the git branch"
+		}
+	}
+	if number == "" {
+		fmt.Fprintln(os.Stderr, "Error: no ticket: give --ticket, set CHANGES_TICKET, or run from a branch named after the ticket")
+		os.Exit(exitcode.Usage)
+	}
+
+	ticket, err := client.FindTicket(strings.ToUpper(strings.TrimSpace(number)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if source != "" {
+		fmt.Fprintf(os.Stderr, "Using %s from %s\n", ticket.TicketNumber, source)
+	}
+	return ticket
+}
+
+// parseDuration reads H:MM, whole hours, or a Go duration (90m, 1h30m), as
+// the blackout tool does
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if hours, minutes, ok := strings.Cut(s, ":"); ok {
+		h, herr := strconv.Atoi(hours)
+		m, merr := strconv.Atoi(minutes)
+		if herr != nil || merr != nil || m < 0 || m > 59 {
+			return 0, fmt.Errorf("invalid duration %q: use H:MM, hours, or a duration like 90m", s)
+		}
+		d = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+	} else if h, err := strconv.Atoi(s); err == nil {
+		d = time.Duration(h) * time.Hour
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use H:MM, hours, or a duration like 90m", s)
+	}
+
+	if d < time.Minute {
+		return 0, fmt.Errorf("duration %q must be at least a minute", s)
+	}
+	if d > models.MaxBlackoutMinutes*time.Minute {
+		return 0, fmt.Errorf("duration %q is more than 7 days", s)
+	}
+	return d.Round(time.Minute), nil
+}
+
+// blackoutState describes a blackout: active only while it is in effect
+func blackoutState(b models.Blackout) string {
+	if b.Status == models.BlackoutStatusActive && !b.EndTime.After(time.Now()) {
+		return string(models.BlackoutStatusExpired)
+	}
+	return string(b.Status)
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
@@ -1,9 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -14,11 +22,31 @@ var ConfigCmd = &cobra.Command{
 	Short: "Manage CLI configuration",
 	Long: `View and modify CLI configuration settings.
 
-Configuration is stored in ~/.adsops-utils/config.yaml
+Configuration is stored in ~/.config/changes/config.yaml (or under
+$XDG_CONFIG_HOME). A config file in the old location,
+~/.adsops-utils/config.yaml, is still read until 'changes config init' moves
+it. Settings can also come from ADSOPS_<KEY> environment variables, and
+--api-url, --output and --verbose override the file.
+
+Settings:
+  api_url              API server URL
+  web_url              Web interface URL, for ticket links
+  token_ref            Where to read the API token instead of 'changes login':
+                       env:NAME, file:PATH or cmd:COMMAND
+  credential_store     Where 'changes login' keeps credentials (keyring, file)
+  default_industry     Industry for new tickets
+  default_compliance   Compliance frameworks for new tickets in the default
+                       industry (comma-separated)
+  tickets_dir          Local tickets directory (default ./tickets)
+  templates_dir        Local ticket templates directory
+  output               Default output format (table, json, yaml)
+  verbose              Print extra detail (true, false)
+
+Sections such as database.* and github.* can be set the same way.
 
 Examples:
   # Initialize configuration
-  changes config init
+  changes config init --industry healthcare --compliance hipaa
 
   # View current configuration
   changes config view
@@ -26,6 +54,9 @@ Examples:
   # Set a configuration value
   changes config set api_url https://api.example.com
 
+  # Read the API token from a password manager
+  changes config set token_ref "cmd:pass show changes/api-token"
+
   # Get a configuration value
   changes config get api_url`,
 }
@@ -35,6 +66,22 @@ func init() {
 	ConfigCmd.AddCommand(viewCmd)
 	ConfigCmd.AddCommand(setCmd)
 	ConfigCmd.AddCommand(getCmd)
+
+	initCmd.Flags().Bool("force", false, "Overwrite an existing config file without asking")
+	initCmd.Flags().String("web-url", "", "Web interface URL")
+	initCmd.Flags().String("token-ref", "", "Where to read the API token: env:NAME, file:PATH or cmd:COMMAND")
+	initCmd.Flags().String("industry", "", "Default industry for new tickets")
+	initCmd.Flags().String("compliance", "", "Default compliance frameworks for new tickets (comma-separated)")
+	initCmd.Flags().String("tickets-dir", "", "Local tickets directory")
+
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.Key
+	}
+	getCmd.ValidArgsFunction = cobra.FixedCompletions(keys, cobra.ShellCompDirectiveNoFileComp)
+	setCmd.ValidArgsFunction = completeSet
+	initCmd.RegisterFlagCompletionFunc("industry", cobra.FixedCompletions(findSetting("default_industry").Values, cobra.ShellCompDirectiveNoFileComp))
+	initCmd.RegisterFlagCompletionFunc("compliance", cobra.FixedCompletions(findSetting("default_compliance").Values, cobra.ShellCompDirectiveNoFileComp))
 }
 
 var initCmd = &cobra.Command{
@@ -42,23 +89,36 @@ var initCmd = &cobra.Command{
 	Short: "Initialize CLI configuration",
 	Long: `Initialize the CLI configuration file.
 
-This will create a default configuration file at
-~/.adsops-utils/config.yaml`,
-	Run: runInit,
+This will create a configuration file at ~/.config/changes/config.yaml with
+the default API and web URLs and any settings given as flags. Settings from
+an old ~/.adsops-utils/config.yaml are carried over.`,
+	Args: cobra.NoArgs,
+	Run:  runInit,
+}
+
+// initFlags maps init's flags to the settings they set
+var initFlags = map[string]string{
+	"web-url":     "web_url",
+	"token-ref":   "token_ref",
+	"industry":    "default_industry",
+	"compliance":  "default_compliance",
+	"tickets-dir": "tickets_dir",
 }
 
 func runInit(cmd *cobra.Command, args []string) {
-	home, err := os.UserHomeDir()
+	force, _ := cmd.Flags().GetBool("force")
+
+	configFile, err := Path()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error finding home directory: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
+	}
+	if cmd.Flags().Changed("config") {
+		configFile = viper.ConfigFileUsed()
 	}
-
-	configDir := home + "/.adsops-utils"
-	configFile := configDir + "/config.yaml"
 
 	// Check if config already exists
-	if _, err := os.Stat(configFile); err == nil {
+	if _, err := os.Stat(configFile); err == nil && !force {
 		fmt.Printf("Configuration already exists at %s\n", configFile)
 		fmt.Print("Overwrite? [y/N] ")
 		var response string
@@ -69,86 +129,302 @@ func runInit(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Create config directory
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating config directory: %v\n", err)
-		os.Exit(1)
+	v := viper.New()
+	v.SetConfigType("yaml")
+	legacy, _ := legacyPath()
+	migrated := false
+	if legacy != "" && legacy != configFile {
+		if _, err := os.Stat(legacy); err == nil {
+			v.SetConfigFile(legacy)
+			if err := v.ReadInConfig(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", legacy, err)
+				os.Exit(exitcode.For(err))
+			}
+			migrated = true
+		}
 	}
 
 	// Set default values
-	viper.Set("api_url", "https://api.changes.afterdarksys.com")
-	viper.Set("output", "table")
-	viper.Set("verbose", false)
+	if api := cmd.Flag("api-url"); api != nil && api.Changed {
+		v.Set("api_url", api.Value.String())
+	}
+	for _, key := range []string{"api_url", "web_url", "output"} {
+		if !v.IsSet(key) {
+			v.Set(key, findSetting(key).Default)
+		}
+	}
+	for flag, key := range initFlags {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(flag)
+		parsed, err := findSetting(key).parse(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.Validation)
+		}
+		v.Set(key, parsed)
+	}
 
-	// Write config file
-	if err := viper.WriteConfigAs(configFile); err != nil {
+	if err := writeConfig(v, configFile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing config file: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	fmt.Printf("Configuration initialized at %s\n", configFile)
+	if migrated {
+		fmt.Printf("Settings were copied from %s, which is no longer read and can be deleted.\n", legacy)
+	}
 }
 
 var viewCmd = &cobra.Command{
 	Use:     "view",
 	Aliases: []string{"show"},
 	Short:   "View current configuration",
-	Run:     runView,
+	Long: `Show every setting with its effective value and where it comes from:
+the config file, an ADSOPS_ environment variable, a flag, or the default.
+Tokens and passwords are masked.`,
+	Args: cobra.NoArgs,
+	Run:  runView,
+}
+
+// configEntry is one row of 'config view'
+type configEntry struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
 }
 
 func runView(cmd *cobra.Command, args []string) {
+	configFile := viper.ConfigFileUsed()
+	file, err := readConfig(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var entries []configEntry
+	for _, s := range settings {
+		entry := configEntry{Key: s.Key, Value: viper.Get(s.Key), Source: "default"}
+		switch {
+		case s.Flag != "" && cmd.Flag(s.Flag) != nil && cmd.Flag(s.Flag).Changed:
+			entry.Source = "flag --" + s.Flag
+		case os.Getenv(envName(s.Key)) != "":
+			entry.Source = "env " + envName(s.Key)
+		case file.IsSet(s.Key):
+			entry.Source = "file"
+		default:
+			entry.Value = nil
+			if s.Default != "" {
+				entry.Value = s.Default
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	// Anything else in the file, such as database.* for ticket numbering
+	var others []string
+	for _, key := range file.AllKeys() {
+		if findSetting(key) == nil {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		entries = append(entries, configEntry{Key: key, Value: file.Get(key), Source: "file"})
+	}
+
+	for i := range entries {
+		if entries[i].Value != nil && isSecret(entries[i].Key) {
+			entries[i].Value = "********"
+		}
+	}
+
+	if output.Structured() {
+		output.Print(map[string]interface{}{"file": configFile, "settings": entries})
+		return
+	}
+
 	fmt.Println("Current Configuration")
 	fmt.Println("====================")
 	fmt.Println()
+	if _, err := os.Stat(configFile); err != nil {
+		fmt.Printf("Config file: %s (not created yet, run 'changes config init')\n\n", configFile)
+	} else {
+		fmt.Printf("Config file: %s\n\n", configFile)
+	}
 
-	settings := viper.AllSettings()
-	for key, value := range settings {
-		fmt.Printf("%s: %v\n", key, value)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Key, formatValue(e.Value), e.Source)
 	}
+	w.Flush()
 }
 
 var setCmd = &cobra.Command{
 	Use:   "set [key] [value]",
 	Short: "Set a configuration value",
-	Long: `Set a configuration value.
+	Long: `Set a configuration value in the config file.
+
+Values are checked before they are saved: URLs must be http(s), and
+industries, compliance frameworks and output formats must be ones the CLI
+knows. Lists are comma-separated.
 
 Examples:
   changes config set api_url https://api.example.com
   changes config set output json
-  changes config set verbose true`,
+  changes config set verbose true
+  changes config set default_industry finance
+  changes config set default_compliance sox,glba
+  changes config set tickets_dir ~/work/changes/tickets
+  changes config set token_ref env:CHANGES_TOKEN`,
 	Args: cobra.ExactArgs(2),
 	Run:  runSet,
 }
 
 func runSet(cmd *cobra.Command, args []string) {
-	key := args[0]
+	key := strings.ToLower(args[0])
 	value := args[1]
 
-	viper.Set(key, value)
+	var stored interface{} = value
+	if s := findSetting(key); s != nil {
+		parsed, err := s.parse(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.Validation)
+		}
+		stored = parsed
+	} else if !strings.Contains(key, ".") {
+		fmt.Fprintf(os.Stderr, "Warning: %s is not a setting the CLI uses (see 'changes config --help'); saving it anyway\n", key)
+	}
+	if key == "auth_token" {
+		fmt.Fprintln(os.Stderr, "Warning: auth_token keeps the token in the config file in plain text; token_ref can point at it instead")
+	}
 
-	if err := viper.WriteConfig(); err != nil {
+	configFile := viper.ConfigFileUsed()
+	v, err := readConfig(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	v.Set(key, stored)
+	if err := writeConfig(v, configFile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
-	fmt.Printf("Set %s = %s\n", key, value)
+	if isSecret(key) {
+		stored = "********"
+	}
+	fmt.Printf("Set %s = %s in %s\n", key, formatValue(stored), configFile)
+	if legacy, _ := legacyPath(); configFile == legacy {
+		fmt.Println("Run 'changes config init' to move the config file to ~/.config/changes.")
+	}
 }
 
 var getCmd = &cobra.Command{
 	Use:   "get [key]",
 	Short: "Get a configuration value",
-	Args:  cobra.ExactArgs(1),
-	Run:   runGet,
+	Long: `Print the effective value of a setting, taking environment variables and
+flags into account.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runGet,
 }
 
 func runGet(cmd *cobra.Command, args []string) {
-	key := args[0]
+	key := strings.ToLower(args[0])
 	value := viper.Get(key)
 
+	isDefault := false
+	if value == nil {
+		if s := findSetting(key); s != nil && s.Default != "" {
+			value, isDefault = s.Default, true
+		}
+	}
 	if value == nil {
-		fmt.Printf("%s is not set\n", key)
+		fmt.Fprintf(os.Stderr, "%s is not set\n", key)
+		os.Exit(exitcode.NotFound)
+	}
+
+	if output.Structured() {
+		output.Print(map[string]interface{}{"key": key, "value": value, "default": isDefault})
+		return
+	}
+	if isDefault {
+		fmt.Printf("%s = %s (default)\n", key, value)
 		return
 	}
+	fmt.Printf("%s = %s\n", key, formatValue(value))
+}
+
+// completeSet completes setting names, then the values of settings that
+// have a fixed set
+func completeSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		keys := make([]string, len(settings))
+		for i, s := range settings {
+			keys[i] = s.Key
+		}
+		return keys, cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		if s := findSetting(args[0]); s != nil {
+			if s.Bool {
+				return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+			}
+			if len(s.Values) > 0 {
+				return s.Values, cobra.ShellCompDirectiveNoFileComp
+			}
+			if strings.HasSuffix(s.Key, "_dir") {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// readConfig loads just the config file, without environment variables or
+// flags, so that writing it back doesn't save them. A missing file is empty.
+func readConfig(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return v, nil
+}
 
-	fmt.Printf("%s = %v\n", key, value)
+// writeConfig writes v to path, readable only by the user since it can hold
+// tokens and database passwords
+func writeConfig(v *viper.Viper, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := v.WriteConfigAs(path); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// envName is the environment variable that overrides a setting
+func envName(key string) string {
+	return "ADSOPS_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(v)
 }
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Path is where the config file lives: $XDG_CONFIG_HOME/changes/config.yaml,
+// or ~/.config/changes/config.yaml
+func Path() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "changes", "config.yaml"), nil
+}
+
+// legacyPath is where the config file lived before ~/.config/changes. It is
+// still read until 'changes config init' moves it.
+func legacyPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".adsops-utils", "config.yaml"), nil
+}
+
+// FilePath is the config file to read: the one at Path, or the legacy
+// ~/.adsops-utils/config.yaml when only that exists
+func FilePath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if legacy, err := legacyPath(); err == nil {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+	}
+	return path, nil
+}
+
+// setting is a config key the CLI knows, with how to check a value for it
+type setting struct {
+	Key         string
+	Description string
+	Default     string
+	Flag        string   // the global flag that overrides it
+	Values      []string // the allowed values, when there is a fixed set
+	List        bool     // a comma-separated list
+	Bool        bool
+	Secret      bool
+	check       func(string) error
+}
+
+var settings = []setting{
+	{Key: "api_url", Description: "API server URL", Default: apiclient.DefaultAPIURL, Flag: "api-url", check: checkURL},
+	{Key: "web_url", Description: "Web interface URL, for ticket links", Default: "https://changes.afterdarksys.com", check: checkURL},
+	{Key: "token_ref", Description: "Where to read the API token: env:NAME, file:PATH or cmd:COMMAND (default: the 'changes login' credentials)", check: checkTokenRef},
+	{Key: "auth_token", Description: "API token in plain text (prefer token_ref)", Secret: true},
+	{Key: "credential_store", Description: "Where 'changes login' keeps credentials", Values: []string{"keyring", "file"}},
+	{Key: "default_industry", Description: "Industry for new tickets", Values: industries()},
+	{Key: "default_compliance", Description: "Compliance frameworks for new tickets in the default industry", List: true, Values: frameworks()},
+	{Key: "tickets_dir", Description: "Local tickets directory", Default: "./tickets"},
+	{Key: "templates_dir", Description: "Local ticket templates directory", Default: "~/.adsops-utils/templates"},
+	{Key: "output", Description: "Default output format", Default: "table", Flag: "output", Values: []string{"table", "json", "yaml"}},
+	{Key: "verbose", Description: "Print extra detail", Default: "false", Flag: "verbose", Bool: true},
+}
+
+func findSetting(key string) *setting {
+	for i := range settings {
+		if settings[i].Key == key {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// parse checks a value given on the command line and converts it to what
+// is stored: a bool, a list, or the string itself
+func (s *setting) parse(value string) (interface{}, error) {
+	switch {
+	case s.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", s.Key)
+		}
+		return b, nil
+	case s.List:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			if err := s.checkValue(item); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	if err := s.checkValue(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (s *setting) checkValue(value string) error {
+	if len(s.Values) > 0 {
+		for _, v := range s.Values {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid %s %q (valid: %s)", s.Key, value, strings.Join(s.Values, ", "))
+	}
+	if s.check != nil {
+		return s.check(value)
+	}
+	return nil
+}
+
+// isSecret reports whether a key's value should be masked when shown
+func isSecret(key string) bool {
+	if s := findSetting(key); s != nil {
+		return s.Secret
+	}
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") || strings.HasSuffix(key, "token")
+}
+
+func checkURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", value)
+	}
+	return nil
+}
+
+func checkTokenRef(value string) error {
+	_, _, err := apiclient.ParseTokenRef(value)
+	return err
+}
+
+func industries() []string {
+	var values []string
+	for _, i := range []models.IndustryType{
+		models.IndustryIT, models.IndustryFinance, models.IndustryHealthcare,
+		models.IndustryInsurance, models.IndustryGovernment,
+	} {
+		values = append(values, string(i))
+	}
+	return values
+}
+
+func frameworks() []string {
+	var values []string
+	for _, f := range []models.ComplianceFramework{
+		models.ComplianceGLBA, models.ComplianceSOX, models.ComplianceHIPAA,
+		models.ComplianceBankingSecrecyAct, models.ComplianceGDPR, models.ComplianceCustom,
+	} {
+		values = append(values, string(f))
+	}
+	return values
+}
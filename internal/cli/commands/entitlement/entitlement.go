@@ -11,6 +11,8 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -134,13 +136,13 @@ func runLogin(cmd *cobra.Command, args []string) {
 		resp, err := client.Do(req)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Failed to connect to API: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode == 401 {
 			fmt.Fprintln(os.Stderr, "Error: Invalid API key")
-			os.Exit(1)
+			os.Exit(exitcode.Auth)
 		}
 
 		// Save auth config
@@ -151,7 +153,7 @@ func runLogin(cmd *cobra.Command, args []string) {
 		}
 		if err := saveAuthConfig(auth); err != nil {
 			fmt.Fprintf(os.Stderr, "Error saving credentials: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
 
 		fmt.Println("Successfully authenticated with API key")
@@ -177,14 +179,14 @@ func runLogin(cmd *cobra.Command, args []string) {
 	resp, err := client.Do(req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to connect to API: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
 		fmt.Fprintf(os.Stderr, "Error: Login failed: %s\n", string(body))
-		os.Exit(1)
+		os.Exit(exitcode.Auth)
 	}
 
 	var loginResp struct {
@@ -209,7 +211,7 @@ func runLogin(cmd *cobra.Command, args []string) {
 	}
 	if err := saveAuthConfig(auth); err != nil {
 		fmt.Fprintf(os.Stderr, "Error saving credentials: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	fmt.Printf("Successfully logged in as %s\n", loginResp.User.Email)
@@ -225,7 +227,7 @@ var logoutCmd = &cobra.Command{
 		authPath := getAuthConfigPath()
 		if err := os.Remove(authPath); err != nil && !os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "Error removing credentials: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
 		fmt.Println("Logged out successfully")
 	},
@@ -277,7 +279,7 @@ func runList(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("GET", endpoint, nil, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -301,6 +303,11 @@ func runList(cmd *cobra.Command, args []string) {
 		result.Entitlements = filtered
 	}
 
+	if output.Structured() {
+		output.Print(result.Entitlements)
+		return
+	}
+
 	// Print results
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "PRODUCT\tDOMAIN\tTIER\tSOURCE\tEXPIRES")
@@ -357,7 +364,7 @@ func runCheck(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("GET", endpoint, nil, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -367,6 +374,10 @@ func runCheck(cmd *cobra.Command, args []string) {
 	}
 	json.Unmarshal(resp, &result)
 
+	if output.Structured() {
+		output.Print(result)
+		return
+	}
 	if result.HasAccess {
 		fmt.Printf("Access: GRANTED\n")
 		if result.Entitlement != nil {
@@ -419,7 +430,7 @@ func runUsage(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("GET", endpoint, nil, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -431,6 +442,10 @@ func runUsage(cmd *cobra.Command, args []string) {
 	}
 	json.Unmarshal(resp, &result)
 
+	if output.Structured() {
+		output.Print(result)
+		return
+	}
 	fmt.Printf("Domain:     %s\n", domain)
 	if metric != "" {
 		fmt.Printf("Metric:     %s\n", metric)
@@ -493,7 +508,7 @@ func runGrant(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("POST", "/api/entitlements/admin/grant", bodyBytes, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -544,7 +559,7 @@ func runRevoke(cmd *cobra.Command, args []string) {
 	_, err := makeAuthenticatedRequest("DELETE", "/api/entitlements/admin/grant/"+grantID, bodyBytes, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	fmt.Printf("Successfully revoked grant %s\n", grantID)
@@ -584,7 +599,7 @@ func runApprovers(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("GET", endpoint, nil, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -598,6 +613,10 @@ func runApprovers(cmd *cobra.Command, args []string) {
 	}
 	json.Unmarshal(resp, &result)
 
+	if output.Structured() {
+		output.Print(result.Approvers)
+		return
+	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tDOMAINS\tCAN GRANT")
 	fmt.Fprintln(w, "--\t----\t-----\t-------\t---------")
@@ -652,7 +671,7 @@ func runUsers(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("GET", endpoint, nil, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -669,6 +688,10 @@ func runUsers(cmd *cobra.Command, args []string) {
 	}
 	json.Unmarshal(resp, &result)
 
+	if output.Structured() {
+		output.Print(result)
+		return
+	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "ID\tEMAIL\tTIER\tPRODUCT\tSOURCE\tEXPIRES")
 	fmt.Fprintln(w, "--\t-----\t----\t-------\t------\t-------")
@@ -733,7 +756,7 @@ func runLog(cmd *cobra.Command, args []string) {
 	resp, err := makeAuthenticatedRequest("GET", endpoint, nil, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	var result struct {
@@ -751,6 +774,10 @@ func runLog(cmd *cobra.Command, args []string) {
 	}
 	json.Unmarshal(resp, &result)
 
+	if output.Structured() {
+		output.Print(result.Entries)
+		return
+	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "TIMESTAMP\tACTION\tUSER\tPRODUCT\tACTOR\tREASON")
 	fmt.Fprintln(w, "---------\t------\t----\t-------\t-----\t------")
@@ -805,7 +832,7 @@ func runFreeze(cmd *cobra.Command, args []string) {
 	_, err := makeAuthenticatedRequest("POST", "/api/entitlements/admin/user/"+userID+"/freeze", bodyBytes, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	fmt.Printf("Entitlements frozen for user %s\n", userID)
@@ -835,7 +862,7 @@ func runUnfreeze(cmd *cobra.Command, args []string) {
 	_, err := makeAuthenticatedRequest("POST", "/api/entitlements/admin/user/"+userID+"/freeze", bodyBytes, auth)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	fmt.Printf("Entitlements unfrozen for user %s\n", userID)
@@ -902,7 +929,7 @@ func mustGetAuth() *AuthConfig {
 	auth, err := loadAuthConfig()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Not authenticated. Run 'changes entitlement login' first.")
-		os.Exit(1)
+		os.Exit(exitcode.Auth)
 	}
 
 	// Check if token is expired
@@ -912,12 +939,12 @@ func mustGetAuth() *AuthConfig {
 			newAuth, err := refreshToken(auth)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, "Session expired. Run 'changes entitlement login' again.")
-				os.Exit(1)
+				os.Exit(exitcode.Auth)
 			}
 			return newAuth
 		}
 		fmt.Fprintln(os.Stderr, "Session expired. Run 'changes entitlement login' again.")
-		os.Exit(1)
+		os.Exit(exitcode.Auth)
 	}
 
 	return auth
@@ -978,20 +1005,20 @@ func makeAuthenticatedRequest(method, endpoint string, body []byte, auth *AuthCo
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode == 401 {
-		return nil, fmt.Errorf("unauthorized - please login again")
+		return nil, exitcode.Wrap(exitcode.Auth, fmt.Errorf("unauthorized - please login again"))
 	}
 	if resp.StatusCode == 403 {
-		return nil, fmt.Errorf("forbidden - insufficient privileges")
+		return nil, exitcode.Wrap(exitcode.Auth, fmt.Errorf("forbidden - insufficient privileges"))
 	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, exitcode.Wrap(exitcode.ForStatus(resp.StatusCode), fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody)))
 	}
 
 	return respBody, nil
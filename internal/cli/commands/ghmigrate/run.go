@@ -9,6 +9,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -81,7 +82,7 @@ func runGHMigrate(cmd *cobra.Command, args []string) {
 		fmt.Println("Error: must specify an action flag (-l/--list, -i/--import, or -s/--status)")
 		fmt.Println()
 		cmd.Help()
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 
 	// Handle status first (doesn't need repos)
@@ -95,7 +96,7 @@ func runGHMigrate(cmd *cobra.Command, args []string) {
 	if len(repos) == 0 {
 		fmt.Println("Error: --repos/-r is required for list and import operations")
 		fmt.Println("Example: gh-migrate -l -r owner/repo")
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 
 	// Get GitHub credentials
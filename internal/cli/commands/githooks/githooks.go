@@ -0,0 +1,360 @@
+package githooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// GitHooksCmd represents the githooks command group
+var GitHooksCmd = &cobra.Command{
+	Use:   "githooks",
+	Short: "Enforce change control from git hooks",
+	Long: `Install git hooks in the current repository that tie commits and pushes to
+change tickets:
+
+  prepare-commit-msg  adds a "Refs: CHG-2025-00001" trailer to commit messages
+                      when the branch name carries a ticket number
+  pre-push            rejects pushes to protected branches unless the pushed
+                      commits or branch reference a ticket the API reports as
+                      approved (or implementing, or an emergency change under
+                      review)
+
+Protected branches and the ticket prefix are kept in the repository's git
+config (changes.protectedBranches and changes.ticketPrefix), so they can be
+changed later with git config. 'git push --no-verify' skips the check.
+
+Examples:
+  # Protect main, master and release branches
+  changes githooks install
+
+  # Protect other branches
+  changes githooks install --branch main --branch 'hotfix/*'
+
+  # Show what is installed
+  changes githooks status
+
+  # Remove the hooks, restoring any that were there before
+  changes githooks uninstall`,
+}
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the change control hooks in the current repository",
+	Long: `Install the prepare-commit-msg and pre-push hooks in the current repository.
+A hook that is already there is kept as <hook>.local and runs after the
+change control check.`,
+	Args: cobra.NoArgs,
+	Run:  runInstall,
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the change control hooks from the current repository",
+	Args:  cobra.NoArgs,
+	Run:   runUninstall,
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the change control hooks and settings for the current repository",
+	Args:  cobra.NoArgs,
+	Run:   runStatus,
+}
+
+// hookMarker identifies hook scripts written by install
+const hookMarker = "# changes githooks"
+
+// hookNames are the hooks install writes
+var hookNames = []string{"prepare-commit-msg", "pre-push"}
+
+// defaultProtectedBranches are protected when changes.protectedBranches
+// isn't set
+var defaultProtectedBranches = []string{"main", "master", "release/*"}
+
+func init() {
+	installCmd.Flags().StringSlice("branch", nil, "Protected branch or glob (repeatable; default: main, master, release/*)")
+	installCmd.Flags().String("prefix", "", "Ticket number prefix (default: CHG)")
+	installCmd.Flags().StringSlice("hook", hookNames, "Hooks to install")
+	installCmd.RegisterFlagCompletionFunc("hook", cobra.FixedCompletions(hookNames, cobra.ShellCompDirectiveNoFileComp))
+
+	GitHooksCmd.AddCommand(installCmd)
+	GitHooksCmd.AddCommand(uninstallCmd)
+	GitHooksCmd.AddCommand(statusCmd)
+	GitHooksCmd.AddCommand(runCmd)
+}
+
+func runInstall(cmd *cobra.Command, args []string) {
+	branches, _ := cmd.Flags().GetStringSlice("branch")
+	prefix, _ := cmd.Flags().GetString("prefix")
+	hooks, _ := cmd.Flags().GetStringSlice("hook")
+
+	for _, hook := range hooks {
+		if !contains(hookNames, hook) {
+			fmt.Fprintf(os.Stderr, "Error: unknown hook %q (valid: %s)\n", hook, strings.Join(hookNames, ", "))
+			os.Exit(exitcode.Validation)
+		}
+	}
+	if prefix != "" && !models.ValidTicketNumberPrefix(prefix) {
+		fmt.Fprintf(os.Stderr, "Error: invalid --prefix %q: use 2-10 uppercase letters and digits, starting with a letter\n", prefix)
+		os.Exit(exitcode.Validation)
+	}
+
+	dir, err := hooksDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", dir, err)
+		os.Exit(exitcode.For(err))
+	}
+
+	// The hooks call back into this binary, falling back to whatever
+	// 'changes' is on the PATH if it moves
+	self, err := os.Executable()
+	if err != nil {
+		self = "changes"
+	} else if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+
+	for _, hook := range hooks {
+		path := filepath.Join(dir, hook)
+		if existing, err := os.ReadFile(path); err == nil && !bytes.Contains(existing, []byte(hookMarker)) {
+			local := path + ".local"
+			if _, err := os.Stat(local); err == nil {
+				fmt.Fprintf(os.Stderr, "Error: %s and %s both exist; remove one and run install again\n", path, local)
+				os.Exit(1)
+			}
+			if err := os.Rename(path, local); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitcode.For(err))
+			}
+			fmt.Printf("Kept the existing %s hook as %s\n", hook, filepath.Base(local))
+		}
+		if err := os.WriteFile(path, []byte(hookScript(hook, self)), 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+			os.Exit(exitcode.For(err))
+		}
+		fmt.Printf("Installed %s\n", path)
+	}
+
+	if len(branches) > 0 {
+		if err := setConfigList("changes.protectedBranches", branches); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving protected branches: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+	if prefix != "" {
+		if _, err := git("config", "changes.ticketPrefix", prefix); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving ticket prefix: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+
+	fmt.Printf("Protected branches: %s\n", strings.Join(protectedBranches(), ", "))
+	fmt.Printf("Ticket prefix: %s\n", ticketPrefix())
+}
+
+func runUninstall(cmd *cobra.Command, args []string) {
+	dir, err := hooksDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	removed := 0
+	for _, hook := range hookNames {
+		path := filepath.Join(dir, hook)
+		data, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(data, []byte(hookMarker)) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		removed++
+		fmt.Printf("Removed %s\n", path)
+		if _, err := os.Stat(path + ".local"); err == nil {
+			if err := os.Rename(path+".local", path); err != nil {
+				fmt.Fprintf(os.Stderr, "Error restoring %s: %v\n", path, err)
+				os.Exit(exitcode.For(err))
+			}
+			fmt.Printf("Restored the previous %s hook\n", hook)
+		}
+	}
+	if removed == 0 {
+		fmt.Println("No change control hooks are installed")
+	}
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	dir, err := hooksDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	type hookStatus struct {
+		Hook        string `json:"hook"`
+		State       string `json:"state"`
+		ChainsLocal bool   `json:"chains_local_hook"`
+	}
+	var hooks []hookStatus
+	for _, hook := range hookNames {
+		path := filepath.Join(dir, hook)
+		st := hookStatus{Hook: hook, State: "not_installed"}
+		if data, err := os.ReadFile(path); err == nil {
+			if bytes.Contains(data, []byte(hookMarker)) {
+				st.State = "installed"
+				if _, err := os.Stat(path + ".local"); err == nil {
+					st.ChainsLocal = true
+				}
+			} else {
+				st.State = "other"
+			}
+		}
+		hooks = append(hooks, st)
+	}
+
+	if output.Structured() {
+		output.Print(map[string]interface{}{
+			"hooks":              hooks,
+			"protected_branches": protectedBranches(),
+			"ticket_prefix":      ticketPrefix(),
+		})
+		return
+	}
+	for _, st := range hooks {
+		state := "not installed"
+		switch st.State {
+		case "installed":
+			state = "installed"
+			if st.ChainsLocal {
+				state += ", runs " + st.Hook + ".local after"
+			}
+		case "other":
+			state = "another hook is installed"
+		}
+		fmt.Printf("%-20s %s\n", st.Hook+":", state)
+	}
+	fmt.Printf("%-20s %s\n", "Protected branches:", strings.Join(protectedBranches(), ", "))
+	fmt.Printf("%-20s %s\n", "Ticket prefix:", ticketPrefix())
+}
+
+// hookScript is the shell script for a hook. It runs 'changes githooks run',
+// then the hook it replaced, if any.
+func hookScript(hook, changes string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/sh\n%s: installed by 'changes githooks install', removed by\n", hookMarker)
+	fmt.Fprintf(&b, "# 'changes githooks uninstall'. A hook that was here before runs as %s.local.\n", hook)
+	fmt.Fprintf(&b, "changes=%s\n", shellQuote(changes))
+	b.WriteString("command -v \"$changes\" >/dev/null 2>&1 || changes=changes\n")
+	fmt.Fprintf(&b, "local_hook=\"$(dirname \"$0\")/%s.local\"\n\n", hook)
+
+	switch hook {
+	case "pre-push":
+		// git passes the refs being pushed on stdin; both hooks need them
+		b.WriteString(`if ! command -v "$changes" >/dev/null 2>&1; then
+	echo "pre-push: the changes CLI was not found, so the push can't be checked against change tickets" >&2
+	exit 1
+fi
+input=$(cat)
+printf '%s\n' "$input" | "$changes" githooks run pre-push "$@" || exit $?
+if [ -x "$local_hook" ]; then
+	printf '%s\n' "$input" | "$local_hook" "$@" || exit $?
+fi
+`)
+	default:
+		fmt.Fprintf(&b, `if command -v "$changes" >/dev/null 2>&1; then
+	"$changes" githooks run %s "$@" || exit $?
+fi
+if [ -x "$local_hook" ]; then
+	"$local_hook" "$@" || exit $?
+fi
+`, hook)
+	}
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hooksDir is the current repository's hooks directory, honouring
+// core.hooksPath
+func hooksDir() (string, error) {
+	dir, err := git("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", errors.New("not in a git repository")
+	}
+	return filepath.Abs(dir)
+}
+
+// protectedBranches are the branch names and globs pre-push checks
+func protectedBranches() []string {
+	out, err := git("config", "--get-all", "changes.protectedBranches")
+	if err != nil || out == "" {
+		return defaultProtectedBranches
+	}
+	var branches []string
+	for _, line := range strings.Split(out, "\n") {
+		for _, b := range strings.Split(line, ",") {
+			if b = strings.TrimSpace(b); b != "" {
+				branches = append(branches, b)
+			}
+		}
+	}
+	return branches
+}
+
+// ticketPrefix is the prefix of the organization's ticket numbers
+func ticketPrefix() string {
+	if prefix, err := git("config", "changes.ticketPrefix"); err == nil && prefix != "" {
+		return prefix
+	}
+	return "CHG"
+}
+
+func setConfigList(key string, values []string) error {
+	// --unset-all fails when the key isn't set, which is fine
+	git("config", "--unset-all", key)
+	for _, v := range values {
+		if _, err := git("config", "--add", key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// git runs a git command and returns its trimmed output
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), err
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
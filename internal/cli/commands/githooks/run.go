@@ -0,0 +1,249 @@
+package githooks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// runCmd is what the installed hook scripts call
+var runCmd = &cobra.Command{
+	Use:                "run <hook> [args...]",
+	Short:              "Run a change control hook (called by the installed hooks)",
+	Hidden:             true,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "prepare-commit-msg":
+			err = prepareCommitMsg(args[1:])
+		case "pre-push":
+			err = prePush(args[1:], os.Stdin)
+		default:
+			err = fmt.Errorf("unknown hook %q", args[0])
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+			os.Exit(exitcode.For(err))
+		}
+	},
+}
+
+// zeroSHA is the object name git uses for a ref that doesn't exist
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// ticketPattern matches ticket numbers with the prefix in commit messages
+// and branch names
+func ticketPattern(prefix string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(prefix) + `-\d{4}-\d+\b`)
+}
+
+// ticketNumbers returns the ticket numbers referenced in text, uppercased
+// and deduplicated. Underscores count as separators, so branches like
+// chg-2025-00001_fix match.
+func ticketNumbers(pattern *regexp.Regexp, text string) []string {
+	seen := make(map[string]bool)
+	var numbers []string
+	for _, match := range pattern.FindAllString(strings.ReplaceAll(text, "_", " "), -1) {
+		number := strings.ToUpper(match)
+		if !seen[number] {
+			seen[number] = true
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// BranchTicket returns the ticket number the current git branch is named
+// after, or "" outside a checkout or when the branch names none or several
+func BranchTicket() string {
+	branch, err := git("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	if numbers := ticketNumbers(ticketPattern(ticketPrefix()), branch); len(numbers) == 1 {
+		return numbers[0]
+	}
+	return ""
+}
+
+// prepareCommitMsg adds a Refs trailer for the ticket named in the branch
+// when the message doesn't reference one. It never blocks a commit.
+func prepareCommitMsg(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing commit message file")
+	}
+	file := args[0]
+	// Merges, squashes and amended or reused messages keep what they have
+	if len(args) > 1 {
+		switch args[1] {
+		case "merge", "squash", "commit":
+			return nil
+		}
+	}
+
+	message, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	pattern := ticketPattern(ticketPrefix())
+	if pattern.Match(message) {
+		return nil
+	}
+
+	branch, _ := git("symbolic-ref", "--quiet", "--short", "HEAD")
+	numbers := ticketNumbers(pattern, branch)
+	if len(numbers) == 0 {
+		if branch != "" && isProtected(branch) {
+			fmt.Fprintf(os.Stderr, "hint: pushes to %s need a commit referencing an approved change ticket (%s-YYYY-NNNNN)\n", branch, ticketPrefix())
+		}
+		return nil
+	}
+
+	trailers := []string{"interpret-trailers", "--in-place"}
+	for _, number := range numbers {
+		trailers = append(trailers, "--trailer", "Refs: "+number)
+	}
+	_, err = git(append(trailers, file)...)
+	return err
+}
+
+// pushedRef is one line of pre-push's input
+type pushedRef struct {
+	LocalRef, LocalSHA, RemoteRef, RemoteSHA string
+}
+
+// prePush rejects pushes to protected branches that don't reference an
+// approved ticket
+func prePush(args []string, stdin io.Reader) error {
+	remote := "origin"
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	var refs []pushedRef
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		ref := pushedRef{fields[0], fields[1], fields[2], fields[3]}
+		branch, ok := strings.CutPrefix(ref.RemoteRef, "refs/heads/")
+		// Deleting a branch pushes no commits; leave that to the server
+		if !ok || ref.LocalSHA == zeroSHA || !isProtected(branch) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	prefix := ticketPrefix()
+	pattern := ticketPattern(prefix)
+	var numbers []string
+	var branches []string
+	for _, ref := range refs {
+		messages, err := pushedMessages(remote, ref)
+		if err != nil {
+			return err
+		}
+		if messages == "" {
+			// Nothing new, e.g. a branch that is already up to date
+			continue
+		}
+		branches = append(branches, strings.TrimPrefix(ref.RemoteRef, "refs/heads/"))
+		text := messages + "\n" + strings.TrimPrefix(ref.LocalRef, "refs/heads/")
+		for _, number := range ticketNumbers(pattern, text) {
+			if !contains(numbers, number) {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	if len(branches) == 0 {
+		return nil
+	}
+	target := strings.Join(branches, ", ")
+	if len(numbers) == 0 {
+		return fmt.Errorf("pushes to %s need a commit referencing an approved change ticket (%s-YYYY-NNNNN); none of the pushed commits do", target, prefix)
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		return fmt.Errorf("can't check %s against the API: %w", strings.Join(numbers, ", "), err)
+	}
+
+	var problems []string
+	for _, number := range numbers {
+		ticket, err := client.FindTicket(number)
+		if err != nil {
+			if apiclient.IsNotFound(err) {
+				problems = append(problems, number+" was not found")
+				continue
+			}
+			return fmt.Errorf("can't check %s against the API: %w", number, err)
+		}
+		if ok, note := allowsPush(ticket); ok {
+			fmt.Fprintf(os.Stderr, "%s is %s%s: push to %s allowed\n", number, ticket.Status, note, target)
+			return nil
+		}
+		problems = append(problems, fmt.Sprintf("%s is %s", number, ticket.Status))
+	}
+	return fmt.Errorf("pushes to %s need an approved change ticket: %s", target, strings.Join(problems, "; "))
+}
+
+// allowsPush reports whether a ticket's changes may be pushed to a protected
+// branch: once approved and until implementation ends, or while an
+// emergency change awaits its approvals
+func allowsPush(t *models.Ticket) (bool, string) {
+	switch t.Status {
+	case models.TicketStatusApproved, models.TicketStatusImplementing:
+		return true, ""
+	case models.TicketStatusSubmitted, models.TicketStatusInReview, models.TicketStatusPartiallyApproved:
+		if t.IsEmergencyChange() {
+			return true, " (emergency change)"
+		}
+	}
+	return false, ""
+}
+
+// pushedMessages returns the messages of the commits a ref update sends
+// that the remote doesn't have yet
+func pushedMessages(remote string, ref pushedRef) (string, error) {
+	var revs []string
+	if ref.RemoteSHA != zeroSHA {
+		if _, err := git("cat-file", "-e", ref.RemoteSHA+"^{commit}"); err == nil {
+			revs = []string{ref.RemoteSHA + ".." + ref.LocalSHA}
+		}
+	}
+	if revs == nil {
+		// A new branch, or one whose remote tip hasn't been fetched
+		revs = []string{ref.LocalSHA, "--not", "--remotes=" + remote}
+	}
+	return git(append([]string{"log", "--format=%B"}, revs...)...)
+}
+
+// isProtected reports whether a branch matches one of the protected branch
+// names or globs
+func isProtected(branch string) bool {
+	for _, p := range protectedBranches() {
+		if ok, _ := path.Match(p, branch); ok || p == branch {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package inbox
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// InboxCmd represents the inbox command
+var InboxCmd = &cobra.Command{
+	Use:   "inbox",
+	Short: "Work through your approvals and assigned tickets",
+	Long: `Show the tickets waiting for your approval and the open tickets assigned
+to you in an interactive terminal view that refreshes from the API.
+
+Keys:
+  up/down, j/k   Move between tickets
+  tab            Jump to the other list
+  enter, o       Show the ticket's details
+  a              Approve (with an optional comment)
+  d              Deny (a reason is required)
+  c              Comment on the ticket
+  w              Open the ticket in the web interface
+  r              Refresh now
+  q              Quit (esc leaves the details view)
+
+When stdin is not a terminal, or with --once, the inbox is printed once
+instead.
+
+Examples:
+  # Open the inbox
+  changes inbox
+
+  # Refresh every 10 seconds
+  changes inbox --refresh 10s
+
+  # Print it once, for scripts
+  changes inbox --once --output json`,
+	Args: cobra.NoArgs,
+	Run:  runInbox,
+}
+
+func init() {
+	InboxCmd.Flags().Duration("refresh", 30*time.Second, "How often to refresh from the API (0 to only refresh with r)")
+	InboxCmd.Flags().Bool("once", false, "Print the inbox once instead of opening the interactive view")
+}
+
+// snapshot is the inbox as of one fetch
+type snapshot struct {
+	Approvals []models.ApprovalSummary `json:"approvals"`
+	Assigned  []models.TicketSummary   `json:"assigned"`
+	FetchedAt time.Time                `json:"fetched_at"`
+}
+
+func runInbox(cmd *cobra.Command, args []string) {
+	refresh, _ := cmd.Flags().GetDuration("refresh")
+	once, _ := cmd.Flags().GetBool("once")
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if !once {
+		term, err := openTerminal()
+		if err == nil {
+			err = newView(client, term, refresh).run()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitcode.For(err))
+			}
+			return
+		}
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "Not opening the interactive inbox: %v\n", err)
+		}
+	}
+
+	snap, err := fetchInbox(client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading inbox: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	printInbox(snap)
+}
+
+// fetchInbox loads the caller's pending approvals and the open tickets
+// assigned to them
+func fetchInbox(client *apiclient.Client) (*snapshot, error) {
+	snap := &snapshot{FetchedAt: time.Now()}
+
+	filter := models.ApprovalListFilter{
+		Status:  []models.ApprovalStatus{models.ApprovalStatusPending},
+		PerPage: 100,
+	}
+	for filter.Page = 1; ; filter.Page++ {
+		list, err := client.ListApprovals(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list approvals: %w", err)
+		}
+		snap.Approvals = append(snap.Approvals, list.Approvals...)
+		if len(list.Approvals) < filter.PerPage || filter.Page*filter.PerPage >= list.Total {
+			break
+		}
+	}
+
+	open := models.OpenTicketStatuses()
+	statuses := make([]string, len(open))
+	for i, s := range open {
+		statuses[i] = string(s)
+	}
+	list, err := client.ListTickets(url.Values{
+		"assigned_to": []string{"me"},
+		"status":      []string{strings.Join(statuses, ",")},
+		"per_page":    []string{"100"},
+		"sort_by":     []string{"updated_at"},
+		"sort_order":  []string{"desc"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assigned tickets: %w", err)
+	}
+	for _, t := range list.Tickets {
+		snap.Assigned = append(snap.Assigned, t.ToSummary())
+	}
+	return snap, nil
+}
+
+// printInbox writes the inbox once, for --once and non-interactive use
+func printInbox(snap *snapshot) {
+	if output.Structured() {
+		output.Print(snap)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "AWAITING YOUR APPROVAL (%d)\n", len(snap.Approvals))
+	if len(snap.Approvals) == 0 {
+		fmt.Fprintln(w, "  Nothing to approve")
+	}
+	for _, a := range snap.Approvals {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", a.TicketNumber, a.ApprovalType, truncate(a.TicketTitle, 50), waiting(a.CreatedAt))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "ASSIGNED TO YOU (%d)\n", len(snap.Assigned))
+	if len(snap.Assigned) == 0 {
+		fmt.Fprintln(w, "  No open tickets")
+	}
+	for _, t := range snap.Assigned {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", t.TicketNumber, t.Status, t.Priority, truncate(t.Title, 50))
+	}
+	w.Flush()
+}
+
+// approve approves one of the caller's pending approvals
+func approve(client *apiclient.Client, a *models.ApprovalSummary, comment string) error {
+	input := models.ApproveInput{}
+	if comment != "" {
+		input.Comment = &comment
+	}
+	return client.Approve(a.ID, input)
+}
+
+// deny denies one of the caller's pending approvals. The API wants at least
+// 10 characters of reason.
+func deny(client *apiclient.Client, a *models.ApprovalSummary, reason string) error {
+	if len(strings.TrimSpace(reason)) < 10 {
+		return fmt.Errorf("a reason of at least 10 characters is required")
+	}
+	return client.Deny(a.ID, models.DenyInput{Comment: reason, Reason: reason})
+}
+
+func comment(client *apiclient.Client, ticketID uuid.UUID, text string) error {
+	return client.AddComment(ticketID, models.CreateCommentInput{Comment: text}, "")
+}
+
+// ticketLink is the ticket's page in the web interface
+func ticketLink(ticketNumber string) string {
+	webURL := viper.GetString("web_url")
+	if webURL == "" {
+		webURL = "https://changes.afterdarksys.com"
+	}
+	return fmt.Sprintf("%s/tickets/%s", strings.TrimRight(webURL, "/"), ticketNumber)
+}
+
+// waiting says how long ago something happened, roughly
+func waiting(since time.Time) string {
+	d := time.Since(since)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
@@ -0,0 +1,155 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// terminal is the controlling terminal in character-at-a-time mode, showing
+// the alternate screen so the shell's scrollback is left alone
+type terminal struct {
+	saved      string
+	keys       chan []key
+	rows, cols int
+}
+
+// openTerminal switches the terminal to character-at-a-time input through
+// stty. It fails when stdin or stdout is not a terminal.
+func openTerminal() (*terminal, error) {
+	if runtime.GOOS == "windows" {
+		return nil, errors.New("the interactive inbox needs stty, which Windows doesn't have")
+	}
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return nil, errors.New("not a terminal")
+		}
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1", "time", "0"); err != nil {
+		return nil, fmt.Errorf("failed to set terminal mode: %w", err)
+	}
+
+	t := &terminal{saved: strings.TrimSpace(saved), keys: make(chan []key)}
+	t.measure()
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	go t.read()
+	return t, nil
+}
+
+// close restores the screen and the terminal settings
+func (t *terminal) close() {
+	os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	stty(t.saved)
+}
+
+// measure reads the terminal size
+func (t *terminal) measure() {
+	rows, cols := 24, 80
+	if out, err := stty("size"); err == nil {
+		// Some terminals, such as a serial console, report 0 0
+		var r, c int
+		if fmt.Sscan(out, &r, &c); r > 0 && c > 0 {
+			rows, cols = r, c
+		}
+	}
+	t.rows, t.cols = rows, cols
+}
+
+// read sends each burst of input as keys. An escape sequence arrives in a
+// single read, which is how it is told apart from a lone escape.
+func (t *terminal) read() {
+	buf := make([]byte, 256)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(t.keys)
+			return
+		}
+		t.keys <- decodeKeys(buf[:n])
+	}
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyTab
+	keyClearLine
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b && len(b) > 2 && (b[1] == '[' || b[1] == 'O'):
+			// CSI or SS3 sequence: parameters, then a final byte
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			if end == len(b) {
+				return keys
+			}
+			switch string(b[2 : end+1]) {
+			case "A":
+				keys = append(keys, key{code: keyUp})
+			case "B":
+				keys = append(keys, key{code: keyDown})
+			case "5~":
+				keys = append(keys, key{code: keyPageUp})
+			case "6~":
+				keys = append(keys, key{code: keyPageDown})
+			}
+			b = b[end+1:]
+			continue
+		case c == 0x1b:
+			keys = append(keys, key{code: keyEscape})
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{code: keyEnter})
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+		case c == '\t':
+			keys = append(keys, key{code: keyTab})
+		case c == 0x15:
+			keys = append(keys, key{code: keyClearLine})
+		case c < 0x20:
+			// Other control characters do nothing
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, key{code: keyRune, r: r})
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
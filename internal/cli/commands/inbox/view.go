@@ -0,0 +1,552 @@
+package inbox
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+)
+
+// item is one row of the inbox: an approval waiting on the caller, or a
+// ticket assigned to them
+type item struct {
+	approval *models.ApprovalSummary
+	ticket   *models.TicketSummary
+}
+
+func (i item) key() string {
+	if i.approval != nil {
+		return "approval:" + i.approval.ID.String()
+	}
+	return "ticket:" + i.ticket.ID.String()
+}
+
+func (i item) ticketID() uuid.UUID {
+	if i.approval != nil {
+		return i.approval.TicketID
+	}
+	return i.ticket.ID
+}
+
+func (i item) number() string {
+	if i.approval != nil {
+		return i.approval.TicketNumber
+	}
+	return i.ticket.TicketNumber
+}
+
+// prompt is a line of input being typed at the bottom of the screen
+type prompt struct {
+	label  string
+	input  []rune
+	submit func(string)
+}
+
+type fetchResult struct {
+	snap *snapshot
+	err  error
+}
+
+// view is the interactive inbox
+type view struct {
+	client  *apiclient.Client
+	term    *terminal
+	refresh time.Duration
+
+	snap     *snapshot
+	fetchErr error
+	loading  bool
+	results  chan fetchResult
+
+	selected string // key of the selected item, kept across refreshes
+	offset   int    // first list line shown
+
+	detail       *models.Ticket
+	detailOffset int
+
+	prompt *prompt
+	status string
+}
+
+func newView(client *apiclient.Client, term *terminal, refresh time.Duration) *view {
+	return &view{client: client, term: term, refresh: refresh, results: make(chan fetchResult, 1)}
+}
+
+// run shows the inbox until the user quits
+func (v *view) run() error {
+	defer v.term.close()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var tick <-chan time.Time
+	if v.refresh > 0 {
+		ticker := time.NewTicker(v.refresh)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	// Terminals only signal a resize with SIGWINCH, which Windows lacks;
+	// checking the size every second works everywhere
+	sizeTicker := time.NewTicker(time.Second)
+	defer sizeTicker.Stop()
+
+	v.reload()
+	for {
+		v.render()
+		select {
+		case keys, ok := <-v.term.keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range keys {
+				if v.handle(k) {
+					return nil
+				}
+			}
+		case r := <-v.results:
+			v.loading = false
+			v.fetchErr = r.err
+			if r.err == nil {
+				v.snap = r.snap
+			}
+		case <-tick:
+			v.reload()
+		case <-sizeTicker.C:
+			v.term.measure()
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+// reload fetches the inbox in the background
+func (v *view) reload() {
+	if v.loading {
+		return
+	}
+	v.loading = true
+	go func() {
+		snap, err := fetchInbox(v.client)
+		v.results <- fetchResult{snap, err}
+	}()
+}
+
+// items is every row, approvals first
+func (v *view) items() []item {
+	if v.snap == nil {
+		return nil
+	}
+	items := make([]item, 0, len(v.snap.Approvals)+len(v.snap.Assigned))
+	for i := range v.snap.Approvals {
+		items = append(items, item{approval: &v.snap.Approvals[i]})
+	}
+	for i := range v.snap.Assigned {
+		items = append(items, item{ticket: &v.snap.Assigned[i]})
+	}
+	return items
+}
+
+// cursor returns the index of the selected item, falling back to the first
+// when it has gone
+func (v *view) cursor(items []item) int {
+	for i, it := range items {
+		if it.key() == v.selected {
+			return i
+		}
+	}
+	if len(items) > 0 {
+		v.selected = items[0].key()
+	}
+	return 0
+}
+
+func (v *view) current() (item, bool) {
+	items := v.items()
+	if len(items) == 0 {
+		return item{}, false
+	}
+	return items[v.cursor(items)], true
+}
+
+// handle acts on a key and reports whether to quit
+func (v *view) handle(k key) bool {
+	if v.prompt != nil {
+		v.handlePrompt(k)
+		return false
+	}
+
+	items := v.items()
+	cursor := v.cursor(items)
+	move := func(to int) {
+		if len(items) > 0 {
+			v.selected = items[max(0, min(to, len(items)-1))].key()
+		}
+	}
+
+	if v.detail != nil {
+		switch {
+		case k.code == keyEscape || k.code == keyBackspace || k.r == 'q':
+			v.detail = nil
+		case k.code == keyUp || k.r == 'k':
+			v.detailOffset = max(0, v.detailOffset-1)
+		case k.code == keyDown || k.r == 'j':
+			v.detailOffset++
+		case k.code == keyPageUp:
+			v.detailOffset = max(0, v.detailOffset-v.term.rows/2)
+		case k.code == keyPageDown:
+			v.detailOffset += v.term.rows / 2
+		default:
+			v.action(k.r)
+		}
+		return false
+	}
+
+	switch {
+	case k.r == 'q':
+		return true
+	case k.code == keyUp || k.r == 'k':
+		move(cursor - 1)
+	case k.code == keyDown || k.r == 'j':
+		move(cursor + 1)
+	case k.code == keyPageUp:
+		move(cursor - v.term.rows/2)
+	case k.code == keyPageDown:
+		move(cursor + v.term.rows/2)
+	case k.code == keyTab && v.snap != nil:
+		// Jump to the start of the other list
+		if cursor < len(v.snap.Approvals) {
+			move(len(v.snap.Approvals))
+		} else {
+			move(0)
+		}
+	case k.code == keyEnter || k.r == 'o':
+		v.openDetail()
+	default:
+		v.action(k.r)
+	}
+	return false
+}
+
+// action runs the commands available in both the list and the details
+func (v *view) action(r rune) {
+	it, ok := v.current()
+	switch r {
+	case 'r':
+		v.status = ""
+		v.reload()
+		return
+	case 'a', 'd', 'c', 'w':
+		if !ok {
+			v.status = "Nothing selected"
+			return
+		}
+	default:
+		return
+	}
+
+	number := it.number()
+	if (r == 'a' || r == 'd') && it.approval == nil {
+		v.status = fmt.Sprintf("No approval of yours is pending on %s", number)
+		return
+	}
+
+	switch r {
+	case 'a':
+		a := *it.approval
+		v.ask(fmt.Sprintf("Approve %s (%s)? Comment, optional: ", number, a.ApprovalType), func(text string) {
+			v.do(fmt.Sprintf("Approving %s", number), fmt.Sprintf("Approved %s (%s)", number, a.ApprovalType), func() error {
+				return approve(v.client, &a, text)
+			})
+		})
+	case 'd':
+		a := *it.approval
+		v.ask(fmt.Sprintf("Deny %s (%s)? Reason: ", number, a.ApprovalType), func(text string) {
+			v.do(fmt.Sprintf("Denying %s", number), fmt.Sprintf("Denied %s (%s)", number, a.ApprovalType), func() error {
+				return deny(v.client, &a, text)
+			})
+		})
+	case 'c':
+		ticketID := it.ticketID()
+		v.ask(fmt.Sprintf("Comment on %s: ", number), func(text string) {
+			if text == "" {
+				v.status = "Comment not sent: it was empty"
+				return
+			}
+			v.do(fmt.Sprintf("Commenting on %s", number), fmt.Sprintf("Commented on %s", number), func() error {
+				return comment(v.client, ticketID, text)
+			})
+		})
+	case 'w':
+		link := ticketLink(number)
+		if err := ui.OpenBrowser(link); err != nil {
+			v.status = fmt.Sprintf("Could not open a browser: %v. Visit %s", err, link)
+		} else {
+			v.status = "Opened " + link
+		}
+	}
+}
+
+// do runs an API call, reporting progress and the outcome on the status
+// line, then refreshes
+func (v *view) do(progress, done string, call func() error) {
+	v.status = progress + "..."
+	v.render()
+	if err := call(); err != nil {
+		v.status = fmt.Sprintf("%s failed: %v", progress, err)
+		return
+	}
+	v.status = done
+	v.detail = nil
+	v.reload()
+}
+
+func (v *view) ask(label string, submit func(string)) {
+	v.prompt = &prompt{label: label, submit: submit}
+}
+
+func (v *view) handlePrompt(k key) {
+	p := v.prompt
+	switch k.code {
+	case keyEscape:
+		v.prompt = nil
+		v.status = "Cancelled"
+	case keyEnter:
+		v.prompt = nil
+		p.submit(strings.TrimSpace(string(p.input)))
+	case keyBackspace:
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	case keyClearLine:
+		p.input = nil
+	case keyRune:
+		p.input = append(p.input, k.r)
+	}
+}
+
+func (v *view) openDetail() {
+	it, ok := v.current()
+	if !ok {
+		return
+	}
+	v.status = fmt.Sprintf("Loading %s...", it.number())
+	v.render()
+	t, err := v.client.GetTicket(it.ticketID())
+	if err != nil {
+		v.status = fmt.Sprintf("Failed to load %s: %v", it.number(), err)
+		return
+	}
+	v.status = ""
+	v.detail = t
+	v.detailOffset = 0
+}
+
+// render draws the whole screen
+func (v *view) render() {
+	rows, cols := v.term.rows, v.term.cols
+	bodyHeight := max(1, rows-3)
+
+	var body []string
+	var selectedLine int
+	if v.detail != nil {
+		body = detailLines(v.detail, cols)
+		v.detailOffset = max(0, min(v.detailOffset, len(body)-bodyHeight))
+		v.offset = v.detailOffset
+	} else {
+		body, selectedLine = v.listLines(cols)
+		if selectedLine < v.offset {
+			v.offset = selectedLine
+		}
+		if selectedLine >= v.offset+bodyHeight {
+			v.offset = selectedLine - bodyHeight + 1
+		}
+		v.offset = max(0, min(v.offset, len(body)-bodyHeight))
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	b.WriteString(inverse(pad(v.header(), cols)))
+	b.WriteString("\r\n")
+	for i := 0; i < bodyHeight; i++ {
+		if n := v.offset + i; n < len(body) {
+			b.WriteString(body[n])
+		}
+		b.WriteString("\x1b[K\r\n")
+	}
+
+	if v.prompt != nil {
+		text := v.prompt.label + string(v.prompt.input)
+		b.WriteString(clip(text, cols-1, true))
+		b.WriteString("\x1b[K\r\n")
+		b.WriteString(dim(clip("enter send  esc cancel  ctrl-u clear", cols, false)))
+		b.WriteString("\x1b[K")
+		// Leave the cursor at the end of the input
+		fmt.Fprintf(&b, "\x1b[%d;%dH\x1b[?25h", rows-1, min(len([]rune(text)), cols-1)+1)
+	} else {
+		b.WriteString(clip(v.status, cols, false))
+		b.WriteString("\x1b[K\r\n")
+		help := "↑/↓ move  tab switch list  enter details  a approve  d deny  c comment  w web  r refresh  q quit"
+		if v.detail != nil {
+			help = "↑/↓ scroll  esc back  a approve  d deny  c comment  w web  r refresh"
+		}
+		b.WriteString(dim(clip(help, cols, false)))
+		b.WriteString("\x1b[K\x1b[?25l")
+	}
+	os.Stdout.WriteString(b.String())
+}
+
+func (v *view) header() string {
+	h := " Inbox"
+	if v.snap != nil {
+		h += fmt.Sprintf(" · %d to approve · %d assigned · updated %s", len(v.snap.Approvals), len(v.snap.Assigned), v.snap.FetchedAt.Format("15:04:05"))
+	}
+	switch {
+	case v.loading:
+		h += " · refreshing..."
+	case v.fetchErr != nil:
+		h += " · refresh failed: " + v.fetchErr.Error()
+	}
+	return h
+}
+
+// listLines lays out both lists and returns the line of the selected item
+func (v *view) listLines(cols int) ([]string, int) {
+	if v.snap == nil {
+		if v.fetchErr != nil {
+			return []string{"", "  Could not load the inbox. Press r to try again."}, 0
+		}
+		return []string{"", "  Loading..."}, 0
+	}
+
+	items := v.items()
+	cursor := v.cursor(items)
+	var lines []string
+	selectedLine := 0
+	row := func(i int, text string) {
+		if i == cursor {
+			selectedLine = len(lines)
+			lines = append(lines, inverse(pad("> "+text, cols)))
+			return
+		}
+		lines = append(lines, clip("  "+text, cols, false))
+	}
+
+	lines = append(lines, "", bold(clip(fmt.Sprintf("Awaiting your approval (%d)", len(v.snap.Approvals)), cols, false)))
+	if len(v.snap.Approvals) == 0 {
+		lines = append(lines, "  Nothing to approve")
+	}
+	for i, a := range v.snap.Approvals {
+		row(i, fmt.Sprintf("%-16s %-24s %5s  %s", a.TicketNumber, a.ApprovalType, waiting(a.CreatedAt), a.TicketTitle))
+	}
+
+	lines = append(lines, "", bold(clip(fmt.Sprintf("Assigned to you (%d)", len(v.snap.Assigned)), cols, false)))
+	if len(v.snap.Assigned) == 0 {
+		lines = append(lines, "  No open tickets")
+	}
+	for i, t := range v.snap.Assigned {
+		row(len(v.snap.Approvals)+i, fmt.Sprintf("%-16s %-16s %-9s %s", t.TicketNumber, t.Status, t.Priority, t.Title))
+	}
+	return lines, selectedLine
+}
+
+// detailLines lays out a ticket for the details view
+func detailLines(t *models.Ticket, cols int) []string {
+	width := max(20, cols-4)
+	lines := []string{"", bold(clip(fmt.Sprintf("%s: %s", t.TicketNumber, t.Title), cols, false))}
+	field := func(label, value string) {
+		if value != "" {
+			lines = append(lines, clip(fmt.Sprintf("  %-18s %s", label+":", value), cols, false))
+		}
+	}
+
+	risk := string(t.RiskLevel)
+	if t.IsEmergency {
+		risk += " (emergency)"
+	}
+	field("Status", string(t.Status))
+	field("Priority", string(t.Priority))
+	field("Risk", risk)
+	if t.Creator != nil {
+		field("Created by", t.Creator.FullName)
+	}
+	if t.ScheduledStart != nil {
+		field("Scheduled start", t.ScheduledStart.Local().Format("2006-01-02 15:04"))
+	}
+	if t.ScheduledEnd != nil {
+		field("Scheduled end", t.ScheduledEnd.Local().Format("2006-01-02 15:04"))
+	}
+	field("Affected systems", strings.Join(t.AffectedSystems, ", "))
+
+	section := func(title, text string) {
+		if text == "" {
+			return
+		}
+		lines = append(lines, "", bold("  "+title))
+		for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+			for _, l := range wrap(paragraph, width) {
+				lines = append(lines, "  "+l)
+			}
+		}
+	}
+	section("Description", t.Description)
+	if t.RollbackPlan != nil {
+		section("Rollback plan", *t.RollbackPlan)
+	}
+	if t.TestingPlan != nil {
+		section("Testing plan", *t.TestingPlan)
+	}
+	return lines
+}
+
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	line := ""
+	for _, w := range words {
+		if line != "" && len([]rune(line))+1+len([]rune(w)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	return append(lines, line)
+}
+
+// clip cuts text to width runes. keepEnd keeps the end instead, for input
+// that is still being typed.
+func clip(text string, width int, keepEnd bool) string {
+	r := []rune(text)
+	if width <= 0 {
+		return ""
+	}
+	if len(r) <= width {
+		return text
+	}
+	if keepEnd {
+		return string(r[len(r)-width:])
+	}
+	return string(r[:width])
+}
+
+func pad(text string, width int) string {
+	text = clip(text, width, false)
+	return text + strings.Repeat(" ", width-len([]rune(text)))
+}
+
+func inverse(s string) string { return "\x1b[7m" + s + "\x1b[0m" }
+func bold(s string) string    { return "\x1b[1m" + s + "\x1b[0m" }
+func dim(s string) string     { return "\x1b[2m" + s + "\x1b[0m" }
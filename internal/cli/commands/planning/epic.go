@@ -0,0 +1,171 @@
+package planning
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// EpicCmd represents the epic command group
+var EpicCmd = &cobra.Command{
+	Use:     "epic",
+	Aliases: []string{"epics"},
+	Short:   "View epics and group tickets into them",
+	Long: `View the epics that group related tickets across sprints, with the story
+points done and remaining in each, and add tickets to them.
+
+Examples:
+  # List open and in-progress epics with their progress
+  changes epic list --status open,in_progress
+
+  # Add tickets to an epic (by name or ID)
+  changes epic add-ticket "Keycloak rollout" CHG-2025-00004 CHG-2025-00005`,
+}
+
+var epicListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List epics with their story point rollups",
+	Args:    cobra.NoArgs,
+	Run:     runEpicList,
+}
+
+var epicAddTicketCmd = &cobra.Command{
+	Use:   "add-ticket <epic> <ticket-id...>",
+	Short: "Add tickets to an epic",
+	Long: `Add tickets to an epic, given by name or ID. A ticket already in another
+epic is moved.`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runEpicAddTicket,
+}
+
+func init() {
+	epicListCmd.Flags().StringSlice("status", []string{}, "Filter by status (open, in_progress, done)")
+
+	EpicCmd.AddCommand(epicListCmd)
+	EpicCmd.AddCommand(epicAddTicketCmd)
+}
+
+// epicSummary is an epic with its rollup, as listed
+type epicSummary struct {
+	models.Epic
+	Rollup *models.TicketRollup `json:"rollup"`
+}
+
+func runEpicList(cmd *cobra.Command, args []string) {
+	statuses, _ := cmd.Flags().GetStringSlice("status")
+	for _, status := range statuses {
+		if !models.EpicStatus(status).Valid() {
+			fmt.Fprintf(os.Stderr, "Error: invalid status %q: use open, in_progress or done\n", status)
+			os.Exit(exitcode.Validation)
+		}
+	}
+
+	client := newClient()
+	var epics []models.Epic
+	if len(statuses) == 0 {
+		statuses = []string{""}
+	}
+	// The API filters on one status at a time
+	for _, status := range statuses {
+		query := url.Values{}
+		if status != "" {
+			query.Set("status", status)
+		}
+		list, err := client.ListEpics(query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing epics: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		epics = append(epics, list...)
+	}
+
+	summaries := make([]epicSummary, 0, len(epics))
+	for _, e := range epics {
+		rollup, err := client.EpicRollup(e.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching the rollup of %s: %v\n", e.Name, err)
+			os.Exit(exitcode.For(err))
+		}
+		summaries = append(summaries, epicSummary{e, rollup})
+	}
+
+	if output.Structured() {
+		output.Print(summaries)
+		return
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No epics")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tTARGET\tTICKETS\tPOINTS\tDONE\tREMAINING\tPROGRESS")
+	fmt.Fprintln(w, "----\t------\t------\t-------\t------\t----\t---------\t--------")
+	for _, e := range summaries {
+		target := "-"
+		if e.TargetDate != nil {
+			target = e.TargetDate.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", e.Name, e.Status, target,
+			e.Rollup.TicketCount, e.Rollup.TotalPoints, e.Rollup.CompletedPoints, e.Rollup.RemainingPoints, progress(e.Rollup))
+	}
+	w.Flush()
+}
+
+func runEpicAddTicket(cmd *cobra.Command, args []string) {
+	client := newClient()
+	epic, err := client.FindEpic(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var added []string
+	status := exitcode.OK
+	for _, ref := range args[1:] {
+		ticket, err := client.FindTicket(ref)
+		if err == nil && ticket.EpicID != nil && *ticket.EpicID == epic.ID {
+			if !output.Structured() {
+				fmt.Printf("%s is already in %s\n", ticket.TicketNumber, epic.Name)
+			}
+			continue
+		}
+		if err == nil {
+			version := ticket.Version
+			_, err = client.UpdateTicket(ticket.ID, models.UpdateTicketInput{EpicID: &epic.ID, Version: &version})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding %s to %s: %v\n", ref, epic.Name, err)
+			if status == exitcode.OK {
+				status = exitcode.For(err)
+			}
+			continue
+		}
+		added = append(added, ticket.TicketNumber)
+		if !output.Structured() {
+			fmt.Printf("Added %s to %s\n", ticket.TicketNumber, epic.Name)
+		}
+	}
+
+	rollup, err := client.EpicRollup(epic.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching the rollup of %s: %v\n", epic.Name, err)
+		os.Exit(exitcode.For(err))
+	}
+	if output.Structured() {
+		if added == nil {
+			added = []string{}
+		}
+		output.Print(map[string]interface{}{"epic": epic, "added": added, "rollup": rollup})
+	} else {
+		fmt.Printf("%s: %d tickets, %s\n", epic.Name, rollup.TicketCount, points(rollup))
+	}
+	os.Exit(status)
+}
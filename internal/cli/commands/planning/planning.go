@@ -0,0 +1,116 @@
+package planning
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+func newClient() *apiclient.Client {
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	return client
+}
+
+// listTickets returns every ticket matching the query, fetching all pages
+func listTickets(client *apiclient.Client, query url.Values) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	query.Set("per_page", "100")
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		list, err := client.ListTickets(query)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, list.Tickets...)
+		if len(list.Tickets) == 0 || len(tickets) >= list.Total {
+			return tickets, nil
+		}
+	}
+}
+
+// points describes a rollup's story points in one line
+func points(r *models.TicketRollup) string {
+	line := fmt.Sprintf("%d of %d points done, %d remaining", r.CompletedPoints, r.TotalPoints, r.RemainingPoints)
+	if r.TotalPoints > 0 {
+		line += fmt.Sprintf(" (%d%%)", r.CompletedPoints*100/r.TotalPoints)
+	}
+	if r.Unestimated > 0 {
+		line += fmt.Sprintf("; %d unestimated", r.Unestimated)
+	}
+	return line
+}
+
+// progress is a rollup's completed share of its points, or "-" without any
+func progress(r *models.TicketRollup) string {
+	if r.TotalPoints == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", r.CompletedPoints*100/r.TotalPoints)
+}
+
+// printRollup prints a rollup's totals and its tickets and points by status
+func printRollup(r *models.TicketRollup) {
+	fmt.Printf("Points:   %s\n", points(r))
+	if r.TicketCount == 0 {
+		fmt.Println("Tickets:  none")
+		return
+	}
+	fmt.Printf("Tickets:  %d\n\n", r.TicketCount)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tTICKETS\tPOINTS")
+	fmt.Fprintln(w, "------\t-------\t------")
+	for _, status := range models.TicketStatuses {
+		if bucket, ok := r.ByStatus[status]; ok {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", status, bucket.Tickets, bucket.Points)
+		}
+	}
+	w.Flush()
+}
+
+// printTickets lists tickets with their story points
+func printTickets(tickets []models.Ticket) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TICKET\tSTATUS\tPOINTS\tASSIGNEE\tTITLE")
+	fmt.Fprintln(w, "------\t------\t------\t--------\t-----")
+	for _, t := range tickets {
+		pts := "-"
+		if t.StoryPoints != nil {
+			pts = strconv.Itoa(*t.StoryPoints)
+		}
+		assignee := "-"
+		switch {
+		case t.Assignee != nil:
+			assignee = t.Assignee.Email
+		case t.AssignedTo != nil:
+			assignee = t.AssignedTo.String()[:8]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.TicketNumber, t.Status, pts, assignee, truncate(t.Title, 50))
+	}
+	w.Flush()
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+func orDash(s *string) string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return "-"
+	}
+	return *s
+}
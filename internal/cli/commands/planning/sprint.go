@@ -0,0 +1,186 @@
+package planning
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// SprintCmd represents the sprint command group
+var SprintCmd = &cobra.Command{
+	Use:     "sprint",
+	Aliases: []string{"sprints"},
+	Short:   "View sprints and their story points",
+	Long: `View the sprints tickets are scheduled into, with the story points done and
+remaining in each.
+
+Examples:
+  # List sprints with their progress
+  changes sprint list
+
+  # Show the active sprint and its tickets
+  changes sprint show
+
+  # Show a sprint by name
+  changes sprint show "2025 Sprint 4"`,
+}
+
+var sprintListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List sprints with their story point rollups",
+	Args:    cobra.NoArgs,
+	Run:     runSprintList,
+}
+
+var sprintShowCmd = &cobra.Command{
+	Use:   "show [sprint]",
+	Short: "Show a sprint's rollup and tickets",
+	Long: `Show a sprint's dates, goal, story point rollup and tickets. The sprint is
+given by name or ID; without one the active sprint is shown.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runSprintShow,
+}
+
+func init() {
+	sprintListCmd.Flags().String("status", "", "Filter by status (planned, active, completed)")
+
+	SprintCmd.AddCommand(sprintListCmd)
+	SprintCmd.AddCommand(sprintShowCmd)
+}
+
+// sprintSummary is a sprint with its rollup, as listed
+type sprintSummary struct {
+	models.Sprint
+	Rollup *models.TicketRollup `json:"rollup"`
+}
+
+func runSprintList(cmd *cobra.Command, args []string) {
+	status, _ := cmd.Flags().GetString("status")
+	if status != "" && !models.SprintStatus(status).Valid() {
+		fmt.Fprintf(os.Stderr, "Error: invalid status %q: use planned, active or completed\n", status)
+		os.Exit(exitcode.Validation)
+	}
+
+	client := newClient()
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	sprints, err := client.ListSprints(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing sprints: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	summaries := make([]sprintSummary, 0, len(sprints))
+	for _, s := range sprints {
+		rollup, err := client.SprintRollup(s.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching the rollup of %s: %v\n", s.Name, err)
+			os.Exit(exitcode.For(err))
+		}
+		summaries = append(summaries, sprintSummary{s, rollup})
+	}
+
+	if output.Structured() {
+		output.Print(summaries)
+		return
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No sprints")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tSTART\tEND\tTICKETS\tPOINTS\tDONE\tREMAINING\tPROGRESS")
+	fmt.Fprintln(w, "----\t------\t-----\t---\t-------\t------\t----\t---------\t--------")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", s.Name, s.Status,
+			s.StartDate.Format("2006-01-02"), s.EndDate.Format("2006-01-02"),
+			s.Rollup.TicketCount, s.Rollup.TotalPoints, s.Rollup.CompletedPoints, s.Rollup.RemainingPoints, progress(s.Rollup))
+	}
+	w.Flush()
+}
+
+func runSprintShow(cmd *cobra.Command, args []string) {
+	client := newClient()
+
+	var sprint *models.Sprint
+	var err error
+	if len(args) == 1 {
+		sprint, err = client.FindSprint(args[0])
+	} else {
+		sprint, err = activeSprint(client)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	sprint, rollup, err := client.GetSprint(sprint.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	tickets, err := listTickets(client, url.Values{"sprint_id": []string{sprint.ID.String()}})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing the sprint's tickets: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		if tickets == nil {
+			tickets = []models.Ticket{}
+		}
+		output.Print(map[string]interface{}{"sprint": sprint, "rollup": rollup, "tickets": tickets})
+		return
+	}
+
+	fmt.Printf("%s  [%s]\n", sprint.Name, sprint.Status)
+	fmt.Printf("Dates:    %s to %s%s\n", sprint.StartDate.Format("2006-01-02"), sprint.EndDate.Format("2006-01-02"), sprintDay(sprint, time.Now()))
+	fmt.Printf("Goal:     %s\n", orDash(sprint.Goal))
+	printRollup(rollup)
+	if len(tickets) > 0 {
+		fmt.Println()
+		printTickets(tickets)
+	}
+}
+
+// activeSprint returns the sprint in progress, or the one that started
+// last when several are
+func activeSprint(client *apiclient.Client) (*models.Sprint, error) {
+	sprints, err := client.ListSprints(url.Values{"status": []string{string(models.SprintStatusActive)}})
+	if err != nil {
+		return nil, err
+	}
+	if len(sprints) == 0 {
+		return nil, exitcode.Wrap(exitcode.NotFound, errors.New("no sprint is active; name one, or see 'changes sprint list'"))
+	}
+	return &sprints[0], nil
+}
+
+// sprintDay says how far into an active sprint today is
+func sprintDay(s *models.Sprint, now time.Time) string {
+	if s.Status != models.SprintStatusActive {
+		return ""
+	}
+	days := int(s.EndDate.Sub(s.StartDate).Hours()/24) + 1
+	day := int(now.UTC().Sub(s.StartDate).Hours()/24) + 1
+	switch {
+	case day < 1:
+		return " (not started)"
+	case day > days:
+		return " (past its end)"
+	}
+	return fmt.Sprintf(" (day %d of %d)", day, days)
+}
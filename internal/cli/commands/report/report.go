@@ -0,0 +1,302 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// ReportCmd shows change metrics for the current user, teams or the whole
+// organization
+var ReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show change volume, success rate and approval times",
+	Long: `Show change metrics over a period: how many changes were submitted, the
+share that were emergencies, how many completed and how many of those failed,
+and how long approvals took.
+
+Without --team or --org the metrics cover your own changes, the tickets you
+created or are assigned. --team shows the changes a team owns and --org the
+whole organization's; both need the admin or auditor role.
+
+Periods: this-week, last-week, this-month, last-month, this-quarter,
+last-quarter, this-year, last-year, or a number of days such as 30d. --from
+and --to (YYYY-MM-DD, both inclusive) give any other range.
+
+Examples:
+  # Your own changes over the last 90 days
+  changes report
+
+  # A team's changes last month
+  changes report --team platform --period last-month
+
+  # Compare teams this quarter, as CSV for a slide deck
+  changes report --team platform,security --period this-quarter --csv metrics.csv`,
+	Args: cobra.NoArgs,
+	Run:  runReport,
+}
+
+func init() {
+	ReportCmd.Flags().StringSlice("team", []string{}, "Teams (group names or IDs) to report on")
+	ReportCmd.Flags().Bool("org", false, "Report on the whole organization")
+	ReportCmd.Flags().StringP("period", "p", "90d", "Period to report on")
+	ReportCmd.Flags().String("from", "", "First day to report on, YYYY-MM-DD (overrides --period)")
+	ReportCmd.Flags().String("to", "", "Last day to report on, YYYY-MM-DD (default: today)")
+	ReportCmd.Flags().String("csv", "", "Also write the metrics as CSV to this file (- for stdout)")
+}
+
+// scopedMetrics are the metrics of one report subject
+type scopedMetrics struct {
+	Scope   string                `json:"scope"`
+	Metrics *models.ChangeMetrics `json:"metrics"`
+}
+
+func runReport(cmd *cobra.Command, args []string) {
+	teams, _ := cmd.Flags().GetStringSlice("team")
+	org, _ := cmd.Flags().GetBool("org")
+	period, _ := cmd.Flags().GetString("period")
+	fromFlag, _ := cmd.Flags().GetString("from")
+	toFlag, _ := cmd.Flags().GetString("to")
+	csvPath, _ := cmd.Flags().GetString("csv")
+
+	if org && len(teams) > 0 {
+		fmt.Fprintln(os.Stderr, "Error: give --team or --org, not both")
+		os.Exit(exitcode.Usage)
+	}
+
+	from, to, err := reportRange(period, fromFlag, toFlag, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.Validation)
+	}
+	query := url.Values{}
+	query.Set("from", from.Format("2006-01-02"))
+	query.Set("to", to.Format("2006-01-02"))
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var reports []scopedMetrics
+	switch {
+	case len(teams) > 0:
+		for _, team := range teams {
+			q := url.Values{"team": []string{team}}
+			for k, v := range query {
+				q[k] = v
+			}
+			m, err := client.ChangeMetrics(q)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error fetching metrics for team %s: %v\n", team, err)
+				os.Exit(exitcode.For(err))
+			}
+			name := team
+			if m.Team != nil {
+				name = m.Team.Name
+			}
+			reports = append(reports, scopedMetrics{"team " + name, m})
+		}
+	case org:
+		m, err := client.ChangeMetrics(query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching metrics: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		reports = append(reports, scopedMetrics{"organization", m})
+	default:
+		m, err := client.MyChangeMetrics(query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching metrics: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		scope := "me"
+		if m.User != nil {
+			scope = m.User.Email
+		}
+		reports = append(reports, scopedMetrics{scope, m})
+	}
+
+	if csvPath != "" {
+		if err := writeCSV(csvPath, reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", csvPath, err)
+			os.Exit(exitcode.Error)
+		}
+		if csvPath == "-" {
+			return
+		}
+	}
+
+	if output.Structured() {
+		output.Print(reports)
+		return
+	}
+	for i, r := range reports {
+		if i > 0 {
+			fmt.Println()
+		}
+		printMetrics(r)
+	}
+	if csvPath != "" {
+		fmt.Printf("\nWrote %s\n", csvPath)
+	}
+}
+
+var daysPeriod = regexp.MustCompile(`^(?:last-)?(\d+)d$`)
+
+// reportRange turns the --period, --from and --to flags into a range of
+// whole days, from inclusive and to exclusive, as the API takes it
+func reportRange(period, from, to string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if from != "" || to != "" {
+		end := today.AddDate(0, 0, 1)
+		if to != "" {
+			t, err := time.Parse("2006-01-02", to)
+			if err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid --to %q: use YYYY-MM-DD", to)
+			}
+			end = t.AddDate(0, 0, 1)
+		}
+		start := end.AddDate(0, 0, -models.DefaultReportDays)
+		if from != "" {
+			t, err := time.Parse("2006-01-02", from)
+			if err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid --from %q: use YYYY-MM-DD", from)
+			}
+			start = t
+		}
+		if !start.Before(end) {
+			return time.Time{}, time.Time{}, fmt.Errorf("--from must not be after --to")
+		}
+		return start, end, nil
+	}
+
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	quarter := time.Date(today.Year(), (today.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	year := time.Date(today.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7) // Monday
+
+	switch strings.ToLower(strings.TrimSpace(period)) {
+	case "this-week":
+		return week, today.AddDate(0, 0, 1), nil
+	case "last-week":
+		return week.AddDate(0, 0, -7), week, nil
+	case "this-month":
+		return month, today.AddDate(0, 0, 1), nil
+	case "last-month":
+		return month.AddDate(0, -1, 0), month, nil
+	case "this-quarter":
+		return quarter, today.AddDate(0, 0, 1), nil
+	case "last-quarter":
+		return quarter.AddDate(0, -3, 0), quarter, nil
+	case "this-year":
+		return year, today.AddDate(0, 0, 1), nil
+	case "last-year":
+		return year.AddDate(-1, 0, 0), year, nil
+	}
+	if m := daysPeriod.FindStringSubmatch(strings.ToLower(strings.TrimSpace(period))); m != nil {
+		days, err := strconv.Atoi(m[1])
+		if err == nil && days > 0 {
+			return today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q: use this-week, last-week, this-month, last-month, this-quarter, last-quarter, this-year, last-year or a number of days like 30d", period)
+}
+
+// lastDay is the last day a period covers, since the API's end is exclusive
+func lastDay(p models.ReportPeriod) time.Time {
+	return p.To.Add(-time.Nanosecond)
+}
+
+func printMetrics(r scopedMetrics) {
+	m := r.Metrics
+	fmt.Printf("Change metrics for %s, %s to %s\n\n", r.Scope, m.From.Format("2006-01-02"), lastDay(m.ReportPeriod).Format("2006-01-02"))
+	fmt.Printf("Changes submitted:   %d\n", m.TotalChanges)
+	if m.EmergencyChangeRatio != nil {
+		fmt.Printf("Emergency changes:   %d (%s)\n", m.EmergencyChanges, percent(m.EmergencyChangeRatio))
+	} else {
+		fmt.Printf("Emergency changes:   %d\n", m.EmergencyChanges)
+	}
+	fmt.Printf("Changes completed:   %d\n", m.CompletedChanges)
+	fmt.Printf("Failed changes:      %d\n", m.FailedChanges)
+	fmt.Printf("Success rate:        %s\n", percent(m.ChangeSuccessRate))
+	fmt.Printf("Approvals decided:   %d\n", m.DecidedApprovals)
+	fmt.Printf("Mean approval time:  %s\n", hours(m.MeanApprovalHours))
+}
+
+// percent formats a rate as a percentage, or "-" when there was nothing to
+// count
+func percent(rate *float64) string {
+	if rate == nil {
+		return "-"
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", *rate*100), ".0") + "%"
+}
+
+func hours(h *float64) string {
+	if h == nil {
+		return "-"
+	}
+	if *h < 1 {
+		return fmt.Sprintf("%.0f minutes", *h*60)
+	}
+	return fmt.Sprintf("%.1f hours", *h)
+}
+
+// writeCSV writes one row per report subject, with the same column names as
+// the change_metrics report job. Rates are fractions between 0 and 1.
+func writeCSV(path string, reports []scopedMetrics) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"scope", "from", "to", "total_changes", "emergency_changes", "emergency_change_ratio",
+		"completed_changes", "failed_changes", "change_success_rate", "decided_approvals", "mean_approval_hours",
+	})
+	for _, r := range reports {
+		m := r.Metrics
+		cw.Write([]string{
+			r.Scope,
+			m.From.Format("2006-01-02"),
+			lastDay(m.ReportPeriod).Format("2006-01-02"),
+			strconv.Itoa(m.TotalChanges),
+			strconv.Itoa(m.EmergencyChanges),
+			formatFloat(m.EmergencyChangeRatio),
+			strconv.Itoa(m.CompletedChanges),
+			strconv.Itoa(m.FailedChanges),
+			formatFloat(m.ChangeSuccessRate),
+			strconv.Itoa(m.DecidedApprovals),
+			formatFloat(m.MeanApprovalHours),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
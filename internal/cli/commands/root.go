@@ -1,18 +1,30 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"strings"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/apikey"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/approval"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/auth"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/blackout"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/config"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/employee"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/entitlement"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/ghmigrate"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/githooks"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/group"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/inbox"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/planning"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/report"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/ticket"
 	"github.com/afterdarksys/adsops-utils/internal/cli/commands/user"
+	"github.com/afterdarksys/adsops-utils/internal/cli/commands/worklog"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -26,11 +38,29 @@ var (
 
 This tool allows you to:
   - Create, view, edit, and manage change tickets
-  - Approve or deny change requests
+  - Approve or deny change requests, or work through them in an inbox
+  - Log time spent on changes against their estimates
+  - Black out hosts for the maintenance window of an approved change
+  - Follow sprints and epics and their story points
+  - Report change volume, success rates and approval times
   - Track compliance and audit trails
   - Manage user entitlements across all domains
   - Manage employees and groups
-  - Integrate with your CI/CD pipelines`,
+  - Enforce change control from git hooks
+  - Integrate with your CI/CD pipelines
+
+Every command prints tables and messages by default, or its result as data
+with --output json or --output yaml. Errors go to stderr, and the exit
+status says what kind of failure it was:
+
+  0  success
+  1  any other error
+  2  usage: unknown command or flag, wrong arguments, invalid --output
+  3  authentication: not logged in, session expired, or access denied
+  4  validation: invalid input, rejected by the CLI or the API
+  5  not found: the ticket or other resource doesn't exist
+  6  conflict: changed by someone else, or not allowed in its current status
+  7  unavailable: the API can't be reached (changes may be queued in the outbox)`,
 	}
 )
 
@@ -41,59 +71,93 @@ func Execute() error {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentPreRun = preRun
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.adsops-utils/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/changes/config.yaml)")
 	rootCmd.PersistentFlags().String("api-url", "https://api.changes.afterdarksys.com", "API server URL")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose output")
-	rootCmd.PersistentFlags().String("output", "table", "Output format (table, json, yaml)")
+	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table, json, yaml)")
 
 	// Bind flags to viper
 	viper.BindPFlag("api_url", rootCmd.PersistentFlags().Lookup("api-url"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(output.Formats, cobra.ShellCompDirectiveNoFileComp))
 
 	// Add subcommands
 	rootCmd.AddCommand(ticket.TicketCmd)
+	rootCmd.AddCommand(ticket.TemplateCmd)
+	rootCmd.AddCommand(ticket.OutboxCmd)
 	rootCmd.AddCommand(approval.ApprovalCmd)
+	rootCmd.AddCommand(inbox.InboxCmd)
+	rootCmd.AddCommand(worklog.WorklogCmd)
+	rootCmd.AddCommand(blackout.BlackoutCmd)
+	rootCmd.AddCommand(planning.SprintCmd)
+	rootCmd.AddCommand(planning.EpicCmd)
+	rootCmd.AddCommand(report.ReportCmd)
 	rootCmd.AddCommand(auth.AuthCmd)
+	rootCmd.AddCommand(auth.LoginCmd)
+	rootCmd.AddCommand(auth.LogoutCmd)
+	rootCmd.AddCommand(auth.WhoamiCmd)
+	rootCmd.AddCommand(apikey.APIKeyCmd)
 	rootCmd.AddCommand(config.ConfigCmd)
 	rootCmd.AddCommand(user.UserCmd)
 	rootCmd.AddCommand(employee.EmployeeCmd)
 	rootCmd.AddCommand(group.GroupCmd)
 	rootCmd.AddCommand(entitlement.EntitlementCmd)
 	rootCmd.AddCommand(ghmigrate.GHMigrateCmd)
+	rootCmd.AddCommand(githooks.GitHooksCmd)
 }
 
 func initConfig() {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
-		home, err := os.UserHomeDir()
+		configFile, err := config.FilePath()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error finding home directory:", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
-
-		configDir := home + "/.adsops-utils"
-
-		// Create config directory if it doesn't exist
-		if err := os.MkdirAll(configDir, 0700); err != nil {
-			fmt.Fprintln(os.Stderr, "Error creating config directory:", err)
-			os.Exit(1)
-		}
-
-		viper.AddConfigPath(configDir)
-		viper.SetConfigType("yaml")
-		viper.SetConfigName("config")
+		viper.SetConfigFile(configFile)
 	}
+	viper.SetConfigType("yaml")
 
 	viper.SetEnvPrefix("ADSOPS")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err == nil {
 		if viper.GetBool("verbose") {
 			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring config file %s: %v\n", viper.ConfigFileUsed(), err)
+	}
+}
+
+// preRun checks the global flags before any command runs, then replays the
+// outbox
+func preRun(cmd *cobra.Command, args []string) {
+	if err := output.Check(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.Usage)
+	}
+	replayOutbox(cmd)
+}
+
+// replayOutbox sends changes queued while the API was unreachable before
+// running a command, except for commands that manage the outbox, the config
+// or the login themselves, or that never talk to the API
+func replayOutbox(cmd *cobra.Command) {
+	top := cmd
+	for top.HasParent() && top.Parent().HasParent() {
+		top = top.Parent()
+	}
+	switch top.Name() {
+	case "outbox", "config", "login", "logout", "githooks", "completion", "help",
+		cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
 	}
+	ticket.ReplayOutbox()
 }
@@ -0,0 +1,565 @@
+package ticket
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// archiveDir is the subdirectory of the tickets directory that holds
+// archives and their index
+const archiveDir = "archive"
+
+// archiveIndexFile lists every archived ticket and the archive it is in
+const archiveIndexFile = "index.json"
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move old closed and cancelled tickets into a compressed archive",
+	Long: `Move the closed and cancelled ticket files last updated before a date out of
+the tickets directory into a compressed archive, so the directory doesn't grow
+without bound.
+
+Each run writes one tar.gz under ` + archiveDir + `/ in the tickets directory,
+with the ticket files and any PDFs generated from them, and records the
+tickets in ` + archiveDir + `/` + archiveIndexFile + `. Archived tickets keep their
+numbers, and 'changes ticket restore-archive' brings them back.
+
+Examples:
+  # See what would be archived
+  changes ticket archive --before 2024-01-01 --dry-run
+
+  # Archive tickets finished before 2024
+  changes ticket archive --before 2024-01-01`,
+	Args: cobra.NoArgs,
+	Run:  runArchive,
+}
+
+var restoreArchiveCmd = &cobra.Command{
+	Use:   "restore-archive [ticket-id...]",
+	Short: "Restore archived tickets to the tickets directory",
+	Long: `Restore archived ticket files, and their PDFs, to the tickets directory.
+Without ticket IDs, list the archived tickets.
+
+Examples:
+  # List archived tickets
+  changes ticket restore-archive
+
+  # Restore two tickets
+  changes ticket restore-archive CHG-2023-00012 CHG-2023-00013
+
+  # Restore everything archived before a date
+  changes ticket restore-archive --all --before 2023-07-01`,
+	Run: runRestoreArchive,
+}
+
+func init() {
+	archiveCmd.Flags().String("before", "", "Archive tickets last updated before this day, YYYY-MM-DD (required)")
+	archiveCmd.Flags().Bool("dry-run", false, "List the tickets that would be archived without moving them")
+	archiveCmd.Flags().String("dir", "", "Tickets directory (default: ./tickets)")
+	archiveCmd.MarkFlagRequired("before")
+
+	restoreArchiveCmd.Flags().Bool("all", false, "Restore every archived ticket (limit with --before)")
+	restoreArchiveCmd.Flags().String("before", "", "With --all, only tickets last updated before this day, YYYY-MM-DD")
+	restoreArchiveCmd.Flags().Bool("force", false, "Overwrite local ticket files that exist")
+	restoreArchiveCmd.Flags().String("dir", "", "Tickets directory (default: ./tickets)")
+}
+
+// archiveIndex is the index of a tickets directory's archives
+type archiveIndex struct {
+	Tickets map[string]*archivedTicket `json:"tickets"` // keyed by ticket ID
+}
+
+// archivedTicket is one ticket in an archive
+type archivedTicket struct {
+	Archive    string    `json:"archive"` // File name under archiveDir
+	Files      []string  `json:"files"`   // Names in the archive
+	Status     string    `json:"status"`
+	Title      string    `json:"title"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// archiveCandidate is a local ticket old enough to archive
+type archiveCandidate struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Files     []string  `json:"files"`
+}
+
+func runArchive(cmd *cobra.Command, args []string) {
+	beforeFlag, _ := cmd.Flags().GetString("before")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = getTicketsDir()
+	}
+
+	before, err := time.Parse("2006-01-02", beforeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --before %q: use YYYY-MM-DD\n", beforeFlag)
+		os.Exit(exitcode.Validation)
+	}
+
+	candidates, err := archiveCandidates(dir, before)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var archive string
+	if len(candidates) > 0 && !dryRun {
+		archive, err = writeArchive(dir, candidates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+
+	if output.Structured() {
+		if candidates == nil {
+			candidates = []archiveCandidate{}
+		}
+		output.Print(map[string]interface{}{
+			"archive":  archive,
+			"dry_run":  dryRun,
+			"tickets":  candidates,
+			"archived": len(candidates) > 0 && !dryRun,
+		})
+		return
+	}
+
+	if len(candidates) == 0 {
+		fmt.Printf("No closed or cancelled tickets last updated before %s\n", before.Format("2006-01-02"))
+		return
+	}
+	if dryRun {
+		fmt.Println("DRY RUN - no files will be moved")
+		fmt.Println()
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TICKET\tSTATUS\tUPDATED\tTITLE")
+	fmt.Fprintln(w, "------\t------\t-------\t-----")
+	for _, c := range candidates {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.ID, c.Status, c.UpdatedAt.Format("2006-01-02"), truncate(c.Title, 50))
+	}
+	w.Flush()
+	fmt.Println()
+	if dryRun {
+		fmt.Printf("Would archive %d tickets\n", len(candidates))
+	} else {
+		fmt.Printf("Archived %d tickets to %s\n", len(candidates), filepath.Join(dir, archiveDir, archive))
+	}
+}
+
+// archiveCandidates returns the closed and cancelled tickets in dir last
+// updated before the day, oldest first
+func archiveCandidates(dir string, before time.Time) ([]archiveCandidate, error) {
+	ids, err := localTicketIDs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []archiveCandidate
+	for _, id := range ids {
+		data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", id, err)
+		}
+		var t CreateTicketData
+		if err := json.Unmarshal(data, &t); err != nil {
+			// A file that doesn't parse is left for validate to report
+			continue
+		}
+		if t.Status != string(models.TicketStatusClosed) && t.Status != string(models.TicketStatusCancelled) {
+			continue
+		}
+		updated, ok := localUpdatedAt(&t)
+		if !ok || !updated.Before(before) {
+			continue
+		}
+
+		files := []string{id + ".json"}
+		if _, err := os.Stat(filepath.Join(dir, id+".pdf")); err == nil {
+			files = append(files, id+".pdf")
+		}
+		candidates = append(candidates, archiveCandidate{id, t.Status, t.Title, updated, files})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].UpdatedAt.Before(candidates[j].UpdatedAt)
+	})
+	return candidates, nil
+}
+
+// localUpdatedAt is when a local ticket last changed: its updated_at, or its
+// created_at when it was never updated
+func localUpdatedAt(t *CreateTicketData) (time.Time, bool) {
+	for _, s := range []string{t.UpdatedAt, t.CreatedAt} {
+		if ts, err := time.Parse(time.RFC3339, s); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// writeArchive writes the candidates' files to a new archive and records
+// them in the index, and only then removes them from the tickets directory,
+// so an interrupted run loses nothing. It returns the archive's file name.
+func writeArchive(dir string, candidates []archiveCandidate) (string, error) {
+	index, err := loadArchiveIndex(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, archiveDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	now := time.Now()
+	name := "tickets-" + now.UTC().Format("20060102-150405") + ".tar.gz"
+	path := filepath.Join(dir, archiveDir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	err = writeTarGz(f, dir, candidates)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	for _, c := range candidates {
+		index.Tickets[c.ID] = &archivedTicket{
+			Archive:    name,
+			Files:      c.Files,
+			Status:     c.Status,
+			Title:      c.Title,
+			UpdatedAt:  c.UpdatedAt,
+			ArchivedAt: now.UTC(),
+		}
+	}
+	if err := saveArchiveIndex(dir, index); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	for _, c := range candidates {
+		for _, file := range c.Files {
+			if err := os.Remove(filepath.Join(dir, file)); err != nil && !os.IsNotExist(err) {
+				return name, fmt.Errorf("archived %s but failed to remove it: %w", file, err)
+			}
+		}
+	}
+	return name, nil
+}
+
+func writeTarGz(w io.Writer, dir string, candidates []archiveCandidate) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, c := range candidates {
+		for _, file := range c.Files {
+			if err := addToTar(tw, dir, file); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addToTar(tw *tar.Writer, dir, name string) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func runRestoreArchive(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	beforeFlag, _ := cmd.Flags().GetString("before")
+	force, _ := cmd.Flags().GetBool("force")
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = getTicketsDir()
+	}
+
+	var before time.Time
+	if beforeFlag != "" {
+		var err error
+		if before, err = time.Parse("2006-01-02", beforeFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --before %q: use YYYY-MM-DD\n", beforeFlag)
+			os.Exit(exitcode.Validation)
+		}
+	}
+	if len(args) > 0 && all {
+		fmt.Fprintln(os.Stderr, "Error: give ticket IDs or --all, not both")
+		os.Exit(exitcode.Usage)
+	}
+
+	index, err := loadArchiveIndex(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var ids []string
+	if all {
+		for id, t := range index.Tickets {
+			if before.IsZero() || t.UpdatedAt.Before(before) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+	} else {
+		for _, arg := range args {
+			ids = append(ids, strings.ToUpper(strings.TrimSuffix(filepath.Base(arg), ".json")))
+		}
+	}
+
+	if len(args) == 0 && !all {
+		printArchiveIndex(index, before)
+		return
+	}
+
+	// Restore archive by archive, each read once
+	byArchive := map[string][]string{}
+	status := exitcode.OK
+	for _, id := range ids {
+		t, ok := index.Tickets[id]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: %s is not archived\n", id)
+			if status == exitcode.OK {
+				status = exitcode.NotFound
+			}
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); err == nil && !force {
+			fmt.Fprintf(os.Stderr, "Error: %s already exists in %s; use --force to overwrite it\n", id, dir)
+			if status == exitcode.OK {
+				status = exitcode.Conflict
+			}
+			continue
+		}
+		byArchive[t.Archive] = append(byArchive[t.Archive], id)
+	}
+
+	var restored []string
+	archives := make([]string, 0, len(byArchive))
+	for name := range byArchive {
+		archives = append(archives, name)
+	}
+	sort.Strings(archives)
+	for _, name := range archives {
+		done, err := restoreFromArchive(dir, name, index, byArchive[name])
+		restored = append(restored, done...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring from %s: %v\n", name, err)
+			if status == exitcode.OK {
+				status = exitcode.For(err)
+			}
+		}
+	}
+
+	if len(restored) > 0 {
+		for _, id := range restored {
+			delete(index.Tickets, id)
+		}
+		if err := saveArchiveIndex(dir, index); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		removeEmptyArchives(dir, index, archives)
+	}
+
+	if output.Structured() {
+		if restored == nil {
+			restored = []string{}
+		}
+		output.Print(map[string]interface{}{"restored": restored})
+	} else {
+		for _, id := range restored {
+			fmt.Printf("Restored %s\n", id)
+		}
+	}
+	os.Exit(status)
+}
+
+// restoreFromArchive extracts the tickets' files from one archive into the
+// tickets directory, returning the tickets restored
+func restoreFromArchive(dir, name string, index *archiveIndex, ids []string) ([]string, error) {
+	wanted := map[string]string{} // file name -> ticket ID
+	for _, id := range ids {
+		for _, file := range index.Tickets[id].Files {
+			wanted[file] = id
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, archiveDir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	found := map[string]bool{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		id, ok := wanted[header.Name]
+		if !ok || header.Name != filepath.Base(header.Name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, header.Name), data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", header.Name, err)
+		}
+		if header.Name == id+".json" {
+			found[id] = true
+		}
+	}
+
+	var restored []string
+	var missing []string
+	for _, id := range ids {
+		if found[id] {
+			restored = append(restored, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return restored, errors.New("not in the archive: " + strings.Join(missing, ", "))
+	}
+	return restored, nil
+}
+
+// removeEmptyArchives deletes the archives no indexed ticket is in any more
+func removeEmptyArchives(dir string, index *archiveIndex, archives []string) {
+	inUse := map[string]bool{}
+	for _, t := range index.Tickets {
+		inUse[t.Archive] = true
+	}
+	for _, name := range archives {
+		if !inUse[name] {
+			os.Remove(filepath.Join(dir, archiveDir, name))
+		}
+	}
+}
+
+func printArchiveIndex(index *archiveIndex, before time.Time) {
+	ids := make([]string, 0, len(index.Tickets))
+	for id, t := range index.Tickets {
+		if before.IsZero() || t.UpdatedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	if output.Structured() {
+		tickets := make(map[string]*archivedTicket, len(ids))
+		for _, id := range ids {
+			tickets[id] = index.Tickets[id]
+		}
+		output.Print(tickets)
+		return
+	}
+	if len(ids) == 0 {
+		fmt.Println("No archived tickets")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TICKET\tSTATUS\tUPDATED\tARCHIVE\tTITLE")
+	fmt.Fprintln(w, "------\t------\t-------\t-------\t-----")
+	for _, id := range ids {
+		t := index.Tickets[id]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, t.Status, t.UpdatedAt.Format("2006-01-02"), t.Archive, truncate(t.Title, 50))
+	}
+	w.Flush()
+}
+
+func loadArchiveIndex(dir string) (*archiveIndex, error) {
+	index := &archiveIndex{Tickets: map[string]*archivedTicket{}}
+	data, err := os.ReadFile(filepath.Join(dir, archiveDir, archiveIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse archive index: %w", err)
+	}
+	if index.Tickets == nil {
+		index.Tickets = map[string]*archivedTicket{}
+	}
+	return index, nil
+}
+
+// saveArchiveIndex replaces the index through a temporary file, so a
+// failed write leaves the old index intact
+func saveArchiveIndex(dir string, index *archiveIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive index: %w", err)
+	}
+	path := filepath.Join(dir, archiveDir, archiveIndexFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write archive index: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write archive index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package ticket
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <ticket-id> <file...>",
+	Short: "Attach files to a ticket",
+	Long: `Upload files, such as runbooks and test evidence, to a ticket.
+
+Every file is checked against the API's size limit before anything is sent,
+so a file that is too large fails straight away instead of after uploading.
+Progress is shown on a terminal.
+
+Examples:
+  # Attach a runbook
+  changes ticket attach CHG-2025-00001 ./runbook.pdf
+
+  # Attach several files
+  changes ticket attach CHG-2025-00001 plan.md screenshots/*.png`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runAttach,
+}
+
+var attachmentsCmd = &cobra.Command{
+	Use:   "attachments <ticket-id>",
+	Short: "List a ticket's attachments with download links",
+	Long: `List the files attached to a ticket with links to download them. The links
+need no login, so share them with care; they expire after a while (an hour
+by default), and listing again gives fresh ones.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runAttachments,
+}
+
+// upload is a local file to attach
+type upload struct {
+	path string
+	name string
+	size int64
+}
+
+func runAttach(cmd *cobra.Command, args []string) {
+	var uploads []upload
+	for _, path := range args[1:] {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		if !info.Mode().IsRegular() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a file\n", path)
+			os.Exit(exitcode.Validation)
+		}
+		uploads = append(uploads, upload{path: path, name: filepath.Base(path), size: info.Size()})
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	ticket, err := client.FindTicket(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	// Preflight: the listing carries the API's size limit
+	existing, err := client.ListAttachments(ticket.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if limit := existing.MaxSizeBytes; limit > 0 {
+		tooLarge := false
+		for _, u := range uploads {
+			if u.size > limit {
+				fmt.Fprintf(os.Stderr, "Error: %s is %s; attachments can be at most %s\n", u.path, formatBytes(u.size), formatBytes(limit))
+				tooLarge = true
+			}
+		}
+		if tooLarge {
+			os.Exit(exitcode.Validation)
+		}
+	}
+
+	showProgress := !output.Structured() && isTerminal(os.Stderr)
+	var attached []*models.Attachment
+	status := exitcode.OK
+	for _, u := range uploads {
+		a, err := attachFile(client, ticket, u, showProgress)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error attaching %s: %v\n", u.path, err)
+			if status == exitcode.OK {
+				status = exitcode.For(err)
+			}
+			continue
+		}
+		attached = append(attached, a)
+		if !output.Structured() {
+			fmt.Printf("Attached %s (%s) to %s\n", a.Filename, formatBytes(a.SizeBytes), ticket.TicketNumber)
+		}
+	}
+
+	if output.Structured() {
+		if attached == nil {
+			attached = []*models.Attachment{}
+		}
+		output.Print(attached)
+	}
+	os.Exit(status)
+}
+
+// attachFile uploads one file, drawing a progress line on stderr when asked
+func attachFile(client *apiclient.Client, ticket *models.Ticket, u upload, showProgress bool) (*models.Attachment, error) {
+	f, err := os.Open(u.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var progress func(int64)
+	if showProgress {
+		var last time.Time
+		progress = func(sent int64) {
+			if time.Since(last) < 100*time.Millisecond && sent < u.size {
+				return
+			}
+			last = time.Now()
+			percent := 100
+			if u.size > 0 {
+				percent = int(sent * 100 / u.size)
+			}
+			fmt.Fprintf(os.Stderr, "\r\x1b[KUploading %s  %3d%%  %s / %s", u.name, percent, formatBytes(sent), formatBytes(u.size))
+		}
+		defer fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
+	return client.UploadAttachment(ticket.ID, u.name, f, u.size, progress)
+}
+
+func runAttachments(cmd *cobra.Command, args []string) {
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	ticket, err := client.FindTicket(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	list, err := client.ListAttachments(ticket.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing attachments: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(list.Attachments)
+		return
+	}
+
+	fmt.Printf("%s: %s\n\n", ticket.TicketNumber, ticket.Title)
+	if len(list.Attachments) == 0 {
+		fmt.Println("No attachments")
+		return
+	}
+	var expires *time.Time
+	for _, a := range list.Attachments {
+		uploader := "-"
+		if a.Uploader != nil {
+			uploader = a.Uploader.Email
+		}
+		fmt.Printf("%s  %s  %s  %s\n", a.Filename, formatBytes(a.SizeBytes), a.CreatedAt.Local().Format("2006-01-02 15:04"), uploader)
+		if a.DownloadURL != "" {
+			fmt.Printf("  %s\n", a.DownloadURL)
+		}
+		if a.DownloadExpiresAt != nil && (expires == nil || a.DownloadExpiresAt.Before(*expires)) {
+			expires = a.DownloadExpiresAt
+		}
+	}
+	if expires != nil {
+		fmt.Printf("\nLinks expire at %s\n", expires.Local().Format("2006-01-02 15:04 MST"))
+	}
+}
+
+// formatBytes prints a size in the largest unit that keeps it at least 1
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	suffix := ""
+	for _, s := range []string{"KB", "MB", "GB", "TB"} {
+		value /= unit
+		suffix = s
+		if value < unit {
+			break
+		}
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + " " + suffix
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
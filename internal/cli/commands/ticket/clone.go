@@ -0,0 +1,128 @@
+package ticket
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:     "clone [ticket-number]",
+	Aliases: []string{"copy"},
+	Short:   "Create a new draft from an existing ticket",
+	Long: `Create a new draft ticket from an existing one.
+
+The new draft copies the description, change type, industry, priority, risk,
+compliance frameworks, affected systems, required approvals, acceptance
+criteria, and testing and rollback plans. Status, approvals, comments,
+assignee and dependencies start fresh. Useful for changes that recur every
+month.
+
+The source ticket is read from the API, or from the local tickets directory
+when the API doesn't have it.
+
+Examples:
+  # Clone last month's patching ticket
+  changes ticket clone CHG-2025-00001 --title "Monthly OS patching - March"
+
+  # Clone with a different risk level
+  changes ticket clone CHG-2025-00001 --title "Patch staging only" --risk low`,
+	Args: cobra.ExactArgs(1),
+	Run:  runClone,
+}
+
+func init() {
+	cloneCmd.Flags().String("title", "", "Title for the new ticket (default: the source ticket's title)")
+	cloneCmd.Flags().String("description", "", "Description for the new ticket")
+	cloneCmd.Flags().StringP("priority", "p", "", "Priority for the new ticket")
+	cloneCmd.Flags().StringP("risk", "r", "", "Risk level for the new ticket")
+	cloneCmd.Flags().Bool("local", false, "Read the source ticket from the local tickets directory only")
+}
+
+func runClone(cmd *cobra.Command, args []string) {
+	sourceNumber := args[0]
+	localOnly, _ := cmd.Flags().GetBool("local")
+
+	source, err := loadCloneSource(sourceNumber, localOnly)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	ticketID, err := getNextTicketNumber()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating ticket ID: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	ticket := newTicketData(ticketID, false)
+	ticket.Title = source.Title
+	ticket.Description = source.Description
+	ticket.Priority = source.Priority
+	ticket.Risk = source.Risk
+	ticket.Type = source.Type
+	ticket.Industry = source.Industry
+	ticket.ComplianceFrameworks = nonNil(source.ComplianceFrameworks)
+	ticket.AffectedSystems = nonNil(source.AffectedSystems)
+	ticket.AcceptanceCriteria = nonNil(source.AcceptanceCriteria)
+	ticket.TestingPlan = source.TestingPlan
+	ticket.RollbackPlan = source.RollbackPlan
+	ticket.ApprovalsRequired = nonNil(source.ApprovalsRequired)
+	ticket.Comments[0].Text = fmt.Sprintf("Ticket cloned from %s via CLI.", sourceNumber)
+
+	for flag, field := range map[string]*string{
+		"title":       &ticket.Title,
+		"description": &ticket.Description,
+		"priority":    &ticket.Priority,
+		"risk":        &ticket.Risk,
+	} {
+		if cmd.Flags().Changed(flag) {
+			*field, _ = cmd.Flags().GetString(flag)
+		}
+	}
+	if ticket.Title == source.Title {
+		fmt.Fprintf(os.Stderr, "Warning: the new ticket has the same title as %s; set one with --title or 'changes ticket edit'\n", sourceNumber)
+	}
+
+	if err := saveTicket(ticket); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if !output.Structured() {
+		fmt.Printf("Cloned %s: %s\n", sourceNumber, ticket.Title)
+	}
+	printCreated(ticket)
+}
+
+// loadCloneSource reads the ticket to clone in the local ticket format, from
+// the API when possible
+func loadCloneSource(ticketNumber string, localOnly bool) (*CreateTicketData, error) {
+	if !localOnly {
+		client, err := apiclient.New()
+		if err == nil {
+			remote, findErr := client.FindTicket(ticketNumber)
+			if findErr == nil {
+				source := &CreateTicketData{}
+				mergeRemote(source, remote)
+				return source, nil
+			}
+			err = findErr
+		}
+		switch {
+		case apiclient.IsNotFound(err):
+			// The ticket may only exist locally as an unsynced draft
+		case viper.GetBool("verbose") || !errors.Is(err, apiclient.ErrNotAuthenticated):
+			fmt.Fprintf(os.Stderr, "Warning: could not fetch %s from the API: %v\n", ticketNumber, err)
+		}
+	}
+
+	local, _, err := loadLocalTicket(ticketNumber)
+	return local, err
+}
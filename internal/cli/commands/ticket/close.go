@@ -2,7 +2,10 @@ package ticket
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
 	"github.com/spf13/cobra"
 )
 
@@ -12,7 +15,9 @@ var closeCmd = &cobra.Command{
 	Long: `Close a completed change ticket.
 
 Only tickets that have been fully approved and implemented
-can be closed.
+can be closed. Resolution notes are posted as a comment. While the API can't
+be reached, closing is queued in the outbox (see 'changes outbox').
+Closing an emergency change opens its post-implementation review.
 
 Examples:
   # Close a ticket
@@ -26,8 +31,6 @@ Examples:
 
 func init() {
 	closeCmd.Flags().String("notes", "", "Resolution notes")
-	closeCmd.Flags().String("actual-start", "", "Actual implementation start time (ISO8601)")
-	closeCmd.Flags().String("actual-end", "", "Actual implementation end time (ISO8601)")
 	closeCmd.Flags().Bool("force", false, "Skip confirmation prompt")
 }
 
@@ -35,23 +38,39 @@ func runClose(cmd *cobra.Command, args []string) {
 	ticketNumber := args[0]
 	force, _ := cmd.Flags().GetBool("force")
 	notes, _ := cmd.Flags().GetString("notes")
+	if notes != "" {
+		notes = "Resolution: " + notes
+	}
 
-	// TODO: Implement API call to close ticket
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	wt, err := resolveWorkflowTicket(client, ticketNumber, false)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, nil, "close", "", notes, false, force) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
 
 	if !force {
-		fmt.Printf("Close ticket %s? [y/N] ", ticketNumber)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
+		prompt := fmt.Sprintf("Close ticket %s (%s)?", wt.remote.TicketNumber, wt.remote.Title)
+		if ok, err := newPrompter().confirm(prompt, false); err != nil || !ok {
 			fmt.Println("Cancelled")
 			return
 		}
 	}
 
-	fmt.Printf("Closing ticket %s...\n", ticketNumber)
-	if notes != "" {
-		fmt.Printf("Resolution: %s\n", notes)
+	result, err := wt.transition(client, "close", "", notes)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, wt.remote, "close", "", notes, false, true) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: failed to close ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
 	}
-	fmt.Println()
-	fmt.Println("Ticket closed successfully!")
+	printTransition(wt.remote, result)
 }
@@ -0,0 +1,81 @@
+package ticket
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var commentCmd = &cobra.Command{
+	Use:   "comment <ticket-id> <text>",
+	Short: "Comment on a ticket",
+	Long: `Post a comment on a ticket. Give the text as an argument, or - to read it
+from stdin. While the API can't be reached, the comment is queued in the
+outbox (see 'changes outbox').
+
+Examples:
+  # Comment on a ticket
+  changes ticket comment CHG-2025-00001 "Maintenance window moved to 02:00 UTC"
+
+  # Post a file as an internal comment
+  changes ticket comment CHG-2025-00001 - --internal < notes.txt`,
+	Args: cobra.ExactArgs(2),
+	Run:  runComment,
+}
+
+func init() {
+	commentCmd.Flags().Bool("internal", false, "Only visible to your organization, not customers")
+}
+
+func runComment(cmd *cobra.Command, args []string) {
+	ref, text := args[0], args[1]
+	internal, _ := cmd.Flags().GetBool("internal")
+
+	if text == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		text = string(data)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		fmt.Fprintln(os.Stderr, "Error: the comment is empty")
+		os.Exit(exitcode.Validation)
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	entry := &outboxEntry{Kind: outboxComment, Ticket: ref, Text: text, Internal: internal}
+	wt, err := resolveWorkflowTicket(client, ref, false)
+	if err == nil {
+		entry.Ticket = wt.remote.TicketNumber
+		err = client.AddComment(wt.remote.ID, models.CreateCommentInput{Comment: text, IsInternal: internal}, "cli-comment-"+uuid.NewString())
+	}
+	if apiclient.IsUnreachable(err) {
+		queueChanges(err, entry)
+		os.Exit(exitcode.Unavailable)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to post comment: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if output.Structured() {
+		output.Print(map[string]interface{}{"ticket_number": wt.remote.TicketNumber, "comment": text, "is_internal": internal})
+		return
+	}
+	fmt.Printf("Commented on %s\n", wt.remote.TicketNumber)
+}
@@ -0,0 +1,279 @@
+package ticket
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// ticketCacheTTL is how long the ticket list used for shell completion is
+// trusted before completion fetches it again
+const ticketCacheTTL = 10 * time.Minute
+
+// completionTimeout bounds the API call completion makes, so a slow or
+// unreachable server never stalls the shell for long
+const completionTimeout = 2 * time.Second
+
+// ticketCache is the recent API tickets kept for shell completion in
+// ~/.adsops-utils/cache/tickets.json
+type ticketCache struct {
+	APIURL    string        `json:"api_url"`
+	FetchedAt time.Time     `json:"fetched_at"`
+	Tickets   []cachedEntry `json:"tickets"`
+}
+
+type cachedEntry struct {
+	Number string `json:"number"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// registerCompletions wires up argument and flag completion. It runs from
+// TicketCmd's init, once every subcommand has defined its flags.
+func registerCompletions() {
+	// Ticket arguments, limited to tickets the default workflow lets the
+	// command act on
+	viewCmd.ValidArgsFunction = completeTickets(1, nil)
+	cloneCmd.ValidArgsFunction = completeTickets(1, nil)
+	exportCmd.ValidArgsFunction = completeTickets(-1, nil)
+	pdfCmd.ValidArgsFunction = completeTickets(-1, nil)
+	editCmd.ValidArgsFunction = completeTickets(1, func(s models.TicketStatus) bool {
+		return (&models.Ticket{Status: s}).CanEdit()
+	})
+	submitCmd.ValidArgsFunction = completeTickets(1, allowsTransition(models.TicketStatusSubmitted))
+	closeCmd.ValidArgsFunction = completeTickets(1, allowsTransition(models.TicketStatusClosed))
+	openCmd.ValidArgsFunction = completeTickets(1, allowsTransition(models.TicketStatusUpdateRequested))
+	cancelCmd.ValidArgsFunction = completeTickets(1, allowsTransition(models.TicketStatusCancelled))
+	commentCmd.ValidArgsFunction = completeTickets(1, nil)
+	linkRepoCmd.ValidArgsFunction = completeTickets(1, nil)
+	unlinkRepoCmd.ValidArgsFunction = completeTickets(1, nil)
+
+	// Enum flags. template apply shares create's flags, and so their
+	// completions.
+	for _, cmd := range []*cobra.Command{createCmd, cloneCmd, listCmd} {
+		registerEnumFlag(cmd, "priority", priorityValues())
+		registerEnumFlag(cmd, "risk", riskValues())
+	}
+	registerEnumFlag(createCmd, "industry", industryValues())
+	registerEnumFlag(createCmd, "compliance", frameworkValues())
+	registerEnumFlag(createCmd, "approval-types", approvalTypeValues())
+	registerEnumFlag(listCmd, "status", statusValues())
+	registerEnumFlag(listCmd, "columns", listColumnNames())
+	registerEnumFlag(listCmd, "sort", []string{"created_at", "updated_at", "priority", "status", "ticket_number", "title"})
+	registerEnumFlag(exportCmd, "status", statusValues())
+	registerEnumFlag(linkRepoCmd, "type", repositoryLinkTypes)
+	registerEnumFlag(exportCmd, "format", append(append([]string(nil), exportFormats...), "all"))
+
+	createCmd.RegisterFlagCompletionFunc("template", completeTemplates)
+	templateShowCmd.ValidArgsFunction = completeTemplateArg
+	templateApplyCmd.ValidArgsFunction = completeTemplateArg
+}
+
+// completeTickets completes ticket numbers from the local tickets directory
+// and the cached API list. max is how many ticket arguments the command
+// takes (-1 for any number); keep, when set, filters by status.
+func completeTickets(max int, keep func(models.TicketStatus) bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if max >= 0 && len(args) >= max {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		given := map[string]bool{}
+		for _, a := range args {
+			given[a] = true
+		}
+
+		var completions []string
+		seen := map[string]bool{}
+		add := func(e cachedEntry) {
+			if seen[e.Number] || given[e.Number] || !strings.HasPrefix(e.Number, toComplete) {
+				return
+			}
+			if keep != nil && e.Status != "" && !keep(models.TicketStatus(e.Status)) {
+				return
+			}
+			seen[e.Number] = true
+			completions = append(completions, e.Number+"\t"+completionLabel(e))
+		}
+
+		for _, e := range localTicketEntries() {
+			add(e)
+		}
+		for _, e := range cachedTicketEntries() {
+			add(e)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// allowsTransition keeps tickets the default workflow can move to status
+func allowsTransition(status models.TicketStatus) func(models.TicketStatus) bool {
+	return func(from models.TicketStatus) bool {
+		return models.Transitions.Allows(from, status)
+	}
+}
+
+func completionLabel(e cachedEntry) string {
+	label := truncate(e.Title, 50)
+	if e.Status != "" {
+		label += " [" + e.Status + "]"
+	}
+	return label
+}
+
+// localTicketEntries lists the tickets in the local tickets directory
+func localTicketEntries() []cachedEntry {
+	dir := getTicketsDir()
+	ids, err := localTicketIDs(dir)
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]cachedEntry, 0, len(ids))
+	for _, id := range ids {
+		entry := cachedEntry{Number: id}
+		if data, err := os.ReadFile(filepath.Join(dir, id+".json")); err == nil {
+			var t CreateTicketData
+			if json.Unmarshal(data, &t) == nil {
+				entry.Title, entry.Status = t.Title, t.Status
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// cachedTicketEntries returns the cached API ticket list, fetching it again
+// when it is stale or belongs to another server. Any failure just means no
+// API completions.
+func cachedTicketEntries() []cachedEntry {
+	path := ticketCachePath()
+	apiURL := apiclient.APIURL()
+
+	var cache ticketCache
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	if cache.APIURL == apiURL && time.Since(cache.FetchedAt) < ticketCacheTTL {
+		return cache.Tickets
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		return nil
+	}
+	client.HTTPClient.Timeout = completionTimeout
+
+	list, err := client.ListTickets(url.Values{
+		"per_page":   []string{"100"},
+		"sort_by":    []string{"updated_at"},
+		"sort_order": []string{"desc"},
+	})
+	if err != nil {
+		// Stale entries from the same server beat none
+		if cache.APIURL == apiURL {
+			return cache.Tickets
+		}
+		return nil
+	}
+
+	cache = ticketCache{APIURL: apiURL, FetchedAt: time.Now().UTC()}
+	for _, t := range list.Tickets {
+		cache.Tickets = append(cache.Tickets, cachedEntry{Number: t.TicketNumber, Title: t.Title, Status: string(t.Status)})
+	}
+	if data, err := json.Marshal(cache); err == nil {
+		if os.MkdirAll(filepath.Dir(path), 0700) == nil {
+			os.WriteFile(path, data, 0600)
+		}
+	}
+	return cache.Tickets
+}
+
+func ticketCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "adsops-utils-tickets.json")
+	}
+	return filepath.Join(home, ".adsops-utils", "cache", "tickets.json")
+}
+
+// completeTemplates completes template names for --template
+func completeTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	templates, err := listTemplates()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, t := range templates {
+		if strings.HasPrefix(t.Name, toComplete) {
+			names = append(names, t.Name+"\t"+truncate(t.Description, 50))
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTemplateArg completes the template name argument of template
+// show and apply
+func completeTemplateArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeTemplates(cmd, args, toComplete)
+}
+
+func registerEnumFlag(cmd *cobra.Command, name string, values []string) {
+	cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func priorityValues() []string {
+	return []string{
+		string(models.TicketPriorityEmergency), string(models.TicketPriorityUrgent), string(models.TicketPriorityHigh),
+		string(models.TicketPriorityNormal), string(models.TicketPriorityLow),
+	}
+}
+
+func riskValues() []string {
+	return []string{
+		string(models.RiskLevelCritical), string(models.RiskLevelHigh),
+		string(models.RiskLevelMedium), string(models.RiskLevelLow),
+	}
+}
+
+func industryValues() []string {
+	return []string{
+		string(models.IndustryIT), string(models.IndustryFinance), string(models.IndustryHealthcare),
+		string(models.IndustryInsurance), string(models.IndustryGovernment),
+	}
+}
+
+func frameworkValues() []string {
+	return []string{
+		string(models.ComplianceGLBA), string(models.ComplianceSOX), string(models.ComplianceHIPAA),
+		string(models.ComplianceBankingSecrecyAct), string(models.ComplianceGDPR), string(models.ComplianceCustom),
+	}
+}
+
+func approvalTypeValues() []string {
+	return []string{
+		string(models.ApprovalTypeOperations), string(models.ApprovalTypeIT), string(models.ApprovalTypeSecurity),
+		string(models.ApprovalTypeRisk), string(models.ApprovalTypeNetworkEngineering), string(models.ApprovalTypeCloud),
+		string(models.ApprovalTypeAIOps), string(models.ApprovalTypeChangeManagementBoard),
+	}
+}
+
+func statusValues() []string {
+	values := make([]string, len(models.TicketStatuses))
+	for i, s := range models.TicketStatuses {
+		values[i] = string(s)
+	}
+	return values
+}
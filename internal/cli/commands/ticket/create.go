@@ -6,11 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
 	_ "github.com/lib/pq"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -69,7 +70,10 @@ Examples:
     --risk medium \
     --industry finance \
     --compliance glba,sox \
-    --approval-types operations,it,security`,
+    --approval-types operations,it,security
+
+  # Start from a template (see 'changes template list')
+  changes ticket create --template standard-db-migration --title "Add index to orders"`,
 	Run: runCreate,
 }
 
@@ -88,22 +92,13 @@ func init() {
 	createCmd.Flags().String("testing", "", "Testing plan")
 	createCmd.Flags().Bool("submit", false, "Submit immediately instead of saving as draft")
 	createCmd.Flags().Bool("interactive", true, "Use interactive mode")
+	createCmd.Flags().String("template", "", "Pre-fill fields from a ticket template")
 }
 
 // getMaxTicketNumFromDB attempts to get the max ticket number from the database
 // Returns 0 if database is unavailable or query fails (graceful degradation)
 func getMaxTicketNumFromDB(year int) int {
-	// Try to load database config from viper
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./config")
-	viper.AddConfigPath("/etc/adsops-utils")
-	viper.SetEnvPrefix("ADSOPS")
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.AutomaticEnv()
-	_ = viper.ReadInConfig() // Ignore error, env vars may be enough
-
+	// database.* from the CLI config file or ADSOPS_DATABASE_* variables
 	host := viper.GetString("database.host")
 	port := viper.GetInt("database.port")
 	user := viper.GetString("database.user")
@@ -157,17 +152,28 @@ func getMaxTicketNumFromDB(year int) int {
 	return 0
 }
 
-// getMaxTicketNumFromFiles scans local JSON files for the max ticket number
+// getMaxTicketNumFromFiles scans local JSON files, and the archive index,
+// for the max ticket number
 func getMaxTicketNumFromFiles(ticketsDir string, year int) int {
 	entries, err := os.ReadDir(ticketsDir)
 	if err != nil {
 		return 0
 	}
 
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// Archived tickets keep their numbers
+	if index, err := loadArchiveIndex(ticketsDir); err == nil {
+		for id := range index.Tickets {
+			names = append(names, id+".json")
+		}
+	}
+
 	var maxNum int
 	prefix := fmt.Sprintf("CHG-%d-", year)
-	for _, entry := range entries {
-		name := entry.Name()
+	for _, name := range names {
 		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".json") {
 			continue
 		}
@@ -185,6 +191,27 @@ func getMaxTicketNumFromFiles(ticketsDir string, year int) int {
 	return maxNum
 }
 
+// defaultCompliance is default_compliance from the config, for tickets in
+// the default industry (or any industry when none is set), so the frameworks
+// don't follow a ticket into an industry they don't apply to
+func defaultCompliance(industry string) []string {
+	if def := viper.GetString("default_industry"); def != "" && def != industry {
+		return []string{}
+	}
+	var frameworks []string
+	for _, f := range viper.GetStringSlice("default_compliance") {
+		for _, name := range strings.Split(f, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				frameworks = append(frameworks, name)
+			}
+		}
+	}
+	if frameworks == nil {
+		return []string{}
+	}
+	return frameworks
+}
+
 // getNextTicketNumber determines the next available ticket number
 // Checks BOTH local JSON files AND the database (if available) to prevent ID collisions
 func getNextTicketNumber() (string, error) {
@@ -247,6 +274,26 @@ func saveTicket(ticket *CreateTicketData) error {
 }
 
 func runCreate(cmd *cobra.Command, args []string) {
+	templateName, _ := cmd.Flags().GetString("template")
+	createTicket(cmd, templateName)
+}
+
+// createTicket creates a ticket from the create flags, with any flags not
+// given filled in from the named template
+func createTicket(cmd *cobra.Command, templateName string) {
+	if templateName != "" {
+		tmpl, err := findTemplate(templateName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		if err := applyTemplate(cmd, tmpl); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		fmt.Fprintf(os.Stderr, "Using template %s (%s)\n", tmpl.Name, tmpl.Source)
+	}
+
 	interactive, _ := cmd.Flags().GetBool("interactive")
 
 	// If interactive is explicitly false or we have a title, use non-interactive
@@ -264,14 +311,14 @@ func runCreate(cmd *cobra.Command, args []string) {
 	if title == "" {
 		fmt.Println("Error: --title is required in non-interactive mode")
 		fmt.Println("Usage: changes ticket create --title \"Your ticket title\" [other flags]")
-		os.Exit(1)
+		os.Exit(exitcode.Validation)
 	}
 
 	// Get next ticket number
 	ticketID, err := getNextTicketNumber()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating ticket ID: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	// Collect all flags
@@ -287,6 +334,39 @@ func runCreate(cmd *cobra.Command, args []string) {
 	testing, _ := cmd.Flags().GetString("testing")
 	submit, _ := cmd.Flags().GetBool("submit")
 
+	ticket := newTicketData(ticketID, submit)
+	ticket.Title = title
+	ticket.Description = description
+	ticket.Priority = priority
+	ticket.Risk = risk
+	ticket.Type = changeType
+	if industry == "" {
+		industry = viper.GetString("default_industry")
+	}
+	if len(compliance) == 0 {
+		compliance = defaultCompliance(industry)
+	}
+	ticket.Industry = industry
+	ticket.ComplianceFrameworks = compliance
+	ticket.AffectedSystems = affectedSystems
+	ticket.TestingPlan = testing
+	ticket.RollbackPlan = rollback
+	ticket.ApprovalsRequired = approvalTypes
+
+	if err := saveTicket(ticket); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if !output.Structured() {
+		fmt.Printf("Creating ticket: %s\n", title)
+	}
+	printCreated(ticket)
+}
+
+// newTicketData returns an empty local ticket stamped with its creator,
+// sprint and creation comment
+func newTicketData(ticketID string, submit bool) *CreateTicketData {
 	now := time.Now().UTC()
 	status := "draft"
 	if submit {
@@ -305,26 +385,18 @@ func runCreate(cmd *cobra.Command, args []string) {
 	quarter := (now.Month()-1)/3 + 1
 	sprint := fmt.Sprintf("%d-Q%d-Sprint-%d", now.Year(), quarter, (week-1)%2+1)
 
-	ticket := &CreateTicketData{
+	return &CreateTicketData{
 		ID:                   ticketID,
-		Title:                title,
-		Description:          description,
 		Status:               status,
-		Priority:             priority,
-		Risk:                 risk,
-		Type:                 changeType,
-		Industry:             industry,
-		ComplianceFrameworks: compliance,
-		AffectedSystems:      affectedSystems,
+		ComplianceFrameworks: []string{},
+		AffectedSystems:      []string{},
 		AcceptanceCriteria:   []string{},
-		TestingPlan:          testing,
-		RollbackPlan:         rollback,
 		CreatedBy:            createdBy,
 		CreatedAt:            now.Format(time.RFC3339),
 		UpdatedAt:            now.Format(time.RFC3339),
 		Sprint:               sprint,
 		Assignee:             nil,
-		ApprovalsRequired:    approvalTypes,
+		ApprovalsRequired:    []string{},
 		Approvals:            []string{},
 		Dependencies:         []string{},
 		Comments: []struct {
@@ -339,46 +411,19 @@ func runCreate(cmd *cobra.Command, args []string) {
 			},
 		},
 	}
+}
 
-	if err := saveTicket(ticket); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving ticket: %v\n", err)
-		os.Exit(1)
+// printCreated reports a newly saved ticket and what to do next
+func printCreated(ticket *CreateTicketData) {
+	if output.Structured() {
+		output.Print(ticket)
+		return
 	}
-
-	fmt.Printf("Creating ticket: %s\n", title)
-	fmt.Printf("Ticket created successfully: %s\n", ticketID)
-	if submit {
+	fmt.Printf("Ticket created successfully: %s\n", ticket.ID)
+	if ticket.Status == "submitted" {
 		fmt.Println("Status: submitted (awaiting approval)")
 	} else {
 		fmt.Println("Status: draft")
-		fmt.Println("Use 'changes ticket submit " + ticketID + "' to submit for approval.")
-	}
-}
-
-func runInteractiveCreate(cmd *cobra.Command) {
-	// For now, provide a simple interactive flow using standard input
-	fmt.Println("Interactive ticket creation")
-	fmt.Println("===========================")
-	fmt.Println()
-	fmt.Println("Use --interactive=false with --title to create non-interactively.")
-	fmt.Println()
-	fmt.Println("Example:")
-	fmt.Println("  changes ticket create --interactive=false --title \"My ticket\" --priority high")
-	fmt.Println()
-
-	// List existing tickets
-	tickets, err := loadLocalTickets()
-	if err == nil && len(tickets) > 0 {
-		fmt.Println("Recent tickets:")
-		// Sort by created date descending
-		sort.Slice(tickets, func(i, j int) bool {
-			return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
-		})
-		for i, t := range tickets {
-			if i >= 5 {
-				break
-			}
-			fmt.Printf("  %s: %s (%s)\n", t.ID, t.Title, t.Status)
-		}
+		fmt.Println("Use 'changes ticket submit " + ticket.ID + "' to submit for approval.")
 	}
 }
@@ -0,0 +1,465 @@
+package ticket
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// errAborted is returned when stdin closes before a required answer
+var errAborted = errors.New("input closed")
+
+// prompter asks questions on the terminal
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter() *prompter {
+	return &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+}
+
+// readLine returns the next line of input without its newline
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", errAborted
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ask returns the answer to a free-text question, or def when left blank
+func (p *prompter) ask(label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+	answer, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askRequired repeats the question until it gets a non-blank answer
+func (p *prompter) askRequired(label string) (string, error) {
+	for {
+		answer, err := p.ask(label, "")
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "  %s is required\n", label)
+	}
+}
+
+// choose picks one option by number or name
+func (p *prompter) choose(label string, options []string, def string) (string, error) {
+	fmt.Fprintf(p.out, "%s:\n", label)
+	for i, o := range options {
+		marker := " "
+		if o == def {
+			marker = "*"
+		}
+		fmt.Fprintf(p.out, "  %s %d) %s\n", marker, i+1, o)
+	}
+	for {
+		answer, err := p.ask("Choose", def)
+		if err != nil {
+			return "", err
+		}
+		if choice, ok := pickOption(options, answer); ok {
+			return choice, nil
+		}
+		fmt.Fprintf(p.out, "  Enter a number from 1 to %d or one of the names above\n", len(options))
+	}
+}
+
+// chooseMany picks any number of options as a comma-separated list of
+// numbers or names. A blank answer keeps defaults; "none" clears them.
+func (p *prompter) chooseMany(label string, options, defaults []string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, d := range defaults {
+		selected[d] = true
+	}
+
+	fmt.Fprintf(p.out, "%s (comma-separated, blank keeps the marked ones, \"none\" for none):\n", label)
+	for i, o := range options {
+		marker := " "
+		if selected[o] {
+			marker = "*"
+		}
+		fmt.Fprintf(p.out, "  %s %d) %s\n", marker, i+1, o)
+	}
+
+	for {
+		answer, err := p.ask("Choose", strings.Join(defaults, ","))
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" || strings.EqualFold(answer, "none") {
+			return []string{}, nil
+		}
+
+		var picked []string
+		valid := true
+		for _, part := range strings.Split(answer, ",") {
+			choice, ok := pickOption(options, strings.TrimSpace(part))
+			if !ok {
+				fmt.Fprintf(p.out, "  Unknown choice %q\n", strings.TrimSpace(part))
+				valid = false
+				break
+			}
+			picked = appendUnique(picked, choice)
+		}
+		if valid {
+			return picked, nil
+		}
+	}
+}
+
+// confirm asks a yes/no question
+func (p *prompter) confirm(label string, def bool) (bool, error) {
+	ok, err := ui.Confirm(p.in, p.out, label, def)
+	if err != nil {
+		return false, errAborted
+	}
+	return ok, nil
+}
+
+// editText collects multi-line text. It opens $VISUAL or $EDITOR on a
+// scratch file when one is set and otherwise reads lines until a lone ".".
+func (p *prompter) editText(label, initial string) (string, error) {
+	if editor := editorCommand(); editor != "" {
+		open, err := p.confirm(fmt.Sprintf("Write the %s in %s?", strings.ToLower(label), editor), true)
+		if err != nil {
+			return "", err
+		}
+		if open {
+			text, err := editInEditor(editor, label, initial)
+			if err == nil {
+				return text, nil
+			}
+			fmt.Fprintf(p.out, "  Editor failed (%v); enter the text here instead\n", err)
+		}
+	}
+
+	fmt.Fprintf(p.out, "%s (end with a line containing only \".\"", label)
+	if initial != "" {
+		fmt.Fprint(p.out, ", blank to keep the current text")
+	}
+	fmt.Fprintln(p.out, "):")
+
+	var lines []string
+	for {
+		line, err := p.in.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "." {
+			break
+		}
+		if err != nil {
+			if trimmed != "" {
+				lines = append(lines, trimmed)
+			}
+			break
+		}
+		lines = append(lines, trimmed)
+	}
+
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" {
+		return initial, nil
+	}
+	return text, nil
+}
+
+// editInEditor opens editor on a temporary file holding initial and returns
+// what was saved, without the comment lines explaining the file
+func editInEditor(editor, label, initial string) (string, error) {
+	f, err := os.CreateTemp("", "changes-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	header := fmt.Sprintf("# %s\n# Lines starting with # are ignored. Save and close the editor when done.\n", label)
+	if _, err := f.WriteString(header + initial); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	if err := runEditor(editor, f.Name()); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	var kept []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), nil
+}
+
+// editorCommand returns $VISUAL or $EDITOR, or "" when neither is set
+func editorCommand() string {
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	return os.Getenv("EDITOR")
+}
+
+// runEditor opens path in editor and waits for it to exit
+func runEditor(editor, path string) error {
+	// EDITOR may carry arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// pickOption resolves a 1-based number or a case-insensitive name
+func pickOption(options []string, answer string) (string, bool) {
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n >= 1 && n <= len(options) {
+			return options[n-1], true
+		}
+		return "", false
+	}
+	for _, o := range options {
+		if strings.EqualFold(o, answer) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, have := range list {
+			if have == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// suggestedApprovalTypes returns the approvals a change at this risk level
+// normally needs
+func suggestedApprovalTypes(risk string) []string {
+	types := []string{string(models.ApprovalTypeOperations), string(models.ApprovalTypeIT)}
+	switch models.RiskLevel(risk) {
+	case models.RiskLevelHigh:
+		types = append(types, string(models.ApprovalTypeSecurity))
+	case models.RiskLevelCritical:
+		types = append(types, string(models.ApprovalTypeSecurity), string(models.ApprovalTypeRisk),
+			string(models.ApprovalTypeChangeManagementBoard))
+	}
+	return types
+}
+
+func runInteractiveCreate(cmd *cobra.Command) {
+	ticket, submit, err := promptTicket(cmd, newPrompter())
+	if errors.Is(err, errAborted) {
+		fmt.Println()
+		fmt.Println("Cancelled")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if ticket == nil {
+		fmt.Println("Cancelled")
+		return
+	}
+
+	ticketID, err := getNextTicketNumber()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating ticket ID: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	saved := newTicketData(ticketID, submit)
+	saved.Title = ticket.Title
+	saved.Description = ticket.Description
+	saved.Priority = ticket.Priority
+	saved.Risk = ticket.Risk
+	saved.Type = ticket.Type
+	saved.Industry = ticket.Industry
+	saved.ComplianceFrameworks = ticket.ComplianceFrameworks
+	saved.AffectedSystems = ticket.AffectedSystems
+	saved.RollbackPlan = ticket.RollbackPlan
+	saved.TestingPlan = ticket.TestingPlan
+	saved.ApprovalsRequired = ticket.ApprovalsRequired
+
+	if err := saveTicket(saved); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	fmt.Println()
+	printCreated(saved)
+}
+
+// promptTicket walks through the ticket fields, using any flags given as
+// defaults, and ends with a summary to confirm. It returns a nil ticket when
+// the user cancels at the summary.
+func promptTicket(cmd *cobra.Command, p *prompter) (*CreateTicketData, bool, error) {
+	description, _ := cmd.Flags().GetString("description")
+	priority, _ := cmd.Flags().GetString("priority")
+	risk, _ := cmd.Flags().GetString("risk")
+	industry, _ := cmd.Flags().GetString("industry")
+	compliance, _ := cmd.Flags().GetStringSlice("compliance")
+	approvalTypes, _ := cmd.Flags().GetStringSlice("approval-types")
+	affectedSystems, _ := cmd.Flags().GetStringSlice("affected-systems")
+	changeType, _ := cmd.Flags().GetString("change-type")
+	rollback, _ := cmd.Flags().GetString("rollback")
+	testing, _ := cmd.Flags().GetString("testing")
+	submit, _ := cmd.Flags().GetBool("submit")
+
+	fmt.Fprintln(p.out, "Create a change ticket")
+	fmt.Fprintln(p.out, "======================")
+	fmt.Fprintln(p.out, "Press Enter to accept the value in [brackets]. Ctrl-D cancels.")
+	fmt.Fprintln(p.out)
+
+	t := &CreateTicketData{}
+	var err error
+
+	if t.Title, err = p.askRequired("Title"); err != nil {
+		return nil, false, err
+	}
+	if t.Description, err = p.editText("Description", description); err != nil {
+		return nil, false, err
+	}
+	if t.Type, err = p.ask("Change type (e.g. standard, enhancement, infrastructure)", changeType); err != nil {
+		return nil, false, err
+	}
+	fmt.Fprintln(p.out)
+
+	// Industry drives which compliance frameworks are suggested
+	industries := industryValues()
+	if industry == "" {
+		industry = viper.GetString("default_industry")
+	}
+	if industry == "" {
+		industry = string(models.IndustryIT)
+	}
+	if t.Industry, err = p.choose("Industry", industries, industry); err != nil {
+		return nil, false, err
+	}
+
+	suggested := compliance
+	if len(suggested) == 0 {
+		suggested = defaultCompliance(t.Industry)
+	}
+	if len(suggested) == 0 {
+		for _, f := range models.IndustryType(t.Industry).SuggestedFrameworks() {
+			suggested = append(suggested, string(f))
+		}
+	}
+	frameworks := frameworkValues()
+	if t.ComplianceFrameworks, err = p.chooseMany("Compliance frameworks", frameworks, suggested); err != nil {
+		return nil, false, err
+	}
+	fmt.Fprintln(p.out)
+
+	priorities := priorityValues()
+	if t.Priority, err = p.choose("Priority", priorities, priority); err != nil {
+		return nil, false, err
+	}
+	risks := riskValues()
+	if t.Risk, err = p.choose("Risk level", risks, risk); err != nil {
+		return nil, false, err
+	}
+	fmt.Fprintln(p.out)
+
+	systems, err := p.ask("Affected systems (comma-separated)", strings.Join(affectedSystems, ", "))
+	if err != nil {
+		return nil, false, err
+	}
+	t.AffectedSystems = []string{}
+	for _, s := range strings.Split(systems, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			t.AffectedSystems = appendUnique(t.AffectedSystems, s)
+		}
+	}
+
+	approvalDefaults := approvalTypes
+	if len(approvalDefaults) == 0 {
+		approvalDefaults = suggestedApprovalTypes(t.Risk)
+	}
+	approvalOptions := approvalTypeValues()
+	if t.ApprovalsRequired, err = p.chooseMany("Required approvals", approvalOptions, approvalDefaults); err != nil {
+		return nil, false, err
+	}
+	fmt.Fprintln(p.out)
+
+	if t.RollbackPlan, err = p.editText("Rollback plan", rollback); err != nil {
+		return nil, false, err
+	}
+	if t.TestingPlan, err = p.editText("Testing plan", testing); err != nil {
+		return nil, false, err
+	}
+
+	// Summary
+	fmt.Fprintln(p.out)
+	view := viewFromLocal(t, 0)
+	view.Number = "(new)"
+	view.Status = "draft"
+	view.Source = "not saved yet"
+	renderTicket(p.out, view)
+	fmt.Fprintln(p.out)
+
+	highRisk := t.Risk == string(models.RiskLevelHigh) || t.Risk == string(models.RiskLevelCritical)
+	if highRisk && (t.RollbackPlan == "" || t.TestingPlan == "") {
+		fmt.Fprintln(p.out, "Warning: high and critical risk changes are usually denied without rollback and testing plans.")
+		fmt.Fprintln(p.out)
+	}
+
+	def := "draft"
+	if submit {
+		def = "submit"
+	}
+	action, err := p.choose("Save this ticket", []string{"draft", "submit", "cancel"}, def)
+	if err != nil {
+		return nil, false, err
+	}
+	switch action {
+	case "cancel":
+		return nil, false, nil
+	case "submit":
+		return t, true, nil
+	}
+	return t, false, nil
+}
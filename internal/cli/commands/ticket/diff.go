@@ -0,0 +1,236 @@
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <ticket-id>",
+	Short: "Show how a local ticket file differs from the API",
+	Long: `Compare a ticket's local JSON file with its current version in the API,
+field by field, before sending the local edits with 'changes ticket import
+--update' or 'changes ticket sync'.
+
+Lines marked - are the API's, lines marked + are the local file's. Long text
+fields are compared line by line and lists item by item. Only the fields
+import and sync carry are compared: title, description, priority, risk, type,
+compliance frameworks, affected systems, rollback and testing plans, and
+required approvals.
+
+Output is colored on a terminal unless --no-color is given or NO_COLOR is set.
+
+Examples:
+  # Review local edits before importing them
+  changes ticket diff CHG-2025-00001
+
+  # Fail a script when the file and the API disagree
+  changes ticket diff CHG-2025-00001 --exit-code`,
+	Args: cobra.ExactArgs(1),
+	Run:  runDiff,
+}
+
+func init() {
+	diffCmd.Flags().String("dir", "", "Directory containing ticket JSON files (default: ./tickets)")
+	diffCmd.Flags().Bool("no-color", false, "Don't color the output")
+	diffCmd.Flags().Bool("exit-code", false, "Exit with status 1 when the ticket differs, like git diff --exit-code")
+}
+
+// fieldDifference is one synced field that differs, as shown with
+// --output json|yaml
+type fieldDifference struct {
+	Field  string      `json:"field"`
+	Local  interface{} `json:"local"`
+	Remote interface{} `json:"remote"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	exitCode, _ := cmd.Flags().GetBool("exit-code")
+	if dir == "" {
+		dir = getTicketsDir()
+	}
+
+	id := strings.TrimSuffix(filepath.Base(args[0]), ".json")
+	path := filepath.Join(dir, id+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = exitcode.Wrap(exitcode.NotFound, fmt.Errorf("no local file for %s in %s", id, dir))
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	var local CreateTicketData
+	if err := json.Unmarshal(data, &local); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to parse %s: %v\n", path, err)
+		os.Exit(exitcode.Validation)
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	remote, err := remoteForLocal(client, dir, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var diffs []fieldDifference
+	for _, f := range syncedFields(&local, remote) {
+		if !reflect.DeepEqual(f.Local, f.Remote) {
+			diffs = append(diffs, fieldDifference{f.Name, f.Local, f.Remote})
+		}
+	}
+
+	if output.Structured() {
+		if diffs == nil {
+			diffs = []fieldDifference{}
+		}
+		output.Print(map[string]interface{}{
+			"ticket":         remote.TicketNumber,
+			"local_file":     path,
+			"remote_id":      remote.ID,
+			"remote_version": remote.Version,
+			"differences":    diffs,
+		})
+	} else {
+		printDiff(path, remote, diffs, useColor(noColor))
+	}
+
+	if exitCode && len(diffs) > 0 {
+		os.Exit(exitcode.Error)
+	}
+}
+
+// remoteForLocal fetches the API ticket a local file stands for: the one sync
+// linked it to, or else the ticket with the same number
+func remoteForLocal(client *apiclient.Client, dir, id string) (*models.Ticket, error) {
+	if state, err := loadSyncState(dir); err == nil {
+		if link, ok := state.Tickets[id]; ok && link.RemoteID != uuid.Nil {
+			return client.GetTicket(link.RemoteID)
+		}
+	}
+	return client.FindTicket(id)
+}
+
+func printDiff(path string, remote *models.Ticket, diffs []fieldDifference, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("%s matches the API (version %d)\n", path, remote.Version)
+		return
+	}
+
+	fmt.Println(paint("1", fmt.Sprintf("--- %s in the API (version %d, updated %s)", remote.TicketNumber, remote.Version, remote.UpdatedAt.Local().Format("2006-01-02 15:04"))))
+	fmt.Println(paint("1", "+++ "+path))
+	for _, d := range diffs {
+		fmt.Println()
+		fmt.Println(paint("36", d.Field))
+		for _, l := range diffLines(diffValueLines(d.Remote), diffValueLines(d.Local)) {
+			switch l.op {
+			case '-':
+				fmt.Println(paint("31", "- "+l.text))
+			case '+':
+				fmt.Println(paint("32", "+ "+l.text))
+			default:
+				fmt.Println("  " + l.text)
+			}
+		}
+	}
+
+	noun := "fields differ"
+	if len(diffs) == 1 {
+		noun = "field differs"
+	}
+	fmt.Printf("\n%d %s. 'changes ticket import %s --update' or 'changes ticket sync' sends the local version.\n", len(diffs), noun, filepath.Base(path))
+}
+
+// diffValueLines splits a synced field's value into the lines compared: a
+// string's lines or a list's items
+func diffValueLines(v interface{}) []string {
+	switch t := v.(type) {
+	case []string:
+		return t
+	case string:
+		if t == "" {
+			return nil
+		}
+		return strings.Split(strings.TrimRight(t, "\n"), "\n")
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+// diffLine is one line of a line diff: ' ' kept, '-' only in the old
+// version, '+' only in the new
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines diffs two versions line by line through their longest common
+// subsequence. Ticket fields are short, so the quadratic table is fine.
+func diffLines(old, new []string) []diffLine {
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			lines = append(lines, diffLine{' ', old[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', old[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', new[j]})
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		lines = append(lines, diffLine{'-', old[i]})
+	}
+	for ; j < len(new); j++ {
+		lines = append(lines, diffLine{'+', new[j]})
+	}
+	return lines
+}
+
+// useColor reports whether to color output: on a terminal, unless turned off
+// with --no-color or NO_COLOR
+func useColor(noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+}
@@ -1,9 +1,23 @@
 package ticket
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var editCmd = &cobra.Command{
@@ -11,15 +25,26 @@ var editCmd = &cobra.Command{
 	Short: "Edit a change ticket",
 	Long: `Edit an existing change ticket.
 
+Without field flags the ticket opens in $VISUAL or $EDITOR as YAML (or JSON
+with --format json). Save and close the editor to review the changed fields,
+then confirm; only those fields are sent. If someone else updated the ticket
+in the meantime the edit is refused and your file is kept so nothing is lost.
+
+--set takes any field from the editor document. List fields accept comma
+separated values, and += / -= add or remove entries.
+
 Only tickets in draft or update_requested status can be edited.
 
 Examples:
-  # Edit interactively
+  # Edit in your editor
   changes ticket edit CHG-2025-00001
 
   # Update specific fields
   changes ticket edit CHG-2025-00001 --priority urgent --risk high
 
+  # Any field, without an editor
+  changes ticket edit CHG-2025-00001 --set scheduled_start=2025-07-01T22:00:00Z --set labels+=network
+
   # Add to description
   changes ticket edit CHG-2025-00001 --add-description "Additional context..."`,
 	Args: cobra.ExactArgs(1),
@@ -27,6 +52,9 @@ Examples:
 }
 
 func init() {
+	editCmd.Flags().StringArray("set", nil, "Set a field (field=value, field+=value, field-=value; repeatable)")
+	editCmd.Flags().String("format", "yaml", "Editor document format (yaml, json)")
+	editCmd.Flags().BoolP("yes", "y", false, "Save without asking for confirmation")
 	editCmd.Flags().String("title", "", "Update title")
 	editCmd.Flags().String("description", "", "Replace description")
 	editCmd.Flags().String("add-description", "", "Append to description")
@@ -37,22 +65,558 @@ func init() {
 	editCmd.Flags().String("impact", "", "Update impact description")
 	editCmd.Flags().String("rollback", "", "Update rollback plan")
 	editCmd.Flags().String("testing", "", "Update testing plan")
-	editCmd.Flags().Bool("interactive", true, "Use interactive mode")
+}
+
+// ticketDocument is the editable part of a ticket, as written to the editor
+// and addressed by --set
+type ticketDocument struct {
+	Title                       string     `yaml:"title" json:"title"`
+	Description                 string     `yaml:"description" json:"description"`
+	Priority                    string     `yaml:"priority" json:"priority"`
+	RiskLevel                   string     `yaml:"risk_level" json:"risk_level"`
+	ChangeType                  string     `yaml:"change_type" json:"change_type"`
+	ComplianceFrameworks        []string   `yaml:"compliance_frameworks" json:"compliance_frameworks"`
+	ComplianceNotes             string     `yaml:"compliance_notes" json:"compliance_notes"`
+	AffectedSystems             []string   `yaml:"affected_systems" json:"affected_systems"`
+	AffectedDataTypes           []string   `yaml:"affected_data_types" json:"affected_data_types"`
+	ImpactDescription           string     `yaml:"impact_description" json:"impact_description"`
+	RollbackPlan                string     `yaml:"rollback_plan" json:"rollback_plan"`
+	TestingPlan                 string     `yaml:"testing_plan" json:"testing_plan"`
+	RequiresApprovalTypes       []string   `yaml:"requires_approval_types" json:"requires_approval_types"`
+	RequestedImplementationDate *time.Time `yaml:"requested_implementation_date" json:"requested_implementation_date"`
+	ScheduledStart              *time.Time `yaml:"scheduled_start" json:"scheduled_start"`
+	ScheduledEnd                *time.Time `yaml:"scheduled_end" json:"scheduled_end"`
+	ApprovalDeadline            *time.Time `yaml:"approval_deadline" json:"approval_deadline"`
+	Labels                      []string   `yaml:"labels" json:"labels"`
+	StoryPoints                 *int       `yaml:"story_points" json:"story_points"`
+	TimeEstimateHours           *float64   `yaml:"time_estimate_hours" json:"time_estimate_hours"`
+	ExternalReference           string     `yaml:"external_reference" json:"external_reference"`
+	IsConfidential              bool       `yaml:"is_confidential" json:"is_confidential"`
+}
+
+// fieldChange is one edited field, for the review before saving
+type fieldChange struct {
+	Field string
+	Old   string
+	New   string
 }
 
 func runEdit(cmd *cobra.Command, args []string) {
-	ticketNumber := args[0]
-	interactive, _ := cmd.Flags().GetBool("interactive")
+	format, _ := cmd.Flags().GetString("format")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	if format != "yaml" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use yaml or json)\n", format)
+		os.Exit(exitcode.Validation)
+	}
+	sets, err := editSets(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.Validation)
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	ticket, err := client.FindTicket(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if !ticket.CanEdit() {
+		fmt.Fprintf(os.Stderr, "Error: %s is %s; only draft or update_requested tickets can be edited\n", ticket.TicketNumber, ticket.Status)
+		os.Exit(exitcode.Conflict)
+	}
+
+	original := documentFromTicket(ticket)
+	var edited *ticketDocument
+	var draftPath string
+	if len(sets) > 0 {
+		edited, err = applySets(original, sets)
+	} else {
+		edited, draftPath, err = editDocument(original, ticket, format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		keepDraft(draftPath)
+		os.Exit(exitcode.For(err))
+	}
+
+	input, changes, err := buildUpdate(original, edited)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		keepDraft(draftPath)
+		os.Exit(exitcode.For(err))
+	}
+	if len(changes) == 0 {
+		fmt.Println("No changes.")
+		removeDraft(draftPath)
+		return
+	}
 
-	// TODO: Check if ticket can be edited
+	fmt.Printf("Changes to %s:\n", ticket.TicketNumber)
+	for _, c := range changes {
+		fmt.Printf("  %-30s %s -> %s\n", c.Field, c.Old, c.New)
+	}
+	fmt.Println()
 
-	if interactive {
-		fmt.Printf("Opening interactive editor for %s...\n", ticketNumber)
-		fmt.Println("(TUI implementation pending)")
+	if !yes && len(sets) == 0 {
+		ok, err := newPrompter().confirm(fmt.Sprintf("Save %d change(s)?", len(changes)), true)
+		if err != nil || !ok {
+			fmt.Println("Aborted")
+			keepDraft(draftPath)
+			return
+		}
+	}
+
+	input.Version = &ticket.Version
+	updated, err := client.UpdateTicket(ticket.ID, input)
+	if apiclient.IsConflict(err) {
+		fmt.Fprintf(os.Stderr, "Error: %s was changed by someone else since version %d. Run edit again to start from the latest version.\n", ticket.TicketNumber, ticket.Version)
+		keepDraft(draftPath)
+		os.Exit(exitcode.Conflict)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to update ticket: %v\n", err)
+		keepDraft(draftPath)
+		os.Exit(exitcode.For(err))
+	}
+	removeDraft(draftPath)
+	if output.Structured() {
+		output.Print(updated)
 		return
 	}
+	fmt.Printf("Updated %s (version %d)\n", updated.TicketNumber, updated.Version)
+}
+
+// editSets turns --set and the field shortcut flags into set expressions
+func editSets(cmd *cobra.Command) ([]string, error) {
+	sets, _ := cmd.Flags().GetStringArray("set")
+
+	shortcuts := map[string]string{
+		"title":       "title",
+		"description": "description",
+		"priority":    "priority",
+		"risk":        "risk_level",
+		"impact":      "impact_description",
+		"rollback":    "rollback_plan",
+		"testing":     "testing_plan",
+	}
+	names := make([]string, 0, len(shortcuts))
+	for flag := range shortcuts {
+		names = append(names, flag)
+	}
+	sort.Strings(names)
+	for _, flag := range names {
+		if cmd.Flags().Changed(flag) {
+			value, _ := cmd.Flags().GetString(flag)
+			sets = append(sets, shortcuts[flag]+"="+value)
+		}
+	}
+
+	if cmd.Flags().Changed("add-description") {
+		if cmd.Flags().Changed("description") {
+			return nil, errors.New("use either --description or --add-description")
+		}
+		value, _ := cmd.Flags().GetString("add-description")
+		sets = append(sets, "description+="+value)
+	}
+	if add, _ := cmd.Flags().GetStringSlice("add-systems"); len(add) > 0 {
+		sets = append(sets, "affected_systems+="+strings.Join(add, ","))
+	}
+	if remove, _ := cmd.Flags().GetStringSlice("remove-systems"); len(remove) > 0 {
+		sets = append(sets, "affected_systems-="+strings.Join(remove, ","))
+	}
+	return sets, nil
+}
+
+func documentFromTicket(t *models.Ticket) *ticketDocument {
+	return &ticketDocument{
+		Title:                       t.Title,
+		Description:                 t.Description,
+		Priority:                    string(t.Priority),
+		RiskLevel:                   string(t.RiskLevel),
+		ChangeType:                  deref(t.ChangeType),
+		ComplianceFrameworks:        fromFrameworks(t.ComplianceFrameworks),
+		ComplianceNotes:             deref(t.ComplianceNotes),
+		AffectedSystems:             nonNil(t.AffectedSystems),
+		AffectedDataTypes:           nonNil(t.AffectedDataTypes),
+		ImpactDescription:           deref(t.ImpactDescription),
+		RollbackPlan:                deref(t.RollbackPlan),
+		TestingPlan:                 deref(t.TestingPlan),
+		RequiresApprovalTypes:       fromApprovalTypes(t.RequiresApprovalTypes),
+		RequestedImplementationDate: t.RequestedImplementationDate,
+		ScheduledStart:              t.ScheduledStart,
+		ScheduledEnd:                t.ScheduledEnd,
+		ApprovalDeadline:            t.ApprovalDeadline,
+		Labels:                      nonNil(t.Labels),
+		StoryPoints:                 t.StoryPoints,
+		TimeEstimateHours:           t.TimeEstimateHours,
+		ExternalReference:           deref(t.ExternalReference),
+		IsConfidential:              t.IsConfidential,
+	}
+}
+
+// editDocument round-trips the document through the user's editor until it
+// parses or they give up. The file is left in place so it can be kept if the
+// update fails; the returned path is where it is.
+func editDocument(doc *ticketDocument, t *models.Ticket, format string) (*ticketDocument, string, error) {
+	editor := editorCommand()
+	if editor == "" {
+		editor = "vi"
+	}
+
+	var body []byte
+	var err error
+	if format == "json" {
+		body, err = json.MarshalIndent(doc, "", "  ")
+	} else {
+		body, err = yaml.Marshal(doc)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := os.CreateTemp("", fmt.Sprintf("changes-%s-*.%s", t.TicketNumber, format))
+	if err != nil {
+		return nil, "", err
+	}
+	path := f.Name()
+	if format == "yaml" {
+		fmt.Fprintf(f, "# Editing %s (version %d, %s)\n", t.TicketNumber, t.Version, t.Status)
+		fmt.Fprintln(f, "# Lines starting with # are ignored. Save and close the editor when done.")
+	}
+	f.Write(body)
+	f.Close()
+
+	p := newPrompter()
+	for {
+		if err := runEditor(editor, path); err != nil {
+			return nil, path, fmt.Errorf("editor failed: %w", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, path, err
+		}
+
+		edited := &ticketDocument{}
+		if format == "json" {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(edited)
+		} else {
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			err = dec.Decode(edited)
+		}
+		if err == nil {
+			return edited, path, nil
+		}
+
+		fmt.Fprintf(os.Stderr, "The ticket does not parse: %v\n", err)
+		again, perr := p.confirm("Re-open the editor?", true)
+		if perr != nil || !again {
+			return nil, path, errors.New("edit abandoned")
+		}
+	}
+}
+
+// applySets applies field=value, field+=value and field-=value expressions.
+// The document goes through a generic map so every field can be addressed by
+// its editor name and the values are type-checked on the way back.
+func applySets(doc *ticketDocument, sets []string) (*ticketDocument, error) {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, set := range sets {
+		field, op, value, ok := parseSet(set)
+		if !ok {
+			return nil, fmt.Errorf("invalid --set %q: use field=value", set)
+		}
+		current, known := fields[field]
+		if !known {
+			return nil, fmt.Errorf("unknown field %q (fields: %s)", field, strings.Join(documentFields(), ", "))
+		}
+
+		_, isList := current.([]interface{})
+		if isList || isListField(field) {
+			fields[field] = applyListSet(current, op, value)
+			continue
+		}
+
+		switch op {
+		case "=":
+			var parsed interface{}
+			if err := yaml.Unmarshal([]byte(value), &parsed); err != nil || parsed == nil {
+				parsed = value
+			}
+			// Text fields stay text even when the value looks like a number
+			if _, isText := current.(string); isText {
+				parsed = value
+			}
+			fields[field] = parsed
+		case "+=":
+			text, _ := current.(string)
+			if text != "" {
+				text += "\n\n"
+			}
+			fields[field] = text + value
+		default:
+			return nil, fmt.Errorf("%s is not a list; -= only removes list entries", field)
+		}
+	}
 
-	// TODO: Implement API call to update ticket
-	fmt.Printf("Updating ticket %s...\n", ticketNumber)
-	fmt.Println("Ticket updated successfully")
+	data, err = yaml.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	edited := &ticketDocument{}
+	if err := yaml.Unmarshal(data, edited); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	return edited, nil
+}
+
+// parseSet splits field=value, field+=value or field-=value
+func parseSet(set string) (field, op, value string, ok bool) {
+	i := strings.Index(set, "=")
+	if i <= 0 {
+		return "", "", "", false
+	}
+	field, op, value = set[:i], "=", set[i+1:]
+	if strings.HasSuffix(field, "+") || strings.HasSuffix(field, "-") {
+		op = field[len(field)-1:] + "="
+		field = field[:len(field)-1]
+	}
+	return strings.TrimSpace(field), op, value, field != ""
+}
+
+func applyListSet(current interface{}, op, value string) []interface{} {
+	var items []interface{}
+	if list, ok := current.([]interface{}); ok {
+		items = list
+	}
+
+	var values []string
+	for _, v := range strings.Split(strings.Trim(value, "[]"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	switch op {
+	case "+=":
+		for _, v := range values {
+			found := false
+			for _, item := range items {
+				if fmt.Sprint(item) == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				items = append(items, v)
+			}
+		}
+		return items
+	case "-=":
+		kept := []interface{}{}
+		for _, item := range items {
+			remove := false
+			for _, v := range values {
+				if fmt.Sprint(item) == v {
+					remove = true
+					break
+				}
+			}
+			if !remove {
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	}
+
+	out := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+	return out
+}
+
+// documentFields lists the editor names of the document's fields
+func documentFields() []string {
+	t := reflect.TypeOf(ticketDocument{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, t.Field(i).Tag.Get("yaml"))
+	}
+	return names
+}
+
+func isListField(field string) bool {
+	t := reflect.TypeOf(ticketDocument{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") == field {
+			return t.Field(i).Type.Kind() == reflect.Slice
+		}
+	}
+	return false
+}
+
+// buildUpdate compares the documents and fills in only the fields that
+// changed. The API can't clear list, date or number fields, so emptying one
+// is an error rather than a silent no-op.
+func buildUpdate(old, new *ticketDocument) (models.UpdateTicketInput, []fieldChange, error) {
+	var input models.UpdateTicketInput
+	var changes []fieldChange
+	var cleared []string
+
+	text := func(name, a, b string, set func(*string)) {
+		if a == b {
+			return
+		}
+		changes = append(changes, fieldChange{name, summarize(a), summarize(b)})
+		set(&b)
+	}
+	list := func(name string, a, b []string, set func([]string)) {
+		if reflect.DeepEqual(nonNil(a), nonNil(b)) {
+			return
+		}
+		if len(b) == 0 {
+			cleared = append(cleared, name)
+			return
+		}
+		changes = append(changes, fieldChange{name, summarizeList(a), summarizeList(b)})
+		set(b)
+	}
+	date := func(name string, a, b *time.Time, set func(*time.Time)) {
+		if a == nil && b == nil || a != nil && b != nil && a.Equal(*b) {
+			return
+		}
+		if b == nil {
+			cleared = append(cleared, name)
+			return
+		}
+		changes = append(changes, fieldChange{name, formatOptionalTime(a), formatOptionalTime(b)})
+		set(b)
+	}
+
+	text("title", old.Title, new.Title, func(v *string) { input.Title = v })
+	text("description", old.Description, new.Description, func(v *string) { input.Description = v })
+	text("priority", old.Priority, new.Priority, func(v *string) {
+		p := models.TicketPriority(strings.ToLower(*v))
+		input.Priority = &p
+	})
+	text("risk_level", old.RiskLevel, new.RiskLevel, func(v *string) {
+		r := models.RiskLevel(strings.ToLower(*v))
+		input.RiskLevel = &r
+	})
+	text("change_type", old.ChangeType, new.ChangeType, func(v *string) { input.ChangeType = v })
+	list("compliance_frameworks", old.ComplianceFrameworks, new.ComplianceFrameworks, func(v []string) { input.ComplianceFrameworks = toFrameworks(v) })
+	text("compliance_notes", old.ComplianceNotes, new.ComplianceNotes, func(v *string) { input.ComplianceNotes = v })
+	list("affected_systems", old.AffectedSystems, new.AffectedSystems, func(v []string) { input.AffectedSystems = v })
+	list("affected_data_types", old.AffectedDataTypes, new.AffectedDataTypes, func(v []string) { input.AffectedDataTypes = v })
+	text("impact_description", old.ImpactDescription, new.ImpactDescription, func(v *string) { input.ImpactDescription = v })
+	text("rollback_plan", old.RollbackPlan, new.RollbackPlan, func(v *string) { input.RollbackPlan = v })
+	text("testing_plan", old.TestingPlan, new.TestingPlan, func(v *string) { input.TestingPlan = v })
+	list("requires_approval_types", old.RequiresApprovalTypes, new.RequiresApprovalTypes, func(v []string) { input.RequiresApprovalTypes = toApprovalTypes(v) })
+	date("requested_implementation_date", old.RequestedImplementationDate, new.RequestedImplementationDate, func(v *time.Time) { input.RequestedImplementationDate = v })
+	date("scheduled_start", old.ScheduledStart, new.ScheduledStart, func(v *time.Time) { input.ScheduledStart = v })
+	date("scheduled_end", old.ScheduledEnd, new.ScheduledEnd, func(v *time.Time) { input.ScheduledEnd = v })
+	date("approval_deadline", old.ApprovalDeadline, new.ApprovalDeadline, func(v *time.Time) { input.ApprovalDeadline = v })
+	list("labels", old.Labels, new.Labels, func(v []string) { input.Labels = v })
+	text("external_reference", old.ExternalReference, new.ExternalReference, func(v *string) { input.ExternalReference = v })
+
+	if !reflect.DeepEqual(old.StoryPoints, new.StoryPoints) {
+		if new.StoryPoints == nil {
+			cleared = append(cleared, "story_points")
+		} else {
+			changes = append(changes, fieldChange{"story_points", formatOptionalInt(old.StoryPoints), formatOptionalInt(new.StoryPoints)})
+			input.StoryPoints = new.StoryPoints
+		}
+	}
+	if !reflect.DeepEqual(old.TimeEstimateHours, new.TimeEstimateHours) {
+		if new.TimeEstimateHours == nil {
+			cleared = append(cleared, "time_estimate_hours")
+		} else {
+			changes = append(changes, fieldChange{"time_estimate_hours", formatOptionalFloat(old.TimeEstimateHours), formatOptionalFloat(new.TimeEstimateHours)})
+			input.TimeEstimateHours = new.TimeEstimateHours
+		}
+	}
+	if old.IsConfidential != new.IsConfidential {
+		changes = append(changes, fieldChange{"is_confidential", fmt.Sprint(old.IsConfidential), fmt.Sprint(new.IsConfidential)})
+		input.IsConfidential = &new.IsConfidential
+	}
+
+	if len(cleared) > 0 {
+		return input, nil, fmt.Errorf("%s cannot be cleared from the CLI; use the web app", strings.Join(cleared, ", "))
+	}
+	return input, changes, nil
+}
+
+// summarize shortens a text value for the change review
+func summarize(s string) string {
+	if s == "" {
+		return "(empty)"
+	}
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return fmt.Sprintf("%q (%d lines)", truncate(s[:i], 30), strings.Count(s, "\n")+1)
+	}
+	return fmt.Sprintf("%q", truncate(s, 40))
+}
+
+func summarizeList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "(none)"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatOptionalInt(n *int) string {
+	if n == nil {
+		return "(none)"
+	}
+	return fmt.Sprint(*n)
+}
+
+func formatOptionalFloat(n *float64) string {
+	if n == nil {
+		return "(none)"
+	}
+	return fmt.Sprint(*n)
+}
+
+// keepDraft tells the user where their edits are when they were not saved
+func keepDraft(path string) {
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Your edits are kept in %s\n", filepath.Clean(path))
+	}
+}
+
+func removeDraft(path string) {
+	if path != "" {
+		os.Remove(path)
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
 }
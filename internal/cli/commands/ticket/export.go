@@ -4,23 +4,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/models"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export [ticket-id...]",
-	Short: "Export tickets from the changes system to JSON files",
-	Long: `Export change tickets from the changes management API to local JSON files.
+	Short: "Export tickets from the changes system to JSON, PDF, Markdown or HTML",
+	Long: `Export change tickets from the changes management API to local files.
 
-This command fetches tickets from the API and saves them as JSON files locally.
-Useful for backup, migration, or offline analysis.
+This command fetches tickets from the API and saves them locally as JSON,
+PDF, Markdown or HTML. PDF, Markdown and HTML documents include every
+comment, the approval status, attachment links and the ticket's audit
+history, and keep the formatting of the description and plans. Useful for backup, migration, offline analysis,
+or attaching a change record to an audit.
 
 Examples:
   # Export a single ticket
@@ -36,42 +42,54 @@ Examples:
   changes ticket export --all --dir /path/to/backup
 
   # Export tickets matching a filter
-  changes ticket export --status submitted,in_review
+  changes ticket export --all --status submitted,in_review
 
   # Export as PDF documents
   changes ticket export CHG-2025-00001 --format pdf
 
-  # Export all tickets as both JSON and PDF
+  # Export readable Markdown and HTML documents
+  changes ticket export CHG-2025-00001 --format md,html
+
+  # Export all tickets in every format
   changes ticket export --all --format all`,
 	Run: runExport,
 }
 
+// exportFormats are the file formats export writes, in the order "all"
+// writes them
+var exportFormats = []string{"json", "pdf", "md", "html"}
+
 func init() {
 	exportCmd.Flags().Bool("all", false, "Export all tickets from the API")
 	exportCmd.Flags().String("dir", "", "Output directory (default: ./tickets)")
 	exportCmd.Flags().StringSlice("status", []string{}, "Filter by status when using --all")
-	exportCmd.Flags().String("format", "json", "Output format: json, pdf, or all")
+	exportCmd.Flags().StringSlice("format", []string{"json"}, "Output formats: json, pdf, md, html, or all")
 	exportCmd.Flags().Bool("overwrite", false, "Overwrite existing files")
-	exportCmd.Flags().String("api-url", "", "API URL (default: from config or https://api.changes.afterdarksys.com)")
 }
 
 func runExport(cmd *cobra.Command, args []string) {
 	exportAll, _ := cmd.Flags().GetBool("all")
 	outputDir, _ := cmd.Flags().GetString("dir")
 	statusFilter, _ := cmd.Flags().GetStringSlice("status")
-	format, _ := cmd.Flags().GetString("format")
+	formatFlag, _ := cmd.Flags().GetStringSlice("format")
 	overwrite, _ := cmd.Flags().GetBool("overwrite")
-	apiURL, _ := cmd.Flags().GetString("api-url")
 
-	// Determine API URL
-	if apiURL == "" {
-		apiURL = viper.GetString("api.url")
+	formats, err := parseExportFormats(formatFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.Validation)
 	}
-	if apiURL == "" {
-		apiURL = os.Getenv("CHANGES_API_URL")
+
+	if !exportAll && len(args) == 0 {
+		fmt.Println("Usage: changes ticket export [ticket-id...] or changes ticket export --all")
+		fmt.Println("Run 'changes ticket export --help' for more information.")
+		os.Exit(exitcode.Usage)
 	}
-	if apiURL == "" {
-		apiURL = "https://api.changes.afterdarksys.com"
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
 	}
 
 	// Get output directory
@@ -82,25 +100,17 @@ func runExport(cmd *cobra.Command, args []string) {
 	// Ensure output directory exists
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
-	var ticketIDs []string
-
+	ticketIDs := args
 	if exportAll {
-		// Fetch ticket list from API
-		ids, err := fetchTicketIDsFromAPI(apiURL, statusFilter)
+		ids, err := fetchTicketNumbers(client, statusFilter)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error fetching ticket list: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
 		ticketIDs = ids
-	} else if len(args) > 0 {
-		ticketIDs = args
-	} else {
-		fmt.Println("Usage: changes ticket export [ticket-id...] or changes ticket export --all")
-		fmt.Println("Run 'changes ticket export --help' for more information.")
-		os.Exit(1)
 	}
 
 	if len(ticketIDs) == 0 {
@@ -116,335 +126,154 @@ func runExport(cmd *cobra.Command, args []string) {
 	for _, ticketID := range ticketIDs {
 		fmt.Printf("Exporting %s... ", ticketID)
 
-		// Fetch ticket from API
-		ticketData, err := fetchTicketFromAPI(apiURL, ticketID)
+		t, err := client.FindTicket(ticketID)
 		if err != nil {
 			fmt.Printf("FAILED (%v)\n", err)
 			failed++
 			continue
 		}
 
-		// Export JSON
-		if format == "json" || format == "all" {
-			jsonFile := filepath.Join(outputDir, ticketID+".json")
+		var written, existing, errs []string
+		var view *ticketView
+		for _, format := range formats {
+			path := filepath.Join(outputDir, t.TicketNumber+"."+format)
 			if !overwrite {
-				if _, err := os.Stat(jsonFile); err == nil {
-					fmt.Print("SKIPPED (exists) ")
-					skipped++
+				if _, err := os.Stat(path); err == nil {
+					existing = append(existing, format)
 					continue
 				}
 			}
 
-			// Pretty print JSON
-			prettyJSON, err := json.MarshalIndent(ticketData, "", "  ")
-			if err != nil {
-				fmt.Printf("FAILED (marshal error: %v)\n", err)
-				failed++
-				continue
+			// PDF, Markdown and HTML share a view with approvals, every
+			// comment and the audit history, fetched once
+			if format != "json" && view == nil {
+				view = exportView(client, t)
 			}
-
-			if err := os.WriteFile(jsonFile, prettyJSON, 0600); err != nil {
-				fmt.Printf("FAILED (write error: %v)\n", err)
-				failed++
+			if err := writeExport(format, path, t, view); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", format, err))
 				continue
 			}
-
-			if format == "json" {
-				fmt.Println("OK")
-			}
+			written = append(written, format)
 		}
 
-		// Export PDF
-		if format == "pdf" || format == "all" {
-			pdfFile := filepath.Join(outputDir, ticketID+".pdf")
-			if !overwrite {
-				if _, err := os.Stat(pdfFile); err == nil {
-					if format == "pdf" {
-						fmt.Print("SKIPPED (exists) ")
-						skipped++
-						continue
-					}
-				}
-			}
-
-			if err := generateTicketPDF(ticketData, pdfFile); err != nil {
-				if format == "pdf" {
-					fmt.Printf("FAILED (PDF error: %v)\n", err)
-					failed++
-					continue
-				} else {
-					fmt.Printf("(PDF failed) ")
-				}
-			}
-
-			if format == "all" {
-				fmt.Println("OK (JSON+PDF)")
-			} else {
-				fmt.Println("OK")
-			}
+		switch {
+		case len(errs) > 0:
+			fmt.Printf("FAILED (%s)\n", strings.Join(errs, "; "))
+			failed++
+		case len(written) == 0:
+			fmt.Println("SKIPPED (exists)")
+			skipped++
+		default:
+			fmt.Printf("OK (%s)\n", strings.Join(written, ", "))
+			exported++
 		}
-
-		exported++
 	}
 
 	fmt.Println()
 	fmt.Printf("Export complete: %d exported, %d skipped, %d failed\n", exported, skipped, failed)
 }
 
-func fetchTicketIDsFromAPI(apiURL string, statusFilter []string) ([]string, error) {
-	url := fmt.Sprintf("%s/v1/tickets?per_page=1000", apiURL)
-	if len(statusFilter) > 0 {
-		url += "&status=" + strings.Join(statusFilter, ",")
-	}
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Tickets []struct {
-			ID           string `json:"id"`
-			TicketNumber string `json:"ticket_number"`
-		} `json:"tickets"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+// parseExportFormats checks the --format values, expanding "all"
+func parseExportFormats(values []string) ([]string, error) {
+	want := map[string]bool{}
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		switch v {
+		case "all":
+			for _, f := range exportFormats {
+				want[f] = true
+			}
+		case "markdown":
+			want["md"] = true
+		case "json", "pdf", "md", "html":
+			want[v] = true
+		default:
+			return nil, fmt.Errorf("unknown format %q (available: %s, all)", v, strings.Join(exportFormats, ", "))
+		}
 	}
 
-	var ids []string
-	for _, t := range result.Tickets {
-		id := t.TicketNumber
-		if id == "" {
-			id = t.ID
+	var formats []string
+	for _, f := range exportFormats {
+		if want[f] {
+			formats = append(formats, f)
 		}
-		ids = append(ids, id)
 	}
-	return ids, nil
+	return formats, nil
 }
 
-func fetchTicketFromAPI(apiURL, ticketID string) (map[string]interface{}, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/v1/tickets/%s", apiURL, ticketID))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Ticket map[string]interface{} `json:"ticket"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+// writeExport writes one ticket in one format
+func writeExport(format, path string, t *models.Ticket, view *ticketView) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0600)
 	}
 
-	return result.Ticket, nil
+	write := map[string]func(io.Writer, *ticketView) error{
+		"pdf":  writePDF,
+		"md":   writeMarkdown,
+		"html": writeHTML,
+	}[format]
+	return writeDocument(path, view, write)
 }
 
-// generateTicketPDF creates a PDF document from ticket data
-// This generates a simple text-based PDF using basic PDF primitives
-func generateTicketPDF(ticketData map[string]interface{}, outputPath string) error {
-	// Extract ticket fields
-	ticketID := getString(ticketData, "ticket_number", getString(ticketData, "id", "Unknown"))
-	title := getString(ticketData, "title", "Untitled")
-	description := getString(ticketData, "description", "No description")
-	status := getString(ticketData, "status", "unknown")
-	priority := getString(ticketData, "priority", "normal")
-	risk := getString(ticketData, "risk_level", getString(ticketData, "risk", "medium"))
-	industry := getString(ticketData, "industry", "")
-	createdBy := getString(ticketData, "created_by", "Unknown")
-	createdAt := getString(ticketData, "created_at", time.Now().Format(time.RFC3339))
-	rollbackPlan := getString(ticketData, "rollback_plan", "")
-	testingPlan := getString(ticketData, "testing_plan", "")
-
-	// Get arrays
-	compliance := getStringArray(ticketData, "compliance_frameworks")
-	affectedSystems := getStringArray(ticketData, "affected_systems")
-	approvalsRequired := getStringArrayAlt(ticketData, "approvals_required", "requires_approval_types")
-
-	// Build PDF content
-	var content strings.Builder
-
-	// PDF Header
-	content.WriteString("%PDF-1.4\n")
-	content.WriteString("1 0 obj<</Type/Catalog/Pages 2 0 R>>endobj\n")
-	content.WriteString("2 0 obj<</Type/Pages/Kids[3 0 R]/Count 1>>endobj\n")
-	content.WriteString("3 0 obj<</Type/Page/Parent 2 0 R/MediaBox[0 0 612 792]/Contents 4 0 R/Resources<</Font<</F1 5 0 R/F2 6 0 R>>>>>>endobj\n")
-	content.WriteString("5 0 obj<</Type/Font/Subtype/Type1/BaseFont/Helvetica-Bold>>endobj\n")
-	content.WriteString("6 0 obj<</Type/Font/Subtype/Type1/BaseFont/Helvetica>>endobj\n")
-
-	// Build page content
-	var pageContent strings.Builder
-	y := 750
-
-	// Title section
-	pageContent.WriteString("BT\n")
-	pageContent.WriteString(fmt.Sprintf("/F1 16 Tf %d %d Td (Change Request: %s) Tj\n", 50, y, escapePDF(ticketID)))
-	y -= 25
-	pageContent.WriteString(fmt.Sprintf("/F1 12 Tf %d %d Td (%s) Tj\n", 50, y, escapePDF(title)))
-	y -= 30
-
-	// Status and Priority
-	pageContent.WriteString(fmt.Sprintf("/F2 10 Tf %d %d Td (Status: %s    Priority: %s    Risk: %s) Tj\n", 50, y, status, priority, risk))
-	y -= 20
-	pageContent.WriteString(fmt.Sprintf("%d %d Td (Created By: %s    Date: %s) Tj\n", 50, y, escapePDF(createdBy), formatDate(createdAt)))
-	y -= 25
-
-	// Compliance
-	if industry != "" || len(compliance) > 0 {
-		pageContent.WriteString(fmt.Sprintf("/F1 10 Tf %d %d Td (Compliance Information) Tj\n", 50, y))
-		y -= 15
-		pageContent.WriteString("/F2 10 Tf\n")
-		if industry != "" {
-			pageContent.WriteString(fmt.Sprintf("%d %d Td (Industry: %s) Tj\n", 50, y, industry))
-			y -= 15
-		}
-		if len(compliance) > 0 {
-			pageContent.WriteString(fmt.Sprintf("%d %d Td (Frameworks: %s) Tj\n", 50, y, strings.Join(compliance, ", ")))
-			y -= 15
-		}
-		y -= 10
-	}
-
-	// Description
-	pageContent.WriteString(fmt.Sprintf("/F1 10 Tf %d %d Td (Description) Tj\n", 50, y))
-	y -= 15
-	pageContent.WriteString("/F2 10 Tf\n")
-	for _, line := range wrapText(description, 80) {
-		if y < 100 {
-			break // Don't overflow page
-		}
-		pageContent.WriteString(fmt.Sprintf("%d %d Td (%s) Tj\n", 50, y, escapePDF(line)))
-		y -= 12
+// writeDocument writes a ticket document to path
+func writeDocument(path string, view *ticketView, write func(io.Writer, *ticketView) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
 	}
-	y -= 10
-
-	// Affected Systems
-	if len(affectedSystems) > 0 && y > 200 {
-		pageContent.WriteString(fmt.Sprintf("/F1 10 Tf %d %d Td (Affected Systems) Tj\n", 50, y))
-		y -= 15
-		pageContent.WriteString(fmt.Sprintf("/F2 10 Tf %d %d Td (%s) Tj\n", 50, y, escapePDF(strings.Join(affectedSystems, ", "))))
-		y -= 20
+	err = write(f, view)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
+	return err
+}
 
-	// Approvals Required
-	if len(approvalsRequired) > 0 && y > 180 {
-		pageContent.WriteString(fmt.Sprintf("/F1 10 Tf %d %d Td (Approvals Required) Tj\n", 50, y))
-		y -= 15
-		pageContent.WriteString(fmt.Sprintf("/F2 10 Tf %d %d Td (%s) Tj\n", 50, y, escapePDF(strings.Join(approvalsRequired, ", "))))
-		y -= 20
+// exportView builds the document view of a ticket: its approvals, all of
+// its comments and its audit history. History is left out, with a warning,
+// when the caller can't read the audit trail.
+func exportView(client *apiclient.Client, t *models.Ticket) *ticketView {
+	view := buildTicketView(client, t, math.MaxInt)
+	audit, err := client.TicketAudit(t.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nWarning: %s exported without its history: %v\n", t.TicketNumber, err)
+		return view
 	}
+	view.History = historyFromAudit(audit)
+	return view
+}
 
-	// Rollback Plan
-	if rollbackPlan != "" && y > 160 {
-		pageContent.WriteString(fmt.Sprintf("/F1 10 Tf %d %d Td (Rollback Plan) Tj\n", 50, y))
-		y -= 15
-		pageContent.WriteString("/F2 10 Tf\n")
-		for _, line := range wrapText(rollbackPlan, 80) {
-			if y < 100 {
-				break
-			}
-			pageContent.WriteString(fmt.Sprintf("%d %d Td (%s) Tj\n", 50, y, escapePDF(line)))
-			y -= 12
+// fetchTicketNumbers lists every ticket matching the status filter, a page
+// at a time
+func fetchTicketNumbers(client *apiclient.Client, statusFilter []string) ([]string, error) {
+	const perPage = 100
+
+	var numbers []string
+	for page := 1; ; page++ {
+		query := url.Values{
+			"page":       []string{strconv.Itoa(page)},
+			"per_page":   []string{strconv.Itoa(perPage)},
+			"sort_by":    []string{"ticket_number"},
+			"sort_order": []string{"asc"},
 		}
-		y -= 10
-	}
-
-	// Testing Plan
-	if testingPlan != "" && y > 140 {
-		pageContent.WriteString(fmt.Sprintf("/F1 10 Tf %d %d Td (Testing Plan) Tj\n", 50, y))
-		y -= 15
-		pageContent.WriteString("/F2 10 Tf\n")
-		for _, line := range wrapText(testingPlan, 80) {
-			if y < 100 {
-				break
-			}
-			pageContent.WriteString(fmt.Sprintf("%d %d Td (%s) Tj\n", 50, y, escapePDF(line)))
-			y -= 12
+		if len(statusFilter) > 0 {
+			query.Set("status", strings.Join(statusFilter, ","))
 		}
-	}
 
-	// Footer
-	pageContent.WriteString(fmt.Sprintf("/F2 8 Tf %d 30 Td (Generated by After Dark Systems Change Management - %s) Tj\n", 50, time.Now().Format("2006-01-02 15:04:05")))
-
-	pageContent.WriteString("ET\n")
-
-	// Add content stream
-	streamContent := pageContent.String()
-	content.WriteString(fmt.Sprintf("4 0 obj<</Length %d>>stream\n%sendstream endobj\n", len(streamContent), streamContent))
-
-	// PDF trailer
-	content.WriteString("xref\n")
-	content.WriteString("0 7\n")
-	content.WriteString("0000000000 65535 f \n")
-	content.WriteString("0000000009 00000 n \n")
-	content.WriteString("0000000052 00000 n \n")
-	content.WriteString("0000000101 00000 n \n")
-	content.WriteString("0000000249 00000 n \n")
-	content.WriteString("0000000350 00000 n \n")
-	content.WriteString("0000000417 00000 n \n")
-	content.WriteString("trailer<</Size 7/Root 1 0 R>>\n")
-	content.WriteString("startxref\n")
-	content.WriteString("478\n")
-	content.WriteString("%%EOF\n")
-
-	return os.WriteFile(outputPath, []byte(content.String()), 0600)
-}
-
-func getString(data map[string]interface{}, key, defaultVal string) string {
-	if v, ok := data[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
+		list, err := client.ListTickets(query)
+		if err != nil {
+			return nil, err
 		}
-	}
-	return defaultVal
-}
-
-func getStringArrayAlt(data map[string]interface{}, key1, key2 string) []string {
-	result := getStringArray(data, key1)
-	if len(result) == 0 {
-		return getStringArray(data, key2)
-	}
-	return result
-}
-
-func getStringArray(data map[string]interface{}, key string) []string {
-	if v, ok := data[key]; ok {
-		if arr, ok := v.([]interface{}); ok {
-			var result []string
-			for _, item := range arr {
-				if s, ok := item.(string); ok {
-					result = append(result, s)
-				}
-			}
-			return result
+		for _, t := range list.Tickets {
+			numbers = append(numbers, t.TicketNumber)
 		}
-		if arr, ok := v.([]string); ok {
-			return arr
+		if len(list.Tickets) < perPage || len(numbers) >= list.Total {
+			return numbers, nil
 		}
 	}
-	return nil
-}
-
-func escapePDF(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "(", "\\(")
-	s = strings.ReplaceAll(s, ")", "\\)")
-	s = strings.ReplaceAll(s, "\n", " ")
-	s = strings.ReplaceAll(s, "\r", " ")
-	return s
 }
 
 func wrapText(text string, maxLen int) []string {
@@ -474,11 +303,3 @@ func wrapText(text string, maxLen int) []string {
 
 	return lines
 }
-
-func formatDate(dateStr string) string {
-	t, err := time.Parse(time.RFC3339, dateStr)
-	if err != nil {
-		return dateStr
-	}
-	return t.Format("2006-01-02")
-}
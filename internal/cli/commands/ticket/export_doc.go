@@ -0,0 +1,404 @@
+package ticket
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/pdf"
+)
+
+// historyRow is one audit trail entry in an exported document
+type historyRow struct {
+	At     string
+	Actor  string
+	Action string
+	Detail string
+}
+
+// historyFromAudit turns audit entries into document rows, oldest first
+func historyFromAudit(entries []models.TicketAuditLog) []historyRow {
+	sorted := append([]models.TicketAuditLog(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	rows := make([]historyRow, 0, len(sorted))
+	for _, e := range sorted {
+		row := historyRow{At: formatTime(&e.CreatedAt), Actor: "system", Action: e.Action}
+		if e.UserID != nil {
+			row.Actor = e.UserID.String()[:8]
+		}
+		if e.FieldName != nil {
+			row.Detail = fmt.Sprintf("%s: %s → %s", *e.FieldName, orDash(deref(e.OldValue)), orDash(deref(e.NewValue)))
+		} else if e.ActionCategory != "" {
+			row.Detail = e.ActionCategory
+		}
+		if e.IsEmergency {
+			row.Detail = joinNonEmpty(", ", row.Detail, "emergency")
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// writeMarkdown writes a ticket as a Markdown document
+func writeMarkdown(out io.Writer, v *ticketView) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s: %s\n\n", v.Number, v.Title)
+	badges := fmt.Sprintf("**Status:** %s · **Priority:** %s · **Risk:** %s", orDash(v.Status), orDash(v.Priority), orDash(v.Risk))
+	if v.Emergency {
+		badges += " · **EMERGENCY**"
+	}
+	b.WriteString(badges + "\n\n")
+
+	b.WriteString("| | |\n|---|---|\n")
+	mdRow(&b, "Created", joinNonEmpty(" by ", v.CreatedAt, v.CreatedBy))
+	mdRow(&b, "Updated", v.UpdatedAt)
+	mdRow(&b, "Assignee", v.Assignee)
+	mdRow(&b, "Change type", v.ChangeType)
+	if v.ScheduledStart != "" || v.ScheduledEnd != "" {
+		mdRow(&b, "Scheduled", orDash(v.ScheduledStart)+" – "+orDash(v.ScheduledEnd))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Compliance\n\n")
+	fmt.Fprintf(&b, "- **Industry:** %s\n", orDash(v.Industry))
+	fmt.Fprintf(&b, "- **Frameworks:** %s\n", orDash(strings.Join(v.Compliance, ", ")))
+	if len(v.DataTypes) > 0 {
+		fmt.Fprintf(&b, "- **Data types:** %s\n", strings.Join(v.DataTypes, ", "))
+	}
+	if v.ComplianceNotes != "" {
+		fmt.Fprintf(&b, "\n%s\n", mdText(v.ComplianceNotes))
+	}
+
+	mdSection(&b, "Description", v.Description)
+	if len(v.AffectedSystems) > 0 {
+		b.WriteString("\n## Affected Systems\n\n")
+		for _, s := range v.AffectedSystems {
+			fmt.Fprintf(&b, "- %s\n", s)
+		}
+	}
+	mdSection(&b, "Rollback Plan", v.RollbackPlan)
+	mdSection(&b, "Testing Plan", v.TestingPlan)
+
+	b.WriteString("\n## Approvals\n\n")
+	if len(v.Approvals) == 0 {
+		b.WriteString("None required.\n")
+	} else {
+		b.WriteString("| Type | Required | Approved | Pending | Denied | Outcome |\n|---|---:|---:|---:|---:|---|\n")
+		for _, a := range v.Approvals {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %s |\n", mdCell(a.Type), a.Required, a.Approved, a.Pending, a.Denied, approvalOutcome(a))
+		}
+	}
+
+	if len(v.Comments) > 0 {
+		b.WriteString("\n## Comments\n")
+		for _, c := range v.Comments {
+			fmt.Fprintf(&b, "\n**%s** · %s\n\n", c.Author, orDash(c.At))
+			for _, line := range strings.Split(strings.TrimSpace(c.Text), "\n") {
+				fmt.Fprintf(&b, "> %s\n", line)
+			}
+		}
+	}
+
+	if len(v.Repositories) > 0 {
+		b.WriteString("\n## Linked Repositories\n\n| Repository | Link | Branch | PR |\n|---|---|---|---|\n")
+		for _, r := range v.Repositories {
+			name := mdCell(orDash(r.Name))
+			if r.URL != "" {
+				name = fmt.Sprintf("[%s](%s)", name, r.URL)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", name, mdCell(orDash(r.LinkType)), mdCell(orDash(r.Branch)), orDash(r.PR))
+		}
+	}
+
+	if len(v.Attachments) > 0 {
+		b.WriteString("\n## Attachments\n\n")
+		for _, a := range v.Attachments {
+			fmt.Fprintf(&b, "- <%s>\n", a)
+		}
+	}
+
+	if len(v.History) > 0 {
+		b.WriteString("\n## History\n\n| When | Who | Action | Detail |\n|---|---|---|---|\n")
+		for _, h := range v.History {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", mdCell(h.At), mdCell(h.Actor), mdCell(h.Action), mdCell(h.Detail))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n---\n\n_Exported from %s on %s._\n", v.Source, time.Now().Format("2006-01-02 15:04 MST"))
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func mdRow(b *strings.Builder, label, value string) {
+	fmt.Fprintf(b, "| **%s** | %s |\n", label, mdCell(orDash(value)))
+}
+
+func mdSection(b *strings.Builder, title, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n%s\n", title, mdText(text))
+}
+
+// mdText keeps the author's line breaks, which Markdown would otherwise
+// join into one paragraph
+func mdText(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "  \n")
+}
+
+// mdCell makes text safe inside a Markdown table cell
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func approvalOutcome(a approvalRow) string {
+	switch {
+	case a.Denied > 0:
+		return "denied"
+	case a.Required > 0 && a.Approved >= a.Required:
+		return "approved"
+	}
+	return "pending"
+}
+
+// writePDF writes a ticket as a paginated PDF document, with the approvals
+// and history as tables
+func writePDF(out io.Writer, v *ticketView) error {
+	doc := pdf.New(v.Number + ": " + v.Title)
+	doc.Title(v.Number + ": " + v.Title)
+
+	badges := fmt.Sprintf("Status: %s · Priority: %s · Risk: %s", orDash(v.Status), orDash(v.Priority), orDash(v.Risk))
+	if v.Emergency {
+		badges += " · EMERGENCY"
+	}
+	doc.Text(badges)
+	doc.Space()
+	doc.Field("Created", orDash(joinNonEmpty(" by ", v.CreatedAt, v.CreatedBy)))
+	doc.Field("Updated", orDash(v.UpdatedAt))
+	doc.Field("Assignee", orDash(v.Assignee))
+	doc.Field("Change type", orDash(v.ChangeType))
+	if v.ScheduledStart != "" || v.ScheduledEnd != "" {
+		doc.Field("Scheduled", orDash(v.ScheduledStart)+" – "+orDash(v.ScheduledEnd))
+	}
+
+	doc.Heading("Compliance")
+	doc.Field("Industry", orDash(v.Industry))
+	doc.Field("Frameworks", orDash(strings.Join(v.Compliance, ", ")))
+	if len(v.DataTypes) > 0 {
+		doc.Field("Data types", strings.Join(v.DataTypes, ", "))
+	}
+	if v.ComplianceNotes != "" {
+		doc.Space()
+		doc.Text(strings.TrimSpace(v.ComplianceNotes))
+	}
+
+	pdfSection(doc, "Description", v.Description)
+	if len(v.AffectedSystems) > 0 {
+		doc.Heading("Affected Systems")
+		for _, s := range v.AffectedSystems {
+			doc.Bullet(s)
+		}
+	}
+	pdfSection(doc, "Rollback Plan", v.RollbackPlan)
+	pdfSection(doc, "Testing Plan", v.TestingPlan)
+
+	doc.Heading("Approvals")
+	if len(v.Approvals) == 0 {
+		doc.Text("None required.")
+	} else {
+		rows := make([][]string, len(v.Approvals))
+		for i, a := range v.Approvals {
+			rows[i] = []string{a.Type, fmt.Sprint(a.Required), fmt.Sprint(a.Approved), fmt.Sprint(a.Pending), fmt.Sprint(a.Denied), approvalOutcome(a)}
+		}
+		doc.Table([]string{"Type", "Required", "Approved", "Pending", "Denied", "Outcome"}, []float64{4, 1.5, 1.5, 1.5, 1.5, 2}, rows)
+	}
+
+	if len(v.Comments) > 0 {
+		doc.Heading("Comments")
+		for _, c := range v.Comments {
+			doc.Field(c.Author, orDash(c.At))
+			doc.Bullet(strings.TrimSpace(c.Text))
+			doc.Space()
+		}
+	}
+
+	if len(v.Repositories) > 0 {
+		doc.Heading("Linked Repositories")
+		rows := make([][]string, len(v.Repositories))
+		for i, r := range v.Repositories {
+			rows[i] = []string{joinNonEmpty("\n", orDash(r.Name), r.URL), orDash(r.LinkType), orDash(r.Branch), orDash(r.PR)}
+		}
+		doc.Table([]string{"Repository", "Link", "Branch", "PR"}, []float64{5, 1.5, 2.5, 1}, rows)
+	}
+
+	if len(v.Attachments) > 0 {
+		doc.Heading("Attachments")
+		for _, a := range v.Attachments {
+			doc.Bullet(a)
+		}
+	}
+
+	if len(v.History) > 0 {
+		doc.Heading("History")
+		rows := make([][]string, len(v.History))
+		for i, h := range v.History {
+			rows[i] = []string{h.At, h.Actor, h.Action, h.Detail}
+		}
+		doc.Table([]string{"When", "Who", "Action", "Detail"}, []float64{2.2, 1.3, 2, 4.5}, rows)
+	}
+
+	doc.Space()
+	doc.Text(fmt.Sprintf("Exported from %s on %s.", v.Source, time.Now().Format("2006-01-02 15:04 MST")))
+
+	_, err := doc.WriteTo(out)
+	return err
+}
+
+func pdfSection(doc *pdf.Document, title, text string) {
+	if text == "" {
+		return
+	}
+	doc.Heading(title)
+	doc.Text(strings.TrimSpace(text))
+}
+
+// writeHTML writes a ticket as a standalone HTML page
+func writeHTML(out io.Writer, v *ticketView) error {
+	return htmlTemplate.Execute(out, struct {
+		*ticketView
+		Exported string
+	}{v, time.Now().Format("2006-01-02 15:04 MST")})
+}
+
+var htmlTemplate = template.Must(template.New("ticket").Funcs(template.FuncMap{
+	"dash":    orDash,
+	"join":    strings.Join,
+	"outcome": approvalOutcome,
+	"paras":   func(s string) []string { return strings.Split(strings.TrimSpace(s), "\n") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Number}}: {{.Title}}</title>
+<style>
+  body { font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 860px; margin: 2em auto; padding: 0 1em; }
+  h1 { font-size: 1.6em; margin-bottom: .2em; }
+  h2 { font-size: 1.2em; border-bottom: 1px solid #d0d7de; padding-bottom: .2em; margin-top: 1.6em; }
+  table { border-collapse: collapse; width: 100%; margin: .5em 0; }
+  th, td { text-align: left; border: 1px solid #d0d7de; padding: 4px 8px; vertical-align: top; }
+  th { background: #f6f8fa; }
+  .badges span { display: inline-block; margin-right: .6em; padding: 1px 8px; border-radius: 10px; background: #eaeef2; font-size: .9em; }
+  .badges .emergency, .denied { background: #ffebe9; color: #cf222e; }
+  .approved { color: #1a7f37; }
+  .text p { margin: 0 0 .4em; white-space: pre-wrap; }
+  blockquote { margin: .3em 0 1em; padding-left: 1em; border-left: 3px solid #d0d7de; color: #424a53; }
+  footer { margin-top: 2em; color: #6e7781; font-size: .85em; }
+  @media print { body { margin: 0; max-width: none; } h2 { break-after: avoid; } tr { break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>{{.Number}}: {{.Title}}</h1>
+<div class="badges">
+  <span>{{dash .Status}}</span><span>Priority: {{dash .Priority}}</span><span>Risk: {{dash .Risk}}</span>{{if .Emergency}}<span class="emergency">EMERGENCY</span>{{end}}
+</div>
+
+<table>
+  <tr><th>Created</th><td>{{dash .CreatedAt}}{{if .CreatedBy}} by {{.CreatedBy}}{{end}}</td></tr>
+  <tr><th>Updated</th><td>{{dash .UpdatedAt}}</td></tr>
+  <tr><th>Assignee</th><td>{{dash .Assignee}}</td></tr>
+  <tr><th>Change type</th><td>{{dash .ChangeType}}</td></tr>
+  {{- if or .ScheduledStart .ScheduledEnd}}
+  <tr><th>Scheduled</th><td>{{dash .ScheduledStart}} – {{dash .ScheduledEnd}}</td></tr>
+  {{- end}}
+</table>
+
+<h2>Compliance</h2>
+<table>
+  <tr><th>Industry</th><td>{{dash .Industry}}</td></tr>
+  <tr><th>Frameworks</th><td>{{dash (join .Compliance ", ")}}</td></tr>
+  {{- if .DataTypes}}
+  <tr><th>Data types</th><td>{{join .DataTypes ", "}}</td></tr>
+  {{- end}}
+</table>
+{{- if .ComplianceNotes}}
+<div class="text">{{range paras .ComplianceNotes}}<p>{{.}}</p>{{end}}</div>
+{{- end}}
+
+{{- if .Description}}
+<h2>Description</h2>
+<div class="text">{{range paras .Description}}<p>{{.}}</p>{{end}}</div>
+{{- end}}
+
+{{- if .AffectedSystems}}
+<h2>Affected Systems</h2>
+<ul>{{range .AffectedSystems}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+
+{{- if .RollbackPlan}}
+<h2>Rollback Plan</h2>
+<div class="text">{{range paras .RollbackPlan}}<p>{{.}}</p>{{end}}</div>
+{{- end}}
+
+{{- if .TestingPlan}}
+<h2>Testing Plan</h2>
+<div class="text">{{range paras .TestingPlan}}<p>{{.}}</p>{{end}}</div>
+{{- end}}
+
+<h2>Approvals</h2>
+{{- if .Approvals}}
+<table>
+  <tr><th>Type</th><th>Required</th><th>Approved</th><th>Pending</th><th>Denied</th><th>Outcome</th></tr>
+  {{- range .Approvals}}
+  {{- $outcome := outcome .}}
+  <tr><td>{{.Type}}</td><td>{{.Required}}</td><td>{{.Approved}}</td><td>{{.Pending}}</td><td>{{.Denied}}</td><td class="{{$outcome}}">{{$outcome}}</td></tr>
+  {{- end}}
+</table>
+{{- else}}
+<p>None required.</p>
+{{- end}}
+
+{{- if .Comments}}
+<h2>Comments</h2>
+{{- range .Comments}}
+<p><strong>{{.Author}}</strong> · {{dash .At}}</p>
+<blockquote class="text">{{range paras .Text}}<p>{{.}}</p>{{end}}</blockquote>
+{{- end}}
+{{- end}}
+
+{{- if .Repositories}}
+<h2>Linked Repositories</h2>
+<table>
+  <tr><th>Repository</th><th>Link</th><th>Branch</th><th>PR</th></tr>
+  {{- range .Repositories}}
+  <tr><td>{{if .URL}}<a href="{{.URL}}">{{dash .Name}}</a>{{else}}{{dash .Name}}{{end}}</td><td>{{dash .LinkType}}</td><td>{{dash .Branch}}</td><td>{{dash .PR}}</td></tr>
+  {{- end}}
+</table>
+{{- end}}
+
+{{- if .Attachments}}
+<h2>Attachments</h2>
+<ul>{{range .Attachments}}<li><a href="{{.}}">{{.}}</a></li>{{end}}</ul>
+{{- end}}
+
+{{- if .History}}
+<h2>History</h2>
+<table>
+  <tr><th>When</th><th>Who</th><th>Action</th><th>Detail</th></tr>
+  {{- range .History}}
+  <tr><td>{{.At}}</td><td>{{.Actor}}</td><td>{{.Action}}</td><td>{{.Detail}}</td></tr>
+  {{- end}}
+</table>
+{{- end}}
+
+<footer>Exported from {{.Source}} on {{.Exported}}.</footer>
+</body>
+</html>
+`))
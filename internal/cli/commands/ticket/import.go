@@ -1,16 +1,18 @@
 package ticket
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var importCmd = &cobra.Command{
@@ -20,6 +22,34 @@ var importCmd = &cobra.Command{
 
 This command reads JSON ticket files and creates them in the changes system
 via the API. Existing tickets (by ID) will be skipped unless --update is specified.
+To keep the tickets directory and the API in step in both directions, use
+'changes ticket sync' instead. Review what an update would change with
+'changes ticket diff' first.
+
+With --csv, tickets are created from the rows of a spreadsheet instead, for
+teams moving off a spreadsheet change log. Columns named after ticket fields
+(title, description, priority, risk_level, affected_systems, ...) are read
+as they are; a --mapping file renames columns, translates values and sets
+defaults:
+
+  columns:
+    title: Summary
+    description: Details
+    risk_level: Risk
+    affected_systems: Systems
+    requested_implementation_date: Change Date
+  values:
+    priority: {P1: urgent, P2: high, P3: normal, P4: low}
+  defaults:
+    requires_approval_types: change_management_board
+  separator: ";"            # between list items (default ",")
+  timezone: Europe/London   # for dates without an offset (default UTC)
+  date_formats: ["02/01/2006"]
+
+Every row is checked before anything is created. If any row is invalid,
+nothing is imported unless --skip-invalid is given. --report writes the rows
+that were not imported, with the reasons, to a CSV that can be fixed and
+imported again.
 
 Examples:
   # Import a single ticket
@@ -38,7 +68,11 @@ Examples:
   changes ticket import --dir /path/to/tickets --all
 
   # Dry run to see what would be imported
-  changes ticket import --all --dry-run`,
+  changes ticket import --all --dry-run
+
+  # Check a spreadsheet export, then import it
+  changes ticket import --csv changes.csv --mapping mapping.yaml --dry-run --report errors.csv
+  changes ticket import --csv changes.csv --mapping mapping.yaml`,
 	Run: runImport,
 }
 
@@ -47,40 +81,23 @@ func init() {
 	importCmd.Flags().Bool("update", false, "Update existing tickets instead of skipping them")
 	importCmd.Flags().String("dir", "", "Directory containing ticket JSON files (default: ./tickets)")
 	importCmd.Flags().Bool("dry-run", false, "Show what would be imported without actually importing")
-	importCmd.Flags().String("api-url", "", "API URL (default: from config or https://api.changes.afterdarksys.com)")
-	importCmd.Flags().String("token", "", "API authentication token (or set CHANGES_API_TOKEN env var)")
+	importCmd.Flags().String("csv", "", "Create tickets from the rows of a CSV file")
+	importCmd.Flags().String("mapping", "", "YAML file mapping CSV columns and values to ticket fields (with --csv)")
+	importCmd.Flags().String("report", "", "Write rows that were not imported, with the reasons, to this CSV file (with --csv)")
+	importCmd.Flags().Bool("skip-invalid", false, "Import the valid rows even when others are invalid (with --csv)")
+	importCmd.Flags().Bool("submit", false, "Submit imported tickets for approval instead of saving them as drafts (with --csv)")
 }
 
-// Global token for API requests (set in runImport)
-var apiToken string
-
 func runImport(cmd *cobra.Command, args []string) {
+	if csvPath, _ := cmd.Flags().GetString("csv"); csvPath != "" {
+		runCSVImport(cmd, csvPath)
+		return
+	}
+
 	importAll, _ := cmd.Flags().GetBool("all")
 	update, _ := cmd.Flags().GetBool("update")
 	customDir, _ := cmd.Flags().GetString("dir")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	apiURL, _ := cmd.Flags().GetString("api-url")
-	token, _ := cmd.Flags().GetString("token")
-
-	// Determine API URL
-	if apiURL == "" {
-		apiURL = viper.GetString("api.url")
-	}
-	if apiURL == "" {
-		apiURL = os.Getenv("CHANGES_API_URL")
-	}
-	if apiURL == "" {
-		apiURL = "https://api.changes.afterdarksys.com"
-	}
-
-	// Determine API token
-	apiToken = token
-	if apiToken == "" {
-		apiToken = viper.GetString("api.token")
-	}
-	if apiToken == "" {
-		apiToken = os.Getenv("CHANGES_API_TOKEN")
-	}
 
 	// Get tickets directory
 	ticketsDir := customDir
@@ -95,7 +112,7 @@ func runImport(cmd *cobra.Command, args []string) {
 		entries, err := os.ReadDir(ticketsDir)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading tickets directory: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
 		for _, entry := range entries {
 			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && strings.HasPrefix(entry.Name(), "CHG-") {
@@ -118,7 +135,7 @@ func runImport(cmd *cobra.Command, args []string) {
 	} else {
 		fmt.Println("Usage: changes ticket import [file...] or changes ticket import --all")
 		fmt.Println("Run 'changes ticket import --help' for more information.")
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 
 	if len(files) == 0 {
@@ -126,6 +143,16 @@ func runImport(cmd *cobra.Command, args []string) {
 		os.Exit(0)
 	}
 
+	var client *apiclient.Client
+	if !dryRun {
+		var err error
+		client, err = apiclient.New()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+
 	fmt.Printf("Found %d ticket file(s) to import\n", len(files))
 	if dryRun {
 		fmt.Println("DRY RUN - no changes will be made")
@@ -161,7 +188,7 @@ func runImport(cmd *cobra.Command, args []string) {
 		}
 
 		// Check if ticket exists (GET request)
-		exists := checkTicketExists(apiURL, ticketID)
+		exists, etag := checkTicketExists(client, ticketID)
 
 		if exists && !update {
 			fmt.Println("SKIPPED (already exists)")
@@ -172,9 +199,9 @@ func runImport(cmd *cobra.Command, args []string) {
 		// Import or update the ticket
 		var err2 error
 		if exists && update {
-			err2 = updateTicketViaAPI(apiURL, ticketID, data)
+			err2 = updateTicketViaAPI(client, ticketID, etag, data)
 		} else {
-			err2 = createTicketViaAPI(apiURL, data)
+			err2 = createTicketViaAPI(client, ticketID, data)
 		}
 
 		if err2 != nil {
@@ -195,76 +222,29 @@ func runImport(cmd *cobra.Command, args []string) {
 	fmt.Printf("Import complete: %d imported, %d skipped, %d failed\n", imported, skipped, failed)
 }
 
-func checkTicketExists(apiURL, ticketID string) bool {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/tickets/%s", apiURL, ticketID), nil)
-	if err != nil {
-		return false
-	}
-	if apiToken != "" {
-		req.Header.Set("Authorization", "Bearer "+apiToken)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// checkTicketExists reports whether the ticket exists along with its ETag,
+// which updates must send back as If-Match
+func checkTicketExists(client *apiclient.Client, ticketID string) (bool, string) {
+	etag, err := client.TicketETag(ticketID)
 	if err != nil {
-		return false
+		return false, ""
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
+	return true, etag
 }
 
-func createTicketViaAPI(apiURL string, data []byte) error {
-	req, err := http.NewRequest(
-		http.MethodPost,
-		fmt.Sprintf("%s/v1/tickets", apiURL),
-		strings.NewReader(string(data)),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if apiToken != "" {
-		req.Header.Set("Authorization", "Bearer "+apiToken)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-	return nil
+// createTicketViaAPI creates a ticket with an Idempotency-Key derived from the
+// source ticket and its content, so re-running an interrupted import doesn't
+// create duplicates
+func createTicketViaAPI(client *apiclient.Client, ticketID string, data []byte) error {
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("import-%s-%s", ticketID, hex.EncodeToString(sum[:8]))
+	return client.Post("/v1/tickets", key, json.RawMessage(data), nil)
 }
 
-func updateTicketViaAPI(apiURL, ticketID string, data []byte) error {
-	req, err := http.NewRequest(
-		http.MethodPatch,
-		fmt.Sprintf("%s/v1/tickets/%s", apiURL, ticketID),
-		strings.NewReader(string(data)),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if apiToken != "" {
-		req.Header.Set("Authorization", "Bearer "+apiToken)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+func updateTicketViaAPI(client *apiclient.Client, ticketID, etag string, data []byte) error {
+	var headers http.Header
+	if etag != "" {
+		headers = http.Header{"If-Match": []string{etag}}
 	}
-	return nil
+	return client.Do(http.MethodPatch, "/v1/tickets/"+ticketID, headers, json.RawMessage(data), nil)
 }
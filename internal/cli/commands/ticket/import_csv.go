@@ -0,0 +1,487 @@
+package ticket
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// csvMapping says how spreadsheet columns become ticket fields. Fields are
+// named as in the API's create ticket request, e.g. risk_level.
+type csvMapping struct {
+	// Columns maps a ticket field to the CSV column header it is read from.
+	// Fields not listed are read from a column named after the field.
+	Columns map[string]string `yaml:"columns"`
+	// Values translates spreadsheet values, per field, such as "P1" to
+	// "urgent". Matching ignores case.
+	Values map[string]map[string]string `yaml:"values"`
+	// Defaults fill fields whose cell is empty
+	Defaults map[string]string `yaml:"defaults"`
+	// Separator splits list fields such as affected_systems (default ",")
+	Separator string `yaml:"separator"`
+	// Timezone for dates without an offset (default UTC)
+	Timezone string `yaml:"timezone"`
+	// DateFormats are Go time layouts tried before the built-in ones
+	DateFormats []string `yaml:"date_formats"`
+}
+
+// csvFields are the ticket fields a CSV import can set
+var csvFields = []string{
+	"title", "description", "priority", "risk_level", "industry", "compliance_frameworks",
+	"compliance_notes", "change_type", "affected_systems", "affected_data_types", "impact_description",
+	"rollback_plan", "testing_plan", "requested_implementation_date", "requires_approval_types",
+	"approval_deadline", "labels", "external_reference", "story_points", "time_estimate_hours",
+	"is_confidential",
+}
+
+// csvDateLayouts are tried after the mapping's date_formats; layouts
+// without an offset are read in the mapping's timezone
+var csvDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"1/2/2006 15:04",
+	"1/2/2006",
+}
+
+// csvRow is one spreadsheet row and what became of it
+type csvRow struct {
+	line   int // Line in the file, counting the header as 1
+	values []string
+	input  *models.CreateTicketInput
+	errs   []string
+}
+
+func runCSVImport(cmd *cobra.Command, path string) {
+	mappingPath, _ := cmd.Flags().GetString("mapping")
+	reportPath, _ := cmd.Flags().GetString("report")
+	skipInvalid, _ := cmd.Flags().GetBool("skip-invalid")
+	submit, _ := cmd.Flags().GetBool("submit")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	mapping := &csvMapping{}
+	if mappingPath != "" {
+		var err error
+		if mapping, err = loadCSVMapping(mappingPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+	loc := time.UTC
+	if mapping.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(mapping.Timezone); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid timezone in mapping: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+
+	header, rows, err := readCSV(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	columns, err := mapping.resolve(header)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if len(rows) == 0 {
+		fmt.Println("No rows found to import.")
+		return
+	}
+
+	var invalid int
+	for _, row := range rows {
+		row.input, row.errs = mapping.ticket(columns, row.values, loc)
+		row.input.Submit = submit
+		if len(row.errs) > 0 {
+			invalid++
+		}
+	}
+
+	fmt.Printf("Read %d row(s) from %s\n", len(rows), path)
+	if dryRun {
+		fmt.Println("DRY RUN - no changes will be made")
+	}
+	fmt.Println()
+
+	if invalid > 0 {
+		for _, row := range rows {
+			if len(row.errs) > 0 {
+				fmt.Printf("Row %d: INVALID\n", row.line)
+				for _, e := range row.errs {
+					fmt.Printf("  - %s\n", e)
+				}
+			}
+		}
+		fmt.Println()
+	}
+	writeReport := func() {
+		if reportPath == "" {
+			return
+		}
+		if err := writeCSVReport(reportPath, header, rows); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		fmt.Printf("Rows that were not imported are listed in %s\n", reportPath)
+	}
+
+	if dryRun {
+		fmt.Printf("Validation complete: %d valid, %d invalid\n", len(rows)-invalid, invalid)
+		writeReport()
+		if invalid > 0 {
+			os.Exit(exitcode.Validation)
+		}
+		return
+	}
+	if invalid > 0 && !skipInvalid {
+		writeReport()
+		fmt.Fprintf(os.Stderr, "Error: %d of %d rows are invalid; nothing was imported. Fix them, or import the valid rows with --skip-invalid\n", invalid, len(rows))
+		os.Exit(exitcode.Validation)
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var imported, failed int
+	for _, row := range rows {
+		if len(row.errs) > 0 {
+			continue
+		}
+		fmt.Printf("Importing row %d (%s)... ", row.line, truncate(row.input.Title, 40))
+		ticket, err := client.CreateTicket(*row.input, csvIdempotencyKey(row.values))
+		if err != nil {
+			fmt.Printf("FAILED (%v)\n", err)
+			row.errs = append(row.errs, err.Error())
+			failed++
+			continue
+		}
+		fmt.Printf("OK -> %s\n", ticket.TicketNumber)
+		imported++
+	}
+
+	fmt.Println()
+	fmt.Printf("CSV import complete: %d imported, %d invalid, %d failed\n", imported, invalid, failed)
+	if invalid+failed > 0 {
+		writeReport()
+	}
+}
+
+// loadCSVMapping reads a mapping file and checks it only names known fields
+func loadCSVMapping(path string) (*csvMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping: %w", err)
+	}
+	var m csvMapping
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping %s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, f := range csvFields {
+		known[f] = true
+	}
+	for _, section := range []map[string]string{m.Columns, m.Defaults} {
+		for field := range section {
+			if !known[field] {
+				return nil, fmt.Errorf("mapping %s: unknown ticket field %q (known fields: %s)", path, field, strings.Join(csvFields, ", "))
+			}
+		}
+	}
+	for field := range m.Values {
+		if !known[field] {
+			return nil, fmt.Errorf("mapping %s: unknown ticket field %q in values", path, field)
+		}
+	}
+	return &m, nil
+}
+
+// readCSV reads the header and every row of a CSV file
+func readCSV(path string) ([]string, []*csvRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%s is empty", path)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // Excel byte order mark
+	}
+
+	var rows []*csvRow
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(values, "")) == "" {
+			continue // Blank rows are common at the end of spreadsheets
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, &csvRow{line: line, values: values})
+	}
+	return header, rows, nil
+}
+
+// resolve finds the column each ticket field is read from
+func (m *csvMapping) resolve(header []string) (map[string]int, error) {
+	index := map[string]int{}
+	for i, h := range header {
+		index[normalizeColumn(h)] = i
+	}
+
+	columns := map[string]int{}
+	for _, field := range csvFields {
+		if name, ok := m.Columns[field]; ok {
+			i, found := index[normalizeColumn(name)]
+			if !found {
+				return nil, fmt.Errorf("column %q, mapped to %s, is not in the CSV header", name, field)
+			}
+			columns[field] = i
+		} else if i, found := index[field]; found {
+			columns[field] = i
+		}
+	}
+	for _, required := range []string{"title", "description"} {
+		if _, ok := columns[required]; !ok && m.Defaults[required] == "" {
+			return nil, fmt.Errorf("no column for %s; name a column %q or map one in the mapping file", required, required)
+		}
+	}
+	return columns, nil
+}
+
+// ticket converts a row, returning every problem found rather than the first
+func (m *csvMapping) ticket(columns map[string]int, values []string, loc *time.Location) (*models.CreateTicketInput, []string) {
+	var errs []string
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, field+": "+fmt.Sprintf(format, args...))
+	}
+	get := func(field string) string {
+		value := ""
+		if i, ok := columns[field]; ok && i < len(values) {
+			value = strings.TrimSpace(values[i])
+		}
+		if value == "" {
+			value = m.Defaults[field]
+		}
+		return m.translate(field, value)
+	}
+	list := func(field string) []string {
+		sep := m.Separator
+		if sep == "" {
+			sep = ","
+		}
+		var items []string
+		for _, item := range strings.Split(get(field), sep) {
+			if item = m.translate(field, strings.TrimSpace(item)); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	optional := func(field string) *string {
+		if v := get(field); v != "" {
+			return &v
+		}
+		return nil
+	}
+	date := func(field string) *time.Time {
+		v := get(field)
+		if v == "" {
+			return nil
+		}
+		for _, layout := range append(append([]string(nil), m.DateFormats...), csvDateLayouts...) {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return &t
+			}
+		}
+		fail(field, "unrecognized date %q", v)
+		return nil
+	}
+
+	priority, risk, industry := get("priority"), get("risk_level"), get("industry")
+	input := &models.CreateTicketInput{
+		Title:                       get("title"),
+		Description:                 get("description"),
+		Priority:                    models.TicketPriority(strings.ToLower(priority)),
+		RiskLevel:                   models.RiskLevel(strings.ToLower(risk)),
+		Industry:                    models.IndustryType(strings.ToLower(industry)),
+		ComplianceNotes:             optional("compliance_notes"),
+		ChangeType:                  optional("change_type"),
+		AffectedSystems:             list("affected_systems"),
+		AffectedDataTypes:           list("affected_data_types"),
+		ImpactDescription:           optional("impact_description"),
+		RollbackPlan:                optional("rollback_plan"),
+		TestingPlan:                 optional("testing_plan"),
+		RequestedImplementationDate: date("requested_implementation_date"),
+		ApprovalDeadline:            date("approval_deadline"),
+		Labels:                      list("labels"),
+		ExternalReference:           optional("external_reference"),
+	}
+
+	if n := utf8.RuneCountInString(input.Title); n < 5 || n > 500 {
+		fail("title", "must be 5 to 500 characters, got %d", n)
+	}
+	if n := utf8.RuneCountInString(input.Description); n < 10 {
+		fail("description", "must be at least 10 characters, got %d", n)
+	}
+	if input.Priority == "" {
+		input.Priority = models.TicketPriorityNormal
+	} else if !input.Priority.Valid() {
+		fail("priority", "unknown value %q (expected one of %s)", priority, strings.Join(priorityValues(), ", "))
+	}
+	if input.RiskLevel == "" {
+		input.RiskLevel = models.RiskLevelMedium
+	} else if !input.RiskLevel.Valid() {
+		fail("risk_level", "unknown value %q (expected one of %s)", risk, strings.Join(riskValues(), ", "))
+	}
+	if input.Industry != "" && !input.Industry.Valid() {
+		fail("industry", "unknown value %q (expected one of %s)", industry, strings.Join(industryValues(), ", "))
+	}
+	for _, f := range list("compliance_frameworks") {
+		framework := models.ComplianceFramework(strings.ToLower(f))
+		if !framework.Valid() {
+			fail("compliance_frameworks", "unknown value %q (expected one of %s)", f, strings.Join(frameworkValues(), ", "))
+			continue
+		}
+		input.ComplianceFrameworks = append(input.ComplianceFrameworks, framework)
+	}
+	for _, a := range list("requires_approval_types") {
+		approvalType := models.ApprovalType(strings.ToLower(a))
+		if !approvalType.Valid() {
+			fail("requires_approval_types", "unknown value %q (expected one of %s)", a, strings.Join(approvalTypeValues(), ", "))
+			continue
+		}
+		input.RequiresApprovalTypes = append(input.RequiresApprovalTypes, approvalType)
+	}
+	if len(input.RequiresApprovalTypes) == 0 && !containsField(errs, "requires_approval_types") {
+		fail("requires_approval_types", "at least one approval type is required; add a column or a default in the mapping")
+	}
+
+	if v := get("story_points"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			fail("story_points", "must be a whole number, got %q", v)
+		} else {
+			input.StoryPoints = &n
+		}
+	}
+	if v := get("time_estimate_hours"); v != "" {
+		if h, err := strconv.ParseFloat(v, 64); err != nil || h < 0 {
+			fail("time_estimate_hours", "must be a number of hours, got %q", v)
+		} else {
+			input.TimeEstimateHours = &h
+		}
+	}
+	if v := get("is_confidential"); v != "" {
+		switch strings.ToLower(v) {
+		case "true", "yes", "y", "1", "x":
+			input.IsConfidential = true
+		case "false", "no", "n", "0":
+		default:
+			fail("is_confidential", "must be yes or no, got %q", v)
+		}
+	}
+
+	// Report problems in field order, whatever order they were found in
+	order := map[string]int{}
+	for i, f := range csvFields {
+		order[f] = i
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		fi, _, _ := strings.Cut(errs[i], ":")
+		fj, _, _ := strings.Cut(errs[j], ":")
+		return order[fi] < order[fj]
+	})
+	return input, errs
+}
+
+// translate applies the mapping's values for field
+func (m *csvMapping) translate(field, value string) string {
+	for from, to := range m.Values[field] {
+		if strings.EqualFold(from, value) {
+			return to
+		}
+	}
+	return value
+}
+
+func containsField(errs []string, field string) bool {
+	for _, e := range errs {
+		if strings.HasPrefix(e, field+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// csvIdempotencyKey is derived from the row's content, so importing the
+// same spreadsheet again doesn't create duplicates
+func csvIdempotencyKey(values []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x1f")))
+	return "csv-import-" + hex.EncodeToString(sum[:16])
+}
+
+// writeCSVReport writes the rows that were not imported: their line in the
+// source file, the original columns and what went wrong. The report can be
+// fixed and imported again with the same mapping.
+func writeCSVReport(path string, header []string, rows []*csvRow) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(append(append([]string{"csv_line"}, header...), "import_errors"))
+	for _, row := range rows {
+		if len(row.errs) == 0 {
+			continue
+		}
+		values := make([]string, len(header))
+		copy(values, row.values)
+		w.Write(append(append([]string{strconv.Itoa(row.line)}, values...), strings.Join(row.errs, "; ")))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
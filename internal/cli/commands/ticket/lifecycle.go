@@ -0,0 +1,227 @@
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/viper"
+)
+
+// workflowTicket is a ticket resolved for submit, cancel, close or reopen:
+// the API copy and, when there is one, the local file that mirrors it
+type workflowTicket struct {
+	remote  *models.Ticket
+	dir     string
+	state   *syncState
+	localID string
+}
+
+// resolveWorkflowTicket finds ref in the API, through the sync state when
+// ref is a local ticket ID. With upload set, a draft that only exists
+// locally is created in the API first, as 'changes ticket sync' would.
+func resolveWorkflowTicket(client *apiclient.Client, ref string, upload bool) (*workflowTicket, error) {
+	dir := getTicketsDir()
+	state, err := loadSyncState(dir)
+	if err != nil {
+		return nil, err
+	}
+	wt := &workflowTicket{dir: dir, state: state}
+
+	if tracked, ok := state.Tickets[ref]; ok {
+		wt.localID = ref
+		wt.remote, err = client.GetTicket(tracked.RemoteID)
+		return wt, err
+	}
+
+	wt.remote, err = client.FindTicket(ref)
+	if err == nil {
+		wt.localID = wt.localIDFor(wt.remote)
+		return wt, nil
+	}
+	if !apiclient.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Not in the API; maybe a ticket created locally that was never synced
+	path := filepath.Join(dir, ref+".json")
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, err
+	}
+	if !upload {
+		return nil, fmt.Errorf("%s only exists locally; run 'changes ticket sync' to upload it first", ref)
+	}
+
+	var local CreateTicketData
+	if err := json.Unmarshal(data, &local); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if local.Status != string(models.TicketStatusDraft) {
+		return nil, fmt.Errorf("%s only exists locally and is %s, not a draft", ref, orDash(local.Status))
+	}
+
+	// Same idempotency key as sync, so a sync that races this one can't
+	// create the ticket twice
+	hash := hashBytes(data)
+	created, err := client.CreateTicket(createInputFromLocal(&local), "sync-"+ref+"-"+hash[:16])
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload local draft: %w", err)
+	}
+	fmt.Printf("Uploaded local draft %s as %s\n", ref, created.TicketNumber)
+
+	wt.remote = created
+	wt.localID = ref
+	state.Tickets[ref] = &syncedTicket{
+		RemoteID:        created.ID,
+		RemoteNumber:    created.TicketNumber,
+		Version:         created.Version,
+		RemoteUpdatedAt: created.UpdatedAt,
+		LocalHash:       hash,
+		SyncedAt:        time.Now().UTC(),
+	}
+	if state.APIURL == "" {
+		state.APIURL = client.BaseURL
+	}
+	if err := saveSyncState(dir, state); err != nil {
+		return nil, err
+	}
+	return wt, nil
+}
+
+// localIDFor returns the local file that mirrors remote: the one the sync
+// state links to it, or a file named after its ticket number
+func (wt *workflowTicket) localIDFor(remote *models.Ticket) string {
+	for id, tracked := range wt.state.Tickets {
+		if tracked.RemoteID == remote.ID {
+			return id
+		}
+	}
+	if _, err := os.Stat(filepath.Join(wt.dir, remote.TicketNumber+".json")); err == nil {
+		return remote.TicketNumber
+	}
+	return ""
+}
+
+// transition runs a workflow action and brings the local copy up to date.
+// note, when set, is posted as a comment after the transition succeeds.
+func (wt *workflowTicket) transition(client *apiclient.Client, action, reason, note string) (*apiclient.TransitionResult, error) {
+	result, err := client.TransitionTicket(wt.remote.ID, action, wt.remote.Version, reason)
+	if apiclient.IsConflict(err) {
+		return nil, exitcode.Wrap(exitcode.Conflict, fmt.Errorf("%s was changed by someone else while you were working on it; check it with 'changes ticket show %s' and try again", wt.remote.TicketNumber, wt.remote.TicketNumber))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if note != "" {
+		if err := client.AddComment(wt.remote.ID, models.CreateCommentInput{Comment: note}, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s, but the note could not be posted: %v\n", strings.ToLower(result.Message), err)
+		}
+	}
+
+	if updated, err := client.GetTicket(wt.remote.ID); err == nil {
+		wt.remote = updated
+	}
+	if path, err := wt.updateLocal(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update the local copy: %v\n", err)
+	} else if path != "" && viper.GetBool("verbose") {
+		fmt.Printf("Updated local copy %s\n", path)
+	}
+	return result, nil
+}
+
+// updateLocal writes the new status to the local file. A file with no
+// unsynced edits takes the whole API copy; one with pending edits only
+// takes the status, and keeps counting as locally modified so the next
+// sync still pushes those edits.
+func (wt *workflowTicket) updateLocal() (string, error) {
+	if wt.localID == "" {
+		return "", nil
+	}
+	path := filepath.Join(wt.dir, wt.localID+".json")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var local CreateTicketData
+	if err := json.Unmarshal(data, &local); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	tracked := wt.state.Tickets[wt.localID]
+	clean := tracked != nil && tracked.LocalHash == hashBytes(data)
+	if clean {
+		mergeRemote(&local, wt.remote)
+	} else {
+		local.Status = string(wt.remote.Status)
+		local.UpdatedAt = wt.remote.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	local.ID = wt.localID
+
+	hash, err := writeLocalTicket(wt.dir, &local)
+	if err != nil {
+		return "", err
+	}
+	if tracked != nil {
+		tracked.Version = wt.remote.Version
+		tracked.RemoteUpdatedAt = wt.remote.UpdatedAt
+		if clean {
+			tracked.LocalHash = hash
+		}
+		tracked.SyncedAt = time.Now().UTC()
+		if err := saveSyncState(wt.dir, wt.state); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// printTransition reports the outcome of a workflow action
+func printTransition(t *models.Ticket, result *apiclient.TransitionResult) {
+	if output.Structured() {
+		output.Print(struct {
+			TicketNumber string `json:"ticket_number"`
+			Status       string `json:"status"`
+			Version      int    `json:"version"`
+			*apiclient.TransitionResult
+		}{t.TicketNumber, string(t.Status), t.Version, result})
+		return
+	}
+
+	fmt.Printf("%s: %s\n", t.TicketNumber, result.Message)
+	fmt.Printf("Status: %s\n", t.Status)
+
+	if plan := result.ApprovalPlan; plan != nil && !plan.AutoApprove && len(plan.Requirements) > 0 {
+		fmt.Println()
+		fmt.Println("Approvals requested:")
+		for _, r := range plan.Requirements {
+			fmt.Printf("  - %s (%d)\n", r.ApprovalType, r.MinApprovals)
+		}
+	}
+	if result.OnCallNotified > 0 {
+		fmt.Printf("On-call approvers notified: %d\n", result.OnCallNotified)
+	}
+	if result.Assignment != nil && result.Assignment.RuleName != "" {
+		fmt.Printf("Assigned by rule: %s\n", result.Assignment.RuleName)
+	}
+	if pir := result.PostImplementationReview; pir != nil {
+		fmt.Println()
+		fmt.Print("A post-implementation review is required")
+		if pir.DueAt != nil {
+			fmt.Printf(" by %s", pir.DueAt.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Println(".")
+	}
+}
@@ -0,0 +1,337 @@
+package ticket
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var linkRepoCmd = &cobra.Command{
+	Use:   "link-repo <ticket-id>",
+	Short: "Link a repository, branch or pull request to a ticket",
+	Long: `Link a git repository to a ticket, with the branch, commit and pull request
+that carry the change. Linking the same repository again updates the link.
+
+Inside a git checkout, the repository and branch are taken from the current
+branch's remote (origin when it has none) unless --url or --branch are given.
+SSH remotes are linked by their web address, and credentials in the remote
+URL are never sent.
+
+Examples:
+  # Link the current checkout and branch
+  changes ticket link-repo CHG-2025-00001
+
+  # Link the current branch's pull request
+  changes ticket link-repo CHG-2025-00001 --pr 123 --type implements
+
+  # Link a repository explicitly
+  changes ticket link-repo CHG-2025-00001 --url https://github.com/org/repo --branch feat/x --pr 123`,
+	Args: cobra.ExactArgs(1),
+	Run:  runLinkRepo,
+}
+
+var unlinkRepoCmd = &cobra.Command{
+	Use:   "unlink-repo <ticket-id>",
+	Short: "Remove a repository link from a ticket",
+	Long: `Remove a repository link from a ticket. The repository is the one given
+with --url (its URL or owner/name), or the current checkout's remote.
+
+Examples:
+  # Unlink the current checkout
+  changes ticket unlink-repo CHG-2025-00001
+
+  # Unlink another repository
+  changes ticket unlink-repo CHG-2025-00001 --url org/repo`,
+	Args: cobra.ExactArgs(1),
+	Run:  runUnlinkRepo,
+}
+
+// repositoryLinkTypes are the kinds of link the API accepts
+var repositoryLinkTypes = []string{"related", "implements", "fixes", "affects"}
+
+func init() {
+	linkRepoCmd.Flags().String("url", "", "Repository URL (default: the current checkout's remote)")
+	linkRepoCmd.Flags().String("branch", "", "Branch carrying the change (default: the current branch)")
+	linkRepoCmd.Flags().Int("pr", 0, "Pull request number")
+	linkRepoCmd.Flags().String("commit", "", "Commit SHA, or a ref to resolve in the current checkout")
+	linkRepoCmd.Flags().String("type", "related", "Link type ("+strings.Join(repositoryLinkTypes, ", ")+")")
+	linkRepoCmd.Flags().String("notes", "", "Notes on the link")
+	linkRepoCmd.Flags().Bool("no-detect", false, "Don't fill in the repository and branch from the current checkout")
+	unlinkRepoCmd.Flags().String("url", "", "Repository URL or owner/name (default: the current checkout's remote)")
+}
+
+func runLinkRepo(cmd *cobra.Command, args []string) {
+	repoURL, _ := cmd.Flags().GetString("url")
+	branch, _ := cmd.Flags().GetString("branch")
+	pr, _ := cmd.Flags().GetInt("pr")
+	commit, _ := cmd.Flags().GetString("commit")
+	linkType, _ := cmd.Flags().GetString("type")
+	notes, _ := cmd.Flags().GetString("notes")
+	noDetect, _ := cmd.Flags().GetBool("no-detect")
+
+	if !containsString(repositoryLinkTypes, linkType) {
+		fmt.Fprintf(os.Stderr, "Error: invalid --type %q (valid: %s)\n", linkType, strings.Join(repositoryLinkTypes, ", "))
+		os.Exit(exitcode.Validation)
+	}
+	if cmd.Flags().Changed("pr") && pr <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --pr must be a pull request number")
+		os.Exit(exitcode.Validation)
+	}
+
+	var checkout *gitCheckout
+	if !noDetect {
+		checkout = detectCheckout()
+	}
+	if repoURL == "" {
+		if checkout == nil || checkout.URL == "" {
+			fmt.Fprintln(os.Stderr, "Error: --url is required outside a git checkout with a hosted remote")
+			os.Exit(exitcode.Validation)
+		}
+		repoURL = checkout.URL
+		if branch == "" && checkout.Branch != "" {
+			branch = checkout.Branch
+		}
+		if !output.Structured() {
+			fmt.Printf("Using %s", repoURL)
+			if branch != "" {
+				fmt.Printf(" (branch %s)", branch)
+			}
+			fmt.Printf(" from remote %s\n", checkout.Remote)
+		}
+	} else {
+		webURL, err := repositoryWebURL(repoURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.Validation)
+		}
+		repoURL = webURL
+	}
+	if commit != "" && checkout != nil {
+		if sha, err := git("rev-parse", "--verify", "--quiet", commit+"^{commit}"); err == nil {
+			commit = sha
+		}
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	wt, err := resolveWorkflowTicket(client, args[0], false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	input := models.LinkRepositoryByURLInput{URL: repoURL, LinkType: linkType}
+	if branch != "" {
+		input.BranchName = &branch
+	}
+	if commit != "" {
+		input.CommitSHA = &commit
+	}
+	if pr > 0 {
+		input.PRNumber = &pr
+	}
+	if notes != "" {
+		input.Notes = &notes
+	}
+
+	link, err := client.LinkRepository(wt.remote.ID, input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error linking repository: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(link)
+		return
+	}
+	name := repoURL
+	if link != nil && link.Repository != nil {
+		name = link.Repository.Name
+	}
+	details := []string{linkType}
+	if branch != "" {
+		details = append(details, "branch "+branch)
+	}
+	if commit != "" {
+		details = append(details, "commit "+truncate(commit, 12))
+	}
+	if pr > 0 {
+		details = append(details, fmt.Sprintf("PR #%d", pr))
+	}
+	fmt.Printf("Linked %s to %s (%s)\n", name, wt.remote.TicketNumber, strings.Join(details, ", "))
+}
+
+func runUnlinkRepo(cmd *cobra.Command, args []string) {
+	ref, _ := cmd.Flags().GetString("url")
+	if ref == "" {
+		checkout := detectCheckout()
+		if checkout == nil || checkout.URL == "" {
+			fmt.Fprintln(os.Stderr, "Error: --url is required outside a git checkout with a hosted remote")
+			os.Exit(exitcode.Validation)
+		}
+		ref = checkout.URL
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	wt, err := resolveWorkflowTicket(client, args[0], false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	link := findRepositoryLink(wt.remote.Repositories, ref)
+	if link == nil {
+		var linked []string
+		for _, r := range wt.remote.Repositories {
+			if r.Repository != nil {
+				linked = append(linked, r.Repository.URL)
+			}
+		}
+		if len(linked) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: %s has no linked repositories\n", wt.remote.TicketNumber)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %s is not linked to %s (linked: %s)\n", ref, wt.remote.TicketNumber, strings.Join(linked, ", "))
+		}
+		os.Exit(exitcode.NotFound)
+	}
+
+	if err := client.UnlinkRepository(wt.remote.ID, link.RepositoryID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error unlinking repository: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if output.Structured() {
+		output.Print(map[string]interface{}{"ticket_number": wt.remote.TicketNumber, "unlinked": link})
+		return
+	}
+	fmt.Printf("Unlinked %s from %s\n", link.Repository.Name, wt.remote.TicketNumber)
+}
+
+// findRepositoryLink finds the link to the repository ref names, by URL
+// (in any form repositoryWebURL understands) or by owner/name
+func findRepositoryLink(links []models.TicketRepository, ref string) *models.TicketRepository {
+	want := strings.ToLower(strings.Trim(ref, "/"))
+	if webURL, err := repositoryWebURL(ref); err == nil {
+		want = strings.ToLower(webURL)
+	}
+	for i, link := range links {
+		if link.Repository == nil {
+			continue
+		}
+		linkURL := strings.ToLower(strings.TrimSuffix(strings.TrimRight(link.Repository.URL, "/"), ".git"))
+		if linkURL == want || strings.ToLower(link.Repository.Name) == want || strings.HasSuffix(linkURL, "/"+want) {
+			return &links[i]
+		}
+	}
+	return nil
+}
+
+// gitCheckout is the git repository the working directory is in
+type gitCheckout struct {
+	Remote string
+	URL    string // the remote's web address, empty when it isn't hosted
+	Branch string // empty on a detached HEAD
+}
+
+// detectCheckout reads the current branch and its remote, or returns nil
+// outside a git checkout
+func detectCheckout() *gitCheckout {
+	if _, err := git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil
+	}
+	c := &gitCheckout{}
+	c.Branch, _ = git("symbolic-ref", "--quiet", "--short", "HEAD")
+
+	// The branch's upstream remote, then origin, then whatever there is
+	if c.Branch != "" {
+		c.Remote, _ = git("config", "branch."+c.Branch+".remote")
+	}
+	if c.Remote == "" || c.Remote == "." {
+		remotes, _ := git("remote")
+		names := strings.Fields(remotes)
+		c.Remote = ""
+		if containsString(names, "origin") {
+			c.Remote = "origin"
+		} else if len(names) > 0 {
+			c.Remote = names[0]
+		}
+	}
+	if c.Remote != "" {
+		if remoteURL, err := git("remote", "get-url", c.Remote); err == nil {
+			c.URL, _ = repositoryWebURL(remoteURL)
+		}
+	}
+	return c
+}
+
+// git runs a git command in the working directory and returns its trimmed
+// output
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// repositoryWebURL turns a git remote into the repository's web address:
+// git@github.com:org/repo.git and ssh://git@github.com/org/repo become
+// https://github.com/org/repo. Credentials in an https remote are dropped.
+func repositoryWebURL(remote string) (string, error) {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	scheme := "https"
+
+	if i := strings.Index(remote, "://"); i >= 0 {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", fmt.Errorf("invalid repository URL %q", remote)
+		}
+		switch u.Scheme {
+		case "http", "https":
+			scheme, host = u.Scheme, u.Host
+		case "ssh", "git", "git+ssh":
+			host = u.Hostname()
+		default:
+			return "", fmt.Errorf("%s is not a hosted repository", remote)
+		}
+		path = u.Path
+	} else if at := strings.Index(remote, ":"); at > 0 && !strings.ContainsAny(remote[:at], "/\\") {
+		// scp-like syntax: [user@]host:path
+		host = remote[:at]
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		path = remote[at+1:]
+	} else {
+		return "", fmt.Errorf("%s is not a hosted repository", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", fmt.Errorf("%s is not a hosted repository", remote)
+	}
+	host = strings.ToLower(host)
+
+	// Azure DevOps SSH remotes look nothing like the web address
+	if host == "ssh.dev.azure.com" {
+		parts := strings.Split(strings.TrimPrefix(path, "v3/"), "/")
+		if len(parts) != 3 {
+			return "", errors.New("unrecognized Azure DevOps remote " + remote)
+		}
+		return fmt.Sprintf("https://dev.azure.com/%s/%s/_git/%s", parts[0], parts[1], parts[2]), nil
+	}
+	return scheme + "://" + host + "/" + path, nil
+}
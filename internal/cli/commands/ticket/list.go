@@ -2,15 +2,22 @@ package ticket
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // LocalTicket represents a ticket stored in local JSON files
@@ -33,8 +40,13 @@ type LocalTicket struct {
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
-	Short:   "List change tickets",
-	Long: `List change tickets with optional filters.
+	Short:   "List and search change tickets",
+	Long: `List and search change tickets with optional filters.
+
+Tickets are listed from the API. Use --local to list the JSON files in the
+local tickets directory instead; this also happens when you are not logged in.
+
+Columns: ` + strings.Join(listColumnNames(), ", ") + `
 
 Examples:
   # List all tickets
@@ -43,12 +55,15 @@ Examples:
   # List only pending tickets
   changes ticket list --status submitted,in_review
 
-  # List high priority tickets
-  changes ticket list --priority high,urgent,emergency
+  # List high priority tickets assigned to you that mention the database
+  changes ticket list --priority high --assignee me --search "database"
 
-  # List tickets assigned to you
+  # List tickets you created
   changes ticket list --mine
 
+  # Choose columns and page through results
+  changes ticket list --columns ticket,status,assignee,title --limit 20 --page 2
+
   # List tickets with JSON output
   changes ticket list --output json`,
 	Run: runList,
@@ -58,16 +73,86 @@ func init() {
 	listCmd.Flags().StringSlice("status", []string{}, "Filter by status")
 	listCmd.Flags().StringSlice("priority", []string{}, "Filter by priority")
 	listCmd.Flags().StringSlice("risk", []string{}, "Filter by risk level")
+	listCmd.Flags().StringSlice("label", []string{}, "Filter by label")
+	listCmd.Flags().String("assignee", "", "Filter by assignee (user ID or \"me\")")
+	listCmd.Flags().String("search", "", "Search ticket number, title, and description")
 	listCmd.Flags().Bool("mine", false, "Show only tickets created by me")
 	listCmd.Flags().Bool("assigned", false, "Show only tickets assigned to me")
-	listCmd.Flags().Int("limit", 50, "Maximum number of tickets to display")
-	listCmd.Flags().String("sort", "created_at", "Sort field (created_at, updated_at, priority)")
+	listCmd.Flags().StringSlice("columns", defaultListColumns, "Columns to show")
+	listCmd.Flags().Int("limit", 50, "Maximum number of tickets per page (API maximum 100)")
+	listCmd.Flags().Int("page", 1, "Page of results to show")
+	listCmd.Flags().String("sort", "created_at", "Sort field (created_at, updated_at, priority, status, ticket_number, title)")
 	listCmd.Flags().Bool("desc", true, "Sort descending")
+	listCmd.Flags().String("view", "", "Start from a saved filter, yours or a shared one (its ID, or \"default\"); other filters refine it")
+	listCmd.Flags().Bool("local", false, "List the local tickets directory instead of the API")
+}
+
+// listRow is one ticket as the list table shows it
+type listRow struct {
+	Number   string
+	Status   string
+	Priority string
+	Risk     string
+	Type     string
+	Title    string
+	Assignee string
+	Created  time.Time
+	Updated  time.Time
+}
+
+// listColumn renders one column of the list table
+type listColumn struct {
+	Header string
+	Value  func(r listRow) string
+}
+
+var listColumns = map[string]listColumn{
+	"ticket":   {"TICKET", func(r listRow) string { return r.Number }},
+	"status":   {"STATUS", func(r listRow) string { return r.Status }},
+	"priority": {"PRIORITY", func(r listRow) string { return r.Priority }},
+	"risk":     {"RISK", func(r listRow) string { return r.Risk }},
+	"type":     {"TYPE", func(r listRow) string { return r.Type }},
+	"title": {"TITLE", func(r listRow) string {
+		if len(r.Title) > 40 {
+			return r.Title[:37] + "..."
+		}
+		return r.Title
+	}},
+	"assignee": {"ASSIGNEE", func(r listRow) string { return r.Assignee }},
+	"created":  {"CREATED", func(r listRow) string { return formatListDate(r.Created) }},
+	"updated":  {"UPDATED", func(r listRow) string { return formatListDate(r.Updated) }},
+}
+
+var defaultListColumns = []string{"ticket", "status", "priority", "title", "created"}
+
+func listColumnNames() []string {
+	names := make([]string, 0, len(listColumns))
+	for name := range listColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatListDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
 }
 
 // getTicketsDir returns the path to the tickets directory
 func getTicketsDir() string {
-	// First try current directory
+	// tickets_dir in the config wins
+	if dir := viper.GetString("tickets_dir"); dir != "" {
+		if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				return filepath.Join(home, rest)
+			}
+		}
+		return dir
+	}
+	// Then try current directory
 	if _, err := os.Stat("tickets"); err == nil {
 		return "tickets"
 	}
@@ -116,49 +201,167 @@ func loadLocalTickets() ([]LocalTicket, error) {
 }
 
 func runList(cmd *cobra.Command, args []string) {
+	columns, _ := cmd.Flags().GetStringSlice("columns")
+	localOnly, _ := cmd.Flags().GetBool("local")
+
+	for _, name := range columns {
+		if _, ok := listColumns[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown column %q (available: %s)\n", name, strings.Join(listColumnNames(), ", "))
+			os.Exit(exitcode.Validation)
+		}
+	}
+
+	if !localOnly {
+		client, err := apiclient.New()
+		if err == nil {
+			runRemoteList(cmd, client, columns)
+			return
+		}
+		if !errors.Is(err, apiclient.ErrNotAuthenticated) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		fmt.Fprintln(os.Stderr, "Not logged in; listing the local tickets directory")
+	}
+
+	runLocalList(cmd, columns)
+}
+
+// runRemoteList lists one page of tickets from the API
+func runRemoteList(cmd *cobra.Command, client *apiclient.Client, columns []string) {
 	statusFilter, _ := cmd.Flags().GetStringSlice("status")
 	priorityFilter, _ := cmd.Flags().GetStringSlice("priority")
+	riskFilter, _ := cmd.Flags().GetStringSlice("risk")
+	labels, _ := cmd.Flags().GetStringSlice("label")
+	assignee, _ := cmd.Flags().GetString("assignee")
+	search, _ := cmd.Flags().GetString("search")
+	mine, _ := cmd.Flags().GetBool("mine")
+	assigned, _ := cmd.Flags().GetBool("assigned")
 	limit, _ := cmd.Flags().GetInt("limit")
+	page, _ := cmd.Flags().GetInt("page")
+	sortField, _ := cmd.Flags().GetString("sort")
+	descending, _ := cmd.Flags().GetBool("desc")
+	view, _ := cmd.Flags().GetString("view")
+
+	if assigned && assignee == "" {
+		assignee = "me"
+	}
+
+	query := url.Values{}
+	if view != "" {
+		query.Set("view", view)
+	}
+	setList := func(key string, values []string) {
+		if len(values) > 0 {
+			query.Set(key, strings.Join(values, ","))
+		}
+	}
+	setList("status", statusFilter)
+	setList("priority", priorityFilter)
+	setList("risk_level", riskFilter)
+	setList("labels", labels)
+	if assignee != "" {
+		query.Set("assigned_to", assignee)
+	}
+	if mine {
+		query.Set("created_by", "me")
+	}
+	if search != "" {
+		query.Set("search", search)
+	}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(limit))
+	// A saved view keeps its own order unless asked otherwise
+	if view == "" || cmd.Flags().Changed("sort") || cmd.Flags().Changed("desc") {
+		query.Set("sort_by", sortField)
+		if descending {
+			query.Set("sort_order", "desc")
+		} else {
+			query.Set("sort_order", "asc")
+		}
+	}
+
+	list, err := client.ListTickets(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing tickets: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(list)
+		return
+	}
+
+	rows := make([]listRow, 0, len(list.Tickets))
+	for _, t := range list.Tickets {
+		row := listRow{
+			Number:   t.TicketNumber,
+			Status:   string(t.Status),
+			Priority: string(t.Priority),
+			Risk:     string(t.RiskLevel),
+			Type:     deref(t.ChangeType),
+			Title:    t.Title,
+			Assignee: "-",
+			Created:  t.CreatedAt,
+			Updated:  t.UpdatedAt,
+		}
+		switch {
+		case t.Assignee != nil:
+			row.Assignee = t.Assignee.Email
+		case t.AssignedTo != nil:
+			row.Assignee = t.AssignedTo.String()[:8]
+		}
+		rows = append(rows, row)
+	}
+	printListTable(rows, columns)
+
+	if len(rows) == 0 {
+		fmt.Println("\nNo tickets found.")
+		return
+	}
+	perPage := list.PerPage
+	if perPage < 1 {
+		perPage = limit
+	}
+	pages := (list.Total + perPage - 1) / perPage
+	fmt.Printf("\n%d ticket(s) found, page %d of %d.", list.Total, page, pages)
+	if page < pages {
+		fmt.Printf(" Use --page %d for more.", page+1)
+	}
+	fmt.Println()
+}
+
+// runLocalList lists tickets from the local tickets directory
+func runLocalList(cmd *cobra.Command, columns []string) {
+	statusFilter, _ := cmd.Flags().GetStringSlice("status")
+	priorityFilter, _ := cmd.Flags().GetStringSlice("priority")
+	riskFilter, _ := cmd.Flags().GetStringSlice("risk")
+	search, _ := cmd.Flags().GetString("search")
+	limit, _ := cmd.Flags().GetInt("limit")
+	page, _ := cmd.Flags().GetInt("page")
 	sortField, _ := cmd.Flags().GetString("sort")
 	descending, _ := cmd.Flags().GetBool("desc")
 
 	tickets, err := loadLocalTickets()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading tickets: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	// Apply filters
 	var filtered []LocalTicket
 	for _, t := range tickets {
-		// Status filter
-		if len(statusFilter) > 0 {
-			match := false
-			for _, s := range statusFilter {
-				if strings.EqualFold(t.Status, s) {
-					match = true
-					break
-				}
-			}
-			if !match {
-				continue
-			}
+		if !matchesAny(t.Status, statusFilter) || !matchesAny(t.Priority, priorityFilter) || !matchesAny(t.Risk, riskFilter) {
+			continue
 		}
-
-		// Priority filter
-		if len(priorityFilter) > 0 {
-			match := false
-			for _, p := range priorityFilter {
-				if strings.EqualFold(t.Priority, p) {
-					match = true
-					break
-				}
-			}
-			if !match {
+		if search != "" {
+			needle := strings.ToLower(search)
+			if !strings.Contains(strings.ToLower(t.ID), needle) &&
+				!strings.Contains(strings.ToLower(t.Title), needle) &&
+				!strings.Contains(strings.ToLower(t.Description), needle) {
 				continue
 			}
 		}
-
 		filtered = append(filtered, t)
 	}
 
@@ -171,6 +374,12 @@ func runList(cmd *cobra.Command, args []string) {
 			less = priorityOrder[strings.ToLower(filtered[i].Priority)] < priorityOrder[strings.ToLower(filtered[j].Priority)]
 		case "updated_at":
 			less = filtered[i].UpdatedAt.Before(filtered[j].UpdatedAt)
+		case "status":
+			less = filtered[i].Status < filtered[j].Status
+		case "ticket_number":
+			less = filtered[i].ID < filtered[j].ID
+		case "title":
+			less = filtered[i].Title < filtered[j].Title
 		default: // created_at
 			less = filtered[i].CreatedAt.Before(filtered[j].CreatedAt)
 		}
@@ -180,29 +389,81 @@ func runList(cmd *cobra.Command, args []string) {
 		return less
 	})
 
-	// Apply limit
-	if limit > 0 && len(filtered) > limit {
-		filtered = filtered[:limit]
+	total := len(filtered)
+
+	// Apply paging
+	if limit > 0 {
+		start := (page - 1) * limit
+		if start > len(filtered) {
+			start = len(filtered)
+		}
+		end := start + limit
+		if end > len(filtered) {
+			end = len(filtered)
+		}
+		filtered = filtered[start:end]
 	}
 
-	// Output
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "TICKET\tSTATUS\tPRIORITY\tTITLE\tCREATED")
-	fmt.Fprintln(w, "------\t------\t--------\t-----\t-------")
+	if output.Structured() {
+		output.Print(filtered)
+		return
+	}
 
+	rows := make([]listRow, 0, len(filtered))
 	for _, t := range filtered {
-		title := t.Title
-		if len(title) > 40 {
-			title = title[:37] + "..."
-		}
-		created := t.CreatedAt.Format("2006-01-02")
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, t.Status, t.Priority, title, created)
+		rows = append(rows, listRow{
+			Number:   t.ID,
+			Status:   t.Status,
+			Priority: t.Priority,
+			Risk:     t.Risk,
+			Type:     t.Type,
+			Title:    t.Title,
+			Assignee: orDash(t.Assignee),
+			Created:  t.CreatedAt,
+			Updated:  t.UpdatedAt,
+		})
 	}
-	w.Flush()
+	printListTable(rows, columns)
 
-	if len(filtered) == 0 {
+	if total == 0 {
 		fmt.Println("\nNo tickets found.")
 	} else {
-		fmt.Printf("\n%d ticket(s) found.\n", len(filtered))
+		fmt.Printf("\n%d ticket(s) found.\n", total)
+	}
+}
+
+// matchesAny reports whether value equals one of filter, ignoring case. An
+// empty filter matches everything.
+func matchesAny(value string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
 	}
+	for _, f := range filter {
+		if strings.EqualFold(value, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func printListTable(rows []listRow, columns []string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	headers := make([]string, len(columns))
+	rules := make([]string, len(columns))
+	for i, name := range columns {
+		headers[i] = listColumns[name].Header
+		rules[i] = strings.Repeat("-", len(headers[i]))
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	fmt.Fprintln(w, strings.Join(rules, "\t"))
+
+	for _, r := range rows {
+		values := make([]string, len(columns))
+		for i, name := range columns {
+			values[i] = listColumns[name].Value(r)
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	w.Flush()
 }
@@ -2,7 +2,10 @@ package ticket
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +16,8 @@ var openCmd = &cobra.Command{
 	Long: `Reopen a previously closed change ticket.
 
 This may be necessary if issues are discovered after
-implementation that require additional changes.
+implementation that require additional changes. The ticket
+returns to update_requested and the reason is posted as a comment.
 
 Examples:
   # Reopen a ticket
@@ -34,13 +38,29 @@ func runOpen(cmd *cobra.Command, args []string) {
 	ticketNumber := args[0]
 	reason, _ := cmd.Flags().GetString("reason")
 
-	// TODO: Implement API call to reopen ticket
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	wt, err := resolveWorkflowTicket(client, ticketNumber, false)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, nil, "reopen", "", "Reopened: "+reason, false, true) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
 
-	fmt.Printf("Reopening ticket %s...\n", ticketNumber)
-	fmt.Printf("Reason: %s\n", reason)
-	fmt.Println()
-	fmt.Println("Ticket reopened successfully!")
-	fmt.Println("Status changed to: update_requested")
+	result, err := wt.transition(client, "reopen", "", "Reopened: "+reason)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, wt.remote, "reopen", "", "Reopened: "+reason, false, true) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: failed to reopen ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	printTransition(wt.remote, result)
 }
 
 var cancelCmd = &cobra.Command{
@@ -71,20 +91,35 @@ func runCancel(cmd *cobra.Command, args []string) {
 	force, _ := cmd.Flags().GetBool("force")
 	reason, _ := cmd.Flags().GetString("reason")
 
-	// TODO: Implement API call to cancel ticket
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	wt, err := resolveWorkflowTicket(client, ticketNumber, false)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, nil, "cancel", reason, "", false, force) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
 
 	if !force {
-		fmt.Printf("Cancel ticket %s? This cannot be undone. [y/N] ", ticketNumber)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
+		prompt := fmt.Sprintf("Cancel ticket %s (%s)? This cannot be undone.", wt.remote.TicketNumber, wt.remote.Title)
+		if ok, err := newPrompter().confirm(prompt, false); err != nil || !ok {
 			fmt.Println("Cancelled")
 			return
 		}
 	}
 
-	fmt.Printf("Cancelling ticket %s...\n", ticketNumber)
-	fmt.Printf("Reason: %s\n", reason)
-	fmt.Println()
-	fmt.Println("Ticket cancelled successfully!")
+	result, err := wt.transition(client, "cancel", reason, "")
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, wt.remote, "cancel", reason, "", false, true) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: failed to cancel ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	printTransition(wt.remote, result)
 }
@@ -0,0 +1,540 @@
+package ticket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// OutboxCmd represents the outbox command group. It is a top-level command
+// but lives here because replaying the outbox is ticket workflow.
+var OutboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "Show and send changes queued while the API was unreachable",
+	Long: `Ticket changes made while the API can't be reached are queued in
+~/.adsops-utils/outbox.json instead of failing: submitting, closing,
+cancelling and reopening tickets, uploading local drafts, and comments.
+
+The next command run once the API is reachable sends them, in the order they
+were made. A queued status change is held back as a conflict when the ticket
+changed in the API since it was last synced, or no longer allows the change;
+review it, then send it anyway with 'changes outbox push --force <id>' or drop
+it. Later changes to the same ticket wait behind one that wasn't sent.
+
+Examples:
+  # Show what is queued
+  changes outbox
+
+  # Send the queue now, retrying entries that failed before
+  changes outbox push
+
+  # Send a conflicting entry anyway
+  changes outbox push --force 3f2a9c1e
+
+  # Forget an entry
+  changes outbox drop 3f2a9c1e`,
+	Args: cobra.NoArgs,
+	Run:  runOutboxList,
+}
+
+var outboxListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List queued changes",
+	Args:    cobra.NoArgs,
+	Run:     runOutboxList,
+}
+
+var outboxPushCmd = &cobra.Command{
+	Use:   "push [id...]",
+	Short: "Send queued changes now",
+	Run:   runOutboxPush,
+}
+
+var outboxDropCmd = &cobra.Command{
+	Use:     "drop <id>...",
+	Aliases: []string{"rm"},
+	Short:   "Remove queued changes without sending them",
+	Run:     runOutboxDrop,
+}
+
+// outboxProbeTimeout bounds the check made before replaying the outbox, so
+// commands stay quick while the API is still unreachable
+const outboxProbeTimeout = 3 * time.Second
+
+// Outbox entry kinds
+const (
+	outboxCreate     = "create"     // upload a local draft
+	outboxTransition = "transition" // submit, close, cancel or reopen
+	outboxComment    = "comment"
+)
+
+// Outbox entry states
+const (
+	outboxPending  = "pending"
+	outboxConflict = "conflict"
+	outboxFailed   = "failed"
+)
+
+// transitionTargets is the status each workflow action moves a ticket to
+var transitionTargets = map[string]models.TicketStatus{
+	"submit": models.TicketStatusSubmitted,
+	"close":  models.TicketStatusClosed,
+	"cancel": models.TicketStatusCancelled,
+	"reopen": models.TicketStatusUpdateRequested,
+}
+
+// outboxEntry is one queued change
+type outboxEntry struct {
+	ID     string `json:"id"`     // also the Idempotency-Key for comments
+	Kind   string `json:"kind"`   // create, transition or comment
+	Ticket string `json:"ticket"` // as given: a ticket number or local ID
+	Action string `json:"action,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Text   string `json:"text,omitempty"` // the comment, or the note posted with a transition
+
+	Internal bool `json:"internal,omitempty"` // an internal comment
+
+	// BaseVersion is the ticket's version when it was last seen, 0 when
+	// unknown; a transition is a conflict once the ticket has moved on
+	BaseVersion int `json:"base_version,omitempty"`
+
+	APIURL    string    `json:"api_url"`
+	QueuedAt  time.Time `json:"queued_at"`
+	State     string    `json:"state"`
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// outbox is the queue of changes waiting for the API
+type outbox struct {
+	Entries []*outboxEntry `json:"entries"`
+}
+
+// errOutboxConflict marks a queued change the ticket has moved past
+type errOutboxConflict struct{ msg string }
+
+func (e *errOutboxConflict) Error() string { return e.msg }
+
+func init() {
+	outboxPushCmd.Flags().Bool("force", false, "Send conflicting entries anyway")
+	outboxDropCmd.Flags().Bool("all", false, "Drop every queued change")
+
+	OutboxCmd.AddCommand(outboxListCmd)
+	OutboxCmd.AddCommand(outboxPushCmd)
+	OutboxCmd.AddCommand(outboxDropCmd)
+}
+
+func outboxPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".adsops-utils", "outbox.json"), nil
+}
+
+func loadOutbox() (*outbox, error) {
+	ob := &outbox{}
+	path, err := outboxPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ob, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	if err := json.Unmarshal(data, ob); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return ob, nil
+}
+
+func (ob *outbox) save() error {
+	path, err := outboxPath()
+	if err != nil {
+		return err
+	}
+	if len(ob.Entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(ob, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// forAPI returns the entries queued for the API the CLI points at
+func (ob *outbox) forAPI() []*outboxEntry {
+	apiURL := apiclient.APIURL()
+	var entries []*outboxEntry
+	for _, e := range ob.Entries {
+		if e.APIURL == apiURL {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// find returns the entries whose IDs start with each of ids
+func (ob *outbox) find(ids []string) ([]*outboxEntry, error) {
+	var found []*outboxEntry
+	for _, id := range ids {
+		var matches []*outboxEntry
+		for _, e := range ob.Entries {
+			if strings.HasPrefix(e.ID, strings.ToLower(id)) {
+				matches = append(matches, e)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, exitcode.Wrap(exitcode.NotFound, fmt.Errorf("no queued change %s", id))
+		case 1:
+			found = append(found, matches[0])
+		default:
+			return nil, exitcode.Wrap(exitcode.Validation, fmt.Errorf("%s matches %d queued changes; give more of the ID", id, len(matches)))
+		}
+	}
+	return found, nil
+}
+
+func (ob *outbox) remove(entry *outboxEntry) {
+	for i, e := range ob.Entries {
+		if e == entry {
+			ob.Entries = append(ob.Entries[:i], ob.Entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// describe is a one-line summary of the change
+func (e *outboxEntry) describe() string {
+	switch e.Kind {
+	case outboxCreate:
+		return "upload local draft " + e.Ticket
+	case outboxComment:
+		return fmt.Sprintf("comment on %s: %q", e.Ticket, truncate(e.Text, 40))
+	}
+	return e.Action + " " + e.Ticket
+}
+
+// queueChanges adds entries to the outbox and tells the user
+func queueChanges(cause error, entries ...*outboxEntry) {
+	ob, err := loadOutbox()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	now := time.Now().UTC()
+	for _, e := range entries {
+		e.ID = uuid.NewString()
+		e.APIURL = apiclient.APIURL()
+		e.QueuedAt = now
+		e.State = outboxPending
+		ob.Entries = append(ob.Entries, e)
+	}
+	if err := ob.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to save outbox: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	fmt.Fprintf(os.Stderr, "The API can't be reached: %v\n", cause)
+	if output.Structured() {
+		output.Print(map[string]interface{}{"queued": entries})
+	} else {
+		for _, e := range entries {
+			fmt.Printf("Queued: %s (%s)\n", e.describe(), e.ID[:8])
+		}
+		fmt.Println("It will be sent the next time the API is reachable; see 'changes outbox'.")
+	}
+}
+
+// queueTransitionOffline queues a workflow action that failed because the
+// API can't be reached, and reports whether it did. remote is the ticket
+// when it was fetched before the connection was lost. With upload set, a
+// local draft that was never synced is queued for upload first. Unless
+// confirmed, the user is asked before anything is queued.
+func queueTransitionOffline(err error, ref string, remote *models.Ticket, action, reason, note string, upload, confirmed bool) bool {
+	if !apiclient.IsUnreachable(err) {
+		return false
+	}
+
+	entry := &outboxEntry{Kind: outboxTransition, Ticket: ref, Action: action, Reason: reason, Text: note}
+	var entries []*outboxEntry
+	if remote != nil {
+		entry.Ticket = remote.TicketNumber
+		entry.BaseVersion = remote.Version
+	} else if state, serr := loadSyncState(getTicketsDir()); serr == nil {
+		if tracked, ok := state.Tickets[ref]; ok {
+			entry.BaseVersion = tracked.Version
+		} else if _, ferr := os.Stat(filepath.Join(getTicketsDir(), ref+".json")); ferr == nil && upload {
+			entries = append(entries, &outboxEntry{Kind: outboxCreate, Ticket: ref})
+		}
+	}
+	entries = append(entries, entry)
+
+	if !confirmed {
+		fmt.Fprintf(os.Stderr, "The API can't be reached: %v\n", err)
+		ok, perr := newPrompter().confirm(fmt.Sprintf("Queue %s to send when it is back?", entry.describe()), false)
+		if perr != nil || !ok {
+			fmt.Println("Cancelled")
+			return true
+		}
+	}
+	queueChanges(err, entries...)
+	return true
+}
+
+// ReplayOutbox sends the changes queued for the current API, when there are
+// any and the API answers, reporting what happened on stderr. Entries that
+// conflicted or failed before wait for 'changes outbox push'.
+func ReplayOutbox() {
+	ob, err := loadOutbox()
+	if err != nil {
+		return
+	}
+	pending := 0
+	for _, e := range ob.forAPI() {
+		if e.State == outboxPending {
+			pending++
+		}
+	}
+	if pending == 0 {
+		return
+	}
+
+	client, err := apiclient.New()
+	if err != nil || !apiReachable(client) {
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "%d queued change(s) are waiting for the API\n", pending)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Sending %d change(s) queued while the API was unreachable\n", pending)
+	replayOutbox(client, ob, ob.forAPI(), false, false, os.Stderr)
+}
+
+// apiReachable checks quickly whether the API answers
+func apiReachable(client *apiclient.Client) bool {
+	probe := *client
+	probe.HTTPClient = &http.Client{Timeout: outboxProbeTimeout}
+	err := probe.Get("/health", nil, nil)
+	return err == nil || !apiclient.IsUnreachable(err)
+}
+
+// replayOutbox sends entries in order and reports each to w. It stops at the
+// first one the API can't be reached for. An entry that isn't sent holds
+// back later ones for the same ticket, so changes still apply in the order
+// they were made. retry also sends entries that conflicted or failed
+// before; force sends conflicting ones regardless. It returns how many
+// entries were sent and how many remain.
+func replayOutbox(client *apiclient.Client, ob *outbox, entries []*outboxEntry, retry, force bool, w io.Writer) (sent, remaining int) {
+	held := map[string]bool{}
+	for i, e := range entries {
+		if held[e.Ticket] || (e.State != outboxPending && !retry) {
+			held[e.Ticket] = true
+			remaining++
+			continue
+		}
+
+		e.Attempts++
+		msg, err := e.replay(client, force)
+		var conflict *errOutboxConflict
+		switch {
+		case err == nil:
+			ob.remove(e)
+			sent++
+			fmt.Fprintf(w, "  sent     %s: %s\n", e.describe(), msg)
+		case apiclient.IsUnreachable(err):
+			e.LastError = err.Error()
+			fmt.Fprintf(w, "  waiting  %s: the API can't be reached\n", e.describe())
+			ob.save()
+			return sent, remaining + len(entries) - i
+		case errors.As(err, &conflict):
+			e.State, e.LastError = outboxConflict, err.Error()
+			held[e.Ticket] = true
+			remaining++
+			fmt.Fprintf(w, "  conflict %s: %v; send it anyway with 'changes outbox push --force %s' or drop it\n", e.describe(), err, e.ID[:8])
+		default:
+			e.State, e.LastError = outboxFailed, err.Error()
+			held[e.Ticket] = true
+			remaining++
+			fmt.Fprintf(w, "  failed   %s: %v\n", e.describe(), err)
+		}
+		if serr := ob.save(); serr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save outbox: %v\n", serr)
+		}
+	}
+	return sent, remaining
+}
+
+// replay sends one entry and describes the outcome
+func (e *outboxEntry) replay(client *apiclient.Client, force bool) (string, error) {
+	switch e.Kind {
+	case outboxCreate:
+		wt, err := resolveWorkflowTicket(client, e.Ticket, true)
+		if err != nil {
+			return "", err
+		}
+		return "now " + wt.remote.TicketNumber, nil
+
+	case outboxComment:
+		wt, err := resolveWorkflowTicket(client, e.Ticket, false)
+		if err != nil {
+			return "", err
+		}
+		if err := client.AddComment(wt.remote.ID, models.CreateCommentInput{Comment: e.Text, IsInternal: e.Internal}, e.ID); err != nil {
+			return "", err
+		}
+		return "posted", nil
+
+	case outboxTransition:
+		target, ok := transitionTargets[e.Action]
+		if !ok {
+			return "", fmt.Errorf("unknown action %q", e.Action)
+		}
+		wt, err := resolveWorkflowTicket(client, e.Ticket, false)
+		if err != nil {
+			return "", err
+		}
+		if wt.remote.Status == target {
+			// Sent before, but the response never arrived
+			return "already " + string(target), nil
+		}
+		if !force {
+			if e.BaseVersion != 0 && wt.remote.Version != e.BaseVersion {
+				return "", &errOutboxConflict{fmt.Sprintf("%s changed since this was queued (version %d, now %d, %s)", wt.remote.TicketNumber, e.BaseVersion, wt.remote.Version, wt.remote.Status)}
+			}
+			if models.Transitions.Validate(wt.remote.Status, target, nil) != nil {
+				return "", &errOutboxConflict{fmt.Sprintf("%s is now %s and can't be moved to %s", wt.remote.TicketNumber, wt.remote.Status, target)}
+			}
+		}
+		result, err := wt.transition(client, e.Action, e.Reason, e.Text)
+		if err != nil {
+			return "", err
+		}
+		return strings.ToLower(result.Message), nil
+	}
+	return "", fmt.Errorf("unknown kind %q", e.Kind)
+}
+
+func runOutboxList(cmd *cobra.Command, args []string) {
+	ob, err := loadOutbox()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(ob.Entries)
+		return
+	}
+	if len(ob.Entries) == 0 {
+		fmt.Println("Nothing queued")
+		return
+	}
+
+	apiURL := apiclient.APIURL()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tQUEUED\tSTATE\tCHANGE\tLAST ERROR")
+	fmt.Fprintln(w, "--\t------\t-----\t------\t----------")
+	for _, e := range ob.Entries {
+		change := e.describe()
+		if e.APIURL != apiURL {
+			change += " [" + e.APIURL + "]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.ID[:8], e.QueuedAt.Local().Format("2006-01-02 15:04"), e.State, change, orDash(truncate(e.LastError, 60)))
+	}
+	w.Flush()
+}
+
+func runOutboxPush(cmd *cobra.Command, args []string) {
+	force, _ := cmd.Flags().GetBool("force")
+
+	ob, err := loadOutbox()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	entries := ob.forAPI()
+	if len(args) > 0 {
+		if entries, err = ob.find(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Println("Nothing queued for " + apiclient.APIURL())
+		return
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	sent, remaining := replayOutbox(client, ob, entries, true, force, os.Stdout)
+	fmt.Printf("\nSent %d, %d still queued\n", sent, remaining)
+	if remaining > 0 {
+		for _, e := range entries {
+			if e.State == outboxConflict {
+				os.Exit(exitcode.Conflict)
+			}
+		}
+		os.Exit(exitcode.Unavailable)
+	}
+}
+
+func runOutboxDrop(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	if !all && len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: give the IDs of the changes to drop, or --all")
+		os.Exit(exitcode.Usage)
+	}
+
+	ob, err := loadOutbox()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	entries := ob.Entries
+	if !all {
+		if entries, err = ob.find(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+	for _, e := range append([]*outboxEntry(nil), entries...) {
+		ob.remove(e)
+		fmt.Printf("Dropped: %s\n", e.describe())
+	}
+	if err := ob.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to save outbox: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+}
@@ -3,10 +3,12 @@ package ticket
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +19,9 @@ var pdfCmd = &cobra.Command{
 
 This command reads ticket JSON files from the local tickets directory
 and generates corresponding PDF documents for documentation or printing.
+Documents run over as many pages as they need, with the approvals in a
+table. To include the full comment thread and audit history of a ticket in
+the API, use 'changes ticket export --format pdf' instead.
 
 Examples:
   # Generate PDF for a single ticket
@@ -56,7 +61,7 @@ func runPDF(cmd *cobra.Command, args []string) {
 		entries, err := os.ReadDir(ticketsDir)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading tickets directory: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitcode.For(err))
 		}
 		for _, entry := range entries {
 			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && strings.HasPrefix(entry.Name(), "CHG-") {
@@ -77,7 +82,7 @@ func runPDF(cmd *cobra.Command, args []string) {
 	} else {
 		fmt.Println("Usage: changes ticket pdf [ticket-id...] or changes ticket pdf --all")
 		fmt.Println("Run 'changes ticket pdf --help' for more information.")
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 
 	if len(files) == 0 {
@@ -116,8 +121,8 @@ func runPDF(cmd *cobra.Command, args []string) {
 			continue
 		}
 
-		var ticketData map[string]interface{}
-		if err := json.Unmarshal(data, &ticketData); err != nil {
+		var ticket CreateTicketData
+		if err := json.Unmarshal(data, &ticket); err != nil {
 			fmt.Printf("FAILED (parse error: %v)\n", err)
 			failed++
 			continue
@@ -133,7 +138,9 @@ func runPDF(cmd *cobra.Command, args []string) {
 		}
 
 		// Generate PDF
-		if err := generateTicketPDF(ticketData, pdfPath); err != nil {
+		view := viewFromLocal(&ticket, math.MaxInt)
+		view.Source = "local file " + file
+		if err := writeDocument(pdfPath, view, writePDF); err != nil {
 			fmt.Printf("FAILED (%v)\n", err)
 			failed++
 			continue
@@ -0,0 +1,479 @@
+package ticket
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ticketSchema is the published JSON schema for local ticket files, printed
+// by 'changes ticket validate --print-schema'
+//
+//go:embed ticket.schema.json
+var ticketSchema []byte
+
+// schema is the subset of JSON Schema that ticket.schema.json uses. Schemas
+// using other keywords are rejected rather than half-checked.
+type schema struct {
+	Schema      string             `json:"$schema"`
+	ID          string             `json:"$id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Type        schemaTypes        `json:"type"`
+	Required    []string           `json:"required"`
+	Properties  map[string]*schema `json:"properties"`
+	Items       *schema            `json:"items"`
+	Enum        []interface{}      `json:"enum"`
+	Const       *json.RawMessage   `json:"const"`
+	MinLength   *int               `json:"minLength"`
+	MaxLength   *int               `json:"maxLength"`
+	MinItems    *int               `json:"minItems"`
+	Pattern     string             `json:"pattern"`
+	Format      string             `json:"format"`
+	Contains    *schema            `json:"contains"`
+	AllOf       []*schema          `json:"allOf"`
+	AnyOf       []*schema          `json:"anyOf"`
+	If          *schema            `json:"if"`
+	Then        *schema            `json:"then"`
+	Else        *schema            `json:"else"`
+	Not         *schema            `json:"not"`
+
+	pattern  *regexp.Regexp
+	constVal interface{}
+}
+
+// schemaTypes is the type keyword, which is a name or a list of names
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("type must be a string or a list of strings")
+	}
+	*t = many
+	return nil
+}
+
+func (t schemaTypes) has(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaError is one way a document fails its schema. Path is a JSON
+// pointer to the offending value.
+type schemaError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e schemaError) String() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// parseSchema reads a schema and compiles its patterns
+func parseSchema(data []byte) (*schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var s schema
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("unsupported or invalid schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *schema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	if s.Const != nil {
+		if err := json.Unmarshal(*s.Const, &s.constVal); err != nil {
+			return err
+		}
+	}
+	if s.Format != "" && s.Format != "date-time" {
+		return fmt.Errorf("unsupported format %q", s.Format)
+	}
+	subs := []*schema{s.Items, s.Contains, s.If, s.Then, s.Else, s.Not}
+	subs = append(subs, s.AllOf...)
+	subs = append(subs, s.AnyOf...)
+	for _, p := range s.Properties {
+		subs = append(subs, p)
+	}
+	for _, sub := range subs {
+		if err := sub.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enumStrings is the schema's enum as strings, or the enum of its items for
+// an array of enum values. For anyOf it is the enums of all the choices.
+func (s *schema) enumStrings() []string {
+	if s == nil {
+		return nil
+	}
+	if len(s.Enum) == 0 && s.Items != nil {
+		return s.Items.enumStrings()
+	}
+	var values []string
+	for _, choice := range s.AnyOf {
+		values = append(values, choice.enumStrings()...)
+	}
+	for _, v := range s.Enum {
+		if str, ok := v.(string); ok {
+			values = append(values, str)
+		}
+	}
+	return values
+}
+
+// validate checks v, a document from decodeJSON, against the schema
+func (s *schema) validate(v interface{}, path string) []schemaError {
+	if s == nil {
+		return nil
+	}
+	var errs []schemaError
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, schemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !typeMatches(s.Type, v) {
+		fail("must be %s, not %s", strings.Join(s.Type, " or "), jsonType(v))
+		return errs
+	}
+	if len(s.Enum) > 0 && !containsValue(s.Enum, v) {
+		fail("%s is not one of %s", jsonText(v), joinValues(s.Enum))
+	}
+	if s.Const != nil && !sameValue(s.constVal, v) {
+		fail("must be %s", jsonText(s.constVal))
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters (is %d)", *s.MinLength, n)
+			}
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters (is %d)", *s.MaxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("%s does not match %s", jsonText(v), s.Pattern)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("%s is not an RFC 3339 date-time", jsonText(v))
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			if *s.MinItems == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must have at least %d items", *s.MinItems)
+			}
+		}
+		for i, item := range v {
+			errs = append(errs, s.Items.validate(item, fmt.Sprintf("%s/%d", path, i))...)
+		}
+		if s.Contains != nil {
+			found := false
+			for _, item := range v {
+				if len(s.Contains.validate(item, "")) == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				fail("must contain %s", s.Contains.describe())
+			}
+		}
+	case *jsonObject:
+		for _, name := range s.Required {
+			if _, ok := v.values[name]; !ok {
+				errs = append(errs, schemaError{Path: path + "/" + pointerEscape(name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return v.index(names[i]) < v.index(names[j]) })
+		for _, name := range names {
+			if value, ok := v.values[name]; ok {
+				errs = append(errs, s.Properties[name].validate(value, path+"/"+pointerEscape(name))...)
+			}
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		errs = append(errs, sub.validate(v, path)...)
+	}
+	if len(s.AnyOf) > 0 {
+		errs = append(errs, s.validateAnyOf(v, path)...)
+	}
+	if s.If != nil {
+		branch := s.Else
+		if len(s.If.validate(v, path)) == 0 {
+			branch = s.Then
+		}
+		for _, e := range branch.validate(v, path) {
+			if s.Description != "" {
+				e.Message += " (" + strings.ToLower(s.Description) + ")"
+			}
+			errs = append(errs, e)
+		}
+	}
+	if s.Not != nil && len(s.Not.validate(v, path)) == 0 {
+		fail("must not match %s", s.Not.describe())
+	}
+	return errs
+}
+
+// validateAnyOf passes when any choice matches. Otherwise, when only one
+// choice takes a value of v's type, its errors are the useful ones.
+func (s *schema) validateAnyOf(v interface{}, path string) []schemaError {
+	var candidates [][]schemaError
+	var names []string
+	for _, choice := range s.AnyOf {
+		errs := choice.validate(v, path)
+		if len(errs) == 0 {
+			return nil
+		}
+		if len(choice.Type) == 0 || typeMatches(choice.Type, v) {
+			candidates = append(candidates, errs)
+		}
+		names = append(names, choice.describe())
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return []schemaError{{Path: path, Message: "must be " + strings.Join(names, " or ")}}
+}
+
+// describe names what a schema accepts, for messages about contains and not
+func (s *schema) describe() string {
+	switch {
+	case s.Const != nil:
+		return jsonText(s.constVal)
+	case len(s.Enum) > 0:
+		return "one of " + joinValues(s.Enum)
+	case s.Description != "":
+		return s.Description
+	}
+	return "a matching item"
+}
+
+func typeMatches(types schemaTypes, v interface{}) bool {
+	for _, t := range types {
+		switch got := jsonType(v); {
+		case t == got:
+			return true
+		case t == "number" && got == "integer":
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// sameValue compares scalar JSON values, which is all enum and const are
+// used for
+func sameValue(a, b interface{}) bool {
+	switch a.(type) {
+	case nil, bool, float64, string:
+		return a == b
+	}
+	return false
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if sameValue(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			parts[i] = s
+		} else {
+			parts[i] = jsonText(v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func jsonText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func pointerEscape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// jsonObject is a decoded JSON object that remembers its key order, so a
+// fixed ticket file is written back in the order it was read
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *jsonObject) index(key string) int {
+	for i, k := range o.keys {
+		if k == key {
+			return i
+		}
+	}
+	return len(o.keys)
+}
+
+func (o *jsonObject) set(key string, v interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := marshalUnescaped(k)
+		value, err := marshalUnescaped(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalUnescaped is json.Marshal without escaping <, > and &, which would
+// make a fixed file differ from the original in every such character
+func marshalUnescaped(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// decodeJSON decodes a document with objects as *jsonObject
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	return v, nil
+}
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &jsonObject{values: map[string]interface{}{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(keyTok.(string), value)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
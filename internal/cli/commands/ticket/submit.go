@@ -1,8 +1,13 @@
 package ticket
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/models"
 	"github.com/spf13/cobra"
 )
 
@@ -12,7 +17,10 @@ var submitCmd = &cobra.Command{
 	Long: `Submit a draft ticket for approval.
 
 This will trigger the approval workflow and send notifications
-to all required approvers.
+to all required approvers. A draft that only exists in the local
+tickets directory is uploaded first. The local copy is updated to
+the new status. While the API can't be reached, the submission is
+queued in the outbox (see 'changes outbox').
 
 Examples:
   # Submit a ticket
@@ -34,27 +42,46 @@ func runSubmit(cmd *cobra.Command, args []string) {
 	force, _ := cmd.Flags().GetBool("force")
 	note, _ := cmd.Flags().GetString("note")
 
-	// TODO: Implement API call to submit ticket
-
 	if !force {
-		fmt.Printf("Submit ticket %s for approval? [y/N] ", ticketNumber)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
+		ok, err := newPrompter().confirm(fmt.Sprintf("Submit ticket %s for approval?", ticketNumber), false)
+		if err != nil || !ok {
 			fmt.Println("Cancelled")
 			return
 		}
 	}
 
-	fmt.Printf("Submitting ticket %s...\n", ticketNumber)
-	if note != "" {
-		fmt.Printf("Note: %s\n", note)
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	wt, err := resolveWorkflowTicket(client, ticketNumber, true)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, nil, "submit", "", note, true, true) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if !wt.remote.CanSubmit() {
+		fmt.Fprintf(os.Stderr, "Error: %s is %s and cannot be submitted\n", wt.remote.TicketNumber, wt.remote.Status)
+		os.Exit(exitcode.Conflict)
+	}
+	// The server has the final say, as its approval rules may add approvals
+	var gaps *models.ComplianceError
+	if errors.As(models.ValidateCompliance(wt.remote, nil), &gaps) {
+		for _, issue := range gaps.Issues {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", issue)
+		}
+	}
+
+	result, err := wt.transition(client, "submit", "", note)
+	if err != nil {
+		if queueTransitionOffline(err, ticketNumber, wt.remote, "submit", "", note, false, true) {
+			os.Exit(exitcode.Unavailable)
+		}
+		fmt.Fprintf(os.Stderr, "Error: failed to submit ticket: %v\n", err)
+		os.Exit(exitcode.For(err))
 	}
-	fmt.Println()
-	fmt.Println("Ticket submitted successfully!")
-	fmt.Println()
-	fmt.Println("Approvals requested from:")
-	fmt.Println("  - Operations Team (ops-team@example.com)")
-	fmt.Println("  - IT Team (it-team@example.com)")
-	fmt.Println("  - Security Team (security@example.com)")
+	printTransition(wt.remote, result)
 }
@@ -0,0 +1,606 @@
+package ticket
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// syncStateFile records, per local ticket, the remote ticket it is linked to
+// and what both sides looked like at the last sync
+const syncStateFile = ".sync-state"
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the local tickets directory with the API",
+	Long: `Reconcile the local tickets directory with the changes API in both directions.
+
+For each local ticket file:
+  - local-only drafts are created in the API (submitted ones are submitted)
+  - local edits since the last sync are pushed
+  - remote edits since the last sync are pulled into the file
+  - tickets edited on both sides are reported as conflicts
+
+Local changes are detected by file content and remote changes by ticket
+version, against the ` + syncStateFile + ` file kept in the tickets directory.
+Resolve conflicts with --prefer local or --prefer remote.
+
+Examples:
+  # Preview what sync would do
+  changes ticket sync --dry-run
+
+  # Sync, also pulling remote tickets you created that aren't local yet
+  changes ticket sync --pull-new
+
+  # Resolve conflicts by keeping the API's version
+  changes ticket sync --prefer remote`,
+	Args: cobra.NoArgs,
+	Run:  runSync,
+}
+
+func init() {
+	syncCmd.Flags().String("dir", "", "Directory containing ticket JSON files (default: ./tickets)")
+	syncCmd.Flags().Bool("dry-run", false, "Show what would change without changing anything")
+	syncCmd.Flags().String("prefer", "", "Resolve conflicts with the local or remote version (local, remote)")
+	syncCmd.Flags().Bool("pull-new", false, "Also pull remote tickets you created that have no local file")
+}
+
+// syncState is the contents of the sync state file
+type syncState struct {
+	APIURL  string                   `json:"api_url"`
+	Tickets map[string]*syncedTicket `json:"tickets"` // keyed by local ticket ID
+}
+
+// syncedTicket is one local ticket's link to the API as of its last sync
+type syncedTicket struct {
+	RemoteID        uuid.UUID `json:"remote_id"`
+	RemoteNumber    string    `json:"remote_number"`
+	Version         int       `json:"version"`
+	RemoteUpdatedAt time.Time `json:"remote_updated_at"`
+	LocalHash       string    `json:"local_hash"`
+	SyncedAt        time.Time `json:"synced_at"`
+}
+
+// syncer carries what a sync run needs while it walks the tickets
+type syncer struct {
+	client *apiclient.Client
+	dir    string
+	state  *syncState
+	dryRun bool
+	prefer string
+
+	pushed, pulled, unchanged, conflicts, skipped, failed int
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	prefer, _ := cmd.Flags().GetString("prefer")
+	pullNew, _ := cmd.Flags().GetBool("pull-new")
+
+	if prefer != "" && prefer != "local" && prefer != "remote" {
+		fmt.Fprintln(os.Stderr, "Error: --prefer must be local or remote")
+		os.Exit(exitcode.Validation)
+	}
+	if dir == "" {
+		dir = getTicketsDir()
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	state, err := loadSyncState(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if state.APIURL != "" && state.APIURL != client.BaseURL {
+		fmt.Fprintf(os.Stderr, "Error: %s was synced with %s, not %s\n", dir, state.APIURL, client.BaseURL)
+		fmt.Fprintf(os.Stderr, "Remove %s to start over against the new API.\n", filepath.Join(dir, syncStateFile))
+		os.Exit(1)
+	}
+	state.APIURL = client.BaseURL
+
+	s := &syncer{client: client, dir: dir, state: state, dryRun: dryRun, prefer: prefer}
+
+	if dryRun {
+		fmt.Println("DRY RUN - no changes will be made")
+		fmt.Println()
+	}
+
+	ids, err := localTicketIDs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	for _, id := range ids {
+		s.syncLocal(id)
+	}
+	if pullNew {
+		s.pullNew()
+	}
+
+	if !dryRun {
+		if err := saveSyncState(dir, state); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving sync state: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Sync complete: %d pushed, %d pulled, %d unchanged, %d conflicts, %d skipped, %d failed\n",
+		s.pushed, s.pulled, s.unchanged, s.conflicts, s.skipped, s.failed)
+	if s.conflicts > 0 {
+		os.Exit(exitcode.Conflict)
+	}
+	if s.failed > 0 {
+		os.Exit(exitcode.Error)
+	}
+}
+
+// syncLocal reconciles one local ticket file with the API
+func (s *syncer) syncLocal(id string) {
+	path := filepath.Join(s.dir, id+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.report(id, "FAILED (read error: %v)", err)
+		s.failed++
+		return
+	}
+	var local CreateTicketData
+	if err := json.Unmarshal(data, &local); err != nil {
+		s.report(id, "FAILED (invalid JSON: %v)", err)
+		s.failed++
+		return
+	}
+	hash := hashBytes(data)
+
+	link, tracked := s.state.Tickets[id]
+	if !tracked {
+		s.syncUntracked(id, &local, hash)
+		return
+	}
+
+	remote, err := s.client.GetTicket(link.RemoteID)
+	if apiclient.IsNotFound(err) {
+		s.report(id, "SKIPPED (%s no longer exists in the API)", link.RemoteNumber)
+		s.skipped++
+		return
+	}
+	if err != nil {
+		s.report(id, "FAILED (%v)", err)
+		s.failed++
+		return
+	}
+
+	localChanged := hash != link.LocalHash
+	remoteChanged := remote.Version != link.Version
+
+	switch {
+	case !localChanged && !remoteChanged:
+		s.unchanged++
+	case localChanged && !remoteChanged:
+		s.push(id, &local, remote, hash)
+	case !localChanged && remoteChanged:
+		s.pull(id, &local, remote)
+	default:
+		s.resolve(id, &local, remote, hash)
+	}
+}
+
+// syncUntracked links or creates a local ticket that has never been synced.
+// A remote ticket with the same number and title is taken to be the same
+// change; anything else is new.
+func (s *syncer) syncUntracked(id string, local *CreateTicketData, hash string) {
+	if remote, err := s.client.FindTicket(id); err == nil && strings.EqualFold(remote.Title, local.Title) {
+		if len(ticketDiff(local, remote)) == 0 {
+			s.track(id, remote, hash)
+			s.report(id, "linked to %s", remote.TicketNumber)
+			s.unchanged++
+			return
+		}
+		// Neither side has a sync baseline, so both count as changed
+		s.resolve(id, local, remote, hash)
+		return
+	} else if err != nil && !apiclient.IsNotFound(err) {
+		s.report(id, "FAILED (%v)", err)
+		s.failed++
+		return
+	}
+
+	if local.Status != string(models.TicketStatusDraft) && local.Status != string(models.TicketStatusSubmitted) {
+		s.report(id, "SKIPPED (local-only %s ticket; only drafts and submitted tickets are pushed)", orDash(local.Status))
+		s.skipped++
+		return
+	}
+
+	if s.dryRun {
+		s.report(id, "would create in the API")
+		s.pushed++
+		return
+	}
+
+	created, err := s.client.CreateTicket(createInputFromLocal(local), "sync-"+id+"-"+hash[:16])
+	if err != nil {
+		s.report(id, "FAILED (create: %v)", err)
+		s.failed++
+		return
+	}
+	s.track(id, created, hash)
+	s.report(id, "created %s", created.TicketNumber)
+	s.pushed++
+}
+
+// push sends local edits to the API
+func (s *syncer) push(id string, local *CreateTicketData, remote *models.Ticket, hash string) {
+	if s.dryRun {
+		s.report(id, "would push %s", strings.Join(ticketDiff(local, remote), ", "))
+		s.pushed++
+		return
+	}
+
+	updated, err := s.client.UpdateTicket(remote.ID, updateInputFromLocal(local, remote.Version))
+	if apiclient.IsConflict(err) {
+		s.report(id, "CONFLICT (%s changed in the API during sync; run sync again)", remote.TicketNumber)
+		s.conflicts++
+		return
+	}
+	if err != nil {
+		s.report(id, "FAILED (update: %v)", err)
+		s.failed++
+		return
+	}
+	s.track(id, updated, hash)
+	s.report(id, "pushed to %s", updated.TicketNumber)
+	s.pushed++
+}
+
+// pull rewrites the local file with remote edits
+func (s *syncer) pull(id string, local *CreateTicketData, remote *models.Ticket) {
+	if s.dryRun {
+		s.report(id, "would pull %s", strings.Join(ticketDiff(local, remote), ", "))
+		s.pulled++
+		return
+	}
+
+	mergeRemote(local, remote)
+	hash, err := writeLocalTicket(s.dir, local)
+	if err != nil {
+		s.report(id, "FAILED (%v)", err)
+		s.failed++
+		return
+	}
+	s.track(id, remote, hash)
+	s.report(id, "pulled from %s", remote.TicketNumber)
+	s.pulled++
+}
+
+// resolve handles a ticket edited on both sides
+func (s *syncer) resolve(id string, local *CreateTicketData, remote *models.Ticket, hash string) {
+	fields := ticketDiff(local, remote)
+	if len(fields) == 0 {
+		// Both sides made the same edit
+		s.track(id, remote, hash)
+		s.unchanged++
+		return
+	}
+
+	switch s.prefer {
+	case "local":
+		s.push(id, local, remote, hash)
+	case "remote":
+		s.pull(id, local, remote)
+	default:
+		s.report(id, "CONFLICT (%s edited locally and in the API: %s; use --prefer local or --prefer remote)",
+			remote.TicketNumber, strings.Join(fields, ", "))
+		s.conflicts++
+	}
+}
+
+// pullNew writes local files for remote tickets the caller created that are
+// not linked to any local file yet
+func (s *syncer) pullNew() {
+	linked := map[uuid.UUID]bool{}
+	for _, link := range s.state.Tickets {
+		linked[link.RemoteID] = true
+	}
+
+	query := url.Values{"created_by": {"me"}, "per_page": {"100"}}
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		list, err := s.client.ListTickets(query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing remote tickets: %v\n", err)
+			s.failed++
+			return
+		}
+
+		for i := range list.Tickets {
+			remote := &list.Tickets[i]
+			if linked[remote.ID] {
+				continue
+			}
+			id := remote.TicketNumber
+			if _, err := os.Stat(filepath.Join(s.dir, id+".json")); err == nil {
+				s.report(id, "SKIPPED (a different local ticket already uses this number)")
+				s.skipped++
+				continue
+			}
+			if s.dryRun {
+				s.report(id, "would pull new ticket")
+				s.pulled++
+				continue
+			}
+
+			local := &CreateTicketData{ID: id, CreatedAt: remote.CreatedAt.UTC().Format(time.RFC3339)}
+			if remote.Creator != nil {
+				local.CreatedBy = remote.Creator.Email
+			}
+			mergeRemote(local, remote)
+			hash, err := writeLocalTicket(s.dir, local)
+			if err != nil {
+				s.report(id, "FAILED (%v)", err)
+				s.failed++
+				continue
+			}
+			s.track(id, remote, hash)
+			s.report(id, "pulled new ticket")
+			s.pulled++
+		}
+
+		if len(list.Tickets) < 100 || page*100 >= list.Total {
+			return
+		}
+	}
+}
+
+// track records the state of a local ticket after a sync step
+func (s *syncer) track(id string, remote *models.Ticket, localHash string) {
+	if s.dryRun {
+		return
+	}
+	s.state.Tickets[id] = &syncedTicket{
+		RemoteID:        remote.ID,
+		RemoteNumber:    remote.TicketNumber,
+		Version:         remote.Version,
+		RemoteUpdatedAt: remote.UpdatedAt,
+		LocalHash:       localHash,
+		SyncedAt:        time.Now().UTC(),
+	}
+}
+
+func (s *syncer) report(id, format string, args ...interface{}) {
+	fmt.Printf("%s  %s\n", id, fmt.Sprintf(format, args...))
+}
+
+// localTicketIDs lists the ticket files in dir by ID
+func localTicketIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tickets directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".json") && strings.HasPrefix(name, "CHG-") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func loadSyncState(dir string) (*syncState, error) {
+	state := &syncState{Tickets: map[string]*syncedTicket{}}
+	data, err := os.ReadFile(filepath.Join(dir, syncStateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	if state.Tickets == nil {
+		state.Tickets = map[string]*syncedTicket{}
+	}
+	return state, nil
+}
+
+func saveSyncState(dir string, state *syncState) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create tickets directory: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, syncStateFile), data, 0600)
+}
+
+// writeLocalTicket saves a ticket file in dir and returns the hash of what
+// was written
+func writeLocalTicket(dir string, ticket *CreateTicketData) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create tickets directory: %w", err)
+	}
+	data, err := json.MarshalIndent(ticket, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ticket: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ticket.ID+".json"), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write ticket file: %w", err)
+	}
+	return hashBytes(data), nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// createInputFromLocal maps a local ticket file onto a create request
+func createInputFromLocal(t *CreateTicketData) models.CreateTicketInput {
+	return models.CreateTicketInput{
+		Title:                 t.Title,
+		Description:           t.Description,
+		Priority:              models.TicketPriority(t.Priority),
+		RiskLevel:             models.RiskLevel(t.Risk),
+		Industry:              models.IndustryType(t.Industry),
+		ComplianceFrameworks:  toFrameworks(t.ComplianceFrameworks),
+		ChangeType:            optionalString(t.Type),
+		AffectedSystems:       t.AffectedSystems,
+		RollbackPlan:          optionalString(t.RollbackPlan),
+		TestingPlan:           optionalString(t.TestingPlan),
+		RequiresApprovalTypes: toApprovalTypes(t.ApprovalsRequired),
+		Submit:                t.Status == string(models.TicketStatusSubmitted),
+	}
+}
+
+// updateInputFromLocal maps a local ticket file onto a full update based on
+// version
+func updateInputFromLocal(t *CreateTicketData, version int) models.UpdateTicketInput {
+	priority := models.TicketPriority(t.Priority)
+	risk := models.RiskLevel(t.Risk)
+	reason := "Synced from local ticket file " + t.ID
+	return models.UpdateTicketInput{
+		Title:                 &t.Title,
+		Description:           &t.Description,
+		Priority:              &priority,
+		RiskLevel:             &risk,
+		ComplianceFrameworks:  toFrameworks(t.ComplianceFrameworks),
+		ChangeType:            optionalString(t.Type),
+		AffectedSystems:       t.AffectedSystems,
+		RollbackPlan:          optionalString(t.RollbackPlan),
+		TestingPlan:           optionalString(t.TestingPlan),
+		RequiresApprovalTypes: toApprovalTypes(t.ApprovalsRequired),
+		Version:               &version,
+		ChangeReason:          &reason,
+	}
+}
+
+// mergeRemote copies the API's version of the synced fields into a local
+// ticket, keeping local-only fields such as comments and sprint
+func mergeRemote(local *CreateTicketData, remote *models.Ticket) {
+	local.Title = remote.Title
+	local.Description = remote.Description
+	local.Status = string(remote.Status)
+	local.Priority = string(remote.Priority)
+	local.Risk = string(remote.RiskLevel)
+	local.Type = deref(remote.ChangeType)
+	local.Industry = string(remote.Industry)
+	local.ComplianceFrameworks = fromFrameworks(remote.ComplianceFrameworks)
+	local.AffectedSystems = nonNil(remote.AffectedSystems)
+	local.RollbackPlan = deref(remote.RollbackPlan)
+	local.TestingPlan = deref(remote.TestingPlan)
+	local.ApprovalsRequired = fromApprovalTypes(remote.RequiresApprovalTypes)
+	local.UpdatedAt = remote.UpdatedAt.UTC().Format(time.RFC3339)
+	if remote.Assignee != nil {
+		local.Assignee = &remote.Assignee.Email
+	}
+}
+
+// syncedField is one field sync and import --update carry between a local
+// ticket file and the API, as each side has it
+type syncedField struct {
+	Name   string
+	Local  interface{} // string or []string
+	Remote interface{}
+}
+
+// syncedFields lists the synced fields of both sides, normalized so equal
+// values compare equal
+func syncedFields(local *CreateTicketData, remote *models.Ticket) []syncedField {
+	return []syncedField{
+		{"title", local.Title, remote.Title},
+		{"description", local.Description, remote.Description},
+		{"priority", local.Priority, string(remote.Priority)},
+		{"risk", local.Risk, string(remote.RiskLevel)},
+		{"type", local.Type, deref(remote.ChangeType)},
+		{"compliance_frameworks", fromFrameworks(toFrameworks(local.ComplianceFrameworks)), fromFrameworks(remote.ComplianceFrameworks)},
+		{"affected_systems", nonNil(local.AffectedSystems), nonNil(remote.AffectedSystems)},
+		{"rollback_plan", local.RollbackPlan, deref(remote.RollbackPlan)},
+		{"testing_plan", local.TestingPlan, deref(remote.TestingPlan)},
+		{"approvals_required", fromApprovalTypes(toApprovalTypes(local.ApprovalsRequired)), fromApprovalTypes(remote.RequiresApprovalTypes)},
+	}
+}
+
+// ticketDiff names the synced fields that differ between the two sides
+func ticketDiff(local *CreateTicketData, remote *models.Ticket) []string {
+	var fields []string
+	for _, f := range syncedFields(local, remote) {
+		if !reflect.DeepEqual(f.Local, f.Remote) {
+			fields = append(fields, f.Name)
+		}
+	}
+	return fields
+}
+
+func toFrameworks(values []string) []models.ComplianceFramework {
+	out := make([]models.ComplianceFramework, 0, len(values))
+	for _, v := range values {
+		out = append(out, models.ComplianceFramework(strings.ToLower(v)))
+	}
+	return out
+}
+
+func fromFrameworks(values []models.ComplianceFramework) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, string(v))
+	}
+	return out
+}
+
+func toApprovalTypes(values []string) []models.ApprovalType {
+	out := make([]models.ApprovalType, 0, len(values))
+	for _, v := range values {
+		out = append(out, models.ApprovalType(strings.ToLower(v)))
+	}
+	return out
+}
+
+func fromApprovalTypes(values []models.ApprovalType) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, string(v))
+	}
+	return out
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
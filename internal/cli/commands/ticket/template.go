@@ -0,0 +1,461 @@
+package ticket
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateCmd represents the template command group. It lives in the ticket
+// package because applying a template is ticket creation.
+var TemplateCmd = &cobra.Command{
+	Use:     "template",
+	Aliases: []string{"templates", "tpl"},
+	Short:   "List and apply ticket templates",
+	Long: `List, show and apply ticket templates.
+
+A template pre-fills the compliance frameworks, approvals, affected systems,
+and rollback and testing plans for a kind of change that happens often.
+Templates come from your organization through the API. When the API can't
+be reached, or doesn't know a template, YAML or JSON files in the local
+templates directory are used instead (~/.adsops-utils/templates, or
+templates_dir in the config file).
+
+Examples:
+  # List available templates
+  changes template list
+
+  # Show what a template fills in
+  changes template show standard-db-migration
+
+  # Create a ticket from a template
+  changes template apply standard-db-migration --title "Add index to orders"
+
+  # Same thing, from ticket create
+  changes ticket create --template standard-db-migration
+
+  # Share a local template with your organization (admins)
+  changes template publish standard-db-migration --org`,
+}
+
+func init() {
+	TemplateCmd.AddCommand(templateListCmd)
+	TemplateCmd.AddCommand(templateShowCmd)
+	TemplateCmd.AddCommand(templateApplyCmd)
+	TemplateCmd.AddCommand(templatePublishCmd)
+	TemplateCmd.AddCommand(templateUnpublishCmd)
+}
+
+// ticketTemplate is a template and where it was found
+type ticketTemplate struct {
+	apiclient.TicketTemplate `yaml:",inline"`
+	Source                   string `json:"source" yaml:"-"`
+}
+
+var templateListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List ticket templates",
+	Args:    cobra.NoArgs,
+	Run:     runTemplateList,
+}
+
+func runTemplateList(cmd *cobra.Command, args []string) {
+	templates, err := listTemplates()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(templates)
+		return
+	}
+	if len(templates) == 0 {
+		fmt.Printf("No templates found. Add YAML files to %s or ask an admin to publish some.\n", templatesDir())
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tRISK\tAPPROVALS\tDESCRIPTION")
+	fmt.Fprintln(w, "----\t------\t----\t---------\t-----------")
+	for _, t := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			t.Name,
+			t.Source,
+			orDash(t.Ticket.Risk),
+			orDash(strings.Join(t.Ticket.ApprovalTypes, ",")),
+			truncate(t.Description, 50),
+		)
+	}
+	w.Flush()
+}
+
+var templateShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a ticket template",
+	Long: `Show the fields a ticket template fills in.
+
+Examples:
+  changes template show standard-db-migration
+
+  # Copy an organization template into the local templates directory
+  changes template show standard-db-migration --output yaml > ~/.adsops-utils/templates/my-migration.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run:  runTemplateShow,
+}
+
+func runTemplateShow(cmd *cobra.Command, args []string) {
+	t, err := findTemplate(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	switch output.Format() {
+	case output.JSON:
+		output.Print(t)
+		return
+	case output.YAML:
+		// The template file format, so it can be saved and edited
+		data, err := yaml.Marshal(t.TicketTemplate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding YAML: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		fmt.Print(string(data))
+		return
+	}
+
+	f := t.Ticket
+	fmt.Printf("%s\n", t.Name)
+	if t.Description != "" {
+		fmt.Printf("%s\n", t.Description)
+	}
+	fmt.Printf("Source: %s\n\n", t.Source)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Change type:\t%s\n", orDash(f.ChangeType))
+	fmt.Fprintf(w, "Priority:\t%s\n", orDash(f.Priority))
+	fmt.Fprintf(w, "Risk:\t%s\n", orDash(f.Risk))
+	fmt.Fprintf(w, "Industry:\t%s\n", orDash(f.Industry))
+	fmt.Fprintf(w, "Compliance:\t%s\n", orDash(strings.Join(f.ComplianceFrameworks, ", ")))
+	fmt.Fprintf(w, "Approvals:\t%s\n", orDash(strings.Join(f.ApprovalTypes, ", ")))
+	fmt.Fprintf(w, "Affected systems:\t%s\n", orDash(strings.Join(f.AffectedSystems, ", ")))
+	w.Flush()
+
+	for _, section := range []struct{ label, text string }{
+		{"Description", f.Description},
+		{"Testing plan", f.TestingPlan},
+		{"Rollback plan", f.RollbackPlan},
+	} {
+		if section.text == "" {
+			continue
+		}
+		fmt.Printf("\n%s:\n", section.label)
+		for _, line := range strings.Split(strings.TrimRight(section.text, "\n"), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+var templateApplyCmd = &cobra.Command{
+	Use:   "apply [name]",
+	Short: "Create a ticket from a template",
+	Long: `Create a ticket from a template. This is the same as
+'changes ticket create --template <name>' and takes the same flags;
+flags given on the command line override the template.
+
+Examples:
+  # Prompt for the rest, with the template's values as defaults
+  changes template apply standard-db-migration
+
+  # Create without prompting
+  changes template apply standard-db-migration --title "Add index to orders" --risk medium`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		createTicket(cmd, args[0])
+	},
+}
+
+func init() {
+	// Share create's flags so both commands read the same values; the
+	// template comes from the argument instead
+	templateApplyCmd.Flags().AddFlagSet(createCmd.Flags())
+	templateApplyCmd.Flags().MarkHidden("template")
+}
+
+var templatePublishCmd = &cobra.Command{
+	Use:   "publish [name]",
+	Short: "Publish a local template to the API",
+	Long: `Publish a template from the local templates directory to the API, so it
+is available wherever you use the CLI. With --org it is shared with your
+whole organization, which needs the admin role.
+
+Examples:
+  changes template publish standard-db-migration
+  changes template publish standard-db-migration --org`,
+	Args: cobra.ExactArgs(1),
+	Run:  runTemplatePublish,
+}
+
+var templateUnpublishCmd = &cobra.Command{
+	Use:   "unpublish [name]",
+	Short: "Remove a template from the API",
+	Long: `Remove a template you published, or with --org one of your
+organization's templates (admins). Local template files are left alone.
+
+Examples:
+  changes template unpublish standard-db-migration --org`,
+	Args: cobra.ExactArgs(1),
+	Run:  runTemplateUnpublish,
+}
+
+func init() {
+	templatePublishCmd.Flags().Bool("org", false, "Share the template with your organization")
+	templateUnpublishCmd.Flags().Bool("org", false, "Remove the organization's template")
+}
+
+// publishScope is the API template scope the --org flag selects
+func publishScope(cmd *cobra.Command) string {
+	if org, _ := cmd.Flags().GetBool("org"); org {
+		return "org"
+	}
+	return "user"
+}
+
+func runTemplatePublish(cmd *cobra.Command, args []string) {
+	local, err := loadLocalTemplates()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	var t *ticketTemplate
+	for i := range local {
+		if strings.EqualFold(local[i].Name, args[0]) {
+			t = &local[i]
+			break
+		}
+	}
+	if t == nil {
+		err := exitcode.Wrap(exitcode.NotFound, fmt.Errorf("no local template named %q in %s", args[0], templatesDir()))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	published, err := client.CreateTemplate(&t.TicketTemplate, publishScope(cmd))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(published)
+		return
+	}
+	fmt.Printf("Published %s (%s)\n", published.Name, published.Scope)
+}
+
+func runTemplateUnpublish(cmd *cobra.Command, args []string) {
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if err := client.DeleteTemplate(args[0], publishScope(cmd)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	fmt.Printf("Removed %s\n", args[0])
+}
+
+// applyTemplate uses the template's values for every create flag that was
+// not given on the command line
+func applyTemplate(cmd *cobra.Command, t *ticketTemplate) error {
+	f := t.Ticket
+	values := map[string]string{
+		"description": f.Description,
+		"change-type": f.ChangeType,
+		"priority":    f.Priority,
+		"risk":        f.Risk,
+		"industry":    f.Industry,
+		"testing":     f.TestingPlan,
+		"rollback":    f.RollbackPlan,
+	}
+	for name, value := range values {
+		if value == "" || cmd.Flags().Changed(name) {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("template %s: invalid %s: %w", t.Name, name, err)
+		}
+	}
+
+	lists := map[string][]string{
+		"compliance":       f.ComplianceFrameworks,
+		"approval-types":   f.ApprovalTypes,
+		"affected-systems": f.AffectedSystems,
+	}
+	for name, value := range lists {
+		if len(value) == 0 || cmd.Flags().Changed(name) {
+			continue
+		}
+		// Replace rather than Set, which would split values on commas
+		slice, ok := cmd.Flags().Lookup(name).Value.(interface{ Replace([]string) error })
+		if !ok {
+			return fmt.Errorf("flag --%s is not a list", name)
+		}
+		if err := slice.Replace(value); err != nil {
+			return fmt.Errorf("template %s: invalid %s: %w", t.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// findTemplate looks name up in the API first, then in the local templates
+// directory
+func findTemplate(name string) (*ticketTemplate, error) {
+	if client := templateClient(); client != nil {
+		t, err := client.GetTemplate(name)
+		if err == nil {
+			return &ticketTemplate{TicketTemplate: *t, Source: apiSource(t)}, nil
+		}
+		warnTemplateAPI(err)
+	}
+
+	local, err := loadLocalTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for i := range local {
+		if strings.EqualFold(local[i].Name, name) {
+			return &local[i], nil
+		}
+	}
+	return nil, exitcode.Wrap(exitcode.NotFound, fmt.Errorf("template %q not found; see 'changes template list'", name))
+}
+
+// listTemplates returns the API's templates followed by local ones. A local
+// template with the same name as an API template is left out, since
+// findTemplate would never pick it.
+func listTemplates() ([]ticketTemplate, error) {
+	var templates []ticketTemplate
+	seen := map[string]bool{}
+
+	if client := templateClient(); client != nil {
+		remote, err := client.ListTemplates()
+		if err != nil {
+			warnTemplateAPI(err)
+		}
+		for _, t := range remote {
+			templates = append(templates, ticketTemplate{TicketTemplate: t, Source: apiSource(&t)})
+			seen[strings.ToLower(t.Name)] = true
+		}
+	}
+
+	local, err := loadLocalTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range local {
+		if !seen[strings.ToLower(t.Name)] {
+			templates = append(templates, t)
+		}
+	}
+	return templates, nil
+}
+
+// templateClient returns an API client, or nil when the CLI isn't logged in
+// and only local templates can be used
+func templateClient() *apiclient.Client {
+	client, err := apiclient.New()
+	if err != nil {
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "Using local templates only: %v\n", err)
+		}
+		return nil
+	}
+	return client
+}
+
+// warnTemplateAPI reports an API failure that isn't simply the template (or
+// the templates endpoint) not existing
+func warnTemplateAPI(err error) {
+	if apiclient.IsNotFound(err) || apiclient.IsNotImplemented(err) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: failed to load templates from the API, using local templates: %v\n", err)
+}
+
+func apiSource(t *apiclient.TicketTemplate) string {
+	if t.Scope == "" {
+		return "api"
+	}
+	return "api (" + t.Scope + ")"
+}
+
+// templatesDir is where local templates are kept
+func templatesDir() string {
+	if dir := viper.GetString("templates_dir"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "templates"
+	}
+	return filepath.Join(home, ".adsops-utils", "templates")
+}
+
+// loadLocalTemplates reads every .yaml, .yml and .json file in the templates
+// directory. A file without a name field is named after the file.
+func loadLocalTemplates() ([]ticketTemplate, error) {
+	dir := templatesDir()
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	var templates []ticketTemplate
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+
+		// YAML is a superset of JSON, so one decoder reads both
+		var t apiclient.TicketTemplate
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(entry.Name(), ext)
+		}
+		templates = append(templates, ticketTemplate{TicketTemplate: t, Source: "local"})
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
@@ -21,23 +21,39 @@ Examples:
   # List all tickets
   changes ticket list
 
-  # View a specific ticket
-  changes ticket view CHG-2025-00001
+  # Show a specific ticket
+  changes ticket show CHG-2025-00001
 
   # Edit a ticket
   changes ticket edit CHG-2025-00001
 
+  # Start a new draft from an existing ticket
+  changes ticket clone CHG-2025-00001 --title "Monthly OS patching - March"
+
   # Submit a draft ticket for approval
   changes ticket submit CHG-2025-00001
 
+  # Comment on a ticket (queued while the API is unreachable)
+  changes ticket comment CHG-2025-00001 "Window moved to 02:00 UTC"
+
   # Close a completed ticket
   changes ticket close CHG-2025-00001
 
-  # Import tickets from JSON files
+  # Link the current git branch and its pull request to a ticket
+  changes ticket link-repo CHG-2025-00001 --pr 123
+
+  # Check ticket files against the schema, then import them
+  changes ticket validate --all
   changes ticket import --all
 
   # Export tickets to JSON or PDF
-  changes ticket export CHG-2025-00001 --format pdf`,
+  changes ticket export CHG-2025-00001 --format pdf
+
+  # Sync the local tickets directory with the API both ways
+  changes ticket sync
+
+  # Archive old closed and cancelled ticket files
+  changes ticket archive --before 2024-01-01`,
 }
 
 func init() {
@@ -46,11 +62,24 @@ func init() {
 	TicketCmd.AddCommand(listCmd)
 	TicketCmd.AddCommand(viewCmd)
 	TicketCmd.AddCommand(editCmd)
+	TicketCmd.AddCommand(cloneCmd)
 	TicketCmd.AddCommand(submitCmd)
 	TicketCmd.AddCommand(closeCmd)
 	TicketCmd.AddCommand(openCmd)
 	TicketCmd.AddCommand(cancelCmd)
+	TicketCmd.AddCommand(commentCmd)
+	TicketCmd.AddCommand(linkRepoCmd)
+	TicketCmd.AddCommand(unlinkRepoCmd)
+	TicketCmd.AddCommand(attachCmd)
+	TicketCmd.AddCommand(attachmentsCmd)
 	TicketCmd.AddCommand(importCmd)
+	TicketCmd.AddCommand(validateCmd)
 	TicketCmd.AddCommand(exportCmd)
+	TicketCmd.AddCommand(syncCmd)
+	TicketCmd.AddCommand(diffCmd)
+	TicketCmd.AddCommand(archiveCmd)
+	TicketCmd.AddCommand(restoreArchiveCmd)
 	// pdfCmd is registered in pdf.go init()
+
+	registerCompletions()
 }
@@ -0,0 +1,357 @@
+package ticket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Check local ticket files against the ticket schema",
+	Long: `Check local JSON ticket files against the published ticket schema before
+importing them: required fields, statuses, priorities, risk levels, approval
+types, compliance frameworks, and the extra fields each industry needs
+(healthcare changes need HIPAA, testing and rollback plans; finance changes
+need SOX, GLBA or BSA and a rollback plan; and so on).
+
+With --fix, common problems are corrected and the files rewritten:
+  - enum values in the wrong case or spelling ("High", "in-progress",
+    "canceled", "CAB", "bsa") become the schema's values
+  - null lists become empty lists, and repeated list entries are dropped
+  - timestamps in other formats become RFC 3339 in UTC
+  - surrounding whitespace is trimmed from single-line values
+Problems that need a decision, such as a framework the schema doesn't know
+or a missing rollback plan, are still reported.
+
+The schema is built in; --print-schema prints it, and --schema-file checks
+against an organisation's own schema instead.
+
+Examples:
+  # Validate one ticket from the tickets directory
+  changes ticket validate CHG-2025-00001
+
+  # Validate every ticket before an import
+  changes ticket validate --all && changes ticket import --all
+
+  # Fix what can be fixed automatically
+  changes ticket validate --all --fix
+
+  # Publish the schema for editors and CI
+  changes ticket validate --print-schema > ticket.schema.json`,
+	Run: runValidate,
+}
+
+func init() {
+	validateCmd.Flags().Bool("all", false, "Validate all JSON ticket files in the tickets directory")
+	validateCmd.Flags().String("dir", "", "Directory containing ticket JSON files (default: ./tickets)")
+	validateCmd.Flags().Bool("fix", false, "Normalize common problems and rewrite the files")
+	validateCmd.Flags().String("schema-file", "", "Validate against this JSON schema instead of the built-in one")
+	validateCmd.Flags().Bool("print-schema", false, "Print the built-in JSON schema and exit")
+}
+
+// validateResult is the outcome for one file
+type validateResult struct {
+	File   string          `json:"file"`
+	Valid  bool            `json:"valid"`
+	Errors []schemaError   `json:"errors"`
+	Fixes  []validationFix `json:"fixes,omitempty"`
+}
+
+// validationFix is one change made by --fix
+type validationFix struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	if printSchema, _ := cmd.Flags().GetBool("print-schema"); printSchema {
+		os.Stdout.Write(ticketSchema)
+		return
+	}
+
+	validateAll, _ := cmd.Flags().GetBool("all")
+	customDir, _ := cmd.Flags().GetString("dir")
+	fix, _ := cmd.Flags().GetBool("fix")
+	schemaFile, _ := cmd.Flags().GetString("schema-file")
+
+	schemaData := ticketSchema
+	if schemaFile != "" {
+		data, err := os.ReadFile(schemaFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading schema: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		schemaData = data
+	}
+	s, err := parseSchema(schemaData)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	ticketsDir := customDir
+	if ticketsDir == "" {
+		ticketsDir = getTicketsDir()
+	}
+
+	var files []string
+	if validateAll {
+		entries, err := os.ReadDir(ticketsDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading tickets directory: %v\n", err)
+			os.Exit(exitcode.For(err))
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && strings.HasPrefix(entry.Name(), "CHG-") {
+				files = append(files, filepath.Join(ticketsDir, entry.Name()))
+			}
+		}
+	} else if len(args) > 0 {
+		for _, arg := range args {
+			if !strings.HasSuffix(arg, ".json") {
+				arg = arg + ".json"
+			}
+			if !strings.Contains(arg, string(os.PathSeparator)) {
+				arg = filepath.Join(ticketsDir, arg)
+			}
+			files = append(files, arg)
+		}
+	} else {
+		fmt.Println("Usage: changes ticket validate [file...] or changes ticket validate --all")
+		fmt.Println("Run 'changes ticket validate --help' for more information.")
+		os.Exit(exitcode.Usage)
+	}
+
+	if len(files) == 0 {
+		fmt.Println("No ticket files found to validate.")
+		return
+	}
+
+	var results []validateResult
+	invalid := 0
+	for _, file := range files {
+		result := validateFile(s, file, fix)
+		if !result.Valid {
+			invalid++
+		}
+		results = append(results, result)
+	}
+
+	if output.Structured() {
+		output.Print(results)
+	} else {
+		for _, r := range results {
+			status := "ok"
+			if !r.Valid {
+				status = fmt.Sprintf("%d problem(s)", len(r.Errors))
+			}
+			if len(r.Fixes) > 0 {
+				status = fmt.Sprintf("fixed %d, %s", len(r.Fixes), status)
+			}
+			fmt.Printf("%s: %s\n", r.File, status)
+			for _, f := range r.Fixes {
+				fmt.Printf("  fixed %s: %s -> %s\n", f.Path, jsonText(f.From), jsonText(f.To))
+			}
+			for _, e := range r.Errors {
+				fmt.Printf("  %s\n", e)
+			}
+		}
+		fmt.Printf("\n%d file(s) checked, %d valid, %d invalid\n", len(results), len(results)-invalid, invalid)
+	}
+
+	if invalid > 0 {
+		os.Exit(exitcode.Validation)
+	}
+}
+
+// validateFile checks one file, first fixing and rewriting it when fix is set
+func validateFile(s *schema, file string, fix bool) validateResult {
+	result := validateResult{File: file, Errors: []schemaError{}}
+	fail := func(format string, args ...interface{}) validateResult {
+		result.Errors = append(result.Errors, schemaError{Message: fmt.Sprintf(format, args...)})
+		return result
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fail("read error: %v", err)
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return fail("invalid JSON: %v", err)
+	}
+
+	if fix {
+		doc = fixValue(s, doc, "", &result.Fixes)
+		if len(result.Fixes) > 0 {
+			var out bytes.Buffer
+			enc := json.NewEncoder(&out)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(doc); err != nil {
+				return fail("failed to encode fixed ticket: %v", err)
+			}
+			// Encode ends with a newline; keep the file's own choice
+			fixed := out.Bytes()
+			if !bytes.HasSuffix(data, []byte("\n")) {
+				fixed = bytes.TrimSuffix(fixed, []byte("\n"))
+			}
+			if err := os.WriteFile(file, fixed, 0600); err != nil {
+				return fail("failed to write fixed ticket: %v", err)
+			}
+		}
+	}
+
+	if errs := s.validate(doc, ""); len(errs) > 0 {
+		result.Errors = errs
+	}
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// enumAliases maps common spellings, after lowercasing and replacing spaces
+// and hyphens with underscores, to the schema's values. An alias is only used
+// where its target is allowed, so "medium" is a risk but a "normal" priority.
+var enumAliases = map[string]string{
+	"pending":                "submitted",
+	"open":                   "submitted",
+	"in_progress":            "implementing",
+	"canceled":               "cancelled",
+	"done":                   "completed",
+	"complete":               "completed",
+	"rejected":               "denied",
+	"medium":                 "normal",
+	"moderate":               "medium",
+	"very_high":              "critical",
+	"cab":                    "change_management_board",
+	"change_advisory_board":  "change_management_board",
+	"network":                "network_engineering",
+	"aiops":                  "ai_ops",
+	"bsa":                    "banking_secrecy_act",
+	"sarbanes_oxley":         "sox",
+	"gramm_leach_bliley_act": "glba",
+}
+
+// fixTimeLayouts are the timestamp formats --fix rewrites to RFC 3339. Those
+// without a zone are taken as UTC.
+var fixTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// fixValue returns v with the problems --fix knows how to correct put right,
+// recording each change in fixes
+func fixValue(s *schema, v interface{}, path string, fixes *[]validationFix) interface{} {
+	if s == nil {
+		return v
+	}
+	record := func(from, to interface{}) interface{} {
+		*fixes = append(*fixes, validationFix{Path: path, From: from, To: to})
+		return to
+	}
+
+	for _, choice := range s.AnyOf {
+		if typeMatches(choice.Type, v) {
+			return fixValue(choice, v, path, fixes)
+		}
+	}
+
+	switch value := v.(type) {
+	case nil:
+		if s.Type.has("array") && !s.Type.has("null") {
+			return record(nil, []interface{}{})
+		}
+	case string:
+		if fixed := fixString(s, value); fixed != value {
+			return record(value, fixed)
+		}
+	case []interface{}:
+		seen := map[string]bool{}
+		list := []interface{}{}
+		for i, item := range value {
+			item = fixValue(s.Items, item, fmt.Sprintf("%s/%d", path, i), fixes)
+			if str, ok := item.(string); ok && len(s.enumStrings()) > 0 {
+				if seen[str] {
+					continue
+				}
+				seen[str] = true
+			}
+			list = append(list, item)
+		}
+		if len(list) < len(value) {
+			record(value, list)
+		}
+		return list
+	case *jsonObject:
+		for _, key := range value.keys {
+			if sub, ok := s.Properties[key]; ok {
+				value.values[key] = fixValue(sub, value.values[key], path+"/"+pointerEscape(key), fixes)
+			}
+		}
+	}
+	return v
+}
+
+// fixString corrects a string value where the schema says unambiguously what
+// it should be, and otherwise leaves it for validation to report
+func fixString(s *schema, value string) string {
+	trimmed := value
+	if !strings.Contains(value, "\n") {
+		trimmed = strings.TrimSpace(value)
+	}
+
+	if allowed := s.enumStrings(); len(allowed) > 0 {
+		if containsString(allowed, trimmed) {
+			return trimmed
+		}
+		key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(value)))
+		if containsString(allowed, key) {
+			return key
+		}
+		if alias, ok := enumAliases[key]; ok && containsString(allowed, alias) {
+			return alias
+		}
+		return value
+	}
+
+	if s.pattern != nil && !s.pattern.MatchString(trimmed) {
+		if upper := strings.ToUpper(trimmed); s.pattern.MatchString(upper) {
+			return upper
+		}
+	}
+
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, trimmed); err != nil {
+			for _, layout := range fixTimeLayouts {
+				if t, err := time.Parse(layout, trimmed); err == nil {
+					return t.UTC().Format(time.RFC3339)
+				}
+			}
+		}
+	}
+	return trimmed
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,68 +1,577 @@
 package ticket
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/afterdarksys/adsops-utils/internal/models"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// defaultWebURL is the web interface used by --web when web_url is not configured
+const defaultWebURL = "https://changes.afterdarksys.com"
+
 var viewCmd = &cobra.Command{
-	Use:   "view [ticket-number]",
-	Short: "View a change ticket",
-	Long: `View detailed information about a specific change ticket.
+	Use:     "show [ticket-number]",
+	Aliases: []string{"view"},
+	Short:   "Show a change ticket",
+	Long: `Show a change ticket with its compliance details, approvals,
+recent comments, and linked repositories.
+
+The ticket is fetched from the API. If the API can't be reached or you
+are not logged in, the local JSON file in the tickets directory is shown
+instead.
 
 Examples:
-  # View a ticket
-  changes ticket view CHG-2025-00001
+  # Show a ticket
+  changes ticket show CHG-2025-00001
 
-  # View with full approval history
-  changes ticket view CHG-2025-00001 --approvals
+  # Show the raw ticket as JSON
+  changes ticket show CHG-2025-00001 --json
 
-  # View with comments
-  changes ticket view CHG-2025-00001 --comments
+  # Open the ticket in the web interface
+  changes ticket show CHG-2025-00001 --web
 
-  # View audit trail
-  changes ticket view CHG-2025-00001 --audit`,
+  # Show only the local copy
+  changes ticket show CHG-2025-00001 --local`,
 	Args: cobra.ExactArgs(1),
 	Run:  runView,
 }
 
 func init() {
-	viewCmd.Flags().Bool("approvals", false, "Show approval history")
-	viewCmd.Flags().Bool("comments", false, "Show comments")
-	viewCmd.Flags().Bool("audit", false, "Show audit trail")
-	viewCmd.Flags().Bool("revisions", false, "Show revision history")
+	viewCmd.Flags().Bool("json", false, "Print the ticket as JSON")
+	viewCmd.Flags().Bool("web", false, "Open the ticket in the web interface")
+	viewCmd.Flags().Bool("local", false, "Read the local JSON file without calling the API")
+	viewCmd.Flags().Int("comments", 5, "Number of recent comments to show (0 to hide)")
+}
+
+// ticketView is what show renders, built from either the API or a local file
+type ticketView struct {
+	Number          string
+	Title           string
+	Status          string
+	Priority        string
+	Risk            string
+	Emergency       bool
+	Industry        string
+	Compliance      []string
+	ComplianceNotes string
+	DataTypes       []string
+	ComplianceGaps  []string // What submitting still needs, for tickets not yet submitted
+	ChangeType      string
+	Description     string
+	AffectedSystems []string
+	RollbackPlan    string
+	TestingPlan     string
+	CreatedBy       string
+	CreatedAt       string
+	UpdatedAt       string
+	Assignee        string
+	ScheduledStart  string
+	ScheduledEnd    string
+	ApprovalSLA     string // Only while the ticket awaits approval
+	Approvals       []approvalRow
+	Comments        []commentRow
+	Repositories    []repositoryRow
+	Attachments     []string     // The ticket's and its comments' attachment URLs
+	History         []historyRow // Only filled in for exported documents
+	Source          string
+}
+
+type approvalRow struct {
+	Type     string
+	Required int
+	Approved int
+	Pending  int
+	Denied   int
+}
+
+type commentRow struct {
+	Author string
+	At     string
+	Text   string
+}
+
+type repositoryRow struct {
+	Name     string
+	URL      string
+	Branch   string
+	PR       string
+	LinkType string
 }
 
 func runView(cmd *cobra.Command, args []string) {
 	ticketNumber := args[0]
+	asJSON, _ := cmd.Flags().GetBool("json")
+	web, _ := cmd.Flags().GetBool("web")
+	localOnly, _ := cmd.Flags().GetBool("local")
+	maxComments, _ := cmd.Flags().GetInt("comments")
+
+	if output.Structured() {
+		asJSON = true
+	}
+
+	if web {
+		webURL := viper.GetString("web_url")
+		if webURL == "" {
+			webURL = defaultWebURL
+		}
+		link := fmt.Sprintf("%s/tickets/%s", strings.TrimRight(webURL, "/"), ticketNumber)
+		if err := ui.OpenBrowser(link); err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening browser: %v\n", err)
+			fmt.Println(link)
+			os.Exit(exitcode.For(err))
+		}
+		fmt.Printf("Opened %s\n", link)
+		return
+	}
+
+	if !localOnly {
+		view, raw, err := fetchTicketView(ticketNumber, maxComments)
+		switch {
+		case err == nil:
+			if asJSON {
+				output.Print(raw)
+				return
+			}
+			renderTicket(os.Stdout, view)
+			return
+		case apiclient.IsNotFound(err):
+			// The ticket may only exist locally as an unimported draft
+		default:
+			if viper.GetBool("verbose") || !errors.Is(err, apiclient.ErrNotAuthenticated) {
+				fmt.Fprintf(os.Stderr, "Warning: could not fetch %s from the API: %v\n", ticketNumber, err)
+			}
+		}
+	}
+
+	local, path, err := loadLocalTicket(ticketNumber)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if asJSON {
+		output.Print(local)
+		return
+	}
+	view := viewFromLocal(local, maxComments)
+	view.Source = "local file " + path
+	renderTicket(os.Stdout, view)
+}
+
+// fetchTicketView loads a ticket and the pieces show renders alongside it.
+// Only the ticket itself is required; approvals and comments are best effort.
+func fetchTicketView(ticketNumber string, maxComments int) (*ticketView, *models.Ticket, error) {
+	client, err := apiclient.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := client.FindTicket(ticketNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buildTicketView(client, t, maxComments), t, nil
+}
+
+// buildTicketView fills in a fetched ticket's approvals and comments and
+// turns it into a view
+func buildTicketView(client *apiclient.Client, t *models.Ticket, maxComments int) *ticketView {
+	var required []models.ApprovalRequirement
+	if plan, err := client.GetApprovalPlan(t.ID); err == nil {
+		required = plan.Plan.Requirements
+	}
+	if approvals, err := client.ListApprovals(models.ApprovalListFilter{TicketID: &t.ID, PerPage: 100}); err == nil {
+		for _, a := range approvals.Approvals {
+			t.Approvals = append(t.Approvals, models.Approval{
+				ID:           a.ID,
+				TicketID:     a.TicketID,
+				ApprovalType: a.ApprovalType,
+				Status:       a.Status,
+				CreatedAt:    a.CreatedAt,
+			})
+		}
+	}
+	if maxComments > 0 {
+		if comments, err := client.ListComments(t.ID); err == nil {
+			t.Comments = comments
+		}
+	}
+
+	view := viewFromTicket(t, required, maxComments)
+	view.Source = "API " + client.BaseURL
+	return view
+}
+
+// loadLocalTicket reads a ticket from the local tickets directory
+func loadLocalTicket(ticketNumber string) (*CreateTicketData, string, error) {
+	path := filepath.Join(getTicketsDir(), ticketNumber+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", exitcode.Wrap(exitcode.NotFound, fmt.Errorf("ticket %s not found in the API or %s", ticketNumber, getTicketsDir()))
+		}
+		return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var ticket CreateTicketData
+	if err := json.Unmarshal(data, &ticket); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &ticket, path, nil
+}
+
+func viewFromTicket(t *models.Ticket, required []models.ApprovalRequirement, maxComments int) *ticketView {
+	v := &ticketView{
+		Number:          t.TicketNumber,
+		Title:           t.Title,
+		Status:          string(t.Status),
+		Priority:        string(t.Priority),
+		Risk:            string(t.RiskLevel),
+		Emergency:       t.IsEmergency,
+		Industry:        string(t.Industry),
+		ComplianceNotes: deref(t.ComplianceNotes),
+		DataTypes:       t.AffectedDataTypes,
+		ChangeType:      deref(t.ChangeType),
+		Description:     t.Description,
+		AffectedSystems: t.AffectedSystems,
+		RollbackPlan:    deref(t.RollbackPlan),
+		TestingPlan:     deref(t.TestingPlan),
+		CreatedAt:       formatTime(&t.CreatedAt),
+		UpdatedAt:       formatTime(&t.UpdatedAt),
+		ScheduledStart:  formatTime(t.ScheduledStart),
+		ScheduledEnd:    formatTime(t.ScheduledEnd),
+	}
+	for _, f := range t.ComplianceFrameworks {
+		v.Compliance = append(v.Compliance, strings.ToUpper(string(f)))
+	}
+	if t.CanSubmit() {
+		var gaps *models.ComplianceError
+		if errors.As(models.ValidateCompliance(t, nil), &gaps) {
+			for _, issue := range gaps.Issues {
+				v.ComplianceGaps = append(v.ComplianceGaps, issue.String())
+			}
+		}
+	}
+	if t.Creator != nil {
+		v.CreatedBy = userLabel(t.Creator)
+	}
+	// Servers report the SLA under the organization's windows; older ones
+	// don't, so fall back to the default windows
+	sla := t.SLA
+	if sla == nil {
+		sla = &models.TicketSLA{Status: t.SLAStatus(time.Now()), ApprovalDueAt: t.ApprovalDueAt()}
+	}
+	if sla.Status != models.SLAStatusNone {
+		v.ApprovalSLA = fmt.Sprintf("due %s (%s)", formatTime(sla.ApprovalDueAt), strings.ReplaceAll(string(sla.Status), "_", " "))
+	}
+	if t.Assignee != nil {
+		v.Assignee = userLabel(t.Assignee)
+	}
+
+	// The plan's quorums come first; types the ticket asked for without a
+	// matching rule need a single approval
+	rows := map[models.ApprovalType]*approvalRow{}
+	var order []models.ApprovalType
+	addRow := func(at models.ApprovalType, min int) *approvalRow {
+		if row, ok := rows[at]; ok {
+			return row
+		}
+		row := &approvalRow{Type: string(at), Required: min}
+		rows[at] = row
+		order = append(order, at)
+		return row
+	}
+	for _, req := range required {
+		addRow(req.ApprovalType, req.MinApprovals)
+	}
+	for _, at := range t.RequiresApprovalTypes {
+		addRow(at, 1)
+	}
+	for _, a := range t.Approvals {
+		row := addRow(a.ApprovalType, 0)
+		switch a.Status {
+		case models.ApprovalStatusApproved:
+			row.Approved++
+		case models.ApprovalStatusDenied:
+			row.Denied++
+		case models.ApprovalStatusPending:
+			row.Pending++
+		}
+	}
+	for _, at := range order {
+		v.Approvals = append(v.Approvals, *rows[at])
+	}
+
+	comments := t.Comments
+	if len(comments) > maxComments {
+		comments = comments[len(comments)-maxComments:]
+	}
+	for _, c := range comments {
+		author := "unknown"
+		if c.Author != nil {
+			author = userLabel(c.Author)
+		}
+		v.Comments = append(v.Comments, commentRow{Author: author, At: formatTime(&c.CreatedAt), Text: c.Comment})
+	}
+
+	// Attachments on every comment count, including ones cut from the view
+	seen := map[string]bool{}
+	urls := append([]string(nil), t.AttachmentURLs...)
+	for _, c := range t.Comments {
+		urls = append(urls, c.AttachmentURLs...)
+	}
+	for _, u := range urls {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			v.Attachments = append(v.Attachments, u)
+		}
+	}
+
+	for _, r := range t.Repositories {
+		row := repositoryRow{LinkType: r.LinkType, Branch: deref(r.BranchName)}
+		if r.Repository != nil {
+			row.Name = r.Repository.Name
+			row.URL = r.Repository.URL
+		}
+		if r.PRNumber != nil {
+			row.PR = fmt.Sprintf("#%d", *r.PRNumber)
+		}
+		v.Repositories = append(v.Repositories, row)
+	}
+
+	return v
+}
+
+func viewFromLocal(t *CreateTicketData, maxComments int) *ticketView {
+	v := &ticketView{
+		Number:          t.ID,
+		Title:           t.Title,
+		Status:          t.Status,
+		Priority:        t.Priority,
+		Risk:            t.Risk,
+		Emergency:       strings.EqualFold(t.Priority, "emergency"),
+		Industry:        t.Industry,
+		ChangeType:      t.Type,
+		Description:     t.Description,
+		AffectedSystems: t.AffectedSystems,
+		RollbackPlan:    t.RollbackPlan,
+		TestingPlan:     t.TestingPlan,
+		CreatedBy:       t.CreatedBy,
+		CreatedAt:       formatTimestamp(t.CreatedAt),
+		UpdatedAt:       formatTimestamp(t.UpdatedAt),
+		Assignee:        deref(t.Assignee),
+	}
+	for _, f := range t.ComplianceFrameworks {
+		v.Compliance = append(v.Compliance, strings.ToUpper(f))
+	}
+
+	// Local files only record which types have approved
+	approved := map[string]bool{}
+	for _, a := range t.Approvals {
+		approved[strings.ToLower(a)] = true
+	}
+	for _, at := range t.ApprovalsRequired {
+		row := approvalRow{Type: at, Required: 1, Pending: 1}
+		if approved[strings.ToLower(at)] {
+			row.Approved, row.Pending = 1, 0
+		}
+		v.Approvals = append(v.Approvals, row)
+	}
+
+	comments := t.Comments
+	if len(comments) > maxComments {
+		comments = comments[len(comments)-maxComments:]
+	}
+	for _, c := range comments {
+		v.Comments = append(v.Comments, commentRow{Author: c.Author, At: formatTimestamp(c.Timestamp), Text: c.Text})
+	}
+
+	return v
+}
+
+// renderTicket writes the human-readable view of a ticket
+func renderTicket(out io.Writer, v *ticketView) {
+	header := fmt.Sprintf("%s  %s", v.Number, v.Title)
+	fmt.Fprintln(out, header)
+	badges := fmt.Sprintf("[%s]  Priority: %s  Risk: %s", strings.ToUpper(orDash(v.Status)), orDash(v.Priority), orDash(v.Risk))
+	if v.Emergency {
+		badges += "  EMERGENCY"
+	}
+	fmt.Fprintln(out, badges)
+	fmt.Fprintln(out, strings.Repeat("=", max(len(header), len(badges))))
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Created:\t%s\n", joinNonEmpty(" by ", v.CreatedAt, v.CreatedBy))
+	fmt.Fprintf(w, "Updated:\t%s\n", orDash(v.UpdatedAt))
+	fmt.Fprintf(w, "Assignee:\t%s\n", orDash(v.Assignee))
+	if v.ChangeType != "" {
+		fmt.Fprintf(w, "Change Type:\t%s\n", v.ChangeType)
+	}
+	if v.ScheduledStart != "" || v.ScheduledEnd != "" {
+		fmt.Fprintf(w, "Scheduled:\t%s - %s\n", orDash(v.ScheduledStart), orDash(v.ScheduledEnd))
+	}
+	if v.ApprovalSLA != "" {
+		fmt.Fprintf(w, "Approval SLA:\t%s\n", v.ApprovalSLA)
+	}
+	w.Flush()
+
+	section(out, "Compliance")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Industry:\t%s\n", orDash(v.Industry))
+	fmt.Fprintf(w, "  Frameworks:\t%s\n", orDash(strings.Join(v.Compliance, ", ")))
+	if len(v.DataTypes) > 0 {
+		fmt.Fprintf(w, "  Data Types:\t%s\n", strings.Join(v.DataTypes, ", "))
+	}
+	w.Flush()
+	for _, gap := range v.ComplianceGaps {
+		fmt.Fprintf(out, "  ! %s\n", gap)
+	}
+	if v.ComplianceNotes != "" {
+		printIndented(out, v.ComplianceNotes)
+	}
+
+	if v.Description != "" {
+		section(out, "Description")
+		printIndented(out, v.Description)
+	}
+
+	if len(v.AffectedSystems) > 0 {
+		section(out, "Affected Systems")
+		for _, s := range v.AffectedSystems {
+			fmt.Fprintf(out, "  - %s\n", s)
+		}
+	}
+
+	if v.RollbackPlan != "" {
+		section(out, "Rollback Plan")
+		printIndented(out, v.RollbackPlan)
+	}
+	if v.TestingPlan != "" {
+		section(out, "Testing Plan")
+		printIndented(out, v.TestingPlan)
+	}
+
+	section(out, "Approvals")
+	if len(v.Approvals) == 0 {
+		fmt.Fprintln(out, "  None required")
+	} else {
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  \tTYPE\tREQUIRED\tAPPROVED\tPENDING\tDENIED")
+		for _, a := range v.Approvals {
+			mark := "[ ]"
+			switch {
+			case a.Denied > 0:
+				mark = "[✗]"
+			case a.Required > 0 && a.Approved >= a.Required:
+				mark = "[✓]"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%d\t%d\n", mark, a.Type, a.Required, a.Approved, a.Pending, a.Denied)
+		}
+		w.Flush()
+	}
+
+	if len(v.Comments) > 0 {
+		section(out, "Recent Comments")
+		for i, c := range v.Comments {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "  %s  %s\n", orDash(c.At), c.Author)
+			printIndented(out, c.Text)
+		}
+	}
+
+	if len(v.Repositories) > 0 {
+		section(out, "Linked Repositories")
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, r := range v.Repositories {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", orDash(r.Name), orDash(r.LinkType), orDash(r.Branch), orDash(r.PR), r.URL)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Source: %s\n", v.Source)
+}
+
+func section(out io.Writer, title string) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, title)
+}
+
+// printIndented wraps each paragraph of text to the terminal width, keeping
+// the author's line breaks
+func printIndented(out io.Writer, text string) {
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		lines := wrapText(paragraph, 76)
+		if len(lines) == 0 {
+			fmt.Fprintln(out)
+			continue
+		}
+		for _, line := range lines {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+}
+
+func userLabel(u *models.UserSummary) string {
+	if u.FullName == "" {
+		return u.Email
+	}
+	return fmt.Sprintf("%s <%s>", u.FullName, u.Email)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04 MST")
+}
+
+// formatTimestamp formats an RFC 3339 timestamp from a local file, leaving
+// anything else as written
+func formatTimestamp(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return formatTime(&t)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
 
-	// TODO: Implement API call to get ticket details
-
-	fmt.Printf("Ticket: %s\n", ticketNumber)
-	fmt.Println("========================================")
-	fmt.Println()
-	fmt.Println("Title:       Database schema update")
-	fmt.Println("Status:      submitted")
-	fmt.Println("Priority:    high")
-	fmt.Println("Risk Level:  medium")
-	fmt.Println()
-	fmt.Println("Industry:    Finance")
-	fmt.Println("Compliance:  GLBA, SOX")
-	fmt.Println()
-	fmt.Println("Description:")
-	fmt.Println("  Adding new index to improve query performance")
-	fmt.Println("  on the transactions table.")
-	fmt.Println()
-	fmt.Println("Affected Systems:")
-	fmt.Println("  - PostgreSQL primary database")
-	fmt.Println("  - Transaction processing service")
-	fmt.Println()
-	fmt.Println("Approvals Required:")
-	fmt.Println("  [ ] Operations Approval")
-	fmt.Println("  [ ] IT Approval")
-	fmt.Println("  [ ] Security Approval")
-	fmt.Println()
-	fmt.Println("Created:     2025-12-20 10:30:00 UTC")
-	fmt.Println("Created By:  John Doe <john@example.com>")
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return orDash(strings.Join(kept, sep))
 }
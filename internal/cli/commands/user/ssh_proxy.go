@@ -10,6 +10,8 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -56,7 +58,7 @@ func getAPIClient() (*http.Client, string, error) {
 		tokenFile := os.ExpandEnv("$HOME/.config/afterdark/token")
 		data, err := os.ReadFile(tokenFile)
 		if err != nil {
-			return nil, "", fmt.Errorf("not authenticated. Run 'changes auth login' first")
+			return nil, "", exitcode.Wrap(exitcode.Auth, fmt.Errorf("not authenticated. Run 'changes login' first"))
 		}
 		token = strings.TrimSpace(string(data))
 	}
@@ -124,14 +126,14 @@ func runSSHGrant(cmd *cobra.Command, args []string) {
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 	defer resp.Body.Close()
 
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	if resp.StatusCode != 200 {
@@ -140,7 +142,7 @@ func runSSHGrant(cmd *cobra.Command, args []string) {
 			errMsg = "Unknown error"
 		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", errMsg)
-		os.Exit(1)
+		os.Exit(exitcode.ForStatus(resp.StatusCode))
 	}
 
 	fmt.Printf("SSH proxy access granted to %s\n", email)
@@ -171,14 +173,14 @@ func runSSHRevoke(cmd *cobra.Command, args []string) {
 	resp, err := makeAPIRequest("DELETE", "/api/admin/ssh-proxy-access/"+email, nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 	defer resp.Body.Close()
 
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	if resp.StatusCode != 200 {
@@ -187,7 +189,7 @@ func runSSHRevoke(cmd *cobra.Command, args []string) {
 			errMsg = "Unknown error"
 		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", errMsg)
-		os.Exit(1)
+		os.Exit(exitcode.ForStatus(resp.StatusCode))
 	}
 
 	fmt.Printf("SSH proxy access revoked from %s\n", email)
@@ -214,7 +216,7 @@ func runSSHList(cmd *cobra.Command, args []string) {
 	resp, err := makeAPIRequest("GET", "/api/admin/ssh-proxy-access", nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 	defer resp.Body.Close()
 
@@ -234,14 +236,18 @@ func runSSHList(cmd *cobra.Command, args []string) {
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	if resp.StatusCode != 200 {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", result.Error)
-		os.Exit(1)
+		os.Exit(exitcode.ForStatus(resp.StatusCode))
 	}
 
+	if output.Structured() {
+		output.Print(result.Users)
+		return
+	}
 	if len(result.Users) == 0 {
 		fmt.Println("No users with SSH proxy access found.")
 		return
@@ -295,7 +301,7 @@ func runSSHStatus(cmd *cobra.Command, args []string) {
 	resp, err := makeAPIRequest("GET", "/api/admin/ssh-proxy-access/"+email, nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 	defer resp.Body.Close()
 
@@ -308,19 +314,23 @@ func runSSHStatus(cmd *cobra.Command, args []string) {
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitcode.For(err))
 	}
 
 	if resp.StatusCode == 404 {
 		fmt.Fprintf(os.Stderr, "User not found: %s\n", email)
-		os.Exit(1)
+		os.Exit(exitcode.NotFound)
 	}
 
 	if resp.StatusCode != 200 {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", result.Error)
-		os.Exit(1)
+		os.Exit(exitcode.ForStatus(resp.StatusCode))
 	}
 
+	if output.Structured() {
+		output.Print(map[string]interface{}{"email": email, "ssh_proxy_access": result.SSHProxyAccess})
+		return
+	}
 	fmt.Printf("SSH Proxy Access Status for %s\n", email)
 	fmt.Println(strings.Repeat("=", 40+len(email)))
 	fmt.Println()
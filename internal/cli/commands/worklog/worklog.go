@@ -0,0 +1,303 @@
+package worklog
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/cli/exitcode"
+	"github.com/afterdarksys/adsops-utils/internal/cli/output"
+	"github.com/afterdarksys/adsops-utils/internal/cli/ui"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// WorklogCmd represents the worklog command group
+var WorklogCmd = &cobra.Command{
+	Use:     "worklog",
+	Aliases: []string{"worklogs", "time"},
+	Short:   "Track time spent on change tickets",
+	Long: `Log the time spent implementing a change and compare it with the ticket's
+estimate.
+
+Examples:
+  # Log an hour and a half
+  changes worklog add CHG-2025-00001 1h30m --note "schema migration"
+
+  # Log time for yesterday that isn't billable
+  changes worklog add CHG-2025-00001 2 --date 2025-03-14 --non-billable
+
+  # Show a ticket's worklogs against its estimate
+  changes worklog list CHG-2025-00001
+
+  # Delete a worklog (by the ID shown in the list)
+  changes worklog delete CHG-2025-00001 3f2a9c1e`,
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <ticket-id> <duration>",
+	Short: "Log time on a ticket",
+	Long: `Log time spent on a ticket. The duration is hours (1.5) or a Go-style
+duration (1h30m, 90m, 2h), at most 24 hours per entry.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runAdd,
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list <ticket-id>",
+	Aliases: []string{"ls"},
+	Short:   "List a ticket's worklogs with time spent against the estimate",
+	Args:    cobra.ExactArgs(1),
+	Run:     runList,
+}
+
+var deleteCmd = &cobra.Command{
+	Use:     "delete <ticket-id> <worklog-id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a worklog you logged",
+	Long: `Delete a worklog. The ID may be the short form shown by 'changes worklog
+list'. Only the author or an admin can delete a worklog.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runDelete,
+}
+
+func init() {
+	addCmd.Flags().StringP("note", "n", "", "What the time was spent on")
+	addCmd.Flags().String("date", "", "Day the work was done, YYYY-MM-DD (default: today)")
+	addCmd.Flags().Bool("non-billable", false, "Don't bill the time to the ticket's customer")
+	deleteCmd.Flags().Bool("force", false, "Skip confirmation")
+
+	WorklogCmd.AddCommand(addCmd)
+	WorklogCmd.AddCommand(listCmd)
+	WorklogCmd.AddCommand(deleteCmd)
+}
+
+func runAdd(cmd *cobra.Command, args []string) {
+	note, _ := cmd.Flags().GetString("note")
+	date, _ := cmd.Flags().GetString("date")
+	nonBillable, _ := cmd.Flags().GetBool("non-billable")
+
+	hours, err := parseHours(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.Validation)
+	}
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --date %q: use YYYY-MM-DD\n", date)
+			os.Exit(exitcode.Validation)
+		}
+	}
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	ticket, err := client.FindTicket(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	billable := !nonBillable
+	input := models.CreateWorklogInput{Hours: hours, WorkDate: date, Billable: &billable}
+	if note != "" {
+		input.Description = &note
+	}
+
+	worklog, tracking, err := client.CreateWorklog(ticket.ID, input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error logging time: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(map[string]interface{}{"worklog": worklog, "time_tracking": tracking})
+		return
+	}
+	fmt.Printf("Logged %s on %s for %s\n", formatHours(worklog.Hours), ticket.TicketNumber, worklog.WorkDate.Format("2006-01-02"))
+	fmt.Println(summary(tracking))
+}
+
+func runList(cmd *cobra.Command, args []string) {
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	ticket, err := client.FindTicket(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	list, err := client.ListWorklogs(ticket.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing worklogs: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	if output.Structured() {
+		output.Print(list)
+		return
+	}
+
+	fmt.Printf("%s: %s\n\n", ticket.TicketNumber, ticket.Title)
+	if len(list.Worklogs) == 0 {
+		fmt.Println("No time logged")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tDATE\tTIME\tUSER\tBILLABLE\tNOTE")
+		fmt.Fprintln(w, "--\t----\t----\t----\t--------\t----")
+		for _, wl := range list.Worklogs {
+			user := "-"
+			if wl.User != nil {
+				user = wl.User.Email
+			}
+			billable := "yes"
+			if !wl.Billable {
+				billable = "no"
+			}
+			note := "-"
+			if wl.Description != nil && *wl.Description != "" {
+				note = truncate(*wl.Description, 40)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", shortID(wl.ID.String()), wl.WorkDate.Format("2006-01-02"), formatHours(wl.Hours), user, billable, note)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	fmt.Println(summary(&list.TimeTracking))
+}
+
+func runDelete(cmd *cobra.Command, args []string) {
+	force, _ := cmd.Flags().GetBool("force")
+
+	client, err := apiclient.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	ticket, err := client.FindTicket(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	list, err := client.ListWorklogs(ticket.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing worklogs: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+
+	var matches []models.Worklog
+	for _, wl := range list.Worklogs {
+		if strings.HasPrefix(wl.ID.String(), strings.ToLower(args[1])) {
+			matches = append(matches, wl)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		fmt.Fprintf(os.Stderr, "Error: %s has no worklog %s\n", ticket.TicketNumber, args[1])
+		os.Exit(exitcode.NotFound)
+	case 1:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %s matches %d worklogs; give more of the ID\n", args[1], len(matches))
+		os.Exit(exitcode.Validation)
+	}
+	wl := matches[0]
+
+	if !force && !ui.ConfirmStdin(fmt.Sprintf("Delete %s logged on %s for %s?", formatHours(wl.Hours), ticket.TicketNumber, wl.WorkDate.Format("2006-01-02"))) {
+		fmt.Println("Cancelled")
+		return
+	}
+	if err := client.DeleteWorklog(ticket.ID, wl.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error deleting worklog: %v\n", err)
+		os.Exit(exitcode.For(err))
+	}
+	if output.Structured() {
+		output.Print(map[string]interface{}{"ticket_number": ticket.TicketNumber, "deleted": wl})
+		return
+	}
+	fmt.Printf("Deleted %s from %s\n", formatHours(wl.Hours), ticket.TicketNumber)
+}
+
+// parseHours reads a duration given as hours (1.5) or as a Go duration
+// (1h30m), rounded to the hundredths of an hour the API keeps
+func parseHours(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	hours, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		d, derr := time.ParseDuration(strings.ReplaceAll(s, " ", ""))
+		if derr != nil {
+			return 0, fmt.Errorf("invalid duration %q: use hours (1.5) or a duration like 1h30m", s)
+		}
+		hours = d.Hours()
+	}
+	hours = math.Round(hours*100) / 100
+	if hours <= 0 {
+		return 0, fmt.Errorf("duration %q must be more than zero", s)
+	}
+	if hours > models.MaxWorklogHours {
+		return 0, fmt.Errorf("duration %q is more than %d hours; log each day separately", s, models.MaxWorklogHours)
+	}
+	return hours, nil
+}
+
+// formatHours shows hours as 1h30m
+func formatHours(hours float64) string {
+	sign := ""
+	if hours < 0 {
+		sign, hours = "-", -hours
+	}
+	minutes := int(math.Round(hours * 60))
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%s%dm", sign, minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%s%dh", sign, minutes/60)
+	}
+	return fmt.Sprintf("%s%dh%02dm", sign, minutes/60, minutes%60)
+}
+
+// summary describes time spent against the estimate
+func summary(t *apiclient.TimeTracking) string {
+	spent := 0.0
+	if t.TimeSpentHours != nil {
+		spent = *t.TimeSpentHours
+	}
+	if t.TimeEstimateHours == nil {
+		return fmt.Sprintf("Spent %s (no estimate)", formatHours(spent))
+	}
+	line := fmt.Sprintf("Spent %s of %s estimated", formatHours(spent), formatHours(*t.TimeEstimateHours))
+	if t.TimeRemainingHours != nil {
+		if t.OverEstimate {
+			line += fmt.Sprintf(", %s over estimate", formatHours(-*t.TimeRemainingHours))
+		} else {
+			line += fmt.Sprintf(", %s remaining", formatHours(*t.TimeRemainingHours))
+		}
+	}
+	if *t.TimeEstimateHours > 0 {
+		line += fmt.Sprintf(" (%.0f%%)", spent / *t.TimeEstimateHours * 100)
+	}
+	return line
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
@@ -0,0 +1,92 @@
+// Package exitcode defines the exit status the changes CLI reports for each
+// class of failure, so scripts and CI pipelines can branch on the result
+// without parsing messages
+package exitcode
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/afterdarksys/adsops-utils/internal/cli/apiclient"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Exit statuses. They are part of the CLI's interface: add new ones, but
+// never renumber them.
+const (
+	OK          = 0 // success
+	Error       = 1 // any failure not covered below
+	Usage       = 2 // unknown command or flag, wrong arguments, invalid --output
+	Auth        = 3 // not logged in, session expired, or access denied (401, 403)
+	Validation  = 4 // invalid input, caught by the CLI or the API (400, 413, 422)
+	NotFound    = 5 // the ticket or other resource doesn't exist (404, 410)
+	Conflict    = 6 // changed by someone else, or not allowed in its current status (409, 412)
+	Unavailable = 7 // the API can't be reached or is overloaded (429, 502, 503, 504)
+)
+
+// codedError is an error that carries the status to exit with
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// Wrap attaches an exit status to err, for errors whose message replaces the
+// API error they were caused by
+func Wrap(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// For returns the exit status that describes err
+func For(err error) int {
+	if err == nil {
+		return OK
+	}
+
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	if errors.Is(err, apiclient.ErrNotAuthenticated) || errors.Is(err, apiclient.ErrSessionExpired) {
+		return Auth
+	}
+	var validation *models.ValidationError
+	if errors.As(err, &validation) {
+		return Validation
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return NotFound
+	}
+	if apiclient.IsUnreachable(err) {
+		return Unavailable
+	}
+
+	var apiErr *apiclient.APIError
+	if errors.As(err, &apiErr) {
+		return ForStatus(apiErr.StatusCode)
+	}
+	return Error
+}
+
+// ForStatus returns the exit status for a failed API response
+func ForStatus(status int) int {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return Auth
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return Validation
+	case http.StatusNotFound, http.StatusGone:
+		return NotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return Conflict
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return Unavailable
+	}
+	return Error
+}
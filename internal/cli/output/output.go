@@ -0,0 +1,150 @@
+// Package output prints command results in the format chosen with the global
+// --output flag, so every changes subcommand can be scripted the same way
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+// Formats lists the values --output accepts
+var Formats = []string{Table, JSON, YAML}
+
+// Format returns the chosen output format, table by default
+func Format() string {
+	f := strings.ToLower(strings.TrimSpace(viper.GetString("output")))
+	if f == "" {
+		return Table
+	}
+	return f
+}
+
+// Check returns an error when --output isn't one of Formats
+func Check() error {
+	f := Format()
+	for _, known := range Formats {
+		if f == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format %q: use %s", f, strings.Join(Formats, ", "))
+}
+
+// Structured reports whether results should be printed as data (JSON or
+// YAML) rather than as tables and messages
+func Structured() bool {
+	f := Format()
+	return f == JSON || f == YAML
+}
+
+// Print writes v to stdout as JSON or YAML
+func Print(v interface{}) {
+	if err := Fprint(os.Stdout, v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Fprint writes v to w as YAML when that format was chosen, and as indented
+// JSON otherwise. YAML uses the same field names and order as JSON, so the
+// json struct tags apply to both.
+func Fprint(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if Format() == YAML {
+		if data, err = jsonToYAML(data); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeNode reads the next JSON value as a YAML node, keeping object keys
+// in order
+func decodeNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		return node, nil
+	case string:
+		// Encode quotes strings that would read back as another type
+		node := &yaml.Node{}
+		return node, node.Encode(t)
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+}
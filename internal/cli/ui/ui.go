@@ -0,0 +1,56 @@
+// Package ui holds the small interactive helpers shared by the changes CLI
+// commands: yes/no questions and opening links in the user's browser
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Confirm asks a yes/no question on out and reads the answer from in. A
+// blank answer is def and anything other than y or yes is no. It returns
+// the read error if input ends before an answer.
+func Confirm(in *bufio.Reader, out io.Writer, label string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	fmt.Fprintf(out, "%s [%s]: ", label, hint)
+	answer, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// ConfirmStdin asks a yes/no question on the terminal, defaulting to no
+func ConfirmStdin(label string) bool {
+	ok, err := Confirm(bufio.NewReader(os.Stdin), os.Stdout, label, false)
+	return err == nil && ok
+}
+
+// OpenBrowser opens the URL in the user's default browser without waiting
+// for it
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
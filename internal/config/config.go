@@ -17,24 +17,63 @@ type Config struct {
 	// Database
 	Database DatabaseConfig `mapstructure:"database"`
 
+	// Read replica for ticket lists, search and reports. Leave the host
+	// empty to read everything from the main database.
+	Replica DatabaseConfig `mapstructure:"replica"`
+
+	// Host inventory shared with hostctl and the blackout tool. Leave the
+	// host empty when the inventory tables live in the main database.
+	Inventory DatabaseConfig `mapstructure:"inventory"`
+
+	// Blackout expiry and monitoring export
+	Blackout BlackoutConfig `mapstructure:"blackout"`
+
+	// Inventory reconciliation against cloud instances
+	Reconcile ReconcileConfig `mapstructure:"reconcile"`
+
+	// Background worker
+	Worker WorkerConfig `mapstructure:"worker"`
+
 	// Redis
 	Redis RedisConfig `mapstructure:"redis"`
 
+	// Ticket read cache, kept in Redis
+	Cache CacheConfig `mapstructure:"cache"`
+
 	// JWT
 	JWT JWTConfig `mapstructure:"jwt"`
 
 	// OAuth2 Providers
 	OAuth2 OAuth2Config `mapstructure:"oauth2"`
 
+	// Passkeys
+	WebAuthn WebAuthnConfig `mapstructure:"webauthn"`
+
 	// AWS
 	AWS AWSConfig `mapstructure:"aws"`
 
+	// Audit log archive to S3 or OCI Object Storage
+	AuditArchive AuditArchiveConfig `mapstructure:"audit_archive"`
+
+	// Background report generation
+	Reports ReportsConfig `mapstructure:"reports"`
+
+	// Files attached to tickets
+	Attachments AttachmentsConfig `mapstructure:"attachments"`
+
 	// Email
 	Email EmailConfig `mapstructure:"email"`
 }
 
+// Database drivers
+const (
+	DriverPQ      = "pq"      // lib/pq, with database/sql's connection pool
+	DriverPgxPool = "pgxpool" // pgx, with its own connection pool
+)
+
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
+	Driver       string `mapstructure:"driver"` // DriverPQ (default) or DriverPgxPool
 	Host         string `mapstructure:"host"`
 	Port         int    `mapstructure:"port"`
 	User         string `mapstructure:"user"`
@@ -43,14 +82,66 @@ type DatabaseConfig struct {
 	SSLMode      string `mapstructure:"sslmode"`
 	MaxOpenConns int    `mapstructure:"max_open_conns"`
 	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+	// Connections are closed once they are this old, or have been idle
+	// this long. Zero keeps them open.
+	ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+	ConnMaxIdleSeconds     int `mapstructure:"conn_max_idle_seconds"`
+	// Postgres cancels statements running longer than this. Zero leaves
+	// them unbounded.
+	StatementTimeoutMS int `mapstructure:"statement_timeout_ms"`
+	// Long-running work, such as the worker's archive and report jobs and
+	// streaming audit exports, runs with this statement timeout instead.
+	// Zero leaves it unbounded.
+	JobStatementTimeoutMS int `mapstructure:"job_statement_timeout_ms"`
+	// Statements taking this long are logged with the store method that
+	// ran them. Zero turns the log off.
+	SlowQueryMS int `mapstructure:"slow_query_ms"`
 }
 
 // DSN returns the database connection string
 func (d *DatabaseConfig) DSN() string {
-	return fmt.Sprintf(
+	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		d.Host, d.Port, d.User, d.Password, d.DBName, d.SSLMode,
 	)
+	if d.StatementTimeoutMS > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", d.StatementTimeoutMS)
+	}
+	return dsn
+}
+
+// BlackoutConfig holds where the worker writes the active blackout export
+// read by monitoring, and optionally a Prometheus textfile of its counters
+type BlackoutConfig struct {
+	ExportPath  string `mapstructure:"export_path"`
+	MetricsPath string `mapstructure:"metrics_path"` // e.g. /var/lib/node_exporter/textfile/adsops_blackouts.prom
+}
+
+// ReconcileConfig holds which inventory providers the worker checks
+// against the instances cloudtop reports, and how to run cloudtop
+type ReconcileConfig struct {
+	Providers      string `mapstructure:"providers"`       // Comma-separated inventory providers, e.g. oci,gcp; empty turns it off
+	CloudtopPath   string `mapstructure:"cloudtop_path"`   // cloudtop binary
+	CloudtopConfig string `mapstructure:"cloudtop_config"` // cloudtop.json with provider credentials
+}
+
+// GetProviders returns the inventory providers to reconcile as a slice
+func (r *ReconcileConfig) GetProviders() []string {
+	var providers []string
+	for _, p := range strings.Split(r.Providers, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			providers = append(providers, strings.ToLower(p))
+		}
+	}
+	return providers
+}
+
+// WorkerConfig holds the background worker's settings. With leader
+// election, replicas sharing a database elect one leader to run the
+// scheduled jobs and the rest stand by.
+type WorkerConfig struct {
+	HealthAddr     string `mapstructure:"health_addr"` // Serves probes and /metrics; empty turns it off
+	LeaderElection bool   `mapstructure:"leader_election"`
 }
 
 // RedisConfig holds Redis configuration
@@ -66,6 +157,14 @@ func (r *RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", r.Host, r.Port)
 }
 
+// CacheConfig holds the ticket read cache settings. The cache uses the
+// Redis server configured under redis.
+type CacheConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	TicketTTL int  `mapstructure:"ticket_ttl"` // seconds
+	QueueTTL  int  `mapstructure:"queue_ttl"`  // seconds
+}
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	SecretKey            string `mapstructure:"secret_key"`
@@ -74,6 +173,25 @@ type JWTConfig struct {
 	Issuer               string `mapstructure:"issuer"`
 }
 
+// WebAuthnConfig identifies the site passkeys are registered for. The
+// relying party ID and origins default to the host of email.base_url.
+type WebAuthnConfig struct {
+	RPID    string `mapstructure:"rp_id"`
+	RPName  string `mapstructure:"rp_name"`
+	Origins string `mapstructure:"origins"` // Comma-separated, e.g. https://changes.example.com
+}
+
+// GetOrigins returns the allowed origins as a slice
+func (w *WebAuthnConfig) GetOrigins() []string {
+	var origins []string
+	for _, o := range strings.Split(w.Origins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, strings.TrimRight(o, "/"))
+		}
+	}
+	return origins
+}
+
 // OAuth2Config holds OAuth2 provider configurations
 type OAuth2Config struct {
 	AfterDark OAuth2Provider `mapstructure:"afterdark"`
@@ -89,6 +207,7 @@ type OAuth2Provider struct {
 	TokenURL     string `mapstructure:"token_url"`
 	UserInfoURL  string `mapstructure:"userinfo_url"`
 	Scopes       string `mapstructure:"scopes"`
+	GroupsClaim  string `mapstructure:"groups_claim"` // Userinfo claim listing the user's groups
 }
 
 // GetScopes returns scopes as a slice
@@ -106,6 +225,51 @@ type AWSConfig struct {
 	SecretAccessKey string `mapstructure:"secret_access_key"`
 	S3Bucket        string `mapstructure:"s3_bucket"`
 	SQSQueueURL     string `mapstructure:"sqs_queue_url"`
+
+	// SQS queue subscribed to the SNS topic SES publishes bounces and
+	// complaints to
+	SESFeedbackQueueURL string `mapstructure:"ses_feedback_queue_url"`
+}
+
+// ObjectStoreConfig holds an Amazon S3 or S3-compatible bucket. Region and
+// keys default to the aws.* settings. For OCI Object Storage set endpoint
+// to https://<namespace>.compat.objectstorage.<region>.oraclecloud.com with
+// path_style and a customer secret key.
+type ObjectStoreConfig struct {
+	Bucket          string `mapstructure:"bucket"`
+	Prefix          string `mapstructure:"prefix"`
+	Endpoint        string `mapstructure:"endpoint"` // Empty for Amazon S3
+	Region          string `mapstructure:"region"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	PathStyle       bool   `mapstructure:"path_style"`
+}
+
+// AuditArchiveConfig holds where the worker archives the ticket audit log
+type AuditArchiveConfig struct {
+	ObjectStoreConfig `mapstructure:",squash"`
+	// Days each object is locked in compliance mode; needs a bucket with
+	// Object Lock enabled. Zero leaves retention to the bucket's default.
+	ObjectLockDays int `mapstructure:"object_lock_days"`
+	// Months of audit log kept in the database. Older monthly partitions
+	// are dropped once every day in them is archived. Zero keeps them all.
+	PartitionRetentionMonths int `mapstructure:"partition_retention_months"`
+}
+
+// ReportsConfig holds where the worker stores reports generated in the
+// background and how long their download links last
+type ReportsConfig struct {
+	ObjectStoreConfig `mapstructure:",squash"`
+	LinkTTLHours      int `mapstructure:"link_ttl_hours"` // Presigned links, at most 168
+	RetentionDays     int `mapstructure:"retention_days"` // Files are deleted after this
+}
+
+// AttachmentsConfig holds where files attached to tickets are stored, how
+// large they may be and how long their download links last
+type AttachmentsConfig struct {
+	ObjectStoreConfig `mapstructure:",squash"`
+	MaxSizeMB         int `mapstructure:"max_size_mb"`
+	LinkTTLMinutes    int `mapstructure:"link_ttl_minutes"` // Presigned links, at most 7 days
 }
 
 // EmailConfig holds email configuration
@@ -114,6 +278,15 @@ type EmailConfig struct {
 	ReplyTo     string `mapstructure:"reply_to"`
 	BaseURL     string `mapstructure:"base_url"` // For approval links
 	CompanyName string `mapstructure:"company_name"`
+
+	// Sends per second across all recipients, to stay under the SES
+	// account's maximum send rate. Zero sends as fast as possible.
+	MaxSendRate float64 `mapstructure:"max_send_rate"`
+	// Comment notifications to one recipient are held this long after the
+	// first, or until batch_max have queued, and sent as one email. Zero
+	// sends each on its own.
+	BatchWindowMinutes int `mapstructure:"batch_window_minutes"`
+	BatchMax           int `mapstructure:"batch_max"`
 }
 
 // Load loads configuration from environment and config files
@@ -131,15 +304,62 @@ func Load() (*Config, error) {
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("database.driver", DriverPQ)
 	viper.SetDefault("database.max_open_conns", 25)
 	viper.SetDefault("database.max_idle_conns", 5)
+	viper.SetDefault("database.conn_max_lifetime_seconds", 3600)
+	viper.SetDefault("database.conn_max_idle_seconds", 300)
+	viper.SetDefault("database.statement_timeout_ms", 30000)
+	viper.SetDefault("database.job_statement_timeout_ms", 0)
+	viper.SetDefault("database.slow_query_ms", 500)
+	viper.SetDefault("replica.port", 5432)
+	viper.SetDefault("replica.sslmode", "disable")
+	viper.SetDefault("replica.max_open_conns", 25)
+	viper.SetDefault("replica.max_idle_conns", 5)
+	viper.SetDefault("replica.conn_max_lifetime_seconds", 3600)
+	viper.SetDefault("replica.conn_max_idle_seconds", 300)
+	viper.SetDefault("replica.statement_timeout_ms", 30000)
+	viper.SetDefault("inventory.port", 5432)
+	viper.SetDefault("inventory.sslmode", "require")
+	viper.SetDefault("inventory.max_open_conns", 10)
+	viper.SetDefault("inventory.max_idle_conns", 5)
+	viper.SetDefault("inventory.conn_max_lifetime_seconds", 3600)
+	viper.SetDefault("blackout.export_path", "/var/lib/adsops/active-blackouts.json")
+	viper.SetDefault("reconcile.providers", "") // Registered so ADSOPS_RECONCILE_PROVIDERS is read
+	viper.SetDefault("reconcile.cloudtop_path", "cloudtop")
+	viper.SetDefault("worker.health_addr", ":8081")
+	viper.SetDefault("worker.leader_election", true)
 	viper.SetDefault("redis.host", "localhost")
 	viper.SetDefault("redis.port", 6379)
 	viper.SetDefault("redis.db", 0)
+	viper.SetDefault("cache.enabled", false)
+	viper.SetDefault("cache.ticket_ttl", 30)
+	viper.SetDefault("cache.queue_ttl", 15)
 	viper.SetDefault("jwt.access_token_duration", 15)
 	viper.SetDefault("jwt.refresh_token_duration", 7)
 	viper.SetDefault("jwt.issuer", "changes.afterdarksys.com")
+	viper.SetDefault("webauthn.rp_name", "After Dark Systems Change Management")
+	viper.SetDefault("oauth2.google.auth_url", "https://accounts.google.com/o/oauth2/v2/auth")
+	viper.SetDefault("oauth2.google.token_url", "https://oauth2.googleapis.com/token")
+	viper.SetDefault("oauth2.google.userinfo_url", "https://openidconnect.googleapis.com/v1/userinfo")
+	viper.SetDefault("oauth2.google.scopes", "openid,profile,email")
+	viper.SetDefault("oauth2.google.groups_claim", "groups")
+	viper.SetDefault("oauth2.afterdark.scopes", "openid,profile,email")
+	viper.SetDefault("oauth2.afterdark.groups_claim", "groups")
 	viper.SetDefault("aws.region", "us-east-1")
+	viper.SetDefault("audit_archive.prefix", "audit-log")
+	viper.SetDefault("reports.bucket", "") // Registered so ADSOPS_REPORTS_BUCKET is read
+	viper.SetDefault("reports.prefix", "reports")
+	viper.SetDefault("reports.link_ttl_hours", 24)
+	viper.SetDefault("reports.retention_days", 30)
+	viper.SetDefault("attachments.bucket", "") // Registered so ADSOPS_ATTACHMENTS_BUCKET is read
+	viper.SetDefault("attachments.prefix", "attachments")
+	viper.SetDefault("attachments.max_size_mb", 25)
+	viper.SetDefault("attachments.link_ttl_minutes", 60)
+	viper.SetDefault("email.company_name", "After Dark Systems")
+	viper.SetDefault("email.max_send_rate", 14)
+	viper.SetDefault("email.batch_window_minutes", 5)
+	viper.SetDefault("email.batch_max", 10)
 
 	// Environment variable bindings
 	viper.SetEnvPrefix("ADSOPS")
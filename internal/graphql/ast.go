@@ -0,0 +1,127 @@
+package graphql
+
+// Document is a parsed GraphQL request document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription definition
+type Operation struct {
+	Type         string // query, mutation or subscription
+	Name         string
+	Variables    []*VariableDefinition
+	SelectionSet []Selection
+}
+
+// VariableDefinition declares an operation variable
+type VariableDefinition struct {
+	Name    string
+	Type    string
+	Default Value
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+}
+
+// Selection is a field, fragment spread or inline fragment
+type Selection interface {
+	directives() []*Directive
+}
+
+// Field selects a field, optionally under an alias
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    map[string]Value
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+// ResponseKey is the key the field's value is returned under
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread includes a named fragment
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+// InlineFragment includes a selection set, optionally for one type only
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+// Directive is a directive such as @skip or @include
+type Directive struct {
+	Name      string
+	Arguments map[string]Value
+}
+
+func (f *Field) directives() []*Directive          { return f.Directives }
+func (f *FragmentSpread) directives() []*Directive { return f.Directives }
+func (f *InlineFragment) directives() []*Directive { return f.Directives }
+
+// Value is an argument value, resolved against the request's variables
+type Value interface {
+	Resolve(vars map[string]interface{}) interface{}
+}
+
+// Variable refers to an operation variable
+type Variable struct {
+	Name string
+}
+
+// Literal is a scalar or enum value written in the document
+type Literal struct {
+	Value interface{}
+}
+
+// ListValue is a list of values
+type ListValue struct {
+	Items []Value
+}
+
+// ObjectValue is an input object
+type ObjectValue struct {
+	Fields map[string]Value
+}
+
+// Resolve returns the variable's value, or nil when it is not provided
+func (v *Variable) Resolve(vars map[string]interface{}) interface{} {
+	return vars[v.Name]
+}
+
+// Resolve returns the literal value
+func (v *Literal) Resolve(map[string]interface{}) interface{} {
+	return v.Value
+}
+
+// Resolve returns the resolved items
+func (v *ListValue) Resolve(vars map[string]interface{}) interface{} {
+	items := make([]interface{}, len(v.Items))
+	for i, item := range v.Items {
+		items[i] = item.Resolve(vars)
+	}
+	return items
+}
+
+// Resolve returns the resolved fields
+func (v *ObjectValue) Resolve(vars map[string]interface{}) interface{} {
+	fields := make(map[string]interface{}, len(v.Fields))
+	for name, field := range v.Fields {
+		fields[name] = field.Resolve(vars)
+	}
+	return fields
+}
@@ -0,0 +1,469 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// MaxDepth limits how deeply a query may nest object fields
+const MaxDepth = 10
+
+// Schema is an executable schema. Only query operations are supported.
+type Schema struct {
+	Query *Object
+}
+
+// Object is an object type
+type Object struct {
+	Name   string
+	Fields map[string]*FieldDef
+}
+
+// FieldDef defines a field of an object type. Type is the object type of the
+// field's values, or nil for scalars, which are returned as their JSON
+// encoding. Args lists the accepted arguments and their defaults.
+type FieldDef struct {
+	Type    *Object
+	Args    map[string]interface{}
+	Resolve BatchResolveFunc
+}
+
+// BatchResolveFunc resolves a field for every object at the same path in
+// one call, returning one value per source. Values of object-typed fields
+// are the sources of the next level: a single value, a slice for a list,
+// or nil.
+type BatchResolveFunc func(ctx context.Context, sources []interface{}, args Args) ([]interface{}, error)
+
+// ResolveFunc resolves a field for a single source
+type ResolveFunc func(ctx context.Context, source interface{}, args Args) (interface{}, error)
+
+// Each adapts a per-source resolver for fields that need no batching
+func Each(fn ResolveFunc) BatchResolveFunc {
+	return func(ctx context.Context, sources []interface{}, args Args) ([]interface{}, error) {
+		values := make([]interface{}, len(sources))
+		for i, source := range sources {
+			value, err := fn(ctx, source, args)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+}
+
+// Args holds a field's argument values
+type Args map[string]interface{}
+
+// String returns a string argument, or "" when it is null
+func (a Args) String(name string) (string, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("argument %q must be a string", name)
+}
+
+// Int returns an integer argument, or 0 when it is null. Variables decoded
+// from JSON arrive as float64, so integral floats are accepted.
+func (a Args) Int(name string) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// Bool returns a boolean argument, or false when it is null
+func (a Args) Bool(name string) (bool, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+	return false, fmt.Errorf("argument %q must be a boolean", name)
+}
+
+// Request is a GraphQL request body
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response body. Data is left out when the request
+// failed before execution.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a request or field error
+type Error struct {
+	Message string   `json:"message"`
+	Path    []string `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Execute parses, validates and runs a request. Fields that fail resolve
+// to null and are reported in the response's errors.
+func (s *Schema) Execute(ctx context.Context, req *Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	if op.Type != "query" {
+		return &Response{Errors: []*Error{{Message: "only query operations are supported"}}}
+	}
+
+	e := &executor{doc: doc, vars: variables(op, req.Variables)}
+	if err := e.validate(s.Query, op.SelectionSet, 1); err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	results := e.execute(ctx, s.Query, []interface{}{nil}, op.SelectionSet, nil)
+	return &Response{Data: results[0], Errors: e.errors}
+}
+
+func (d *Document) operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has several operations")
+		}
+		return d.Operations[0], nil
+	}
+	for _, op := range d.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// variables applies the operation's variable defaults to those provided
+func variables(op *Operation, provided map[string]interface{}) map[string]interface{} {
+	vars := make(map[string]interface{}, len(op.Variables))
+	for _, def := range op.Variables {
+		if value, ok := provided[def.Name]; ok {
+			vars[def.Name] = value
+		} else if def.Default != nil {
+			vars[def.Name] = def.Default.Resolve(nil)
+		}
+	}
+	return vars
+}
+
+type executor struct {
+	doc    *Document
+	vars   map[string]interface{}
+	errors []*Error
+}
+
+// fieldGroup is the fields selected under one response key
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+// collectFields flattens fragments and applies @skip and @include, grouping
+// fields by response key in the order they were first selected
+func (e *executor) collectFields(obj *Object, selections []Selection) ([]*fieldGroup, error) {
+	var groups []*fieldGroup
+	index := make(map[string]*fieldGroup)
+	var collect func(selections []Selection, visiting map[string]bool) error
+	collect = func(selections []Selection, visiting map[string]bool) error {
+		for _, selection := range selections {
+			if !e.included(selection.directives()) {
+				continue
+			}
+			switch sel := selection.(type) {
+			case *Field:
+				key := sel.ResponseKey()
+				group, ok := index[key]
+				if !ok {
+					group = &fieldGroup{key: key}
+					index[key] = group
+					groups = append(groups, group)
+				}
+				group.fields = append(group.fields, sel)
+			case *InlineFragment:
+				if sel.TypeCondition != "" && sel.TypeCondition != obj.Name {
+					continue
+				}
+				if err := collect(sel.SelectionSet, visiting); err != nil {
+					return err
+				}
+			case *FragmentSpread:
+				fragment, ok := e.doc.Fragments[sel.Name]
+				if !ok {
+					return fmt.Errorf("unknown fragment %q", sel.Name)
+				}
+				if visiting[sel.Name] {
+					return fmt.Errorf("fragment %q spreads itself", sel.Name)
+				}
+				if fragment.TypeCondition != obj.Name {
+					continue
+				}
+				visiting[sel.Name] = true
+				err := collect(fragment.SelectionSet, visiting)
+				delete(visiting, sel.Name)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return groups, collect(selections, make(map[string]bool))
+}
+
+func (e *executor) included(directives []*Directive) bool {
+	for _, d := range directives {
+		value, ok := d.Arguments["if"]
+		if !ok {
+			continue
+		}
+		condition, _ := value.Resolve(e.vars).(bool)
+		switch d.Name {
+		case "skip":
+			if condition {
+				return false
+			}
+		case "include":
+			if !condition {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validate checks every selected field exists with known arguments and the
+// right kind of selection, before anything is resolved
+func (e *executor) validate(obj *Object, selections []Selection, depth int) error {
+	if depth > MaxDepth {
+		return fmt.Errorf("query is nested more than %d levels deep", MaxDepth)
+	}
+	groups, err := e.collectFields(obj, selections)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		name := group.fields[0].Name
+		var subselections []Selection
+		for _, f := range group.fields {
+			if f.Name != name {
+				return fmt.Errorf("fields %q and %q conflict under response key %q", name, f.Name, group.key)
+			}
+			subselections = append(subselections, f.SelectionSet...)
+		}
+
+		if name == "__typename" {
+			if len(subselections) > 0 {
+				return fmt.Errorf("field \"__typename\" has no subfields")
+			}
+			continue
+		}
+		def, ok := obj.Fields[name]
+		if !ok {
+			return fmt.Errorf("cannot query field %q on type %s", name, obj.Name)
+		}
+		for _, f := range group.fields {
+			for arg := range f.Arguments {
+				if _, ok := def.Args[arg]; !ok {
+					return fmt.Errorf("unknown argument %q on field %s.%s", arg, obj.Name, name)
+				}
+			}
+		}
+
+		if def.Type == nil {
+			if len(subselections) > 0 {
+				return fmt.Errorf("field %s.%s has no subfields", obj.Name, name)
+			}
+			continue
+		}
+		if len(subselections) == 0 {
+			return fmt.Errorf("field %s.%s must select subfields of %s", obj.Name, name, def.Type.Name)
+		}
+		if err := e.validate(def.Type, subselections, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arguments resolves a field's arguments over the definition's defaults
+func (e *executor) arguments(def *FieldDef, f *Field) Args {
+	args := make(Args, len(def.Args))
+	for name, value := range def.Args {
+		args[name] = value
+	}
+	for name, value := range f.Arguments {
+		if v, ok := value.(*Variable); ok {
+			if _, provided := e.vars[v.Name]; !provided {
+				continue
+			}
+		}
+		args[name] = value.Resolve(e.vars)
+	}
+	return args
+}
+
+// execute resolves a selection set for all sources at once. Each field is
+// resolved with a single call across the sources, and the object values it
+// returns are resolved together at the next level, so the number of
+// resolver calls grows with the query's size and not with the data's.
+func (e *executor) execute(ctx context.Context, obj *Object, sources []interface{}, selections []Selection, path []string) []*resultMap {
+	results := make([]*resultMap, len(sources))
+	for i := range results {
+		results[i] = &resultMap{values: make(map[string]interface{})}
+	}
+	groups, _ := e.collectFields(obj, selections) // Validated already
+
+	for _, group := range groups {
+		field := group.fields[0]
+		fieldPath := append(path[:len(path):len(path)], group.key)
+		if field.Name == "__typename" {
+			for _, r := range results {
+				r.set(group.key, obj.Name)
+			}
+			continue
+		}
+
+		def := obj.Fields[field.Name]
+		values, err := def.Resolve(ctx, sources, e.arguments(def, field))
+		if err == nil && len(values) != len(sources) {
+			err = fmt.Errorf("resolver returned %d values for %d objects", len(values), len(sources))
+		}
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Path: fieldPath})
+			for _, r := range results {
+				r.set(group.key, nil)
+			}
+			continue
+		}
+
+		if def.Type == nil {
+			for i, r := range results {
+				r.set(group.key, values[i])
+			}
+			continue
+		}
+
+		// Flatten the object values so the next level resolves in one pass,
+		// remembering where each came from. -1 marks a null.
+		var children []interface{}
+		slots := make([][]int, len(values))
+		lists := make([]bool, len(values))
+		for i, value := range values {
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+				lists[i] = true
+				slots[i] = make([]int, rv.Len())
+				for j := range slots[i] {
+					slots[i][j] = appendChild(&children, rv.Index(j).Interface())
+				}
+				continue
+			}
+			slots[i] = []int{appendChild(&children, value)}
+		}
+
+		var subselections []Selection
+		for _, f := range group.fields {
+			subselections = append(subselections, f.SelectionSet...)
+		}
+		childResults := e.execute(ctx, def.Type, children, subselections, fieldPath)
+
+		for i, r := range results {
+			items := make([]interface{}, len(slots[i]))
+			for j, slot := range slots[i] {
+				if slot >= 0 {
+					items[j] = childResults[slot]
+				}
+			}
+			if lists[i] {
+				r.set(group.key, items)
+			} else {
+				r.set(group.key, items[0])
+			}
+		}
+	}
+	return results
+}
+
+func appendChild(children *[]interface{}, value interface{}) int {
+	if isNil(value) {
+		return -1
+	}
+	*children = append(*children, value)
+	return len(*children) - 1
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// resultMap is an object result that keeps its fields in selection order
+type resultMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *resultMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON encodes the fields in selection order
+func (m *resultMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,220 @@
+// Package jira mirrors change tickets to Jira Cloud projects and pulls
+// status and comment changes made in Jira back onto the tickets.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxSearchPages bounds a single search so a bad JQL window cannot page forever
+const maxSearchPages = 20
+
+// Client is a minimal Jira Cloud REST API v3 client using API token auth
+type Client struct {
+	baseURL  string
+	email    string
+	apiToken string
+	http     *http.Client
+}
+
+// NewClient creates a client for a Jira Cloud site such as https://example.atlassian.net
+func NewClient(baseURL, email, apiToken string) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		email:    email,
+		apiToken: apiToken,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is a non-2xx response from Jira
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("jira API returned %d: %s", e.StatusCode, e.Body)
+}
+
+// Issue is the subset of a Jira issue the sync uses
+type Issue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Fields struct {
+		Status struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		Updated string `json:"updated"`
+	} `json:"fields"`
+}
+
+// Transition is a workflow transition available on an issue
+type Transition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   struct {
+		Name string `json:"name"`
+	} `json:"to"`
+}
+
+// Comment is a Jira issue comment. Body is Atlassian Document Format.
+type Comment struct {
+	ID     string `json:"id"`
+	Author struct {
+		DisplayName string `json:"displayName"`
+	} `json:"author"`
+	Body    json.RawMessage `json:"body"`
+	Created string          `json:"created"`
+}
+
+// CreateIssue creates an issue and returns its ID and key
+func (c *Client) CreateIssue(ctx context.Context, fields map[string]interface{}) (*Issue, error) {
+	issue := &Issue{}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/3/issue", map[string]interface{}{"fields": fields}, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// UpdateIssue sets fields on an existing issue
+func (c *Client) UpdateIssue(ctx context.Context, key string, fields map[string]interface{}) error {
+	path := "/rest/api/3/issue/" + url.PathEscape(key)
+	return c.do(ctx, http.MethodPut, path, map[string]interface{}{"fields": fields}, nil)
+}
+
+// GetIssue retrieves an issue's status
+func (c *Client) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	issue := &Issue{}
+	path := "/rest/api/3/issue/" + url.PathEscape(key) + "?fields=status,updated"
+	if err := c.do(ctx, http.MethodGet, path, nil, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// Transitions lists the transitions currently available on an issue
+func (c *Client) Transitions(ctx context.Context, key string) ([]Transition, error) {
+	var resp struct {
+		Transitions []Transition `json:"transitions"`
+	}
+	path := "/rest/api/3/issue/" + url.PathEscape(key) + "/transitions"
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Transitions, nil
+}
+
+// TransitionIssue moves an issue through a workflow transition
+func (c *Client) TransitionIssue(ctx context.Context, key, transitionID string) error {
+	path := "/rest/api/3/issue/" + url.PathEscape(key) + "/transitions"
+	body := map[string]interface{}{"transition": map[string]string{"id": transitionID}}
+	return c.do(ctx, http.MethodPost, path, body, nil)
+}
+
+// SearchIssues returns the issues matching a JQL query with their status
+func (c *Client) SearchIssues(ctx context.Context, jql string) ([]Issue, error) {
+	var issues []Issue
+	token := ""
+	for page := 0; page < maxSearchPages; page++ {
+		body := map[string]interface{}{
+			"jql":        jql,
+			"fields":     []string{"status", "updated"},
+			"maxResults": 100,
+		}
+		if token != "" {
+			body["nextPageToken"] = token
+		}
+
+		var resp struct {
+			Issues        []Issue `json:"issues"`
+			NextPageToken string  `json:"nextPageToken"`
+			IsLast        bool    `json:"isLast"`
+		}
+		if err := c.do(ctx, http.MethodPost, "/rest/api/3/search/jql", body, &resp); err != nil {
+			return nil, err
+		}
+		issues = append(issues, resp.Issues...)
+		if resp.IsLast || resp.NextPageToken == "" {
+			return issues, nil
+		}
+		token = resp.NextPageToken
+	}
+	return issues, nil
+}
+
+// Comments lists an issue's comments, oldest first
+func (c *Client) Comments(ctx context.Context, key string) ([]Comment, error) {
+	var comments []Comment
+	for startAt := 0; ; {
+		var resp struct {
+			Comments   []Comment `json:"comments"`
+			StartAt    int       `json:"startAt"`
+			MaxResults int       `json:"maxResults"`
+			Total      int       `json:"total"`
+		}
+		path := fmt.Sprintf("/rest/api/3/issue/%s/comment?orderBy=created&startAt=%d&maxResults=100", url.PathEscape(key), startAt)
+		if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+		comments = append(comments, resp.Comments...)
+		startAt += len(resp.Comments)
+		if len(resp.Comments) == 0 || startAt >= resp.Total {
+			return comments, nil
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode jira request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build jira request: %w", err)
+	}
+	req.SetBasicAuth(c.email, c.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read jira response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := string(data)
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return &APIError{StatusCode: resp.StatusCode, Body: msg}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode jira response: %w", err)
+	}
+	return nil
+}
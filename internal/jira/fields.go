@@ -0,0 +1,162 @@
+package jira
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// IssueFields builds the Jira fields for a ticket from the org's field
+// mapping. Text sent to the description field is converted to Atlassian
+// Document Format; the built-in priority field gets a Jira priority name.
+// Other targets receive plain values, and unset ticket fields clear theirs.
+func IssueFields(t *models.Ticket, mapping map[string]string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(mapping))
+	for field, target := range mapping {
+		value := ticketFieldValue(t, field)
+		switch target {
+		case "description":
+			if s, ok := value.(string); ok {
+				value = Document(s)
+			}
+		case "priority":
+			if field == "priority" {
+				value = map[string]string{"name": jiraPriority(t.Priority)}
+			}
+		}
+		fields[target] = value
+	}
+	return fields
+}
+
+func ticketFieldValue(t *models.Ticket, field string) interface{} {
+	switch field {
+	case "title":
+		return t.Title
+	case "description":
+		return t.Description
+	case "ticket_number":
+		return t.TicketNumber
+	case "priority":
+		return string(t.Priority)
+	case "risk_level":
+		return string(t.RiskLevel)
+	case "change_type":
+		return stringValue(t.ChangeType)
+	case "labels":
+		// Jira labels cannot contain spaces
+		labels := make([]string, 0, len(t.Labels))
+		for _, l := range t.Labels {
+			labels = append(labels, strings.ReplaceAll(l, " ", "-"))
+		}
+		return labels
+	case "impact_description":
+		return stringValue(t.ImpactDescription)
+	case "rollback_plan":
+		return stringValue(t.RollbackPlan)
+	case "testing_plan":
+		return stringValue(t.TestingPlan)
+	case "scheduled_start":
+		return timeValue(t.ScheduledStart)
+	case "scheduled_end":
+		return timeValue(t.ScheduledEnd)
+	}
+	return nil
+}
+
+func stringValue(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	// Jira datetime fields expect ISO 8601 with a numeric offset
+	return t.UTC().Format("2006-01-02T15:04:05.000-0700")
+}
+
+// jiraPriority maps ticket priorities onto Jira's default priority scheme
+func jiraPriority(p models.TicketPriority) string {
+	switch p {
+	case models.TicketPriorityEmergency, models.TicketPriorityUrgent:
+		return "Highest"
+	case models.TicketPriorityHigh:
+		return "High"
+	case models.TicketPriorityLow:
+		return "Low"
+	}
+	return "Medium"
+}
+
+// Document converts plain text to an Atlassian Document Format document,
+// one paragraph per blank-line separated block
+func Document(text string) map[string]interface{} {
+	content := []interface{}{}
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		var nodes []interface{}
+		for i, line := range strings.Split(block, "\n") {
+			if i > 0 {
+				nodes = append(nodes, map[string]interface{}{"type": "hardBreak"})
+			}
+			if line != "" {
+				nodes = append(nodes, map[string]interface{}{"type": "text", "text": line})
+			}
+		}
+		content = append(content, map[string]interface{}{"type": "paragraph", "content": nodes})
+	}
+	return map[string]interface{}{"type": "doc", "version": 1, "content": content}
+}
+
+// PlainText flattens an Atlassian Document Format body to text. Bodies
+// that are plain JSON strings (API v2) are returned as is.
+func PlainText(body json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(body, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+
+	var doc adfNode
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return ""
+	}
+	var b strings.Builder
+	doc.write(&b)
+	return strings.TrimSpace(b.String())
+}
+
+type adfNode struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text"`
+	Content []adfNode `json:"content"`
+	Attrs   struct {
+		Text string `json:"text"`
+	} `json:"attrs"`
+}
+
+func (n *adfNode) write(b *strings.Builder) {
+	switch n.Type {
+	case "text":
+		b.WriteString(n.Text)
+	case "hardBreak":
+		b.WriteString("\n")
+	case "mention", "emoji":
+		b.WriteString(n.Attrs.Text)
+	}
+	for i := range n.Content {
+		n.Content[i].write(b)
+	}
+	switch n.Type {
+	case "paragraph", "heading", "listItem", "codeBlock", "blockquote":
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,294 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+const (
+	pushBatchSize     = 100
+	pullOverlap       = 5 * time.Minute // re-read the edge of the last window in case of clock skew
+	initialPullWindow = 24 * time.Hour
+)
+
+// Syncer mirrors one organization's tickets to its Jira project.
+//
+// Loops are avoided through the sync state: a ticket is pushed only when
+// its version is newer than the version last written to Jira, and a Jira
+// status is pulled only when it differs from the status last pushed or
+// pulled. Status changes applied from Jira record the resulting ticket
+// version as pushed, so they are not sent straight back.
+type Syncer struct {
+	store       *store.Store
+	client      *Client
+	integration *models.JiraIntegration
+}
+
+// NewSyncer creates a syncer for an organization's Jira integration
+func NewSyncer(s *store.Store, integration *models.JiraIntegration) *Syncer {
+	return &Syncer{
+		store:       s,
+		client:      NewClient(integration.BaseURL, integration.Email, integration.APIToken),
+		integration: integration,
+	}
+}
+
+// Run pushes changed tickets to Jira, then pulls status and comment
+// changes back. Per-ticket push failures are recorded on the sync state
+// and counted in the result rather than aborting the run.
+func (s *Syncer) Run(ctx context.Context, now time.Time) (*models.JiraSyncResult, error) {
+	result := &models.JiraSyncResult{OrganizationID: s.integration.OrganizationID}
+
+	if err := s.push(ctx, result); err != nil {
+		return result, err
+	}
+	if err := s.pull(ctx, now, result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (s *Syncer) push(ctx context.Context, result *models.JiraSyncResult) error {
+	orgID := s.integration.OrganizationID
+
+	ticketIDs, err := s.store.Jira.ListTicketsToPush(ctx, orgID, pushBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, ticketID := range ticketIDs {
+		if err := s.pushTicket(ctx, ticketID, result); err != nil {
+			result.Errors++
+			if recErr := s.store.Jira.RecordError(ctx, orgID, ticketID, err.Error()); recErr != nil {
+				return recErr
+			}
+		}
+	}
+
+	return nil
+}
+
+// pushTicket creates or updates the ticket's issue and moves it to the
+// Jira status mapped to the ticket's status
+func (s *Syncer) pushTicket(ctx context.Context, ticketID uuid.UUID, result *models.JiraSyncResult) error {
+	orgID := s.integration.OrganizationID
+
+	ticket, err := s.store.Tickets.GetByID(ctx, orgID, ticketID)
+	if err != nil {
+		return err
+	}
+
+	state, err := s.store.Jira.GetSyncState(ctx, orgID, ticketID)
+	if err != nil && err.Error() != "jira sync state not found" {
+		return err
+	}
+	if state == nil {
+		state = &models.JiraSyncState{TicketID: ticketID, OrganizationID: orgID}
+	}
+
+	fields := IssueFields(ticket, s.integration.FieldMapping)
+	if state.IssueKey == nil {
+		fields["project"] = map[string]string{"key": s.integration.ProjectKey}
+		fields["issuetype"] = map[string]string{"name": s.integration.IssueType}
+		issue, err := s.client.CreateIssue(ctx, fields)
+		if err != nil {
+			return fmt.Errorf("failed to create jira issue: %w", err)
+		}
+		state.IssueID = &issue.ID
+		state.IssueKey = &issue.Key
+		result.IssuesCreated++
+
+		// Record the new issue before anything else can fail, so a retry
+		// updates it rather than creating a duplicate
+		if err := s.store.Jira.SaveSyncState(ctx, state); err != nil {
+			return err
+		}
+
+		issue, err = s.client.GetIssue(ctx, issue.Key)
+		if err != nil {
+			return fmt.Errorf("failed to get jira issue: %w", err)
+		}
+		state.JiraStatus = &issue.Fields.Status.Name
+	} else {
+		if err := s.client.UpdateIssue(ctx, *state.IssueKey, fields); err != nil {
+			return fmt.Errorf("failed to update jira issue %s: %w", *state.IssueKey, err)
+		}
+		result.IssuesUpdated++
+	}
+
+	if target, ok := s.integration.StatusMapping[ticket.Status]; ok {
+		if state.JiraStatus == nil || !strings.EqualFold(*state.JiraStatus, target) {
+			if err := s.transition(ctx, *state.IssueKey, target); err != nil {
+				return err
+			}
+			state.JiraStatus = &target
+			result.Transitions++
+		}
+	}
+
+	state.PushedVersion = ticket.Version
+	state.PushedStatus = ticket.Status
+	return s.store.Jira.SaveSyncState(ctx, state)
+}
+
+// transition moves an issue to the named status through whichever available
+// transition leads there
+func (s *Syncer) transition(ctx context.Context, issueKey, status string) error {
+	transitions, err := s.client.Transitions(ctx, issueKey)
+	if err != nil {
+		return fmt.Errorf("failed to list jira transitions for %s: %w", issueKey, err)
+	}
+	for _, t := range transitions {
+		if strings.EqualFold(t.To.Name, status) {
+			if err := s.client.TransitionIssue(ctx, issueKey, t.ID); err != nil {
+				return fmt.Errorf("failed to transition jira issue %s: %w", issueKey, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no jira transition from the current status of %s to %q", issueKey, status)
+}
+
+// pull applies status and comment changes made in Jira since the last pull
+func (s *Syncer) pull(ctx context.Context, now time.Time, result *models.JiraSyncResult) error {
+	orgID := s.integration.OrganizationID
+
+	since := now.Add(-initialPullWindow)
+	if s.integration.LastPulledAt != nil {
+		since = *s.integration.LastPulledAt
+	}
+	minutes := int(now.Sub(since.Add(-pullOverlap)).Minutes()) + 1
+	jql := fmt.Sprintf(`project = "%s" AND updated >= -%dm ORDER BY updated ASC`, s.integration.ProjectKey, minutes)
+
+	issues, err := s.client.SearchIssues(ctx, jql)
+	if err != nil {
+		return fmt.Errorf("failed to search jira issues: %w", err)
+	}
+	if len(issues) > 0 {
+		keys := make([]string, len(issues))
+		for i, issue := range issues {
+			keys[i] = issue.Key
+		}
+		states, err := s.store.Jira.ListSyncStatesByIssueKey(ctx, orgID, keys)
+		if err != nil {
+			return err
+		}
+
+		for i := range issues {
+			// Issues created directly in Jira have no ticket and are left alone
+			state, ok := states[issues[i].Key]
+			if !ok {
+				continue
+			}
+			if err := s.pullStatus(ctx, state, &issues[i], result); err != nil {
+				return err
+			}
+			if err := s.pullComments(ctx, state, issues[i].Key, result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.store.Jira.MarkPulled(ctx, orgID, now)
+}
+
+// pullStatus applies a Jira status change to the ticket when it maps to a
+// status the ticket may move to without skipping approval, and otherwise
+// notes the change on the ticket
+func (s *Syncer) pullStatus(ctx context.Context, state *models.JiraSyncState, issue *Issue, result *models.JiraSyncResult) error {
+	jiraStatus := issue.Fields.Status.Name
+	if jiraStatus == "" || (state.JiraStatus != nil && strings.EqualFold(*state.JiraStatus, jiraStatus)) {
+		return nil
+	}
+
+	orgID := s.integration.OrganizationID
+	actorID := s.integration.ActorUserID
+
+	ticket, err := s.store.Tickets.GetByID(ctx, orgID, state.TicketID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	to, mapped := s.integration.TicketStatusFor(jiraStatus)
+	switch {
+	case mapped && to == ticket.Status:
+	case mapped && models.JiraPullTransitionAllowed(ticket.Status, to):
+		if _, err := s.store.Tickets.UpdateStatus(ctx, orgID, ticket.ID, to, ticket.Version); err != nil {
+			var conflict *models.VersionConflictError
+			if errors.As(err, &conflict) {
+				// The ticket changed underneath us; the next push reconciles Jira
+				return nil
+			}
+			return err
+		}
+		s.store.Audit.LogTicketAccess(ctx, ticket.ID, actorID, "status_change", nil, nil, map[string]interface{}{
+			"from":        ticket.Status,
+			"to":          to,
+			"source":      "jira",
+			"issue_key":   issue.Key,
+			"jira_status": jiraStatus,
+		})
+		// The status change bumped the version; it already matches Jira
+		if state.PushedVersion == ticket.Version {
+			state.PushedVersion = ticket.Version + 1
+		}
+		state.PushedStatus = to
+		result.StatusesPulled++
+	default:
+		note := fmt.Sprintf("Jira issue %s moved to %q. The ticket stays %s; change its status here to keep the approval workflow intact.",
+			issue.Key, jiraStatus, ticket.Status)
+		if _, err := s.store.Comments.Create(ctx, orgID, ticket.ID, actorID, &models.CreateCommentInput{Comment: note}); err != nil {
+			return err
+		}
+	}
+
+	state.JiraStatus = &jiraStatus
+	return s.store.Jira.SaveSyncState(ctx, state)
+}
+
+// pullComments copies Jira comments not yet seen onto the ticket
+func (s *Syncer) pullComments(ctx context.Context, state *models.JiraSyncState, issueKey string, result *models.JiraSyncResult) error {
+	orgID := s.integration.OrganizationID
+
+	linked, err := s.store.Jira.LinkedCommentIDs(ctx, orgID, issueKey)
+	if err != nil {
+		return err
+	}
+	comments, err := s.client.Comments(ctx, issueKey)
+	if err != nil {
+		return fmt.Errorf("failed to list jira comments for %s: %w", issueKey, err)
+	}
+
+	for _, c := range comments {
+		if linked[c.ID] {
+			continue
+		}
+		text := PlainText(c.Body)
+		if text == "" {
+			continue
+		}
+
+		body := fmt.Sprintf("[Jira %s] %s: %s", issueKey, c.Author.DisplayName, text)
+		comment, err := s.store.Comments.Create(ctx, orgID, state.TicketID, s.integration.ActorUserID, &models.CreateCommentInput{Comment: body})
+		if err != nil {
+			return err
+		}
+		if err := s.store.Jira.LinkComment(ctx, orgID, c.ID, issueKey, comment.ID); err != nil {
+			return err
+		}
+		result.CommentsPulled++
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// electionInterval is how often a standby tries to take the lock and the
+// leader checks it still holds it
+const electionInterval = 5 * time.Second
+
+// Leadership gates a scheduler so that, of several replicas, only the one
+// holding leadership runs jobs
+type Leadership interface {
+	// Term returns a context cancelled when this replica stops leading, and
+	// false if it is not the leader
+	Term() (context.Context, bool)
+}
+
+// LeaderStatus reports an elector's state
+type LeaderStatus struct {
+	Leader bool       `json:"leader"`
+	Since  *time.Time `json:"since,omitempty"` // When leadership was taken
+}
+
+// Elector holds leadership through a Postgres session-level advisory lock
+// on a dedicated connection. Postgres releases the lock when the session
+// ends, so a replica that crashes or loses its connection hands leadership
+// to a standby within electionInterval.
+type Elector struct {
+	db     *sql.DB
+	key    int64
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	conn   *sql.Conn
+	term   context.Context
+	cancel context.CancelFunc
+	since  time.Time
+
+	done chan struct{}
+}
+
+// NewElector creates an elector for the lock named name. Replicas using the
+// same name and database elect one leader between them.
+func NewElector(db *sql.DB, name string, logger *zap.Logger) *Elector {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &Elector{
+		db:     db,
+		key:    int64(h.Sum64()),
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start makes a first attempt at the lock, so a sole replica leads before
+// its scheduler starts, then keeps campaigning until ctx is cancelled, when
+// the lock is released
+func (e *Elector) Start(ctx context.Context) {
+	e.campaign(ctx)
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(electionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				e.resign()
+				return
+			case <-ticker.C:
+				e.campaign(ctx)
+			}
+		}
+	}()
+}
+
+// Wait blocks until the elector has stopped and released the lock
+func (e *Elector) Wait() {
+	<-e.done
+}
+
+// Term implements Leadership
+func (e *Elector) Term() (context.Context, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.term == nil {
+		return nil, false
+	}
+	return e.term, true
+}
+
+// Status reports whether this replica is the leader
+func (e *Elector) Status() LeaderStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.term == nil {
+		return LeaderStatus{}
+	}
+	since := e.since
+	return LeaderStatus{Leader: true, Since: &since}
+}
+
+// campaign takes the lock if it is free, or checks it is still held
+func (e *Elector) campaign(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, electionInterval)
+	defer cancel()
+
+	e.mu.Lock()
+	leading := e.term != nil
+	e.mu.Unlock()
+
+	if leading {
+		if err := e.check(ctx); err != nil {
+			e.logger.Warn("Lost worker leadership", zap.Error(err))
+			e.stepDown()
+		}
+		return
+	}
+
+	acquired, err := e.tryLock(ctx)
+	if err != nil {
+		e.logger.Warn("Leader election failed", zap.Error(err))
+		e.closeConn()
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	e.mu.Lock()
+	e.term, e.cancel = context.WithCancel(context.Background())
+	e.since = time.Now()
+	e.mu.Unlock()
+	e.logger.Info("Took worker leadership")
+}
+
+func (e *Elector) tryLock(ctx context.Context) (bool, error) {
+	if e.conn == nil {
+		conn, err := e.db.Conn(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to open leader election connection: %w", err)
+		}
+		e.conn = conn
+	}
+
+	var acquired bool
+	if err := e.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("failed to take leader lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// check confirms the session still holds the lock. The connection is the
+// only one the elector takes advisory locks on, so any granted advisory
+// lock of its backend is the leader lock.
+func (e *Elector) check(ctx context.Context) error {
+	var held bool
+	err := e.conn.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_locks
+			WHERE locktype = 'advisory' AND pid = pg_backend_pid() AND granted
+		)`).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("failed to check leader lock: %w", err)
+	}
+	if !held {
+		return fmt.Errorf("leader lock no longer held")
+	}
+	return nil
+}
+
+// stepDown cancels the current term and closes the connection, which
+// releases the lock if the session is still alive
+func (e *Elector) stepDown() {
+	e.mu.Lock()
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.term, e.cancel = nil, nil
+	e.mu.Unlock()
+	e.closeConn()
+}
+
+// resign releases the lock on shutdown so a standby can take over at once
+func (e *Elector) resign() {
+	e.mu.Lock()
+	leading := e.term != nil
+	e.mu.Unlock()
+
+	if leading {
+		ctx, cancel := context.WithTimeout(context.Background(), electionInterval)
+		defer cancel()
+		if _, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.key); err != nil {
+			e.logger.Warn("Failed to release leader lock", zap.Error(err))
+		} else {
+			e.logger.Info("Released worker leadership")
+		}
+	}
+	e.stepDown()
+}
+
+// closeConn discards the connection rather than returning it to the pool,
+// where the session would keep holding the lock
+func (e *Elector) closeConn() {
+	if e.conn != nil {
+		e.conn.Raw(func(any) error { return driver.ErrBadConn })
+		e.conn.Close()
+		e.conn = nil
+	}
+}
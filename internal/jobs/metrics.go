@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// jobStats counts a job's runs for WriteMetrics
+type jobStats struct {
+	mu sync.Mutex
+	jobCounts
+}
+
+type jobCounts struct {
+	runs         int64
+	failures     int64 // Runs that returned an error or panicked
+	skipped      int64 // Runs skipped because the previous one was still going
+	durationSum  time.Duration
+	lastDuration time.Duration
+	lastSuccess  time.Time
+}
+
+func (st *jobStats) record(d time.Duration, failed bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.runs++
+	st.durationSum += d
+	st.lastDuration = d
+	if failed {
+		st.failures++
+	} else {
+		st.lastSuccess = time.Now()
+	}
+}
+
+func (st *jobStats) skip() {
+	st.mu.Lock()
+	st.skipped++
+	st.mu.Unlock()
+}
+
+// WriteMetrics writes each job's run counters and durations in the
+// Prometheus text format. A replica that isn't leading reports no runs.
+func (s *Scheduler) WriteMetrics(w io.Writer) {
+	type snapshot struct {
+		jobCounts
+		name    string
+		running bool
+	}
+	snaps := make([]snapshot, len(s.entries))
+	for i, e := range s.entries {
+		e.stats.mu.Lock()
+		snaps[i] = snapshot{jobCounts: e.stats.jobCounts, name: e.job.Name, running: e.running.Load()}
+		e.stats.mu.Unlock()
+	}
+
+	fmt.Fprintln(w, "# HELP adsops_worker_job_runs_total Job runs finished.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_runs_total counter")
+	for _, sn := range snaps {
+		fmt.Fprintf(w, "adsops_worker_job_runs_total{job=%q} %d\n", sn.name, sn.runs)
+	}
+	fmt.Fprintln(w, "# HELP adsops_worker_job_failures_total Job runs that returned an error or panicked.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_failures_total counter")
+	for _, sn := range snaps {
+		fmt.Fprintf(w, "adsops_worker_job_failures_total{job=%q} %d\n", sn.name, sn.failures)
+	}
+	fmt.Fprintln(w, "# HELP adsops_worker_job_skipped_total Job runs skipped because the previous run was still going.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_skipped_total counter")
+	for _, sn := range snaps {
+		fmt.Fprintf(w, "adsops_worker_job_skipped_total{job=%q} %d\n", sn.name, sn.skipped)
+	}
+	fmt.Fprintln(w, "# HELP adsops_worker_job_running Whether the job is running.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_running gauge")
+	for _, sn := range snaps {
+		running := 0
+		if sn.running {
+			running = 1
+		}
+		fmt.Fprintf(w, "adsops_worker_job_running{job=%q} %d\n", sn.name, running)
+	}
+	fmt.Fprintln(w, "# HELP adsops_worker_job_duration_seconds Time taken by job runs.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_duration_seconds summary")
+	for _, sn := range snaps {
+		fmt.Fprintf(w, "adsops_worker_job_duration_seconds_sum{job=%q} %g\n", sn.name, sn.durationSum.Seconds())
+		fmt.Fprintf(w, "adsops_worker_job_duration_seconds_count{job=%q} %d\n", sn.name, sn.runs)
+	}
+	fmt.Fprintln(w, "# HELP adsops_worker_job_last_duration_seconds Time taken by the job's last run.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_last_duration_seconds gauge")
+	for _, sn := range snaps {
+		fmt.Fprintf(w, "adsops_worker_job_last_duration_seconds{job=%q} %g\n", sn.name, sn.lastDuration.Seconds())
+	}
+	fmt.Fprintln(w, "# HELP adsops_worker_job_last_success_timestamp_seconds Time of the job's last successful run.")
+	fmt.Fprintln(w, "# TYPE adsops_worker_job_last_success_timestamp_seconds gauge")
+	for _, sn := range snaps {
+		if !sn.lastSuccess.IsZero() {
+			fmt.Fprintf(w, "adsops_worker_job_last_success_timestamp_seconds{job=%q} %d\n", sn.name, sn.lastSuccess.Unix())
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package jobs runs the worker's background jobs on cron-style schedules
+// with per-job timeouts, jitter, panic recovery and overlap prevention.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a named unit of background work
+type Job struct {
+	Name     string
+	Schedule string        // Cron expression or descriptor, see ParseSchedule
+	Timeout  time.Duration // Cancels the run's context; zero means no limit
+	Jitter   time.Duration // Random delay of up to this much before each run
+
+	// RunOnStart runs the job once as soon as the scheduler starts, before
+	// its first scheduled time
+	RunOnStart bool
+
+	Run func(ctx context.Context) error
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	running  atomic.Bool
+	stats    jobStats
+}
+
+// Scheduler runs registered jobs until its context is cancelled
+type Scheduler struct {
+	logger     *zap.Logger
+	entries    []*entry
+	names      map[string]bool
+	leadership Leadership
+	wg         sync.WaitGroup
+}
+
+// New creates an empty scheduler
+func New(logger *zap.Logger) *Scheduler {
+	return &Scheduler{logger: logger, names: make(map[string]bool)}
+}
+
+// Register adds a job. It must be called before Start.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+	if s.names[job.Name] {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	if job.Run == nil {
+		return fmt.Errorf("job %s has no Run function", job.Name)
+	}
+	schedule, err := ParseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("job %s: schedule %q never fires", job.Name, job.Schedule)
+	}
+
+	s.names[job.Name] = true
+	s.entries = append(s.entries, &entry{job: job, schedule: schedule})
+	return nil
+}
+
+// MustRegister adds a job and panics if it is invalid
+func (s *Scheduler) MustRegister(job Job) {
+	if err := s.Register(job); err != nil {
+		panic(err)
+	}
+}
+
+// SetLeadership makes the scheduler run jobs only while l reports this
+// replica as the leader. A run in progress when leadership is lost has its
+// context cancelled. A RunOnStart job runs at start only if the replica is
+// leading then; one that takes over later runs it on schedule. It must be
+// called before Start.
+func (s *Scheduler) SetLeadership(l Leadership) {
+	s.leadership = l
+}
+
+// Start launches every registered job's schedule loop
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+}
+
+// Wait blocks until every schedule loop and in-flight run has returned, or
+// until timeout. It returns false on timeout.
+func (s *Scheduler) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	if e.job.RunOnStart {
+		s.dispatch(ctx, e)
+	}
+
+	for {
+		now := time.Now()
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			s.logger.Error("Job schedule never fires", zap.String("job", e.job.Name))
+			return
+		}
+		wait := next.Sub(now)
+		if e.job.Jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(e.job.Jitter)))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.dispatch(ctx, e)
+		}
+	}
+}
+
+// dispatch starts a run unless this replica isn't leading or the previous
+// run is still going
+func (s *Scheduler) dispatch(ctx context.Context, e *entry) {
+	var term context.Context
+	if s.leadership != nil {
+		var leading bool
+		if term, leading = s.leadership.Term(); !leading {
+			s.logger.Debug("Skipping job run, not the leader", zap.String("job", e.job.Name))
+			return
+		}
+	}
+	if !e.running.CompareAndSwap(false, true) {
+		e.stats.skip()
+		s.logger.Warn("Skipping job run, previous run still in progress", zap.String("job", e.job.Name))
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer e.running.Store(false)
+
+		runCtx := ctx
+		if term != nil {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithCancel(ctx)
+			defer cancel()
+			stop := context.AfterFunc(term, cancel)
+			defer stop()
+		}
+		s.run(runCtx, e)
+	}()
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	started := time.Now()
+	failed := true
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Job panicked",
+				zap.String("job", e.job.Name),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()),
+			)
+		}
+		e.stats.record(time.Since(started), failed)
+	}()
+
+	runCtx := ctx
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	if err := e.job.Run(runCtx); err != nil {
+		s.logger.Error("Job failed",
+			zap.String("job", e.job.Name),
+			zap.Duration("duration", time.Since(started)),
+			zap.Error(err),
+		)
+		return
+	}
+	failed = false
+	s.logger.Debug("Job finished",
+		zap.String("job", e.job.Name),
+		zap.Duration("duration", time.Since(started)),
+	)
+}
@@ -120,7 +120,7 @@ func (a *TicketACL) IsActive() bool {
 
 // GrantTicketACLInput represents input for granting access to a ticket
 type GrantTicketACLInput struct {
-	TicketID      uuid.UUID     `json:"ticket_id" validate:"required"`
+	TicketID      uuid.UUID     `json:"ticket_id"` // Set from the URL
 	PrincipalType string        `json:"principal_type" validate:"required,oneof=user group role"`
 	PrincipalID   *uuid.UUID    `json:"principal_id,omitempty"`   // Required for user/group
 	RoleName      *string       `json:"role_name,omitempty"`      // Required for role
@@ -131,6 +131,17 @@ type GrantTicketACLInput struct {
 
 // Validate validates the input
 func (i *GrantTicketACLInput) Validate() error {
+	switch i.PrincipalType {
+	case ACLPrincipalUser, ACLPrincipalGroup, ACLPrincipalRole:
+	default:
+		return &ValidationError{Field: "principal_type", Message: "principal_type must be user, group or role"}
+	}
+	if !i.ACLRole.Valid() {
+		return &ValidationError{Field: "acl_role", Message: "invalid acl_role"}
+	}
+	if i.ExpiresAt != nil && i.ExpiresAt.Before(time.Now()) {
+		return &ValidationError{Field: "expires_at", Message: "expires_at must be in the future"}
+	}
 	if i.PrincipalType == "user" || i.PrincipalType == "group" {
 		if i.PrincipalID == nil {
 			return &ValidationError{Field: "principal_id", Message: "principal_id is required for user/group type"}
@@ -161,6 +172,114 @@ type BulkGrantACLInput struct {
 	Reason    *string        `json:"reason,omitempty"`
 }
 
+// ACL principal types
+const (
+	ACLPrincipalUser  = "user"
+	ACLPrincipalGroup = "group"
+	ACLPrincipalRole  = "role"
+)
+
+// TicketAccessor identifies who is reading or writing tickets. It is carried
+// on the request context; a nil accessor means a system caller with full access.
+type TicketAccessor struct {
+	UserID uuid.UUID
+	Roles  []string
+}
+
+// HasRole returns true if the accessor has the given organization role
+func (a *TicketAccessor) HasRole(role string) bool {
+	for _, r := range a.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanReadAll returns true if the accessor can see every ticket in the organization
+func (a *TicketAccessor) CanReadAll() bool {
+	return a.HasRole(string(UserRoleAdmin)) || a.HasRole(string(UserRoleAuditor))
+}
+
+// CanWriteAll returns true if the accessor can modify every ticket in the organization
+func (a *TicketAccessor) CanWriteAll() bool {
+	return a.HasRole(string(UserRoleAdmin))
+}
+
+// TicketAccess is a user's effective access to a single ticket
+type TicketAccess struct {
+	Roles      []TicketACLRole `json:"roles"`
+	IsCreator  bool            `json:"is_creator"`
+	IsAssignee bool            `json:"is_assignee"`
+	Inherited  bool            `json:"inherited"` // Via project lead or owning group membership
+	ReadAll    bool            `json:"read_all"`
+	WriteAll   bool            `json:"write_all"`
+}
+
+// CanView returns true if the ticket is visible. Non-confidential tickets are
+// visible to the whole organization.
+func (a *TicketAccess) CanView(confidential bool) bool {
+	if !confidential || a.ReadAll || a.IsCreator || a.IsAssignee || a.Inherited {
+		return true
+	}
+	for _, r := range a.Roles {
+		if r.CanView() {
+			return true
+		}
+	}
+	return false
+}
+
+// CanComment returns true if the user can comment on the ticket
+func (a *TicketAccess) CanComment() bool {
+	if a.WriteAll || a.IsCreator || a.IsAssignee || a.Inherited {
+		return true
+	}
+	for _, r := range a.Roles {
+		if r.CanComment() {
+			return true
+		}
+	}
+	return false
+}
+
+// CanEdit returns true if the user can modify the ticket
+func (a *TicketAccess) CanEdit() bool {
+	if a.WriteAll || a.IsCreator || a.IsAssignee || a.Inherited {
+		return true
+	}
+	for _, r := range a.Roles {
+		if r.CanEdit() {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageACLs returns true if the user can grant and revoke ticket access
+func (a *TicketAccess) CanManageACLs() bool {
+	if a.WriteAll || a.IsCreator {
+		return true
+	}
+	for _, r := range a.Roles {
+		if r.CanManageACLs() {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketAuditEvent is one event for AuditStore.LogTicketEvents to record.
+// A nil UserID records it as done by the system.
+type TicketAuditEvent struct {
+	TicketID  uuid.UUID
+	UserID    *uuid.UUID
+	Action    string
+	IPAddress *string
+	UserAgent *string
+	Changes   map[string]interface{}
+}
+
 // TicketAuditLog represents a SOX-compliant audit log entry for tickets
 type TicketAuditLog struct {
 	ID                   uuid.UUID             `db:"id" json:"id"`
@@ -180,9 +299,12 @@ type TicketAuditLog struct {
 	IsComplianceRelevant bool                  `db:"is_compliance_relevant" json:"is_compliance_relevant"`
 	ComplianceFrameworks []ComplianceFramework `db:"compliance_frameworks" json:"compliance_frameworks,omitempty"`
 	RequiresReview       bool                  `db:"requires_review" json:"requires_review"`
+	IsEmergency          bool                  `db:"is_emergency" json:"is_emergency"`
 	ReviewedBy           *uuid.UUID            `db:"reviewed_by" json:"reviewed_by,omitempty"`
 	ReviewedAt           *time.Time            `db:"reviewed_at" json:"reviewed_at,omitempty"`
 	CreatedAt            time.Time             `db:"created_at" json:"created_at"`
+	Anonymized           bool                  `db:"anonymized" json:"anonymized"`
+	AnonymizedAt         *time.Time            `db:"anonymized_at" json:"anonymized_at,omitempty"`
 
 	// Relationships
 	User         *UserSummary `db:"-" json:"user,omitempty"`
@@ -197,10 +319,15 @@ type AuditLogFilter struct {
 	ActionCategory       *string    `json:"action_category,omitempty"`
 	IsComplianceRelevant *bool      `json:"is_compliance_relevant,omitempty"`
 	RequiresReview       *bool      `json:"requires_review,omitempty"`
+	IsEmergency          *bool      `json:"is_emergency,omitempty"`
 	FromDate             *time.Time `json:"from_date,omitempty"`
 	ToDate               *time.Time `json:"to_date,omitempty"`
 	Page                 int        `json:"page" validate:"min=1"`
 	PerPage              int        `json:"per_page" validate:"min=1,max=100"`
+	// Keyset pagination over created_at: when Cursor is set Page is
+	// ignored. NextCursor is set by the store when another page follows.
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"-"`
 }
 
 // SetDefaults sets default values for the filter
@@ -218,6 +345,50 @@ func (f *AuditLogFilter) Offset() int {
 	return (f.Page - 1) * f.PerPage
 }
 
+// MaxAuditExportRows caps a single audit export so one request cannot
+// stream the entire history of a large organization
+const MaxAuditExportRows = 100000
+
+// AuditExportFilter represents filter options for bulk audit log export
+type AuditExportFilter struct {
+	FromDate       *time.Time            `json:"from_date,omitempty"`
+	ToDate         *time.Time            `json:"to_date,omitempty"`
+	Frameworks     []ComplianceFramework `json:"frameworks,omitempty"` // Matches entries tagged with any of these
+	ActionCategory *string               `json:"action_category,omitempty"`
+	ComplianceOnly bool                  `json:"compliance_only,omitempty"`
+	EmergencyOnly  bool                  `json:"emergency_only,omitempty"`
+	MaxRows        int                   `json:"max_rows,omitempty"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *AuditExportFilter) SetDefaults() {
+	if f.MaxRows < 1 || f.MaxRows > MaxAuditExportRows {
+		f.MaxRows = MaxAuditExportRows
+	}
+}
+
+// AuditArchive records one day of the ticket audit log archived to object
+// storage for long-term retention
+type AuditArchive struct {
+	Date               time.Time `db:"archive_date" json:"date"` // UTC day archived
+	ObjectKey          string    `db:"object_key" json:"object_key"`
+	ManifestKey        string    `db:"manifest_key" json:"manifest_key"`
+	RecordCount        int64     `db:"record_count" json:"record_count"`
+	CompressedBytes    int64     `db:"compressed_bytes" json:"compressed_bytes"`
+	SHA256             string    `db:"sha256" json:"sha256"`                           // Of the gzipped object
+	UncompressedSHA256 string    `db:"uncompressed_sha256" json:"uncompressed_sha256"` // Of the NDJSON inside
+	ExportedAt         time.Time `db:"exported_at" json:"exported_at"`
+}
+
+// AuditPartition is a month of the ticket audit log, stored as its own
+// partition of the table
+type AuditPartition struct {
+	Name          string    `json:"name"`
+	From          time.Time `json:"from"` // First instant of the UTC month
+	To            time.Time `json:"to"`   // Exclusive
+	EstimatedRows int64     `json:"estimated_rows"`
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -261,6 +432,33 @@ type CollectContactInfoInput struct {
 	Message          *string      `json:"message,omitempty"`
 }
 
+// FailedSignupContactThreshold is the number of failed attempts after which
+// the signup form offers to collect contact details for follow-up
+const FailedSignupContactThreshold = 5
+
+// FailedSignupFilter represents filter options for listing failed signups
+type FailedSignupFilter struct {
+	ContactCollected *bool `json:"contact_collected,omitempty"`
+	Unresolved       bool  `json:"unresolved,omitempty"`
+	Page             int   `json:"page" validate:"min=1"`
+	PerPage          int   `json:"per_page" validate:"min=1,max=100"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *FailedSignupFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the SQL offset for pagination
+func (f *FailedSignupFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
 // ResolveFailedSignupInput represents input for resolving a failed signup
 type ResolveFailedSignupInput struct {
 	Resolution string `json:"resolution" validate:"required"`
@@ -1,12 +1,25 @@
 package models
 
 import (
+	"errors"
 	"net"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrApprovalLinkExpired is returned when an emailed approval link is used
+// after ApprovalTokenTTL
+var ErrApprovalLinkExpired = errors.New("approval link has expired")
+
+// ApprovalTokenTTL is how long the link in an approval request email can be
+// used to decide the approval without logging in
+const ApprovalTokenTTL = 72 * time.Hour
+
+// AuditActionApprovalsExpired records a ticket sent back because its
+// approval deadline passed without a decision
+const AuditActionApprovalsExpired = "approvals_expired"
+
 // Approval represents an approval record for a ticket
 type Approval struct {
 	ID                 uuid.UUID      `db:"id" json:"id"`
@@ -90,6 +103,34 @@ type RequestUpdateInput struct {
 	RequiredChanges string `json:"required_changes" validate:"required,min=10"`
 }
 
+// Decision returns the approval decision the input records
+func (i *ApproveInput) Decision() ApprovalDecision {
+	return ApprovalDecision{Status: ApprovalStatusApproved, Comment: i.Comment, Conditions: i.Conditions}
+}
+
+// Decision returns the approval decision the input records, with the
+// reason ahead of the comment
+func (i *DenyInput) Decision() ApprovalDecision {
+	comment := i.Reason + "\n\n" + i.Comment
+	return ApprovalDecision{Status: ApprovalStatusDenied, Comment: &comment}
+}
+
+// Decision returns the approval decision the input records, with the
+// required changes ahead of the comment
+func (i *RequestUpdateInput) Decision() ApprovalDecision {
+	comment := i.RequiredChanges + "\n\n" + i.Comment
+	return ApprovalDecision{Status: ApprovalStatusUpdateRequested, Comment: &comment}
+}
+
+// ApprovalDecision is an approver's decision on a pending approval
+type ApprovalDecision struct {
+	Status     ApprovalStatus
+	Comment    *string
+	Conditions *string
+	IP         *string // Where the decision came from, for the record
+	UserAgent  *string
+}
+
 // ApprovalListFilter represents filter options for listing approvals
 type ApprovalListFilter struct {
 	Status       []ApprovalStatus `json:"status,omitempty"`
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApprovalRule represents an org-configured rule that decides which approvals
+// a ticket needs when it is submitted
+type ApprovalRule struct {
+	ID             uuid.UUID             `db:"id" json:"id"`
+	OrganizationID uuid.UUID             `db:"organization_id" json:"organization_id"`
+	Name           string                `db:"name" json:"name"`
+	Description    *string               `db:"description" json:"description,omitempty"`
+	EvalOrder      int                   `db:"eval_order" json:"eval_order"` // Lower values are evaluated first
+	Conditions     ApprovalRuleCondition `db:"conditions" json:"conditions"`
+	Requirements   []ApprovalRequirement `db:"requirements" json:"requirements"`
+	AutoApprove    bool                  `db:"auto_approve" json:"auto_approve"`
+	StopProcessing bool                  `db:"stop_processing" json:"stop_processing"` // Skip later rules when this one matches
+	IsActive       bool                  `db:"is_active" json:"is_active"`
+	CreatedBy      *uuid.UUID            `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+// ApprovalRuleCondition describes which tickets a rule applies to.
+// Empty fields match any value; populated fields must all match.
+type ApprovalRuleCondition struct {
+	RiskLevels           []RiskLevel           `json:"risk_levels,omitempty"`
+	Priorities           []TicketPriority      `json:"priorities,omitempty"`
+	ChangeTypes          []string              `json:"change_types,omitempty"`
+	ComplianceFrameworks []ComplianceFramework `json:"compliance_frameworks,omitempty"`                   // Matches if the ticket has any of these
+	MinRiskScore         int                   `json:"min_risk_score,omitempty" validate:"min=0,max=100"` // Matches tickets whose risk score is at least this
+}
+
+// Matches returns true if the ticket satisfies every populated condition
+func (c *ApprovalRuleCondition) Matches(t *Ticket) bool {
+	if len(c.RiskLevels) > 0 && !containsRiskLevel(c.RiskLevels, t.RiskLevel) {
+		return false
+	}
+	if len(c.Priorities) > 0 && !containsPriority(c.Priorities, t.Priority) {
+		return false
+	}
+	if c.MinRiskScore > 0 && t.RiskScore < c.MinRiskScore {
+		return false
+	}
+	if len(c.ChangeTypes) > 0 {
+		if t.ChangeType == nil {
+			return false
+		}
+		found := false
+		for _, ct := range c.ChangeTypes {
+			if ct == *t.ChangeType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(c.ComplianceFrameworks) > 0 {
+		found := false
+		for _, want := range c.ComplianceFrameworks {
+			for _, have := range t.ComplianceFrameworks {
+				if want == have {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ApprovalRequirement is a quorum for a single approval type,
+// e.g. 2 of the available security approvers
+type ApprovalRequirement struct {
+	ApprovalType ApprovalType `json:"approval_type"`
+	MinApprovals int          `json:"min_approvals"`
+}
+
+// ApprovalPlan is the result of evaluating approval rules against a ticket.
+// It is stored on the ticket at submit time so later rule edits do not
+// change the requirements of in-flight changes.
+type ApprovalPlan struct {
+	AutoApprove    bool                  `json:"auto_approve"`
+	Requirements   []ApprovalRequirement `json:"requirements"`
+	MatchedRuleIDs []uuid.UUID           `json:"matched_rule_ids,omitempty"`
+	EvaluatedAt    time.Time             `json:"evaluated_at"`
+}
+
+// ApprovalOutcome is the aggregate state of a ticket's approvals against its plan
+type ApprovalOutcome string
+
+const (
+	ApprovalOutcomePending         ApprovalOutcome = "pending"
+	ApprovalOutcomePartial         ApprovalOutcome = "partial"
+	ApprovalOutcomeApproved        ApprovalOutcome = "approved"
+	ApprovalOutcomeDenied          ApprovalOutcome = "denied"
+	ApprovalOutcomeUpdateRequested ApprovalOutcome = "update_requested"
+)
+
+// EvaluateApprovalRules builds an approval plan for a ticket from the given rules.
+// Rules are expected in evaluation order. Requirements from every matching rule
+// are merged, keeping the highest quorum per approval type. If no rule matches,
+// the ticket falls back to one approval per requested approval type, or as
+// many as its compliance frameworks demand, such as two under SOX.
+func EvaluateApprovalRules(t *Ticket, rules []ApprovalRule) ApprovalPlan {
+	plan := ApprovalPlan{EvaluatedAt: time.Now()}
+	quorum := make(map[ApprovalType]int)
+	var order []ApprovalType
+
+	addRequirement := func(req ApprovalRequirement) {
+		min := req.MinApprovals
+		if min < 1 {
+			min = 1
+		}
+		current, seen := quorum[req.ApprovalType]
+		if !seen {
+			order = append(order, req.ApprovalType)
+		}
+		if min > current {
+			quorum[req.ApprovalType] = min
+		}
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsActive || !rule.Conditions.Matches(t) {
+			continue
+		}
+		plan.MatchedRuleIDs = append(plan.MatchedRuleIDs, rule.ID)
+		if rule.AutoApprove {
+			plan.AutoApprove = true
+		}
+		for _, req := range rule.Requirements {
+			addRequirement(req)
+		}
+		if rule.StopProcessing {
+			break
+		}
+	}
+
+	// Any explicit requirement overrides auto-approval
+	if len(quorum) > 0 {
+		plan.AutoApprove = false
+	}
+
+	if len(plan.MatchedRuleIDs) == 0 {
+		min := max(1, t.complianceMinApprovals())
+		for _, at := range t.RequiresApprovalTypes {
+			addRequirement(ApprovalRequirement{ApprovalType: at, MinApprovals: min})
+		}
+	}
+
+	for _, at := range order {
+		plan.Requirements = append(plan.Requirements, ApprovalRequirement{
+			ApprovalType: at,
+			MinApprovals: quorum[at],
+		})
+	}
+
+	return plan
+}
+
+// Outcome computes the aggregate approval state for the plan. A single denial
+// or update request is decisive; otherwise the ticket is approved once every
+// requirement has reached its quorum.
+func (p *ApprovalPlan) Outcome(approvals []Approval) ApprovalOutcome {
+	if p.AutoApprove {
+		return ApprovalOutcomeApproved
+	}
+
+	approved := make(map[ApprovalType]int)
+	for _, a := range approvals {
+		switch a.Status {
+		case ApprovalStatusDenied:
+			return ApprovalOutcomeDenied
+		case ApprovalStatusUpdateRequested:
+			return ApprovalOutcomeUpdateRequested
+		case ApprovalStatusApproved:
+			approved[a.ApprovalType]++
+		}
+	}
+
+	satisfied := 0
+	anyApproved := false
+	for _, req := range p.Requirements {
+		if approved[req.ApprovalType] > 0 {
+			anyApproved = true
+		}
+		if approved[req.ApprovalType] >= req.MinApprovals {
+			satisfied++
+		}
+	}
+
+	switch {
+	case satisfied == len(p.Requirements):
+		return ApprovalOutcomeApproved
+	case anyApproved:
+		return ApprovalOutcomePartial
+	default:
+		return ApprovalOutcomePending
+	}
+}
+
+// TicketStatus maps the aggregate outcome to the ticket status it implies
+func (o ApprovalOutcome) TicketStatus() TicketStatus {
+	switch o {
+	case ApprovalOutcomeApproved:
+		return TicketStatusApproved
+	case ApprovalOutcomeDenied:
+		return TicketStatusDenied
+	case ApprovalOutcomeUpdateRequested:
+		return TicketStatusUpdateRequested
+	case ApprovalOutcomePartial:
+		return TicketStatusPartiallyApproved
+	}
+	return TicketStatusInReview
+}
+
+// CreateApprovalRuleInput represents input for creating an approval rule
+type CreateApprovalRuleInput struct {
+	Name           string                `json:"name" validate:"required,min=2,max=255"`
+	Description    *string               `json:"description,omitempty"`
+	EvalOrder      int                   `json:"eval_order"`
+	Conditions     ApprovalRuleCondition `json:"conditions"`
+	Requirements   []ApprovalRequirement `json:"requirements" validate:"dive"`
+	AutoApprove    bool                  `json:"auto_approve"`
+	StopProcessing bool                  `json:"stop_processing"`
+}
+
+// Validate checks that the rule is internally consistent
+func (i *CreateApprovalRuleInput) Validate() error {
+	if !i.AutoApprove && len(i.Requirements) == 0 {
+		return &ValidationError{Field: "requirements", Message: "at least one requirement is needed unless auto_approve is set"}
+	}
+	if i.AutoApprove && len(i.Requirements) > 0 {
+		return &ValidationError{Field: "auto_approve", Message: "auto-approving rules cannot also have requirements"}
+	}
+	for _, req := range i.Requirements {
+		if !req.ApprovalType.Valid() {
+			return &ValidationError{Field: "requirements", Message: "invalid approval type: " + string(req.ApprovalType)}
+		}
+		if req.MinApprovals < 1 {
+			return &ValidationError{Field: "requirements", Message: "min_approvals must be at least 1"}
+		}
+	}
+	return nil
+}
+
+// UpdateApprovalRuleInput represents input for updating an approval rule
+type UpdateApprovalRuleInput struct {
+	Name           *string                `json:"name,omitempty" validate:"omitempty,min=2,max=255"`
+	Description    *string                `json:"description,omitempty"`
+	EvalOrder      *int                   `json:"eval_order,omitempty"`
+	Conditions     *ApprovalRuleCondition `json:"conditions,omitempty"`
+	Requirements   []ApprovalRequirement  `json:"requirements,omitempty"`
+	AutoApprove    *bool                  `json:"auto_approve,omitempty"`
+	StopProcessing *bool                  `json:"stop_processing,omitempty"`
+	IsActive       *bool                  `json:"is_active,omitempty"`
+}
+
+func containsRiskLevel(list []RiskLevel, v RiskLevel) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPriority(list []TicketPriority, v TicketPriority) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
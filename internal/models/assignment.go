@@ -0,0 +1,185 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditActionAutoAssign is logged when the queue bot assigns a ticket
+const AuditActionAutoAssign = "auto_assign"
+
+// AssignmentStrategy picks an assignee among a rule's candidates
+type AssignmentStrategy string
+
+const (
+	// AssignmentStrategyRoundRobin rotates through the group's members
+	AssignmentStrategyRoundRobin AssignmentStrategy = "round_robin"
+	// AssignmentStrategySystemOwner picks among the inventory owners of the
+	// ticket's affected hosts, the least loaded first
+	AssignmentStrategySystemOwner AssignmentStrategy = "system_owner"
+	// AssignmentStrategyLeastLoaded picks the group member with the fewest
+	// open assigned tickets
+	AssignmentStrategyLeastLoaded AssignmentStrategy = "least_loaded"
+)
+
+// Valid reports whether the strategy is known
+func (s AssignmentStrategy) Valid() bool {
+	switch s {
+	case AssignmentStrategyRoundRobin, AssignmentStrategySystemOwner, AssignmentStrategyLeastLoaded:
+		return true
+	}
+	return false
+}
+
+// AssignmentTrigger records what ran the queue bot
+type AssignmentTrigger string
+
+const (
+	AssignmentTriggerSubmit   AssignmentTrigger = "submit"
+	AssignmentTriggerSchedule AssignmentTrigger = "schedule"
+	AssignmentTriggerManual   AssignmentTrigger = "manual"
+)
+
+// AssignmentRule is an org-configured rule the queue bot uses to assign
+// unassigned tickets. The first active rule, in evaluation order, whose
+// conditions match and that finds a candidate assigns the ticket.
+type AssignmentRule struct {
+	ID             uuid.UUID             `db:"id" json:"id"`
+	OrganizationID uuid.UUID             `db:"organization_id" json:"organization_id"`
+	Name           string                `db:"name" json:"name"`
+	Description    *string               `db:"description" json:"description,omitempty"`
+	EvalOrder      int                   `db:"eval_order" json:"eval_order"` // Lower values are evaluated first
+	Conditions     ApprovalRuleCondition `db:"conditions" json:"conditions"`
+	Strategy       AssignmentStrategy    `db:"strategy" json:"strategy"`
+	GroupID        *uuid.UUID            `db:"group_id" json:"group_id,omitempty"` // Candidate pool; nil uses the ticket's owning group
+	LastAssignedTo *uuid.UUID            `db:"last_assigned_to" json:"last_assigned_to,omitempty"`
+	IsActive       bool                  `db:"is_active" json:"is_active"`
+	CreatedBy      *uuid.UUID            `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+// PoolGroup returns the group whose members are candidates for a ticket,
+// or nil if neither the rule nor the ticket names one
+func (r *AssignmentRule) PoolGroup(t *Ticket) *uuid.UUID {
+	if r.GroupID != nil {
+		return r.GroupID
+	}
+	return t.OwningGroupID
+}
+
+// AssignmentResult reports what the queue bot did with one ticket
+type AssignmentResult struct {
+	TicketID     uuid.UUID          `json:"ticket_id"`
+	TicketNumber string             `json:"ticket_number"`
+	AssignedTo   *uuid.UUID         `json:"assigned_to,omitempty"`
+	RuleID       *uuid.UUID         `json:"rule_id,omitempty"`
+	RuleName     string             `json:"rule_name,omitempty"`
+	Strategy     AssignmentStrategy `json:"strategy,omitempty"`
+	Reason       string             `json:"reason,omitempty"` // Why the ticket was left unassigned
+}
+
+// AssignmentQueueStatuses are the statuses in which an unassigned ticket
+// waits in the queue for an assignee
+var AssignmentQueueStatuses = []TicketStatus{
+	TicketStatusSubmitted,
+	TicketStatusInReview,
+	TicketStatusUpdateRequested,
+}
+
+// AwaitingAssignment reports whether the ticket is in the assignment queue
+func (t *Ticket) AwaitingAssignment() bool {
+	if t.AssignedTo != nil {
+		return false
+	}
+	for _, st := range AssignmentQueueStatuses {
+		if t.Status == st {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRoundRobin returns the candidate after last, wrapping around.
+// Candidates are taken in ID order so the rotation is stable as members
+// join and leave.
+func NextRoundRobin(candidates []uuid.UUID, last *uuid.UUID) uuid.UUID {
+	sorted := append([]uuid.UUID(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+	if last != nil {
+		for _, c := range sorted {
+			if c.String() > last.String() {
+				return c
+			}
+		}
+	}
+	return sorted[0]
+}
+
+// LeastLoaded returns the candidate with the fewest open tickets, breaking
+// ties by ID
+func LeastLoaded(candidates []uuid.UUID, load map[uuid.UUID]int) uuid.UUID {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if load[c] < load[best] || (load[c] == load[best] && c.String() < best.String()) {
+			best = c
+		}
+	}
+	return best
+}
+
+// CreateAssignmentRuleInput represents input for creating an assignment rule
+type CreateAssignmentRuleInput struct {
+	Name        string                `json:"name" validate:"required,min=2,max=255"`
+	Description *string               `json:"description,omitempty"`
+	EvalOrder   int                   `json:"eval_order"`
+	Conditions  ApprovalRuleCondition `json:"conditions"`
+	Strategy    AssignmentStrategy    `json:"strategy" validate:"required"`
+	GroupID     *uuid.UUID            `json:"group_id,omitempty"`
+}
+
+// Validate checks the assignment rule input
+func (i *CreateAssignmentRuleInput) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	if len(i.Name) < 2 || len(i.Name) > 255 {
+		return &ValidationError{Field: "name", Message: "name must be between 2 and 255 characters"}
+	}
+	if !i.Strategy.Valid() {
+		return &ValidationError{Field: "strategy", Message: "strategy must be one of: round_robin, system_owner, least_loaded"}
+	}
+	return nil
+}
+
+// UpdateAssignmentRuleInput represents input for updating an assignment
+// rule. Setting clear_group makes the rule use each ticket's owning group.
+type UpdateAssignmentRuleInput struct {
+	Name        *string                `json:"name,omitempty" validate:"omitempty,min=2,max=255"`
+	Description *string                `json:"description,omitempty"`
+	EvalOrder   *int                   `json:"eval_order,omitempty"`
+	Conditions  *ApprovalRuleCondition `json:"conditions,omitempty"`
+	Strategy    *AssignmentStrategy    `json:"strategy,omitempty"`
+	GroupID     *uuid.UUID             `json:"group_id,omitempty"`
+	ClearGroup  bool                   `json:"clear_group,omitempty"`
+	IsActive    *bool                  `json:"is_active,omitempty"`
+}
+
+// Validate checks the assignment rule update input
+func (i *UpdateAssignmentRuleInput) Validate() error {
+	if i.Name != nil {
+		name := strings.TrimSpace(*i.Name)
+		if len(name) < 2 || len(name) > 255 {
+			return &ValidationError{Field: "name", Message: "name must be between 2 and 255 characters"}
+		}
+		i.Name = &name
+	}
+	if i.Strategy != nil && !i.Strategy.Valid() {
+		return &ValidationError{Field: "strategy", Message: "strategy must be one of: round_robin, system_owner, least_loaded"}
+	}
+	if i.ClearGroup && i.GroupID != nil {
+		return &ValidationError{Field: "group_id", Message: "group_id cannot be set together with clear_group"}
+	}
+	return nil
+}
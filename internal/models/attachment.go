@@ -0,0 +1,61 @@
+package models
+
+import (
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Attachment limits. Downloads are signed for a short time because the links
+// carry their own authorization.
+const (
+	DefaultMaxAttachmentBytes = 25 << 20
+	MaxAttachmentLinkTTL      = 7 * 24 * time.Hour
+	maxAttachmentNameLength   = 255
+)
+
+// Attachment is a file uploaded to a ticket and kept in object storage
+type Attachment struct {
+	ID                uuid.UUID  `db:"id" json:"id"`
+	OrganizationID    uuid.UUID  `db:"organization_id" json:"organization_id"`
+	TicketID          uuid.UUID  `db:"ticket_id" json:"ticket_id"`
+	Filename          string     `db:"filename" json:"filename"`
+	ContentType       string     `db:"content_type" json:"content_type"`
+	SizeBytes         int64      `db:"size_bytes" json:"size_bytes"`
+	SHA256            string     `db:"sha256" json:"sha256"`
+	ObjectKey         string     `db:"object_key" json:"-"`
+	UploadedBy        *uuid.UUID `db:"uploaded_by" json:"uploaded_by,omitempty"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	DownloadURL       string     `db:"-" json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `db:"-" json:"download_expires_at,omitempty"`
+
+	// Relationships
+	Uploader *UserSummary `db:"-" json:"uploader,omitempty"`
+}
+
+// CleanAttachmentName reduces an uploaded file's name to a base name that is
+// safe to store and to offer as a download name
+func CleanAttachmentName(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "", &ValidationError{Field: "file", Message: "the file needs a name"}
+	}
+	if len(name) > maxAttachmentNameLength {
+		ext := path.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:maxAttachmentNameLength-len(ext)], "") + ext
+	}
+	return name, nil
+}
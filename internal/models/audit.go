@@ -51,6 +51,17 @@ const (
 	AuditActionMFADisable      = "mfa_disable"
 	AuditActionExport          = "export"
 	AuditActionDownload        = "download"
+	AuditActionAnonymize       = "anonymize"
+	AuditActionACLGrant        = "acl_grant"
+	AuditActionACLRevoke       = "acl_revoke"
+	AuditActionRepositoryLink  = "repository_link"
+	AuditActionRepositoryUnlink = "repository_unlink"
+	AuditActionImport          = "import"
+	AuditActionBlackoutStart   = "blackout_start"
+	AuditActionBlackoutEnd     = "blackout_end"
+	AuditActionBlackoutExtend  = "blackout_extend"
+	AuditActionRestore         = "restore"
+	AuditActionPurge           = "purge"
 )
 
 // AuditResourceType constants
@@ -62,6 +73,10 @@ const (
 	AuditResourceOrganization = "organization"
 	AuditResourceSession      = "session"
 	AuditResourceReport       = "report"
+	AuditResourceHost         = "host"
+	AuditResourceCalendarFeed = "calendar_feed"
+	AuditResourceRepository   = "repository"
+	AuditResourceWebhook      = "webhook"
 )
 
 // AuditChanges represents before/after changes
@@ -106,6 +121,7 @@ type NotificationQueue struct {
 	FailedAt         *time.Time `db:"failed_at" json:"failed_at,omitempty"`
 	ErrorMessage     *string    `db:"error_message" json:"error_message,omitempty"`
 	SESMessageID     *string    `db:"ses_message_id" json:"ses_message_id,omitempty"`
+	Priority         int        `db:"priority" json:"priority"` // Higher is sent first
 	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
 	ScheduledFor     time.Time  `db:"scheduled_for" json:"scheduled_for"`
 }
@@ -127,4 +143,109 @@ const (
 	NotificationTypeTicketUpdated    = "ticket_updated"
 	NotificationTypeCommentAdded     = "comment_added"
 	NotificationTypeMention          = "mention"
+
+	NotificationTypeEmergencyApproval   = "emergency_approval"
+	NotificationTypeEmergencyEscalation = "emergency_escalation"
+	NotificationTypeEmailVerification   = "email_verification"
+	NotificationTypeDigest              = "digest"
+	NotificationTypeReportReady         = "report_ready"
+	NotificationTypeReportFailed        = "report_failed"
+)
+
+// BatchedNotificationTypes are collapsed into one email when several are
+// queued for the same recipient within the batch window
+var BatchedNotificationTypes = []string{
+	NotificationTypeCommentAdded,
+	NotificationTypeMention,
+}
+
+// Notification priority constants
+const (
+	NotificationPriorityNormal    = 0
+	NotificationPriorityEmergency = 100
+)
+
+// Notification delivery retry bounds
+const (
+	NotificationRetryBaseDelay = 1 * time.Minute
+	NotificationRetryMaxDelay  = 1 * time.Hour
+)
+
+// NotificationRetryDelay returns how long to wait before retrying a
+// notification after its nth failed attempt, doubling from one minute up
+// to an hour
+func NotificationRetryDelay(attempt int) time.Duration {
+	delay := NotificationRetryBaseDelay
+	for i := 1; i < attempt && delay < NotificationRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > NotificationRetryMaxDelay {
+		delay = NotificationRetryMaxDelay
+	}
+	return delay
+}
+
+// NotificationMessage is the rendered content of a notification before it is
+// fanned out to recipients
+type NotificationMessage struct {
+	NotificationType string
+	Subject          string
+	BodyHTML         string
+	BodyText         string
+	Priority         int
+}
+
+// NotificationRecipient is who a notification is queued for and what it
+// is about
+type NotificationRecipient struct {
+	OrganizationID uuid.UUID
+	UserID         *uuid.UUID
+	Email          string
+	TicketID       *uuid.UUID
+	ApprovalID     *uuid.UUID
+	ScheduledFor   *time.Time // Defaults to now
+}
+
+// QueuedNotification is one notification for NotificationStore.EnqueueMany
+// to queue
+type QueuedNotification struct {
+	Recipient NotificationRecipient
+	Message   NotificationMessage
+}
+
+// RequeueNotificationsInput selects failed and bounced notifications to
+// send again. Without IDs or an email, every failed notification since
+// Since is selected.
+type RequeueNotificationsInput struct {
+	IDs   []uuid.UUID `json:"ids,omitempty"`
+	Email string      `json:"email,omitempty"`
+	Since *time.Time  `json:"since,omitempty"`
+}
+
+// EmailSuppressionReason constants
+const (
+	EmailSuppressionBounce    = "bounce"
+	EmailSuppressionComplaint = "complaint"
 )
+
+// EmailSuppression is an address notifications are no longer sent to after
+// SES reported a hard bounce or a complaint for it
+type EmailSuppression struct {
+	Email        string    `db:"email" json:"email"`
+	Reason       string    `db:"reason" json:"reason"`
+	Detail       *string   `db:"detail" json:"detail,omitempty"`
+	SESMessageID *string   `db:"ses_message_id" json:"ses_message_id,omitempty"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// EmailFlaggedUser is a user whose address bounced or complained, awaiting
+// an admin's review
+type EmailFlaggedUser struct {
+	UserID      uuid.UUID         `json:"user_id"`
+	Email       string            `json:"email"`
+	FullName    string            `json:"full_name"`
+	FlaggedAt   time.Time         `json:"flagged_at"`
+	Reason      string            `json:"reason"`
+	Suppression *EmailSuppression `json:"suppression,omitempty"` // Nil once lifted
+}
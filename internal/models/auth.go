@@ -0,0 +1,212 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions for passkey management
+const (
+	AuditActionPasskeyRegister = "passkey_register"
+	AuditActionPasskeyRevoke   = "passkey_revoke"
+)
+
+// Authentication methods recorded on sessions and access tokens
+const (
+	AuthMethodPassword = "password"
+	AuthMethodMFA      = "password+mfa"
+	AuthMethodPasskey  = "passkey"
+)
+
+// WithMFA returns the method recorded when a first factor is followed by
+// an MFA code, e.g. password+mfa
+func WithMFA(firstFactor string) string {
+	return firstFactor + "+mfa"
+}
+
+// StrongAuthMethod returns true for methods that count as an MFA
+// verification: passkeys and anything completed with an MFA code
+func StrongAuthMethod(method string) bool {
+	return method == AuthMethodPasskey || strings.HasSuffix(method, "+mfa")
+}
+
+// Lifetimes of pending authentication steps
+const (
+	PasskeyChallengeTTL = 5 * time.Minute
+	MFAChallengeTTL     = 5 * time.Minute
+)
+
+// MaxPasskeysPerUser bounds the passkeys stored on one account
+const MaxPasskeysPerUser = 20
+
+// AuthChallengePurpose is the step a stored challenge belongs to
+type AuthChallengePurpose string
+
+const (
+	AuthChallengePasskeyRegistration AuthChallengePurpose = "passkey_registration"
+	AuthChallengePasskeyLogin        AuthChallengePurpose = "passkey_login"
+	AuthChallengeMFA                 AuthChallengePurpose = "mfa"
+)
+
+// AuthChallenge is a single-use challenge for a passkey ceremony or a
+// pending MFA login
+type AuthChallenge struct {
+	ID        uuid.UUID            `db:"id" json:"id"`
+	Purpose   AuthChallengePurpose `db:"purpose" json:"purpose"`
+	UserID    *uuid.UUID           `db:"user_id" json:"user_id,omitempty"`
+	Challenge []byte               `db:"challenge" json:"-"`
+	Data      json.RawMessage      `db:"data" json:"-"` // State for the next step, e.g. a pending SSO identity
+	ExpiresAt time.Time            `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time            `db:"created_at" json:"created_at"`
+}
+
+// WebAuthnCredential is a passkey registered to a user, stored in the
+// user's webauthn_credentials column
+type WebAuthnCredential struct {
+	ID             string     `json:"id"` // base64url credential ID
+	Name           string     `json:"name"`
+	PublicKey      []byte     `json:"public_key"` // COSE_Key
+	Algorithm      int        `json:"algorithm"`
+	SignCount      uint32     `json:"sign_count"`
+	AAGUID         string     `json:"aaguid,omitempty"`
+	Transports     []string   `json:"transports,omitempty"`
+	BackupEligible bool       `json:"backup_eligible"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Passkey is the API view of a registered credential
+type Passkey struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	AAGUID         string     `json:"aaguid,omitempty"`
+	Transports     []string   `json:"transports,omitempty"`
+	BackupEligible bool       `json:"backup_eligible"` // Synced passkey that can move between devices
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// ToPasskey converts a stored credential to its API view
+func (c *WebAuthnCredential) ToPasskey() Passkey {
+	return Passkey{
+		ID:             c.ID,
+		Name:           c.Name,
+		AAGUID:         c.AAGUID,
+		Transports:     c.Transports,
+		BackupEligible: c.BackupEligible,
+		CreatedAt:      c.CreatedAt,
+		LastUsedAt:     c.LastUsedAt,
+	}
+}
+
+// LoginUser is the account data needed to authenticate a user
+type LoginUser struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Email          string
+	FullName       string
+	PasswordHash   *string
+	MFAEnabled     bool
+	MFASecret      *string // Set once enrollment starts
+	BackupCodes    int     // Unused backup codes
+	Roles          []string
+	Credentials    []WebAuthnCredential
+	OAuthProvider  *string // Linked SSO identity
+	OAuthSubject   *string
+	CustomerID     *uuid.UUID // Customer portal users only
+	MFALockedUntil *time.Time // Set after too many wrong codes; see MFALockout
+
+	// MFA policy
+	MFARequiredByAdmin bool
+	OrgRequireMFA      bool
+	OrgMFARoles        []UserRole
+}
+
+// MFARequired returns true if the user must use MFA, by their
+// organization's policy or because an admin required it
+func (u *LoginUser) MFARequired() bool {
+	return u.MFARequiredByAdmin || (u.OrgRequireMFA && MFARoleCovered(u.OrgMFARoles, u.Roles))
+}
+
+// MFAStatus returns the user's MFA enrollment
+func (u *LoginUser) MFAStatus() MFAStatus {
+	return MFAStatus{
+		Enabled:              u.MFAEnabled,
+		Required:             u.MFARequired(),
+		RequiredByAdmin:      u.MFARequiredByAdmin,
+		BackupCodesRemaining: u.BackupCodes,
+		Passkeys:             len(u.Credentials),
+	}
+}
+
+// Credential returns the user's passkey with the given ID
+func (u *LoginUser) Credential(id string) *WebAuthnCredential {
+	for i := range u.Credentials {
+		if u.Credentials[i].ID == id {
+			return &u.Credentials[i]
+		}
+	}
+	return nil
+}
+
+// LoginInput represents an email/password login. Organization is the org
+// slug, needed only when the email belongs to accounts in several orgs.
+type LoginInput struct {
+	Email        string `json:"email" validate:"required,email"`
+	Password     string `json:"password" validate:"required"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// Validate checks the login input
+func (i *LoginInput) Validate() error {
+	i.Email = strings.TrimSpace(i.Email)
+	if i.Email == "" {
+		return &ValidationError{Field: "email", Message: "email is required"}
+	}
+	if i.Password == "" {
+		return &ValidationError{Field: "password", Message: "password is required"}
+	}
+	return nil
+}
+
+// PasskeyLoginBeginInput starts a passkey login. Without an email the
+// browser offers any discoverable passkey for this site.
+type PasskeyLoginBeginInput struct {
+	Email        string `json:"email,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// PasskeyRegistrationInput names a passkey being registered
+type PasskeyRegistrationInput struct {
+	ChallengeID uuid.UUID `json:"challenge_id" validate:"required"`
+	Name        string    `json:"name,omitempty" validate:"omitempty,max=100"`
+}
+
+// Validate checks the registration input
+func (i *PasskeyRegistrationInput) Validate() error {
+	if i.ChallengeID == uuid.Nil {
+		return &ValidationError{Field: "challenge_id", Message: "challenge_id is required"}
+	}
+	i.Name = strings.TrimSpace(i.Name)
+	if i.Name == "" {
+		i.Name = "Passkey"
+	}
+	if len(i.Name) > 100 {
+		return &ValidationError{Field: "name", Message: "name must be at most 100 characters"}
+	}
+	return nil
+}
+
+// AuthTokens is returned on successful login
+type AuthTokens struct {
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	ExpiresIn   int         `json:"expires_in"` // seconds
+	ExpiresAt   time.Time   `json:"expires_at"`
+	AuthMethod  string      `json:"auth_method"`
+	Scope       string      `json:"scope,omitempty"` // mfa_enrollment until required MFA is set up, customer_portal for portal users
+	User        UserSummary `json:"user"`
+}
@@ -0,0 +1,302 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCABDecisions bounds the decisions recorded for one meeting
+const MaxCABDecisions = 200
+
+// CABAwaitingStatuses are the ticket statuses that can still await a
+// change management board decision
+var CABAwaitingStatuses = []TicketStatus{
+	TicketStatusSubmitted,
+	TicketStatusInReview,
+	TicketStatusPartiallyApproved,
+}
+
+// CABDecision is the board's decision on a ticket
+type CABDecision string
+
+const (
+	CABDecisionApprove       CABDecision = "approve"
+	CABDecisionDeny          CABDecision = "deny"
+	CABDecisionRequestUpdate CABDecision = "request_update"
+)
+
+// ApprovalStatus returns the approval status recorded for the decision
+func (d CABDecision) ApprovalStatus() ApprovalStatus {
+	switch d {
+	case CABDecisionApprove:
+		return ApprovalStatusApproved
+	case CABDecisionDeny:
+		return ApprovalStatusDenied
+	case CABDecisionRequestUpdate:
+		return ApprovalStatusUpdateRequested
+	}
+	return ""
+}
+
+// Valid reports whether the decision is known
+func (d CABDecision) Valid() bool {
+	return d.ApprovalStatus() != ""
+}
+
+// CABAgendaItem is a ticket awaiting the board, with its progress toward
+// the board's quorum
+type CABAgendaItem struct {
+	TicketID        uuid.UUID      `json:"ticket_id"`
+	TicketNumber    string         `json:"ticket_number"`
+	Title           string         `json:"title"`
+	Status          TicketStatus   `json:"status"`
+	Priority        TicketPriority `json:"priority"`
+	RiskLevel       RiskLevel      `json:"risk_level"`
+	ChangeType      *string        `json:"change_type,omitempty"`
+	AffectedSystems []string       `json:"affected_systems"`
+	IsEmergency     bool           `json:"is_emergency"`
+	ScheduledStart  *time.Time     `json:"scheduled_start,omitempty"`
+	ScheduledEnd    *time.Time     `json:"scheduled_end,omitempty"`
+	SubmittedAt     *time.Time     `json:"submitted_at,omitempty"`
+	Approvals       int            `json:"approvals"`
+	Quorum          int            `json:"quorum"`
+}
+
+// CABRiskGroup lists the agenda items at one risk level
+type CABRiskGroup struct {
+	RiskLevel RiskLevel       `json:"risk_level"`
+	Items     []CABAgendaItem `json:"items"`
+}
+
+// CABSystemGroup lists the tickets affecting one system. A ticket listing
+// several systems appears in each of their groups.
+type CABSystemGroup struct {
+	System        string   `json:"system"`
+	TicketNumbers []string `json:"ticket_numbers"`
+}
+
+// CABAgenda is the agenda for a change management board meeting: tickets
+// awaiting the board, highest risk first
+type CABAgenda struct {
+	Date     string           `json:"date"`
+	Total    int              `json:"total"`
+	ByRisk   []CABRiskGroup   `json:"by_risk"`
+	BySystem []CABSystemGroup `json:"by_system"`
+}
+
+// CABTicketDecision is the board's decision on one ticket
+type CABTicketDecision struct {
+	TicketID   uuid.UUID   `json:"ticket_id"`
+	Decision   CABDecision `json:"decision"`
+	Comment    string      `json:"comment,omitempty"`
+	Conditions string      `json:"conditions,omitempty"`
+}
+
+// CABDecisionResult is the outcome of recording one decision
+type CABDecisionResult struct {
+	CABTicketDecision
+	TicketNumber string       `json:"ticket_number,omitempty"`
+	Title        string       `json:"title,omitempty"`
+	TicketStatus TicketStatus `json:"ticket_status,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// CABMeeting is a recorded change management board meeting, from which
+// minutes are produced
+type CABMeeting struct {
+	ID             uuid.UUID           `db:"id" json:"id"`
+	OrganizationID uuid.UUID           `db:"organization_id" json:"organization_id"`
+	MeetingDate    time.Time           `db:"meeting_date" json:"meeting_date"`
+	RecordedBy     uuid.UUID           `db:"recorded_by" json:"recorded_by"`
+	Attendees      []string            `db:"attendees" json:"attendees"`
+	Notes          *string             `db:"notes" json:"notes,omitempty"`
+	Decisions      []CABDecisionResult `db:"decisions" json:"decisions"`
+	CreatedAt      time.Time           `db:"created_at" json:"created_at"`
+}
+
+// DecisionsJSON returns the decisions for storage
+func (m *CABMeeting) DecisionsJSON() []byte {
+	data, _ := json.Marshal(m.Decisions)
+	return data
+}
+
+// RecordCABMeetingInput represents the outcome of a board meeting: one
+// decision per ticket discussed
+type RecordCABMeetingInput struct {
+	MeetingDate string              `json:"meeting_date,omitempty"` // YYYY-MM-DD, defaults to today
+	Attendees   []string            `json:"attendees,omitempty"`
+	Notes       *string             `json:"notes,omitempty"`
+	Decisions   []CABTicketDecision `json:"decisions" validate:"required,min=1"`
+
+	Date time.Time `json:"-"`
+}
+
+// Validate checks the meeting input and parses the meeting date
+func (i *RecordCABMeetingInput) Validate() error {
+	if i.MeetingDate == "" {
+		i.Date = time.Now().UTC().Truncate(24 * time.Hour)
+	} else {
+		d, err := time.Parse("2006-01-02", i.MeetingDate)
+		if err != nil {
+			return &ValidationError{Field: "meeting_date", Message: "meeting_date must be YYYY-MM-DD"}
+		}
+		i.Date = d
+	}
+
+	if len(i.Decisions) == 0 {
+		return &ValidationError{Field: "decisions", Message: "decisions is required"}
+	}
+	if len(i.Decisions) > MaxCABDecisions {
+		return &ValidationError{Field: "decisions", Message: "decisions must list at most 200 tickets"}
+	}
+	seen := make(map[uuid.UUID]bool, len(i.Decisions))
+	for n := range i.Decisions {
+		d := &i.Decisions[n]
+		if d.TicketID == uuid.Nil {
+			return &ValidationError{Field: "decisions", Message: "decisions must each have a ticket_id"}
+		}
+		if seen[d.TicketID] {
+			return &ValidationError{Field: "decisions", Message: "decisions must list each ticket once"}
+		}
+		seen[d.TicketID] = true
+		if !d.Decision.Valid() {
+			return &ValidationError{Field: "decisions", Message: "decision must be one of: approve, deny, request_update"}
+		}
+		d.Comment = strings.TrimSpace(d.Comment)
+		d.Conditions = strings.TrimSpace(d.Conditions)
+		if d.Decision != CABDecisionApprove && d.Comment == "" {
+			return &ValidationError{Field: "decisions", Message: "a comment is required when denying or requesting an update"}
+		}
+	}
+
+	var attendees []string
+	for _, a := range i.Attendees {
+		if a = strings.TrimSpace(a); a != "" {
+			attendees = append(attendees, a)
+		}
+	}
+	i.Attendees = attendees
+	return nil
+}
+
+// CABQuorum returns the number of board approvals a ticket needs: the
+// quorum in its frozen approval plan, or one if it only lists the board
+// among its required approval types. Zero means the board isn't required.
+func (t *Ticket) CABQuorum() int {
+	if len(t.ApprovalPlan) > 0 {
+		var plan ApprovalPlan
+		if err := json.Unmarshal(t.ApprovalPlan, &plan); err == nil && len(plan.Requirements) > 0 {
+			for _, req := range plan.Requirements {
+				if req.ApprovalType == ApprovalTypeChangeManagementBoard {
+					return req.MinApprovals
+				}
+			}
+			return 0
+		}
+	}
+	for _, at := range t.RequiresApprovalTypes {
+		if at == ApprovalTypeChangeManagementBoard {
+			return 1
+		}
+	}
+	return 0
+}
+
+// EffectiveApprovalPlan returns the plan frozen at submit, or one approval
+// per required approval type for tickets submitted without one
+func (t *Ticket) EffectiveApprovalPlan() ApprovalPlan {
+	if len(t.ApprovalPlan) > 0 {
+		var plan ApprovalPlan
+		if err := json.Unmarshal(t.ApprovalPlan, &plan); err == nil {
+			return plan
+		}
+	}
+	return EvaluateApprovalRules(t, nil)
+}
+
+// BuildCABAgenda assembles the agenda from the tickets awaiting the board
+// and their approvals. Tickets that have already reached the board's quorum
+// are left out. Within each risk level the oldest submission comes first.
+func BuildCABAgenda(date time.Time, tickets []Ticket, approvals []Approval) *CABAgenda {
+	approved := make(map[uuid.UUID]int)
+	for _, a := range approvals {
+		if a.ApprovalType == ApprovalTypeChangeManagementBoard && a.Status == ApprovalStatusApproved {
+			approved[a.TicketID]++
+		}
+	}
+
+	agenda := &CABAgenda{
+		Date:     date.Format("2006-01-02"),
+		ByRisk:   []CABRiskGroup{},
+		BySystem: []CABSystemGroup{},
+	}
+	byRisk := make(map[RiskLevel][]CABAgendaItem)
+	bySystem := make(map[string][]string)
+	var systems []string
+
+	for i := range tickets {
+		t := &tickets[i]
+		quorum := t.CABQuorum()
+		if quorum == 0 || approved[t.ID] >= quorum {
+			continue
+		}
+		item := CABAgendaItem{
+			TicketID:        t.ID,
+			TicketNumber:    t.TicketNumber,
+			Title:           t.Title,
+			Status:          t.Status,
+			Priority:        t.Priority,
+			RiskLevel:       t.RiskLevel,
+			ChangeType:      t.ChangeType,
+			AffectedSystems: t.AffectedSystems,
+			IsEmergency:     t.IsEmergencyChange(),
+			ScheduledStart:  t.ScheduledStart,
+			ScheduledEnd:    t.ScheduledEnd,
+			SubmittedAt:     t.SubmittedAt,
+			Approvals:       approved[t.ID],
+			Quorum:          quorum,
+		}
+		if item.AffectedSystems == nil {
+			item.AffectedSystems = []string{}
+		}
+		byRisk[t.RiskLevel] = append(byRisk[t.RiskLevel], item)
+		agenda.Total++
+
+		listed := t.AffectedSystems
+		if len(listed) == 0 {
+			listed = []string{CABUnspecifiedSystem}
+		}
+		for _, system := range listed {
+			if _, ok := bySystem[system]; !ok {
+				systems = append(systems, system)
+			}
+			bySystem[system] = append(bySystem[system], t.TicketNumber)
+		}
+	}
+
+	for _, risk := range cabRiskOrder {
+		if items := byRisk[risk]; len(items) > 0 {
+			agenda.ByRisk = append(agenda.ByRisk, CABRiskGroup{RiskLevel: risk, Items: items})
+			delete(byRisk, risk)
+		}
+	}
+	for risk, items := range byRisk {
+		agenda.ByRisk = append(agenda.ByRisk, CABRiskGroup{RiskLevel: risk, Items: items})
+	}
+
+	sort.Strings(systems)
+	for _, system := range systems {
+		agenda.BySystem = append(agenda.BySystem, CABSystemGroup{System: system, TicketNumbers: bySystem[system]})
+	}
+
+	return agenda
+}
+
+// CABUnspecifiedSystem groups agenda tickets that list no affected systems
+const CABUnspecifiedSystem = "(unspecified)"
+
+var cabRiskOrder = []RiskLevel{RiskLevelCritical, RiskLevelHigh, RiskLevelMedium, RiskLevelLow}
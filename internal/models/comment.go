@@ -12,6 +12,7 @@ type Comment struct {
 	ID             uuid.UUID       `db:"id" json:"id"`
 	TicketID       uuid.UUID       `db:"ticket_id" json:"ticket_id"`
 	OrganizationID uuid.UUID       `db:"organization_id" json:"organization_id"`
+	ParentID       *uuid.UUID      `db:"parent_comment_id" json:"parent_comment_id,omitempty"` // The comment this replies to
 	AuthorID       uuid.UUID       `db:"author_id" json:"author_id"`
 	Comment        string          `db:"comment" json:"comment"`
 	IsInternal     bool            `db:"is_internal" json:"is_internal"`
@@ -22,9 +23,56 @@ type Comment struct {
 	DeletedAt      *time.Time      `db:"deleted_at" json:"deleted_at,omitempty"`
 	Edited         bool            `db:"edited" json:"edited"`
 	EditHistory    json.RawMessage `db:"edit_history" json:"edit_history,omitempty"`
+	Anonymized     bool            `db:"anonymized" json:"anonymized"`
+	AnonymizedAt   *time.Time      `db:"anonymized_at" json:"anonymized_at,omitempty"`
 
 	// Relationships
-	Author *UserSummary `db:"-" json:"author,omitempty"`
+	Author  *UserSummary `db:"-" json:"author,omitempty"`
+	Replies []Comment    `db:"-" json:"replies,omitempty"` // Set by ThreadComments
+}
+
+// MaxListComments is the most comments per ticket a ticket list can include
+const MaxListComments = 20
+
+// DeletedCommentText stands in for the text of a deleted comment that is
+// kept in a thread for its replies
+const DeletedCommentText = "[deleted]"
+
+// ThreadComments nests comments under the comments they reply to, keeping
+// their order within each level. Replies whose parent isn't in the list
+// are kept at the top level.
+func ThreadComments(comments []Comment) []Comment {
+	children := make(map[uuid.UUID][]int)
+	present := make(map[uuid.UUID]bool, len(comments))
+	for _, c := range comments {
+		present[c.ID] = true
+	}
+	var roots []int
+	for i, c := range comments {
+		if c.ParentID != nil && present[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	// Parents are set when a reply is created and never change, so there
+	// are no cycles to guard against
+	var build func(i int) Comment
+	build = func(i int) Comment {
+		c := comments[i]
+		c.Replies = nil
+		for _, j := range children[c.ID] {
+			c.Replies = append(c.Replies, build(j))
+		}
+		return c
+	}
+
+	threads := make([]Comment, 0, len(roots))
+	for _, i := range roots {
+		threads = append(threads, build(i))
+	}
+	return threads
 }
 
 // CanEdit checks if a user can edit this comment
@@ -45,6 +93,7 @@ func (c *Comment) CanDelete(userID uuid.UUID, isAdmin bool) bool {
 // CreateCommentInput represents input for creating a comment
 type CreateCommentInput struct {
 	Comment        string      `json:"comment" validate:"required,min=1"`
+	ParentID       *uuid.UUID  `json:"parent_comment_id,omitempty"` // Reply to this comment on the same ticket
 	IsInternal     bool        `json:"is_internal"`
 	MentionedUsers []uuid.UUID `json:"mentioned_users,omitempty"`
 	AttachmentURLs []string    `json:"attachment_urls,omitempty"`
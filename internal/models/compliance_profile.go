@@ -0,0 +1,179 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ComplianceProfile is what a compliance framework demands of the changes
+// it covers before they can be submitted
+type ComplianceProfile struct {
+	Framework      ComplianceFramework `json:"framework"`
+	RequiredFields []string            `json:"required_fields"`         // Ticket fields, by JSON name, that must be filled in
+	MinApprovals   int                 `json:"min_approvals,omitempty"` // Different people who must approve, e.g. two for SOX's dual approval
+}
+
+// ComplianceProfiles are the requirements of each framework. Frameworks
+// left out, such as custom, demand nothing beyond the usual fields.
+var ComplianceProfiles = map[ComplianceFramework]ComplianceProfile{
+	ComplianceHIPAA: {
+		Framework:      ComplianceHIPAA,
+		RequiredFields: []string{"affected_data_types", "rollback_plan"},
+	},
+	ComplianceSOX: {
+		Framework:      ComplianceSOX,
+		RequiredFields: []string{"testing_plan", "rollback_plan"},
+		MinApprovals:   2,
+	},
+	ComplianceGLBA: {
+		Framework:      ComplianceGLBA,
+		RequiredFields: []string{"affected_data_types"},
+	},
+	ComplianceBankingSecrecyAct: {
+		Framework:      ComplianceBankingSecrecyAct,
+		RequiredFields: []string{"affected_systems", "rollback_plan"},
+	},
+	ComplianceGDPR: {
+		Framework:      ComplianceGDPR,
+		RequiredFields: []string{"affected_data_types", "impact_description"},
+	},
+}
+
+// ComplianceIssue is one requirement a ticket doesn't meet
+type ComplianceIssue struct {
+	Framework ComplianceFramework `json:"framework"`
+	Field     string              `json:"field"`
+	Message   string              `json:"message"`
+}
+
+func (i ComplianceIssue) String() string {
+	return fmt.Sprintf("%s: %s", strings.ToUpper(string(i.Framework)), i.Message)
+}
+
+// ComplianceError is returned when a ticket doesn't meet the requirements
+// of its compliance frameworks
+type ComplianceError struct {
+	Issues []ComplianceIssue
+}
+
+func (e *ComplianceError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.String()
+	}
+	return "ticket does not meet its compliance requirements: " + strings.Join(msgs, "; ")
+}
+
+// Validate returns the profile's requirements the ticket doesn't meet when
+// submitted with plan. A nil plan means the ticket's own.
+func (p *ComplianceProfile) Validate(t *Ticket, plan *ApprovalPlan) []ComplianceIssue {
+	var issues []ComplianceIssue
+	for _, field := range p.RequiredFields {
+		if !t.hasField(field) {
+			issues = append(issues, ComplianceIssue{
+				Framework: p.Framework,
+				Field:     field,
+				Message:   strings.ReplaceAll(field, "_", " ") + " is required",
+			})
+		}
+	}
+	if p.MinApprovals > 0 {
+		if plan == nil {
+			plan = t.compliancePlan()
+		}
+		// An approval rule can't waive the framework's approvals, so
+		// auto-approved changes don't meet it
+		switch n := plan.RequiredApprovers(); {
+		case plan.AutoApprove:
+			issues = append(issues, ComplianceIssue{
+				Framework: p.Framework,
+				Field:     "requires_approval_types",
+				Message:   fmt.Sprintf("at least %d approvers are required, but an approval rule approves the change automatically", p.MinApprovals),
+			})
+		case n < p.MinApprovals:
+			issues = append(issues, ComplianceIssue{
+				Framework: p.Framework,
+				Field:     "requires_approval_types",
+				Message:   fmt.Sprintf("at least %d approvers are required, the approval plan needs %d", p.MinApprovals, n),
+			})
+		}
+	}
+	return issues
+}
+
+// ValidateCompliance checks the ticket, submitted with plan, against the
+// profile of each of its compliance frameworks, returning a
+// *ComplianceError listing what's missing. A nil plan means the ticket's
+// own: the one it was submitted with, or for drafts the one it gets
+// without approval rules.
+func ValidateCompliance(t *Ticket, plan *ApprovalPlan) error {
+	var issues []ComplianceIssue
+	for _, f := range t.ComplianceFrameworks {
+		if profile, ok := ComplianceProfiles[f]; ok {
+			issues = append(issues, profile.Validate(t, plan)...)
+		}
+	}
+	if len(issues) > 0 {
+		return &ComplianceError{Issues: issues}
+	}
+	return nil
+}
+
+// hasField returns true if the ticket field, by JSON name, is filled in
+func (t *Ticket) hasField(field string) bool {
+	switch field {
+	case "affected_data_types":
+		return len(t.AffectedDataTypes) > 0
+	case "affected_systems":
+		return len(t.AffectedSystems) > 0
+	case "rollback_plan":
+		return t.RollbackPlan != nil && strings.TrimSpace(*t.RollbackPlan) != ""
+	case "testing_plan":
+		return t.TestingPlan != nil && strings.TrimSpace(*t.TestingPlan) != ""
+	case "impact_description":
+		return t.ImpactDescription != nil && strings.TrimSpace(*t.ImpactDescription) != ""
+	case "compliance_notes":
+		return t.ComplianceNotes != nil && strings.TrimSpace(*t.ComplianceNotes) != ""
+	}
+	return false
+}
+
+// compliancePlan is the ticket's approval plan, or the one it would get
+// without approval rules
+func (t *Ticket) compliancePlan() *ApprovalPlan {
+	if len(t.ApprovalPlan) > 0 && string(t.ApprovalPlan) != "null" {
+		var plan ApprovalPlan
+		if err := json.Unmarshal(t.ApprovalPlan, &plan); err == nil {
+			return &plan
+		}
+	}
+	plan := EvaluateApprovalRules(t, nil)
+	return &plan
+}
+
+// complianceMinApprovals is the most different approvers any of the
+// ticket's compliance frameworks demands, or 0 if none does
+func (t *Ticket) complianceMinApprovals() int {
+	n := 0
+	for _, f := range t.ComplianceFrameworks {
+		if profile, ok := ComplianceProfiles[f]; ok {
+			n = max(n, profile.MinApprovals)
+		}
+	}
+	return n
+}
+
+// RequiredApprovers is how many different people must approve under the
+// plan: its largest quorum, since one person may approve for several
+// approval types but only once for each. Auto-approved plans need none.
+func (p *ApprovalPlan) RequiredApprovers() int {
+	if p.AutoApprove {
+		return 0
+	}
+	n := 0
+	for _, req := range p.Requirements {
+		n = max(n, req.MinApprovals)
+	}
+	return n
+}
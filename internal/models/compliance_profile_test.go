@@ -0,0 +1,45 @@
+package models
+
+import "testing"
+
+func TestDefaultPlanMeetsSOXQuorum(t *testing.T) {
+	plan := "documented"
+	ticket := &Ticket{
+		ComplianceFrameworks:  []ComplianceFramework{ComplianceSOX},
+		TestingPlan:           &plan,
+		RollbackPlan:          &plan,
+		RequiresApprovalTypes: []ApprovalType{ApprovalTypeOperations, ApprovalTypeIT},
+	}
+
+	got := EvaluateApprovalRules(ticket, nil)
+	if n := got.RequiredApprovers(); n != 2 {
+		t.Errorf("SOX default plan needs %d approvers, want 2", n)
+	}
+	if err := ValidateCompliance(ticket, nil); err != nil {
+		t.Errorf("ValidateCompliance() = %v, want nil", err)
+	}
+}
+
+func TestDefaultPlanQuorumWithoutCompliance(t *testing.T) {
+	ticket := &Ticket{RequiresApprovalTypes: []ApprovalType{ApprovalTypeOperations}}
+
+	got := EvaluateApprovalRules(ticket, nil)
+	if n := got.RequiredApprovers(); n != 1 {
+		t.Errorf("default plan needs %d approvers, want 1", n)
+	}
+}
+
+func TestApplyDefaultsKeepsHigherQuorum(t *testing.T) {
+	plan := ApprovalPlan{Requirements: []ApprovalRequirement{
+		{ApprovalType: ApprovalTypeOperations, MinApprovals: 3},
+		{ApprovalType: ApprovalTypeIT, MinApprovals: 1},
+	}}
+
+	(&ApprovalPolicy{DefaultMinApprovals: 2}).ApplyDefaults(&plan)
+	if n := plan.Requirements[0].MinApprovals; n != 3 {
+		t.Errorf("operations quorum = %d, want 3", n)
+	}
+	if n := plan.Requirements[1].MinApprovals; n != 2 {
+		t.Errorf("it quorum = %d, want 2", n)
+	}
+}
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomFieldType is the kind of value a custom field holds
+type CustomFieldType string
+
+const (
+	CustomFieldString      CustomFieldType = "string"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldBoolean     CustomFieldType = "boolean"
+	CustomFieldDate        CustomFieldType = "date" // YYYY-MM-DD
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multi_select"
+)
+
+// Valid returns true if the type is supported
+func (t CustomFieldType) Valid() bool {
+	switch t {
+	case CustomFieldString, CustomFieldNumber, CustomFieldBoolean, CustomFieldDate,
+		CustomFieldSelect, CustomFieldMultiSelect:
+		return true
+	}
+	return false
+}
+
+// HasOptions returns true for types whose values come from a fixed list
+func (t CustomFieldType) HasOptions() bool {
+	return t == CustomFieldSelect || t == CustomFieldMultiSelect
+}
+
+// CustomField defines a key an organization's tickets may carry in
+// custom_fields
+type CustomField struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	OrganizationID uuid.UUID       `db:"organization_id" json:"organization_id"`
+	Name           string          `db:"name" json:"name"`
+	Label          string          `db:"label" json:"label"`
+	Description    *string         `db:"description" json:"description,omitempty"`
+	FieldType      CustomFieldType `db:"field_type" json:"field_type"`
+	Required       bool            `db:"required" json:"required"`
+	AllowedValues  []string        `db:"allowed_values" json:"allowed_values,omitempty"`
+	ChangeTypes    []string        `db:"change_types" json:"change_types,omitempty"` // Empty applies to every change type
+	CreatedBy      *uuid.UUID      `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// AppliesTo returns true if the field is used on tickets of the change type
+func (f *CustomField) AppliesTo(changeType *string) bool {
+	if len(f.ChangeTypes) == 0 {
+		return true
+	}
+	if changeType == nil {
+		return false
+	}
+	for _, ct := range f.ChangeTypes {
+		if ct == *changeType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *CustomField) allows(value string) bool {
+	for _, v := range f.AllowedValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomFieldErrors lists every custom field that failed validation
+type CustomFieldErrors []ValidationError
+
+func (e CustomFieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return "invalid custom fields: " + strings.Join(msgs, "; ")
+}
+
+// ValidateCustomFields checks a ticket's custom fields against the
+// organization's definitions. Every defined field that applies to the change
+// type must have a value of its type, and required ones must be set. Keys
+// without a definition are rejected unless they are already on the ticket,
+// so fields written before a schema existed, or by imports, survive edits.
+// Organizations without definitions accept any object.
+func ValidateCustomFields(defs []CustomField, changeType *string, values, current json.RawMessage) CustomFieldErrors {
+	fields, err := decodeCustomFields(values)
+	if err != nil {
+		return CustomFieldErrors{{Field: "custom_fields", Message: "must be a JSON object"}}
+	}
+	if len(defs) == 0 {
+		return nil
+	}
+	existing, _ := decodeCustomFields(current)
+
+	byName := make(map[string]*CustomField, len(defs))
+	for i := range defs {
+		byName[defs[i].Name] = &defs[i]
+	}
+
+	var errs CustomFieldErrors
+	add := func(name, message string) {
+		errs = append(errs, ValidationError{Field: "custom_fields." + name, Message: message})
+	}
+
+	for name, raw := range fields {
+		def, ok := byName[name]
+		if !ok {
+			if prev, had := existing[name]; !had || !sameJSON(prev, raw) {
+				add(name, "unknown field")
+			}
+			continue
+		}
+		if isJSONNull(raw) {
+			continue
+		}
+		if !def.AppliesTo(changeType) {
+			add(name, "does not apply to this change type")
+			continue
+		}
+		if msg := checkCustomFieldValue(def, raw); msg != "" {
+			add(name, msg)
+		}
+	}
+
+	for i := range defs {
+		def := &defs[i]
+		if !def.Required || !def.AppliesTo(changeType) {
+			continue
+		}
+		if raw, ok := fields[def.Name]; !ok || isEmptyCustomFieldValue(raw) {
+			add(def.Name, "is required")
+		}
+	}
+
+	sort.Slice(errs, func(a, b int) bool { return errs[a].Field < errs[b].Field })
+	return errs
+}
+
+// MergeCustomFields applies a patch to a ticket's custom fields: keys in the
+// patch replace the current values and null removes a key
+func MergeCustomFields(current, patch json.RawMessage) (json.RawMessage, error) {
+	fields, err := decodeCustomFields(current)
+	if err != nil {
+		fields = map[string]json.RawMessage{}
+	}
+	changes, err := decodeCustomFields(patch)
+	if err != nil {
+		return nil, &ValidationError{Field: "custom_fields", Message: "must be a JSON object"}
+	}
+
+	for name, raw := range changes {
+		if isJSONNull(raw) {
+			delete(fields, name)
+		} else {
+			fields[name] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func decodeCustomFields(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(raw) == 0 || isJSONNull(raw) {
+		return fields, nil
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	return fields, nil
+}
+
+func checkCustomFieldValue(def *CustomField, raw json.RawMessage) string {
+	switch def.FieldType {
+	case CustomFieldString:
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return "must be a string"
+		}
+	case CustomFieldNumber:
+		var n float64
+		if json.Unmarshal(raw, &n) != nil {
+			return "must be a number"
+		}
+	case CustomFieldBoolean:
+		var b bool
+		if json.Unmarshal(raw, &b) != nil {
+			return "must be true or false"
+		}
+	case CustomFieldDate:
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case CustomFieldSelect:
+		var s string
+		if json.Unmarshal(raw, &s) != nil || !def.allows(s) {
+			return "must be one of: " + strings.Join(def.AllowedValues, ", ")
+		}
+	case CustomFieldMultiSelect:
+		var list []string
+		if json.Unmarshal(raw, &list) != nil {
+			return "must be a list of: " + strings.Join(def.AllowedValues, ", ")
+		}
+		seen := make(map[string]bool, len(list))
+		for _, s := range list {
+			if !def.allows(s) {
+				return "invalid value " + s + "; must be one of: " + strings.Join(def.AllowedValues, ", ")
+			}
+			if seen[s] {
+				return "duplicate value " + s
+			}
+			seen[s] = true
+		}
+	}
+	return ""
+}
+
+// sameJSON compares two JSON values ignoring insignificant whitespace
+func sameJSON(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+// isEmptyCustomFieldValue returns true for null, blank strings and empty
+// lists, none of which satisfy a required field
+func isEmptyCustomFieldValue(raw json.RawMessage) bool {
+	switch string(bytes.TrimSpace(raw)) {
+	case "null", `""`, "[]":
+		return true
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s) == ""
+	}
+	return false
+}
+
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// CreateCustomFieldInput represents input for defining a custom field
+type CreateCustomFieldInput struct {
+	Name          string          `json:"name" validate:"required"`
+	Label         string          `json:"label" validate:"required,max=255"`
+	Description   *string         `json:"description,omitempty"`
+	FieldType     CustomFieldType `json:"field_type" validate:"required"`
+	Required      bool            `json:"required"`
+	AllowedValues []string        `json:"allowed_values,omitempty"`
+	ChangeTypes   []string        `json:"change_types,omitempty"`
+}
+
+// Validate checks the definition
+func (i *CreateCustomFieldInput) Validate() error {
+	if !customFieldNamePattern.MatchString(i.Name) {
+		return &ValidationError{Field: "name", Message: "must start with a letter and contain only lowercase letters, digits and underscores (max 64)"}
+	}
+	if strings.TrimSpace(i.Label) == "" {
+		return &ValidationError{Field: "label", Message: "label is required"}
+	}
+	if !i.FieldType.Valid() {
+		return &ValidationError{Field: "field_type", Message: "invalid field type: " + string(i.FieldType)}
+	}
+	if err := validateAllowedValues(i.FieldType, i.AllowedValues); err != nil {
+		return err
+	}
+	return validateChangeTypes(i.ChangeTypes)
+}
+
+// UpdateCustomFieldInput represents input for updating a custom field.
+// The name and type can't change since tickets already hold values for them.
+type UpdateCustomFieldInput struct {
+	Label         *string   `json:"label,omitempty" validate:"omitempty,max=255"`
+	Description   *string   `json:"description,omitempty"`
+	Required      *bool     `json:"required,omitempty"`
+	AllowedValues *[]string `json:"allowed_values,omitempty"`
+	ChangeTypes   *[]string `json:"change_types,omitempty"` // An empty list applies the field everywhere
+}
+
+// Validate checks the update against the field's type
+func (i *UpdateCustomFieldInput) Validate(fieldType CustomFieldType) error {
+	if i.Label != nil && strings.TrimSpace(*i.Label) == "" {
+		return &ValidationError{Field: "label", Message: "label must not be empty"}
+	}
+	if i.AllowedValues != nil {
+		if err := validateAllowedValues(fieldType, *i.AllowedValues); err != nil {
+			return err
+		}
+	}
+	if i.ChangeTypes != nil {
+		return validateChangeTypes(*i.ChangeTypes)
+	}
+	return nil
+}
+
+func validateAllowedValues(fieldType CustomFieldType, allowed []string) error {
+	if !fieldType.HasOptions() {
+		if len(allowed) > 0 {
+			return &ValidationError{Field: "allowed_values", Message: "only select and multi_select fields take allowed values"}
+		}
+		return nil
+	}
+	if len(allowed) == 0 {
+		return &ValidationError{Field: "allowed_values", Message: string(fieldType) + " fields need at least one allowed value"}
+	}
+	seen := make(map[string]bool, len(allowed))
+	for _, v := range allowed {
+		if strings.TrimSpace(v) == "" {
+			return &ValidationError{Field: "allowed_values", Message: "values must not be empty"}
+		}
+		if seen[v] {
+			return &ValidationError{Field: "allowed_values", Message: "duplicate value: " + v}
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+func validateChangeTypes(changeTypes []string) error {
+	for _, ct := range changeTypes {
+		if strings.TrimSpace(ct) == "" {
+			return &ValidationError{Field: "change_types", Message: "change types must not be empty"}
+		}
+	}
+	return nil
+}
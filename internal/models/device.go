@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// AuthMethodDevice is recorded for sessions opened by a device login. A
+// login approved with a recent MFA verification is recorded as device+mfa.
+const AuthMethodDevice = "device"
+
+// AuthChallengeDeviceLogin is a pending device login, waiting for the user
+// to approve its code
+const AuthChallengeDeviceLogin AuthChallengePurpose = "device_login"
+
+// Device login timing (RFC 8628)
+const (
+	DeviceCodeTTL      = 10 * time.Minute
+	DevicePollInterval = 5 * time.Second
+)
+
+// Errors returned while polling a device login, as named by RFC 8628
+const (
+	DeviceErrorPending = "authorization_pending"
+	DeviceErrorExpired = "expired_token"
+)
+
+// DeviceAuthorization is the response that starts a device login. The
+// client shows the user code and verification URI, then polls with the
+// device code.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceTokenInput polls a device login for its tokens
+type DeviceTokenInput struct {
+	DeviceCode string `json:"device_code" validate:"required"`
+}
+
+// Validate checks the poll input
+func (i *DeviceTokenInput) Validate() error {
+	if i.DeviceCode == "" {
+		return &ValidationError{Field: "device_code", Message: "device_code is required"}
+	}
+	return nil
+}
+
+// DeviceApprovalInput approves a device login for the signed-in user
+type DeviceApprovalInput struct {
+	UserCode string `json:"user_code" validate:"required"`
+}
+
+// Validate checks the approval input
+func (i *DeviceApprovalInput) Validate() error {
+	if i.UserCode == "" {
+		return &ValidationError{Field: "user_code", Message: "user_code is required"}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DigestFrequency constants
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Digest scheduling bounds
+const (
+	// DigestSendWindow is how late after its scheduled time a digest may
+	// still go out, so a worker outage doesn't send a burst of stale digests
+	DigestSendWindow = 2 * time.Hour
+	// MaxDigestItems caps each section of a digest
+	MaxDigestItems = 25
+)
+
+// NotificationPreferences are a user's digest settings
+type NotificationPreferences struct {
+	UserID              uuid.UUID  `db:"user_id" json:"user_id"`
+	OrganizationID      uuid.UUID  `db:"organization_id" json:"organization_id"`
+	DigestFrequency     string     `db:"digest_frequency" json:"digest_frequency"`
+	DigestTime          string     `db:"digest_time" json:"digest_time"`       // HH:MM local
+	DigestWeekday       int        `db:"digest_weekday" json:"digest_weekday"` // 0 = Sunday
+	Timezone            string     `db:"timezone" json:"timezone"`
+	DigestApprovals     bool       `db:"digest_approvals" json:"digest_approvals"`
+	DigestStatusChanges bool       `db:"digest_status_changes" json:"digest_status_changes"`
+	DigestUpcoming      bool       `db:"digest_upcoming" json:"digest_upcoming"`
+	LastDigestAt        *time.Time `db:"last_digest_at" json:"last_digest_at,omitempty"`
+}
+
+// DefaultNotificationPreferences returns the preferences of a user who has
+// not set any
+func DefaultNotificationPreferences(userID, orgID uuid.UUID) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:              userID,
+		OrganizationID:      orgID,
+		DigestFrequency:     DigestOff,
+		DigestTime:          "08:00",
+		DigestWeekday:       int(time.Monday),
+		Timezone:            "UTC",
+		DigestApprovals:     true,
+		DigestStatusChanges: true,
+		DigestUpcoming:      true,
+	}
+}
+
+// Period returns how much time each digest covers
+func (p *NotificationPreferences) Period() time.Duration {
+	if p.DigestFrequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// ScheduledDigestAt returns the latest time at or before now a digest was
+// scheduled for, in the user's timezone. ok is false when digests are off
+// or the preferences can't be read.
+func (p *NotificationPreferences) ScheduledDigestAt(now time.Time) (time.Time, bool) {
+	if p.DigestFrequency != DigestDaily && p.DigestFrequency != DigestWeekly {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	clock, err := time.Parse("15:04", p.DigestTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	at := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if at.After(local) {
+		at = at.AddDate(0, 0, -1)
+	}
+	if p.DigestFrequency == DigestWeekly {
+		back := (int(at.Weekday()) - p.DigestWeekday + 7) % 7
+		at = at.AddDate(0, 0, -back)
+	}
+	return at, true
+}
+
+// DigestDue returns the scheduled time of the digest to send now, if one
+// is due: it has not been sent and is no more than DigestSendWindow late
+func (p *NotificationPreferences) DigestDue(now time.Time) (time.Time, bool) {
+	at, ok := p.ScheduledDigestAt(now)
+	if !ok || now.Sub(at) > DigestSendWindow {
+		return time.Time{}, false
+	}
+	if p.LastDigestAt != nil && !p.LastDigestAt.Before(at) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// UpdateNotificationPreferencesInput represents input for changing a user's
+// digest settings
+type UpdateNotificationPreferencesInput struct {
+	DigestFrequency     *string `json:"digest_frequency,omitempty"`
+	DigestTime          *string `json:"digest_time,omitempty"`
+	DigestWeekday       *int    `json:"digest_weekday,omitempty"`
+	Timezone            *string `json:"timezone,omitempty"`
+	DigestApprovals     *bool   `json:"digest_approvals,omitempty"`
+	DigestStatusChanges *bool   `json:"digest_status_changes,omitempty"`
+	DigestUpcoming      *bool   `json:"digest_upcoming,omitempty"`
+}
+
+// Validate checks the preference changes
+func (i *UpdateNotificationPreferencesInput) Validate() error {
+	if i.DigestFrequency != nil {
+		switch *i.DigestFrequency {
+		case DigestOff, DigestDaily, DigestWeekly:
+		default:
+			return &ValidationError{Field: "digest_frequency", Message: "digest_frequency must be off, daily or weekly"}
+		}
+	}
+	if i.DigestTime != nil {
+		if _, err := time.Parse("15:04", *i.DigestTime); err != nil || len(*i.DigestTime) != 5 {
+			return &ValidationError{Field: "digest_time", Message: "digest_time must be HH:MM"}
+		}
+	}
+	if i.DigestWeekday != nil && (*i.DigestWeekday < 0 || *i.DigestWeekday > 6) {
+		return &ValidationError{Field: "digest_weekday", Message: "digest_weekday must be between 0 (Sunday) and 6"}
+	}
+	if i.Timezone != nil {
+		if _, err := time.LoadLocation(*i.Timezone); err != nil || *i.Timezone == "" || *i.Timezone == "Local" {
+			return &ValidationError{Field: "timezone", Message: fmt.Sprintf("unknown timezone %q", *i.Timezone)}
+		}
+	}
+	return nil
+}
+
+// Apply sets the changed preferences
+func (i *UpdateNotificationPreferencesInput) Apply(p *NotificationPreferences) {
+	if i.DigestFrequency != nil {
+		p.DigestFrequency = *i.DigestFrequency
+	}
+	if i.DigestTime != nil {
+		p.DigestTime = *i.DigestTime
+	}
+	if i.DigestWeekday != nil {
+		p.DigestWeekday = *i.DigestWeekday
+	}
+	if i.Timezone != nil {
+		p.Timezone = *i.Timezone
+	}
+	if i.DigestApprovals != nil {
+		p.DigestApprovals = *i.DigestApprovals
+	}
+	if i.DigestStatusChanges != nil {
+		p.DigestStatusChanges = *i.DigestStatusChanges
+	}
+	if i.DigestUpcoming != nil {
+		p.DigestUpcoming = *i.DigestUpcoming
+	}
+}
+
+// DigestRecipient is a user who receives digests, with their preferences
+type DigestRecipient struct {
+	UserID         uuid.UUID
+	OrganizationID uuid.UUID
+	Email          string
+	Username       *string
+	FullName       string
+	Roles          []string
+	Preferences    NotificationPreferences
+}
+
+// QueuedDigest is a due digest for NotificationStore.QueueDigests to record
+// as sent at ScheduledAt. Message is nil when there was nothing to report.
+type QueuedDigest struct {
+	Recipient   *DigestRecipient
+	ScheduledAt time.Time
+	Message     *NotificationMessage
+}
+
+// DigestApproval is an approval waiting on the digest's recipient
+type DigestApproval struct {
+	TicketNumber string
+	Title        string
+	ApprovalType ApprovalType
+	RiskLevel    RiskLevel
+	RequestedAt  time.Time
+	Deadline     *time.Time // When approval is due under the organization's SLA
+}
+
+// DigestStatusChange is a status change to one of the recipient's tickets
+type DigestStatusChange struct {
+	TicketNumber string
+	Title        string
+	From         TicketStatus
+	To           TicketStatus
+	ChangedAt    time.Time
+}
+
+// DigestScheduledChange is an upcoming change touching hosts the recipient
+// owns
+type DigestScheduledChange struct {
+	TicketNumber   string
+	Title          string
+	Status         TicketStatus
+	ScheduledStart time.Time
+	ScheduledEnd   *time.Time
+	Hosts          []string
+}
+
+// Digest is one user's summary for a period
+type Digest struct {
+	Frequency     string
+	From          time.Time
+	To            time.Time
+	Location      *time.Location // Recipient's timezone, for rendering times
+	Approvals     []DigestApproval
+	StatusChanges []DigestStatusChange
+	Upcoming      []DigestScheduledChange
+}
+
+// Empty returns true if the digest has nothing to report
+func (d *Digest) Empty() bool {
+	return len(d.Approvals) == 0 && len(d.StatusChanges) == 0 && len(d.Upcoming) == 0
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Emergency change timing. Emergency tickets must be decided within the
+// emergency SLA; once the deadline passes the worker escalates every
+// EmergencyEscalationInterval until MaxEmergencyEscalationLevel is reached.
+const (
+	EmergencyApprovalWindow     = 1 * time.Hour
+	EmergencyEscalationInterval = 15 * time.Minute
+	MaxEmergencyEscalationLevel = 3
+
+	// EmergencyPIRWindow is how long after close a PIR must be completed
+	EmergencyPIRWindow = 5 * 24 * time.Hour
+)
+
+// Audit actions specific to the emergency workflow
+const (
+	AuditActionEmergencySubmit     = "emergency_submit"
+	AuditActionEmergencyEscalation = "emergency_escalation"
+)
+
+// EmergencyApprovalDeadline returns the shortened approval deadline for an
+// emergency ticket, keeping an earlier deadline if one was requested
+func EmergencyApprovalDeadline(requested *time.Time, now time.Time) time.Time {
+	deadline := now.Add(EmergencyApprovalWindow)
+	if requested != nil && requested.Before(deadline) && requested.After(now) {
+		return *requested
+	}
+	return deadline
+}
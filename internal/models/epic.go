@@ -0,0 +1,169 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EpicStatus represents the status of an epic
+type EpicStatus string
+
+const (
+	EpicStatusOpen       EpicStatus = "open"
+	EpicStatusInProgress EpicStatus = "in_progress"
+	EpicStatusDone       EpicStatus = "done"
+)
+
+// Valid returns true if the epic status is valid
+func (s EpicStatus) Valid() bool {
+	switch s {
+	case EpicStatusOpen, EpicStatusInProgress, EpicStatusDone:
+		return true
+	}
+	return false
+}
+
+// Epic groups related tickets across sprints
+type Epic struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	ProjectID      *uuid.UUID `db:"project_id" json:"project_id,omitempty"`
+	Name           string     `db:"name" json:"name"`
+	Description    *string    `db:"description" json:"description,omitempty"`
+	Status         EpicStatus `db:"status" json:"status"`
+	TargetDate     *time.Time `db:"target_date" json:"target_date,omitempty"`
+	CreatedBy      *uuid.UUID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// EpicListFilter narrows an epic listing
+type EpicListFilter struct {
+	ProjectID *uuid.UUID
+	Status    *EpicStatus
+}
+
+// CreateEpicInput represents input for creating an epic
+type CreateEpicInput struct {
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	Name        string     `json:"name" validate:"required,max=255"`
+	Description *string    `json:"description,omitempty"`
+	TargetDate  string     `json:"target_date,omitempty"` // YYYY-MM-DD
+
+	Target *time.Time `json:"-"`
+}
+
+// Validate checks the epic input and parses the target date
+func (i *CreateEpicInput) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	if err := validatePlanningName(i.Name); err != nil {
+		return err
+	}
+	if i.TargetDate != "" {
+		d, err := parsePlanningDate("target_date", i.TargetDate)
+		if err != nil {
+			return err
+		}
+		i.Target = &d
+	}
+	return nil
+}
+
+// UpdateEpicInput represents input for updating an epic
+type UpdateEpicInput struct {
+	Name        *string     `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description *string     `json:"description,omitempty"`
+	Status      *EpicStatus `json:"status,omitempty"`
+	TargetDate  *string     `json:"target_date,omitempty"` // YYYY-MM-DD, or empty to clear
+
+	Target *time.Time `json:"-"`
+}
+
+// Validate checks the epic update and parses the target date
+func (i *UpdateEpicInput) Validate() error {
+	if i.Name != nil {
+		name := strings.TrimSpace(*i.Name)
+		if err := validatePlanningName(name); err != nil {
+			return err
+		}
+		i.Name = &name
+	}
+	if i.Status != nil && !i.Status.Valid() {
+		return &ValidationError{Field: "status", Message: "invalid epic status: " + string(*i.Status)}
+	}
+	if i.TargetDate != nil && *i.TargetDate != "" {
+		d, err := parsePlanningDate("target_date", *i.TargetDate)
+		if err != nil {
+			return err
+		}
+		i.Target = &d
+	}
+	return nil
+}
+
+func validatePlanningName(name string) error {
+	if name == "" {
+		return &ValidationError{Field: "name", Message: "name is required"}
+	}
+	if len(name) > 255 {
+		return &ValidationError{Field: "name", Message: "name must be at most 255 characters"}
+	}
+	return nil
+}
+
+func parsePlanningDate(field, value string) (time.Time, error) {
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, &ValidationError{Field: field, Message: field + " must be YYYY-MM-DD"}
+	}
+	return d, nil
+}
+
+// StatusRollup counts the tickets and story points in one status
+type StatusRollup struct {
+	Tickets int `json:"tickets"`
+	Points  int `json:"points"`
+}
+
+// TicketRollup summarizes the tickets in an epic or sprint. Cancelled and
+// denied tickets appear in the breakdown but not in the point totals.
+type TicketRollup struct {
+	TicketCount     int                           `json:"ticket_count"`
+	TotalPoints     int                           `json:"total_points"`
+	CompletedPoints int                           `json:"completed_points"`
+	RemainingPoints int                           `json:"remaining_points"`
+	Unestimated     int                           `json:"unestimated"` // Tickets without story points
+	ByStatus        map[TicketStatus]StatusRollup `json:"by_status"`
+}
+
+// Add counts a ticket in the rollup
+func (r *TicketRollup) Add(status TicketStatus, points *int) {
+	if r.ByStatus == nil {
+		r.ByStatus = make(map[TicketStatus]StatusRollup)
+	}
+	p := 0
+	if points != nil {
+		p = *points
+	}
+
+	bucket := r.ByStatus[status]
+	bucket.Tickets++
+	bucket.Points += p
+	r.ByStatus[status] = bucket
+	r.TicketCount++
+
+	if status.IsWithdrawn() {
+		return
+	}
+	if points == nil {
+		r.Unestimated++
+	}
+	r.TotalPoints += p
+	if status.IsDone() {
+		r.CompletedPoints += p
+	} else {
+		r.RemainingPoints += p
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import "errors"
+
+// Kinds of store error. Stores return errors wrapping one of these, so
+// callers can tell them apart with errors.Is rather than by their message.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("conflict")
+	ErrForbidden         = errors.New("forbidden")
+	ErrInvalidTransition = errors.New("invalid transition")
+)
+
+// StoreError is a store error of one kind, with its own message
+type StoreError struct {
+	Kind    error
+	Message string
+}
+
+func (e *StoreError) Error() string {
+	return e.Message
+}
+
+func (e *StoreError) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound returns an ErrNotFound error with the message, such as
+// "ticket not found"
+func NotFound(message string) error {
+	return &StoreError{Kind: ErrNotFound, Message: message}
+}
+
+// Conflict returns an ErrConflict error, for writes that clash with
+// existing data, such as a duplicate name
+func Conflict(message string) error {
+	return &StoreError{Kind: ErrConflict, Message: message}
+}
+
+// Forbidden returns an ErrForbidden error, for callers not allowed to do
+// what they asked
+func Forbidden(message string) error {
+	return &StoreError{Kind: ErrForbidden, Message: message}
+}
+
+// InvalidTransition returns an ErrInvalidTransition error, for actions the
+// record's current state doesn't allow
+func InvalidTransition(message string) error {
+	return &StoreError{Kind: ErrInvalidTransition, Message: message}
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GitHub webhook limits
+const (
+	MinGitHubWebhookSecretLength = 16
+	MaxGitHubWebhookPayloadBytes = 25 << 20 // GitHub caps payloads at 25 MB
+	GitHubDeliveryRetention      = 30 * 24 * time.Hour
+)
+
+// GitHubIntegration holds an organization's GitHub webhook configuration.
+// ActorUserID is recorded as the linker and comment author for changes
+// made from webhook deliveries.
+type GitHubIntegration struct {
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	WebhookSecret  string     `db:"webhook_secret" json:"-"`
+	ActorUserID    uuid.UUID  `db:"actor_user_id" json:"actor_user_id"`
+	IsActive       bool       `db:"is_active" json:"is_active"`
+	UpdatedBy      *uuid.UUID `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// UpdateGitHubIntegrationInput represents input for configuring the GitHub integration
+type UpdateGitHubIntegrationInput struct {
+	WebhookSecret *string    `json:"webhook_secret,omitempty"`
+	ActorUserID   *uuid.UUID `json:"actor_user_id,omitempty"`
+	IsActive      *bool      `json:"is_active,omitempty"`
+}
+
+// Validate checks the GitHub integration input
+func (i *UpdateGitHubIntegrationInput) Validate() error {
+	if i.WebhookSecret != nil && len(*i.WebhookSecret) < MinGitHubWebhookSecretLength {
+		return &ValidationError{Field: "webhook_secret", Message: "webhook_secret must be at least 16 characters"}
+	}
+	if i.ActorUserID != nil && *i.ActorUserID == uuid.Nil {
+		return &ValidationError{Field: "actor_user_id", Message: "actor_user_id is required"}
+	}
+	return nil
+}
+
+// GitHubRepositoryMatch is an organization with an active GitHub integration
+// that tracks the repository a webhook delivery came from
+type GitHubRepositoryMatch struct {
+	Integration        GitHubIntegration
+	RepositoryID       uuid.UUID
+	TicketNumberPrefix string
+}
+
+// GitHubRepository is the repository block of a webhook payload
+type GitHubRepository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+// URLs returns the forms a repository URL may have been registered under
+func (r *GitHubRepository) URLs() []string {
+	var urls []string
+	for _, u := range []string{r.HTMLURL, r.CloneURL, r.SSHURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if r.HTMLURL != "" {
+		urls = append(urls, r.HTMLURL+".git", r.HTMLURL+"/")
+	}
+	return urls
+}
+
+// GitHubUser is a user block of a webhook payload
+type GitHubUser struct {
+	Login string `json:"login"`
+}
+
+// GitHubPullRequest is the pull_request block of a webhook payload
+type GitHubPullRequest struct {
+	Number   int         `json:"number"`
+	Title    string      `json:"title"`
+	HTMLURL  string      `json:"html_url"`
+	State    string      `json:"state"`
+	Draft    bool        `json:"draft"`
+	Merged   bool        `json:"merged"`
+	MergedBy *GitHubUser `json:"merged_by"`
+	Head     struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// GitHubPullRequestEvent is the payload of a pull_request webhook delivery
+type GitHubPullRequestEvent struct {
+	Action      string             `json:"action"`
+	PullRequest *GitHubPullRequest `json:"pull_request"`
+	Repository  *GitHubRepository  `json:"repository"`
+	Sender      GitHubUser         `json:"sender"`
+}
+
+// Linkable returns true for pull request actions that should link or
+// refresh the ticket link
+func (e *GitHubPullRequestEvent) Linkable() bool {
+	switch e.Action {
+	case "opened", "edited", "reopened", "synchronize", "closed", "ready_for_review", "converted_to_draft":
+		return e.PullRequest != nil && e.Repository != nil
+	}
+	return false
+}
+
+// TicketNumbers returns the ticket numbers with the given prefix referenced
+// in the pull request title or head branch, uppercased and deduplicated
+func (e *GitHubPullRequestEvent) TicketNumbers(prefix string) []string {
+	if e.PullRequest == nil {
+		return nil
+	}
+	pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(prefix) + `-\d{4}-\d+\b`)
+	// Underscores are word characters; treat them as separators so
+	// branches like chg-2025-00001_fix still match
+	text := strings.ReplaceAll(e.PullRequest.Title+" "+e.PullRequest.Head.Ref, "_", " ")
+
+	seen := make(map[string]bool)
+	var numbers []string
+	for _, match := range pattern.FindAllString(text, -1) {
+		number := strings.ToUpper(match)
+		if !seen[number] {
+			seen[number] = true
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// StatusComment returns the ticket comment describing the pull request
+// status change, or "" for actions that do not change its status
+func (e *GitHubPullRequestEvent) StatusComment() string {
+	pr := e.PullRequest
+	ref := fmt.Sprintf("%s#%d", e.Repository.FullName, pr.Number)
+
+	var status string
+	switch e.Action {
+	case "opened":
+		status = "opened by @" + e.Sender.Login
+		if pr.Draft {
+			status = "opened as a draft by @" + e.Sender.Login
+		}
+	case "reopened":
+		status = "reopened by @" + e.Sender.Login
+	case "ready_for_review":
+		status = "marked ready for review by @" + e.Sender.Login
+	case "converted_to_draft":
+		status = "converted to a draft by @" + e.Sender.Login
+	case "closed":
+		if !pr.Merged {
+			status = "closed without merging by @" + e.Sender.Login
+			break
+		}
+		mergedBy := e.Sender.Login
+		if pr.MergedBy != nil {
+			mergedBy = pr.MergedBy.Login
+		}
+		status = "merged by @" + mergedBy
+	default:
+		return ""
+	}
+
+	return fmt.Sprintf("Pull request %s %s: %s\n%s", ref, status, pr.Title, pr.HTMLURL)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Idempotency key limits
+const (
+	IdempotencyKeyTTL       = 24 * time.Hour
+	MaxIdempotencyKeyLength = 255
+	MaxIdempotentBodyBytes  = 1 << 20 // Keyed requests are buffered to hash them
+)
+
+// IdempotencyKey records a keyed request and, once finished, its response so
+// retries with the same key replay it instead of repeating the side effects
+type IdempotencyKey struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID  `db:"user_id" json:"user_id"`
+	Key            string     `db:"idempotency_key" json:"idempotency_key"`
+	Method         string     `db:"method" json:"method"`
+	Path           string     `db:"path" json:"path"`
+	RequestHash    string     `db:"request_hash" json:"request_hash"`
+	StatusCode     *int       `db:"status_code" json:"status_code,omitempty"`
+	ContentType    *string    `db:"content_type" json:"content_type,omitempty"`
+	ResponseBody   []byte     `db:"response_body" json:"-"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	CompletedAt    *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+}
+
+// IsComplete returns true once the original request's response has been stored
+func (k *IdempotencyKey) IsComplete() bool {
+	return k.CompletedAt != nil
+}
@@ -0,0 +1,325 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxBlackoutMinutes bounds a single blackout or extension (7 days)
+const MaxBlackoutMinutes = 7 * 24 * 60
+
+// HostStatus is the status of an inventory host. The values match hostctl.
+type HostStatus string
+
+const (
+	HostStatusActive         HostStatus = "active"
+	HostStatusInactive       HostStatus = "inactive"
+	HostStatusBuild          HostStatus = "build"
+	HostStatusBlackout       HostStatus = "blackout"
+	HostStatusMaintenance    HostStatus = "maintenance"
+	HostStatusDecommissioned HostStatus = "decommissioned"
+)
+
+// Valid reports whether the status is known
+func (s HostStatus) Valid() bool {
+	switch s {
+	case HostStatusActive, HostStatusInactive, HostStatusBuild, HostStatusBlackout,
+		HostStatusMaintenance, HostStatusDecommissioned:
+		return true
+	}
+	return false
+}
+
+// Host types, providers and environments accepted by hostctl
+var (
+	HostTypes        = []string{"server", "container", "vm", "k8s-node", "load-balancer", "database"}
+	HostProviders    = []string{"oci", "gcp", "onprem", "other"}
+	HostEnvironments = []string{"production", "staging", "development"}
+)
+
+// BlackoutStatus is the status of a blackout window. The values match the
+// blackout tool.
+type BlackoutStatus string
+
+const (
+	BlackoutStatusActive    BlackoutStatus = "active"
+	BlackoutStatusCompleted BlackoutStatus = "completed" // ended early by hand
+	BlackoutStatusExpired   BlackoutStatus = "expired"   // ran to its end time
+)
+
+// Host is an infrastructure host in inventory_resources, the table hostctl
+// manages. Inventory is shared across organizations.
+type Host struct {
+	ID                 int                    `db:"id" json:"id"`
+	ResourceName       string                 `db:"resource_name" json:"resource_name"`
+	Hostname           string                 `db:"hostname" json:"hostname"`
+	Type               string                 `db:"type" json:"type"`
+	Provider           string                 `db:"provider" json:"provider"`
+	Region             *string                `db:"region" json:"region,omitempty"`
+	Status             HostStatus             `db:"status" json:"status"`
+	Environment        string                 `db:"environment" json:"environment"`
+	Owners             []string               `db:"owners" json:"owners"`
+	MailGroups         []string               `db:"mailgroups" json:"mailgroups"`
+	Metadata           map[string]interface{} `db:"metadata" json:"metadata"`
+	AverageDailyCost   *float64               `db:"average_daily_cost" json:"average_daily_cost,omitempty"`
+	AverageMonthlyCost *float64               `db:"average_monthly_cost" json:"average_monthly_cost,omitempty"`
+	ExternalID         *string                `db:"external_id" json:"external_id,omitempty"`
+	ExternalURL        *string                `db:"external_url" json:"external_url,omitempty"`
+	CreatedAt          time.Time              `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time              `db:"updated_at" json:"updated_at"`
+
+	// Relationships
+	ActiveBlackout *Blackout `db:"-" json:"active_blackout,omitempty"`
+}
+
+// Blackout is a maintenance window in inventory_blackouts, during which
+// monitoring alerts for the host are suppressed. CreatedBy is a Unix
+// username for blackouts started with the blackout tool and an email
+// address for those started through the API.
+type Blackout struct {
+	ID            int            `db:"id" json:"id"`
+	TicketNumber  string         `db:"ticket_number" json:"ticket_number"`
+	Hostname      string         `db:"hostname" json:"hostname"`
+	StartTime     time.Time      `db:"start_time" json:"start_time"`
+	EndTime       time.Time      `db:"end_time" json:"end_time"`
+	ActualEndTime *time.Time     `db:"actual_end_time" json:"actual_end_time,omitempty"`
+	Reason        string         `db:"reason" json:"reason"`
+	CreatedBy     string         `db:"created_by" json:"created_by"`
+	Status        BlackoutStatus `db:"status" json:"status"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+}
+
+// DiscrepancyKind says which side of a reconciliation is missing a host
+type DiscrepancyKind string
+
+const (
+	DiscrepancyGhost DiscrepancyKind = "ghost" // in inventory, not in the cloud
+	DiscrepancyStray DiscrepancyKind = "stray" // in the cloud, not in inventory
+)
+
+// Valid reports whether the kind is known
+func (k DiscrepancyKind) Valid() bool {
+	return k == DiscrepancyGhost || k == DiscrepancyStray
+}
+
+// CloudtopProviders maps the inventory providers that can be reconciled
+// to the cloudtop provider reporting their instances
+var CloudtopProviders = map[string]string{
+	"oci": "oracle",
+	"gcp": "gcp",
+}
+
+// CloudInstance is a compute instance reported by a cloudtop provider
+type CloudInstance struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Status string `json:"status"`
+}
+
+// Terminated reports whether the instance is gone or going, so it no
+// longer needs an inventory entry
+func (i *CloudInstance) Terminated() bool {
+	switch strings.ToLower(i.Status) {
+	case "terminated", "terminating", "deleted", "deleting":
+		return true
+	}
+	return false
+}
+
+// InventoryDiscrepancy is a host found on only one side when the worker
+// reconciles inventory against a cloud provider. It stays open while later
+// runs keep finding it and is resolved once they don't.
+type InventoryDiscrepancy struct {
+	ID          int             `db:"id" json:"id"`
+	Kind        DiscrepancyKind `db:"kind" json:"kind"`
+	Provider    string          `db:"provider" json:"provider"`
+	ResourceID  *int            `db:"resource_id" json:"resource_id,omitempty"`
+	Hostname    *string         `db:"hostname" json:"hostname,omitempty"`
+	ExternalID  *string         `db:"external_id" json:"external_id,omitempty"`
+	CloudName   *string         `db:"cloud_name" json:"cloud_name,omitempty"`
+	Region      *string         `db:"region" json:"region,omitempty"`
+	CloudStatus *string         `db:"cloud_status" json:"cloud_status,omitempty"`
+	FirstSeenAt time.Time       `db:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt  time.Time       `db:"last_seen_at" json:"last_seen_at"`
+	ResolvedAt  *time.Time      `db:"resolved_at" json:"resolved_at,omitempty"`
+}
+
+// MatchKey identifies the discrepancy across runs: the hostname of a ghost
+// or the instance ID of a stray
+func (d *InventoryDiscrepancy) MatchKey() string {
+	if d.Kind == DiscrepancyGhost && d.Hostname != nil {
+		return *d.Hostname
+	}
+	if d.ExternalID != nil {
+		return *d.ExternalID
+	}
+	return ""
+}
+
+// DiscrepancyFilter represents filter options for listing discrepancies
+type DiscrepancyFilter struct {
+	Kind     DiscrepancyKind `json:"kind,omitempty"`
+	Provider string          `json:"provider,omitempty"`
+	Resolved bool            `json:"resolved,omitempty"` // include resolved discrepancies
+	Limit    int             `json:"limit,omitempty"`
+}
+
+// AffectedResource is an inventory host that one of a ticket's affected
+// systems resolved to. A system names a host by hostname or resource name,
+// or a group of hosts with a * pattern such as "web-*".
+type AffectedResource struct {
+	TicketID    uuid.UUID `db:"ticket_id" json:"ticket_id"`
+	ResourceID  int       `db:"resource_id" json:"resource_id"`
+	Hostname    string    `db:"hostname" json:"hostname"`
+	System      string    `db:"system" json:"system"`
+	Environment string    `db:"environment" json:"environment,omitempty"` // The host's environment when resolved (migration 046)
+	ResolvedAt  time.Time `db:"resolved_at" json:"resolved_at"`
+}
+
+// IsHostPattern returns true if an affected system names a group of hosts
+func IsHostPattern(system string) bool {
+	return strings.Contains(system, "*")
+}
+
+// HostListFilter represents filter options for listing hosts
+type HostListFilter struct {
+	Status      *HostStatus `json:"status,omitempty"`
+	Environment string      `json:"environment,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	Provider    string      `json:"provider,omitempty"`
+	Search      string      `json:"search,omitempty"` // hostname substring
+	Page        int         `json:"page" validate:"min=1"`
+	PerPage     int         `json:"per_page" validate:"min=1,max=100"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *HostListFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the offset for pagination
+func (f *HostListFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
+// CreateHostInput represents input for adding a host to inventory
+type CreateHostInput struct {
+	Hostname           string                 `json:"hostname" validate:"required,max=255"`
+	Type               string                 `json:"type" validate:"required"`
+	Provider           string                 `json:"provider" validate:"required"`
+	Region             *string                `json:"region,omitempty"`
+	Status             HostStatus             `json:"status,omitempty"`
+	Environment        string                 `json:"environment" validate:"required"`
+	Owners             []string               `json:"owners,omitempty"`
+	MailGroups         []string               `json:"mailgroups,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	AverageDailyCost   *float64               `json:"average_daily_cost,omitempty"`
+	AverageMonthlyCost *float64               `json:"average_monthly_cost,omitempty"`
+	ExternalID         *string                `json:"external_id,omitempty"`
+	ExternalURL        *string                `json:"external_url,omitempty"`
+}
+
+// UpdateHostInput represents input for updating a host. Metadata keys are
+// merged into the existing metadata.
+type UpdateHostInput struct {
+	Type               *string                `json:"type,omitempty"`
+	Provider           *string                `json:"provider,omitempty"`
+	Region             *string                `json:"region,omitempty"`
+	Status             *HostStatus            `json:"status,omitempty"`
+	Environment        *string                `json:"environment,omitempty"`
+	Owners             []string               `json:"owners,omitempty"`
+	MailGroups         []string               `json:"mailgroups,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	AverageDailyCost   *float64               `json:"average_daily_cost,omitempty"`
+	AverageMonthlyCost *float64               `json:"average_monthly_cost,omitempty"`
+	ExternalID         *string                `json:"external_id,omitempty"`
+	ExternalURL        *string                `json:"external_url,omitempty"`
+}
+
+// Validate checks the host input and applies defaults
+func (i *CreateHostInput) Validate() error {
+	i.Hostname = strings.TrimSpace(i.Hostname)
+	if i.Hostname == "" {
+		return &ValidationError{Field: "hostname", Message: "hostname is required"}
+	}
+	if len(i.Hostname) > 255 {
+		return &ValidationError{Field: "hostname", Message: "hostname must be at most 255 characters"}
+	}
+	if i.Status == "" {
+		i.Status = HostStatusActive
+	}
+	return validateHostFields(&i.Type, &i.Provider, &i.Environment, &i.Status)
+}
+
+// Validate checks the host update input
+func (i *UpdateHostInput) Validate() error {
+	return validateHostFields(i.Type, i.Provider, i.Environment, i.Status)
+}
+
+func validateHostFields(hostType, provider, environment *string, status *HostStatus) error {
+	if hostType != nil && !containsString(HostTypes, *hostType) {
+		return &ValidationError{Field: "type", Message: "type must be one of: " + strings.Join(HostTypes, ", ")}
+	}
+	if provider != nil && !containsString(HostProviders, *provider) {
+		return &ValidationError{Field: "provider", Message: "provider must be one of: " + strings.Join(HostProviders, ", ")}
+	}
+	if environment != nil && !containsString(HostEnvironments, *environment) {
+		return &ValidationError{Field: "environment", Message: "environment must be one of: " + strings.Join(HostEnvironments, ", ")}
+	}
+	if status != nil && !status.Valid() {
+		return &ValidationError{Field: "status", Message: "invalid status"}
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StartBlackoutInput represents input for starting a blackout from a
+// ticket. Without a duration the blackout runs to the ticket's scheduled
+// end. The reason defaults to the ticket title.
+type StartBlackoutInput struct {
+	TicketNumber    string `json:"ticket_number" validate:"required"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// Validate checks the blackout input
+func (i *StartBlackoutInput) Validate() error {
+	i.TicketNumber = strings.TrimSpace(i.TicketNumber)
+	if i.TicketNumber == "" {
+		return &ValidationError{Field: "ticket_number", Message: "ticket_number is required"}
+	}
+	if i.DurationMinutes < 0 || i.DurationMinutes > MaxBlackoutMinutes {
+		return &ValidationError{Field: "duration_minutes", Message: "duration_minutes must be between 1 and 10080"}
+	}
+	i.Reason = strings.TrimSpace(i.Reason)
+	return nil
+}
+
+// ExtendBlackoutInput represents input for extending an active blackout
+type ExtendBlackoutInput struct {
+	DurationMinutes int `json:"duration_minutes" validate:"required"`
+}
+
+// Validate checks the extension input
+func (i *ExtendBlackoutInput) Validate() error {
+	if i.DurationMinutes < 1 || i.DurationMinutes > MaxBlackoutMinutes {
+		return &ValidationError{Field: "duration_minutes", Message: "duration_minutes must be between 1 and 10080"}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jira sync limits
+const (
+	DefaultJiraIssueType = "Task"
+	MaxJiraSyncErrors    = 10 // consecutive push failures before a ticket is skipped until it changes
+)
+
+// JiraSyncFields are the ticket fields that can be mapped onto Jira issue fields
+var JiraSyncFields = map[string]bool{
+	"title":              true,
+	"description":        true,
+	"ticket_number":      true,
+	"priority":           true,
+	"risk_level":         true,
+	"change_type":        true,
+	"labels":             true,
+	"impact_description": true,
+	"rollback_plan":      true,
+	"testing_plan":       true,
+	"scheduled_start":    true,
+	"scheduled_end":      true,
+}
+
+// DefaultJiraFieldMapping maps the fields every Jira project has
+func DefaultJiraFieldMapping() map[string]string {
+	return map[string]string{
+		"title":       "summary",
+		"description": "description",
+	}
+}
+
+// JiraIntegration mirrors an organization's change tickets to a Jira
+// project. FieldMapping maps ticket fields to Jira field IDs (e.g.
+// "risk_level": "customfield_10042"); StatusMapping maps ticket statuses
+// to Jira status names. ActorUserID authors comments pulled from Jira.
+type JiraIntegration struct {
+	OrganizationID uuid.UUID               `db:"organization_id" json:"organization_id"`
+	BaseURL        string                  `db:"base_url" json:"base_url"`
+	Email          string                  `db:"email" json:"email"`
+	APIToken       string                  `db:"api_token" json:"-"`
+	ProjectKey     string                  `db:"project_key" json:"project_key"`
+	IssueType      string                  `db:"issue_type" json:"issue_type"`
+	FieldMapping   map[string]string       `db:"field_mapping" json:"field_mapping"`
+	StatusMapping  map[TicketStatus]string `db:"status_mapping" json:"status_mapping"`
+	ActorUserID    uuid.UUID               `db:"actor_user_id" json:"actor_user_id"`
+	IsActive       bool                    `db:"is_active" json:"is_active"`
+	LastPulledAt   *time.Time              `db:"last_pulled_at" json:"last_pulled_at,omitempty"`
+	UpdatedBy      *uuid.UUID              `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt      time.Time               `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time               `db:"updated_at" json:"updated_at"`
+}
+
+// TicketStatusFor returns the ticket status mapped to a Jira status name
+func (j *JiraIntegration) TicketStatusFor(jiraStatus string) (TicketStatus, bool) {
+	for status, name := range j.StatusMapping {
+		if strings.EqualFold(name, jiraStatus) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// UpdateJiraIntegrationInput represents input for configuring the Jira integration
+type UpdateJiraIntegrationInput struct {
+	BaseURL       *string                 `json:"base_url,omitempty"`
+	Email         *string                 `json:"email,omitempty"`
+	APIToken      *string                 `json:"api_token,omitempty"`
+	ProjectKey    *string                 `json:"project_key,omitempty"`
+	IssueType     *string                 `json:"issue_type,omitempty"`
+	FieldMapping  map[string]string       `json:"field_mapping,omitempty"`
+	StatusMapping map[TicketStatus]string `json:"status_mapping,omitempty"`
+	ActorUserID   *uuid.UUID              `json:"actor_user_id,omitempty"`
+	IsActive      *bool                   `json:"is_active,omitempty"`
+}
+
+// Validate checks the Jira integration input
+func (i *UpdateJiraIntegrationInput) Validate() error {
+	if i.BaseURL != nil {
+		base := strings.TrimRight(strings.TrimSpace(*i.BaseURL), "/")
+		u, err := url.Parse(base)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return &ValidationError{Field: "base_url", Message: "base_url must be an https URL"}
+		}
+		i.BaseURL = &base
+	}
+	if i.Email != nil && !strings.Contains(*i.Email, "@") {
+		return &ValidationError{Field: "email", Message: "email must be a valid email address"}
+	}
+	if i.APIToken != nil && strings.TrimSpace(*i.APIToken) == "" {
+		return &ValidationError{Field: "api_token", Message: "api_token is required"}
+	}
+	if i.ProjectKey != nil {
+		key := strings.ToUpper(strings.TrimSpace(*i.ProjectKey))
+		if key == "" {
+			return &ValidationError{Field: "project_key", Message: "project_key is required"}
+		}
+		i.ProjectKey = &key
+	}
+	for field, target := range i.FieldMapping {
+		if !JiraSyncFields[field] {
+			return &ValidationError{Field: "field_mapping", Message: "unsupported ticket field: " + field}
+		}
+		if strings.TrimSpace(target) == "" {
+			return &ValidationError{Field: "field_mapping", Message: "missing Jira field for " + field}
+		}
+	}
+	for status, name := range i.StatusMapping {
+		if !status.Valid() {
+			return &ValidationError{Field: "status_mapping", Message: "unknown ticket status: " + string(status)}
+		}
+		if strings.TrimSpace(name) == "" {
+			return &ValidationError{Field: "status_mapping", Message: "missing Jira status for " + string(status)}
+		}
+	}
+	if i.ActorUserID != nil && *i.ActorUserID == uuid.Nil {
+		return &ValidationError{Field: "actor_user_id", Message: "actor_user_id is required"}
+	}
+	return nil
+}
+
+// JiraSyncState tracks a ticket's mirrored Jira issue. PushedVersion is the
+// ticket version last written to Jira, so changes pulled from Jira are not
+// pushed straight back.
+type JiraSyncState struct {
+	TicketID       uuid.UUID    `db:"ticket_id" json:"ticket_id"`
+	OrganizationID uuid.UUID    `db:"organization_id" json:"organization_id"`
+	IssueID        *string      `db:"issue_id" json:"issue_id,omitempty"`
+	IssueKey       *string      `db:"issue_key" json:"issue_key,omitempty"`
+	PushedVersion  int          `db:"pushed_version" json:"pushed_version"`
+	PushedStatus   TicketStatus `db:"pushed_status" json:"pushed_status,omitempty"`
+	JiraStatus     *string      `db:"jira_status" json:"jira_status,omitempty"`
+	LastSyncedAt   *time.Time   `db:"last_synced_at" json:"last_synced_at,omitempty"`
+	LastError      *string      `db:"last_error" json:"last_error,omitempty"`
+	ErrorCount     int          `db:"error_count" json:"error_count"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+// JiraPullTransitionAllowed returns true if a status change made in Jira may
+// be applied to the ticket. Only moves that do not skip approval are
+// applied; the rest are reported on the ticket as comments.
+func JiraPullTransitionAllowed(from, to TicketStatus) bool {
+	switch to {
+	case TicketStatusImplementing:
+		return from == TicketStatusApproved
+	case TicketStatusCompleted:
+		return from == TicketStatusImplementing
+	case TicketStatusCancelled:
+		return from == TicketStatusDraft || from == TicketStatusSubmitted
+	}
+	return false
+}
+
+// JiraSyncResult summarizes a sync run for one organization
+type JiraSyncResult struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	IssuesCreated  int       `json:"issues_created"`
+	IssuesUpdated  int       `json:"issues_updated"`
+	Transitions    int       `json:"transitions"`
+	StatusesPulled int       `json:"statuses_pulled"`
+	CommentsPulled int       `json:"comments_pulled"`
+	Errors         int       `json:"errors"`
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxLabelNameLength bounds label names
+const MaxLabelNameLength = 64
+
+// DefaultLabelColor is used for labels created without a color
+const DefaultLabelColor = "#6b7280"
+
+// Label is an organization-defined label tickets can carry
+type Label struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	Name           string     `db:"name" json:"name"`
+	Color          string     `db:"color" json:"color"`
+	Description    *string    `db:"description" json:"description,omitempty"`
+	CreatedBy      *uuid.UUID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// LabelUsage reports how many tickets carry a label. Labels in use on
+// tickets but no longer defined are reported with Defined false.
+type LabelUsage struct {
+	Name            string     `json:"name"`
+	LabelID         *uuid.UUID `json:"label_id,omitempty"`
+	Color           *string    `json:"color,omitempty"`
+	Defined         bool       `json:"defined"`
+	TicketCount     int        `json:"ticket_count"`
+	OpenTicketCount int        `json:"open_ticket_count"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"` // Most recent update of a ticket carrying it
+}
+
+// UnknownLabels returns the labels that are not defined and not already on
+// the ticket, so labels added before the organization defined any, or by
+// imports, survive edits. Organizations without labels accept any.
+func UnknownLabels(defined []Label, labels, current []string) []string {
+	if len(defined) == 0 {
+		return nil
+	}
+	known := make(map[string]bool, len(defined)+len(current))
+	for _, l := range defined {
+		known[l.Name] = true
+	}
+	for _, l := range current {
+		known[l] = true
+	}
+
+	var unknown []string
+	for _, l := range labels {
+		if !known[l] {
+			unknown = append(unknown, l)
+			known[l] = true
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// CreateLabelInput represents input for defining a label
+type CreateLabelInput struct {
+	Name        string  `json:"name" validate:"required,max=64"`
+	Color       string  `json:"color,omitempty"` // #rrggbb
+	Description *string `json:"description,omitempty"`
+}
+
+// Validate checks the label and normalizes its name and color
+func (i *CreateLabelInput) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	if err := validateLabelName(i.Name); err != nil {
+		return err
+	}
+	if i.Color == "" {
+		i.Color = DefaultLabelColor
+	}
+	color, err := normalizeLabelColor(i.Color)
+	if err != nil {
+		return err
+	}
+	i.Color = color
+	return nil
+}
+
+// UpdateLabelInput represents input for updating a label. Renaming a label
+// renames it on every ticket that carries it.
+type UpdateLabelInput struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,max=64"`
+	Color       *string `json:"color,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// Validate checks the update and normalizes its name and color
+func (i *UpdateLabelInput) Validate() error {
+	if i.Name != nil {
+		name := strings.TrimSpace(*i.Name)
+		if err := validateLabelName(name); err != nil {
+			return err
+		}
+		i.Name = &name
+	}
+	if i.Color != nil {
+		color, err := normalizeLabelColor(*i.Color)
+		if err != nil {
+			return err
+		}
+		i.Color = &color
+	}
+	return nil
+}
+
+// Label names are listed comma-separated in ticket filters, so they can't
+// contain commas
+func validateLabelName(name string) error {
+	if name == "" {
+		return &ValidationError{Field: "name", Message: "name is required"}
+	}
+	if len(name) > MaxLabelNameLength {
+		return &ValidationError{Field: "name", Message: "name must be at most 64 characters"}
+	}
+	if strings.Contains(name, ",") {
+		return &ValidationError{Field: "name", Message: "name must not contain commas"}
+	}
+	return nil
+}
+
+func normalizeLabelColor(color string) (string, error) {
+	if !labelColorPattern.MatchString(color) {
+		return "", &ValidationError{Field: "color", Message: "color must be a hex color like #1f6feb"}
+	}
+	return strings.ToLower(color), nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Audit actions for MFA beyond enabling and disabling it
+const (
+	AuditActionMFARequire     = "mfa_require"
+	AuditActionMFABackupCodes = "mfa_backup_codes"
+	AuditActionMFAStepUp      = "mfa_step_up"
+	AuditActionMFALockout     = "mfa_lockout"
+)
+
+// BackupCodeCount is how many recovery codes are issued at a time
+const BackupCodeCount = 10
+
+// MaxMFAAttempts is how many wrong codes a pending MFA login allows before
+// the user has to sign in again
+const MaxMFAAttempts = 5
+
+// MFALockout is how long a signed-in user can't step up or manage their
+// MFA after MaxMFAAttempts wrong codes in a row
+const MFALockout = 15 * time.Minute
+
+// StepUpMaxAge is how recently a user must have completed MFA to perform
+// sensitive actions such as approving critical-risk changes
+const StepUpMaxAge = 10 * time.Minute
+
+// MFAStatus describes a user's MFA enrollment
+type MFAStatus struct {
+	Enabled              bool `json:"enabled"`
+	Required             bool `json:"required"` // By org policy or by an admin
+	RequiredByAdmin      bool `json:"required_by_admin"`
+	BackupCodesRemaining int  `json:"backup_codes_remaining"`
+	Passkeys             int  `json:"passkeys"`
+}
+
+// MFACodeInput carries a TOTP code or a backup code
+type MFACodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// Validate checks the code input
+func (i *MFACodeInput) Validate() error {
+	i.Code = strings.TrimSpace(i.Code)
+	if i.Code == "" {
+		return &ValidationError{Field: "code", Message: "code is required"}
+	}
+	return nil
+}
+
+// LoginMFAInput completes a password login for a user with MFA enabled
+type LoginMFAInput struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// Validate checks the MFA login input
+func (i *LoginMFAInput) Validate() error {
+	i.Code = strings.TrimSpace(i.Code)
+	if i.MFAToken == "" {
+		return &ValidationError{Field: "mfa_token", Message: "mfa_token is required"}
+	}
+	if i.Code == "" {
+		return &ValidationError{Field: "code", Message: "code is required"}
+	}
+	return nil
+}
+
+// ApprovalRequiresStepUp returns true if approving the ticket needs a
+// recent MFA verification
+func (t *Ticket) ApprovalRequiresStepUp() bool {
+	return t.RiskLevel == RiskLevelCritical
+}
+
+// MFARoleCovered returns true if an MFA requirement for requiredRoles
+// applies to a user with roles. An empty list covers every user.
+func MFARoleCovered(requiredRoles []UserRole, roles []string) bool {
+	if len(requiredRoles) == 0 {
+		return true
+	}
+	for _, required := range requiredRoles {
+		for _, r := range roles {
+			if r == string(required) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrganizationPolicy is the org-configurable policy kept in the
+// organization's settings document, as opposed to its own columns
+type OrganizationPolicy struct {
+	SLA           SLAPolicy      `json:"sla"`
+	FreezeWindows []FreezeWindow `json:"freeze_windows"`
+	Approvals     ApprovalPolicy `json:"approvals"`
+}
+
+// SLAPolicy overrides how long tickets of each priority may wait for
+// approval. Priorities left out keep their default window.
+type SLAPolicy struct {
+	ApprovalMinutes map[TicketPriority]int `json:"approval_minutes,omitempty"`
+}
+
+// FreezeWindow is a period in which changes shouldn't be implemented, such
+// as a year-end code freeze
+type FreezeWindow struct {
+	Name           string    `json:"name"`
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	AllowEmergency bool      `json:"allow_emergency"` // Emergency changes may still go ahead
+}
+
+// ApprovalPolicy holds the organization's defaults for approvals
+type ApprovalPolicy struct {
+	// Approvals needed per approval type when no approval rule matches.
+	// Zero means one.
+	DefaultMinApprovals int `json:"default_min_approvals,omitempty"`
+}
+
+// maxFreezeWindows bounds the freeze windows an organization can keep
+const maxFreezeWindows = 100
+
+// Validate checks the SLA windows
+func (p *SLAPolicy) Validate() error {
+	for priority, minutes := range p.ApprovalMinutes {
+		if !priority.Valid() {
+			return &ValidationError{Field: "sla.approval_minutes", Message: "unknown priority: " + string(priority)}
+		}
+		if minutes <= 0 {
+			return &ValidationError{Field: "sla.approval_minutes", Message: fmt.Sprintf("window for %s must be at least a minute", priority)}
+		}
+	}
+	return nil
+}
+
+// Validate checks the approval defaults
+func (p *ApprovalPolicy) Validate() error {
+	if p.DefaultMinApprovals < 0 || p.DefaultMinApprovals > 10 {
+		return &ValidationError{Field: "approvals.default_min_approvals", Message: "must be between 0 and 10"}
+	}
+	return nil
+}
+
+// ApplyDefaults raises the quorum of a plan no approval rule matched to
+// the default. A higher quorum, such as the one SOX demands, is kept.
+func (p *ApprovalPolicy) ApplyDefaults(plan *ApprovalPlan) {
+	if len(plan.MatchedRuleIDs) > 0 || p.DefaultMinApprovals <= 1 {
+		return
+	}
+	for i := range plan.Requirements {
+		plan.Requirements[i].MinApprovals = max(plan.Requirements[i].MinApprovals, p.DefaultMinApprovals)
+	}
+}
+
+// validateFreezeWindows checks the windows, trimming their names
+func validateFreezeWindows(windows []FreezeWindow) error {
+	if len(windows) > maxFreezeWindows {
+		return &ValidationError{Field: "freeze_windows", Message: fmt.Sprintf("at most %d freeze windows are allowed", maxFreezeWindows)}
+	}
+	for i := range windows {
+		w := &windows[i]
+		w.Name = strings.TrimSpace(w.Name)
+		if w.Name == "" {
+			return &ValidationError{Field: "freeze_windows", Message: "every freeze window needs a name"}
+		}
+		if !w.EndsAt.After(w.StartsAt) {
+			return &ValidationError{Field: "freeze_windows", Message: fmt.Sprintf("freeze window %q must end after it starts", w.Name)}
+		}
+	}
+	return nil
+}
+
+// FreezeDuring returns the first freeze window overlapping start to end
+// that holds a change back, or nil. Emergency changes are only held back
+// by windows that don't allow them.
+func (p *OrganizationPolicy) FreezeDuring(start, end time.Time, emergency bool) *FreezeWindow {
+	for i := range p.FreezeWindows {
+		w := &p.FreezeWindows[i]
+		if emergency && w.AllowEmergency {
+			continue
+		}
+		if start.Before(w.EndsAt) && end.After(w.StartsAt) {
+			return w
+		}
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"encoding/json"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,24 +12,28 @@ import (
 
 // Organization represents a tenant in the multi-tenant system
 type Organization struct {
-	ID                        uuid.UUID             `db:"id" json:"id"`
-	Name                      string                `db:"name" json:"name"`
-	Slug                      string                `db:"slug" json:"slug"`
-	Industry                  IndustryType          `db:"industry" json:"industry"`
-	ComplianceFrameworks      []ComplianceFramework `db:"compliance_frameworks" json:"compliance_frameworks"`
-	CustomComplianceSpec      json.RawMessage       `db:"custom_compliance_spec" json:"custom_compliance_spec,omitempty"`
-	PrimaryRegion             string                `db:"primary_region" json:"primary_region"`
-	DataResidencyRequirements json.RawMessage       `db:"data_residency_requirements" json:"data_residency_requirements,omitempty"`
-	RequireMFA                bool                  `db:"require_mfa" json:"require_mfa"`
-	SessionTimeoutMinutes     int                   `db:"session_timeout_minutes" json:"session_timeout_minutes"`
-	PasswordPolicy            json.RawMessage       `db:"password_policy" json:"password_policy,omitempty"`
-	AdminEmail                string                `db:"admin_email" json:"admin_email"`
-	SupportEmail              string                `db:"support_email" json:"support_email,omitempty"`
-	CreatedAt                 time.Time             `db:"created_at" json:"created_at"`
-	UpdatedAt                 time.Time             `db:"updated_at" json:"updated_at"`
-	DeletedAt                 *time.Time            `db:"deleted_at" json:"deleted_at,omitempty"`
-	CreatedBy                 *uuid.UUID            `db:"created_by" json:"created_by,omitempty"`
-	UpdatedBy                 *uuid.UUID            `db:"updated_by" json:"updated_by,omitempty"`
+	ID                          uuid.UUID             `db:"id" json:"id"`
+	Name                        string                `db:"name" json:"name"`
+	Slug                        string                `db:"slug" json:"slug"`
+	Industry                    IndustryType          `db:"industry" json:"industry"`
+	ComplianceFrameworks        []ComplianceFramework `db:"compliance_frameworks" json:"compliance_frameworks"`
+	CustomComplianceSpec        json.RawMessage       `db:"custom_compliance_spec" json:"custom_compliance_spec,omitempty"`
+	PrimaryRegion               string                `db:"primary_region" json:"primary_region"`
+	DataResidencyRequirements   json.RawMessage       `db:"data_residency_requirements" json:"data_residency_requirements,omitempty"`
+	RequireMFA                  bool                  `db:"require_mfa" json:"require_mfa"`
+	MFARequiredRoles            []UserRole            `db:"mfa_required_roles" json:"mfa_required_roles"` // Empty applies require_mfa to everyone
+	SessionTimeoutMinutes       int                   `db:"session_timeout_minutes" json:"session_timeout_minutes"`
+	PasswordPolicy              json.RawMessage       `db:"password_policy" json:"password_policy,omitempty"`
+	AdminEmail                  string                `db:"admin_email" json:"admin_email"`
+	SupportEmail                string                `db:"support_email" json:"support_email,omitempty"`
+	TicketNumberPrefix          string                `db:"ticket_number_prefix" json:"ticket_number_prefix"`
+	DefaultComplianceFrameworks []ComplianceFramework `db:"default_compliance_frameworks" json:"default_compliance_frameworks"`
+	Policy                      OrganizationPolicy    `db:"settings" json:"-"` // Served through Settings
+	CreatedAt                   time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt                   time.Time             `db:"updated_at" json:"updated_at"`
+	DeletedAt                   *time.Time            `db:"deleted_at" json:"deleted_at,omitempty"`
+	CreatedBy                   *uuid.UUID            `db:"created_by" json:"created_by,omitempty"`
+	UpdatedBy                   *uuid.UUID            `db:"updated_by" json:"updated_by,omitempty"`
 }
 
 // PasswordPolicyConfig represents password policy settings
@@ -56,11 +63,11 @@ func DefaultPasswordPolicy() PasswordPolicyConfig {
 // CreateOrganizationInput represents input for creating an organization
 type CreateOrganizationInput struct {
 	Name                 string                `json:"name" validate:"required,min=2,max=255"`
-	Slug                 string                `json:"slug" validate:"required,min=2,max=100,alphanum"`
+	Slug                 string                `json:"slug" validate:"required,min=3,max=100"`
 	Industry             IndustryType          `json:"industry" validate:"required"`
 	ComplianceFrameworks []ComplianceFramework `json:"compliance_frameworks" validate:"required,min=1,dive"`
 	CustomComplianceSpec json.RawMessage       `json:"custom_compliance_spec,omitempty"`
-	PrimaryRegion        string                `json:"primary_region" validate:"required"`
+	PrimaryRegion        string                `json:"primary_region,omitempty"` // Defaults to us-east-1
 	RequireMFA           bool                  `json:"require_mfa"`
 	AdminEmail           string                `json:"admin_email" validate:"required,email"`
 	SupportEmail         string                `json:"support_email,omitempty" validate:"omitempty,email"`
@@ -68,12 +75,248 @@ type CreateOrganizationInput struct {
 
 // UpdateOrganizationInput represents input for updating an organization
 type UpdateOrganizationInput struct {
-	Name                 *string                `json:"name,omitempty" validate:"omitempty,min=2,max=255"`
-	Industry             *IndustryType          `json:"industry,omitempty"`
-	ComplianceFrameworks []ComplianceFramework  `json:"compliance_frameworks,omitempty" validate:"omitempty,min=1,dive"`
-	CustomComplianceSpec json.RawMessage        `json:"custom_compliance_spec,omitempty"`
-	RequireMFA           *bool                  `json:"require_mfa,omitempty"`
+	Name                  *string               `json:"name,omitempty" validate:"omitempty,min=2,max=255"`
+	Industry              *IndustryType         `json:"industry,omitempty"`
+	ComplianceFrameworks  []ComplianceFramework `json:"compliance_frameworks,omitempty" validate:"omitempty,min=1,dive"`
+	CustomComplianceSpec  json.RawMessage       `json:"custom_compliance_spec,omitempty"`
+	RequireMFA            *bool                 `json:"require_mfa,omitempty"`
+	MFARequiredRoles      *[]UserRole           `json:"mfa_required_roles,omitempty"`
 	SessionTimeoutMinutes *int                  `json:"session_timeout_minutes,omitempty" validate:"omitempty,min=5,max=1440"`
-	PasswordPolicy       *PasswordPolicyConfig  `json:"password_policy,omitempty"`
-	SupportEmail         *string                `json:"support_email,omitempty" validate:"omitempty,email"`
+	PasswordPolicy        *PasswordPolicyConfig `json:"password_policy,omitempty"`
+	SupportEmail          *string               `json:"support_email,omitempty" validate:"omitempty,email"`
+}
+
+// DefaultTicketNumberPrefix is used for organizations that have not chosen one
+const DefaultTicketNumberPrefix = "CHG"
+
+// EmailVerificationTTL is how long a signup verification link stays valid
+const EmailVerificationTTL = 48 * time.Hour
+
+var (
+	slugPattern         = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,98}[a-z0-9]$`)
+	ticketPrefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+)
+
+// ValidSlug returns true if the slug can be used in URLs
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// ValidTicketNumberPrefix returns true if the prefix can be used for ticket numbers
+func ValidTicketNumberPrefix(prefix string) bool {
+	return ticketPrefixPattern.MatchString(prefix)
+}
+
+func validateFrameworks(field string, frameworks []ComplianceFramework) error {
+	for _, f := range frameworks {
+		if !f.Valid() {
+			return &ValidationError{Field: field, Message: "unknown compliance framework: " + string(f)}
+		}
+	}
+	return nil
+}
+
+// Validate checks the organization input
+func (i *CreateOrganizationInput) Validate() error {
+	i.Slug = strings.ToLower(strings.TrimSpace(i.Slug))
+	if len(strings.TrimSpace(i.Name)) < 2 {
+		return &ValidationError{Field: "name", Message: "name must be at least 2 characters"}
+	}
+	if !ValidSlug(i.Slug) {
+		return &ValidationError{Field: "slug", Message: "slug must be 3-100 lowercase letters, digits or hyphens"}
+	}
+	if !i.Industry.Valid() {
+		return &ValidationError{Field: "industry", Message: "unknown industry"}
+	}
+	if err := validateFrameworks("compliance_frameworks", i.ComplianceFrameworks); err != nil {
+		return err
+	}
+	if _, err := mail.ParseAddress(i.AdminEmail); err != nil {
+		return &ValidationError{Field: "admin_email", Message: "admin_email must be a valid email address"}
+	}
+	if i.PrimaryRegion == "" {
+		i.PrimaryRegion = "us-east-1"
+	}
+	return nil
+}
+
+// Validate checks the organization update input
+func (i *UpdateOrganizationInput) Validate() error {
+	if i.Name != nil && len(strings.TrimSpace(*i.Name)) < 2 {
+		return &ValidationError{Field: "name", Message: "name must be at least 2 characters"}
+	}
+	if i.Industry != nil && !i.Industry.Valid() {
+		return &ValidationError{Field: "industry", Message: "unknown industry"}
+	}
+	if i.SessionTimeoutMinutes != nil && (*i.SessionTimeoutMinutes < 5 || *i.SessionTimeoutMinutes > 1440) {
+		return &ValidationError{Field: "session_timeout_minutes", Message: "must be between 5 and 1440"}
+	}
+	if i.MFARequiredRoles != nil {
+		for _, r := range *i.MFARequiredRoles {
+			if !r.Valid() {
+				return &ValidationError{Field: "mfa_required_roles", Message: "unknown role: " + string(r)}
+			}
+		}
+	}
+	return validateFrameworks("compliance_frameworks", i.ComplianceFrameworks)
+}
+
+// OrganizationSettings are the per-org defaults applied to new tickets and
+// the organization's configurable policy
+type OrganizationSettings struct {
+	TicketNumberPrefix          string                `json:"ticket_number_prefix"`
+	DefaultComplianceFrameworks []ComplianceFramework `json:"default_compliance_frameworks"`
+	Industry                    IndustryType          `json:"industry"`
+	OrganizationPolicy
+}
+
+// Settings returns the organization's ticket settings and policy
+func (o *Organization) Settings() OrganizationSettings {
+	policy := o.Policy
+	if policy.FreezeWindows == nil {
+		policy.FreezeWindows = []FreezeWindow{}
+	}
+	return OrganizationSettings{
+		TicketNumberPrefix:          o.TicketNumberPrefix,
+		DefaultComplianceFrameworks: o.DefaultComplianceFrameworks,
+		Industry:                    o.Industry,
+		OrganizationPolicy:          policy,
+	}
+}
+
+// UpdateOrganizationSettingsInput represents input for updating org settings.
+// The SLA and approval policies are replaced whole; an empty freeze_windows
+// list removes them all.
+type UpdateOrganizationSettingsInput struct {
+	TicketNumberPrefix          *string               `json:"ticket_number_prefix,omitempty"`
+	DefaultComplianceFrameworks []ComplianceFramework `json:"default_compliance_frameworks,omitempty"`
+	SLA                         *SLAPolicy            `json:"sla,omitempty"`
+	FreezeWindows               []FreezeWindow        `json:"freeze_windows,omitempty"`
+	Approvals                   *ApprovalPolicy       `json:"approvals,omitempty"`
+}
+
+// Validate checks the settings input
+func (i *UpdateOrganizationSettingsInput) Validate() error {
+	if i.TicketNumberPrefix != nil {
+		prefix := strings.ToUpper(strings.TrimSpace(*i.TicketNumberPrefix))
+		if !ValidTicketNumberPrefix(prefix) {
+			return &ValidationError{Field: "ticket_number_prefix", Message: "prefix must be 2-10 uppercase letters or digits, starting with a letter"}
+		}
+		i.TicketNumberPrefix = &prefix
+	}
+	if i.SLA != nil {
+		if err := i.SLA.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := validateFreezeWindows(i.FreezeWindows); err != nil {
+		return err
+	}
+	if i.Approvals != nil {
+		if err := i.Approvals.Validate(); err != nil {
+			return err
+		}
+	}
+	return validateFrameworks("default_compliance_frameworks", i.DefaultComplianceFrameworks)
+}
+
+// OrganizationListFilter represents filter options for listing organizations
+type OrganizationListFilter struct {
+	Search         *string `json:"search,omitempty"`
+	IncludeDeleted bool    `json:"include_deleted,omitempty"`
+	Page           int     `json:"page" validate:"min=1"`
+	PerPage        int     `json:"per_page" validate:"min=1,max=100"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *OrganizationListFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the SQL offset for pagination
+func (f *OrganizationListFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
+// SignupInput represents a self-service signup creating an organization and its first admin
+type SignupInput struct {
+	OrganizationName     string                `json:"organization_name" validate:"required,min=2,max=255"`
+	Slug                 string                `json:"slug" validate:"required"`
+	Industry             IndustryType          `json:"industry" validate:"required"`
+	ComplianceFrameworks []ComplianceFramework `json:"compliance_frameworks,omitempty" validate:"omitempty,dive"`
+	PrimaryRegion        string                `json:"primary_region,omitempty"`
+	FullName             string                `json:"full_name" validate:"required,min=2,max=255"`
+	Email                string                `json:"email" validate:"required,email"`
+	Password             string                `json:"password" validate:"required,min=12"`
+	AcceptTermsVersion   string                `json:"accept_terms_version" validate:"required"`
+}
+
+// Validate checks the signup input against the default password policy
+func (i *SignupInput) Validate() error {
+	org := CreateOrganizationInput{
+		Name:                 i.OrganizationName,
+		Slug:                 i.Slug,
+		Industry:             i.Industry,
+		ComplianceFrameworks: i.ComplianceFrameworks,
+		PrimaryRegion:        i.PrimaryRegion,
+		AdminEmail:           i.Email,
+	}
+	if err := org.Validate(); err != nil {
+		return err
+	}
+	i.Slug = org.Slug
+	i.PrimaryRegion = org.PrimaryRegion
+	i.Email = strings.ToLower(strings.TrimSpace(i.Email))
+
+	if len(strings.TrimSpace(i.FullName)) < 2 {
+		return &ValidationError{Field: "full_name", Message: "full_name must be at least 2 characters"}
+	}
+	if i.AcceptTermsVersion == "" {
+		return &ValidationError{Field: "accept_terms_version", Message: "terms must be accepted"}
+	}
+	if msg := checkPassword(i.Password, DefaultPasswordPolicy()); msg != "" {
+		return &ValidationError{Field: "password", Message: msg}
+	}
+	return nil
+}
+
+// checkPassword returns a description of the first policy rule the password breaks
+func checkPassword(password string, policy PasswordPolicyConfig) string {
+	if len(password) < policy.MinLength {
+		return "password is too short"
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	switch {
+	case policy.RequireUppercase && !upper:
+		return "password must contain an uppercase letter"
+	case policy.RequireLowercase && !lower:
+		return "password must contain a lowercase letter"
+	case policy.RequireNumbers && !digit:
+		return "password must contain a digit"
+	case policy.RequireSymbols && !symbol:
+		return "password must contain a symbol"
+	}
+	return ""
+}
+
+// VerifyEmailInput represents input for confirming a signup email address
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a keyset-paginated list: the sort column value
+// of the last row returned and its ID as a tie-breaker
+type Cursor struct {
+	SortBy string    `json:"s"`
+	Value  string    `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// TimeCursor returns the cursor after a row of a list sorted by a timestamp
+// column, such as created_at
+func TimeCursor(sortBy string, t time.Time, id uuid.UUID) *Cursor {
+	return &Cursor{SortBy: sortBy, Value: t.Format(time.RFC3339Nano), ID: id}
+}
+
+// Time parses the value of a cursor made by TimeCursor
+func (c *Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+	return t, nil
+}
+
+// Encode returns the opaque cursor string handed to clients
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor string produced by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil || c.ID == uuid.Nil {
+		return nil, &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	}
+	return c, nil
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PIRStatus represents the state of a post-implementation review
+type PIRStatus string
+
+const (
+	PIRStatusPending   PIRStatus = "pending"
+	PIRStatusCompleted PIRStatus = "completed"
+	PIRStatusSignedOff PIRStatus = "signed_off"
+)
+
+// PIROutcome represents how a change went once implemented
+type PIROutcome string
+
+const (
+	PIROutcomeSuccess    PIROutcome = "success"
+	PIROutcomeRolledBack PIROutcome = "rolled_back"
+	PIROutcomePartial    PIROutcome = "partial"
+)
+
+// Valid returns true if the outcome is valid
+func (o PIROutcome) Valid() bool {
+	switch o {
+	case PIROutcomeSuccess, PIROutcomeRolledBack, PIROutcomePartial:
+		return true
+	}
+	return false
+}
+
+// PIR required reasons
+const (
+	PIRReasonEmergencyChange = "emergency_change"
+)
+
+// PostImplementationReview represents a review of a change after it was implemented
+type PostImplementationReview struct {
+	ID             uuid.UUID   `db:"id" json:"id"`
+	TicketID       uuid.UUID   `db:"ticket_id" json:"ticket_id"`
+	OrganizationID uuid.UUID   `db:"organization_id" json:"organization_id"`
+	Status         PIRStatus   `db:"status" json:"status"`
+	RequiredReason *string     `db:"required_reason" json:"required_reason,omitempty"`
+	DueAt          *time.Time  `db:"due_at" json:"due_at,omitempty"`
+	Outcome        *PIROutcome `db:"outcome" json:"outcome,omitempty"`
+	Summary        *string     `db:"summary" json:"summary,omitempty"`
+	IncidentLinks  []string    `db:"incident_links" json:"incident_links,omitempty"`
+	LessonsLearned *string     `db:"lessons_learned" json:"lessons_learned,omitempty"`
+	CreatedBy      *uuid.UUID  `db:"created_by" json:"created_by,omitempty"`
+	CompletedAt    *time.Time  `db:"completed_at" json:"completed_at,omitempty"`
+	SignedOffBy    *uuid.UUID  `db:"signed_off_by" json:"signed_off_by,omitempty"`
+	SignedOffAt    *time.Time  `db:"signed_off_at" json:"signed_off_at,omitempty"`
+	SignOffComment *string     `db:"sign_off_comment" json:"sign_off_comment,omitempty"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at" json:"updated_at"`
+
+	// Relationships
+	Ticket *TicketSummary `db:"-" json:"ticket,omitempty"`
+}
+
+// IsOverdue returns true if a pending review has passed its due date
+func (p *PostImplementationReview) IsOverdue() bool {
+	return p.Status == PIRStatusPending && p.DueAt != nil && time.Now().After(*p.DueAt)
+}
+
+// CanEdit returns true if the review can still be changed
+func (p *PostImplementationReview) CanEdit() bool {
+	return p.Status != PIRStatusSignedOff
+}
+
+// CanSignOff returns true if the review is ready for reviewer sign-off
+func (p *PostImplementationReview) CanSignOff() bool {
+	return p.Status == PIRStatusCompleted
+}
+
+// SubmitPIRInput represents input for recording a post-implementation review
+type SubmitPIRInput struct {
+	Outcome        PIROutcome `json:"outcome" validate:"required"`
+	Summary        *string    `json:"summary,omitempty"`
+	IncidentLinks  []string   `json:"incident_links,omitempty" validate:"dive,url"`
+	LessonsLearned *string    `json:"lessons_learned,omitempty"`
+}
+
+// Validate checks the review input
+func (i *SubmitPIRInput) Validate() error {
+	if !i.Outcome.Valid() {
+		return &ValidationError{Field: "outcome", Message: "outcome must be one of success, rolled_back, partial"}
+	}
+	// Anything other than a clean success needs an explanation
+	if i.Outcome != PIROutcomeSuccess && (i.LessonsLearned == nil || *i.LessonsLearned == "") {
+		return &ValidationError{Field: "lessons_learned", Message: "lessons_learned is required when the change was not fully successful"}
+	}
+	return nil
+}
+
+// SignOffPIRInput represents a reviewer's sign-off on a review
+type SignOffPIRInput struct {
+	Comment *string `json:"comment,omitempty"`
+}
+
+// PIRListFilter represents filter options for listing reviews
+type PIRListFilter struct {
+	Status      []PIRStatus `json:"status,omitempty"`
+	OverdueOnly bool        `json:"overdue_only,omitempty"`
+	Page        int         `json:"page" validate:"min=1"`
+	PerPage     int         `json:"per_page" validate:"min=1,max=100"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *PIRListFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the SQL offset for pagination
+func (f *PIRListFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
+// MissingPIREntry is a closed change that has no completed review
+type MissingPIREntry struct {
+	TicketID     uuid.UUID      `db:"ticket_id" json:"ticket_id"`
+	TicketNumber string         `db:"ticket_number" json:"ticket_number"`
+	Title        string         `db:"title" json:"title"`
+	Priority     TicketPriority `db:"priority" json:"priority"`
+	RiskLevel    RiskLevel      `db:"risk_level" json:"risk_level"`
+	IsEmergency  bool           `db:"is_emergency" json:"is_emergency"`
+	CreatedBy    uuid.UUID      `db:"created_by" json:"created_by"`
+	ClosedAt     *time.Time     `db:"closed_at" json:"closed_at,omitempty"`
+	PIRID        *uuid.UUID     `db:"pir_id" json:"pir_id,omitempty"`
+	PIRStatus    *PIRStatus     `db:"pir_status" json:"pir_status,omitempty"`
+	PIRDueAt     *time.Time     `db:"pir_due_at" json:"pir_due_at,omitempty"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PortalTicket is the customer-facing view of a ticket. It leaves out
+// internal fields such as risk, compliance, approvals, plans, assignees,
+// custom fields and time tracking.
+type PortalTicket struct {
+	ID                uuid.UUID      `json:"id"`
+	TicketNumber      string         `json:"ticket_number"`
+	Title             string         `json:"title"`
+	Description       string         `json:"description"`
+	Status            TicketStatus   `json:"status"`
+	Priority          TicketPriority `json:"priority"`
+	ChangeType        *string        `json:"change_type,omitempty"`
+	ImpactDescription *string        `json:"impact_description,omitempty"`
+	IsEmergency       bool           `json:"is_emergency"`
+	ScheduledStart    *time.Time     `json:"scheduled_start,omitempty"`
+	ScheduledEnd      *time.Time     `json:"scheduled_end,omitempty"`
+	ActualStart       *time.Time     `json:"actual_start,omitempty"`
+	ActualEnd         *time.Time     `json:"actual_end,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	ClosedAt          *time.Time     `json:"closed_at,omitempty"`
+}
+
+// NewPortalTicket returns the customer-facing view of a ticket
+func NewPortalTicket(t *Ticket) PortalTicket {
+	return PortalTicket{
+		ID:                t.ID,
+		TicketNumber:      t.TicketNumber,
+		Title:             t.Title,
+		Description:       t.Description,
+		Status:            t.Status,
+		Priority:          t.Priority,
+		ChangeType:        t.ChangeType,
+		ImpactDescription: t.ImpactDescription,
+		IsEmergency:       t.IsEmergency,
+		ScheduledStart:    t.ScheduledStart,
+		ScheduledEnd:      t.ScheduledEnd,
+		ActualStart:       t.ActualStart,
+		ActualEnd:         t.ActualEnd,
+		CreatedAt:         t.CreatedAt,
+		UpdatedAt:         t.UpdatedAt,
+		ClosedAt:          t.ClosedAt,
+	}
+}
+
+// PortalTicketFilter narrows a customer's ticket listing
+type PortalTicketFilter struct {
+	Status  []TicketStatus `json:"status,omitempty"`
+	Search  string         `json:"search,omitempty"`
+	Page    int            `json:"page,omitempty"`
+	PerPage int            `json:"per_page,omitempty"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *PortalTicketFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// PortalComment is a public ticket comment as customers see it. Staff are
+// shown by name only.
+type PortalComment struct {
+	ID           uuid.UUID `json:"id"`
+	Author       string    `json:"author"`
+	FromCustomer bool      `json:"from_customer"`
+	Comment      string    `json:"comment"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreatePortalCommentInput represents a comment posted from the portal
+type CreatePortalCommentInput struct {
+	Comment string `json:"comment" binding:"required"`
+}
+
+// PortalUser is an account a customer uses to sign in to the portal
+type PortalUser struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	CustomerID     uuid.UUID  `db:"customer_id" json:"customer_id"`
+	Email          string     `db:"email" json:"email"`
+	FullName       string     `db:"full_name" json:"full_name"`
+	IsActive       bool       `db:"is_active" json:"is_active"`
+	LastLoginAt    *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// CreatePortalUserInput represents input for giving a customer contact
+// access to the portal
+type CreatePortalUserInput struct {
+	CustomerID uuid.UUID `json:"customer_id" validate:"required"`
+	Email      string    `json:"email" validate:"required,email"`
+	FullName   string    `json:"full_name" validate:"required,min=2,max=255"`
+	Password   string    `json:"password" validate:"required"`
+}
+
+// Validate checks the portal account and normalizes its email
+func (i *CreatePortalUserInput) Validate() error {
+	if i.CustomerID == uuid.Nil {
+		return &ValidationError{Field: "customer_id", Message: "customer_id is required"}
+	}
+	i.Email = strings.ToLower(strings.TrimSpace(i.Email))
+	if _, err := mail.ParseAddress(i.Email); err != nil {
+		return &ValidationError{Field: "email", Message: "email must be a valid email address"}
+	}
+	i.FullName = strings.TrimSpace(i.FullName)
+	if len(i.FullName) < 2 {
+		return &ValidationError{Field: "full_name", Message: "full_name must be at least 2 characters"}
+	}
+	if msg := checkPassword(i.Password, DefaultPasswordPolicy()); msg != "" {
+		return &ValidationError{Field: "password", Message: msg}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultReportDays is the period reports cover when no range is given
+const DefaultReportDays = 90
+
+// MaxReportDays bounds the period a report may cover
+const MaxReportDays = 731
+
+// ReportPeriod is the half-open time range a report covers
+type ReportPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"` // Exclusive
+}
+
+// NewReportPeriod fills in a report's range. Without a to the period ends
+// at the start of tomorrow (UTC), and without a from it starts
+// DefaultReportDays earlier, so repeated requests share a range.
+func NewReportPeriod(from, to *time.Time, now time.Time) (ReportPeriod, error) {
+	p := ReportPeriod{}
+	if to != nil {
+		p.To = to.UTC()
+	} else {
+		p.To = now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if from != nil {
+		p.From = from.UTC()
+	} else {
+		p.From = p.To.AddDate(0, 0, -DefaultReportDays)
+	}
+
+	if !p.From.Before(p.To) {
+		return p, &ValidationError{Field: "from", Message: "from must be before to"}
+	}
+	if p.To.Sub(p.From) > MaxReportDays*24*time.Hour {
+		return p, &ValidationError{Field: "from", Message: "reports can cover at most two years"}
+	}
+	return p, nil
+}
+
+// ChangeMetricsScope narrows change metrics from the whole organization to
+// one team's or one person's changes
+type ChangeMetricsScope struct {
+	GroupID *uuid.UUID // Tickets the group owns
+	UserID  *uuid.UUID // Tickets the user created or is assigned
+}
+
+// ChangeMetrics are an organization's change KPIs over a period. Rates
+// are fractions between 0 and 1, and are omitted when nothing was counted.
+type ChangeMetrics struct {
+	ReportPeriod
+
+	// Set when the metrics cover one team's or one user's changes
+	Team *GroupSummary `json:"team,omitempty"`
+	User *UserSummary  `json:"user,omitempty"`
+
+	// Changes submitted in the period
+	TotalChanges         int      `json:"total_changes"`
+	EmergencyChanges     int      `json:"emergency_changes"`
+	EmergencyChangeRatio *float64 `json:"emergency_change_ratio,omitempty"`
+
+	// Changes completed in the period. A completed change failed if its
+	// post-implementation review found it rolled back or only partly done.
+	CompletedChanges  int      `json:"completed_changes"`
+	FailedChanges     int      `json:"failed_changes"`
+	ChangeSuccessRate *float64 `json:"change_success_rate,omitempty"`
+
+	// Approvals decided in the period, from request to decision
+	DecidedApprovals  int      `json:"decided_approvals"`
+	MeanApprovalHours *float64 `json:"mean_approval_hours,omitempty"`
+
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ComputeRates derives the ratios from the counts
+func (m *ChangeMetrics) ComputeRates() {
+	m.EmergencyChangeRatio = ratio(m.EmergencyChanges, m.TotalChanges)
+	m.ChangeSuccessRate = ratio(m.CompletedChanges-m.FailedChanges, m.CompletedChanges)
+}
+
+// SystemChangeCount is the number of changes submitted against one
+// affected system in one month
+type SystemChangeCount struct {
+	System  string `json:"system"`
+	Month   string `json:"month"` // YYYY-MM
+	Changes int    `json:"changes"`
+}
+
+// SystemChangeReport counts changes per affected system per month
+type SystemChangeReport struct {
+	ReportPeriod
+	Systems     []SystemChangeCount `json:"systems"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// UserActivityReport summarizes what one user did over a period
+type UserActivityReport struct {
+	ReportPeriod
+	User UserSummary `json:"user"`
+
+	TicketsCreated   int `json:"tickets_created"`
+	TicketsSubmitted int `json:"tickets_submitted"`
+	TicketsCompleted int `json:"tickets_completed"` // Assigned to the user and completed in the period
+	OpenAssigned     int `json:"open_assigned"`     // Currently, regardless of the period
+	CommentsPosted   int `json:"comments_posted"`
+
+	ApprovalsApproved int      `json:"approvals_approved"`
+	ApprovalsDenied   int      `json:"approvals_denied"`
+	ApprovalsPending  int      `json:"approvals_pending"` // Currently, regardless of the period
+	MeanApprovalHours *float64 `json:"mean_approval_hours,omitempty"`
+
+	HoursLogged    float64        `json:"hours_logged"`
+	AuditEvents    map[string]int `json:"audit_events"` // By action
+	LastActivityAt *time.Time     `json:"last_activity_at,omitempty"`
+}
+
+// ratio returns n/d rounded to four places, or nil when d is zero
+func ratio(n, d int) *float64 {
+	if d == 0 {
+		return nil
+	}
+	r := math.Round(float64(n)/float64(d)*10000) / 10000
+	return &r
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report types that can be generated in the background
+const (
+	ReportTypeAuditLog      = "audit_log"
+	ReportTypeChangeMetrics = "change_metrics"
+	ReportTypeSystemChanges = "system_changes"
+	ReportTypeUserActivity  = "user_activity"
+)
+
+// ReportTypes lists the report types jobs may request
+var ReportTypes = []string{
+	ReportTypeAuditLog,
+	ReportTypeChangeMetrics,
+	ReportTypeSystemChanges,
+	ReportTypeUserActivity,
+}
+
+// reportTitles names each report type in documents and emails
+var reportTitles = map[string]string{
+	ReportTypeAuditLog:      "Audit log",
+	ReportTypeChangeMetrics: "Change metrics",
+	ReportTypeSystemChanges: "Changes by system",
+	ReportTypeUserActivity:  "User activity",
+}
+
+// ReportFormat constants
+const (
+	ReportFormatCSV = "csv"
+	ReportFormatPDF = "pdf"
+)
+
+// ReportJobStatus constants
+const (
+	ReportJobQueued    = "queued"
+	ReportJobRunning   = "running"
+	ReportJobCompleted = "completed"
+	ReportJobFailed    = "failed"
+	ReportJobExpired   = "expired" // Past retention; the file has been deleted
+)
+
+// Report job limits
+const (
+	ReportJobMaxAttempts = 3
+	// ReportJobLease is how long a claimed job may run before another
+	// worker may claim it again
+	ReportJobLease = 30 * time.Minute
+	// MaxPendingReportJobs caps the queued and running jobs per user
+	MaxPendingReportJobs = 5
+	// MaxReportLinkTTL is the longest a presigned download link can last
+	MaxReportLinkTTL = 7 * 24 * time.Hour
+)
+
+// ReportJobParams are what a report job covers. The period is fixed when
+// the job is created.
+type ReportJobParams struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"` // Exclusive
+
+	// user_activity
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+
+	// audit_log
+	Frameworks     []ComplianceFramework `json:"frameworks,omitempty"`
+	ComplianceOnly bool                  `json:"compliance_only,omitempty"`
+	EmergencyOnly  bool                  `json:"emergency_only,omitempty"`
+}
+
+// Period returns the period the report covers
+func (p *ReportJobParams) Period() ReportPeriod {
+	return ReportPeriod{From: p.From, To: p.To}
+}
+
+// ReportJob is a report requested through the API and rendered by the
+// worker
+type ReportJob struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	OrganizationID uuid.UUID       `db:"organization_id" json:"organization_id"`
+	RequestedBy    uuid.UUID       `db:"requested_by" json:"requested_by"`
+	ReportType     string          `db:"report_type" json:"report_type"`
+	Format         string          `db:"format" json:"format"`
+	Parameters     ReportJobParams `db:"parameters" json:"parameters"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	ErrorMessage   *string         `db:"error_message" json:"error_message,omitempty"`
+	ObjectKey      *string         `db:"object_key" json:"-"`
+	Filename       *string         `db:"filename" json:"filename,omitempty"`
+	ContentType    *string         `db:"content_type" json:"content_type,omitempty"`
+	SizeBytes      *int64          `db:"size_bytes" json:"size_bytes,omitempty"`
+	RowCount       *int            `db:"row_count" json:"row_count,omitempty"`
+	SHA256         *string         `db:"sha256" json:"sha256,omitempty"`
+	StartedAt      *time.Time      `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt    *time.Time      `db:"completed_at" json:"completed_at,omitempty"`
+	ExpiresAt      *time.Time      `db:"expires_at" json:"expires_at,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Title names the job's report type
+func (j *ReportJob) Title() string {
+	if title, ok := reportTitles[j.ReportType]; ok {
+		return title
+	}
+	return j.ReportType
+}
+
+// ReportOutput describes a rendered report file
+type ReportOutput struct {
+	ObjectKey   string
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	RowCount    int
+	SHA256      string
+}
+
+// CreateReportJobInput represents input for requesting a report. Without
+// from and to the report covers the last DefaultReportDays days.
+type CreateReportJobInput struct {
+	ReportType     string                `json:"report_type"`
+	Format         string                `json:"format"`
+	From           *time.Time            `json:"from,omitempty"`
+	To             *time.Time            `json:"to,omitempty"`
+	UserID         *uuid.UUID            `json:"user_id,omitempty"`
+	Frameworks     []ComplianceFramework `json:"frameworks,omitempty"`
+	ComplianceOnly bool                  `json:"compliance_only,omitempty"`
+	EmergencyOnly  bool                  `json:"emergency_only,omitempty"`
+}
+
+// Validate checks the report request
+func (i *CreateReportJobInput) Validate() error {
+	switch i.ReportType {
+	case ReportTypeAuditLog, ReportTypeChangeMetrics, ReportTypeSystemChanges, ReportTypeUserActivity:
+	default:
+		return &ValidationError{Field: "report_type", Message: fmt.Sprintf("report_type must be one of %v", ReportTypes)}
+	}
+	if i.Format == "" {
+		i.Format = ReportFormatCSV
+	}
+	if i.Format != ReportFormatCSV && i.Format != ReportFormatPDF {
+		return &ValidationError{Field: "format", Message: "format must be csv or pdf"}
+	}
+	if i.ReportType == ReportTypeUserActivity && i.UserID == nil {
+		return &ValidationError{Field: "user_id", Message: "user_id is required for user_activity reports"}
+	}
+	for _, f := range i.Frameworks {
+		if !f.Valid() {
+			return &ValidationError{Field: "frameworks", Message: "invalid compliance framework: " + string(f)}
+		}
+	}
+	return nil
+}
+
+// Params resolves the report's period as of now
+func (i *CreateReportJobInput) Params(now time.Time) (ReportJobParams, error) {
+	period, err := NewReportPeriod(i.From, i.To, now)
+	if err != nil {
+		return ReportJobParams{}, err
+	}
+	params := ReportJobParams{From: period.From, To: period.To}
+	switch i.ReportType {
+	case ReportTypeUserActivity:
+		params.UserID = i.UserID
+	case ReportTypeAuditLog:
+		params.Frameworks = i.Frameworks
+		params.ComplianceOnly = i.ComplianceOnly
+		params.EmergencyOnly = i.EmergencyOnly
+	}
+	return params, nil
+}
+
+// ReportJobFilter represents filter options for listing report jobs
+type ReportJobFilter struct {
+	RequestedBy *uuid.UUID
+	Status      string
+	Limit       int
+}
@@ -41,6 +41,7 @@ type Repository struct {
 	Description    *string            `db:"description" json:"description,omitempty"`
 	Language       *string            `db:"language" json:"language,omitempty"`
 	LastSyncedAt   *time.Time         `db:"last_synced_at" json:"last_synced_at,omitempty"`
+	LastSyncError  *string            `db:"last_sync_error" json:"last_sync_error,omitempty"`
 	Metadata       json.RawMessage    `db:"metadata" json:"metadata,omitempty"`
 	CreatedAt      time.Time          `db:"created_at" json:"created_at"`
 	UpdatedAt      time.Time          `db:"updated_at" json:"updated_at"`
@@ -146,6 +147,11 @@ type RepositoryListFilter struct {
 	PerPage      int                 `json:"per_page" validate:"min=1,max=100"`
 	SortBy       string              `json:"sort_by,omitempty"`
 	SortOrder    string              `json:"sort_order,omitempty"`
+
+	// Keyset pagination: when Cursor is set Page is ignored. NextCursor is
+	// filled in by the store when more results follow.
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"-"`
 }
 
 // SetDefaults sets default values for the filter
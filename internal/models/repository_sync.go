@@ -0,0 +1,95 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSyncTokenNotFound is returned when an organization has no sync token
+// for a provider
+var ErrSyncTokenNotFound = NotFound("repository sync token not found")
+
+// Default API endpoints for the hosted providers
+const (
+	DefaultGitHubAPIBaseURL = "https://api.github.com"
+	DefaultGitLabAPIBaseURL = "https://gitlab.com/api/v4"
+)
+
+// Syncable returns true if repository details can be synced from the provider
+func (r RepositoryProvider) Syncable() bool {
+	return r == RepositoryProviderGitHub || r == RepositoryProviderGitLab
+}
+
+// DefaultAPIBaseURL returns the hosted API endpoint for a syncable provider
+func (r RepositoryProvider) DefaultAPIBaseURL() string {
+	switch r {
+	case RepositoryProviderGitHub:
+		return DefaultGitHubAPIBaseURL
+	case RepositoryProviderGitLab:
+		return DefaultGitLabAPIBaseURL
+	}
+	return ""
+}
+
+// RepositorySyncToken is an organization's API token for one provider
+type RepositorySyncToken struct {
+	OrganizationID uuid.UUID          `db:"organization_id" json:"organization_id"`
+	Provider       RepositoryProvider `db:"provider" json:"provider"`
+	APIBaseURL     string             `db:"api_base_url" json:"api_base_url"`
+	APIToken       string             `db:"api_token" json:"-"`
+	UpdatedBy      *uuid.UUID         `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt      time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `db:"updated_at" json:"updated_at"`
+}
+
+// UpdateRepositorySyncTokenInput represents input for setting a provider's
+// sync token
+type UpdateRepositorySyncTokenInput struct {
+	APIToken   string  `json:"api_token" validate:"required"`
+	APIBaseURL *string `json:"api_base_url,omitempty"` // Defaults to the hosted API
+}
+
+// Validate checks the token and API endpoint
+func (i *UpdateRepositorySyncTokenInput) Validate() error {
+	i.APIToken = strings.TrimSpace(i.APIToken)
+	if i.APIToken == "" {
+		return &ValidationError{Field: "api_token", Message: "api_token is required"}
+	}
+	if i.APIBaseURL != nil {
+		base := strings.TrimRight(strings.TrimSpace(*i.APIBaseURL), "/")
+		u, err := url.Parse(base)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return &ValidationError{Field: "api_base_url", Message: "api_base_url must be an https URL"}
+		}
+		i.APIBaseURL = &base
+	}
+	return nil
+}
+
+// RepositoryDetails are the repository fields read from the provider
+type RepositoryDetails struct {
+	Name          string
+	DefaultBranch string
+	Language      *string
+	IsPrivate     bool
+	Description   *string
+}
+
+// RepositorySyncResult reports the outcome of syncing one repository
+type RepositorySyncResult struct {
+	RepositoryID uuid.UUID `json:"repository_id"`
+	Changed      []string  `json:"changed"` // Fields the sync changed
+	Error        string    `json:"error,omitempty"`
+}
+
+// RepositorySyncRun summarizes syncing an organization's repositories
+type RepositorySyncRun struct {
+	OrganizationID uuid.UUID              `json:"organization_id"`
+	Synced         int                    `json:"synced"`
+	Changed        int                    `json:"changed"` // Synced repositories with at least one changed field
+	Failed         int                    `json:"failed"`
+	Results        []RepositorySyncResult `json:"results"`
+}
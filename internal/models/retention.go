@@ -0,0 +1,143 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Default retention periods applied when an organization has no policy
+const (
+	DefaultPIIRetentionDays     = 365
+	DefaultCommentRetentionDays = 730
+	DefaultTrashRetentionDays   = 30
+	MinRetentionDays            = 30
+	MaxTrashRetentionDays       = 365
+)
+
+// Expired credentials are kept this long after they expire or are revoked,
+// so recent sign-ins and key use can still be investigated, then deleted
+const (
+	ExpiredSessionRetention = 30 * 24 * time.Hour
+	ExpiredAPIKeyRetention  = 90 * 24 * time.Hour
+)
+
+// RetentionPolicy controls how long personal data is kept for an organization
+type RetentionPolicy struct {
+	OrganizationID       uuid.UUID  `db:"organization_id" json:"organization_id"`
+	PIIRetentionDays     int        `db:"pii_retention_days" json:"pii_retention_days"`
+	CommentRetentionDays int        `db:"comment_retention_days" json:"comment_retention_days"`
+	TrashRetentionDays   int        `db:"trash_retention_days" json:"trash_retention_days"`
+	LastAppliedAt        *time.Time `db:"last_applied_at" json:"last_applied_at,omitempty"`
+	UpdatedBy            *uuid.UUID `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// DefaultRetentionPolicy returns the policy used for orgs that have not configured one
+func DefaultRetentionPolicy(orgID uuid.UUID) RetentionPolicy {
+	return RetentionPolicy{
+		OrganizationID:       orgID,
+		PIIRetentionDays:     DefaultPIIRetentionDays,
+		CommentRetentionDays: DefaultCommentRetentionDays,
+		TrashRetentionDays:   DefaultTrashRetentionDays,
+	}
+}
+
+// PIICutoff returns the time before which audit PII should be scrubbed
+func (p *RetentionPolicy) PIICutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.PIIRetentionDays)
+}
+
+// CommentCutoff returns the close time before which comments should be scrubbed
+func (p *RetentionPolicy) CommentCutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.CommentRetentionDays)
+}
+
+// TrashCutoff returns the deletion time before which trashed tickets are purged
+func (p *RetentionPolicy) TrashCutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.TrashRetentionDays)
+}
+
+// UpdateRetentionPolicyInput represents input for updating a retention policy
+type UpdateRetentionPolicyInput struct {
+	PIIRetentionDays     *int `json:"pii_retention_days,omitempty" validate:"omitempty,min=30"`
+	CommentRetentionDays *int `json:"comment_retention_days,omitempty" validate:"omitempty,min=30"`
+	TrashRetentionDays   *int `json:"trash_retention_days,omitempty" validate:"omitempty,min=1,max=365"`
+}
+
+// Validate checks retention periods are within allowed bounds
+func (i *UpdateRetentionPolicyInput) Validate() error {
+	if i.PIIRetentionDays != nil && *i.PIIRetentionDays < MinRetentionDays {
+		return &ValidationError{Field: "pii_retention_days", Message: "must be at least 30 days"}
+	}
+	if i.CommentRetentionDays != nil && *i.CommentRetentionDays < MinRetentionDays {
+		return &ValidationError{Field: "comment_retention_days", Message: "must be at least 30 days"}
+	}
+	if i.TrashRetentionDays != nil && (*i.TrashRetentionDays < 1 || *i.TrashRetentionDays > MaxTrashRetentionDays) {
+		return &ValidationError{Field: "trash_retention_days", Message: "must be between 1 and 365 days"}
+	}
+	return nil
+}
+
+// RetentionResult summarizes a retention run for one organization
+type RetentionResult struct {
+	OrganizationID       uuid.UUID `json:"organization_id"`
+	AuditLogsScrubbed    int64     `json:"audit_logs_scrubbed"`
+	TicketAuditsScrubbed int64     `json:"ticket_audits_scrubbed"`
+	CommentsScrubbed     int64     `json:"comments_scrubbed"`
+	AppliedAt            time.Time `json:"applied_at"`
+}
+
+// PurgeRun summarizes one nightly retention run across all organizations
+type PurgeRun struct {
+	ID                   uuid.UUID `db:"id" json:"id"`
+	StartedAt            time.Time `db:"started_at" json:"started_at"`
+	FinishedAt           time.Time `db:"finished_at" json:"finished_at"`
+	Organizations        int       `db:"organizations" json:"organizations"`
+	AuditLogsScrubbed    int64     `db:"audit_logs_scrubbed" json:"audit_logs_scrubbed"`
+	TicketAuditsScrubbed int64     `db:"ticket_audits_scrubbed" json:"ticket_audits_scrubbed"`
+	CommentsScrubbed     int64     `db:"comments_scrubbed" json:"comments_scrubbed"`
+	TicketsPurged        int64     `db:"tickets_purged" json:"tickets_purged"`
+	APIKeysDeleted       int64     `db:"api_keys_deleted" json:"api_keys_deleted"`
+	SessionsDeleted      int64     `db:"sessions_deleted" json:"sessions_deleted"`
+	Errors               []string  `db:"errors" json:"errors"`
+}
+
+// AddRetention adds one organization's retention result to the run
+func (r *PurgeRun) AddRetention(result *RetentionResult) {
+	r.AuditLogsScrubbed += result.AuditLogsScrubbed
+	r.TicketAuditsScrubbed += result.TicketAuditsScrubbed
+	r.CommentsScrubbed += result.CommentsScrubbed
+}
+
+// AnonymizationStatus represents the state of an anonymization request
+type AnonymizationStatus string
+
+const (
+	AnonymizationStatusPending   AnonymizationStatus = "pending"
+	AnonymizationStatusCompleted AnonymizationStatus = "completed"
+	AnonymizationStatusFailed    AnonymizationStatus = "failed"
+)
+
+// MaxAnonymizationAttempts is how many times the worker retries a request before marking it failed
+const MaxAnonymizationAttempts = 5
+
+// AnonymizationRequest represents a GDPR right-to-be-forgotten request
+type AnonymizationRequest struct {
+	ID             uuid.UUID           `db:"id" json:"id"`
+	OrganizationID uuid.UUID           `db:"organization_id" json:"organization_id"`
+	SubjectUserID  uuid.UUID           `db:"subject_user_id" json:"subject_user_id"`
+	RequestedBy    *uuid.UUID          `db:"requested_by" json:"requested_by,omitempty"`
+	Reason         *string             `db:"reason" json:"reason,omitempty"`
+	Status         AnonymizationStatus `db:"status" json:"status"`
+	Attempts       int                 `db:"attempts" json:"attempts"`
+	ErrorMessage   *string             `db:"error_message" json:"error_message,omitempty"`
+	RequestedAt    time.Time           `db:"requested_at" json:"requested_at"`
+	ProcessedAt    *time.Time          `db:"processed_at" json:"processed_at,omitempty"`
+}
+
+// CreateAnonymizationRequestInput represents input for requesting anonymization
+type CreateAnonymizationRequestInput struct {
+	Reason *string `json:"reason,omitempty" validate:"omitempty,max=2000"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import "strings"
+
+// MaxRiskScore is the highest risk score; scores start at 0
+const MaxRiskScore = 100
+
+// HostEnvironmentProduction is the inventory environment of production hosts
+const HostEnvironmentProduction = "production"
+
+// riskLevelScores are the base score of each risk level
+var riskLevelScores = map[RiskLevel]int{
+	RiskLevelLow:      10,
+	RiskLevelMedium:   25,
+	RiskLevelHigh:     40,
+	RiskLevelCritical: 50,
+}
+
+// changeTypeScores add to the score of change types that tend to go wrong
+// widely. Change types are free text, so they're matched ignoring case.
+var changeTypeScores = map[string]int{
+	"breaking_change": 15,
+	"database":        15,
+	"infrastructure":  15,
+	"network":         15,
+	"security":        10,
+	"incident":        10,
+}
+
+// Each production host adds riskPerProductionSystem, and each compliance
+// framework riskPerFramework, up to their caps
+const (
+	riskPerProductionSystem = 5
+	maxProductionSystemRisk = 25
+	riskPerFramework        = 5
+	maxFrameworkRisk        = 10
+)
+
+// ScoreRisk combines the ticket's risk level, the production hosts it
+// affects, its compliance frameworks and its change type into a score from
+// 0 to MaxRiskScore. ProductionSystems must be loaded.
+func (t *Ticket) ScoreRisk() int {
+	score := riskLevelScores[t.RiskLevel]
+	score += min(t.ProductionSystems*riskPerProductionSystem, maxProductionSystemRisk)
+	score += min(len(t.ComplianceFrameworks)*riskPerFramework, maxFrameworkRisk)
+	if t.ChangeType != nil {
+		score += changeTypeScores[strings.ToLower(strings.TrimSpace(*t.ChangeType))]
+	}
+	return min(score, MaxRiskScore)
+}
+
+// CountProductionSystems returns how many of the resources are production
+// hosts
+func CountProductionSystems(resources []AffectedResource) int {
+	n := 0
+	for _, r := range resources {
+		if r.Environment == HostEnvironmentProduction {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxSavedFilterNameLength bounds saved filter names
+const MaxSavedFilterNameLength = 255
+
+// SavedFilter is a named ticket list filter saved by a user, such as
+// "My open high-risk changes". At most one per user is the default view.
+// Shared filters are visible to, and usable by, the whole organization.
+type SavedFilter struct {
+	ID             uuid.UUID        `db:"id" json:"id"`
+	OrganizationID uuid.UUID        `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID        `db:"user_id" json:"user_id"`
+	Name           string           `db:"name" json:"name"`
+	Description    *string          `db:"description" json:"description,omitempty"`
+	Filter         TicketListFilter `db:"filter" json:"filter"`
+	IsDefault      bool             `db:"is_default" json:"is_default"`
+	IsShared       bool             `db:"is_shared" json:"is_shared"`
+	CreatedAt      time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time        `db:"updated_at" json:"updated_at"`
+}
+
+// CreateSavedFilterInput represents input for saving a ticket filter
+type CreateSavedFilterInput struct {
+	Name        string           `json:"name" validate:"required,max=255"`
+	Description *string          `json:"description,omitempty"`
+	Filter      TicketListFilter `json:"filter"`
+	IsDefault   bool             `json:"is_default"`
+	IsShared    bool             `json:"is_shared"`
+}
+
+// UpdateSavedFilterInput represents input for updating a saved filter
+type UpdateSavedFilterInput struct {
+	Name        *string           `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description *string           `json:"description,omitempty"`
+	Filter      *TicketListFilter `json:"filter,omitempty"`
+	IsDefault   *bool             `json:"is_default,omitempty"`
+	IsShared    *bool             `json:"is_shared,omitempty"`
+}
+
+// Validate checks the saved filter input
+func (i *CreateSavedFilterInput) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	if err := validateSavedFilterName(i.Name); err != nil {
+		return err
+	}
+	i.Filter.ClearPagination()
+	return nil
+}
+
+// Validate checks the saved filter update input
+func (i *UpdateSavedFilterInput) Validate() error {
+	if i.Name != nil {
+		name := strings.TrimSpace(*i.Name)
+		if err := validateSavedFilterName(name); err != nil {
+			return err
+		}
+		i.Name = &name
+	}
+	if i.Filter != nil {
+		i.Filter.ClearPagination()
+	}
+	return nil
+}
+
+func validateSavedFilterName(name string) error {
+	if name == "" {
+		return &ValidationError{Field: "name", Message: "name is required"}
+	}
+	if len(name) > MaxSavedFilterNameLength {
+		return &ValidationError{Field: "name", Message: "name must be at most 255 characters"}
+	}
+	return nil
+}
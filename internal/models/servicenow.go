@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceNow import limits
+const (
+	MaxServiceNowImportBytes   = 50 << 20
+	MaxServiceNowImportRecords = 10000
+)
+
+// ImportTicketInput is a ticket brought in from another change system.
+// Unlike CreateTicketInput it carries the ticket's lifecycle as recorded
+// there, so historical changes keep their original status and timestamps.
+type ImportTicketInput struct {
+	CreateTicketInput
+	Status         TicketStatus `json:"status"`
+	IsEmergency    bool         `json:"is_emergency"`
+	ScheduledStart *time.Time   `json:"scheduled_start,omitempty"`
+	ScheduledEnd   *time.Time   `json:"scheduled_end,omitempty"`
+	ActualStart    *time.Time   `json:"actual_start,omitempty"`
+	ActualEnd      *time.Time   `json:"actual_end,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	ClosedAt       *time.Time   `json:"closed_at,omitempty"`
+}
+
+// ServiceNowImportOptions supplies the ticket fields a ServiceNow export
+// has no equivalent for. Unset industry and frameworks fall back to the
+// organization's ticket defaults.
+type ServiceNowImportOptions struct {
+	DryRun                bool
+	Industry              IndustryType
+	ComplianceFrameworks  []ComplianceFramework
+	RequiresApprovalTypes []ApprovalType
+	Location              *time.Location // Zone of timestamps without an offset
+}
+
+// Validate checks the import options
+func (o *ServiceNowImportOptions) Validate() error {
+	if o.Industry != "" && !o.Industry.Valid() {
+		return &ValidationError{Field: "industry", Message: "unknown industry: " + string(o.Industry)}
+	}
+	if err := validateFrameworks("compliance_frameworks", o.ComplianceFrameworks); err != nil {
+		return err
+	}
+	if len(o.RequiresApprovalTypes) == 0 {
+		o.RequiresApprovalTypes = []ApprovalType{ApprovalTypeChangeManagementBoard}
+	}
+	for _, t := range o.RequiresApprovalTypes {
+		if !t.Valid() {
+			return &ValidationError{Field: "approval_types", Message: "unknown approval type: " + string(t)}
+		}
+	}
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	return nil
+}
+
+// Import result statuses
+const (
+	ImportResultImported = "imported"
+	ImportResultValid    = "valid" // Dry run: would be imported
+	ImportResultSkipped  = "skipped"
+	ImportResultFailed   = "failed"
+)
+
+// ServiceNowImportResult reports the outcome for one change_request record
+type ServiceNowImportResult struct {
+	Row          int          `json:"row"` // 1-based position in the export
+	Number       string       `json:"number,omitempty"`
+	Result       string       `json:"result"`
+	Status       TicketStatus `json:"status,omitempty"`
+	TicketNumber string       `json:"ticket_number,omitempty"`
+	TicketID     *uuid.UUID   `json:"ticket_id,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// ServiceNowImportReport summarizes an import or dry run
+type ServiceNowImportReport struct {
+	DryRun   bool                     `json:"dry_run"`
+	Total    int                      `json:"total"`
+	Imported int                      `json:"imported"`
+	Valid    int                      `json:"valid"`
+	Skipped  int                      `json:"skipped"`
+	Failed   int                      `json:"failed"`
+	Results  []ServiceNowImportResult `json:"results"`
+}
+
+// Add records a result and updates the counts
+func (r *ServiceNowImportReport) Add(result ServiceNowImportResult) {
+	switch result.Result {
+	case ImportResultImported:
+		r.Imported++
+	case ImportResultValid:
+		r.Valid++
+	case ImportResultSkipped:
+		r.Skipped++
+	case ImportResultFailed:
+		r.Failed++
+	}
+	r.Total++
+	r.Results = append(r.Results, result)
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// SLAStatus is how a ticket awaiting approval stands against its approval
+// window
+type SLAStatus string
+
+const (
+	SLAStatusNone     SLAStatus = "none"     // Not awaiting approval
+	SLAStatusOnTrack  SLAStatus = "on_track" // Within the window
+	SLAStatusAtRisk   SLAStatus = "at_risk"  // SLAAtRiskFraction of the window has passed
+	SLAStatusBreached SLAStatus = "breached" // Past the due time
+)
+
+// SLAAtRiskFraction is the share of the approval window after which a
+// ticket is at risk of breaching it
+const SLAAtRiskFraction = 0.75
+
+// SLADuration is how long a ticket of the priority may await approval,
+// counted from its submission. Unknown priorities get the normal window.
+func (p TicketPriority) SLADuration() time.Duration {
+	switch p {
+	case TicketPriorityEmergency:
+		return EmergencyApprovalWindow
+	case TicketPriorityUrgent:
+		return 4 * time.Hour
+	case TicketPriorityHigh:
+		return 24 * time.Hour
+	case TicketPriorityLow:
+		return 7 * 24 * time.Hour
+	}
+	return 72 * time.Hour
+}
+
+// TicketSLA is a ticket's approval SLA as the API reports it
+type TicketSLA struct {
+	Status        SLAStatus  `json:"status"`
+	ApprovalDueAt *time.Time `json:"approval_due_at,omitempty"`
+}
+
+// AwaitingApproval returns true if the ticket is submitted and undecided
+func (t *Ticket) AwaitingApproval() bool {
+	for _, st := range CABAwaitingStatuses {
+		if t.Status == st {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalDueAt returns when the ticket's approval is due under the default
+// window for its priority, or nil if it was never submitted
+func (t *Ticket) ApprovalDueAt() *time.Time {
+	return t.approvalDueWithin(t.Priority.SLADuration())
+}
+
+// SLAStatus returns how the ticket stands against the default approval
+// window for its priority at now
+func (t *Ticket) SLAStatus(now time.Time) SLAStatus {
+	return t.slaStatusWithin(t.Priority.SLADuration(), now)
+}
+
+// approvalDueWithin is the ticket's submission plus the window, or its
+// approval deadline if that comes first
+func (t *Ticket) approvalDueWithin(window time.Duration) *time.Time {
+	if t.SubmittedAt == nil {
+		return nil
+	}
+	due := t.SubmittedAt.Add(window)
+	if t.ApprovalDeadline != nil && t.ApprovalDeadline.Before(due) {
+		due = *t.ApprovalDeadline
+	}
+	return &due
+}
+
+func (t *Ticket) slaStatusWithin(window time.Duration, now time.Time) SLAStatus {
+	due := t.approvalDueWithin(window)
+	if due == nil || !t.AwaitingApproval() {
+		return SLAStatusNone
+	}
+	if now.After(*due) {
+		return SLAStatusBreached
+	}
+	atRisk := due.Add(-time.Duration(float64(due.Sub(*t.SubmittedAt)) * (1 - SLAAtRiskFraction)))
+	if now.After(atRisk) {
+		return SLAStatusAtRisk
+	}
+	return SLAStatusOnTrack
+}
+
+// SLADuration is the organization's approval window for the priority: its
+// override if it has one, otherwise the default
+func (p *OrganizationPolicy) SLADuration(priority TicketPriority) time.Duration {
+	if minutes, ok := p.SLA.ApprovalMinutes[priority]; ok {
+		return time.Duration(minutes) * time.Minute
+	}
+	return priority.SLADuration()
+}
+
+// TicketSLA returns the ticket's approval SLA at now under the
+// organization's windows
+func (p *OrganizationPolicy) TicketSLA(t *Ticket, now time.Time) *TicketSLA {
+	window := p.SLADuration(t.Priority)
+	return &TicketSLA{
+		Status:        t.slaStatusWithin(window, now),
+		ApprovalDueAt: t.approvalDueWithin(window),
+	}
+}
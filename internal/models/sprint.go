@@ -0,0 +1,222 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SprintStatus represents the status of a sprint
+type SprintStatus string
+
+const (
+	SprintStatusPlanned   SprintStatus = "planned"
+	SprintStatusActive    SprintStatus = "active"
+	SprintStatusCompleted SprintStatus = "completed"
+)
+
+// Valid returns true if the sprint status is valid
+func (s SprintStatus) Valid() bool {
+	switch s {
+	case SprintStatusPlanned, SprintStatusActive, SprintStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// CanMoveTo returns true if a sprint may go from this status to another.
+// Sprints only move forward: planned, then active, then completed.
+func (s SprintStatus) CanMoveTo(to SprintStatus) bool {
+	switch s {
+	case SprintStatusPlanned:
+		return to == SprintStatusActive || to == SprintStatusCompleted
+	case SprintStatusActive:
+		return to == SprintStatusCompleted
+	}
+	return false
+}
+
+// MaxSprintDays bounds the length of a sprint
+const MaxSprintDays = 90
+
+// Sprint is a fixed period of work tickets are scheduled into
+type Sprint struct {
+	ID             uuid.UUID    `db:"id" json:"id"`
+	OrganizationID uuid.UUID    `db:"organization_id" json:"organization_id"`
+	ProjectID      *uuid.UUID   `db:"project_id" json:"project_id,omitempty"`
+	Name           string       `db:"name" json:"name"`
+	Goal           *string      `db:"goal" json:"goal,omitempty"`
+	Status         SprintStatus `db:"status" json:"status"`
+	StartDate      time.Time    `db:"start_date" json:"start_date"`
+	EndDate        time.Time    `db:"end_date" json:"end_date"`
+	CompletedAt    *time.Time   `db:"completed_at" json:"completed_at,omitempty"`
+	CreatedBy      *uuid.UUID   `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+// SprintListFilter narrows a sprint listing
+type SprintListFilter struct {
+	ProjectID *uuid.UUID
+	Status    *SprintStatus
+}
+
+// CreateSprintInput represents input for creating a sprint
+type CreateSprintInput struct {
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	Name      string     `json:"name" validate:"required,max=255"`
+	Goal      *string    `json:"goal,omitempty"`
+	StartDate string     `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate   string     `json:"end_date" validate:"required"`   // YYYY-MM-DD
+
+	Start time.Time `json:"-"`
+	End   time.Time `json:"-"`
+}
+
+// Validate checks the sprint input and parses its dates
+func (i *CreateSprintInput) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	if err := validatePlanningName(i.Name); err != nil {
+		return err
+	}
+	start, err := parsePlanningDate("start_date", i.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := parsePlanningDate("end_date", i.EndDate)
+	if err != nil {
+		return err
+	}
+	i.Start, i.End = start, end
+	return validateSprintDates(start, end)
+}
+
+// UpdateSprintInput represents input for updating a sprint
+type UpdateSprintInput struct {
+	Name      *string       `json:"name,omitempty" validate:"omitempty,max=255"`
+	Goal      *string       `json:"goal,omitempty"`
+	Status    *SprintStatus `json:"status,omitempty"`
+	StartDate *string       `json:"start_date,omitempty"` // YYYY-MM-DD
+	EndDate   *string       `json:"end_date,omitempty"`   // YYYY-MM-DD
+
+	Start *time.Time `json:"-"`
+	End   *time.Time `json:"-"`
+}
+
+// Validate checks the update against the sprint's current state and parses
+// its dates
+func (i *UpdateSprintInput) Validate(current *Sprint) error {
+	if i.Name != nil {
+		name := strings.TrimSpace(*i.Name)
+		if err := validatePlanningName(name); err != nil {
+			return err
+		}
+		i.Name = &name
+	}
+	if i.Status != nil && *i.Status != current.Status {
+		if !i.Status.Valid() {
+			return &ValidationError{Field: "status", Message: "invalid sprint status: " + string(*i.Status)}
+		}
+		if !current.Status.CanMoveTo(*i.Status) {
+			return &ValidationError{Field: "status", Message: "sprint cannot move from " + string(current.Status) + " to " + string(*i.Status)}
+		}
+	}
+
+	start, end := current.StartDate, current.EndDate
+	if i.StartDate != nil {
+		d, err := parsePlanningDate("start_date", *i.StartDate)
+		if err != nil {
+			return err
+		}
+		i.Start, start = &d, d
+	}
+	if i.EndDate != nil {
+		d, err := parsePlanningDate("end_date", *i.EndDate)
+		if err != nil {
+			return err
+		}
+		i.End, end = &d, d
+	}
+	if i.Start != nil || i.End != nil {
+		return validateSprintDates(start, end)
+	}
+	return nil
+}
+
+func validateSprintDates(start, end time.Time) error {
+	if end.Before(start) {
+		return &ValidationError{Field: "end_date", Message: "end_date must not be before start_date"}
+	}
+	if end.Sub(start) > MaxSprintDays*24*time.Hour {
+		return &ValidationError{Field: "end_date", Message: "sprints can be at most 90 days long"}
+	}
+	return nil
+}
+
+// BurndownTicket is the part of a sprint ticket a burndown needs
+type BurndownTicket struct {
+	Status      TicketStatus
+	StoryPoints *int
+	CompletedAt *time.Time
+}
+
+// BurndownDay is the remaining work at the end of one sprint day.
+// Remaining is omitted for days that haven't happened yet.
+type BurndownDay struct {
+	Date      string  `json:"date"` // YYYY-MM-DD
+	Remaining *int    `json:"remaining,omitempty"`
+	Ideal     float64 `json:"ideal"`
+}
+
+// Burndown charts a sprint's remaining story points day by day against an
+// even burn from the total to zero
+type Burndown struct {
+	SprintID    uuid.UUID     `json:"sprint_id"`
+	TotalPoints int           `json:"total_points"`
+	Days        []BurndownDay `json:"days"`
+}
+
+// BuildBurndown computes a sprint's burndown from its current tickets. A
+// ticket's points burn on the day it was completed; cancelled and denied
+// tickets are left out. Days are UTC.
+func BuildBurndown(sprint *Sprint, tickets []BurndownTicket, now time.Time) *Burndown {
+	b := &Burndown{SprintID: sprint.ID, Days: []BurndownDay{}}
+
+	burned := make(map[string]int)
+	for _, t := range tickets {
+		if t.Status.IsWithdrawn() || t.StoryPoints == nil {
+			continue
+		}
+		b.TotalPoints += *t.StoryPoints
+		if t.Status.IsDone() && t.CompletedAt != nil {
+			day := t.CompletedAt.UTC().Format("2006-01-02")
+			if t.CompletedAt.Before(sprint.StartDate) {
+				day = sprint.StartDate.Format("2006-01-02")
+			}
+			burned[day] += *t.StoryPoints
+		}
+	}
+
+	start := sprint.StartDate.UTC().Truncate(24 * time.Hour)
+	end := sprint.EndDate.UTC().Truncate(24 * time.Hour)
+	today := now.UTC().Format("2006-01-02")
+	days := int(end.Sub(start)/(24*time.Hour)) + 1
+
+	remaining := b.TotalPoints
+	for n := 0; n < days; n++ {
+		date := start.AddDate(0, 0, n).Format("2006-01-02")
+		day := BurndownDay{Date: date, Ideal: float64(b.TotalPoints)}
+		if days > 1 {
+			day.Ideal = float64(b.TotalPoints) * float64(days-1-n) / float64(days-1)
+		}
+		remaining -= burned[date]
+		if date <= today {
+			r := remaining
+			day.Remaining = &r
+		}
+		b.Days = append(b.Days, day)
+	}
+
+	return b
+}
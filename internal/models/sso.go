@@ -0,0 +1,210 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions for single sign-on
+const (
+	AuditActionSSOProvision = "sso_provision"
+	AuditActionSSOLink      = "sso_link"
+	AuditActionSSOConfigure = "sso_configure"
+)
+
+// Lifetimes of pending SSO steps
+const (
+	OAuth2StateTTL = 10 * time.Minute
+	SSOLinkTTL     = 10 * time.Minute
+)
+
+// Challenge purposes for SSO
+const (
+	AuthChallengeOAuth2  AuthChallengePurpose = "oauth2"
+	AuthChallengeSSOLink AuthChallengePurpose = "sso_link"
+)
+
+// SSOProvider is an OAuth2 / OIDC identity provider
+type SSOProvider string
+
+const (
+	SSOProviderGoogle    SSOProvider = "google"
+	SSOProviderAfterDark SSOProvider = "afterdark"
+)
+
+// Valid returns true if the provider is supported
+func (p SSOProvider) Valid() bool {
+	switch p {
+	case SSOProviderGoogle, SSOProviderAfterDark:
+		return true
+	}
+	return false
+}
+
+// AuthMethod returns the authentication method recorded for sign-ins
+// through the provider
+func (p SSOProvider) AuthMethod() string {
+	return "sso_" + string(p)
+}
+
+// SSODomain is an email domain whose SSO users sign in to an organization
+type SSODomain struct {
+	Domain         string     `db:"domain" json:"domain"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	CreatedBy      *uuid.UUID `db:"created_by" json:"created_by,omitempty"`
+}
+
+// SSOConnection is an organization's settings for one identity provider
+type SSOConnection struct {
+	ID              uuid.UUID           `db:"id" json:"id"`
+	OrganizationID  uuid.UUID           `db:"organization_id" json:"organization_id"`
+	Provider        SSOProvider         `db:"provider" json:"provider"`
+	Enabled         bool                `db:"enabled" json:"enabled"`
+	JITProvisioning bool                `db:"jit_provisioning" json:"jit_provisioning"`
+	DefaultRoles    []UserRole          `db:"default_roles" json:"default_roles"`
+	GroupRoles      map[string]UserRole `db:"group_roles" json:"group_roles"` // IdP group -> role
+	SyncRoles       bool                `db:"sync_roles" json:"sync_roles"`
+	CreatedAt       time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `db:"updated_at" json:"updated_at"`
+	CreatedBy       *uuid.UUID          `db:"created_by" json:"created_by,omitempty"`
+	UpdatedBy       *uuid.UUID          `db:"updated_by" json:"updated_by,omitempty"`
+}
+
+// RolesFor returns the default roles plus those mapped from the user's
+// IdP groups, sorted and without duplicates
+func (c *SSOConnection) RolesFor(groups []string) []string {
+	seen := map[string]bool{}
+	for _, r := range c.DefaultRoles {
+		seen[string(r)] = true
+	}
+	for _, g := range groups {
+		if r, ok := c.GroupRoles[g]; ok {
+			seen[string(r)] = true
+		}
+	}
+	roles := make([]string, 0, len(seen))
+	for r := range seen {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// SyncedRoles returns the roles a user keeps after a role sync: those
+// mapped from the IdP plus a platform role, which SSO never grants or
+// removes
+func (c *SSOConnection) SyncedRoles(current, groups []string) []string {
+	roles := c.RolesFor(groups)
+	for _, r := range current {
+		if r == string(UserRolePlatformAdmin) {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// SSOIdentity is the user an identity provider signed in
+type SSOIdentity struct {
+	Provider     SSOProvider `json:"provider"`
+	Subject      string      `json:"subject"`
+	Email        string      `json:"email"`
+	Name         string      `json:"name,omitempty"`
+	Picture      string      `json:"picture,omitempty"`
+	HostedDomain string      `json:"hosted_domain,omitempty"` // Google Workspace domain
+	Groups       []string    `json:"groups,omitempty"`
+}
+
+// EmailDomain returns the lowercase domain of the identity's email
+func (i *SSOIdentity) EmailDomain() string {
+	at := strings.LastIndex(i.Email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(i.Email[at+1:])
+}
+
+// OAuth2CallbackInput completes an authorization-code sign-in
+type OAuth2CallbackInput struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// Validate checks the callback input
+func (i *OAuth2CallbackInput) Validate() error {
+	if i.Code == "" {
+		return &ValidationError{Field: "code", Message: "code is required"}
+	}
+	if i.State == "" {
+		return &ValidationError{Field: "state", Message: "state is required"}
+	}
+	return nil
+}
+
+// SSOLinkInput links an SSO identity to an existing password account
+type SSOLinkInput struct {
+	LinkToken string `json:"link_token" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+}
+
+// Validate checks the link input
+func (i *SSOLinkInput) Validate() error {
+	if i.LinkToken == "" {
+		return &ValidationError{Field: "link_token", Message: "link_token is required"}
+	}
+	if i.Password == "" {
+		return &ValidationError{Field: "password", Message: "password is required"}
+	}
+	return nil
+}
+
+// UpsertSSOConnectionInput creates or updates an organization's settings
+// for a provider. Omitted fields keep their current value.
+type UpsertSSOConnectionInput struct {
+	Enabled         *bool                `json:"enabled,omitempty"`
+	JITProvisioning *bool                `json:"jit_provisioning,omitempty"`
+	DefaultRoles    []UserRole           `json:"default_roles,omitempty"`
+	GroupRoles      *map[string]UserRole `json:"group_roles,omitempty"`
+	SyncRoles       *bool                `json:"sync_roles,omitempty"`
+}
+
+// Validate checks the connection input. Platform roles can't be granted
+// through SSO.
+func (i *UpsertSSOConnectionInput) Validate() error {
+	for _, r := range i.DefaultRoles {
+		if !r.Valid() || r == UserRolePlatformAdmin {
+			return &ValidationError{Field: "default_roles", Message: "invalid role: " + string(r)}
+		}
+	}
+	if i.GroupRoles != nil {
+		for group, r := range *i.GroupRoles {
+			if strings.TrimSpace(group) == "" {
+				return &ValidationError{Field: "group_roles", Message: "group names must not be empty"}
+			}
+			if !r.Valid() || r == UserRolePlatformAdmin {
+				return &ValidationError{Field: "group_roles", Message: "invalid role: " + string(r)}
+			}
+		}
+	}
+	return nil
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// CreateSSODomainInput claims an email domain for an organization
+type CreateSSODomainInput struct {
+	Domain string `json:"domain" validate:"required"`
+}
+
+// Validate normalizes and checks the domain
+func (i *CreateSSODomainInput) Validate() error {
+	i.Domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(i.Domain)), ".")
+	if !domainPattern.MatchString(i.Domain) || len(i.Domain) > 255 {
+		return &ValidationError{Field: "domain", Message: "invalid domain"}
+	}
+	return nil
+}
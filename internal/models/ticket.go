@@ -2,6 +2,9 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,6 +38,7 @@ type Ticket struct {
 	ActualEnd                    *time.Time            `db:"actual_end" json:"actual_end,omitempty"`
 	RequiresApprovalTypes        []ApprovalType        `db:"requires_approval_types" json:"requires_approval_types"`
 	ApprovalDeadline             *time.Time            `db:"approval_deadline" json:"approval_deadline,omitempty"`
+	ApprovalPlan                 json.RawMessage       `db:"approval_plan" json:"approval_plan,omitempty"` // Frozen at submit (migration 004)
 	AttachmentURLs               []string              `db:"attachment_urls" json:"attachment_urls,omitempty"`
 	CustomFields                 json.RawMessage       `db:"custom_fields" json:"custom_fields,omitempty"`
 	SubmittedAt                  *time.Time            `db:"submitted_at" json:"submitted_at,omitempty"`
@@ -43,6 +47,7 @@ type Ticket struct {
 	CreatedAt                    time.Time             `db:"created_at" json:"created_at"`
 	UpdatedAt                    time.Time             `db:"updated_at" json:"updated_at"`
 	ClosedAt                     *time.Time            `db:"closed_at" json:"closed_at,omitempty"`
+	CompletedAt                  *time.Time            `db:"completed_at" json:"completed_at,omitempty"` // When the ticket last reached completed (migration 026)
 	DeletedAt                    *time.Time            `db:"deleted_at" json:"deleted_at,omitempty"`
 	DeletionReason               *string               `db:"deletion_reason" json:"deletion_reason,omitempty"`
 
@@ -52,6 +57,7 @@ type Ticket struct {
 	CustomerID        *uuid.UUID  `db:"customer_id" json:"customer_id,omitempty"`
 	ParentTicketID    *uuid.UUID  `db:"parent_ticket_id" json:"parent_ticket_id,omitempty"`
 	EpicID            *uuid.UUID  `db:"epic_id" json:"epic_id,omitempty"`
+	SprintID          *uuid.UUID  `db:"sprint_id" json:"sprint_id,omitempty"`
 	StoryPoints       *int        `db:"story_points" json:"story_points,omitempty"`
 	TimeEstimateHours *float64    `db:"time_estimate_hours" json:"time_estimate_hours,omitempty"`
 	TimeSpentHours    *float64    `db:"time_spent_hours" json:"time_spent_hours,omitempty"`
@@ -61,6 +67,24 @@ type Ticket struct {
 	ACLInheritance    bool        `db:"acl_inheritance" json:"acl_inheritance"`
 	IsConfidential    bool        `db:"is_confidential" json:"is_confidential"`
 
+	// Time tracking, derived from the estimate and worklogs. Remaining is
+	// negative once the ticket is over its estimate.
+	TimeRemainingHours *float64 `db:"-" json:"time_remaining_hours,omitempty"`
+	OverEstimate       bool     `db:"-" json:"over_estimate,omitempty"`
+
+	// Approval SLA under the organization's windows, derived on read
+	SLA *TicketSLA `db:"-" json:"sla,omitempty"`
+
+	// Risk score (see ScoreRisk) and the production hosts among the
+	// ticket's affected resources it counts, derived on read
+	RiskScore         int `db:"-" json:"risk_score"`
+	ProductionSystems int `db:"-" json:"production_systems"`
+
+	// Emergency workflow (from migration 005)
+	IsEmergency     bool       `db:"is_emergency" json:"is_emergency"`
+	EscalationLevel int        `db:"escalation_level" json:"escalation_level"`
+	LastEscalatedAt *time.Time `db:"last_escalated_at" json:"last_escalated_at,omitempty"`
+
 	// Relationships (populated via joins)
 	Creator       *UserSummary     `db:"-" json:"creator,omitempty"`
 	Assignee      *UserSummary     `db:"-" json:"assignee,omitempty"`
@@ -74,6 +98,7 @@ type Ticket struct {
 	Repositories  []TicketRepository `db:"-" json:"repositories,omitempty"`
 	ACLs          []TicketACL      `db:"-" json:"acls,omitempty"`
 	Contacts      []Contact        `db:"-" json:"contacts,omitempty"`
+	AffectedResources []AffectedResource `db:"-" json:"affected_resources,omitempty"`
 }
 
 // IsDraft returns true if the ticket is in draft status
@@ -86,29 +111,42 @@ func (t *Ticket) IsSubmitted() bool {
 	return t.SubmittedAt != nil
 }
 
+// IsEmergencyChange returns true if the ticket follows the expedited emergency path
+func (t *Ticket) IsEmergencyChange() bool {
+	return t.IsEmergency || t.Priority == TicketPriorityEmergency
+}
+
 // CanEdit returns true if the ticket can be edited
 func (t *Ticket) CanEdit() bool {
 	return t.Status == TicketStatusDraft || t.Status == TicketStatusUpdateRequested
 }
 
-// CanSubmit returns true if the ticket can be submitted
+// CanSubmit returns true if the default workflow lets the ticket be submitted
 func (t *Ticket) CanSubmit() bool {
-	return t.Status == TicketStatusDraft || t.Status == TicketStatusUpdateRequested
+	return Transitions.Allows(t.Status, TicketStatusSubmitted)
 }
 
-// CanCancel returns true if the ticket can be cancelled
-func (t *Ticket) CanCancel() bool {
-	return t.Status == TicketStatusDraft || t.Status == TicketStatusSubmitted
+// CanStartBlackout returns true if the ticket's hosts may be blacked out,
+// which is once it is approved and until implementation ends
+func (t *Ticket) CanStartBlackout() bool {
+	return t.Status == TicketStatusApproved || t.Status == TicketStatusImplementing
 }
 
-// CanClose returns true if the ticket can be closed
-func (t *Ticket) CanClose() bool {
-	return t.Status == TicketStatusCompleted
-}
-
-// CanReopen returns true if the ticket can be reopened
-func (t *Ticket) CanReopen() bool {
-	return t.Status == TicketStatusClosed
+// Affects returns true if the ticket lists the host among its affected
+// systems, ignoring case, or one of them resolved to it. AffectedResources
+// must be loaded for hosts named through a group.
+func (t *Ticket) Affects(hostname string) bool {
+	for _, s := range t.AffectedSystems {
+		if strings.EqualFold(strings.TrimSpace(s), hostname) {
+			return true
+		}
+	}
+	for _, r := range t.AffectedResources {
+		if strings.EqualFold(r.Hostname, hostname) {
+			return true
+		}
+	}
+	return false
 }
 
 // TicketSummary represents a minimal ticket for list views
@@ -142,14 +180,24 @@ func (t *Ticket) ToSummary() TicketSummary {
 	return summary
 }
 
+// MaxTicketSearchResults is the most tickets a full-text search returns
+const MaxTicketSearchResults = 100
+
+// TicketSearchResult is a ticket matched by a full-text search
+type TicketSearchResult struct {
+	Ticket   TicketSummary `json:"ticket"`
+	Rank     float64       `json:"rank"`     // Higher is a better match
+	Headline string        `json:"headline"` // Description excerpt with the matched words in <b></b>
+}
+
 // CreateTicketInput represents input for creating a ticket
 type CreateTicketInput struct {
 	Title                       string                `json:"title" validate:"required,min=5,max=500"`
 	Description                 string                `json:"description" validate:"required,min=10"`
 	Priority                    TicketPriority        `json:"priority" validate:"required"`
 	RiskLevel                   RiskLevel             `json:"risk_level" validate:"required"`
-	Industry                    IndustryType          `json:"industry" validate:"required"`
-	ComplianceFrameworks        []ComplianceFramework `json:"compliance_frameworks" validate:"required,min=1,dive"`
+	Industry                    IndustryType          `json:"industry,omitempty"`                                        // Defaults to the organization's
+	ComplianceFrameworks        []ComplianceFramework `json:"compliance_frameworks,omitempty" validate:"omitempty,dive"` // Defaults to the organization's
 	ComplianceNotes             *string               `json:"compliance_notes,omitempty"`
 	ChangeType                  *string               `json:"change_type,omitempty"`
 	AffectedSystems             []string              `json:"affected_systems,omitempty"`
@@ -169,6 +217,7 @@ type CreateTicketInput struct {
 	CustomerID        *uuid.UUID  `json:"customer_id,omitempty"`
 	ParentTicketID    *uuid.UUID  `json:"parent_ticket_id,omitempty"`
 	EpicID            *uuid.UUID  `json:"epic_id,omitempty"`
+	SprintID          *uuid.UUID  `json:"sprint_id,omitempty"`
 	StoryPoints       *int        `json:"story_points,omitempty"`
 	TimeEstimateHours *float64    `json:"time_estimate_hours,omitempty"`
 	Labels            []string    `json:"labels,omitempty"`
@@ -207,7 +256,8 @@ type UpdateTicketInput struct {
 	OwningGroupID     *uuid.UUID  `json:"owning_group_id,omitempty"`
 	CustomerID        *uuid.UUID  `json:"customer_id,omitempty"`
 	ParentTicketID    *uuid.UUID  `json:"parent_ticket_id,omitempty"`
-	EpicID            *uuid.UUID  `json:"epic_id,omitempty"`
+	EpicID            *uuid.UUID  `json:"epic_id,omitempty"`   // uuid.Nil removes the ticket from its epic
+	SprintID          *uuid.UUID  `json:"sprint_id,omitempty"` // uuid.Nil removes the ticket from its sprint
 	StoryPoints       *int        `json:"story_points,omitempty"`
 	TimeEstimateHours *float64    `json:"time_estimate_hours,omitempty"`
 	TimeSpentHours    *float64    `json:"time_spent_hours,omitempty"`
@@ -215,8 +265,101 @@ type UpdateTicketInput struct {
 	Watchers          []uuid.UUID `json:"watchers,omitempty"`
 	ExternalReference *string     `json:"external_reference,omitempty"`
 	IsConfidential    *bool       `json:"is_confidential,omitempty"`
+	ACLInheritance    *bool       `json:"acl_inheritance,omitempty"`
+
+	// Version the client last read, required; the update is rejected if the
+	// ticket has moved on
+	Version *int `json:"version,omitempty"`
+
+	// Recorded on the ticket revision
+	ChangeReason *string `json:"change_reason,omitempty"`
+}
+
+// Conflicts returns the fields this update would set whose current values
+// differ from the request, for reporting stale writes back to the client
+func (i *UpdateTicketInput) Conflicts(t *Ticket) map[string]FieldConflict {
+	conflicts := make(map[string]FieldConflict)
+	add := func(field string, current, requested interface{}) {
+		if !reflect.DeepEqual(current, requested) {
+			conflicts[field] = FieldConflict{Current: current, Requested: requested}
+		}
+	}
+
+	if i.Title != nil {
+		add("title", t.Title, *i.Title)
+	}
+	if i.Description != nil {
+		add("description", t.Description, *i.Description)
+	}
+	if i.Priority != nil {
+		add("priority", t.Priority, *i.Priority)
+	}
+	if i.RiskLevel != nil {
+		add("risk_level", t.RiskLevel, *i.RiskLevel)
+	}
+	if i.AssignedTo != nil {
+		add("assigned_to", t.AssignedTo, i.AssignedTo)
+	}
+	if i.ProjectID != nil {
+		add("project_id", t.ProjectID, i.ProjectID)
+	}
+	if i.OwningGroupID != nil {
+		add("owning_group_id", t.OwningGroupID, i.OwningGroupID)
+	}
+	if i.EpicID != nil {
+		add("epic_id", t.EpicID, i.EpicID)
+	}
+	if i.SprintID != nil {
+		add("sprint_id", t.SprintID, i.SprintID)
+	}
+	if i.StoryPoints != nil {
+		add("story_points", t.StoryPoints, i.StoryPoints)
+	}
+	if i.Labels != nil {
+		add("labels", t.Labels, i.Labels)
+	}
+	if i.IsConfidential != nil {
+		add("is_confidential", t.IsConfidential, *i.IsConfidential)
+	}
+	if i.ACLInheritance != nil {
+		add("acl_inheritance", t.ACLInheritance, *i.ACLInheritance)
+	}
+	if i.CustomFields != nil && !sameJSON(t.CustomFields, i.CustomFields) {
+		conflicts["custom_fields"] = FieldConflict{Current: t.CustomFields, Requested: i.CustomFields}
+	}
+
+	return conflicts
+}
+
+// FieldConflict pairs the stored value of a field with the value a stale write tried to set
+type FieldConflict struct {
+	Current   interface{} `json:"current"`
+	Requested interface{} `json:"requested"`
 }
 
+// VersionConflictError is returned when a write is based on an outdated ticket version
+type VersionConflictError struct {
+	ExpectedVersion int                      `json:"expected_version"`
+	CurrentVersion  int                      `json:"current_version"`
+	Conflicts       map[string]FieldConflict `json:"conflicts"`
+}
+
+func (e *VersionConflictError) Error() string {
+	return "ticket has been modified since it was read"
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// ErrVersionRequired is returned by ticket writes that must name the
+// version they are based on but don't
+var ErrVersionRequired = &ValidationError{Field: "version", Message: "version is required"}
+
+// ErrTicketPurged is returned when restoring a ticket the trash has already
+// purged
+var ErrTicketPurged = errors.New("ticket has been purged")
+
 // TicketRevision represents a change history entry for a ticket
 type TicketRevision struct {
 	ID             uuid.UUID       `db:"id" json:"id"`
@@ -235,6 +378,69 @@ type TicketRevision struct {
 	ChangedByUser *UserSummary `db:"-" json:"changed_by_user,omitempty"`
 }
 
+// FieldChange records a field's value before and after a revision
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// revisionIgnoredFields change on every write or duplicate the ticket itself
+var revisionIgnoredFields = map[string]bool{
+	"version":            true,
+	"updated_at":         true,
+	"submitted_snapshot": true,
+}
+
+// TicketChanges returns the field-level differences between two versions of
+// a ticket, keyed by JSON field name
+func TicketChanges(before, after *Ticket) map[string]FieldChange {
+	var oldFields, newFields map[string]interface{}
+	if data, err := json.Marshal(before); err == nil {
+		json.Unmarshal(data, &oldFields)
+	}
+	if data, err := json.Marshal(after); err == nil {
+		json.Unmarshal(data, &newFields)
+	}
+
+	changes := make(map[string]FieldChange)
+	for field, newValue := range newFields {
+		if revisionIgnoredFields[field] {
+			continue
+		}
+		if oldValue := oldFields[field]; !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+	for field, oldValue := range oldFields {
+		if _, ok := newFields[field]; !ok && !revisionIgnoredFields[field] {
+			changes[field] = FieldChange{Old: oldValue, New: nil}
+		}
+	}
+
+	return changes
+}
+
+// TicketRevisionFilter represents pagination for listing ticket revisions
+type TicketRevisionFilter struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *TicketRevisionFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the SQL offset for pagination
+func (f *TicketRevisionFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
 // TicketListFilter represents filter options for listing tickets
 type TicketListFilter struct {
 	Status              []TicketStatus        `json:"status,omitempty"`
@@ -251,16 +457,23 @@ type TicketListFilter struct {
 	SortBy              string                `json:"sort_by,omitempty"`
 	SortOrder           string                `json:"sort_order,omitempty"` // asc or desc
 
+	// Keyset pagination: when Cursor is set Page is ignored. NextCursor is
+	// filled in by the store when more results follow.
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"-"`
+
 	// JIRA-like filters
 	ProjectID      *uuid.UUID `json:"project_id,omitempty"`
 	OwningGroupID  *uuid.UUID `json:"owning_group_id,omitempty"`
 	CustomerID     *uuid.UUID `json:"customer_id,omitempty"`
 	EpicID         *uuid.UUID `json:"epic_id,omitempty"`
+	SprintID       *uuid.UUID `json:"sprint_id,omitempty"`
 	ParentTicketID *uuid.UUID `json:"parent_ticket_id,omitempty"`
 	Labels         []string   `json:"labels,omitempty"`
 	WatchedBy      *uuid.UUID `json:"watched_by,omitempty"`
 	IsConfidential *bool      `json:"is_confidential,omitempty"`
 	NeedsAssignment bool      `json:"needs_assignment,omitempty"` // For queue bot
+	Host           string     `json:"host,omitempty"`             // affected inventory host
 }
 
 // SetDefaults sets default values for the filter
@@ -279,6 +492,14 @@ func (f *TicketListFilter) SetDefaults() {
 	}
 }
 
+// ClearPagination drops the page position so the filter can be saved and
+// reapplied later. PerPage and sorting are kept as part of the view.
+func (f *TicketListFilter) ClearPagination() {
+	f.Page = 0
+	f.Cursor = ""
+	f.NextCursor = ""
+}
+
 // Offset returns the offset for pagination
 func (f *TicketListFilter) Offset() int {
 	return (f.Page - 1) * f.PerPage
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ticket template scopes
+const (
+	TemplateScopeOrg  = "org"  // Shared with the whole organization
+	TemplateScopeUser = "user" // Kept by one user for themselves
+)
+
+// templateNamePattern matches template names, which appear in URLs
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// TicketTemplate is a named set of ticket defaults for a kind of change
+// that happens often, such as the approvals, compliance frameworks and
+// plans every database migration needs
+type TicketTemplate struct {
+	ID             uuid.UUID            `db:"id" json:"id"`
+	OrganizationID uuid.UUID            `db:"organization_id" json:"organization_id"`
+	UserID         *uuid.UUID           `db:"user_id" json:"user_id,omitempty"` // Nil for organization templates
+	Name           string               `db:"name" json:"name"`
+	Description    string               `db:"description" json:"description,omitempty"`
+	Scope          string               `db:"-" json:"scope"`
+	Ticket         TicketTemplateFields `db:"ticket" json:"ticket"`
+	CreatedBy      *uuid.UUID           `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time            `db:"updated_at" json:"updated_at"`
+}
+
+// TicketTemplateFields are the ticket fields a template fills in. Empty
+// fields leave the ticket's own values and the usual defaults alone. The
+// names match the CLI's template files.
+type TicketTemplateFields struct {
+	Description          string                `json:"description,omitempty"`
+	ChangeType           string                `json:"change_type,omitempty"`
+	Priority             TicketPriority        `json:"priority,omitempty"`
+	RiskLevel            RiskLevel             `json:"risk,omitempty"`
+	Industry             IndustryType          `json:"industry,omitempty"`
+	ComplianceFrameworks []ComplianceFramework `json:"compliance_frameworks,omitempty"`
+	ApprovalTypes        []ApprovalType        `json:"approval_types,omitempty"`
+	AffectedSystems      []string              `json:"affected_systems,omitempty"`
+	TestingPlan          string                `json:"testing_plan,omitempty"`
+	RollbackPlan         string                `json:"rollback_plan,omitempty"`
+}
+
+// Apply fills in the ticket fields the input leaves empty from the template
+func (f *TicketTemplateFields) Apply(input *CreateTicketInput) {
+	if input.Description == "" {
+		input.Description = f.Description
+	}
+	if input.ChangeType == nil && f.ChangeType != "" {
+		changeType := f.ChangeType
+		input.ChangeType = &changeType
+	}
+	if input.Priority == "" {
+		input.Priority = f.Priority
+	}
+	if input.RiskLevel == "" {
+		input.RiskLevel = f.RiskLevel
+	}
+	if input.Industry == "" {
+		input.Industry = f.Industry
+	}
+	if len(input.ComplianceFrameworks) == 0 {
+		input.ComplianceFrameworks = f.ComplianceFrameworks
+	}
+	if len(input.RequiresApprovalTypes) == 0 {
+		input.RequiresApprovalTypes = f.ApprovalTypes
+	}
+	if len(input.AffectedSystems) == 0 {
+		input.AffectedSystems = f.AffectedSystems
+	}
+	if input.TestingPlan == nil && f.TestingPlan != "" {
+		plan := f.TestingPlan
+		input.TestingPlan = &plan
+	}
+	if input.RollbackPlan == nil && f.RollbackPlan != "" {
+		plan := f.RollbackPlan
+		input.RollbackPlan = &plan
+	}
+}
+
+// Validate checks the template's enumerated fields
+func (f *TicketTemplateFields) Validate() error {
+	if f.Priority != "" && !f.Priority.Valid() {
+		return &ValidationError{Field: "ticket.priority", Message: "unknown priority: " + string(f.Priority)}
+	}
+	if f.RiskLevel != "" && !f.RiskLevel.Valid() {
+		return &ValidationError{Field: "ticket.risk", Message: "unknown risk level: " + string(f.RiskLevel)}
+	}
+	for _, at := range f.ApprovalTypes {
+		if !at.Valid() {
+			return &ValidationError{Field: "ticket.approval_types", Message: "unknown approval type: " + string(at)}
+		}
+	}
+	return validateFrameworks("ticket.compliance_frameworks", f.ComplianceFrameworks)
+}
+
+// CreateTicketTemplateInput represents input for creating a ticket template
+type CreateTicketTemplateInput struct {
+	Name        string               `json:"name" validate:"required"`
+	Description string               `json:"description,omitempty"`
+	Scope       string               `json:"scope,omitempty"` // "user" (the default) or "org"
+	Ticket      TicketTemplateFields `json:"ticket"`
+}
+
+// UpdateTicketTemplateInput represents input for updating a ticket template.
+// The ticket fields are replaced whole.
+type UpdateTicketTemplateInput struct {
+	Description *string               `json:"description,omitempty"`
+	Ticket      *TicketTemplateFields `json:"ticket,omitempty"`
+}
+
+// Validate checks the template input
+func (i *CreateTicketTemplateInput) Validate() error {
+	i.Name = strings.ToLower(strings.TrimSpace(i.Name))
+	if !templateNamePattern.MatchString(i.Name) {
+		return &ValidationError{Field: "name", Message: "name must be 1-100 lowercase letters, digits, dots, dashes or underscores"}
+	}
+	switch i.Scope {
+	case "":
+		i.Scope = TemplateScopeUser
+	case TemplateScopeUser, TemplateScopeOrg:
+	default:
+		return &ValidationError{Field: "scope", Message: "scope must be user or org"}
+	}
+	return i.Ticket.Validate()
+}
+
+// Validate checks the template update input
+func (i *UpdateTicketTemplateInput) Validate() error {
+	if i.Ticket != nil {
+		return i.Ticket.Validate()
+	}
+	return nil
+}
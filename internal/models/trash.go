@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrashedTicket is a soft-deleted ticket as listed in the trash
+type TrashedTicket struct {
+	ID             uuid.UUID      `json:"id"`
+	TicketNumber   string         `json:"ticket_number"`
+	Title          string         `json:"title"`
+	Status         TicketStatus   `json:"status"`
+	Priority       TicketPriority `json:"priority"`
+	CreatedBy      UserSummary    `json:"created_by"`
+	DeletedBy      *UserSummary   `json:"deleted_by,omitempty"`
+	DeletionReason *string        `json:"deletion_reason,omitempty"`
+	DeletedAt      time.Time      `json:"deleted_at"`
+	PurgeAfter     time.Time      `json:"purge_after"` // When the worker purges it
+}
+
+// DeleteTicketInput represents input for moving a ticket to the trash
+type DeleteTicketInput struct {
+	Reason  *string `json:"reason,omitempty" validate:"omitempty,max=2000"`
+	Version *int    `json:"version,omitempty"`
+}
+
+// Validate checks the deletion reason
+func (i *DeleteTicketInput) Validate() error {
+	if i.Reason != nil && len(*i.Reason) > 2000 {
+		return &ValidationError{Field: "reason", Message: "reason must be at most 2000 characters"}
+	}
+	return nil
+}
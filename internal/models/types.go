@@ -20,6 +20,22 @@ func (i IndustryType) Valid() bool {
 	return false
 }
 
+// SuggestedFrameworks returns the compliance frameworks changes in this
+// industry usually fall under
+func (i IndustryType) SuggestedFrameworks() []ComplianceFramework {
+	switch i {
+	case IndustryHealthcare:
+		return []ComplianceFramework{ComplianceHIPAA}
+	case IndustryFinance:
+		return []ComplianceFramework{ComplianceGLBA, ComplianceSOX, ComplianceBankingSecrecyAct}
+	case IndustryInsurance:
+		return []ComplianceFramework{ComplianceGLBA, ComplianceHIPAA}
+	case IndustryIT:
+		return []ComplianceFramework{ComplianceGDPR}
+	}
+	return nil
+}
+
 // ComplianceFramework represents regulatory compliance frameworks
 type ComplianceFramework string
 
@@ -58,6 +74,13 @@ const (
 	TicketStatusCancelled         TicketStatus = "cancelled"
 )
 
+// TicketStatuses lists every ticket status in workflow order
+var TicketStatuses = []TicketStatus{
+	TicketStatusDraft, TicketStatusSubmitted, TicketStatusInReview, TicketStatusPartiallyApproved,
+	TicketStatusApproved, TicketStatusDenied, TicketStatusUpdateRequested, TicketStatusImplementing,
+	TicketStatusCompleted, TicketStatusClosed, TicketStatusCancelled,
+}
+
 // Valid returns true if the ticket status is valid
 func (t TicketStatus) Valid() bool {
 	switch t {
@@ -79,6 +102,27 @@ func (t TicketStatus) IsOpen() bool {
 	return false
 }
 
+// IsDone returns true once the change has been implemented
+func (t TicketStatus) IsDone() bool {
+	return t == TicketStatusCompleted || t == TicketStatusClosed
+}
+
+// IsWithdrawn returns true for tickets that will never be implemented
+func (t TicketStatus) IsWithdrawn() bool {
+	return t == TicketStatusCancelled || t == TicketStatusDenied
+}
+
+// OpenTicketStatuses returns the statuses IsOpen reports as open
+func OpenTicketStatuses() []TicketStatus {
+	var open []TicketStatus
+	for _, st := range TicketStatuses {
+		if st.IsOpen() {
+			open = append(open, st)
+		}
+	}
+	return open
+}
+
 // ApprovalType represents different types of approvals
 type ApprovalType string
 
@@ -193,12 +237,15 @@ const (
 	UserRoleApprover UserRole = "approver"
 	UserRoleUser     UserRole = "user"
 	UserRoleAuditor  UserRole = "auditor"
+
+	// UserRolePlatformAdmin manages organizations across tenants
+	UserRolePlatformAdmin UserRole = "platform_admin"
 )
 
 // Valid returns true if the user role is valid
 func (u UserRole) Valid() bool {
 	switch u {
-	case UserRoleAdmin, UserRoleApprover, UserRoleUser, UserRoleAuditor:
+	case UserRoleAdmin, UserRoleApprover, UserRoleUser, UserRoleAuditor, UserRolePlatformAdmin:
 		return true
 	}
 	return false
@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"net"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +29,7 @@ type User struct {
 	IsApprover            bool           `db:"is_approver" json:"is_approver"`
 	ApprovalTypes         []ApprovalType `db:"approval_types" json:"approval_types,omitempty"`
 	ApprovalDelegateID    *uuid.UUID     `db:"approval_delegate_id" json:"approval_delegate_id,omitempty"`
+	IsOnCall              bool           `db:"is_on_call" json:"is_on_call"`
 	IsActive              bool           `db:"is_active" json:"is_active"`
 	EmailVerified         bool           `db:"email_verified" json:"email_verified"`
 	LastLoginAt           *time.Time     `db:"last_login_at" json:"last_login_at,omitempty"`
@@ -106,6 +109,112 @@ type UpdateUserInput struct {
 	RequirePasswordChange *bool          `json:"require_password_change,omitempty"`
 }
 
+// Validate checks the new user and normalizes their email. Platform roles
+// can't be granted within an organization, and approvers need at least one
+// approval type.
+func (i *CreateUserInput) Validate() error {
+	i.Email = strings.ToLower(strings.TrimSpace(i.Email))
+	if _, err := mail.ParseAddress(i.Email); err != nil {
+		return &ValidationError{Field: "email", Message: "email must be a valid email address"}
+	}
+	i.FullName = strings.TrimSpace(i.FullName)
+	if len(i.FullName) < 2 {
+		return &ValidationError{Field: "full_name", Message: "full_name must be at least 2 characters"}
+	}
+	if err := validateRoles(i.Roles); err != nil {
+		return err
+	}
+	if err := validateApprovalTypes(i.ApprovalTypes); err != nil {
+		return err
+	}
+	if i.IsApprover && len(i.ApprovalTypes) == 0 {
+		return &ValidationError{Field: "approval_types", Message: "approvers need at least one approval type"}
+	}
+	if i.Password != nil {
+		if msg := checkPassword(*i.Password, DefaultPasswordPolicy()); msg != "" {
+			return &ValidationError{Field: "password", Message: msg}
+		}
+	}
+	return nil
+}
+
+// Validate checks the changes to a user
+func (i *UpdateUserInput) Validate() error {
+	if i.FullName != nil {
+		name := strings.TrimSpace(*i.FullName)
+		if len(name) < 2 {
+			return &ValidationError{Field: "full_name", Message: "full_name must be at least 2 characters"}
+		}
+		i.FullName = &name
+	}
+	if i.Roles != nil {
+		if len(i.Roles) == 0 {
+			return &ValidationError{Field: "roles", Message: "roles must not be empty"}
+		}
+		if err := validateRoles(i.Roles); err != nil {
+			return err
+		}
+	}
+	return validateApprovalTypes(i.ApprovalTypes)
+}
+
+func validateRoles(roles []UserRole) error {
+	for _, r := range roles {
+		if !r.Valid() || r == UserRolePlatformAdmin {
+			return &ValidationError{Field: "roles", Message: "invalid role: " + string(r)}
+		}
+	}
+	return nil
+}
+
+func validateApprovalTypes(types []ApprovalType) error {
+	for _, at := range types {
+		if !at.Valid() {
+			return &ValidationError{Field: "approval_types", Message: "invalid approval type: " + string(at)}
+		}
+	}
+	return nil
+}
+
+// SetPasswordInput represents input for an admin resetting a user's
+// password. Without a password a temporary one is generated.
+type SetPasswordInput struct {
+	Password string `json:"password,omitempty"`
+}
+
+// Validate checks the password against the password policy
+func (i *SetPasswordInput) Validate() error {
+	if msg := checkPassword(i.Password, DefaultPasswordPolicy()); msg != "" {
+		return &ValidationError{Field: "password", Message: msg}
+	}
+	return nil
+}
+
+// UserListFilter represents filter options for listing users
+type UserListFilter struct {
+	Role         *UserRole     `json:"role,omitempty"`
+	ApprovalType *ApprovalType `json:"approval_type,omitempty"`
+	IsActive     *bool         `json:"is_active,omitempty"`
+	Search       string        `json:"search,omitempty"` // Matches email, username or name
+	Page         int           `json:"page" validate:"min=1"`
+	PerPage      int           `json:"per_page" validate:"min=1,max=100"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *UserListFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the offset for pagination
+func (f *UserListFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
 // Session represents an authenticated session
 type Session struct {
 	ID                uuid.UUID  `db:"id" json:"id"`
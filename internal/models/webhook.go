@@ -0,0 +1,262 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types
+const (
+	WebhookEventTicketCreated       = "ticket.created"
+	WebhookEventTicketUpdated       = "ticket.updated"
+	WebhookEventTicketStatusChanged = "ticket.status_changed"
+	WebhookEventTicketDeleted       = "ticket.deleted"
+	WebhookEventTicketRestored      = "ticket.restored"
+
+	// WebhookEventAll subscribes to every event type
+	WebhookEventAll = "*"
+)
+
+// WebhookEventTypes lists the event types subscriptions may name
+var WebhookEventTypes = []string{
+	WebhookEventTicketCreated,
+	WebhookEventTicketUpdated,
+	WebhookEventTicketStatusChanged,
+	WebhookEventTicketDeleted,
+	WebhookEventTicketRestored,
+}
+
+// WebhookDeliveryStatus constants
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead" // Gave up after the subscription's max attempts
+)
+
+// Webhook delivery limits
+const (
+	MinWebhookSecretLength       = 16
+	DefaultWebhookMaxAttempts    = 10
+	MaxWebhookMaxAttempts        = 20
+	WebhookRetryBaseDelay        = 30 * time.Second
+	WebhookRetryMaxDelay         = 6 * time.Hour
+	WebhookDeliveryRetention     = 30 * 24 * time.Hour // Delivered and dead deliveries are purged after this
+	MaxWebhookResponseBodyLength = 1000                // Bytes of the endpoint's response kept for debugging
+)
+
+// WebhookRetryDelay returns how long to wait before retrying a delivery
+// after its nth failed attempt, doubling from 30 seconds up to 6 hours
+func WebhookRetryDelay(attempt int) time.Duration {
+	delay := WebhookRetryBaseDelay
+	for i := 1; i < attempt && delay < WebhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > WebhookRetryMaxDelay {
+		delay = WebhookRetryMaxDelay
+	}
+	return delay
+}
+
+// WebhookSubscription is an endpoint an organization has asked to receive
+// events at
+type WebhookSubscription struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	URL            string     `db:"url" json:"url"`
+	Secret         string     `db:"secret" json:"-"` // HMAC key deliveries are signed with
+	Events         []string   `db:"events" json:"events"`
+	Description    *string    `db:"description" json:"description,omitempty"`
+	IsActive       bool       `db:"is_active" json:"is_active"`
+	MaxAttempts    int        `db:"max_attempts" json:"max_attempts"`
+	CreatedBy      *uuid.UUID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+
+	Stats *WebhookDeliveryStats `json:"stats,omitempty"`
+}
+
+// WebhookDeliveryStats summarizes a subscription's recent deliveries
+type WebhookDeliveryStats struct {
+	Pending           int        `json:"pending"`
+	Delivered24h      int        `json:"delivered_24h"`
+	Dead24h           int        `json:"dead_24h"`
+	FailedAttempts24h int        `json:"failed_attempts_24h"`
+	AvgLatencyMS      *int       `json:"avg_latency_ms,omitempty"` // Of successful deliveries in the last 24h
+	LastDeliveredAt   *time.Time `json:"last_delivered_at,omitempty"`
+	LastFailedAt      *time.Time `json:"last_failed_at,omitempty"`
+}
+
+// CreateWebhookSubscriptionInput represents input for subscribing an endpoint
+type CreateWebhookSubscriptionInput struct {
+	URL         string   `json:"url" validate:"required,url"`
+	Events      []string `json:"events" validate:"required,min=1"`
+	Secret      *string  `json:"secret,omitempty"` // Generated when omitted
+	Description *string  `json:"description,omitempty"`
+	MaxAttempts *int     `json:"max_attempts,omitempty"`
+}
+
+// Validate checks the subscription input
+func (i *CreateWebhookSubscriptionInput) Validate() error {
+	if err := validateWebhookURL(i.URL); err != nil {
+		return err
+	}
+	if err := validateWebhookEvents(i.Events); err != nil {
+		return err
+	}
+	if i.Secret != nil && len(*i.Secret) < MinWebhookSecretLength {
+		return &ValidationError{Field: "secret", Message: fmt.Sprintf("secret must be at least %d characters", MinWebhookSecretLength)}
+	}
+	return validateWebhookMaxAttempts(i.MaxAttempts)
+}
+
+// UpdateWebhookSubscriptionInput represents input for changing a subscription
+type UpdateWebhookSubscriptionInput struct {
+	URL          *string  `json:"url,omitempty"`
+	Events       []string `json:"events,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	IsActive     *bool    `json:"is_active,omitempty"`
+	MaxAttempts  *int     `json:"max_attempts,omitempty"`
+	RotateSecret bool     `json:"rotate_secret,omitempty"`
+}
+
+// Validate checks the subscription changes
+func (i *UpdateWebhookSubscriptionInput) Validate() error {
+	if i.URL != nil {
+		if err := validateWebhookURL(*i.URL); err != nil {
+			return err
+		}
+	}
+	if i.Events != nil {
+		if err := validateWebhookEvents(i.Events); err != nil {
+			return err
+		}
+	}
+	return validateWebhookMaxAttempts(i.MaxAttempts)
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || u.Scheme != "https" {
+		return &ValidationError{Field: "url", Message: "url must be an absolute https URL"}
+	}
+	// Names are checked again when the dispatcher connects, since they can
+	// resolve to anything
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return &ValidationError{Field: "url", Message: "url must not point at a private or local address"}
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return &ValidationError{Field: "url", Message: "url must not point at a private or local address"}
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// net.IP doesn't count as private
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP returns false for loopback, private, link-local (including
+// cloud metadata at 169.254.169.254), shared, unspecified and multicast
+// addresses, which webhooks may not reach
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (ip4[0] == 0 || sharedAddressSpace.Contains(ip4)) {
+		return false
+	}
+	return true
+}
+
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return &ValidationError{Field: "events", Message: "at least one event is required"}
+	}
+	for _, e := range events {
+		if e == WebhookEventAll {
+			continue
+		}
+		known := false
+		for _, t := range WebhookEventTypes {
+			if e == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return &ValidationError{Field: "events", Message: fmt.Sprintf("unknown event %q; must be * or one of %s", e, strings.Join(WebhookEventTypes, ", "))}
+		}
+	}
+	return nil
+}
+
+func validateWebhookMaxAttempts(n *int) error {
+	if n != nil && (*n < 1 || *n > MaxWebhookMaxAttempts) {
+		return &ValidationError{Field: "max_attempts", Message: fmt.Sprintf("max_attempts must be between 1 and %d", MaxWebhookMaxAttempts)}
+	}
+	return nil
+}
+
+// WebhookEvent is the body POSTed to subscribers
+type WebhookEvent struct {
+	ID             uuid.UUID       `json:"id"` // Shared by every subscription's delivery of the event
+	Type           string          `json:"type"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery is one event queued in the outbox for one subscription
+type WebhookDelivery struct {
+	ID               uuid.UUID       `db:"id" json:"id"`
+	OrganizationID   uuid.UUID       `db:"organization_id" json:"organization_id"`
+	SubscriptionID   uuid.UUID       `db:"subscription_id" json:"subscription_id"`
+	EventID          uuid.UUID       `db:"event_id" json:"event_id"`
+	EventType        string          `db:"event_type" json:"event_type"`
+	Payload          json.RawMessage `db:"payload" json:"payload,omitempty"`
+	Status           string          `db:"status" json:"status"`
+	Attempts         int             `db:"attempts" json:"attempts"`
+	MaxAttempts      int             `db:"max_attempts" json:"max_attempts"`
+	NextAttemptAt    time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastAttemptAt    *time.Time      `db:"last_attempt_at" json:"last_attempt_at,omitempty"`
+	LastStatusCode   *int            `db:"last_status_code" json:"last_status_code,omitempty"`
+	LastError        *string         `db:"last_error" json:"last_error,omitempty"`
+	LastResponseBody *string         `db:"last_response_body" json:"last_response_body,omitempty"`
+	LastLatencyMS    *int            `db:"last_latency_ms" json:"last_latency_ms,omitempty"`
+	DeliveredAt      *time.Time      `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
+
+	// Set when claimed for sending
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookDeliveryFilter represents filter options for listing deliveries
+type WebhookDeliveryFilter struct {
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	Status         *string    `json:"status,omitempty"`
+	Page           int        `json:"page"`
+	PerPage        int        `json:"per_page"`
+}
+
+// SetDefaults sets default values for the filter
+func (f *WebhookDeliveryFilter) SetDefaults() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PerPage < 1 || f.PerPage > 100 {
+		f.PerPage = 50
+	}
+}
+
+// Offset returns the offset for pagination
+func (f *WebhookDeliveryFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TicketTransition is a status change a ticket's workflow permits
+type TicketTransition struct {
+	From    TicketStatus       `json:"from"`
+	To      TicketStatus       `json:"to"`
+	Roles   []string           `json:"roles,omitempty"`   // Empty lets anyone who can edit the ticket make it
+	Effects []TransitionEffect `json:"effects,omitempty"` // What else happens, for clients to show
+}
+
+// TransitionEffect is something done alongside a status change
+type TransitionEffect string
+
+const (
+	// The submitted time, a snapshot of the ticket, its approval plan and a
+	// revision are recorded
+	TransitionEffectRecordSubmission TransitionEffect = "record_submission"
+	// Approvals are requested for the plan's requirements
+	TransitionEffectRequestApprovals TransitionEffect = "request_approvals"
+	// Approvals still pending are expired
+	TransitionEffectExpireApprovals TransitionEffect = "expire_approvals"
+	// The cancellation reason is recorded
+	TransitionEffectRecordReason TransitionEffect = "record_reason"
+	// The completed time is recorded, for sprint burndowns
+	TransitionEffectRecordCompletion TransitionEffect = "record_completion"
+	// The closed time is recorded
+	TransitionEffectRecordClosure TransitionEffect = "record_closure"
+)
+
+var (
+	submitEffects   = []TransitionEffect{TransitionEffectRecordSubmission, TransitionEffectRequestApprovals}
+	decisionEffects = []TransitionEffect{TransitionEffectExpireApprovals}
+	cancelEffects   = []TransitionEffect{TransitionEffectRecordReason}
+)
+
+// DefaultTicketTransitions is the workflow every organization starts with.
+// Approval outcomes are applied by approvals and board decisions; the rest
+// are made through the ticket endpoints and integrations.
+var DefaultTicketTransitions = []TicketTransition{
+	{From: TicketStatusDraft, To: TicketStatusSubmitted, Effects: submitEffects},
+	{From: TicketStatusDraft, To: TicketStatusApproved, Effects: []TransitionEffect{TransitionEffectRecordSubmission}}, // Auto-approved on submit
+	{From: TicketStatusDraft, To: TicketStatusCancelled, Effects: cancelEffects},
+	{From: TicketStatusUpdateRequested, To: TicketStatusSubmitted, Effects: submitEffects},
+	{From: TicketStatusUpdateRequested, To: TicketStatusApproved, Effects: []TransitionEffect{TransitionEffectRecordSubmission}},
+	{From: TicketStatusSubmitted, To: TicketStatusInReview, Roles: []string{string(UserRoleApprover), string(UserRoleAdmin)}},
+	{From: TicketStatusSubmitted, To: TicketStatusPartiallyApproved},
+	{From: TicketStatusSubmitted, To: TicketStatusApproved, Effects: decisionEffects},
+	{From: TicketStatusSubmitted, To: TicketStatusDenied, Effects: decisionEffects},
+	{From: TicketStatusSubmitted, To: TicketStatusUpdateRequested, Effects: decisionEffects},
+	{From: TicketStatusSubmitted, To: TicketStatusCancelled, Effects: cancelEffects},
+	{From: TicketStatusInReview, To: TicketStatusPartiallyApproved},
+	{From: TicketStatusInReview, To: TicketStatusApproved, Effects: decisionEffects},
+	{From: TicketStatusInReview, To: TicketStatusDenied, Effects: decisionEffects},
+	{From: TicketStatusInReview, To: TicketStatusUpdateRequested, Effects: decisionEffects},
+	{From: TicketStatusPartiallyApproved, To: TicketStatusInReview},
+	{From: TicketStatusPartiallyApproved, To: TicketStatusApproved, Effects: decisionEffects},
+	{From: TicketStatusPartiallyApproved, To: TicketStatusDenied, Effects: decisionEffects},
+	{From: TicketStatusPartiallyApproved, To: TicketStatusUpdateRequested, Effects: decisionEffects},
+	{From: TicketStatusApproved, To: TicketStatusImplementing},
+	{From: TicketStatusImplementing, To: TicketStatusCompleted, Effects: []TransitionEffect{TransitionEffectRecordCompletion}},
+	{From: TicketStatusCompleted, To: TicketStatusClosed, Effects: []TransitionEffect{TransitionEffectRecordClosure}},
+	{From: TicketStatusClosed, To: TicketStatusUpdateRequested}, // Reopen
+}
+
+// TransitionMap holds a workflow's transitions by their from and to
+// statuses
+type TransitionMap map[TicketStatus]map[TicketStatus]TicketTransition
+
+// Transitions is the default workflow. Stores, handlers and the CLI check
+// status changes against it, or against an organization's TicketWorkflow,
+// which adds to it.
+var Transitions = NewTransitionMap(DefaultTicketTransitions)
+
+// NewTransitionMap builds a map from lists of transitions. A later
+// transition between the same statuses replaces an earlier one.
+func NewTransitionMap(lists ...[]TicketTransition) TransitionMap {
+	m := make(TransitionMap)
+	for _, list := range lists {
+		for _, t := range list {
+			m.Add(t)
+		}
+	}
+	return m
+}
+
+// Add adds a transition to the map
+func (m TransitionMap) Add(t TicketTransition) {
+	if m[t.From] == nil {
+		m[t.From] = make(map[TicketStatus]TicketTransition)
+	}
+	m[t.From][t.To] = t
+}
+
+// Get returns the transition between the statuses, if there is one
+func (m TransitionMap) Get(from, to TicketStatus) (TicketTransition, bool) {
+	t, ok := m[from][to]
+	return t, ok
+}
+
+// Allows returns true if there is a transition between the statuses
+func (m TransitionMap) Allows(from, to TicketStatus) bool {
+	_, ok := m[from][to]
+	return ok
+}
+
+// Validate returns an error unless a ticket may move between the statuses
+// for an actor holding the roles: a TransitionError if there is no such
+// transition, or ErrForbidden if the transition is limited to roles the
+// actor doesn't hold. Nil roles stand for system callers such as
+// integrations, which roles don't restrict.
+func (m TransitionMap) Validate(from, to TicketStatus, actorRoles []string) error {
+	t, ok := m[from][to]
+	if !ok {
+		return &TransitionError{From: from, To: to}
+	}
+	if len(t.Roles) == 0 || actorRoles == nil {
+		return nil
+	}
+	for _, want := range t.Roles {
+		for _, have := range actorRoles {
+			if want == have {
+				return nil
+			}
+		}
+	}
+	return Forbidden("insufficient ticket permissions")
+}
+
+// Next returns the statuses a ticket in the status may move to
+func (m TransitionMap) Next(from TicketStatus) []TicketStatus {
+	var next []TicketStatus
+	for _, to := range TicketStatuses {
+		if m.Allows(from, to) {
+			next = append(next, to)
+		}
+	}
+	return next
+}
+
+// TransitionError is returned when a ticket's workflow has no transition
+// between two statuses
+type TransitionError struct {
+	From TicketStatus `json:"from"`
+	To   TicketStatus `json:"to"`
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("ticket cannot move from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// TransitionGuard can veto a transition the workflow otherwise allows. The
+// actor is nil for system callers.
+type TransitionGuard func(ticket *Ticket, to TicketStatus, actor *TicketAccessor) error
+
+// TransitionEngine decides which status changes a ticket may make
+type TransitionEngine struct {
+	transitions TransitionMap
+	guards      map[TicketStatus]map[TicketStatus][]TransitionGuard
+}
+
+// NewTransitionEngine builds an engine from the default transitions plus an
+// organization's extra ones
+func NewTransitionEngine(extra []TicketTransition) *TransitionEngine {
+	return &TransitionEngine{
+		transitions: NewTransitionMap(DefaultTicketTransitions, extra),
+		guards:      make(map[TicketStatus]map[TicketStatus][]TransitionGuard),
+	}
+}
+
+// DefaultTransitionEngine returns an engine for the default workflow
+func DefaultTransitionEngine() *TransitionEngine {
+	return NewTransitionEngine(nil)
+}
+
+// Allow adds a transition
+func (e *TransitionEngine) Allow(t TicketTransition) {
+	e.transitions.Add(t)
+}
+
+// Guard registers a check run whenever a ticket makes the transition
+func (e *TransitionEngine) Guard(from, to TicketStatus, guard TransitionGuard) {
+	if e.guards[from] == nil {
+		e.guards[from] = make(map[TicketStatus][]TransitionGuard)
+	}
+	e.guards[from][to] = append(e.guards[from][to], guard)
+}
+
+// Allows returns true if the workflow has a transition between the statuses
+func (e *TransitionEngine) Allows(from, to TicketStatus) bool {
+	return e.transitions.Allows(from, to)
+}
+
+// Transitions returns the engine's workflow
+func (e *TransitionEngine) Transitions() TransitionMap {
+	return e.transitions
+}
+
+// Check returns an error unless the ticket may move to the status
+func (e *TransitionEngine) Check(ticket *Ticket, to TicketStatus, actor *TicketAccessor) error {
+	var roles []string
+	if actor != nil {
+		roles = append([]string{}, actor.Roles...)
+	}
+	if err := e.transitions.Validate(ticket.Status, to, roles); err != nil {
+		return err
+	}
+	for _, guard := range e.guards[ticket.Status][to] {
+		if err := guard(ticket, to, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Next returns the statuses a ticket in the status may move to
+func (e *TransitionEngine) Next(from TicketStatus) []TicketStatus {
+	return e.transitions.Next(from)
+}
+
+// TicketWorkflow is an organization's additions to the default workflow
+type TicketWorkflow struct {
+	OrganizationID uuid.UUID          `db:"organization_id" json:"organization_id"`
+	Transitions    []TicketTransition `db:"transitions" json:"transitions"`
+	UpdatedBy      *uuid.UUID         `db:"updated_by" json:"updated_by,omitempty"`
+	CreatedAt      time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `db:"updated_at" json:"updated_at"`
+}
+
+// Engine returns the transition engine for the organization's workflow
+func (w *TicketWorkflow) Engine() *TransitionEngine {
+	return NewTransitionEngine(w.Transitions)
+}
+
+// MaxWorkflowTransitions bounds the extra transitions an organization may add
+const MaxWorkflowTransitions = 100
+
+// UpdateTicketWorkflowInput replaces an organization's extra transitions
+type UpdateTicketWorkflowInput struct {
+	Transitions []TicketTransition `json:"transitions"`
+}
+
+// Validate checks the extra transitions. Approval outcomes can only be
+// reached through approvals, so they can't be added as targets.
+func (i *UpdateTicketWorkflowInput) Validate() error {
+	if len(i.Transitions) > MaxWorkflowTransitions {
+		return &ValidationError{Field: "transitions", Message: fmt.Sprintf("at most %d transitions are allowed", MaxWorkflowTransitions)}
+	}
+
+	defaults := DefaultTransitionEngine()
+	seen := make(map[[2]TicketStatus]bool, len(i.Transitions))
+	for _, t := range i.Transitions {
+		if !t.From.Valid() {
+			return &ValidationError{Field: "transitions", Message: "invalid status: " + string(t.From)}
+		}
+		if !t.To.Valid() {
+			return &ValidationError{Field: "transitions", Message: "invalid status: " + string(t.To)}
+		}
+		if t.From == t.To {
+			return &ValidationError{Field: "transitions", Message: "a transition must change the status: " + string(t.From)}
+		}
+		switch t.To {
+		case TicketStatusApproved, TicketStatusPartiallyApproved, TicketStatusDenied:
+			return &ValidationError{Field: "transitions", Message: string(t.To) + " can only be reached through approvals"}
+		}
+		if defaults.Allows(t.From, t.To) {
+			return &ValidationError{Field: "transitions", Message: fmt.Sprintf("%s to %s is already part of the default workflow", t.From, t.To)}
+		}
+		key := [2]TicketStatus{t.From, t.To}
+		if seen[key] {
+			return &ValidationError{Field: "transitions", Message: fmt.Sprintf("duplicate transition: %s to %s", t.From, t.To)}
+		}
+		seen[key] = true
+		for _, role := range t.Roles {
+			if !UserRole(role).Valid() {
+				return &ValidationError{Field: "transitions", Message: "invalid role: " + role}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxWorklogHours bounds a single worklog entry to one day of work
+const MaxWorklogHours = 24
+
+// Worklog is time a user spent on a ticket
+type Worklog struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	TicketID       uuid.UUID `db:"ticket_id" json:"ticket_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	Hours          float64   `db:"hours" json:"hours"`
+	WorkDate       time.Time `db:"work_date" json:"work_date"`
+	Description    *string   `db:"description" json:"description,omitempty"`
+	Billable       bool      `db:"billable" json:"billable"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+
+	// Relationships
+	User *UserSummary `db:"-" json:"user,omitempty"`
+}
+
+// CanDelete checks if a user can delete this worklog
+func (w *Worklog) CanDelete(userID uuid.UUID, isAdmin bool) bool {
+	return w.UserID == userID || isAdmin
+}
+
+// CreateWorklogInput represents input for logging time on a ticket
+type CreateWorklogInput struct {
+	Hours       float64 `json:"hours" validate:"required,gt=0,max=24"`
+	WorkDate    string  `json:"work_date,omitempty"` // YYYY-MM-DD, defaults to today
+	Description *string `json:"description,omitempty"`
+	Billable    *bool   `json:"billable,omitempty"` // Defaults to true
+
+	Date time.Time `json:"-"`
+}
+
+// Validate checks the worklog, rounds its hours to the hundredths the
+// database keeps and fills in the work date and billable defaults
+func (i *CreateWorklogInput) Validate(now time.Time) error {
+	i.Hours = math.Round(i.Hours*100) / 100
+	if i.Hours <= 0 {
+		return &ValidationError{Field: "hours", Message: "hours must be greater than 0"}
+	}
+	if i.Hours > MaxWorklogHours {
+		return &ValidationError{Field: "hours", Message: "hours must be at most 24"}
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	i.Date = today
+	if i.WorkDate != "" {
+		d, err := time.Parse("2006-01-02", i.WorkDate)
+		if err != nil {
+			return &ValidationError{Field: "work_date", Message: "work_date must be YYYY-MM-DD"}
+		}
+		// Allow a day of slack for users ahead of UTC
+		if d.After(today.AddDate(0, 0, 1)) {
+			return &ValidationError{Field: "work_date", Message: "work_date must not be in the future"}
+		}
+		i.Date = d
+	}
+
+	if i.Billable == nil {
+		billable := true
+		i.Billable = &billable
+	}
+	return nil
+}
+
+// WorklogReportFilter narrows a worklog report
+type WorklogReportFilter struct {
+	From       *time.Time // Inclusive work date
+	To         *time.Time // Exclusive work date
+	UserID     *uuid.UUID
+	CustomerID *uuid.UUID // Only tickets for this customer
+	ProjectID  *uuid.UUID
+}
+
+// WorklogUserTotal is the time one user logged over a report's period
+type WorklogUserTotal struct {
+	User          UserSummary `json:"user"`
+	Hours         float64     `json:"hours"`
+	BillableHours float64     `json:"billable_hours"`
+	TicketCount   int         `json:"ticket_count"`
+	EntryCount    int         `json:"entry_count"`
+}
+
+// TimeRemaining compares time spent against the estimate. Remaining is nil
+// without an estimate and negative once the ticket is over it.
+func TimeRemaining(estimate, spent *float64) (remaining *float64, overEstimate bool) {
+	if estimate == nil {
+		return nil, false
+	}
+	r := *estimate
+	if spent != nil {
+		r -= *spent
+	}
+	r = math.Round(r*100) / 100
+	return &r, r < 0
+}
@@ -0,0 +1,228 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// upcomingScanLimit bounds the scheduled tickets checked for a recipient's
+// hosts
+const upcomingScanLimit = 500
+
+// Digester compiles and queues users' daily and weekly digests
+type Digester struct {
+	store   *store.Store
+	baseURL string
+}
+
+// NewDigester creates a digester linking tickets under baseURL
+func NewDigester(s *store.Store, baseURL string) *Digester {
+	return &Digester{store: s, baseURL: baseURL}
+}
+
+// DigestResult summarizes one digest run
+type DigestResult struct {
+	Queued  int
+	Skipped int // Due but with nothing to report
+	Failed  int
+}
+
+// Run queues the digests that are due. Each is scheduled at the user's
+// local digest time; one not sent within models.DigestSendWindow of it is
+// skipped. A digest with nothing to report is recorded as sent without
+// being queued. A user whose digest can't be compiled is counted and the
+// rest carry on; the first error is returned. The digests compiled are
+// queued together, so if that fails they all count as failed.
+func (d *Digester) Run(ctx context.Context, now time.Time) (*DigestResult, error) {
+	result := &DigestResult{}
+
+	recipients, err := d.store.Notifications.ListDigestRecipients(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	run := &digestRun{
+		resources: make(map[uuid.UUID][]models.AffectedResource),
+		owners:    make(map[int][]string),
+	}
+	var queued []models.QueuedDigest
+	var firstErr error
+	for i := range recipients {
+		r := &recipients[i]
+		scheduledAt, due := r.Preferences.DigestDue(now)
+		if !due {
+			continue
+		}
+
+		digest, err := d.compile(ctx, run, r, scheduledAt)
+		if err != nil {
+			result.Failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to compile digest for %s: %w", r.Email, err)
+			}
+			continue
+		}
+		q := models.QueuedDigest{Recipient: r, ScheduledAt: scheduledAt}
+		if !digest.Empty() {
+			msg := DigestEmail(r, digest, d.baseURL)
+			q.Message = &msg
+		}
+		queued = append(queued, q)
+	}
+
+	if err := d.store.Notifications.QueueDigests(ctx, queued); err != nil {
+		result.Failed += len(queued)
+		if firstErr == nil {
+			firstErr = fmt.Errorf("failed to queue digests: %w", err)
+		}
+		return result, firstErr
+	}
+	for _, q := range queued {
+		if q.Message == nil {
+			result.Skipped++
+		} else {
+			result.Queued++
+		}
+	}
+	return result, firstErr
+}
+
+// digestRun caches what recipients of one run share: the hosts each
+// ticket affects and the owners of each host
+type digestRun struct {
+	resources map[uuid.UUID][]models.AffectedResource
+	owners    map[int][]string
+}
+
+// compile gathers the digest scheduled for a recipient at scheduledAt. It
+// covers the period up to scheduledAt, and changes scheduled over the
+// period that follows it.
+func (d *Digester) compile(ctx context.Context, run *digestRun, r *models.DigestRecipient, scheduledAt time.Time) (*models.Digest, error) {
+	prefs := &r.Preferences
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	digest := &models.Digest{
+		Frequency: prefs.DigestFrequency,
+		From:      scheduledAt.Add(-prefs.Period()),
+		To:        scheduledAt,
+		Location:  loc,
+	}
+
+	if prefs.DigestApprovals {
+		digest.Approvals, err = d.store.Approvals.ListAwaitingApprover(ctx, r.OrganizationID, r.UserID, models.MaxDigestItems)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if prefs.DigestStatusChanges {
+		digest.StatusChanges, err = d.store.Audit.ListStatusChangesForCreator(ctx, r.OrganizationID, r.UserID, digest.From, digest.To, models.MaxDigestItems)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if prefs.DigestUpcoming {
+		digest.Upcoming, err = d.upcoming(ctx, run, r, scheduledAt, scheduledAt.Add(prefs.Period()))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return digest, nil
+}
+
+// upcoming lists changes scheduled in [from, to] that the recipient can see
+// and that affect an inventory host listing them as an owner, by email or
+// username
+func (d *Digester) upcoming(ctx context.Context, run *digestRun, r *models.DigestRecipient, from, to time.Time) ([]models.DigestScheduledChange, error) {
+	names := map[string]bool{strings.ToLower(r.Email): true}
+	if r.Username != nil && *r.Username != "" {
+		names[strings.ToLower(*r.Username)] = true
+	}
+
+	// Scheduled tickets are listed as the recipient, so confidential
+	// tickets they can't see stay out of their digest
+	asUser := store.WithAccessor(ctx, &models.TicketAccessor{UserID: r.UserID, Roles: r.Roles})
+	tickets, err := d.store.Tickets.ListScheduled(asUser, r.OrganizationID, nil, from, to, upcomingScanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var upcoming []models.DigestScheduledChange
+	for i := range tickets {
+		t := &tickets[i]
+		resources, err := run.affectedResources(ctx, d.store, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := run.loadOwners(ctx, d.store, resources); err != nil {
+			return nil, err
+		}
+
+		var hosts []string
+		for _, res := range resources {
+			for _, owner := range run.owners[res.ResourceID] {
+				if names[strings.ToLower(owner)] {
+					hosts = append(hosts, res.Hostname)
+					break
+				}
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+
+		upcoming = append(upcoming, models.DigestScheduledChange{
+			TicketNumber:   t.TicketNumber,
+			Title:          t.Title,
+			Status:         t.Status,
+			ScheduledStart: *t.ScheduledStart,
+			ScheduledEnd:   t.ScheduledEnd,
+			Hosts:          hosts,
+		})
+		if len(upcoming) == models.MaxDigestItems {
+			break
+		}
+	}
+	return upcoming, nil
+}
+
+func (run *digestRun) affectedResources(ctx context.Context, s *store.Store, ticketID uuid.UUID) ([]models.AffectedResource, error) {
+	if resources, ok := run.resources[ticketID]; ok {
+		return resources, nil
+	}
+	resources, err := s.Tickets.ListAffectedResources(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	run.resources[ticketID] = resources
+	return resources, nil
+}
+
+// loadOwners looks up the owners of any of the hosts not already cached
+func (run *digestRun) loadOwners(ctx context.Context, s *store.Store, resources []models.AffectedResource) error {
+	var missing []int
+	for _, res := range resources {
+		if _, ok := run.owners[res.ResourceID]; !ok {
+			missing = append(missing, res.ResourceID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	owners, err := s.Inventory.OwnersByResource(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for _, id := range missing {
+		run.owners[id] = owners[id] // nil marks a host without owners as looked up
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/ses"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+const (
+	// feedbackBatches bounds the queue reads per run so a backlog is worked
+	// through over several runs
+	feedbackBatches = 20
+	// feedbackWait is how long each read waits for a message to arrive
+	feedbackWait = 5 * time.Second
+)
+
+// FeedbackQueue is the queue SES bounce and complaint notifications arrive on
+type FeedbackQueue interface {
+	Receive(ctx context.Context, max int, wait time.Duration) ([]ses.QueueMessage, error)
+	Delete(ctx context.Context, receiptHandle string) error
+}
+
+// FeedbackConsumer applies SES bounce and complaint notifications
+type FeedbackConsumer struct {
+	store *store.Store
+	queue FeedbackQueue
+}
+
+// NewFeedbackConsumer creates a consumer reading from queue
+func NewFeedbackConsumer(s *store.Store, queue FeedbackQueue) *FeedbackConsumer {
+	return &FeedbackConsumer{store: s, queue: queue}
+}
+
+// FeedbackResult summarizes one consumer run
+type FeedbackResult struct {
+	Received   int
+	Bounced    int64 // Notifications marked bounced
+	Suppressed int   // Addresses no longer sent to
+	Flagged    int64 // Users flagged for review
+	Invalid    int   // Messages that were not SES notifications, discarded
+}
+
+// Consume reads notifications until the queue is empty. Bounces mark the
+// notifications they belong to bounced. Hard bounces and complaints also
+// suppress the address and flag its users for an admin to review.
+//
+// A message is deleted once applied, or straight away if it cannot be
+// decoded; one that fails to apply is left for SQS to deliver again.
+func (c *FeedbackConsumer) Consume(ctx context.Context) (*FeedbackResult, error) {
+	result := &FeedbackResult{}
+
+	for i := 0; i < feedbackBatches; i++ {
+		messages, err := c.queue.Receive(ctx, 10, feedbackWait)
+		if err != nil {
+			return result, err
+		}
+		if len(messages) == 0 {
+			return result, nil
+		}
+		result.Received += len(messages)
+
+		for _, msg := range messages {
+			fb, err := ses.ParseFeedback([]byte(msg.Body))
+			if err != nil {
+				result.Invalid++
+			} else if err := c.apply(ctx, fb, result); err != nil {
+				return result, fmt.Errorf("failed to apply SES %s for message %s: %w", strings.ToLower(fb.Type), fb.MessageID, err)
+			}
+			if err := c.queue.Delete(ctx, msg.ReceiptHandle); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *FeedbackConsumer) apply(ctx context.Context, fb *ses.Feedback, result *FeedbackResult) error {
+	var reason, flag string
+	switch {
+	case fb.Type == ses.FeedbackComplaint:
+		reason = models.EmailSuppressionComplaint
+		flag = "Recipient marked a notification as spam"
+	case fb.Permanent():
+		reason = models.EmailSuppressionBounce
+		flag = "Notifications to this address hard-bounced"
+	}
+
+	for _, r := range fb.Recipients {
+		if fb.Type == ses.FeedbackBounce {
+			message := "SES " + strings.ToLower(fb.BounceType) + " bounce"
+			if fb.Detail != "" {
+				message += " (" + fb.Detail + ")"
+			}
+			if r.Diagnostic != "" {
+				message += ": " + r.Diagnostic
+			}
+			n, err := c.store.Notifications.MarkMessageBounced(ctx, fb.MessageID, r.Email, message)
+			if err != nil {
+				return err
+			}
+			result.Bounced += n
+		}
+
+		if reason == "" {
+			continue
+		}
+		detail := fb.Detail
+		if r.Diagnostic != "" {
+			detail = strings.TrimPrefix(detail+": "+r.Diagnostic, ": ")
+		}
+		if err := c.store.Notifications.Suppress(ctx, r.Email, reason, detail, fb.MessageID); err != nil {
+			return err
+		}
+		result.Suppressed++
+		n, err := c.store.Users.FlagEmail(ctx, r.Email, flag)
+		if err != nil {
+			return err
+		}
+		result.Flagged += n
+	}
+	return nil
+}
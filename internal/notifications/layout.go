@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// The queued bodies are fragments; these layouts wrap them in the branded
+// email sent to recipients
+var (
+	htmlLayout = htmltemplate.Must(htmltemplate.New("email.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin:0;padding:0;background:#f4f5f7;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#172b4d;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background:#f4f5f7;padding:24px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:6px;">
+<tr><td style="padding:20px 32px;border-bottom:1px solid #dfe1e6;font-size:16px;font-weight:600;">{{.CompanyName}} Change Management</td></tr>
+<tr><td style="padding:24px 32px;font-size:14px;line-height:1.5;">{{.Body}}</td></tr>
+<tr><td style="padding:16px 32px;border-top:1px solid #dfe1e6;font-size:12px;color:#6b778c;">You are receiving this email because of your role in {{.CompanyName}} change management.</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+	textLayout = texttemplate.Must(texttemplate.New("email.txt").Parse(`{{.Body}}
+--
+{{.CompanyName}} Change Management
+You are receiving this email because of your role in {{.CompanyName}} change management.
+`))
+)
+
+// RenderEmail wraps a queued notification's HTML and text bodies in the
+// email layout. The HTML body was escaped when the notification was
+// rendered and is inserted as-is.
+func RenderEmail(companyName, subject, bodyHTML, bodyText string) (string, string, error) {
+	var htmlOut bytes.Buffer
+	err := htmlLayout.Execute(&htmlOut, struct {
+		CompanyName string
+		Subject     string
+		Body        htmltemplate.HTML
+	}{companyName, subject, htmltemplate.HTML(bodyHTML)})
+	if err != nil {
+		return "", "", err
+	}
+
+	var textOut bytes.Buffer
+	err = textLayout.Execute(&textOut, struct {
+		CompanyName string
+		Body        string
+	}{companyName, bodyText})
+	if err != nil {
+		return "", "", err
+	}
+
+	return htmlOut.String(), textOut.String(), nil
+}
@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sendLimiter spaces sends evenly so they stay under a rate. Only the
+// leader worker sends, so a limiter per process limits the deployment.
+type sendLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newSendLimiter allows perSecond sends a second; zero or less is no limit
+func newSendLimiter(perSecond float64) *sendLimiter {
+	if perSecond <= 0 {
+		return &sendLimiter{}
+	}
+	return &sendLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the next send is allowed or ctx is done
+func (l *sendLimiter) Wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/ses"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+const (
+	// processBatchSize bounds the notifications claimed per run
+	processBatchSize = 50
+	// sendLease is how long a claimed notification is held before another
+	// worker may claim it again
+	sendLease = 5 * time.Minute
+	// maxSendTime bounds how long the claimed notifications take to send
+	// at the configured send rate, well inside the lease and job timeout
+	maxSendTime = time.Minute
+)
+
+// Mailer sends one email and returns the provider's message ID
+type Mailer interface {
+	SendEmail(ctx context.Context, msg *ses.Message) (string, error)
+}
+
+// Processor delivers queued notifications
+type Processor struct {
+	store    *store.Store
+	mailer   Mailer
+	email    config.EmailConfig
+	batching *store.NotificationBatching
+	limiter  *sendLimiter
+}
+
+// NewProcessor creates a processor sending through mailer
+func NewProcessor(s *store.Store, mailer Mailer, email config.EmailConfig) *Processor {
+	p := &Processor{store: s, mailer: mailer, email: email, limiter: newSendLimiter(email.MaxSendRate)}
+	if email.BatchWindowMinutes > 0 {
+		p.batching = &store.NotificationBatching{
+			Types:  models.BatchedNotificationTypes,
+			Window: time.Duration(email.BatchWindowMinutes) * time.Minute,
+			Max:    email.BatchMax,
+		}
+	}
+	return p
+}
+
+// ProcessResult summarizes one processing run. Counts are of
+// notifications; Batched of those sent together with others.
+type ProcessResult struct {
+	Sent       int
+	Retried    int
+	Failed     int
+	Bounced    int
+	Suppressed int // Not sent because the address hard-bounced or complained
+	Batched    int
+}
+
+// Process claims due notifications and sends them. Comment notifications
+// held for a recipient are sent as one email. Transient failures are
+// retried with exponential backoff until the notification's max attempts
+// are used up; messages SES rejects outright are marked bounced, and other
+// permanent errors failed. Notifications to suppressed addresses are marked
+// bounced without being sent. Sends are spaced to stay under the
+// configured send rate.
+func (p *Processor) Process(ctx context.Context, now time.Time) (*ProcessResult, error) {
+	result := &ProcessResult{}
+
+	batch, err := p.store.Notifications.ClaimBatch(ctx, p.claimLimit(), sendLease, p.batching)
+	if err != nil {
+		return result, err
+	}
+
+	for _, group := range p.group(batch) {
+		sup, err := p.store.Notifications.GetSuppression(ctx, group[0].Email)
+		if err != nil {
+			return result, err
+		}
+		if sup != nil {
+			for _, n := range group {
+				if err := p.store.Notifications.MarkBounced(ctx, n.ID,
+					"recipient address is suppressed after a "+sup.Reason); err != nil {
+					return result, err
+				}
+				result.Suppressed++
+			}
+			continue
+		}
+
+		if err := p.limiter.Wait(ctx); err != nil {
+			return result, err
+		}
+		messageID, sendErr := p.send(ctx, group)
+		if sendErr == nil {
+			for _, n := range group {
+				if err := p.store.Notifications.MarkSent(ctx, n.ID, messageID); err != nil {
+					return result, err
+				}
+				result.Sent++
+			}
+			if len(group) > 1 {
+				result.Batched += len(group)
+			}
+			continue
+		}
+
+		var apiErr *ses.APIError
+		isAPIErr := errors.As(sendErr, &apiErr)
+		for _, n := range group {
+			switch {
+			case isAPIErr && apiErr.Rejected():
+				err = p.store.Notifications.MarkBounced(ctx, n.ID, sendErr.Error())
+				result.Bounced++
+			case (isAPIErr && !apiErr.Retryable()) || n.Attempts >= n.MaxAttempts:
+				err = p.store.Notifications.MarkFailed(ctx, n.ID, sendErr.Error())
+				result.Failed++
+			default:
+				retryAt := now.Add(models.NotificationRetryDelay(n.Attempts))
+				err = p.store.Notifications.MarkRetry(ctx, n.ID, sendErr.Error(), retryAt)
+				result.Retried++
+			}
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// claimLimit caps a run's claim at what can be sent within maxSendTime
+func (p *Processor) claimLimit() int {
+	if p.email.MaxSendRate <= 0 {
+		return processBatchSize
+	}
+	limit := int(p.email.MaxSendRate * maxSendTime.Seconds())
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > processBatchSize {
+		limit = processBatchSize
+	}
+	return limit
+}
+
+// group splits claimed notifications into emails: one per notification,
+// except batched types to the same recipient, which share an email of at
+// most batch_max. Emails keep the order of their first notification.
+func (p *Processor) group(batch []models.NotificationQueue) [][]*models.NotificationQueue {
+	var groups [][]*models.NotificationQueue
+	open := make(map[string]int)
+	for i := range batch {
+		n := &batch[i]
+		if p.batching == nil || !isBatched(n.NotificationType) {
+			groups = append(groups, []*models.NotificationQueue{n})
+			continue
+		}
+		key := n.OrganizationID.String() + "/" + strings.ToLower(n.Email)
+		if g, ok := open[key]; ok && (p.batching.Max <= 0 || len(groups[g]) < p.batching.Max) {
+			groups[g] = append(groups[g], n)
+			continue
+		}
+		open[key] = len(groups)
+		groups = append(groups, []*models.NotificationQueue{n})
+	}
+	return groups
+}
+
+func isBatched(notificationType string) bool {
+	for _, t := range models.BatchedNotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Processor) send(ctx context.Context, group []*models.NotificationQueue) (string, error) {
+	subject, html, text := group[0].Subject, group[0].BodyHTML, group[0].BodyText
+	if len(group) > 1 {
+		msg := NotificationBatch(group)
+		subject, html, text = msg.Subject, msg.BodyHTML, msg.BodyText
+	}
+	bodyHTML, bodyText, err := RenderEmail(p.email.CompanyName, subject, html, text)
+	if err != nil {
+		return "", err
+	}
+	return p.mailer.SendEmail(ctx, &ses.Message{
+		From:     p.email.From,
+		ReplyTo:  p.email.ReplyTo,
+		To:       group[0].Email,
+		Subject:  subject,
+		BodyHTML: bodyHTML,
+		BodyText: bodyText,
+	})
+}
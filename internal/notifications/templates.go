@@ -0,0 +1,326 @@
+package notifications
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// TicketURL returns the web URL for a ticket
+func TicketURL(baseURL string, ticket *models.Ticket) string {
+	return ticketNumberURL(baseURL, ticket.TicketNumber)
+}
+
+func ticketNumberURL(baseURL, ticketNumber string) string {
+	return fmt.Sprintf("%s/tickets/%s", strings.TrimRight(baseURL, "/"), ticketNumber)
+}
+
+// EmergencyApproval renders the notification sent to on-call approvers when
+// an emergency ticket is submitted
+func EmergencyApproval(ticket *models.Ticket, baseURL string) models.NotificationMessage {
+	subject := fmt.Sprintf("[EMERGENCY] Approval needed: %s %s", ticket.TicketNumber, ticket.Title)
+	deadline := "within 1 hour"
+	if ticket.ApprovalDeadline != nil {
+		deadline = "by " + ticket.ApprovalDeadline.UTC().Format("2006-01-02 15:04 MST")
+	}
+	link := TicketURL(baseURL, ticket)
+
+	text := fmt.Sprintf(
+		"An emergency change has been submitted and needs your approval %s.\n\n"+
+			"Ticket:   %s\nTitle:    %s\nRisk:     %s\n\n%s\n",
+		deadline, ticket.TicketNumber, ticket.Title, ticket.RiskLevel, link,
+	)
+	body := fmt.Sprintf(
+		"<p>An emergency change has been submitted and needs your approval <strong>%s</strong>.</p>"+
+			"<p><strong>%s</strong>: %s<br>Risk: %s</p><p><a href=\"%s\">Review ticket</a></p>",
+		html.EscapeString(deadline), html.EscapeString(ticket.TicketNumber),
+		html.EscapeString(ticket.Title), html.EscapeString(string(ticket.RiskLevel)), link,
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeEmergencyApproval,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityEmergency,
+	}
+}
+
+// EmergencyEscalation renders the notification sent when an emergency ticket
+// passes its approval deadline without a decision
+func EmergencyEscalation(ticket *models.Ticket, level int, baseURL string) models.NotificationMessage {
+	subject := fmt.Sprintf("[EMERGENCY][ESCALATION %d] %s is awaiting approval", level, ticket.TicketNumber)
+	link := TicketURL(baseURL, ticket)
+
+	text := fmt.Sprintf(
+		"Emergency change %s (%s) has passed its approval deadline without a decision.\n"+
+			"This is escalation level %d of %d.\n\n%s\n",
+		ticket.TicketNumber, ticket.Title, level, models.MaxEmergencyEscalationLevel, link,
+	)
+	body := fmt.Sprintf(
+		"<p>Emergency change <strong>%s</strong> (%s) has passed its approval deadline without a decision.</p>"+
+			"<p>This is escalation level %d of %d.</p><p><a href=\"%s\">Review ticket</a></p>",
+		html.EscapeString(ticket.TicketNumber), html.EscapeString(ticket.Title),
+		level, models.MaxEmergencyEscalationLevel, link,
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeEmergencyEscalation,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityEmergency,
+	}
+}
+
+// ApprovalRequest renders the notification asking an approver to decide
+// one of a submitted ticket's approvals. The link decides it without
+// signing in while the approval's token is good.
+func ApprovalRequest(ticket *models.Ticket, approval *models.Approval, baseURL string) models.NotificationMessage {
+	subject := fmt.Sprintf("Approval needed: %s %s", ticket.TicketNumber, ticket.Title)
+	link := TicketURL(baseURL, ticket)
+	if approval.ApprovalToken != nil {
+		link = fmt.Sprintf("%s/approvals/token/%s", strings.TrimRight(baseURL, "/"), url.PathEscape(*approval.ApprovalToken))
+	}
+	approvalType := approval.ApprovalType.DisplayName()
+
+	text := fmt.Sprintf(
+		"A change has been submitted and needs your %s approval.\n\n"+
+			"Ticket:   %s\nTitle:    %s\nRisk:     %s\n\n%s\n",
+		approvalType, ticket.TicketNumber, ticket.Title, ticket.RiskLevel, link,
+	)
+	body := fmt.Sprintf(
+		"<p>A change has been submitted and needs your %s approval.</p>"+
+			"<p><strong>%s</strong>: %s<br>Risk: %s</p><p><a href=\"%s\">Review and decide</a></p>",
+		html.EscapeString(approvalType), html.EscapeString(ticket.TicketNumber),
+		html.EscapeString(ticket.Title), html.EscapeString(string(ticket.RiskLevel)), html.EscapeString(link),
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeApprovalRequest,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityNormal,
+	}
+}
+
+// CABDecision renders the notification telling a ticket's creator what the
+// change management board decided on it
+func CABDecision(ticket *models.Ticket, decision *models.CABTicketDecision, baseURL string) models.NotificationMessage {
+	verdict := strings.ReplaceAll(string(decision.Decision), "_", " ")
+	subject := fmt.Sprintf("Change management board decision on %s: %s", ticket.TicketNumber, verdict)
+	link := TicketURL(baseURL, ticket)
+
+	var notes, notesHTML string
+	if decision.Comment != "" {
+		notes += fmt.Sprintf("Comment:    %s\n", decision.Comment)
+		notesHTML += fmt.Sprintf("<br>Comment: %s", html.EscapeString(decision.Comment))
+	}
+	if decision.Conditions != "" {
+		notes += fmt.Sprintf("Conditions: %s\n", decision.Conditions)
+		notesHTML += fmt.Sprintf("<br>Conditions: %s", html.EscapeString(decision.Conditions))
+	}
+
+	text := fmt.Sprintf(
+		"The change management board's decision on your change is: %s.\n\n"+
+			"Ticket:     %s\nTitle:      %s\nStatus:     %s\n%s\n%s\n",
+		verdict, ticket.TicketNumber, ticket.Title, ticket.Status, notes, link,
+	)
+	body := fmt.Sprintf(
+		"<p>The change management board's decision on your change is: <strong>%s</strong>.</p>"+
+			"<p><strong>%s</strong>: %s<br>Status: %s%s</p><p><a href=\"%s\">View ticket</a></p>",
+		html.EscapeString(verdict), html.EscapeString(ticket.TicketNumber), html.EscapeString(ticket.Title),
+		html.EscapeString(string(ticket.Status)), notesHTML, link,
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeApprovalDecision,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityNormal,
+	}
+}
+
+// EmailVerification renders the message asking a new organization's first
+// admin to confirm their email address
+func EmailVerification(user *models.User, org *models.Organization, token, baseURL string) models.NotificationMessage {
+	subject := fmt.Sprintf("Confirm your email for %s", org.Name)
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(baseURL, "/"), url.QueryEscape(token))
+	hours := int(models.EmailVerificationTTL.Hours())
+
+	text := fmt.Sprintf(
+		"Hi %s,\n\nYour organization %s has been created. Confirm your email address "+
+			"within %d hours to finish setting up your account:\n\n%s\n",
+		user.FullName, org.Name, hours, link,
+	)
+	body := fmt.Sprintf(
+		"<p>Hi %s,</p><p>Your organization <strong>%s</strong> has been created. "+
+			"Confirm your email address within %d hours to finish setting up your account.</p>"+
+			"<p><a href=\"%s\">Confirm email</a></p>",
+		html.EscapeString(user.FullName), html.EscapeString(org.Name), hours, html.EscapeString(link),
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeEmailVerification,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityNormal,
+	}
+}
+
+// DigestEmail renders a user's daily or weekly digest. Times are shown in
+// the digest's timezone.
+func DigestEmail(r *models.DigestRecipient, digest *models.Digest, baseURL string) models.NotificationMessage {
+	period := "Daily"
+	if digest.Frequency == models.DigestWeekly {
+		period = "Weekly"
+	}
+	localTime := func(t time.Time) string {
+		return t.In(digest.Location).Format("Mon Jan 2 15:04 MST")
+	}
+	subject := fmt.Sprintf("%s change digest for %s", period, digest.To.In(digest.Location).Format("Mon Jan 2"))
+
+	var text, body strings.Builder
+	fmt.Fprintf(&text, "Hi %s,\n\nHere is your %s change digest.\n", r.FullName, strings.ToLower(period))
+	fmt.Fprintf(&body, "<p>Hi %s,</p><p>Here is your %s change digest.</p>",
+		html.EscapeString(r.FullName), strings.ToLower(period))
+
+	section := func(title string, count int) {
+		fmt.Fprintf(&text, "\n%s (%d)\n", title, count)
+		fmt.Fprintf(&body, "<h3>%s (%d)</h3><ul>", html.EscapeString(title), count)
+	}
+	item := func(number, title, detail string) {
+		link := ticketNumberURL(baseURL, number)
+		fmt.Fprintf(&text, "- %s %s: %s\n  %s\n", number, title, detail, link)
+		fmt.Fprintf(&body, "<li><a href=\"%s\">%s</a> %s<br>%s</li>",
+			html.EscapeString(link), html.EscapeString(number), html.EscapeString(title), html.EscapeString(detail))
+	}
+
+	if len(digest.Approvals) > 0 {
+		section("Awaiting your approval", len(digest.Approvals))
+		for _, a := range digest.Approvals {
+			detail := fmt.Sprintf("%s approval, %s risk, requested %s", a.ApprovalType, a.RiskLevel, localTime(a.RequestedAt))
+			if a.Deadline != nil {
+				detail += ", due " + localTime(*a.Deadline)
+			}
+			item(a.TicketNumber, a.Title, detail)
+		}
+		body.WriteString("</ul>")
+	}
+	if len(digest.StatusChanges) > 0 {
+		section("Your tickets that changed status", len(digest.StatusChanges))
+		for _, c := range digest.StatusChanges {
+			item(c.TicketNumber, c.Title, fmt.Sprintf("%s → %s at %s", c.From, c.To, localTime(c.ChangedAt)))
+		}
+		body.WriteString("</ul>")
+	}
+	if len(digest.Upcoming) > 0 {
+		section("Upcoming changes on your systems", len(digest.Upcoming))
+		for _, u := range digest.Upcoming {
+			detail := "starts " + localTime(u.ScheduledStart)
+			if u.ScheduledEnd != nil {
+				detail += ", ends " + localTime(*u.ScheduledEnd)
+			}
+			detail += "; hosts: " + strings.Join(u.Hosts, ", ")
+			item(u.TicketNumber, u.Title, detail)
+		}
+		body.WriteString("</ul>")
+	}
+
+	text.WriteString("\nTo change or stop this digest, update your notification preferences.\n")
+	body.WriteString("<p>To change or stop this digest, update your notification preferences.</p>")
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeDigest,
+		Subject:          subject,
+		BodyHTML:         body.String(),
+		BodyText:         text.String(),
+		Priority:         models.NotificationPriorityNormal,
+	}
+}
+
+// ReportReady renders the message telling a user their report has been
+// generated, with a signed link that downloads it until linkExpires
+func ReportReady(job *models.ReportJob, link string, linkExpires time.Time) models.NotificationMessage {
+	title := job.Title()
+	subject := fmt.Sprintf("Your %s report is ready", strings.ToLower(title))
+	period := fmt.Sprintf("%s to %s", job.Parameters.From.UTC().Format("2006-01-02"), job.Parameters.To.UTC().Format("2006-01-02"))
+	expires := linkExpires.UTC().Format("2006-01-02 15:04 MST")
+
+	text := fmt.Sprintf(
+		"Your %s report (%s, %s) has been generated.\n\n"+
+			"Download it before %s:\n\n%s\n\n"+
+			"After that, request a new link from the reports API.\n",
+		strings.ToLower(title), period, strings.ToUpper(job.Format), expires, link,
+	)
+	body := fmt.Sprintf(
+		"<p>Your %s report (%s, %s) has been generated.</p>"+
+			"<p><a href=\"%s\">Download report</a></p>"+
+			"<p>This link works until %s. After that, request a new link from the reports API.</p>",
+		html.EscapeString(strings.ToLower(title)), html.EscapeString(period),
+		html.EscapeString(strings.ToUpper(job.Format)), html.EscapeString(link), html.EscapeString(expires),
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeReportReady,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityNormal,
+	}
+}
+
+// ReportFailed renders the message telling a user their report could not
+// be generated
+func ReportFailed(job *models.ReportJob, reason string) models.NotificationMessage {
+	title := job.Title()
+	subject := fmt.Sprintf("Your %s report could not be generated", strings.ToLower(title))
+
+	text := fmt.Sprintf(
+		"Your %s report could not be generated after %d attempts:\n\n%s\n\n"+
+			"Please try again, or contact your administrator if it keeps failing.\n",
+		strings.ToLower(title), job.Attempts, reason,
+	)
+	body := fmt.Sprintf(
+		"<p>Your %s report could not be generated after %d attempts:</p><p><code>%s</code></p>"+
+			"<p>Please try again, or contact your administrator if it keeps failing.</p>",
+		html.EscapeString(strings.ToLower(title)), job.Attempts, html.EscapeString(reason),
+	)
+
+	return models.NotificationMessage{
+		NotificationType: models.NotificationTypeReportFailed,
+		Subject:          subject,
+		BodyHTML:         body,
+		BodyText:         text,
+		Priority:         models.NotificationPriorityNormal,
+	}
+}
+
+// NotificationBatch combines several notifications to one recipient, such
+// as a burst of comments, into one message
+func NotificationBatch(batch []*models.NotificationQueue) models.NotificationMessage {
+	subject := fmt.Sprintf("%d new comments and mentions", len(batch))
+
+	var text, body strings.Builder
+	fmt.Fprintf(&text, "You have %d new notifications.\n", len(batch))
+	fmt.Fprintf(&body, "<p>You have %d new notifications.</p>", len(batch))
+	for _, n := range batch {
+		fmt.Fprintf(&text, "\n----\n%s\n\n%s\n", n.Subject, strings.TrimSpace(n.BodyText))
+		fmt.Fprintf(&body, "<hr><h3>%s</h3>%s", html.EscapeString(n.Subject), n.BodyHTML)
+	}
+
+	return models.NotificationMessage{
+		NotificationType: batch[0].NotificationType,
+		Subject:          subject,
+		BodyHTML:         body.String(),
+		BodyText:         text.String(),
+		Priority:         batch[0].Priority,
+	}
+}
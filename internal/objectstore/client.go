@@ -0,0 +1,175 @@
+// Package objectstore writes objects to Amazon S3 or an S3-compatible store
+// such as OCI Object Storage.
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/pkg/awsauth"
+)
+
+// Client is a minimal S3 client signing requests with AWS Signature
+// Version 4
+type Client struct {
+	creds     awsauth.Credentials
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	http      *http.Client
+}
+
+// NewClient creates a client for a bucket. Region and keys not set on the
+// bucket fall back to the AWS configuration.
+func NewClient(cfg *config.ObjectStoreConfig, aws *config.AWSConfig) (*Client, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("object storage bucket is required")
+	}
+
+	merged := *aws
+	if cfg.Region != "" {
+		merged.Region = cfg.Region
+	}
+	if cfg.AccessKeyID != "" {
+		merged.AccessKeyID = cfg.AccessKeyID
+		merged.SecretAccessKey = cfg.SecretAccessKey
+	}
+	creds, err := awsauth.Load(&merged)
+	if err != nil {
+		return nil, fmt.Errorf("object storage: %w", err)
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + creds.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid object storage endpoint %q", endpoint)
+	}
+
+	return &Client{
+		creds:     creds,
+		endpoint:  u,
+		bucket:    cfg.Bucket,
+		pathStyle: cfg.PathStyle,
+		http:      &http.Client{Timeout: 30 * time.Minute},
+	}, nil
+}
+
+// Object is one object to upload
+type Object struct {
+	Key         string
+	Body        io.Reader
+	Size        int64
+	SHA256      string // Hex SHA-256 of the body, signed with the request
+	ContentMD5  string // Base64 MD5 of the body, required with Object Lock
+	ContentType string
+	Metadata    map[string]string // Sent as x-amz-meta-* headers
+	RetainUntil time.Time         // Compliance-mode Object Lock; zero for none
+}
+
+// APIError is a non-2xx response from the object store
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("object storage returned %d: %s", e.StatusCode, e.Body)
+}
+
+// PutObject uploads an object in a single request
+func (c *Client) PutObject(ctx context.Context, obj *Object) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.objectURL(obj.Key), obj.Body)
+	if err != nil {
+		return fmt.Errorf("failed to build object storage request: %w", err)
+	}
+	req.ContentLength = obj.Size
+	if obj.ContentType != "" {
+		req.Header.Set("Content-Type", obj.ContentType)
+	}
+	if obj.ContentMD5 != "" {
+		req.Header.Set("Content-MD5", obj.ContentMD5)
+	}
+	for name, value := range obj.Metadata {
+		req.Header.Set("X-Amz-Meta-"+name, value)
+	}
+	if !obj.RetainUntil.IsZero() {
+		req.Header.Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+		req.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", obj.RetainUntil.UTC().Format(time.RFC3339))
+	}
+	c.creds.Sign(req, obj.SHA256, "s3", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("object storage request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// PresignGet returns a URL that downloads key without credentials until
+// expires passes. A filename makes browsers save the object under that
+// name.
+func (c *Client) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.objectURL(key), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build object storage request: %w", err)
+	}
+	if filename != "" {
+		query := req.URL.Query()
+		query.Set("response-content-disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		req.URL.RawQuery = query.Encode()
+	}
+	return c.creds.Presign(req, "s3", expires, time.Now()), nil
+}
+
+// DeleteObject deletes an object. Deleting one that doesn't exist succeeds.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.objectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build object storage request: %w", err)
+	}
+	c.creds.Sign(req, awsauth.HashPayload(nil), "s3", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("object storage request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < 200 || resp.StatusCode >= 300) && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// objectURL returns the URL of a key, in path style or with the bucket in
+// the host name
+func (c *Client) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	path := strings.Join(segments, "/")
+
+	if c.pathStyle {
+		return c.endpoint.Scheme + "://" + c.endpoint.Host + "/" + url.PathEscape(c.bucket) + "/" + path
+	}
+	return c.endpoint.Scheme + "://" + c.bucket + "." + c.endpoint.Host + "/" + path
+}
@@ -0,0 +1,353 @@
+// Package pdf writes simple text documents, such as meeting minutes and
+// ticket exports, as PDF. It supports headings, wrapped paragraphs, tables
+// and automatic page breaks using the standard Helvetica fonts, so no fonts
+// are embedded. UTF-8 text is converted to the fonts' Windows-1252 character
+// set.
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ContentType is the MIME type of a PDF document
+const ContentType = "application/pdf"
+
+// US Letter, in points
+const (
+	pageWidth  = 612.0
+	pageHeight = 792.0
+	margin     = 54.0
+)
+
+// bottom is the lowest a line of body text may sit, leaving room for the
+// page footer
+const bottom = margin + 20
+
+// headingKeep is the space a heading needs below it for at least a few lines
+// of its section
+const headingKeep = 60
+
+// Helvetica averages about half an em per character; wrapping a little
+// early keeps lines inside the margin
+const charWidth = 0.52
+
+type font string
+
+const (
+	regular font = "F1"
+	bold    font = "F2"
+)
+
+// Document is a PDF document built top to bottom. Each page is kept as its
+// content stream.
+type Document struct {
+	title string
+	pages []*strings.Builder
+	y     float64
+}
+
+// New starts a document with the given title, shown in viewers' title bars
+// and each page footer
+func New(title string) *Document {
+	d := &Document{title: title}
+	d.newPage()
+	return d
+}
+
+// Title writes the document title in large bold type
+func (d *Document) Title(text string) {
+	d.write(text, bold, 16, 0, 8)
+}
+
+// Heading writes a section heading. A heading too close to the bottom of
+// the page starts the next one, so it isn't left apart from its section.
+func (d *Document) Heading(text string) {
+	d.Space()
+	if d.y < bottom+headingKeep {
+		d.newPage()
+	}
+	d.write(text, bold, 12, 0, 4)
+}
+
+// Text writes a paragraph, wrapping it to the page width
+func (d *Document) Text(text string) {
+	d.write(text, regular, 10, 0, 2)
+}
+
+// Field writes a label followed by its value on the same line
+func (d *Document) Field(label, value string) {
+	d.write(label+": "+value, regular, 10, 0, 2)
+}
+
+// Bullet writes an indented paragraph
+func (d *Document) Bullet(text string) {
+	d.write("- "+text, regular, 10, 12, 2)
+}
+
+// Space adds a blank line
+func (d *Document) Space() {
+	d.y -= 10
+}
+
+// Table writes rows under a bold header row. widths are the columns'
+// relative widths and must match the header's length. Cells wrap within
+// their column; a row that doesn't fit on the page moves to the next one,
+// under a repeated header.
+func (d *Document) Table(header []string, widths []float64, rows [][]string) {
+	var total float64
+	for _, w := range widths {
+		total += w
+	}
+	cols := make([]float64, len(widths))
+	for i, w := range widths {
+		cols[i] = w / total * (pageWidth - 2*margin)
+	}
+
+	d.Space()
+	if d.y < bottom+headingKeep {
+		d.newPage()
+	}
+	d.tableRow(header, cols, bold, true)
+	for _, row := range rows {
+		if !d.tableRow(row, cols, regular, false) {
+			d.newPage()
+			d.tableRow(header, cols, bold, true)
+			d.tableRow(row, cols, regular, false)
+		}
+	}
+	d.y -= 6
+}
+
+// tableRow draws one row at the cursor. It returns false, drawing nothing,
+// when a body row doesn't fit on the current page. A row taller than a
+// whole page is cut off rather than split.
+func (d *Document) tableRow(cells []string, cols []float64, f font, header bool) bool {
+	const size, leading, pad = 9.0, 11.0, 3.0
+
+	lines := make([][]string, len(cols))
+	height := 0
+	for i, w := range cols {
+		text := ""
+		if i < len(cells) {
+			text = cells[i]
+		}
+		for _, paragraph := range strings.Split(text, "\n") {
+			lines[i] = append(lines[i], wrap(paragraph, int((w-2*pad)/(size*charWidth)))...)
+		}
+		height = max(height, len(lines[i]))
+	}
+
+	var usable float64 = pageHeight - margin - bottom - 2*pad
+	maxLines := int(usable / leading)
+	height = min(height, maxLines)
+	h := float64(height)*leading + 2*pad
+	if d.y-h < bottom && !header {
+		return false
+	}
+
+	page := d.pages[len(d.pages)-1]
+	x := margin
+	for i, w := range cols {
+		if header {
+			fmt.Fprintf(page, "0.93 g %.2f %.2f %.2f %.2f re f 0 g\n", x, d.y-h, w, h)
+		}
+		fmt.Fprintf(page, "0.6 G 0.5 w %.2f %.2f %.2f %.2f re S 0 G\n", x, d.y-h, w, h)
+		for j, l := range lines[i] {
+			if j == height {
+				break
+			}
+			baseline := d.y - pad - size - float64(j)*leading + 2
+			fmt.Fprintf(page, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", f, size, x+pad, baseline, escape(l))
+		}
+		x += w
+	}
+	d.y -= h
+	return true
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &strings.Builder{})
+	d.y = pageHeight - margin
+}
+
+func (d *Document) write(text string, f font, size, indent, after float64) {
+	maxChars := int((pageWidth - 2*margin - indent) / (size * charWidth))
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, l := range wrap(paragraph, maxChars) {
+			if d.y-size < bottom {
+				d.newPage()
+			}
+			d.y -= size
+			fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n",
+				f, size, margin+indent, d.y, escape(l))
+			d.y -= 2
+		}
+	}
+	d.y -= after
+}
+
+// wrap splits text into lines of at most n characters, breaking at spaces
+// where possible
+func wrap(text string, n int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	current := ""
+	for _, w := range words {
+		for len([]rune(w)) > n {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			r := []rune(w)
+			lines = append(lines, string(r[:n]))
+			w = string(r[n:])
+		}
+		switch {
+		case current == "":
+			current = w
+		case len([]rune(current))+1+len([]rune(w)) <= n:
+			current += " " + w
+		default:
+			lines = append(lines, current)
+			current = w
+		}
+	}
+	return append(lines, current)
+}
+
+// WriteTo writes the document as PDF
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	var offsets []int64
+	object := func(body string) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are the catalog, page tree, two fonts and info; each page
+	// then takes two objects, the page and its content stream
+	first := 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", first+2*i)
+	}
+
+	cw.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (adsops-utils) >>", escape(d.title)))
+
+	for i, page := range d.pages {
+		var content strings.Builder
+		content.WriteString(page.String())
+		footer := fmt.Sprintf("%s - page %d of %d", d.title, i+1, len(d.pages))
+		fmt.Fprintf(&content, "BT /F1 8 Tf %.2f %.2f Td (%s) Tj ET\n", margin, margin-10, escape(footer))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, first+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// escape encodes text as a PDF string body in WinAnsiEncoding, the
+// Windows-1252 superset of Latin-1 the standard fonts use. Accented letters
+// outside it lose their accents, a few common symbols get ASCII stand-ins,
+// and anything else becomes '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t' || r == '\u00a0':
+			b.WriteByte(' ')
+		case r < 0x20 || r == 0x7f:
+			b.WriteByte('?')
+		default:
+			b.WriteString(winAnsi(r))
+		}
+	}
+	return b.String()
+}
+
+// substitutes are ASCII stand-ins for symbols WinAnsiEncoding lacks
+var substitutes = map[rune]string{
+	'→': "->", '←': "<-", '⇒': "=>", '↔': "<->",
+	'≤': "<=", '≥': ">=", '≠': "!=", '−': "-",
+	'✓': "v", '✔': "v", '✗': "x", '✘': "x",
+	'\u2010': "-", '\u2011': "-",
+	// Letters with strokes, which don't decompose
+	'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d", 'Ħ': "H", 'ħ': "h", 'ı': "i",
+}
+
+// winAnsi returns r as WinAnsiEncoding bytes
+func winAnsi(r rune) string {
+	if b, ok := charmap.Windows1252.EncodeRune(r); ok {
+		return string([]byte{b})
+	}
+	if sub, ok := substitutes[r]; ok {
+		return sub
+	}
+
+	// Decompose, so "ł" or "ő" can still be shown as their base letter
+	var out []byte
+	for _, d := range norm.NFKD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		b, ok := charmap.Windows1252.EncodeRune(d)
+		if !ok || b < 0x20 {
+			return "?"
+		}
+		out = append(out, b)
+	}
+	if len(out) == 0 {
+		return "?"
+	}
+	return string(out)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+func (c *countingWriter) WriteString(s string) (int, error) {
+	return c.Write([]byte(s))
+}
@@ -0,0 +1,40 @@
+// Package awsauth loads AWS credentials and signs requests with AWS
+// Signature Version 4 for the SES, SQS and S3 clients.
+package awsauth
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+)
+
+// Credentials are the region and keys requests are signed with
+type Credentials struct {
+	Region       string
+	AccessKeyID  string
+	SecretKey    string
+	SessionToken string
+}
+
+// Load reads the region and keys from the AWS configuration, falling back
+// to the standard AWS_* environment variables
+func Load(cfg *config.AWSConfig) (Credentials, error) {
+	creds := Credentials{
+		Region:       cfg.Region,
+		AccessKeyID:  cfg.AccessKeyID,
+		SecretKey:    cfg.SecretAccessKey,
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" {
+		creds.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		creds.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if creds.Region == "" {
+		creds.Region = os.Getenv("AWS_REGION")
+	}
+	if creds.AccessKeyID == "" || creds.SecretKey == "" || creds.Region == "" {
+		return creds, fmt.Errorf("an AWS region and credentials are required")
+	}
+	return creds, nil
+}
@@ -0,0 +1,190 @@
+// Package queuebot assigns tickets waiting in the queue using each
+// organization's assignment rules. It runs when a ticket is submitted and
+// on a schedule from the worker, and records every assignment it makes in
+// the ticket's audit log.
+package queuebot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// Bot applies assignment rules to unassigned tickets
+type Bot struct {
+	store *store.Store
+}
+
+// New creates a queue bot
+func New(s *store.Store) *Bot {
+	return &Bot{store: s}
+}
+
+// Run assigns the tickets in an organization's queue, oldest first. Tickets
+// no rule can assign are reported with the reason and left in the queue.
+func (b *Bot) Run(ctx context.Context, orgID uuid.UUID, trigger models.AssignmentTrigger) ([]models.AssignmentResult, error) {
+	rules, err := b.store.AssignmentRules.ListActive(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	results := []models.AssignmentResult{}
+	if len(rules) == 0 {
+		return results, nil
+	}
+
+	tickets, err := b.store.Tickets.GetQueue(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tickets {
+		result, err := b.assign(ctx, &tickets[i], rules, trigger)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// AssignTicket assigns a single ticket, such as one that has just been
+// submitted. Tickets that already have an assignee or aren't in the queue
+// are left alone.
+func (b *Bot) AssignTicket(ctx context.Context, ticket *models.Ticket, trigger models.AssignmentTrigger) (*models.AssignmentResult, error) {
+	if !ticket.AwaitingAssignment() {
+		return &models.AssignmentResult{
+			TicketID:     ticket.ID,
+			TicketNumber: ticket.TicketNumber,
+			Reason:       "ticket is not awaiting assignment",
+		}, nil
+	}
+
+	rules, err := b.store.AssignmentRules.ListActive(ctx, ticket.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	return b.assign(ctx, ticket, rules, trigger)
+}
+
+// assign tries each rule in order until one matches the ticket and has a
+// candidate. A rule whose candidates can't be looked up is skipped rather
+// than failing the run; only a failure to assign is returned as an error.
+func (b *Bot) assign(ctx context.Context, ticket *models.Ticket, rules []models.AssignmentRule, trigger models.AssignmentTrigger) (*models.AssignmentResult, error) {
+	result := &models.AssignmentResult{
+		TicketID:     ticket.ID,
+		TicketNumber: ticket.TicketNumber,
+		Reason:       "no assignment rule matches the ticket",
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Conditions.Matches(ticket) {
+			continue
+		}
+		candidates, err := b.candidates(ctx, rule, ticket)
+		if err != nil {
+			result.Reason = fmt.Sprintf("rule %q: %v", rule.Name, err)
+			continue
+		}
+		if len(candidates) == 0 {
+			result.Reason = fmt.Sprintf("rule %q found no one to assign", rule.Name)
+			continue
+		}
+
+		assignee, err := b.pick(ctx, rule, ticket.OrganizationID, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.store.AssignmentRules.Assign(ctx, ticket.OrganizationID, ticket.ID, rule.ID, assignee); err != nil {
+			if errors.Is(err, models.ErrConflict) {
+				result.Reason = err.Error()
+				return result, nil
+			}
+			return nil, err
+		}
+		// Later tickets in the same run continue the rotation from here
+		rule.LastAssignedTo = &assignee
+
+		b.store.Audit.LogSystemEvent(ctx, ticket.ID, models.AuditActionAutoAssign, map[string]interface{}{
+			"assigned_to": assignee,
+			"rule_id":     rule.ID,
+			"rule_name":   rule.Name,
+			"strategy":    rule.Strategy,
+			"trigger":     trigger,
+		})
+
+		ruleID := rule.ID
+		result.AssignedTo = &assignee
+		result.RuleID = &ruleID
+		result.RuleName = rule.Name
+		result.Strategy = rule.Strategy
+		result.Reason = ""
+		return result, nil
+	}
+
+	return result, nil
+}
+
+// candidates returns the users a rule may assign the ticket to. Group
+// strategies draw from the rule's group or the ticket's owning group; the
+// system owner strategy draws from the inventory owners of the ticket's
+// affected hosts, narrowed to the rule's group when it names one.
+func (b *Bot) candidates(ctx context.Context, rule *models.AssignmentRule, ticket *models.Ticket) ([]uuid.UUID, error) {
+	orgID := ticket.OrganizationID
+
+	if rule.Strategy != models.AssignmentStrategySystemOwner {
+		group := rule.PoolGroup(ticket)
+		if group == nil {
+			return nil, nil
+		}
+		return b.store.AssignmentRules.GroupMembers(ctx, orgID, *group)
+	}
+
+	resources, err := b.store.Tickets.ListAffectedResources(ctx, ticket.ID)
+	if err != nil || len(resources) == 0 {
+		return nil, err
+	}
+	ids := make([]int, len(resources))
+	for i, r := range resources {
+		ids[i] = r.ResourceID
+	}
+	owners, err := b.store.Inventory.Owners(ctx, ids)
+	if err != nil || len(owners) == 0 {
+		return nil, err
+	}
+	users, err := b.store.Users.ResolveActive(ctx, orgID, owners)
+	if err != nil || rule.GroupID == nil {
+		return users, err
+	}
+
+	members, err := b.store.AssignmentRules.GroupMembers(ctx, orgID, *rule.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	inGroup := make(map[uuid.UUID]bool, len(members))
+	for _, m := range members {
+		inGroup[m] = true
+	}
+	var narrowed []uuid.UUID
+	for _, u := range users {
+		if inGroup[u] {
+			narrowed = append(narrowed, u)
+		}
+	}
+	return narrowed, nil
+}
+
+func (b *Bot) pick(ctx context.Context, rule *models.AssignmentRule, orgID uuid.UUID, candidates []uuid.UUID) (uuid.UUID, error) {
+	if rule.Strategy == models.AssignmentStrategyRoundRobin {
+		return models.NextRoundRobin(candidates, rule.LastAssignedTo), nil
+	}
+	load, err := b.store.AssignmentRules.OpenLoad(ctx, orgID, candidates)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return models.LeastLoaded(candidates, load), nil
+}
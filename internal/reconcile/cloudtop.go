@@ -0,0 +1,85 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Cloudtop lists compute instances by running the cloudtop binary, which
+// holds the provider clients and their credentials
+type Cloudtop struct {
+	path   string
+	config string
+}
+
+// NewCloudtop creates a lister running the cloudtop binary at path with
+// the given cloudtop.json, or cloudtop's own default config if empty
+func NewCloudtop(path, config string) *Cloudtop {
+	return &Cloudtop{path: path, config: config}
+}
+
+// cloudtopOutput is the part of `cloudtop --json` the reconciler reads
+type cloudtopOutput struct {
+	Providers map[string]struct {
+		Resources []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Type   string `json:"type"`
+			Region string `json:"region"`
+			Status string `json:"status"`
+		} `json:"Resources"`
+	} `json:"providers"`
+	Errors map[string]string `json:"errors"`
+}
+
+// ListInstances returns every compute instance the cloudtop provider
+// reports. It fails unless the provider answered, so a provider that is
+// down or unconfigured is never mistaken for one without instances.
+func (c *Cloudtop) ListInstances(ctx context.Context, provider string) ([]models.CloudInstance, error) {
+	args := []string{"--provider", provider, "--service", "compute", "--json"}
+	if c.config != "" {
+		args = append([]string{"--config", c.config}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cloudtop failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var out cloudtopOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse cloudtop output: %w", err)
+	}
+	if msg, ok := out.Errors[provider]; ok {
+		return nil, fmt.Errorf("cloudtop %s: %s", provider, msg)
+	}
+	result, ok := out.Providers[provider]
+	if !ok {
+		// cloudtop leaves out providers it could not initialize and says
+		// why on stderr
+		return nil, fmt.Errorf("cloudtop returned no results for %s: %s", provider, strings.TrimSpace(stderr.String()))
+	}
+
+	instances := make([]models.CloudInstance, 0, len(result.Resources))
+	for _, r := range result.Resources {
+		if r.Type != "compute" {
+			continue
+		}
+		instances = append(instances, models.CloudInstance{
+			ID:     r.ID,
+			Name:   r.Name,
+			Region: r.Region,
+			Status: r.Status,
+		})
+	}
+	return instances, nil
+}
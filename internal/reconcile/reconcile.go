@@ -0,0 +1,160 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// InstanceLister lists the compute instances a cloudtop provider reports
+type InstanceLister interface {
+	ListInstances(ctx context.Context, provider string) ([]models.CloudInstance, error)
+}
+
+// Result summarizes the reconciliation of one provider
+type Result struct {
+	Provider  string
+	Hosts     int   // Inventory hosts checked
+	Instances int   // Live cloud instances checked
+	Ghosts    int   // Hosts with no instance
+	Strays    int   // Instances with no host
+	Opened    int64 // Discrepancies found for the first time
+	Resolved  int64 // Discrepancies no longer found
+}
+
+// Reconciler compares inventory_resources with the instances the cloud
+// providers report and records the differences in inventory_discrepancies
+type Reconciler struct {
+	store  *store.Store
+	lister InstanceLister
+}
+
+// NewReconciler creates a reconciler listing instances through lister
+func NewReconciler(s *store.Store, lister InstanceLister) *Reconciler {
+	return &Reconciler{store: s, lister: lister}
+}
+
+// ReconcileProvider reconciles the hosts of one inventory provider. If the
+// provider's instances can't be listed nothing is recorded, so an outage
+// doesn't turn every host into a ghost.
+func (r *Reconciler) ReconcileProvider(ctx context.Context, provider string) (*Result, error) {
+	result := &Result{Provider: provider}
+
+	cloudtopName, ok := models.CloudtopProviders[provider]
+	if !ok {
+		return result, fmt.Errorf("provider %s can't be reconciled", provider)
+	}
+
+	instances, err := r.lister.ListInstances(ctx, cloudtopName)
+	if err != nil {
+		return result, err
+	}
+	hosts, err := r.store.Inventory.ListProviderHosts(ctx, provider)
+	if err != nil {
+		return result, err
+	}
+
+	live := make([]models.CloudInstance, 0, len(instances))
+	for _, inst := range instances {
+		if !inst.Terminated() {
+			live = append(live, inst)
+		}
+	}
+	result.Hosts = len(hosts)
+	result.Instances = len(live)
+
+	found := Compare(hosts, live)
+	for _, d := range found {
+		if d.Kind == models.DiscrepancyGhost {
+			result.Ghosts++
+		} else {
+			result.Strays++
+		}
+	}
+
+	result.Opened, result.Resolved, err = r.store.Inventory.RecordDiscrepancies(ctx, provider, found)
+	return result, err
+}
+
+// Compare matches hosts to instances and returns the hosts left without an
+// instance as ghosts and the instances left without a host as strays. A
+// host matches an instance whose ID is its external ID, or failing that
+// whose name is its hostname, the hostname's first label or its resource
+// name, ignoring case.
+func Compare(hosts []models.Host, instances []models.CloudInstance) []models.InventoryDiscrepancy {
+	byID := make(map[string]int, len(instances))
+	byName := make(map[string]int, len(instances))
+	for i, inst := range instances {
+		byID[inst.ID] = i
+		if name := strings.ToLower(inst.Name); name != "" {
+			if _, dup := byName[name]; !dup {
+				byName[name] = i
+			}
+		}
+	}
+
+	matched := make([]bool, len(instances))
+	var found []models.InventoryDiscrepancy
+	for i := range hosts {
+		h := &hosts[i]
+		idx, ok := -1, false
+		if h.ExternalID != nil && *h.ExternalID != "" {
+			idx, ok = byID[*h.ExternalID]
+		}
+		if !ok {
+			idx, ok = matchName(byName, h)
+		}
+		if ok {
+			matched[idx] = true
+			continue
+		}
+
+		id, hostname := h.ID, h.Hostname
+		found = append(found, models.InventoryDiscrepancy{
+			Kind:       models.DiscrepancyGhost,
+			ResourceID: &id,
+			Hostname:   &hostname,
+			ExternalID: h.ExternalID,
+			Region:     h.Region,
+		})
+	}
+
+	for i := range instances {
+		if matched[i] {
+			continue
+		}
+		inst := instances[i]
+		found = append(found, models.InventoryDiscrepancy{
+			Kind:        models.DiscrepancyStray,
+			ExternalID:  &inst.ID,
+			CloudName:   nonEmpty(inst.Name),
+			Region:      nonEmpty(inst.Region),
+			CloudStatus: nonEmpty(inst.Status),
+		})
+	}
+	return found
+}
+
+func matchName(byName map[string]int, h *models.Host) (int, bool) {
+	hostname := strings.ToLower(h.Hostname)
+	candidates := []string{hostname, strings.ToLower(h.ResourceName)}
+	if label, _, ok := strings.Cut(hostname, "."); ok {
+		candidates = append(candidates, label)
+	}
+	for _, name := range candidates {
+		if idx, ok := byName[name]; ok && name != "" {
+			return idx, true
+		}
+	}
+	return -1, false
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
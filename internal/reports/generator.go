@@ -0,0 +1,249 @@
+// Package reports renders reports requested through the API in the
+// background, uploads them to object storage and emails the requester a
+// signed link to download them.
+package reports
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/notifications"
+	"github.com/afterdarksys/adsops-utils/internal/objectstore"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+const (
+	// runBatchSize bounds the jobs one run renders, one after another
+	runBatchSize = 5
+	// expireBatchSize bounds the files one expiry run deletes
+	expireBatchSize = 500
+	// defaultRetention applies when no retention is configured
+	defaultRetention = 30 * 24 * time.Hour
+)
+
+// Storage stores rendered reports and signs links to them
+type Storage interface {
+	PutObject(ctx context.Context, obj *objectstore.Object) error
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// Generator renders queued report jobs
+type Generator struct {
+	store     *store.Store
+	storage   Storage
+	prefix    string
+	linkTTL   time.Duration
+	retention time.Duration
+}
+
+// NewGenerator creates a generator writing under the configured prefix
+func NewGenerator(s *store.Store, storage Storage, cfg *config.ReportsConfig) *Generator {
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &Generator{
+		store:     s,
+		storage:   storage,
+		prefix:    strings.Trim(cfg.Prefix, "/"),
+		linkTTL:   LinkTTL(cfg),
+		retention: retention,
+	}
+}
+
+// LinkTTL returns how long download links last: the configured hours,
+// capped at what a presigned URL allows
+func LinkTTL(cfg *config.ReportsConfig) time.Duration {
+	ttl := time.Duration(cfg.LinkTTLHours) * time.Hour
+	if ttl <= 0 || ttl > models.MaxReportLinkTTL {
+		ttl = models.MaxReportLinkTTL
+	}
+	return ttl
+}
+
+// RunResult summarizes one generator run
+type RunResult struct {
+	Completed int
+	Retried   int
+	Failed    int // Gave up after models.ReportJobMaxAttempts
+}
+
+// Run claims queued jobs and renders them until the queue is empty or
+// runBatchSize jobs have been rendered. A job that fails to render is
+// queued again until its attempts are used up, when it is marked failed
+// and the requester is told.
+func (g *Generator) Run(ctx context.Context, now time.Time) (*RunResult, error) {
+	result := &RunResult{}
+	for i := 0; i < runBatchSize && ctx.Err() == nil; i++ {
+		job, err := g.store.ReportJobs.Claim(ctx, models.ReportJobLease)
+		if err != nil {
+			return result, err
+		}
+		if job == nil {
+			break
+		}
+
+		requester, err := g.requester(ctx, job)
+		if err != nil {
+			return result, err
+		}
+
+		out, renderErr := g.generate(ctx, job)
+		if renderErr == nil {
+			expiresAt := now.Add(g.retention)
+			ttl := g.linkTTL
+			if ttl > g.retention {
+				ttl = g.retention
+			}
+			link, err := g.storage.PresignGet(out.ObjectKey, out.Filename, ttl)
+			if err != nil {
+				renderErr = err
+			} else {
+				msg := notifications.ReportReady(job, link, now.Add(ttl))
+				if err := g.store.ReportJobs.Complete(ctx, job, out, expiresAt, requester, msg); err != nil {
+					return result, err
+				}
+				result.Completed++
+				continue
+			}
+		}
+
+		// A run cut short by shutdown is not the job's fault; its lease
+		// runs out and it is claimed again
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if job.Attempts < models.ReportJobMaxAttempts {
+			if err := g.store.ReportJobs.Requeue(ctx, job.ID, renderErr.Error()); err != nil {
+				return result, err
+			}
+			result.Retried++
+			continue
+		}
+		msg := notifications.ReportFailed(job, renderErr.Error())
+		if err := g.store.ReportJobs.Fail(ctx, job, renderErr.Error(), requester, msg); err != nil {
+			return result, err
+		}
+		result.Failed++
+	}
+	return result, nil
+}
+
+// Expire deletes the files of completed jobs past retention and marks the
+// jobs expired. It returns how many were expired.
+func (g *Generator) Expire(ctx context.Context, now time.Time) (int, error) {
+	jobs, err := g.store.ReportJobs.ListExpired(ctx, now, expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range jobs {
+		job := &jobs[i]
+		if job.ObjectKey != nil {
+			if err := g.storage.DeleteObject(ctx, *job.ObjectKey); err != nil {
+				return expired, fmt.Errorf("failed to delete report %s: %w", job.ID, err)
+			}
+		}
+		if err := g.store.ReportJobs.MarkExpired(ctx, job.ID); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// requester looks up who to tell about a job; nil if they have been
+// deleted
+func (g *Generator) requester(ctx context.Context, job *models.ReportJob) (*models.UserSummary, error) {
+	users, err := g.store.Users.GetSummaries(ctx, job.OrganizationID, []uuid.UUID{job.RequestedBy})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
+
+// generate renders a job to a temp file and uploads it
+func (g *Generator) generate(ctx context.Context, job *models.ReportJob) (*models.ReportOutput, error) {
+	tmp, err := os.CreateTemp("", "report-*."+job.Format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, hash)}
+	// Reports may span years, so their queries run with the long-running
+	// statement timeout
+	rows, contentType, err := g.render(g.store.LongRunning(ctx), job, counter)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &models.ReportOutput{
+		Filename:    filename(job),
+		ContentType: contentType,
+		SizeBytes:   counter.n,
+		RowCount:    rows,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}
+	out.ObjectKey = fmt.Sprintf("%s/%s/%s", job.OrganizationID, job.ID, out.Filename)
+	if g.prefix != "" {
+		out.ObjectKey = g.prefix + "/" + out.ObjectKey
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind report: %w", err)
+	}
+	err = g.storage.PutObject(ctx, &objectstore.Object{
+		Key:         out.ObjectKey,
+		Body:        tmp,
+		Size:        out.SizeBytes,
+		SHA256:      out.SHA256,
+		ContentType: contentType,
+		Metadata: map[string]string{
+			"Report-Job":  job.ID.String(),
+			"Report-Type": job.ReportType,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// filename names a job's file after its report type and period, e.g.
+// audit-log-2024-01-01-2024-04-01.csv
+func filename(job *models.ReportJob) string {
+	return fmt.Sprintf("%s-%s-%s.%s",
+		strings.ReplaceAll(job.ReportType, "_", "-"),
+		job.Parameters.From.UTC().Format("2006-01-02"),
+		job.Parameters.To.UTC().Format("2006-01-02"),
+		job.Format,
+	)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,228 @@
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/audit"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/pdf"
+)
+
+// maxPDFAuditRows bounds the audit log entries a PDF lists; larger
+// exports should be requested as CSV
+const maxPDFAuditRows = 5000
+
+// render writes a job's report to w, returning the rows written and the
+// file's content type
+func (g *Generator) render(ctx context.Context, job *models.ReportJob, w io.Writer) (int, string, error) {
+	if job.ReportType == models.ReportTypeAuditLog {
+		return g.renderAuditLog(ctx, job, w)
+	}
+
+	table, err := g.summary(ctx, job)
+	if err != nil {
+		return 0, "", err
+	}
+	if job.Format == models.ReportFormatPDF {
+		doc := newDocument(job)
+		if table.user != nil {
+			doc.Field("User", fmt.Sprintf("%s <%s>", table.user.FullName, table.user.Email))
+		}
+		doc.Heading(job.Title())
+		for _, row := range table.rows {
+			doc.Field(row[0], strings.Join(row[1:], ", "))
+		}
+		if len(table.rows) == 0 {
+			doc.Text("Nothing to report for this period.")
+		}
+		if _, err := doc.WriteTo(w); err != nil {
+			return 0, "", fmt.Errorf("failed to write report: %w", err)
+		}
+		return len(table.rows), pdf.ContentType, nil
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(table.header)
+	for _, row := range table.rows {
+		cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return 0, "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return len(table.rows), audit.FormatCSV.ContentType(), nil
+}
+
+// renderAuditLog writes the organization's audit log entries in the period,
+// with the CSV columns of the audit export, or one line per entry as PDF
+func (g *Generator) renderAuditLog(ctx context.Context, job *models.ReportJob, w io.Writer) (int, string, error) {
+	p := job.Parameters
+	filter := &models.AuditExportFilter{
+		FromDate:       &p.From,
+		ToDate:         &p.To,
+		Frameworks:     p.Frameworks,
+		ComplianceOnly: p.ComplianceOnly,
+		EmergencyOnly:  p.EmergencyOnly,
+	}
+
+	rows := 0
+	if job.Format == models.ReportFormatPDF {
+		filter.MaxRows = maxPDFAuditRows
+		doc := newDocument(job)
+		doc.Heading("Entries")
+		err := g.store.Audit.StreamForExport(ctx, job.OrganizationID, filter, func(entry *models.TicketAuditLog) error {
+			rows++
+			user := "system"
+			if entry.UserID != nil {
+				user = entry.UserID.String()
+			}
+			line := fmt.Sprintf("%s  %s  ticket %s  by %s",
+				entry.CreatedAt.UTC().Format("2006-01-02 15:04:05"), entry.Action, entry.TicketID, user)
+			if entry.FieldName != nil {
+				line += "  field " + *entry.FieldName
+			}
+			doc.Bullet(line)
+			return nil
+		})
+		if err != nil {
+			return 0, "", err
+		}
+		switch {
+		case rows == 0:
+			doc.Text("No entries in this period.")
+		case rows == maxPDFAuditRows:
+			doc.Space()
+			doc.Text(fmt.Sprintf("Only the first %d entries are listed. Request a CSV report for the full log.", maxPDFAuditRows))
+		}
+		if _, err := doc.WriteTo(w); err != nil {
+			return 0, "", fmt.Errorf("failed to write report: %w", err)
+		}
+		return rows, pdf.ContentType, nil
+	}
+
+	enc, err := audit.NewEncoder(audit.FormatCSV, w)
+	if err != nil {
+		return 0, "", err
+	}
+	err = g.store.Audit.StreamForExport(ctx, job.OrganizationID, filter, func(entry *models.TicketAuditLog) error {
+		rows++
+		return enc.Encode(entry)
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	if err := enc.Flush(); err != nil {
+		return 0, "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return rows, audit.FormatCSV.ContentType(), nil
+}
+
+// summaryTable is a report with one row per metric or group
+type summaryTable struct {
+	header []string
+	rows   [][]string
+	user   *models.UserSummary // The subject of a user_activity report
+}
+
+// summary computes one of the aggregate reports as a table
+func (g *Generator) summary(ctx context.Context, job *models.ReportJob) (*summaryTable, error) {
+	period := job.Parameters.Period()
+	switch job.ReportType {
+	case models.ReportTypeChangeMetrics:
+		m, err := g.store.Reports.ChangeMetrics(ctx, job.OrganizationID, period, models.ChangeMetricsScope{})
+		if err != nil {
+			return nil, err
+		}
+		return &summaryTable{
+			header: []string{"metric", "value"},
+			rows: [][]string{
+				{"total_changes", strconv.Itoa(m.TotalChanges)},
+				{"emergency_changes", strconv.Itoa(m.EmergencyChanges)},
+				{"emergency_change_ratio", formatFloat(m.EmergencyChangeRatio)},
+				{"completed_changes", strconv.Itoa(m.CompletedChanges)},
+				{"failed_changes", strconv.Itoa(m.FailedChanges)},
+				{"change_success_rate", formatFloat(m.ChangeSuccessRate)},
+				{"decided_approvals", strconv.Itoa(m.DecidedApprovals)},
+				{"mean_approval_hours", formatFloat(m.MeanApprovalHours)},
+			},
+		}, nil
+
+	case models.ReportTypeSystemChanges:
+		r, err := g.store.Reports.SystemChanges(ctx, job.OrganizationID, period)
+		if err != nil {
+			return nil, err
+		}
+		t := &summaryTable{header: []string{"system", "month", "changes"}}
+		for _, s := range r.Systems {
+			t.rows = append(t.rows, []string{s.System, s.Month, strconv.Itoa(s.Changes)})
+		}
+		return t, nil
+
+	case models.ReportTypeUserActivity:
+		if job.Parameters.UserID == nil {
+			return nil, fmt.Errorf("user_activity report has no user")
+		}
+		r, err := g.store.Reports.UserActivity(ctx, job.OrganizationID, *job.Parameters.UserID, period)
+		if err != nil {
+			return nil, err
+		}
+		t := &summaryTable{
+			header: []string{"metric", "value"},
+			rows: [][]string{
+				{"tickets_created", strconv.Itoa(r.TicketsCreated)},
+				{"tickets_submitted", strconv.Itoa(r.TicketsSubmitted)},
+				{"tickets_completed", strconv.Itoa(r.TicketsCompleted)},
+				{"open_assigned", strconv.Itoa(r.OpenAssigned)},
+				{"comments_posted", strconv.Itoa(r.CommentsPosted)},
+				{"approvals_approved", strconv.Itoa(r.ApprovalsApproved)},
+				{"approvals_denied", strconv.Itoa(r.ApprovalsDenied)},
+				{"approvals_pending", strconv.Itoa(r.ApprovalsPending)},
+				{"mean_approval_hours", formatFloat(r.MeanApprovalHours)},
+				{"hours_logged", strconv.FormatFloat(r.HoursLogged, 'f', -1, 64)},
+				{"last_activity_at", formatTime(r.LastActivityAt)},
+			},
+			user: &r.User,
+		}
+		actions := make([]string, 0, len(r.AuditEvents))
+		for action := range r.AuditEvents {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			t.rows = append(t.rows, []string{"audit_events." + action, strconv.Itoa(r.AuditEvents[action])})
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("unsupported report type: %s", job.ReportType)
+}
+
+// newDocument starts a PDF report with its title and period
+func newDocument(job *models.ReportJob) *pdf.Document {
+	doc := pdf.New(job.Title() + " report")
+	doc.Title(job.Title() + " report")
+	doc.Field("Period", fmt.Sprintf("%s to %s (UTC, end exclusive)",
+		job.Parameters.From.UTC().Format("2006-01-02 15:04"), job.Parameters.To.UTC().Format("2006-01-02 15:04")))
+	doc.Field("Generated", time.Now().UTC().Format("2006-01-02 15:04 MST"))
+	return doc
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
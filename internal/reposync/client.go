@@ -0,0 +1,202 @@
+// Package reposync refreshes repository details from the GitHub and GitLab
+// REST APIs using an organization-level API token per provider.
+package reposync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Client reads repository details from one provider's API
+type Client struct {
+	provider models.RepositoryProvider
+	baseURL  string
+	apiToken string
+	http     *http.Client
+}
+
+// NewClient creates a client for a provider's API endpoint
+func NewClient(provider models.RepositoryProvider, baseURL, apiToken string) *Client {
+	return &Client{
+		provider: provider,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		apiToken: apiToken,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is a non-2xx response from the provider
+type APIError struct {
+	Provider   models.RepositoryProvider
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API returned %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// GetRepository reads a repository's details, addressed by its clone or
+// web URL
+func (c *Client) GetRepository(ctx context.Context, repoURL string) (*models.RepositoryDetails, error) {
+	path, err := repositoryPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.provider {
+	case models.RepositoryProviderGitHub:
+		return c.getGitHubRepository(ctx, path)
+	case models.RepositoryProviderGitLab:
+		return c.getGitLabProject(ctx, path)
+	}
+	return nil, fmt.Errorf("repository sync is not supported for provider %s", c.provider)
+}
+
+func (c *Client) getGitHubRepository(ctx context.Context, path string) (*models.RepositoryDetails, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("github repository URL must be of the form owner/repo")
+	}
+
+	var resp struct {
+		Name          string  `json:"name"`
+		DefaultBranch string  `json:"default_branch"`
+		Language      *string `json:"language"`
+		Private       bool    `json:"private"`
+		Description   *string `json:"description"`
+	}
+	reqPath := "/repos/" + url.PathEscape(parts[0]) + "/" + url.PathEscape(parts[1])
+	if err := c.get(ctx, reqPath, &resp); err != nil {
+		return nil, err
+	}
+
+	return &models.RepositoryDetails{
+		Name:          resp.Name,
+		DefaultBranch: resp.DefaultBranch,
+		Language:      nonEmpty(resp.Language),
+		IsPrivate:     resp.Private,
+		Description:   nonEmpty(resp.Description),
+	}, nil
+}
+
+func (c *Client) getGitLabProject(ctx context.Context, path string) (*models.RepositoryDetails, error) {
+	var resp struct {
+		ID            int64   `json:"id"`
+		Name          string  `json:"name"`
+		DefaultBranch string  `json:"default_branch"`
+		Visibility    string  `json:"visibility"`
+		Description   *string `json:"description"`
+	}
+	if err := c.get(ctx, "/projects/"+url.PathEscape(path), &resp); err != nil {
+		return nil, err
+	}
+
+	// GitLab reports languages as percentages; the largest share is the
+	// repository's language
+	var languages map[string]float64
+	if err := c.get(ctx, fmt.Sprintf("/projects/%d/languages", resp.ID), &languages); err != nil {
+		return nil, err
+	}
+	var language *string
+	best := 0.0
+	for name, share := range languages {
+		if share > best || (share == best && language != nil && name < *language) {
+			n := name
+			language = &n
+			best = share
+		}
+	}
+
+	return &models.RepositoryDetails{
+		Name:          resp.Name,
+		DefaultBranch: resp.DefaultBranch,
+		Language:      language,
+		IsPrivate:     resp.Visibility != "public",
+		Description:   nonEmpty(resp.Description),
+	}, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", c.provider, err)
+	}
+	switch c.provider {
+	case models.RepositoryProviderGitHub:
+		req.Header.Set("Authorization", "Bearer "+c.apiToken)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	case models.RepositoryProviderGitLab:
+		req.Header.Set("PRIVATE-TOKEN", c.apiToken)
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", c.provider, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", c.provider, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := string(data)
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return &APIError{Provider: c.provider, StatusCode: resp.StatusCode, Body: msg}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", c.provider, err)
+	}
+	return nil
+}
+
+// repositoryPath extracts the owner/name path from an https or scp-style
+// (git@host:owner/name.git) repository URL
+func repositoryPath(rawURL string) (string, error) {
+	raw := strings.TrimSpace(rawURL)
+	var path string
+	if strings.HasPrefix(raw, "git@") {
+		idx := strings.Index(raw, ":")
+		if idx < 0 {
+			return "", fmt.Errorf("invalid repository URL: %s", rawURL)
+		}
+		path = raw[idx+1:]
+	} else {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid repository URL: %s", rawURL)
+		}
+		path = u.Path
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	// GitLab web URLs may carry a /-/ suffix such as /-/tree/main
+	if idx := strings.Index(path, "/-/"); idx >= 0 {
+		path = path[:idx]
+	}
+	if !strings.Contains(path, "/") {
+		return "", fmt.Errorf("repository URL has no owner/name path: %s", rawURL)
+	}
+	return path, nil
+}
+
+func nonEmpty(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package reposync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// syncBatchSize bounds the repositories synced per organization per run;
+// the least recently synced go first so large organizations rotate through
+const syncBatchSize = 200
+
+// Syncer refreshes repository details from their providers
+type Syncer struct {
+	store   *store.Store
+	clients map[clientKey]*Client
+}
+
+type clientKey struct {
+	orgID    uuid.UUID
+	provider models.RepositoryProvider
+}
+
+// NewSyncer creates a syncer. Provider clients are built from the
+// organization's sync tokens as they are first needed.
+func NewSyncer(s *store.Store) *Syncer {
+	return &Syncer{
+		store:   s,
+		clients: make(map[clientKey]*Client),
+	}
+}
+
+// SyncRepository refreshes one repository. Provider failures are recorded
+// on the repository and returned in the result error as well as err.
+func (s *Syncer) SyncRepository(ctx context.Context, repo *models.Repository) (*models.RepositorySyncResult, *models.Repository, error) {
+	result := &models.RepositorySyncResult{RepositoryID: repo.ID, Changed: []string{}}
+
+	client, err := s.client(ctx, repo.OrganizationID, repo.Provider)
+	if err != nil {
+		return result, nil, err
+	}
+
+	details, err := client.GetRepository(ctx, repo.URL)
+	if err != nil {
+		result.Error = err.Error()
+		if recErr := s.store.Repositories.RecordSyncError(ctx, repo.OrganizationID, repo.ID, result.Error); recErr != nil {
+			return result, nil, recErr
+		}
+		return result, nil, err
+	}
+
+	result.Changed = changedFields(repo, details)
+
+	updated, err := s.store.Repositories.ApplySync(ctx, repo.OrganizationID, repo.ID, details)
+	if err != nil {
+		return result, nil, err
+	}
+	return result, updated, nil
+}
+
+// SyncOrganization refreshes an organization's active repositories on
+// providers it has a token for. Per-repository provider failures are
+// counted in the run rather than aborting it.
+func (s *Syncer) SyncOrganization(ctx context.Context, orgID uuid.UUID) (*models.RepositorySyncRun, error) {
+	run := &models.RepositorySyncRun{OrganizationID: orgID, Results: []models.RepositorySyncResult{}}
+
+	repos, err := s.store.Repositories.ListSyncable(ctx, orgID, syncBatchSize)
+	if err != nil {
+		return run, err
+	}
+
+	for i := range repos {
+		result, _, err := s.SyncRepository(ctx, &repos[i])
+		run.Results = append(run.Results, *result)
+		if err != nil {
+			if result.Error == "" {
+				return run, err
+			}
+			run.Failed++
+			continue
+		}
+		run.Synced++
+		if len(result.Changed) > 0 {
+			run.Changed++
+		}
+	}
+
+	return run, nil
+}
+
+func (s *Syncer) client(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) (*Client, error) {
+	if !provider.Syncable() {
+		return nil, fmt.Errorf("repository sync is not supported for provider %s", provider)
+	}
+	key := clientKey{orgID: orgID, provider: provider}
+	if c, ok := s.clients[key]; ok {
+		return c, nil
+	}
+	token, err := s.store.Repositories.GetSyncToken(ctx, orgID, provider)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClient(provider, token.APIBaseURL, token.APIToken)
+	s.clients[key] = c
+	return c, nil
+}
+
+func changedFields(repo *models.Repository, d *models.RepositoryDetails) []string {
+	changed := []string{}
+	if repo.Name != d.Name {
+		changed = append(changed, "name")
+	}
+	if repo.DefaultBranch != d.DefaultBranch {
+		changed = append(changed, "default_branch")
+	}
+	if !equalStringPtr(repo.Language, d.Language) {
+		changed = append(changed, "language")
+	}
+	if repo.IsPrivate != d.IsPrivate {
+		changed = append(changed, "is_private")
+	}
+	if !equalStringPtr(repo.Description, d.Description) {
+		changed = append(changed, "description")
+	}
+	return changed
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
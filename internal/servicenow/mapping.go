@@ -0,0 +1,206 @@
+package servicenow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ServiceNow change_request state values, by code and label. Changes still
+// open in ServiceNow are imported as drafts so their approvals are gathered
+// here; only finished changes keep their status.
+var ticketStatuses = map[string]models.TicketStatus{
+	"-5": models.TicketStatusDraft, "new": models.TicketStatusDraft,
+	"-4": models.TicketStatusDraft, "assess": models.TicketStatusDraft,
+	"-3": models.TicketStatusDraft, "authorize": models.TicketStatusDraft,
+	"-2": models.TicketStatusDraft, "scheduled": models.TicketStatusDraft,
+	"-1": models.TicketStatusDraft, "implement": models.TicketStatusDraft,
+	"0": models.TicketStatusCompleted, "review": models.TicketStatusCompleted,
+	"3": models.TicketStatusClosed, "closed": models.TicketStatusClosed,
+	"closed complete": models.TicketStatusClosed, "closed incomplete": models.TicketStatusClosed,
+	"closed skipped": models.TicketStatusClosed,
+	"4":              models.TicketStatusCancelled, "canceled": models.TicketStatusCancelled,
+	"cancelled": models.TicketStatusCancelled,
+}
+
+var ticketPriorities = map[string]models.TicketPriority{
+	"1": models.TicketPriorityUrgent, "critical": models.TicketPriorityUrgent,
+	"2": models.TicketPriorityHigh, "high": models.TicketPriorityHigh,
+	"3": models.TicketPriorityNormal, "moderate": models.TicketPriorityNormal,
+	"4": models.TicketPriorityLow, "low": models.TicketPriorityLow,
+	"5": models.TicketPriorityLow, "planning": models.TicketPriorityLow,
+}
+
+var riskLevels = map[string]models.RiskLevel{
+	"1": models.RiskLevelCritical, "very high": models.RiskLevelCritical,
+	"2": models.RiskLevelHigh, "high": models.RiskLevelHigh,
+	"3": models.RiskLevelMedium, "moderate": models.RiskLevelMedium,
+	"4": models.RiskLevelLow, "low": models.RiskLevelLow,
+}
+
+// Timestamp layouts tried in order; layouts without an offset are read in
+// the import's location
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Ticket maps a change_request record to a ticket import. The ServiceNow
+// number becomes the ticket's external reference and the full record is
+// kept under custom_fields.servicenow.
+func Ticket(rec Record, opts *models.ServiceNowImportOptions) (*models.ImportTicketInput, error) {
+	number := rec.Get("number")
+	if number == "" {
+		return nil, &models.ValidationError{Field: "number", Message: "number is required"}
+	}
+
+	title := rec.Get("short_description")
+	if utf8.RuneCountInString(title) < 5 {
+		return nil, &models.ValidationError{Field: "short_description", Message: "short_description must be at least 5 characters"}
+	}
+	if utf8.RuneCountInString(title) > 500 {
+		title = string([]rune(title)[:500])
+	}
+	description := rec.Get("description")
+	if utf8.RuneCountInString(description) < 10 {
+		description = strings.TrimSpace(fmt.Sprintf("Imported from ServiceNow %s: %s\n\n%s", number, title, description))
+	}
+
+	status, err := lookup(ticketStatuses, "state", rec.Get("state"), "")
+	if err != nil {
+		return nil, err
+	}
+	changeType := strings.ToLower(rec.Get("type"))
+	priority, err := lookup(ticketPriorities, "priority", rec.Get("priority"), models.TicketPriorityNormal)
+	if err != nil {
+		return nil, err
+	}
+	if changeType == "emergency" {
+		priority = models.TicketPriorityEmergency
+	}
+	risk, err := lookup(riskLevels, "risk", rec.Get("risk"), models.RiskLevelMedium)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[string]*time.Time)
+	for _, field := range []string{"opened_at", "sys_created_on", "sys_updated_on", "closed_at", "start_date", "end_date", "work_start", "work_end"} {
+		t, err := parseTime(rec.Get(field), opts.Location)
+		if err != nil {
+			return nil, &models.ValidationError{Field: field, Message: err.Error()}
+		}
+		times[field] = t
+	}
+	opened := firstTime(times["opened_at"], times["sys_created_on"])
+	if opened == nil {
+		return nil, &models.ValidationError{Field: "opened_at", Message: "opened_at is required"}
+	}
+	updated := firstTime(times["sys_updated_on"], times["closed_at"], opened)
+
+	input := &models.ImportTicketInput{
+		CreateTicketInput: models.CreateTicketInput{
+			Title:                       title,
+			Description:                 description,
+			Priority:                    priority,
+			RiskLevel:                   risk,
+			Industry:                    opts.Industry,
+			ComplianceFrameworks:        opts.ComplianceFrameworks,
+			AffectedSystems:             splitList(rec.Get("cmdb_ci")),
+			ImpactDescription:           optional(rec.Get("risk_impact_analysis")),
+			RollbackPlan:                optional(rec.Get("backout_plan")),
+			TestingPlan:                 optional(rec.Get("test_plan")),
+			RequestedImplementationDate: times["start_date"],
+			RequiresApprovalTypes:       opts.RequiresApprovalTypes,
+			ExternalReference:           &number,
+		},
+		Status:         status,
+		IsEmergency:    changeType == "emergency",
+		ScheduledStart: times["start_date"],
+		ScheduledEnd:   times["end_date"],
+		ActualStart:    times["work_start"],
+		ActualEnd:      times["work_end"],
+		CreatedAt:      *opened,
+		UpdatedAt:      *updated,
+	}
+	if changeType != "" {
+		input.ChangeType = &changeType
+	}
+	if status == models.TicketStatusClosed || status == models.TicketStatusCancelled {
+		input.ClosedAt = firstTime(times["closed_at"], updated)
+		if input.ClosedAt.Before(input.CreatedAt) {
+			return nil, &models.ValidationError{Field: "closed_at", Message: "closed_at is before opened_at"}
+		}
+	}
+	if input.UpdatedAt.Before(input.CreatedAt) {
+		input.UpdatedAt = input.CreatedAt
+	}
+
+	input.CustomFields, _ = json.Marshal(map[string]interface{}{"servicenow": rec})
+	return input, nil
+}
+
+// lookup resolves a choice list value such as "3 - Moderate", "3" or
+// "Moderate". An empty value yields the fallback, or an error without one.
+func lookup[T ~string](choices map[string]T, field, value string, fallback T) (T, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		if fallback == "" {
+			return "", &models.ValidationError{Field: field, Message: field + " is required"}
+		}
+		return fallback, nil
+	}
+	code, label, _ := strings.Cut(value, " - ")
+	if v, ok := choices[code]; ok {
+		return v, nil
+	}
+	if v, ok := choices[label]; ok {
+		return v, nil
+	}
+	return "", &models.ValidationError{Field: field, Message: "unknown " + field + ": " + value}
+}
+
+func parseTime(value string, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized timestamp: %s", strconv.Quote(value))
+}
+
+func firstTime(times ...*time.Time) *time.Time {
+	for _, t := range times {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
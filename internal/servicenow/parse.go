@@ -0,0 +1,170 @@
+package servicenow
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Record is one change_request row keyed by ServiceNow field name
+type Record map[string]string
+
+// Get returns the trimmed value of a field, or "" if it is absent
+func (r Record) Get(field string) string {
+	return strings.TrimSpace(r[field])
+}
+
+// Parse reads a change_request export in the given format
+func Parse(r io.Reader, format string) ([]Record, error) {
+	var records []Record
+	var err error
+	switch format {
+	case FormatCSV:
+		records, err = ParseCSV(r)
+	case FormatJSON:
+		records, err = ParseJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > models.MaxServiceNowImportRecords {
+		return nil, fmt.Errorf("export has %d records; at most %d can be imported at once", len(records), models.MaxServiceNowImportRecords)
+	}
+	return records, nil
+}
+
+// csvColumns maps the column labels of a list view export to field names
+var csvColumns = map[string]string{
+	"configuration_item":       "cmdb_ci",
+	"planned_start_date":       "start_date",
+	"planned_end_date":         "end_date",
+	"actual_start":             "work_start",
+	"actual_end":               "work_end",
+	"opened":                   "opened_at",
+	"closed":                   "closed_at",
+	"created":                  "sys_created_on",
+	"updated":                  "sys_updated_on",
+	"risk_and_impact_analysis": "risk_impact_analysis",
+}
+
+// ParseCSV reads a CSV export. Columns may be headed by field name or by
+// the label ServiceNow uses in list view exports.
+func ParseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	fields := make([]string, len(header))
+	for i, column := range header {
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff") // Excel byte order mark
+		}
+		field := strings.ToLower(strings.TrimSpace(column))
+		field = strings.NewReplacer(" ", "_", "-", "_").Replace(field)
+		if mapped, ok := csvColumns[field]; ok {
+			field = mapped
+		}
+		fields[i] = field
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		record := make(Record, len(fields))
+		for i, value := range row {
+			record[fields[i]] = value
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ParseJSON reads a JSON export: the {"records": [...]} shape of a JSONv2
+// export, the {"result": [...]} shape of the Table API, or a bare array.
+// Table API values fetched with display values may be objects; reference
+// fields use their display value and everything else its raw value.
+func ParseJSON(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+
+	var rows []map[string]interface{}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		err = decodeJSON(data, &rows)
+	} else {
+		var envelope struct {
+			Records []map[string]interface{} `json:"records"`
+			Result  []map[string]interface{} `json:"result"`
+		}
+		err = decodeJSON(data, &envelope)
+		rows = envelope.Records
+		if rows == nil {
+			rows = envelope.Result
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	records := make([]Record, len(rows))
+	for i, row := range rows {
+		record := make(Record, len(row))
+		for field, value := range row {
+			record[field] = jsonValue(value)
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func jsonValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]interface{}:
+		raw, _ := v["value"].(string)
+		display, _ := v["display_value"].(string)
+		if _, isReference := v["link"]; isReference || raw == "" {
+			return display
+		}
+		return raw
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+// Package ses sends email through the Amazon SES v2 API and reads the
+// bounce and complaint notifications SES publishes to an SQS queue.
+package ses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/pkg/awsauth"
+)
+
+// Client is a minimal SES v2 client signing requests with AWS Signature
+// Version 4
+type Client struct {
+	creds    awsauth.Credentials
+	endpoint string
+	http     *http.Client
+}
+
+// NewClient creates a client from the AWS configuration. Credentials not
+// set in the configuration are read from the standard AWS_* environment
+// variables.
+func NewClient(cfg *config.AWSConfig) (*Client, error) {
+	creds, err := awsauth.Load(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("SES: %w", err)
+	}
+	return &Client{
+		creds:    creds,
+		endpoint: "https://email." + creds.Region + ".amazonaws.com",
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Message is one email to send
+type Message struct {
+	From     string
+	ReplyTo  string
+	To       string
+	Subject  string
+	BodyHTML string
+	BodyText string
+}
+
+// APIError is a non-2xx response from SES or SQS
+type APIError struct {
+	Service    string // SES or SQS
+	StatusCode int
+	Type       string // e.g. MessageRejected, TooManyRequestsException
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s returned %d %s: %s", e.Service, e.StatusCode, e.Type, e.Message)
+}
+
+// Retryable returns true if the request may succeed when sent again
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Rejected returns true if SES refused the message for its recipient or
+// content rather than for account or request problems
+func (e *APIError) Rejected() bool {
+	return e.Type == "MessageRejected"
+}
+
+// SendEmail sends a message and returns the SES message ID
+func (c *Client) SendEmail(ctx context.Context, msg *Message) (string, error) {
+	content := map[string]interface{}{
+		"Subject": map[string]string{"Data": msg.Subject, "Charset": "UTF-8"},
+		"Body": map[string]interface{}{
+			"Html": map[string]string{"Data": msg.BodyHTML, "Charset": "UTF-8"},
+			"Text": map[string]string{"Data": msg.BodyText, "Charset": "UTF-8"},
+		},
+	}
+	body := map[string]interface{}{
+		"FromEmailAddress": msg.From,
+		"Destination":      map[string][]string{"ToAddresses": {msg.To}},
+		"Content":          map[string]interface{}{"Simple": content},
+	}
+	if msg.ReplyTo != "" {
+		body["ReplyToAddresses"] = []string{msg.ReplyTo}
+	}
+
+	var resp struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v2/email/outbound-emails", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.MessageID, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode SES request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build SES request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.creds.Sign(req, awsauth.HashPayload(payload), "ses", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("SES request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read SES response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{Service: "SES", StatusCode: resp.StatusCode}
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &e)
+		apiErr.Message = e.Message
+		if apiErr.Message == "" {
+			apiErr.Message = string(data)
+			if len(apiErr.Message) > 500 {
+				apiErr.Message = apiErr.Message[:500]
+			}
+		}
+		// The error type header may carry a trailing ":<docs URL>"
+		apiErr.Type = strings.SplitN(resp.Header.Get("X-Amzn-ErrorType"), ":", 2)[0]
+		return apiErr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode SES response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package ses
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Feedback types SES publishes
+const (
+	FeedbackBounce    = "Bounce"
+	FeedbackComplaint = "Complaint"
+	FeedbackDelivery  = "Delivery"
+)
+
+// Feedback is a bounce, complaint or delivery notification for one sent
+// message
+type Feedback struct {
+	Type       string // Bounce, Complaint or Delivery
+	MessageID  string // SES message ID of the original email
+	BounceType string // Permanent, Transient or Undetermined for bounces
+	Detail     string // Bounce subtype or complaint feedback type
+	Recipients []FeedbackRecipient
+}
+
+// FeedbackRecipient is one address a notification applies to
+type FeedbackRecipient struct {
+	Email      string
+	Diagnostic string // SMTP diagnostic from the receiving server, if any
+}
+
+// Permanent returns true for hard bounces, which will fail again if the
+// address is sent to
+func (f *Feedback) Permanent() bool {
+	return f.Type == FeedbackBounce && f.BounceType == "Permanent"
+}
+
+type snsEnvelope struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"` // Set instead by configuration set event publishing
+	Mail             struct {
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce struct {
+		BounceType        string `json:"bounceType"`
+		BounceSubType     string `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Delivery struct {
+		Recipients []string `json:"recipients"`
+	} `json:"delivery"`
+}
+
+// ParseFeedback decodes an SES notification as delivered to SQS, either
+// wrapped in the SNS envelope or, with raw message delivery, bare
+func ParseFeedback(body []byte) (*Feedback, error) {
+	var env snsEnvelope
+	if err := json.Unmarshal(body, &env); err == nil && env.Type == "Notification" && env.Message != "" {
+		body = []byte(env.Message)
+	}
+
+	var n sesNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("failed to decode SES notification: %w", err)
+	}
+
+	fb := &Feedback{Type: n.NotificationType, MessageID: n.Mail.MessageID}
+	if fb.Type == "" {
+		fb.Type = n.EventType
+	}
+	switch fb.Type {
+	case FeedbackBounce:
+		fb.BounceType = n.Bounce.BounceType
+		fb.Detail = n.Bounce.BounceSubType
+		for _, r := range n.Bounce.BouncedRecipients {
+			fb.Recipients = append(fb.Recipients, FeedbackRecipient{Email: r.EmailAddress, Diagnostic: r.DiagnosticCode})
+		}
+	case FeedbackComplaint:
+		fb.Detail = n.Complaint.ComplaintFeedbackType
+		for _, r := range n.Complaint.ComplainedRecipients {
+			fb.Recipients = append(fb.Recipients, FeedbackRecipient{Email: r.EmailAddress})
+		}
+	case FeedbackDelivery:
+		for _, email := range n.Delivery.Recipients {
+			fb.Recipients = append(fb.Recipients, FeedbackRecipient{Email: email})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SES notification type %q", fb.Type)
+	}
+
+	for i := range fb.Recipients {
+		fb.Recipients[i].Email = strings.ToLower(strings.TrimSpace(fb.Recipients[i].Email))
+	}
+	return fb, nil
+}
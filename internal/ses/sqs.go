@@ -0,0 +1,132 @@
+package ses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+	"github.com/afterdarksys/adsops-utils/internal/pkg/awsauth"
+)
+
+// QueueClient reads messages from one SQS queue through the SQS JSON API
+type QueueClient struct {
+	creds    awsauth.Credentials
+	queueURL string
+	endpoint string
+	http     *http.Client
+}
+
+// QueueMessage is one message received from the queue
+type QueueMessage struct {
+	MessageID     string `json:"MessageId"`
+	ReceiptHandle string `json:"ReceiptHandle"`
+	Body          string `json:"Body"`
+}
+
+// NewQueueClient creates a client for the queue at queueURL, e.g.
+// https://sqs.us-east-1.amazonaws.com/123456789012/ses-feedback. The
+// queue's region is taken from its URL when it names one.
+func NewQueueClient(cfg *config.AWSConfig, queueURL string) (*QueueClient, error) {
+	creds, err := awsauth.Load(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("SQS: %w", err)
+	}
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid SQS queue URL %q", queueURL)
+	}
+	if parts := strings.Split(u.Host, "."); len(parts) == 4 && parts[0] == "sqs" {
+		creds.Region = parts[1]
+	}
+	return &QueueClient{
+		creds:    creds,
+		queueURL: queueURL,
+		endpoint: u.Scheme + "://" + u.Host + "/",
+		// Long polls hold the connection open for up to 20 seconds
+		http: &http.Client{Timeout: 40 * time.Second},
+	}, nil
+}
+
+// Receive returns up to max messages (at most 10), waiting up to wait for
+// one to arrive. Received messages are hidden from other consumers until
+// deleted or until the queue's visibility timeout passes.
+func (c *QueueClient) Receive(ctx context.Context, max int, wait time.Duration) ([]QueueMessage, error) {
+	var resp struct {
+		Messages []QueueMessage `json:"Messages"`
+	}
+	err := c.do(ctx, "ReceiveMessage", map[string]interface{}{
+		"QueueUrl":            c.queueURL,
+		"MaxNumberOfMessages": max,
+		"WaitTimeSeconds":     int(wait / time.Second),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Messages, nil
+}
+
+// Delete removes a handled message from the queue
+func (c *QueueClient) Delete(ctx context.Context, receiptHandle string) error {
+	return c.do(ctx, "DeleteMessage", map[string]interface{}{
+		"QueueUrl":      c.queueURL,
+		"ReceiptHandle": receiptHandle,
+	}, nil)
+}
+
+func (c *QueueClient) do(ctx context.Context, action string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode SQS request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build SQS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+	c.creds.Sign(req, awsauth.HashPayload(payload), "sqs", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("SQS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read SQS response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{Service: "SQS", StatusCode: resp.StatusCode}
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &e)
+		// The type is namespaced, e.g. com.amazonaws.sqs#QueueDoesNotExist
+		apiErr.Type = e.Type[strings.LastIndex(e.Type, "#")+1:]
+		apiErr.Message = e.Message
+		if apiErr.Message == "" {
+			apiErr.Message = string(data)
+			if len(apiErr.Message) > 500 {
+				apiErr.Message = apiErr.Message[:500]
+			}
+		}
+		return apiErr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode SQS response: %w", err)
+	}
+	return nil
+}
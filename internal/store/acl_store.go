@@ -0,0 +1,254 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ACLStore handles ticket ACL database operations
+type ACLStore struct {
+	db conn
+}
+
+type accessorKey struct{}
+
+// WithAccessor attaches the calling user to the context so ticket reads and
+// writes can be checked against ticket ACLs
+func WithAccessor(ctx context.Context, a *models.TicketAccessor) context.Context {
+	return context.WithValue(ctx, accessorKey{}, a)
+}
+
+// AccessorFrom returns the calling user from the context, or nil for
+// system callers (worker jobs, internal lookups) which bypass ACL checks
+func AccessorFrom(ctx context.Context) *models.TicketAccessor {
+	a, _ := ctx.Value(accessorKey{}).(*models.TicketAccessor)
+	return a
+}
+
+const ticketACLColumns = `
+	id, ticket_id, principal_type, principal_id, role_name, acl_role,
+	granted_by, expires_at, reason, created_at, revoked_at, revoked_by
+`
+
+// aclGrantMatch returns a condition matching active grants in alias that
+// apply to the user and roles bound at the given placeholders
+func aclGrantMatch(alias, userArg, rolesArg string) string {
+	return fmt.Sprintf(`%[1]s.revoked_at IS NULL
+		AND (%[1]s.expires_at IS NULL OR %[1]s.expires_at > NOW())
+		AND (
+			(%[1]s.principal_type = 'user' AND %[1]s.principal_id = %[2]s)
+			OR (%[1]s.principal_type = 'group' AND %[1]s.principal_id IN (SELECT group_id FROM group_members WHERE user_id = %[2]s))
+			OR (%[1]s.principal_type = 'role' AND %[1]s.role_name = ANY(%[3]s))
+		)`, alias, userArg, rolesArg)
+}
+
+// ticketVisibilityCondition returns a change_tickets condition limiting rows
+// to those the accessor may see, along with its arguments
+func ticketVisibilityCondition(a *models.TicketAccessor, argNum int) (string, []interface{}) {
+	userArg := fmt.Sprintf("$%d", argNum)
+	rolesArg := fmt.Sprintf("$%d", argNum+1)
+
+	condition := fmt.Sprintf(`(
+		is_confidential IS NOT TRUE
+		OR created_by = %[1]s
+		OR assigned_to = %[1]s
+		OR EXISTS (SELECT 1 FROM ticket_acls ta WHERE ta.ticket_id = change_tickets.id AND %[2]s)
+		OR (acl_inheritance IS NOT FALSE AND (
+			owning_group_id IN (SELECT group_id FROM group_members WHERE user_id = %[1]s)
+			OR project_id IN (
+				SELECT p.id FROM projects p
+				WHERE p.lead_user_id = %[1]s
+				   OR p.owning_group_id IN (SELECT group_id FROM group_members WHERE user_id = %[1]s)
+			)
+		))
+	)`, userArg, aclGrantMatch("ta", userArg, rolesArg))
+
+	return condition, []interface{}{a.UserID, pq.Array(a.Roles)}
+}
+
+// ticketAccess computes the accessor's effective access to a ticket. Explicit
+// grants always apply; project lead and owning group membership only count
+// while the ticket inherits ACLs.
+func ticketAccess(ctx context.Context, db conn, ticket *models.Ticket, a *models.TicketAccessor) (*models.TicketAccess, error) {
+	access := &models.TicketAccess{
+		IsCreator: ticket.CreatedBy == a.UserID,
+		ReadAll:   a.CanReadAll(),
+		WriteAll:  a.CanWriteAll(),
+	}
+	if ticket.AssignedTo != nil && *ticket.AssignedTo == a.UserID {
+		access.IsAssignee = true
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			ARRAY(SELECT ta.acl_role::text FROM ticket_acls ta WHERE ta.ticket_id = $1 AND %s),
+			$4::boolean AND (
+				EXISTS (SELECT 1 FROM group_members WHERE user_id = $2 AND group_id = $5::uuid)
+				OR EXISTS (
+					SELECT 1 FROM projects p
+					WHERE p.id = $6::uuid
+					  AND (p.lead_user_id = $2
+					       OR p.owning_group_id IN (SELECT group_id FROM group_members WHERE user_id = $2))
+				)
+			)
+	`, aclGrantMatch("ta", "$2", "$3"))
+
+	var roles []string
+	err := db.QueryRowContext(ctx, query,
+		ticket.ID, a.UserID, pq.Array(a.Roles), ticket.ACLInheritance, ticket.OwningGroupID, ticket.ProjectID,
+	).Scan(pq.Array(&roles), &access.Inherited)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check ticket access: %w", err)
+	}
+
+	access.Roles = make([]models.TicketACLRole, len(roles))
+	for i, r := range roles {
+		access.Roles[i] = models.TicketACLRole(r)
+	}
+
+	return access, nil
+}
+
+// EffectiveAccess returns the calling user's access to a ticket. System
+// callers without an accessor get full access.
+func (s *ACLStore) EffectiveAccess(ctx context.Context, ticket *models.Ticket) (*models.TicketAccess, error) {
+	a := AccessorFrom(ctx)
+	if a == nil {
+		return &models.TicketAccess{ReadAll: true, WriteAll: true}, nil
+	}
+	return ticketAccess(ctx, s.db, ticket, a)
+}
+
+// List retrieves the ACL entries for a ticket, newest first
+func (s *ACLStore) List(ctx context.Context, ticketID uuid.UUID, includeRevoked bool) ([]models.TicketACL, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ticket_acls
+		WHERE ticket_id = $1 AND ($2 OR revoked_at IS NULL)
+		ORDER BY created_at DESC
+	`, ticketACLColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, ticketID, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ticket ACLs: %w", err)
+	}
+	defer rows.Close()
+
+	var acls []models.TicketACL
+	for rows.Next() {
+		acl, err := scanTicketACL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ticket ACL: %w", err)
+		}
+		acls = append(acls, *acl)
+	}
+
+	return acls, rows.Err()
+}
+
+// Grant gives a principal access to a ticket. An existing active grant for
+// the same principal is revoked and replaced so role changes keep history.
+func (s *ACLStore) Grant(ctx context.Context, orgID, ticketID, grantedBy uuid.UUID, input *models.GrantTicketACLInput) (*models.TicketACL, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var principalID interface{}
+	var roleName interface{}
+	switch input.PrincipalType {
+	case models.ACLPrincipalUser, models.ACLPrincipalGroup:
+		table := "users"
+		if input.PrincipalType == models.ACLPrincipalGroup {
+			table = "groups"
+		}
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND organization_id = $2)", table),
+			*input.PrincipalID, orgID,
+		).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check principal: %w", err)
+		}
+		if !exists {
+			return nil, models.NotFound("principal not found")
+		}
+		principalID = *input.PrincipalID
+		_, err = tx.ExecContext(ctx, `
+			UPDATE ticket_acls SET revoked_at = NOW(), revoked_by = $4
+			WHERE ticket_id = $1 AND principal_type = $2 AND principal_id = $3 AND revoked_at IS NULL
+		`, ticketID, input.PrincipalType, principalID, grantedBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace existing grant: %w", err)
+		}
+	case models.ACLPrincipalRole:
+		roleName = *input.RoleName
+		_, err = tx.ExecContext(ctx, `
+			UPDATE ticket_acls SET revoked_at = NOW(), revoked_by = $3
+			WHERE ticket_id = $1 AND role_name = $2 AND revoked_at IS NULL
+		`, ticketID, roleName, grantedBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace existing grant: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO ticket_acls (
+			ticket_id, principal_type, principal_id, role_name, acl_role,
+			granted_by, expires_at, reason
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING %s
+	`, ticketACLColumns)
+
+	acl, err := scanTicketACL(tx.QueryRowContext(ctx, query,
+		ticketID, input.PrincipalType, principalID, roleName, input.ACLRole,
+		grantedBy, input.ExpiresAt, input.Reason,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant ticket access: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return acl, nil
+}
+
+// Revoke revokes an active ACL entry on a ticket
+func (s *ACLStore) Revoke(ctx context.Context, ticketID, aclID, revokedBy uuid.UUID) (*models.TicketACL, error) {
+	query := fmt.Sprintf(`
+		UPDATE ticket_acls
+		SET revoked_at = NOW(), revoked_by = $3
+		WHERE id = $1 AND ticket_id = $2 AND revoked_at IS NULL
+		RETURNING %s
+	`, ticketACLColumns)
+
+	acl, err := scanTicketACL(s.db.QueryRowContext(ctx, query, aclID, ticketID, revokedBy))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("ticket ACL not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke ticket access: %w", err)
+	}
+
+	return acl, nil
+}
+
+func scanTicketACL(row rowScanner) (*models.TicketACL, error) {
+	a := &models.TicketACL{}
+	err := row.Scan(
+		&a.ID, &a.TicketID, &a.PrincipalType, &a.PrincipalID, &a.RoleName, &a.ACLRole,
+		&a.GrantedBy, &a.ExpiresAt, &a.Reason, &a.CreatedAt, &a.RevokedAt, &a.RevokedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ApprovalRuleStore handles approval rule database operations
+type ApprovalRuleStore struct {
+	db conn
+}
+
+const approvalRuleColumns = `
+	id, organization_id, name, description, eval_order, conditions, requirements,
+	auto_approve, stop_processing, is_active, created_by, created_at, updated_at
+`
+
+// Create creates a new approval rule
+func (s *ApprovalRuleStore) Create(ctx context.Context, orgID, createdBy uuid.UUID, input *models.CreateApprovalRuleInput) (*models.ApprovalRule, error) {
+	rule := &models.ApprovalRule{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Name:           input.Name,
+		Description:    input.Description,
+		EvalOrder:      input.EvalOrder,
+		Conditions:     input.Conditions,
+		Requirements:   input.Requirements,
+		AutoApprove:    input.AutoApprove,
+		StopProcessing: input.StopProcessing,
+		IsActive:       true,
+		CreatedBy:      &createdBy,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if rule.Requirements == nil {
+		rule.Requirements = []models.ApprovalRequirement{}
+	}
+
+	conditionsJSON, _ := json.Marshal(rule.Conditions)
+	requirementsJSON, _ := json.Marshal(rule.Requirements)
+
+	query := `
+		INSERT INTO approval_rules (
+			id, organization_id, name, description, eval_order, conditions, requirements,
+			auto_approve, stop_processing, is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		rule.ID, rule.OrganizationID, rule.Name, rule.Description, rule.EvalOrder,
+		conditionsJSON, requirementsJSON, rule.AutoApprove, rule.StopProcessing,
+		rule.IsActive, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetByID retrieves an approval rule by ID
+func (s *ApprovalRuleStore) GetByID(ctx context.Context, orgID, ruleID uuid.UUID) (*models.ApprovalRule, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM approval_rules
+		WHERE id = $1 AND organization_id = $2
+	`, approvalRuleColumns)
+
+	rule, err := scanApprovalRule(s.db.QueryRowContext(ctx, query, ruleID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("approval rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// List retrieves all approval rules for an organization in evaluation order
+func (s *ApprovalRuleStore) List(ctx context.Context, orgID uuid.UUID, activeOnly bool) ([]models.ApprovalRule, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM approval_rules
+		WHERE organization_id = $1
+	`, approvalRuleColumns)
+	if activeOnly {
+		query += " AND is_active = true"
+	}
+	query += " ORDER BY eval_order ASC, created_at ASC"
+
+	rows, err := s.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approval rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.ApprovalRule
+	for rows.Next() {
+		rule, err := scanApprovalRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// ListActive retrieves the active rules used when evaluating a ticket
+func (s *ApprovalRuleStore) ListActive(ctx context.Context, orgID uuid.UUID) ([]models.ApprovalRule, error) {
+	return s.List(ctx, orgID, true)
+}
+
+// Update updates an approval rule
+func (s *ApprovalRuleStore) Update(ctx context.Context, orgID, ruleID uuid.UUID, input *models.UpdateApprovalRuleInput) (*models.ApprovalRule, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *input.Name)
+		argNum++
+	}
+
+	if input.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+
+	if input.EvalOrder != nil {
+		updates = append(updates, fmt.Sprintf("eval_order = $%d", argNum))
+		args = append(args, *input.EvalOrder)
+		argNum++
+	}
+
+	if input.Conditions != nil {
+		conditionsJSON, _ := json.Marshal(input.Conditions)
+		updates = append(updates, fmt.Sprintf("conditions = $%d", argNum))
+		args = append(args, conditionsJSON)
+		argNum++
+	}
+
+	if input.Requirements != nil {
+		requirementsJSON, _ := json.Marshal(input.Requirements)
+		updates = append(updates, fmt.Sprintf("requirements = $%d", argNum))
+		args = append(args, requirementsJSON)
+		argNum++
+	}
+
+	if input.AutoApprove != nil {
+		updates = append(updates, fmt.Sprintf("auto_approve = $%d", argNum))
+		args = append(args, *input.AutoApprove)
+		argNum++
+	}
+
+	if input.StopProcessing != nil {
+		updates = append(updates, fmt.Sprintf("stop_processing = $%d", argNum))
+		args = append(args, *input.StopProcessing)
+		argNum++
+	}
+
+	if input.IsActive != nil {
+		updates = append(updates, fmt.Sprintf("is_active = $%d", argNum))
+		args = append(args, *input.IsActive)
+		argNum++
+	}
+
+	if len(updates) == 0 {
+		return s.GetByID(ctx, orgID, ruleID)
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE approval_rules SET %s WHERE id = $%d AND organization_id = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1,
+	)
+	args = append(args, ruleID, orgID)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update approval rule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, models.NotFound("approval rule not found")
+	}
+
+	return s.GetByID(ctx, orgID, ruleID)
+}
+
+// Delete deletes an approval rule. Plans already frozen on tickets are unaffected.
+func (s *ApprovalRuleStore) Delete(ctx context.Context, orgID, ruleID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM approval_rules WHERE id = $1 AND organization_id = $2",
+		ruleID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval rule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("approval rule not found")
+	}
+	return nil
+}
+
+// Evaluate builds the approval plan for a ticket from the org's active
+// rules, falling back to the org's default quorum when none match
+func (s *ApprovalRuleStore) Evaluate(ctx context.Context, ticket *models.Ticket) (*models.ApprovalPlan, error) {
+	rules, err := s.ListActive(ctx, ticket.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := getOrganizationSettings(ctx, s.db, ticket.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	plan := models.EvaluateApprovalRules(ticket, rules)
+	settings.Approvals.ApplyDefaults(&plan)
+	return &plan, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanApprovalRule(row rowScanner) (*models.ApprovalRule, error) {
+	rule := &models.ApprovalRule{}
+	var conditionsJSON, requirementsJSON []byte
+
+	err := row.Scan(
+		&rule.ID, &rule.OrganizationID, &rule.Name, &rule.Description, &rule.EvalOrder,
+		&conditionsJSON, &requirementsJSON, &rule.AutoApprove, &rule.StopProcessing,
+		&rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(conditionsJSON, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("invalid rule conditions: %w", err)
+	}
+	if err := json.Unmarshal(requirementsJSON, &rule.Requirements); err != nil {
+		return nil, fmt.Errorf("invalid rule requirements: %w", err)
+	}
+
+	return rule, nil
+}
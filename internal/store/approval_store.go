@@ -0,0 +1,580 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ApprovalStore handles approval database operations
+type ApprovalStore struct {
+	db      conn
+	tickets ticketEvicter
+}
+
+const approvalColumns = `
+	id, ticket_id, organization_id, approval_type, COALESCE(sequence_order, 0),
+	approver_id, delegated_from, status, approved_at, denied_at, decision_comment,
+	conditions, token_expires_at, notification_sent_at, notification_read_at,
+	reminder_sent_at, created_at, updated_at
+`
+
+// Create fans a submitted ticket's approval plan out into pending
+// approvals: one for every active approver of each required approval type,
+// other than the ticket's creator. Approvals from an earlier submission are
+// expired first, so a resubmitted ticket is decided afresh. Each approval
+// gets a token for deciding it from an email link, returned in its
+// ApprovalToken; only the token's hash is stored.
+func (s *ApprovalStore) Create(ctx context.Context, ticket *models.Ticket, plan *models.ApprovalPlan) ([]models.Approval, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE approvals
+		SET status = 'expired', approval_token = NULL, updated_at = NOW()
+		WHERE ticket_id = $1 AND organization_id = $2 AND status <> 'expired'
+	`, ticket.ID, ticket.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire earlier approvals: %w", err)
+	}
+
+	var approvals []models.Approval
+	if plan == nil || plan.AutoApprove {
+		return approvals, tx.Commit()
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO approvals (
+			ticket_id, organization_id, approval_type, sequence_order, approver_id,
+			approval_token, token_expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (ticket_id, approval_type, approver_id) DO UPDATE
+		SET sequence_order = EXCLUDED.sequence_order,
+		    delegated_from = NULL,
+		    status = 'pending',
+		    approved_at = NULL,
+		    denied_at = NULL,
+		    decision_comment = NULL,
+		    conditions = NULL,
+		    approval_token = EXCLUDED.approval_token,
+		    token_expires_at = EXCLUDED.token_expires_at,
+		    approval_ip = NULL,
+		    approval_user_agent = NULL,
+		    notification_sent_at = NULL,
+		    notification_read_at = NULL,
+		    reminder_sent_at = NULL,
+		    updated_at = NOW()
+		RETURNING %s
+	`, approvalColumns)
+
+	expires := time.Now().Add(models.ApprovalTokenTTL)
+	for i, req := range plan.Requirements {
+		approvers, err := listApprovers(ctx, tx, ticket.OrganizationID, req.ApprovalType, ticket.CreatedBy)
+		if err != nil {
+			return nil, err
+		}
+		for _, approverID := range approvers {
+			token, err := newApprovalToken()
+			if err != nil {
+				return nil, err
+			}
+			a, err := scanApproval(tx.QueryRowContext(ctx, query,
+				ticket.ID, ticket.OrganizationID, req.ApprovalType, i, approverID,
+				hashVerificationToken(token), expires,
+			))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create approval: %w", err)
+			}
+			a.ApprovalToken = &token
+			approvals = append(approvals, *a)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// Get retrieves an approval
+func (s *ApprovalStore) Get(ctx context.Context, orgID, approvalID uuid.UUID) (*models.Approval, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM approvals
+		WHERE id = $1 AND organization_id = $2
+	`, approvalColumns)
+
+	a, err := scanApproval(s.db.QueryRowContext(ctx, query, approvalID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("approval not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	return a, nil
+}
+
+// GetByToken retrieves the approval an email link's token decides. Tokens
+// are cleared once the approval is decided or expired, and stop working
+// after ApprovalTokenTTL.
+func (s *ApprovalStore) GetByToken(ctx context.Context, token string) (*models.Approval, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM approvals
+		WHERE approval_token = $1
+	`, approvalColumns)
+
+	a, err := scanApproval(s.db.QueryRowContext(ctx, query, hashVerificationToken(token)))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("approval not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	if a.TokenExpiresAt == nil || !time.Now().Before(*a.TokenExpiresAt) {
+		return nil, models.ErrApprovalLinkExpired
+	}
+	return a, nil
+}
+
+// ListForTicket retrieves a ticket's approvals in sequence order
+func (s *ApprovalStore) ListForTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Approval, error) {
+	return s.ListByTickets(ctx, orgID, []uuid.UUID{ticketID})
+}
+
+// ListForApprover retrieves the approvals assigned to a user, newest first,
+// with the total matching the filter
+func (s *ApprovalStore) ListForApprover(ctx context.Context, orgID, approverID uuid.UUID, filter *models.ApprovalListFilter) ([]models.ApprovalSummary, int, error) {
+	filter.SetDefaults()
+
+	conditions := []string{"a.organization_id = $1", "a.approver_id = $2", "t.deleted_at IS NULL"}
+	args := []interface{}{orgID, approverID}
+	if len(filter.Status) > 0 {
+		statuses := make([]string, len(filter.Status))
+		for i, st := range filter.Status {
+			statuses[i] = string(st)
+		}
+		args = append(args, pq.Array(statuses))
+		conditions = append(conditions, fmt.Sprintf("a.status::TEXT = ANY($%d)", len(args)))
+	}
+	if len(filter.ApprovalType) > 0 {
+		types := make([]string, len(filter.ApprovalType))
+		for i, at := range filter.ApprovalType {
+			types[i] = string(at)
+		}
+		args = append(args, pq.Array(types))
+		conditions = append(conditions, fmt.Sprintf("a.approval_type::TEXT = ANY($%d)", len(args)))
+	}
+	if filter.TicketID != nil {
+		args = append(args, *filter.TicketID)
+		conditions = append(conditions, fmt.Sprintf("a.ticket_id = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM approvals a
+		JOIN change_tickets t ON t.id = a.ticket_id
+		WHERE %s
+	`, where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count approvals: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id, a.ticket_id, t.ticket_number, t.title, a.approval_type, a.status,
+		       a.created_at, a.token_expires_at
+		FROM approvals a
+		JOIN change_tickets t ON t.id = a.ticket_id
+		WHERE %s
+		ORDER BY a.created_at DESC, a.id
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, filter.PerPage, filter.Offset())...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []models.ApprovalSummary
+	for rows.Next() {
+		var a models.ApprovalSummary
+		err := rows.Scan(
+			&a.ID, &a.TicketID, &a.TicketNumber, &a.TicketTitle, &a.ApprovalType, &a.Status,
+			&a.CreatedAt, &a.TokenExpiresAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, a)
+	}
+
+	return approvals, total, rows.Err()
+}
+
+// Decide records an approver's decision on a pending approval, then moves
+// the ticket to the status its approvals now imply, recorded as a revision.
+// Once the ticket is no longer awaiting approval its other pending
+// approvals are expired. Returns the decided approval and the ticket's
+// status change, which is nil when the status stays the same.
+func (s *ApprovalStore) Decide(ctx context.Context, orgID, approvalID, approverID uuid.UUID, decision *models.ApprovalDecision) (*models.Approval, *models.TicketTransition, error) {
+	current, err := s.Get(ctx, orgID, approvalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if current.ApproverID != approverID {
+		return nil, nil, models.Forbidden("approval is assigned to another approver")
+	}
+	if !decision.Status.Valid() || decision.Status == models.ApprovalStatusPending || decision.Status == models.ApprovalStatusExpired {
+		return nil, nil, fmt.Errorf("invalid decision: %s", decision.Status)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize decisions on the ticket so concurrent approvals are counted,
+	// locking it before the approval as RecordDecision does
+	_, err = tx.ExecContext(ctx,
+		"SELECT 1 FROM change_tickets WHERE id = $1 AND organization_id = $2 FOR UPDATE",
+		current.TicketID, orgID,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock ticket: %w", err)
+	}
+	before, err := getTicket(ctx, tx, orgID, current.TicketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !awaitingApproval(before) {
+		return nil, nil, models.InvalidTransition("ticket is not awaiting approval")
+	}
+
+	var approvedAt, deniedAt *time.Time
+	now := time.Now()
+	if decision.Status == models.ApprovalStatusApproved {
+		approvedAt = &now
+	} else {
+		deniedAt = &now
+	}
+	query := fmt.Sprintf(`
+		UPDATE approvals
+		SET status = $1, approved_at = $2, denied_at = $3, decision_comment = $4,
+		    conditions = $5, approval_ip = $6, approval_user_agent = $7,
+		    approval_token = NULL, updated_at = NOW()
+		WHERE id = $8 AND organization_id = $9 AND status = 'pending'
+		RETURNING %s
+	`, approvalColumns)
+	a, err := scanApproval(tx.QueryRowContext(ctx, query,
+		decision.Status, approvedAt, deniedAt, decision.Comment, decision.Conditions,
+		decision.IP, decision.UserAgent, approvalID, orgID,
+	))
+	if err == sql.ErrNoRows {
+		if current.Status == models.ApprovalStatusExpired {
+			return nil, nil, models.Conflict("approval has expired")
+		}
+		return nil, nil, models.Conflict("approval has already been decided")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to record approval: %w", err)
+	}
+
+	approvals, err := listTicketApprovals(ctx, tx, orgID, before.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan := before.EffectiveApprovalPlan()
+	next := plan.Outcome(approvals).TicketStatus()
+	if next == before.Status {
+		return a, nil, tx.Commit()
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE change_tickets
+		SET status = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND organization_id = $3
+	`, next, before.ID, orgID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update ticket status: %w", err)
+	}
+	after, err := getTicket(ctx, tx, orgID, before.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reason := "Approval decision: " + string(decision.Status)
+	if err := insertTicketRevision(ctx, tx, before, after, approverID, &reason); err != nil {
+		return nil, nil, err
+	}
+	if !awaitingApproval(after) {
+		if err := expirePendingApprovals(ctx, tx, before.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	s.tickets.evict(ctx, orgID, before.ID)
+	return a, &models.TicketTransition{From: before.Status, To: next}, nil
+}
+
+// DecideByToken records a decision made from an email link, as the
+// approver the link was sent to
+func (s *ApprovalStore) DecideByToken(ctx context.Context, token string, decision *models.ApprovalDecision) (*models.Approval, *models.TicketTransition, error) {
+	a, err := s.GetByToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.Decide(ctx, a.OrganizationID, a.ID, a.ApproverID, decision)
+}
+
+// ExpireOverdue expires the pending approvals of tickets across all
+// organizations that are past their approval deadline, and sends those
+// tickets back to their creators as update requested so they can be
+// resubmitted. Emergency tickets are escalated instead and are left alone.
+// Returns the tickets sent back.
+func (s *ApprovalStore) ExpireOverdue(ctx context.Context, now time.Time) ([]models.Ticket, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, organization_id, ticket_number, title, status, approval_deadline
+		FROM change_tickets
+		WHERE is_emergency = false
+		  AND status IN ('submitted', 'in_review', 'partially_approved')
+		  AND deleted_at IS NULL
+		  AND approval_deadline < $1
+		ORDER BY approval_deadline
+		LIMIT 100
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue tickets: %w", err)
+	}
+	var overdue []models.Ticket
+	for rows.Next() {
+		var t models.Ticket
+		if err := rows.Scan(&t.ID, &t.OrganizationID, &t.TicketNumber, &t.Title, &t.Status, &t.ApprovalDeadline); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		overdue = append(overdue, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list overdue tickets: %w", err)
+	}
+
+	var expired []models.Ticket
+	for _, t := range overdue {
+		ok, err := s.expireTicket(ctx, &t)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			t.Status = models.TicketStatusUpdateRequested
+			expired = append(expired, t)
+		}
+	}
+	return expired, nil
+}
+
+// expireTicket sends one overdue ticket back, unless a decision moved it on
+// since it was listed
+func (s *ApprovalStore) expireTicket(ctx context.Context, t *models.Ticket) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE change_tickets
+		SET status = 'update_requested', version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND status = $2 AND deleted_at IS NULL
+	`, t.ID, t.Status)
+	if err != nil {
+		return false, fmt.Errorf("failed to update ticket status: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if err := expirePendingApprovals(ctx, tx, t.ID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	s.tickets.evict(ctx, t.OrganizationID, t.ID)
+	return true, nil
+}
+
+// listApprovers returns the active users who can give an approval type,
+// leaving out the ticket's creator, who can't approve their own change
+func listApprovers(ctx context.Context, tx *Tx, orgID uuid.UUID, approvalType models.ApprovalType, creatorID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id
+		FROM users
+		WHERE organization_id = $1
+		  AND is_active = true
+		  AND deleted_at IS NULL
+		  AND is_approver = true
+		  AND $2::approval_type = ANY(approval_types)
+		  AND id <> $3
+		ORDER BY id
+	`, orgID, approvalType, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvers: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan approver: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// expirePendingApprovals expires a ticket's undecided approvals and their
+// links
+func expirePendingApprovals(ctx context.Context, tx *Tx, ticketID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE approvals
+		SET status = 'expired', approval_token = NULL, updated_at = NOW()
+		WHERE ticket_id = $1 AND status = 'pending'
+	`, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to expire pending approvals: %w", err)
+	}
+	return nil
+}
+
+// awaitingApproval reports whether a ticket's approvals can still change
+// its status
+func awaitingApproval(t *models.Ticket) bool {
+	for _, st := range models.CABAwaitingStatuses {
+		if t.Status == st {
+			return true
+		}
+	}
+	return false
+}
+
+func newApprovalToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate approval token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// ListByTickets retrieves the approvals of several tickets at once, in
+// sequence order within each ticket
+func (s *ApprovalStore) ListByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Approval, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM approvals
+		WHERE organization_id = $1 AND ticket_id = ANY($2)
+		ORDER BY ticket_id, sequence_order, created_at
+	`, approvalColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, pq.Array(ticketIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []models.Approval
+	for rows.Next() {
+		a, err := scanApproval(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, *a)
+	}
+
+	return approvals, rows.Err()
+}
+
+// ListAwaitingApprover retrieves the pending approvals assigned to a user
+// on tickets still under review, oldest first
+func (s *ApprovalStore) ListAwaitingApprover(ctx context.Context, orgID, approverID uuid.UUID, limit int) ([]models.DigestApproval, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.ticket_number, t.title, t.status, t.priority, a.approval_type, t.risk_level, a.created_at,
+		       t.submitted_at, t.approval_deadline
+		FROM approvals a
+		JOIN change_tickets t ON t.id = a.ticket_id
+		WHERE a.organization_id = $1 AND a.approver_id = $2 AND a.status = 'pending'
+		  AND t.deleted_at IS NULL AND t.status = ANY($3)
+		ORDER BY a.created_at
+		LIMIT $4
+	`, orgID, approverID, pq.Array([]string{
+		string(models.TicketStatusSubmitted),
+		string(models.TicketStatusInReview),
+		string(models.TicketStatusPartiallyApproved),
+	}), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []models.DigestApproval
+	var tickets []models.Ticket
+	for rows.Next() {
+		var a models.DigestApproval
+		var t models.Ticket
+		if err := rows.Scan(&a.TicketNumber, &a.Title, &t.Status, &t.Priority, &a.ApprovalType, &a.RiskLevel, &a.RequestedAt,
+			&t.SubmittedAt, &t.ApprovalDeadline); err != nil {
+			return nil, fmt.Errorf("failed to scan pending approval: %w", err)
+		}
+		approvals = append(approvals, a)
+		tickets = append(tickets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(approvals) == 0 {
+		return approvals, nil
+	}
+
+	// Due dates follow the organization's approval windows, as the API's do
+	settings, err := getOrganizationSettings(ctx, s.db, orgID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range approvals {
+		approvals[i].Deadline = settings.TicketSLA(&tickets[i], now).ApprovalDueAt
+	}
+
+	return approvals, nil
+}
+
+func scanApproval(row rowScanner) (*models.Approval, error) {
+	a := &models.Approval{}
+	err := row.Scan(
+		&a.ID, &a.TicketID, &a.OrganizationID, &a.ApprovalType, &a.SequenceOrder,
+		&a.ApproverID, &a.DelegatedFrom, &a.Status, &a.ApprovedAt, &a.DeniedAt,
+		&a.DecisionComment, &a.Conditions, &a.TokenExpiresAt, &a.NotificationSentAt,
+		&a.NotificationReadAt, &a.ReminderSentAt, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
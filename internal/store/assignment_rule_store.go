@@ -0,0 +1,347 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// AssignmentRuleStore handles assignment rule database operations and the
+// lookups the queue bot needs to pick an assignee
+type AssignmentRuleStore struct {
+	db      conn
+	tickets ticketEvicter
+}
+
+const assignmentRuleColumns = `
+	id, organization_id, name, description, eval_order, conditions, strategy,
+	group_id, last_assigned_to, is_active, created_by, created_at, updated_at
+`
+
+// Create creates a new assignment rule
+func (s *AssignmentRuleStore) Create(ctx context.Context, orgID, createdBy uuid.UUID, input *models.CreateAssignmentRuleInput) (*models.AssignmentRule, error) {
+	rule := &models.AssignmentRule{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Name:           input.Name,
+		Description:    input.Description,
+		EvalOrder:      input.EvalOrder,
+		Conditions:     input.Conditions,
+		Strategy:       input.Strategy,
+		GroupID:        input.GroupID,
+		IsActive:       true,
+		CreatedBy:      &createdBy,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	conditionsJSON, _ := json.Marshal(rule.Conditions)
+
+	query := `
+		INSERT INTO assignment_rules (
+			id, organization_id, name, description, eval_order, conditions, strategy,
+			group_id, is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		rule.ID, rule.OrganizationID, rule.Name, rule.Description, rule.EvalOrder,
+		conditionsJSON, rule.Strategy, rule.GroupID,
+		rule.IsActive, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create assignment rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetByID retrieves an assignment rule by ID
+func (s *AssignmentRuleStore) GetByID(ctx context.Context, orgID, ruleID uuid.UUID) (*models.AssignmentRule, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM assignment_rules
+		WHERE id = $1 AND organization_id = $2
+	`, assignmentRuleColumns)
+
+	rule, err := scanAssignmentRule(s.db.QueryRowContext(ctx, query, ruleID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("assignment rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// List retrieves all assignment rules for an organization in evaluation order
+func (s *AssignmentRuleStore) List(ctx context.Context, orgID uuid.UUID, activeOnly bool) ([]models.AssignmentRule, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM assignment_rules
+		WHERE organization_id = $1
+	`, assignmentRuleColumns)
+	if activeOnly {
+		query += " AND is_active = true"
+	}
+	query += " ORDER BY eval_order ASC, created_at ASC"
+
+	rows, err := s.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assignment rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.AssignmentRule
+	for rows.Next() {
+		rule, err := scanAssignmentRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// ListActive retrieves the active rules the queue bot evaluates
+func (s *AssignmentRuleStore) ListActive(ctx context.Context, orgID uuid.UUID) ([]models.AssignmentRule, error) {
+	return s.List(ctx, orgID, true)
+}
+
+// ListOrganizations returns the organizations with at least one active rule
+func (s *AssignmentRuleStore) ListOrganizations(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT DISTINCT organization_id FROM assignment_rules WHERE is_active = true",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations with assignment rules: %w", err)
+	}
+	defer rows.Close()
+
+	var orgIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgIDs = append(orgIDs, id)
+	}
+
+	return orgIDs, rows.Err()
+}
+
+// Update updates an assignment rule
+func (s *AssignmentRuleStore) Update(ctx context.Context, orgID, ruleID uuid.UUID, input *models.UpdateAssignmentRuleInput) (*models.AssignmentRule, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *input.Name)
+		argNum++
+	}
+
+	if input.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+
+	if input.EvalOrder != nil {
+		updates = append(updates, fmt.Sprintf("eval_order = $%d", argNum))
+		args = append(args, *input.EvalOrder)
+		argNum++
+	}
+
+	if input.Conditions != nil {
+		conditionsJSON, _ := json.Marshal(input.Conditions)
+		updates = append(updates, fmt.Sprintf("conditions = $%d", argNum))
+		args = append(args, conditionsJSON)
+		argNum++
+	}
+
+	if input.Strategy != nil {
+		updates = append(updates, fmt.Sprintf("strategy = $%d", argNum))
+		args = append(args, *input.Strategy)
+		argNum++
+	}
+
+	// A new candidate pool starts the rotation over
+	if input.GroupID != nil {
+		updates = append(updates, fmt.Sprintf("group_id = $%d", argNum), "last_assigned_to = NULL")
+		args = append(args, *input.GroupID)
+		argNum++
+	} else if input.ClearGroup {
+		updates = append(updates, "group_id = NULL", "last_assigned_to = NULL")
+	}
+
+	if input.IsActive != nil {
+		updates = append(updates, fmt.Sprintf("is_active = $%d", argNum))
+		args = append(args, *input.IsActive)
+		argNum++
+	}
+
+	if len(updates) == 0 {
+		return s.GetByID(ctx, orgID, ruleID)
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE assignment_rules SET %s WHERE id = $%d AND organization_id = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1,
+	)
+	args = append(args, ruleID, orgID)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update assignment rule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, models.NotFound("assignment rule not found")
+	}
+
+	return s.GetByID(ctx, orgID, ruleID)
+}
+
+// Delete deletes an assignment rule. Tickets it already assigned keep their assignee.
+func (s *AssignmentRuleStore) Delete(ctx context.Context, orgID, ruleID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM assignment_rules WHERE id = $1 AND organization_id = $2",
+		ruleID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete assignment rule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("assignment rule not found")
+	}
+	return nil
+}
+
+// GroupMembers returns the active users in an organization's group, in ID order
+func (s *AssignmentRuleStore) GroupMembers(ctx context.Context, orgID, groupID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id
+		FROM group_members gm
+		JOIN groups g ON g.id = gm.group_id
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = $1 AND g.organization_id = $2 AND g.is_active = true
+		  AND u.organization_id = $2 AND u.is_active = true AND u.deleted_at IS NULL
+		ORDER BY u.id
+	`, groupID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		members = append(members, id)
+	}
+
+	return members, rows.Err()
+}
+
+// OpenLoad counts the tickets assigned to each user that are not yet
+// finished. Users with no open tickets are left out of the map.
+func (s *AssignmentRuleStore) OpenLoad(ctx context.Context, orgID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT assigned_to, COUNT(*)
+		FROM change_tickets
+		WHERE organization_id = $1 AND assigned_to = ANY($2) AND deleted_at IS NULL
+		  AND status NOT IN ('draft', 'denied', 'completed', 'closed', 'cancelled')
+		GROUP BY assigned_to
+	`, orgID, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open tickets: %w", err)
+	}
+	defer rows.Close()
+
+	load := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var id uuid.UUID
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan ticket count: %w", err)
+		}
+		load[id] = n
+	}
+
+	return load, rows.Err()
+}
+
+// Assign assigns a ticket on behalf of a rule and records the assignee as
+// the rule's round-robin position. The ticket is only assigned if it is
+// still waiting in the queue, so a manual assignment made in the meantime
+// is never overwritten.
+func (s *AssignmentRuleStore) Assign(ctx context.Context, orgID, ticketID, ruleID, userID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statuses := make([]string, len(models.AssignmentQueueStatuses))
+	for i, st := range models.AssignmentQueueStatuses {
+		statuses[i] = string(st)
+	}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE change_tickets
+		SET assigned_to = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND organization_id = $3 AND assigned_to IS NULL
+		  AND deleted_at IS NULL AND status = ANY($4)
+	`, userID, ticketID, orgID, pq.Array(statuses))
+	if err != nil {
+		return fmt.Errorf("failed to assign ticket: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.Conflict("ticket is no longer awaiting assignment")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE assignment_rules SET last_assigned_to = $1 WHERE id = $2 AND organization_id = $3",
+		userID, ruleID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update assignment rule: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.tickets.evict(ctx, orgID, ticketID)
+	return nil
+}
+
+func scanAssignmentRule(row rowScanner) (*models.AssignmentRule, error) {
+	rule := &models.AssignmentRule{}
+	var conditionsJSON []byte
+
+	err := row.Scan(
+		&rule.ID, &rule.OrganizationID, &rule.Name, &rule.Description, &rule.EvalOrder,
+		&conditionsJSON, &rule.Strategy, &rule.GroupID, &rule.LastAssignedTo,
+		&rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(conditionsJSON, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("invalid rule conditions: %w", err)
+	}
+
+	return rule, nil
+}
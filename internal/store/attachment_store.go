@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// AttachmentStore handles ticket attachment database operations
+type AttachmentStore struct {
+	db conn
+}
+
+const attachmentColumns = `
+	a.id, a.organization_id, a.ticket_id, a.filename, a.content_type, a.size_bytes,
+	a.sha256, a.object_key, a.uploaded_by, a.created_at, u.email, u.full_name
+`
+
+// Create records a file uploaded to object storage as an attachment of a
+// ticket. a.ID is the ID the object key was made from.
+func (s *AttachmentStore) Create(ctx context.Context, a *models.Attachment) (*models.Attachment, error) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO ticket_attachments (id, organization_id, ticket_id, filename, content_type, size_bytes, sha256, object_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, a.ID, a.OrganizationID, a.TicketID, a.Filename, a.ContentType, a.SizeBytes, a.SHA256, a.ObjectKey, a.UploadedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return s.GetByID(ctx, a.OrganizationID, a.TicketID, a.ID)
+}
+
+// GetByID retrieves an attachment of a ticket
+func (s *AttachmentStore) GetByID(ctx context.Context, orgID, ticketID, attachmentID uuid.UUID) (*models.Attachment, error) {
+	a, err := scanAttachment(s.db.QueryRowContext(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM ticket_attachments a
+		LEFT JOIN users u ON u.id = a.uploaded_by
+		WHERE a.id = $1 AND a.ticket_id = $2 AND a.organization_id = $3
+	`, attachmentID, ticketID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("attachment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return a, nil
+}
+
+// ListByTicket retrieves a ticket's attachments, oldest first
+func (s *AttachmentStore) ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Attachment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM ticket_attachments a
+		LEFT JOIN users u ON u.id = a.uploaded_by
+		WHERE a.organization_id = $1 AND a.ticket_id = $2
+		ORDER BY a.created_at, a.filename
+	`, orgID, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, *a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	a := &models.Attachment{}
+	var email, fullName sql.NullString
+	err := row.Scan(
+		&a.ID, &a.OrganizationID, &a.TicketID, &a.Filename, &a.ContentType, &a.SizeBytes,
+		&a.SHA256, &a.ObjectKey, &a.UploadedBy, &a.CreatedAt, &email, &fullName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if email.Valid && a.UploadedBy != nil {
+		a.Uploader = &models.UserSummary{ID: *a.UploadedBy, Email: email.String, FullName: fullName.String}
+	}
+	return a, nil
+}
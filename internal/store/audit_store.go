@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -14,24 +16,35 @@ import (
 
 // AuditStore handles audit log database operations
 type AuditStore struct {
-	db *sql.DB
+	db conn
 }
 
 // LogTicketAccess logs an access event for a ticket (SOX compliance)
 func (s *AuditStore) LogTicketAccess(ctx context.Context, ticketID, userID uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error {
+	return s.logTicketEvent(ctx, ticketID, &userID, action, ipAddress, userAgent, changes)
+}
+
+// LogSystemEvent logs an event performed by the system rather than a user,
+// such as worker-driven escalations
+func (s *AuditStore) LogSystemEvent(ctx context.Context, ticketID uuid.UUID, action string, changes map[string]interface{}) error {
+	return s.logTicketEvent(ctx, ticketID, nil, action, nil, nil, changes)
+}
+
+func (s *AuditStore) logTicketEvent(ctx context.Context, ticketID uuid.UUID, userID *uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error {
 	// Get ticket org and compliance info
 	var orgID uuid.UUID
 	var complianceFrameworks []string
+	var isEmergency bool
 	err := s.db.QueryRowContext(ctx,
-		"SELECT organization_id, compliance_frameworks FROM change_tickets WHERE id = $1",
+		"SELECT organization_id, compliance_frameworks, COALESCE(is_emergency, false) FROM change_tickets WHERE id = $1",
 		ticketID,
-	).Scan(&orgID, pq.Array(&complianceFrameworks))
+	).Scan(&orgID, pq.Array(&complianceFrameworks), &isEmergency)
 	if err != nil {
 		return fmt.Errorf("failed to get ticket info: %w", err)
 	}
 
-	actionCategory := getActionCategory(action)
-	isComplianceRelevant := isComplianceRelevantAction(action)
+	actionCategory := ActionCategory(action)
+	isComplianceRelevant := IsComplianceRelevantAction(action) || (isEmergency && actionCategory != "access")
 
 	changesJSON, _ := json.Marshal(changes)
 
@@ -39,14 +52,14 @@ func (s *AuditStore) LogTicketAccess(ctx context.Context, ticketID, userID uuid.
 		INSERT INTO ticket_audit_log (
 			ticket_id, organization_id, user_id, action, action_category,
 			changes, ip_address, user_agent, is_compliance_relevant,
-			compliance_frameworks, requires_review
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			compliance_frameworks, requires_review, is_emergency
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	_, err = s.db.ExecContext(ctx, query,
 		ticketID, orgID, userID, action, actionCategory,
 		changesJSON, ipAddress, userAgent, isComplianceRelevant,
-		pq.Array(complianceFrameworks), isComplianceRelevant,
+		pq.Array(complianceFrameworks), isComplianceRelevant, isEmergency,
 	)
 	return err
 }
@@ -70,6 +83,121 @@ func (s *AuditStore) LogTicketStatusChange(ctx context.Context, ticketID, userID
 	return s.LogTicketAccess(ctx, ticketID, userID, "status_change", ipAddress, userAgent, changes)
 }
 
+// ticketAuditRow holds the ticket_audit_log columns of one event
+const ticketAuditRow = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+// LogTicketEvents records many ticket events at once, for bulk operations
+// such as imports. The tickets are looked up in one query and the events
+// written with multi-row INSERTs in one transaction, so either all are
+// recorded or none.
+func (s *AuditStore) LogTicketEvents(ctx context.Context, events []models.TicketAuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	type ticketInfo struct {
+		orgID       uuid.UUID
+		frameworks  []string
+		isEmergency bool
+	}
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.TicketID)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, organization_id, compliance_frameworks, COALESCE(is_emergency, false) FROM change_tickets WHERE id = ANY($1)",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get ticket info: %w", err)
+	}
+	defer rows.Close()
+	tickets := make(map[uuid.UUID]ticketInfo)
+	for rows.Next() {
+		var id uuid.UUID
+		var t ticketInfo
+		if err := rows.Scan(&id, &t.orgID, pq.Array(&t.frameworks), &t.isEmergency); err != nil {
+			return fmt.Errorf("failed to get ticket info: %w", err)
+		}
+		tickets[id] = t
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get ticket info: %w", err)
+	}
+
+	args := make([]interface{}, 0, len(events)*12)
+	for _, e := range events {
+		t, ok := tickets[e.TicketID]
+		if !ok {
+			return models.NotFound(fmt.Sprintf("failed to get ticket info: ticket %s not found", e.TicketID))
+		}
+		actionCategory := ActionCategory(e.Action)
+		isComplianceRelevant := IsComplianceRelevantAction(e.Action) || (t.isEmergency && actionCategory != "access")
+		changesJSON, _ := json.Marshal(e.Changes)
+		args = append(args,
+			e.TicketID, t.orgID, e.UserID, e.Action, actionCategory,
+			changesJSON, e.IPAddress, e.UserAgent, isComplianceRelevant,
+			pq.Array(t.frameworks), isComplianceRelevant, t.isEmergency,
+		)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	batch := bulkBatchSize(12)
+	for start := 0; start < len(events); start += batch {
+		end := start + batch
+		if end > len(events) {
+			end = len(events)
+		}
+		query := `
+			INSERT INTO ticket_audit_log (
+				ticket_id, organization_id, user_id, action, action_category,
+				changes, ip_address, user_agent, is_compliance_relevant,
+				compliance_frameworks, requires_review, is_emergency
+			) VALUES ` + bulkValues(end-start, ticketAuditRow)
+		if _, err := tx.ExecContext(ctx, query, args[start*12:end*12]...); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListStatusChangesForCreator retrieves status changes made in [from, to)
+// to tickets the user created, oldest first. Changes the user made
+// themselves are left out.
+func (s *AuditStore) ListStatusChangesForCreator(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time, limit int) ([]models.DigestStatusChange, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.ticket_number, t.title,
+		       COALESCE(l.changes->>'old_status', ''), COALESCE(l.changes->>'new_status', ''), l.created_at
+		FROM ticket_audit_log l
+		JOIN change_tickets t ON t.id = l.ticket_id
+		WHERE l.action = 'status_change' AND l.created_at >= $3 AND l.created_at < $4
+		  AND t.organization_id = $1 AND t.created_by = $2 AND t.deleted_at IS NULL
+		  AND l.user_id IS DISTINCT FROM $2
+		ORDER BY l.created_at
+		LIMIT $5
+	`, orgID, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.DigestStatusChange
+	for rows.Next() {
+		var c models.DigestStatusChange
+		if err := rows.Scan(&c.TicketNumber, &c.Title, &c.From, &c.To, &c.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
 // GetTicketAuditLog retrieves audit log entries for a ticket
 func (s *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID, filter *models.AuditLogFilter) ([]models.TicketAuditLog, int, error) {
 	filter.SetDefaults()
@@ -112,6 +240,25 @@ func (s *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID,
 		argNum++
 	}
 
+	if filter.IsEmergency != nil {
+		conditions = append(conditions, fmt.Sprintf("is_emergency = $%d", argNum))
+		args = append(args, *filter.IsEmergency)
+		argNum++
+	}
+
+	// Date bounds limit the query to the partitions of those months
+	if filter.FromDate != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argNum))
+		args = append(args, *filter.FromDate)
+		argNum++
+	}
+
+	if filter.ToDate != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argNum))
+		args = append(args, *filter.ToDate)
+		argNum++
+	}
+
 	whereClause := strings.Join(conditions, " AND ")
 
 	// Count total
@@ -122,19 +269,32 @@ func (s *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID,
 		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
 	}
 
-	// Get logs
+	offset := filter.Offset()
+	condition, cursorArgs, err := cursorCondition(filter.Cursor, "created_at", "DESC", argNum)
+	if err != nil {
+		return nil, 0, err
+	}
+	if condition != "" {
+		// The plain bound on created_at lets later pages skip newer partitions
+		whereClause += " AND " + condition + fmt.Sprintf(" AND created_at <= $%d", argNum)
+		args = append(args, cursorArgs...)
+		argNum += len(cursorArgs)
+		offset = 0
+	}
+
+	// Get logs, plus one extra row to tell whether another page follows
 	query := fmt.Sprintf(`
 		SELECT id, ticket_id, organization_id, user_id, action, action_category,
 		       field_name, old_value, new_value, changes, ip_address, user_agent,
 		       session_id, request_id, is_compliance_relevant, compliance_frameworks,
-		       requires_review, reviewed_by, reviewed_at, created_at
+		       requires_review, is_emergency, reviewed_by, reviewed_at, created_at
 		FROM ticket_audit_log
 		WHERE %s
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT $%d OFFSET $%d
 	`, whereClause, argNum, argNum+1)
 
-	args = append(args, filter.PerPage, filter.Offset())
+	args = append(args, filter.PerPage+1, offset)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -153,7 +313,7 @@ func (s *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID,
 			&log.NewValue, &changesJSON, &log.IPAddress, &log.UserAgent,
 			&log.SessionID, &log.RequestID, &log.IsComplianceRelevant,
 			pq.Array(&complianceFrameworks), &log.RequiresReview,
-			&log.ReviewedBy, &log.ReviewedAt, &log.CreatedAt,
+			&log.IsEmergency, &log.ReviewedBy, &log.ReviewedAt, &log.CreatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan audit log: %w", err)
@@ -171,6 +331,13 @@ func (s *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID,
 		logs = append(logs, log)
 	}
 
+	filter.NextCursor = ""
+	if len(logs) > filter.PerPage {
+		logs = logs[:filter.PerPage]
+		last := &logs[len(logs)-1]
+		filter.NextCursor = models.TimeCursor("created_at", last.CreatedAt, last.ID).Encode()
+	}
+
 	return logs, total, nil
 }
 
@@ -233,24 +400,380 @@ func (s *AuditStore) GetPendingReviews(ctx context.Context, orgID uuid.UUID) ([]
 	return logs, nil
 }
 
-func getActionCategory(action string) string {
+// ActionCategory returns the category a ticket audit action is filed under:
+// access, modification, approval, compliance, emergency, access_control or
+// other
+func ActionCategory(action string) string {
 	switch action {
 	case "view", "search", "export":
 		return "access"
-	case "create", "update", "edit", "delete":
+	case "create", "update", "edit", "delete", models.AuditActionRepositoryLink, models.AuditActionRepositoryUnlink, models.AuditActionImport,
+		models.AuditActionBlackoutStart, models.AuditActionBlackoutEnd, models.AuditActionBlackoutExtend,
+		models.AuditActionAutoAssign:
 		return "modification"
 	case "approve", "deny", "submit", "status_change":
 		return "approval"
+	case "pir_submit", "pir_sign_off":
+		return "compliance"
+	case models.AuditActionEmergencySubmit, models.AuditActionEmergencyEscalation:
+		return "emergency"
+	case models.AuditActionACLGrant, models.AuditActionACLRevoke,
+		models.AuditActionPasskeyRegister, models.AuditActionPasskeyRevoke,
+		models.AuditActionMFAEnable, models.AuditActionMFADisable, models.AuditActionMFARequire,
+		models.AuditActionMFABackupCodes, models.AuditActionMFAStepUp, models.AuditActionMFALockout,
+		models.AuditActionSSOProvision, models.AuditActionSSOLink, models.AuditActionSSOConfigure:
+		return "access_control"
 	default:
 		return "other"
 	}
 }
 
-func isComplianceRelevantAction(action string) bool {
+// IsComplianceRelevantAction reports whether entries for a ticket audit
+// action are compliance relevant and need review
+func IsComplianceRelevantAction(action string) bool {
 	switch action {
-	case "create", "update", "edit", "delete", "approve", "deny", "submit", "status_change":
+	case "create", "update", "edit", "delete", "approve", "deny", "submit", "status_change",
+		"pir_submit", "pir_sign_off",
+		models.AuditActionEmergencySubmit, models.AuditActionEmergencyEscalation,
+		models.AuditActionACLGrant, models.AuditActionACLRevoke,
+		models.AuditActionPasskeyRegister, models.AuditActionPasskeyRevoke,
+		models.AuditActionMFAEnable, models.AuditActionMFADisable, models.AuditActionMFARequire,
+		models.AuditActionMFABackupCodes, models.AuditActionMFALockout,
+		models.AuditActionSSOProvision, models.AuditActionSSOLink, models.AuditActionSSOConfigure,
+		models.AuditActionRepositoryLink, models.AuditActionRepositoryUnlink, models.AuditActionImport,
+		models.AuditActionBlackoutStart, models.AuditActionBlackoutEnd, models.AuditActionBlackoutExtend,
+		models.AuditActionAutoAssign:
 		return true
 	default:
 		return false
 	}
 }
+
+// exportConditions builds the WHERE clause shared by the export count and stream queries
+func exportConditions(orgID uuid.UUID, filter *models.AuditExportFilter) (string, []interface{}) {
+	conditions := []string{"organization_id = $1"}
+	args := []interface{}{orgID}
+	argNum := 2
+
+	if filter.FromDate != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argNum))
+		args = append(args, *filter.FromDate)
+		argNum++
+	}
+
+	if filter.ToDate != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argNum))
+		args = append(args, *filter.ToDate)
+		argNum++
+	}
+
+	if len(filter.Frameworks) > 0 {
+		conditions = append(conditions, fmt.Sprintf("compliance_frameworks && $%d::compliance_framework[]", argNum))
+		args = append(args, pq.Array(filter.Frameworks))
+		argNum++
+	}
+
+	if filter.ActionCategory != nil {
+		conditions = append(conditions, fmt.Sprintf("action_category = $%d", argNum))
+		args = append(args, *filter.ActionCategory)
+		argNum++
+	}
+
+	if filter.ComplianceOnly {
+		conditions = append(conditions, "is_compliance_relevant = true")
+	}
+
+	if filter.EmergencyOnly {
+		conditions = append(conditions, "is_emergency = true")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// CountForExport returns the number of entries an export would contain
+func (s *AuditStore) CountForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter) (int, error) {
+	whereClause, args := exportConditions(orgID, filter)
+
+	var total int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM ticket_audit_log WHERE %s", whereClause)
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	return total, nil
+}
+
+// StreamForExport calls fn for every matching entry in chronological order,
+// up to filter.MaxRows, without loading the result set into memory
+func (s *AuditStore) StreamForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter, fn func(*models.TicketAuditLog) error) error {
+	filter.SetDefaults()
+	whereClause, args := exportConditions(orgID, filter)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ticket_audit_log
+		WHERE %s
+		ORDER BY created_at ASC, id ASC
+		LIMIT %d
+	`, auditExportColumns, whereClause, filter.MaxRows)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, err := scanAuditExport(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// StreamRange calls fn for every organization's entries created in
+// [from, to) in chronological order, for archiving
+func (s *AuditStore) StreamRange(ctx context.Context, from, to time.Time, fn func(*models.TicketAuditLog) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+auditExportColumns+`
+		FROM ticket_audit_log
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at ASC, id ASC
+	`, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to read audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, err := scanAuditExport(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+const auditExportColumns = `
+	id, ticket_id, organization_id, user_id, action, action_category,
+	field_name, old_value, new_value, changes, ip_address, user_agent,
+	session_id, request_id, is_compliance_relevant, compliance_frameworks,
+	requires_review, is_emergency, reviewed_by, reviewed_at, created_at`
+
+func scanAuditExport(row rowScanner) (*models.TicketAuditLog, error) {
+	var log models.TicketAuditLog
+	var changesJSON []byte
+	var complianceFrameworks []string
+	err := row.Scan(
+		&log.ID, &log.TicketID, &log.OrganizationID, &log.UserID,
+		&log.Action, &log.ActionCategory, &log.FieldName, &log.OldValue,
+		&log.NewValue, &changesJSON, &log.IPAddress, &log.UserAgent,
+		&log.SessionID, &log.RequestID, &log.IsComplianceRelevant,
+		pq.Array(&complianceFrameworks), &log.RequiresReview,
+		&log.IsEmergency, &log.ReviewedBy, &log.ReviewedAt, &log.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if changesJSON != nil {
+		json.Unmarshal(changesJSON, &log.Changes)
+	}
+
+	log.ComplianceFrameworks = make([]models.ComplianceFramework, len(complianceFrameworks))
+	for i, cf := range complianceFrameworks {
+		log.ComplianceFrameworks[i] = models.ComplianceFramework(cf)
+	}
+	return &log, nil
+}
+
+// ArchivedDays returns which days in [from, to] have been archived, keyed
+// by date as YYYY-MM-DD
+func (s *AuditStore) ArchivedDays(ctx context.Context, from, to time.Time) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT to_char(archive_date, 'YYYY-MM-DD') FROM audit_log_archives
+		WHERE archive_date BETWEEN $1::date AND $2::date
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit archives: %w", err)
+	}
+	defer rows.Close()
+
+	days := make(map[string]bool)
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("failed to scan audit archive: %w", err)
+		}
+		days[day] = true
+	}
+
+	return days, rows.Err()
+}
+
+// LastArchivedDay returns the latest archived day, or nil if none has been
+func (s *AuditStore) LastArchivedDay(ctx context.Context) (*time.Time, error) {
+	var day sql.NullTime
+	err := s.db.QueryRowContext(ctx, "SELECT MAX(archive_date) FROM audit_log_archives").Scan(&day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last audit archive: %w", err)
+	}
+	if !day.Valid {
+		return nil, nil
+	}
+	return &day.Time, nil
+}
+
+// auditPartitionName matches the monthly partitions of ticket_audit_log,
+// leaving out the default partition
+var auditPartitionName = regexp.MustCompile(`^ticket_audit_log_y([0-9]{4})m([0-9]{2})$`)
+
+// EnsurePartitions creates the monthly audit log partitions missing from the
+// current UTC month through the month of through, and returns how many it
+// created
+func (s *AuditStore) EnsurePartitions(ctx context.Context, through time.Time) (int, error) {
+	var created int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT ensure_ticket_audit_partitions($1::date)", through.UTC().Format("2006-01-02"),
+	).Scan(&created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create audit log partitions: %w", err)
+	}
+	return created, nil
+}
+
+// ListPartitions lists the monthly audit log partitions, oldest first. Row
+// counts are the planner's estimates, as of the partition's last analyze.
+func (s *AuditStore) ListPartitions(ctx context.Context) ([]models.AuditPartition, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'ticket_audit_log'::regclass
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []models.AuditPartition
+	for rows.Next() {
+		var p models.AuditPartition
+		if err := rows.Scan(&p.Name, &p.EstimatedRows); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log partition: %w", err)
+		}
+		m := auditPartitionName.FindStringSubmatch(p.Name)
+		if m == nil {
+			continue
+		}
+		p.From, _ = time.Parse("2006-01", m[1]+"-"+m[2])
+		p.To = p.From.AddDate(0, 1, 0)
+		partitions = append(partitions, p)
+	}
+
+	return partitions, rows.Err()
+}
+
+// DropPartition detaches and drops the audit log partition of the UTC month
+// containing month. Every day of the month must have been archived first;
+// the archive is then the only copy of its entries.
+func (s *AuditStore) DropPartition(ctx context.Context, month time.Time) error {
+	from := time.Date(month.UTC().Year(), month.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	name := fmt.Sprintf("ticket_audit_log_y%04dm%02d", from.Year(), int(from.Month()))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to find audit log partition: %w", err)
+	}
+	if !exists {
+		return models.NotFound("audit log partition not found")
+	}
+
+	var archivedDays int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM audit_log_archives WHERE archive_date >= $1::date AND archive_date < $2::date",
+		from.Format("2006-01-02"), to.Format("2006-01-02"),
+	).Scan(&archivedDays)
+	if err != nil {
+		return fmt.Errorf("failed to count audit archives: %w", err)
+	}
+	if archivedDays < int(to.Sub(from).Hours()/24) {
+		return models.Conflict("audit log partition not fully archived")
+	}
+
+	// The name is built from the month above, not from input
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE ticket_audit_log DETACH PARTITION "+name); err != nil {
+		return fmt.Errorf("failed to detach audit log partition: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DROP TABLE "+name); err != nil {
+		return fmt.Errorf("failed to drop audit log partition: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RecordArchive records a day's archive once its objects are uploaded
+func (s *AuditStore) RecordArchive(ctx context.Context, a *models.AuditArchive) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_log_archives (
+			archive_date, object_key, manifest_key, record_count,
+			compressed_bytes, sha256, uncompressed_sha256, exported_at
+		) VALUES ($1::date, $2, $3, $4, $5, $6, $7, $8)
+	`, a.Date.Format("2006-01-02"), a.ObjectKey, a.ManifestKey, a.RecordCount,
+		a.CompressedBytes, a.SHA256, a.UncompressedSHA256, a.ExportedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit archive: %w", err)
+	}
+	return nil
+}
+
+// Log records an organization-level audit event (logins, exports, admin actions)
+func (s *AuditStore) Log(ctx context.Context, orgID uuid.UUID, input *models.CreateAuditLogInput) error {
+	var ipAddress *string
+	if input.IPAddress != nil {
+		ip := input.IPAddress.String()
+		ipAddress = &ip
+	}
+
+	query := `
+		INSERT INTO audit_log (
+			organization_id, user_id, username, action, resource_type, resource_id,
+			description, changes, metadata, ip_address, user_agent, session_id,
+			compliance_relevant, compliance_frameworks
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		orgID, input.UserID, input.Username, input.Action, input.ResourceType,
+		input.ResourceID, input.Description, nullableJSON(input.Changes),
+		nullableJSON(input.Metadata), ipAddress, input.UserAgent, input.SessionID,
+		input.ComplianceRelevant, pq.Array(input.ComplianceFrameworks),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+func nullableJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return []byte(data)
+}
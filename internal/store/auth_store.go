@@ -0,0 +1,535 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// AuthStore handles login challenges, sessions and the account data used
+// to authenticate users
+type AuthStore struct {
+	db conn
+}
+
+// Only active users in live organizations can sign in
+const loginUserColumns = `
+	u.id, u.organization_id, u.email, u.full_name, u.password_hash,
+	COALESCE(u.mfa_enabled, false), u.mfa_secret, COALESCE(cardinality(u.backup_codes), 0),
+	u.roles, COALESCE(u.webauthn_credentials, '[]'),
+	u.mfa_required, COALESCE(o.require_mfa, false), o.mfa_required_roles,
+	u.oauth_provider, u.oauth_subject, u.customer_id,
+	CASE WHEN u.mfa_locked_until > NOW() THEN u.mfa_locked_until END
+`
+
+const loginUserFrom = `
+	FROM users u
+	JOIN organizations o ON o.id = u.organization_id
+	WHERE u.is_active = true AND u.deleted_at IS NULL AND o.deleted_at IS NULL
+`
+
+// CreateChallenge stores a single-use challenge and returns its ID. data
+// carries any state the next step needs and may be nil.
+func (s *AuthStore) CreateChallenge(ctx context.Context, purpose models.AuthChallengePurpose, userID *uuid.UUID, challenge []byte, data json.RawMessage, ttl time.Duration) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO auth_challenges (purpose, user_id, challenge, data, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, purpose, userID, challenge, nullableJSON(data), time.Now().Add(ttl)).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create challenge: %w", err)
+	}
+	return id, nil
+}
+
+// ConsumeChallenge removes and returns an unexpired challenge, so each one
+// can be answered only once
+func (s *AuthStore) ConsumeChallenge(ctx context.Context, id uuid.UUID, purpose models.AuthChallengePurpose) (*models.AuthChallenge, error) {
+	c := &models.AuthChallenge{}
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `
+		DELETE FROM auth_challenges
+		WHERE id = $1 AND purpose = $2
+		RETURNING id, purpose, user_id, challenge, data, expires_at, created_at
+	`, id, purpose).Scan(&c.ID, &c.Purpose, &c.UserID, &c.Challenge, &data, &c.ExpiresAt, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("challenge not found or expired")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	c.Data = data
+	if time.Now().After(c.ExpiresAt) {
+		return nil, fmt.Errorf("challenge not found or expired")
+	}
+	return c, nil
+}
+
+// GetChallenge returns an unexpired challenge without using it up
+func (s *AuthStore) GetChallenge(ctx context.Context, id uuid.UUID, purpose models.AuthChallengePurpose) (*models.AuthChallenge, error) {
+	c := &models.AuthChallenge{}
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, purpose, user_id, challenge, data, expires_at, created_at
+		FROM auth_challenges
+		WHERE id = $1 AND purpose = $2 AND expires_at > NOW()
+	`, id, purpose).Scan(&c.ID, &c.Purpose, &c.UserID, &c.Challenge, &data, &c.ExpiresAt, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("challenge not found or expired")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+	c.Data = data
+	return c, nil
+}
+
+// FailChallenge counts a wrong answer to a challenge and removes it once
+// the allowed attempts are used up
+func (s *AuthStore) FailChallenge(ctx context.Context, id uuid.UUID, maxAttempts int) error {
+	_, err := s.db.ExecContext(ctx, `
+		WITH failed AS (
+			UPDATE auth_challenges SET attempts = attempts + 1 WHERE id = $1 RETURNING id, attempts
+		)
+		DELETE FROM auth_challenges c USING failed f WHERE c.id = f.id AND f.attempts >= $2
+	`, id, maxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to record attempt: %w", err)
+	}
+	return nil
+}
+
+// CreateDeviceLogin stores a pending device login under the hash of its
+// user code. The returned ID is the device code the client polls with.
+func (s *AuthStore) CreateDeviceLogin(ctx context.Context, userCode string, ttl time.Duration) (uuid.UUID, error) {
+	return s.CreateChallenge(ctx, models.AuthChallengeDeviceLogin, nil, []byte(hashVerificationToken(userCode)), nil, ttl)
+}
+
+// ApproveDeviceLogin hands a pending device login to the user who entered
+// its code. data records how they were signed in when they approved it.
+func (s *AuthStore) ApproveDeviceLogin(ctx context.Context, userCode string, userID uuid.UUID, data json.RawMessage) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE auth_challenges SET user_id = $3, data = $4
+		WHERE purpose = $1 AND challenge = $2 AND user_id IS NULL AND expires_at > NOW()
+	`, models.AuthChallengeDeviceLogin, []byte(hashVerificationToken(userCode)), userID, nullableJSON(data))
+	if err != nil {
+		return fmt.Errorf("failed to approve device login: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("code not found or expired")
+	}
+	return nil
+}
+
+// PurgeChallenges deletes expired challenges and returns how many were removed
+func (s *AuthStore) PurgeChallenges(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM auth_challenges WHERE expires_at < NOW()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge challenges: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PurgeSessions deletes sessions that expired, or were revoked, before the
+// cutoff and returns how many were removed
+func (s *AuthStore) PurgeSessions(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM sessions
+		WHERE expires_at < $1 OR (revoked AND revoked_at < $1)
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// FindLoginUsers returns the active accounts with an email address, in any
+// organization unless an org slug is given
+func (s *AuthStore) FindLoginUsers(ctx context.Context, email, orgSlug string) ([]models.LoginUser, error) {
+	query := "SELECT " + loginUserColumns + loginUserFrom + " AND lower(u.email) = lower($1)"
+	args := []interface{}{email}
+	if orgSlug != "" {
+		query += " AND o.slug = $2"
+		args = append(args, orgSlug)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.LoginUser
+	for rows.Next() {
+		u, err := scanLoginUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *u)
+	}
+
+	return users, rows.Err()
+}
+
+// GetLoginUser returns an active account by ID
+func (s *AuthStore) GetLoginUser(ctx context.Context, userID uuid.UUID) (*models.LoginUser, error) {
+	query := "SELECT " + loginUserColumns + loginUserFrom + " AND u.id = $1"
+	u, err := scanLoginUser(s.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// FindPasskeyUser returns the active account a passkey is registered to
+func (s *AuthStore) FindPasskeyUser(ctx context.Context, credentialID string) (*models.LoginUser, error) {
+	match, _ := json.Marshal([]map[string]string{{"id": credentialID}})
+	query := "SELECT " + loginUserColumns + loginUserFrom + " AND u.webauthn_credentials @> $1"
+	u, err := scanLoginUser(s.db.QueryRowContext(ctx, query, match))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("passkey not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkey: %w", err)
+	}
+	return u, nil
+}
+
+// ListPasskeys returns a user's registered passkeys
+func (s *AuthStore) ListPasskeys(ctx context.Context, orgID, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(webauthn_credentials, '[]')
+		FROM users
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
+	`, userID, orgID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+
+	var creds []models.WebAuthnCredential
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("invalid stored passkeys: %w", err)
+	}
+	return creds, nil
+}
+
+// AddPasskey stores a newly registered passkey. A credential ID can belong
+// to only one account.
+func (s *AuthStore) AddPasskey(ctx context.Context, userID uuid.UUID, cred *models.WebAuthnCredential) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	match, _ := json.Marshal([]map[string]string{{"id": cred.ID}})
+	var exists bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE webauthn_credentials @> $1)", match,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check passkey: %w", err)
+	}
+	if exists {
+		return models.Conflict("passkey is already registered")
+	}
+
+	var count int
+	err = tx.QueryRowContext(ctx,
+		"SELECT jsonb_array_length(COALESCE(webauthn_credentials, '[]')) FROM users WHERE id = $1 FOR UPDATE",
+		userID,
+	).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count passkeys: %w", err)
+	}
+	if count >= models.MaxPasskeysPerUser {
+		return models.Conflict("passkey limit reached")
+	}
+
+	entry, _ := json.Marshal([]*models.WebAuthnCredential{cred})
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET webauthn_credentials = COALESCE(webauthn_credentials, '[]') || $1::jsonb, updated_at = NOW()
+		WHERE id = $2
+	`, entry, userID)
+	if err != nil {
+		return fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RecordPasskeyUse stores a passkey's new signature count and last use
+func (s *AuthStore) RecordPasskeyUse(ctx context.Context, userID uuid.UUID, credentialID string, signCount uint32) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET webauthn_credentials = (
+			SELECT jsonb_agg(CASE WHEN c->>'id' = $2
+				THEN c || jsonb_build_object('sign_count', $3::bigint, 'last_used_at', NOW())
+				ELSE c END)
+			FROM jsonb_array_elements(webauthn_credentials) c
+		)
+		WHERE id = $1
+	`, userID, credentialID, signCount)
+	if err != nil {
+		return fmt.Errorf("failed to record passkey use: %w", err)
+	}
+	return nil
+}
+
+// RemovePasskey deletes one of a user's passkeys
+func (s *AuthStore) RemovePasskey(ctx context.Context, orgID, userID uuid.UUID, credentialID string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET webauthn_credentials = COALESCE((
+			SELECT jsonb_agg(c) FROM jsonb_array_elements(webauthn_credentials) c WHERE c->>'id' <> $3
+		), '[]'), updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2
+		  AND webauthn_credentials @> jsonb_build_array(jsonb_build_object('id', $3::text))
+	`, userID, orgID, credentialID)
+	if err != nil {
+		return fmt.Errorf("failed to remove passkey: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("passkey not found")
+	}
+	return nil
+}
+
+// StartMFAEnrollment stores a new TOTP secret for a user who hasn't
+// enabled MFA yet. It replaces any earlier unfinished enrollment.
+func (s *AuthStore) StartMFAEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET mfa_secret = $2, mfa_last_step = NULL, updated_at = NOW()
+		WHERE id = $1 AND NOT COALESCE(mfa_enabled, false)
+	`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to start mfa enrollment: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.Conflict("mfa is already enabled")
+	}
+	return nil
+}
+
+// EnableMFA turns on MFA once the user has confirmed a code, storing the
+// hashes of their backup codes
+func (s *AuthStore) EnableMFA(ctx context.Context, userID uuid.UUID, backupCodes []string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET mfa_enabled = true, backup_codes = $2, updated_at = NOW()
+		WHERE id = $1 AND mfa_secret IS NOT NULL AND NOT COALESCE(mfa_enabled, false)
+	`, userID, pq.Array(hashBackupCodes(backupCodes)))
+	if err != nil {
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.InvalidTransition("mfa enrollment not started")
+	}
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted code. It returns false
+// if that step or a later one was already used, so a code works only once.
+func (s *AuthStore) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET mfa_last_step = $2
+		WHERE id = $1 AND (mfa_last_step IS NULL OR mfa_last_step < $2)
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record mfa code: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// UseBackupCode removes a backup code, returning false if it isn't one of
+// the user's unused codes
+func (s *AuthStore) UseBackupCode(ctx context.Context, userID uuid.UUID, code string) (bool, error) {
+	hash := hashVerificationToken(code)
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET backup_codes = array_remove(backup_codes, $2)
+		WHERE id = $1 AND $2 = ANY(backup_codes)
+	`, userID, hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use backup code: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// FailMFACode counts a wrong MFA code from a signed-in user. Once
+// maxAttempts are wrong in a row it locks the user's MFA for lockout, starts
+// the count again and returns true.
+func (s *AuthStore) FailMFACode(ctx context.Context, userID uuid.UUID, maxAttempts int, lockout time.Duration) (bool, error) {
+	var locked bool
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users SET
+			mfa_failed_attempts = CASE WHEN mfa_failed_attempts + 1 >= $2 THEN 0 ELSE mfa_failed_attempts + 1 END,
+			mfa_locked_until = CASE WHEN mfa_failed_attempts + 1 >= $2 THEN NOW() + $3 * INTERVAL '1 second' ELSE mfa_locked_until END
+		WHERE id = $1
+		RETURNING mfa_failed_attempts = 0
+	`, userID, maxAttempts, lockout.Seconds()).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return false, models.NotFound("user not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record mfa attempt: %w", err)
+	}
+	return locked, nil
+}
+
+// ClearMFAFailures starts the count of wrong MFA codes again after a right one
+func (s *AuthStore) ClearMFAFailures(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET mfa_failed_attempts = 0 WHERE id = $1 AND mfa_failed_attempts > 0",
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear mfa attempts: %w", err)
+	}
+	return nil
+}
+
+// ReplaceBackupCodes swaps a user's backup codes for a new set
+func (s *AuthStore) ReplaceBackupCodes(ctx context.Context, userID uuid.UUID, backupCodes []string) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET backup_codes = $2, updated_at = NOW() WHERE id = $1 AND mfa_enabled = true",
+		userID, pq.Array(hashBackupCodes(backupCodes)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to replace backup codes: %w", err)
+	}
+	return nil
+}
+
+// ResetMFA turns off MFA and discards the user's secret and backup codes
+func (s *AuthStore) ResetMFA(ctx context.Context, orgID, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET mfa_enabled = false, mfa_secret = NULL, backup_codes = NULL, mfa_last_step = NULL,
+			mfa_failed_attempts = 0, mfa_locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
+	`, userID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to reset mfa: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("user not found")
+	}
+	return nil
+}
+
+// SetMFARequired sets whether a user must use MFA regardless of their roles
+func (s *AuthStore) SetMFARequired(ctx context.Context, orgID, userID uuid.UUID, required bool) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET mfa_required = $3, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
+	`, userID, orgID, required)
+	if err != nil {
+		return fmt.Errorf("failed to update mfa requirement: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("user not found")
+	}
+	return nil
+}
+
+// CreateSession records a login under the session ID carried in the access
+// token. Only a hash of the token is stored.
+func (s *AuthStore) CreateSession(ctx context.Context, sessionID uuid.UUID, user *models.LoginUser, accessToken string, ip net.IP, userAgent string, expiresAt time.Time) error {
+	var ipAddress *string
+	if ip != nil {
+		v := ip.String()
+		ipAddress = &v
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sessions (id, user_id, organization_id, session_token, ip_address, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, sessionID, user.ID, user.OrganizationID, hashVerificationToken(accessToken), ipAddress, userAgent, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET last_login_at = NOW(), last_login_ip = $1 WHERE id = $2",
+		ipAddress, user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RotateSessionToken replaces a session's access token after step-up
+func (s *AuthStore) RotateSessionToken(ctx context.Context, sessionID uuid.UUID, accessToken string, expiresAt time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET session_token = $2, expires_at = $3, last_active_at = NOW()
+		WHERE id = $1 AND NOT COALESCE(revoked, false)
+	`, sessionID, hashVerificationToken(accessToken), expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("session not found")
+	}
+	return nil
+}
+
+func hashBackupCodes(codes []string) []string {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashVerificationToken(code)
+	}
+	return hashes
+}
+
+func scanLoginUser(row rowScanner) (*models.LoginUser, error) {
+	u := &models.LoginUser{}
+	var credentials []byte
+	var mfaRoles []string
+	err := row.Scan(
+		&u.ID, &u.OrganizationID, &u.Email, &u.FullName, &u.PasswordHash,
+		&u.MFAEnabled, &u.MFASecret, &u.BackupCodes,
+		pq.Array(&u.Roles), &credentials,
+		&u.MFARequiredByAdmin, &u.OrgRequireMFA, pq.Array(&mfaRoles),
+		&u.OAuthProvider, &u.OAuthSubject, &u.CustomerID,
+		&u.MFALockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	u.OrgMFARoles = make([]models.UserRole, len(mfaRoles))
+	for i, r := range mfaRoles {
+		u.OrgMFARoles[i] = models.UserRole(r)
+	}
+	if err := json.Unmarshal(credentials, &u.Credentials); err != nil {
+		return nil, fmt.Errorf("invalid stored passkeys: %w", err)
+	}
+	return u, nil
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxBindParams is the most parameters Postgres accepts in one statement
+const maxBindParams = 65535
+
+// bulkBatchSize is how many rows with perRow parameters each fit in one
+// multi-row INSERT
+func bulkBatchSize(perRow int) int {
+	return maxBindParams / perRow
+}
+
+// bulkValues repeats the row template, such as "(?, ?, COALESCE(?, NOW()))",
+// rows times for a multi-row VALUES list, numbering the ? placeholders
+// from $1
+func bulkValues(rows int, row string) string {
+	var b strings.Builder
+	n := 0
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		for _, part := range strings.SplitAfter(row, "?") {
+			if strings.HasSuffix(part, "?") {
+				n++
+				b.WriteString(part[:len(part)-1])
+				fmt.Fprintf(&b, "$%d", n)
+				continue
+			}
+			b.WriteString(part)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,25 @@
+package store
+
+import "testing"
+
+func TestBulkValues(t *testing.T) {
+	tests := []struct {
+		name string
+		rows int
+		row  string
+		want string
+	}{
+		{"none", 0, "(?, ?)", ""},
+		{"one row", 1, "(?, ?)", "($1, $2)"},
+		{"numbering continues across rows", 3, "(?, ?)", "($1, $2), ($3, $4), ($5, $6)"},
+		{"placeholders inside expressions", 2, "(?, COALESCE(?, NOW()))", "($1, COALESCE($2, NOW())), ($3, COALESCE($4, NOW()))"},
+		{"no placeholders", 2, "(DEFAULT)", "(DEFAULT), (DEFAULT)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bulkValues(tt.rows, tt.row); got != tt.want {
+				t.Errorf("bulkValues(%d, %q) = %q, want %q", tt.rows, tt.row, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// CABStore handles change management board agenda and meeting database
+// operations
+type CABStore struct {
+	db      conn
+	tickets ticketEvicter
+}
+
+const cabMeetingColumns = `
+	id, organization_id, meeting_date, recorded_by, attendees, notes, decisions, created_at
+`
+
+// ListAwaiting retrieves tickets submitted before the given time that are
+// still awaiting approval and require the change management board, oldest
+// submission first. Tickets the caller may not see are left out.
+func (s *CABStore) ListAwaiting(ctx context.Context, orgID uuid.UUID, submittedBefore time.Time) ([]models.Ticket, error) {
+	statuses := make([]string, len(models.CABAwaitingStatuses))
+	for i, st := range models.CABAwaitingStatuses {
+		statuses[i] = string(st)
+	}
+
+	conditions := []string{
+		"organization_id = $1",
+		"deleted_at IS NULL",
+		"status = ANY($2)",
+		"submitted_at < $3",
+		`(approval_plan->'requirements' @> '[{"approval_type": "change_management_board"}]'
+			OR 'change_management_board' = ANY(requires_approval_types))`,
+	}
+	args := []interface{}{orgID, pq.Array(statuses), submittedBefore}
+	if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+		condition, visArgs := ticketVisibilityCondition(a, 4)
+		conditions = append(conditions, condition)
+		args = append(args, visArgs...)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM change_tickets
+		WHERE %s
+		ORDER BY submitted_at, ticket_number
+	`, ticketColumns, strings.Join(conditions, " AND "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets awaiting the board: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []models.Ticket
+	for rows.Next() {
+		t, err := scanTicket(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		tickets = append(tickets, *t)
+	}
+
+	return tickets, rows.Err()
+}
+
+// RecordDecision records the board's decision on a ticket as the caller's
+// change management board approval, then moves the ticket to the status its
+// approvals now imply. The status change is recorded as a revision.
+func (s *CABStore) RecordDecision(ctx context.Context, orgID, userID uuid.UUID, decision *models.CABTicketDecision) (*models.Ticket, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize decisions on the ticket so concurrent approvals are counted
+	_, err = tx.ExecContext(ctx,
+		"SELECT 1 FROM change_tickets WHERE id = $1 AND organization_id = $2 FOR UPDATE",
+		decision.TicketID, orgID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ticket: %w", err)
+	}
+	before, err := getTicket(ctx, tx, orgID, decision.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	if !awaitingApproval(before) {
+		return nil, models.InvalidTransition("ticket is not awaiting approval")
+	}
+	if before.CABQuorum() == 0 {
+		return nil, models.InvalidTransition("ticket does not require change management board approval")
+	}
+
+	status := decision.Decision.ApprovalStatus()
+	var approvedAt, deniedAt *time.Time
+	now := time.Now()
+	if status == models.ApprovalStatusApproved {
+		approvedAt = &now
+	} else {
+		deniedAt = &now
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO approvals (
+			ticket_id, organization_id, approval_type, approver_id, status,
+			approved_at, denied_at, decision_comment, conditions
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (ticket_id, approval_type, approver_id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    approved_at = EXCLUDED.approved_at,
+		    denied_at = EXCLUDED.denied_at,
+		    decision_comment = EXCLUDED.decision_comment,
+		    conditions = EXCLUDED.conditions,
+		    updated_at = NOW()
+	`, decision.TicketID, orgID, models.ApprovalTypeChangeManagementBoard, userID, status,
+		approvedAt, deniedAt, nullableString(decision.Comment), nullableString(decision.Conditions),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record approval: %w", err)
+	}
+
+	approvals, err := listTicketApprovals(ctx, tx, orgID, decision.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	plan := before.EffectiveApprovalPlan()
+	next := plan.Outcome(approvals).TicketStatus()
+	if next == before.Status {
+		return before, tx.Commit()
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE change_tickets
+		SET status = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND organization_id = $3
+	`, next, decision.TicketID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ticket status: %w", err)
+	}
+	after, err := getTicket(ctx, tx, orgID, decision.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	reason := "Change management board decision: " + string(decision.Decision)
+	if err := insertTicketRevision(ctx, tx, before, after, userID, &reason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.tickets.evict(ctx, orgID, decision.TicketID)
+	return after, nil
+}
+
+// CreateMeeting records a board meeting and the results of its decisions
+func (s *CABStore) CreateMeeting(ctx context.Context, meeting *models.CABMeeting) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO cab_meetings (organization_id, meeting_date, recorded_by, attendees, notes, decisions)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, meeting.OrganizationID, meeting.MeetingDate, meeting.RecordedBy, pq.Array(meeting.Attendees),
+		meeting.Notes, meeting.DecisionsJSON(),
+	).Scan(&meeting.ID, &meeting.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record board meeting: %w", err)
+	}
+	return nil
+}
+
+// ListMeetings retrieves an organization's recorded meetings, most recent first
+func (s *CABStore) ListMeetings(ctx context.Context, orgID uuid.UUID, limit int) ([]models.CABMeeting, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM cab_meetings
+		WHERE organization_id = $1
+		ORDER BY meeting_date DESC, created_at DESC
+		LIMIT $2
+	`, cabMeetingColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list board meetings: %w", err)
+	}
+	defer rows.Close()
+
+	var meetings []models.CABMeeting
+	for rows.Next() {
+		m, err := scanCABMeeting(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan board meeting: %w", err)
+		}
+		meetings = append(meetings, *m)
+	}
+
+	return meetings, rows.Err()
+}
+
+// GetMeeting retrieves a recorded meeting
+func (s *CABStore) GetMeeting(ctx context.Context, orgID, meetingID uuid.UUID) (*models.CABMeeting, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM cab_meetings
+		WHERE id = $1 AND organization_id = $2
+	`, cabMeetingColumns)
+
+	m, err := scanCABMeeting(s.db.QueryRowContext(ctx, query, meetingID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("board meeting not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board meeting: %w", err)
+	}
+	return m, nil
+}
+
+// listTicketApprovals loads a ticket's approvals inside the caller's transaction
+func listTicketApprovals(ctx context.Context, tx *Tx, orgID, ticketID uuid.UUID) ([]models.Approval, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM approvals
+		WHERE organization_id = $1 AND ticket_id = $2
+	`, approvalColumns)
+
+	rows, err := tx.QueryContext(ctx, query, orgID, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []models.Approval
+	for rows.Next() {
+		a, err := scanApproval(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, *a)
+	}
+	return approvals, rows.Err()
+}
+
+func scanCABMeeting(row rowScanner) (*models.CABMeeting, error) {
+	m := &models.CABMeeting{}
+	var decisions []byte
+	err := row.Scan(
+		&m.ID, &m.OrganizationID, &m.MeetingDate, &m.RecordedBy, pq.Array(&m.Attendees),
+		&m.Notes, &decisions, &m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(decisions, &m.Decisions); err != nil {
+		return nil, fmt.Errorf("failed to decode meeting decisions: %w", err)
+	}
+	return m, nil
+}
+
+// nullableString stores empty strings as NULL
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// CalendarStore handles calendar feed database operations
+type CalendarStore struct {
+	db conn
+}
+
+const calendarFeedColumns = `
+	id, organization_id, user_id, name, created_by, last_accessed_at, created_at
+`
+
+// List retrieves the active feeds a user created, newest first
+func (s *CalendarStore) List(ctx context.Context, orgID, userID uuid.UUID) ([]models.CalendarFeed, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM calendar_feeds
+		WHERE organization_id = $1 AND created_by = $2 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, calendarFeedColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []models.CalendarFeed
+	for rows.Next() {
+		f, err := scanCalendarFeed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan calendar feed: %w", err)
+		}
+		feeds = append(feeds, *f)
+	}
+
+	return feeds, rows.Err()
+}
+
+// Create adds a feed and returns it with its token. User feeds belong to
+// their creator.
+func (s *CalendarStore) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateCalendarFeedInput) (*models.CalendarFeed, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	var owner *uuid.UUID
+	if input.Scope == models.CalendarFeedScopeUser {
+		owner = &userID
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO calendar_feeds (organization_id, user_id, name, token_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING %s
+	`, calendarFeedColumns)
+
+	f, err := scanCalendarFeed(s.db.QueryRowContext(ctx, query,
+		orgID, owner, input.Name, hashVerificationToken(token), userID,
+	))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+
+	return f, token, nil
+}
+
+// Revoke disables a feed. With createdBy set, only that user's feeds match.
+func (s *CalendarStore) Revoke(ctx context.Context, orgID, feedID uuid.UUID, createdBy *uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE calendar_feeds
+		SET revoked_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND revoked_at IS NULL
+		  AND ($3::uuid IS NULL OR created_by = $3)
+	`, feedID, orgID, createdBy)
+	if err != nil {
+		return fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.NotFound("calendar feed not found")
+	}
+	return nil
+}
+
+// Redeem looks up the feed for a token and records the access. Feeds stop
+// working when revoked or when their creator is deactivated.
+func (s *CalendarStore) Redeem(ctx context.Context, token string) (*models.CalendarFeed, error) {
+	f := &models.CalendarFeed{}
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE calendar_feeds f
+		SET last_accessed_at = NOW()
+		FROM users u
+		WHERE f.token_hash = $1 AND f.revoked_at IS NULL
+		  AND u.id = f.created_by AND u.is_active AND u.deleted_at IS NULL
+		RETURNING f.id, f.organization_id, f.user_id, f.name, f.created_by,
+			f.last_accessed_at, f.created_at, u.roles
+	`, hashVerificationToken(token)).Scan(
+		&f.ID, &f.OrganizationID, &f.UserID, &f.Name, &f.CreatedBy,
+		&f.LastAccessedAt, &f.CreatedAt, pq.Array(&f.OwnerRoles),
+	)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("calendar feed not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	return f, nil
+}
+
+func scanCalendarFeed(row rowScanner) (*models.CalendarFeed, error) {
+	f := &models.CalendarFeed{}
+	err := row.Scan(
+		&f.ID, &f.OrganizationID, &f.UserID, &f.Name, &f.CreatedBy,
+		&f.LastAccessedAt, &f.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// CommentStore handles ticket comment database operations
+type CommentStore struct {
+	db conn
+}
+
+// Create adds a comment to a ticket. A reply's parent must be a comment on
+// the same ticket that hasn't been deleted.
+func (s *CommentStore) Create(ctx context.Context, orgID, ticketID, authorID uuid.UUID, input *models.CreateCommentInput) (*models.Comment, error) {
+	if input.ParentID != nil {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM ticket_comments
+				WHERE id = $1 AND ticket_id = $2 AND organization_id = $3 AND deleted_at IS NULL
+			)
+		`, *input.ParentID, ticketID, orgID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check parent comment: %w", err)
+		}
+		if !exists {
+			return nil, &models.ValidationError{Field: "parent_comment_id", Message: "parent comment not found"}
+		}
+	}
+
+	query := `
+		INSERT INTO ticket_comments (
+			ticket_id, organization_id, parent_comment_id, author_id, comment, is_internal,
+			mentioned_users, attachment_urls
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	comment := &models.Comment{
+		TicketID:       ticketID,
+		OrganizationID: orgID,
+		ParentID:       input.ParentID,
+		AuthorID:       authorID,
+		Comment:        input.Comment,
+		IsInternal:     input.IsInternal,
+		MentionedUsers: input.MentionedUsers,
+		AttachmentURLs: input.AttachmentURLs,
+	}
+
+	err := s.db.QueryRowContext(ctx, query,
+		ticketID, orgID, input.ParentID, authorID, input.Comment, input.IsInternal,
+		pq.Array(input.MentionedUsers), pq.Array(input.AttachmentURLs),
+	).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+const commentColumns = `
+	id, ticket_id, organization_id, parent_comment_id, author_id, comment, COALESCE(is_internal, false),
+	mentioned_users, attachment_urls, created_at, updated_at, deleted_at, COALESCE(edited, false),
+	COALESCE(edit_history, '[]'), COALESCE(anonymized, false), anonymized_at
+`
+
+// Get retrieves a comment that hasn't been deleted
+func (s *CommentStore) Get(ctx context.Context, orgID, commentID uuid.UUID) (*models.Comment, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ticket_comments
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
+	`, commentColumns)
+
+	c, err := scanComment(s.db.QueryRowContext(ctx, query, commentID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("comment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	return c, nil
+}
+
+// ListByTicket retrieves a ticket's comments, oldest first, with their
+// authors. A deleted comment is kept, with its text replaced by
+// models.DeletedCommentText, while it has replies that aren't deleted, so
+// models.ThreadComments can keep them in their thread.
+func (s *CommentStore) ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Comment, error) {
+	query := fmt.Sprintf(`
+		SELECT c.*, u.email, u.full_name
+		FROM (
+			SELECT %s
+			FROM ticket_comments tc
+			WHERE organization_id = $1 AND ticket_id = $2
+			  AND (deleted_at IS NULL OR EXISTS (
+				SELECT 1 FROM ticket_comments r
+				WHERE r.parent_comment_id = tc.id AND r.deleted_at IS NULL
+			  ))
+		) c
+		LEFT JOIN users u ON u.id = c.author_id
+		ORDER BY c.created_at, c.id
+	`, commentColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var email, fullName sql.NullString
+		c, err := scanComment(rows, &email, &fullName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		if c.DeletedAt != nil {
+			c.Comment = models.DeletedCommentText
+			c.MentionedUsers = nil
+			c.AttachmentURLs = nil
+			c.EditHistory = nil
+		} else if email.Valid {
+			c.Author = &models.UserSummary{ID: c.AuthorID, Email: email.String, FullName: fullName.String}
+		}
+		comments = append(comments, *c)
+	}
+
+	return comments, rows.Err()
+}
+
+// LatestByTickets retrieves up to limit of the most recent comments on each
+// of several tickets, oldest first within each ticket, for list views.
+// Deleted comments are left out.
+func (s *CommentStore) LatestByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID, limit int) ([]models.Comment, error) {
+	// One index range scan per ticket rather than ranking every comment
+	query := fmt.Sprintf(`
+		SELECT latest.*
+		FROM unnest($2::uuid[]) AS t(id)
+		CROSS JOIN LATERAL (
+			SELECT %s
+			FROM ticket_comments
+			WHERE ticket_id = t.id AND organization_id = $1 AND deleted_at IS NULL
+			ORDER BY created_at DESC
+			LIMIT $3
+		) latest
+		ORDER BY latest.ticket_id, latest.created_at
+	`, commentColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, pq.Array(ticketIDs), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list latest comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, *c)
+	}
+
+	return comments, rows.Err()
+}
+
+// Update replaces a comment's text, keeping the text it replaces in the
+// comment's edit history
+func (s *CommentStore) Update(ctx context.Context, orgID, commentID uuid.UUID, text string) (*models.Comment, error) {
+	query := fmt.Sprintf(`
+		UPDATE ticket_comments
+		SET edit_history = COALESCE(edit_history, '[]') || jsonb_build_array(jsonb_build_object(
+		        'previous_comment', comment,
+		        'edited_at', NOW()
+		    )),
+		    comment = $1,
+		    edited = true,
+		    updated_at = NOW()
+		WHERE id = $2 AND organization_id = $3 AND deleted_at IS NULL
+		RETURNING %s
+	`, commentColumns)
+
+	c, err := scanComment(s.db.QueryRowContext(ctx, query, text, commentID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("comment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	return c, nil
+}
+
+// Delete soft-deletes a comment. Its replies stay in the thread.
+func (s *CommentStore) Delete(ctx context.Context, orgID, commentID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE ticket_comments
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
+	`, commentID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("comment not found")
+	}
+	return nil
+}
+
+// ListByTickets retrieves the comments on several tickets at once, oldest
+// first within each ticket. Deleted comments are left out.
+func (s *CommentStore) ListByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Comment, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ticket_comments
+		WHERE organization_id = $1 AND ticket_id = ANY($2) AND deleted_at IS NULL
+		ORDER BY ticket_id, created_at
+	`, commentColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, pq.Array(ticketIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, *c)
+	}
+
+	return comments, rows.Err()
+}
+
+// scanComment scans commentColumns, followed by any extra columns into
+// extra
+func scanComment(row rowScanner, extra ...interface{}) (*models.Comment, error) {
+	c := &models.Comment{}
+	var mentioned []string
+	var history []byte
+	dest := []interface{}{
+		&c.ID, &c.TicketID, &c.OrganizationID, &c.ParentID, &c.AuthorID, &c.Comment, &c.IsInternal,
+		pq.Array(&mentioned), pq.Array(&c.AttachmentURLs), &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt,
+		&c.Edited, &history, &c.Anonymized, &c.AnonymizedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range mentioned {
+		if uid, err := uuid.Parse(id); err == nil {
+			c.MentionedUsers = append(c.MentionedUsers, uid)
+		}
+	}
+	c.EditHistory = history
+	return c, nil
+}
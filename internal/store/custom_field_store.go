@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// CustomFieldStore handles custom field definition database operations
+type CustomFieldStore struct {
+	db conn
+}
+
+const customFieldColumns = `
+	id, organization_id, name, label, description, field_type, required,
+	allowed_values, change_types, created_by, created_at, updated_at
+`
+
+// Create defines a new custom field
+func (s *CustomFieldStore) Create(ctx context.Context, orgID, createdBy uuid.UUID, input *models.CreateCustomFieldInput) (*models.CustomField, error) {
+	allowed := input.AllowedValues
+	if allowed == nil {
+		allowed = []string{}
+	}
+	changeTypes := input.ChangeTypes
+	if changeTypes == nil {
+		changeTypes = []string{}
+	}
+
+	field, err := scanCustomField(s.db.QueryRowContext(ctx, `
+		INSERT INTO custom_field_definitions (
+			organization_id, name, label, description, field_type, required,
+			allowed_values, change_types, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+customFieldColumns,
+		orgID, input.Name, input.Label, input.Description, input.FieldType, input.Required,
+		pq.Array(allowed), pq.Array(changeTypes), createdBy,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("custom field already exists")
+		}
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+	return field, nil
+}
+
+// GetByID retrieves a custom field definition by ID
+func (s *CustomFieldStore) GetByID(ctx context.Context, orgID, fieldID uuid.UUID) (*models.CustomField, error) {
+	field, err := scanCustomField(s.db.QueryRowContext(ctx,
+		"SELECT "+customFieldColumns+" FROM custom_field_definitions WHERE id = $1 AND organization_id = $2",
+		fieldID, orgID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("custom field not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom field: %w", err)
+	}
+	return field, nil
+}
+
+// List retrieves an organization's custom field definitions by name
+func (s *CustomFieldStore) List(ctx context.Context, orgID uuid.UUID) ([]models.CustomField, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+customFieldColumns+" FROM custom_field_definitions WHERE organization_id = $1 ORDER BY name",
+		orgID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	defer rows.Close()
+
+	var fields []models.CustomField
+	for rows.Next() {
+		field, err := scanCustomField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom field: %w", err)
+		}
+		fields = append(fields, *field)
+	}
+
+	return fields, rows.Err()
+}
+
+// Update updates a custom field definition. Values already on tickets are
+// not rewritten; they are checked against the new definition the next time
+// the ticket's custom fields change.
+func (s *CustomFieldStore) Update(ctx context.Context, orgID, fieldID uuid.UUID, input *models.UpdateCustomFieldInput) (*models.CustomField, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Label != nil {
+		updates = append(updates, fmt.Sprintf("label = $%d", argNum))
+		args = append(args, *input.Label)
+		argNum++
+	}
+
+	if input.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+
+	if input.Required != nil {
+		updates = append(updates, fmt.Sprintf("required = $%d", argNum))
+		args = append(args, *input.Required)
+		argNum++
+	}
+
+	if input.AllowedValues != nil {
+		updates = append(updates, fmt.Sprintf("allowed_values = $%d", argNum))
+		args = append(args, pq.Array(*input.AllowedValues))
+		argNum++
+	}
+
+	if input.ChangeTypes != nil {
+		updates = append(updates, fmt.Sprintf("change_types = $%d", argNum))
+		args = append(args, pq.Array(*input.ChangeTypes))
+		argNum++
+	}
+
+	if len(updates) == 0 {
+		return s.GetByID(ctx, orgID, fieldID)
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE custom_field_definitions SET %s WHERE id = $%d AND organization_id = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1,
+	)
+	args = append(args, fieldID, orgID)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, models.NotFound("custom field not found")
+	}
+
+	return s.GetByID(ctx, orgID, fieldID)
+}
+
+// Delete removes a custom field definition. Tickets keep their values.
+func (s *CustomFieldStore) Delete(ctx context.Context, orgID, fieldID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM custom_field_definitions WHERE id = $1 AND organization_id = $2",
+		fieldID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("custom field not found")
+	}
+	return nil
+}
+
+func scanCustomField(row rowScanner) (*models.CustomField, error) {
+	f := &models.CustomField{}
+	err := row.Scan(
+		&f.ID, &f.OrganizationID, &f.Name, &f.Label, &f.Description, &f.FieldType, &f.Required,
+		pq.Array(&f.AllowedValues), pq.Array(&f.ChangeTypes), &f.CreatedBy, &f.CreatedAt, &f.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
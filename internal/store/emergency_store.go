@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// EmergencyStore handles the expedited emergency change workflow
+type EmergencyStore struct {
+	db      conn
+	tickets ticketEvicter
+}
+
+// NotifyOnCallApprovers queues a notification for every on-call approver in the
+// ticket's organization. When includeAdmins is set, org admins are notified too.
+// Returns the number of notifications queued.
+func (s *EmergencyStore) NotifyOnCallApprovers(ctx context.Context, ticket *models.Ticket, msg models.NotificationMessage, includeAdmins bool) (int, error) {
+	query := `
+		INSERT INTO notification_queue (
+			organization_id, user_id, email, notification_type, subject,
+			body_html, body_text, ticket_id, priority
+		)
+		SELECT organization_id, id, email, $2, $3, $4, $5, $6, $7
+		FROM users
+		WHERE organization_id = $1
+		  AND is_active = true
+		  AND deleted_at IS NULL
+		  AND ((is_approver = true AND is_on_call = true) OR ($8 AND 'admin' = ANY(roles)))
+	`
+
+	result, err := s.db.ExecContext(ctx, query,
+		ticket.OrganizationID, msg.NotificationType, msg.Subject, msg.BodyHTML,
+		msg.BodyText, ticket.ID, msg.Priority, includeAdmins,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue on-call notifications: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
+
+// ListOverdue retrieves emergency tickets across all organizations that are
+// past their approval deadline and due for another escalation
+func (s *EmergencyStore) ListOverdue(ctx context.Context, now time.Time) ([]models.Ticket, error) {
+	query := `
+		SELECT id, organization_id, ticket_number, title, status, priority, risk_level,
+		       approval_deadline, escalation_level, last_escalated_at
+		FROM change_tickets
+		WHERE is_emergency = true
+		  AND status IN ('submitted', 'in_review', 'partially_approved')
+		  AND deleted_at IS NULL
+		  AND approval_deadline < $1
+		  AND escalation_level < $2
+		  AND (last_escalated_at IS NULL OR last_escalated_at < $3)
+		ORDER BY approval_deadline ASC
+		LIMIT 100
+	`
+
+	rows, err := s.db.QueryContext(ctx, query,
+		now, models.MaxEmergencyEscalationLevel, now.Add(-models.EmergencyEscalationInterval),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue emergency tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []models.Ticket
+	for rows.Next() {
+		var t models.Ticket
+		err := rows.Scan(
+			&t.ID, &t.OrganizationID, &t.TicketNumber, &t.Title, &t.Status,
+			&t.Priority, &t.RiskLevel, &t.ApprovalDeadline, &t.EscalationLevel,
+			&t.LastEscalatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		t.IsEmergency = true
+		tickets = append(tickets, t)
+	}
+
+	return tickets, rows.Err()
+}
+
+// Escalate bumps the escalation level of an emergency ticket and returns the
+// new level. The update is conditional on the current level so concurrent
+// workers cannot escalate the same ticket twice.
+func (s *EmergencyStore) Escalate(ctx context.Context, ticketID uuid.UUID, currentLevel int) (int, error) {
+	query := `
+		UPDATE change_tickets
+		SET escalation_level = escalation_level + 1,
+		    last_escalated_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1 AND escalation_level = $2
+		RETURNING escalation_level, organization_id
+	`
+
+	var level int
+	var orgID uuid.UUID
+	err := s.db.QueryRowContext(ctx, query, ticketID, currentLevel).Scan(&level, &orgID)
+	if err == sql.ErrNoRows {
+		return 0, models.Conflict("ticket already escalated")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to escalate ticket: %w", err)
+	}
+
+	s.tickets.evict(ctx, orgID, ticketID)
+	return level, nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// EpicStore handles epic database operations
+type EpicStore struct {
+	db conn
+}
+
+const epicColumns = `
+	id, organization_id, project_id, name, description, status, target_date,
+	created_by, created_at, updated_at
+`
+
+// Create creates a new epic
+func (s *EpicStore) Create(ctx context.Context, orgID, createdBy uuid.UUID, input *models.CreateEpicInput) (*models.Epic, error) {
+	epic, err := scanEpic(s.db.QueryRowContext(ctx, `
+		INSERT INTO epics (organization_id, project_id, name, description, target_date, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+epicColumns,
+		orgID, input.ProjectID, input.Name, input.Description, input.Target, createdBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create epic: %w", err)
+	}
+	return epic, nil
+}
+
+// GetByID retrieves an epic by ID
+func (s *EpicStore) GetByID(ctx context.Context, orgID, epicID uuid.UUID) (*models.Epic, error) {
+	epic, err := scanEpic(s.db.QueryRowContext(ctx,
+		"SELECT "+epicColumns+" FROM epics WHERE id = $1 AND organization_id = $2",
+		epicID, orgID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("epic not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get epic: %w", err)
+	}
+	return epic, nil
+}
+
+// List retrieves an organization's epics, newest first
+func (s *EpicStore) List(ctx context.Context, orgID uuid.UUID, filter *models.EpicListFilter) ([]models.Epic, error) {
+	conditions := []string{"organization_id = $1"}
+	args := []interface{}{orgID}
+	argNum := 2
+
+	if filter.ProjectID != nil {
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", argNum))
+		args = append(args, *filter.ProjectID)
+		argNum++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
+		args = append(args, *filter.Status)
+		argNum++
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+epicColumns+" FROM epics WHERE "+strings.Join(conditions, " AND ")+" ORDER BY created_at DESC",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list epics: %w", err)
+	}
+	defer rows.Close()
+
+	var epics []models.Epic
+	for rows.Next() {
+		epic, err := scanEpic(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan epic: %w", err)
+		}
+		epics = append(epics, *epic)
+	}
+
+	return epics, rows.Err()
+}
+
+// Update updates an epic
+func (s *EpicStore) Update(ctx context.Context, orgID, epicID uuid.UUID, input *models.UpdateEpicInput) (*models.Epic, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *input.Name)
+		argNum++
+	}
+
+	if input.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+
+	if input.Status != nil {
+		updates = append(updates, fmt.Sprintf("status = $%d", argNum))
+		args = append(args, *input.Status)
+		argNum++
+	}
+
+	if input.TargetDate != nil {
+		updates = append(updates, fmt.Sprintf("target_date = $%d", argNum))
+		args = append(args, input.Target)
+		argNum++
+	}
+
+	if len(updates) == 0 {
+		return s.GetByID(ctx, orgID, epicID)
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE epics SET %s WHERE id = $%d AND organization_id = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1,
+	)
+	args = append(args, epicID, orgID)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update epic: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, models.NotFound("epic not found")
+	}
+
+	return s.GetByID(ctx, orgID, epicID)
+}
+
+// Delete removes an epic. Its tickets are kept and lose the link.
+func (s *EpicStore) Delete(ctx context.Context, orgID, epicID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM epics WHERE id = $1 AND organization_id = $2",
+		epicID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete epic: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("epic not found")
+	}
+	return nil
+}
+
+// Rollup summarizes the epic's tickets
+func (s *EpicStore) Rollup(ctx context.Context, orgID, epicID uuid.UUID) (*models.TicketRollup, error) {
+	return ticketRollup(ctx, s.db, orgID, "epic_id", epicID)
+}
+
+// ticketRollup counts the tickets linked through column, which must be a
+// trusted column name
+func ticketRollup(ctx context.Context, db conn, orgID uuid.UUID, column string, id uuid.UUID) (*models.TicketRollup, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT status, story_points
+		FROM change_tickets
+		WHERE organization_id = $1 AND `+column+` = $2 AND deleted_at IS NULL
+	`, orgID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll up tickets: %w", err)
+	}
+	defer rows.Close()
+
+	rollup := &models.TicketRollup{ByStatus: map[models.TicketStatus]models.StatusRollup{}}
+	for rows.Next() {
+		var status models.TicketStatus
+		var points *int
+		if err := rows.Scan(&status, &points); err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		rollup.Add(status, points)
+	}
+
+	return rollup, rows.Err()
+}
+
+func scanEpic(row rowScanner) (*models.Epic, error) {
+	e := &models.Epic{}
+	err := row.Scan(
+		&e.ID, &e.OrganizationID, &e.ProjectID, &e.Name, &e.Description, &e.Status, &e.TargetDate,
+		&e.CreatedBy, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
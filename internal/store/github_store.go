@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// GitHubStore handles GitHub integration database operations
+type GitHubStore struct {
+	db conn
+}
+
+const githubIntegrationColumns = `
+	organization_id, webhook_secret, actor_user_id, is_active, updated_by, created_at, updated_at
+`
+
+// GetIntegration retrieves an organization's GitHub integration
+func (s *GitHubStore) GetIntegration(ctx context.Context, orgID uuid.UUID) (*models.GitHubIntegration, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM github_integrations
+		WHERE organization_id = $1
+	`, githubIntegrationColumns)
+
+	integration, err := scanGitHubIntegration(s.db.QueryRowContext(ctx, query, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("github integration not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get github integration: %w", err)
+	}
+
+	return integration, nil
+}
+
+// UpdateIntegration creates or updates an organization's GitHub integration.
+// Creating one requires both a webhook secret and an actor user.
+func (s *GitHubStore) UpdateIntegration(ctx context.Context, orgID, userID uuid.UUID, input *models.UpdateGitHubIntegrationInput) (*models.GitHubIntegration, error) {
+	current, err := s.GetIntegration(ctx, orgID)
+	if err != nil && err.Error() != "github integration not found" {
+		return nil, err
+	}
+	if current == nil {
+		if input.WebhookSecret == nil || input.ActorUserID == nil {
+			return nil, &models.ValidationError{Field: "webhook_secret", Message: "webhook_secret and actor_user_id are required to enable the integration"}
+		}
+		current = &models.GitHubIntegration{OrganizationID: orgID, IsActive: true}
+	}
+	if input.WebhookSecret != nil {
+		current.WebhookSecret = *input.WebhookSecret
+	}
+	if input.ActorUserID != nil {
+		current.ActorUserID = *input.ActorUserID
+	}
+	if input.IsActive != nil {
+		current.IsActive = *input.IsActive
+	}
+
+	var actorInOrg bool
+	err = s.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL)",
+		current.ActorUserID, orgID,
+	).Scan(&actorInOrg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check actor user: %w", err)
+	}
+	if !actorInOrg {
+		return nil, &models.ValidationError{Field: "actor_user_id", Message: "actor_user_id must be a user in this organization"}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO github_integrations (organization_id, webhook_secret, actor_user_id, is_active, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET webhook_secret = EXCLUDED.webhook_secret,
+		    actor_user_id = EXCLUDED.actor_user_id,
+		    is_active = EXCLUDED.is_active,
+		    updated_by = EXCLUDED.updated_by
+		RETURNING %s
+	`, githubIntegrationColumns)
+
+	integration, err := scanGitHubIntegration(s.db.QueryRowContext(ctx, query,
+		orgID, current.WebhookSecret, current.ActorUserID, current.IsActive, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update github integration: %w", err)
+	}
+
+	return integration, nil
+}
+
+// MatchRepository finds the organizations with an active GitHub integration
+// that track a repository registered under any of the given URLs
+func (s *GitHubStore) MatchRepository(ctx context.Context, urls []string) ([]models.GitHubRepositoryMatch, error) {
+	query := `
+		SELECT DISTINCT ON (g.organization_id)
+		       g.organization_id, g.webhook_secret, g.actor_user_id, g.is_active, g.updated_by,
+		       g.created_at, g.updated_at,
+		       r.id, COALESCE(o.ticket_number_prefix, 'CHG')
+		FROM repositories r
+		JOIN github_integrations g ON g.organization_id = r.organization_id
+		JOIN organizations o ON o.id = r.organization_id
+		WHERE r.url = ANY($1) AND COALESCE(r.is_active, true)
+		  AND g.is_active AND o.deleted_at IS NULL
+		ORDER BY g.organization_id, r.created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(urls))
+	if err != nil {
+		return nil, fmt.Errorf("failed to match repository: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []models.GitHubRepositoryMatch
+	for rows.Next() {
+		var m models.GitHubRepositoryMatch
+		g := &m.Integration
+		err := rows.Scan(
+			&g.OrganizationID, &g.WebhookSecret, &g.ActorUserID, &g.IsActive, &g.UpdatedBy,
+			&g.CreatedAt, &g.UpdatedAt,
+			&m.RepositoryID, &m.TicketNumberPrefix,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan repository match: %w", err)
+		}
+		matches = append(matches, m)
+	}
+
+	return matches, rows.Err()
+}
+
+// RecordDelivery records a webhook delivery for an organization, returning
+// false if it was already processed
+func (s *GitHubStore) RecordDelivery(ctx context.Context, orgID uuid.UUID, deliveryID, event string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO github_webhook_deliveries (organization_id, delivery_id, event)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, delivery_id) DO NOTHING
+	`, orgID, deliveryID, event)
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// ReleaseDelivery drops a delivery record whose processing failed so a
+// redelivery is handled again
+func (s *GitHubStore) ReleaseDelivery(ctx context.Context, orgID uuid.UUID, deliveryID string) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM github_webhook_deliveries WHERE organization_id = $1 AND delivery_id = $2",
+		orgID, deliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeliveries deletes delivery records received before the cutoff and
+// returns how many were removed
+func (s *GitHubStore) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM github_webhook_deliveries WHERE received_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanGitHubIntegration(row rowScanner) (*models.GitHubIntegration, error) {
+	g := &models.GitHubIntegration{}
+	err := row.Scan(
+		&g.OrganizationID, &g.WebhookSecret, &g.ActorUserID, &g.IsActive, &g.UpdatedBy,
+		&g.CreatedAt, &g.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
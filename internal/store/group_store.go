@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// GroupStore handles group database operations
+type GroupStore struct {
+	db conn
+}
+
+// Find looks up one of an organization's groups by ID or by name, ignoring
+// case
+func (s *GroupStore) Find(ctx context.Context, orgID uuid.UUID, ref string) (*models.GroupSummary, error) {
+	var g models.GroupSummary
+	query := "SELECT id, name, group_type, is_active FROM groups WHERE organization_id = $1 AND "
+	var arg interface{}
+	if id, err := uuid.Parse(ref); err == nil {
+		query += "id = $2"
+		arg = id
+	} else {
+		query += "LOWER(name) = $2"
+		arg = strings.ToLower(strings.TrimSpace(ref))
+	}
+
+	err := s.db.QueryRowContext(ctx, query, orgID, arg).Scan(&g.ID, &g.Name, &g.GroupType, &g.IsActive)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("group not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return &g, nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// IdempotencyStore handles idempotency key database operations
+type IdempotencyStore struct {
+	db conn
+}
+
+const idempotencyKeyColumns = `
+	id, organization_id, user_id, idempotency_key, method, path, request_hash,
+	status_code, content_type, response_body, created_at, completed_at, expires_at
+`
+
+// Begin claims an idempotency key for a request. It returns the new record
+// with created=true, or the existing unexpired record with created=false.
+// Expired keys are reclaimed in place.
+func (s *IdempotencyStore) Begin(ctx context.Context, orgID, userID uuid.UUID, key, method, path, requestHash string) (*models.IdempotencyKey, bool, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO idempotency_keys (
+			organization_id, user_id, idempotency_key, method, path, request_hash, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (organization_id, user_id, idempotency_key) DO UPDATE
+		SET method = EXCLUDED.method,
+		    path = EXCLUDED.path,
+		    request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    content_type = NULL,
+		    response_body = NULL,
+		    created_at = NOW(),
+		    completed_at = NULL,
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING %s
+	`, idempotencyKeyColumns)
+
+	record, err := scanIdempotencyKey(s.db.QueryRowContext(ctx, query,
+		orgID, userID, key, method, path, requestHash, time.Now().Add(models.IdempotencyKeyTTL),
+	))
+	if err == nil {
+		return record, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	// Conflict with a live key: hand back the existing record
+	query = fmt.Sprintf(`
+		SELECT %s FROM idempotency_keys
+		WHERE organization_id = $1 AND user_id = $2 AND idempotency_key = $3
+	`, idempotencyKeyColumns)
+
+	record, err = scanIdempotencyKey(s.db.QueryRowContext(ctx, query, orgID, userID, key))
+	if err == sql.ErrNoRows {
+		return nil, false, models.NotFound("idempotency key not found")
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return record, false, nil
+}
+
+// Complete stores the response for a claimed key so retries can replay it
+func (s *IdempotencyStore) Complete(ctx context.Context, id uuid.UUID, statusCode int, contentType string, body []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $2, content_type = $3, response_body = $4, completed_at = NOW()
+		WHERE id = $1
+	`, id, statusCode, contentType, body)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release drops a claimed key whose request failed so the client can retry it
+func (s *IdempotencyStore) Release(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes keys past their expiry and returns how many were removed
+func (s *IdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanIdempotencyKey(row rowScanner) (*models.IdempotencyKey, error) {
+	k := &models.IdempotencyKey{}
+	err := row.Scan(
+		&k.ID, &k.OrganizationID, &k.UserID, &k.Key, &k.Method, &k.Path, &k.RequestHash,
+		&k.StatusCode, &k.ContentType, &k.ResponseBody, &k.CreatedAt, &k.CompletedAt, &k.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
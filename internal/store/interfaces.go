@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// The interfaces below are what the handlers and workers use of the stores
+// they are built on, so those can be swapped for the in-memory stores in
+// package memstore or the mocks in package storemock. Regenerate the mocks
+// with go generate ./internal/store/storemock after changing one.
+
+// TicketStorer is the ticket store
+type TicketStorer interface {
+	Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateTicketInput) (*models.Ticket, error)
+	GetNumberByExternalReference(ctx context.Context, orgID uuid.UUID, reference string) (string, error)
+	Import(ctx context.Context, orgID, userID uuid.UUID, input *models.ImportTicketInput) (*models.Ticket, error)
+	GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error)
+	GetByIDs(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Ticket, error)
+	ListScheduled(ctx context.Context, orgID uuid.UUID, involving *uuid.UUID, from, to time.Time, limit int) ([]models.Ticket, error)
+	GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNumber string) (*models.Ticket, error)
+	List(ctx context.Context, orgID uuid.UUID, filter *models.TicketListFilter) ([]models.Ticket, int, error)
+	Search(ctx context.Context, orgID uuid.UUID, query string, limit int) ([]models.TicketSearchResult, error)
+	Update(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error)
+	UpdateStatus(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus, expectedVersion int) (*models.TicketTransition, error)
+	Submit(ctx context.Context, orgID, ticketID, userID uuid.UUID, plan *models.ApprovalPlan, expectedVersion int) (*models.TicketTransition, error)
+	Close(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error)
+	Cancel(ctx context.Context, orgID, ticketID uuid.UUID, reason string, expectedVersion int) (*models.TicketTransition, error)
+	Reopen(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error)
+	Delete(ctx context.Context, orgID, ticketID, userID uuid.UUID, reason *string, expectedVersion int) error
+	ListTrash(ctx context.Context, orgID uuid.UUID, page, perPage int) ([]models.TrashedTicket, int, error)
+	Restore(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error)
+	PurgeTrash(ctx context.Context, orgID uuid.UUID, before time.Time) ([]uuid.UUID, error)
+	GetQueue(ctx context.Context, orgID uuid.UUID) ([]models.Ticket, error)
+	Assign(ctx context.Context, orgID, ticketID, userID uuid.UUID) error
+	AddWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error
+	RemoveWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error
+	LinkRepository(ctx context.Context, ticketID, repoID, linkedBy uuid.UUID, input *models.LinkRepositoryInput) error
+	UnlinkRepository(ctx context.Context, ticketID, repoID uuid.UUID) error
+	SetAffectedResources(ctx context.Context, orgID, ticketID uuid.UUID, resources []models.AffectedResource) error
+	ListAffectedResources(ctx context.Context, ticketID uuid.UUID) ([]models.AffectedResource, error)
+}
+
+// RepositoryStorer is the repository store
+type RepositoryStorer interface {
+	Create(ctx context.Context, orgID uuid.UUID, input *models.CreateRepositoryInput) (*models.Repository, error)
+	GetByID(ctx context.Context, orgID, repoID uuid.UUID) (*models.Repository, error)
+	GetByURL(ctx context.Context, orgID uuid.UUID, url string) (*models.Repository, error)
+	List(ctx context.Context, orgID uuid.UUID, filter *models.RepositoryListFilter) ([]models.Repository, int, error)
+	Update(ctx context.Context, orgID, repoID uuid.UUID, input *models.UpdateRepositoryInput) (*models.Repository, error)
+	Delete(ctx context.Context, orgID, repoID uuid.UUID) error
+	ApplySync(ctx context.Context, orgID, repoID uuid.UUID, details *models.RepositoryDetails) (*models.Repository, error)
+	RecordSyncError(ctx context.Context, orgID, repoID uuid.UUID, message string) error
+	ListSyncable(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Repository, error)
+	GetSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) (*models.RepositorySyncToken, error)
+	ListSyncTokens(ctx context.Context, orgID uuid.UUID) ([]models.RepositorySyncToken, error)
+	SetSyncToken(ctx context.Context, orgID, userID uuid.UUID, provider models.RepositoryProvider, input *models.UpdateRepositorySyncTokenInput) (*models.RepositorySyncToken, error)
+	DeleteSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) error
+	ListSyncOrganizations(ctx context.Context) ([]uuid.UUID, error)
+	GetTicketRepositories(ctx context.Context, ticketID uuid.UUID) ([]models.TicketRepository, error)
+	ListTicketRepositories(ctx context.Context, ticketIDs []uuid.UUID) ([]models.TicketRepository, error)
+}
+
+// AuditStorer is the audit log store
+type AuditStorer interface {
+	LogTicketAccess(ctx context.Context, ticketID, userID uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error
+	LogSystemEvent(ctx context.Context, ticketID uuid.UUID, action string, changes map[string]interface{}) error
+	LogTicketView(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string) error
+	LogTicketEdit(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string, changes map[string]interface{}) error
+	LogTicketStatusChange(ctx context.Context, ticketID, userID uuid.UUID, oldStatus, newStatus string, ipAddress, userAgent *string) error
+	LogTicketEvents(ctx context.Context, events []models.TicketAuditEvent) error
+	ListStatusChangesForCreator(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time, limit int) ([]models.DigestStatusChange, error)
+	GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID, filter *models.AuditLogFilter) ([]models.TicketAuditLog, int, error)
+	MarkReviewed(ctx context.Context, auditID, reviewerID uuid.UUID) error
+	GetPendingReviews(ctx context.Context, orgID uuid.UUID) ([]models.TicketAuditLog, error)
+	CountForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter) (int, error)
+	StreamForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter, fn func(*models.TicketAuditLog) error) error
+	StreamRange(ctx context.Context, from, to time.Time, fn func(*models.TicketAuditLog) error) error
+	ArchivedDays(ctx context.Context, from, to time.Time) (map[string]bool, error)
+	LastArchivedDay(ctx context.Context) (*time.Time, error)
+	RecordArchive(ctx context.Context, a *models.AuditArchive) error
+	EnsurePartitions(ctx context.Context, through time.Time) (int, error)
+	ListPartitions(ctx context.Context) ([]models.AuditPartition, error)
+	DropPartition(ctx context.Context, month time.Time) error
+	Log(ctx context.Context, orgID uuid.UUID, input *models.CreateAuditLogInput) error
+}
+
+// CommentStorer is the ticket comment store
+type CommentStorer interface {
+	Create(ctx context.Context, orgID, ticketID, authorID uuid.UUID, input *models.CreateCommentInput) (*models.Comment, error)
+	Get(ctx context.Context, orgID, commentID uuid.UUID) (*models.Comment, error)
+	ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Comment, error)
+	LatestByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID, limit int) ([]models.Comment, error)
+	Update(ctx context.Context, orgID, commentID uuid.UUID, text string) (*models.Comment, error)
+	Delete(ctx context.Context, orgID, commentID uuid.UUID) error
+	ListByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Comment, error)
+}
+
+var (
+	_ TicketStorer     = (*TicketStore)(nil)
+	_ RepositoryStorer = (*RepositoryStore)(nil)
+	_ AuditStorer      = (*AuditStore)(nil)
+	_ CommentStorer    = (*CommentStore)(nil)
+)
@@ -0,0 +1,851 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// InventoryStore handles host inventory and blackout operations on the
+// tables shared with hostctl and the blackout tool. Inventory is not
+// scoped to an organization.
+type InventoryStore struct {
+	db conn
+}
+
+// Hosts added by the blackout tool carry only a hostname and status, so the
+// other columns may be null. Owners and mail groups are read through
+// to_jsonb so inventories that declare them as text arrays work too.
+const hostColumns = `
+	id, COALESCE(resource_name, hostname), hostname, COALESCE(type, ''),
+	COALESCE(provider, ''), region, COALESCE(status, 'active'), COALESCE(environment, ''),
+	to_jsonb(owners), to_jsonb(mailgroups), metadata, average_daily_cost, average_monthly_cost,
+	external_id, external_url, created_at, updated_at
+`
+
+func scanHost(row rowScanner) (*models.Host, error) {
+	h := &models.Host{}
+	var owners, mailGroups, metadata []byte
+	err := row.Scan(
+		&h.ID, &h.ResourceName, &h.Hostname, &h.Type,
+		&h.Provider, &h.Region, &h.Status, &h.Environment,
+		&owners, &mailGroups, &metadata, &h.AverageDailyCost, &h.AverageMonthlyCost,
+		&h.ExternalID, &h.ExternalURL, &h.CreatedAt, &h.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(owners, &h.Owners)
+	json.Unmarshal(mailGroups, &h.MailGroups)
+	json.Unmarshal(metadata, &h.Metadata)
+	if h.Owners == nil {
+		h.Owners = []string{}
+	}
+	if h.MailGroups == nil {
+		h.MailGroups = []string{}
+	}
+	return h, nil
+}
+
+// ListHosts lists hosts ordered by hostname, with their active blackouts
+func (s *InventoryStore) ListHosts(ctx context.Context, filter *models.HostListFilter) ([]models.Host, int, error) {
+	filter.SetDefaults()
+
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("COALESCE(status, 'active') = $%d", argNum))
+		args = append(args, *filter.Status)
+		argNum++
+	}
+	if filter.Environment != "" {
+		conditions = append(conditions, fmt.Sprintf("environment = $%d", argNum))
+		args = append(args, filter.Environment)
+		argNum++
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, fmt.Sprintf("type = $%d", argNum))
+		args = append(args, filter.Type)
+		argNum++
+	}
+	if filter.Provider != "" {
+		conditions = append(conditions, fmt.Sprintf("provider = $%d", argNum))
+		args = append(args, filter.Provider)
+		argNum++
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("hostname ILIKE $%d", argNum))
+		args = append(args, "%"+filter.Search+"%")
+		argNum++
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM inventory_resources "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count hosts: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM inventory_resources
+		%s
+		ORDER BY hostname
+		LIMIT $%d OFFSET $%d
+	`, hostColumns, where, argNum, argNum+1)
+	args = append(args, filter.PerPage, filter.Offset())
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	defer rows.Close()
+
+	var hosts []models.Host
+	for rows.Next() {
+		h, err := scanHost(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan host: %w", err)
+		}
+		hosts = append(hosts, *h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	hostnames := make([]string, len(hosts))
+	for i := range hosts {
+		hostnames[i] = hosts[i].Hostname
+	}
+	blackouts, err := s.ActiveBlackouts(ctx, hostnames)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range hosts {
+		hosts[i].ActiveBlackout = blackouts[hosts[i].Hostname]
+	}
+
+	return hosts, total, nil
+}
+
+// GetHost retrieves a host by hostname, with its active blackout
+func (s *InventoryStore) GetHost(ctx context.Context, hostname string) (*models.Host, error) {
+	query := fmt.Sprintf("SELECT %s FROM inventory_resources WHERE hostname = $1", hostColumns)
+	h, err := scanHost(s.db.QueryRowContext(ctx, query, hostname))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("host not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host: %w", err)
+	}
+
+	blackouts, err := s.ActiveBlackouts(ctx, []string{hostname})
+	if err != nil {
+		return nil, err
+	}
+	h.ActiveBlackout = blackouts[hostname]
+
+	return h, nil
+}
+
+// CreateHost adds a host to inventory
+func (s *InventoryStore) CreateHost(ctx context.Context, input *models.CreateHostInput) (*models.Host, error) {
+	owners, _ := json.Marshal(nonNil(input.Owners))
+	mailGroups, _ := json.Marshal(nonNil(input.MailGroups))
+	metadata := input.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	query := fmt.Sprintf(`
+		INSERT INTO inventory_resources (
+			resource_name, hostname, type, provider, region, status, environment,
+			owners, mailgroups, metadata, average_daily_cost, average_monthly_cost,
+			external_id, external_url, created_at, updated_at
+		) VALUES ($1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
+		RETURNING %s
+	`, hostColumns)
+
+	h, err := scanHost(s.db.QueryRowContext(ctx, query,
+		input.Hostname, input.Type, input.Provider, input.Region, input.Status,
+		input.Environment, owners, mailGroups, metadataJSON, input.AverageDailyCost,
+		input.AverageMonthlyCost, input.ExternalID, input.ExternalURL,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("host already exists")
+		}
+		return nil, fmt.Errorf("failed to create host: %w", err)
+	}
+
+	return h, nil
+}
+
+// UpdateHost updates a host, merging metadata keys into the existing
+// metadata
+func (s *InventoryStore) UpdateHost(ctx context.Context, hostname string, input *models.UpdateHostInput) (*models.Host, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	set := func(column string, value interface{}) {
+		updates = append(updates, fmt.Sprintf("%s = $%d", column, argNum))
+		args = append(args, value)
+		argNum++
+	}
+
+	if input.Type != nil {
+		set("type", *input.Type)
+	}
+	if input.Provider != nil {
+		set("provider", *input.Provider)
+	}
+	if input.Region != nil {
+		set("region", *input.Region)
+	}
+	if input.Status != nil {
+		set("status", *input.Status)
+	}
+	if input.Environment != nil {
+		set("environment", *input.Environment)
+	}
+	if input.Owners != nil {
+		owners, _ := json.Marshal(input.Owners)
+		set("owners", owners)
+	}
+	if input.MailGroups != nil {
+		mailGroups, _ := json.Marshal(input.MailGroups)
+		set("mailgroups", mailGroups)
+	}
+	if input.Metadata != nil {
+		metadata, _ := json.Marshal(input.Metadata)
+		updates = append(updates, fmt.Sprintf("metadata = COALESCE(metadata::jsonb, '{}'::jsonb) || $%d::jsonb", argNum))
+		args = append(args, metadata)
+		argNum++
+	}
+	if input.AverageDailyCost != nil {
+		set("average_daily_cost", *input.AverageDailyCost)
+	}
+	if input.AverageMonthlyCost != nil {
+		set("average_monthly_cost", *input.AverageMonthlyCost)
+	}
+	if input.ExternalID != nil {
+		set("external_id", *input.ExternalID)
+	}
+	if input.ExternalURL != nil {
+		set("external_url", *input.ExternalURL)
+	}
+
+	if len(updates) == 0 {
+		return s.GetHost(ctx, hostname)
+	}
+
+	updates = append(updates, "updated_at = NOW()")
+	args = append(args, hostname)
+
+	query := fmt.Sprintf(
+		"UPDATE inventory_resources SET %s WHERE hostname = $%d",
+		strings.Join(updates, ", "), argNum,
+	)
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update host: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, models.NotFound("host not found")
+	}
+
+	return s.GetHost(ctx, hostname)
+}
+
+// DeleteHost removes a host from inventory. Its blackout history is kept.
+func (s *InventoryStore) DeleteHost(ctx context.Context, hostname string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM inventory_resources WHERE hostname = $1", hostname)
+	if err != nil {
+		return fmt.Errorf("failed to delete host: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.NotFound("host not found")
+	}
+	return nil
+}
+
+const blackoutColumns = `
+	id, ticket_number, hostname, start_time, end_time, actual_end_time,
+	COALESCE(reason, ''), COALESCE(created_by, ''), COALESCE(status, 'active'), created_at
+`
+
+func scanBlackout(row rowScanner) (*models.Blackout, error) {
+	b := &models.Blackout{}
+	err := row.Scan(
+		&b.ID, &b.TicketNumber, &b.Hostname, &b.StartTime, &b.EndTime, &b.ActualEndTime,
+		&b.Reason, &b.CreatedBy, &b.Status, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// A blackout is in effect while it is active and before its end time;
+// the worker and the blackout tool's cleanup mark it expired afterwards
+const blackoutInEffect = "status = 'active' AND end_time > NOW()"
+
+// ActiveBlackouts returns the blackout in effect for each of the hosts,
+// keyed by hostname. Hosts without one are left out.
+func (s *InventoryStore) ActiveBlackouts(ctx context.Context, hostnames []string) (map[string]*models.Blackout, error) {
+	blackouts := make(map[string]*models.Blackout)
+	if len(hostnames) == 0 {
+		return blackouts, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT ON (hostname) %s
+		FROM inventory_blackouts
+		WHERE hostname = ANY($1) AND %s
+		ORDER BY hostname, start_time DESC
+	`, blackoutColumns, blackoutInEffect)
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(hostnames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active blackouts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		b, err := scanBlackout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		blackouts[b.Hostname] = b
+	}
+
+	return blackouts, rows.Err()
+}
+
+// ListBlackouts lists a host's blackouts, newest first
+func (s *InventoryStore) ListBlackouts(ctx context.Context, hostname string, activeOnly bool, limit int) ([]models.Blackout, error) {
+	query := fmt.Sprintf("SELECT %s FROM inventory_blackouts WHERE hostname = $1", blackoutColumns)
+	if activeOnly {
+		query += " AND " + blackoutInEffect
+	}
+	query += " ORDER BY start_time DESC LIMIT $2"
+
+	rows, err := s.db.QueryContext(ctx, query, hostname, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blackouts: %w", err)
+	}
+	defer rows.Close()
+
+	var blackouts []models.Blackout
+	for rows.Next() {
+		b, err := scanBlackout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		blackouts = append(blackouts, *b)
+	}
+
+	return blackouts, rows.Err()
+}
+
+// ListBlackoutsByTicket lists the blackouts started for a ticket, newest
+// first
+func (s *InventoryStore) ListBlackoutsByTicket(ctx context.Context, ticketNumber string, activeOnly bool, limit int) ([]models.Blackout, error) {
+	query := fmt.Sprintf("SELECT %s FROM inventory_blackouts WHERE ticket_number = $1", blackoutColumns)
+	if activeOnly {
+		query += " AND " + blackoutInEffect
+	}
+	query += " ORDER BY start_time DESC LIMIT $2"
+
+	rows, err := s.db.QueryContext(ctx, query, ticketNumber, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ticket blackouts: %w", err)
+	}
+	defer rows.Close()
+
+	var blackouts []models.Blackout
+	for rows.Next() {
+		b, err := scanBlackout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		blackouts = append(blackouts, *b)
+	}
+
+	return blackouts, rows.Err()
+}
+
+// StartBlackout starts a blackout for a host now and marks the host as in
+// blackout. Fails with "host already in blackout" if one is in effect.
+func (s *InventoryStore) StartBlackout(ctx context.Context, hostname, ticketNumber string, endTime time.Time, reason, createdBy string) (*models.Blackout, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the host so concurrent starts can't both pass the check
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(status, 'active') FROM inventory_resources WHERE hostname = $1 FOR UPDATE",
+		hostname,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("host not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host: %w", err)
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM inventory_blackouts WHERE hostname = $1 AND "+blackoutInEffect+")",
+		hostname,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active blackouts: %w", err)
+	}
+	if exists {
+		return nil, models.Conflict("host already in blackout")
+	}
+
+	// The columns are UTC timestamps without a time zone
+	query := fmt.Sprintf(`
+		INSERT INTO inventory_blackouts (
+			ticket_number, hostname, start_time, end_time, reason, created_by, status
+		) VALUES ($1, $2, $3, $4, $5, $6, 'active')
+		RETURNING %s
+	`, blackoutColumns)
+	b, err := scanBlackout(tx.QueryRowContext(ctx, query,
+		ticketNumber, hostname, time.Now().UTC(), endTime.UTC(), reason, createdBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blackout: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE inventory_resources SET status = 'blackout', updated_at = NOW() WHERE hostname = $1",
+		hostname,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update host status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit blackout: %w", err)
+	}
+	return b, nil
+}
+
+// EndBlackout ends a blackout early and returns the host to active
+func (s *InventoryStore) EndBlackout(ctx context.Context, blackoutID int) (*models.Blackout, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		UPDATE inventory_blackouts
+		SET status = 'completed', actual_end_time = $1
+		WHERE id = $2 AND %s
+		RETURNING %s
+	`, blackoutInEffect, blackoutColumns)
+	b, err := scanBlackout(tx.QueryRowContext(ctx, query, time.Now().UTC(), blackoutID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("no active blackout")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to end blackout: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE inventory_resources SET status = 'active', updated_at = NOW() WHERE hostname = $1 AND status = 'blackout'",
+		b.Hostname,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update host status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit blackout: %w", err)
+	}
+	return b, nil
+}
+
+// ExtendBlackout moves an active blackout's end time later
+func (s *InventoryStore) ExtendBlackout(ctx context.Context, blackoutID int, by time.Duration) (*models.Blackout, error) {
+	query := fmt.Sprintf(`
+		UPDATE inventory_blackouts
+		SET end_time = end_time + $1 * INTERVAL '1 second'
+		WHERE id = $2 AND %s
+		RETURNING %s
+	`, blackoutInEffect, blackoutColumns)
+	b, err := scanBlackout(s.db.QueryRowContext(ctx, query, int64(by.Seconds()), blackoutID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("no active blackout")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extend blackout: %w", err)
+	}
+	return b, nil
+}
+
+// ListBlackoutsInEffect lists every blackout in effect, soonest to end first
+func (s *InventoryStore) ListBlackoutsInEffect(ctx context.Context) ([]models.Blackout, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM inventory_blackouts WHERE %s ORDER BY end_time ASC",
+		blackoutColumns, blackoutInEffect,
+	)
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blackouts in effect: %w", err)
+	}
+	defer rows.Close()
+
+	var blackouts []models.Blackout
+	for rows.Next() {
+		b, err := scanBlackout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		blackouts = append(blackouts, *b)
+	}
+
+	return blackouts, rows.Err()
+}
+
+// ExpireBlackouts marks blackouts past their end time expired and returns
+// hosts with no other blackout in effect to active, as the blackout tool's
+// cleanup does. Returns the number of blackouts expired and hosts restored.
+func (s *InventoryStore) ExpireBlackouts(ctx context.Context) (int64, int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE inventory_blackouts
+		SET status = 'expired'
+		WHERE status = 'active' AND end_time <= NOW()
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to expire blackouts: %w", err)
+	}
+	expired, _ := result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx, `
+		UPDATE inventory_resources
+		SET status = 'active', updated_at = NOW()
+		WHERE status = 'blackout'
+		  AND hostname NOT IN (SELECT hostname FROM inventory_blackouts WHERE `+blackoutInEffect+`)
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to restore host status: %w", err)
+	}
+	restored, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit blackout expiry: %w", err)
+	}
+	return expired, restored, nil
+}
+
+// ResolveSystems matches a ticket's affected systems to inventory hosts.
+// A system matches a host by hostname or resource name, ignoring case; a
+// system containing * matches every hostname fitting the pattern. Systems
+// matching no host are returned as unknown.
+func (s *InventoryStore) ResolveSystems(ctx context.Context, systems []string) ([]models.AffectedResource, []string, error) {
+	var resources []models.AffectedResource
+	var unknown []string
+	seen := make(map[string]bool)
+
+	for _, system := range systems {
+		system = strings.TrimSpace(system)
+		if system == "" || seen[strings.ToLower(system)] {
+			continue
+		}
+		seen[strings.ToLower(system)] = true
+
+		var rows *sql.Rows
+		var err error
+		if models.IsHostPattern(system) {
+			pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "*", "%").Replace(system)
+			rows, err = s.db.QueryContext(ctx,
+				"SELECT id, hostname, COALESCE(environment, '') FROM inventory_resources WHERE hostname ILIKE $1 ORDER BY hostname",
+				pattern,
+			)
+		} else {
+			rows, err = s.db.QueryContext(ctx,
+				"SELECT id, hostname, COALESCE(environment, '') FROM inventory_resources WHERE lower(hostname) = lower($1) OR lower(resource_name) = lower($1)",
+				system,
+			)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve affected systems: %w", err)
+		}
+
+		matched := false
+		for rows.Next() {
+			r := models.AffectedResource{System: system}
+			if err := rows.Scan(&r.ResourceID, &r.Hostname, &r.Environment); err != nil {
+				rows.Close()
+				return nil, nil, fmt.Errorf("failed to scan host: %w", err)
+			}
+			resources = append(resources, r)
+			matched = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, nil, err
+		}
+		if !matched {
+			unknown = append(unknown, system)
+		}
+	}
+
+	return resources, unknown, nil
+}
+
+// Owners returns the distinct owners listed on the given hosts
+func (s *InventoryStore) Owners(ctx context.Context, resourceIDs []int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT to_jsonb(owners) FROM inventory_resources WHERE id = ANY($1) AND owners IS NOT NULL",
+		pq.Array(resourceIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host owners: %w", err)
+	}
+	defer rows.Close()
+
+	var owners []string
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan host owners: %w", err)
+		}
+		var listed []string
+		json.Unmarshal(data, &listed)
+		for _, o := range listed {
+			o = strings.TrimSpace(o)
+			if o != "" && !seen[strings.ToLower(o)] {
+				seen[strings.ToLower(o)] = true
+				owners = append(owners, o)
+			}
+		}
+	}
+
+	return owners, rows.Err()
+}
+
+// OwnersByResource returns the owners listed on each of the given hosts.
+// Hosts without owners are left out.
+func (s *InventoryStore) OwnersByResource(ctx context.Context, resourceIDs []int) (map[int][]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, to_jsonb(owners) FROM inventory_resources WHERE id = ANY($1) AND owners IS NOT NULL",
+		pq.Array(resourceIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host owners: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan host owners: %w", err)
+		}
+		var listed []string
+		json.Unmarshal(data, &listed)
+		for _, o := range listed {
+			if o = strings.TrimSpace(o); o != "" {
+				owners[id] = append(owners[id], o)
+			}
+		}
+	}
+
+	return owners, rows.Err()
+}
+
+// ListProviderHosts lists the hosts of one provider that should exist in
+// the cloud, leaving out decommissioned hosts
+func (s *InventoryStore) ListProviderHosts(ctx context.Context, provider string) ([]models.Host, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM inventory_resources
+		WHERE provider = $1 AND COALESCE(status, 'active') <> 'decommissioned'
+		ORDER BY hostname
+	`, hostColumns)
+	rows, err := s.db.QueryContext(ctx, query, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s hosts: %w", provider, err)
+	}
+	defer rows.Close()
+
+	var hosts []models.Host
+	for rows.Next() {
+		h, err := scanHost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan host: %w", err)
+		}
+		hosts = append(hosts, *h)
+	}
+	return hosts, rows.Err()
+}
+
+const discrepancyColumns = `
+	id, kind, provider, resource_id, hostname, external_id, cloud_name, region,
+	cloud_status, first_seen_at, last_seen_at, resolved_at
+`
+
+func scanDiscrepancy(row rowScanner) (*models.InventoryDiscrepancy, error) {
+	d := &models.InventoryDiscrepancy{}
+	err := row.Scan(
+		&d.ID, &d.Kind, &d.Provider, &d.ResourceID, &d.Hostname, &d.ExternalID, &d.CloudName, &d.Region,
+		&d.CloudStatus, &d.FirstSeenAt, &d.LastSeenAt, &d.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// RecordDiscrepancies replaces a provider's open discrepancies with those
+// found by a reconciliation run. Ones still found keep their first_seen_at;
+// ones no longer found are resolved. Returns the number opened and resolved.
+func (s *InventoryStore) RecordDiscrepancies(ctx context.Context, provider string, found []models.InventoryDiscrepancy) (int64, int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var opened int64
+	keys := make([]string, 0, len(found))
+	for i := range found {
+		d := &found[i]
+		key := string(d.Kind) + ":" + d.MatchKey()
+		keys = append(keys, key)
+
+		var inserted bool
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO inventory_discrepancies (
+				kind, provider, match_key, resource_id, hostname, external_id,
+				cloud_name, region, cloud_status
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (kind, provider, match_key) WHERE resolved_at IS NULL
+			DO UPDATE SET
+				resource_id = EXCLUDED.resource_id,
+				external_id = EXCLUDED.external_id,
+				cloud_name = EXCLUDED.cloud_name,
+				region = EXCLUDED.region,
+				cloud_status = EXCLUDED.cloud_status,
+				last_seen_at = NOW()
+			RETURNING xmax = 0
+		`, d.Kind, provider, d.MatchKey(), d.ResourceID, d.Hostname, d.ExternalID,
+			d.CloudName, d.Region, d.CloudStatus,
+		).Scan(&inserted)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to record discrepancy: %w", err)
+		}
+		if inserted {
+			opened++
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE inventory_discrepancies
+		SET resolved_at = NOW()
+		WHERE provider = $1 AND resolved_at IS NULL
+		  AND NOT (kind || ':' || match_key = ANY($2))
+	`, provider, pq.Array(keys))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to resolve discrepancies: %w", err)
+	}
+	resolved, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit discrepancies: %w", err)
+	}
+	return opened, resolved, nil
+}
+
+// ListDiscrepancies lists discrepancies, open ones first and then most
+// recently seen
+func (s *InventoryStore) ListDiscrepancies(ctx context.Context, filter *models.DiscrepancyFilter) ([]models.InventoryDiscrepancy, error) {
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	if !filter.Resolved {
+		conditions = append(conditions, "resolved_at IS NULL")
+	}
+	if filter.Kind != "" {
+		conditions = append(conditions, fmt.Sprintf("kind = $%d", argNum))
+		args = append(args, filter.Kind)
+		argNum++
+	}
+	if filter.Provider != "" {
+		conditions = append(conditions, fmt.Sprintf("provider = $%d", argNum))
+		args = append(args, filter.Provider)
+		argNum++
+	}
+	if filter.Limit < 1 || filter.Limit > 500 {
+		filter.Limit = 500
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM inventory_discrepancies
+		%s
+		ORDER BY resolved_at IS NOT NULL, last_seen_at DESC, id
+		LIMIT $%d
+	`, discrepancyColumns, where, argNum)
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discrepancies: %w", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []models.InventoryDiscrepancy{}
+	for rows.Next() {
+		d, err := scanDiscrepancy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan discrepancy: %w", err)
+		}
+		discrepancies = append(discrepancies, *d)
+	}
+	return discrepancies, rows.Err()
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,377 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// JiraStore handles Jira integration and sync state database operations
+type JiraStore struct {
+	db conn
+}
+
+const jiraIntegrationColumns = `
+	organization_id, base_url, email, api_token, project_key, issue_type,
+	field_mapping, status_mapping, actor_user_id, is_active, last_pulled_at,
+	updated_by, created_at, updated_at
+`
+
+const jiraSyncStateColumns = `
+	ticket_id, organization_id, issue_id, issue_key, pushed_version,
+	COALESCE(pushed_status, ''), jira_status, last_synced_at, last_error,
+	error_count, created_at, updated_at
+`
+
+// GetIntegration retrieves an organization's Jira integration
+func (s *JiraStore) GetIntegration(ctx context.Context, orgID uuid.UUID) (*models.JiraIntegration, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM jira_integrations
+		WHERE organization_id = $1
+	`, jiraIntegrationColumns)
+
+	integration, err := scanJiraIntegration(s.db.QueryRowContext(ctx, query, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("jira integration not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jira integration: %w", err)
+	}
+
+	return integration, nil
+}
+
+// UpdateIntegration creates or updates an organization's Jira integration.
+// Creating one requires the connection settings and an actor user.
+func (s *JiraStore) UpdateIntegration(ctx context.Context, orgID, userID uuid.UUID, input *models.UpdateJiraIntegrationInput) (*models.JiraIntegration, error) {
+	current, err := s.GetIntegration(ctx, orgID)
+	if err != nil && err.Error() != "jira integration not found" {
+		return nil, err
+	}
+	if current == nil {
+		if input.BaseURL == nil || input.Email == nil || input.APIToken == nil || input.ProjectKey == nil || input.ActorUserID == nil {
+			return nil, &models.ValidationError{Field: "base_url", Message: "base_url, email, api_token, project_key and actor_user_id are required to enable the integration"}
+		}
+		current = &models.JiraIntegration{
+			OrganizationID: orgID,
+			IssueType:      models.DefaultJiraIssueType,
+			FieldMapping:   models.DefaultJiraFieldMapping(),
+			StatusMapping:  map[models.TicketStatus]string{},
+			IsActive:       true,
+		}
+	}
+	if input.BaseURL != nil {
+		current.BaseURL = *input.BaseURL
+	}
+	if input.Email != nil {
+		current.Email = *input.Email
+	}
+	if input.APIToken != nil {
+		current.APIToken = *input.APIToken
+	}
+	if input.ProjectKey != nil {
+		current.ProjectKey = *input.ProjectKey
+	}
+	if input.IssueType != nil {
+		current.IssueType = *input.IssueType
+	}
+	if input.FieldMapping != nil {
+		current.FieldMapping = input.FieldMapping
+	}
+	if input.StatusMapping != nil {
+		current.StatusMapping = input.StatusMapping
+	}
+	if input.ActorUserID != nil {
+		current.ActorUserID = *input.ActorUserID
+	}
+	if input.IsActive != nil {
+		current.IsActive = *input.IsActive
+	}
+
+	var actorInOrg bool
+	err = s.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL)",
+		current.ActorUserID, orgID,
+	).Scan(&actorInOrg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check actor user: %w", err)
+	}
+	if !actorInOrg {
+		return nil, &models.ValidationError{Field: "actor_user_id", Message: "actor_user_id must be a user in this organization"}
+	}
+
+	fieldMapping, err := json.Marshal(current.FieldMapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode field mapping: %w", err)
+	}
+	statusMapping, err := json.Marshal(current.StatusMapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode status mapping: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO jira_integrations (
+			organization_id, base_url, email, api_token, project_key, issue_type,
+			field_mapping, status_mapping, actor_user_id, is_active, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET base_url = EXCLUDED.base_url,
+		    email = EXCLUDED.email,
+		    api_token = EXCLUDED.api_token,
+		    project_key = EXCLUDED.project_key,
+		    issue_type = EXCLUDED.issue_type,
+		    field_mapping = EXCLUDED.field_mapping,
+		    status_mapping = EXCLUDED.status_mapping,
+		    actor_user_id = EXCLUDED.actor_user_id,
+		    is_active = EXCLUDED.is_active,
+		    updated_by = EXCLUDED.updated_by
+		RETURNING %s
+	`, jiraIntegrationColumns)
+
+	integration, err := scanJiraIntegration(s.db.QueryRowContext(ctx, query,
+		orgID, current.BaseURL, current.Email, current.APIToken, current.ProjectKey, current.IssueType,
+		fieldMapping, statusMapping, current.ActorUserID, current.IsActive, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update jira integration: %w", err)
+	}
+
+	return integration, nil
+}
+
+// ListActiveIntegrations returns every active Jira integration, for the sync job
+func (s *JiraStore) ListActiveIntegrations(ctx context.Context) ([]models.JiraIntegration, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM jira_integrations
+		WHERE is_active
+	`, jiraIntegrationColumns)
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jira integrations: %w", err)
+	}
+	defer rows.Close()
+
+	var integrations []models.JiraIntegration
+	for rows.Next() {
+		integration, err := scanJiraIntegration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan jira integration: %w", err)
+		}
+		integrations = append(integrations, *integration)
+	}
+
+	return integrations, rows.Err()
+}
+
+// MarkPulled records when changes were last pulled from Jira
+func (s *JiraStore) MarkPulled(ctx context.Context, orgID uuid.UUID, at time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE jira_integrations SET last_pulled_at = $2 WHERE organization_id = $1",
+		orgID, at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark jira pull: %w", err)
+	}
+	return nil
+}
+
+// GetSyncState retrieves a ticket's Jira sync state
+func (s *JiraStore) GetSyncState(ctx context.Context, orgID, ticketID uuid.UUID) (*models.JiraSyncState, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM jira_sync_state
+		WHERE ticket_id = $1 AND organization_id = $2
+	`, jiraSyncStateColumns)
+
+	state, err := scanJiraSyncState(s.db.QueryRowContext(ctx, query, ticketID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("jira sync state not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jira sync state: %w", err)
+	}
+
+	return state, nil
+}
+
+// ListSyncStatesByIssueKey returns the sync states for the given Jira issues, keyed by issue key
+func (s *JiraStore) ListSyncStatesByIssueKey(ctx context.Context, orgID uuid.UUID, issueKeys []string) (map[string]*models.JiraSyncState, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM jira_sync_state
+		WHERE organization_id = $1 AND issue_key = ANY($2)
+	`, jiraSyncStateColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, pq.Array(issueKeys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jira sync states: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]*models.JiraSyncState)
+	for rows.Next() {
+		state, err := scanJiraSyncState(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan jira sync state: %w", err)
+		}
+		if state.IssueKey != nil {
+			states[*state.IssueKey] = state
+		}
+	}
+
+	return states, rows.Err()
+}
+
+// ListTicketsToPush returns tickets changed since they were last mirrored to
+// Jira, oldest change first. Confidential tickets are never mirrored, and
+// tickets that keep failing are skipped until they change again.
+func (s *JiraStore) ListTicketsToPush(ctx context.Context, orgID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT t.id
+		FROM change_tickets t
+		LEFT JOIN jira_sync_state s ON s.ticket_id = t.id
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+		  AND NOT COALESCE(t.is_confidential, false)
+		  AND (s.ticket_id IS NULL OR t.version > s.pushed_version)
+		  AND (s.ticket_id IS NULL OR s.error_count < $2 OR t.updated_at > s.updated_at)
+		ORDER BY t.updated_at
+		LIMIT $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, models.MaxJiraSyncErrors, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets to push: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// SaveSyncState records a successful push or pull and clears any error
+func (s *JiraStore) SaveSyncState(ctx context.Context, state *models.JiraSyncState) error {
+	query := `
+		INSERT INTO jira_sync_state (
+			ticket_id, organization_id, issue_id, issue_key, pushed_version,
+			pushed_status, jira_status, last_synced_at, last_error, error_count
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NULL, 0)
+		ON CONFLICT (ticket_id) DO UPDATE
+		SET issue_id = EXCLUDED.issue_id,
+		    issue_key = EXCLUDED.issue_key,
+		    pushed_version = EXCLUDED.pushed_version,
+		    pushed_status = EXCLUDED.pushed_status,
+		    jira_status = EXCLUDED.jira_status,
+		    last_synced_at = EXCLUDED.last_synced_at,
+		    last_error = NULL,
+		    error_count = 0
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		state.TicketID, state.OrganizationID, state.IssueID, state.IssueKey, state.PushedVersion,
+		state.PushedStatus, state.JiraStatus,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save jira sync state: %w", err)
+	}
+	return nil
+}
+
+// RecordError records a failed push for a ticket
+func (s *JiraStore) RecordError(ctx context.Context, orgID, ticketID uuid.UUID, message string) error {
+	query := `
+		INSERT INTO jira_sync_state (ticket_id, organization_id, last_error, error_count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (ticket_id) DO UPDATE
+		SET last_error = EXCLUDED.last_error,
+		    error_count = jira_sync_state.error_count + 1
+	`
+	if _, err := s.db.ExecContext(ctx, query, ticketID, orgID, message); err != nil {
+		return fmt.Errorf("failed to record jira sync error: %w", err)
+	}
+	return nil
+}
+
+// LinkedCommentIDs returns the Jira comment IDs on an issue already copied to its ticket
+func (s *JiraStore) LinkedCommentIDs(ctx context.Context, orgID uuid.UUID, issueKey string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT jira_comment_id FROM jira_comment_links WHERE organization_id = $1 AND issue_key = $2",
+		orgID, issueKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jira comment links: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan jira comment link: %w", err)
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// LinkComment records that a Jira comment was copied to a ticket comment
+func (s *JiraStore) LinkComment(ctx context.Context, orgID uuid.UUID, jiraCommentID, issueKey string, commentID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO jira_comment_links (organization_id, jira_comment_id, issue_key, comment_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, jira_comment_id) DO NOTHING
+	`, orgID, jiraCommentID, issueKey, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to link jira comment: %w", err)
+	}
+	return nil
+}
+
+func scanJiraIntegration(row rowScanner) (*models.JiraIntegration, error) {
+	j := &models.JiraIntegration{}
+	var fieldMapping, statusMapping []byte
+	err := row.Scan(
+		&j.OrganizationID, &j.BaseURL, &j.Email, &j.APIToken, &j.ProjectKey, &j.IssueType,
+		&fieldMapping, &statusMapping, &j.ActorUserID, &j.IsActive, &j.LastPulledAt,
+		&j.UpdatedBy, &j.CreatedAt, &j.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fieldMapping, &j.FieldMapping); err != nil {
+		return nil, fmt.Errorf("failed to decode field mapping: %w", err)
+	}
+	if err := json.Unmarshal(statusMapping, &j.StatusMapping); err != nil {
+		return nil, fmt.Errorf("failed to decode status mapping: %w", err)
+	}
+	return j, nil
+}
+
+func scanJiraSyncState(row rowScanner) (*models.JiraSyncState, error) {
+	st := &models.JiraSyncState{}
+	err := row.Scan(
+		&st.TicketID, &st.OrganizationID, &st.IssueID, &st.IssueKey, &st.PushedVersion,
+		&st.PushedStatus, &st.JiraStatus, &st.LastSyncedAt, &st.LastError,
+		&st.ErrorCount, &st.CreatedAt, &st.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
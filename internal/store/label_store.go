@@ -0,0 +1,257 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// LabelStore handles label database operations
+type LabelStore struct {
+	db      conn
+	tickets ticketEvicter
+}
+
+const labelColumns = `id, organization_id, name, color, description, created_by, created_at, updated_at`
+
+// Create defines a new label
+func (s *LabelStore) Create(ctx context.Context, orgID, createdBy uuid.UUID, input *models.CreateLabelInput) (*models.Label, error) {
+	label, err := scanLabel(s.db.QueryRowContext(ctx, `
+		INSERT INTO labels (organization_id, name, color, description, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+labelColumns,
+		orgID, input.Name, input.Color, input.Description, createdBy,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("label already exists")
+		}
+		return nil, fmt.Errorf("failed to create label: %w", err)
+	}
+	return label, nil
+}
+
+// GetByID retrieves a label by ID
+func (s *LabelStore) GetByID(ctx context.Context, orgID, labelID uuid.UUID) (*models.Label, error) {
+	return getLabel(ctx, s.db, orgID, labelID)
+}
+
+func getLabel(ctx context.Context, q execQuerier, orgID, labelID uuid.UUID) (*models.Label, error) {
+	label, err := scanLabel(q.QueryRowContext(ctx,
+		"SELECT "+labelColumns+" FROM labels WHERE id = $1 AND organization_id = $2",
+		labelID, orgID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("label not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get label: %w", err)
+	}
+	return label, nil
+}
+
+// List retrieves an organization's labels by name
+func (s *LabelStore) List(ctx context.Context, orgID uuid.UUID) ([]models.Label, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+labelColumns+" FROM labels WHERE organization_id = $1 ORDER BY name",
+		orgID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	defer rows.Close()
+
+	var labels []models.Label
+	for rows.Next() {
+		label, err := scanLabel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		labels = append(labels, *label)
+	}
+
+	return labels, rows.Err()
+}
+
+// Update updates a label. A rename is applied to every ticket carrying the
+// label in the same transaction.
+func (s *LabelStore) Update(ctx context.Context, orgID, labelID uuid.UUID, input *models.UpdateLabelInput) (*models.Label, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *input.Name)
+		argNum++
+	}
+
+	if input.Color != nil {
+		updates = append(updates, fmt.Sprintf("color = $%d", argNum))
+		args = append(args, *input.Color)
+		argNum++
+	}
+
+	if input.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+
+	if len(updates) == 0 {
+		return s.GetByID(ctx, orgID, labelID)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := getLabel(ctx, tx, orgID, labelID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE labels SET %s WHERE id = $%d AND organization_id = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1,
+	)
+	args = append(args, labelID, orgID)
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("label already exists")
+		}
+		return nil, fmt.Errorf("failed to update label: %w", err)
+	}
+
+	var renamed []uuid.UUID
+	if input.Name != nil && *input.Name != current.Name {
+		// Tickets that already carry the new name just drop the old one
+		renamed, err = queryTicketIDs(ctx, tx, `
+			UPDATE change_tickets
+			SET labels = CASE
+			        WHEN labels @> ARRAY[$2]::text[] THEN array_remove(labels, $1)
+			        ELSE array_replace(labels, $1, $2)
+			    END,
+			    version = version + 1,
+			    updated_at = NOW()
+			WHERE organization_id = $3 AND labels @> ARRAY[$1]::text[]
+			RETURNING id
+		`, current.Name, *input.Name, orgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rename label on tickets: %w", err)
+		}
+	}
+
+	label, err := getLabel(ctx, tx, orgID, labelID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(renamed) > 0 {
+		s.tickets.evict(ctx, orgID, renamed...)
+	}
+	return label, nil
+}
+
+// Delete removes a label and takes it off every ticket that carries it
+func (s *LabelStore) Delete(ctx context.Context, orgID, labelID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name string
+	err = tx.QueryRowContext(ctx,
+		"DELETE FROM labels WHERE id = $1 AND organization_id = $2 RETURNING name",
+		labelID, orgID,
+	).Scan(&name)
+	if err == sql.ErrNoRows {
+		return models.NotFound("label not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+
+	removed, err := queryTicketIDs(ctx, tx, `
+		UPDATE change_tickets
+		SET labels = array_remove(labels, $1), version = version + 1, updated_at = NOW()
+		WHERE organization_id = $2 AND labels @> ARRAY[$1]::text[]
+		RETURNING id
+	`, name, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to remove label from tickets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		s.tickets.evict(ctx, orgID, removed...)
+	}
+	return nil
+}
+
+// Usage counts the tickets carrying each label, including labels in use on
+// tickets that are not defined, most used first
+func (s *LabelStore) Usage(ctx context.Context, orgID uuid.UUID) ([]models.LabelUsage, error) {
+	var open []string
+	for _, st := range models.OpenTicketStatuses() {
+		open = append(open, string(st))
+	}
+
+	query := `
+		WITH usage AS (
+			SELECT u.label,
+			       COUNT(*) AS ticket_count,
+			       COUNT(*) FILTER (WHERE t.status::text = ANY($2)) AS open_ticket_count,
+			       MAX(t.updated_at) AS last_used_at
+			FROM change_tickets t, unnest(t.labels) AS u(label)
+			WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+			GROUP BY u.label
+		),
+		defined AS (
+			SELECT id, name, color FROM labels WHERE organization_id = $1
+		)
+		SELECT COALESCE(d.name, u.label), d.id, d.color,
+		       COALESCE(u.ticket_count, 0), COALESCE(u.open_ticket_count, 0), u.last_used_at
+		FROM defined d
+		FULL OUTER JOIN usage u ON u.label = d.name
+		ORDER BY COALESCE(u.ticket_count, 0) DESC, 1
+	`
+	rows, err := s.db.QueryContext(ctx, query, orgID, pq.Array(open))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get label usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []models.LabelUsage{}
+	for rows.Next() {
+		var u models.LabelUsage
+		if err := rows.Scan(&u.Name, &u.LabelID, &u.Color, &u.TicketCount, &u.OpenTicketCount, &u.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan label usage: %w", err)
+		}
+		u.Defined = u.LabelID != nil
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
+
+func scanLabel(row rowScanner) (*models.Label, error) {
+	l := &models.Label{}
+	err := row.Scan(&l.ID, &l.OrganizationID, &l.Name, &l.Color, &l.Description, &l.CreatedBy, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
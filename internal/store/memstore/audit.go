@@ -0,0 +1,457 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// AuditStore keeps the ticket audit log, the organization audit log and the
+// record of archived days in memory
+type AuditStore struct {
+	d *data
+}
+
+// AuditEntry is an organization-level audit event recorded by Log
+type AuditEntry struct {
+	OrganizationID uuid.UUID
+	models.CreateAuditLogInput
+	CreatedAt time.Time
+}
+
+// Entries returns the organization-level events Log recorded, oldest first,
+// for tests to check what a handler audited
+func (s *AuditStore) Entries(orgID uuid.UUID) []AuditEntry {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var entries []AuditEntry
+	for _, e := range s.d.auditLog {
+		if e.OrganizationID == orgID {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// LogTicketAccess logs an access event for a ticket (SOX compliance)
+func (s *AuditStore) LogTicketAccess(ctx context.Context, ticketID, userID uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error {
+	return s.logTicketEvent(ticketID, &userID, action, ipAddress, userAgent, changes)
+}
+
+// LogSystemEvent logs an event performed by the system rather than a user
+func (s *AuditStore) LogSystemEvent(ctx context.Context, ticketID uuid.UUID, action string, changes map[string]interface{}) error {
+	return s.logTicketEvent(ticketID, nil, action, nil, nil, changes)
+}
+
+func (s *AuditStore) logTicketEvent(ticketID uuid.UUID, userID *uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, ok := s.d.tickets[ticketID]
+	if !ok {
+		return models.NotFound("failed to get ticket info: ticket not found")
+	}
+	t := &row.ticket
+
+	category := store.ActionCategory(action)
+	relevant := store.IsComplianceRelevantAction(action) || (t.IsEmergency && category != "access")
+
+	s.d.ticketAudit = append(s.d.ticketAudit, models.TicketAuditLog{
+		ID:                   uuid.New(),
+		TicketID:             ticketID,
+		OrganizationID:       t.OrganizationID,
+		UserID:               userID,
+		Action:               action,
+		ActionCategory:       category,
+		Changes:              changes,
+		IPAddress:            ipAddress,
+		UserAgent:            userAgent,
+		IsComplianceRelevant: relevant,
+		ComplianceFrameworks: t.ComplianceFrameworks,
+		RequiresReview:       relevant,
+		IsEmergency:          t.IsEmergency,
+		CreatedAt:            time.Now(),
+	})
+	return nil
+}
+
+// LogTicketEvents records many ticket events at once. Like the database
+// store it records none if any ticket is missing.
+func (s *AuditStore) LogTicketEvents(ctx context.Context, events []models.TicketAuditEvent) error {
+	s.d.mu.Lock()
+	for _, e := range events {
+		if _, ok := s.d.tickets[e.TicketID]; !ok {
+			s.d.mu.Unlock()
+			return models.NotFound(fmt.Sprintf("failed to get ticket info: ticket %s not found", e.TicketID))
+		}
+	}
+	s.d.mu.Unlock()
+
+	for _, e := range events {
+		if err := s.logTicketEvent(e.TicketID, e.UserID, e.Action, e.IPAddress, e.UserAgent, e.Changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogTicketView logs a view event for a ticket
+func (s *AuditStore) LogTicketView(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string) error {
+	return s.LogTicketAccess(ctx, ticketID, userID, "view", ipAddress, userAgent, nil)
+}
+
+// LogTicketEdit logs an edit event for a ticket
+func (s *AuditStore) LogTicketEdit(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string, changes map[string]interface{}) error {
+	return s.LogTicketAccess(ctx, ticketID, userID, "edit", ipAddress, userAgent, changes)
+}
+
+// LogTicketStatusChange logs a status change event
+func (s *AuditStore) LogTicketStatusChange(ctx context.Context, ticketID, userID uuid.UUID, oldStatus, newStatus string, ipAddress, userAgent *string) error {
+	changes := map[string]interface{}{
+		"old_status": oldStatus,
+		"new_status": newStatus,
+	}
+	return s.LogTicketAccess(ctx, ticketID, userID, "status_change", ipAddress, userAgent, changes)
+}
+
+// ListStatusChangesForCreator retrieves status changes made in [from, to)
+// to tickets the user created, oldest first. Changes the user made
+// themselves are left out.
+func (s *AuditStore) ListStatusChangesForCreator(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time, limit int) ([]models.DigestStatusChange, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var changes []models.DigestStatusChange
+	for _, l := range s.d.ticketAudit {
+		if l.Action != "status_change" || l.CreatedAt.Before(from) || !l.CreatedAt.Before(to) {
+			continue
+		}
+		if l.UserID != nil && *l.UserID == userID {
+			continue
+		}
+		row, ok := s.d.tickets[l.TicketID]
+		if !ok || row.ticket.OrganizationID != orgID || row.ticket.CreatedBy != userID || row.ticket.DeletedAt != nil {
+			continue
+		}
+		oldStatus, _ := l.Changes["old_status"].(string)
+		newStatus, _ := l.Changes["new_status"].(string)
+		changes = append(changes, models.DigestStatusChange{
+			TicketNumber: row.ticket.TicketNumber,
+			Title:        row.ticket.Title,
+			From:         models.TicketStatus(oldStatus),
+			To:           models.TicketStatus(newStatus),
+			ChangedAt:    l.CreatedAt,
+		})
+		if len(changes) == limit {
+			break
+		}
+	}
+	return changes, nil
+}
+
+// GetTicketAuditLog retrieves audit log entries for a ticket, newest first
+func (s *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID, filter *models.AuditLogFilter) ([]models.TicketAuditLog, int, error) {
+	filter.SetDefaults()
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var logs []models.TicketAuditLog
+	for i := len(s.d.ticketAudit) - 1; i >= 0; i-- {
+		l := s.d.ticketAudit[i]
+		switch {
+		case l.TicketID != ticketID,
+			filter.UserID != nil && (l.UserID == nil || *l.UserID != *filter.UserID),
+			filter.Action != nil && l.Action != *filter.Action,
+			filter.ActionCategory != nil && l.ActionCategory != *filter.ActionCategory,
+			filter.IsComplianceRelevant != nil && l.IsComplianceRelevant != *filter.IsComplianceRelevant,
+			filter.RequiresReview != nil && l.RequiresReview != *filter.RequiresReview,
+			filter.IsEmergency != nil && l.IsEmergency != *filter.IsEmergency,
+			filter.FromDate != nil && l.CreatedAt.Before(*filter.FromDate),
+			filter.ToDate != nil && !l.CreatedAt.Before(*filter.ToDate):
+			continue
+		}
+		logs = append(logs, l)
+	}
+	total := len(logs)
+	// Order like the SQL store so cursors compare the same way
+	sort.SliceStable(logs, func(i, j int) bool {
+		if !logs[i].CreatedAt.Equal(logs[j].CreatedAt) {
+			return logs[i].CreatedAt.After(logs[j].CreatedAt)
+		}
+		return logs[i].ID.String() > logs[j].ID.String()
+	})
+
+	start := filter.Offset()
+	if filter.Cursor != "" {
+		cursor, err := models.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		if cursor.SortBy != "created_at" {
+			return nil, 0, &models.ValidationError{Field: "cursor", Message: "cursor does not match sort_by"}
+		}
+		after, err := cursor.Time()
+		if err != nil {
+			return nil, 0, err
+		}
+		start = len(logs)
+		for i, l := range logs {
+			if l.CreatedAt.Before(after) || (l.CreatedAt.Equal(after) && l.ID.String() < cursor.ID.String()) {
+				start = i
+				break
+			}
+		}
+	}
+	if start > len(logs) {
+		start = len(logs)
+	}
+	end := start + filter.PerPage
+	if end > len(logs) {
+		end = len(logs)
+	}
+
+	filter.NextCursor = ""
+	if end < len(logs) {
+		last := &logs[end-1]
+		filter.NextCursor = models.TimeCursor("created_at", last.CreatedAt, last.ID).Encode()
+	}
+	return logs[start:end], total, nil
+}
+
+// MarkReviewed marks an audit log entry as reviewed
+func (s *AuditStore) MarkReviewed(ctx context.Context, auditID, reviewerID uuid.UUID) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for i := range s.d.ticketAudit {
+		l := &s.d.ticketAudit[i]
+		if l.ID == auditID && l.RequiresReview && l.ReviewedAt == nil {
+			now := time.Now()
+			l.ReviewedBy = &reviewerID
+			l.ReviewedAt = &now
+		}
+	}
+	return nil
+}
+
+// GetPendingReviews retrieves up to 100 audit log entries pending review,
+// oldest first
+func (s *AuditStore) GetPendingReviews(ctx context.Context, orgID uuid.UUID) ([]models.TicketAuditLog, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var logs []models.TicketAuditLog
+	for _, l := range s.d.ticketAudit {
+		if l.OrganizationID == orgID && l.RequiresReview && l.ReviewedAt == nil {
+			logs = append(logs, l)
+			if len(logs) == 100 {
+				break
+			}
+		}
+	}
+	return logs, nil
+}
+
+// exported returns the organization's entries an export matches, oldest
+// first
+func (d *data) exported(orgID uuid.UUID, filter *models.AuditExportFilter) []models.TicketAuditLog {
+	var logs []models.TicketAuditLog
+	for _, l := range d.ticketAudit {
+		switch {
+		case l.OrganizationID != orgID,
+			filter.FromDate != nil && l.CreatedAt.Before(*filter.FromDate),
+			filter.ToDate != nil && !l.CreatedAt.Before(*filter.ToDate),
+			len(filter.Frameworks) > 0 && !sharesFramework(l.ComplianceFrameworks, filter.Frameworks),
+			filter.ActionCategory != nil && l.ActionCategory != *filter.ActionCategory,
+			filter.ComplianceOnly && !l.IsComplianceRelevant,
+			filter.EmergencyOnly && !l.IsEmergency:
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs
+}
+
+func sharesFramework(tagged, wanted []models.ComplianceFramework) bool {
+	for _, a := range tagged {
+		for _, b := range wanted {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CountForExport returns the number of entries an export would contain
+func (s *AuditStore) CountForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	return len(s.d.exported(orgID, filter)), nil
+}
+
+// StreamForExport calls fn for every matching entry in chronological order,
+// up to filter.MaxRows
+func (s *AuditStore) StreamForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter, fn func(*models.TicketAuditLog) error) error {
+	filter.SetDefaults()
+
+	s.d.mu.Lock()
+	logs := s.d.exported(orgID, filter)
+	s.d.mu.Unlock()
+
+	if len(logs) > filter.MaxRows {
+		logs = logs[:filter.MaxRows]
+	}
+	for i := range logs {
+		if err := fn(&logs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamRange calls fn for every organization's entries created in
+// [from, to) in chronological order, for archiving
+func (s *AuditStore) StreamRange(ctx context.Context, from, to time.Time, fn func(*models.TicketAuditLog) error) error {
+	s.d.mu.Lock()
+	var logs []models.TicketAuditLog
+	for _, l := range s.d.ticketAudit {
+		if !l.CreatedAt.Before(from) && l.CreatedAt.Before(to) {
+			logs = append(logs, l)
+		}
+	}
+	s.d.mu.Unlock()
+
+	for i := range logs {
+		if err := fn(&logs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchivedDays returns which days in [from, to] have been archived, keyed
+// by date as YYYY-MM-DD
+func (s *AuditStore) ArchivedDays(ctx context.Context, from, to time.Time) (map[string]bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	first, last := from.Format("2006-01-02"), to.Format("2006-01-02")
+	days := make(map[string]bool)
+	for day := range s.d.archives {
+		if day >= first && day <= last {
+			days[day] = true
+		}
+	}
+	return days, nil
+}
+
+// LastArchivedDay returns the latest archived day, or nil if none has been
+func (s *AuditStore) LastArchivedDay(ctx context.Context) (*time.Time, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	days := make([]string, 0, len(s.d.archives))
+	for day := range s.d.archives {
+		days = append(days, day)
+	}
+	if len(days) == 0 {
+		return nil, nil
+	}
+	sort.Strings(days)
+	last, err := time.Parse("2006-01-02", days[len(days)-1])
+	if err != nil {
+		return nil, err
+	}
+	return &last, nil
+}
+
+// EnsurePartitions does nothing: the in-memory log isn't partitioned
+func (s *AuditStore) EnsurePartitions(ctx context.Context, through time.Time) (int, error) {
+	return 0, nil
+}
+
+// ListPartitions lists the UTC months with ticket audit entries, oldest
+// first, as the SQL store lists its partitions
+func (s *AuditStore) ListPartitions(ctx context.Context) ([]models.AuditPartition, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	counts := make(map[time.Time]int64)
+	for _, l := range s.d.ticketAudit {
+		t := l.CreatedAt.UTC()
+		counts[time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)]++
+	}
+	var partitions []models.AuditPartition
+	for from, n := range counts {
+		partitions = append(partitions, models.AuditPartition{
+			Name:          fmt.Sprintf("ticket_audit_log_y%04dm%02d", from.Year(), int(from.Month())),
+			From:          from,
+			To:            from.AddDate(0, 1, 0),
+			EstimatedRows: n,
+		})
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].From.Before(partitions[j].From) })
+	return partitions, nil
+}
+
+// DropPartition removes the ticket audit entries of the UTC month containing
+// month, once every day of it has been archived
+func (s *AuditStore) DropPartition(ctx context.Context, month time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	from := time.Date(month.UTC().Year(), month.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if _, ok := s.d.archives[day.Format("2006-01-02")]; !ok {
+			return models.Conflict("audit log partition not fully archived")
+		}
+	}
+
+	kept := s.d.ticketAudit[:0]
+	for _, l := range s.d.ticketAudit {
+		if l.CreatedAt.Before(from) || !l.CreatedAt.Before(to) {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == len(s.d.ticketAudit) {
+		return models.NotFound("audit log partition not found")
+	}
+	s.d.ticketAudit = kept
+	return nil
+}
+
+// RecordArchive records a day's archive once its objects are uploaded
+func (s *AuditStore) RecordArchive(ctx context.Context, a *models.AuditArchive) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	day := a.Date.Format("2006-01-02")
+	if _, ok := s.d.archives[day]; ok {
+		return fmt.Errorf("failed to record audit archive: %s is already archived", day)
+	}
+	s.d.archives[day] = *a
+	return nil
+}
+
+// Log records an organization-level audit event (logins, exports, admin actions)
+func (s *AuditStore) Log(ctx context.Context, orgID uuid.UUID, input *models.CreateAuditLogInput) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.auditLog = append(s.d.auditLog, AuditEntry{
+		OrganizationID:      orgID,
+		CreateAuditLogInput: *input,
+		CreatedAt:           time.Now(),
+	})
+	return nil
+}
@@ -0,0 +1,202 @@
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// CommentStore keeps ticket comments in memory
+type CommentStore struct {
+	d *data
+}
+
+// Create adds a comment to a ticket. A reply's parent must be a comment on
+// the same ticket that hasn't been deleted.
+func (s *CommentStore) Create(ctx context.Context, orgID, ticketID, authorID uuid.UUID, input *models.CreateCommentInput) (*models.Comment, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if input.ParentID != nil {
+		parent, err := s.d.comment(orgID, *input.ParentID)
+		if err != nil || parent.TicketID != ticketID {
+			return nil, &models.ValidationError{Field: "parent_comment_id", Message: "parent comment not found"}
+		}
+	}
+
+	now := time.Now()
+	comment := &models.Comment{
+		ID:             uuid.New(),
+		TicketID:       ticketID,
+		OrganizationID: orgID,
+		ParentID:       input.ParentID,
+		AuthorID:       authorID,
+		Comment:        input.Comment,
+		IsInternal:     input.IsInternal,
+		MentionedUsers: input.MentionedUsers,
+		AttachmentURLs: input.AttachmentURLs,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.d.comments = append(s.d.comments, comment)
+
+	created := *comment
+	return &created, nil
+}
+
+// comment returns a comment that hasn't been deleted
+func (d *data) comment(orgID, commentID uuid.UUID) (*models.Comment, error) {
+	for _, c := range d.comments {
+		if c.ID == commentID && c.OrganizationID == orgID && c.DeletedAt == nil {
+			return c, nil
+		}
+	}
+	return nil, models.NotFound("comment not found")
+}
+
+// Get retrieves a comment that hasn't been deleted
+func (s *CommentStore) Get(ctx context.Context, orgID, commentID uuid.UUID) (*models.Comment, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, err := s.d.comment(orgID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	found := *c
+	return &found, nil
+}
+
+// ListByTicket retrieves a ticket's comments, oldest first. A deleted
+// comment is kept, with its text replaced by models.DeletedCommentText,
+// while it has replies that aren't deleted. Users aren't kept here, so
+// Author is left unset.
+func (s *CommentStore) ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Comment, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	liveReplies := make(map[uuid.UUID]bool)
+	for _, c := range s.d.comments {
+		if c.ParentID != nil && c.DeletedAt == nil {
+			liveReplies[*c.ParentID] = true
+		}
+	}
+
+	var comments []models.Comment
+	for _, c := range s.d.comments {
+		if c.OrganizationID != orgID || c.TicketID != ticketID {
+			continue
+		}
+		if c.DeletedAt != nil {
+			if !liveReplies[c.ID] {
+				continue
+			}
+			deleted := *c
+			deleted.Comment = models.DeletedCommentText
+			deleted.MentionedUsers = nil
+			deleted.AttachmentURLs = nil
+			deleted.EditHistory = nil
+			comments = append(comments, deleted)
+			continue
+		}
+		comments = append(comments, *c)
+	}
+	return comments, nil
+}
+
+// LatestByTickets retrieves up to limit of the most recent comments on each
+// of several tickets, oldest first within each ticket. Deleted comments are
+// left out.
+func (s *CommentStore) LatestByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID, limit int) ([]models.Comment, error) {
+	comments, err := s.ListByTickets(ctx, orgID, ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest []models.Comment
+	for start := 0; start < len(comments); {
+		end := start
+		for end < len(comments) && comments[end].TicketID == comments[start].TicketID {
+			end++
+		}
+		from := start
+		if end-start > limit {
+			from = end - limit
+		}
+		latest = append(latest, comments[from:end]...)
+		start = end
+	}
+	return latest, nil
+}
+
+// Update replaces a comment's text, keeping the text it replaces in the
+// comment's edit history
+func (s *CommentStore) Update(ctx context.Context, orgID, commentID uuid.UUID, text string) (*models.Comment, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, err := s.d.comment(orgID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var history []map[string]interface{}
+	if len(c.EditHistory) > 0 {
+		json.Unmarshal(c.EditHistory, &history)
+	}
+	history = append(history, map[string]interface{}{
+		"previous_comment": c.Comment,
+		"edited_at":        now,
+	})
+	c.EditHistory, _ = json.Marshal(history)
+	c.Comment = text
+	c.Edited = true
+	c.UpdatedAt = now
+
+	updated := *c
+	return &updated, nil
+}
+
+// Delete soft-deletes a comment. Its replies stay in the thread.
+func (s *CommentStore) Delete(ctx context.Context, orgID, commentID uuid.UUID) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	c, err := s.d.comment(orgID, commentID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	c.DeletedAt = &now
+	c.UpdatedAt = now
+	return nil
+}
+
+// ListByTickets retrieves the comments on several tickets at once, oldest
+// first within each ticket. Deleted comments are left out.
+func (s *CommentStore) ListByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Comment, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(ticketIDs))
+	for _, id := range ticketIDs {
+		wanted[id] = true
+	}
+
+	var comments []models.Comment
+	for _, c := range s.d.comments {
+		if c.OrganizationID == orgID && wanted[c.TicketID] && c.DeletedAt == nil {
+			comments = append(comments, *c)
+		}
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].TicketID.String() < comments[j].TicketID.String()
+	})
+	return comments, nil
+}
@@ -0,0 +1,62 @@
+// Package memstore keeps the ticket, repository, audit and comment stores
+// in memory, so handlers can be tested without Postgres.
+//
+// The stores follow the SQL stores closely enough for handlers: errors carry
+// the same messages, tickets are versioned and trashed the same way, and
+// status changes go through the default workflow. There are no ACLs, so
+// confidential tickets are visible to everyone, and no ticket revisions or
+// organization workflows are kept.
+package memstore
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// New returns a store whose ticket, repository, audit and comment stores
+// keep their data in memory. Its other stores are nil, and it has no
+// database: WithTx runs without a transaction and BeginTx fails.
+func New() *store.Store {
+	d := &data{
+		tickets:      make(map[uuid.UUID]*ticketRow),
+		affected:     make(map[uuid.UUID][]models.AffectedResource),
+		repositories: make(map[uuid.UUID]*models.Repository),
+		syncTokens:   make(map[syncTokenKey]*models.RepositorySyncToken),
+		archives:     make(map[string]models.AuditArchive),
+	}
+	return &store.Store{
+		Tickets:      &TicketStore{d: d},
+		Repositories: &RepositoryStore{d: d},
+		Audit:        &AuditStore{d: d},
+		Comments:     &CommentStore{d: d},
+	}
+}
+
+// data holds the tables of the stores from one New, which read each other's
+// rows the way the SQL stores join them
+type data struct {
+	mu sync.Mutex
+
+	tickets  map[uuid.UUID]*ticketRow
+	affected map[uuid.UUID][]models.AffectedResource
+
+	repositories map[uuid.UUID]*models.Repository
+	ticketRepos  []models.TicketRepository
+	syncTokens   map[syncTokenKey]*models.RepositorySyncToken
+
+	ticketAudit []models.TicketAuditLog
+	auditLog    []AuditEntry
+	archives    map[string]models.AuditArchive
+
+	comments []*models.Comment // In the order they were created
+}
+
+var (
+	_ store.TicketStorer     = (*TicketStore)(nil)
+	_ store.RepositoryStorer = (*RepositoryStore)(nil)
+	_ store.AuditStorer      = (*AuditStore)(nil)
+	_ store.CommentStorer    = (*CommentStore)(nil)
+)
@@ -0,0 +1,473 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// RepositoryStore keeps repositories, their sync tokens and their links to
+// tickets in memory
+type RepositoryStore struct {
+	d *data
+}
+
+type syncTokenKey struct {
+	orgID    uuid.UUID
+	provider models.RepositoryProvider
+}
+
+// Create creates a new repository
+func (s *RepositoryStore) Create(ctx context.Context, orgID uuid.UUID, input *models.CreateRepositoryInput) (*models.Repository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := time.Now()
+	repo := &models.Repository{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Name:           input.Name,
+		URL:            input.URL,
+		Provider:       input.Provider,
+		OwnerUserID:    input.OwnerUserID,
+		OwnerGroupID:   input.OwnerGroupID,
+		DefaultBranch:  "main",
+		IsActive:       true,
+		IsPrivate:      true,
+		Description:    input.Description,
+		Language:       input.Language,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if input.DefaultBranch != "" {
+		repo.DefaultBranch = input.DefaultBranch
+	}
+	if input.IsPrivate != nil {
+		repo.IsPrivate = *input.IsPrivate
+	}
+	s.d.repositories[repo.ID] = repo
+
+	created := *repo
+	return &created, nil
+}
+
+// repository returns one of the organization's repositories
+func (d *data) repository(orgID, repoID uuid.UUID) (*models.Repository, error) {
+	repo, ok := d.repositories[repoID]
+	if !ok || repo.OrganizationID != orgID {
+		return nil, models.NotFound("repository not found")
+	}
+	return repo, nil
+}
+
+// GetByID retrieves a repository by ID
+func (s *RepositoryStore) GetByID(ctx context.Context, orgID, repoID uuid.UUID) (*models.Repository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	repo, err := s.d.repository(orgID, repoID)
+	if err != nil {
+		return nil, err
+	}
+	found := *repo
+	return &found, nil
+}
+
+// GetByURL retrieves a repository by URL
+func (s *RepositoryStore) GetByURL(ctx context.Context, orgID uuid.UUID, url string) (*models.Repository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for _, repo := range s.d.repositories {
+		if repo.OrganizationID == orgID && repo.URL == url {
+			found := *repo
+			return &found, nil
+		}
+	}
+	return nil, models.NotFound("repository not found")
+}
+
+// List retrieves repositories with filtering, paging by offset or, with
+// filter.Cursor set, by keyset
+func (s *RepositoryStore) List(ctx context.Context, orgID uuid.UUID, filter *models.RepositoryListFilter) ([]models.Repository, int, error) {
+	filter.SetDefaults()
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	search := strings.ToLower(filter.Search)
+	var repos []models.Repository
+	for _, r := range s.d.repositories {
+		switch {
+		case r.OrganizationID != orgID,
+			filter.Provider != nil && r.Provider != *filter.Provider,
+			!sameUUID(filter.OwnerUserID, r.OwnerUserID),
+			!sameUUID(filter.OwnerGroupID, r.OwnerGroupID),
+			filter.IsActive != nil && r.IsActive != *filter.IsActive,
+			search != "" && !strings.Contains(strings.ToLower(r.Name), search) && !strings.Contains(strings.ToLower(r.URL), search):
+			continue
+		}
+		repos = append(repos, *r)
+	}
+	total := len(repos)
+
+	sortBy := "name"
+	if filter.SortBy == "created_at" || filter.SortBy == "updated_at" {
+		sortBy = filter.SortBy
+	}
+	desc := filter.SortOrder == "desc"
+
+	sort.Slice(repos, func(i, j int) bool {
+		c := compareRepositories(&repos[i], &repos[j], sortBy)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	offset := filter.Offset()
+	if filter.Cursor != "" {
+		cursor, err := models.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		if cursor.SortBy != sortBy {
+			return nil, 0, &models.ValidationError{Field: "cursor", Message: "cursor does not match sort_by"}
+		}
+		offset = len(repos)
+		for i := range repos {
+			c := compareRepositoryToCursor(&repos[i], sortBy, cursor)
+			if (desc && c < 0) || (!desc && c > 0) {
+				offset = i
+				break
+			}
+		}
+	}
+
+	if offset > len(repos) {
+		offset = len(repos)
+	}
+	repos = repos[offset:]
+
+	filter.NextCursor = ""
+	if len(repos) > filter.PerPage {
+		repos = repos[:filter.PerPage]
+		last := &repos[len(repos)-1]
+		value := last.Name
+		switch sortBy {
+		case "created_at":
+			value = last.CreatedAt.Format(time.RFC3339Nano)
+		case "updated_at":
+			value = last.UpdatedAt.Format(time.RFC3339Nano)
+		}
+		filter.NextCursor = (&models.Cursor{SortBy: sortBy, Value: value, ID: last.ID}).Encode()
+	}
+
+	return repos, total, nil
+}
+
+// compareRepositories orders repositories by the sort column, then by ID
+func compareRepositories(a, b *models.Repository, sortBy string) int {
+	var c int
+	switch sortBy {
+	case "created_at":
+		c = compareTimes(a.CreatedAt, b.CreatedAt)
+	case "updated_at":
+		c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+	default:
+		c = strings.Compare(a.Name, b.Name)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID.String(), b.ID.String())
+}
+
+// compareRepositoryToCursor orders a repository against the row a cursor
+// was taken from
+func compareRepositoryToCursor(r *models.Repository, sortBy string, cursor *models.Cursor) int {
+	var c int
+	switch sortBy {
+	case "created_at", "updated_at":
+		at, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return 1
+		}
+		value := r.CreatedAt
+		if sortBy == "updated_at" {
+			value = r.UpdatedAt
+		}
+		c = compareTimes(value, at)
+	default:
+		c = strings.Compare(r.Name, cursor.Value)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(r.ID.String(), cursor.ID.String())
+}
+
+// Update updates a repository
+func (s *RepositoryStore) Update(ctx context.Context, orgID, repoID uuid.UUID, input *models.UpdateRepositoryInput) (*models.Repository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	repo, err := s.d.repository(orgID, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	if input.Name != nil {
+		repo.Name = *input.Name
+		changed = true
+	}
+	if input.URL != nil {
+		repo.URL = *input.URL
+		changed = true
+	}
+	if input.Provider != nil {
+		repo.Provider = *input.Provider
+		changed = true
+	}
+	if input.OwnerUserID != nil {
+		repo.OwnerUserID = uuidPtr(*input.OwnerUserID)
+		changed = true
+	}
+	if input.OwnerGroupID != nil {
+		repo.OwnerGroupID = uuidPtr(*input.OwnerGroupID)
+		changed = true
+	}
+	if input.IsActive != nil {
+		repo.IsActive = *input.IsActive
+		changed = true
+	}
+	if input.IsPrivate != nil {
+		repo.IsPrivate = *input.IsPrivate
+		changed = true
+	}
+	if input.Description != nil {
+		description := *input.Description
+		repo.Description = &description
+		changed = true
+	}
+	if changed {
+		repo.UpdatedAt = time.Now()
+	}
+
+	updated := *repo
+	return &updated, nil
+}
+
+// Delete deactivates a repository
+func (s *RepositoryStore) Delete(ctx context.Context, orgID, repoID uuid.UUID) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if repo, err := s.d.repository(orgID, repoID); err == nil {
+		repo.IsActive = false
+		repo.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// ApplySync writes the details read from the provider, records the sync
+// time and clears any earlier sync error
+func (s *RepositoryStore) ApplySync(ctx context.Context, orgID, repoID uuid.UUID, details *models.RepositoryDetails) (*models.Repository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	repo, err := s.d.repository(orgID, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	repo.Name = details.Name
+	repo.DefaultBranch = details.DefaultBranch
+	repo.Language = details.Language
+	repo.IsPrivate = details.IsPrivate
+	repo.Description = details.Description
+	repo.LastSyncedAt = &now
+	repo.LastSyncError = nil
+	repo.UpdatedAt = now
+
+	synced := *repo
+	return &synced, nil
+}
+
+// RecordSyncError records why a repository's last sync failed
+func (s *RepositoryStore) RecordSyncError(ctx context.Context, orgID, repoID uuid.UUID, message string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if repo, err := s.d.repository(orgID, repoID); err == nil {
+		repo.LastSyncError = &message
+	}
+	return nil
+}
+
+// ListSyncable retrieves an organization's active repositories on providers
+// it has a sync token for, least recently synced first
+func (s *RepositoryStore) ListSyncable(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Repository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var repos []models.Repository
+	for _, r := range s.d.repositories {
+		if r.OrganizationID != orgID || !r.IsActive {
+			continue
+		}
+		if _, ok := s.d.syncTokens[syncTokenKey{orgID, r.Provider}]; ok {
+			repos = append(repos, *r)
+		}
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		a, b := repos[i].LastSyncedAt, repos[j].LastSyncedAt
+		switch {
+		case a == nil && b == nil:
+			return repos[i].ID.String() < repos[j].ID.String()
+		case a == nil || b == nil:
+			return a == nil
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return repos[i].ID.String() < repos[j].ID.String()
+	})
+	if len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, nil
+}
+
+// GetSyncToken retrieves an organization's sync token for a provider
+func (s *RepositoryStore) GetSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) (*models.RepositorySyncToken, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	token, ok := s.d.syncTokens[syncTokenKey{orgID, provider}]
+	if !ok {
+		return nil, models.ErrSyncTokenNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+// ListSyncTokens retrieves an organization's sync tokens
+func (s *RepositoryStore) ListSyncTokens(ctx context.Context, orgID uuid.UUID) ([]models.RepositorySyncToken, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	tokens := []models.RepositorySyncToken{}
+	for key, token := range s.d.syncTokens {
+		if key.orgID == orgID {
+			tokens = append(tokens, *token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Provider < tokens[j].Provider
+	})
+	return tokens, nil
+}
+
+// SetSyncToken creates or replaces an organization's sync token for a provider
+func (s *RepositoryStore) SetSyncToken(ctx context.Context, orgID, userID uuid.UUID, provider models.RepositoryProvider, input *models.UpdateRepositorySyncTokenInput) (*models.RepositorySyncToken, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	baseURL := provider.DefaultAPIBaseURL()
+	if input.APIBaseURL != nil {
+		baseURL = *input.APIBaseURL
+	}
+
+	now := time.Now()
+	key := syncTokenKey{orgID, provider}
+	token, ok := s.d.syncTokens[key]
+	if !ok {
+		token = &models.RepositorySyncToken{OrganizationID: orgID, Provider: provider, CreatedAt: now}
+		s.d.syncTokens[key] = token
+	}
+	token.APIBaseURL = baseURL
+	token.APIToken = input.APIToken
+	token.UpdatedBy = &userID
+	token.UpdatedAt = now
+
+	set := *token
+	return &set, nil
+}
+
+// DeleteSyncToken removes an organization's sync token for a provider
+func (s *RepositoryStore) DeleteSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	key := syncTokenKey{orgID, provider}
+	if _, ok := s.d.syncTokens[key]; !ok {
+		return models.ErrSyncTokenNotFound
+	}
+	delete(s.d.syncTokens, key)
+	return nil
+}
+
+// ListSyncOrganizations returns the organizations with at least one sync
+// token, for the sync job
+func (s *RepositoryStore) ListSyncOrganizations(ctx context.Context) ([]uuid.UUID, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for key := range s.d.syncTokens {
+		if !seen[key.orgID] {
+			seen[key.orgID] = true
+			ids = append(ids, key.orgID)
+		}
+	}
+	return ids, nil
+}
+
+// GetTicketRepositories retrieves repositories linked to a ticket
+func (s *RepositoryStore) GetTicketRepositories(ctx context.Context, ticketID uuid.UUID) ([]models.TicketRepository, error) {
+	return s.ListTicketRepositories(ctx, []uuid.UUID{ticketID})
+}
+
+// ListTicketRepositories retrieves the repositories linked to several
+// tickets at once, newest link first within each ticket
+func (s *RepositoryStore) ListTicketRepositories(ctx context.Context, ticketIDs []uuid.UUID) ([]models.TicketRepository, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(ticketIDs))
+	for _, id := range ticketIDs {
+		wanted[id] = true
+	}
+
+	var links []models.TicketRepository
+	for _, link := range s.d.ticketRepos {
+		repo, ok := s.d.repositories[link.RepositoryID]
+		if !wanted[link.TicketID] || !ok {
+			continue
+		}
+		link.Repository = &models.RepositorySummary{
+			ID:       repo.ID,
+			Name:     repo.Name,
+			URL:      repo.URL,
+			Provider: repo.Provider,
+			IsActive: repo.IsActive,
+		}
+		links = append(links, link)
+	}
+
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].TicketID != links[j].TicketID {
+			return links[i].TicketID.String() < links[j].TicketID.String()
+		}
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+	return links, nil
+}
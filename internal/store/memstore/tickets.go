@@ -0,0 +1,1096 @@
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+// TicketStore keeps tickets in memory
+type TicketStore struct {
+	d *data
+}
+
+// ticketRow is a ticket with the trash columns the model doesn't carry
+type ticketRow struct {
+	ticket    models.Ticket
+	deletedBy *uuid.UUID
+	purgedAt  *time.Time
+}
+
+var ticketNumberDigits = regexp.MustCompile(`[0-9]+$`)
+
+// nextTicketNumber numbers a ticket the way generate_ticket_number does,
+// continuing from the organization's highest number opened in the year
+func (d *data) nextTicketNumber(orgID uuid.UUID, year int) string {
+	next := 1
+	for _, row := range d.tickets {
+		t := &row.ticket
+		if t.OrganizationID != orgID || t.CreatedAt.Year() != year {
+			continue
+		}
+		if n, err := strconv.Atoi(ticketNumberDigits.FindString(t.TicketNumber)); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return fmt.Sprintf("CHG-%d-%05d", year, next)
+}
+
+// liveTicket returns a ticket that hasn't been deleted
+func (d *data) liveTicket(orgID, ticketID uuid.UUID) (*ticketRow, error) {
+	row, ok := d.tickets[ticketID]
+	if !ok || row.ticket.OrganizationID != orgID || row.ticket.DeletedAt != nil {
+		return nil, models.NotFound("ticket not found")
+	}
+	return row, nil
+}
+
+// Create creates a new ticket
+func (s *TicketStore) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateTicketInput) (*models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := time.Now()
+	ticket := models.Ticket{
+		ID:                          uuid.New(),
+		OrganizationID:              orgID,
+		TicketNumber:                s.d.nextTicketNumber(orgID, now.Year()),
+		CreatedBy:                   userID,
+		Title:                       input.Title,
+		Description:                 input.Description,
+		Status:                      models.TicketStatusDraft,
+		Priority:                    input.Priority,
+		RiskLevel:                   input.RiskLevel,
+		Industry:                    input.Industry,
+		ComplianceFrameworks:        input.ComplianceFrameworks,
+		ComplianceNotes:             input.ComplianceNotes,
+		ChangeType:                  input.ChangeType,
+		AffectedSystems:             input.AffectedSystems,
+		AffectedDataTypes:           input.AffectedDataTypes,
+		ImpactDescription:           input.ImpactDescription,
+		RollbackPlan:                input.RollbackPlan,
+		TestingPlan:                 input.TestingPlan,
+		RequestedImplementationDate: input.RequestedImplementationDate,
+		RequiresApprovalTypes:       input.RequiresApprovalTypes,
+		ApprovalDeadline:            input.ApprovalDeadline,
+		CustomFields:                input.CustomFields,
+		Version:                     1,
+		CreatedAt:                   now,
+		UpdatedAt:                   now,
+		ProjectID:                   input.ProjectID,
+		OwningGroupID:               input.OwningGroupID,
+		CustomerID:                  input.CustomerID,
+		ParentTicketID:              input.ParentTicketID,
+		EpicID:                      input.EpicID,
+		SprintID:                    input.SprintID,
+		StoryPoints:                 input.StoryPoints,
+		TimeEstimateHours:           input.TimeEstimateHours,
+		Labels:                      input.Labels,
+		Watchers:                    input.Watchers,
+		ExternalReference:           input.ExternalReference,
+		ACLInheritance:              true,
+		IsConfidential:              input.IsConfidential,
+	}
+	s.d.score(&ticket)
+	s.d.tickets[ticket.ID] = &ticketRow{ticket: ticket}
+
+	return &ticket, nil
+}
+
+// GetNumberByExternalReference returns the number of the ticket carrying an
+// external reference, including deleted tickets, or "" if there is none
+func (s *TicketStore) GetNumberByExternalReference(ctx context.Context, orgID uuid.UUID, reference string) (string, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for _, row := range s.d.tickets {
+		t := &row.ticket
+		if t.OrganizationID == orgID && t.ExternalReference != nil && *t.ExternalReference == reference {
+			return t.TicketNumber, nil
+		}
+	}
+	return "", nil
+}
+
+// Import creates a ticket brought in from another change system, keeping
+// its status and timestamps
+func (s *TicketStore) Import(ctx context.Context, orgID, userID uuid.UUID, input *models.ImportTicketInput) (*models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if input.ExternalReference != nil {
+		for _, row := range s.d.tickets {
+			ref := row.ticket.ExternalReference
+			if row.ticket.OrganizationID == orgID && ref != nil && *ref == *input.ExternalReference {
+				return nil, models.Conflict("ticket already imported")
+			}
+		}
+	}
+
+	ticket := models.Ticket{
+		ID:                          uuid.New(),
+		OrganizationID:              orgID,
+		TicketNumber:                s.d.nextTicketNumber(orgID, input.CreatedAt.Year()),
+		CreatedBy:                   userID,
+		Title:                       input.Title,
+		Description:                 input.Description,
+		Status:                      input.Status,
+		Priority:                    input.Priority,
+		RiskLevel:                   input.RiskLevel,
+		Industry:                    input.Industry,
+		ComplianceFrameworks:        input.ComplianceFrameworks,
+		ComplianceNotes:             input.ComplianceNotes,
+		ChangeType:                  input.ChangeType,
+		AffectedSystems:             input.AffectedSystems,
+		AffectedDataTypes:           input.AffectedDataTypes,
+		ImpactDescription:           input.ImpactDescription,
+		RollbackPlan:                input.RollbackPlan,
+		TestingPlan:                 input.TestingPlan,
+		RequestedImplementationDate: input.RequestedImplementationDate,
+		ScheduledStart:              input.ScheduledStart,
+		ScheduledEnd:                input.ScheduledEnd,
+		ActualStart:                 input.ActualStart,
+		ActualEnd:                   input.ActualEnd,
+		RequiresApprovalTypes:       input.RequiresApprovalTypes,
+		CustomFields:                input.CustomFields,
+		Version:                     1,
+		CreatedAt:                   input.CreatedAt,
+		UpdatedAt:                   input.UpdatedAt,
+		ClosedAt:                    input.ClosedAt,
+		ExternalReference:           input.ExternalReference,
+		ACLInheritance:              true,
+		IsConfidential:              input.IsConfidential,
+		IsEmergency:                 input.IsEmergency,
+	}
+	s.d.score(&ticket)
+	s.d.tickets[ticket.ID] = &ticketRow{ticket: ticket}
+
+	return &ticket, nil
+}
+
+// GetByID retrieves a ticket by ID
+func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, err := s.d.liveTicket(orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	ticket := row.ticket
+	return &ticket, nil
+}
+
+// GetByIDs retrieves several tickets at once, leaving out those that don't
+// exist
+func (s *TicketStore) GetByIDs(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var tickets []models.Ticket
+	for _, id := range ticketIDs {
+		if row, err := s.d.liveTicket(orgID, id); err == nil {
+			tickets = append(tickets, row.ticket)
+		}
+	}
+	return tickets, nil
+}
+
+// ListScheduled retrieves approved and implementing tickets whose scheduled
+// window overlaps [from, to], soonest first. With involving set, only
+// tickets the user created, is assigned to or watches are listed; approvals
+// aren't kept here.
+func (s *TicketStore) ListScheduled(ctx context.Context, orgID uuid.UUID, involving *uuid.UUID, from, to time.Time, limit int) ([]models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var tickets []models.Ticket
+	for _, row := range s.d.tickets {
+		t := &row.ticket
+		if t.OrganizationID != orgID || t.DeletedAt != nil || t.ScheduledStart == nil {
+			continue
+		}
+		if t.Status != models.TicketStatusApproved && t.Status != models.TicketStatusImplementing {
+			continue
+		}
+		end := t.ScheduledStart
+		if t.ScheduledEnd != nil {
+			end = t.ScheduledEnd
+		}
+		if t.ScheduledStart.After(to) || end.Before(from) {
+			continue
+		}
+		if involving != nil && !involves(t, *involving) {
+			continue
+		}
+		tickets = append(tickets, *t)
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		a, b := tickets[i], tickets[j]
+		if !a.ScheduledStart.Equal(*b.ScheduledStart) {
+			return a.ScheduledStart.Before(*b.ScheduledStart)
+		}
+		return a.TicketNumber < b.TicketNumber
+	})
+	if len(tickets) > limit {
+		tickets = tickets[:limit]
+	}
+	return tickets, nil
+}
+
+func involves(t *models.Ticket, userID uuid.UUID) bool {
+	if t.CreatedBy == userID || (t.AssignedTo != nil && *t.AssignedTo == userID) {
+		return true
+	}
+	for _, w := range t.Watchers {
+		if w == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByNumber retrieves a ticket by ticket number
+func (s *TicketStore) GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNumber string) (*models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	for _, row := range s.d.tickets {
+		t := row.ticket
+		if t.OrganizationID == orgID && t.TicketNumber == ticketNumber && t.DeletedAt == nil {
+			return &t, nil
+		}
+	}
+	return nil, models.NotFound("ticket not found")
+}
+
+// List retrieves tickets with filtering, paging by offset or, with
+// filter.Cursor set, by keyset. Priority and status sort as text.
+func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.TicketListFilter) ([]models.Ticket, int, error) {
+	filter.SetDefaults()
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	var tickets []models.Ticket
+	for _, row := range s.d.tickets {
+		t := &row.ticket
+		if t.OrganizationID == orgID && t.DeletedAt == nil && s.d.matches(t, filter) {
+			tickets = append(tickets, *t)
+		}
+	}
+	total := len(tickets)
+
+	validSortFields := map[string]bool{
+		"created_at": true, "updated_at": true, "priority": true,
+		"status": true, "ticket_number": true, "title": true,
+	}
+	sortBy := "created_at"
+	if validSortFields[filter.SortBy] {
+		sortBy = filter.SortBy
+	}
+	desc := filter.SortOrder != "asc"
+
+	sort.Slice(tickets, func(i, j int) bool {
+		c := compareTickets(&tickets[i], &tickets[j], sortBy)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	offset := filter.Offset()
+	if filter.Cursor != "" {
+		cursor, err := models.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		if cursor.SortBy != sortBy {
+			return nil, 0, &models.ValidationError{Field: "cursor", Message: "cursor does not match sort_by"}
+		}
+		offset = len(tickets)
+		for i := range tickets {
+			c := compareToCursor(&tickets[i], sortBy, cursor)
+			if (desc && c < 0) || (!desc && c > 0) {
+				offset = i
+				break
+			}
+		}
+	}
+
+	if offset > len(tickets) {
+		offset = len(tickets)
+	}
+	tickets = tickets[offset:]
+
+	filter.NextCursor = ""
+	if len(tickets) > filter.PerPage {
+		tickets = tickets[:filter.PerPage]
+		last := &tickets[len(tickets)-1]
+		filter.NextCursor = (&models.Cursor{SortBy: sortBy, Value: ticketSortValue(last, sortBy), ID: last.ID}).Encode()
+	}
+
+	return tickets, total, nil
+}
+
+// matches applies the list filters the SQL store supports
+func (d *data) matches(t *models.Ticket, filter *models.TicketListFilter) bool {
+	if len(filter.Status) > 0 && !containsStatus(filter.Status, t.Status) {
+		return false
+	}
+	if len(filter.Priority) > 0 && !containsPriority(filter.Priority, t.Priority) {
+		return false
+	}
+	if len(filter.RiskLevel) > 0 && !containsRiskLevel(filter.RiskLevel, t.RiskLevel) {
+		return false
+	}
+	if filter.CreatedBy != nil && t.CreatedBy != *filter.CreatedBy {
+		return false
+	}
+	if !sameUUID(filter.AssignedTo, t.AssignedTo) || !sameUUID(filter.ProjectID, t.ProjectID) ||
+		!sameUUID(filter.OwningGroupID, t.OwningGroupID) || !sameUUID(filter.CustomerID, t.CustomerID) ||
+		!sameUUID(filter.EpicID, t.EpicID) || !sameUUID(filter.SprintID, t.SprintID) {
+		return false
+	}
+	for _, label := range filter.Labels {
+		if !containsString(t.Labels, label) {
+			return false
+		}
+	}
+	if filter.IsConfidential != nil && t.IsConfidential != *filter.IsConfidential {
+		return false
+	}
+	if filter.Host != "" {
+		found := false
+		for _, r := range d.affected[t.ID] {
+			if strings.EqualFold(r.Hostname, filter.Host) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.NeedsAssignment {
+		if t.AssignedTo != nil {
+			return false
+		}
+		switch t.Status {
+		case models.TicketStatusSubmitted, models.TicketStatusInReview, models.TicketStatusUpdateRequested:
+		default:
+			return false
+		}
+	}
+	if filter.Search != "" && searchRank(t, filter.Search) == 0 && !strings.EqualFold(t.TicketNumber, filter.Search) {
+		return false
+	}
+	return true
+}
+
+// Search finds tickets containing every word of the query in their number,
+// title or description. It approximates the SQL store's full-text search:
+// the rank is the number of times the words occur, and there is no
+// stemming or query syntax.
+func (s *TicketStore) Search(ctx context.Context, orgID uuid.UUID, query string, limit int) ([]models.TicketSearchResult, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	results := []models.TicketSearchResult{}
+	for _, row := range s.d.tickets {
+		t := &row.ticket
+		if t.OrganizationID != orgID || t.DeletedAt != nil {
+			continue
+		}
+		if rank := searchRank(t, query); rank > 0 {
+			results = append(results, models.TicketSearchResult{
+				Ticket:   t.ToSummary(),
+				Rank:     rank,
+				Headline: searchHeadline(t.Description, query),
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Ticket.UpdatedAt.After(results[j].Ticket.UpdatedAt)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// searchRank counts the occurrences of the query's words in a ticket, or
+// returns 0 if any word is missing
+func searchRank(t *models.Ticket, query string) float64 {
+	text := strings.ToLower(t.TicketNumber + " " + t.Title + " " + t.Description)
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return 0
+	}
+	var rank float64
+	for _, w := range words {
+		n := strings.Count(text, w)
+		if n == 0 {
+			return 0
+		}
+		rank += float64(n)
+	}
+	return rank
+}
+
+// searchHeadline returns the description around the first matched word
+// with the matches in <b></b>, like ts_headline
+func searchHeadline(description, query string) string {
+	words := strings.Fields(description)
+	start := -1
+	for i, w := range words {
+		for _, q := range strings.Fields(query) {
+			if strings.Contains(strings.ToLower(w), strings.ToLower(q)) {
+				if start < 0 {
+					start = i
+				}
+				words[i] = "<b>" + w + "</b>"
+				break
+			}
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + 20
+	if end > len(words) {
+		end = len(words)
+	}
+	return strings.Join(words[start:end], " ")
+}
+
+// sameUUID reports whether a filter on an optional column matches
+func sameUUID(filter, value *uuid.UUID) bool {
+	return filter == nil || (value != nil && *value == *filter)
+}
+
+func containsStatus(list []models.TicketStatus, v models.TicketStatus) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPriority(list []models.TicketPriority, v models.TicketPriority) bool {
+	for _, p := range list {
+		if p == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRiskLevel(list []models.RiskLevel, v models.RiskLevel) bool {
+	for _, r := range list {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// compareTickets orders tickets by the sort column, then by ID
+func compareTickets(a, b *models.Ticket, sortBy string) int {
+	var c int
+	switch sortBy {
+	case "created_at":
+		c = compareTimes(a.CreatedAt, b.CreatedAt)
+	case "updated_at":
+		c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+	default:
+		c = strings.Compare(ticketSortValue(a, sortBy), ticketSortValue(b, sortBy))
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID.String(), b.ID.String())
+}
+
+// compareToCursor orders a ticket against the row a cursor was taken from
+func compareToCursor(t *models.Ticket, sortBy string, cursor *models.Cursor) int {
+	var c int
+	switch sortBy {
+	case "created_at", "updated_at":
+		at, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return 1
+		}
+		value := t.CreatedAt
+		if sortBy == "updated_at" {
+			value = t.UpdatedAt
+		}
+		c = compareTimes(value, at)
+	default:
+		c = strings.Compare(ticketSortValue(t, sortBy), cursor.Value)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(t.ID.String(), cursor.ID.String())
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// ticketSortValue returns the value of a ticket's sort column as it goes in
+// a cursor
+func ticketSortValue(t *models.Ticket, sortBy string) string {
+	switch sortBy {
+	case "updated_at":
+		return t.UpdatedAt.Format(time.RFC3339Nano)
+	case "priority":
+		return string(t.Priority)
+	case "status":
+		return string(t.Status)
+	case "ticket_number":
+		return t.TicketNumber
+	case "title":
+		return t.Title
+	}
+	return t.CreatedAt.Format(time.RFC3339Nano)
+}
+
+// Update updates a ticket's editable fields, bumping its version
+func (s *TicketStore) Update(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error) {
+	if input.Version == nil {
+		return nil, models.ErrVersionRequired
+	}
+
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, err := s.d.liveTicket(orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	t := &row.ticket
+
+	if *input.Version != t.Version {
+		return nil, &models.VersionConflictError{
+			ExpectedVersion: *input.Version,
+			CurrentVersion:  t.Version,
+			Conflicts:       input.Conflicts(t),
+		}
+	}
+
+	if !t.CanEdit() {
+		return nil, models.InvalidTransition("ticket cannot be edited in current status")
+	}
+
+	changed := false
+	if input.Title != nil {
+		t.Title = *input.Title
+		changed = true
+	}
+	if input.Description != nil {
+		t.Description = *input.Description
+		changed = true
+	}
+	if input.Priority != nil {
+		t.Priority = *input.Priority
+		changed = true
+	}
+	if input.RiskLevel != nil {
+		t.RiskLevel = *input.RiskLevel
+		changed = true
+	}
+	if input.AssignedTo != nil {
+		t.AssignedTo = uuidPtr(*input.AssignedTo)
+		changed = true
+	}
+	if input.ProjectID != nil {
+		t.ProjectID = uuidPtr(*input.ProjectID)
+		changed = true
+	}
+	if input.OwningGroupID != nil {
+		t.OwningGroupID = uuidPtr(*input.OwningGroupID)
+		changed = true
+	}
+	// uuid.Nil unlinks the ticket
+	if input.EpicID != nil {
+		t.EpicID = nullableUUID(*input.EpicID)
+		changed = true
+	}
+	if input.SprintID != nil {
+		t.SprintID = nullableUUID(*input.SprintID)
+		changed = true
+	}
+	if input.StoryPoints != nil {
+		points := *input.StoryPoints
+		t.StoryPoints = &points
+		changed = true
+	}
+	if input.Labels != nil {
+		t.Labels = append([]string(nil), input.Labels...)
+		changed = true
+	}
+	if input.CustomFields != nil {
+		t.CustomFields = append(json.RawMessage(nil), input.CustomFields...)
+		changed = true
+	}
+	if input.IsConfidential != nil {
+		t.IsConfidential = *input.IsConfidential
+		changed = true
+	}
+	if input.ACLInheritance != nil {
+		t.ACLInheritance = *input.ACLInheritance
+		changed = true
+	}
+
+	if !changed {
+		unchanged := *t
+		return &unchanged, nil
+	}
+
+	t.Version++
+	t.UpdatedAt = time.Now()
+	s.d.score(t)
+	updated := *t
+	return &updated, nil
+}
+
+func uuidPtr(id uuid.UUID) *uuid.UUID {
+	return &id
+}
+
+func nullableUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+// checkVersion rejects a status transition based on a stale read. An
+// expected version of 0 skips the check for system callers.
+func checkVersion(t *models.Ticket, expected int, to models.TicketStatus) error {
+	if expected == 0 || expected == t.Version {
+		return nil
+	}
+	return &models.VersionConflictError{
+		ExpectedVersion: expected,
+		CurrentVersion:  t.Version,
+		Conflicts: map[string]models.FieldConflict{
+			"status": {Current: t.Status, Requested: to},
+		},
+	}
+}
+
+// transition moves a ticket to a status the default workflow allows, with
+// apply making any other changes, and returns the change made
+func (s *TicketStore) transition(ctx context.Context, orgID, ticketID uuid.UUID, to models.TicketStatus, expectedVersion int, apply func(t *models.Ticket)) (*models.TicketTransition, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, err := s.d.liveTicket(orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	t := &row.ticket
+
+	if err := checkVersion(t, expectedVersion, to); err != nil {
+		return nil, err
+	}
+	if err := models.DefaultTransitionEngine().Check(t, to, store.AccessorFrom(ctx)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from := t.Status
+	t.Status = to
+	if to == models.TicketStatusCompleted {
+		t.CompletedAt = &now
+	}
+	if apply != nil {
+		apply(t)
+	}
+	t.Version++
+	t.UpdatedAt = now
+
+	return &models.TicketTransition{From: from, To: to}, nil
+}
+
+// UpdateStatus moves a ticket to any status the default workflow allows
+func (s *TicketStore) UpdateStatus(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus, expectedVersion int) (*models.TicketTransition, error) {
+	if expectedVersion == 0 {
+		return nil, models.ErrVersionRequired
+	}
+	return s.transition(ctx, orgID, ticketID, status, expectedVersion, nil)
+}
+
+// Submit submits a ticket for approval with the plan evaluated from the
+// org's approval rules. Auto-approved plans move the ticket straight to
+// approved, and emergency tickets get a shortened approval deadline.
+func (s *TicketStore) Submit(ctx context.Context, orgID, ticketID, userID uuid.UUID, plan *models.ApprovalPlan, expectedVersion int) (*models.TicketTransition, error) {
+	status := models.TicketStatusSubmitted
+	if plan != nil && plan.AutoApprove {
+		status = models.TicketStatusApproved
+	}
+
+	return s.transition(ctx, orgID, ticketID, status, expectedVersion, func(t *models.Ticket) {
+		now := time.Now()
+		snapshot, _ := json.Marshal(t)
+		t.SubmittedAt = &now
+		t.SubmittedSnapshot = snapshot
+		t.ApprovalPlan, _ = json.Marshal(plan)
+		if t.IsEmergencyChange() {
+			t.IsEmergency = true
+			deadline := models.EmergencyApprovalDeadline(t.ApprovalDeadline, now)
+			t.ApprovalDeadline = &deadline
+		}
+	})
+}
+
+// Close closes a completed ticket
+func (s *TicketStore) Close(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	return s.transition(ctx, orgID, ticketID, models.TicketStatusClosed, expectedVersion, func(t *models.Ticket) {
+		now := time.Now()
+		t.ClosedAt = &now
+	})
+}
+
+// Cancel cancels a ticket, recording the reason
+func (s *TicketStore) Cancel(ctx context.Context, orgID, ticketID uuid.UUID, reason string, expectedVersion int) (*models.TicketTransition, error) {
+	return s.transition(ctx, orgID, ticketID, models.TicketStatusCancelled, expectedVersion, func(t *models.Ticket) {
+		t.DeletionReason = &reason
+	})
+}
+
+// Reopen sends a closed ticket back for updates
+func (s *TicketStore) Reopen(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	return s.transition(ctx, orgID, ticketID, models.TicketStatusUpdateRequested, expectedVersion, nil)
+}
+
+// Delete moves a ticket to the trash. A non-zero expected version rejects
+// the deletion if the ticket has been modified since it was read.
+func (s *TicketStore) Delete(ctx context.Context, orgID, ticketID, userID uuid.UUID, reason *string, expectedVersion int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, err := s.d.liveTicket(orgID, ticketID)
+	if err != nil {
+		return err
+	}
+	t := &row.ticket
+	if expectedVersion != 0 && expectedVersion != t.Version {
+		return &models.VersionConflictError{
+			ExpectedVersion: expectedVersion,
+			CurrentVersion:  t.Version,
+			Conflicts:       map[string]models.FieldConflict{},
+		}
+	}
+
+	now := time.Now()
+	t.DeletedAt = &now
+	row.deletedBy = &userID
+	if reason != nil {
+		t.DeletionReason = reason
+	}
+	t.Version++
+	t.UpdatedAt = now
+	return nil
+}
+
+// ListTrash retrieves an organization's soft-deleted tickets that have not
+// been purged, most recently deleted first. Users aren't kept here, so only
+// their IDs are filled in.
+func (s *TicketStore) ListTrash(ctx context.Context, orgID uuid.UUID, page, perPage int) ([]models.TrashedTicket, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	tickets := []models.TrashedTicket{}
+	for _, row := range s.d.tickets {
+		t := &row.ticket
+		if t.OrganizationID != orgID || t.DeletedAt == nil || row.purgedAt != nil {
+			continue
+		}
+		trashed := models.TrashedTicket{
+			ID:             t.ID,
+			TicketNumber:   t.TicketNumber,
+			Title:          t.Title,
+			Status:         t.Status,
+			Priority:       t.Priority,
+			CreatedBy:      models.UserSummary{ID: t.CreatedBy},
+			DeletionReason: t.DeletionReason,
+			DeletedAt:      *t.DeletedAt,
+		}
+		if row.deletedBy != nil {
+			trashed.DeletedBy = &models.UserSummary{ID: *row.deletedBy}
+		}
+		tickets = append(tickets, trashed)
+	}
+	total := len(tickets)
+
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].DeletedAt.After(tickets[j].DeletedAt)
+	})
+	start := (page - 1) * perPage
+	if start > len(tickets) {
+		start = len(tickets)
+	}
+	end := start + perPage
+	if end > len(tickets) {
+		end = len(tickets)
+	}
+
+	return tickets[start:end], total, nil
+}
+
+// Restore takes a ticket out of the trash. Purged tickets can't be restored.
+// The deletion reason is cleared unless it is the ticket's cancellation
+// reason.
+func (s *TicketStore) Restore(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, ok := s.d.tickets[ticketID]
+	if !ok || row.ticket.OrganizationID != orgID || row.ticket.DeletedAt == nil {
+		return nil, models.NotFound("ticket not found")
+	}
+	if row.purgedAt != nil {
+		return nil, models.ErrTicketPurged
+	}
+
+	t := &row.ticket
+	t.DeletedAt = nil
+	row.deletedBy = nil
+	if t.Status != models.TicketStatusCancelled {
+		t.DeletionReason = nil
+	}
+	t.Version++
+	t.UpdatedAt = time.Now()
+
+	restored := *t
+	return &restored, nil
+}
+
+// PurgeTrash scrubs tickets deleted before the cutoff, and their comments,
+// and marks them purged. Returns the IDs of the purged tickets.
+func (s *TicketStore) PurgeTrash(ctx context.Context, orgID uuid.UUID, before time.Time) ([]uuid.UUID, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := time.Now()
+	purged := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for id, row := range s.d.tickets {
+		t := &row.ticket
+		if t.OrganizationID != orgID || t.DeletedAt == nil || !t.DeletedAt.Before(before) || row.purgedAt != nil {
+			continue
+		}
+		t.Title = "[purged]"
+		t.Description = ""
+		t.ComplianceNotes = nil
+		t.ImpactDescription = nil
+		t.RollbackPlan = nil
+		t.TestingPlan = nil
+		t.AttachmentURLs = nil
+		t.CustomFields = json.RawMessage("{}")
+		t.SubmittedSnapshot = nil
+		t.DeletionReason = nil
+		row.purgedAt = &now
+		purged[id] = true
+		ids = append(ids, id)
+	}
+
+	for _, c := range s.d.comments {
+		if purged[c.TicketID] {
+			c.Comment = "[purged]"
+			c.MentionedUsers = nil
+			c.AttachmentURLs = nil
+			c.EditHistory = json.RawMessage("[]")
+		}
+	}
+
+	return ids, nil
+}
+
+// GetQueue retrieves tickets that need assignment (for ticket queue bot)
+func (s *TicketStore) GetQueue(ctx context.Context, orgID uuid.UUID) ([]models.Ticket, error) {
+	filter := &models.TicketListFilter{
+		NeedsAssignment: true,
+		SortBy:          "created_at",
+		SortOrder:       "asc",
+		PerPage:         100,
+	}
+	tickets, _, err := s.List(ctx, orgID, filter)
+	return tickets, err
+}
+
+// Assign assigns a ticket to a user
+func (s *TicketStore) Assign(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	return s.modify(orgID, ticketID, func(t *models.Ticket) {
+		t.AssignedTo = &userID
+	})
+}
+
+// AddWatcher adds a watcher to a ticket
+func (s *TicketStore) AddWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	return s.modify(orgID, ticketID, func(t *models.Ticket) {
+		for _, w := range t.Watchers {
+			if w == userID {
+				return
+			}
+		}
+		t.Watchers = append(append([]uuid.UUID(nil), t.Watchers...), userID)
+	})
+}
+
+// RemoveWatcher removes a watcher from a ticket
+func (s *TicketStore) RemoveWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	return s.modify(orgID, ticketID, func(t *models.Ticket) {
+		watchers := []uuid.UUID{}
+		for _, w := range t.Watchers {
+			if w != userID {
+				watchers = append(watchers, w)
+			}
+		}
+		t.Watchers = watchers
+	})
+}
+
+// modify changes a ticket without bumping its version. Like the UPDATEs it
+// stands in for, it does nothing if there is no such ticket.
+func (s *TicketStore) modify(orgID, ticketID uuid.UUID, fn func(t *models.Ticket)) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	row, ok := s.d.tickets[ticketID]
+	if !ok || row.ticket.OrganizationID != orgID {
+		return nil
+	}
+	fn(&row.ticket)
+	row.ticket.UpdatedAt = time.Now()
+	return nil
+}
+
+// LinkRepository links a repository to a ticket, replacing the details of
+// an existing link
+func (s *TicketStore) LinkRepository(ctx context.Context, ticketID, repoID, linkedBy uuid.UUID, input *models.LinkRepositoryInput) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	linkType := "related"
+	if input.LinkType != "" {
+		linkType = input.LinkType
+	}
+
+	for i := range s.d.ticketRepos {
+		link := &s.d.ticketRepos[i]
+		if link.TicketID == ticketID && link.RepositoryID == input.RepositoryID {
+			link.LinkType = linkType
+			link.BranchName, link.CommitSHA, link.PRNumber, link.Notes = input.BranchName, input.CommitSHA, input.PRNumber, input.Notes
+			return nil
+		}
+	}
+
+	s.d.ticketRepos = append(s.d.ticketRepos, models.TicketRepository{
+		ID:           uuid.New(),
+		TicketID:     ticketID,
+		RepositoryID: input.RepositoryID,
+		LinkedBy:     linkedBy,
+		LinkType:     linkType,
+		BranchName:   input.BranchName,
+		CommitSHA:    input.CommitSHA,
+		PRNumber:     input.PRNumber,
+		Notes:        input.Notes,
+		CreatedAt:    time.Now(),
+	})
+	return nil
+}
+
+// UnlinkRepository unlinks a repository from a ticket
+func (s *TicketStore) UnlinkRepository(ctx context.Context, ticketID, repoID uuid.UUID) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	links := s.d.ticketRepos[:0]
+	for _, link := range s.d.ticketRepos {
+		if link.TicketID != ticketID || link.RepositoryID != repoID {
+			links = append(links, link)
+		}
+	}
+	s.d.ticketRepos = links
+	return nil
+}
+
+// score sets the ticket's production host count and risk score, which the
+// SQL store derives on read
+func (d *data) score(t *models.Ticket) {
+	t.ProductionSystems = models.CountProductionSystems(d.affected[t.ID])
+	t.RiskScore = t.ScoreRisk()
+}
+
+// SetAffectedResources replaces the inventory hosts a ticket's affected
+// systems resolved to
+func (s *TicketStore) SetAffectedResources(ctx context.Context, orgID, ticketID uuid.UUID, resources []models.AffectedResource) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[int]bool)
+	var kept []models.AffectedResource
+	for _, r := range resources {
+		if seen[r.ResourceID] {
+			continue
+		}
+		seen[r.ResourceID] = true
+		r.TicketID = ticketID
+		r.ResolvedAt = now
+		kept = append(kept, r)
+	}
+	s.d.affected[ticketID] = kept
+	if row, ok := s.d.tickets[ticketID]; ok {
+		s.d.score(&row.ticket)
+	}
+	return nil
+}
+
+// ListAffectedResources retrieves the inventory hosts a ticket affects,
+// ordered by hostname
+func (s *TicketStore) ListAffectedResources(ctx context.Context, ticketID uuid.UUID) ([]models.AffectedResource, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	resources := append([]models.AffectedResource(nil), s.d.affected[ticketID]...)
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Hostname < resources[j].Hostname
+	})
+	return resources, nil
+}
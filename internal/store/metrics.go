@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// queryBuckets are the upper bounds, in seconds, of the query duration
+// histogram
+var queryBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// maxLoggedStatement bounds the statement text in slow query logs
+const maxLoggedStatement = 1000
+
+// queryMetrics times the statements run through the store, per store
+// method, and logs the slow ones
+type queryMetrics struct {
+	logger *zap.Logger
+	slow   time.Duration // Zero turns slow query logging off
+
+	mu      sync.Mutex
+	methods map[string]*queryHistogram
+}
+
+type queryHistogram struct {
+	counts []int64 // Per bucket, not cumulative; the last is +Inf
+	sum    time.Duration
+	count  int64
+	errors int64
+}
+
+// Instrument times every statement the stores run, for WriteMetrics, and
+// logs those taking slowQuery or longer with the store method that ran them
+// and the organization of the request. Queries are timed until their first
+// rows are ready, not while the caller reads them.
+func (s *Store) Instrument(logger *zap.Logger, slowQuery time.Duration) {
+	s.metrics = &queryMetrics{logger: logger, slow: slowQuery, methods: make(map[string]*queryHistogram)}
+	if d, ok := s.conn.(dbConn); ok {
+		d.metrics = s.metrics
+		s.conn = d
+		if s.replica != nil {
+			s.replica.conn = d
+			s.replica.replica.metrics = s.metrics
+		}
+		s.bind(d)
+	}
+	if s.inventoryDB != nil {
+		s.Inventory = &InventoryStore{db: dbConn{DB: s.inventoryDB, metrics: s.metrics}}
+	}
+}
+
+// observe records a statement that took d, and logs it if it was slow
+func (m *queryMetrics) observe(ctx context.Context, query string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	method := callingMethod()
+
+	m.mu.Lock()
+	h, ok := m.methods[method]
+	if !ok {
+		h = &queryHistogram{counts: make([]int64, len(queryBuckets)+1)}
+		m.methods[method] = h
+	}
+	i := sort.SearchFloat64s(queryBuckets, d.Seconds())
+	h.counts[i]++
+	h.sum += d
+	h.count++
+	if err != nil {
+		h.errors++
+	}
+	m.mu.Unlock()
+
+	if m.slow > 0 && d >= m.slow {
+		fields := []zap.Field{
+			zap.String("method", method),
+			zap.Duration("duration", d),
+			zap.String("statement", compactStatement(query)),
+		}
+		if orgID, ok := OrgFrom(ctx); ok {
+			fields = append(fields, zap.String("org_id", orgID.String()))
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		m.logger.Warn("Slow query", fields...)
+	}
+}
+
+// storePackage prefixes the names of the functions in this package
+const storePackage = "github.com/afterdarksys/adsops-utils/internal/store."
+
+// callingMethod names the outermost exported store method on the stack,
+// such as TicketStore.List, so helpers like getTicket are counted under the
+// method using them. Statements run outside any store method, such as in
+// WithTx callbacks, are counted as "other".
+func callingMethod() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	method := "other"
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, storePackage); ok {
+			// (*TicketStore).List
+			recv, fn, found := strings.Cut(name, ").")
+			recv = strings.TrimPrefix(recv, "(*")
+			if found && strings.HasSuffix(recv, "Store") && recv != "Store" && isExported(recv) &&
+				isExported(fn) && !strings.Contains(fn, ".") {
+				method = recv + "." + fn
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return method
+}
+
+func isExported(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// compactStatement collapses a statement's whitespace for logging
+func compactStatement(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxLoggedStatement {
+		query = query[:maxLoggedStatement] + "..."
+	}
+	return query
+}
+
+// WriteMetrics writes the statement duration histogram and error counts
+// per store method in the Prometheus text format. It writes nothing
+// unless the store is instrumented.
+func (s *Store) WriteMetrics(w io.Writer) {
+	m := s.metrics
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	methods := make([]string, 0, len(m.methods))
+	snaps := make(map[string]queryHistogram, len(m.methods))
+	for name, h := range m.methods {
+		methods = append(methods, name)
+		snap := *h
+		snap.counts = append([]int64(nil), h.counts...)
+		snaps[name] = snap
+	}
+	m.mu.Unlock()
+	sort.Strings(methods)
+
+	fmt.Fprintln(w, "# HELP adsops_store_query_duration_seconds Time taken by database statements, by store method.")
+	fmt.Fprintln(w, "# TYPE adsops_store_query_duration_seconds histogram")
+	for _, name := range methods {
+		h := snaps[name]
+		var cumulative int64
+		for i, le := range queryBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "adsops_store_query_duration_seconds_bucket{method=%q,le=\"%g\"} %d\n", name, le, cumulative)
+		}
+		fmt.Fprintf(w, "adsops_store_query_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", name, h.count)
+		fmt.Fprintf(w, "adsops_store_query_duration_seconds_sum{method=%q} %g\n", name, h.sum.Seconds())
+		fmt.Fprintf(w, "adsops_store_query_duration_seconds_count{method=%q} %d\n", name, h.count)
+	}
+	fmt.Fprintln(w, "# HELP adsops_store_query_errors_total Database statements that failed, by store method.")
+	fmt.Fprintln(w, "# TYPE adsops_store_query_errors_total counter")
+	for _, name := range methods {
+		fmt.Fprintf(w, "adsops_store_query_errors_total{method=%q} %d\n", name, snaps[name].errors)
+	}
+}
@@ -0,0 +1,568 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// NotificationStore handles the outbound notification queue
+type NotificationStore struct {
+	db conn
+}
+
+const notificationColumns = `
+	id, organization_id, user_id, email, notification_type, subject, body_html, body_text,
+	ticket_id, approval_id, COALESCE(status, 'pending'), COALESCE(attempts, 0),
+	COALESCE(max_attempts, 3), sent_at, failed_at, error_message, ses_message_id,
+	COALESCE(priority, 0), created_at, COALESCE(scheduled_for, created_at)`
+
+// NotificationBatching holds back notifications of some types so several
+// to one recipient can be sent as one email. A recipient's notifications of
+// those types are held until Window has passed since the oldest of them, or
+// until Max are pending.
+type NotificationBatching struct {
+	Types  []string
+	Window time.Duration
+	Max    int
+}
+
+// Enqueue queues a notification for one recipient and returns its ID
+func (s *NotificationStore) Enqueue(ctx context.Context, r *models.NotificationRecipient, msg models.NotificationMessage) (uuid.UUID, error) {
+	return enqueueNotification(ctx, s.db, r, msg)
+}
+
+// enqueueNotification queues a notification on q, so stores can queue
+// one inside their own transactions
+func enqueueNotification(ctx context.Context, q execQuerier, r *models.NotificationRecipient, msg models.NotificationMessage) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO notification_queue (
+			organization_id, user_id, email, notification_type, subject,
+			body_html, body_text, ticket_id, approval_id, priority, scheduled_for
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, NOW()))
+		RETURNING id
+	`, r.OrganizationID, r.UserID, r.Email, msg.NotificationType, msg.Subject,
+		msg.BodyHTML, msg.BodyText, r.TicketID, r.ApprovalID, msg.Priority, r.ScheduledFor,
+	).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return id, nil
+}
+
+// notificationRow holds the notification_queue columns of one queued
+// notification
+const notificationRow = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, NOW()))"
+
+// notificationRowParams is the number of parameters in notificationRow
+const notificationRowParams = 12
+
+// EnqueueMany queues many notifications with multi-row INSERTs in one
+// transaction and returns their IDs in the same order
+func (s *NotificationStore) EnqueueMany(ctx context.Context, notifications []models.QueuedNotification) ([]uuid.UUID, error) {
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids, err := enqueueNotifications(ctx, tx, notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// enqueueNotifications queues notifications on q with multi-row INSERTs,
+// as many rows to a statement as Postgres takes parameters for
+func enqueueNotifications(ctx context.Context, q execQuerier, notifications []models.QueuedNotification) ([]uuid.UUID, error) {
+	ids, args := notificationArgs(notifications)
+	batch := bulkBatchSize(notificationRowParams)
+	for start := 0; start < len(notifications); start += batch {
+		end := start + batch
+		if end > len(notifications) {
+			end = len(notifications)
+		}
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO notification_queue (
+				id, organization_id, user_id, email, notification_type, subject,
+				body_html, body_text, ticket_id, approval_id, priority, scheduled_for
+			) VALUES `+bulkValues(end-start, notificationRow),
+			args[start*notificationRowParams:end*notificationRowParams]...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue notifications: %w", err)
+		}
+	}
+	return ids, nil
+}
+
+// notificationArgs gives each notification an ID and lays out the
+// parameters of their notificationRows. The IDs are made here, as
+// RETURNING doesn't promise the order of the rows.
+func notificationArgs(notifications []models.QueuedNotification) ([]uuid.UUID, []interface{}) {
+	ids := make([]uuid.UUID, 0, len(notifications))
+	args := make([]interface{}, 0, len(notifications)*notificationRowParams)
+	for _, n := range notifications {
+		id := uuid.New()
+		ids = append(ids, id)
+		r, msg := n.Recipient, n.Message
+		args = append(args, id, r.OrganizationID, r.UserID, r.Email, msg.NotificationType, msg.Subject,
+			msg.BodyHTML, msg.BodyText, r.TicketID, r.ApprovalID, msg.Priority, r.ScheduledFor)
+	}
+	return ids, args
+}
+
+// ClaimBatch locks up to limit due notifications, highest priority first,
+// and leases them to the caller by counting the attempt and pushing
+// scheduled_for out by lease. Rows locked by another worker are skipped.
+// A worker that dies mid-send leaves the row to be claimed again once the
+// lease runs out. With batching, notifications still being held for their
+// recipient are left in the queue.
+func (s *NotificationStore) ClaimBatch(ctx context.Context, limit int, lease time.Duration, batching *NotificationBatching) ([]models.NotificationQueue, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	batchTypes := []string{}
+	var window float64
+	batchMax := 0
+	if batching != nil && batching.Window > 0 {
+		batchTypes, window, batchMax = batching.Types, batching.Window.Seconds(), batching.Max
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM notification_queue
+		WHERE status = 'pending' AND scheduled_for <= NOW()
+		  AND NOT (
+			notification_type = ANY($2) AND lower(email) IN (
+				SELECT lower(email) FROM notification_queue
+				WHERE status = 'pending' AND notification_type = ANY($2)
+				GROUP BY lower(email)
+				HAVING MIN(created_at) > NOW() - make_interval(secs => $3)
+				   AND ($4 <= 0 OR COUNT(*) < $4)
+			)
+		  )
+		ORDER BY priority DESC, scheduled_for ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit, pq.Array(batchTypes), window, batchMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock notifications: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		UPDATE notification_queue
+		SET attempts = COALESCE(attempts, 0) + 1,
+		    scheduled_for = NOW() + make_interval(secs => $2)
+		WHERE id = ANY($1)
+		RETURNING `+notificationColumns,
+		pq.Array(ids), lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []models.NotificationQueue
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		claimed = append(claimed, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// MarkSent records a delivered notification and its SES message ID
+func (s *NotificationStore) MarkSent(ctx context.Context, id uuid.UUID, sesMessageID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notification_queue
+		SET status = 'sent', sent_at = NOW(), ses_message_id = $2, error_message = NULL
+		WHERE id = $1
+	`, id, sesMessageID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (s *NotificationStore) MarkRetry(ctx context.Context, id uuid.UUID, message string, retryAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notification_queue
+		SET error_message = $2, scheduled_for = $3
+		WHERE id = $1
+	`, id, message, retryAt)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule notification: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed gives up on a notification after a permanent error or its
+// last attempt
+func (s *NotificationStore) MarkFailed(ctx context.Context, id uuid.UUID, message string) error {
+	return s.giveUp(ctx, id, models.NotificationStatusFailed, message)
+}
+
+// MarkBounced gives up on a notification SES rejected, or whose address is
+// suppressed
+func (s *NotificationStore) MarkBounced(ctx context.Context, id uuid.UUID, message string) error {
+	return s.giveUp(ctx, id, models.NotificationStatusBounced, message)
+}
+
+func (s *NotificationStore) giveUp(ctx context.Context, id uuid.UUID, status, message string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notification_queue
+		SET status = $2, failed_at = NOW(), error_message = $3
+		WHERE id = $1
+	`, id, status, message)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification %s: %w", status, err)
+	}
+	return nil
+}
+
+// Requeue puts the organization's failed and bounced notifications picked
+// by input back in the queue with their attempts reset. Notifications to
+// addresses that are still suppressed are left alone, since they would only
+// bounce again. Returns the number requeued.
+func (s *NotificationStore) Requeue(ctx context.Context, orgID uuid.UUID, input *models.RequeueNotificationsInput) (int64, error) {
+	conditions := []string{
+		"n.organization_id = $1",
+		"n.status IN ('failed', 'bounced')",
+		"NOT EXISTS (SELECT 1 FROM email_suppressions es WHERE es.email = lower(n.email))",
+	}
+	args := []interface{}{orgID}
+	if len(input.IDs) > 0 {
+		args = append(args, pq.Array(input.IDs))
+		conditions = append(conditions, fmt.Sprintf("n.id = ANY($%d)", len(args)))
+	}
+	if input.Email != "" {
+		args = append(args, input.Email)
+		conditions = append(conditions, fmt.Sprintf("lower(n.email) = lower($%d)", len(args)))
+	}
+	if len(input.IDs) == 0 && input.Email == "" {
+		conditions = append(conditions, "n.status = 'failed'")
+	}
+	if input.Since != nil {
+		args = append(args, *input.Since)
+		conditions = append(conditions, fmt.Sprintf("n.failed_at >= $%d", len(args)))
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notification_queue n
+		SET status = 'pending', attempts = 0, scheduled_for = NOW(),
+		    failed_at = NULL, error_message = NULL
+		WHERE `+strings.Join(conditions, " AND "),
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue notifications: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// QueueStats summarizes a delivery queue for monitoring
+type QueueStats struct {
+	Pending   int64      // Waiting to be sent, including retries scheduled later
+	Due       int64      // Pending and due now
+	OldestDue *time.Time // When the longest-waiting due item became due
+}
+
+// QueueStats summarizes the pending notifications
+func (s *NotificationStore) QueueStats(ctx context.Context) (*QueueStats, error) {
+	stats := &QueueStats{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE scheduled_for <= NOW()),
+		       MIN(scheduled_for) FILTER (WHERE scheduled_for <= NOW())
+		FROM notification_queue
+		WHERE status = 'pending'
+	`).Scan(&stats.Pending, &stats.Due, &stats.OldestDue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification queue stats: %w", err)
+	}
+	return stats, nil
+}
+
+func scanNotification(row rowScanner) (*models.NotificationQueue, error) {
+	n := &models.NotificationQueue{}
+	err := row.Scan(
+		&n.ID, &n.OrganizationID, &n.UserID, &n.Email, &n.NotificationType, &n.Subject,
+		&n.BodyHTML, &n.BodyText, &n.TicketID, &n.ApprovalID, &n.Status, &n.Attempts,
+		&n.MaxAttempts, &n.SentAt, &n.FailedAt, &n.ErrorMessage, &n.SESMessageID,
+		&n.Priority, &n.CreatedAt, &n.ScheduledFor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// MarkMessageBounced marks the notifications sent to email as SES message
+// sesMessageID bounced, after SES reported the bounce. Returns the number
+// of notifications updated.
+func (s *NotificationStore) MarkMessageBounced(ctx context.Context, sesMessageID, email, message string) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notification_queue
+		SET status = 'bounced', failed_at = COALESCE(failed_at, NOW()), error_message = $3
+		WHERE ses_message_id = $1 AND lower(email) = lower($2)
+	`, sesMessageID, email, message)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notification bounced: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Suppress stops notifications to an address, replacing the reason for an
+// address already suppressed
+func (s *NotificationStore) Suppress(ctx context.Context, email, reason, detail, sesMessageID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_suppressions (email, reason, detail, ses_message_id)
+		VALUES (lower($1), $2, NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (email) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			detail = EXCLUDED.detail,
+			ses_message_id = EXCLUDED.ses_message_id
+	`, email, reason, detail, sesMessageID)
+	if err != nil {
+		return fmt.Errorf("failed to suppress email: %w", err)
+	}
+	return nil
+}
+
+// GetSuppression returns the suppression for an address, or nil if it may
+// be sent to
+func (s *NotificationStore) GetSuppression(ctx context.Context, email string) (*models.EmailSuppression, error) {
+	sup := &models.EmailSuppression{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT email, reason, detail, ses_message_id, created_at, updated_at
+		FROM email_suppressions WHERE email = lower($1)
+	`, email).Scan(&sup.Email, &sup.Reason, &sup.Detail, &sup.SESMessageID, &sup.CreatedAt, &sup.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email suppression: %w", err)
+	}
+	return sup, nil
+}
+
+// Unsuppress lets notifications go to an address again
+func (s *NotificationStore) Unsuppress(ctx context.Context, email string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM email_suppressions WHERE email = lower($1)", email)
+	if err != nil {
+		return fmt.Errorf("failed to lift email suppression: %w", err)
+	}
+	return nil
+}
+
+const notificationPreferenceColumns = `
+	user_id, organization_id, digest_frequency, digest_time, digest_weekday, timezone,
+	digest_approvals, digest_status_changes, digest_upcoming, last_digest_at`
+
+// GetPreferences returns a user's digest settings, or the defaults if they
+// have not set any
+func (s *NotificationStore) GetPreferences(ctx context.Context, orgID, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	p, err := scanNotificationPreferences(s.db.QueryRowContext(ctx,
+		"SELECT "+notificationPreferenceColumns+" FROM notification_preferences WHERE organization_id = $1 AND user_id = $2",
+		orgID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return models.DefaultNotificationPreferences(userID, orgID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return p, nil
+}
+
+// SavePreferences stores a user's digest settings
+func (s *NotificationStore) SavePreferences(ctx context.Context, p *models.NotificationPreferences) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_preferences (
+			user_id, organization_id, digest_frequency, digest_time, digest_weekday, timezone,
+			digest_approvals, digest_status_changes, digest_upcoming
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			digest_frequency = EXCLUDED.digest_frequency,
+			digest_time = EXCLUDED.digest_time,
+			digest_weekday = EXCLUDED.digest_weekday,
+			timezone = EXCLUDED.timezone,
+			digest_approvals = EXCLUDED.digest_approvals,
+			digest_status_changes = EXCLUDED.digest_status_changes,
+			digest_upcoming = EXCLUDED.digest_upcoming
+	`, p.UserID, p.OrganizationID, p.DigestFrequency, p.DigestTime, p.DigestWeekday, p.Timezone,
+		p.DigestApprovals, p.DigestStatusChanges, p.DigestUpcoming,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+// ListDigestRecipients returns the active staff users who have turned
+// digests on. Whether each one is due is up to the caller, since it
+// depends on their timezone.
+func (s *NotificationStore) ListDigestRecipients(ctx context.Context) ([]models.DigestRecipient, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.organization_id, u.email, u.username, u.full_name, u.roles,
+		       np.user_id, np.organization_id, np.digest_frequency, np.digest_time, np.digest_weekday,
+		       np.timezone, np.digest_approvals, np.digest_status_changes, np.digest_upcoming, np.last_digest_at
+		FROM notification_preferences np
+		JOIN users u ON u.id = np.user_id
+		WHERE np.digest_frequency <> 'off'
+		  AND u.is_active = true AND u.deleted_at IS NULL AND u.customer_id IS NULL
+		ORDER BY u.organization_id, u.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []models.DigestRecipient
+	for rows.Next() {
+		var r models.DigestRecipient
+		p := &r.Preferences
+		if err := rows.Scan(
+			&r.UserID, &r.OrganizationID, &r.Email, &r.Username, &r.FullName, pq.Array(&r.Roles),
+			&p.UserID, &p.OrganizationID, &p.DigestFrequency, &p.DigestTime, &p.DigestWeekday,
+			&p.Timezone, &p.DigestApprovals, &p.DigestStatusChanges, &p.DigestUpcoming, &p.LastDigestAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan digest recipient: %w", err)
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, rows.Err()
+}
+
+// digestRow pairs a user with the time their digest was scheduled for
+const digestRow = "(?::uuid, ?::timestamptz)"
+
+// QueueDigests records digests as sent for their scheduled times and queues
+// those with something to report for delivery, all in one transaction. A
+// digest already recorded by another run isn't queued again.
+func (s *NotificationStore) QueueDigests(ctx context.Context, digests []models.QueuedDigest) error {
+	if len(digests) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	recorded := make(map[uuid.UUID]bool, len(digests))
+	batch := bulkBatchSize(2)
+	for start := 0; start < len(digests); start += batch {
+		end := start + batch
+		if end > len(digests) {
+			end = len(digests)
+		}
+		args := make([]interface{}, 0, (end-start)*2)
+		for _, d := range digests[start:end] {
+			args = append(args, d.Recipient.UserID, d.ScheduledAt)
+		}
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE notification_preferences np SET last_digest_at = d.scheduled_at
+			FROM (VALUES `+bulkValues(end-start, digestRow)+`) AS d (user_id, scheduled_at)
+			WHERE np.user_id = d.user_id
+			  AND (np.last_digest_at IS NULL OR np.last_digest_at < d.scheduled_at)
+			RETURNING np.user_id
+		`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to record digests: %w", err)
+		}
+		for rows.Next() {
+			var userID uuid.UUID
+			if err := rows.Scan(&userID); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan recorded digest: %w", err)
+			}
+			recorded[userID] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to record digests: %w", err)
+		}
+	}
+
+	var queued []models.QueuedNotification
+	for _, d := range digests {
+		if d.Message == nil || !recorded[d.Recipient.UserID] {
+			continue
+		}
+		userID := d.Recipient.UserID
+		queued = append(queued, models.QueuedNotification{
+			Recipient: models.NotificationRecipient{
+				OrganizationID: d.Recipient.OrganizationID,
+				UserID:         &userID,
+				Email:          d.Recipient.Email,
+			},
+			Message: *d.Message,
+		})
+	}
+	if _, err := enqueueNotifications(ctx, tx, queued); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit digests: %w", err)
+	}
+	return nil
+}
+
+func scanNotificationPreferences(row rowScanner) (*models.NotificationPreferences, error) {
+	p := &models.NotificationPreferences{}
+	err := row.Scan(
+		&p.UserID, &p.OrganizationID, &p.DigestFrequency, &p.DigestTime, &p.DigestWeekday,
+		&p.Timezone, &p.DigestApprovals, &p.DigestStatusChanges, &p.DigestUpcoming, &p.LastDigestAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+func TestNotificationArgs(t *testing.T) {
+	orgID, userID, ticketID := uuid.New(), uuid.New(), uuid.New()
+	notifications := []models.QueuedNotification{
+		{
+			Recipient: models.NotificationRecipient{OrganizationID: orgID, UserID: &userID, Email: "a@example.com", TicketID: &ticketID},
+			Message:   models.NotificationMessage{NotificationType: models.NotificationTypeApprovalRequest, Subject: "first"},
+		},
+		{
+			Recipient: models.NotificationRecipient{OrganizationID: orgID, Email: "b@example.com"},
+			Message:   models.NotificationMessage{NotificationType: models.NotificationTypeDigest, Subject: "second", Priority: 5},
+		},
+	}
+
+	ids, args := notificationArgs(notifications)
+	if len(ids) != len(notifications) {
+		t.Fatalf("got %d IDs, want %d", len(ids), len(notifications))
+	}
+	if len(args) != len(notifications)*notificationRowParams {
+		t.Fatalf("got %d args, want %d", len(args), len(notifications)*notificationRowParams)
+	}
+	if got := strings.Count(bulkValues(len(notifications), notificationRow), "$"); got != len(args) {
+		t.Errorf("rows have %d placeholders for %d args", got, len(args))
+	}
+
+	for i, n := range notifications {
+		row := args[i*notificationRowParams : (i+1)*notificationRowParams]
+		if row[0] != ids[i] {
+			t.Errorf("row %d ID = %v, want %v", i, row[0], ids[i])
+		}
+		if row[3] != n.Recipient.Email || row[5] != n.Message.Subject || row[10] != n.Message.Priority {
+			t.Errorf("row %d = %v, out of line with notification %d", i, row, i)
+		}
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("notifications share ID %v", ids[0])
+	}
+}
+
+func TestNotificationBatchFitsBindParams(t *testing.T) {
+	if n := bulkBatchSize(notificationRowParams) * notificationRowParams; n > maxBindParams {
+		t.Errorf("a batch of notifications takes %d parameters, more than %d", n, maxBindParams)
+	}
+}
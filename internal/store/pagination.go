@@ -0,0 +1,36 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// keysetCondition returns the condition selecting rows after the cursor in
+// ORDER BY column, id. The column must be NOT NULL and come from a whitelist.
+func keysetCondition(column, order string, cursor *models.Cursor, argNum int) (string, []interface{}) {
+	op := ">"
+	if order == "DESC" {
+		op = "<"
+	}
+	condition := fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, op, argNum, argNum+1)
+	return condition, []interface{}{cursor.Value, cursor.ID}
+}
+
+// cursorCondition decodes a list's cursor and returns the condition selecting
+// the rows after it, or "" when the list has no cursor. A cursor taken from
+// the list sorted by another column is rejected.
+func cursorCondition(encoded, sortBy, order string, argNum int) (string, []interface{}, error) {
+	if encoded == "" {
+		return "", nil, nil
+	}
+	cursor, err := models.DecodeCursor(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	if cursor.SortBy != sortBy {
+		return "", nil, &models.ValidationError{Field: "cursor", Message: "cursor does not match sort_by"}
+	}
+	condition, args := keysetCondition(sortBy, order, cursor, argNum)
+	return condition, args, nil
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+func TestPostgresErrorsFromEitherDriver(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		unique     bool
+		constraint string
+	}{
+		{"lib/pq", &pq.Error{Code: "23505", Constraint: "users_email_key"}, true, "users_email_key"},
+		{"pgx", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, true, "users_email_key"},
+		{"wrapped", fmt.Errorf("failed to create user: %w", &pgconn.PgError{Code: "23505"}), true, ""},
+		{"other server error", &pq.Error{Code: "P0001", Constraint: "api_key_limit"}, false, "api_key_limit"},
+		{"not from the server", errors.New("connection refused"), false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err); got != tt.unique {
+				t.Errorf("isUniqueViolation() = %v, want %v", got, tt.unique)
+			}
+			if got := ConstraintName(tt.err); got != tt.constraint {
+				t.Errorf("ConstraintName() = %q, want %q", got, tt.constraint)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// PIRStore handles post-implementation review database operations
+type PIRStore struct {
+	db conn
+}
+
+const pirColumns = `
+	id, ticket_id, organization_id, status, required_reason, due_at, outcome,
+	summary, incident_links, lessons_learned, created_by, completed_at,
+	signed_off_by, signed_off_at, sign_off_comment, created_at, updated_at
+`
+
+// CreateRequired creates a pending PIR for a ticket if one does not already exist
+func (s *PIRStore) CreateRequired(ctx context.Context, orgID, ticketID uuid.UUID, reason string, dueAt time.Time) (*models.PostImplementationReview, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO post_implementation_reviews (organization_id, ticket_id, status, required_reason, due_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (ticket_id) DO UPDATE SET updated_at = post_implementation_reviews.updated_at
+		RETURNING %s
+	`, pirColumns)
+
+	pir, err := scanPIR(s.db.QueryRowContext(ctx, query, orgID, ticketID, models.PIRStatusPending, reason, dueAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create post-implementation review: %w", err)
+	}
+
+	return pir, nil
+}
+
+// GetByTicket retrieves the review for a ticket
+func (s *PIRStore) GetByTicket(ctx context.Context, orgID, ticketID uuid.UUID) (*models.PostImplementationReview, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM post_implementation_reviews
+		WHERE ticket_id = $1 AND organization_id = $2
+	`, pirColumns)
+
+	pir, err := scanPIR(s.db.QueryRowContext(ctx, query, ticketID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("post-implementation review not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post-implementation review: %w", err)
+	}
+
+	return pir, nil
+}
+
+// Submit records the findings of a review, creating it if no review was required
+func (s *PIRStore) Submit(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.SubmitPIRInput) (*models.PostImplementationReview, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO post_implementation_reviews (
+			organization_id, ticket_id, status, outcome, summary, incident_links,
+			lessons_learned, created_by, completed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		ON CONFLICT (ticket_id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    outcome = EXCLUDED.outcome,
+		    summary = EXCLUDED.summary,
+		    incident_links = EXCLUDED.incident_links,
+		    lessons_learned = EXCLUDED.lessons_learned,
+		    created_by = COALESCE(post_implementation_reviews.created_by, EXCLUDED.created_by),
+		    completed_at = NOW()
+		RETURNING %s
+	`, pirColumns)
+
+	pir, err := scanPIR(s.db.QueryRowContext(ctx, query,
+		orgID, ticketID, models.PIRStatusCompleted, input.Outcome, input.Summary,
+		pq.Array(input.IncidentLinks), input.LessonsLearned, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit post-implementation review: %w", err)
+	}
+
+	return pir, nil
+}
+
+// SignOff records reviewer sign-off. The reviewer may not be the ticket's
+// creator or the PIR author (segregation of duties).
+func (s *PIRStore) SignOff(ctx context.Context, orgID, ticketID, reviewerID uuid.UUID, input *models.SignOffPIRInput) (*models.PostImplementationReview, error) {
+	pir, err := s.GetByTicket(ctx, orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if !pir.CanSignOff() {
+		return nil, models.InvalidTransition("post-implementation review must be completed before sign-off")
+	}
+	if pir.CreatedBy != nil && *pir.CreatedBy == reviewerID {
+		return nil, models.Forbidden("reviewer cannot sign off their own post-implementation review")
+	}
+
+	var ticketCreator uuid.UUID
+	err = s.db.QueryRowContext(ctx,
+		"SELECT created_by FROM change_tickets WHERE id = $1 AND organization_id = $2",
+		ticketID, orgID,
+	).Scan(&ticketCreator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticketCreator == reviewerID {
+		return nil, models.Forbidden("ticket creator cannot sign off the post-implementation review")
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE post_implementation_reviews
+		SET status = $1, signed_off_by = $2, signed_off_at = NOW(), sign_off_comment = $3
+		WHERE ticket_id = $4 AND organization_id = $5 AND status = $6
+		RETURNING %s
+	`, pirColumns)
+
+	pir, err = scanPIR(s.db.QueryRowContext(ctx, query,
+		models.PIRStatusSignedOff, reviewerID, input.Comment, ticketID, orgID, models.PIRStatusCompleted,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.InvalidTransition("post-implementation review must be completed before sign-off")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign off post-implementation review: %w", err)
+	}
+
+	return pir, nil
+}
+
+// List retrieves reviews with filtering
+func (s *PIRStore) List(ctx context.Context, orgID uuid.UUID, filter *models.PIRListFilter) ([]models.PostImplementationReview, int, error) {
+	filter.SetDefaults()
+
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	conditions = append(conditions, fmt.Sprintf("organization_id = $%d", argNum))
+	args = append(args, orgID)
+	argNum++
+
+	if len(filter.Status) > 0 {
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", argNum))
+		args = append(args, pq.Array(filter.Status))
+		argNum++
+	}
+
+	if filter.OverdueOnly {
+		conditions = append(conditions, "status = 'pending' AND due_at < NOW()")
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM post_implementation_reviews WHERE %s", whereClause)
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count post-implementation reviews: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM post_implementation_reviews
+		WHERE %s
+		ORDER BY due_at ASC NULLS LAST, created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, pirColumns, whereClause, argNum, argNum+1)
+	args = append(args, filter.PerPage, filter.Offset())
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list post-implementation reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []models.PostImplementationReview
+	for rows.Next() {
+		pir, err := scanPIR(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan post-implementation review: %w", err)
+		}
+		reviews = append(reviews, *pir)
+	}
+
+	return reviews, total, rows.Err()
+}
+
+// ListClosedWithoutPIR retrieves changes closed in the given window that have
+// no completed review, for SOX evidence reporting
+func (s *PIRStore) ListClosedWithoutPIR(ctx context.Context, orgID uuid.UUID, from, to *time.Time) ([]models.MissingPIREntry, error) {
+	conditions := []string{"organization_id = $1"}
+	args := []interface{}{orgID}
+	argNum := 2
+
+	if from != nil {
+		conditions = append(conditions, fmt.Sprintf("closed_at >= $%d", argNum))
+		args = append(args, *from)
+		argNum++
+	}
+
+	if to != nil {
+		conditions = append(conditions, fmt.Sprintf("closed_at < $%d", argNum))
+		args = append(args, *to)
+		argNum++
+	}
+
+	query := fmt.Sprintf(`
+		SELECT ticket_id, ticket_number, title, priority, risk_level, is_emergency,
+		       created_by, closed_at, pir_id, pir_status, pir_due_at
+		FROM v_closed_without_pir
+		WHERE %s
+		ORDER BY closed_at DESC
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes without PIR: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.MissingPIREntry
+	for rows.Next() {
+		var e models.MissingPIREntry
+		err := rows.Scan(
+			&e.TicketID, &e.TicketNumber, &e.Title, &e.Priority, &e.RiskLevel,
+			&e.IsEmergency, &e.CreatedBy, &e.ClosedAt, &e.PIRID, &e.PIRStatus, &e.PIRDueAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change without PIR: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func scanPIR(row rowScanner) (*models.PostImplementationReview, error) {
+	pir := &models.PostImplementationReview{}
+	var incidentLinks []string
+
+	err := row.Scan(
+		&pir.ID, &pir.TicketID, &pir.OrganizationID, &pir.Status, &pir.RequiredReason,
+		&pir.DueAt, &pir.Outcome, &pir.Summary, pq.Array(&incidentLinks),
+		&pir.LessonsLearned, &pir.CreatedBy, &pir.CompletedAt, &pir.SignedOffBy,
+		&pir.SignedOffAt, &pir.SignOffComment, &pir.CreatedAt, &pir.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	pir.IncidentLinks = incidentLinks
+
+	return pir, nil
+}
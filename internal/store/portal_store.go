@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PortalStore handles the customer portal: portal accounts and the
+// customer-facing view of tickets and comments
+type PortalStore struct {
+	db conn
+}
+
+// portalTicketConditions limit tickets to the ones a customer may see:
+// their own, submitted and not confidential
+const portalTicketConditions = `
+	organization_id = $1 AND customer_id = $2 AND deleted_at IS NULL
+	AND is_confidential = false AND status <> 'draft'
+`
+
+// ListTickets retrieves a customer's tickets, most recently updated first
+func (s *PortalStore) ListTickets(ctx context.Context, orgID, customerID uuid.UUID, filter *models.PortalTicketFilter) ([]models.PortalTicket, int, error) {
+	filter.SetDefaults()
+
+	conditions := []string{portalTicketConditions}
+	args := []interface{}{orgID, customerID}
+	argNum := 3
+
+	if len(filter.Status) > 0 {
+		statuses := make([]string, len(filter.Status))
+		for i, st := range filter.Status {
+			statuses[i] = string(st)
+		}
+		conditions = append(conditions, fmt.Sprintf("status::text = ANY($%d)", argNum))
+		args = append(args, pq.Array(statuses))
+		argNum++
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(title ILIKE $%d OR ticket_number ILIKE $%d)", argNum, argNum))
+		// Match the search text literally, not as a pattern
+		args = append(args, "%"+strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Search)+"%")
+		argNum++
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM change_tickets WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tickets: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM change_tickets
+		WHERE %s
+		ORDER BY updated_at DESC, id
+		LIMIT $%d OFFSET $%d
+	`, ticketColumns, where, argNum, argNum+1)
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	defer rows.Close()
+
+	tickets := []models.PortalTicket{}
+	for rows.Next() {
+		t, err := scanTicket(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		tickets = append(tickets, models.NewPortalTicket(t))
+	}
+
+	return tickets, total, rows.Err()
+}
+
+// GetTicket retrieves one of a customer's tickets. Tickets the customer
+// can't see are reported as missing.
+func (s *PortalStore) GetTicket(ctx context.Context, orgID, customerID, ticketID uuid.UUID) (*models.PortalTicket, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM change_tickets
+		WHERE %s AND id = $3
+	`, ticketColumns, portalTicketConditions)
+
+	t, err := scanTicket(s.db.QueryRowContext(ctx, query, orgID, customerID, ticketID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("ticket not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	ticket := models.NewPortalTicket(t)
+	return &ticket, nil
+}
+
+// ListComments retrieves a ticket's public comments, oldest first. Internal
+// and deleted comments are left out.
+func (s *PortalStore) ListComments(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.PortalComment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, COALESCE(u.full_name, ''), u.customer_id IS NOT NULL, c.comment, c.created_at
+		FROM ticket_comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.organization_id = $1 AND c.ticket_id = $2
+		  AND c.deleted_at IS NULL AND COALESCE(c.is_internal, false) = false
+		ORDER BY c.created_at
+	`, orgID, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []models.PortalComment{}
+	for rows.Next() {
+		var c models.PortalComment
+		if err := rows.Scan(&c.ID, &c.Author, &c.FromCustomer, &c.Comment, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
+
+// GetCustomer returns the summary of the customer a portal user belongs to
+func (s *PortalStore) GetCustomer(ctx context.Context, orgID, customerID uuid.UUID) (*models.CustomerSummary, error) {
+	c := &models.CustomerSummary{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, short_name, tier, COALESCE(is_active, true)
+		FROM customers
+		WHERE id = $1 AND organization_id = $2
+	`, customerID, orgID).Scan(&c.ID, &c.Name, &c.ShortName, &c.Tier, &c.IsActive)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("customer not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	return c, nil
+}
+
+const portalUserColumns = `
+	id, organization_id, customer_id, email, full_name, COALESCE(is_active, true),
+	last_login_at, created_at
+`
+
+// CreateUser creates a portal account for one of the organization's
+// customers. Portal accounts have no roles.
+func (s *PortalStore) CreateUser(ctx context.Context, orgID uuid.UUID, input *models.CreatePortalUserInput) (*models.PortalUser, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := scanPortalUser(s.db.QueryRowContext(ctx, `
+		INSERT INTO users (organization_id, customer_id, email, full_name, password_hash, roles)
+		SELECT $1, id, $3, $4, $5, '{}'
+		FROM customers
+		WHERE id = $2 AND organization_id = $1
+		RETURNING `+portalUserColumns,
+		orgID, input.CustomerID, input.Email, input.FullName, string(hash),
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("customer not found")
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("user already exists")
+		}
+		return nil, fmt.Errorf("failed to create portal user: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers retrieves the organization's portal accounts, optionally for
+// one customer
+func (s *PortalStore) ListUsers(ctx context.Context, orgID uuid.UUID, customerID *uuid.UUID) ([]models.PortalUser, error) {
+	query := "SELECT " + portalUserColumns + " FROM users WHERE organization_id = $1 AND customer_id IS NOT NULL AND deleted_at IS NULL"
+	args := []interface{}{orgID}
+	if customerID != nil {
+		query += " AND customer_id = $2"
+		args = append(args, *customerID)
+	}
+	query += " ORDER BY email"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list portal users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.PortalUser{}
+	for rows.Next() {
+		u, err := scanPortalUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan portal user: %w", err)
+		}
+		users = append(users, *u)
+	}
+
+	return users, rows.Err()
+}
+
+// DeactivateUser stops a portal account from signing in
+func (s *PortalStore) DeactivateUser(ctx context.Context, orgID, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET is_active = false, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND customer_id IS NOT NULL AND deleted_at IS NULL
+	`, userID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate portal user: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("portal user not found")
+	}
+	return nil
+}
+
+func scanPortalUser(row rowScanner) (*models.PortalUser, error) {
+	u := &models.PortalUser{}
+	err := row.Scan(&u.ID, &u.OrganizationID, &u.CustomerID, &u.Email, &u.FullName, &u.IsActive, &u.LastLoginAt, &u.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
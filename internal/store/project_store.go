@@ -6,12 +6,13 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/afterdarksys/adsops-utils/internal/models"
 )
 
 // ProjectStore handles project database operations
 type ProjectStore struct {
-	db *sql.DB
+	db conn
 }
 
 // Create creates a new project
@@ -69,7 +70,7 @@ func (s *ProjectStore) GetByID(ctx context.Context, orgID, projectID uuid.UUID)
 		&project.CreatedAt, &project.UpdatedAt, &project.CreatedBy,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project not found")
+		return nil, models.NotFound("project not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project: %w", err)
@@ -78,6 +79,40 @@ func (s *ProjectStore) GetByID(ctx context.Context, orgID, projectID uuid.UUID)
 	return project, nil
 }
 
+// GetByIDs retrieves several projects at once. Projects that don't exist
+// are left out.
+func (s *ProjectStore) GetByIDs(ctx context.Context, orgID uuid.UUID, projectIDs []uuid.UUID) ([]models.Project, error) {
+	query := `
+		SELECT id, organization_id, project_key, name, description, lead_user_id,
+		       default_assignee_id, owning_group_id, customer_id, is_active, icon_url,
+		       created_at, updated_at, created_by
+		FROM projects
+		WHERE organization_id = $1 AND id = ANY($2)
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, pq.Array(projectIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var p models.Project
+		err := rows.Scan(
+			&p.ID, &p.OrganizationID, &p.ProjectKey, &p.Name, &p.Description,
+			&p.LeadUserID, &p.DefaultAssigneeID, &p.OwningGroupID, &p.CustomerID,
+			&p.IsActive, &p.IconURL, &p.CreatedAt, &p.UpdatedAt, &p.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
 // GetByKey retrieves a project by key
 func (s *ProjectStore) GetByKey(ctx context.Context, orgID uuid.UUID, key string) (*models.Project, error) {
 	var projectID uuid.UUID
@@ -86,7 +121,7 @@ func (s *ProjectStore) GetByKey(ctx context.Context, orgID uuid.UUID, key string
 		orgID, key,
 	).Scan(&projectID)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("project not found")
+		return nil, models.NotFound("project not found")
 	}
 	if err != nil {
 		return nil, err
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/config"
+)
+
+// replicaRetryAfter is how long queries skip a replica that couldn't be
+// reached before trying it again
+const replicaRetryAfter = 30 * time.Second
+
+// replicaConn sends queries to a read replica and everything else to the
+// primary. A query the replica fails is run again on the primary. When the
+// replica can't be reached at all it is skipped for replicaRetryAfter.
+type replicaConn struct {
+	conn                    // The primary
+	replica   dbConn
+	downUntil *atomic.Int64 // Unix nanoseconds
+}
+
+// OpenReplica sends ticket list and search queries and reports to a read
+// replica, so heavy reads don't contend with writes on the primary. They
+// may lag the primary by the replica's replication delay.
+func (s *Store) OpenReplica(cfg *config.DatabaseConfig) error {
+	db, err := openScoped(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open replica database: %w", err)
+	}
+
+	configurePool(db, cfg)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping replica database: %w", err)
+	}
+
+	s.replicaDB = db
+	s.replica = &replicaConn{
+		conn:      s.conn,
+		replica:   dbConn{DB: db, metrics: s.metrics},
+		downUntil: new(atomic.Int64),
+	}
+	s.bind(s.conn)
+	return nil
+}
+
+// reader is the connection for read-heavy queries on c: the replica when c
+// is the primary and a replica is open, otherwise c itself, so reads in a
+// transaction see its writes
+func (s *Store) reader(c conn) conn {
+	if s.replica == nil || c != s.replica.conn {
+		return c
+	}
+	return s.replica
+}
+
+func (r *replicaConn) available() bool {
+	return time.Now().UnixNano() >= r.downUntil.Load()
+}
+
+// fallBack reports whether a replica error should be retried on the
+// primary. Errors from the replica's server, such as a query canceled by
+// replication, are retried; failing to reach it at all also takes it out
+// of use for a while. Canceled requests aren't retried.
+func (r *replicaConn) fallBack(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if sqlState(err) == "" {
+		r.downUntil.Store(time.Now().Add(replicaRetryAfter).UnixNano())
+	}
+	return true
+}
+
+// QueryContext runs a query on the replica, or on the primary if the
+// replica fails
+func (r *replicaConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r.available() {
+		rows, err := r.replica.QueryContext(ctx, query, args...)
+		if err == nil || !r.fallBack(ctx, err) {
+			return rows, err
+		}
+	}
+	return r.conn.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a single-row query on the replica, or on the
+// primary if the replica fails
+func (r *replicaConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if r.available() {
+		row := r.replica.QueryRowContext(ctx, query, args...)
+		if err := row.Err(); err == nil || !r.fallBack(ctx, err) {
+			return row
+		}
+	}
+	return r.conn.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,296 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ReportJobStore handles the queue of reports rendered by the worker
+type ReportJobStore struct {
+	db conn
+}
+
+const reportJobColumns = `
+	id, organization_id, requested_by, report_type, format, parameters, status,
+	attempts, error_message, object_key, filename, content_type, size_bytes,
+	row_count, sha256, started_at, completed_at, expires_at, created_at, updated_at`
+
+// Create queues a report. A user may have at most
+// models.MaxPendingReportJobs reports queued or running at once.
+func (s *ReportJobStore) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateReportJobInput, params models.ReportJobParams) (*models.ReportJob, error) {
+	var pending int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM report_jobs
+		WHERE requested_by = $1 AND status IN ('queued', 'running')
+	`, userID).Scan(&pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending reports: %w", err)
+	}
+	if pending >= models.MaxPendingReportJobs {
+		return nil, models.Conflict("too many reports in progress")
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report parameters: %w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO report_jobs (organization_id, requested_by, report_type, format, parameters)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+reportJobColumns,
+		orgID, userID, input.ReportType, input.Format, paramsJSON,
+	)
+	job, err := scanReportJob(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report job: %w", err)
+	}
+	return job, nil
+}
+
+// Get returns one of an organization's report jobs
+func (s *ReportJobStore) Get(ctx context.Context, orgID, id uuid.UUID) (*models.ReportJob, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+reportJobColumns+` FROM report_jobs
+		WHERE organization_id = $1 AND id = $2
+	`, orgID, id)
+	job, err := scanReportJob(row)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("report job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report job: %w", err)
+	}
+	return job, nil
+}
+
+// List lists an organization's report jobs, newest first
+func (s *ReportJobStore) List(ctx context.Context, orgID uuid.UUID, filter *models.ReportJobFilter) ([]models.ReportJob, error) {
+	conditions := []string{"organization_id = $1"}
+	args := []interface{}{orgID}
+	if filter.RequestedBy != nil {
+		args = append(args, *filter.RequestedBy)
+		conditions = append(conditions, fmt.Sprintf("requested_by = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	limit := filter.Limit
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM report_jobs
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT %d
+	`, reportJobColumns, strings.Join(conditions, " AND "), limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.ReportJob
+	for rows.Next() {
+		job, err := scanReportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// Claim leases the oldest queued job to the caller, or a running job whose
+// lease has run out because its worker died. It returns nil when there is
+// nothing to do. Jobs locked by another worker are skipped.
+func (s *ReportJobStore) Claim(ctx context.Context, lease time.Duration) (*models.ReportJob, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE report_jobs
+		SET status = 'running', attempts = attempts + 1, started_at = NOW(),
+		    lease_until = NOW() + make_interval(secs => $1)
+		WHERE id = (
+			SELECT id FROM report_jobs
+			WHERE status = 'queued' OR (status = 'running' AND lease_until < NOW())
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+reportJobColumns,
+		lease.Seconds(),
+	)
+	job, err := scanReportJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim report job: %w", err)
+	}
+	return job, nil
+}
+
+// Complete records a rendered report and queues msg to the requester. Both
+// happen in one transaction so the requester is told exactly once.
+func (s *ReportJobStore) Complete(ctx context.Context, job *models.ReportJob, out *models.ReportOutput, expiresAt time.Time, requester *models.UserSummary, msg models.NotificationMessage) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE report_jobs
+		SET status = 'completed', error_message = NULL, lease_until = NULL,
+		    object_key = $2, filename = $3, content_type = $4, size_bytes = $5,
+		    row_count = $6, sha256 = $7, completed_at = NOW(), expires_at = $8
+		WHERE id = $1
+	`, job.ID, out.ObjectKey, out.Filename, out.ContentType, out.SizeBytes,
+		out.RowCount, out.SHA256, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete report job: %w", err)
+	}
+	if err := queueReportNotification(ctx, tx, job, requester, msg); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit report job: %w", err)
+	}
+	return nil
+}
+
+// Requeue puts a job that failed back in the queue to be tried again
+func (s *ReportJobStore) Requeue(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE report_jobs
+		SET status = 'queued', error_message = $2, lease_until = NULL
+		WHERE id = $1
+	`, id, message)
+	if err != nil {
+		return fmt.Errorf("failed to requeue report job: %w", err)
+	}
+	return nil
+}
+
+// Fail gives up on a job and queues msg to the requester
+func (s *ReportJobStore) Fail(ctx context.Context, job *models.ReportJob, message string, requester *models.UserSummary, msg models.NotificationMessage) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE report_jobs
+		SET status = 'failed', error_message = $2, lease_until = NULL, completed_at = NOW()
+		WHERE id = $1
+	`, job.ID, message)
+	if err != nil {
+		return fmt.Errorf("failed to mark report job failed: %w", err)
+	}
+	if err := queueReportNotification(ctx, tx, job, requester, msg); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit report job: %w", err)
+	}
+	return nil
+}
+
+// ListExpired lists up to limit completed jobs whose files are past
+// retention
+func (s *ReportJobStore) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.ReportJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+reportJobColumns+` FROM report_jobs
+		WHERE status = 'completed' AND expires_at <= $1
+		ORDER BY expires_at ASC
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired report jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.ReportJob
+	for rows.Next() {
+		job, err := scanReportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// MarkExpired records that a job's file has been deleted
+func (s *ReportJobStore) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE report_jobs SET status = 'expired', object_key = NULL
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark report job expired: %w", err)
+	}
+	return nil
+}
+
+// QueueStats summarizes the report jobs waiting for or being rendered.
+// Queued jobs, and running jobs whose lease has run out, are due.
+func (s *ReportJobStore) QueueStats(ctx context.Context) (*QueueStats, error) {
+	stats := &QueueStats{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status = 'queued' OR lease_until < NOW()),
+		       MIN(created_at) FILTER (WHERE status = 'queued' OR lease_until < NOW())
+		FROM report_jobs
+		WHERE status IN ('queued', 'running')
+	`).Scan(&stats.Pending, &stats.Due, &stats.OldestDue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report job queue stats: %w", err)
+	}
+	return stats, nil
+}
+
+// queueReportNotification queues msg to the requester. A requester that
+// has since been deleted is nil and is not told.
+func queueReportNotification(ctx context.Context, tx *Tx, job *models.ReportJob, requester *models.UserSummary, msg models.NotificationMessage) error {
+	if requester == nil {
+		return nil
+	}
+	_, err := enqueueNotification(ctx, tx, &models.NotificationRecipient{
+		OrganizationID: job.OrganizationID,
+		UserID:         &requester.ID,
+		Email:          requester.Email,
+	}, msg)
+	return err
+}
+
+func scanReportJob(row rowScanner) (*models.ReportJob, error) {
+	job := &models.ReportJob{}
+	var params []byte
+	err := row.Scan(
+		&job.ID, &job.OrganizationID, &job.RequestedBy, &job.ReportType, &job.Format,
+		&params, &job.Status, &job.Attempts, &job.ErrorMessage, &job.ObjectKey,
+		&job.Filename, &job.ContentType, &job.SizeBytes, &job.RowCount, &job.SHA256,
+		&job.StartedAt, &job.CompletedAt, &job.ExpiresAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &job.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid report parameters: %w", err)
+	}
+	return job, nil
+}
@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ReportStore computes dashboard and activity reports with SQL aggregates
+type ReportStore struct {
+	db conn
+}
+
+// Changes that completed but whose review found them rolled back or only
+// partly done count as failed
+const failedChangeCondition = `EXISTS (
+	SELECT 1 FROM post_implementation_reviews p
+	WHERE p.ticket_id = t.id AND p.outcome IN ('rolled_back', 'partial')
+)`
+
+// changeScopeCondition limits tickets t to a ChangeMetricsScope passed as
+// $4 (group) and $5 (user), either of which may be NULL
+const changeScopeCondition = `($4::uuid IS NULL OR t.owning_group_id = $4)
+	AND ($5::uuid IS NULL OR t.created_by = $5 OR t.assigned_to = $5)`
+
+// ChangeMetrics computes an organization's change KPIs over the period,
+// limited to the scope's team or user when set. A user's approval times are
+// how long their changes waited for approval.
+func (s *ReportStore) ChangeMetrics(ctx context.Context, orgID uuid.UUID, period models.ReportPeriod, scope models.ChangeMetricsScope) (*models.ChangeMetrics, error) {
+	m := &models.ChangeMetrics{ReportPeriod: period}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE t.submitted_at >= $2 AND t.submitted_at < $3),
+			COUNT(*) FILTER (WHERE t.submitted_at >= $2 AND t.submitted_at < $3
+			                   AND (t.is_emergency OR t.priority = 'emergency')),
+			COUNT(*) FILTER (WHERE t.completed_at >= $2 AND t.completed_at < $3
+			                   AND t.status IN ('completed', 'closed')),
+			COUNT(*) FILTER (WHERE t.completed_at >= $2 AND t.completed_at < $3
+			                   AND t.status IN ('completed', 'closed') AND `+failedChangeCondition+`)
+		FROM change_tickets t
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL AND `+changeScopeCondition+`
+	`, orgID, period.From, period.To, scope.GroupID, scope.UserID).Scan(
+		&m.TotalChanges, &m.EmergencyChanges, &m.CompletedChanges, &m.FailedChanges,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change metrics: %w", err)
+	}
+
+	var meanHours sql.NullFloat64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       AVG(EXTRACT(EPOCH FROM (COALESCE(a.approved_at, a.denied_at) - a.created_at)) / 3600)
+		FROM approvals a
+		JOIN change_tickets t ON t.id = a.ticket_id
+		WHERE a.organization_id = $1 AND t.deleted_at IS NULL
+		  AND a.status IN ('approved', 'denied')
+		  AND COALESCE(a.approved_at, a.denied_at) >= $2
+		  AND COALESCE(a.approved_at, a.denied_at) < $3
+		  AND `+changeScopeCondition+`
+	`, orgID, period.From, period.To, scope.GroupID, scope.UserID).Scan(&m.DecidedApprovals, &meanHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval times: %w", err)
+	}
+	m.MeanApprovalHours = roundedHours(meanHours)
+
+	m.ComputeRates()
+	m.GeneratedAt = time.Now().UTC()
+	return m, nil
+}
+
+// SystemChanges counts the changes submitted against each affected system
+// per month, busiest systems first
+func (s *ReportStore) SystemChanges(ctx context.Context, orgID uuid.UUID, period models.ReportPeriod) (*models.SystemChangeReport, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT LOWER(TRIM(sys.name)) AS system,
+		       TO_CHAR(DATE_TRUNC('month', t.submitted_at AT TIME ZONE 'UTC'), 'YYYY-MM') AS month,
+		       COUNT(DISTINCT t.id)
+		FROM change_tickets t, unnest(t.affected_systems) AS sys(name)
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+		  AND t.submitted_at >= $2 AND t.submitted_at < $3
+		  AND TRIM(sys.name) <> ''
+		GROUP BY 1, 2
+		ORDER BY SUM(COUNT(DISTINCT t.id)) OVER (PARTITION BY LOWER(TRIM(sys.name))) DESC, 1, 2
+	`, orgID, period.From, period.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system changes: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.SystemChangeReport{ReportPeriod: period, Systems: []models.SystemChangeCount{}}
+	for rows.Next() {
+		var c models.SystemChangeCount
+		if err := rows.Scan(&c.System, &c.Month, &c.Changes); err != nil {
+			return nil, fmt.Errorf("failed to scan system changes: %w", err)
+		}
+		report.Systems = append(report.Systems, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.GeneratedAt = time.Now().UTC()
+	return report, nil
+}
+
+// UserActivity summarizes a user's tickets, comments, approvals, worklogs
+// and audited actions over the period
+func (s *ReportStore) UserActivity(ctx context.Context, orgID, userID uuid.UUID, period models.ReportPeriod) (*models.UserActivityReport, error) {
+	r := &models.UserActivityReport{ReportPeriod: period, AuditEvents: map[string]int{}}
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, email, full_name FROM users WHERE id = $1 AND organization_id = $2",
+		userID, orgID,
+	).Scan(&r.User.ID, &r.User.Email, &r.User.FullName)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var open []string
+	for _, st := range models.OpenTicketStatuses() {
+		open = append(open, string(st))
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE t.created_by = $2 AND t.created_at >= $3 AND t.created_at < $4),
+			COUNT(*) FILTER (WHERE t.created_by = $2 AND t.submitted_at >= $3 AND t.submitted_at < $4),
+			COUNT(*) FILTER (WHERE t.assigned_to = $2 AND t.completed_at >= $3 AND t.completed_at < $4
+			                   AND t.status IN ('completed', 'closed')),
+			COUNT(*) FILTER (WHERE t.assigned_to = $2 AND t.status::text = ANY($5))
+		FROM change_tickets t
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+		  AND (t.created_by = $2 OR t.assigned_to = $2)
+	`, orgID, userID, period.From, period.To, pq.Array(open)).Scan(
+		&r.TicketsCreated, &r.TicketsSubmitted, &r.TicketsCompleted, &r.OpenAssigned,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket activity: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM ticket_comments c
+		JOIN change_tickets t ON t.id = c.ticket_id
+		WHERE t.organization_id = $1 AND c.author_id = $2
+		  AND c.created_at >= $3 AND c.created_at < $4
+	`, orgID, userID, period.From, period.To).Scan(&r.CommentsPosted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment activity: %w", err)
+	}
+
+	var meanHours sql.NullFloat64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE a.status = 'approved' AND a.approved_at >= $3 AND a.approved_at < $4),
+			COUNT(*) FILTER (WHERE a.status = 'denied' AND a.denied_at >= $3 AND a.denied_at < $4),
+			COUNT(*) FILTER (WHERE a.status = 'pending'),
+			AVG(EXTRACT(EPOCH FROM (COALESCE(a.approved_at, a.denied_at) - a.created_at)) / 3600)
+				FILTER (WHERE a.status IN ('approved', 'denied')
+				          AND COALESCE(a.approved_at, a.denied_at) >= $3
+				          AND COALESCE(a.approved_at, a.denied_at) < $4)
+		FROM approvals a
+		WHERE a.organization_id = $1 AND a.approver_id = $2
+	`, orgID, userID, period.From, period.To).Scan(
+		&r.ApprovalsApproved, &r.ApprovalsDenied, &r.ApprovalsPending, &meanHours,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval activity: %w", err)
+	}
+	r.MeanApprovalHours = roundedHours(meanHours)
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(hours), 0)
+		FROM ticket_worklogs
+		WHERE organization_id = $1 AND user_id = $2
+		  AND work_date >= $3 AND work_date < $4
+	`, orgID, userID, period.From, period.To).Scan(&r.HoursLogged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logged hours: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT action, COUNT(*), MAX(created_at)
+		FROM audit_log
+		WHERE organization_id = $1 AND user_id = $2
+		  AND created_at >= $3 AND created_at < $4
+		GROUP BY action
+	`, orgID, userID, period.From, period.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit activity: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var action string
+		var count int
+		var last time.Time
+		if err := rows.Scan(&action, &count, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan audit activity: %w", err)
+		}
+		r.AuditEvents[action] = count
+		if r.LastActivityAt == nil || last.After(*r.LastActivityAt) {
+			r.LastActivityAt = &last
+		}
+	}
+
+	return r, rows.Err()
+}
+
+func roundedHours(h sql.NullFloat64) *float64 {
+	if !h.Valid {
+		return nil
+	}
+	r := math.Round(h.Float64*100) / 100
+	return &r
+}
@@ -7,12 +7,13 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/afterdarksys/adsops-utils/internal/models"
 )
 
 // RepositoryStore handles repository database operations
 type RepositoryStore struct {
-	db *sql.DB
+	db conn
 }
 
 // Create creates a new repository
@@ -63,7 +64,7 @@ func (s *RepositoryStore) GetByID(ctx context.Context, orgID, repoID uuid.UUID)
 	query := `
 		SELECT id, organization_id, name, url, provider, owner_user_id, owner_group_id,
 		       default_branch, is_active, is_private, description, language,
-		       last_synced_at, created_at, updated_at
+		       last_synced_at, last_sync_error, created_at, updated_at
 		FROM repositories
 		WHERE id = $1 AND organization_id = $2
 	`
@@ -73,10 +74,10 @@ func (s *RepositoryStore) GetByID(ctx context.Context, orgID, repoID uuid.UUID)
 		&repo.ID, &repo.OrganizationID, &repo.Name, &repo.URL, &repo.Provider,
 		&repo.OwnerUserID, &repo.OwnerGroupID, &repo.DefaultBranch,
 		&repo.IsActive, &repo.IsPrivate, &repo.Description, &repo.Language,
-		&repo.LastSyncedAt, &repo.CreatedAt, &repo.UpdatedAt,
+		&repo.LastSyncedAt, &repo.LastSyncError, &repo.CreatedAt, &repo.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("repository not found")
+		return nil, models.NotFound("repository not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository: %w", err)
@@ -93,7 +94,7 @@ func (s *RepositoryStore) GetByURL(ctx context.Context, orgID uuid.UUID, url str
 		orgID, url,
 	).Scan(&repoID)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("repository not found")
+		return nil, models.NotFound("repository not found")
 	}
 	if err != nil {
 		return nil, err
@@ -101,7 +102,8 @@ func (s *RepositoryStore) GetByURL(ctx context.Context, orgID uuid.UUID, url str
 	return s.GetByID(ctx, orgID, repoID)
 }
 
-// List retrieves repositories with filtering
+// List retrieves repositories with filtering. With filter.Cursor set it pages
+// by keyset instead of OFFSET and sets filter.NextCursor when more rows follow.
 func (s *RepositoryStore) List(ctx context.Context, orgID uuid.UUID, filter *models.RepositoryListFilter) ([]models.Repository, int, error) {
 	filter.SetDefaults()
 
@@ -154,18 +156,43 @@ func (s *RepositoryStore) List(ctx context.Context, orgID uuid.UUID, filter *mod
 		return nil, 0, fmt.Errorf("failed to count repositories: %w", err)
 	}
 
-	// Get repositories
+	validSortFields := map[string]bool{
+		"name": true, "created_at": true, "updated_at": true,
+	}
+	sortBy := "name"
+	if validSortFields[filter.SortBy] {
+		sortBy = filter.SortBy
+	}
+
+	sortOrder := "ASC"
+	if filter.SortOrder == "desc" {
+		sortOrder = "DESC"
+	}
+
+	offset := filter.Offset()
+	condition, cursorArgs, err := cursorCondition(filter.Cursor, sortBy, sortOrder, argNum)
+	if err != nil {
+		return nil, 0, err
+	}
+	if condition != "" {
+		whereClause += " AND " + condition
+		args = append(args, cursorArgs...)
+		argNum += len(cursorArgs)
+		offset = 0
+	}
+
+	// Get repositories, plus one extra row to tell whether another page follows
 	query := fmt.Sprintf(`
 		SELECT id, organization_id, name, url, provider, owner_user_id, owner_group_id,
 		       default_branch, is_active, is_private, description, language,
-		       last_synced_at, created_at, updated_at
+		       last_synced_at, last_sync_error, created_at, updated_at
 		FROM repositories
 		WHERE %s
-		ORDER BY %s %s
+		ORDER BY %s %s, id %s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, filter.SortBy, filter.SortOrder, argNum, argNum+1)
+	`, whereClause, sortBy, sortOrder, sortOrder, argNum, argNum+1)
 
-	args = append(args, filter.PerPage, filter.Offset())
+	args = append(args, filter.PerPage+1, offset)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -180,7 +207,7 @@ func (s *RepositoryStore) List(ctx context.Context, orgID uuid.UUID, filter *mod
 			&r.ID, &r.OrganizationID, &r.Name, &r.URL, &r.Provider,
 			&r.OwnerUserID, &r.OwnerGroupID, &r.DefaultBranch,
 			&r.IsActive, &r.IsPrivate, &r.Description, &r.Language,
-			&r.LastSyncedAt, &r.CreatedAt, &r.UpdatedAt,
+			&r.LastSyncedAt, &r.LastSyncError, &r.CreatedAt, &r.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan repository: %w", err)
@@ -188,6 +215,20 @@ func (s *RepositoryStore) List(ctx context.Context, orgID uuid.UUID, filter *mod
 		repos = append(repos, r)
 	}
 
+	filter.NextCursor = ""
+	if len(repos) > filter.PerPage {
+		repos = repos[:filter.PerPage]
+		last := &repos[len(repos)-1]
+		cursor := &models.Cursor{SortBy: sortBy, Value: last.Name, ID: last.ID}
+		switch sortBy {
+		case "created_at":
+			cursor = models.TimeCursor(sortBy, last.CreatedAt, last.ID)
+		case "updated_at":
+			cursor = models.TimeCursor(sortBy, last.UpdatedAt, last.ID)
+		}
+		filter.NextCursor = cursor.Encode()
+	}
+
 	return repos, total, nil
 }
 
@@ -272,19 +313,198 @@ func (s *RepositoryStore) Delete(ctx context.Context, orgID, repoID uuid.UUID) e
 	return err
 }
 
+// ApplySync writes the details read from the provider, records the sync
+// time and clears any earlier sync error
+func (s *RepositoryStore) ApplySync(ctx context.Context, orgID, repoID uuid.UUID, details *models.RepositoryDetails) (*models.Repository, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE repositories
+		SET name = $1, default_branch = $2, language = $3, is_private = $4, description = $5,
+		    last_synced_at = NOW(), last_sync_error = NULL, updated_at = NOW()
+		WHERE id = $6 AND organization_id = $7
+	`, details.Name, details.DefaultBranch, details.Language, details.IsPrivate, details.Description, repoID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply repository sync: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, models.NotFound("repository not found")
+	}
+	return s.GetByID(ctx, orgID, repoID)
+}
+
+// RecordSyncError records why a repository's last sync failed
+func (s *RepositoryStore) RecordSyncError(ctx context.Context, orgID, repoID uuid.UUID, message string) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE repositories SET last_sync_error = $1 WHERE id = $2 AND organization_id = $3",
+		message, repoID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record repository sync error: %w", err)
+	}
+	return nil
+}
+
+// ListSyncable retrieves an organization's active repositories on providers
+// it has a sync token for, least recently synced first
+func (s *RepositoryStore) ListSyncable(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Repository, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, r.organization_id, r.name, r.url, r.provider, r.owner_user_id, r.owner_group_id,
+		       r.default_branch, r.is_active, r.is_private, r.description, r.language,
+		       r.last_synced_at, r.last_sync_error, r.created_at, r.updated_at
+		FROM repositories r
+		JOIN repository_sync_tokens t ON t.organization_id = r.organization_id AND t.provider = r.provider
+		WHERE r.organization_id = $1 AND r.is_active = true
+		ORDER BY r.last_synced_at ASC NULLS FIRST, r.id
+		LIMIT $2
+	`, orgID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list syncable repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var repos []models.Repository
+	for rows.Next() {
+		var r models.Repository
+		err := rows.Scan(
+			&r.ID, &r.OrganizationID, &r.Name, &r.URL, &r.Provider,
+			&r.OwnerUserID, &r.OwnerGroupID, &r.DefaultBranch,
+			&r.IsActive, &r.IsPrivate, &r.Description, &r.Language,
+			&r.LastSyncedAt, &r.LastSyncError, &r.CreatedAt, &r.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %w", err)
+		}
+		repos = append(repos, r)
+	}
+
+	return repos, rows.Err()
+}
+
+const syncTokenColumns = `organization_id, provider, api_base_url, api_token, updated_by, created_at, updated_at`
+
+// GetSyncToken retrieves an organization's sync token for a provider
+func (s *RepositoryStore) GetSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) (*models.RepositorySyncToken, error) {
+	token, err := scanSyncToken(s.db.QueryRowContext(ctx,
+		"SELECT "+syncTokenColumns+" FROM repository_sync_tokens WHERE organization_id = $1 AND provider = $2",
+		orgID, provider,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrSyncTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository sync token: %w", err)
+	}
+	return token, nil
+}
+
+// ListSyncTokens retrieves an organization's sync tokens
+func (s *RepositoryStore) ListSyncTokens(ctx context.Context, orgID uuid.UUID) ([]models.RepositorySyncToken, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+syncTokenColumns+" FROM repository_sync_tokens WHERE organization_id = $1 ORDER BY provider",
+		orgID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository sync tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.RepositorySyncToken{}
+	for rows.Next() {
+		token, err := scanSyncToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan repository sync token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// SetSyncToken creates or replaces an organization's sync token for a provider
+func (s *RepositoryStore) SetSyncToken(ctx context.Context, orgID, userID uuid.UUID, provider models.RepositoryProvider, input *models.UpdateRepositorySyncTokenInput) (*models.RepositorySyncToken, error) {
+	baseURL := provider.DefaultAPIBaseURL()
+	if input.APIBaseURL != nil {
+		baseURL = *input.APIBaseURL
+	}
+
+	token, err := scanSyncToken(s.db.QueryRowContext(ctx, `
+		INSERT INTO repository_sync_tokens (organization_id, provider, api_base_url, api_token, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id, provider) DO UPDATE
+		SET api_base_url = EXCLUDED.api_base_url,
+		    api_token = EXCLUDED.api_token,
+		    updated_by = EXCLUDED.updated_by
+		RETURNING `+syncTokenColumns,
+		orgID, provider, baseURL, input.APIToken, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set repository sync token: %w", err)
+	}
+	return token, nil
+}
+
+// DeleteSyncToken removes an organization's sync token for a provider
+func (s *RepositoryStore) DeleteSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM repository_sync_tokens WHERE organization_id = $1 AND provider = $2",
+		orgID, provider,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete repository sync token: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.ErrSyncTokenNotFound
+	}
+	return nil
+}
+
+// ListSyncOrganizations returns the organizations with at least one sync
+// token, for the sync job
+func (s *RepositoryStore) ListSyncOrganizations(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT DISTINCT organization_id FROM repository_sync_tokens")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository sync organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanSyncToken(row rowScanner) (*models.RepositorySyncToken, error) {
+	t := &models.RepositorySyncToken{}
+	err := row.Scan(&t.OrganizationID, &t.Provider, &t.APIBaseURL, &t.APIToken, &t.UpdatedBy, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
 // GetTicketRepositories retrieves repositories linked to a ticket
 func (s *RepositoryStore) GetTicketRepositories(ctx context.Context, ticketID uuid.UUID) ([]models.TicketRepository, error) {
+	return s.ListTicketRepositories(ctx, []uuid.UUID{ticketID})
+}
+
+// ListTicketRepositories retrieves the repositories linked to several
+// tickets at once, newest link first within each ticket
+func (s *RepositoryStore) ListTicketRepositories(ctx context.Context, ticketIDs []uuid.UUID) ([]models.TicketRepository, error) {
 	query := `
 		SELECT tr.id, tr.ticket_id, tr.repository_id, tr.linked_by, tr.link_type,
 		       tr.branch_name, tr.commit_sha, tr.pr_number, tr.notes, tr.created_at,
 		       r.name, r.url, r.provider, r.is_active
 		FROM ticket_repositories tr
 		JOIN repositories r ON tr.repository_id = r.id
-		WHERE tr.ticket_id = $1
-		ORDER BY tr.created_at DESC
+		WHERE tr.ticket_id = ANY($1)
+		ORDER BY tr.ticket_id, tr.created_at DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, ticketID)
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ticketIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ticket repositories: %w", err)
 	}
@@ -0,0 +1,353 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// RetentionStore handles data retention policies and GDPR anonymization
+type RetentionStore struct {
+	db conn
+}
+
+// GetPolicy retrieves an organization's retention policy, falling back to the defaults
+func (s *RetentionStore) GetPolicy(ctx context.Context, orgID uuid.UUID) (*models.RetentionPolicy, error) {
+	query := `
+		SELECT organization_id, pii_retention_days, comment_retention_days, trash_retention_days,
+		       last_applied_at, updated_by, created_at, updated_at
+		FROM data_retention_policies
+		WHERE organization_id = $1
+	`
+
+	policy := &models.RetentionPolicy{}
+	err := s.db.QueryRowContext(ctx, query, orgID).Scan(
+		&policy.OrganizationID, &policy.PIIRetentionDays, &policy.CommentRetentionDays, &policy.TrashRetentionDays,
+		&policy.LastAppliedAt, &policy.UpdatedBy, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		def := models.DefaultRetentionPolicy(orgID)
+		return &def, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy creates or updates an organization's retention policy
+func (s *RetentionStore) UpdatePolicy(ctx context.Context, orgID, userID uuid.UUID, input *models.UpdateRetentionPolicyInput) (*models.RetentionPolicy, error) {
+	current, err := s.GetPolicy(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if input.PIIRetentionDays != nil {
+		current.PIIRetentionDays = *input.PIIRetentionDays
+	}
+	if input.CommentRetentionDays != nil {
+		current.CommentRetentionDays = *input.CommentRetentionDays
+	}
+	if input.TrashRetentionDays != nil {
+		current.TrashRetentionDays = *input.TrashRetentionDays
+	}
+
+	query := `
+		INSERT INTO data_retention_policies (organization_id, pii_retention_days, comment_retention_days, trash_retention_days, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET pii_retention_days = EXCLUDED.pii_retention_days,
+		    comment_retention_days = EXCLUDED.comment_retention_days,
+		    trash_retention_days = EXCLUDED.trash_retention_days,
+		    updated_by = EXCLUDED.updated_by
+	`
+	_, err = s.db.ExecContext(ctx, query, orgID, current.PIIRetentionDays, current.CommentRetentionDays, current.TrashRetentionDays, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update retention policy: %w", err)
+	}
+
+	return s.GetPolicy(ctx, orgID)
+}
+
+// ListOrganizationIDs returns every active organization, for the retention job
+func (s *RetentionStore) ListOrganizationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM organizations WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ApplyRetention scrubs personal data older than the policy allows.
+// Compliance-relevant audit entries keep their content; only identifying
+// context (IP, user agent, session) is removed from non-compliance entries.
+// Comments on tickets without compliance frameworks are scrubbed once the
+// ticket has been closed longer than the comment retention period.
+func (s *RetentionStore) ApplyRetention(ctx context.Context, policy *models.RetentionPolicy, now time.Time) (*models.RetentionResult, error) {
+	result := &models.RetentionResult{OrganizationID: policy.OrganizationID, AppliedAt: now}
+	piiCutoff := policy.PIICutoff(now)
+	commentCutoff := policy.CommentCutoff(now)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE audit_log
+		SET ip_address = NULL, user_agent = NULL, session_id = NULL,
+		    anonymized = true, anonymized_at = $3
+		WHERE organization_id = $1
+		  AND created_at < $2
+		  AND compliance_relevant = false
+		  AND anonymized = false
+	`, policy.OrganizationID, piiCutoff, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrub audit log: %w", err)
+	}
+	result.AuditLogsScrubbed, _ = res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE ticket_audit_log
+		SET ip_address = NULL, user_agent = NULL, session_id = NULL,
+		    anonymized = true, anonymized_at = $3
+		WHERE organization_id = $1
+		  AND created_at < $2
+		  AND is_compliance_relevant = false
+		  AND anonymized = false
+	`, policy.OrganizationID, piiCutoff, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrub ticket audit log: %w", err)
+	}
+	result.TicketAuditsScrubbed, _ = res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE ticket_comments c
+		SET comment = '[removed under data retention policy]',
+		    mentioned_users = '{}',
+		    attachment_urls = '{}',
+		    edit_history = '[]',
+		    anonymized = true,
+		    anonymized_at = $3
+		FROM change_tickets t
+		WHERE c.ticket_id = t.id
+		  AND c.organization_id = $1
+		  AND c.anonymized = false
+		  AND t.closed_at < $2
+		  AND COALESCE(array_length(t.compliance_frameworks, 1), 0) = 0
+	`, policy.OrganizationID, commentCutoff, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrub comments: %w", err)
+	}
+	result.CommentsScrubbed, _ = res.RowsAffected()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO data_retention_policies (organization_id, pii_retention_days, comment_retention_days, trash_retention_days, last_applied_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id) DO UPDATE SET last_applied_at = EXCLUDED.last_applied_at
+	`, policy.OrganizationID, policy.PIIRetentionDays, policy.CommentRetentionDays, policy.TrashRetentionDays, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record retention run: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PurgeAPIKeys deletes API keys that expired, or were revoked, before the
+// cutoff and returns how many were removed. Their usage audit goes with
+// them.
+func (s *RetentionStore) PurgeAPIKeys(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM api_keys
+		WHERE expires_at < $1 OR revoked_at < $1
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge API keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// RecordPurgeRun stores the summary of a retention run
+func (s *RetentionStore) RecordPurgeRun(ctx context.Context, run *models.PurgeRun) error {
+	errs := run.Errors
+	if errs == nil {
+		errs = []string{}
+	}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO data_purge_runs (
+			started_at, finished_at, organizations, audit_logs_scrubbed,
+			ticket_audits_scrubbed, comments_scrubbed, tickets_purged,
+			api_keys_deleted, sessions_deleted, errors
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, run.StartedAt, run.FinishedAt, run.Organizations, run.AuditLogsScrubbed,
+		run.TicketAuditsScrubbed, run.CommentsScrubbed, run.TicketsPurged,
+		run.APIKeysDeleted, run.SessionsDeleted, pq.Array(errs),
+	).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record purge run: %w", err)
+	}
+	return nil
+}
+
+const anonymizationRequestColumns = `
+	id, organization_id, subject_user_id, requested_by, reason, status,
+	attempts, error_message, requested_at, processed_at
+`
+
+// CreateAnonymizationRequest records a right-to-be-forgotten request for a user
+func (s *RetentionStore) CreateAnonymizationRequest(ctx context.Context, orgID, subjectID, requestedBy uuid.UUID, input *models.CreateAnonymizationRequestInput) (*models.AnonymizationRequest, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND organization_id = $2)",
+		subjectID, orgID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return nil, models.NotFound("user not found")
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO anonymization_requests (organization_id, subject_user_id, requested_by, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING %s
+	`, anonymizationRequestColumns)
+
+	req, err := scanAnonymizationRequest(s.db.QueryRowContext(ctx, query, orgID, subjectID, requestedBy, input.Reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create anonymization request: %w", err)
+	}
+	return req, nil
+}
+
+// ProcessAnonymizationRequest anonymizes the subject of a pending request and
+// records the outcome. Failures are retried up to MaxAnonymizationAttempts.
+func (s *RetentionStore) ProcessAnonymizationRequest(ctx context.Context, requestID uuid.UUID) (*models.AnonymizationRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var subjectID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		SELECT subject_user_id FROM anonymization_requests
+		WHERE id = $1 AND status = 'pending'
+		FOR UPDATE SKIP LOCKED
+	`, requestID).Scan(&subjectID)
+	if err == sql.ErrNoRows {
+		return nil, models.InvalidTransition("anonymization request is not pending")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock anonymization request: %w", err)
+	}
+
+	if _, anonErr := tx.ExecContext(ctx, "SELECT anonymize_user_data($1)", subjectID); anonErr != nil {
+		tx.Rollback()
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE anonymization_requests
+			SET attempts = attempts + 1,
+			    error_message = $2,
+			    status = CASE WHEN attempts + 1 >= $3 THEN 'failed' ELSE 'pending' END
+			WHERE id = $1
+		`, requestID, anonErr.Error(), models.MaxAnonymizationAttempts)
+		if err != nil {
+			return nil, fmt.Errorf("anonymization failed: %v, and recording failure failed: %w", anonErr, err)
+		}
+		return nil, fmt.Errorf("anonymization failed: %w", anonErr)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE anonymization_requests
+		SET status = 'completed', attempts = attempts + 1, error_message = NULL, processed_at = NOW()
+		WHERE id = $1
+		RETURNING %s
+	`, anonymizationRequestColumns)
+
+	req, err := scanAnonymizationRequest(tx.QueryRowContext(ctx, query, requestID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete anonymization request: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ListAnonymizationRequests retrieves an organization's anonymization requests
+func (s *RetentionStore) ListAnonymizationRequests(ctx context.Context, orgID uuid.UUID) ([]models.AnonymizationRequest, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM anonymization_requests
+		WHERE organization_id = $1
+		ORDER BY requested_at DESC
+		LIMIT 500
+	`, anonymizationRequestColumns)
+
+	return s.queryAnonymizationRequests(ctx, query, orgID)
+}
+
+// ListPendingAnonymizationRequests retrieves pending requests across all organizations
+func (s *RetentionStore) ListPendingAnonymizationRequests(ctx context.Context, limit int) ([]models.AnonymizationRequest, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM anonymization_requests
+		WHERE status = 'pending'
+		ORDER BY requested_at ASC
+		LIMIT $1
+	`, anonymizationRequestColumns)
+
+	return s.queryAnonymizationRequests(ctx, query, limit)
+}
+
+func (s *RetentionStore) queryAnonymizationRequests(ctx context.Context, query string, args ...interface{}) ([]models.AnonymizationRequest, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list anonymization requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.AnonymizationRequest
+	for rows.Next() {
+		req, err := scanAnonymizationRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan anonymization request: %w", err)
+		}
+		requests = append(requests, *req)
+	}
+	return requests, rows.Err()
+}
+
+func scanAnonymizationRequest(row rowScanner) (*models.AnonymizationRequest, error) {
+	req := &models.AnonymizationRequest{}
+	err := row.Scan(
+		&req.ID, &req.OrganizationID, &req.SubjectUserID, &req.RequestedBy, &req.Reason,
+		&req.Status, &req.Attempts, &req.ErrorMessage, &req.RequestedAt, &req.ProcessedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
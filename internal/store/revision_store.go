@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// RevisionStore handles ticket revision history
+type RevisionStore struct {
+	db conn
+}
+
+// insertTicketRevision records the next revision of a ticket with the field
+// changes between before and after. It runs in the caller's transaction so
+// the revision commits with the write it describes.
+func insertTicketRevision(ctx context.Context, q execQuerier, before, after *models.Ticket, changedBy uuid.UUID, reason *string) error {
+	changes, err := json.Marshal(models.TicketChanges(before, after))
+	if err != nil {
+		return fmt.Errorf("failed to marshal ticket changes: %w", err)
+	}
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ticket snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO ticket_revisions (
+			ticket_id, organization_id, revision_number, changed_by,
+			change_reason, changes, ticket_snapshot
+		) VALUES (
+			$1, $2,
+			(SELECT COALESCE(MAX(revision_number), 0) + 1 FROM ticket_revisions WHERE ticket_id = $1),
+			$3, $4, $5, $6
+		)
+	`
+	_, err = q.ExecContext(ctx, query, after.ID, after.OrganizationID, changedBy, reason, changes, snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to record ticket revision: %w", err)
+	}
+	return nil
+}
+
+// ListByTicket retrieves a ticket's revisions, newest first, with the users who made them
+func (s *RevisionStore) ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID, filter *models.TicketRevisionFilter) ([]models.TicketRevision, int, error) {
+	filter.SetDefaults()
+
+	var total int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM ticket_revisions WHERE ticket_id = $1 AND organization_id = $2",
+		ticketID, orgID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count ticket revisions: %w", err)
+	}
+
+	query := `
+		SELECT r.id, r.ticket_id, r.organization_id, r.revision_number, r.changed_by,
+		       r.change_reason, r.changes, r.ticket_snapshot, r.created_at,
+		       host(r.ip_address), r.user_agent,
+		       u.email, u.full_name
+		FROM ticket_revisions r
+		LEFT JOIN users u ON u.id = r.changed_by
+		WHERE r.ticket_id = $1 AND r.organization_id = $2
+		ORDER BY r.revision_number DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, ticketID, orgID, filter.PerPage, filter.Offset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list ticket revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []models.TicketRevision
+	for rows.Next() {
+		var r models.TicketRevision
+		var changes, snapshot []byte
+		var email, fullName sql.NullString
+		err := rows.Scan(
+			&r.ID, &r.TicketID, &r.OrganizationID, &r.RevisionNumber, &r.ChangedBy,
+			&r.ChangeReason, &changes, &snapshot, &r.CreatedAt,
+			&r.IPAddress, &r.UserAgent,
+			&email, &fullName,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan ticket revision: %w", err)
+		}
+		r.Changes = changes
+		r.TicketSnapshot = snapshot
+		if email.Valid {
+			r.ChangedByUser = &models.UserSummary{ID: r.ChangedBy, Email: email.String, FullName: fullName.String}
+		}
+		revisions = append(revisions, r)
+	}
+
+	return revisions, total, rows.Err()
+}
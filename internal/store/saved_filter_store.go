@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// SavedFilterStore handles saved ticket filter database operations. Users
+// see their own filters and those others have shared with the
+// organization, but only change their own.
+type SavedFilterStore struct {
+	db conn
+}
+
+const savedFilterColumns = `
+	id, organization_id, user_id, name, description, filter, is_default, is_shared, created_at, updated_at
+`
+
+// savedFilterColumnsFor are the columns as the user in parameter param sees
+// them: a shared filter being its owner's default doesn't make it theirs
+func savedFilterColumnsFor(param int) string {
+	return strings.Replace(savedFilterColumns, "is_default", fmt.Sprintf("is_default AND user_id = $%d", param), 1)
+}
+
+// List retrieves a user's saved filters and the organization's shared ones:
+// the user's default first, then their own by name, then the shared ones
+func (s *SavedFilterStore) List(ctx context.Context, orgID, userID uuid.UUID) ([]models.SavedFilter, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM saved_filters
+		WHERE organization_id = $1 AND (user_id = $2 OR is_shared)
+		ORDER BY user_id = $2 AND is_default DESC, user_id = $2 DESC, name
+	`, savedFilterColumnsFor(2))
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved filters: %w", err)
+	}
+	defer rows.Close()
+
+	var filters []models.SavedFilter
+	for rows.Next() {
+		f, err := scanSavedFilter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved filter: %w", err)
+		}
+		filters = append(filters, *f)
+	}
+
+	return filters, rows.Err()
+}
+
+// GetByID retrieves one of a user's saved filters, or a shared one
+func (s *SavedFilterStore) GetByID(ctx context.Context, orgID, userID, filterID uuid.UUID) (*models.SavedFilter, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM saved_filters
+		WHERE id = $1 AND organization_id = $2 AND (user_id = $3 OR is_shared)
+	`, savedFilterColumnsFor(3))
+
+	f, err := scanSavedFilter(s.db.QueryRowContext(ctx, query, filterID, orgID, userID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("saved filter not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved filter: %w", err)
+	}
+
+	return f, nil
+}
+
+// GetDefault retrieves the user's default saved filter
+func (s *SavedFilterStore) GetDefault(ctx context.Context, orgID, userID uuid.UUID) (*models.SavedFilter, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM saved_filters
+		WHERE organization_id = $1 AND user_id = $2 AND is_default
+	`, savedFilterColumns)
+
+	f, err := scanSavedFilter(s.db.QueryRowContext(ctx, query, orgID, userID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("saved filter not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default saved filter: %w", err)
+	}
+
+	return f, nil
+}
+
+// Create saves a new filter for the user. Saving it as the default clears
+// the user's previous default in the same transaction.
+func (s *SavedFilterStore) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateSavedFilterInput) (*models.SavedFilter, error) {
+	filter, err := json.Marshal(input.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode saved filter: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if input.IsDefault {
+		if err := clearDefaultSavedFilter(ctx, tx, orgID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO saved_filters (organization_id, user_id, name, description, filter, is_default, is_shared)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING %s
+	`, savedFilterColumns)
+
+	f, err := scanSavedFilter(tx.QueryRowContext(ctx, query,
+		orgID, userID, input.Name, input.Description, filter, input.IsDefault, input.IsShared,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("saved filter name already exists")
+		}
+		return nil, fmt.Errorf("failed to create saved filter: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return f, nil
+}
+
+// Update updates one of a user's saved filters
+func (s *SavedFilterStore) Update(ctx context.Context, orgID, userID, filterID uuid.UUID, input *models.UpdateSavedFilterInput) (*models.SavedFilter, error) {
+	var setClauses []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Name != nil {
+		setClauses = append(setClauses, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *input.Name)
+		argNum++
+	}
+	if input.Description != nil {
+		setClauses = append(setClauses, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+	if input.Filter != nil {
+		filter, err := json.Marshal(input.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode saved filter: %w", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("filter = $%d", argNum))
+		args = append(args, filter)
+		argNum++
+	}
+	if input.IsDefault != nil {
+		setClauses = append(setClauses, fmt.Sprintf("is_default = $%d", argNum))
+		args = append(args, *input.IsDefault)
+		argNum++
+	}
+	if input.IsShared != nil {
+		setClauses = append(setClauses, fmt.Sprintf("is_shared = $%d", argNum))
+		args = append(args, *input.IsShared)
+		argNum++
+	}
+
+	if len(setClauses) == 0 {
+		// Nothing to change, but the filter must still be the user's own
+		query := fmt.Sprintf(`
+			SELECT %s
+			FROM saved_filters
+			WHERE id = $1 AND organization_id = $2 AND user_id = $3
+		`, savedFilterColumns)
+		f, err := scanSavedFilter(s.db.QueryRowContext(ctx, query, filterID, orgID, userID))
+		if err == sql.ErrNoRows {
+			return nil, models.NotFound("saved filter not found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get saved filter: %w", err)
+		}
+		return f, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if input.IsDefault != nil && *input.IsDefault {
+		if err := clearDefaultSavedFilter(ctx, tx, orgID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE saved_filters
+		SET %s
+		WHERE id = $%d AND organization_id = $%d AND user_id = $%d
+		RETURNING %s
+	`, strings.Join(setClauses, ", "), argNum, argNum+1, argNum+2, savedFilterColumns)
+	args = append(args, filterID, orgID, userID)
+
+	f, err := scanSavedFilter(tx.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("saved filter not found")
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("saved filter name already exists")
+		}
+		return nil, fmt.Errorf("failed to update saved filter: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return f, nil
+}
+
+// SetDefault makes one of the user's own saved filters their default view.
+// Another user's shared filter can't be a default; the user saves a copy
+// of it instead.
+func (s *SavedFilterStore) SetDefault(ctx context.Context, orgID, userID, filterID uuid.UUID) (*models.SavedFilter, error) {
+	isDefault := true
+	f, err := s.Update(ctx, orgID, userID, filterID, &models.UpdateSavedFilterInput{IsDefault: &isDefault})
+	if errors.Is(err, models.ErrNotFound) {
+		if _, getErr := s.GetByID(ctx, orgID, userID, filterID); getErr == nil {
+			return nil, models.Forbidden("only your own saved filters can be your default; save a copy of a shared filter first")
+		}
+	}
+	return f, err
+}
+
+// Delete removes one of a user's saved filters
+func (s *SavedFilterStore) Delete(ctx context.Context, orgID, userID, filterID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM saved_filters WHERE id = $1 AND organization_id = $2 AND user_id = $3",
+		filterID, orgID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved filter: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("saved filter not found")
+	}
+
+	return nil
+}
+
+func clearDefaultSavedFilter(ctx context.Context, q execQuerier, orgID, userID uuid.UUID) error {
+	_, err := q.ExecContext(ctx,
+		"UPDATE saved_filters SET is_default = false WHERE organization_id = $1 AND user_id = $2 AND is_default",
+		orgID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear default saved filter: %w", err)
+	}
+	return nil
+}
+
+func scanSavedFilter(row rowScanner) (*models.SavedFilter, error) {
+	f := &models.SavedFilter{}
+	var filter []byte
+	err := row.Scan(
+		&f.ID, &f.OrganizationID, &f.UserID, &f.Name, &f.Description, &filter,
+		&f.IsDefault, &f.IsShared, &f.CreatedAt, &f.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(filter) > 0 {
+		if err := json.Unmarshal(filter, &f.Filter); err != nil {
+			return nil, fmt.Errorf("failed to decode saved filter: %w", err)
+		}
+	}
+	return f, nil
+}
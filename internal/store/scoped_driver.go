@@ -0,0 +1,277 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/config"
+)
+
+// openScoped opens a database whose connections follow the organization of
+// each statement's context, so row-level security applies to reads as well
+// as writes. Outside a transaction, a connection's app.org_id is set to the
+// context's organization, or cleared without one, before a statement runs
+// on it, unless it already has that value; a connection kept by one
+// organization's requests costs nothing extra. Transactions are scoped
+// locally when they begin, so a rollback can't undo a session setting the
+// connection is believed to have. A statement timeout given by
+// WithStatementTimeout follows the context the same way.
+//
+// Connections come from lib/pq, or with database.driver set to pgxpool
+// from a pgx connection pool.
+func openScoped(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	switch cfg.Driver {
+	case "", config.DriverPQ:
+		connector, err := pq.NewConnector(cfg.DSN())
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(scopedConnector{Connector: connector}), nil
+	case config.DriverPgxPool:
+		return openPgxPool(cfg)
+	}
+	return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+}
+
+// openPgxPool opens a database on a pgx pool of up to max_open_conns
+// connections, replaced and closed after the same lifetime and idle time
+// as the database/sql pool's. database/sql keeps its idle connections
+// acquired from the pool, so their session settings stay known.
+func openPgxPool(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetimeSeconds > 0 {
+		poolConfig.MaxConnLifetime = time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second
+	}
+	if cfg.ConnMaxIdleSeconds > 0 {
+		poolConfig.MaxConnIdleTime = time.Duration(cfg.ConnMaxIdleSeconds) * time.Second
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(scopedConnector{
+		Connector: stdlib.GetPoolConnector(pool),
+		pooled:    true,
+		close:     pool.Close,
+	}), nil
+}
+
+// scopedConnector opens scopedConns
+type scopedConnector struct {
+	driver.Connector
+	pooled bool   // Connections may have been scoped by an earlier user
+	close  func() // Closes what the connections come from, if anything
+}
+
+// unknownSetting marks a session setting an earlier user of a pooled
+// connection may have changed; it matches no setting, so the first
+// statement sets it
+const unknownSetting = "\x00"
+
+// Connect opens a connection, unscoped until a statement says otherwise
+func (c scopedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pc, ok := cn.(pgConn)
+	if !ok {
+		cn.Close()
+		return nil, fmt.Errorf("unsupported postgres driver connection %T", cn)
+	}
+	if c.pooled {
+		return &scopedConn{pgConn: pc, org: unknownSetting, timeout: unknownSetting}, nil
+	}
+	return &scopedConn{pgConn: pc}, nil
+}
+
+// Close closes the pool connections come from, when there is one.
+// database/sql calls it when the database is closed.
+func (c scopedConnector) Close() error {
+	if c.close != nil {
+		c.close()
+	}
+	return nil
+}
+
+// pgConn is what database/sql uses of a lib/pq or pgx connection
+type pgConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+}
+
+// scopedConn is a connection that sets app.org_id, and statement_timeout
+// when the context overrides it, from the context of the statements it
+// runs. database/sql never uses a connection concurrently, so its fields
+// need no lock.
+type scopedConn struct {
+	pgConn
+	org     string // The session's app.org_id, empty when unscoped
+	timeout string // The session's statement_timeout, empty for the DSN's
+	inTx    bool
+}
+
+// orgSetting is the app.org_id value for the context
+func orgSetting(ctx context.Context) string {
+	if orgID, ok := OrgFrom(ctx); ok {
+		return orgID.String()
+	}
+	return ""
+}
+
+// setOrg sets app.org_id for the session, or with local for the current
+// transaction only
+func (c *scopedConn) setOrg(ctx context.Context, org string, local bool) error {
+	_, err := c.pgConn.ExecContext(ctx, "SELECT set_config('app.org_id', $1, $2)",
+		[]driver.NamedValue{{Ordinal: 1, Value: org}, {Ordinal: 2, Value: local}})
+	return err
+}
+
+// setTimeout sets statement_timeout for the session, or with local for
+// the current transaction only. An empty timeout restores the DSN's.
+func (c *scopedConn) setTimeout(ctx context.Context, timeout string, local bool) error {
+	var err error
+	switch {
+	case timeout != "":
+		_, err = c.pgConn.ExecContext(ctx, "SELECT set_config('statement_timeout', $1, $2)",
+			[]driver.NamedValue{{Ordinal: 1, Value: timeout}, {Ordinal: 2, Value: local}})
+	case local:
+		_, err = c.pgConn.ExecContext(ctx, "SET LOCAL statement_timeout TO DEFAULT", nil)
+	default:
+		_, err = c.pgConn.ExecContext(ctx, "RESET statement_timeout", nil)
+	}
+	return err
+}
+
+// scope points the session at the context's organization and statement
+// timeout. Statements in a transaction run as the transaction was scoped.
+func (c *scopedConn) scope(ctx context.Context) error {
+	if c.inTx {
+		return nil
+	}
+	if org := orgSetting(ctx); org != c.org {
+		if err := c.setOrg(ctx, org, false); err != nil {
+			return fmt.Errorf("failed to scope connection to organization: %w", err)
+		}
+		c.org = org
+	}
+	if timeout := timeoutSetting(ctx); timeout != c.timeout {
+		if err := c.setTimeout(ctx, timeout, false); err != nil {
+			return fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+		c.timeout = timeout
+	}
+	return nil
+}
+
+// BeginTx starts a transaction scoped to the context's organization and
+// statement timeout
+func (c *scopedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.pgConn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if org := orgSetting(ctx); org != c.org {
+		if err := c.setOrg(ctx, org, true); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to scope transaction to organization: %w", err)
+		}
+	}
+	if timeout := timeoutSetting(ctx); timeout != c.timeout {
+		if err := c.setTimeout(ctx, timeout, true); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to set the transaction's statement timeout: %w", err)
+		}
+	}
+	c.inTx = true
+	return scopedTx{Tx: tx, conn: c}, nil
+}
+
+// ExecContext executes a statement as the context's organization
+func (c *scopedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.pgConn.ExecContext(ctx, query, args)
+}
+
+// QueryContext runs a query as the context's organization
+func (c *scopedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.scope(ctx); err != nil {
+		return nil, err
+	}
+	return c.pgConn.QueryContext(ctx, query, args)
+}
+
+// PrepareContext prepares a statement that runs as the context of each
+// execution's organization
+func (c *scopedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	st, err := c.pgConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scopedStmt{Stmt: st, conn: c}, nil
+}
+
+// IsValid reports whether database/sql may keep the connection, as the
+// driver's connection says when it can
+func (c *scopedConn) IsValid() bool {
+	if v, ok := c.pgConn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// scopedTx ends a scopedConn's transaction
+type scopedTx struct {
+	driver.Tx
+	conn *scopedConn
+}
+
+func (t scopedTx) Commit() error {
+	t.conn.inTx = false
+	return t.Tx.Commit()
+}
+
+func (t scopedTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
+
+// scopedStmt is a prepared statement of a scopedConn
+type scopedStmt struct {
+	driver.Stmt
+	conn *scopedConn
+}
+
+func (s scopedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.conn.scope(ctx); err != nil {
+		return nil, err
+	}
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+}
+
+func (s scopedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.conn.scope(ctx); err != nil {
+		return nil, err
+	}
+	return s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, args)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+type settingsCacheKey struct{}
+
+// settingsCache holds the organization settings read during one request
+type settingsCache struct {
+	mu       sync.Mutex
+	settings map[uuid.UUID]*models.OrganizationSettings
+}
+
+// WithSettingsCache gives the context a cache of organization settings, so
+// OrganizationStore.GetSettings reads each organization's settings once
+// for the life of the context. Settings updated through the store replace
+// the cached copy. Give it to short-lived contexts, such as a request's;
+// changes made elsewhere aren't seen until the next one.
+func WithSettingsCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, settingsCacheKey{}, &settingsCache{
+		settings: make(map[uuid.UUID]*models.OrganizationSettings),
+	})
+}
+
+// cachedSettings returns the organization's settings if the context has
+// them cached. Callers must not modify them.
+func cachedSettings(ctx context.Context, orgID uuid.UUID) (*models.OrganizationSettings, bool) {
+	c, _ := ctx.Value(settingsCacheKey{}).(*settingsCache)
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	settings, ok := c.settings[orgID]
+	return settings, ok
+}
+
+// cacheSettings keeps the organization's settings in the context's cache,
+// if it has one, and returns them
+func cacheSettings(ctx context.Context, org *models.Organization) *models.OrganizationSettings {
+	settings := org.Settings()
+	if c, _ := ctx.Value(settingsCacheKey{}).(*settingsCache); c != nil {
+		c.mu.Lock()
+		c.settings[org.ID] = &settings
+		c.mu.Unlock()
+	}
+	return &settings
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// SignupStore handles failed signup tracking
+type SignupStore struct {
+	db conn
+}
+
+const failedSignupColumns = `
+	id, email, host(ip_address), attempt_count, last_attempt_at, first_attempt_at,
+	contact_collected, collected_email, collected_phone, collected_discord,
+	collected_slack, preferred_contact, contact_message, contacted_at, contacted_by,
+	resolution, resolved_at, created_at, updated_at
+`
+
+// TrackFailure records a failed signup attempt and returns the attempt count for the email
+func (s *SignupStore) TrackFailure(ctx context.Context, email, ip string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT track_failed_signup($1, $2)", strings.ToLower(email), ip).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to track failed signup: %w", err)
+	}
+	return count, nil
+}
+
+// CollectContact attaches contact details to the most recent unresolved
+// attempt from the given email or IP address
+func (s *SignupStore) CollectContact(ctx context.Context, ip string, input *models.CollectContactInfoInput) (*models.FailedSignupAttempt, error) {
+	query := fmt.Sprintf(`
+		UPDATE failed_signup_attempts
+		SET contact_collected = true,
+		    collected_email = $3,
+		    collected_phone = $4,
+		    collected_discord = $5,
+		    collected_slack = $6,
+		    preferred_contact = $7,
+		    contact_message = $8
+		WHERE id = (
+			SELECT id FROM failed_signup_attempts
+			WHERE (email = $1 OR ip_address = $2::inet)
+			  AND resolved_at IS NULL
+			ORDER BY last_attempt_at DESC
+			LIMIT 1
+		)
+		RETURNING %s
+	`, failedSignupColumns)
+
+	attempt, err := scanFailedSignup(s.db.QueryRowContext(ctx, query,
+		strings.ToLower(input.Email), ip, input.Email, input.Phone, input.Discord,
+		input.Slack, input.PreferredContact, input.Message,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("failed signup not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect contact info: %w", err)
+	}
+
+	return attempt, nil
+}
+
+// List retrieves failed signup attempts with filtering
+func (s *SignupStore) List(ctx context.Context, filter *models.FailedSignupFilter) ([]models.FailedSignupAttempt, int, error) {
+	filter.SetDefaults()
+
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	if filter.ContactCollected != nil {
+		conditions = append(conditions, fmt.Sprintf("contact_collected = $%d", argNum))
+		args = append(args, *filter.ContactCollected)
+		argNum++
+	}
+
+	if filter.Unresolved {
+		conditions = append(conditions, "resolved_at IS NULL")
+	}
+
+	whereClause := "TRUE"
+	if len(conditions) > 0 {
+		whereClause = strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM failed_signup_attempts WHERE %s", whereClause)
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count failed signups: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM failed_signup_attempts
+		WHERE %s
+		ORDER BY last_attempt_at DESC
+		LIMIT $%d OFFSET $%d
+	`, failedSignupColumns, whereClause, argNum, argNum+1)
+	args = append(args, filter.PerPage, filter.Offset())
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list failed signups: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []models.FailedSignupAttempt
+	for rows.Next() {
+		attempt, err := scanFailedSignup(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan failed signup: %w", err)
+		}
+		attempts = append(attempts, *attempt)
+	}
+
+	return attempts, total, rows.Err()
+}
+
+// Resolve records the follow-up outcome for a failed signup
+func (s *SignupStore) Resolve(ctx context.Context, id, userID uuid.UUID, input *models.ResolveFailedSignupInput) (*models.FailedSignupAttempt, error) {
+	query := fmt.Sprintf(`
+		UPDATE failed_signup_attempts
+		SET resolution = $2,
+		    resolved_at = NOW(),
+		    contacted_by = $3,
+		    contacted_at = COALESCE(contacted_at, NOW())
+		WHERE id = $1 AND resolved_at IS NULL
+		RETURNING %s
+	`, failedSignupColumns)
+
+	attempt, err := scanFailedSignup(s.db.QueryRowContext(ctx, query, id, input.Resolution, userID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("failed signup not found or already resolved")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve failed signup: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func scanFailedSignup(row rowScanner) (*models.FailedSignupAttempt, error) {
+	a := &models.FailedSignupAttempt{}
+	err := row.Scan(
+		&a.ID, &a.Email, &a.IPAddress, &a.AttemptCount, &a.LastAttemptAt, &a.FirstAttemptAt,
+		&a.ContactCollected, &a.CollectedEmail, &a.CollectedPhone, &a.CollectedDiscord,
+		&a.CollectedSlack, &a.PreferredContact, &a.ContactMessage, &a.ContactedAt, &a.ContactedBy,
+		&a.Resolution, &a.ResolvedAt, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
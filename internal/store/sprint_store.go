@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// SprintStore handles sprint database operations
+type SprintStore struct {
+	db conn
+}
+
+const sprintColumns = `
+	id, organization_id, project_id, name, goal, status, start_date, end_date,
+	completed_at, created_by, created_at, updated_at
+`
+
+// Create creates a new planned sprint
+func (s *SprintStore) Create(ctx context.Context, orgID, createdBy uuid.UUID, input *models.CreateSprintInput) (*models.Sprint, error) {
+	sprint, err := scanSprint(s.db.QueryRowContext(ctx, `
+		INSERT INTO sprints (organization_id, project_id, name, goal, start_date, end_date, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+sprintColumns,
+		orgID, input.ProjectID, input.Name, input.Goal, input.Start, input.End, createdBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sprint: %w", err)
+	}
+	return sprint, nil
+}
+
+// GetByID retrieves a sprint by ID
+func (s *SprintStore) GetByID(ctx context.Context, orgID, sprintID uuid.UUID) (*models.Sprint, error) {
+	sprint, err := scanSprint(s.db.QueryRowContext(ctx,
+		"SELECT "+sprintColumns+" FROM sprints WHERE id = $1 AND organization_id = $2",
+		sprintID, orgID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("sprint not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sprint: %w", err)
+	}
+	return sprint, nil
+}
+
+// List retrieves an organization's sprints, latest first
+func (s *SprintStore) List(ctx context.Context, orgID uuid.UUID, filter *models.SprintListFilter) ([]models.Sprint, error) {
+	conditions := []string{"organization_id = $1"}
+	args := []interface{}{orgID}
+	argNum := 2
+
+	if filter.ProjectID != nil {
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", argNum))
+		args = append(args, *filter.ProjectID)
+		argNum++
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argNum))
+		args = append(args, *filter.Status)
+		argNum++
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+sprintColumns+" FROM sprints WHERE "+strings.Join(conditions, " AND ")+" ORDER BY start_date DESC, name",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sprints: %w", err)
+	}
+	defer rows.Close()
+
+	var sprints []models.Sprint
+	for rows.Next() {
+		sprint, err := scanSprint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sprint: %w", err)
+		}
+		sprints = append(sprints, *sprint)
+	}
+
+	return sprints, rows.Err()
+}
+
+// Update updates a sprint. Completing it records when.
+func (s *SprintStore) Update(ctx context.Context, orgID, sprintID uuid.UUID, input *models.UpdateSprintInput) (*models.Sprint, error) {
+	var updates []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *input.Name)
+		argNum++
+	}
+
+	if input.Goal != nil {
+		updates = append(updates, fmt.Sprintf("goal = $%d", argNum))
+		args = append(args, *input.Goal)
+		argNum++
+	}
+
+	if input.Status != nil {
+		updates = append(updates, fmt.Sprintf("status = $%d", argNum))
+		args = append(args, *input.Status)
+		argNum++
+		if *input.Status == models.SprintStatusCompleted {
+			updates = append(updates, "completed_at = COALESCE(completed_at, NOW())")
+		}
+	}
+
+	if input.Start != nil {
+		updates = append(updates, fmt.Sprintf("start_date = $%d", argNum))
+		args = append(args, *input.Start)
+		argNum++
+	}
+
+	if input.End != nil {
+		updates = append(updates, fmt.Sprintf("end_date = $%d", argNum))
+		args = append(args, *input.End)
+		argNum++
+	}
+
+	if len(updates) == 0 {
+		return s.GetByID(ctx, orgID, sprintID)
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE sprints SET %s WHERE id = $%d AND organization_id = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1,
+	)
+	args = append(args, sprintID, orgID)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sprint: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, models.NotFound("sprint not found")
+	}
+
+	return s.GetByID(ctx, orgID, sprintID)
+}
+
+// Delete removes a sprint. Its tickets are kept and lose the link.
+func (s *SprintStore) Delete(ctx context.Context, orgID, sprintID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM sprints WHERE id = $1 AND organization_id = $2",
+		sprintID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete sprint: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("sprint not found")
+	}
+	return nil
+}
+
+// Rollup summarizes the sprint's tickets
+func (s *SprintStore) Rollup(ctx context.Context, orgID, sprintID uuid.UUID) (*models.TicketRollup, error) {
+	return ticketRollup(ctx, s.db, orgID, "sprint_id", sprintID)
+}
+
+// Burndown computes the sprint's burndown from its current tickets
+func (s *SprintStore) Burndown(ctx context.Context, sprint *models.Sprint) (*models.Burndown, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT status, story_points, completed_at
+		FROM change_tickets
+		WHERE organization_id = $1 AND sprint_id = $2 AND deleted_at IS NULL
+	`, sprint.OrganizationID, sprint.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sprint tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []models.BurndownTicket
+	for rows.Next() {
+		var t models.BurndownTicket
+		if err := rows.Scan(&t.Status, &t.StoryPoints, &t.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		tickets = append(tickets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return models.BuildBurndown(sprint, tickets, time.Now()), nil
+}
+
+func scanSprint(row rowScanner) (*models.Sprint, error) {
+	sp := &models.Sprint{}
+	err := row.Scan(
+		&sp.ID, &sp.OrganizationID, &sp.ProjectID, &sp.Name, &sp.Goal, &sp.Status, &sp.StartDate, &sp.EndDate,
+		&sp.CompletedAt, &sp.CreatedBy, &sp.CreatedAt, &sp.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
@@ -0,0 +1,322 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// SSOStore handles single sign-on settings and the accounts SSO signs in
+type SSOStore struct {
+	db conn
+}
+
+const ssoConnectionColumns = `
+	id, organization_id, provider, enabled, jit_provisioning, default_roles,
+	group_roles, sync_roles, created_at, updated_at, created_by, updated_by
+`
+
+// ListConnections returns an organization's SSO connections
+func (s *SSOStore) ListConnections(ctx context.Context, orgID uuid.UUID) ([]models.SSOConnection, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+ssoConnectionColumns+" FROM sso_connections WHERE organization_id = $1 ORDER BY provider",
+		orgID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sso connections: %w", err)
+	}
+	defer rows.Close()
+
+	var conns []models.SSOConnection
+	for rows.Next() {
+		conn, err := scanSSOConnection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sso connection: %w", err)
+		}
+		conns = append(conns, *conn)
+	}
+
+	return conns, rows.Err()
+}
+
+// GetConnection returns an organization's connection for a provider
+func (s *SSOStore) GetConnection(ctx context.Context, orgID uuid.UUID, provider models.SSOProvider) (*models.SSOConnection, error) {
+	conn, err := scanSSOConnection(s.db.QueryRowContext(ctx,
+		"SELECT "+ssoConnectionColumns+" FROM sso_connections WHERE organization_id = $1 AND provider = $2",
+		orgID, provider,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("sso connection not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sso connection: %w", err)
+	}
+	return conn, nil
+}
+
+// UpsertConnection creates or updates an organization's connection for a
+// provider. New connections start from the column defaults.
+func (s *SSOStore) UpsertConnection(ctx context.Context, orgID, userID uuid.UUID, provider models.SSOProvider, input *models.UpsertSSOConnectionInput) (*models.SSOConnection, error) {
+	var defaultRoles interface{}
+	if input.DefaultRoles != nil {
+		defaultRoles = pq.Array(input.DefaultRoles)
+	}
+	var groupRoles []byte
+	if input.GroupRoles != nil {
+		groupRoles, _ = json.Marshal(*input.GroupRoles)
+	}
+
+	conn, err := scanSSOConnection(s.db.QueryRowContext(ctx, `
+		INSERT INTO sso_connections (
+			organization_id, provider, enabled, jit_provisioning, default_roles,
+			group_roles, sync_roles, created_by, updated_by
+		) VALUES (
+			$1, $2, COALESCE($3, true), COALESCE($4, true), COALESCE($5::varchar[], '{"user"}'),
+			COALESCE($6::jsonb, '{}'), COALESCE($7, false), $8, $8
+		)
+		ON CONFLICT (organization_id, provider) DO UPDATE SET
+			enabled = COALESCE($3, sso_connections.enabled),
+			jit_provisioning = COALESCE($4, sso_connections.jit_provisioning),
+			default_roles = COALESCE($5::varchar[], sso_connections.default_roles),
+			group_roles = COALESCE($6::jsonb, sso_connections.group_roles),
+			sync_roles = COALESCE($7, sso_connections.sync_roles),
+			updated_by = $8
+		RETURNING `+ssoConnectionColumns,
+		orgID, provider, input.Enabled, input.JITProvisioning, defaultRoles,
+		nullableJSON(groupRoles), input.SyncRoles, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save sso connection: %w", err)
+	}
+	return conn, nil
+}
+
+// DeleteConnection removes an organization's connection for a provider.
+// Linked accounts keep their identity and work again if it is re-created.
+func (s *SSOStore) DeleteConnection(ctx context.Context, orgID uuid.UUID, provider models.SSOProvider) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM sso_connections WHERE organization_id = $1 AND provider = $2",
+		orgID, provider,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete sso connection: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("sso connection not found")
+	}
+	return nil
+}
+
+// ListDomains returns the email domains an organization has claimed
+func (s *SSOStore) ListDomains(ctx context.Context, orgID uuid.UUID) ([]models.SSODomain, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT domain, organization_id, created_at, created_by
+		FROM sso_domains
+		WHERE organization_id = $1
+		ORDER BY domain
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sso domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []models.SSODomain
+	for rows.Next() {
+		var d models.SSODomain
+		if err := rows.Scan(&d.Domain, &d.OrganizationID, &d.CreatedAt, &d.CreatedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan sso domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+
+	return domains, rows.Err()
+}
+
+// AddDomain claims an email domain for an organization
+func (s *SSOStore) AddDomain(ctx context.Context, orgID, userID uuid.UUID, domain string) (*models.SSODomain, error) {
+	d := &models.SSODomain{}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO sso_domains (domain, organization_id, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING domain, organization_id, created_at, created_by
+	`, domain, orgID, userID).Scan(&d.Domain, &d.OrganizationID, &d.CreatedAt, &d.CreatedBy)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("domain is already claimed")
+		}
+		return nil, fmt.Errorf("failed to add sso domain: %w", err)
+	}
+	return d, nil
+}
+
+// RemoveDomain releases an organization's claim on an email domain
+func (s *SSOStore) RemoveDomain(ctx context.Context, orgID uuid.UUID, domain string) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM sso_domains WHERE organization_id = $1 AND domain = $2",
+		orgID, domain,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove sso domain: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("sso domain not found")
+	}
+	return nil
+}
+
+// OrganizationForDomain returns the live organization that claimed an
+// email domain
+func (s *SSOStore) OrganizationForDomain(ctx context.Context, domain string) (uuid.UUID, error) {
+	var orgID uuid.UUID
+	err := s.db.QueryRowContext(ctx, `
+		SELECT d.organization_id
+		FROM sso_domains d
+		JOIN organizations o ON o.id = d.organization_id
+		WHERE d.domain = $1 AND o.deleted_at IS NULL
+	`, domain).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, models.NotFound("sso domain not found")
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find sso domain: %w", err)
+	}
+	return orgID, nil
+}
+
+// FindLinkedUser returns the active account linked to an SSO identity
+func (s *SSOStore) FindLinkedUser(ctx context.Context, provider models.SSOProvider, subject string) (*models.LoginUser, error) {
+	query := "SELECT " + loginUserColumns + loginUserFrom + " AND u.oauth_provider = $1 AND u.oauth_subject = $2"
+	u, err := scanLoginUser(s.db.QueryRowContext(ctx, query, provider, subject))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return u, nil
+}
+
+// FindUserByEmail returns the active account with an email in an organization
+func (s *SSOStore) FindUserByEmail(ctx context.Context, orgID uuid.UUID, email string) (*models.LoginUser, error) {
+	query := "SELECT " + loginUserColumns + loginUserFrom + " AND u.organization_id = $1 AND lower(u.email) = lower($2)"
+	u, err := scanLoginUser(s.db.QueryRowContext(ctx, query, orgID, email))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return u, nil
+}
+
+// EmailInUse returns true if any account in the organization, active or
+// not, has the email
+func (s *SSOStore) EmailInUse(ctx context.Context, orgID uuid.UUID, email string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE organization_id = $1 AND lower(email) = lower($2))",
+		orgID, email,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return exists, nil
+}
+
+// LinkUser links an SSO identity to an existing account
+func (s *SSOStore) LinkUser(ctx context.Context, userID uuid.UUID, identity *models.SSOIdentity) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET oauth_provider = $2, oauth_subject = $3, oauth_picture_url = NULLIF($4, ''),
+		    oauth_last_sync = NOW(), email_verified = true, updated_at = NOW()
+		WHERE id = $1
+	`, userID, identity.Provider, identity.Subject, identity.Picture)
+	if err != nil {
+		return fmt.Errorf("failed to link account: %w", err)
+	}
+	return nil
+}
+
+// ProvisionUser creates an account for an SSO identity on first sign-in
+func (s *SSOStore) ProvisionUser(ctx context.Context, orgID uuid.UUID, identity *models.SSOIdentity, roles []string) (*models.LoginUser, error) {
+	name := identity.Name
+	if name == "" {
+		name = identity.Email
+	}
+
+	var userID uuid.UUID
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO users (
+			organization_id, email, full_name, roles, is_approver, email_verified,
+			oauth_provider, oauth_subject, oauth_picture_url, oauth_last_sync
+		) VALUES ($1, $2, $3, $4, 'approver' = ANY($4::varchar[]), true, $5, $6, NULLIF($7, ''), NOW())
+		RETURNING id
+	`, orgID, identity.Email, name, pq.Array(roles), identity.Provider, identity.Subject, identity.Picture).Scan(&userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("user already exists")
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	query := "SELECT " + loginUserColumns + loginUserFrom + " AND u.id = $1"
+	u, err := scanLoginUser(s.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// SyncUser records an SSO sign-in, replacing the user's roles when roles
+// is not nil
+func (s *SSOStore) SyncUser(ctx context.Context, userID uuid.UUID, identity *models.SSOIdentity, roles []string) error {
+	var err error
+	if roles != nil {
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE users
+			SET roles = $2, is_approver = 'approver' = ANY($2::varchar[]),
+			    oauth_picture_url = NULLIF($3, ''), oauth_last_sync = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, userID, pq.Array(roles), identity.Picture)
+	} else {
+		_, err = s.db.ExecContext(ctx,
+			"UPDATE users SET oauth_picture_url = NULLIF($2, ''), oauth_last_sync = NOW() WHERE id = $1",
+			userID, identity.Picture,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync user: %w", err)
+	}
+	return nil
+}
+
+func scanSSOConnection(row rowScanner) (*models.SSOConnection, error) {
+	conn := &models.SSOConnection{}
+	var defaultRoles []string
+	var groupRoles []byte
+	err := row.Scan(
+		&conn.ID, &conn.OrganizationID, &conn.Provider, &conn.Enabled, &conn.JITProvisioning,
+		pq.Array(&defaultRoles), &groupRoles, &conn.SyncRoles, &conn.CreatedAt, &conn.UpdatedAt,
+		&conn.CreatedBy, &conn.UpdatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.DefaultRoles = make([]models.UserRole, len(defaultRoles))
+	for i, r := range defaultRoles {
+		conn.DefaultRoles[i] = models.UserRole(r)
+	}
+	conn.GroupRoles = map[string]models.UserRole{}
+	if len(groupRoles) > 0 {
+		if err := json.Unmarshal(groupRoles, &conn.GroupRoles); err != nil {
+			return nil, fmt.Errorf("invalid group roles: %w", err)
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+type timeoutKey struct{}
+
+// WithStatementTimeout runs the context's statements with Postgres's
+// statement_timeout set to d instead of the database's
+// statement_timeout_ms, for work such as streaming exports that
+// legitimately runs longer. Zero lets statements run unbounded. Like the
+// organization, it is applied per connection (see openScoped).
+func WithStatementTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, d)
+}
+
+// timeoutSetting is the statement_timeout value for the context, empty to
+// keep the one the DSN sets
+func timeoutSetting(ctx context.Context) string {
+	d, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
+
+// LongRunning gives the context the statement timeout configured for
+// long-running work, database.job_statement_timeout_ms: the worker's
+// archive and report jobs and streaming audit exports
+func (s *Store) LongRunning(ctx context.Context) context.Context {
+	return WithStatementTimeout(ctx, s.jobTimeout)
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeoutSetting(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"the DSN's by default", context.Background(), ""},
+		{"unbounded", WithStatementTimeout(context.Background(), 0), "0"},
+		{"in milliseconds", WithStatementTimeout(context.Background(), 10*time.Minute), "600000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeoutSetting(tt.ctx); got != tt.want {
+				t.Errorf("timeoutSetting() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/afterdarksys/adsops-utils/internal/config"
@@ -12,52 +13,180 @@ import (
 // Store provides access to all data stores
 type Store struct {
 	db      *sql.DB
-	Tickets *TicketStore
+	Tickets TicketStorer
 	Projects *ProjectStore
 	Groups  *GroupStore
-	Repositories *RepositoryStore
+	Repositories RepositoryStorer
 	Contacts *ContactStore
 	Employees *EmployeeStore
 	ACLs    *ACLStore
-	Audit   *AuditStore
+	Audit   AuditStorer
+	ApprovalRules *ApprovalRuleStore
+	Emergency *EmergencyStore
+	PIRs    *PIRStore
+	Retention *RetentionStore
+	Organizations *OrganizationStore
+	Signups *SignupStore
+	Idempotency *IdempotencyStore
+	Revisions *RevisionStore
+	SavedFilters *SavedFilterStore
+	Templates *TicketTemplateStore
+	GitHub  *GitHubStore
+	Comments CommentStorer
+	Jira    *JiraStore
+	Approvals *ApprovalStore
+	Users   *UserStore
+	Inventory *InventoryStore
+	Calendar *CalendarStore
+	CAB     *CABStore
+	AssignmentRules *AssignmentRuleStore
+	Auth    *AuthStore
+	SSO     *SSOStore
+	CustomFields *CustomFieldStore
+	Workflows *WorkflowStore
+	Labels  *LabelStore
+	Epics   *EpicStore
+	Sprints *SprintStore
+	Worklogs *WorklogStore
+	Attachments *AttachmentStore
+	Portal  *PortalStore
+	Reports *ReportStore
+	Notifications *NotificationStore
+	Webhooks *WebhookStore
+	ReportJobs *ReportJobStore
+
+	inventoryDB *sql.DB
+	replicaDB   *sql.DB
+	replica     *replicaConn // Set by OpenReplica
+	conn        conn         // The database, or the transaction of a WithTx store
+	ticketCache *ticketCache // Set by CacheTickets
+	staleKeys   *[]string    // Cache keys to evict once the WithTx transaction commits
+	metrics     *queryMetrics // Set by Instrument
+	jobTimeout  time.Duration // Statement timeout for LongRunning contexts
 }
 
 // New creates a new store instance
 func New(cfg *config.DatabaseConfig) (*Store, error) {
-	db, err := sql.Open("postgres", cfg.DSN())
+	db, err := openScoped(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	configurePool(db, cfg)
 
 	// Test connection
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	s := &Store{db: db}
-	s.Tickets = &TicketStore{db: db}
-	s.Projects = &ProjectStore{db: db}
-	s.Groups = &GroupStore{db: db}
-	s.Repositories = &RepositoryStore{db: db}
-	s.Contacts = &ContactStore{db: db}
-	s.Employees = &EmployeeStore{db: db}
-	s.ACLs = &ACLStore{db: db}
-	s.Audit = &AuditStore{db: db}
+	s := &Store{db: db, conn: dbConn{DB: db}, jobTimeout: time.Duration(cfg.JobStatementTimeoutMS) * time.Millisecond}
+	s.bind(s.conn)
 
 	return s, nil
 }
 
+// configurePool applies the connection pool settings. The statement
+// timeout is set per connection through the DSN, and per context by
+// WithStatementTimeout.
+func configurePool(db *sql.DB, cfg *config.DatabaseConfig) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleSeconds) * time.Second)
+}
+
+// bind points every store at c, the database or a transaction
+func (s *Store) bind(c conn) {
+	s.Tickets = &TicketStore{db: c, read: s.reader(c)}
+	if s.ticketCache != nil {
+		s.Tickets = &cachedTickets{TicketStorer: s.Tickets, ticketCache: s.ticketCache, stale: s.staleKeys}
+	}
+	tickets := ticketEvicter{cache: s.ticketCache, stale: s.staleKeys}
+	s.Projects = &ProjectStore{db: c}
+	s.Groups = &GroupStore{db: c}
+	s.Repositories = &RepositoryStore{db: c}
+	s.Contacts = &ContactStore{db: c}
+	s.Employees = &EmployeeStore{db: c}
+	s.ACLs = &ACLStore{db: c}
+	s.Audit = &AuditStore{db: c}
+	s.ApprovalRules = &ApprovalRuleStore{db: c}
+	s.Emergency = &EmergencyStore{db: c, tickets: tickets}
+	s.PIRs = &PIRStore{db: c}
+	s.Retention = &RetentionStore{db: c}
+	s.Organizations = &OrganizationStore{db: c}
+	s.Signups = &SignupStore{db: c}
+	s.Idempotency = &IdempotencyStore{db: c}
+	s.Revisions = &RevisionStore{db: c}
+	s.SavedFilters = &SavedFilterStore{db: c}
+	s.Templates = &TicketTemplateStore{db: c}
+	s.GitHub = &GitHubStore{db: c}
+	s.Comments = &CommentStore{db: c}
+	s.Jira = &JiraStore{db: c}
+	s.Approvals = &ApprovalStore{db: c, tickets: tickets}
+	s.Users = &UserStore{db: c}
+	if s.inventoryDB == nil {
+		s.Inventory = &InventoryStore{db: c}
+	}
+	s.Calendar = &CalendarStore{db: c}
+	s.CAB = &CABStore{db: c, tickets: tickets}
+	s.AssignmentRules = &AssignmentRuleStore{db: c, tickets: tickets}
+	s.Auth = &AuthStore{db: c}
+	s.SSO = &SSOStore{db: c}
+	s.CustomFields = &CustomFieldStore{db: c}
+	s.Workflows = &WorkflowStore{db: c}
+	s.Labels = &LabelStore{db: c, tickets: tickets}
+	s.Epics = &EpicStore{db: c}
+	s.Sprints = &SprintStore{db: c}
+	s.Worklogs = &WorklogStore{db: c, tickets: tickets}
+	s.Attachments = &AttachmentStore{db: c}
+	s.Portal = &PortalStore{db: c}
+	s.Reports = &ReportStore{db: s.reader(c)}
+	s.Notifications = &NotificationStore{db: c}
+	s.Webhooks = &WebhookStore{db: c}
+	s.ReportJobs = &ReportJobStore{db: c}
+}
+
+// OpenInventory points the inventory store at a separate database, for
+// deployments where hostctl's inventory lives outside the main database
+func (s *Store) OpenInventory(cfg *config.DatabaseConfig) error {
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		return fmt.Errorf("failed to open inventory database: %w", err)
+	}
+
+	configurePool(db, cfg)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping inventory database: %w", err)
+	}
+
+	s.inventoryDB = db
+	s.Inventory = &InventoryStore{db: dbConn{DB: db, metrics: s.metrics}}
+	return nil
+}
+
 // Close closes the database connection
 func (s *Store) Close() error {
+	if s.replicaDB != nil {
+		s.replicaDB.Close()
+	}
+	if s.inventoryDB != nil {
+		s.inventoryDB.Close()
+	}
+	if s.db == nil {
+		return nil
+	}
 	return s.db.Close()
 }
 
-// BeginTx starts a new transaction
-func (s *Store) BeginTx(ctx context.Context) (*sql.Tx, error) {
-	return s.db.BeginTx(ctx, nil)
+// BeginTx starts a new transaction, or a savepoint when the store is
+// already in one
+func (s *Store) BeginTx(ctx context.Context) (*Tx, error) {
+	if s.conn == nil {
+		return nil, fmt.Errorf("store has no database")
+	}
+	return s.conn.BeginTx(ctx, nil)
 }
 
 // DB returns the underlying database connection
@@ -65,19 +194,49 @@ func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
-// WithTx executes a function within a transaction
-func (s *Store) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+// WithTx runs fn with a copy of the store whose stores all work in one
+// transaction, committed if fn returns nil and rolled back otherwise. Use
+// it for changes that must not be half made, such as a ticket and its
+// audit trail. Store methods that begin their own transactions run them as
+// savepoints inside this one. Called on a store that is already in a
+// transaction, WithTx nests as a savepoint too.
+//
+// The inventory store stays on its own connection when it has one. A store
+// without a database, such as one from memstore.New, has no transaction to
+// run in and passes itself to fn.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+
 	tx, err := s.BeginTx(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	txStore := &Store{db: s.db, inventoryDB: s.inventoryDB, conn: tx, ticketCache: s.ticketCache, staleKeys: s.staleKeys, metrics: s.metrics, jobTimeout: s.jobTimeout}
+	if s.ticketCache != nil && s.staleKeys == nil {
+		txStore.staleKeys = new([]string)
+	}
+	txStore.bind(tx)
+	if s.inventoryDB != nil {
+		txStore.Inventory = s.Inventory
 	}
 
-	if err := fn(tx); err != nil {
+	if err := fn(txStore); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("tx error: %v, rollback error: %v", err, rbErr)
 		}
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	// Readers may have cached what the transaction replaced before it
+	// committed; the outermost transaction evicts that
+	if s.staleKeys == nil && txStore.staleKeys != nil && len(*txStore.staleKeys) > 0 {
+		s.ticketCache.cache.Delete(ctx, *txStore.staleKeys...)
+	}
+	return nil
 }
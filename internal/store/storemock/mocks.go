@@ -0,0 +1,665 @@
+// Code generated by storemock/gen. DO NOT EDIT.
+
+package storemock
+
+import (
+	"context"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+	"github.com/google/uuid"
+)
+
+var (
+	_ store.TicketStorer     = (*TicketStore)(nil)
+	_ store.RepositoryStorer = (*RepositoryStore)(nil)
+	_ store.AuditStorer      = (*AuditStore)(nil)
+	_ store.CommentStorer    = (*CommentStore)(nil)
+)
+
+// TicketStore mocks store.TicketStorer
+type TicketStore struct {
+	CreateFunc                       func(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateTicketInput) (*models.Ticket, error)
+	GetNumberByExternalReferenceFunc func(ctx context.Context, orgID uuid.UUID, reference string) (string, error)
+	ImportFunc                       func(ctx context.Context, orgID, userID uuid.UUID, input *models.ImportTicketInput) (*models.Ticket, error)
+	GetByIDFunc                      func(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error)
+	GetByIDsFunc                     func(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Ticket, error)
+	ListScheduledFunc                func(ctx context.Context, orgID uuid.UUID, involving *uuid.UUID, from, to time.Time, limit int) ([]models.Ticket, error)
+	GetByNumberFunc                  func(ctx context.Context, orgID uuid.UUID, ticketNumber string) (*models.Ticket, error)
+	ListFunc                         func(ctx context.Context, orgID uuid.UUID, filter *models.TicketListFilter) ([]models.Ticket, int, error)
+	SearchFunc                       func(ctx context.Context, orgID uuid.UUID, query string, limit int) ([]models.TicketSearchResult, error)
+	UpdateFunc                       func(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error)
+	UpdateStatusFunc                 func(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus, expectedVersion int) (*models.TicketTransition, error)
+	SubmitFunc                       func(ctx context.Context, orgID, ticketID, userID uuid.UUID, plan *models.ApprovalPlan, expectedVersion int) (*models.TicketTransition, error)
+	CloseFunc                        func(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error)
+	CancelFunc                       func(ctx context.Context, orgID, ticketID uuid.UUID, reason string, expectedVersion int) (*models.TicketTransition, error)
+	ReopenFunc                       func(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error)
+	DeleteFunc                       func(ctx context.Context, orgID, ticketID, userID uuid.UUID, reason *string, expectedVersion int) error
+	ListTrashFunc                    func(ctx context.Context, orgID uuid.UUID, page, perPage int) ([]models.TrashedTicket, int, error)
+	RestoreFunc                      func(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error)
+	PurgeTrashFunc                   func(ctx context.Context, orgID uuid.UUID, before time.Time) ([]uuid.UUID, error)
+	GetQueueFunc                     func(ctx context.Context, orgID uuid.UUID) ([]models.Ticket, error)
+	AssignFunc                       func(ctx context.Context, orgID, ticketID, userID uuid.UUID) error
+	AddWatcherFunc                   func(ctx context.Context, orgID, ticketID, userID uuid.UUID) error
+	RemoveWatcherFunc                func(ctx context.Context, orgID, ticketID, userID uuid.UUID) error
+	LinkRepositoryFunc               func(ctx context.Context, ticketID, repoID, linkedBy uuid.UUID, input *models.LinkRepositoryInput) error
+	UnlinkRepositoryFunc             func(ctx context.Context, ticketID, repoID uuid.UUID) error
+	SetAffectedResourcesFunc         func(ctx context.Context, orgID, ticketID uuid.UUID, resources []models.AffectedResource) error
+	ListAffectedResourcesFunc        func(ctx context.Context, ticketID uuid.UUID) ([]models.AffectedResource, error)
+}
+
+// Create calls CreateFunc
+func (m *TicketStore) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateTicketInput) (*models.Ticket, error) {
+	if m.CreateFunc == nil {
+		panic("storemock: TicketStore.Create called but CreateFunc is not set")
+	}
+	return m.CreateFunc(ctx, orgID, userID, input)
+}
+
+// GetNumberByExternalReference calls GetNumberByExternalReferenceFunc
+func (m *TicketStore) GetNumberByExternalReference(ctx context.Context, orgID uuid.UUID, reference string) (string, error) {
+	if m.GetNumberByExternalReferenceFunc == nil {
+		panic("storemock: TicketStore.GetNumberByExternalReference called but GetNumberByExternalReferenceFunc is not set")
+	}
+	return m.GetNumberByExternalReferenceFunc(ctx, orgID, reference)
+}
+
+// Import calls ImportFunc
+func (m *TicketStore) Import(ctx context.Context, orgID, userID uuid.UUID, input *models.ImportTicketInput) (*models.Ticket, error) {
+	if m.ImportFunc == nil {
+		panic("storemock: TicketStore.Import called but ImportFunc is not set")
+	}
+	return m.ImportFunc(ctx, orgID, userID, input)
+}
+
+// GetByID calls GetByIDFunc
+func (m *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	if m.GetByIDFunc == nil {
+		panic("storemock: TicketStore.GetByID called but GetByIDFunc is not set")
+	}
+	return m.GetByIDFunc(ctx, orgID, ticketID)
+}
+
+// GetByIDs calls GetByIDsFunc
+func (m *TicketStore) GetByIDs(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Ticket, error) {
+	if m.GetByIDsFunc == nil {
+		panic("storemock: TicketStore.GetByIDs called but GetByIDsFunc is not set")
+	}
+	return m.GetByIDsFunc(ctx, orgID, ticketIDs)
+}
+
+// ListScheduled calls ListScheduledFunc
+func (m *TicketStore) ListScheduled(ctx context.Context, orgID uuid.UUID, involving *uuid.UUID, from, to time.Time, limit int) ([]models.Ticket, error) {
+	if m.ListScheduledFunc == nil {
+		panic("storemock: TicketStore.ListScheduled called but ListScheduledFunc is not set")
+	}
+	return m.ListScheduledFunc(ctx, orgID, involving, from, to, limit)
+}
+
+// GetByNumber calls GetByNumberFunc
+func (m *TicketStore) GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNumber string) (*models.Ticket, error) {
+	if m.GetByNumberFunc == nil {
+		panic("storemock: TicketStore.GetByNumber called but GetByNumberFunc is not set")
+	}
+	return m.GetByNumberFunc(ctx, orgID, ticketNumber)
+}
+
+// List calls ListFunc
+func (m *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.TicketListFilter) ([]models.Ticket, int, error) {
+	if m.ListFunc == nil {
+		panic("storemock: TicketStore.List called but ListFunc is not set")
+	}
+	return m.ListFunc(ctx, orgID, filter)
+}
+
+// Search calls SearchFunc
+func (m *TicketStore) Search(ctx context.Context, orgID uuid.UUID, query string, limit int) ([]models.TicketSearchResult, error) {
+	if m.SearchFunc == nil {
+		panic("storemock: TicketStore.Search called but SearchFunc is not set")
+	}
+	return m.SearchFunc(ctx, orgID, query, limit)
+}
+
+// Update calls UpdateFunc
+func (m *TicketStore) Update(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error) {
+	if m.UpdateFunc == nil {
+		panic("storemock: TicketStore.Update called but UpdateFunc is not set")
+	}
+	return m.UpdateFunc(ctx, orgID, ticketID, userID, input)
+}
+
+// UpdateStatus calls UpdateStatusFunc
+func (m *TicketStore) UpdateStatus(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus, expectedVersion int) (*models.TicketTransition, error) {
+	if m.UpdateStatusFunc == nil {
+		panic("storemock: TicketStore.UpdateStatus called but UpdateStatusFunc is not set")
+	}
+	return m.UpdateStatusFunc(ctx, orgID, ticketID, status, expectedVersion)
+}
+
+// Submit calls SubmitFunc
+func (m *TicketStore) Submit(ctx context.Context, orgID, ticketID, userID uuid.UUID, plan *models.ApprovalPlan, expectedVersion int) (*models.TicketTransition, error) {
+	if m.SubmitFunc == nil {
+		panic("storemock: TicketStore.Submit called but SubmitFunc is not set")
+	}
+	return m.SubmitFunc(ctx, orgID, ticketID, userID, plan, expectedVersion)
+}
+
+// Close calls CloseFunc
+func (m *TicketStore) Close(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	if m.CloseFunc == nil {
+		panic("storemock: TicketStore.Close called but CloseFunc is not set")
+	}
+	return m.CloseFunc(ctx, orgID, ticketID, expectedVersion)
+}
+
+// Cancel calls CancelFunc
+func (m *TicketStore) Cancel(ctx context.Context, orgID, ticketID uuid.UUID, reason string, expectedVersion int) (*models.TicketTransition, error) {
+	if m.CancelFunc == nil {
+		panic("storemock: TicketStore.Cancel called but CancelFunc is not set")
+	}
+	return m.CancelFunc(ctx, orgID, ticketID, reason, expectedVersion)
+}
+
+// Reopen calls ReopenFunc
+func (m *TicketStore) Reopen(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	if m.ReopenFunc == nil {
+		panic("storemock: TicketStore.Reopen called but ReopenFunc is not set")
+	}
+	return m.ReopenFunc(ctx, orgID, ticketID, expectedVersion)
+}
+
+// Delete calls DeleteFunc
+func (m *TicketStore) Delete(ctx context.Context, orgID, ticketID, userID uuid.UUID, reason *string, expectedVersion int) error {
+	if m.DeleteFunc == nil {
+		panic("storemock: TicketStore.Delete called but DeleteFunc is not set")
+	}
+	return m.DeleteFunc(ctx, orgID, ticketID, userID, reason, expectedVersion)
+}
+
+// ListTrash calls ListTrashFunc
+func (m *TicketStore) ListTrash(ctx context.Context, orgID uuid.UUID, page, perPage int) ([]models.TrashedTicket, int, error) {
+	if m.ListTrashFunc == nil {
+		panic("storemock: TicketStore.ListTrash called but ListTrashFunc is not set")
+	}
+	return m.ListTrashFunc(ctx, orgID, page, perPage)
+}
+
+// Restore calls RestoreFunc
+func (m *TicketStore) Restore(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	if m.RestoreFunc == nil {
+		panic("storemock: TicketStore.Restore called but RestoreFunc is not set")
+	}
+	return m.RestoreFunc(ctx, orgID, ticketID)
+}
+
+// PurgeTrash calls PurgeTrashFunc
+func (m *TicketStore) PurgeTrash(ctx context.Context, orgID uuid.UUID, before time.Time) ([]uuid.UUID, error) {
+	if m.PurgeTrashFunc == nil {
+		panic("storemock: TicketStore.PurgeTrash called but PurgeTrashFunc is not set")
+	}
+	return m.PurgeTrashFunc(ctx, orgID, before)
+}
+
+// GetQueue calls GetQueueFunc
+func (m *TicketStore) GetQueue(ctx context.Context, orgID uuid.UUID) ([]models.Ticket, error) {
+	if m.GetQueueFunc == nil {
+		panic("storemock: TicketStore.GetQueue called but GetQueueFunc is not set")
+	}
+	return m.GetQueueFunc(ctx, orgID)
+}
+
+// Assign calls AssignFunc
+func (m *TicketStore) Assign(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	if m.AssignFunc == nil {
+		panic("storemock: TicketStore.Assign called but AssignFunc is not set")
+	}
+	return m.AssignFunc(ctx, orgID, ticketID, userID)
+}
+
+// AddWatcher calls AddWatcherFunc
+func (m *TicketStore) AddWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	if m.AddWatcherFunc == nil {
+		panic("storemock: TicketStore.AddWatcher called but AddWatcherFunc is not set")
+	}
+	return m.AddWatcherFunc(ctx, orgID, ticketID, userID)
+}
+
+// RemoveWatcher calls RemoveWatcherFunc
+func (m *TicketStore) RemoveWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	if m.RemoveWatcherFunc == nil {
+		panic("storemock: TicketStore.RemoveWatcher called but RemoveWatcherFunc is not set")
+	}
+	return m.RemoveWatcherFunc(ctx, orgID, ticketID, userID)
+}
+
+// LinkRepository calls LinkRepositoryFunc
+func (m *TicketStore) LinkRepository(ctx context.Context, ticketID, repoID, linkedBy uuid.UUID, input *models.LinkRepositoryInput) error {
+	if m.LinkRepositoryFunc == nil {
+		panic("storemock: TicketStore.LinkRepository called but LinkRepositoryFunc is not set")
+	}
+	return m.LinkRepositoryFunc(ctx, ticketID, repoID, linkedBy, input)
+}
+
+// UnlinkRepository calls UnlinkRepositoryFunc
+func (m *TicketStore) UnlinkRepository(ctx context.Context, ticketID, repoID uuid.UUID) error {
+	if m.UnlinkRepositoryFunc == nil {
+		panic("storemock: TicketStore.UnlinkRepository called but UnlinkRepositoryFunc is not set")
+	}
+	return m.UnlinkRepositoryFunc(ctx, ticketID, repoID)
+}
+
+// SetAffectedResources calls SetAffectedResourcesFunc
+func (m *TicketStore) SetAffectedResources(ctx context.Context, orgID, ticketID uuid.UUID, resources []models.AffectedResource) error {
+	if m.SetAffectedResourcesFunc == nil {
+		panic("storemock: TicketStore.SetAffectedResources called but SetAffectedResourcesFunc is not set")
+	}
+	return m.SetAffectedResourcesFunc(ctx, orgID, ticketID, resources)
+}
+
+// ListAffectedResources calls ListAffectedResourcesFunc
+func (m *TicketStore) ListAffectedResources(ctx context.Context, ticketID uuid.UUID) ([]models.AffectedResource, error) {
+	if m.ListAffectedResourcesFunc == nil {
+		panic("storemock: TicketStore.ListAffectedResources called but ListAffectedResourcesFunc is not set")
+	}
+	return m.ListAffectedResourcesFunc(ctx, ticketID)
+}
+
+// RepositoryStore mocks store.RepositoryStorer
+type RepositoryStore struct {
+	CreateFunc                 func(ctx context.Context, orgID uuid.UUID, input *models.CreateRepositoryInput) (*models.Repository, error)
+	GetByIDFunc                func(ctx context.Context, orgID, repoID uuid.UUID) (*models.Repository, error)
+	GetByURLFunc               func(ctx context.Context, orgID uuid.UUID, url string) (*models.Repository, error)
+	ListFunc                   func(ctx context.Context, orgID uuid.UUID, filter *models.RepositoryListFilter) ([]models.Repository, int, error)
+	UpdateFunc                 func(ctx context.Context, orgID, repoID uuid.UUID, input *models.UpdateRepositoryInput) (*models.Repository, error)
+	DeleteFunc                 func(ctx context.Context, orgID, repoID uuid.UUID) error
+	ApplySyncFunc              func(ctx context.Context, orgID, repoID uuid.UUID, details *models.RepositoryDetails) (*models.Repository, error)
+	RecordSyncErrorFunc        func(ctx context.Context, orgID, repoID uuid.UUID, message string) error
+	ListSyncableFunc           func(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Repository, error)
+	GetSyncTokenFunc           func(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) (*models.RepositorySyncToken, error)
+	ListSyncTokensFunc         func(ctx context.Context, orgID uuid.UUID) ([]models.RepositorySyncToken, error)
+	SetSyncTokenFunc           func(ctx context.Context, orgID, userID uuid.UUID, provider models.RepositoryProvider, input *models.UpdateRepositorySyncTokenInput) (*models.RepositorySyncToken, error)
+	DeleteSyncTokenFunc        func(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) error
+	ListSyncOrganizationsFunc  func(ctx context.Context) ([]uuid.UUID, error)
+	GetTicketRepositoriesFunc  func(ctx context.Context, ticketID uuid.UUID) ([]models.TicketRepository, error)
+	ListTicketRepositoriesFunc func(ctx context.Context, ticketIDs []uuid.UUID) ([]models.TicketRepository, error)
+}
+
+// Create calls CreateFunc
+func (m *RepositoryStore) Create(ctx context.Context, orgID uuid.UUID, input *models.CreateRepositoryInput) (*models.Repository, error) {
+	if m.CreateFunc == nil {
+		panic("storemock: RepositoryStore.Create called but CreateFunc is not set")
+	}
+	return m.CreateFunc(ctx, orgID, input)
+}
+
+// GetByID calls GetByIDFunc
+func (m *RepositoryStore) GetByID(ctx context.Context, orgID, repoID uuid.UUID) (*models.Repository, error) {
+	if m.GetByIDFunc == nil {
+		panic("storemock: RepositoryStore.GetByID called but GetByIDFunc is not set")
+	}
+	return m.GetByIDFunc(ctx, orgID, repoID)
+}
+
+// GetByURL calls GetByURLFunc
+func (m *RepositoryStore) GetByURL(ctx context.Context, orgID uuid.UUID, url string) (*models.Repository, error) {
+	if m.GetByURLFunc == nil {
+		panic("storemock: RepositoryStore.GetByURL called but GetByURLFunc is not set")
+	}
+	return m.GetByURLFunc(ctx, orgID, url)
+}
+
+// List calls ListFunc
+func (m *RepositoryStore) List(ctx context.Context, orgID uuid.UUID, filter *models.RepositoryListFilter) ([]models.Repository, int, error) {
+	if m.ListFunc == nil {
+		panic("storemock: RepositoryStore.List called but ListFunc is not set")
+	}
+	return m.ListFunc(ctx, orgID, filter)
+}
+
+// Update calls UpdateFunc
+func (m *RepositoryStore) Update(ctx context.Context, orgID, repoID uuid.UUID, input *models.UpdateRepositoryInput) (*models.Repository, error) {
+	if m.UpdateFunc == nil {
+		panic("storemock: RepositoryStore.Update called but UpdateFunc is not set")
+	}
+	return m.UpdateFunc(ctx, orgID, repoID, input)
+}
+
+// Delete calls DeleteFunc
+func (m *RepositoryStore) Delete(ctx context.Context, orgID, repoID uuid.UUID) error {
+	if m.DeleteFunc == nil {
+		panic("storemock: RepositoryStore.Delete called but DeleteFunc is not set")
+	}
+	return m.DeleteFunc(ctx, orgID, repoID)
+}
+
+// ApplySync calls ApplySyncFunc
+func (m *RepositoryStore) ApplySync(ctx context.Context, orgID, repoID uuid.UUID, details *models.RepositoryDetails) (*models.Repository, error) {
+	if m.ApplySyncFunc == nil {
+		panic("storemock: RepositoryStore.ApplySync called but ApplySyncFunc is not set")
+	}
+	return m.ApplySyncFunc(ctx, orgID, repoID, details)
+}
+
+// RecordSyncError calls RecordSyncErrorFunc
+func (m *RepositoryStore) RecordSyncError(ctx context.Context, orgID, repoID uuid.UUID, message string) error {
+	if m.RecordSyncErrorFunc == nil {
+		panic("storemock: RepositoryStore.RecordSyncError called but RecordSyncErrorFunc is not set")
+	}
+	return m.RecordSyncErrorFunc(ctx, orgID, repoID, message)
+}
+
+// ListSyncable calls ListSyncableFunc
+func (m *RepositoryStore) ListSyncable(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Repository, error) {
+	if m.ListSyncableFunc == nil {
+		panic("storemock: RepositoryStore.ListSyncable called but ListSyncableFunc is not set")
+	}
+	return m.ListSyncableFunc(ctx, orgID, limit)
+}
+
+// GetSyncToken calls GetSyncTokenFunc
+func (m *RepositoryStore) GetSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) (*models.RepositorySyncToken, error) {
+	if m.GetSyncTokenFunc == nil {
+		panic("storemock: RepositoryStore.GetSyncToken called but GetSyncTokenFunc is not set")
+	}
+	return m.GetSyncTokenFunc(ctx, orgID, provider)
+}
+
+// ListSyncTokens calls ListSyncTokensFunc
+func (m *RepositoryStore) ListSyncTokens(ctx context.Context, orgID uuid.UUID) ([]models.RepositorySyncToken, error) {
+	if m.ListSyncTokensFunc == nil {
+		panic("storemock: RepositoryStore.ListSyncTokens called but ListSyncTokensFunc is not set")
+	}
+	return m.ListSyncTokensFunc(ctx, orgID)
+}
+
+// SetSyncToken calls SetSyncTokenFunc
+func (m *RepositoryStore) SetSyncToken(ctx context.Context, orgID, userID uuid.UUID, provider models.RepositoryProvider, input *models.UpdateRepositorySyncTokenInput) (*models.RepositorySyncToken, error) {
+	if m.SetSyncTokenFunc == nil {
+		panic("storemock: RepositoryStore.SetSyncToken called but SetSyncTokenFunc is not set")
+	}
+	return m.SetSyncTokenFunc(ctx, orgID, userID, provider, input)
+}
+
+// DeleteSyncToken calls DeleteSyncTokenFunc
+func (m *RepositoryStore) DeleteSyncToken(ctx context.Context, orgID uuid.UUID, provider models.RepositoryProvider) error {
+	if m.DeleteSyncTokenFunc == nil {
+		panic("storemock: RepositoryStore.DeleteSyncToken called but DeleteSyncTokenFunc is not set")
+	}
+	return m.DeleteSyncTokenFunc(ctx, orgID, provider)
+}
+
+// ListSyncOrganizations calls ListSyncOrganizationsFunc
+func (m *RepositoryStore) ListSyncOrganizations(ctx context.Context) ([]uuid.UUID, error) {
+	if m.ListSyncOrganizationsFunc == nil {
+		panic("storemock: RepositoryStore.ListSyncOrganizations called but ListSyncOrganizationsFunc is not set")
+	}
+	return m.ListSyncOrganizationsFunc(ctx)
+}
+
+// GetTicketRepositories calls GetTicketRepositoriesFunc
+func (m *RepositoryStore) GetTicketRepositories(ctx context.Context, ticketID uuid.UUID) ([]models.TicketRepository, error) {
+	if m.GetTicketRepositoriesFunc == nil {
+		panic("storemock: RepositoryStore.GetTicketRepositories called but GetTicketRepositoriesFunc is not set")
+	}
+	return m.GetTicketRepositoriesFunc(ctx, ticketID)
+}
+
+// ListTicketRepositories calls ListTicketRepositoriesFunc
+func (m *RepositoryStore) ListTicketRepositories(ctx context.Context, ticketIDs []uuid.UUID) ([]models.TicketRepository, error) {
+	if m.ListTicketRepositoriesFunc == nil {
+		panic("storemock: RepositoryStore.ListTicketRepositories called but ListTicketRepositoriesFunc is not set")
+	}
+	return m.ListTicketRepositoriesFunc(ctx, ticketIDs)
+}
+
+// AuditStore mocks store.AuditStorer
+type AuditStore struct {
+	LogTicketAccessFunc             func(ctx context.Context, ticketID, userID uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error
+	LogSystemEventFunc              func(ctx context.Context, ticketID uuid.UUID, action string, changes map[string]interface{}) error
+	LogTicketViewFunc               func(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string) error
+	LogTicketEditFunc               func(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string, changes map[string]interface{}) error
+	LogTicketStatusChangeFunc       func(ctx context.Context, ticketID, userID uuid.UUID, oldStatus, newStatus string, ipAddress, userAgent *string) error
+	LogTicketEventsFunc             func(ctx context.Context, events []models.TicketAuditEvent) error
+	ListStatusChangesForCreatorFunc func(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time, limit int) ([]models.DigestStatusChange, error)
+	GetTicketAuditLogFunc           func(ctx context.Context, ticketID uuid.UUID, filter *models.AuditLogFilter) ([]models.TicketAuditLog, int, error)
+	MarkReviewedFunc                func(ctx context.Context, auditID, reviewerID uuid.UUID) error
+	GetPendingReviewsFunc           func(ctx context.Context, orgID uuid.UUID) ([]models.TicketAuditLog, error)
+	CountForExportFunc              func(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter) (int, error)
+	StreamForExportFunc             func(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter, fn func(*models.TicketAuditLog) error) error
+	StreamRangeFunc                 func(ctx context.Context, from, to time.Time, fn func(*models.TicketAuditLog) error) error
+	ArchivedDaysFunc                func(ctx context.Context, from, to time.Time) (map[string]bool, error)
+	LastArchivedDayFunc             func(ctx context.Context) (*time.Time, error)
+	RecordArchiveFunc               func(ctx context.Context, a *models.AuditArchive) error
+	EnsurePartitionsFunc            func(ctx context.Context, through time.Time) (int, error)
+	ListPartitionsFunc              func(ctx context.Context) ([]models.AuditPartition, error)
+	DropPartitionFunc               func(ctx context.Context, month time.Time) error
+	LogFunc                         func(ctx context.Context, orgID uuid.UUID, input *models.CreateAuditLogInput) error
+}
+
+// LogTicketAccess calls LogTicketAccessFunc
+func (m *AuditStore) LogTicketAccess(ctx context.Context, ticketID, userID uuid.UUID, action string, ipAddress, userAgent *string, changes map[string]interface{}) error {
+	if m.LogTicketAccessFunc == nil {
+		panic("storemock: AuditStore.LogTicketAccess called but LogTicketAccessFunc is not set")
+	}
+	return m.LogTicketAccessFunc(ctx, ticketID, userID, action, ipAddress, userAgent, changes)
+}
+
+// LogSystemEvent calls LogSystemEventFunc
+func (m *AuditStore) LogSystemEvent(ctx context.Context, ticketID uuid.UUID, action string, changes map[string]interface{}) error {
+	if m.LogSystemEventFunc == nil {
+		panic("storemock: AuditStore.LogSystemEvent called but LogSystemEventFunc is not set")
+	}
+	return m.LogSystemEventFunc(ctx, ticketID, action, changes)
+}
+
+// LogTicketView calls LogTicketViewFunc
+func (m *AuditStore) LogTicketView(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string) error {
+	if m.LogTicketViewFunc == nil {
+		panic("storemock: AuditStore.LogTicketView called but LogTicketViewFunc is not set")
+	}
+	return m.LogTicketViewFunc(ctx, ticketID, userID, ipAddress, userAgent)
+}
+
+// LogTicketEdit calls LogTicketEditFunc
+func (m *AuditStore) LogTicketEdit(ctx context.Context, ticketID, userID uuid.UUID, ipAddress, userAgent *string, changes map[string]interface{}) error {
+	if m.LogTicketEditFunc == nil {
+		panic("storemock: AuditStore.LogTicketEdit called but LogTicketEditFunc is not set")
+	}
+	return m.LogTicketEditFunc(ctx, ticketID, userID, ipAddress, userAgent, changes)
+}
+
+// LogTicketStatusChange calls LogTicketStatusChangeFunc
+func (m *AuditStore) LogTicketStatusChange(ctx context.Context, ticketID, userID uuid.UUID, oldStatus, newStatus string, ipAddress, userAgent *string) error {
+	if m.LogTicketStatusChangeFunc == nil {
+		panic("storemock: AuditStore.LogTicketStatusChange called but LogTicketStatusChangeFunc is not set")
+	}
+	return m.LogTicketStatusChangeFunc(ctx, ticketID, userID, oldStatus, newStatus, ipAddress, userAgent)
+}
+
+// LogTicketEvents calls LogTicketEventsFunc
+func (m *AuditStore) LogTicketEvents(ctx context.Context, events []models.TicketAuditEvent) error {
+	if m.LogTicketEventsFunc == nil {
+		panic("storemock: AuditStore.LogTicketEvents called but LogTicketEventsFunc is not set")
+	}
+	return m.LogTicketEventsFunc(ctx, events)
+}
+
+// ListStatusChangesForCreator calls ListStatusChangesForCreatorFunc
+func (m *AuditStore) ListStatusChangesForCreator(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time, limit int) ([]models.DigestStatusChange, error) {
+	if m.ListStatusChangesForCreatorFunc == nil {
+		panic("storemock: AuditStore.ListStatusChangesForCreator called but ListStatusChangesForCreatorFunc is not set")
+	}
+	return m.ListStatusChangesForCreatorFunc(ctx, orgID, userID, from, to, limit)
+}
+
+// GetTicketAuditLog calls GetTicketAuditLogFunc
+func (m *AuditStore) GetTicketAuditLog(ctx context.Context, ticketID uuid.UUID, filter *models.AuditLogFilter) ([]models.TicketAuditLog, int, error) {
+	if m.GetTicketAuditLogFunc == nil {
+		panic("storemock: AuditStore.GetTicketAuditLog called but GetTicketAuditLogFunc is not set")
+	}
+	return m.GetTicketAuditLogFunc(ctx, ticketID, filter)
+}
+
+// MarkReviewed calls MarkReviewedFunc
+func (m *AuditStore) MarkReviewed(ctx context.Context, auditID, reviewerID uuid.UUID) error {
+	if m.MarkReviewedFunc == nil {
+		panic("storemock: AuditStore.MarkReviewed called but MarkReviewedFunc is not set")
+	}
+	return m.MarkReviewedFunc(ctx, auditID, reviewerID)
+}
+
+// GetPendingReviews calls GetPendingReviewsFunc
+func (m *AuditStore) GetPendingReviews(ctx context.Context, orgID uuid.UUID) ([]models.TicketAuditLog, error) {
+	if m.GetPendingReviewsFunc == nil {
+		panic("storemock: AuditStore.GetPendingReviews called but GetPendingReviewsFunc is not set")
+	}
+	return m.GetPendingReviewsFunc(ctx, orgID)
+}
+
+// CountForExport calls CountForExportFunc
+func (m *AuditStore) CountForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter) (int, error) {
+	if m.CountForExportFunc == nil {
+		panic("storemock: AuditStore.CountForExport called but CountForExportFunc is not set")
+	}
+	return m.CountForExportFunc(ctx, orgID, filter)
+}
+
+// StreamForExport calls StreamForExportFunc
+func (m *AuditStore) StreamForExport(ctx context.Context, orgID uuid.UUID, filter *models.AuditExportFilter, fn func(*models.TicketAuditLog) error) error {
+	if m.StreamForExportFunc == nil {
+		panic("storemock: AuditStore.StreamForExport called but StreamForExportFunc is not set")
+	}
+	return m.StreamForExportFunc(ctx, orgID, filter, fn)
+}
+
+// StreamRange calls StreamRangeFunc
+func (m *AuditStore) StreamRange(ctx context.Context, from, to time.Time, fn func(*models.TicketAuditLog) error) error {
+	if m.StreamRangeFunc == nil {
+		panic("storemock: AuditStore.StreamRange called but StreamRangeFunc is not set")
+	}
+	return m.StreamRangeFunc(ctx, from, to, fn)
+}
+
+// ArchivedDays calls ArchivedDaysFunc
+func (m *AuditStore) ArchivedDays(ctx context.Context, from, to time.Time) (map[string]bool, error) {
+	if m.ArchivedDaysFunc == nil {
+		panic("storemock: AuditStore.ArchivedDays called but ArchivedDaysFunc is not set")
+	}
+	return m.ArchivedDaysFunc(ctx, from, to)
+}
+
+// LastArchivedDay calls LastArchivedDayFunc
+func (m *AuditStore) LastArchivedDay(ctx context.Context) (*time.Time, error) {
+	if m.LastArchivedDayFunc == nil {
+		panic("storemock: AuditStore.LastArchivedDay called but LastArchivedDayFunc is not set")
+	}
+	return m.LastArchivedDayFunc(ctx)
+}
+
+// RecordArchive calls RecordArchiveFunc
+func (m *AuditStore) RecordArchive(ctx context.Context, a *models.AuditArchive) error {
+	if m.RecordArchiveFunc == nil {
+		panic("storemock: AuditStore.RecordArchive called but RecordArchiveFunc is not set")
+	}
+	return m.RecordArchiveFunc(ctx, a)
+}
+
+// EnsurePartitions calls EnsurePartitionsFunc
+func (m *AuditStore) EnsurePartitions(ctx context.Context, through time.Time) (int, error) {
+	if m.EnsurePartitionsFunc == nil {
+		panic("storemock: AuditStore.EnsurePartitions called but EnsurePartitionsFunc is not set")
+	}
+	return m.EnsurePartitionsFunc(ctx, through)
+}
+
+// ListPartitions calls ListPartitionsFunc
+func (m *AuditStore) ListPartitions(ctx context.Context) ([]models.AuditPartition, error) {
+	if m.ListPartitionsFunc == nil {
+		panic("storemock: AuditStore.ListPartitions called but ListPartitionsFunc is not set")
+	}
+	return m.ListPartitionsFunc(ctx)
+}
+
+// DropPartition calls DropPartitionFunc
+func (m *AuditStore) DropPartition(ctx context.Context, month time.Time) error {
+	if m.DropPartitionFunc == nil {
+		panic("storemock: AuditStore.DropPartition called but DropPartitionFunc is not set")
+	}
+	return m.DropPartitionFunc(ctx, month)
+}
+
+// Log calls LogFunc
+func (m *AuditStore) Log(ctx context.Context, orgID uuid.UUID, input *models.CreateAuditLogInput) error {
+	if m.LogFunc == nil {
+		panic("storemock: AuditStore.Log called but LogFunc is not set")
+	}
+	return m.LogFunc(ctx, orgID, input)
+}
+
+// CommentStore mocks store.CommentStorer
+type CommentStore struct {
+	CreateFunc          func(ctx context.Context, orgID, ticketID, authorID uuid.UUID, input *models.CreateCommentInput) (*models.Comment, error)
+	GetFunc             func(ctx context.Context, orgID, commentID uuid.UUID) (*models.Comment, error)
+	ListByTicketFunc    func(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Comment, error)
+	LatestByTicketsFunc func(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID, limit int) ([]models.Comment, error)
+	UpdateFunc          func(ctx context.Context, orgID, commentID uuid.UUID, text string) (*models.Comment, error)
+	DeleteFunc          func(ctx context.Context, orgID, commentID uuid.UUID) error
+	ListByTicketsFunc   func(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Comment, error)
+}
+
+// Create calls CreateFunc
+func (m *CommentStore) Create(ctx context.Context, orgID, ticketID, authorID uuid.UUID, input *models.CreateCommentInput) (*models.Comment, error) {
+	if m.CreateFunc == nil {
+		panic("storemock: CommentStore.Create called but CreateFunc is not set")
+	}
+	return m.CreateFunc(ctx, orgID, ticketID, authorID, input)
+}
+
+// Get calls GetFunc
+func (m *CommentStore) Get(ctx context.Context, orgID, commentID uuid.UUID) (*models.Comment, error) {
+	if m.GetFunc == nil {
+		panic("storemock: CommentStore.Get called but GetFunc is not set")
+	}
+	return m.GetFunc(ctx, orgID, commentID)
+}
+
+// ListByTicket calls ListByTicketFunc
+func (m *CommentStore) ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Comment, error) {
+	if m.ListByTicketFunc == nil {
+		panic("storemock: CommentStore.ListByTicket called but ListByTicketFunc is not set")
+	}
+	return m.ListByTicketFunc(ctx, orgID, ticketID)
+}
+
+// LatestByTickets calls LatestByTicketsFunc
+func (m *CommentStore) LatestByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID, limit int) ([]models.Comment, error) {
+	if m.LatestByTicketsFunc == nil {
+		panic("storemock: CommentStore.LatestByTickets called but LatestByTicketsFunc is not set")
+	}
+	return m.LatestByTicketsFunc(ctx, orgID, ticketIDs, limit)
+}
+
+// Update calls UpdateFunc
+func (m *CommentStore) Update(ctx context.Context, orgID, commentID uuid.UUID, text string) (*models.Comment, error) {
+	if m.UpdateFunc == nil {
+		panic("storemock: CommentStore.Update called but UpdateFunc is not set")
+	}
+	return m.UpdateFunc(ctx, orgID, commentID, text)
+}
+
+// Delete calls DeleteFunc
+func (m *CommentStore) Delete(ctx context.Context, orgID, commentID uuid.UUID) error {
+	if m.DeleteFunc == nil {
+		panic("storemock: CommentStore.Delete called but DeleteFunc is not set")
+	}
+	return m.DeleteFunc(ctx, orgID, commentID)
+}
+
+// ListByTickets calls ListByTicketsFunc
+func (m *CommentStore) ListByTickets(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Comment, error) {
+	if m.ListByTicketsFunc == nil {
+		panic("storemock: CommentStore.ListByTickets called but ListByTicketsFunc is not set")
+	}
+	return m.ListByTicketsFunc(ctx, orgID, ticketIDs)
+}
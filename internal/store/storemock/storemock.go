@@ -0,0 +1,14 @@
+// Package storemock has mocks of the store interfaces, for tests that need
+// a store to return particular results or errors. Set the func fields of
+// the methods a test expects to be called and assign the mock to the
+// matching field of a store.Store, such as one from memstore.New:
+//
+//	s := memstore.New()
+//	s.Audit = &storemock.AuditStore{
+//		LogFunc: func(ctx context.Context, orgID uuid.UUID, input *models.CreateAuditLogInput) error {
+//			return errors.New("audit log unavailable")
+//		},
+//	}
+package storemock
+
+//go:generate go run ./gen
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type orgKey struct{}
+
+// WithOrg scopes the context to an organization. Every statement and
+// transaction run with it, reads as well as writes, runs with app.org_id
+// set (see openScoped), so the database's row-level security (migration
+// 041) hides and refuses rows of other organizations even if a query
+// forgets its organization_id condition.
+func WithOrg(ctx context.Context, orgID uuid.UUID) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// OrgFrom returns the organization the context is scoped to, if any.
+// Worker jobs and sign-in run unscoped and may see every organization.
+func OrgFrom(ctx context.Context) (uuid.UUID, bool) {
+	orgID, ok := ctx.Value(orgKey{}).(uuid.UUID)
+	return orgID, ok && orgID != uuid.Nil
+}
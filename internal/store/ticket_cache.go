@@ -0,0 +1,261 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/cache"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// ticketCache holds the settings of the ticket read cache
+type ticketCache struct {
+	cache     cache.Cache
+	ticketTTL time.Duration
+	queueTTL  time.Duration
+}
+
+// CacheTickets serves tickets read by ID or number, and the assignment
+// queue, from c for up to ticketTTL and queueTTL. Writes through the ticket
+// store evict what they change, and so do the other stores that write
+// tickets, such as an approval moving a ticket on. Confidential tickets are
+// never cached.
+func (s *Store) CacheTickets(c cache.Cache, ticketTTL, queueTTL time.Duration) {
+	s.ticketCache = &ticketCache{cache: c, ticketTTL: ticketTTL, queueTTL: queueTTL}
+	if s.conn == nil {
+		s.Tickets = &cachedTickets{TicketStorer: s.Tickets, ticketCache: s.ticketCache}
+		return
+	}
+	s.bind(s.conn)
+}
+
+// cachedTickets wraps a ticket store with the read cache. Methods it
+// doesn't override go straight to the wrapped store.
+type cachedTickets struct {
+	TicketStorer
+	*ticketCache
+	stale *[]string // Keys to evict again once the WithTx transaction commits
+}
+
+func ticketCacheKey(orgID, ticketID uuid.UUID) string {
+	return fmt.Sprintf("ticket:%s:%s", orgID, ticketID)
+}
+
+func ticketNumberCacheKey(orgID uuid.UUID, ticketNumber string) string {
+	return fmt.Sprintf("ticket-number:%s:%s", orgID, ticketNumber)
+}
+
+func ticketQueueCacheKey(orgID uuid.UUID) string {
+	return fmt.Sprintf("ticket-queue:%s", orgID)
+}
+
+// GetByID retrieves a ticket, from the cache when it's there. The cache is
+// best effort: when it fails the ticket is read from the database.
+func (c *cachedTickets) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	key := ticketCacheKey(orgID, ticketID)
+	var cached models.Ticket
+	if hit, _ := cache.GetJSON(ctx, c.cache, key, &cached); hit && !cached.IsConfidential {
+		return &cached, nil
+	}
+
+	ticket, err := c.TicketStorer.GetByID(ctx, orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if !ticket.IsConfidential {
+		cache.SetJSON(ctx, c.cache, key, ticket, c.ticketTTL)
+	}
+	return ticket, nil
+}
+
+// GetByNumber retrieves a ticket by its number. Numbers never move to
+// another ticket, so the cache maps them to ticket IDs and the ticket
+// itself comes from GetByID.
+func (c *cachedTickets) GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNumber string) (*models.Ticket, error) {
+	key := ticketNumberCacheKey(orgID, ticketNumber)
+	var ticketID uuid.UUID
+	if hit, _ := cache.GetJSON(ctx, c.cache, key, &ticketID); hit {
+		return c.GetByID(ctx, orgID, ticketID)
+	}
+
+	ticket, err := c.TicketStorer.GetByNumber(ctx, orgID, ticketNumber)
+	if err != nil {
+		return nil, err
+	}
+	cache.SetJSON(ctx, c.cache, key, ticket.ID, c.ticketTTL)
+	if !ticket.IsConfidential {
+		cache.SetJSON(ctx, c.cache, ticketCacheKey(orgID, ticket.ID), ticket, c.ticketTTL)
+	}
+	return ticket, nil
+}
+
+// GetQueue retrieves the unassigned tickets waiting for an assignee. The
+// queue is cached for callers who can see every ticket; others get it
+// filtered by their access from the database.
+func (c *cachedTickets) GetQueue(ctx context.Context, orgID uuid.UUID) ([]models.Ticket, error) {
+	if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+		return c.TicketStorer.GetQueue(ctx, orgID)
+	}
+
+	key := ticketQueueCacheKey(orgID)
+	var cached []models.Ticket
+	if hit, _ := cache.GetJSON(ctx, c.cache, key, &cached); hit {
+		return cached, nil
+	}
+
+	tickets, err := c.TicketStorer.GetQueue(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	cache.SetJSON(ctx, c.cache, key, tickets, c.queueTTL)
+	return tickets, nil
+}
+
+// evict removes the tickets and the organization's queue from the cache
+func (c *cachedTickets) evict(ctx context.Context, orgID uuid.UUID, ticketIDs ...uuid.UUID) {
+	ticketEvicter{cache: c.ticketCache, stale: c.stale}.evict(ctx, orgID, ticketIDs...)
+}
+
+// ticketEvicter lets stores other than the ticket store evict the tickets
+// they write. Without a cache it does nothing.
+type ticketEvicter struct {
+	cache *ticketCache
+	stale *[]string // Keys to evict again once the WithTx transaction commits
+}
+
+// evict removes the tickets and the organization's queue from the cache.
+// Inside WithTx the keys are evicted again after the commit, in case a
+// read in between cached the uncommitted state's predecessor.
+func (e ticketEvicter) evict(ctx context.Context, orgID uuid.UUID, ticketIDs ...uuid.UUID) {
+	if e.cache == nil {
+		return
+	}
+	keys := []string{ticketQueueCacheKey(orgID)}
+	for _, id := range ticketIDs {
+		keys = append(keys, ticketCacheKey(orgID, id))
+	}
+	e.cache.cache.Delete(ctx, keys...)
+	if e.stale != nil {
+		*e.stale = append(*e.stale, keys...)
+	}
+}
+
+// queryTicketIDs runs an UPDATE ... RETURNING id on tickets and returns the
+// IDs, for evicting them
+func queryTicketIDs(ctx context.Context, q conn, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Create creates a ticket, evicting the queue it may join
+func (c *cachedTickets) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateTicketInput) (*models.Ticket, error) {
+	ticket, err := c.TicketStorer.Create(ctx, orgID, userID, input)
+	if err == nil {
+		c.evict(ctx, orgID)
+	}
+	return ticket, err
+}
+
+// Import imports a ticket, evicting the queue it may join
+func (c *cachedTickets) Import(ctx context.Context, orgID, userID uuid.UUID, input *models.ImportTicketInput) (*models.Ticket, error) {
+	ticket, err := c.TicketStorer.Import(ctx, orgID, userID, input)
+	if err == nil {
+		c.evict(ctx, orgID)
+	}
+	return ticket, err
+}
+
+// Update updates a ticket and evicts it
+func (c *cachedTickets) Update(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Update(ctx, orgID, ticketID, userID, input)
+}
+
+// UpdateStatus changes a ticket's status and evicts it
+func (c *cachedTickets) UpdateStatus(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus, expectedVersion int) (*models.TicketTransition, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.UpdateStatus(ctx, orgID, ticketID, status, expectedVersion)
+}
+
+// Submit submits a ticket for approval and evicts it
+func (c *cachedTickets) Submit(ctx context.Context, orgID, ticketID, userID uuid.UUID, plan *models.ApprovalPlan, expectedVersion int) (*models.TicketTransition, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Submit(ctx, orgID, ticketID, userID, plan, expectedVersion)
+}
+
+// Close closes a ticket and evicts it
+func (c *cachedTickets) Close(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Close(ctx, orgID, ticketID, expectedVersion)
+}
+
+// Cancel cancels a ticket and evicts it
+func (c *cachedTickets) Cancel(ctx context.Context, orgID, ticketID uuid.UUID, reason string, expectedVersion int) (*models.TicketTransition, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Cancel(ctx, orgID, ticketID, reason, expectedVersion)
+}
+
+// Reopen reopens a ticket and evicts it
+func (c *cachedTickets) Reopen(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Reopen(ctx, orgID, ticketID, expectedVersion)
+}
+
+// Delete moves a ticket to the trash and evicts it
+func (c *cachedTickets) Delete(ctx context.Context, orgID, ticketID, userID uuid.UUID, reason *string, expectedVersion int) error {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Delete(ctx, orgID, ticketID, userID, reason, expectedVersion)
+}
+
+// Restore brings a ticket back from the trash and evicts it
+func (c *cachedTickets) Restore(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Restore(ctx, orgID, ticketID)
+}
+
+// PurgeTrash purges trashed tickets and evicts them
+func (c *cachedTickets) PurgeTrash(ctx context.Context, orgID uuid.UUID, before time.Time) ([]uuid.UUID, error) {
+	purged, err := c.TicketStorer.PurgeTrash(ctx, orgID, before)
+	c.evict(ctx, orgID, purged...)
+	return purged, err
+}
+
+// Assign assigns a ticket and evicts it
+func (c *cachedTickets) Assign(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.Assign(ctx, orgID, ticketID, userID)
+}
+
+// AddWatcher adds a watcher to a ticket and evicts it
+func (c *cachedTickets) AddWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.AddWatcher(ctx, orgID, ticketID, userID)
+}
+
+// RemoveWatcher removes a watcher from a ticket and evicts it
+func (c *cachedTickets) RemoveWatcher(ctx context.Context, orgID, ticketID, userID uuid.UUID) error {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.RemoveWatcher(ctx, orgID, ticketID, userID)
+}
+
+// SetAffectedResources replaces a ticket's affected hosts and evicts it, as
+// they count toward its risk score
+func (c *cachedTickets) SetAffectedResources(ctx context.Context, orgID, ticketID uuid.UUID, resources []models.AffectedResource) error {
+	defer c.evict(ctx, orgID, ticketID)
+	return c.TicketStorer.SetAffectedResources(ctx, orgID, ticketID, resources)
+}
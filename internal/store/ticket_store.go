@@ -15,7 +15,8 @@ import (
 
 // TicketStore handles ticket database operations
 type TicketStore struct {
-	db *sql.DB
+	db   conn
+	read conn // For List and Search: a read replica when there is one
 }
 
 // Create creates a new ticket
@@ -62,6 +63,7 @@ func (s *TicketStore) Create(ctx context.Context, orgID, userID uuid.UUID, input
 		CustomerID:        input.CustomerID,
 		ParentTicketID:    input.ParentTicketID,
 		EpicID:            input.EpicID,
+		SprintID:          input.SprintID,
 		StoryPoints:       input.StoryPoints,
 		TimeEstimateHours: input.TimeEstimateHours,
 		Labels:            input.Labels,
@@ -80,11 +82,11 @@ func (s *TicketStore) Create(ctx context.Context, orgID, userID uuid.UUID, input
 			requires_approval_types, approval_deadline, custom_fields, version,
 			project_id, owning_group_id, customer_id, parent_ticket_id, epic_id,
 			story_points, time_estimate_hours, labels, watchers, external_reference,
-			acl_inheritance, is_confidential, created_at, updated_at
+			acl_inheritance, is_confidential, created_at, updated_at, sprint_id
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
 			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28,
-			$29, $30, $31, $32, $33, $34, $35, $36, $37
+			$29, $30, $31, $32, $33, $34, $35, $36, $37, $38
 		)
 	`
 
@@ -102,38 +104,307 @@ func (s *TicketStore) Create(ctx context.Context, orgID, userID uuid.UUID, input
 		ticket.ParentTicketID, ticket.EpicID, ticket.StoryPoints, ticket.TimeEstimateHours,
 		pq.Array(ticket.Labels), pq.Array(ticket.Watchers), ticket.ExternalReference,
 		ticket.ACLInheritance, ticket.IsConfidential, ticket.CreatedAt, ticket.UpdatedAt,
+		ticket.SprintID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
 
+	ticket.RiskScore = ticket.ScoreRisk()
 	return ticket, nil
 }
 
-// GetByID retrieves a ticket by ID
-func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
-	query := `
-		SELECT
-			id, organization_id, ticket_number, created_by, assigned_to, title,
-			description, status, priority, risk_level, industry, compliance_frameworks,
+// GetNumberByExternalReference returns the number of the ticket carrying an
+// external reference, including deleted tickets, or "" if there is none
+func (s *TicketStore) GetNumberByExternalReference(ctx context.Context, orgID uuid.UUID, reference string) (string, error) {
+	var ticketNumber string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT ticket_number FROM change_tickets WHERE organization_id = $1 AND external_reference = $2 LIMIT 1",
+		orgID, reference,
+	).Scan(&ticketNumber)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up external reference: %w", err)
+	}
+	return ticketNumber, nil
+}
+
+// Import creates a ticket brought in from another change system, keeping
+// its status and timestamps. The number is drawn from the year the change
+// was opened. Fails with "ticket already imported" if a ticket carries the
+// same external reference.
+func (s *TicketStore) Import(ctx context.Context, orgID, userID uuid.UUID, input *models.ImportTicketInput) (*models.Ticket, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if input.ExternalReference != nil {
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM change_tickets WHERE organization_id = $1 AND external_reference = $2)",
+			orgID, *input.ExternalReference,
+		).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up external reference: %w", err)
+		}
+		if exists {
+			return nil, models.Conflict("ticket already imported")
+		}
+	}
+
+	var ticketNumber string
+	err = tx.QueryRowContext(ctx,
+		"SELECT generate_ticket_number($1, $2)",
+		orgID, input.CreatedAt.Year(),
+	).Scan(&ticketNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ticket number: %w", err)
+	}
+
+	ticket := &models.Ticket{
+		ID:                          uuid.New(),
+		OrganizationID:              orgID,
+		TicketNumber:                ticketNumber,
+		CreatedBy:                   userID,
+		Title:                       input.Title,
+		Description:                 input.Description,
+		Status:                      input.Status,
+		Priority:                    input.Priority,
+		RiskLevel:                   input.RiskLevel,
+		Industry:                    input.Industry,
+		ComplianceFrameworks:        input.ComplianceFrameworks,
+		ComplianceNotes:             input.ComplianceNotes,
+		ChangeType:                  input.ChangeType,
+		AffectedSystems:             input.AffectedSystems,
+		AffectedDataTypes:           input.AffectedDataTypes,
+		ImpactDescription:           input.ImpactDescription,
+		RollbackPlan:                input.RollbackPlan,
+		TestingPlan:                 input.TestingPlan,
+		RequestedImplementationDate: input.RequestedImplementationDate,
+		ScheduledStart:              input.ScheduledStart,
+		ScheduledEnd:                input.ScheduledEnd,
+		ActualStart:                 input.ActualStart,
+		ActualEnd:                   input.ActualEnd,
+		RequiresApprovalTypes:       input.RequiresApprovalTypes,
+		CustomFields:                input.CustomFields,
+		Version:                     1,
+		CreatedAt:                   input.CreatedAt,
+		UpdatedAt:                   input.UpdatedAt,
+		ClosedAt:                    input.ClosedAt,
+		ExternalReference:           input.ExternalReference,
+		ACLInheritance:              true,
+		IsConfidential:              input.IsConfidential,
+		IsEmergency:                 input.IsEmergency,
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO change_tickets (
+			id, organization_id, ticket_number, created_by, title, description,
+			status, priority, risk_level, industry, compliance_frameworks,
 			compliance_notes, change_type, affected_systems, affected_data_types,
 			impact_description, rollback_plan, testing_plan, requested_implementation_date,
 			scheduled_start, scheduled_end, actual_start, actual_end,
-			requires_approval_types, approval_deadline, attachment_urls, custom_fields,
-			submitted_at, submitted_snapshot, version, created_at, updated_at,
-			closed_at, deleted_at, deletion_reason,
-			project_id, owning_group_id, customer_id, parent_ticket_id, epic_id,
-			story_points, time_estimate_hours, time_spent_hours, labels, watchers,
-			external_reference, acl_inheritance, is_confidential
+			requires_approval_types, custom_fields, version, external_reference,
+			acl_inheritance, is_confidential, is_emergency, created_at, updated_at, closed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28,
+			$29, $30, $31, $32, $33
+		)
+	`,
+		ticket.ID, ticket.OrganizationID, ticket.TicketNumber, ticket.CreatedBy,
+		ticket.Title, ticket.Description, ticket.Status, ticket.Priority,
+		ticket.RiskLevel, ticket.Industry, pq.Array(ticket.ComplianceFrameworks),
+		ticket.ComplianceNotes, ticket.ChangeType, pq.Array(ticket.AffectedSystems),
+		pq.Array(ticket.AffectedDataTypes), ticket.ImpactDescription, ticket.RollbackPlan,
+		ticket.TestingPlan, ticket.RequestedImplementationDate,
+		ticket.ScheduledStart, ticket.ScheduledEnd, ticket.ActualStart, ticket.ActualEnd,
+		pq.Array(ticket.RequiresApprovalTypes), []byte(ticket.CustomFields), ticket.Version,
+		ticket.ExternalReference, ticket.ACLInheritance, ticket.IsConfidential, ticket.IsEmergency,
+		ticket.CreatedAt, ticket.UpdatedAt, ticket.ClosedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import ticket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	ticket.RiskScore = ticket.ScoreRisk()
+	return ticket, nil
+}
+
+// GetByID retrieves a ticket by ID
+func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	ticket, err := getTicket(ctx, s.db, orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Confidential tickets are reported as missing to users without access
+	if ticket.IsConfidential {
+		if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+			access, err := ticketAccess(ctx, s.db, ticket, a)
+			if err != nil {
+				return nil, err
+			}
+			if !access.CanView(true) {
+				return nil, models.NotFound("ticket not found")
+			}
+		}
+	}
+
+	return ticket, nil
+}
+
+// getTicket loads a ticket without access checks, inside a transaction if q is one
+func getTicket(ctx context.Context, q execQuerier, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM change_tickets
 		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NULL
-	`
+	`, ticketColumns)
+
+	ticket, err := scanTicket(q.QueryRowContext(ctx, query, ticketID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("ticket not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
 
+	return ticket, nil
+}
+
+// GetByIDs retrieves several tickets at once, in no particular order.
+// Tickets that don't exist or that the caller may not see are left out.
+func (s *TicketStore) GetByIDs(ctx context.Context, orgID uuid.UUID, ticketIDs []uuid.UUID) ([]models.Ticket, error) {
+	conditions := []string{"organization_id = $1", "id = ANY($2)", "deleted_at IS NULL"}
+	args := []interface{}{orgID, pq.Array(ticketIDs)}
+	if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+		condition, visArgs := ticketVisibilityCondition(a, 3)
+		conditions = append(conditions, condition)
+		args = append(args, visArgs...)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM change_tickets
+		WHERE %s
+	`, ticketColumns, strings.Join(conditions, " AND "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []models.Ticket
+	for rows.Next() {
+		t, err := scanTicket(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		tickets = append(tickets, *t)
+	}
+
+	return tickets, rows.Err()
+}
+
+// ListScheduled retrieves approved and implementing tickets whose scheduled
+// window overlaps [from, to], soonest first. With involving set, only
+// tickets the user created, is assigned to, approves or watches are listed.
+func (s *TicketStore) ListScheduled(ctx context.Context, orgID uuid.UUID, involving *uuid.UUID, from, to time.Time, limit int) ([]models.Ticket, error) {
+	conditions := []string{
+		"organization_id = $1",
+		"deleted_at IS NULL",
+		"status = ANY($2)",
+		"scheduled_start IS NOT NULL",
+		"scheduled_start <= $3",
+		"COALESCE(scheduled_end, scheduled_start) >= $4",
+	}
+	statuses := []string{string(models.TicketStatusApproved), string(models.TicketStatusImplementing)}
+	args := []interface{}{orgID, pq.Array(statuses), to, from}
+	argNum := 5
+
+	if involving != nil {
+		conditions = append(conditions, fmt.Sprintf(`(
+			created_by = $%[1]d
+			OR assigned_to = $%[1]d
+			OR $%[1]d = ANY(watchers)
+			OR EXISTS (SELECT 1 FROM approvals a WHERE a.ticket_id = change_tickets.id AND a.approver_id = $%[1]d)
+		)`, argNum))
+		args = append(args, *involving)
+		argNum++
+	}
+	if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+		condition, visArgs := ticketVisibilityCondition(a, argNum)
+		conditions = append(conditions, condition)
+		args = append(args, visArgs...)
+		argNum += len(visArgs)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM change_tickets
+		WHERE %s
+		ORDER BY scheduled_start, ticket_number
+		LIMIT $%d
+	`, ticketColumns, strings.Join(conditions, " AND "), argNum)
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []models.Ticket
+	for rows.Next() {
+		t, err := scanTicket(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ticket: %w", err)
+		}
+		tickets = append(tickets, *t)
+	}
+
+	return tickets, rows.Err()
+}
+
+const ticketColumns = `
+	id, organization_id, ticket_number, created_by, assigned_to, title,
+	description, status, priority, risk_level, industry, compliance_frameworks,
+	compliance_notes, change_type, affected_systems, affected_data_types,
+	impact_description, rollback_plan, testing_plan, requested_implementation_date,
+	scheduled_start, scheduled_end, actual_start, actual_end,
+	requires_approval_types, approval_deadline, approval_plan, attachment_urls, custom_fields,
+	submitted_at, submitted_snapshot, version, created_at, updated_at,
+	closed_at, deleted_at, deletion_reason,
+	project_id, owning_group_id, customer_id, parent_ticket_id, epic_id,
+	story_points, time_estimate_hours, time_spent_hours, labels, watchers,
+	external_reference, acl_inheritance, is_confidential,
+	is_emergency, escalation_level, last_escalated_at,
+	sprint_id, completed_at,
+	`+productionSystemsColumn+`
+`
+
+// productionSystemsColumn counts the production hosts among a ticket's
+// affected resources, for its risk score. It needs change_tickets unaliased.
+const productionSystemsColumn = `(
+	SELECT COUNT(*) FROM ticket_affected_resources r
+	WHERE r.ticket_id = change_tickets.id AND r.environment = 'production'
+)`
+
+func scanTicket(row rowScanner) (*models.Ticket, error) {
 	ticket := &models.Ticket{}
 	var complianceFrameworks, approvalTypes, affectedSystems, affectedDataTypes, attachmentURLs, labels []string
 	var watchers []string
 
-	err := s.db.QueryRowContext(ctx, query, ticketID, orgID).Scan(
+	err := row.Scan(
 		&ticket.ID, &ticket.OrganizationID, &ticket.TicketNumber, &ticket.CreatedBy,
 		&ticket.AssignedTo, &ticket.Title, &ticket.Description, &ticket.Status,
 		&ticket.Priority, &ticket.RiskLevel, &ticket.Industry, pq.Array(&complianceFrameworks),
@@ -141,7 +412,7 @@ func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*
 		pq.Array(&affectedDataTypes), &ticket.ImpactDescription, &ticket.RollbackPlan,
 		&ticket.TestingPlan, &ticket.RequestedImplementationDate, &ticket.ScheduledStart,
 		&ticket.ScheduledEnd, &ticket.ActualStart, &ticket.ActualEnd,
-		pq.Array(&approvalTypes), &ticket.ApprovalDeadline, pq.Array(&attachmentURLs),
+		pq.Array(&approvalTypes), &ticket.ApprovalDeadline, &ticket.ApprovalPlan, pq.Array(&attachmentURLs),
 		&ticket.CustomFields, &ticket.SubmittedAt, &ticket.SubmittedSnapshot,
 		&ticket.Version, &ticket.CreatedAt, &ticket.UpdatedAt, &ticket.ClosedAt,
 		&ticket.DeletedAt, &ticket.DeletionReason,
@@ -149,15 +420,17 @@ func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*
 		&ticket.ParentTicketID, &ticket.EpicID, &ticket.StoryPoints,
 		&ticket.TimeEstimateHours, &ticket.TimeSpentHours, pq.Array(&labels),
 		pq.Array(&watchers), &ticket.ExternalReference, &ticket.ACLInheritance,
-		&ticket.IsConfidential,
+		&ticket.IsConfidential, &ticket.IsEmergency, &ticket.EscalationLevel,
+		&ticket.LastEscalatedAt,
+		&ticket.SprintID, &ticket.CompletedAt,
+		&ticket.ProductionSystems,
 	)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ticket not found")
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ticket: %w", err)
+		return nil, err
 	}
 
+	ticket.TimeRemainingHours, ticket.OverEstimate = models.TimeRemaining(ticket.TimeEstimateHours, ticket.TimeSpentHours)
+
 	// Convert string arrays to typed arrays
 	ticket.ComplianceFrameworks = make([]models.ComplianceFramework, len(complianceFrameworks))
 	for i, cf := range complianceFrameworks {
@@ -171,6 +444,7 @@ func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*
 	ticket.AffectedDataTypes = affectedDataTypes
 	ticket.AttachmentURLs = attachmentURLs
 	ticket.Labels = labels
+	ticket.RiskScore = ticket.ScoreRisk()
 
 	// Convert watcher strings to UUIDs
 	ticket.Watchers = make([]uuid.UUID, 0, len(watchers))
@@ -183,6 +457,23 @@ func (s *TicketStore) GetByID(ctx context.Context, orgID, ticketID uuid.UUID) (*
 	return ticket, nil
 }
 
+// requireEdit checks the caller may modify a ticket. ACLs restrict writes on
+// confidential tickets; other tickets are editable by the whole organization.
+func (s *TicketStore) requireEdit(ctx context.Context, ticket *models.Ticket) error {
+	a := AccessorFrom(ctx)
+	if a == nil || !ticket.IsConfidential || a.CanWriteAll() {
+		return nil
+	}
+	access, err := ticketAccess(ctx, s.db, ticket, a)
+	if err != nil {
+		return err
+	}
+	if !access.CanEdit() {
+		return models.Forbidden("insufficient ticket permissions")
+	}
+	return nil
+}
+
 // GetByNumber retrieves a ticket by ticket number
 func (s *TicketStore) GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNumber string) (*models.Ticket, error) {
 	var ticketID uuid.UUID
@@ -191,7 +482,7 @@ func (s *TicketStore) GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNu
 		orgID, ticketNumber,
 	).Scan(&ticketID)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ticket not found")
+		return nil, models.NotFound("ticket not found")
 	}
 	if err != nil {
 		return nil, err
@@ -199,7 +490,8 @@ func (s *TicketStore) GetByNumber(ctx context.Context, orgID uuid.UUID, ticketNu
 	return s.GetByID(ctx, orgID, ticketID)
 }
 
-// List retrieves tickets with filtering
+// List retrieves tickets with filtering. With filter.Cursor set it pages by
+// keyset instead of OFFSET and sets filter.NextCursor when more rows follow.
 func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.TicketListFilter) ([]models.Ticket, int, error) {
 	filter.SetDefaults()
 
@@ -225,6 +517,12 @@ func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.
 		argNum++
 	}
 
+	if len(filter.RiskLevel) > 0 {
+		conditions = append(conditions, fmt.Sprintf("risk_level = ANY($%d)", argNum))
+		args = append(args, pq.Array(filter.RiskLevel))
+		argNum++
+	}
+
 	if filter.CreatedBy != nil {
 		conditions = append(conditions, fmt.Sprintf("created_by = $%d", argNum))
 		args = append(args, *filter.CreatedBy)
@@ -261,16 +559,49 @@ func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.
 		argNum++
 	}
 
+	if filter.SprintID != nil {
+		conditions = append(conditions, fmt.Sprintf("sprint_id = $%d", argNum))
+		args = append(args, *filter.SprintID)
+		argNum++
+	}
+
+	if len(filter.Labels) > 0 {
+		conditions = append(conditions, fmt.Sprintf("labels @> $%d::text[]", argNum))
+		args = append(args, pq.Array(filter.Labels))
+		argNum++
+	}
+
+	if filter.IsConfidential != nil {
+		conditions = append(conditions, fmt.Sprintf("is_confidential = $%d", argNum))
+		args = append(args, *filter.IsConfidential)
+		argNum++
+	}
+
+	if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+		condition, visArgs := ticketVisibilityCondition(a, argNum)
+		conditions = append(conditions, condition)
+		args = append(args, visArgs...)
+		argNum += len(visArgs)
+	}
+
+	if filter.Host != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"id IN (SELECT ticket_id FROM ticket_affected_resources WHERE organization_id = $1 AND lower(hostname) = lower($%d))", argNum))
+		args = append(args, filter.Host)
+		argNum++
+	}
+
 	if filter.NeedsAssignment {
 		conditions = append(conditions, "assigned_to IS NULL")
 		conditions = append(conditions, "status IN ('submitted', 'in_review', 'update_requested')")
 	}
 
+	// Full-text search over the number, title and description (migration 040)
 	if filter.Search != "" {
-		conditions = append(conditions, fmt.Sprintf("(title ILIKE $%d OR description ILIKE $%d OR ticket_number ILIKE $%d)", argNum, argNum+1, argNum+2))
-		searchTerm := "%" + filter.Search + "%"
-		args = append(args, searchTerm, searchTerm, searchTerm)
-		argNum += 3
+		conditions = append(conditions, fmt.Sprintf(
+			"(search_vector @@ websearch_to_tsquery('english', $%d) OR ticket_number = upper($%d))", argNum, argNum))
+		args = append(args, filter.Search)
+		argNum++
 	}
 
 	whereClause := strings.Join(conditions, " AND ")
@@ -278,7 +609,7 @@ func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.
 	// Count total
 	var total int
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM change_tickets WHERE %s", whereClause)
-	err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	err := s.read.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count tickets: %w", err)
 	}
@@ -298,19 +629,34 @@ func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.
 		sortOrder = "ASC"
 	}
 
+	offset := filter.Offset()
+	condition, cursorArgs, err := cursorCondition(filter.Cursor, sortBy, sortOrder, argNum)
+	if err != nil {
+		return nil, 0, err
+	}
+	if condition != "" {
+		whereClause += " AND " + condition
+		args = append(args, cursorArgs...)
+		argNum += len(cursorArgs)
+		offset = 0
+	}
+
+	// Fetch one extra row to tell whether another page follows
 	query := fmt.Sprintf(`
 		SELECT id, ticket_number, title, status, priority, risk_level,
 		       created_by, assigned_to, created_at, updated_at,
-		       project_id, owning_group_id, customer_id
+		       project_id, owning_group_id, customer_id, is_confidential,
+		       submitted_at, approval_deadline, compliance_frameworks, change_type,
+		       %s
 		FROM change_tickets
 		WHERE %s
-		ORDER BY %s %s
+		ORDER BY %s %s, id %s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, sortBy, sortOrder, argNum, argNum+1)
+	`, productionSystemsColumn, whereClause, sortBy, sortOrder, sortOrder, argNum, argNum+1)
 
-	args = append(args, filter.PerPage, filter.Offset())
+	args = append(args, filter.PerPage+1, offset)
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	rows, err := s.read.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list tickets: %w", err)
 	}
@@ -319,30 +665,154 @@ func (s *TicketStore) List(ctx context.Context, orgID uuid.UUID, filter *models.
 	var tickets []models.Ticket
 	for rows.Next() {
 		var t models.Ticket
+		var frameworks []string
 		err := rows.Scan(
 			&t.ID, &t.TicketNumber, &t.Title, &t.Status, &t.Priority,
 			&t.RiskLevel, &t.CreatedBy, &t.AssignedTo, &t.CreatedAt, &t.UpdatedAt,
-			&t.ProjectID, &t.OwningGroupID, &t.CustomerID,
+			&t.ProjectID, &t.OwningGroupID, &t.CustomerID, &t.IsConfidential,
+			&t.SubmittedAt, &t.ApprovalDeadline, pq.Array(&frameworks), &t.ChangeType,
+			&t.ProductionSystems,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan ticket: %w", err)
 		}
+		for _, f := range frameworks {
+			t.ComplianceFrameworks = append(t.ComplianceFrameworks, models.ComplianceFramework(f))
+		}
+		t.RiskScore = t.ScoreRisk()
 		tickets = append(tickets, t)
 	}
 
+	filter.NextCursor = ""
+	if len(tickets) > filter.PerPage {
+		tickets = tickets[:filter.PerPage]
+		last := &tickets[len(tickets)-1]
+		filter.NextCursor = (&models.Cursor{SortBy: sortBy, Value: ticketSortValue(last, sortBy), ID: last.ID}).Encode()
+	}
+
 	return tickets, total, nil
 }
 
-// Update updates a ticket
-func (s *TicketStore) Update(ctx context.Context, orgID, ticketID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error) {
+// Search finds tickets matching a web-search style query (words, "quoted
+// phrases", OR, -excluded) in their number, title or description, best
+// match first. Each result has an excerpt of the description around the
+// matched words. Tickets the caller may not see are left out.
+func (s *TicketStore) Search(ctx context.Context, orgID uuid.UUID, query string, limit int) ([]models.TicketSearchResult, error) {
+	conditions := []string{
+		"organization_id = $1",
+		"deleted_at IS NULL",
+		"search_vector @@ websearch_to_tsquery('english', $2)",
+	}
+	args := []interface{}{orgID, query}
+	argNum := 3
+	if a := AccessorFrom(ctx); a != nil && !a.CanReadAll() {
+		condition, visArgs := ticketVisibilityCondition(a, argNum)
+		conditions = append(conditions, condition)
+		args = append(args, visArgs...)
+		argNum += len(visArgs)
+	}
+
+	// Rank the matches first so only the page returned gets a headline
+	rows, err := s.read.QueryContext(ctx, fmt.Sprintf(`
+		SELECT m.id, m.ticket_number, m.title, m.status, m.priority, m.risk_level,
+		       m.created_by, COALESCE(u.email, ''), COALESCE(u.full_name, ''),
+		       m.created_at, m.updated_at, m.rank,
+		       ts_headline('english', m.description, websearch_to_tsquery('english', $2),
+		                   'MaxFragments=2, MaxWords=20, MinWords=5')
+		FROM (
+			SELECT id, ticket_number, title, description, status, priority, risk_level,
+			       created_by, created_at, updated_at,
+			       ts_rank(search_vector, websearch_to_tsquery('english', $2)) AS rank
+			FROM change_tickets
+			WHERE %s
+			ORDER BY rank DESC, updated_at DESC
+			LIMIT $%d
+		) m
+		LEFT JOIN users u ON u.id = m.created_by
+		ORDER BY m.rank DESC, m.updated_at DESC
+	`, strings.Join(conditions, " AND "), argNum), append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.TicketSearchResult{}
+	for rows.Next() {
+		var r models.TicketSearchResult
+		t := &r.Ticket
+		err := rows.Scan(
+			&t.ID, &t.TicketNumber, &t.Title, &t.Status, &t.Priority, &t.RiskLevel,
+			&t.CreatedBy.ID, &t.CreatedBy.Email, &t.CreatedBy.FullName,
+			&t.CreatedAt, &t.UpdatedAt, &r.Rank, &r.Headline,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// ticketSortValue returns the value of a ticket's sort column in the form
+// Postgres accepts back as a query parameter
+func ticketSortValue(t *models.Ticket, sortBy string) string {
+	switch sortBy {
+	case "updated_at":
+		return t.UpdatedAt.Format(time.RFC3339Nano)
+	case "priority":
+		return string(t.Priority)
+	case "status":
+		return string(t.Status)
+	case "ticket_number":
+		return t.TicketNumber
+	case "title":
+		return t.Title
+	}
+	return t.CreatedAt.Format(time.RFC3339Nano)
+}
+
+// Update updates a ticket and records the change as a new revision. The
+// input must carry the version the caller read; the update only applies to
+// that version, and otherwise fails with a VersionConflictError.
+func (s *TicketStore) Update(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.UpdateTicketInput) (*models.Ticket, error) {
+	if input.Version == nil {
+		return nil, models.ErrVersionRequired
+	}
+
 	// Get current ticket
 	ticket, err := s.GetByID(ctx, orgID, ticketID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.requireEdit(ctx, ticket); err != nil {
+		return nil, err
+	}
+
+	if *input.Version != ticket.Version {
+		return nil, &models.VersionConflictError{
+			ExpectedVersion: *input.Version,
+			CurrentVersion:  ticket.Version,
+			Conflicts:       input.Conflicts(ticket),
+		}
+	}
+
 	if !ticket.CanEdit() {
-		return nil, fmt.Errorf("ticket cannot be edited in current status")
+		return nil, models.InvalidTransition("ticket cannot be edited in current status")
+	}
+
+	// Changing who can see the ticket is an ACL change
+	if input.IsConfidential != nil || input.ACLInheritance != nil {
+		if a := AccessorFrom(ctx); a != nil && !a.CanWriteAll() {
+			access, err := ticketAccess(ctx, s.db, ticket, a)
+			if err != nil {
+				return nil, err
+			}
+			if !access.CanManageACLs() {
+				return nil, models.Forbidden("insufficient ticket permissions")
+			}
+		}
 	}
 
 	// Build update query dynamically
@@ -392,6 +862,19 @@ func (s *TicketStore) Update(ctx context.Context, orgID, ticketID uuid.UUID, inp
 		argNum++
 	}
 
+	// uuid.Nil unlinks the ticket
+	if input.EpicID != nil {
+		updates = append(updates, fmt.Sprintf("epic_id = $%d", argNum))
+		args = append(args, nullableUUID(*input.EpicID))
+		argNum++
+	}
+
+	if input.SprintID != nil {
+		updates = append(updates, fmt.Sprintf("sprint_id = $%d", argNum))
+		args = append(args, nullableUUID(*input.SprintID))
+		argNum++
+	}
+
 	if input.StoryPoints != nil {
 		updates = append(updates, fmt.Sprintf("story_points = $%d", argNum))
 		args = append(args, *input.StoryPoints)
@@ -404,6 +887,24 @@ func (s *TicketStore) Update(ctx context.Context, orgID, ticketID uuid.UUID, inp
 		argNum++
 	}
 
+	if input.CustomFields != nil {
+		updates = append(updates, fmt.Sprintf("custom_fields = $%d", argNum))
+		args = append(args, []byte(input.CustomFields))
+		argNum++
+	}
+
+	if input.IsConfidential != nil {
+		updates = append(updates, fmt.Sprintf("is_confidential = $%d", argNum))
+		args = append(args, *input.IsConfidential)
+		argNum++
+	}
+
+	if input.ACLInheritance != nil {
+		updates = append(updates, fmt.Sprintf("acl_inheritance = $%d", argNum))
+		args = append(args, *input.ACLInheritance)
+		argNum++
+	}
+
 	if len(updates) == 0 {
 		return ticket, nil
 	}
@@ -412,99 +913,416 @@ func (s *TicketStore) Update(ctx context.Context, orgID, ticketID uuid.UUID, inp
 	updates = append(updates, fmt.Sprintf("version = version + 1"))
 	updates = append(updates, "updated_at = NOW()")
 
+	expected := *input.Version
 	query := fmt.Sprintf(
-		"UPDATE change_tickets SET %s WHERE id = $%d AND organization_id = $%d",
-		strings.Join(updates, ", "), argNum, argNum+1,
+		"UPDATE change_tickets SET %s WHERE id = $%d AND organization_id = $%d AND version = $%d",
+		strings.Join(updates, ", "), argNum, argNum+1, argNum+2,
 	)
-	args = append(args, ticketID, orgID)
+	args = append(args, ticketID, orgID, expected)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	_, err = s.db.ExecContext(ctx, query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update ticket: %w", err)
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		// Someone else wrote between our read and update
+		current, err := s.GetByID(ctx, orgID, ticketID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &models.VersionConflictError{
+			ExpectedVersion: expected,
+			CurrentVersion:  current.Version,
+			Conflicts:       input.Conflicts(current),
+		}
+	}
 
-	return s.GetByID(ctx, orgID, ticketID)
+	updated, err := getTicket(ctx, tx, orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertTicketRevision(ctx, tx, ticket, updated, userID, input.ChangeReason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
 }
 
-// UpdateStatus updates the status of a ticket
-func (s *TicketStore) UpdateStatus(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus) error {
-	query := `
+// checkVersion rejects a status transition based on a stale read.
+// An expected version of 0 skips the check for system callers.
+func checkVersion(ticket *models.Ticket, expected int, to models.TicketStatus) error {
+	if expected == 0 || expected == ticket.Version {
+		return nil
+	}
+	return transitionConflict(ticket, expected, to)
+}
+
+func transitionConflict(ticket *models.Ticket, expected int, to models.TicketStatus) error {
+	return &models.VersionConflictError{
+		ExpectedVersion: expected,
+		CurrentVersion:  ticket.Version,
+		Conflicts: map[string]models.FieldConflict{
+			"status": {Current: ticket.Status, Requested: to},
+		},
+	}
+}
+
+// execTransition runs a status transition UPDATE whose last placeholder is
+// the expected version. If no row matched, the ticket is re-read to report
+// the conflict.
+func (s *TicketStore) execTransition(ctx context.Context, q execQuerier, orgID, ticketID uuid.UUID, expected int, to models.TicketStatus, query string, args ...interface{}) error {
+	result, err := q.ExecContext(ctx, query, append(args, expected)...)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		current, err := s.GetByID(ctx, orgID, ticketID)
+		if err != nil {
+			return err
+		}
+		return transitionConflict(current, expected, to)
+	}
+	return nil
+}
+
+// transition moves a ticket to a status its organization's workflow allows
+// and returns the change made. set holds extra assignments for the UPDATE
+// whose placeholders start at $5. A non-zero expected version rejects the
+// change if the ticket has been modified since it was read.
+func (s *TicketStore) transition(ctx context.Context, orgID, ticketID uuid.UUID, to models.TicketStatus, expectedVersion int, set string, args ...interface{}) (*models.TicketTransition, error) {
+	ticket, err := s.GetByID(ctx, orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.requireEdit(ctx, ticket); err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(ticket, expectedVersion, to); err != nil {
+		return nil, err
+	}
+
+	if err := checkTransition(ctx, s.db, ticket, to); err != nil {
+		return nil, err
+	}
+
+	// Sprint burndowns burn a ticket's points on the day it completes
+	if to == models.TicketStatusCompleted {
+		set = "completed_at = NOW(), " + set
+	}
+
+	query := fmt.Sprintf(`
 		UPDATE change_tickets
-		SET status = $1, updated_at = NOW()
-		WHERE id = $2 AND organization_id = $3
-	`
-	_, err := s.db.ExecContext(ctx, query, status, ticketID, orgID)
-	return err
+		SET status = $1, %s version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND organization_id = $3 AND status = $4 AND ($%d = 0 OR version = $%d)
+	`, set, len(args)+5, len(args)+5)
+	args = append([]interface{}{to, ticketID, orgID, ticket.Status}, args...)
+	if err := s.execTransition(ctx, s.db, orgID, ticketID, expectedVersion, to, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &models.TicketTransition{From: ticket.Status, To: to}, nil
+}
+
+// UpdateStatus moves a ticket to any status its workflow allows. Unlike
+// the other transitions it always needs the expected version.
+func (s *TicketStore) UpdateStatus(ctx context.Context, orgID, ticketID uuid.UUID, status models.TicketStatus, expectedVersion int) (*models.TicketTransition, error) {
+	if expectedVersion == 0 {
+		return nil, models.ErrVersionRequired
+	}
+	return s.transition(ctx, orgID, ticketID, status, expectedVersion, "")
 }
 
-// Submit submits a ticket for approval
-func (s *TicketStore) Submit(ctx context.Context, orgID, ticketID uuid.UUID) error {
+// Submit submits a ticket for approval with the plan evaluated from the org's
+// approval rules. Auto-approved plans move the ticket straight to approved.
+// Emergency tickets are flagged and get a shortened approval deadline.
+// A non-zero expected version guards against submitting a stale ticket.
+// The submission is recorded as a new revision.
+func (s *TicketStore) Submit(ctx context.Context, orgID, ticketID, userID uuid.UUID, plan *models.ApprovalPlan, expectedVersion int) (*models.TicketTransition, error) {
 	ticket, err := s.GetByID(ctx, orgID, ticketID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := s.requireEdit(ctx, ticket); err != nil {
+		return nil, err
+	}
+
+	status := models.TicketStatusSubmitted
+	if plan != nil && plan.AutoApprove {
+		status = models.TicketStatusApproved
 	}
 
-	if !ticket.CanSubmit() {
-		return fmt.Errorf("ticket cannot be submitted in current status")
+	if err := checkVersion(ticket, expectedVersion, status); err != nil {
+		return nil, err
+	}
+
+	if err := checkTransition(ctx, s.db, ticket, status); err != nil {
+		return nil, err
 	}
 
 	// Create snapshot
 	snapshot, _ := json.Marshal(ticket)
+	planJSON, _ := json.Marshal(plan)
+
+	isEmergency := ticket.IsEmergencyChange()
+	deadline := ticket.ApprovalDeadline
+	if isEmergency {
+		d := models.EmergencyApprovalDeadline(ticket.ApprovalDeadline, time.Now())
+		deadline = &d
+	}
 
 	query := `
 		UPDATE change_tickets
-		SET status = 'submitted',
+		SET status = $1,
 		    submitted_at = NOW(),
-		    submitted_snapshot = $1,
+		    submitted_snapshot = $2,
+		    approval_plan = $3,
+		    is_emergency = $4,
+		    approval_deadline = $5,
+		    version = version + 1,
 		    updated_at = NOW()
-		WHERE id = $2 AND organization_id = $3
+		WHERE id = $6 AND organization_id = $7 AND status = $8 AND ($9 = 0 OR version = $9)
 	`
-	_, err = s.db.ExecContext(ctx, query, snapshot, ticketID, orgID)
-	return err
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = s.execTransition(ctx, tx, orgID, ticketID, expectedVersion, status, query,
+		status, snapshot, planJSON, isEmergency, deadline, ticketID, orgID, ticket.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	submitted, err := getTicket(ctx, tx, orgID, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertTicketRevision(ctx, tx, ticket, submitted, userID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.TicketTransition{From: ticket.Status, To: status}, nil
 }
 
-// Close closes a ticket
-func (s *TicketStore) Close(ctx context.Context, orgID, ticketID uuid.UUID) error {
+// Close closes a completed ticket
+func (s *TicketStore) Close(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	return s.transition(ctx, orgID, ticketID, models.TicketStatusClosed, expectedVersion, "closed_at = NOW(),")
+}
+
+// Cancel cancels a ticket, recording the reason
+func (s *TicketStore) Cancel(ctx context.Context, orgID, ticketID uuid.UUID, reason string, expectedVersion int) (*models.TicketTransition, error) {
+	return s.transition(ctx, orgID, ticketID, models.TicketStatusCancelled, expectedVersion, "deletion_reason = $5,", reason)
+}
+
+// Reopen sends a closed ticket back for updates
+func (s *TicketStore) Reopen(ctx context.Context, orgID, ticketID uuid.UUID, expectedVersion int) (*models.TicketTransition, error) {
+	return s.transition(ctx, orgID, ticketID, models.TicketStatusUpdateRequested, expectedVersion, "")
+}
+
+// Delete moves a ticket to the trash. A non-zero expected version rejects
+// the deletion if the ticket has been modified since it was read.
+func (s *TicketStore) Delete(ctx context.Context, orgID, ticketID, userID uuid.UUID, reason *string, expectedVersion int) error {
 	ticket, err := s.GetByID(ctx, orgID, ticketID)
 	if err != nil {
 		return err
 	}
-
-	if !ticket.CanClose() {
-		return fmt.Errorf("ticket cannot be closed in current status")
+	if expectedVersion != 0 && expectedVersion != ticket.Version {
+		return &models.VersionConflictError{
+			ExpectedVersion: expectedVersion,
+			CurrentVersion:  ticket.Version,
+			Conflicts:       map[string]models.FieldConflict{},
+		}
 	}
 
-	query := `
+	result, err := s.db.ExecContext(ctx, `
 		UPDATE change_tickets
-		SET status = 'closed',
-		    closed_at = NOW(),
-		    updated_at = NOW()
-		WHERE id = $2 AND organization_id = $3
-	`
-	_, err = s.db.ExecContext(ctx, query, ticketID, orgID)
-	return err
+		SET deleted_at = NOW(), deleted_by = $1, deletion_reason = COALESCE($2, deletion_reason),
+		    version = version + 1, updated_at = NOW()
+		WHERE id = $3 AND organization_id = $4 AND deleted_at IS NULL AND version = $5
+	`, userID, reason, ticketID, orgID, ticket.Version)
+	if err != nil {
+		return fmt.Errorf("failed to delete ticket: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		current, err := s.GetByID(ctx, orgID, ticketID)
+		if err != nil {
+			return err
+		}
+		return &models.VersionConflictError{
+			ExpectedVersion: ticket.Version,
+			CurrentVersion:  current.Version,
+			Conflicts:       map[string]models.FieldConflict{},
+		}
+	}
+	return nil
 }
 
-// Cancel cancels a ticket
-func (s *TicketStore) Cancel(ctx context.Context, orgID, ticketID uuid.UUID, reason string) error {
-	ticket, err := s.GetByID(ctx, orgID, ticketID)
+// ListTrash retrieves an organization's soft-deleted tickets that have not
+// been purged, most recently deleted first
+func (s *TicketStore) ListTrash(ctx context.Context, orgID uuid.UUID, page, perPage int) ([]models.TrashedTicket, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM change_tickets
+		WHERE organization_id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+	`, orgID).Scan(&total)
 	if err != nil {
-		return err
+		return nil, 0, fmt.Errorf("failed to count trash: %w", err)
 	}
 
-	if !ticket.CanCancel() {
-		return fmt.Errorf("ticket cannot be cancelled in current status")
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.ticket_number, t.title, t.status, t.priority,
+		       t.created_by, COALESCE(c.email, ''), COALESCE(c.full_name, ''),
+		       t.deleted_by, d.email, d.full_name,
+		       t.deletion_reason, t.deleted_at
+		FROM change_tickets t
+		LEFT JOIN users c ON c.id = t.created_by
+		LEFT JOIN users d ON d.id = t.deleted_by
+		WHERE t.organization_id = $1 AND t.deleted_at IS NOT NULL AND t.purged_at IS NULL
+		ORDER BY t.deleted_at DESC
+		LIMIT $2 OFFSET $3
+	`, orgID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list trash: %w", err)
 	}
+	defer rows.Close()
 
-	query := `
+	tickets := []models.TrashedTicket{}
+	for rows.Next() {
+		var t models.TrashedTicket
+		var deletedBy *uuid.UUID
+		var deletedByEmail, deletedByName sql.NullString
+		err := rows.Scan(
+			&t.ID, &t.TicketNumber, &t.Title, &t.Status, &t.Priority,
+			&t.CreatedBy.ID, &t.CreatedBy.Email, &t.CreatedBy.FullName,
+			&deletedBy, &deletedByEmail, &deletedByName,
+			&t.DeletionReason, &t.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan trashed ticket: %w", err)
+		}
+		if deletedBy != nil {
+			t.DeletedBy = &models.UserSummary{ID: *deletedBy, Email: deletedByEmail.String, FullName: deletedByName.String}
+		}
+		tickets = append(tickets, t)
+	}
+
+	return tickets, total, rows.Err()
+}
+
+// Restore takes a ticket out of the trash. Purged tickets can't be restored.
+// The deletion reason is cleared unless it is the ticket's cancellation
+// reason.
+func (s *TicketStore) Restore(ctx context.Context, orgID, ticketID uuid.UUID) (*models.Ticket, error) {
+	var purgedAt *time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT purged_at FROM change_tickets
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NOT NULL
+	`, ticketID, orgID).Scan(&purgedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("ticket not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed ticket: %w", err)
+	}
+	if purgedAt != nil {
+		return nil, models.ErrTicketPurged
+	}
+
+	_, err = s.db.ExecContext(ctx, `
 		UPDATE change_tickets
-		SET status = 'cancelled',
-		    deletion_reason = $1,
-		    updated_at = NOW()
-		WHERE id = $2 AND organization_id = $3
-	`
-	_, err = s.db.ExecContext(ctx, query, reason, ticketID, orgID)
-	return err
+		SET deleted_at = NULL, deleted_by = NULL,
+		    deletion_reason = CASE WHEN status = 'cancelled' THEN deletion_reason END,
+		    version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND deleted_at IS NOT NULL AND purged_at IS NULL
+	`, ticketID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore ticket: %w", err)
+	}
+
+	return getTicket(ctx, s.db, orgID, ticketID)
+}
+
+// PurgeTrash permanently discards tickets deleted before the cutoff.
+// Tickets can't be hard deleted, so their content and comments are scrubbed
+// and they are marked purged; the ticket number, revisions and audit
+// history remain. Returns the IDs of the purged tickets.
+func (s *TicketStore) PurgeTrash(ctx context.Context, orgID uuid.UUID, before time.Time) ([]uuid.UUID, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE change_tickets
+		SET title = '[purged]',
+		    description = '',
+		    compliance_notes = NULL,
+		    impact_description = NULL,
+		    rollback_plan = NULL,
+		    testing_plan = NULL,
+		    attachment_urls = '{}',
+		    custom_fields = '{}',
+		    submitted_snapshot = NULL,
+		    deletion_reason = NULL,
+		    purged_at = NOW()
+		WHERE organization_id = $1 AND deleted_at < $2 AND purged_at IS NULL
+		RETURNING id
+	`, orgID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge tickets: %w", err)
+	}
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan purged ticket: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ticket_comments
+		SET comment = '[purged]',
+		    mentioned_users = '{}',
+		    attachment_urls = '{}',
+		    edit_history = '[]'
+		WHERE ticket_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge comments: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
 }
 
 // GetQueue retrieves tickets that need assignment (for ticket queue bot)
@@ -574,3 +1392,68 @@ func (s *TicketStore) UnlinkRepository(ctx context.Context, ticketID, repoID uui
 	_, err := s.db.ExecContext(ctx, query, ticketID, repoID)
 	return err
 }
+
+// SetAffectedResources replaces the inventory hosts a ticket's affected
+// systems resolved to
+func (s *TicketStore) SetAffectedResources(ctx context.Context, orgID, ticketID uuid.UUID, resources []models.AffectedResource) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM ticket_affected_resources WHERE ticket_id = $1 AND organization_id = $2",
+		ticketID, orgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear affected resources: %w", err)
+	}
+
+	for _, r := range resources {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO ticket_affected_resources (ticket_id, organization_id, resource_id, hostname, system, environment)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+			ON CONFLICT (ticket_id, resource_id) DO NOTHING
+		`, ticketID, orgID, r.ResourceID, r.Hostname, r.System, r.Environment)
+		if err != nil {
+			return fmt.Errorf("failed to add affected resource: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListAffectedResources retrieves the inventory hosts a ticket affects,
+// ordered by hostname
+func (s *TicketStore) ListAffectedResources(ctx context.Context, ticketID uuid.UUID) ([]models.AffectedResource, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ticket_id, resource_id, hostname, system, COALESCE(environment, ''), resolved_at
+		FROM ticket_affected_resources
+		WHERE ticket_id = $1
+		ORDER BY hostname
+	`, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list affected resources: %w", err)
+	}
+	defer rows.Close()
+
+	var resources []models.AffectedResource
+	for rows.Next() {
+		var r models.AffectedResource
+		if err := rows.Scan(&r.TicketID, &r.ResourceID, &r.Hostname, &r.System, &r.Environment, &r.ResolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan affected resource: %w", err)
+		}
+		resources = append(resources, r)
+	}
+
+	return resources, rows.Err()
+}
+
+// nullableUUID stores uuid.Nil as NULL
+func nullableUUID(id uuid.UUID) interface{} {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
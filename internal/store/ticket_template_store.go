@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// TicketTemplateStore handles ticket template database operations.
+// Templates belong to the organization or to one user; a user's own
+// template takes precedence over an organization template of the same name.
+type TicketTemplateStore struct {
+	db conn
+}
+
+const ticketTemplateColumns = `
+	id, organization_id, user_id, name, COALESCE(description, ''), ticket, created_by, created_at, updated_at
+`
+
+// templateOwner is the user_id of templates in the scope: the user for
+// their own templates, NULL for the organization's
+func templateOwner(scope string, userID uuid.UUID) *uuid.UUID {
+	if scope == models.TemplateScopeOrg {
+		return nil
+	}
+	return &userID
+}
+
+// List retrieves the templates available to a user, the organization's and
+// their own, by name
+func (s *TicketTemplateStore) List(ctx context.Context, orgID, userID uuid.UUID) ([]models.TicketTemplate, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ticket_templates
+		WHERE organization_id = $1 AND (user_id IS NULL OR user_id = $2)
+		ORDER BY name, user_id IS NULL
+	`, ticketTemplateColumns)
+
+	rows, err := s.db.QueryContext(ctx, query, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ticket templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.TicketTemplate{}
+	for rows.Next() {
+		t, err := scanTicketTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ticket template: %w", err)
+		}
+		templates = append(templates, *t)
+	}
+
+	return templates, rows.Err()
+}
+
+// Get retrieves a template by name: the user's own if they have one,
+// otherwise the organization's
+func (s *TicketTemplateStore) Get(ctx context.Context, orgID, userID uuid.UUID, name string) (*models.TicketTemplate, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ticket_templates
+		WHERE organization_id = $1 AND (user_id IS NULL OR user_id = $2) AND name = $3
+		ORDER BY user_id IS NULL
+		LIMIT 1
+	`, ticketTemplateColumns)
+
+	t, err := scanTicketTemplate(s.db.QueryRowContext(ctx, query, orgID, userID, strings.ToLower(name)))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("ticket template not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket template: %w", err)
+	}
+
+	return t, nil
+}
+
+// Create creates a template in the input's scope
+func (s *TicketTemplateStore) Create(ctx context.Context, orgID, userID uuid.UUID, input *models.CreateTicketTemplateInput) (*models.TicketTemplate, error) {
+	fields, err := json.Marshal(input.Ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ticket template: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO ticket_templates (organization_id, user_id, name, description, ticket, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING %s
+	`, ticketTemplateColumns)
+
+	t, err := scanTicketTemplate(s.db.QueryRowContext(ctx, query,
+		orgID, templateOwner(input.Scope, userID), input.Name, input.Description, fields, userID,
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("ticket template name already exists")
+		}
+		return nil, fmt.Errorf("failed to create ticket template: %w", err)
+	}
+
+	return t, nil
+}
+
+// Update updates the user's own template, or the organization's when scope
+// is "org"
+func (s *TicketTemplateStore) Update(ctx context.Context, orgID, userID uuid.UUID, scope, name string, input *models.UpdateTicketTemplateInput) (*models.TicketTemplate, error) {
+	var setClauses []string
+	var args []interface{}
+	argNum := 1
+
+	if input.Description != nil {
+		setClauses = append(setClauses, fmt.Sprintf("description = NULLIF($%d, '')", argNum))
+		args = append(args, *input.Description)
+		argNum++
+	}
+	if input.Ticket != nil {
+		fields, err := json.Marshal(input.Ticket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ticket template: %w", err)
+		}
+		setClauses = append(setClauses, fmt.Sprintf("ticket = $%d", argNum))
+		args = append(args, fields)
+		argNum++
+	}
+
+	var query string
+	if len(setClauses) == 0 {
+		query = fmt.Sprintf(`
+			SELECT %s
+			FROM ticket_templates
+			WHERE organization_id = $%d AND user_id IS NOT DISTINCT FROM $%d AND name = $%d
+		`, ticketTemplateColumns, argNum, argNum+1, argNum+2)
+	} else {
+		query = fmt.Sprintf(`
+			UPDATE ticket_templates
+			SET %s
+			WHERE organization_id = $%d AND user_id IS NOT DISTINCT FROM $%d AND name = $%d
+			RETURNING %s
+		`, strings.Join(setClauses, ", "), argNum, argNum+1, argNum+2, ticketTemplateColumns)
+	}
+	args = append(args, orgID, templateOwner(scope, userID), strings.ToLower(name))
+
+	t, err := scanTicketTemplate(s.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("ticket template not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ticket template: %w", err)
+	}
+
+	return t, nil
+}
+
+// Delete deletes the user's own template, or the organization's when scope
+// is "org"
+func (s *TicketTemplateStore) Delete(ctx context.Context, orgID, userID uuid.UUID, scope, name string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM ticket_templates
+		WHERE organization_id = $1 AND user_id IS NOT DISTINCT FROM $2 AND name = $3
+	`, orgID, templateOwner(scope, userID), strings.ToLower(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete ticket template: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("ticket template not found")
+	}
+
+	return nil
+}
+
+func scanTicketTemplate(row rowScanner) (*models.TicketTemplate, error) {
+	t := &models.TicketTemplate{}
+	var fields []byte
+	err := row.Scan(
+		&t.ID, &t.OrganizationID, &t.UserID, &t.Name, &t.Description, &fields,
+		&t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fields, &t.Ticket); err != nil {
+		return nil, fmt.Errorf("failed to decode ticket template: %w", err)
+	}
+	t.Scope = models.TemplateScopeUser
+	if t.UserID == nil {
+		t.Scope = models.TemplateScopeOrg
+	}
+	return t, nil
+}
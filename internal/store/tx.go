@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// conn is what the stores run statements on: the database, or the
+// transaction of a Store passed to WithTx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error)
+}
+
+// dbConn runs statements on the database itself
+type dbConn struct {
+	*sql.DB
+	metrics *queryMetrics // Set by Store.Instrument
+}
+
+// BeginTx starts a database transaction
+func (d dbConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := d.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx, savepoints: new(int), metrics: d.metrics}, nil
+}
+
+// QueryContext runs a query
+func (d dbConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	d.metrics.observe(ctx, query, time.Since(start), err)
+	return rows, err
+}
+
+// QueryRowContext runs a query expected to return at most one row
+func (d dbConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := d.DB.QueryRowContext(ctx, query, args...)
+	d.metrics.observe(ctx, query, time.Since(start), rowErr(row))
+	return row
+}
+
+// ExecContext executes a statement
+func (d dbConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := d.DB.ExecContext(ctx, query, args...)
+	d.metrics.observe(ctx, query, time.Since(start), err)
+	return result, err
+}
+
+// Tx is a database transaction, or a savepoint within one. Stores begin
+// their own transactions for multi-statement writes; inside WithTx those
+// become savepoints of the surrounding transaction, so a store method stays
+// all-or-nothing on its own while the caller decides whether any of it is
+// committed.
+type Tx struct {
+	tx         *sql.Tx
+	savepoint  string // Empty for the transaction itself
+	savepoints *int   // Savepoints created in the transaction, for naming
+	done       bool
+	metrics    *queryMetrics
+}
+
+// ExecContext executes a statement in the transaction
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := t.tx.ExecContext(ctx, query, args...)
+	t.metrics.observe(ctx, query, time.Since(start), err)
+	return result, err
+}
+
+// QueryContext runs a query in the transaction
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	t.metrics.observe(ctx, query, time.Since(start), err)
+	return rows, err
+}
+
+// QueryRowContext runs a query expected to return at most one row in the
+// transaction
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := t.tx.QueryRowContext(ctx, query, args...)
+	t.metrics.observe(ctx, query, time.Since(start), rowErr(row))
+	return row
+}
+
+// rowErr is the error of a single-row query, other than finding no row
+func rowErr(row *sql.Row) error {
+	if err := row.Err(); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// BeginTx starts a savepoint nested in the transaction. The options can't
+// change a transaction that has already begun, and are ignored.
+func (t *Tx) BeginTx(ctx context.Context, _ *sql.TxOptions) (*Tx, error) {
+	if t.done {
+		return nil, sql.ErrTxDone
+	}
+	*t.savepoints++
+	name := fmt.Sprintf("sp_%d", *t.savepoints)
+	if _, err := t.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	return &Tx{tx: t.tx, savepoint: name, savepoints: t.savepoints, metrics: t.metrics}, nil
+}
+
+// Commit commits the transaction, or releases the savepoint into the
+// surrounding transaction
+func (t *Tx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if t.savepoint == "" {
+		return t.tx.Commit()
+	}
+	_, err := t.tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// Rollback aborts the transaction, or undoes what was done since the
+// savepoint. Like sql.Tx, it returns sql.ErrTxDone after Commit, so it can
+// be deferred.
+func (t *Tx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	_, err := t.tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+	return err
+}
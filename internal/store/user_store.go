@@ -0,0 +1,584 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserStore handles user database operations. It covers the organization's
+// own staff; customer portal accounts are managed by PortalStore. Password
+// hashes, MFA secrets and backup codes are never read here: login and MFA
+// go through AuthStore.
+type UserStore struct {
+	db conn
+}
+
+const userColumns = `
+	id, organization_id, email, username, full_name, COALESCE(require_password_change, false),
+	COALESCE(mfa_enabled, false), oauth_provider, roles, COALESCE(is_approver, false),
+	COALESCE(approval_types, '{}'), approval_delegate_id, COALESCE(is_on_call, false),
+	COALESCE(is_active, true), COALESCE(email_verified, false), last_login_at, host(last_login_ip),
+	accepts_terms_version, accepts_terms_at, COALESCE(data_retention_consent, false),
+	created_at, updated_at, deleted_at
+`
+
+// Create adds a user to the organization. Users created without a password
+// can only sign in through SSO until an admin sets one.
+func (s *UserStore) Create(ctx context.Context, orgID uuid.UUID, input *models.CreateUserInput) (*models.User, error) {
+	var passwordHash *string
+	if input.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		h := string(hash)
+		passwordHash = &h
+	}
+	approvalTypes := input.ApprovalTypes
+	if approvalTypes == nil {
+		approvalTypes = []models.ApprovalType{}
+	}
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, `
+		INSERT INTO users (
+			organization_id, email, username, full_name, password_hash, roles,
+			is_approver, approval_types
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::approval_type[])
+		RETURNING `+userColumns,
+		orgID, input.Email, input.Username, input.FullName, passwordHash,
+		pq.Array(input.Roles), input.IsApprover, pq.Array(approvalTypes),
+	))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("user already exists")
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return user, nil
+}
+
+// Get retrieves one of the organization's users. Deleted users are
+// included so their records can still be looked up.
+func (s *UserStore) Get(ctx context.Context, orgID, userID uuid.UUID) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE organization_id = $1 AND id = $2 AND customer_id IS NULL",
+		orgID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// GetByEmail retrieves the organization's user with an email address,
+// ignoring case. Deleted users are not matched.
+func (s *UserStore) GetByEmail(ctx context.Context, orgID uuid.UUID, email string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE organization_id = $1 AND lower(email) = lower($2) AND customer_id IS NULL AND deleted_at IS NULL
+	`, orgID, strings.TrimSpace(email)))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// GetByOAuthSubject retrieves the user linked to an SSO identity, in any
+// organization. Deleted users are not matched.
+func (s *UserStore) GetByOAuthSubject(ctx context.Context, provider, subject string) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE oauth_provider = $1 AND oauth_subject = $2 AND deleted_at IS NULL
+		ORDER BY created_at
+		LIMIT 1
+	`, provider, subject))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// List retrieves the organization's users, ordered by email, and the total
+// matching the filter. Deleted users are left out.
+func (s *UserStore) List(ctx context.Context, orgID uuid.UUID, filter *models.UserListFilter) ([]models.User, int, error) {
+	filter.SetDefaults()
+
+	conditions := []string{"organization_id = $1", "customer_id IS NULL", "deleted_at IS NULL"}
+	args := []interface{}{orgID}
+	if filter.Role != nil {
+		args = append(args, string(*filter.Role))
+		conditions = append(conditions, fmt.Sprintf("$%d = ANY(roles)", len(args)))
+	}
+	if filter.ApprovalType != nil {
+		args = append(args, string(*filter.ApprovalType))
+		conditions = append(conditions, fmt.Sprintf("is_approver = true AND $%d::approval_type = ANY(approval_types)", len(args)))
+	}
+	if filter.IsActive != nil {
+		args = append(args, *filter.IsActive)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(is_active, true) = $%d", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(email ILIKE $%[1]d OR username ILIKE $%[1]d OR full_name ILIKE $%[1]d)", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM users WHERE %s ORDER BY email, id LIMIT $%d OFFSET $%d",
+		userColumns, where, len(args)+1, len(args)+2)
+	rows, err := s.db.QueryContext(ctx, query, append(args, filter.PerPage, filter.Offset())...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *u)
+	}
+
+	return users, total, rows.Err()
+}
+
+// ListApprovers retrieves the organization's active users who can approve
+// an approval type, on-call approvers first
+func (s *UserStore) ListApprovers(ctx context.Context, orgID uuid.UUID, approvalType models.ApprovalType) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE organization_id = $1 AND is_active = true AND deleted_at IS NULL AND customer_id IS NULL
+		  AND is_approver = true AND $2::approval_type = ANY(approval_types)
+		ORDER BY COALESCE(is_on_call, false) DESC, email
+	`, orgID, approvalType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvers: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *u)
+	}
+
+	return users, rows.Err()
+}
+
+// Update changes a user. The organization must keep an active admin, and
+// deactivating a user revokes their sessions.
+func (s *UserStore) Update(ctx context.Context, orgID, userID uuid.UUID, input *models.UpdateUserInput) (*models.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE organization_id = $1 AND id = $2 AND customer_id IS NULL AND deleted_at IS NULL
+		FOR UPDATE
+	`, orgID, userID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	wasAdmin := user.IsAdmin() && user.IsActive
+
+	sets := []string{"updated_at = NOW()"}
+	args := []interface{}{orgID, userID}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if input.FullName != nil {
+		set("full_name", *input.FullName)
+		user.FullName = *input.FullName
+	}
+	if input.Username != nil {
+		set("username", *input.Username)
+	}
+	if input.Roles != nil {
+		set("roles", pq.Array(input.Roles))
+		user.Roles = input.Roles
+	}
+	if input.ApprovalTypes != nil {
+		args = append(args, pq.Array(input.ApprovalTypes))
+		sets = append(sets, fmt.Sprintf("approval_types = $%d::approval_type[]", len(args)))
+		user.ApprovalTypes = input.ApprovalTypes
+	}
+	if input.IsApprover != nil {
+		set("is_approver", *input.IsApprover)
+		user.IsApprover = *input.IsApprover
+	}
+	if user.IsApprover && len(user.ApprovalTypes) == 0 {
+		return nil, &models.ValidationError{Field: "approval_types", Message: "approvers need at least one approval type"}
+	}
+	if input.ApprovalDelegateID != nil {
+		if *input.ApprovalDelegateID == userID {
+			return nil, &models.ValidationError{Field: "approval_delegate_id", Message: "users cannot delegate approvals to themselves"}
+		}
+		var ok bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(
+				SELECT 1 FROM users
+				WHERE organization_id = $1 AND id = $2 AND is_active = true AND deleted_at IS NULL AND is_approver = true
+			)
+		`, orgID, *input.ApprovalDelegateID).Scan(&ok)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check approval delegate: %w", err)
+		}
+		if !ok {
+			return nil, &models.ValidationError{Field: "approval_delegate_id", Message: "approval delegate not found"}
+		}
+		set("approval_delegate_id", *input.ApprovalDelegateID)
+	}
+	if input.IsActive != nil {
+		set("is_active", *input.IsActive)
+		user.IsActive = *input.IsActive
+	}
+	if input.RequirePasswordChange != nil {
+		set("require_password_change", *input.RequirePasswordChange)
+	}
+
+	if wasAdmin && !(user.IsAdmin() && user.IsActive) {
+		if err := requireOtherAdmin(ctx, tx, orgID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err = scanUser(tx.QueryRowContext(ctx, fmt.Sprintf(
+		"UPDATE users SET %s WHERE organization_id = $1 AND id = $2 RETURNING %s",
+		strings.Join(sets, ", "), userColumns,
+	), args...))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.Conflict("username is already taken")
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if !user.IsActive {
+		if err := revokeUserSessions(ctx, tx, userID, "user deactivated"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Delete soft deletes a user, keeping the account so the history it
+// appears in still resolves. Their credentials are discarded, their
+// sessions revoked, and users delegating approvals to them stop doing so.
+func (s *UserStore) Delete(ctx context.Context, orgID, userID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var roles []string
+	var active bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT roles, COALESCE(is_active, true)
+		FROM users
+		WHERE organization_id = $1 AND id = $2 AND customer_id IS NULL AND deleted_at IS NULL
+		FOR UPDATE
+	`, orgID, userID).Scan(pq.Array(&roles), &active)
+	if err == sql.ErrNoRows {
+		return models.NotFound("user not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	for _, r := range roles {
+		if r == string(models.UserRoleAdmin) && active {
+			if err := requireOtherAdmin(ctx, tx, orgID, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET deleted_at = NOW(), is_active = false, is_on_call = false,
+		    password_hash = NULL, mfa_secret = NULL, backup_codes = NULL, mfa_last_step = NULL,
+		    webauthn_credentials = '[]', approval_delegate_id = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET approval_delegate_id = NULL, updated_at = NOW() WHERE approval_delegate_id = $1",
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear approval delegations: %w", err)
+	}
+	if err := revokeUserSessions(ctx, tx, userID, "user deleted"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetPassword replaces a user's password and revokes their sessions. With
+// requireChange the user must pick a new password at their next login.
+func (s *UserStore) SetPassword(ctx context.Context, orgID, userID uuid.UUID, password string, requireChange bool) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = $3, require_password_change = $4, updated_at = NOW()
+		WHERE organization_id = $1 AND id = $2 AND customer_id IS NULL AND deleted_at IS NULL
+	`, orgID, userID, string(hash), requireChange)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return models.NotFound("user not found")
+	}
+	if err := revokeUserSessions(ctx, tx, userID, "password reset"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// requireOtherAdmin fails unless the organization has an active admin
+// besides the user
+func requireOtherAdmin(ctx context.Context, tx *Tx, orgID, userID uuid.UUID) error {
+	var ok bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM users
+			WHERE organization_id = $1 AND id <> $2 AND 'admin' = ANY(roles)
+			  AND is_active = true AND deleted_at IS NULL
+		)
+	`, orgID, userID).Scan(&ok)
+	if err != nil {
+		return fmt.Errorf("failed to check admins: %w", err)
+	}
+	if !ok {
+		return models.Conflict("organization must keep an active admin")
+	}
+	return nil
+}
+
+func revokeUserSessions(ctx context.Context, tx *Tx, userID uuid.UUID, reason string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE sessions SET revoked = true, revoked_at = NOW(), revoke_reason = $2
+		WHERE user_id = $1 AND revoked = false
+	`, userID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	u := &models.User{}
+	var roles, approvalTypes []string
+	var lastLoginIP sql.NullString
+	err := row.Scan(
+		&u.ID, &u.OrganizationID, &u.Email, &u.Username, &u.FullName, &u.RequirePasswordChange,
+		&u.MFAEnabled, &u.OAuthProvider, pq.Array(&roles), &u.IsApprover,
+		pq.Array(&approvalTypes), &u.ApprovalDelegateID, &u.IsOnCall,
+		&u.IsActive, &u.EmailVerified, &u.LastLoginAt, &lastLoginIP,
+		&u.AcceptsTermsVersion, &u.AcceptsTermsAt, &u.DataRetentionConsent,
+		&u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	u.Roles = make([]models.UserRole, len(roles))
+	for i, r := range roles {
+		u.Roles[i] = models.UserRole(r)
+	}
+	u.ApprovalTypes = make([]models.ApprovalType, len(approvalTypes))
+	for i, at := range approvalTypes {
+		u.ApprovalTypes[i] = models.ApprovalType(at)
+	}
+	if ip := net.ParseIP(lastLoginIP.String); ip != nil {
+		u.LastLoginIP = &ip
+	}
+	return u, nil
+}
+
+// GetSummaries retrieves summaries of several users at once. Deleted users
+// are included so historical records still show who acted; users outside
+// the organization are left out.
+func (s *UserStore) GetSummaries(ctx context.Context, orgID uuid.UUID, userIDs []uuid.UUID) ([]models.UserSummary, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, email, full_name FROM users WHERE organization_id = $1 AND id = ANY($2)",
+		orgID, pq.Array(userIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.UserSummary
+	for rows.Next() {
+		var u models.UserSummary
+		if err := rows.Scan(&u.ID, &u.Email, &u.FullName); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// ResolveActive maps email addresses or usernames, such as the owners listed
+// in the host inventory, to the organization's active users. Names matching
+// no active user, or only a customer portal account, are ignored.
+func (s *UserStore) ResolveActive(ctx context.Context, orgID uuid.UUID, names []string) ([]uuid.UUID, error) {
+	lowered := make([]string, len(names))
+	for i, n := range names {
+		lowered[i] = strings.ToLower(n)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM users
+		WHERE organization_id = $1 AND is_active = true AND deleted_at IS NULL
+		  AND customer_id IS NULL
+		  AND (lower(email) = ANY($2) OR lower(username) = ANY($2))
+		ORDER BY id
+	`, orgID, pq.Array(lowered))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// FlagEmail flags every user with the address for admin review after SES
+// reported it bounced or complained. Users already flagged keep their
+// original flag time. Returns the number of users flagged.
+func (s *UserStore) FlagEmail(ctx context.Context, email, reason string) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET email_flagged_at = COALESCE(email_flagged_at, NOW()), email_flag_reason = $2, updated_at = NOW()
+		WHERE lower(email) = lower($1) AND deleted_at IS NULL
+	`, email, reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to flag user email: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListEmailFlagged lists the organization's users with a flagged address,
+// most recently flagged first, with the address's suppression if any
+func (s *UserStore) ListEmailFlagged(ctx context.Context, orgID uuid.UUID) ([]models.EmailFlaggedUser, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.email, u.full_name, u.email_flagged_at, COALESCE(u.email_flag_reason, ''),
+		       es.email, es.reason, es.detail, es.ses_message_id, es.created_at, es.updated_at
+		FROM users u
+		LEFT JOIN email_suppressions es ON es.email = lower(u.email)
+		WHERE u.organization_id = $1 AND u.email_flagged_at IS NOT NULL AND u.deleted_at IS NULL
+		ORDER BY u.email_flagged_at DESC
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flagged users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.EmailFlaggedUser
+	for rows.Next() {
+		var u models.EmailFlaggedUser
+		var supEmail, supReason sql.NullString
+		var supCreated, supUpdated sql.NullTime
+		sup := &models.EmailSuppression{}
+		if err := rows.Scan(
+			&u.UserID, &u.Email, &u.FullName, &u.FlaggedAt, &u.Reason,
+			&supEmail, &supReason, &sup.Detail, &sup.SESMessageID, &supCreated, &supUpdated,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		if supEmail.Valid {
+			sup.Email = supEmail.String
+			sup.Reason = supReason.String
+			sup.CreatedAt = supCreated.Time
+			sup.UpdatedAt = supUpdated.Time
+			u.Suppression = sup
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// ClearEmailFlag clears a user's email flag after review and returns the
+// user's address
+func (s *UserStore) ClearEmailFlag(ctx context.Context, orgID, userID uuid.UUID) (string, error) {
+	var email string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users
+		SET email_flagged_at = NULL, email_flag_reason = NULL, updated_at = NOW()
+		WHERE organization_id = $1 AND id = $2 AND email_flagged_at IS NOT NULL AND deleted_at IS NULL
+		RETURNING email
+	`, orgID, userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", models.NotFound("flagged user not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to clear email flag: %w", err)
+	}
+	return email, nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// WorkflowStore handles per-organization ticket workflows
+type WorkflowStore struct {
+	db conn
+}
+
+// Get retrieves an organization's workflow. Organizations that have not
+// configured one get the default workflow with no extra transitions.
+func (s *WorkflowStore) Get(ctx context.Context, orgID uuid.UUID) (*models.TicketWorkflow, error) {
+	return getTicketWorkflow(ctx, s.db, orgID)
+}
+
+// Update replaces an organization's extra transitions
+func (s *WorkflowStore) Update(ctx context.Context, orgID, userID uuid.UUID, input *models.UpdateTicketWorkflowInput) (*models.TicketWorkflow, error) {
+	transitions := input.Transitions
+	if transitions == nil {
+		transitions = []models.TicketTransition{}
+	}
+	transitionsJSON, err := json.Marshal(transitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transitions: %w", err)
+	}
+
+	query := `
+		INSERT INTO ticket_workflows (organization_id, transitions, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET transitions = EXCLUDED.transitions,
+		    updated_by = EXCLUDED.updated_by
+	`
+	if _, err := s.db.ExecContext(ctx, query, orgID, transitionsJSON, userID); err != nil {
+		return nil, fmt.Errorf("failed to update ticket workflow: %w", err)
+	}
+
+	return s.Get(ctx, orgID)
+}
+
+func getTicketWorkflow(ctx context.Context, q execQuerier, orgID uuid.UUID) (*models.TicketWorkflow, error) {
+	query := `
+		SELECT organization_id, transitions, updated_by, created_at, updated_at
+		FROM ticket_workflows
+		WHERE organization_id = $1
+	`
+
+	workflow := &models.TicketWorkflow{}
+	var transitionsJSON []byte
+	err := q.QueryRowContext(ctx, query, orgID).Scan(
+		&workflow.OrganizationID, &transitionsJSON, &workflow.UpdatedBy,
+		&workflow.CreatedAt, &workflow.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &models.TicketWorkflow{OrganizationID: orgID, Transitions: []models.TicketTransition{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket workflow: %w", err)
+	}
+	if err := json.Unmarshal(transitionsJSON, &workflow.Transitions); err != nil {
+		return nil, fmt.Errorf("failed to decode ticket workflow: %w", err)
+	}
+
+	return workflow, nil
+}
+
+// checkTransition applies the organization's workflow to a status change
+// the ticket is about to make
+func checkTransition(ctx context.Context, q execQuerier, ticket *models.Ticket, to models.TicketStatus) error {
+	workflow, err := getTicketWorkflow(ctx, q, ticket.OrganizationID)
+	if err != nil {
+		return err
+	}
+	return workflow.Engine().Check(ticket, to, AccessorFrom(ctx))
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/afterdarksys/adsops-utils/internal/models"
+)
+
+// WorklogStore handles ticket worklog database operations
+type WorklogStore struct {
+	db      conn
+	tickets ticketEvicter
+}
+
+const worklogColumns = `
+	w.id, w.organization_id, w.ticket_id, w.user_id, w.hours, w.work_date,
+	w.description, w.billable, w.created_at, w.updated_at, u.email, u.full_name
+`
+
+// Create logs time on a ticket and adds it to the ticket's time spent
+func (s *WorklogStore) Create(ctx context.Context, orgID, ticketID, userID uuid.UUID, input *models.CreateWorklogInput) (*models.Worklog, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var worklogID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO ticket_worklogs (organization_id, ticket_id, user_id, hours, work_date, description, billable)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, orgID, ticketID, userID, input.Hours, input.Date, input.Description, *input.Billable).Scan(&worklogID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worklog: %w", err)
+	}
+
+	if err := addTimeSpent(ctx, tx, orgID, ticketID, input.Hours); err != nil {
+		return nil, err
+	}
+
+	worklog, err := getWorklog(ctx, tx, orgID, ticketID, worklogID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.tickets.evict(ctx, orgID, ticketID)
+	return worklog, nil
+}
+
+// GetByID retrieves a worklog on a ticket
+func (s *WorklogStore) GetByID(ctx context.Context, orgID, ticketID, worklogID uuid.UUID) (*models.Worklog, error) {
+	return getWorklog(ctx, s.db, orgID, ticketID, worklogID)
+}
+
+func getWorklog(ctx context.Context, q execQuerier, orgID, ticketID, worklogID uuid.UUID) (*models.Worklog, error) {
+	worklog, err := scanWorklog(q.QueryRowContext(ctx, `
+		SELECT `+worklogColumns+`
+		FROM ticket_worklogs w
+		LEFT JOIN users u ON u.id = w.user_id
+		WHERE w.id = $1 AND w.ticket_id = $2 AND w.organization_id = $3
+	`, worklogID, ticketID, orgID))
+	if err == sql.ErrNoRows {
+		return nil, models.NotFound("worklog not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worklog: %w", err)
+	}
+	return worklog, nil
+}
+
+// ListByTicket retrieves a ticket's worklogs, most recent work first
+func (s *WorklogStore) ListByTicket(ctx context.Context, orgID, ticketID uuid.UUID) ([]models.Worklog, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+worklogColumns+`
+		FROM ticket_worklogs w
+		LEFT JOIN users u ON u.id = w.user_id
+		WHERE w.organization_id = $1 AND w.ticket_id = $2
+		ORDER BY w.work_date DESC, w.created_at DESC
+	`, orgID, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worklogs: %w", err)
+	}
+	defer rows.Close()
+
+	worklogs := []models.Worklog{}
+	for rows.Next() {
+		worklog, err := scanWorklog(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan worklog: %w", err)
+		}
+		worklogs = append(worklogs, *worklog)
+	}
+
+	return worklogs, rows.Err()
+}
+
+// Delete removes a worklog and takes its hours off the ticket's time spent
+func (s *WorklogStore) Delete(ctx context.Context, orgID, ticketID, worklogID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hours float64
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM ticket_worklogs
+		WHERE id = $1 AND ticket_id = $2 AND organization_id = $3
+		RETURNING hours
+	`, worklogID, ticketID, orgID).Scan(&hours)
+	if err == sql.ErrNoRows {
+		return models.NotFound("worklog not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete worklog: %w", err)
+	}
+
+	if err := addTimeSpent(ctx, tx, orgID, ticketID, -hours); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.tickets.evict(ctx, orgID, ticketID)
+	return nil
+}
+
+// addTimeSpent adjusts a ticket's running time spent. It doesn't bump the
+// ticket version, so logging time never conflicts with concurrent edits.
+func addTimeSpent(ctx context.Context, q execQuerier, orgID, ticketID uuid.UUID, hours float64) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE change_tickets
+		SET time_spent_hours = GREATEST(COALESCE(time_spent_hours, 0) + $1, 0)
+		WHERE id = $2 AND organization_id = $3
+	`, hours, ticketID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to update time spent: %w", err)
+	}
+	return nil
+}
+
+// Report totals the time each user logged, most hours first
+func (s *WorklogStore) Report(ctx context.Context, orgID uuid.UUID, filter *models.WorklogReportFilter) ([]models.WorklogUserTotal, error) {
+	conditions := []string{"w.organization_id = $1", "t.deleted_at IS NULL"}
+	args := []interface{}{orgID}
+	argNum := 2
+
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("w.work_date >= $%d", argNum))
+		args = append(args, *filter.From)
+		argNum++
+	}
+
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("w.work_date < $%d", argNum))
+		args = append(args, *filter.To)
+		argNum++
+	}
+
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("w.user_id = $%d", argNum))
+		args = append(args, *filter.UserID)
+		argNum++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.customer_id = $%d", argNum))
+		args = append(args, *filter.CustomerID)
+		argNum++
+	}
+
+	if filter.ProjectID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.project_id = $%d", argNum))
+		args = append(args, *filter.ProjectID)
+		argNum++
+	}
+
+	query := fmt.Sprintf(`
+		SELECT w.user_id, COALESCE(u.email, ''), COALESCE(u.full_name, ''),
+		       SUM(w.hours),
+		       COALESCE(SUM(w.hours) FILTER (WHERE w.billable), 0),
+		       COUNT(DISTINCT w.ticket_id),
+		       COUNT(*)
+		FROM ticket_worklogs w
+		JOIN change_tickets t ON t.id = w.ticket_id
+		LEFT JOIN users u ON u.id = w.user_id
+		WHERE %s
+		GROUP BY w.user_id, u.email, u.full_name
+		ORDER BY SUM(w.hours) DESC, u.full_name
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worklog report: %w", err)
+	}
+	defer rows.Close()
+
+	totals := []models.WorklogUserTotal{}
+	for rows.Next() {
+		var t models.WorklogUserTotal
+		err := rows.Scan(
+			&t.User.ID, &t.User.Email, &t.User.FullName,
+			&t.Hours, &t.BillableHours, &t.TicketCount, &t.EntryCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan worklog total: %w", err)
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+func scanWorklog(row rowScanner) (*models.Worklog, error) {
+	w := &models.Worklog{}
+	var email, fullName sql.NullString
+	err := row.Scan(
+		&w.ID, &w.OrganizationID, &w.TicketID, &w.UserID, &w.Hours, &w.WorkDate,
+		&w.Description, &w.Billable, &w.CreatedAt, &w.UpdatedAt, &email, &fullName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if email.Valid {
+		w.User = &models.UserSummary{ID: w.UserID, Email: email.String, FullName: fullName.String}
+	}
+	return w, nil
+}
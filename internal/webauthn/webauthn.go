@@ -0,0 +1,387 @@
+// Package webauthn implements the server side of WebAuthn passkey
+// registration and login: building the options passed to
+// navigator.credentials.create/get and verifying the browser's response.
+//
+// Registration asks for no attestation, so attestation statements are not
+// verified; a credential is trusted because the signed-in user registered
+// it. Binary values in options and responses are base64url without padding,
+// as produced by PublicKeyCredential.toJSON().
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ChallengeSize is the number of random bytes in each ceremony challenge
+const ChallengeSize = 32
+
+// Timeout is how long, in milliseconds, the browser waits for the user
+const Timeout = 300000
+
+// ErrSignCount is returned when an authenticator's signature counter went
+// backwards, which suggests the credential has been cloned
+var ErrSignCount = errors.New("authenticator signature counter did not increase; the credential may be cloned")
+
+// Authenticator data flags
+const (
+	flagUserPresent       = 0x01
+	flagUserVerified      = 0x04
+	flagBackupEligible    = 0x08
+	flagAttestedCredData  = 0x40
+	flagExtensionDataIncl = 0x80
+)
+
+// RelyingParty identifies this service to authenticators
+type RelyingParty struct {
+	ID      string   // Domain credentials are scoped to, e.g. changes.example.com
+	Name    string   // Shown by the browser during registration
+	Origins []string // Origins allowed to run ceremonies, e.g. https://changes.example.com
+}
+
+// User identifies the account a credential is registered for
+type User struct {
+	ID          []byte `json:"-"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialDescriptor names an existing credential
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// CreationOptions are the PublicKeyCredentialCreationOptions for registration
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// CredentialParameter is an acceptable credential type and algorithm
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// RequestOptions are the PublicKeyCredentialRequestOptions for login. An
+// empty AllowCredentials lets the user pick any discoverable passkey.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int                    `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse is the JSON form of the credential returned by
+// navigator.credentials.create
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the credential returned by
+// navigator.credentials.get
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential is a newly registered credential to be stored for the user
+type Credential struct {
+	ID             string // base64url credential ID
+	PublicKey      []byte // COSE_Key
+	Algorithm      int
+	SignCount      uint32
+	AAGUID         string
+	Transports     []string
+	BackupEligible bool
+}
+
+// NewChallenge returns a random ceremony challenge
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// EncodeID encodes binary values such as credential IDs as base64url
+func EncodeID(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CreationOptions builds the registration options for a user. Credentials
+// the user already has are excluded so an authenticator isn't registered twice.
+func (rp *RelyingParty) CreationOptions(challenge []byte, user User, existing []CredentialDescriptor) *CreationOptions {
+	opts := &CreationOptions{
+		Challenge:          EncodeID(challenge),
+		Timeout:            Timeout,
+		ExcludeCredentials: existing,
+		Attestation:        "none",
+	}
+	opts.RP.ID = rp.ID
+	opts.RP.Name = rp.Name
+	opts.User.ID = EncodeID(user.ID)
+	opts.User.Name = user.Name
+	opts.User.DisplayName = user.DisplayName
+	for _, alg := range SupportedAlgorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	opts.AuthenticatorSelection.ResidentKey = "preferred"
+	opts.AuthenticatorSelection.UserVerification = "required"
+	if opts.ExcludeCredentials == nil {
+		opts.ExcludeCredentials = []CredentialDescriptor{}
+	}
+	return opts
+}
+
+// RequestOptions builds the login options. allowed limits the ceremony to
+// the given credentials; leave it empty for discoverable login.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allowed []CredentialDescriptor) *RequestOptions {
+	if allowed == nil {
+		allowed = []CredentialDescriptor{}
+	}
+	return &RequestOptions{
+		Challenge:        EncodeID(challenge),
+		RPID:             rp.ID,
+		Timeout:          Timeout,
+		AllowCredentials: allowed,
+		UserVerification: "required",
+	}
+}
+
+// VerifyRegistration checks the browser's response to a registration
+// ceremony and returns the credential to store
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, resp *AttestationResponse) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, errors.New("credential type must be public-key")
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	raw, err := decodeBase64(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestationObject: %w", err)
+	}
+	v, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestationObject: %w", err)
+	}
+	obj, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("invalid attestationObject: not a map")
+	}
+	authData, ok := obj["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("invalid attestationObject: missing authData")
+	}
+
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if data.flags&flagAttestedCredData == 0 {
+		return nil, errors.New("authenticator data has no attested credential")
+	}
+	key, err := parsePublicKey(data.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := EncodeID(data.credentialID)
+	if resp.ID != "" && resp.ID != credentialID {
+		return nil, errors.New("credential ID does not match authenticator data")
+	}
+
+	return &Credential{
+		ID:             credentialID,
+		PublicKey:      data.publicKey,
+		Algorithm:      key.alg,
+		SignCount:      data.signCount,
+		AAGUID:         data.aaguid,
+		Transports:     resp.Response.Transports,
+		BackupEligible: data.flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion checks the browser's response to a login ceremony against
+// the stored credential and returns the authenticator's new signature count.
+// Authenticators that don't keep a counter always report zero.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, resp *AssertionResponse, storedKey []byte, storedCount uint32) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, errors.New("credential type must be public-key")
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := decodeBase64(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("invalid authenticatorData: %w", err)
+	}
+	data, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return 0, err
+	}
+
+	clientData, _ := decodeBase64(resp.Response.ClientDataJSON)
+	signature, err := decodeBase64(resp.Response.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature: %w", err)
+	}
+	key, err := parsePublicKey(storedKey)
+	if err != nil {
+		return 0, err
+	}
+	clientHash := sha256.Sum256(clientData)
+	if !key.verify(append(append([]byte{}, authData...), clientHash[:]...), signature) {
+		return 0, errors.New("signature verification failed")
+	}
+
+	if (data.signCount != 0 || storedCount != 0) && data.signCount <= storedCount {
+		return 0, ErrSignCount
+	}
+	return data.signCount, nil
+}
+
+// verifyClientData checks the ceremony type, challenge and origin the
+// browser signed over
+func (rp *RelyingParty) verifyClientData(encoded, ceremony string, challenge []byte) error {
+	raw, err := decodeBase64(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+	var clientData struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+	if clientData.Type != ceremony {
+		return fmt.Errorf("client data type must be %s", ceremony)
+	}
+	got, err := decodeBase64(clientData.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return errors.New("challenge does not match")
+	}
+	if clientData.CrossOrigin {
+		return errors.New("cross-origin ceremonies are not allowed")
+	}
+	for _, origin := range rp.Origins {
+		if strings.EqualFold(clientData.Origin, origin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %q is not allowed", clientData.Origin)
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	aaguid       string
+	credentialID []byte
+	publicKey    []byte
+}
+
+// parseAuthenticatorData decodes authenticator data and checks it was
+// produced for this relying party with the user present and verified
+func (rp *RelyingParty) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(raw[:32], rpIDHash[:]) {
+		return nil, errors.New("authenticator data is for a different relying party")
+	}
+
+	data := &authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if data.flags&flagUserPresent == 0 {
+		return nil, errors.New("user presence was not confirmed")
+	}
+	if data.flags&flagUserVerified == 0 {
+		return nil, errors.New("user verification was not performed")
+	}
+
+	if data.flags&flagAttestedCredData != 0 {
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data is too short")
+		}
+		data.aaguid = hex.EncodeToString(rest[:16])
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, errors.New("invalid credential ID length")
+		}
+		data.credentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential public key: %w", err)
+		}
+		data.publicKey = rest[:len(rest)-len(after)]
+		if data.flags&flagExtensionDataIncl == 0 && len(after) > 0 {
+			return nil, errors.New("unexpected data after credential public key")
+		}
+	}
+
+	return data, nil
+}
+
+// decodeBase64 accepts base64url with or without padding, and standard
+// base64 from clients that don't use toJSON()
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "+/") {
+		return base64.RawStdEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,206 @@
+// Package webhooks delivers queued events to organizations' webhook
+// subscriptions.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/afterdarksys/adsops-utils/internal/models"
+	"github.com/afterdarksys/adsops-utils/internal/store"
+)
+
+const (
+	// deliverBatchSize bounds the deliveries claimed per run
+	deliverBatchSize = 100
+	// deliverConcurrency is how many endpoints are sent to at once
+	deliverConcurrency = 8
+	// requestTimeout bounds each POST, including reading the response
+	requestTimeout = 10 * time.Second
+	// deliverLease is how long a claimed delivery is held before another
+	// worker may claim it again; comfortably longer than a batch takes
+	deliverLease = 5 * time.Minute
+)
+
+// Delivery request headers
+const (
+	HeaderEvent     = "X-Adsops-Event"
+	HeaderDelivery  = "X-Adsops-Delivery"
+	HeaderTimestamp = "X-Adsops-Timestamp"
+	HeaderSignature = "X-Adsops-Signature"
+)
+
+// Dispatcher POSTs due outbox deliveries to their subscribers
+type Dispatcher struct {
+	store *store.Store
+	http  *http.Client
+}
+
+// NewDispatcher creates a dispatcher
+func NewDispatcher(s *store.Store) *Dispatcher {
+	return &Dispatcher{
+		store: s,
+		http: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: publicTransport(),
+			// A redirect is reported as a failure rather than followed, so
+			// the signed body is only ever sent to the subscribed URL
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// publicTransport returns a transport that only connects to public
+// addresses. The check runs on the address actually dialed, after DNS, so a
+// subscriber can't reach internal services or cloud metadata by pointing a
+// name at them. Proxies are not used, as they would be dialed instead.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !models.IsPublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConnsPerHost:   deliverConcurrency,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   requestTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// DeliverResult summarizes one dispatcher run
+type DeliverResult struct {
+	Delivered int
+	Retried   int
+	Dead      int // Gave up after the subscription's max attempts
+}
+
+// Deliver claims due deliveries and sends them. A 2xx response marks the
+// delivery delivered; anything else is retried with exponential backoff
+// until its max attempts are used up, when it is marked dead.
+func (d *Dispatcher) Deliver(ctx context.Context, now time.Time) (*DeliverResult, error) {
+	result := &DeliverResult{}
+
+	batch, err := d.store.Webhooks.ClaimDue(ctx, deliverBatchSize, deliverLease)
+	if err != nil {
+		return result, err
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	sem := make(chan struct{}, deliverConcurrency)
+	for i := range batch {
+		delivery := &batch[i]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			attempt := d.send(ctx, delivery, now)
+
+			var retryAt *time.Time
+			if attempt.Error != "" && delivery.Attempts < delivery.MaxAttempts {
+				t := now.Add(models.WebhookRetryDelay(delivery.Attempts))
+				retryAt = &t
+			}
+			err := d.store.Webhooks.RecordAttempt(ctx, delivery, attempt, retryAt)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				if firstErr == nil {
+					firstErr = err
+				}
+			case attempt.Error == "":
+				result.Delivered++
+			case retryAt != nil:
+				result.Retried++
+			default:
+				result.Dead++
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result, firstErr
+}
+
+// send POSTs one delivery and reports how it went
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) *store.WebhookAttempt {
+	attempt := &store.WebhookAttempt{}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("invalid webhook request: %v", err)
+		return attempt
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "adsops-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := d.http.Do(req)
+	if err != nil {
+		attempt.Latency = time.Since(start)
+		attempt.Error = err.Error()
+		return attempt
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, models.MaxWebhookResponseBodyLength))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	attempt.Latency = time.Since(start)
+
+	status := resp.StatusCode
+	attempt.StatusCode = &status
+	// Kept in a TEXT column, so it must be valid UTF-8 without NULs
+	attempt.ResponseBody = strings.ReplaceAll(string(bytes.ToValidUTF8(body, nil)), "\x00", "")
+	if status < 200 || status >= 300 {
+		attempt.Error = fmt.Sprintf("endpoint returned %d", status)
+	}
+	return attempt
+}
+
+// Sign returns the X-Adsops-Signature value for a delivery: the hex
+// HMAC-SHA256, keyed with the subscription's secret, of the timestamp
+// header, a period, and the raw body. Receivers should recompute it,
+// compare in constant time, and reject stale timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
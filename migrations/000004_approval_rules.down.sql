@@ -0,0 +1,24 @@
+-- =====================================================
+-- MIGRATION 004 ROLLBACK: Approval Rules
+-- =====================================================
+
+-- Restore one-approval-per-row completion check
+CREATE OR REPLACE FUNCTION check_approval_completion(ticket_uuid UUID)
+RETURNS BOOLEAN AS $$
+DECLARE
+    pending_count INTEGER;
+BEGIN
+    SELECT COUNT(*)
+    INTO pending_count
+    FROM approvals
+    WHERE ticket_id = ticket_uuid
+      AND status = 'pending';
+
+    RETURN pending_count = 0;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE change_tickets DROP COLUMN IF EXISTS approval_plan;
+
+DROP TRIGGER IF EXISTS update_approval_rules_timestamp ON approval_rules;
+DROP TABLE IF EXISTS approval_rules CASCADE;
@@ -0,0 +1,97 @@
+-- =====================================================
+-- MIGRATION 004: Approval Rules
+-- Per-organization approval quorums evaluated at submit time
+-- =====================================================
+
+-- =====================================================
+-- APPROVAL RULES
+-- =====================================================
+
+CREATE TABLE approval_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    eval_order INTEGER NOT NULL DEFAULT 0,     -- Lower values are evaluated first
+
+    -- Matching: {"risk_levels": [...], "priorities": [...], "change_types": [...], "compliance_frameworks": [...]}
+    conditions JSONB NOT NULL DEFAULT '{}',
+
+    -- Quorums: [{"approval_type": "security", "min_approvals": 2}, ...]
+    requirements JSONB NOT NULL DEFAULT '[]',
+
+    auto_approve BOOLEAN NOT NULL DEFAULT false,     -- e.g. low-risk standard changes
+    stop_processing BOOLEAN NOT NULL DEFAULT false,  -- Skip later rules when matched
+    is_active BOOLEAN NOT NULL DEFAULT true,
+
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(organization_id, name),
+    CONSTRAINT auto_approve_has_no_requirements CHECK (
+        NOT auto_approve OR jsonb_array_length(requirements) = 0
+    )
+);
+
+CREATE INDEX idx_approval_rules_org_active ON approval_rules(organization_id, eval_order)
+    WHERE is_active = true;
+
+CREATE TRIGGER update_approval_rules_timestamp
+    BEFORE UPDATE ON approval_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+ALTER TABLE approval_rules ENABLE ROW LEVEL SECURITY;
+
+-- =====================================================
+-- TICKET APPROVAL PLAN
+-- =====================================================
+
+-- The evaluated plan is frozen on the ticket at submit time so editing
+-- rules does not change the requirements of in-flight changes
+ALTER TABLE change_tickets
+    ADD COLUMN approval_plan JSONB;
+
+-- Approval is complete once every requirement in the plan has reached its
+-- quorum. Tickets submitted before approval plans existed keep the original
+-- behaviour of requiring every approval row to be decided.
+CREATE OR REPLACE FUNCTION check_approval_completion(ticket_uuid UUID)
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_plan JSONB;
+    v_unmet INTEGER;
+    pending_count INTEGER;
+BEGIN
+    SELECT approval_plan INTO v_plan
+    FROM change_tickets
+    WHERE id = ticket_uuid;
+
+    IF v_plan IS NULL THEN
+        SELECT COUNT(*)
+        INTO pending_count
+        FROM approvals
+        WHERE ticket_id = ticket_uuid
+          AND status = 'pending';
+
+        RETURN pending_count = 0;
+    END IF;
+
+    IF (v_plan->>'auto_approve')::BOOLEAN THEN
+        RETURN true;
+    END IF;
+
+    SELECT COUNT(*)
+    INTO v_unmet
+    FROM jsonb_array_elements(COALESCE(v_plan->'requirements', '[]'::jsonb)) req
+    WHERE (
+        SELECT COUNT(*)
+        FROM approvals a
+        WHERE a.ticket_id = ticket_uuid
+          AND a.approval_type::TEXT = req->>'approval_type'
+          AND a.status = 'approved'
+    ) < (req->>'min_approvals')::INTEGER;
+
+    RETURN v_unmet = 0;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,20 @@
+-- =====================================================
+-- MIGRATION 005 ROLLBACK: Emergency Change Workflow
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_pir_timestamp ON post_implementation_reviews;
+DROP TABLE IF EXISTS post_implementation_reviews CASCADE;
+
+DROP INDEX IF EXISTS idx_ticket_audit_emergency;
+ALTER TABLE ticket_audit_log DROP COLUMN IF EXISTS is_emergency;
+
+DROP INDEX IF EXISTS idx_notifications_priority;
+ALTER TABLE notification_queue DROP COLUMN IF EXISTS priority;
+
+DROP INDEX IF EXISTS idx_tickets_emergency_pending;
+ALTER TABLE change_tickets DROP COLUMN IF EXISTS last_escalated_at;
+ALTER TABLE change_tickets DROP COLUMN IF EXISTS escalation_level;
+ALTER TABLE change_tickets DROP COLUMN IF EXISTS is_emergency;
+
+DROP INDEX IF EXISTS idx_users_on_call;
+ALTER TABLE users DROP COLUMN IF EXISTS is_on_call;
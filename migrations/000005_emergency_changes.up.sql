@@ -0,0 +1,73 @@
+-- =====================================================
+-- MIGRATION 005: Emergency Change Workflow
+-- On-call approvers, escalation tracking, PIR requirement
+-- =====================================================
+
+-- =====================================================
+-- ON-CALL APPROVERS
+-- =====================================================
+
+ALTER TABLE users
+    ADD COLUMN is_on_call BOOLEAN DEFAULT false;
+
+CREATE INDEX idx_users_on_call ON users(organization_id)
+    WHERE is_on_call = true AND is_approver = true AND deleted_at IS NULL;
+
+-- =====================================================
+-- EMERGENCY TICKETS
+-- =====================================================
+
+ALTER TABLE change_tickets
+    ADD COLUMN is_emergency BOOLEAN DEFAULT false,
+    ADD COLUMN escalation_level INTEGER DEFAULT 0,
+    ADD COLUMN last_escalated_at TIMESTAMPTZ;
+
+-- Used by the worker to find emergency tickets past their approval deadline
+CREATE INDEX idx_tickets_emergency_pending ON change_tickets(approval_deadline)
+    WHERE is_emergency = true
+      AND status IN ('submitted', 'in_review', 'partially_approved')
+      AND deleted_at IS NULL;
+
+-- =====================================================
+-- NOTIFICATION PRIORITY
+-- =====================================================
+
+-- Higher priority notifications are sent first (emergency = 100)
+ALTER TABLE notification_queue
+    ADD COLUMN priority INTEGER DEFAULT 0;
+
+CREATE INDEX idx_notifications_priority ON notification_queue(status, priority DESC, scheduled_for);
+
+-- =====================================================
+-- AUDIT TAGGING
+-- =====================================================
+
+ALTER TABLE ticket_audit_log
+    ADD COLUMN is_emergency BOOLEAN DEFAULT false;
+
+CREATE INDEX idx_ticket_audit_emergency ON ticket_audit_log(organization_id, created_at DESC)
+    WHERE is_emergency = true;
+
+-- =====================================================
+-- POST-IMPLEMENTATION REVIEWS
+-- =====================================================
+
+CREATE TABLE post_implementation_reviews (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL UNIQUE REFERENCES change_tickets(id),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',  -- pending, completed
+    required_reason VARCHAR(100),                   -- emergency_change, etc.
+    due_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pir_org_status ON post_implementation_reviews(organization_id, status);
+
+CREATE TRIGGER update_pir_timestamp
+    BEFORE UPDATE ON post_implementation_reviews
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+ALTER TABLE post_implementation_reviews ENABLE ROW LEVEL SECURITY;
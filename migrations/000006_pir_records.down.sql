@@ -0,0 +1,22 @@
+-- =====================================================
+-- MIGRATION 006 ROLLBACK: Post-Implementation Review Records
+-- =====================================================
+
+DROP VIEW IF EXISTS v_closed_without_pir;
+
+DROP TRIGGER IF EXISTS no_signed_off_pir_changes ON post_implementation_reviews;
+DROP FUNCTION IF EXISTS prevent_signed_off_pir_changes();
+
+ALTER TABLE post_implementation_reviews
+    DROP CONSTRAINT IF EXISTS completed_pir_has_outcome,
+    DROP CONSTRAINT IF EXISTS valid_pir_status,
+    DROP CONSTRAINT IF EXISTS valid_pir_outcome,
+    DROP COLUMN IF EXISTS sign_off_comment,
+    DROP COLUMN IF EXISTS signed_off_at,
+    DROP COLUMN IF EXISTS signed_off_by,
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS lessons_learned,
+    DROP COLUMN IF EXISTS incident_links,
+    DROP COLUMN IF EXISTS summary,
+    DROP COLUMN IF EXISTS outcome;
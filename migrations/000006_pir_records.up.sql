@@ -0,0 +1,64 @@
+-- =====================================================
+-- MIGRATION 006: Post-Implementation Review Records
+-- Outcome, incident links, lessons learned and sign-off
+-- =====================================================
+
+ALTER TABLE post_implementation_reviews
+    ADD COLUMN outcome VARCHAR(50),                 -- success, rolled_back, partial
+    ADD COLUMN summary TEXT,
+    ADD COLUMN incident_links TEXT[] DEFAULT '{}',
+    ADD COLUMN lessons_learned TEXT,
+    ADD COLUMN created_by UUID REFERENCES users(id),
+    ADD COLUMN completed_at TIMESTAMPTZ,
+    ADD COLUMN signed_off_by UUID REFERENCES users(id),
+    ADD COLUMN signed_off_at TIMESTAMPTZ,
+    ADD COLUMN sign_off_comment TEXT,
+    ADD CONSTRAINT valid_pir_outcome CHECK (
+        outcome IS NULL OR outcome IN ('success', 'rolled_back', 'partial')
+    ),
+    ADD CONSTRAINT valid_pir_status CHECK (
+        status IN ('pending', 'completed', 'signed_off')
+    ),
+    ADD CONSTRAINT completed_pir_has_outcome CHECK (
+        status = 'pending' OR outcome IS NOT NULL
+    );
+
+-- Reviews are SOX evidence once signed off
+CREATE OR REPLACE FUNCTION prevent_signed_off_pir_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status = 'signed_off' THEN
+        RAISE EXCEPTION 'Signed-off post-implementation reviews cannot be modified';
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER no_signed_off_pir_changes
+    BEFORE UPDATE OR DELETE ON post_implementation_reviews
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_signed_off_pir_changes();
+
+-- Changes closed without a completed review (SOX evidence gap)
+CREATE VIEW v_closed_without_pir AS
+SELECT
+    t.id AS ticket_id,
+    t.organization_id,
+    t.ticket_number,
+    t.title,
+    t.priority,
+    t.risk_level,
+    t.is_emergency,
+    t.created_by,
+    t.closed_at,
+    p.id AS pir_id,
+    p.status AS pir_status,
+    p.due_at AS pir_due_at
+FROM change_tickets t
+LEFT JOIN post_implementation_reviews p ON p.ticket_id = t.id
+WHERE t.status = 'closed'
+  AND t.deleted_at IS NULL
+  AND (p.id IS NULL OR p.status = 'pending');
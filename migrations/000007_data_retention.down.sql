@@ -0,0 +1,40 @@
+-- =====================================================
+-- MIGRATION 007 ROLLBACK: Data Retention & GDPR Anonymization
+-- =====================================================
+
+-- Restore original anonymization function
+CREATE OR REPLACE FUNCTION anonymize_user_data(user_uuid UUID)
+RETURNS VOID AS $$
+BEGIN
+    -- Anonymize user record
+    UPDATE users
+    SET
+        email = 'anonymized_' || user_uuid || '@deleted.local',
+        username = 'anonymized_' || user_uuid,
+        full_name = 'Deleted User',
+        password_hash = NULL,
+        mfa_secret = NULL,
+        backup_codes = NULL,
+        webauthn_credentials = '[]',
+        deleted_at = NOW()
+    WHERE id = user_uuid;
+
+    -- Anonymize audit log
+    UPDATE audit_log
+    SET
+        username = 'Deleted User',
+        anonymized = true,
+        anonymized_at = NOW()
+    WHERE user_id = user_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS anonymization_requests CASCADE;
+
+ALTER TABLE ticket_comments DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE ticket_comments DROP COLUMN IF EXISTS anonymized;
+ALTER TABLE ticket_audit_log DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE ticket_audit_log DROP COLUMN IF EXISTS anonymized;
+
+DROP TRIGGER IF EXISTS update_retention_policies_timestamp ON data_retention_policies;
+DROP TABLE IF EXISTS data_retention_policies CASCADE;
@@ -0,0 +1,133 @@
+-- =====================================================
+-- MIGRATION 007: Data Retention & GDPR Anonymization
+-- Per-org retention policies and right-to-be-forgotten requests
+-- =====================================================
+
+-- =====================================================
+-- RETENTION POLICIES
+-- =====================================================
+
+CREATE TABLE data_retention_policies (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+
+    -- Personal data (IP, user agent, session) is scrubbed from audit entries
+    -- older than this. Compliance-relevant entries keep their evidence.
+    pii_retention_days INTEGER NOT NULL DEFAULT 365,
+
+    -- Comments on tickets without compliance frameworks are scrubbed this
+    -- long after the ticket closes
+    comment_retention_days INTEGER NOT NULL DEFAULT 730,
+
+    last_applied_at TIMESTAMPTZ,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_pii_retention CHECK (pii_retention_days >= 30),
+    CONSTRAINT valid_comment_retention CHECK (comment_retention_days >= 30)
+);
+
+CREATE TRIGGER update_retention_policies_timestamp
+    BEFORE UPDATE ON data_retention_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- =====================================================
+-- ANONYMIZATION TRACKING
+-- =====================================================
+
+ALTER TABLE ticket_audit_log
+    ADD COLUMN anonymized BOOLEAN DEFAULT false,
+    ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+ALTER TABLE ticket_comments
+    ADD COLUMN anonymized BOOLEAN DEFAULT false,
+    ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+CREATE TABLE anonymization_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    subject_user_id UUID NOT NULL REFERENCES users(id),
+    requested_by UUID REFERENCES users(id),
+    reason TEXT,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',  -- pending, completed, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+
+    CONSTRAINT valid_anonymization_status CHECK (status IN ('pending', 'completed', 'failed'))
+);
+
+CREATE INDEX idx_anonymization_requests_pending ON anonymization_requests(requested_at)
+    WHERE status = 'pending';
+CREATE INDEX idx_anonymization_requests_org ON anonymization_requests(organization_id, requested_at DESC);
+
+-- =====================================================
+-- ANONYMIZATION
+-- =====================================================
+
+-- Extends the original function to cover ticket audit entries and comments.
+-- Compliance-relevant entries keep their content as evidence; only the
+-- identifying context is removed. The user row is pseudonymized so foreign
+-- keys (ticket authorship, approvals) remain intact.
+CREATE OR REPLACE FUNCTION anonymize_user_data(user_uuid UUID)
+RETURNS VOID AS $$
+BEGIN
+    -- Anonymize user record
+    UPDATE users
+    SET
+        email = 'anonymized_' || user_uuid || '@deleted.local',
+        username = 'anonymized_' || user_uuid,
+        full_name = 'Deleted User',
+        password_hash = NULL,
+        mfa_secret = NULL,
+        backup_codes = NULL,
+        webauthn_credentials = '[]',
+        oauth_subject = NULL,
+        oauth_picture_url = NULL,
+        last_login_ip = NULL,
+        is_active = false,
+        deleted_at = COALESCE(deleted_at, NOW())
+    WHERE id = user_uuid;
+
+    -- Anonymize audit log
+    UPDATE audit_log
+    SET
+        username = 'Deleted User',
+        ip_address = NULL,
+        user_agent = NULL,
+        session_id = NULL,
+        anonymized = true,
+        anonymized_at = NOW()
+    WHERE user_id = user_uuid;
+
+    UPDATE ticket_audit_log
+    SET
+        ip_address = NULL,
+        user_agent = NULL,
+        session_id = NULL,
+        anonymized = true,
+        anonymized_at = NOW()
+    WHERE user_id = user_uuid;
+
+    -- Comments on compliance-scoped tickets are evidence and keep their text
+    UPDATE ticket_comments c
+    SET
+        comment = '[removed at the request of the author]',
+        attachment_urls = '{}',
+        edit_history = '[]',
+        anonymized = true,
+        anonymized_at = NOW()
+    FROM change_tickets t
+    WHERE c.ticket_id = t.id
+      AND c.author_id = user_uuid
+      AND COALESCE(array_length(t.compliance_frameworks, 1), 0) = 0;
+
+    UPDATE ticket_comments
+    SET mentioned_users = array_remove(mentioned_users, user_uuid)
+    WHERE user_uuid = ANY(mentioned_users);
+
+    DELETE FROM sessions WHERE user_id = user_uuid;
+END;
+$$ LANGUAGE plpgsql;
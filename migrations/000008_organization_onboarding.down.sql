@@ -0,0 +1,34 @@
+-- =====================================================
+-- MIGRATION 008 ROLLBACK: Organization Onboarding
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_failed_signups_email;
+CREATE INDEX idx_failed_signups_email ON failed_signup_attempts(email);
+
+DROP TABLE IF EXISTS email_verification_tokens CASCADE;
+
+CREATE OR REPLACE FUNCTION generate_ticket_number(org_id UUID, year INTEGER)
+RETURNS VARCHAR AS $$
+DECLARE
+    next_num INTEGER;
+    ticket_num VARCHAR;
+BEGIN
+    SELECT COALESCE(MAX(
+        CAST(
+            SUBSTRING(ticket_number FROM '[0-9]+$') AS INTEGER
+        )
+    ), 0) + 1
+    INTO next_num
+    FROM change_tickets
+    WHERE organization_id = org_id
+      AND EXTRACT(YEAR FROM created_at) = year;
+
+    ticket_num := 'CHG-' || year || '-' || LPAD(next_num::TEXT, 5, '0');
+    RETURN ticket_num;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE organizations
+    DROP CONSTRAINT IF EXISTS valid_ticket_number_prefix,
+    DROP COLUMN IF EXISTS default_compliance_frameworks,
+    DROP COLUMN IF EXISTS ticket_number_prefix;
@@ -0,0 +1,65 @@
+-- =====================================================
+-- MIGRATION 008: Organization Onboarding
+-- Self-service signup, email verification and per-org ticket settings
+-- =====================================================
+
+-- =====================================================
+-- ORGANIZATION SETTINGS
+-- =====================================================
+
+ALTER TABLE organizations
+    ADD COLUMN ticket_number_prefix VARCHAR(10) NOT NULL DEFAULT 'CHG',
+    ADD COLUMN default_compliance_frameworks compliance_framework[] NOT NULL DEFAULT '{}',
+    ADD CONSTRAINT valid_ticket_number_prefix CHECK (ticket_number_prefix ~ '^[A-Z][A-Z0-9]{1,9}$');
+
+-- Ticket numbers use the organization's prefix. Existing tickets keep their
+-- numbers; the sequence continues from the trailing digits either way.
+CREATE OR REPLACE FUNCTION generate_ticket_number(org_id UUID, year INTEGER)
+RETURNS VARCHAR AS $$
+DECLARE
+    next_num INTEGER;
+    prefix VARCHAR;
+    ticket_num VARCHAR;
+BEGIN
+    SELECT COALESCE(ticket_number_prefix, 'CHG')
+    INTO prefix
+    FROM organizations
+    WHERE id = org_id;
+
+    SELECT COALESCE(MAX(
+        CAST(
+            SUBSTRING(ticket_number FROM '[0-9]+$') AS INTEGER
+        )
+    ), 0) + 1
+    INTO next_num
+    FROM change_tickets
+    WHERE organization_id = org_id
+      AND EXTRACT(YEAR FROM created_at) = year;
+
+    ticket_num := COALESCE(prefix, 'CHG') || '-' || year || '-' || LPAD(next_num::TEXT, 5, '0');
+    RETURN ticket_num;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- EMAIL VERIFICATION
+-- =====================================================
+
+CREATE TABLE email_verification_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,  -- SHA-256 hex of the emailed token
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_verification_user ON email_verification_tokens(user_id);
+
+-- =====================================================
+-- FAILED SIGNUPS
+-- =====================================================
+
+-- track_failed_signup() upserts on email, which needs a unique index
+DROP INDEX IF EXISTS idx_failed_signups_email;
+CREATE UNIQUE INDEX idx_failed_signups_email ON failed_signup_attempts(email);
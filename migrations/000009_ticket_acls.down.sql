@@ -0,0 +1,13 @@
+-- =====================================================
+-- MIGRATION 009 ROLLBACK: Fine-grained Ticket ACLs
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_tickets_confidential;
+DROP INDEX IF EXISTS idx_ticket_acls_active_role;
+DROP INDEX IF EXISTS idx_ticket_acls_active_principal;
+
+ALTER TABLE ticket_acls
+    DROP CONSTRAINT IF EXISTS acl_principal_present,
+    DROP CONSTRAINT IF EXISTS valid_acl_principal_type,
+    ADD CONSTRAINT ticket_acls_ticket_id_principal_type_principal_id_role_name_key
+        UNIQUE (ticket_id, principal_type, principal_id, role_name);
@@ -0,0 +1,26 @@
+-- =====================================================
+-- MIGRATION 009: Fine-grained Ticket ACLs
+-- Grant history and lookups for confidential ticket access checks
+-- =====================================================
+
+-- Revoked grants are kept as history, so uniqueness only applies to active
+-- entries. The original constraint also never fired for user/group grants
+-- because role_name is NULL for them.
+ALTER TABLE ticket_acls
+    DROP CONSTRAINT IF EXISTS ticket_acls_ticket_id_principal_type_principal_id_role_name_key,
+    ADD CONSTRAINT valid_acl_principal_type CHECK (principal_type IN ('user', 'group', 'role')),
+    ADD CONSTRAINT acl_principal_present CHECK (
+        (principal_type IN ('user', 'group') AND principal_id IS NOT NULL)
+        OR (principal_type = 'role' AND role_name IS NOT NULL)
+    );
+
+CREATE UNIQUE INDEX idx_ticket_acls_active_principal
+    ON ticket_acls(ticket_id, principal_type, principal_id)
+    WHERE principal_id IS NOT NULL AND revoked_at IS NULL;
+
+CREATE UNIQUE INDEX idx_ticket_acls_active_role
+    ON ticket_acls(ticket_id, role_name)
+    WHERE role_name IS NOT NULL AND revoked_at IS NULL;
+
+CREATE INDEX idx_tickets_confidential ON change_tickets(organization_id)
+    WHERE is_confidential = true AND deleted_at IS NULL;
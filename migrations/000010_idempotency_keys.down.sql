@@ -0,0 +1,5 @@
+-- =====================================================
+-- MIGRATION 010 ROLLBACK: Idempotency Keys
+-- =====================================================
+
+DROP TABLE IF EXISTS idempotency_keys;
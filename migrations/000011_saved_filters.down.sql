@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 011 ROLLBACK: Saved Filters
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_saved_filters_timestamp ON saved_filters;
+DROP TABLE IF EXISTS saved_filters;
@@ -0,0 +1,26 @@
+-- =====================================================
+-- MIGRATION 011: Saved Filters
+-- Named ticket list filters (personal views) per user
+-- =====================================================
+
+CREATE TABLE saved_filters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    filter JSONB NOT NULL DEFAULT '{}',   -- TicketListFilter without pagination state
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(organization_id, user_id, name)
+);
+
+-- At most one default view per user
+CREATE UNIQUE INDEX idx_saved_filters_default ON saved_filters(organization_id, user_id)
+    WHERE is_default;
+
+CREATE TRIGGER update_saved_filters_timestamp
+    BEFORE UPDATE ON saved_filters
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
@@ -0,0 +1,8 @@
+-- =====================================================
+-- MIGRATION 012 ROLLBACK: GitHub Integration
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_repositories_url;
+DROP TABLE IF EXISTS github_webhook_deliveries;
+DROP TRIGGER IF EXISTS update_github_integrations_timestamp ON github_integrations;
+DROP TABLE IF EXISTS github_integrations;
@@ -0,0 +1,33 @@
+-- =====================================================
+-- MIGRATION 012: GitHub Integration
+-- Per-organization webhook secrets and delivery tracking
+-- =====================================================
+
+CREATE TABLE github_integrations (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id),
+    webhook_secret VARCHAR(255) NOT NULL,                -- HMAC key GitHub signs deliveries with
+    actor_user_id UUID NOT NULL REFERENCES users(id),    -- recorded as linker and comment author
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_github_integrations_timestamp
+    BEFORE UPDATE ON github_integrations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- Processed deliveries, so GitHub redeliveries do not repeat ticket comments
+CREATE TABLE github_webhook_deliveries (
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    delivery_id VARCHAR(64) NOT NULL,                    -- X-GitHub-Delivery
+    event VARCHAR(50) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, delivery_id)
+);
+
+CREATE INDEX idx_github_webhook_deliveries_received ON github_webhook_deliveries(received_at);
+
+-- Webhooks resolve organizations by repository URL
+CREATE INDEX idx_repositories_url ON repositories(url);
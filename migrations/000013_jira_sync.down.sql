@@ -0,0 +1,9 @@
+-- =====================================================
+-- MIGRATION 013 ROLLBACK: Jira Sync
+-- =====================================================
+
+DROP TABLE IF EXISTS jira_comment_links;
+DROP TRIGGER IF EXISTS update_jira_sync_state_timestamp ON jira_sync_state;
+DROP TABLE IF EXISTS jira_sync_state;
+DROP TRIGGER IF EXISTS update_jira_integrations_timestamp ON jira_integrations;
+DROP TABLE IF EXISTS jira_integrations;
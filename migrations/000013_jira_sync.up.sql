@@ -0,0 +1,60 @@
+-- =====================================================
+-- MIGRATION 013: Jira Sync
+-- Mirror change tickets to a Jira project and pull back
+-- status and comments
+-- =====================================================
+
+CREATE TABLE jira_integrations (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id),
+    base_url VARCHAR(512) NOT NULL,                      -- e.g. https://example.atlassian.net
+    email VARCHAR(255) NOT NULL,
+    api_token TEXT NOT NULL,
+    project_key VARCHAR(50) NOT NULL,
+    issue_type VARCHAR(100) NOT NULL DEFAULT 'Task',
+    field_mapping JSONB NOT NULL DEFAULT '{}',           -- ticket field -> Jira field ID
+    status_mapping JSONB NOT NULL DEFAULT '{}',          -- ticket status -> Jira status name
+    actor_user_id UUID NOT NULL REFERENCES users(id),    -- author of comments pulled from Jira
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    last_pulled_at TIMESTAMPTZ,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_jira_integrations_timestamp
+    BEFORE UPDATE ON jira_integrations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- One row per mirrored ticket. pushed_version is the ticket version last
+-- written to Jira so changes pulled from Jira are not pushed back (no loops).
+CREATE TABLE jira_sync_state (
+    ticket_id UUID PRIMARY KEY REFERENCES change_tickets(id),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    issue_id VARCHAR(50),
+    issue_key VARCHAR(50),
+    pushed_version INTEGER NOT NULL DEFAULT 0,
+    pushed_status VARCHAR(50),
+    jira_status VARCHAR(255),                            -- Jira status last pushed or pulled
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(organization_id, issue_key)
+);
+
+CREATE TRIGGER update_jira_sync_state_timestamp
+    BEFORE UPDATE ON jira_sync_state
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- Jira comments already copied onto tickets
+CREATE TABLE jira_comment_links (
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    jira_comment_id VARCHAR(50) NOT NULL,
+    issue_key VARCHAR(50) NOT NULL,
+    comment_id UUID NOT NULL REFERENCES ticket_comments(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, jira_comment_id)
+);
@@ -0,0 +1,5 @@
+-- =====================================================
+-- MIGRATION 014 ROLLBACK: Ticket Import
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_change_tickets_external_reference;
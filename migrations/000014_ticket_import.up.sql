@@ -0,0 +1,9 @@
+-- =====================================================
+-- MIGRATION 014: Ticket Import
+-- Lookup of tickets by the ID they had in another change system
+-- =====================================================
+
+-- Imports skip records whose external reference already exists
+CREATE INDEX idx_change_tickets_external_reference
+    ON change_tickets(organization_id, external_reference)
+    WHERE external_reference IS NOT NULL;
@@ -0,0 +1,7 @@
+-- =====================================================
+-- MIGRATION 015 ROLLBACK: Host Inventory
+-- The tables are shared with hostctl and the blackout tool and may predate
+-- this migration, so they are kept.
+-- =====================================================
+
+SELECT 1;
@@ -0,0 +1,48 @@
+-- =====================================================
+-- MIGRATION 015: Host Inventory
+-- Tables shared with hostctl and the blackout tool, for deployments that
+-- keep the inventory in the change management database. Existing tables
+-- are left as they are.
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS inventory_resources (
+    id SERIAL PRIMARY KEY,
+    resource_name VARCHAR(255),
+    hostname VARCHAR(255) NOT NULL UNIQUE,
+    type VARCHAR(50),
+    provider VARCHAR(50),
+    region VARCHAR(100),
+    status VARCHAR(50) DEFAULT 'active',
+    environment VARCHAR(50),
+    owners JSONB,
+    mailgroups JSONB,
+    metadata JSONB,
+    average_daily_cost DECIMAL(10,2),
+    average_monthly_cost DECIMAL(10,2),
+    external_id VARCHAR(255),
+    external_url TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_resources_status ON inventory_resources(status);
+
+-- Times are UTC, as the blackout tool writes them
+CREATE TABLE IF NOT EXISTS inventory_blackouts (
+    id SERIAL PRIMARY KEY,
+    ticket_number VARCHAR(50) NOT NULL,
+    hostname VARCHAR(255) NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    actual_end_time TIMESTAMP,
+    reason TEXT,
+    created_by VARCHAR(255),
+    status VARCHAR(50) DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_blackouts_hostname ON inventory_blackouts(hostname);
+CREATE INDEX IF NOT EXISTS idx_blackouts_ticket ON inventory_blackouts(ticket_number);
+CREATE INDEX IF NOT EXISTS idx_blackouts_active_lookup
+    ON inventory_blackouts(hostname, status, end_time)
+    WHERE status = 'active';
@@ -0,0 +1,5 @@
+-- =====================================================
+-- MIGRATION 016 ROLLBACK: Ticket Affected Resources
+-- =====================================================
+
+DROP TABLE IF EXISTS ticket_affected_resources;
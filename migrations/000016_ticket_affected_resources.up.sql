@@ -0,0 +1,19 @@
+-- =====================================================
+-- MIGRATION 016: Ticket Affected Resources
+-- Inventory hosts each ticket's affected systems resolved to
+-- =====================================================
+
+-- resource_id refers to inventory_resources, which may live in a separate
+-- inventory database, so it is not a foreign key
+CREATE TABLE ticket_affected_resources (
+    ticket_id UUID NOT NULL REFERENCES change_tickets(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    resource_id INTEGER NOT NULL,
+    hostname VARCHAR(255) NOT NULL,
+    system VARCHAR(255) NOT NULL,                        -- affected system as listed on the ticket
+    resolved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ticket_id, resource_id)
+);
+
+-- Impact analysis and post-change verification look tickets up by host
+CREATE INDEX idx_ticket_affected_resources_hostname ON ticket_affected_resources(organization_id, hostname);
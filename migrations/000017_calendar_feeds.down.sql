@@ -0,0 +1,5 @@
+-- =====================================================
+-- MIGRATION 017 ROLLBACK: Calendar Feeds
+-- =====================================================
+
+DROP TABLE IF EXISTS calendar_feeds;
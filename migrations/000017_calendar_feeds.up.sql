@@ -0,0 +1,19 @@
+-- =====================================================
+-- MIGRATION 017: Calendar Feeds
+-- Secret iCalendar feed URLs of scheduled changes
+-- =====================================================
+
+CREATE TABLE calendar_feeds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,     -- NULL for an organization-wide feed
+    name VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,                   -- SHA-256 hex of the feed token
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_accessed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_calendar_feeds_org ON calendar_feeds(organization_id) WHERE revoked_at IS NULL;
+
@@ -0,0 +1,5 @@
+-- =====================================================
+-- MIGRATION 018 ROLLBACK: CAB Meetings
+-- =====================================================
+
+DROP TABLE IF EXISTS cab_meetings;
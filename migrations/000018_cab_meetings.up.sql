@@ -0,0 +1,17 @@
+-- =====================================================
+-- MIGRATION 018: CAB Meetings
+-- Recorded change management board meetings and their decisions
+-- =====================================================
+
+CREATE TABLE cab_meetings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    meeting_date DATE NOT NULL,
+    recorded_by UUID NOT NULL REFERENCES users(id),
+    attendees TEXT[] NOT NULL DEFAULT '{}',
+    notes TEXT,
+    decisions JSONB NOT NULL DEFAULT '[]',   -- CABDecisionResult per ticket discussed
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_cab_meetings_org_date ON cab_meetings(organization_id, meeting_date DESC);
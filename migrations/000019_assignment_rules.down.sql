@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 019 ROLLBACK: Assignment Rules
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_assignment_rules_timestamp ON assignment_rules;
+DROP TABLE IF EXISTS assignment_rules;
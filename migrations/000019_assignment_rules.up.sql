@@ -0,0 +1,36 @@
+-- =====================================================
+-- MIGRATION 019: Assignment Rules
+-- Per-organization rules the queue bot uses to assign tickets
+-- =====================================================
+
+CREATE TABLE assignment_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    eval_order INTEGER NOT NULL DEFAULT 0,     -- Lower values are evaluated first
+
+    -- Matching, as for approval rules: {"risk_levels": [...], "priorities": [...], ...}
+    conditions JSONB NOT NULL DEFAULT '{}',
+
+    strategy VARCHAR(30) NOT NULL,             -- round_robin, system_owner, least_loaded
+    group_id UUID REFERENCES groups(id) ON DELETE CASCADE,  -- NULL uses the ticket's owning group
+    last_assigned_to UUID REFERENCES users(id) ON DELETE SET NULL,  -- round-robin position
+
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(organization_id, name),
+    CONSTRAINT valid_assignment_strategy CHECK (strategy IN ('round_robin', 'system_owner', 'least_loaded'))
+);
+
+CREATE INDEX idx_assignment_rules_org_active ON assignment_rules(organization_id, eval_order)
+    WHERE is_active = true;
+
+CREATE TRIGGER update_assignment_rules_timestamp
+    BEFORE UPDATE ON assignment_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
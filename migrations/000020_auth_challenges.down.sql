@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 020 ROLLBACK: Authentication Challenges
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_users_webauthn_credentials;
+DROP TABLE IF EXISTS auth_challenges;
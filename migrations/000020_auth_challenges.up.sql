@@ -0,0 +1,21 @@
+-- =====================================================
+-- MIGRATION 020: Authentication Challenges
+-- Single-use challenges for passkey ceremonies and pending MFA logins,
+-- and lookup of passkeys by credential ID
+-- =====================================================
+
+CREATE TABLE auth_challenges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    purpose VARCHAR(30) NOT NULL,                 -- passkey_registration, passkey_login, mfa
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,  -- NULL for discoverable passkey login
+    challenge BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_auth_challenge_purpose CHECK (purpose IN ('passkey_registration', 'passkey_login', 'mfa'))
+);
+
+CREATE INDEX idx_auth_challenges_expires ON auth_challenges(expires_at);
+
+-- Passkey login finds the user from the credential the browser returns
+CREATE INDEX idx_users_webauthn_credentials ON users USING GIN (webauthn_credentials jsonb_path_ops);
@@ -0,0 +1,11 @@
+-- =====================================================
+-- MIGRATION 021 ROLLBACK: MFA Policy
+-- =====================================================
+
+ALTER TABLE auth_challenges DROP COLUMN IF EXISTS attempts;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS mfa_last_step,
+    DROP COLUMN IF EXISTS mfa_required;
+
+ALTER TABLE organizations DROP COLUMN IF EXISTS mfa_required_roles;
@@ -0,0 +1,17 @@
+-- =====================================================
+-- MIGRATION 021: MFA Policy
+-- Roles an organization's MFA requirement applies to, per-user MFA
+-- requirement, TOTP replay protection and MFA attempt limits
+-- =====================================================
+
+-- When require_mfa is set, users holding one of these roles must use MFA.
+-- An empty list applies the requirement to every user.
+ALTER TABLE organizations
+    ADD COLUMN mfa_required_roles VARCHAR(50)[] NOT NULL DEFAULT '{"admin","approver"}';
+
+ALTER TABLE users
+    ADD COLUMN mfa_required BOOLEAN NOT NULL DEFAULT false,  -- Required by an admin regardless of role
+    ADD COLUMN mfa_last_step BIGINT;                         -- Last accepted TOTP time step
+
+ALTER TABLE auth_challenges
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
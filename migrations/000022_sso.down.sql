@@ -0,0 +1,14 @@
+-- =====================================================
+-- MIGRATION 022 ROLLBACK: Single Sign-On
+-- =====================================================
+
+DELETE FROM auth_challenges WHERE purpose IN ('oauth2', 'sso_link');
+ALTER TABLE auth_challenges DROP COLUMN IF EXISTS data;
+ALTER TABLE auth_challenges DROP CONSTRAINT valid_auth_challenge_purpose;
+ALTER TABLE auth_challenges
+    ADD CONSTRAINT valid_auth_challenge_purpose
+    CHECK (purpose IN ('passkey_registration', 'passkey_login', 'mfa'));
+
+DROP TRIGGER IF EXISTS update_sso_connections_timestamp ON sso_connections;
+DROP TABLE IF EXISTS sso_connections;
+DROP TABLE IF EXISTS sso_domains;
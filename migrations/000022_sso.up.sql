@@ -0,0 +1,46 @@
+-- =====================================================
+-- MIGRATION 022: Single Sign-On
+-- Email domains owned by organizations, per-provider SSO settings with
+-- group to role mapping, and pending OAuth2 and account-link state
+-- =====================================================
+
+-- A domain belongs to one organization, which SSO users with that email
+-- domain sign in to
+CREATE TABLE sso_domains (
+    domain VARCHAR(255) PRIMARY KEY,              -- Lowercase, e.g. example.com
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(id)
+);
+
+CREATE INDEX idx_sso_domains_org ON sso_domains(organization_id);
+
+CREATE TABLE sso_connections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    provider VARCHAR(30) NOT NULL,                -- google, afterdark
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    jit_provisioning BOOLEAN NOT NULL DEFAULT true,  -- Create accounts on first sign-in
+    default_roles VARCHAR(50)[] NOT NULL DEFAULT '{"user"}',
+    group_roles JSONB NOT NULL DEFAULT '{}',      -- IdP group name -> role
+    sync_roles BOOLEAN NOT NULL DEFAULT false,    -- Recompute roles from groups on every sign-in
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(id),
+    updated_by UUID REFERENCES users(id),
+
+    CONSTRAINT unique_sso_connection UNIQUE (organization_id, provider),
+    CONSTRAINT valid_sso_provider CHECK (provider IN ('google', 'afterdark'))
+);
+
+CREATE TRIGGER update_sso_connections_timestamp
+    BEFORE UPDATE ON sso_connections
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- OAuth2 state and SSO identities waiting to be linked to a password account
+ALTER TABLE auth_challenges DROP CONSTRAINT valid_auth_challenge_purpose;
+ALTER TABLE auth_challenges
+    ADD CONSTRAINT valid_auth_challenge_purpose
+    CHECK (purpose IN ('passkey_registration', 'passkey_login', 'mfa', 'oauth2', 'sso_link'));
+ALTER TABLE auth_challenges ADD COLUMN data JSONB;
@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 023 ROLLBACK: Custom Field Schemas
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_custom_field_definitions_timestamp ON custom_field_definitions;
+DROP TABLE IF EXISTS custom_field_definitions;
@@ -0,0 +1,34 @@
+-- =====================================================
+-- MIGRATION 023: Custom Field Schemas
+-- Per-organization definitions of the keys allowed in
+-- change_tickets.custom_fields, their types and where they apply
+-- =====================================================
+
+CREATE TABLE custom_field_definitions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,                    -- Key in custom_fields, e.g. vendor_ticket
+    label VARCHAR(255) NOT NULL,                  -- Shown on forms
+    description TEXT,
+    field_type VARCHAR(20) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT false,
+    allowed_values TEXT[] NOT NULL DEFAULT '{}',  -- Options for select and multi_select
+    change_types VARCHAR(50)[] NOT NULL DEFAULT '{}',  -- Empty applies to every change type
+
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(organization_id, name),
+    CONSTRAINT valid_custom_field_type CHECK (
+        field_type IN ('string', 'number', 'boolean', 'date', 'select', 'multi_select')
+    ),
+    CONSTRAINT select_has_allowed_values CHECK (
+        field_type NOT IN ('select', 'multi_select') OR cardinality(allowed_values) > 0
+    )
+);
+
+CREATE TRIGGER update_custom_field_definitions_timestamp
+    BEFORE UPDATE ON custom_field_definitions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
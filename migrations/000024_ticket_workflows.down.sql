@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 024 ROLLBACK: Ticket Workflows
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_ticket_workflows_timestamp ON ticket_workflows;
+DROP TABLE IF EXISTS ticket_workflows;
@@ -0,0 +1,23 @@
+-- =====================================================
+-- MIGRATION 024: Ticket Workflows
+-- Per-organization status transitions added to the
+-- default ticket workflow, optionally limited to roles
+-- =====================================================
+
+CREATE TABLE ticket_workflows (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+
+    -- [{"from": "implementing", "to": "cancelled", "roles": ["admin"]}, ...]
+    transitions JSONB NOT NULL DEFAULT '[]',
+
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT transitions_is_array CHECK (jsonb_typeof(transitions) = 'array')
+);
+
+CREATE TRIGGER update_ticket_workflows_timestamp
+    BEFORE UPDATE ON ticket_workflows
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
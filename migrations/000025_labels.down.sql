@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 025 ROLLBACK: Labels
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_labels_timestamp ON labels;
+DROP TABLE IF EXISTS labels;
@@ -0,0 +1,26 @@
+-- =====================================================
+-- MIGRATION 025: Labels
+-- Per-organization label definitions for change_tickets.labels.
+-- Label filters use array containment (labels @> ...), which
+-- is served by idx_tickets_labels from migration 002.
+-- =====================================================
+
+CREATE TABLE labels (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#6b7280',
+    description TEXT,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT unique_label_name UNIQUE (organization_id, name),
+    CONSTRAINT valid_label_color CHECK (color ~ '^#[0-9a-f]{6}$'),
+    CONSTRAINT label_name_has_no_comma CHECK (position(',' IN name) = 0)
+);
+
+CREATE TRIGGER update_labels_timestamp
+    BEFORE UPDATE ON labels
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
@@ -0,0 +1,20 @@
+-- =====================================================
+-- MIGRATION 026 ROLLBACK: Epics & Sprints
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_tickets_sprint;
+
+-- Epic links to epic rows can't be kept once epic_id points at tickets again
+UPDATE change_tickets SET epic_id = NULL
+WHERE epic_id IS NOT NULL AND epic_id NOT IN (SELECT id FROM change_tickets);
+
+ALTER TABLE change_tickets
+    DROP CONSTRAINT IF EXISTS change_tickets_epic_id_fkey,
+    ADD CONSTRAINT change_tickets_epic_id_fkey FOREIGN KEY (epic_id) REFERENCES change_tickets(id),
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS sprint_id;
+
+DROP TRIGGER IF EXISTS update_sprints_timestamp ON sprints;
+DROP TABLE IF EXISTS sprints;
+DROP TRIGGER IF EXISTS update_epics_timestamp ON epics;
+DROP TABLE IF EXISTS epics;
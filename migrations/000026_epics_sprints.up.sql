@@ -0,0 +1,79 @@
+-- =====================================================
+-- MIGRATION 026: Epics & Sprints
+-- Epic and sprint entities for change_tickets.epic_id and
+-- the new sprint_id, plus completed_at for burndowns
+-- =====================================================
+
+CREATE TABLE epics (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    target_date DATE,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_epic_status CHECK (status IN ('open', 'in_progress', 'done'))
+);
+
+CREATE INDEX idx_epics_org_status ON epics(organization_id, status);
+CREATE INDEX idx_epics_project ON epics(project_id);
+
+CREATE TRIGGER update_epics_timestamp
+    BEFORE UPDATE ON epics
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+CREATE TABLE sprints (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    goal TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'planned',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    completed_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_sprint_status CHECK (status IN ('planned', 'active', 'completed')),
+    CONSTRAINT valid_sprint_dates CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_sprints_org_status ON sprints(organization_id, status);
+CREATE INDEX idx_sprints_project ON sprints(project_id);
+
+CREATE TRIGGER update_sprints_timestamp
+    BEFORE UPDATE ON sprints
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- =====================================================
+-- TICKET LINKS
+-- =====================================================
+
+-- epic_id pointed at another ticket. Tickets used as epics become epic
+-- rows with the same ID so existing links survive the new foreign key.
+INSERT INTO epics (id, organization_id, project_id, name, description, status, created_by, created_at)
+SELECT e.id, e.organization_id, e.project_id, LEFT(e.title, 255), e.description,
+       CASE WHEN e.status IN ('completed', 'closed', 'cancelled', 'denied') THEN 'done' ELSE 'open' END,
+       e.created_by, e.created_at
+FROM change_tickets e
+WHERE e.id IN (SELECT epic_id FROM change_tickets WHERE epic_id IS NOT NULL);
+
+ALTER TABLE change_tickets
+    DROP CONSTRAINT IF EXISTS change_tickets_epic_id_fkey,
+    ADD CONSTRAINT change_tickets_epic_id_fkey FOREIGN KEY (epic_id) REFERENCES epics(id) ON DELETE SET NULL,
+    ADD COLUMN sprint_id UUID REFERENCES sprints(id) ON DELETE SET NULL,
+    ADD COLUMN completed_at TIMESTAMPTZ;
+
+CREATE INDEX idx_tickets_sprint ON change_tickets(sprint_id);
+
+UPDATE change_tickets
+SET completed_at = COALESCE(actual_end, closed_at, updated_at)
+WHERE status IN ('completed', 'closed');
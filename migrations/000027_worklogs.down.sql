@@ -0,0 +1,7 @@
+-- =====================================================
+-- MIGRATION 027 ROLLBACK: Worklogs
+-- time_spent_hours keeps the totals accumulated so far.
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_ticket_worklogs_timestamp ON ticket_worklogs;
+DROP TABLE IF EXISTS ticket_worklogs;
@@ -0,0 +1,30 @@
+-- =====================================================
+-- MIGRATION 027: Worklogs
+-- Time logged against tickets. Each entry is added to
+-- change_tickets.time_spent_hours, which stays the running
+-- total compared against time_estimate_hours.
+-- =====================================================
+
+CREATE TABLE ticket_worklogs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    ticket_id UUID NOT NULL REFERENCES change_tickets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    hours DECIMAL(5,2) NOT NULL,
+    work_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    description TEXT,
+    billable BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_worklog_hours CHECK (hours > 0 AND hours <= 24)
+);
+
+CREATE INDEX idx_worklogs_ticket ON ticket_worklogs(ticket_id, work_date DESC);
+CREATE INDEX idx_worklogs_org_user_date ON ticket_worklogs(organization_id, user_id, work_date);
+CREATE INDEX idx_worklogs_org_date ON ticket_worklogs(organization_id, work_date);
+
+CREATE TRIGGER update_ticket_worklogs_timestamp
+    BEFORE UPDATE ON ticket_worklogs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
@@ -0,0 +1,10 @@
+-- =====================================================
+-- MIGRATION 028 ROLLBACK: Customer Portal
+-- Portal accounts are deactivated so they don't become
+-- ordinary users once the customer link is gone.
+-- =====================================================
+
+UPDATE users SET is_active = false, deleted_at = NOW() WHERE customer_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_users_customer;
+ALTER TABLE users DROP COLUMN IF EXISTS customer_id;
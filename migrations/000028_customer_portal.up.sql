@@ -0,0 +1,12 @@
+-- =====================================================
+-- MIGRATION 028: Customer Portal
+-- Users linked to a customer are portal accounts: they sign in
+-- with the usual auth endpoints but get tokens limited to the
+-- portal, which shows their customer's tickets and public
+-- comments only.
+-- =====================================================
+
+ALTER TABLE users
+    ADD COLUMN customer_id UUID REFERENCES customers(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_users_customer ON users(customer_id) WHERE customer_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- =====================================================
+-- MIGRATION 029 ROLLBACK: Ticket Trash
+-- Tickets in the trash stay soft deleted.
+-- =====================================================
+
+ALTER TABLE data_retention_policies
+    DROP CONSTRAINT IF EXISTS valid_trash_retention,
+    DROP COLUMN IF EXISTS trash_retention_days;
+
+DROP INDEX IF EXISTS idx_tickets_trash;
+
+ALTER TABLE change_tickets
+    DROP COLUMN IF EXISTS purged_at,
+    DROP COLUMN IF EXISTS deleted_by;
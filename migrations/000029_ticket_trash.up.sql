@@ -0,0 +1,19 @@
+-- =====================================================
+-- MIGRATION 029: Ticket Trash
+-- Soft-deleted tickets stay in the trash, where admins can
+-- restore them, until the organization's trash retention
+-- period passes. Tickets can't be hard deleted, so purging
+-- scrubs their content and marks them purged; the ticket
+-- number, revisions and audit history remain.
+-- =====================================================
+
+ALTER TABLE change_tickets
+    ADD COLUMN deleted_by UUID REFERENCES users(id),
+    ADD COLUMN purged_at TIMESTAMPTZ;
+
+CREATE INDEX idx_tickets_trash ON change_tickets(organization_id, deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+ALTER TABLE data_retention_policies
+    ADD COLUMN trash_retention_days INTEGER NOT NULL DEFAULT 30,
+    ADD CONSTRAINT valid_trash_retention CHECK (trash_retention_days BETWEEN 1 AND 365);
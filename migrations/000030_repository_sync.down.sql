@@ -0,0 +1,10 @@
+-- =====================================================
+-- MIGRATION 030 ROLLBACK: Repository Sync
+-- Repositories keep the details synced so far.
+-- =====================================================
+
+ALTER TABLE repositories
+    DROP COLUMN IF EXISTS last_sync_error;
+
+DROP TRIGGER IF EXISTS update_repository_sync_tokens_timestamp ON repository_sync_tokens;
+DROP TABLE IF EXISTS repository_sync_tokens;
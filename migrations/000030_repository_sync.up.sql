@@ -0,0 +1,26 @@
+-- =====================================================
+-- MIGRATION 030: Repository Sync
+-- Organization-level GitHub and GitLab API tokens used to
+-- refresh repository details from the provider
+-- =====================================================
+
+CREATE TABLE repository_sync_tokens (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,                        -- github, gitlab
+    api_base_url VARCHAR(512) NOT NULL,                   -- e.g. https://api.github.com or a self-hosted instance
+    api_token TEXT NOT NULL,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, provider),
+    CONSTRAINT valid_sync_provider CHECK (provider IN ('github', 'gitlab'))
+);
+
+CREATE TRIGGER update_repository_sync_tokens_timestamp
+    BEFORE UPDATE ON repository_sync_tokens
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- Why the last sync failed; cleared by the next successful one
+ALTER TABLE repositories
+    ADD COLUMN last_sync_error TEXT;
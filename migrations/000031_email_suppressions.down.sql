@@ -0,0 +1,14 @@
+-- =====================================================
+-- MIGRATION 031 ROLLBACK: Email Suppressions
+-- Suppressed addresses are sent to again.
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_notifications_ses_message;
+DROP INDEX IF EXISTS idx_users_email_flagged;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_flag_reason,
+    DROP COLUMN IF EXISTS email_flagged_at;
+
+DROP TRIGGER IF EXISTS update_email_suppressions_timestamp ON email_suppressions;
+DROP TABLE IF EXISTS email_suppressions;
@@ -0,0 +1,28 @@
+-- =====================================================
+-- MIGRATION 031: Email Suppressions
+-- Addresses SES reported as hard-bounced or complained are
+-- no longer sent to, and the users behind them are flagged
+-- for an admin to review
+-- =====================================================
+
+CREATE TABLE email_suppressions (
+    email VARCHAR(255) PRIMARY KEY,                       -- lower-cased
+    reason VARCHAR(50) NOT NULL,                          -- bounce, complaint
+    detail TEXT,                                          -- bounce subtype and diagnostic, or complaint feedback type
+    ses_message_id VARCHAR(255),                          -- message that triggered the suppression
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_suppression_reason CHECK (reason IN ('bounce', 'complaint'))
+);
+
+CREATE TRIGGER update_email_suppressions_timestamp
+    BEFORE UPDATE ON email_suppressions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+ALTER TABLE users
+    ADD COLUMN email_flagged_at TIMESTAMPTZ,
+    ADD COLUMN email_flag_reason TEXT;
+
+CREATE INDEX idx_users_email_flagged ON users(organization_id) WHERE email_flagged_at IS NOT NULL;
+CREATE INDEX idx_notifications_ses_message ON notification_queue(ses_message_id) WHERE ses_message_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 032 ROLLBACK: Audit Log Archives
+-- Archived objects are left in object storage.
+-- =====================================================
+
+DROP TABLE IF EXISTS audit_log_archives;
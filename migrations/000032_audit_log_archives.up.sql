@@ -0,0 +1,16 @@
+-- =====================================================
+-- MIGRATION 032: Audit Log Archives
+-- Days of ticket_audit_log exported to object storage as
+-- gzipped NDJSON with a manifest, for SOX retention
+-- =====================================================
+
+CREATE TABLE audit_log_archives (
+    archive_date DATE PRIMARY KEY,                        -- UTC day archived
+    object_key TEXT NOT NULL,
+    manifest_key TEXT NOT NULL,
+    record_count BIGINT NOT NULL,
+    compressed_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,                             -- of the gzipped object
+    uncompressed_sha256 CHAR(64) NOT NULL,                -- of the NDJSON inside
+    exported_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,9 @@
+-- =====================================================
+-- MIGRATION 033 ROLLBACK: Webhook Delivery
+-- Undelivered events are discarded.
+-- =====================================================
+
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhook_outbox;
+DROP TRIGGER IF EXISTS update_webhook_subscriptions_timestamp ON webhook_subscriptions;
+DROP TABLE IF EXISTS webhook_subscriptions;
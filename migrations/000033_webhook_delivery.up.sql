@@ -0,0 +1,66 @@
+-- =====================================================
+-- MIGRATION 033: Webhook Delivery
+-- Organization webhook subscriptions and the outbox of
+-- events the worker delivers to them
+-- =====================================================
+
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,                         -- HMAC-SHA256 key deliveries are signed with
+    events TEXT[] NOT NULL,                               -- event types, or '*' for all
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    max_attempts INTEGER NOT NULL DEFAULT 10,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_subscriptions_org ON webhook_subscriptions(organization_id) WHERE is_active = true;
+
+CREATE TRIGGER update_webhook_subscriptions_timestamp
+    BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- One row per event per subscription, written by the API and sent by the worker
+CREATE TABLE webhook_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),       -- X-Adsops-Delivery
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,                               -- shared by every subscription's copy of the event
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    last_status_code INTEGER,
+    last_error TEXT,
+    last_response_body TEXT,
+    last_latency_ms INTEGER,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_webhook_delivery_status CHECK (status IN ('pending', 'delivered', 'dead'))
+);
+
+CREATE INDEX idx_webhook_outbox_due ON webhook_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_outbox_subscription ON webhook_outbox(subscription_id, created_at DESC);
+CREATE INDEX idx_webhook_outbox_org ON webhook_outbox(organization_id, created_at DESC);
+
+-- Every delivery attempt, for delivery metrics
+CREATE TABLE webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_outbox(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    status_code INTEGER,
+    error TEXT,
+    latency_ms INTEGER NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_subscription ON webhook_delivery_attempts(subscription_id, attempted_at DESC);
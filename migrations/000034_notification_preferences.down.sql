@@ -0,0 +1,7 @@
+-- =====================================================
+-- MIGRATION 034 ROLLBACK: Notification Preferences
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_ticket_audit_status_changes;
+DROP TRIGGER IF EXISTS update_notification_preferences_timestamp ON notification_preferences;
+DROP TABLE IF EXISTS notification_preferences;
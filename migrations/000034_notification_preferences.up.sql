@@ -0,0 +1,35 @@
+-- =====================================================
+-- MIGRATION 034: Notification Preferences
+-- Per-user settings for the daily or weekly digest the
+-- worker sends at the user's local time
+-- =====================================================
+
+-- Users without a row get the defaults, which send no digest
+CREATE TABLE notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    digest_frequency VARCHAR(20) NOT NULL DEFAULT 'off',
+    digest_time VARCHAR(5) NOT NULL DEFAULT '08:00',      -- HH:MM in the user's timezone
+    digest_weekday SMALLINT NOT NULL DEFAULT 1,           -- weekly digests; 0 = Sunday
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',          -- IANA name
+    digest_approvals BOOLEAN NOT NULL DEFAULT true,
+    digest_status_changes BOOLEAN NOT NULL DEFAULT true,
+    digest_upcoming BOOLEAN NOT NULL DEFAULT true,
+    last_digest_at TIMESTAMPTZ,                           -- scheduled time of the last digest sent
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_digest_frequency CHECK (digest_frequency IN ('off', 'daily', 'weekly')),
+    CONSTRAINT valid_digest_weekday CHECK (digest_weekday BETWEEN 0 AND 6)
+);
+
+CREATE INDEX idx_notification_preferences_digest ON notification_preferences(digest_frequency)
+    WHERE digest_frequency <> 'off';
+
+CREATE TRIGGER update_notification_preferences_timestamp
+    BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+-- Digests look up status changes to a creator's tickets
+CREATE INDEX idx_ticket_audit_status_changes ON ticket_audit_log(created_at)
+    WHERE action = 'status_change';
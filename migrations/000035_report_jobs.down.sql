@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 035 ROLLBACK: Report Jobs
+-- =====================================================
+
+DROP TRIGGER IF EXISTS update_report_jobs_timestamp ON report_jobs;
+DROP TABLE IF EXISTS report_jobs;
@@ -0,0 +1,42 @@
+-- =====================================================
+-- MIGRATION 035: Report Jobs
+-- Long-running reports requested through the API and
+-- rendered by the worker into object storage
+-- =====================================================
+
+CREATE TABLE report_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    requested_by UUID NOT NULL REFERENCES users(id),
+    report_type VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    parameters JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    lease_until TIMESTAMPTZ,                  -- a running job not finished by then is claimed again
+    object_key TEXT,
+    filename VARCHAR(255),
+    content_type VARCHAR(100),
+    size_bytes BIGINT,
+    row_count INTEGER,
+    sha256 VARCHAR(64),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,                   -- when the file is deleted
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_report_job_status CHECK (status IN ('queued', 'running', 'completed', 'failed', 'expired')),
+    CONSTRAINT valid_report_job_format CHECK (format IN ('csv', 'pdf'))
+);
+
+CREATE INDEX idx_report_jobs_org ON report_jobs(organization_id, created_at DESC);
+CREATE INDEX idx_report_jobs_pending ON report_jobs(created_at)
+    WHERE status IN ('queued', 'running');
+CREATE INDEX idx_report_jobs_expiry ON report_jobs(expires_at)
+    WHERE status = 'completed';
+
+CREATE TRIGGER update_report_jobs_timestamp
+    BEFORE UPDATE ON report_jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
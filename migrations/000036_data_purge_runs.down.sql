@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 036 ROLLBACK: Data Purge Runs
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_api_keys_revoked;
+DROP TABLE IF EXISTS data_purge_runs;
@@ -0,0 +1,24 @@
+-- =====================================================
+-- MIGRATION 036: Data Purge Runs
+-- A summary of each nightly retention run, recording what
+-- was scrubbed, purged and deleted across organizations
+-- =====================================================
+
+CREATE TABLE data_purge_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    organizations INTEGER NOT NULL DEFAULT 0,             -- organizations processed
+    audit_logs_scrubbed BIGINT NOT NULL DEFAULT 0,
+    ticket_audits_scrubbed BIGINT NOT NULL DEFAULT 0,
+    comments_scrubbed BIGINT NOT NULL DEFAULT 0,
+    tickets_purged BIGINT NOT NULL DEFAULT 0,
+    api_keys_deleted BIGINT NOT NULL DEFAULT 0,
+    sessions_deleted BIGINT NOT NULL DEFAULT 0,
+    errors TEXT[] NOT NULL DEFAULT '{}',                  -- steps or organizations that failed
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_data_purge_runs_started ON data_purge_runs(started_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_revoked ON api_keys(revoked_at) WHERE revoked_at IS NOT NULL;
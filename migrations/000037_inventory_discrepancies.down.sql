@@ -0,0 +1,5 @@
+-- =====================================================
+-- MIGRATION 037 ROLLBACK: Inventory Discrepancies
+-- =====================================================
+
+DROP TABLE IF EXISTS inventory_discrepancies;
@@ -0,0 +1,28 @@
+-- =====================================================
+-- MIGRATION 037: Inventory Discrepancies
+-- Differences the worker finds between inventory_resources and the
+-- instances cloudtop reports. Lives next to the inventory tables, which
+-- may be in their own database, so hostctl doctor can read it.
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS inventory_discrepancies (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,                 -- ghost: in inventory, not in the cloud; stray: in the cloud, not in inventory
+    provider VARCHAR(50) NOT NULL,             -- inventory provider (oci, gcp)
+    match_key VARCHAR(255) NOT NULL,           -- hostname for ghosts, cloud instance ID for strays
+    resource_id INTEGER,                       -- inventory_resources.id for ghosts
+    hostname VARCHAR(255),
+    external_id VARCHAR(255),
+    cloud_name VARCHAR(255),
+    region VARCHAR(100),
+    cloud_status VARCHAR(50),
+    first_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP                      -- set once a later run no longer finds it
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_discrepancies_open
+    ON inventory_discrepancies(kind, provider, match_key)
+    WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_inventory_discrepancies_resolved
+    ON inventory_discrepancies(resolved_at);
@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 038 ROLLBACK: Ticket Attachments
+-- The uploaded files stay in object storage.
+-- =====================================================
+
+DROP TABLE IF EXISTS ticket_attachments;
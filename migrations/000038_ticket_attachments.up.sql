@@ -0,0 +1,24 @@
+-- =====================================================
+-- MIGRATION 038: Ticket Attachments
+-- Files uploaded to a ticket, such as runbooks and test
+-- evidence. The files live in object storage under
+-- object_key; downloads go through short-lived signed links.
+-- =====================================================
+
+CREATE TABLE ticket_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    ticket_id UUID NOT NULL REFERENCES change_tickets(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    object_key TEXT NOT NULL UNIQUE,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_attachment_size CHECK (size_bytes >= 0)
+);
+
+CREATE INDEX idx_ticket_attachments_ticket ON ticket_attachments(ticket_id, created_at);
+CREATE INDEX idx_ticket_attachments_org ON ticket_attachments(organization_id);
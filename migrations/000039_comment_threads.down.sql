@@ -0,0 +1,10 @@
+-- =====================================================
+-- MIGRATION 039 ROLLBACK: Comment Threads
+-- Replies become top-level comments.
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_comments_ticket_recent;
+DROP INDEX IF EXISTS idx_comments_parent;
+
+ALTER TABLE ticket_comments
+    DROP COLUMN IF EXISTS parent_comment_id;
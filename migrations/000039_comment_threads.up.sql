@@ -0,0 +1,15 @@
+-- =====================================================
+-- MIGRATION 039: Comment Threads
+-- Replies point at the comment they answer. Deleted
+-- comments keep their row so their replies stay threaded.
+-- =====================================================
+
+ALTER TABLE ticket_comments
+    ADD COLUMN parent_comment_id UUID REFERENCES ticket_comments(id);
+
+CREATE INDEX idx_comments_parent ON ticket_comments(parent_comment_id)
+    WHERE parent_comment_id IS NOT NULL;
+
+-- The latest comments of each ticket, for list views
+CREATE INDEX idx_comments_ticket_recent ON ticket_comments(ticket_id, created_at DESC)
+    WHERE deleted_at IS NULL;
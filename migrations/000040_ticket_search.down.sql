@@ -0,0 +1,12 @@
+-- =====================================================
+-- MIGRATION 040 ROLLBACK: Ticket Full-Text Search
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_tickets_search_vector;
+
+ALTER TABLE change_tickets
+    DROP COLUMN IF EXISTS search_vector;
+
+CREATE INDEX idx_tickets_search ON change_tickets USING gin(
+    to_tsvector('english', title || ' ' || description)
+);
@@ -0,0 +1,19 @@
+-- =====================================================
+-- MIGRATION 040: Ticket Full-Text Search
+-- A stored tsvector over the ticket number, title and
+-- description replaces ILIKE searches, which could not
+-- use an index. Numbers and titles weigh more than
+-- descriptions when ranking.
+-- =====================================================
+
+ALTER TABLE change_tickets
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(ticket_number, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_tickets_search_vector ON change_tickets USING GIN(search_vector);
+
+-- Superseded by the column above; nothing queried this expression
+DROP INDEX IF EXISTS idx_tickets_search;
@@ -0,0 +1,23 @@
+-- =====================================================
+-- MIGRATION 041 ROLLBACK: Tenant Isolation
+-- =====================================================
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'change_tickets', 'ticket_comments', 'ticket_revisions', 'ticket_audit_log',
+        'ticket_affected_resources', 'ticket_worklogs', 'ticket_attachments',
+        'approvals', 'audit_log', 'notification_queue', 'users', 'groups',
+        'projects', 'customers', 'contacts', 'repositories', 'saved_filters',
+        'labels', 'epics', 'sprints', 'custom_field_definitions', 'webhook_subscriptions'
+    ] LOOP
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+    END LOOP;
+END
+$$;
+
+DROP FUNCTION IF EXISTS tenant_row_visible(UUID);
@@ -0,0 +1,35 @@
+-- =====================================================
+-- MIGRATION 041: Tenant Isolation
+-- Row-level security on the organization-scoped tables.
+-- The API sets app.org_id to the caller's organization
+-- in each transaction; rows of other organizations are
+-- then neither visible nor writable, whatever the
+-- statement's WHERE clause says. With app.org_id unset
+-- (workers, sign-in, migrations) the policies allow
+-- every row. FORCE applies them to the table owner too.
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION tenant_row_visible(org UUID) RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('app.org_id', true), '') = ''
+        OR org = current_setting('app.org_id', true)::uuid
+$$ LANGUAGE sql STABLE;
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'change_tickets', 'ticket_comments', 'ticket_revisions', 'ticket_audit_log',
+        'ticket_affected_resources', 'ticket_worklogs', 'ticket_attachments',
+        'approvals', 'audit_log', 'notification_queue', 'users', 'groups',
+        'projects', 'customers', 'contacts', 'repositories', 'saved_filters',
+        'labels', 'epics', 'sprints', 'custom_field_definitions', 'webhook_subscriptions'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format(
+            'CREATE POLICY tenant_isolation ON %I USING (tenant_row_visible(organization_id)) WITH CHECK (tenant_row_visible(organization_id))',
+            t);
+    END LOOP;
+END
+$$;
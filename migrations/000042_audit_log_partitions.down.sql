@@ -0,0 +1,79 @@
+-- =====================================================
+-- MIGRATION 042 ROLLBACK: Audit Log Partitions
+-- Entries in months already dropped are not restored.
+-- =====================================================
+
+ALTER TABLE ticket_audit_log RENAME TO ticket_audit_log_partitioned;
+
+CREATE TABLE ticket_audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES change_tickets(id),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    user_id UUID REFERENCES users(id),
+    action VARCHAR(100) NOT NULL,
+    action_category VARCHAR(50) NOT NULL,
+
+    field_name VARCHAR(100),
+    old_value TEXT,
+    new_value TEXT,
+    changes JSONB,
+
+    ip_address INET,
+    user_agent TEXT,
+    session_id VARCHAR(255),
+    request_id VARCHAR(255),
+
+    is_compliance_relevant BOOLEAN DEFAULT false,
+    compliance_frameworks compliance_framework[],
+    requires_review BOOLEAN DEFAULT false,
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    is_emergency BOOLEAN DEFAULT false,
+    anonymized BOOLEAN DEFAULT false,
+    anonymized_at TIMESTAMPTZ
+);
+
+INSERT INTO ticket_audit_log (
+    id, ticket_id, organization_id, user_id, action, action_category,
+    field_name, old_value, new_value, changes, ip_address, user_agent,
+    session_id, request_id, is_compliance_relevant, compliance_frameworks,
+    requires_review, reviewed_by, reviewed_at, created_at,
+    is_emergency, anonymized, anonymized_at
+)
+SELECT
+    id, ticket_id, organization_id, user_id, action, action_category,
+    field_name, old_value, new_value, changes, ip_address, user_agent,
+    session_id, request_id, is_compliance_relevant, compliance_frameworks,
+    requires_review, reviewed_by, reviewed_at, created_at,
+    is_emergency, anonymized, anonymized_at
+FROM ticket_audit_log_partitioned;
+
+DROP TABLE ticket_audit_log_partitioned;
+DROP FUNCTION IF EXISTS ensure_ticket_audit_partitions(DATE);
+DROP FUNCTION IF EXISTS create_ticket_audit_partition(DATE);
+
+CREATE INDEX idx_ticket_audit_ticket ON ticket_audit_log(ticket_id, created_at DESC);
+CREATE INDEX idx_ticket_audit_user ON ticket_audit_log(user_id, created_at DESC);
+CREATE INDEX idx_ticket_audit_action ON ticket_audit_log(action);
+CREATE INDEX idx_ticket_audit_compliance ON ticket_audit_log(is_compliance_relevant)
+    WHERE is_compliance_relevant = true;
+CREATE INDEX idx_ticket_audit_review ON ticket_audit_log(requires_review)
+    WHERE requires_review = true AND reviewed_at IS NULL;
+CREATE INDEX idx_ticket_audit_emergency ON ticket_audit_log(organization_id, created_at DESC)
+    WHERE is_emergency = true;
+CREATE INDEX idx_ticket_audit_status_changes ON ticket_audit_log(created_at)
+    WHERE action = 'status_change';
+
+CREATE TRIGGER no_audit_deletion
+    BEFORE DELETE ON ticket_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_audit_deletion();
+
+ALTER TABLE ticket_audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ticket_audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON ticket_audit_log
+    USING (tenant_row_visible(organization_id))
+    WITH CHECK (tenant_row_visible(organization_id));
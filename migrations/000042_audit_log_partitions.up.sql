@@ -0,0 +1,143 @@
+-- =====================================================
+-- MIGRATION 042: Audit Log Partitions
+-- ticket_audit_log becomes a table partitioned by month
+-- of created_at (UTC), so queries over a period only
+-- read its months and archived months can be dropped
+-- whole. The worker creates partitions three months
+-- ahead; rows outside every partition land in the
+-- default partition rather than failing.
+-- =====================================================
+
+ALTER TABLE ticket_audit_log RENAME TO ticket_audit_log_unpartitioned;
+
+CREATE TABLE ticket_audit_log (
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES change_tickets(id),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    user_id UUID REFERENCES users(id),
+    action VARCHAR(100) NOT NULL,
+    action_category VARCHAR(50) NOT NULL,
+
+    field_name VARCHAR(100),
+    old_value TEXT,
+    new_value TEXT,
+    changes JSONB,
+
+    ip_address INET,
+    user_agent TEXT,
+    session_id VARCHAR(255),
+    request_id VARCHAR(255),
+
+    is_compliance_relevant BOOLEAN DEFAULT false,
+    compliance_frameworks compliance_framework[],
+    requires_review BOOLEAN DEFAULT false,
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    is_emergency BOOLEAN DEFAULT false,
+    anonymized BOOLEAN DEFAULT false,
+    anonymized_at TIMESTAMPTZ,
+
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE ticket_audit_log_default PARTITION OF ticket_audit_log DEFAULT;
+
+-- Creates the partition for the UTC month starting at month_start, named
+-- ticket_audit_log_yYYYYmMM. Returns whether it had to be created.
+CREATE OR REPLACE FUNCTION create_ticket_audit_partition(month_start DATE)
+RETURNS BOOLEAN AS $$
+DECLARE
+    first_day DATE := date_trunc('month', month_start)::date;
+    partition_name TEXT := 'ticket_audit_log_' || to_char(first_day, '"y"YYYY"m"MM');
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN false;
+    END IF;
+    EXECUTE format(
+        'CREATE TABLE %I PARTITION OF ticket_audit_log FOR VALUES FROM (%L) TO (%L)',
+        partition_name,
+        first_day::text || ' 00:00:00+00',
+        (first_day + INTERVAL '1 month')::date::text || ' 00:00:00+00');
+    RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Creates the partitions from the current UTC month through the month of
+-- through_date. Returns how many were created.
+CREATE OR REPLACE FUNCTION ensure_ticket_audit_partitions(through_date DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    month_start DATE := date_trunc('month', NOW() AT TIME ZONE 'UTC')::date;
+    created INTEGER := 0;
+BEGIN
+    WHILE month_start <= through_date LOOP
+        IF create_ticket_audit_partition(month_start) THEN
+            created := created + 1;
+        END IF;
+        month_start := (month_start + INTERVAL '1 month')::date;
+    END LOOP;
+    RETURN created;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions for the existing entries, then three months ahead
+DO $$
+DECLARE
+    month_start DATE;
+BEGIN
+    SELECT date_trunc('month', COALESCE(MIN(created_at), NOW()) AT TIME ZONE 'UTC')::date
+    INTO month_start
+    FROM ticket_audit_log_unpartitioned;
+
+    WHILE month_start < date_trunc('month', NOW() AT TIME ZONE 'UTC')::date LOOP
+        PERFORM create_ticket_audit_partition(month_start);
+        month_start := (month_start + INTERVAL '1 month')::date;
+    END LOOP;
+    PERFORM ensure_ticket_audit_partitions((NOW() AT TIME ZONE 'UTC' + INTERVAL '3 months')::date);
+END
+$$;
+
+INSERT INTO ticket_audit_log (
+    id, ticket_id, organization_id, user_id, action, action_category,
+    field_name, old_value, new_value, changes, ip_address, user_agent,
+    session_id, request_id, is_compliance_relevant, compliance_frameworks,
+    requires_review, reviewed_by, reviewed_at, created_at,
+    is_emergency, anonymized, anonymized_at
+)
+SELECT
+    id, ticket_id, organization_id, user_id, action, action_category,
+    field_name, old_value, new_value, changes, ip_address, user_agent,
+    session_id, request_id, is_compliance_relevant, compliance_frameworks,
+    requires_review, reviewed_by, reviewed_at, created_at,
+    is_emergency, anonymized, anonymized_at
+FROM ticket_audit_log_unpartitioned;
+
+DROP TABLE ticket_audit_log_unpartitioned;
+
+CREATE INDEX idx_ticket_audit_ticket ON ticket_audit_log(ticket_id, created_at DESC);
+CREATE INDEX idx_ticket_audit_user ON ticket_audit_log(user_id, created_at DESC);
+CREATE INDEX idx_ticket_audit_action ON ticket_audit_log(action);
+CREATE INDEX idx_ticket_audit_compliance ON ticket_audit_log(is_compliance_relevant)
+    WHERE is_compliance_relevant = true;
+CREATE INDEX idx_ticket_audit_review ON ticket_audit_log(requires_review)
+    WHERE requires_review = true AND reviewed_at IS NULL;
+CREATE INDEX idx_ticket_audit_emergency ON ticket_audit_log(organization_id, created_at DESC)
+    WHERE is_emergency = true;
+CREATE INDEX idx_ticket_audit_status_changes ON ticket_audit_log(created_at)
+    WHERE action = 'status_change';
+
+-- Entries still can't be deleted; whole archived months are dropped with
+-- their partition
+CREATE TRIGGER no_audit_deletion
+    BEFORE DELETE ON ticket_audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_audit_deletion();
+
+ALTER TABLE ticket_audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ticket_audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON ticket_audit_log
+    USING (tenant_row_visible(organization_id))
+    WITH CHECK (tenant_row_visible(organization_id));
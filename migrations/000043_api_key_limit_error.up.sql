@@ -0,0 +1,28 @@
+-- =====================================================
+-- MIGRATION 043: API Key Limit Error
+-- The active API key limit is raised as a check
+-- violation of the api_key_limit constraint, so the
+-- API can recognise it without matching the message.
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION enforce_api_key_limit()
+RETURNS TRIGGER AS $$
+DECLARE
+    active_count INTEGER;
+BEGIN
+    -- Count active, non-expired keys for this user
+    SELECT COUNT(*) INTO active_count
+    FROM api_keys
+    WHERE user_id = NEW.user_id
+      AND is_active = true
+      AND (expires_at IS NULL OR expires_at > NOW())
+      AND revoked_at IS NULL;
+
+    IF active_count >= 5 THEN
+        RAISE EXCEPTION 'Maximum of 5 active API keys per user. Please revoke an existing key first.'
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'api_key_limit';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,7 @@
+-- =====================================================
+-- MIGRATION 044 ROLLBACK: Organization Settings
+-- =====================================================
+
+ALTER TABLE organizations
+    DROP CONSTRAINT IF EXISTS valid_settings,
+    DROP COLUMN IF EXISTS settings;
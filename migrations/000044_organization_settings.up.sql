@@ -0,0 +1,10 @@
+-- =====================================================
+-- MIGRATION 044: Organization Settings
+-- Org-configurable policy that doesn't warrant its own
+-- columns (SLA windows, change freeze windows, default
+-- approval quorum) is kept as one JSON document.
+-- =====================================================
+
+ALTER TABLE organizations
+    ADD COLUMN settings JSONB NOT NULL DEFAULT '{}',
+    ADD CONSTRAINT valid_settings CHECK (jsonb_typeof(settings) = 'object');
@@ -0,0 +1,8 @@
+-- =====================================================
+-- MIGRATION 045 ROLLBACK: Ticket Templates
+-- =====================================================
+
+ALTER TABLE saved_filters DROP COLUMN IF EXISTS is_shared;
+
+DROP TRIGGER IF EXISTS update_ticket_templates_timestamp ON ticket_templates;
+DROP TABLE IF EXISTS ticket_templates;
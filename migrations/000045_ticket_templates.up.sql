@@ -0,0 +1,39 @@
+-- =====================================================
+-- MIGRATION 045: Ticket Templates
+-- Named ticket defaults kept by the organization (user_id
+-- NULL) or by a user for themselves, and saved filters
+-- shared with the whole organization.
+-- =====================================================
+
+CREATE TABLE ticket_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL for organization templates
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    ticket JSONB NOT NULL DEFAULT '{}',   -- Ticket fields the template fills in
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_template_name CHECK (name ~ '^[a-z0-9][a-z0-9._-]*$')
+);
+
+-- Names are unique per user, and among the organization's templates
+CREATE UNIQUE INDEX idx_ticket_templates_user_name ON ticket_templates(organization_id, user_id, name)
+    WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_ticket_templates_org_name ON ticket_templates(organization_id, name)
+    WHERE user_id IS NULL;
+
+CREATE TRIGGER update_ticket_templates_timestamp
+    BEFORE UPDATE ON ticket_templates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_timestamp();
+
+ALTER TABLE ticket_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ticket_templates FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON ticket_templates
+    USING (tenant_row_visible(organization_id))
+    WITH CHECK (tenant_row_visible(organization_id));
+
+ALTER TABLE saved_filters
+    ADD COLUMN is_shared BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,6 @@
+-- =====================================================
+-- MIGRATION 046 ROLLBACK: Affected Resource Environment
+-- =====================================================
+
+ALTER TABLE ticket_affected_resources
+    DROP COLUMN IF EXISTS environment;
@@ -0,0 +1,11 @@
+-- =====================================================
+-- MIGRATION 046: Affected Resource Environment
+-- Records each affected host's inventory environment when
+-- it is resolved, so risk scores can count production hosts
+-- without reaching into a separate inventory database.
+-- Hosts resolved earlier count once their ticket's affected
+-- systems are next saved.
+-- =====================================================
+
+ALTER TABLE ticket_affected_resources
+    ADD COLUMN environment VARCHAR(50);
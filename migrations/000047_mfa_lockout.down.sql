@@ -0,0 +1,7 @@
+-- =====================================================
+-- MIGRATION 047 ROLLBACK: MFA Lockout
+-- =====================================================
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS mfa_locked_until,
+    DROP COLUMN IF EXISTS mfa_failed_attempts;
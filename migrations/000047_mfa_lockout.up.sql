@@ -0,0 +1,10 @@
+-- =====================================================
+-- MIGRATION 047: MFA Lockout
+-- Counts wrong MFA codes sent by signed-in users, for step-up
+-- and for managing their MFA, and locks those actions for a
+-- while once too many are wrong in a row.
+-- =====================================================
+
+ALTER TABLE users
+    ADD COLUMN mfa_failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN mfa_locked_until TIMESTAMPTZ;
@@ -0,0 +1,12 @@
+-- =====================================================
+-- MIGRATION 048 ROLLBACK: Device Login
+-- =====================================================
+
+DROP INDEX IF EXISTS idx_auth_challenges_device_login;
+
+DELETE FROM auth_challenges WHERE purpose = 'device_login';
+
+ALTER TABLE auth_challenges DROP CONSTRAINT valid_auth_challenge_purpose;
+ALTER TABLE auth_challenges
+    ADD CONSTRAINT valid_auth_challenge_purpose
+    CHECK (purpose IN ('passkey_registration', 'passkey_login', 'mfa', 'oauth2', 'sso_link'));
@@ -0,0 +1,13 @@
+-- =====================================================
+-- MIGRATION 048: Device Login
+-- Pending OAuth device authorizations, which let the CLI sign
+-- in once the user approves its code in the web app
+-- =====================================================
+
+ALTER TABLE auth_challenges DROP CONSTRAINT valid_auth_challenge_purpose;
+ALTER TABLE auth_challenges
+    ADD CONSTRAINT valid_auth_challenge_purpose
+    CHECK (purpose IN ('passkey_registration', 'passkey_login', 'mfa', 'oauth2', 'sso_link', 'device_login'));
+
+-- Approving finds the pending login by the hash of the code the user typed
+CREATE INDEX idx_auth_challenges_device_login ON auth_challenges(challenge) WHERE purpose = 'device_login';
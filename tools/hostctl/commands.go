@@ -262,6 +262,42 @@ func runSearch(query string) error {
 	return nil
 }
 
+// runDoctor executes the doctor command
+func runDoctor(opts *DoctorOptions) error {
+	if opts.Kind != "" && !contains([]string{"ghost", "stray"}, opts.Kind) {
+		return fmt.Errorf("invalid kind: %s (must be ghost or stray)", opts.Kind)
+	}
+
+	discrepancies, err := listDiscrepancies(opts)
+	if err != nil {
+		printError(err.Error())
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(discrepancies)
+	}
+
+	if len(discrepancies) == 0 {
+		printSuccess("Inventory matches the cloud.")
+		return nil
+	}
+
+	printDiscrepancyTable(discrepancies)
+
+	ghosts := 0
+	for _, d := range discrepancies {
+		if d.Kind == "ghost" {
+			ghosts++
+		}
+	}
+	fmt.Printf("\nTotal: %d ghost(s), %d stray(s)\n", ghosts, len(discrepancies)-ghosts)
+	fmt.Println("Ghosts are in inventory but not in the cloud: remove or decommission them.")
+	fmt.Println("Strays are in the cloud but not in inventory: add them with 'hostctl add'.")
+
+	return nil
+}
+
 // contains checks if a slice contains a string
 func contains(slice []string, str string) bool {
 	for _, s := range slice {
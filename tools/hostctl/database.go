@@ -597,6 +597,61 @@ func searchResources(query string) ([]*Resource, error) {
 	return resources, nil
 }
 
+// listDiscrepancies lists the open discrepancies recorded by the worker's
+// cloud reconciliation. Returns none if the worker has never run it.
+func listDiscrepancies(opts *DoctorOptions) ([]*Discrepancy, error) {
+	db, err := getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := db.QueryRow("SELECT to_regclass('inventory_discrepancies') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check for discrepancies: %v", err)
+	}
+	if !exists {
+		return []*Discrepancy{}, nil
+	}
+
+	query := `
+		SELECT id, kind, provider, hostname, external_id, cloud_name, region, cloud_status,
+			first_seen_at, last_seen_at
+		FROM inventory_discrepancies
+		WHERE resolved_at IS NULL
+	`
+	args := []interface{}{}
+	if opts.Kind != "" {
+		args = append(args, opts.Kind)
+		query += fmt.Sprintf(" AND kind = $%d", len(args))
+	}
+	if opts.Provider != "" {
+		args = append(args, opts.Provider)
+		query += fmt.Sprintf(" AND provider = $%d", len(args))
+	}
+	query += " ORDER BY kind, provider, COALESCE(hostname, cloud_name, external_id)"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discrepancies: %v", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []*Discrepancy{}
+	for rows.Next() {
+		d := &Discrepancy{}
+		err := rows.Scan(
+			&d.ID, &d.Kind, &d.Provider, &d.Hostname, &d.ExternalID, &d.CloudName, &d.Region, &d.CloudStatus,
+			&d.FirstSeenAt, &d.LastSeenAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan discrepancy: %v", err)
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	return discrepancies, rows.Err()
+}
+
 // logStatusChange logs a status change to the database
 func logStatusChange(hostname, oldStatus, newStatus string) error {
 	db, err := getDB()
@@ -40,6 +40,7 @@ func main() {
 	rootCmd.AddCommand(newListCommand())
 	rootCmd.AddCommand(newShowCommand())
 	rootCmd.AddCommand(newSearchCommand())
+	rootCmd.AddCommand(newDoctorCommand())
 	rootCmd.AddCommand(newVersionCommand())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -181,6 +182,23 @@ func newSearchCommand() *cobra.Command {
 	return cmd
 }
 
+func newDoctorCommand() *cobra.Command {
+	var opts DoctorOptions
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Show hosts that don't match the cloud",
+		Long:  "Show open discrepancies from the worker's cloud reconciliation: ghosts (in inventory, not in the cloud) and strays (in the cloud, not in inventory)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(&opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Kind, "kind", "", "Filter by kind (ghost, stray)")
+	cmd.Flags().StringVar(&opts.Provider, "provider", "", "Filter by provider")
+
+	return cmd
+}
+
 func newVersionCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "version",
@@ -182,6 +182,33 @@ func printResourceTable(resources []*Resource) {
 	printTableSeparator(colWidths)
 }
 
+// printDiscrepancyTable prints discrepancies in a table format
+func printDiscrepancyTable(discrepancies []*Discrepancy) {
+	format := "%-6s  %-8s  %-30s  %-12s  %-12s  %s\n"
+
+	fmt.Println()
+	fmt.Printf(colorBold+format+colorReset, "KIND", "PROVIDER", "HOST / INSTANCE", "REGION", "CLOUD STATE", "FIRST SEEN")
+	for _, d := range discrepancies {
+		name := d.Hostname.String
+		color := colorRed
+		if d.Kind == "stray" {
+			name = d.CloudName.String
+			if name == "" {
+				name = d.ExternalID.String
+			}
+			color = colorYellow
+		}
+		fmt.Printf("%s%-6s%s  %-8s  %-30s  %-12s  %-12s  %s\n",
+			color, d.Kind, colorReset,
+			truncate(d.Provider, 8),
+			truncate(name, 30),
+			truncate(d.Region.String, 12),
+			truncate(d.CloudStatus.String, 12),
+			d.FirstSeenAt.Format("2006-01-02 15:04"),
+		)
+	}
+}
+
 // printTableSeparator prints a table separator line
 func printTableSeparator(colWidths map[string]int) {
 	totalWidth := colWidths["hostname"] + colWidths["type"] + colWidths["provider"] +
@@ -76,6 +76,27 @@ type ListOptions struct {
 	Limit       int
 }
 
+// DoctorOptions contains options for the doctor command
+type DoctorOptions struct {
+	Kind     string
+	Provider string
+}
+
+// Discrepancy is a host the worker found only in inventory (a ghost) or
+// only in the cloud (a stray)
+type Discrepancy struct {
+	ID          int            `json:"id"`
+	Kind        string         `json:"kind"`
+	Provider    string         `json:"provider"`
+	Hostname    sql.NullString `json:"hostname"`
+	ExternalID  sql.NullString `json:"external_id"`
+	CloudName   sql.NullString `json:"cloud_name"`
+	Region      sql.NullString `json:"region"`
+	CloudStatus sql.NullString `json:"cloud_status"`
+	FirstSeenAt time.Time      `json:"first_seen_at"`
+	LastSeenAt  time.Time      `json:"last_seen_at"`
+}
+
 // StatusChange represents a status change log entry
 type StatusChange struct {
 	Hostname  string    `json:"hostname"`